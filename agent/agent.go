@@ -33,10 +33,14 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/ipc/messagebus"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/aws/amazon-ssm-agent/agent/preflight"
+	"github.com/aws/amazon-ssm-agent/agent/profiling"
 	"github.com/aws/amazon-ssm-agent/agent/rebooter"
 	"github.com/aws/amazon-ssm-agent/agent/session/utility"
 	"github.com/aws/amazon-ssm-agent/agent/ssm"
 	"github.com/aws/amazon-ssm-agent/agent/startup"
+	"github.com/aws/amazon-ssm-agent/agent/statemigration"
+	"github.com/aws/amazon-ssm-agent/common/identity/credentialrotation"
 	"github.com/aws/amazon-ssm-agent/common/identity/identity"
 )
 
@@ -73,6 +77,8 @@ func start(log log.T, shouldCheckHibernation bool) (ssmAgent agent.ISSMAgent, er
 	}
 
 	context := context.Default(log, config, agentIdentity, "[ssm-agent-worker]")
+	profiling.Start(log, config.Agent.Profiling, profiling.WorkerProcess)
+	credentialrotation.NewWatcher(log, agentIdentity.Credentials()).Start()
 
 	//Reset password for default RunAs user if already exists
 	sessionUtil := &utility.SessionUtil{}
@@ -128,6 +134,12 @@ func start(log log.T, shouldCheckHibernation bool) (ssmAgent agent.ISSMAgent, er
 }
 
 func startAgent(ssmAgent agent.ISSMAgent, context context.T) (err error) {
+	// upgrade the state files written by a previous agent before the core modules parse them
+	statemigration.Run(context)
+
+	// report the host issues in a single report before the core modules fail on them
+	preflight.Run(context)
+
 	cloudwatchPublisher := cloudwatchlogspublisher.NewCloudWatchPublisher(context)
 	coreModules := coremodules.RegisteredCoreModules(context)
 	reboot := &rebooter.SSMRebooter{}
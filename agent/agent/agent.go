@@ -18,9 +18,11 @@ import (
 	"runtime"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/featureflag"
 	"github.com/aws/amazon-ssm-agent/agent/framework/coremanager"
 	"github.com/aws/amazon-ssm-agent/agent/health"
 	"github.com/aws/amazon-ssm-agent/agent/hibernation"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/aws/amazon-ssm-agent/agent/version"
 	_ "go.nanomsg.org/mangos/v3/transport/ipc"
 )
@@ -71,6 +73,9 @@ func (agent *SSMAgent) Start() {
 
 	log.Infof("ssm-agent-worker - %v", version.String())
 	log.Infof("OS: %s, Arch: %s", runtime.GOOS, runtime.GOARCH)
+	featureflag.LogFlags(log, agent.context.AppConfig())
+	network.LogTLSPolicy(log, agent.context.AppConfig())
+	network.LogNetworkConfig(log, agent.context.AppConfig())
 	log.Flush()
 
 	if agent.coreManager == nil {
@@ -130,8 +130,13 @@ func processFingerprint(log logger.T) (exitCode int) {
 
 // registerManagedInstance checks for activation credentials and performs managed instance registration when present
 func registerManagedInstance(log logger.T) (managedInstanceID string, err error) {
+	if err = appconfig.InstanceNameError(); err != nil {
+		return managedInstanceID, err
+	}
+
 	// try to activate the instance with the activation credentials
-	publicKey, privateKey, keyType, err := registration.GenerateKeyPair()
+	appConfig, _ := appconfig.Config(false)
+	publicKey, privateKey, keyType, err := registration.GenerateKeyPairOfType(appconfig.ProfileKeyTypeRsa, appConfig.Profile.KeyStorage)
 	if err != nil {
 		return managedInstanceID, fmt.Errorf("error generating signing keys. %v", err)
 	}
@@ -159,6 +164,7 @@ func registerManagedInstance(log logger.T) (managedInstanceID string, err error)
 	)
 
 	if err != nil {
+		registration.ReleasePrivateKey(log, privateKey)
 		return managedInstanceID, fmt.Errorf("error registering the instance with AWS SSM. %v", err)
 	}
 
@@ -11,7 +11,8 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin/cloudwatch"
 )
 
-const serviceName = "AmazonSSMAgent"
+var serviceName = appconfig.InstanceServiceName(appconfig.ServiceName)
+
 const imageStateComplete = "IMAGE_STATE_COMPLETE"
 
 func main() {
@@ -23,12 +23,14 @@ import (
 	"os"
 	"runtime/debug"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher/cloudwatchlogsinterface"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/amazon-ssm-agent/common/identity/credentialrotation"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -58,6 +60,8 @@ const (
 type CloudWatchLogsService struct {
 	context              context.T
 	cloudWatchLogsClient cloudwatchlogsinterface.CloudWatchLogsClient
+	// usesAgentCredentials is true when the client is created with the agent credentials
+	usesAgentCredentials bool
 	stopPolicy           *sdkutil.StopPolicy
 	isFileComplete       bool
 	isUploadComplete     bool
@@ -128,6 +132,7 @@ func NewCloudWatchLogsService(context context.T) *CloudWatchLogsService {
 	cloudWatchLogsService := CloudWatchLogsService{
 		context:              context,
 		cloudWatchLogsClient: createCloudWatchClient(context),
+		usesAgentCredentials: true,
 		stopPolicy:           createCloudWatchStopPolicy(),
 		isFileComplete:       false,
 		isUploadComplete:     false,
@@ -524,6 +529,15 @@ func (service *CloudWatchLogsService) StreamData(
 		log.Debugf("Received file complete signal %v", service.isFileComplete)
 	}()
 
+	// a new client is created when the agent credentials rotate instead of failing until the old ones expire
+	var credentialsRotated int32
+	if service.usesAgentCredentials {
+		unsubscribe := credentialrotation.Subscribe(func(credentialrotation.Rotation) {
+			atomic.StoreInt32(&credentialsRotated, 1)
+		})
+		defer unsubscribe()
+	}
+
 	// Keeps track of the last known line number that was successfully uploaded to CloudWatch.
 	var lastKnownLineUploadedToCWL int64 = 0
 	// Keeps track of the next line number upto which the logs will be uploaded to CloudWatch.
@@ -565,6 +579,10 @@ func (service *CloudWatchLogsService) StreamData(
 			log.Infof("Started CloudWatch upload")
 			IsFirstTimeLogging = false
 		}
+		if atomic.CompareAndSwapInt32(&credentialsRotated, 1, 0) {
+			log.Info("Agent credentials rotated, creating a new CloudWatch Logs client")
+			service.cloudWatchLogsClient = createCloudWatchClient(service.context)
+		}
 		log.Tracef("Uploading message line %d to CloudWatch", currentLineNumber)
 
 		if !IsLogStreamCreated {
@@ -19,6 +19,7 @@ import (
 	"log"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
@@ -92,8 +93,11 @@ func getAppConfigPath() (path string, err error) {
 func DefaultConfig() SsmagentConfig {
 
 	var credsProfile = CredentialProfile{
-		ShareCreds:        true,
-		KeyAutoRotateDays: defaultProfileKeyAutoRotateDays,
+		ShareCreds:              true,
+		KeyAutoRotateDays:       defaultProfileKeyAutoRotateDays,
+		KeyType:                 ProfileKeyTypeRsa,
+		KeyRotationRetryMinutes: defaultProfileKeyRotationRetryMinutes,
+		KeyStorage:              ProfileKeyStorageFile,
 	}
 	var s3 S3Cfg
 	var mds = MdsCfg{
@@ -107,6 +111,17 @@ func DefaultConfig() SsmagentConfig {
 		StopTimeoutMillis:             DefaultStopTimeoutMillis,
 		SessionWorkerBufferLimit:      DefaultSessionWorkerBufferLimit,
 		DeniedPortForwardingRemoteIPs: DefaultDeniedPortForwardingRemoteIPs,
+		SessionUser: SessionUserCfg{
+			Name:       DefaultRunAsUserName,
+			SudoPolicy: SudoPolicyNoPassword,
+		},
+		SessionCredentials: SessionCredentialsCfg{
+			DurationSeconds: DefaultSessionCredentialsDurationSeconds,
+		},
+		SessionLogs: SessionLogsCfg{
+			OutputBeyondLimit: SessionLogsOutputBeyondLimitRotate,
+			MaxOverflowFiles:  DefaultSessionLogsMaxOverflowFiles,
+		},
 	}
 	var ssm = SsmCfg{
 		HealthFrequencyMinutes:                DefaultSsmHealthFrequencyMinutes,
@@ -118,6 +133,24 @@ func DefaultConfig() SsmagentConfig {
 		SessionLogsRetentionDurationHours:     DefaultSessionLogsRetentionDurationHours,
 		PluginLocalOutputCleanup:              DefaultPluginOutputRetention,
 		OrchestrationDirectoryCleanup:         DefaultOrchestrationDirCleanup,
+		BootAssociationWorkersLimit:           DefaultBootAssociationWorkersLimit,
+		ExecutionHistoryMaxEntries:            DefaultExecutionHistoryMaxEntries,
+		DocumentStateQuarantine: DocumentStateQuarantineCfg{
+			Enabled:          true,
+			SendFailedResult: true,
+			RetentionDays:    DefaultDocumentStateQuarantineRetentionDays,
+		},
+		FileIntegrityInventory: FileIntegrityInventoryCfg{
+			HashAlgorithm: FileIntegrityHashAlgorithmSHA256,
+			MaxFileSizeMB: DefaultFileIntegrityMaxFileSizeMB,
+		},
+		PatchScan: PatchScanCfg{
+			DocumentName: DefaultPatchScanDocumentName,
+		},
+		ScriptCancellation: ScriptCancellation{
+			Signal:             CancellationSignalKill,
+			GracePeriodSeconds: DefaultCancellationGracePeriodSeconds,
+		},
 	}
 	var agent = AgentInfo{
 		Name:                                    "amazon-ssm-agent",
@@ -129,8 +162,17 @@ func DefaultConfig() SsmagentConfig {
 		TelemetryMetricsNamespace:               DefaultTelemetryNamespace,
 		AuditExpirationDay:                      DefaultAuditExpirationDay,
 		LongRunningWorkerMonitorIntervalSeconds: defaultLongRunningWorkerMonitorIntervalSeconds,
+		LongRunningReadinessTimeoutSeconds:      defaultLongRunningReadinessTimeoutSeconds,
+		CloudWatchStopTimeoutSeconds:            defaultCloudWatchStopTimeoutSeconds,
+		CloudWatchOutputMaxSizeMB:               defaultCloudWatchOutputMaxSizeMB,
+		CloudWatchOutputMaxRotatedFiles:         defaultCloudWatchOutputMaxRotatedFiles,
+		LongRunningCrashDumpMaxSizeMB:           defaultLongRunningCrashDumpMaxSizeMB,
 		ForceFileIPC:                            false,
 		GoMaxProcForAgentWorker:                 0,
+		FailedReplyMaxAgeHours:                  DefaultFailedReplyMaxAgeHours,
+		FailedReplyQueueLimit:                   DefaultFailedReplyQueueLimit,
+		CommandTransportOrder:                   DefaultCommandTransportOrder,
+		ManageFirewallRules:                     false,
 	}
 
 	var os = OsInfo{
@@ -138,24 +180,72 @@ func DefaultConfig() SsmagentConfig {
 		Version: "1",
 	}
 	var identity = IdentityCfg{
-		ConsumptionOrder: DefaultIdentityConsumptionOrder,
-		CustomIdentities: []*CustomIdentity{},
+		ConsumptionOrder:      DefaultIdentityConsumptionOrder,
+		RegionResolutionOrder: DefaultRegionResolutionOrder,
+		CustomIdentities:      []*CustomIdentity{},
 	}
 	var birdwatcher BirdwatcherCfg
 	var kms KmsConfig
+	var featureFlags = FeatureFlagCfg{
+		Flags: map[string]bool{},
+	}
+	var tls = TLSCfg{
+		MinVersion:   DefaultTLSMinVersion,
+		CipherSuites: []string{},
+	}
+	var network = NetworkCfg{
+		IPMode:          DefaultNetworkIPMode,
+		DNSServers:      []string{},
+		DNSCacheSeconds: DefaultDNSCacheSeconds,
+		CircuitBreaker: CircuitBreakerCfg{
+			Enabled:          true,
+			FailureThreshold: DefaultCircuitBreakerFailureThreshold,
+			OpenSeconds:      DefaultCircuitBreakerOpenSeconds,
+			MaxOpenSeconds:   DefaultCircuitBreakerMaxOpenSeconds,
+			RetryBudget:      DefaultCircuitBreakerRetryBudget,
+		},
+		RateLimits: []ServiceRateLimit{},
+	}
+	var storage = StorageCfg{
+		StateBackend: StateBackendFile,
+	}
 
 	var ssmagentCfg = SsmagentConfig{
-		Profile:     credsProfile,
-		Mds:         mds,
-		Ssm:         ssm,
-		Mgs:         mgs,
-		Agent:       agent,
-		Os:          os,
-		S3:          s3,
-		Birdwatcher: birdwatcher,
-		Kms:         kms,
-		Identity:    identity,
+		Profile:      credsProfile,
+		Mds:          mds,
+		Ssm:          ssm,
+		Mgs:          mgs,
+		Agent:        agent,
+		Os:           os,
+		S3:           s3,
+		Birdwatcher:  birdwatcher,
+		Kms:          kms,
+		Identity:     identity,
+		FeatureFlags: featureFlags,
+		TLS:          tls,
+		Network:      network,
+		Storage:      storage,
 	}
 
 	return ssmagentCfg
 }
+
+// PluginHealthCheck returns the health check configured for the long running plugin, found is false when there is none
+func (mds MdsCfg) PluginHealthCheck(pluginName string) (healthCheck LongRunningPluginHealthCheck, found bool) {
+	for _, healthCheck := range mds.LongRunningPluginHealthChecks {
+		if strings.EqualFold(healthCheck.Plugin, pluginName) {
+			return healthCheck, true
+		}
+	}
+	return LongRunningPluginHealthCheck{}, false
+}
+
+// PluginTimeoutSeconds returns the execution timeout configured for the plugin, found is false when there is none
+func (ssm SsmCfg) PluginTimeoutSeconds(pluginName string) (timeoutSeconds int, found bool) {
+	for _, timeout := range ssm.PluginTimeouts {
+		if strings.EqualFold(timeout.Plugin, pluginName) {
+			return timeout.TimeoutSeconds, true
+		}
+	}
+	return 0, false
+}
@@ -17,9 +17,25 @@ package appconfig
 
 import (
 	"log"
+	"math"
+	"net"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
+	"time"
+
+	// the association time zones are loaded from the time zone database embedded in the agent on the hosts that
+	// have none, such as Windows
+	_ "time/tzdata"
 )
 
+// sessionUserNameRegex matches user names that are portable across Linux and macOS
+var sessionUserNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// commandLocaleNameRegex matches the characters allowed in locale and culture names
+var commandLocaleNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.@-]*$`)
+
 // func parser(config *T) {
 func parser(config *SsmagentConfig) {
 	log.Printf("processing appconfig overrides")
@@ -35,6 +51,19 @@ func parser(config *SsmagentConfig) {
 		defaultProfileKeyAutoRotateDaysMin,
 		defaultProfileKeyAutoRotateDaysMax,
 		defaultProfileKeyAutoRotateDays)
+	config.Profile.KeyType = getStringEnum(
+		config.Profile.KeyType,
+		[]string{ProfileKeyTypeRsa, ProfileKeyTypeEcdsa},
+		ProfileKeyTypeRsa)
+	config.Profile.KeyRotationRetryMinutes = getNumericValue(
+		config.Profile.KeyRotationRetryMinutes,
+		defaultProfileKeyRotationRetryMinutesMin,
+		defaultProfileKeyRotationRetryMinutesMax,
+		defaultProfileKeyRotationRetryMinutes)
+	config.Profile.KeyStorage = getStringEnum(
+		config.Profile.KeyStorage,
+		[]string{ProfileKeyStorageFile, ProfileKeyStorageHardware},
+		ProfileKeyStorageFile)
 
 	// Agent config
 	config.Agent.Name = getStringValue(config.Agent.Name, DefaultAgentName)
@@ -47,6 +76,31 @@ func parser(config *SsmagentConfig) {
 		defaultLongRunningWorkerMonitorIntervalSecondsMin,
 		defaultLongRunningWorkerMonitorIntervalSecondsMax,
 		defaultLongRunningWorkerMonitorIntervalSeconds)
+	config.Agent.LongRunningReadinessTimeoutSeconds = getNumericValue(
+		config.Agent.LongRunningReadinessTimeoutSeconds,
+		defaultLongRunningReadinessTimeoutSecondsMin,
+		defaultLongRunningReadinessTimeoutSecondsMax,
+		defaultLongRunningReadinessTimeoutSeconds)
+	config.Agent.CloudWatchStopTimeoutSeconds = getNumericValue(
+		config.Agent.CloudWatchStopTimeoutSeconds,
+		defaultCloudWatchStopTimeoutSecondsMin,
+		defaultCloudWatchStopTimeoutSecondsMax,
+		defaultCloudWatchStopTimeoutSeconds)
+	config.Agent.CloudWatchOutputMaxSizeMB = getNumericValue(
+		config.Agent.CloudWatchOutputMaxSizeMB,
+		defaultCloudWatchOutputMaxSizeMBMin,
+		defaultCloudWatchOutputMaxSizeMBMax,
+		defaultCloudWatchOutputMaxSizeMB)
+	config.Agent.CloudWatchOutputMaxRotatedFiles = getNumericValue(
+		config.Agent.CloudWatchOutputMaxRotatedFiles,
+		defaultCloudWatchOutputMaxRotatedFilesMin,
+		defaultCloudWatchOutputMaxRotatedFilesMax,
+		defaultCloudWatchOutputMaxRotatedFiles)
+	config.Agent.LongRunningCrashDumpMaxSizeMB = getNumericValue(
+		config.Agent.LongRunningCrashDumpMaxSizeMB,
+		defaultLongRunningCrashDumpMaxSizeMBMin,
+		defaultLongRunningCrashDumpMaxSizeMBMax,
+		defaultLongRunningCrashDumpMaxSizeMB)
 	config.Agent.SelfUpdateScheduleDay = getNumericValue(
 		config.Agent.SelfUpdateScheduleDay,
 		DefaultSsmSelfUpdateFrequencyDaysMin,
@@ -57,6 +111,33 @@ func parser(config *SsmagentConfig) {
 		runtime.NumCPU(),
 		0)
 
+	config.Agent.FailedReplyMaxAgeHours = getNumericValue(
+		config.Agent.FailedReplyMaxAgeHours,
+		DefaultFailedReplyMaxAgeHoursMin,
+		DefaultFailedReplyMaxAgeHoursMax,
+		DefaultFailedReplyMaxAgeHours)
+	config.Agent.FailedReplyQueueLimit = getNumericValue(
+		config.Agent.FailedReplyQueueLimit,
+		DefaultFailedReplyQueueLimitMin,
+		DefaultFailedReplyQueueLimitMax,
+		DefaultFailedReplyQueueLimit)
+	mirrors := config.Agent.ArtifactMirrors[:0]
+	for _, mirror := range config.Agent.ArtifactMirrors {
+		// a mirror needs both the urls it serves and the url serving them
+		if strings.TrimSpace(mirror.SourcePrefix) != "" && strings.TrimSpace(mirror.URL) != "" {
+			mirrors = append(mirrors, mirror)
+		}
+	}
+	config.Agent.ArtifactMirrors = mirrors
+	commandTransportOptions := map[string]bool{
+		CommandTransportMGS: true,
+		CommandTransportMDS: true,
+	}
+	config.Agent.CommandTransportOrder = getStringListEnum(
+		config.Agent.CommandTransportOrder,
+		commandTransportOptions,
+		DefaultCommandTransportOrder)
+
 	config.Agent.AuditExpirationDay = getNumericValue(
 		config.Agent.AuditExpirationDay,
 		DefaultAuditExpirationDayMin,
@@ -81,7 +162,15 @@ func parser(config *SsmagentConfig) {
 		DefaultSessionWorkersBufferLimitMin,
 		config.Mgs.SessionWorkerBufferLimit, // we do not restrict max number of worker buffer limit here
 		DefaultSessionWorkerBufferLimit)
+	parseSessionUserConfig(&config.Mgs.SessionUser)
+	config.Mgs.SessionCredentials.DurationSeconds = getNumericValue(
+		config.Mgs.SessionCredentials.DurationSeconds,
+		SessionCredentialsDurationSecondsMin,
+		SessionCredentialsDurationSecondsMax,
+		DefaultSessionCredentialsDurationSeconds)
+	parseSessionLogsConfig(&config.Mgs.SessionLogs)
 
+	config.Mds.LongRunningPluginHealthChecks = getLongRunningPluginHealthChecks(config.Mds.LongRunningPluginHealthChecks)
 	config.Mds.CommandRetryLimit = getNumericValue(
 		config.Mds.CommandRetryLimit,
 		DefaultCommandRetryLimitMin,
@@ -92,6 +181,22 @@ func parser(config *SsmagentConfig) {
 		DefaultStopTimeoutMillisMin,
 		DefaultStopTimeoutMillisMax,
 		DefaultStopTimeoutMillis)
+	// 0 is out of range and keeps using StopTimeoutMillis for the long poll
+	config.Mds.LongPollTimeoutMillis = getNumeric64Value(
+		config.Mds.LongPollTimeoutMillis,
+		DefaultStopTimeoutMillisMin,
+		DefaultStopTimeoutMillisMax,
+		0)
+	config.Mds.PollIntervalMillis = getNumeric64Value(
+		config.Mds.PollIntervalMillis,
+		0,
+		MdsPollIntervalMillisMax,
+		0)
+	config.Mds.IdlePollIntervalMaxMillis = getNumeric64Value(
+		config.Mds.IdlePollIntervalMaxMillis,
+		0,
+		MdsPollIntervalMillisMax,
+		0)
 	config.Mds.Endpoint = getStringValue(config.Mds.Endpoint, "")
 
 	// SSM config
@@ -106,6 +211,76 @@ func parser(config *SsmagentConfig) {
 		DefaultSsmAssociationFrequencyMinutesMin,
 		DefaultSsmAssociationFrequencyMinutesMax,
 		DefaultSsmAssociationFrequencyMinutes)
+	config.Ssm.BootAssociationWorkersLimit = getNumericValue(
+		config.Ssm.BootAssociationWorkersLimit,
+		DefaultBootAssociationWorkersLimitMin,
+		DefaultBootAssociationWorkersLimitMax,
+		DefaultBootAssociationWorkersLimit)
+	for i := range config.Ssm.SingletonAssociations {
+		singleton := &config.Ssm.SingletonAssociations[i]
+		singleton.OverlapPolicy = getStringEnum(singleton.OverlapPolicy,
+			[]string{SingletonOverlapPolicySkip, SingletonOverlapPolicyQueue},
+			SingletonOverlapPolicySkip)
+	}
+	config.Ssm.AssociationTimeZones = getAssociationTimeZones(config.Ssm.AssociationTimeZones)
+	config.Ssm.FileIntegrityInventory.HashAlgorithm = getStringEnum(config.Ssm.FileIntegrityInventory.HashAlgorithm,
+		[]string{FileIntegrityHashAlgorithmSHA256, FileIntegrityHashAlgorithmSHA512},
+		FileIntegrityHashAlgorithmSHA256)
+	config.Ssm.FileIntegrityInventory.MaxFileSizeMB = getNumericValue(
+		config.Ssm.FileIntegrityInventory.MaxFileSizeMB,
+		DefaultFileIntegrityMaxFileSizeMBMin,
+		DefaultFileIntegrityMaxFileSizeMBMax,
+		DefaultFileIntegrityMaxFileSizeMB)
+	for i := range config.Ssm.InventoryCollectionWindows {
+		window := &config.Ssm.InventoryCollectionWindows[i]
+		window.Type = getStringEnum(window.Type,
+			[]string{InventoryCollectionWindowAllow, InventoryCollectionWindowDeny},
+			InventoryCollectionWindowDeny)
+		window.DurationMinutes = getNumericValue(
+			window.DurationMinutes,
+			DefaultInventoryCollectionWindowDurationMinutesMin,
+			DefaultInventoryCollectionWindowDurationMinutesMax,
+			DefaultInventoryCollectionWindowDurationMinutes)
+	}
+	if config.Ssm.PatchScan.FrequencyMinutes != 0 {
+		config.Ssm.PatchScan.FrequencyMinutes = getNumericValue(
+			config.Ssm.PatchScan.FrequencyMinutes,
+			DefaultPatchScanFrequencyMinutesMin,
+			DefaultPatchScanFrequencyMinutesMax,
+			DefaultPatchScanFrequencyMinutes)
+	}
+	if strings.TrimSpace(config.Ssm.PatchScan.DocumentName) == "" {
+		config.Ssm.PatchScan.DocumentName = DefaultPatchScanDocumentName
+	}
+	config.Ssm.ProcessPriority = getProcessPriority(config.Ssm.ProcessPriority)
+	config.Ssm.CommandLocale = getCommandLocale(config.Ssm.CommandLocale)
+	config.Ssm.CommandSandbox = getCommandSandbox(config.Ssm.CommandSandbox)
+	config.Ssm.ScriptCancellation.Signal = getStringEnum(config.Ssm.ScriptCancellation.Signal,
+		[]string{CancellationSignalKill, CancellationSignalTerminate, CancellationSignalInterrupt},
+		CancellationSignalKill)
+	config.Ssm.ScriptCancellation.GracePeriodSeconds = getNumericValue(
+		config.Ssm.ScriptCancellation.GracePeriodSeconds,
+		DefaultCancellationGracePeriodSecondsMin,
+		DefaultCancellationGracePeriodSecondsMax,
+		DefaultCancellationGracePeriodSeconds)
+	config.Ssm.OutputSourceCodePage = getNumericValue(
+		config.Ssm.OutputSourceCodePage,
+		OutputSourceCodePageDetect,
+		OutputSourceCodePageMax,
+		OutputSourceCodePageDetect)
+
+	config.Ssm.ExecutionHistoryMaxEntries = getNumericValue(
+		config.Ssm.ExecutionHistoryMaxEntries,
+		0,
+		ExecutionHistoryMaxEntriesMax,
+		DefaultExecutionHistoryMaxEntries)
+
+	config.Ssm.DocumentStateQuarantine.RetentionDays = getNumericValue(
+		config.Ssm.DocumentStateQuarantine.RetentionDays,
+		DocumentStateQuarantineRetentionDaysMin,
+		DocumentStateQuarantineRetentionDaysMax,
+		DefaultDocumentStateQuarantineRetentionDays)
+	config.Ssm.PluginTimeouts = getPluginTimeouts(config.Ssm.PluginTimeouts)
 	config.Ssm.AssociationLogsRetentionDurationHours = getNumericValueAboveMin(
 		config.Ssm.AssociationLogsRetentionDurationHours,
 		DefaultStateOrchestrationLogsRetentionDurationHoursMin,
@@ -130,6 +305,62 @@ func parser(config *SsmagentConfig) {
 		OrchestartionDirCleanupOtions,
 		DefaultOrchestrationDirCleanup)
 
+	// TLS config
+	tlsVersionOptions := []string{
+		TLSVersion12,
+		TLSVersion13,
+	}
+	config.TLS.MinVersion = getStringEnum(config.TLS.MinVersion, tlsVersionOptions, DefaultTLSMinVersion)
+
+	// Network config
+	config.Network.SourceAddress = strings.TrimSpace(config.Network.SourceAddress)
+	if config.Network.SourceAddress != "" && net.ParseIP(config.Network.SourceAddress) == nil {
+		log.Printf("ignoring invalid Network.SourceAddress %v", config.Network.SourceAddress)
+		config.Network.SourceAddress = ""
+	}
+	config.Network.SourceInterface = strings.TrimSpace(config.Network.SourceInterface)
+	ipModeOptions := []string{
+		NetworkIPModeAuto,
+		NetworkIPModePreferIPv6,
+		NetworkIPModeIPv6Only,
+	}
+	config.Network.IPMode = getStringEnum(config.Network.IPMode, ipModeOptions, DefaultNetworkIPMode)
+	config.Network.DNSServers = getDNSServers(config.Network.DNSServers)
+	config.Network.DNSCacheSeconds = getNumericValue(
+		config.Network.DNSCacheSeconds,
+		DefaultDNSCacheSecondsMin,
+		DefaultDNSCacheSecondsMax,
+		DefaultDNSCacheSeconds)
+	config.Network.CircuitBreaker.FailureThreshold = getNumericValue(
+		config.Network.CircuitBreaker.FailureThreshold,
+		DefaultCircuitBreakerFailureThresholdMin,
+		DefaultCircuitBreakerFailureThresholdMax,
+		DefaultCircuitBreakerFailureThreshold)
+	config.Network.CircuitBreaker.OpenSeconds = getNumericValue(
+		config.Network.CircuitBreaker.OpenSeconds,
+		DefaultCircuitBreakerOpenSecondsMin,
+		DefaultCircuitBreakerOpenSecondsMax,
+		DefaultCircuitBreakerOpenSeconds)
+	// the open time of a breaker grows from OpenSeconds
+	defaultMaxOpenSeconds := DefaultCircuitBreakerMaxOpenSeconds
+	if defaultMaxOpenSeconds < config.Network.CircuitBreaker.OpenSeconds {
+		defaultMaxOpenSeconds = config.Network.CircuitBreaker.OpenSeconds
+	}
+	config.Network.CircuitBreaker.MaxOpenSeconds = getNumericValue(
+		config.Network.CircuitBreaker.MaxOpenSeconds,
+		config.Network.CircuitBreaker.OpenSeconds,
+		DefaultCircuitBreakerOpenSecondsMax,
+		defaultMaxOpenSeconds)
+	config.Network.CircuitBreaker.RetryBudget = getNumericValue(
+		config.Network.CircuitBreaker.RetryBudget,
+		DefaultCircuitBreakerRetryBudgetMin,
+		DefaultCircuitBreakerRetryBudgetMax,
+		DefaultCircuitBreakerRetryBudget)
+	config.Network.RateLimits = getServiceRateLimits(config.Network.RateLimits)
+
+	// Storage config
+	parseStorage(&config.Storage)
+
 	config.Identity.Ec2SystemInfoDetectionResponse = getStringEnum(config.Identity.Ec2SystemInfoDetectionResponse, booleanStringOptions, "")
 	IdentityConsumptionOrderOptions := map[string]bool{
 		"OnPrem":         true,
@@ -141,6 +372,16 @@ func parser(config *SsmagentConfig) {
 		config.Identity.ConsumptionOrder,
 		IdentityConsumptionOrderOptions,
 		DefaultIdentityConsumptionOrder)
+	regionResolutionOrderOptions := map[string]bool{
+		RegionSourceAppConfig:   true,
+		RegionSourceEnvironment: true,
+		RegionSourceIdentity:    true,
+		RegionSourceConfigFile:  true,
+	}
+	config.Identity.RegionResolutionOrder = getStringListEnum(
+		config.Identity.RegionResolutionOrder,
+		regionResolutionOrderOptions,
+		DefaultRegionResolutionOrder)
 	CredentialsProviderOptions := map[string]bool{
 		DefaultCustomIdentityCredentialsProvider: true,
 	}
@@ -157,6 +398,68 @@ func getStringValue(configValue string, defaultValue string) string {
 	return configValue
 }
 
+// getProcessPriority returns the process priority with its invalid values replaced by the inherited priority
+func getProcessPriority(priority ProcessPriority) ProcessPriority {
+	priority.Nice = getNumericValue(priority.Nice, ProcessNiceMin, ProcessNiceMax, 0)
+	priority.IOPriorityClass = getStringEnum(priority.IOPriorityClass,
+		[]string{IOPriorityClassBestEffort, IOPriorityClassIdle},
+		"")
+	priority.IOPriorityLevel = getNumericValue(priority.IOPriorityLevel, IOPriorityLevelMin, IOPriorityLevelMax, IOPriorityLevelMin)
+	priority.PriorityClass = getStringEnum(priority.PriorityClass,
+		[]string{ProcessPriorityClassIdle, ProcessPriorityClassBelowNormal, ProcessPriorityClassNormal, ProcessPriorityClassAboveNormal},
+		"")
+	return priority
+}
+
+// getAssociationTimeZones drops the association time zones that do not name an association or a known time zone
+func getAssociationTimeZones(timeZones []AssociationTimeZone) []AssociationTimeZone {
+	var valid []AssociationTimeZone
+	for _, timeZone := range timeZones {
+		timeZone.Association = strings.TrimSpace(timeZone.Association)
+		timeZone.TimeZone = strings.TrimSpace(timeZone.TimeZone)
+		if timeZone.Association == "" || timeZone.TimeZone == "" {
+			continue
+		}
+		if _, err := time.LoadLocation(timeZone.TimeZone); err != nil {
+			log.Printf("ignoring invalid time zone %v of association %v", timeZone.TimeZone, timeZone.Association)
+			continue
+		}
+		valid = append(valid, timeZone)
+	}
+	return valid
+}
+
+// getCommandLocale trims the locale and the culture of the commands, the names containing characters that cannot
+// appear in a locale or culture name are dropped
+func getCommandLocale(locale CommandLocale) CommandLocale {
+	locale.Locale = strings.TrimSpace(locale.Locale)
+	if !commandLocaleNameRegex.MatchString(locale.Locale) {
+		locale.Locale = ""
+	}
+	locale.Culture = strings.TrimSpace(locale.Culture)
+	if !commandLocaleNameRegex.MatchString(locale.Culture) {
+		locale.Culture = ""
+	}
+	return locale
+}
+
+// getCommandSandbox trims the names and the Windows settings of the sandbox profiles, the profiles are validated when a command runs in them
+// so that an invalid profile fails the command instead of running it without isolation
+func getCommandSandbox(sandbox CommandSandbox) CommandSandbox {
+	sandbox.DefaultProfile = strings.TrimSpace(sandbox.DefaultProfile)
+	for i := range sandbox.Profiles {
+		sandbox.Profiles[i].Name = strings.TrimSpace(sandbox.Profiles[i].Name)
+		for j := range sandbox.Profiles[i].Namespaces {
+			sandbox.Profiles[i].Namespaces[j] = strings.TrimSpace(sandbox.Profiles[i].Namespaces[j])
+		}
+		sandbox.Profiles[i].IntegrityLevel = strings.TrimSpace(sandbox.Profiles[i].IntegrityLevel)
+		for j := range sandbox.Profiles[i].Capabilities {
+			sandbox.Profiles[i].Capabilities[j] = strings.TrimSpace(sandbox.Profiles[i].Capabilities[j])
+		}
+	}
+	return sandbox
+}
+
 // getNumericValueAboveMin returns the default if config is below minimum
 func getNumericValueAboveMin(configValue int, minValue int, defaultValue int) int {
 	if configValue < minValue {
@@ -165,6 +468,64 @@ func getNumericValueAboveMin(configValue int, minValue int, defaultValue int) in
 	return configValue
 }
 
+// getServiceRateLimits returns the rate limits with a service and a positive rate, the first limit of a service is used
+func getServiceRateLimits(limits []ServiceRateLimit) []ServiceRateLimit {
+	validLimits := make([]ServiceRateLimit, 0, len(limits))
+	services := make(map[string]bool)
+	for _, limit := range limits {
+		limit.Service = strings.ToLower(strings.TrimSpace(limit.Service))
+		if limit.Service == "" || limit.CallsPerSecond <= 0 || services[limit.Service] {
+			log.Printf("ignoring invalid or duplicate Network.RateLimits entry %v", limit)
+			continue
+		}
+		if limit.Burst < 1 {
+			limit.Burst = int(math.Ceil(limit.CallsPerSecond))
+		}
+		services[limit.Service] = true
+		validLimits = append(validLimits, limit)
+	}
+	return validLimits
+}
+
+// getPluginTimeouts returns the timeouts with a plugin and a timeout within the bounds, the first timeout of a
+// plugin is used
+func getPluginTimeouts(timeouts []PluginTimeout) []PluginTimeout {
+	validTimeouts := make([]PluginTimeout, 0, len(timeouts))
+	plugins := make(map[string]bool)
+	for _, timeout := range timeouts {
+		timeout.Plugin = strings.TrimSpace(timeout.Plugin)
+		plugin := strings.ToLower(timeout.Plugin)
+		if plugin == "" || timeout.TimeoutSeconds < PluginTimeoutSecondsMin || timeout.TimeoutSeconds > PluginTimeoutSecondsMax || plugins[plugin] {
+			log.Printf("ignoring invalid or duplicate Ssm.PluginTimeouts entry %v", timeout)
+			continue
+		}
+		plugins[plugin] = true
+		validTimeouts = append(validTimeouts, timeout)
+	}
+	return validTimeouts
+}
+
+// getLongRunningPluginHealthChecks returns the health checks with a plugin and values within the bounds, 0 being
+// the default, the first health check of a plugin is used
+func getLongRunningPluginHealthChecks(healthChecks []LongRunningPluginHealthCheck) []LongRunningPluginHealthCheck {
+	validHealthChecks := make([]LongRunningPluginHealthCheck, 0, len(healthChecks))
+	plugins := make(map[string]bool)
+	for _, healthCheck := range healthChecks {
+		healthCheck.Plugin = strings.TrimSpace(healthCheck.Plugin)
+		plugin := strings.ToLower(healthCheck.Plugin)
+		if plugin == "" || plugins[plugin] ||
+			(healthCheck.IntervalSeconds != 0 && (healthCheck.IntervalSeconds < LongRunningHealthCheckIntervalSecondsMin || healthCheck.IntervalSeconds > LongRunningHealthCheckIntervalSecondsMax)) ||
+			healthCheck.TimeoutSeconds < 0 || healthCheck.TimeoutSeconds > LongRunningHealthCheckTimeoutSecondsMax ||
+			healthCheck.UnhealthyThreshold < 0 || healthCheck.UnhealthyThreshold > LongRunningUnhealthyThresholdMax {
+			log.Printf("ignoring invalid or duplicate Mds.LongRunningPluginHealthChecks entry %v", healthCheck)
+			continue
+		}
+		plugins[plugin] = true
+		validHealthChecks = append(validHealthChecks, healthCheck)
+	}
+	return validHealthChecks
+}
+
 // getNumericValue returns the default if config value is below min or above max
 func getNumericValue(configValue int, minValue int, maxValue int, defaultValue int) int {
 	if configValue < minValue || configValue > maxValue {
@@ -181,6 +542,76 @@ func getNumeric64Value(configValue int64, minValue int64, maxValue int64, defaul
 	return configValue
 }
 
+// parseSessionUserConfig falls back to the defaults for invalid session user settings
+func parseSessionUserConfig(config *SessionUserCfg) {
+	if !sessionUserNameRegex.MatchString(config.Name) {
+		config.Name = DefaultRunAsUserName
+	}
+	if config.UIDMin < 0 || config.UIDMax < config.UIDMin || (config.UIDMin == 0) != (config.UIDMax == 0) {
+		config.UIDMin = 0
+		config.UIDMax = 0
+	}
+	if config.Shell != "" && !strings.HasPrefix(config.Shell, "/") {
+		config.Shell = ""
+	}
+	config.SudoPolicy = getStringEnum(config.SudoPolicy, []string{SudoPolicyNoPassword, SudoPolicyNone}, SudoPolicyNoPassword)
+}
+
+// parseSessionLogsConfig falls back to the defaults for invalid session log limits
+func parseSessionLogsConfig(config *SessionLogsCfg) {
+	config.MaxFileSizeMB = getNumericValueAboveMin(config.MaxFileSizeMB, 0, 0)
+	config.OutputBeyondLimit = getStringEnum(config.OutputBeyondLimit,
+		[]string{SessionLogsOutputBeyondLimitRotate, SessionLogsOutputBeyondLimitDrop},
+		SessionLogsOutputBeyondLimitRotate)
+	config.MaxOverflowFiles = getNumericValue(
+		config.MaxOverflowFiles,
+		1,
+		SessionLogsMaxOverflowFilesMax,
+		DefaultSessionLogsMaxOverflowFiles)
+}
+
+// getDNSServers drops the DNS servers that are neither an ip nor an ip:port
+func getDNSServers(servers []string) []string {
+	valid := []string{}
+	for _, server := range servers {
+		server = strings.TrimSpace(server)
+		host := server
+		if h, _, err := net.SplitHostPort(server); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) == nil {
+			log.Printf("ignoring invalid DNS server %v", server)
+			continue
+		}
+		valid = append(valid, server)
+	}
+	return valid
+}
+
+// parseStorage drops the storage paths that are not absolute and defaults the state backend to the files
+func parseStorage(storage *StorageCfg) {
+	storage.StateBackend = getStringEnum(
+		storage.StateBackend,
+		[]string{StateBackendFile, StateBackendBoltDB},
+		StateBackendFile)
+	storage.DataStorePath = getStoragePath("DataStorePath", storage.DataStorePath)
+	storage.LogPath = getStoragePath("LogPath", storage.LogPath)
+	storage.DownloadPath = getStoragePath("DownloadPath", storage.DownloadPath)
+	storage.OrchestrationPath = getStoragePath("OrchestrationPath", storage.OrchestrationPath)
+}
+
+// getStoragePath returns the cleaned storage path, relative paths are ignored
+func getStoragePath(name string, path string) string {
+	if path = strings.TrimSpace(path); path == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		log.Printf("ignoring relative Storage.%v %v", name, path)
+		return ""
+	}
+	return filepath.Clean(path)
+}
+
 func getStringEnum(configValue string, possibleValues []string, defaultValue string) string {
 	if stringInList(configValue, possibleValues) {
 		return configValue
@@ -14,6 +14,7 @@
 package appconfig
 
 import (
+	"go/build"
 	"os"
 	"path/filepath"
 	"testing"
@@ -133,6 +134,19 @@ func TestIdentityConsumptionOrder_InvalidConsumptionOrderValue(t *testing.T) {
 	assert.Equal(t, 1, len(agentConfig.Identity.ConsumptionOrder))
 }
 
+func TestRegionResolutionOrder_InvalidValuesDropped(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Identity.RegionResolutionOrder = []string{RegionSourceEnvironment, "InvalidValue", RegionSourceIdentity}
+	parser(&agentConfig)
+
+	assert.Equal(t, []string{RegionSourceEnvironment, RegionSourceIdentity}, agentConfig.Identity.RegionResolutionOrder)
+
+	agentConfig.Identity.RegionResolutionOrder = []string{"InvalidValue"}
+	parser(&agentConfig)
+
+	assert.Equal(t, DefaultRegionResolutionOrder, agentConfig.Identity.RegionResolutionOrder)
+}
+
 func TestIdentityConsumptionOrder_TwoInvalidConsumptionOrderValue(t *testing.T) {
 	agentConfig := DefaultConfig()
 	agentConfig.Identity.ConsumptionOrder = []string{"AnotherInvalidValue", "InvalidValue"}
@@ -161,3 +175,533 @@ func TestIdentityCredentialsValue_InvalidCredentialsProviderToDefault(t *testing
 	parser(&agentConfig)
 	assert.Equal(t, agentConfig.Identity.CustomIdentities[0].CredentialsProvider, DefaultCustomIdentityCredentialsProvider)
 }
+
+func TestTLSMinVersion_InvalidValueToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.TLS.MinVersion = "1.0"
+	parser(&agentConfig)
+	assert.Equal(t, DefaultTLSMinVersion, agentConfig.TLS.MinVersion)
+
+	agentConfig.TLS.MinVersion = TLSVersion13
+	parser(&agentConfig)
+	assert.Equal(t, TLSVersion13, agentConfig.TLS.MinVersion)
+}
+
+func TestNetworkSource_InvalidAddressDropped(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Network.SourceAddress = "10.0.0.300"
+	agentConfig.Network.SourceInterface = " eth1 "
+	parser(&agentConfig)
+	assert.Equal(t, "", agentConfig.Network.SourceAddress)
+	assert.Equal(t, "eth1", agentConfig.Network.SourceInterface)
+
+	agentConfig.Network.SourceAddress = " 10.0.0.12"
+	parser(&agentConfig)
+	assert.Equal(t, "10.0.0.12", agentConfig.Network.SourceAddress)
+}
+
+func TestNetworkIPMode_InvalidValueToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Network.IPMode = "IPv4Only"
+	parser(&agentConfig)
+	assert.Equal(t, DefaultNetworkIPMode, agentConfig.Network.IPMode)
+
+	agentConfig.Network.IPMode = NetworkIPModeIPv6Only
+	parser(&agentConfig)
+	assert.Equal(t, NetworkIPModeIPv6Only, agentConfig.Network.IPMode)
+}
+
+func TestNetworkDNS_InvalidValuesDropped(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Network.DNSServers = []string{"10.0.0.2", " 10.0.0.3:5353", "[2001:db8::53]:53", "dns.example.com", "10.0.0.300"}
+	agentConfig.Network.DNSCacheSeconds = 86400
+	parser(&agentConfig)
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.3:5353", "[2001:db8::53]:53"}, agentConfig.Network.DNSServers)
+	assert.Equal(t, DefaultDNSCacheSeconds, agentConfig.Network.DNSCacheSeconds)
+
+	agentConfig.Network.DNSCacheSeconds = 0
+	parser(&agentConfig)
+	assert.Equal(t, 0, agentConfig.Network.DNSCacheSeconds)
+}
+
+func TestNetworkCircuitBreaker_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, CircuitBreakerCfg{
+		Enabled:          true,
+		FailureThreshold: DefaultCircuitBreakerFailureThreshold,
+		OpenSeconds:      DefaultCircuitBreakerOpenSeconds,
+		MaxOpenSeconds:   DefaultCircuitBreakerMaxOpenSeconds,
+		RetryBudget:      DefaultCircuitBreakerRetryBudget,
+	}, agentConfig.Network.CircuitBreaker)
+
+	agentConfig.Network.CircuitBreaker = CircuitBreakerCfg{FailureThreshold: 0, OpenSeconds: 60, MaxOpenSeconds: 20, RetryBudget: -1}
+	parser(&agentConfig)
+	assert.Equal(t, CircuitBreakerCfg{
+		FailureThreshold: DefaultCircuitBreakerFailureThreshold,
+		OpenSeconds:      60,
+		MaxOpenSeconds:   DefaultCircuitBreakerMaxOpenSeconds,
+		RetryBudget:      DefaultCircuitBreakerRetryBudget,
+	}, agentConfig.Network.CircuitBreaker)
+}
+
+func TestNetworkRateLimits_InvalidValuesDropped(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Network.RateLimits = []ServiceRateLimit{
+		{Service: " SSM ", CallsPerSecond: 2.5},
+		{Service: "ec2messages", CallsPerSecond: 0.2, Burst: 3},
+		{Service: "ssm", CallsPerSecond: 10},
+		{Service: "s3", CallsPerSecond: 0},
+		{Service: "", CallsPerSecond: 1},
+	}
+	parser(&agentConfig)
+	assert.Equal(t, []ServiceRateLimit{
+		{Service: "ssm", CallsPerSecond: 2.5, Burst: 3},
+		{Service: "ec2messages", CallsPerSecond: 0.2, Burst: 3},
+	}, agentConfig.Network.RateLimits)
+}
+
+func TestPluginTimeouts_InvalidValuesDropped(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Ssm.PluginTimeouts = []PluginTimeout{
+		{Plugin: " aws:downloadContent ", TimeoutSeconds: 7200},
+		{Plugin: "aws:configurePackage", TimeoutSeconds: 10800},
+		{Plugin: "AWS:DownloadContent", TimeoutSeconds: 600},
+		{Plugin: "aws:runShellScript", TimeoutSeconds: PluginTimeoutSecondsMin - 1},
+		{Plugin: "aws:runPowerShellScript", TimeoutSeconds: PluginTimeoutSecondsMax + 1},
+		{Plugin: "", TimeoutSeconds: 60},
+	}
+	parser(&agentConfig)
+	assert.Equal(t, []PluginTimeout{
+		{Plugin: "aws:downloadContent", TimeoutSeconds: 7200},
+		{Plugin: "aws:configurePackage", TimeoutSeconds: 10800},
+	}, agentConfig.Ssm.PluginTimeouts)
+
+	timeout, found := agentConfig.Ssm.PluginTimeoutSeconds("aws:DOWNLOADCONTENT")
+	assert.True(t, found)
+	assert.Equal(t, 7200, timeout)
+	_, found = agentConfig.Ssm.PluginTimeoutSeconds("aws:runShellScript")
+	assert.False(t, found)
+}
+
+func TestLongRunningPluginHealthChecks_InvalidValuesDropped(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Mds.LongRunningPluginHealthChecks = []LongRunningPluginHealthCheck{
+		{Plugin: " aws:cloudWatch ", IntervalSeconds: 300, TimeoutSeconds: 60, UnhealthyThreshold: 3},
+		{Plugin: "my-exporter", UnhealthyThreshold: 5},
+		{Plugin: "AWS:CloudWatch", IntervalSeconds: 60},
+		{Plugin: "short-interval", IntervalSeconds: LongRunningHealthCheckIntervalSecondsMin - 1},
+		{Plugin: "long-timeout", TimeoutSeconds: LongRunningHealthCheckTimeoutSecondsMax + 1},
+		{Plugin: "negative-threshold", UnhealthyThreshold: -1},
+		{Plugin: "", IntervalSeconds: 60},
+	}
+	parser(&agentConfig)
+	assert.Equal(t, []LongRunningPluginHealthCheck{
+		{Plugin: "aws:cloudWatch", IntervalSeconds: 300, TimeoutSeconds: 60, UnhealthyThreshold: 3},
+		{Plugin: "my-exporter", UnhealthyThreshold: 5},
+	}, agentConfig.Mds.LongRunningPluginHealthChecks)
+
+	healthCheck, found := agentConfig.Mds.PluginHealthCheck("AWS:CLOUDWATCH")
+	assert.True(t, found)
+	assert.Equal(t, 3, healthCheck.UnhealthyThreshold)
+	_, found = agentConfig.Mds.PluginHealthCheck("short-interval")
+	assert.False(t, found)
+}
+
+func TestStorage_RelativePathsDropped(t *testing.T) {
+	agentConfig := DefaultConfig()
+	absolutePath, _ := filepath.Abs(filepath.Join("mnt", "ephemeral", "ssm", ".."))
+	agentConfig.Storage = StorageCfg{
+		DataStorePath:     " " + absolutePath,
+		DownloadPath:      filepath.Join("relative", "download"),
+		OrchestrationPath: absolutePath + string(filepath.Separator),
+	}
+	parser(&agentConfig)
+	assert.Equal(t, StorageCfg{DataStorePath: filepath.Clean(absolutePath), OrchestrationPath: filepath.Clean(absolutePath), StateBackend: StateBackendFile}, agentConfig.Storage)
+}
+
+func TestStorage_StateBackend(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Storage.StateBackend = StateBackendBoltDB
+	parser(&agentConfig)
+	assert.Equal(t, StateBackendBoltDB, agentConfig.Storage.StateBackend)
+
+	agentConfig.Storage.StateBackend = "Sqlite"
+	parser(&agentConfig)
+	assert.Equal(t, StateBackendFile, agentConfig.Storage.StateBackend)
+}
+
+func TestApplyStoragePaths(t *testing.T) {
+	builtIn := BuiltInStorageLocations()
+	defer applyStoragePaths(StorageCfg{})
+	dataStorePath, _ := filepath.Abs("state")
+	orchestrationPath, _ := filepath.Abs("outputs")
+
+	applyStoragePaths(StorageCfg{DataStorePath: dataStorePath})
+	current := CurrentStorageLocations()
+	assert.Equal(t, builtIn.DownloadRoot, current.DownloadRoot)
+	assert.Equal(t, filepath.Clean(current.DataStorePath), dataStorePath)
+	assert.Equal(t, current.DataStorePath, current.OrchestrationStorePath)
+	assert.Equal(t, filepath.Join(dataStorePath, filepath.Base(builtIn.LocalCommandRoot)), current.LocalCommandRoot)
+	assert.Equal(t, filepath.Join(current.LocalCommandRoot, filepath.Base(LocalCommandRootSubmitted)), LocalCommandRootSubmitted)
+	assert.Equal(t, filepath.Join(dataStorePath, filepath.Base(builtIn.SessionFilesPath)), current.SessionFilesPath)
+	assert.Equal(t, filepath.Join(dataStorePath, filepath.Base(filepath.Dir(builtIn.CustomInventoryFolder)), filepath.Base(builtIn.CustomInventoryFolder)), current.CustomInventoryFolder)
+	assert.Equal(t, filepath.Join(dataStorePath, filepath.Base(builtIn.RuntimeConfigFolderPath)), current.RuntimeConfigFolderPath)
+
+	applyStoragePaths(StorageCfg{DataStorePath: dataStorePath, OrchestrationPath: orchestrationPath})
+	assert.Equal(t, orchestrationPath, filepath.Clean(CurrentStorageLocations().OrchestrationStorePath))
+
+	applyStoragePaths(StorageCfg{})
+	assert.Equal(t, builtIn, CurrentStorageLocations())
+	assert.Equal(t, builtInLocalCommandDirs, [3]string{LocalCommandRootSubmitted, LocalCommandRootCompleted, LocalCommandRootInvalid})
+}
+
+func TestConfig_ReloadKeepsStorageLocations(t *testing.T) {
+	locations := CurrentStorageLocations()
+	configPath := filepath.Join(t.TempDir(), "amazon-ssm-agent.json")
+	assert.NoError(t, ioutil.WriteFile(configPath, []byte(`{"Storage":{"DataStorePath":"/mnt/ssm"}}`), ReadWriteAccess))
+	originalRetrieveAppConfigPath, originalConfig := retrieveAppConfigPath, loadedConfig
+	defer func() { retrieveAppConfigPath, loadedConfig = originalRetrieveAppConfigPath, originalConfig }()
+	retrieveAppConfigPath = func() (string, error) { return configPath, nil }
+
+	config, err := Config(true)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Clean("/mnt/ssm"), config.Storage.DataStorePath)
+	assert.Equal(t, locations, CurrentStorageLocations())
+}
+
+func TestSessionUser_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Mgs.SessionUser = SessionUserCfg{
+		Name:       "ssm user; rm",
+		UIDMin:     5000,
+		UIDMax:     4000,
+		Shell:      "bash",
+		SudoPolicy: "Everything",
+	}
+	parser(&agentConfig)
+	assert.Equal(t, SessionUserCfg{Name: DefaultRunAsUserName, SudoPolicy: SudoPolicyNoPassword}, agentConfig.Mgs.SessionUser)
+
+	agentConfig.Mgs.SessionUser = SessionUserCfg{
+		Name:                    "session-user",
+		UIDMin:                  4000,
+		UIDMax:                  5000,
+		Shell:                   "/bin/bash",
+		SudoPolicy:              SudoPolicyNone,
+		CleanupHomeOnSessionEnd: true,
+	}
+	expected := agentConfig.Mgs.SessionUser
+	parser(&agentConfig)
+	assert.Equal(t, expected, agentConfig.Mgs.SessionUser)
+}
+
+func TestSessionLogs_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, SessionLogsCfg{OutputBeyondLimit: SessionLogsOutputBeyondLimitRotate, MaxOverflowFiles: DefaultSessionLogsMaxOverflowFiles}, agentConfig.Mgs.SessionLogs)
+
+	agentConfig.Mgs.SessionLogs = SessionLogsCfg{MaxFileSizeMB: 50, OutputBeyondLimit: SessionLogsOutputBeyondLimitDrop, MaxOverflowFiles: 10}
+	parser(&agentConfig)
+	assert.Equal(t, SessionLogsCfg{MaxFileSizeMB: 50, OutputBeyondLimit: SessionLogsOutputBeyondLimitDrop, MaxOverflowFiles: 10}, agentConfig.Mgs.SessionLogs)
+
+	agentConfig.Mgs.SessionLogs = SessionLogsCfg{MaxFileSizeMB: -1, OutputBeyondLimit: "Sample", MaxOverflowFiles: 101}
+	parser(&agentConfig)
+	assert.Equal(t, SessionLogsCfg{OutputBeyondLimit: SessionLogsOutputBeyondLimitRotate, MaxOverflowFiles: DefaultSessionLogsMaxOverflowFiles}, agentConfig.Mgs.SessionLogs)
+}
+
+func TestSessionCredentialsDuration_OutOfRangeToDefault(t *testing.T) {
+	tests := map[int]int{
+		0:     DefaultSessionCredentialsDurationSeconds,
+		600:   DefaultSessionCredentialsDurationSeconds,
+		900:   900,
+		7200:  7200,
+		43201: DefaultSessionCredentialsDurationSeconds,
+	}
+	for duration, expected := range tests {
+		agentConfig := DefaultConfig()
+		agentConfig.Mgs.SessionCredentials.DurationSeconds = duration
+		parser(&agentConfig)
+		assert.Equal(t, expected, agentConfig.Mgs.SessionCredentials.DurationSeconds, duration)
+	}
+}
+
+func TestFileIntegrityInventory_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Ssm.FileIntegrityInventory.HashAlgorithm = "MD5"
+	agentConfig.Ssm.FileIntegrityInventory.MaxFileSizeMB = 0
+	parser(&agentConfig)
+	assert.Equal(t, FileIntegrityHashAlgorithmSHA256, agentConfig.Ssm.FileIntegrityInventory.HashAlgorithm)
+	assert.Equal(t, DefaultFileIntegrityMaxFileSizeMB, agentConfig.Ssm.FileIntegrityInventory.MaxFileSizeMB)
+
+	agentConfig.Ssm.FileIntegrityInventory.HashAlgorithm = FileIntegrityHashAlgorithmSHA512
+	agentConfig.Ssm.FileIntegrityInventory.MaxFileSizeMB = 1024
+	parser(&agentConfig)
+	assert.Equal(t, FileIntegrityHashAlgorithmSHA512, agentConfig.Ssm.FileIntegrityInventory.HashAlgorithm)
+	assert.Equal(t, 1024, agentConfig.Ssm.FileIntegrityInventory.MaxFileSizeMB)
+}
+
+func TestInventoryCollectionWindows_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Ssm.InventoryCollectionWindows = []InventoryCollectionWindow{
+		{Schedule: "cron(0 9 ? * MON-FRI *)", DurationMinutes: 480, Type: InventoryCollectionWindowAllow},
+		{Schedule: "cron(0 14 * * ? *)", DurationMinutes: 0, Type: "Blackout"},
+	}
+	parser(&agentConfig)
+	assert.Equal(t, InventoryCollectionWindowAllow, agentConfig.Ssm.InventoryCollectionWindows[0].Type)
+	assert.Equal(t, 480, agentConfig.Ssm.InventoryCollectionWindows[0].DurationMinutes)
+	assert.Equal(t, InventoryCollectionWindowDeny, agentConfig.Ssm.InventoryCollectionWindows[1].Type)
+	assert.Equal(t, DefaultInventoryCollectionWindowDurationMinutes, agentConfig.Ssm.InventoryCollectionWindows[1].DurationMinutes)
+}
+
+func TestArtifactMirrors_InvalidMirrorsDropped(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Agent.ArtifactMirrors = []ArtifactMirror{
+		{SourcePrefix: "https://s3.amazonaws.com/amazon-ssm-us-east-1/", URL: "https://mirror.example.com/ssm/"},
+		{SourcePrefix: "https://s3.amazonaws.com/amazon-ssm-us-east-1/", URL: " "},
+		{URL: "https://mirror.example.com/ssm/"},
+	}
+	parser(&agentConfig)
+	assert.Equal(t, 1, len(agentConfig.Agent.ArtifactMirrors))
+	assert.Equal(t, "https://mirror.example.com/ssm/", agentConfig.Agent.ArtifactMirrors[0].URL)
+}
+
+func TestAssociationTimeZones_DatabaseEmbedded(t *testing.T) {
+	// the time zones must not depend on the time zone database of the host
+	pkg, err := build.ImportDir(".", 0)
+	assert.NoError(t, err)
+	assert.Contains(t, pkg.Imports, "time/tzdata")
+}
+
+func TestAssociationTimeZones_InvalidValuesDropped(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Ssm.AssociationTimeZones = []AssociationTimeZone{
+		{Association: "AWS-RunPatchBaseline", TimeZone: " America/New_York "},
+		{Association: "AWS-GatherSoftwareInventory", TimeZone: "Mars/Olympus_Mons"},
+		{Association: "", TimeZone: "Europe/Paris"},
+		{Association: "*", TimeZone: "UTC"},
+	}
+	parser(&agentConfig)
+	assert.Equal(t, []AssociationTimeZone{
+		{Association: "AWS-RunPatchBaseline", TimeZone: "America/New_York"},
+		{Association: "*", TimeZone: "UTC"},
+	}, agentConfig.Ssm.AssociationTimeZones)
+}
+
+func TestSingletonAssociations_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Ssm.SingletonAssociations = []SingletonAssociation{
+		{Association: "AWS-RunPatchBaseline", OverlapPolicy: SingletonOverlapPolicyQueue},
+		{Association: "AWS-GatherSoftwareInventory", OverlapPolicy: "Wait"},
+		{Association: "AWS-UpdateSSMAgent"},
+	}
+	parser(&agentConfig)
+	assert.Equal(t, SingletonOverlapPolicyQueue, agentConfig.Ssm.SingletonAssociations[0].OverlapPolicy)
+	assert.Equal(t, SingletonOverlapPolicySkip, agentConfig.Ssm.SingletonAssociations[1].OverlapPolicy)
+	assert.Equal(t, SingletonOverlapPolicySkip, agentConfig.Ssm.SingletonAssociations[2].OverlapPolicy)
+}
+
+func TestCommandLocale_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, CommandLocale{}, agentConfig.Ssm.CommandLocale)
+
+	agentConfig.Ssm.CommandLocale = CommandLocale{Locale: " C.UTF-8 ", Culture: "en-US"}
+	parser(&agentConfig)
+	assert.Equal(t, CommandLocale{Locale: "C.UTF-8", Culture: "en-US"}, agentConfig.Ssm.CommandLocale)
+
+	agentConfig.Ssm.CommandLocale = CommandLocale{Locale: "C; id", Culture: "en-US'"}
+	parser(&agentConfig)
+	assert.Equal(t, CommandLocale{}, agentConfig.Ssm.CommandLocale)
+}
+
+func TestCommandSandbox_NamesTrimmed(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, CommandSandbox{}, agentConfig.Ssm.CommandSandbox)
+
+	agentConfig.Ssm.CommandSandbox = CommandSandbox{
+		DefaultProfile: " isolated ",
+		Profiles:       []CommandSandboxProfile{{Name: "isolated ", Namespaces: []string{" Mount", "Network "}, ReadOnlyRoot: true}},
+	}
+	parser(&agentConfig)
+	assert.Equal(t, "isolated", agentConfig.Ssm.CommandSandbox.DefaultProfile)
+	assert.Equal(t, "isolated", agentConfig.Ssm.CommandSandbox.Profiles[0].Name)
+	assert.Equal(t, []string{CommandSandboxNamespaceMount, CommandSandboxNamespaceNetwork}, agentConfig.Ssm.CommandSandbox.Profiles[0].Namespaces)
+
+	agentConfig.Ssm.CommandSandbox.Profiles = []CommandSandboxProfile{{Name: "lowbox", IntegrityLevel: " Low", AppContainer: true, Capabilities: []string{"internetClient "}}}
+	parser(&agentConfig)
+	assert.Equal(t, CommandSandboxIntegrityLevelLow, agentConfig.Ssm.CommandSandbox.Profiles[0].IntegrityLevel)
+	assert.Equal(t, []string{"internetClient"}, agentConfig.Ssm.CommandSandbox.Profiles[0].Capabilities)
+}
+
+func TestProcessPriority_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Ssm.ProcessPriority = ProcessPriority{Nice: 10, IOPriorityClass: IOPriorityClassBestEffort, IOPriorityLevel: 6, PriorityClass: ProcessPriorityClassBelowNormal}
+	parser(&agentConfig)
+	assert.Equal(t, ProcessPriority{Nice: 10, IOPriorityClass: IOPriorityClassBestEffort, IOPriorityLevel: 6, PriorityClass: ProcessPriorityClassBelowNormal}, agentConfig.Ssm.ProcessPriority)
+
+	agentConfig.Ssm.ProcessPriority = ProcessPriority{Nice: 25, IOPriorityClass: "RealTime", IOPriorityLevel: 8, PriorityClass: "High"}
+	parser(&agentConfig)
+	assert.Equal(t, ProcessPriority{}, agentConfig.Ssm.ProcessPriority)
+}
+
+func TestScriptCancellation_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, ScriptCancellation{Signal: CancellationSignalKill, GracePeriodSeconds: DefaultCancellationGracePeriodSeconds}, agentConfig.Ssm.ScriptCancellation)
+
+	agentConfig.Ssm.ScriptCancellation = ScriptCancellation{Signal: CancellationSignalTerminate, GracePeriodSeconds: 30}
+	parser(&agentConfig)
+	assert.Equal(t, ScriptCancellation{Signal: CancellationSignalTerminate, GracePeriodSeconds: 30}, agentConfig.Ssm.ScriptCancellation)
+
+	agentConfig.Ssm.ScriptCancellation = ScriptCancellation{Signal: "SIGHUP", GracePeriodSeconds: 3600}
+	parser(&agentConfig)
+	assert.Equal(t, ScriptCancellation{Signal: CancellationSignalKill, GracePeriodSeconds: DefaultCancellationGracePeriodSeconds}, agentConfig.Ssm.ScriptCancellation)
+}
+
+func TestOutputSourceCodePage_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, OutputSourceCodePageDetect, agentConfig.Ssm.OutputSourceCodePage)
+
+	agentConfig.Ssm.OutputSourceCodePage = 1252
+	parser(&agentConfig)
+	assert.Equal(t, 1252, agentConfig.Ssm.OutputSourceCodePage)
+
+	agentConfig.Ssm.OutputSourceCodePage = -1
+	parser(&agentConfig)
+	assert.Equal(t, OutputSourceCodePageDetect, agentConfig.Ssm.OutputSourceCodePage)
+
+	agentConfig.Ssm.OutputSourceCodePage = 70000
+	parser(&agentConfig)
+	assert.Equal(t, OutputSourceCodePageDetect, agentConfig.Ssm.OutputSourceCodePage)
+}
+
+func TestExecutionHistoryMaxEntries_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, DefaultExecutionHistoryMaxEntries, agentConfig.Ssm.ExecutionHistoryMaxEntries)
+
+	// 0 disables the history
+	agentConfig.Ssm.ExecutionHistoryMaxEntries = 0
+	parser(&agentConfig)
+	assert.Equal(t, 0, agentConfig.Ssm.ExecutionHistoryMaxEntries)
+
+	agentConfig.Ssm.ExecutionHistoryMaxEntries = -1
+	parser(&agentConfig)
+	assert.Equal(t, DefaultExecutionHistoryMaxEntries, agentConfig.Ssm.ExecutionHistoryMaxEntries)
+
+	agentConfig.Ssm.ExecutionHistoryMaxEntries = ExecutionHistoryMaxEntriesMax + 1
+	parser(&agentConfig)
+	assert.Equal(t, DefaultExecutionHistoryMaxEntries, agentConfig.Ssm.ExecutionHistoryMaxEntries)
+}
+
+func TestDocumentStateQuarantine_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.True(t, agentConfig.Ssm.DocumentStateQuarantine.Enabled)
+	assert.True(t, agentConfig.Ssm.DocumentStateQuarantine.SendFailedResult)
+	assert.Equal(t, DefaultDocumentStateQuarantineRetentionDays, agentConfig.Ssm.DocumentStateQuarantine.RetentionDays)
+
+	agentConfig.Ssm.DocumentStateQuarantine.RetentionDays = 30
+	parser(&agentConfig)
+	assert.Equal(t, 30, agentConfig.Ssm.DocumentStateQuarantine.RetentionDays)
+
+	agentConfig.Ssm.DocumentStateQuarantine.RetentionDays = 0
+	parser(&agentConfig)
+	assert.Equal(t, DefaultDocumentStateQuarantineRetentionDays, agentConfig.Ssm.DocumentStateQuarantine.RetentionDays)
+
+	agentConfig.Ssm.DocumentStateQuarantine.RetentionDays = DocumentStateQuarantineRetentionDaysMax + 1
+	parser(&agentConfig)
+	assert.Equal(t, DefaultDocumentStateQuarantineRetentionDays, agentConfig.Ssm.DocumentStateQuarantine.RetentionDays)
+}
+
+func TestPatchScan_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, 0, agentConfig.Ssm.PatchScan.FrequencyMinutes)
+	assert.Equal(t, DefaultPatchScanDocumentName, agentConfig.Ssm.PatchScan.DocumentName)
+
+	agentConfig.Ssm.PatchScan.FrequencyMinutes = 5
+	agentConfig.Ssm.PatchScan.DocumentName = " "
+	parser(&agentConfig)
+	assert.Equal(t, DefaultPatchScanFrequencyMinutes, agentConfig.Ssm.PatchScan.FrequencyMinutes)
+	assert.Equal(t, DefaultPatchScanDocumentName, agentConfig.Ssm.PatchScan.DocumentName)
+
+	agentConfig.Ssm.PatchScan.FrequencyMinutes = 720
+	agentConfig.Ssm.PatchScan.DocumentName = "MyPatchBaselineDocument"
+	parser(&agentConfig)
+	assert.Equal(t, 720, agentConfig.Ssm.PatchScan.FrequencyMinutes)
+	assert.Equal(t, "MyPatchBaselineDocument", agentConfig.Ssm.PatchScan.DocumentName)
+}
+
+func TestMdsPolling_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, int64(0), agentConfig.Mds.LongPollTimeoutMillis)
+	assert.Equal(t, int64(0), agentConfig.Mds.PollIntervalMillis)
+	assert.Equal(t, int64(0), agentConfig.Mds.IdlePollIntervalMaxMillis)
+
+	agentConfig.Mds.LongPollTimeoutMillis = 60000
+	agentConfig.Mds.PollIntervalMillis = 5000
+	agentConfig.Mds.IdlePollIntervalMaxMillis = 300000
+	parser(&agentConfig)
+	assert.Equal(t, int64(60000), agentConfig.Mds.LongPollTimeoutMillis)
+	assert.Equal(t, int64(5000), agentConfig.Mds.PollIntervalMillis)
+	assert.Equal(t, int64(300000), agentConfig.Mds.IdlePollIntervalMaxMillis)
+
+	agentConfig.Mds.LongPollTimeoutMillis = 1000
+	agentConfig.Mds.PollIntervalMillis = -1
+	agentConfig.Mds.IdlePollIntervalMaxMillis = 3600000
+	parser(&agentConfig)
+	assert.Equal(t, int64(0), agentConfig.Mds.LongPollTimeoutMillis)
+	assert.Equal(t, int64(0), agentConfig.Mds.PollIntervalMillis)
+	assert.Equal(t, int64(0), agentConfig.Mds.IdlePollIntervalMaxMillis)
+}
+
+func TestCommandTransportOrder_InvalidValuesDropped(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, DefaultCommandTransportOrder, agentConfig.Agent.CommandTransportOrder)
+
+	agentConfig.Agent.CommandTransportOrder = []string{CommandTransportMDS, "SQS", CommandTransportMGS}
+	parser(&agentConfig)
+	assert.Equal(t, []string{CommandTransportMDS, CommandTransportMGS}, agentConfig.Agent.CommandTransportOrder)
+
+	agentConfig.Agent.CommandTransportOrder = []string{"SQS"}
+	parser(&agentConfig)
+	assert.Equal(t, DefaultCommandTransportOrder, agentConfig.Agent.CommandTransportOrder)
+}
+
+func TestProfileKeyRotation_InvalidValuesToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, ProfileKeyTypeRsa, agentConfig.Profile.KeyType)
+	assert.Equal(t, defaultProfileKeyRotationRetryMinutes, agentConfig.Profile.KeyRotationRetryMinutes)
+
+	agentConfig.Profile.KeyType = ProfileKeyTypeEcdsa
+	agentConfig.Profile.KeyRotationRetryMinutes = 30
+	parser(&agentConfig)
+	assert.Equal(t, ProfileKeyTypeEcdsa, agentConfig.Profile.KeyType)
+	assert.Equal(t, 30, agentConfig.Profile.KeyRotationRetryMinutes)
+
+	agentConfig.Profile.KeyType = "Dsa"
+	agentConfig.Profile.KeyRotationRetryMinutes = 1
+	parser(&agentConfig)
+	assert.Equal(t, ProfileKeyTypeRsa, agentConfig.Profile.KeyType)
+	assert.Equal(t, defaultProfileKeyRotationRetryMinutes, agentConfig.Profile.KeyRotationRetryMinutes)
+}
+
+func TestProfileKeyStorage_InvalidValueToDefault(t *testing.T) {
+	agentConfig := DefaultConfig()
+	parser(&agentConfig)
+	assert.Equal(t, ProfileKeyStorageFile, agentConfig.Profile.KeyStorage)
+
+	agentConfig.Profile.KeyStorage = ProfileKeyStorageHardware
+	parser(&agentConfig)
+	assert.Equal(t, ProfileKeyStorageHardware, agentConfig.Profile.KeyStorage)
+
+	agentConfig.Profile.KeyStorage = "Smartcard"
+	parser(&agentConfig)
+	assert.Equal(t, ProfileKeyStorageFile, agentConfig.Profile.KeyStorage)
+}
@@ -50,6 +50,10 @@ const (
 	DefaultStopTimeoutMillisMin = 10000
 	DefaultStopTimeoutMillisMax = 1000000
 
+	// MdsPollIntervalMillisMax is the maximum time between two MDS polls, it stays below the interval at which
+	// the message poll job is scheduled again
+	MdsPollIntervalMillisMax = 600000
+
 	// SSM defaults
 	DefaultSsmHealthFrequencyMinutes    = 5
 	DefaultSsmHealthFrequencyMinutesMin = 5
@@ -59,6 +63,86 @@ const (
 	DefaultSsmAssociationFrequencyMinutesMin = 5
 	DefaultSsmAssociationFrequencyMinutesMax = 60
 
+	DefaultBootAssociationWorkersLimit    = 1
+	DefaultBootAssociationWorkersLimitMin = 1
+	DefaultBootAssociationWorkersLimitMax = 10
+
+	// SingletonOverlapPolicySkip skips the association run triggered while the previous run is in progress
+	SingletonOverlapPolicySkip = "Skip"
+	// SingletonOverlapPolicyQueue starts the association run triggered while the previous run is in progress once it completes
+	SingletonOverlapPolicyQueue = "Queue"
+
+	// FileIntegrityHashAlgorithmSHA256 hashes the file integrity inventory files with SHA-256
+	FileIntegrityHashAlgorithmSHA256 = "SHA256"
+	// FileIntegrityHashAlgorithmSHA512 hashes the file integrity inventory files with SHA-512
+	FileIntegrityHashAlgorithmSHA512 = "SHA512"
+
+	DefaultFileIntegrityMaxFileSizeMB    = 100
+	DefaultFileIntegrityMaxFileSizeMBMin = 1
+	DefaultFileIntegrityMaxFileSizeMBMax = 4096
+
+	// InventoryCollectionWindowAllow only allows the inventory collection during the window
+	InventoryCollectionWindowAllow = "Allow"
+	// InventoryCollectionWindowDeny forbids the inventory collection during the window
+	InventoryCollectionWindowDeny = "Deny"
+
+	DefaultInventoryCollectionWindowDurationMinutes    = 60
+	DefaultInventoryCollectionWindowDurationMinutesMin = 1
+	DefaultInventoryCollectionWindowDurationMinutesMax = 10080 // 1 week
+
+	// DefaultPatchScanDocumentName is the patch baseline document run by the agent scheduled patch scans
+	DefaultPatchScanDocumentName = "AWS-RunPatchBaseline"
+
+	DefaultPatchScanFrequencyMinutes    = 1440 // 1 day
+	DefaultPatchScanFrequencyMinutesMin = 60
+	DefaultPatchScanFrequencyMinutesMax = 10080 // 1 week
+
+	ProcessNiceMin = -20
+	ProcessNiceMax = 19
+
+	// IOPriorityClassBestEffort schedules the IO of the process with the priority level of the process
+	IOPriorityClassBestEffort = "BestEffort"
+	// IOPriorityClassIdle only schedules the IO of the process when no other process needs the disk
+	IOPriorityClassIdle = "Idle"
+
+	IOPriorityLevelMin = 0
+	IOPriorityLevelMax = 7
+
+	// ProcessPriorityClassIdle runs the process only when the system is idle
+	ProcessPriorityClassIdle = "Idle"
+	// ProcessPriorityClassBelowNormal runs the process below the priority of the foreground processes
+	ProcessPriorityClassBelowNormal = "BelowNormal"
+	// ProcessPriorityClassNormal runs the process with the default priority
+	ProcessPriorityClassNormal = "Normal"
+	// ProcessPriorityClassAboveNormal runs the process above the priority of the foreground processes
+	ProcessPriorityClassAboveNormal = "AboveNormal"
+
+	// CancellationSignalKill kills the script process and its sub processes
+	CancellationSignalKill = "SIGKILL"
+	// CancellationSignalTerminate asks the script process and its sub processes to terminate
+	CancellationSignalTerminate = "SIGTERM"
+	// CancellationSignalInterrupt interrupts the script process and its sub processes
+	CancellationSignalInterrupt = "SIGINT"
+
+	DefaultCancellationGracePeriodSeconds    = 10
+	DefaultCancellationGracePeriodSecondsMin = 1
+	DefaultCancellationGracePeriodSecondsMax = 600
+
+	// CommandSandboxNamespaceMount isolates the mounts of the sandboxed commands
+	CommandSandboxNamespaceMount = "Mount"
+	// CommandSandboxNamespacePid isolates the process tree of the sandboxed commands, a new /proc is mounted
+	CommandSandboxNamespacePid = "Pid"
+	// CommandSandboxNamespaceNetwork leaves the sandboxed commands with a loopback interface only
+	CommandSandboxNamespaceNetwork = "Network"
+	// CommandSandboxIntegrityLevelLow runs the sandboxed commands with a low integrity token on Windows, they
+	// cannot write to the objects of a higher integrity level
+	CommandSandboxIntegrityLevelLow = "Low"
+
+	// OutputSourceCodePageDetect converts the output with the OEM code page on Windows and the code pages it switches
+	// to with chcp
+	OutputSourceCodePageDetect = 0
+	OutputSourceCodePageMax    = 65535
+
 	DefaultSsmSelfUpdateFrequencyDays    = 7
 	DefaultSsmSelfUpdateFrequencyDaysMin = 1 //Minimum frequency is 1 day
 	DefaultSsmSelfUpdateFrequencyDaysMax = 7 //Maximum frequency is 7 day
@@ -70,6 +154,34 @@ const (
 	DefaultLocationOfCorrupt     = "corrupt"
 	DefaultLocationOfState       = "state"
 	DefaultLocationOfAssociation = "association"
+	DefaultLocationOfHistory     = "history"
+	DefaultLocationOfQuarantine  = "quarantine"
+
+	// DocumentStateCorruptionReportSuffix is appended to the name of a quarantined document state to name its report
+	DocumentStateCorruptionReportSuffix = ".report.json"
+
+	DefaultDocumentStateQuarantineRetentionDays = 14
+	DocumentStateQuarantineRetentionDaysMin     = 1
+	DocumentStateQuarantineRetentionDaysMax     = 365
+
+	// ExecutionHistoryFileName is the file of the document state history folder holding the execution history
+	ExecutionHistoryFileName = "executions.json"
+
+	DefaultExecutionHistoryMaxEntries = 1000
+	ExecutionHistoryMaxEntriesMax     = 50000
+
+	// PluginTimeoutSecondsMin and PluginTimeoutSecondsMax bound the execution timeouts of the plugins
+	PluginTimeoutSecondsMin = 5
+	PluginTimeoutSecondsMax = 172800
+
+	// LongRunningHealthCheckIntervalSecondsMin and LongRunningHealthCheckIntervalSecondsMax bound the intervals
+	// of the health checks of the long running plugins
+	LongRunningHealthCheckIntervalSecondsMin = 30
+	LongRunningHealthCheckIntervalSecondsMax = 86400
+	// LongRunningHealthCheckTimeoutSecondsMax and LongRunningUnhealthyThresholdMax bound the timeouts and the
+	// unhealthy thresholds of the health checks of the long running plugins
+	LongRunningHealthCheckTimeoutSecondsMax = 3600
+	LongRunningUnhealthyThresholdMax        = 100
 
 	// PluginLocalOutputCleanup
 	// Delete plugin output file locally after plugin execution
@@ -95,6 +207,42 @@ const (
 	DefaultSessionLogsRetentionDurationHours               = 336 // 14 days default retention
 	DefaultStateOrchestrationLogsRetentionDurationHoursMin = 8   // Min retention of 8hrs as some processes may not timeout before this and don't want logs to be deleted before the process completes
 
+	DefaultFailedReplyMaxAgeHours    = 2   // 2 hours default, matches the document level timeout of the service
+	DefaultFailedReplyMaxAgeHoursMin = 1   // 1 hour min retry duration of failed replies
+	DefaultFailedReplyMaxAgeHoursMax = 168 // 7 days max retry duration of failed replies
+
+	DefaultFailedReplyQueueLimit    = 1000
+	DefaultFailedReplyQueueLimitMin = 10
+	DefaultFailedReplyQueueLimitMax = 10000
+
+	// IP modes of the outbound connections
+	NetworkIPModeAuto       = "Auto"
+	NetworkIPModePreferIPv6 = "PreferIPv6"
+	NetworkIPModeIPv6Only   = "IPv6Only"
+	DefaultNetworkIPMode    = NetworkIPModeAuto
+
+	// host name resolution cache duration of the outbound connections
+	DefaultDNSCacheSeconds    = 30
+	DefaultDNSCacheSecondsMin = 0
+	DefaultDNSCacheSecondsMax = 3600
+
+	// Circuit breakers of the AWS endpoints
+	DefaultCircuitBreakerFailureThreshold    = 10
+	DefaultCircuitBreakerFailureThresholdMin = 1
+	DefaultCircuitBreakerFailureThresholdMax = 1000
+	DefaultCircuitBreakerOpenSeconds         = 30
+	DefaultCircuitBreakerOpenSecondsMin      = 1
+	DefaultCircuitBreakerOpenSecondsMax      = 3600
+	DefaultCircuitBreakerMaxOpenSeconds      = 300
+	DefaultCircuitBreakerRetryBudget         = 100
+	DefaultCircuitBreakerRetryBudgetMin      = 1
+	DefaultCircuitBreakerRetryBudgetMax      = 10000
+
+	// TLS versions accepted as the minimum TLS version of outbound connections
+	TLSVersion12         = "1.2"
+	TLSVersion13         = "1.3"
+	DefaultTLSMinVersion = TLSVersion12
+
 	DefaultAuditExpirationDay    = 7  // 7 days default audit files count
 	DefaultAuditExpirationDayMax = 30 // 30 days max audit files count
 	DefaultAuditExpirationDayMin = 3  // 3 days min audit files count
@@ -102,16 +250,22 @@ const (
 	//aws-ssm-agent bookkeeping constants for long running plugins
 	LongRunningPluginsLocation         = "longrunningplugins"
 	LongRunningPluginsHealthCheck      = "healthcheck"
+	LongRunningPluginsDiagnostics      = "diagnostics"
 	LongRunningPluginDataStoreLocation = "datastore"
 	LongRunningPluginDataStoreFileName = "store"
 	PluginNameLongRunningPluginInvoker = "lrpminvoker"
 
+	// LongRunningExecutablesFileName is the file of DefaultPluginPath declaring the user executables managed as long
+	// running plugins
+	LongRunningExecutablesFileName = "LongRunningExecutables.json"
+
 	//aws-ssm-agent bookkeeping constants for inventory plugin
 	InventoryRootDirName         = "inventory"
 	CustomInventoryRootDirName   = "custom"
 	FileInventoryRootDirName     = "file"
 	RoleInventoryRootDirName     = "role"
 	InventoryContentHashFileName = "contentHash"
+	ApplicationCacheFileName     = "applicationCache"
 
 	//aws-ssm-agent bookkeeping constants for failed sent replies
 	RepliesRootDirName = "replies"
@@ -120,6 +274,15 @@ const (
 	//amazon-ssm-agent bookkeeping constants for storing received commands
 	IdempotencyDirName = "idempotency"
 
+	//aws-ssm-agent bookkeeping constants for the content of the pinned document versions run by associations
+	DocumentCacheRootDirName = "documentcache"
+
+	//aws-ssm-agent bookkeeping constants for the execution locks of the singleton associations
+	AssociationLocksRootDirName = "associationlocks"
+
+	//aws-ssm-agent bookkeeping constants for the reports of the core module crashes
+	CrashReportsRootDirName = "crashreports"
+
 	//aws-ssm-agent bookkeeping constants for compliance
 	ComplianceRootDirName         = "compliance"
 	ComplianceContentHashFileName = "contentHash"
@@ -143,10 +306,50 @@ const (
 	defaultLongRunningWorkerMonitorIntervalSecondsMin = 30
 	defaultLongRunningWorkerMonitorIntervalSecondsMax = 1800
 
+	defaultLongRunningReadinessTimeoutSeconds    = 300
+	defaultLongRunningReadinessTimeoutSecondsMin = 0
+	defaultLongRunningReadinessTimeoutSecondsMax = 3600
+
+	defaultCloudWatchStopTimeoutSeconds    = 30
+	defaultCloudWatchStopTimeoutSecondsMin = 0
+	defaultCloudWatchStopTimeoutSecondsMax = 600
+
+	defaultCloudWatchOutputMaxSizeMB    = 10
+	defaultCloudWatchOutputMaxSizeMBMin = 1
+	defaultCloudWatchOutputMaxSizeMBMax = 1024
+
+	defaultCloudWatchOutputMaxRotatedFiles    = 3
+	defaultCloudWatchOutputMaxRotatedFilesMin = 0
+	defaultCloudWatchOutputMaxRotatedFilesMax = 20
+
+	defaultLongRunningCrashDumpMaxSizeMB    = 256
+	defaultLongRunningCrashDumpMaxSizeMBMin = 0
+	defaultLongRunningCrashDumpMaxSizeMBMax = 4096
+
 	defaultProfileKeyAutoRotateDays    = 0
 	defaultProfileKeyAutoRotateDaysMin = 0
 	defaultProfileKeyAutoRotateDaysMax = 365
 
+	defaultProfileKeyRotationRetryMinutes    = 60
+	defaultProfileKeyRotationRetryMinutesMin = 5
+	defaultProfileKeyRotationRetryMinutesMax = 10080
+
+	// ProfileKeyTypeRsa rotates the managed instance private key to a 2048 bits RSA key
+	ProfileKeyTypeRsa = "Rsa"
+	// ProfileKeyTypeEcdsa rotates the managed instance private key to a P-256 ECDSA key
+	ProfileKeyTypeEcdsa = "Ecdsa"
+
+	// ProfileKeyStorageFile keeps the managed instance private key with the registration information
+	ProfileKeyStorageFile = "File"
+	// ProfileKeyStorageHardware keeps the managed instance private key in the TPM on Linux or in the
+	// platform crypto provider on Windows, the signatures are computed by the secure element
+	ProfileKeyStorageHardware = "Hardware"
+
+	// StateBackendFile persists each state of the instance in its own file
+	StateBackendFile = "File"
+	// StateBackendBoltDB persists the states of the instance in a BoltDB database under the data store
+	StateBackendBoltDB = "BoltDB"
+
 	// Permissions defaults
 	//NOTE: Limit READ, WRITE and EXECUTE access to administrators/root.
 	ReadWriteAccess        = 0600
@@ -214,6 +417,12 @@ const (
 	// PluginNameAwsApplications is the name of the Applications plugin
 	PluginNameAwsApplications = "aws:applications"
 
+	// PluginNameAwsStagePatches is the name of the patch staging plugin
+	PluginNameAwsStagePatches = "aws:stagePatches"
+
+	// PluginNameAwsInstallMacPackage is the name of the macOS package installation plugin
+	PluginNameAwsInstallMacPackage = "aws:installMacPackage"
+
 	AppConfigFileName = "amazon-ssm-agent.json"
 
 	SeelogConfigFileName = "seelog.xml"
@@ -235,11 +444,35 @@ const (
 	// PluginNameNonInteractiveCommands is the name for session manager non-interactive commands plugin.
 	PluginNameNonInteractiveCommands = "NonInteractiveCommands"
 
+	// PluginNameForcedCommand is the name for session manager forced command plugin.
+	PluginNameForcedCommand = "ForcedCommand"
+
 	// PluginNamePort is the name for session manager port plugin.
 	PluginNamePort = "Port"
 
 	// Session default RunAs user name
 	DefaultRunAsUserName = "ssm-user"
+
+	// SudoPolicyNoPassword grants passwordless sudo to the session user
+	SudoPolicyNoPassword = "NoPassword"
+	// SudoPolicyNone never grants sudo to the session user
+	SudoPolicyNone = "None"
+
+	// DefaultSessionCredentialsDurationSeconds represents the default lifetime of session scoped credentials
+	DefaultSessionCredentialsDurationSeconds = 3600
+	// SessionCredentialsDurationSecondsMin represents the minimum lifetime of session scoped credentials allowed by STS
+	SessionCredentialsDurationSecondsMin = 900
+	// SessionCredentialsDurationSecondsMax represents the maximum lifetime of session scoped credentials allowed by STS
+	SessionCredentialsDurationSecondsMax = 43200
+
+	// SessionLogsOutputBeyondLimitRotate writes the session output beyond the transcript limit to overflow files
+	SessionLogsOutputBeyondLimitRotate = "Rotate"
+	// SessionLogsOutputBeyondLimitDrop discards the session output beyond the transcript limit
+	SessionLogsOutputBeyondLimitDrop = "Drop"
+	// DefaultSessionLogsMaxOverflowFiles represents the default number of overflow files kept for a session
+	DefaultSessionLogsMaxOverflowFiles = 5
+	// SessionLogsMaxOverflowFilesMax represents the maximum number of overflow files kept for a session
+	SessionLogsMaxOverflowFilesMax = 100
 )
 
 // Default deny list IP addresses for remote host port forwarding: IMDS ipv4, IMDS ipv6, VPC ipv4, VPC ipv6, Amazon Time Sync Service, Amazon Windows license activation
@@ -259,6 +492,27 @@ var SupportedDocumentVersions = map[string]struct{}{
 	"2.2":   {},
 }
 
+// Precondition operators of the document steps, all of them accept exactly two arguments
+const (
+	PreconditionOperatorStringEquals              = "StringEquals"
+	PreconditionOperatorStringNotEquals           = "StringNotEquals"
+	PreconditionOperatorStringLike                = "StringLike"
+	PreconditionOperatorVersionGreaterThanOrEqual = "VersionGreaterThanOrEqual"
+	PreconditionOperatorVersionLessThan           = "VersionLessThan"
+	// PreconditionOperatorInstancePercentage takes a selection key and the percentage of the instances to select
+	PreconditionOperatorInstancePercentage = "InstancePercentage"
+)
+
+// Precondition operators that are supported by this Agent version.
+var SupportedPreconditionOperators = map[string]struct{}{
+	PreconditionOperatorStringEquals:              {},
+	PreconditionOperatorStringNotEquals:           {},
+	PreconditionOperatorStringLike:                {},
+	PreconditionOperatorVersionGreaterThanOrEqual: {},
+	PreconditionOperatorVersionLessThan:           {},
+	PreconditionOperatorInstancePercentage:        {},
+}
+
 // Session Manager Document versions that are supported by this Agent version.
 var SupportedSessionDocumentVersions = map[string]struct{}{
 	"1.0": {},
@@ -286,3 +540,33 @@ var DefaultIdentityConsumptionOrder = []string{
 }
 
 var DefaultCustomIdentityCredentialsProvider = "DEFAULT"
+
+const (
+	// RegionSourceAppConfig is the region set in the Agent section of the agent configuration
+	RegionSourceAppConfig = "AppConfig"
+	// RegionSourceEnvironment is the region set in the AWS_REGION or AWS_DEFAULT_REGION environment variables
+	RegionSourceEnvironment = "Environment"
+	// RegionSourceIdentity is the region reported by the agent identity, the instance metadata service on EC2,
+	// the task metadata endpoint on ECS and the registration on managed instances
+	RegionSourceIdentity = "Identity"
+	// RegionSourceConfigFile is the region of the profile selected by AWS_PROFILE in the AWS shared config file
+	RegionSourceConfigFile = "ConfigFile"
+)
+
+const (
+	// CommandTransportMGS receives the Run Command documents through the MGS control channel
+	CommandTransportMGS = "MGS"
+	// CommandTransportMDS receives the Run Command documents by polling MDS
+	CommandTransportMDS = "MDS"
+)
+
+// DefaultCommandTransportOrder prefers MGS and falls back to MDS
+var DefaultCommandTransportOrder = []string{
+	CommandTransportMGS,
+	CommandTransportMDS,
+}
+
+// DefaultRegionResolutionOrder defines the default order the region sources are queried
+var DefaultRegionResolutionOrder = []string{
+	RegionSourceIdentity,
+}
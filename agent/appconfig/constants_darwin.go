@@ -19,7 +19,10 @@ package appconfig
 
 var (
 	// DefaultProgramFolder is the default folder for SSM
-	DefaultProgramFolder = "/opt/aws/ssm/"
+	DefaultProgramFolder = InstanceFolder("/opt/aws/ssm/")
+
+	// binaryFolder holds the agent executables, it is shared by the agent instances
+	binaryFolder = "/opt/aws/ssm/bin/"
 
 	// AppConfigPath is the path of the AppConfig
 	AppConfigPath = DefaultProgramFolder + AppConfigFileName
@@ -54,6 +57,9 @@ var (
 	// DefaultDataStorePath represents the directory for storing system data
 	DefaultDataStorePath = DefaultProgramFolder + "data/"
 
+	// OrchestrationStorePath represents the directory under which the outputs of the documents are written
+	OrchestrationStorePath = DefaultProgramFolder + "data/"
+
 	// EC2ConfigDataStorePath represents the directory for storing ec2 config data
 	EC2ConfigDataStorePath = "/var/lib/amazon/ec2config/"
 
@@ -83,11 +89,11 @@ var (
 	// Default Session files Folder
 	SessionFilesPath = DefaultDataStorePath + "session"
 
-	DefaultSSMAgentBinaryPath = DefaultProgramFolder + "bin/amazon-ssm-agent"
-	DefaultSSMAgentWorker     = DefaultProgramFolder + "bin/ssm-agent-worker"
-	DefaultDocumentWorker     = DefaultProgramFolder + "bin/ssm-document-worker"
-	DefaultSessionWorker      = DefaultProgramFolder + "bin/ssm-session-worker"
-	DefaultSessionLogger      = DefaultProgramFolder + "bin/ssm-session-logger"
+	DefaultSSMAgentBinaryPath = binaryFolder + "amazon-ssm-agent"
+	DefaultSSMAgentWorker     = binaryFolder + "ssm-agent-worker"
+	DefaultDocumentWorker     = binaryFolder + "ssm-document-worker"
+	DefaultSessionWorker      = binaryFolder + "ssm-session-worker"
+	DefaultSessionLogger      = binaryFolder + "ssm-session-logger"
 
 	// PowerShellPluginCommandName is the path of the powershell.exe to be used by the runPowerShellScript plugin
 	PowerShellPluginCommandName = "/usr/local/bin/pwsh"
@@ -29,7 +29,7 @@ import (
 var (
 
 	// AgentExtensions specified the root folder for various kinds of downloaded content
-	AgentData = "/var/lib/amazon/ssm/"
+	AgentData = InstanceFolder("/var/lib/amazon/ssm/")
 
 	// PackageRoot specifies the directory under which packages will be downloaded and installed
 	PackageRoot = AgentData + "packages"
@@ -61,6 +61,9 @@ var (
 	// DefaultDataStorePath represents the directory for storing system data
 	DefaultDataStorePath = AgentData
 
+	// OrchestrationStorePath represents the directory under which the outputs of the documents are written
+	OrchestrationStorePath = AgentData
+
 	// EC2ConfigDataStorePath represents the directory for storing ec2 config data
 	EC2ConfigDataStorePath = "/var/lib/amazon/ec2config/"
 
@@ -106,7 +109,7 @@ var (
 	customCertificateFileName = "amazon-ssm-agent.crt"
 
 	// SSM Agent Update download legacy path
-	LegacyUpdateDownloadFolder = "/var/log/amazon/ssm/download"
+	LegacyUpdateDownloadFolder = InstanceFolder("/var/log/amazon/ssm") + "/download"
 
 	// DefaultEC2SharedCredentialsFilePath represents the filepath for storing credentials for ec2 identity
 	DefaultEC2SharedCredentialsFilePath = DefaultDataStorePath + "credentials"
@@ -116,7 +119,7 @@ var (
 var PowerShellPluginCommandName string
 
 // DefaultProgramFolder is the default folder for SSM
-var DefaultProgramFolder = "/etc/amazon/ssm/"
+var DefaultProgramFolder = InstanceFolder("/etc/amazon/ssm/")
 
 var defaultWorkerPath = "/usr/bin/"
 var DefaultSSMAgentBinaryPath = defaultWorkerPath + "amazon-ssm-agent"
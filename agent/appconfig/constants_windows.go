@@ -62,13 +62,16 @@ const (
 	// RunCommandScriptName is the script name where all downloaded or provided commands will be stored
 	RunCommandScriptName = "_script.ps1"
 
-	// ItemPropertyPath is the registry path for AmazonSSMAgent service
-	ItemPropertyPath = "SYSTEM\\CurrentControlSet\\Services\\AmazonSSMAgent"
+	// ServiceName is the name of the service of the primary agent instance
+	ServiceName = "AmazonSSMAgent"
 
 	// ItemPropertyName is the registry variable name that stores proxy settings
 	ItemPropertyName = "Environment"
 )
 
+// ItemPropertyPath is the registry path for the AmazonSSMAgent service of the agent instance
+var ItemPropertyPath = "SYSTEM\\CurrentControlSet\\Services\\" + InstanceServiceName(ServiceName)
+
 // PowerShellPluginCommandName is the path of the powershell.exe to be used by the runPowerShellScript plugin
 var PowerShellPluginCommandName = filepath.Join(os.Getenv("SystemRoot"), "System32", "WindowsPowerShell", "v1.0", "powershell.exe")
 
@@ -99,6 +102,9 @@ var SeelogFilePath string
 // DefaultDataStorePath represents the directory for storing system data
 var DefaultDataStorePath string
 
+// OrchestrationStorePath represents the directory under which the outputs of the documents are written
+var OrchestrationStorePath string
+
 // DefaultEC2SharedCredentialsFilePath represents the filepath for storing credentials for ec2 identity
 var DefaultEC2SharedCredentialsFilePath string
 
@@ -187,7 +193,7 @@ func init() {
 	if programData == "" {
 		programData = filepath.Join(os.Getenv("AllUsersProfile"), "Application Data")
 	}
-	SSMDataPath = filepath.Join(programData, SSMFolder)
+	SSMDataPath = InstanceFolder(filepath.Join(programData, SSMFolder))
 	AmazonDataPath = filepath.Join(programData, AmazonFolder)
 
 	EnvProgramFiles = os.Getenv("ProgramFiles")
@@ -200,11 +206,14 @@ func init() {
 	DefaultDocumentWorker = filepath.Join(DefaultProgramFolder, "ssm-document-worker.exe")
 	DefaultSessionWorker = filepath.Join(DefaultProgramFolder, "ssm-session-worker.exe")
 	DefaultSessionLogger = fmt.Sprintf("&'%s'", filepath.Join(DefaultProgramFolder, "ssm-session-logger.exe"))
-	ManifestCacheDirectory = filepath.Join(EnvProgramFiles, ManifestCacheFolder)
-	AppConfigPath = filepath.Join(DefaultProgramFolder, AppConfigFileName)
-	SeelogFilePath = filepath.Join(DefaultProgramFolder, SeelogConfigFileName)
+	// the binaries and plugins are shared by the agent instances, each instance has its own configuration
+	configFolder := InstanceFolder(DefaultProgramFolder)
+	ManifestCacheDirectory = filepath.Join(configFolder, "Manifests")
+	AppConfigPath = filepath.Join(configFolder, AppConfigFileName)
+	SeelogFilePath = filepath.Join(configFolder, SeelogConfigFileName)
 	DefaultDataStorePath = filepath.Join(SSMDataPath, "InstanceData")
-	DefaultEC2SharedCredentialsFilePath = filepath.Join(DefaultProgramFolder, "credentials")
+	OrchestrationStorePath = DefaultDataStorePath
+	DefaultEC2SharedCredentialsFilePath = filepath.Join(configFolder, "credentials")
 	PackageRoot = filepath.Join(SSMDataPath, "Packages")
 	PackageLockRoot = filepath.Join(SSMDataPath, "Locks\\Packages")
 	DaemonRoot = filepath.Join(SSMDataPath, "Daemons")
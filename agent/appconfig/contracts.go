@@ -20,6 +20,12 @@ type CredentialProfile struct {
 	ShareProfile      string
 	ForceUpdateCreds  bool
 	KeyAutoRotateDays int
+	// KeyType is the type of the keys created by the rotations of the managed instance private key
+	KeyType string
+	// KeyRotationRetryMinutes is the delay before an age based rotation is attempted again after a failure
+	KeyRotationRetryMinutes int
+	// KeyStorage is where the managed instance private key is kept, in a file or in the TPM
+	KeyStorage string
 }
 
 // MdsCfg represents configuration for Message delivery service (MDS)
@@ -29,6 +35,31 @@ type MdsCfg struct {
 	CommandWorkerBufferLimit int
 	StopTimeoutMillis        int64
 	CommandRetryLimit        int
+	// Time the agent waits on a GetMessages long poll before it polls again, StopTimeoutMillis is used when 0
+	LongPollTimeoutMillis int64
+	// Minimum time between the start of two GetMessages calls, 0 polls again as soon as a poll returns
+	PollIntervalMillis int64
+	// Maximum poll interval reached by doubling the interval after each poll that received no message,
+	// the interval goes back to PollIntervalMillis when a message is received. The backoff is disabled when 0.
+	IdlePollIntervalMaxMillis int64
+	// Health checks of the long running plugins, the plugins without one are checked every 15 minutes and are
+	// restarted after their first failed health check
+	LongRunningPluginHealthChecks []LongRunningPluginHealthCheck
+}
+
+// LongRunningPluginHealthCheck is how the long running plugin manager checks the health of a long running plugin,
+// the defaults are used for the fields left to 0
+type LongRunningPluginHealthCheck struct {
+	// Plugin is the name of the long running plugin, e.g. aws:cloudWatch or the name of a declared executable
+	Plugin string
+	// Seconds between two health checks of the plugin
+	IntervalSeconds int
+	// Seconds a health check is given to complete, it fails once elapsed. The executables use the timeout of their
+	// health check command by default, the other plugins are not timed out.
+	TimeoutSeconds int
+	// Number of consecutive failed health checks after which the plugin is restarted, it overrides the threshold of
+	// the definition of an executable
+	UnhealthyThreshold int
 }
 
 // SsmCfg represents configuration for Simple system manager (SSM)
@@ -48,6 +79,198 @@ type SsmCfg struct {
 	PluginLocalOutputCleanup string
 	// Configure only when it is safe to delete orchestration folder after document execution. This config overrides PluginLocalOutputCleanup when set.
 	OrchestrationDirectoryCleanup string
+	// Maximum number of associations applied in parallel when the associations due at boot are applied
+	BootAssociationWorkersLimit int
+	// Ordering hints for the associations applied at boot
+	BootAssociationHints []BootAssociationHint
+	// Associations whose runs must not overlap
+	SingletonAssociations []SingletonAssociation
+	// Time zones the cron expressions of associations are evaluated in, they are evaluated in UTC otherwise
+	AssociationTimeZones []AssociationTimeZone
+	// Registry keys collected by the registry key set inventory gatherer on Windows
+	RegistryInventoryKeySets []RegistryInventoryKeySet
+	// Files hashed by the file integrity inventory gatherer
+	FileIntegrityInventory FileIntegrityInventoryCfg
+	// Time windows during which inventory collection is allowed or forbidden
+	InventoryCollectionWindows []InventoryCollectionWindow
+	// Agent scheduled patch compliance scans
+	PatchScan PatchScanCfg
+	// Priority of the document workers and of the script processes they spawn
+	ProcessPriority ProcessPriority
+	// How the script processes are stopped when their command is cancelled or times out
+	ScriptCancellation ScriptCancellation
+	// Code page of the plugin output that is neither UTF-8 nor UTF-16, 0 uses the OEM code page on Windows
+	OutputSourceCodePage int
+	// Locale forced on the commands executed by the plugins
+	CommandLocale CommandLocale
+	// Namespaces the commands executed by the plugins are isolated in on Linux
+	CommandSandbox CommandSandbox
+	// Maximum number of document executions kept in the local execution history, 0 disables the history
+	ExecutionHistoryMaxEntries int
+	// Handling of the document state files that cannot be parsed
+	DocumentStateQuarantine DocumentStateQuarantineCfg
+	// Execution timeouts of the plugins whose document does not set one
+	PluginTimeouts []PluginTimeout
+}
+
+// PluginTimeout is the execution timeout of a plugin when its document does not set one
+type PluginTimeout struct {
+	// Plugin is the name of the plugin, e.g. aws:runShellScript or aws:downloadContent
+	Plugin         string
+	TimeoutSeconds int
+}
+
+// DocumentStateQuarantineCfg represents the handling of the document state files that cannot be parsed. They are
+// moved to the quarantine folder with a corruption report when it is enabled, to the corrupt folder otherwise.
+type DocumentStateQuarantineCfg struct {
+	Enabled bool
+	// SendFailedResult reports the commands recovered from the quarantined document states as Failed to the service
+	SendFailedResult bool
+	// Days the quarantined document states and their reports are kept
+	RetentionDays int
+}
+
+// CommandSandbox represents the sandbox profiles the commands executed by the plugins can run in, the commands
+// run in the profile named by DefaultProfile and run without sandbox when it is empty
+type CommandSandbox struct {
+	DefaultProfile string
+	Profiles       []CommandSandboxProfile
+}
+
+// CommandSandboxProfile represents the isolation of a command, the Linux namespaces created for it and the file
+// system it sees on Linux, the integrity level and AppContainer of its token on Windows
+type CommandSandboxProfile struct {
+	Name string
+	// Namespaces created for the command: Mount, Pid and Network
+	Namespaces []string
+	// ReadOnlyRoot remounts the root file system read-only in the mount namespace
+	ReadOnlyRoot bool
+	// BindMounts are mounted in the mount namespace before the root file system is remounted read-only
+	BindMounts []CommandSandboxBindMount
+	// IntegrityLevel of the Windows token of the command: Low, the privileges of the token are removed
+	IntegrityLevel string
+	// AppContainer runs the command in an AppContainer of the profile on Windows
+	AppContainer bool
+	// Capabilities granted to the AppContainer, e.g. internetClient
+	Capabilities []string
+}
+
+// CommandSandboxBindMount represents a host path mounted at an existing path of the sandbox
+type CommandSandboxBindMount struct {
+	Source      string
+	Destination string
+	ReadOnly    bool
+}
+
+// CommandLocale represents the locale of the commands executed by the plugins, the zero value keeps the locale
+// inherited from the agent
+type CommandLocale struct {
+	// Locale is set as LANG and LC_ALL in the environment of the commands, e.g. C.UTF-8
+	Locale string
+	// Culture is the culture and UI culture of the PowerShell scripts, e.g. en-US
+	Culture string
+}
+
+// ScriptCancellation represents how a script process is stopped when its command is cancelled or times out
+type ScriptCancellation struct {
+	// Signal is SIGKILL to kill the process right away, or SIGTERM or SIGINT to let the script trap the signal and
+	// clean up before it exits. On Windows SIGTERM and SIGINT are delivered as a CTRL_BREAK console event.
+	Signal string
+	// GracePeriodSeconds is the time the process gets to exit after the signal before it is killed
+	GracePeriodSeconds int
+}
+
+// ProcessPriority represents the scheduling priority of the processes spawned by the agent, the zero value keeps
+// the priority inherited from the agent
+type ProcessPriority struct {
+	// Nice is the niceness of the processes on Linux, macOS and BSD, between -20 and 19
+	Nice int
+	// IOPriorityClass is BestEffort or Idle, it only applies on Linux
+	IOPriorityClass string
+	// IOPriorityLevel is the priority within the BestEffort class, between 0 (highest) and 7 (lowest)
+	IOPriorityLevel int
+	// PriorityClass is Idle, BelowNormal, Normal or AboveNormal, it only applies on Windows
+	PriorityClass string
+}
+
+// PatchScanCfg represents the patch compliance scans scheduled by the agent itself
+type PatchScanCfg struct {
+	// FrequencyMinutes is the time between two scans, the scans are disabled when 0
+	FrequencyMinutes int
+	// DocumentName is the patch baseline document run with the Scan operation
+	DocumentName string
+	// BaselineOverride is the optional S3 path of the patch baseline overriding the default baseline
+	BaselineOverride string
+}
+
+// InventoryCollectionWindow declares a time window during which inventory collection is allowed or forbidden
+type InventoryCollectionWindow struct {
+	// Schedule is the cron expression of the window openings, e.g. cron(0 9 ? * MON-FRI *)
+	Schedule string
+	// DurationMinutes is the time the window stays open after each opening
+	DurationMinutes int
+	// Type is Allow or Deny, when Allow windows are configured the collection only runs during these windows
+	Type string
+	// TimeZone is the IANA time zone the schedule is evaluated in, UTC when empty
+	TimeZone string
+	// Gatherers lists the gatherers the window applies to, e.g. AWS:Application, all gatherers when empty
+	Gatherers []string
+}
+
+// FileIntegrityInventoryCfg represents the files whose digests are reported by the file integrity inventory gatherer
+type FileIntegrityInventoryCfg struct {
+	// Paths lists the files and directories to hash, directories are scanned recursively
+	Paths []string
+	// HashAlgorithm is the digest algorithm, SHA256 or SHA512
+	HashAlgorithm string
+	// MaxFileSizeMB is the size above which files are reported without digest
+	MaxFileSizeMB int
+}
+
+// BootAssociationHint declares the priority and dependencies of an association applied at boot
+type BootAssociationHint struct {
+	// Association is the association id or the document name of the association
+	Association string
+	// Priority orders the associations without pending dependencies, associations with higher priority are applied first
+	Priority int
+	// DependsOn lists the association ids or document names of the associations to complete before the association is applied
+	DependsOn []string
+}
+
+// SingletonAssociation declares that a run of an association must not start while a previous run holding the same
+// execution lock is still in progress
+type SingletonAssociation struct {
+	// Association is the association id or the document name of the association
+	Association string
+	// Mutex is the name of the execution lock, associations sharing a mutex never run at the same time.
+	// The association id is used when empty.
+	Mutex string
+	// OverlapPolicy is Skip to skip the run triggered while the lock is held, or Queue to start it once the lock is released
+	OverlapPolicy string
+}
+
+// AssociationTimeZone declares the time zone the cron expression of an association is evaluated in, daylight saving
+// transitions included
+type AssociationTimeZone struct {
+	// Association is the association id or the document name of the association, * applies to all the associations
+	// that no other entry names
+	Association string
+	// TimeZone is the IANA name of the time zone, e.g. America/New_York
+	TimeZone string
+}
+
+// RegistryInventoryKeySet declares a set of registry keys reported as one custom inventory type
+type RegistryInventoryKeySet struct {
+	// TypeName is the name of the custom inventory type, the Custom: prefix is added when missing
+	TypeName string
+	// Paths lists the registry keys to collect, e.g. HKEY_LOCAL_MACHINE\SOFTWARE\Amazon\SSM
+	Paths []string
+	// Recursive collects the values of the sub keys as well
+	Recursive bool
+	// ValueNames restricts the collected values to the given names, all values are collected when empty
+	ValueNames []string
+	// RedactedValueNames lists the value names, wildcards allowed, whose data is replaced before upload
+	RedactedValueNames []string
 }
 
 // AgentInfo represents metadata for amazon-ssm-agent
@@ -67,8 +290,81 @@ type AgentInfo struct {
 	LongRunningWorkerMonitorIntervalSeconds int
 	AuditExpirationDay                      int
 	ForceFileIPC                            bool
+	// Seconds the long running plugins started at boot wait for their readiness gates, they are started
+	// anyway once elapsed
+	LongRunningReadinessTimeoutSeconds int
+	// Seconds cloudwatch.exe is given to deliver its in-flight batches and exit when it is asked to stop, it is
+	// killed once elapsed and right away when 0
+	CloudWatchStopTimeoutSeconds int
+	// Local account cloudwatch.exe runs as, e.g. ssm-cloudwatch, it runs as LocalSystem when empty. The agent manages
+	// the account like ssm-user: it is created without administrator rights when missing and its password is replaced
+	// each time cloudwatch.exe starts, it must not be used for anything else. An existing account the agent did not
+	// create, or a member of the Administrators group, is refused.
+	CloudWatchRunAsUser string
+	// S3 or https url AWS.CloudWatch.exe is updated from when the cloudwatch plugin starts, the bundled exe is kept
+	// when empty. The update is only applied when CloudWatchExeUpdateSHA256 is set and matches the downloaded exe.
+	CloudWatchExeUpdateSource string
+	// Sha256 checksum of the AWS.CloudWatch.exe published at CloudWatchExeUpdateSource
+	CloudWatchExeUpdateSHA256 string
+	// Megabytes the stdout and stderr files of cloudwatch.exe grow to before they are rotated, and number of rotated
+	// files kept for each of them, the files are truncated instead when no rotated file is kept
+	CloudWatchOutputMaxSizeMB       int
+	CloudWatchOutputMaxRotatedFiles int
+	// Megabytes of the crash dumps captured for the long running plugin processes, larger dumps are truncated and
+	// no dump is captured when 0
+	LongRunningCrashDumpMaxSizeMB int
 	// denotes GOMAXPROCS value for legacy agent worker
 	GoMaxProcForAgentWorker int
+	// Hours to keep retrying undelivered MDS/MGS replies persisted on disk
+	FailedReplyMaxAgeHours int
+	// Maximum number of undelivered replies persisted on disk per upstream service
+	FailedReplyQueueLimit int
+	// Path of the local socket (named pipe on windows) used to stream agent telemetry events as JSON, streaming is disabled when empty.
+	// The directory of the socket must only be accessible by root.
+	TelemetryEventSocketPath string
+	// Path of the local socket (named pipe on windows) serving the message latency and execution backlog metrics as
+	// JSON, disabled when empty. The directory of the socket must only be accessible by root.
+	MessageMetricsSocketPath string
+	// Registers the agent counter set with the Windows performance counters, perfmon and SCOM can then monitor the
+	// commands in progress, the active sessions, the MGS connection state and the queued replies
+	PerformanceCounters bool
+	// Transports the Run Command documents are received from, by order of preference. When MDS comes first the
+	// documents delivered through MGS are only accepted while MDS is unhealthy. A transport left out is not used.
+	CommandTransportOrder []string
+	// Mirrors tried before the source of the artifacts the agent downloads, in their order
+	ArtifactMirrors []ArtifactMirror
+	// Lets ssm-cli get-diagnostics add local firewall rules allowing the agent processes to reach the required
+	// endpoints on port 443 when they are unreachable
+	ManageFirewallRules bool
+	// Profiling endpoints of the agent processes, for ssm-cli get-profile-bundle
+	Profiling ProfilingCfg
+	// Categorized fingerprints of the errors the agent logs, for ssm-cli get-error-fingerprints
+	ErrorFingerprints ErrorFingerprintsCfg
+}
+
+// ErrorFingerprintsCfg represents the collection of the error fingerprints in the audit logs. A fingerprint holds the
+// module, the class and a hash of the message template of an error, never the message itself
+type ErrorFingerprintsCfg struct {
+	Enabled bool
+	// Upload sends the fingerprint counts with the agent telemetry when TelemetryMetricsToSSM is enabled
+	Upload bool
+}
+
+// ProfilingCfg represents the pprof endpoints the agent processes serve on a local socket only the administrators
+// can connect to, a unix domain socket or a named pipe on windows
+type ProfilingCfg struct {
+	Enabled bool
+}
+
+// ArtifactMirror declares a mirror serving the artifacts the agent downloads, e.g. the agent update packages
+type ArtifactMirror struct {
+	// SourcePrefix is the prefix of the artifact urls served by the mirror,
+	// e.g. https://s3.us-east-1.amazonaws.com/amazon-ssm-us-east-1/
+	SourcePrefix string
+	// URL replaces the source prefix of the artifact urls downloaded from the mirror
+	URL string
+	// Headers are added to the requests sent to the mirror, e.g. an Authorization header
+	Headers map[string]string
 }
 
 // MgsConfig represents configuration for Message Gateway service
@@ -79,6 +375,47 @@ type MgsConfig struct {
 	SessionWorkersLimit           int
 	SessionWorkerBufferLimit      int
 	DeniedPortForwardingRemoteIPs []string
+	SessionUser                   SessionUserCfg
+	SessionCredentials            SessionCredentialsCfg
+	SessionLogs                   SessionLogsCfg
+	// ForcedCommands maps the command names accepted by ForcedCommand sessions to the command lines run for them
+	ForcedCommands map[string]string
+}
+
+// SessionUserCfg represents the local user that sessions run as on Linux and macOS when RunAs is not enabled
+type SessionUserCfg struct {
+	// Name is the name of the session user
+	Name string
+	// UIDMin and UIDMax restrict the uid of a newly created session user, the system default is used when both are 0
+	UIDMin int
+	UIDMax int
+	// Shell is the login shell of a newly created session user, the system default is used when empty
+	Shell string
+	// SudoPolicy is NoPassword to grant passwordless sudo to a newly created session user, None to never grant sudo
+	SudoPolicy string
+	// CleanupHomeOnSessionEnd deletes the content of the session user home directory when the last active session ends
+	CleanupHomeOnSessionEnd bool
+}
+
+// SessionCredentialsCfg represents the session scoped credentials injected into shell sessions on Linux and macOS,
+// shell sessions on Windows are refused when a role is configured
+type SessionCredentialsCfg struct {
+	// RoleArn is the role assumed for each session with the session id as session tag, credentials are not injected when empty
+	RoleArn string
+	// DurationSeconds is the lifetime of the session credentials, they are refreshed for as long as the session runs
+	DurationSeconds int
+}
+
+// SessionLogsCfg represents the size limit of the session transcripts written in the orchestration folder
+type SessionLogsCfg struct {
+	// MaxFileSizeMB is the size beyond which the session output is no longer written to the transcript, the input
+	// keeps being recorded. There is no limit when 0.
+	MaxFileSizeMB int
+	// OutputBeyondLimit is Rotate to write the output beyond the limit to overflow files next to the transcript,
+	// Drop to discard it
+	OutputBeyondLimit string
+	// MaxOverflowFiles is the number of overflow files of MaxFileSizeMB kept, the oldest one is deleted beyond
+	MaxOverflowFiles int
 }
 
 // KmsConfig represents configuration for Key Management Service
@@ -106,18 +443,105 @@ type BirdwatcherCfg struct {
 	ForceEnable bool
 }
 
+// FeatureFlagCfg represents configuration for runtime feature flags
+type FeatureFlagCfg struct {
+	// Flags overrides the state of individual feature flags by name
+	Flags map[string]bool
+}
+
+// TLSCfg represents the TLS policy applied to every outbound TLS connection of the agent
+type TLSCfg struct {
+	// MinVersion is the minimum accepted TLS version, 1.2 or 1.3
+	MinVersion string
+	// CipherSuites restricts the TLS 1.2 cipher suites by name, all secure cipher suites are allowed when empty
+	CipherSuites []string
+}
+
+// NetworkCfg represents the local endpoint the outbound connections of the agent are bound to.
+// On multi-homed servers it selects the interface the AWS endpoints are reached through.
+type NetworkCfg struct {
+	// SourceAddress is the local IP address the outbound connections are bound to
+	SourceAddress string
+	// SourceInterface is the name of the network interface whose address the outbound connections are bound to,
+	// it is ignored when SourceAddress is set
+	SourceInterface string
+	// IPMode selects the IP version of the outbound connections, Auto, PreferIPv6 or IPv6Only.
+	// The dualstack service endpoints are used when IPv6 is preferred or forced, and the IPv6 endpoint of the
+	// instance metadata service is used when IPv6 is forced.
+	IPMode string
+	// DNSServers are the DNS servers, ip or ip:port, queried by the agent before the system resolver
+	DNSServers []string
+	// DNSOverTLS queries the DNS servers over TLS, on port 853 when the server has no port
+	DNSOverTLS bool
+	// DNSCacheSeconds is the maximum time the agent caches a host name resolution, the shorter TTL of the records
+	// returned by the DNS servers is respected. 0 disables the cache.
+	DNSCacheSeconds int
+	// CircuitBreaker limits the calls and the retries to the AWS endpoints failing repeatedly
+	CircuitBreaker CircuitBreakerCfg
+	// RateLimits cap the rate of the calls each agent process makes to an AWS service, the calls over the rate
+	// wait for their turn. The calls to the services without a rate limit are not delayed.
+	RateLimits []ServiceRateLimit
+}
+
+// ServiceRateLimit is the token bucket limiting the calls to an AWS service
+type ServiceRateLimit struct {
+	// Service is the endpoint prefix of the service, e.g. ssm, ec2messages, ssmmessages or s3
+	Service string
+	// CallsPerSecond is the sustained call rate, e.g. 0.5 for a call every 2 seconds
+	CallsPerSecond float64
+	// Burst is the number of calls that can be made at once, it defaults to CallsPerSecond rounded up
+	Burst int
+}
+
+// CircuitBreakerCfg represents the circuit breakers guarding the calls to each AWS endpoint, e.g. SSM, EC2Messages,
+// MGS and S3. A breaker opens after consecutive failures and rejects the calls to its endpoint until it lets a
+// probe call through, the retries of the failed calls are limited by a budget refilled by the successful calls.
+type CircuitBreakerCfg struct {
+	Enabled bool
+	// FailureThreshold is the number of consecutive failed calls opening the breaker of an endpoint
+	FailureThreshold int
+	// OpenSeconds is the time an open breaker rejects the calls before letting a probe call through,
+	// it doubles each time the probe fails up to MaxOpenSeconds
+	OpenSeconds    int
+	MaxOpenSeconds int
+	// RetryBudget is the number of retries an endpoint allows in a burst, each successful call gives back a fifth of a retry
+	RetryBudget int
+}
+
+// StorageCfg represents the locations of the files written by the agent, an empty path keeps the built-in location.
+// The locations are resolved when the process starts, the data found in their built-in locations is moved to the
+// configured ones when the agent starts.
+type StorageCfg struct {
+	// DataStorePath is the directory where the state of the instance, the registration, the local commands,
+	// the session files, the custom inventory and the runtime configs are persisted
+	DataStorePath string
+	// LogPath is the directory of the agent logs, the paths of a custom seelog.xml take precedence
+	LogPath string
+	// DownloadPath is the directory under which the documents download their artifacts
+	DownloadPath string
+	// OrchestrationPath is the directory where the outputs of the documents are written, it defaults to DataStorePath
+	OrchestrationPath string
+	// StateBackend is where the document states, the association state and the reply queues are persisted, File keeps
+	// one file per state while BoltDB keeps them in a single database updated in transactions
+	StateBackend string
+}
+
 // SsmagentConfig stores agent configuration values.
 type SsmagentConfig struct {
-	Profile     CredentialProfile
-	Mds         MdsCfg
-	Ssm         SsmCfg
-	Mgs         MgsConfig
-	Agent       AgentInfo
-	Os          OsInfo
-	S3          S3Cfg
-	Birdwatcher BirdwatcherCfg
-	Kms         KmsConfig
-	Identity    IdentityCfg
+	Profile      CredentialProfile
+	Mds          MdsCfg
+	Ssm          SsmCfg
+	Mgs          MgsConfig
+	Agent        AgentInfo
+	Os           OsInfo
+	S3           S3Cfg
+	Birdwatcher  BirdwatcherCfg
+	Kms          KmsConfig
+	Identity     IdentityCfg
+	FeatureFlags FeatureFlagCfg
+	TLS          TLSCfg
+	Network      NetworkCfg
+	Storage      StorageCfg
 }
 
 // AppConstants represents some run time constant variable for various module.
@@ -137,9 +561,10 @@ type CustomIdentity struct {
 	CredentialsProvider string
 }
 
-// IdentityCfg stores identity consumption order and custom identities
+// IdentityCfg stores identity consumption order, region resolution order and custom identities
 type IdentityCfg struct {
 	Ec2SystemInfoDetectionResponse string
 	ConsumptionOrder               []string
+	RegionResolutionOrder          []string
 	CustomIdentities               []*CustomIdentity
 }
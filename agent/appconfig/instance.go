@@ -0,0 +1,99 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package appconfig
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// InstanceNameEnvVariable names the secondary agent instance the process belongs to, the primary instance
+	// leaves it unset. The configuration, data, logs, service and registration of a secondary instance are
+	// kept apart from the ones of the primary instance.
+	InstanceNameEnvVariable = "AWS_SSM_AGENT_INSTANCE"
+
+	maxInstanceNameLength = 32
+)
+
+var instanceNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// instanceName is the name of the agent instance of the process, empty for the primary instance
+var instanceName, instanceNameErr = loadInstanceName(os.Getenv(InstanceNameEnvVariable))
+
+func loadInstanceName(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	if err := ValidateInstanceName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ValidateInstanceName returns an error if the name cannot be used for a secondary agent instance,
+// the names are made of lower case letters, digits and inner dashes
+func ValidateInstanceName(name string) error {
+	if len(name) > maxInstanceNameLength || !instanceNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid agent instance name %q, the name must have at most %v lower case letters, digits and inner dashes",
+			name, maxInstanceNameLength)
+	}
+	return nil
+}
+
+// InstanceName returns the name of the agent instance of the process, empty for the primary instance
+func InstanceName() string {
+	return instanceName
+}
+
+// InstanceNameError returns the error of the instance name set in the environment, the process runs as the
+// primary instance when the name is invalid and must not be started
+func InstanceNameError() error {
+	return instanceNameErr
+}
+
+// IsSecondaryInstance returns true if the process belongs to a secondary agent instance
+func IsSecondaryInstance() bool {
+	return instanceName != ""
+}
+
+// InstanceFolder returns the folder of the agent instance of the process for a folder of the primary instance
+func InstanceFolder(folder string) string {
+	return NamespacedFolder(folder, instanceName)
+}
+
+// NamespacedFolder returns the folder of the given agent instance for a folder of the primary instance,
+// the instance name is appended to the last element of the folder and the trailing separator is kept
+func NamespacedFolder(folder, name string) string {
+	if name == "" {
+		return folder
+	}
+	trimmed := strings.TrimRight(folder, `/\`)
+	return trimmed + "-" + name + folder[len(trimmed):]
+}
+
+// InstanceServiceName returns the service name of the agent instance of the process for a service of the primary instance
+func InstanceServiceName(serviceName string) string {
+	return NamespacedServiceName(serviceName, instanceName)
+}
+
+// NamespacedServiceName returns the service name of the given agent instance for a service of the primary instance
+func NamespacedServiceName(serviceName, name string) string {
+	if name == "" {
+		return serviceName
+	}
+	return serviceName + "-" + name
+}
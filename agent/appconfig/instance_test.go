@@ -0,0 +1,55 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package appconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateInstanceName(t *testing.T) {
+	for _, name := range []string{"tenantb", "org-2", "a", "0123456789abcdef0123456789abcdef"} {
+		assert.NoError(t, ValidateInstanceName(name), name)
+	}
+	for _, name := range []string{"", "TenantB", "-org", "org-", "org_2", "../org", "0123456789abcdef0123456789abcdef0"} {
+		assert.Error(t, ValidateInstanceName(name), name)
+	}
+}
+
+func TestLoadInstanceName(t *testing.T) {
+	name, err := loadInstanceName("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", name)
+
+	name, err = loadInstanceName("tenantb")
+	assert.NoError(t, err)
+	assert.Equal(t, "tenantb", name)
+
+	name, err = loadInstanceName("Tenant B")
+	assert.Error(t, err)
+	assert.Equal(t, "", name)
+}
+
+func TestNamespacedFolder(t *testing.T) {
+	assert.Equal(t, "/var/lib/amazon/ssm/", NamespacedFolder("/var/lib/amazon/ssm/", ""))
+	assert.Equal(t, "/var/lib/amazon/ssm-tenantb/", NamespacedFolder("/var/lib/amazon/ssm/", "tenantb"))
+	assert.Equal(t, "/var/log/amazon/ssm-tenantb", NamespacedFolder("/var/log/amazon/ssm", "tenantb"))
+	assert.Equal(t, `C:\ProgramData\Amazon\SSM-tenantb`, NamespacedFolder(`C:\ProgramData\Amazon\SSM`, "tenantb"))
+}
+
+func TestNamespacedServiceName(t *testing.T) {
+	assert.Equal(t, "AmazonSSMAgent", NamespacedServiceName("AmazonSSMAgent", ""))
+	assert.Equal(t, "amazon-ssm-agent-tenantb", NamespacedServiceName("amazon-ssm-agent", "tenantb"))
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package appconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+)
+
+var (
+	builtInStorage StorageLocations
+
+	// the built-in directories of the local commands, they follow LocalCommandRoot
+	builtInLocalCommandDirs [3]string
+)
+
+// StorageLocations are the directories the data of the agent is written to
+type StorageLocations struct {
+	DataStorePath           string
+	DownloadRoot            string
+	OrchestrationStorePath  string
+	LocalCommandRoot        string
+	SessionFilesPath        string
+	CustomInventoryFolder   string
+	RuntimeConfigFolderPath string
+}
+
+// init resolves the storage locations before the packages importing appconfig derive their paths from them.
+// It runs after the init of the platform constants, which sets the built-in locations and the app config path.
+func init() {
+	builtInStorage = CurrentStorageLocations()
+	builtInLocalCommandDirs = [3]string{LocalCommandRootSubmitted, LocalCommandRootCompleted, LocalCommandRootInvalid}
+
+	var config struct{ Storage StorageCfg }
+	if _, err := os.Stat(AppConfigPath); err == nil {
+		if err = jsonutil.UnmarshalFile(AppConfigPath, &config); err != nil {
+			fmt.Printf("Failed to read the storage locations from %s, using the built-in ones: %v\n", AppConfigPath, err)
+			config.Storage = StorageCfg{}
+		}
+	}
+	parseStorage(&config.Storage)
+	applyStoragePaths(config.Storage)
+}
+
+// BuiltInStorageLocations returns the locations used when no Storage path is configured
+func BuiltInStorageLocations() StorageLocations {
+	return builtInStorage
+}
+
+// CurrentStorageLocations returns the locations in use. They are resolved once when the process starts, a change
+// of the Storage config takes effect when the agent restarts.
+func CurrentStorageLocations() StorageLocations {
+	return StorageLocations{
+		DataStorePath:           DefaultDataStorePath,
+		DownloadRoot:            DownloadRoot,
+		OrchestrationStorePath:  OrchestrationStorePath,
+		LocalCommandRoot:        LocalCommandRoot,
+		SessionFilesPath:        SessionFilesPath,
+		CustomInventoryFolder:   DefaultCustomInventoryFolder,
+		RuntimeConfigFolderPath: RuntimeConfigFolderPath,
+	}
+}
+
+// applyStoragePaths points the storage locations to the configured paths, the built-in locations are used for
+// the paths that are not configured. The local commands, the session files, the custom inventory and the runtime
+// configs are kept under the data store.
+func applyStoragePaths(storage StorageCfg) {
+	DefaultDataStorePath = builtInStorage.DataStorePath
+	LocalCommandRoot = builtInStorage.LocalCommandRoot
+	SessionFilesPath = builtInStorage.SessionFilesPath
+	DefaultCustomInventoryFolder = builtInStorage.CustomInventoryFolder
+	RuntimeConfigFolderPath = builtInStorage.RuntimeConfigFolderPath
+	LocalCommandRootSubmitted, LocalCommandRootCompleted, LocalCommandRootInvalid =
+		builtInLocalCommandDirs[0], builtInLocalCommandDirs[1], builtInLocalCommandDirs[2]
+	if storage.DataStorePath != "" {
+		DefaultDataStorePath = withTrailingSeparator(storage.DataStorePath, builtInStorage.DataStorePath)
+		LocalCommandRoot = underDataStore(builtInStorage.LocalCommandRoot, 1)
+		LocalCommandRootSubmitted = underDataStore(builtInLocalCommandDirs[0], 2)
+		LocalCommandRootCompleted = underDataStore(builtInLocalCommandDirs[1], 2)
+		LocalCommandRootInvalid = underDataStore(builtInLocalCommandDirs[2], 2)
+		SessionFilesPath = underDataStore(builtInStorage.SessionFilesPath, 1)
+		DefaultCustomInventoryFolder = underDataStore(builtInStorage.CustomInventoryFolder, 2)
+		RuntimeConfigFolderPath = underDataStore(builtInStorage.RuntimeConfigFolderPath, 1)
+	}
+
+	DownloadRoot = builtInStorage.DownloadRoot
+	if storage.DownloadPath != "" {
+		DownloadRoot = withTrailingSeparator(storage.DownloadPath, builtInStorage.DownloadRoot)
+	}
+	OrchestrationStorePath = DefaultDataStorePath
+	if storage.OrchestrationPath != "" {
+		OrchestrationStorePath = withTrailingSeparator(storage.OrchestrationPath, builtInStorage.OrchestrationStorePath)
+	}
+}
+
+// underDataStore returns the built-in path with its last depth elements moved under the data store
+func underDataStore(builtIn string, depth int) string {
+	elements := strings.Split(filepath.Clean(builtIn), string(os.PathSeparator))
+	if depth > len(elements) {
+		depth = len(elements)
+	}
+	return filepath.Join(append([]string{DefaultDataStorePath}, elements[len(elements)-depth:]...)...)
+}
+
+// withTrailingSeparator adds a trailing separator to the path when the built-in location has one,
+// some callers concatenate these locations with file names
+func withTrailingSeparator(path string, builtIn string) string {
+	path = filepath.Clean(path)
+	if strings.HasSuffix(builtIn, string(os.PathSeparator)) && !strings.HasSuffix(path, string(os.PathSeparator)) {
+		return path + string(os.PathSeparator)
+	}
+	return path
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package compliance
+
+import (
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	// maxComplianceItemsPerRequest is the maximum number of items accepted by a PutComplianceItems request
+	maxComplianceItemsPerRequest = 10000
+	// maxComplianceItemsSizePerRequest keeps the items of a request below the 1MB PutComplianceItems request limit
+	maxComplianceItemsSizePerRequest = 900 * 1024
+)
+
+// complianceBatch is the items uploaded in a single PutComplianceItems request
+type complianceBatch struct {
+	uploadType  string
+	contentHash string
+	items       []*ssm.ComplianceItemEntry
+}
+
+// buildComplianceBatches splits the association compliance items into PutComplianceItems requests within the
+// request limits. The first request replaces the reported items and the next requests add to them, each request
+// carries the hash of its own items. A report that fits in a single request keeps the content hash of the report.
+func buildComplianceBatches(log log.T, contentHash string, items []*ssm.ComplianceItemEntry) ([]complianceBatch, error) {
+	if fitsInRequest(items) {
+		return []complianceBatch{{contentHash: contentHash, items: items}}, nil
+	}
+
+	var batches []complianceBatch
+	var current []*ssm.ComplianceItemEntry
+	currentSize := 0
+	for _, item := range items {
+		size := itemSize(item)
+		if len(current) > 0 && (len(current) == maxComplianceItemsPerRequest || currentSize+size > maxComplianceItemsSizePerRequest) {
+			batches = append(batches, complianceBatch{uploadType: ssm.ComplianceUploadTypePartial, items: current})
+			current, currentSize = nil, 0
+		}
+		current = append(current, item)
+		currentSize += size
+	}
+	batches = append(batches, complianceBatch{uploadType: ssm.ComplianceUploadTypePartial, items: current})
+	batches[0].uploadType = ssm.ComplianceUploadTypeComplete
+
+	for i := range batches {
+		hash, err := calculateContentHash(batches[i].items)
+		if err != nil {
+			return nil, err
+		}
+		batches[i].contentHash = hash
+	}
+	log.Infof("Uploading %v association compliance items in %v requests", len(items), len(batches))
+	return batches, nil
+}
+
+// fitsInRequest returns true if the items fit in a single PutComplianceItems request
+func fitsInRequest(items []*ssm.ComplianceItemEntry) bool {
+	if len(items) > maxComplianceItemsPerRequest {
+		return false
+	}
+	size := 0
+	for _, item := range items {
+		if size += itemSize(item); size > maxComplianceItemsSizePerRequest {
+			return false
+		}
+	}
+	return true
+}
+
+// itemSize returns the size of the item in the request
+func itemSize(item *ssm.ComplianceItemEntry) int {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// calculateContentHash streams the JSON encoding of the content through gzip into the hash,
+// so large reports are hashed without holding their serialized content in memory
+func calculateContentHash(content interface{}) (string, error) {
+	hash := md5.New()
+	gzipWriter := gzip.NewWriter(hash)
+	if err := json.NewEncoder(gzipWriter).Encode(content); err != nil {
+		return "", err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package compliance
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func createComplianceItems(count int, status string, severity string, titleLength int) []*ssm.ComplianceItemEntry {
+	var items []*ssm.ComplianceItemEntry
+	for i := 0; i < count; i++ {
+		items = append(items, &ssm.ComplianceItemEntry{
+			Id:       aws.String(status + "-" + severity + "-" + strconv.Itoa(i)),
+			Status:   aws.String(status),
+			Severity: aws.String(severity),
+			Title:    aws.String(strings.Repeat("t", titleLength)),
+		})
+	}
+	return items
+}
+
+func TestBuildComplianceBatchesSmallReport(t *testing.T) {
+	items := createComplianceItems(10, ssm.ComplianceStatusCompliant, ssm.ComplianceSeverityUnspecified, 10)
+
+	batches, err := buildComplianceBatches(log.NewMockLog(), "reportHash", items)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []complianceBatch{{contentHash: "reportHash", items: items}}, batches)
+}
+
+func TestBuildComplianceBatchesSplitsLargeReport(t *testing.T) {
+	items := createComplianceItems(maxComplianceItemsPerRequest*2+1, ssm.ComplianceStatusCompliant, ssm.ComplianceSeverityUnspecified, 1)
+
+	batches, err := buildComplianceBatches(log.NewMockLog(), "reportHash", items)
+
+	assert.Nil(t, err)
+	assert.Len(t, batches, 3)
+	assert.Equal(t, ssm.ComplianceUploadTypeComplete, batches[0].uploadType)
+	assert.Equal(t, ssm.ComplianceUploadTypePartial, batches[1].uploadType)
+	assert.Equal(t, ssm.ComplianceUploadTypePartial, batches[2].uploadType)
+	total := 0
+	hashes := make(map[string]bool)
+	for _, batch := range batches {
+		assert.True(t, fitsInRequest(batch.items))
+		total += len(batch.items)
+		hash, _ := calculateContentHash(batch.items)
+		assert.Equal(t, hash, batch.contentHash)
+		hashes[batch.contentHash] = true
+	}
+	assert.Equal(t, len(items), total)
+	assert.Len(t, hashes, 3)
+}
+
+func TestBuildComplianceBatchesSplitsOverTheSizeLimit(t *testing.T) {
+	items := createComplianceItems(2000, ssm.ComplianceStatusNonCompliant, ssm.ComplianceSeverityHigh, 1000)
+
+	batches, err := buildComplianceBatches(log.NewMockLog(), "reportHash", items)
+
+	assert.Nil(t, err)
+	assert.True(t, len(batches) > 1)
+	total := 0
+	for _, batch := range batches {
+		assert.True(t, fitsInRequest(batch.items))
+		assert.NotEqual(t, "reportHash", batch.contentHash)
+		total += len(batch.items)
+	}
+	assert.Equal(t, len(items), total)
+}
+
+func TestCalculateContentHash(t *testing.T) {
+	items := createComplianceItems(2, ssm.ComplianceStatusCompliant, ssm.ComplianceSeverityUnspecified, 10)
+
+	hash, err := calculateContentHash(items)
+	assert.Nil(t, err)
+	sameHash, _ := calculateContentHash(createComplianceItems(2, ssm.ComplianceStatusCompliant, ssm.ComplianceSeverityUnspecified, 10))
+	assert.Equal(t, hash, sameHash)
+	otherHash, _ := calculateContentHash(items[:1])
+	assert.NotEqual(t, hash, otherHash)
+}
@@ -14,8 +14,6 @@
 package compliance
 
 import (
-	"crypto/md5"
-	"encoding/base64"
 	"fmt"
 	"sync"
 	"time"
@@ -100,12 +98,6 @@ func (u *ComplianceUploader) CreateNewServiceIfUnHealthy(log log.T) {
 	}
 }
 
-func calculateCheckSum(data []byte) (checkSum string) {
-	sum := md5.Sum(data)
-	checkSum = base64.StdEncoding.EncodeToString(sum[:])
-	return
-}
-
 /**
  * Update association compliance status, it only report status back when status is either SUCCESS / FAILED / TIMEDOUT
  */
@@ -123,29 +115,39 @@ func (u *ComplianceUploader) UpdateAssociationCompliance(associationID string, i
 
 	oldHash := u.optimizer.GetContentHash(AssociationComplianceItemName)
 	newComplianceItems, itemContentHash, err := u.ConvertToSsmAssociationComplianceItems(log, associationComplianceEntries, oldHash)
+	if err != nil {
+		return fmt.Errorf("Unable to convert association compliance items %v", err)
+	}
 
 	// 1. When call PutComplianceItem failed, it will fail silently  with an error message the agent should have permission to call
 	// 2. When old date arrive at server side before new date, the server side will discard and use the new date
-	response, err := u.ssmSvc.PutComplianceItems(
-		log,
-		&executionTime,
-		"",
-		"",
-		instanceID,
-		associationComplianceType,
-		itemContentHash,
-		newComplianceItems)
-
+	batches, err := buildComplianceBatches(log, itemContentHash, newComplianceItems)
 	if err != nil {
-		err = fmt.Errorf("Unable to update association compliance %v", err)
-		return err
+		return fmt.Errorf("Unable to split association compliance items %v", err)
+	}
+	for _, batch := range batches {
+		response, err := u.ssmSvc.PutComplianceItems(
+			log,
+			&executionTime,
+			"",
+			"",
+			instanceID,
+			associationComplianceType,
+			batch.contentHash,
+			batch.uploadType,
+			batch.items)
+
+		if err != nil {
+			err = fmt.Errorf("Unable to update association compliance %v", err)
+			return err
+		}
+		log.Debugf("Put compliance item %v return response %v", batch.items, response)
 	}
 
 	if itemContentHash != oldHash {
 		u.optimizer.UpdateContentHash(AssociationComplianceItemName, itemContentHash)
 	}
 
-	log.Debugf("Put compliance item %v return response %v", newComplianceItems, response)
 	return nil
 }
 
@@ -166,7 +168,9 @@ func (u *ComplianceUploader) ConvertToSsmAssociationComplianceItems(log log.T, a
 		return
 	}
 
-	newHash = calculateCheckSum(dataB)
+	if newHash, err = calculateContentHash(associationComplianceEntries); err != nil {
+		return
+	}
 
 	log.Debugf("Association compliance item being converted with data - %v with checksum - %v", string(dataB), newHash)
 
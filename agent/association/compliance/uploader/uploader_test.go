@@ -14,7 +14,6 @@
 package compliance
 
 import (
-	"encoding/json"
 	"strconv"
 	"testing"
 	"time"
@@ -156,6 +155,7 @@ func TestUpdateAssociationCompliance(t *testing.T) {
 		mock.AnythingOfType("string"),
 		mock.AnythingOfType("string"),
 		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
 		mock.AnythingOfType("[]*ssm.ComplianceItemEntry")).Return(mockPutComplianceItemOutput, nil)
 
 	executionTime := time.Now()
@@ -177,7 +177,8 @@ func TestUpdateAssociationCompliance(t *testing.T) {
 	assert.Equal(t, "Association", arguments.String(5))
 	assert.NotNil(t, arguments.String(6))
 
-	assert.Equal(t, 1, len(arguments.Get(7).([]*ssm.ComplianceItemEntry)))
+	assert.Equal(t, "", arguments.String(7))
+	assert.Equal(t, 1, len(arguments.Get(8).([]*ssm.ComplianceItemEntry)))
 
 	optimizer.AssertCalled(t, "GetContentHash", mock.AnythingOfType("string"))
 	optimizer.AssertCalled(t, "UpdateContentHash", mock.AnythingOfType("string"), mock.AnythingOfType("string"))
@@ -193,8 +194,7 @@ func TestConvertReturnEmptyForHashMatch(t *testing.T) {
 	u := MockComplianceUploader()
 
 	items = append(items, AssociationComplianceItem())
-	dataB, _ := json.Marshal(items)
-	hash := calculateCheckSum(dataB)
+	hash, _ := calculateContentHash(items)
 
 	optimizer := datauploader.NewMockDefault()
 	optimizer.On("GetContentHash", mock.AnythingOfType("string")).Return(hash)
@@ -214,8 +214,8 @@ func TestChecksumSameForSameContent(t *testing.T) {
 	items = append(items, AssociationComplianceItem())
 	items2 = append(items2, AssociationComplianceItem())
 
-	dataB1, _ := json.Marshal(items)
-	dataB2, _ := json.Marshal(items)
+	hash1, _ := calculateContentHash(items)
+	hash2, _ := calculateContentHash(items)
 
-	assert.Equal(t, calculateCheckSum(dataB1), calculateCheckSum(dataB2))
+	assert.Equal(t, hash1, hash2)
 }
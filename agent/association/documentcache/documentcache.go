@@ -0,0 +1,147 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package documentcache persists the content of the pinned document versions run by associations, so that
+// repeated executions do not call GetDocument again. The entries are keyed by the document name, the version and the
+// checksum of the association: a document deleted and created again under the same name restarts at version 1, the
+// checksum of its associations changes with it.
+package documentcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// retention is the time after which a document that was not used is removed from the cache
+const retention = 30 * 24 * time.Hour
+
+// pinnedVersion matches the document versions whose content never changes, $LATEST and $DEFAULT are not pinned
+var pinnedVersion = regexp.MustCompile(`^[0-9]+$`)
+
+// entry is the cached content of a document version
+type entry struct {
+	Name    string
+	Version string
+	// Checksum is the checksum of the association the content was downloaded for
+	Checksum string
+	// Sha256 is the hash of Content when it was cached, the entry is discarded when they no longer match
+	Sha256  string
+	Content string
+}
+
+// DocumentCache holds the content of the pinned document versions
+type DocumentCache struct {
+	log       log.T
+	directory string
+}
+
+// IsPinnedVersion returns true if the content of the document version can be cached
+func IsPinnedVersion(version string) bool {
+	return pinnedVersion.MatchString(version)
+}
+
+// NewDocumentCache returns the document cache of the instance
+func NewDocumentCache(context context.T) (*DocumentCache, error) {
+	instanceID, err := context.Identity().InstanceID()
+	if err != nil {
+		return nil, err
+	}
+	return New(context.Log(), filepath.Join(appconfig.DefaultDataStorePath, instanceID, appconfig.DocumentCacheRootDirName)), nil
+}
+
+// New returns the document cache stored in directory
+func New(log log.T, directory string) *DocumentCache {
+	return &DocumentCache{
+		log:       log,
+		directory: directory,
+	}
+}
+
+// entryPath returns the path of the cache file of a document version, document names can be arns so the file
+// is named after the hash of the name, the version and the association checksum
+func (c *DocumentCache) entryPath(name string, version string, checksum string) string {
+	key := sha256.Sum256([]byte(name + "\x00" + version + "\x00" + checksum))
+	return filepath.Join(c.directory, hex.EncodeToString(key[:])+".json")
+}
+
+// Get returns the cached content of the document version for the association checksum, found is false if the version
+// is not cached for the checksum or if the cached content does not match its hash
+func (c *DocumentCache) Get(name string, version string, checksum string) (content string, found bool) {
+	if !IsPinnedVersion(version) || checksum == "" {
+		return "", false
+	}
+	path := c.entryPath(name, version, checksum)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	var cached entry
+	if err = json.Unmarshal(data, &cached); err != nil || cached.Name != name || cached.Version != version || cached.Checksum != checksum || cached.Sha256 != contentHash(cached.Content) {
+		c.log.Warnf("Discarding invalid cached content of document %v version %v", name, version)
+		_ = fileutil.DeleteFile(path)
+		return "", false
+	}
+
+	// the modification time records the last use of the entry
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	c.log.Debugf("Using cached content of document %v version %v", name, version)
+	return cached.Content, true
+}
+
+// Put caches the content of a pinned document version for the association checksum and removes the entries that were
+// not used recently
+func (c *DocumentCache) Put(name string, version string, checksum string, content string) {
+	if !IsPinnedVersion(version) || checksum == "" {
+		return
+	}
+	if err := fileutil.MakeDirs(c.directory); err != nil {
+		c.log.Debugf("Unable to create the document cache directory: %v", err)
+		return
+	}
+	data, _ := json.Marshal(entry{Name: name, Version: version, Checksum: checksum, Sha256: contentHash(content), Content: content})
+	if _, err := fileutil.WriteIntoFileWithPermissions(c.entryPath(name, version, checksum), string(data), appconfig.ReadWriteAccess); err != nil {
+		c.log.Debugf("Unable to cache document %v version %v: %v", name, version, err)
+	}
+	c.removeExpiredEntries()
+}
+
+// removeExpiredEntries removes the entries that were not used during the retention period
+func (c *DocumentCache) removeExpiredEntries() {
+	files, err := ioutil.ReadDir(c.directory)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if time.Since(file.ModTime()) > retention {
+			_ = fileutil.DeleteFile(filepath.Join(c.directory, file.Name()))
+		}
+	}
+}
+
+func contentHash(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package documentcache
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCache(t *testing.T) *DocumentCache {
+	return New(log.NewMockLog(), t.TempDir())
+}
+
+func TestIsPinnedVersion(t *testing.T) {
+	assert.True(t, IsPinnedVersion("12"))
+	assert.False(t, IsPinnedVersion(""))
+	assert.False(t, IsPinnedVersion("$LATEST"))
+	assert.False(t, IsPinnedVersion("$DEFAULT"))
+}
+
+func TestGetPut(t *testing.T) {
+	cache := newTestCache(t)
+	name := "arn:aws:ssm:us-east-1:123456789012:document/Shared-Document"
+
+	_, found := cache.Get(name, "3", "checksum")
+	assert.False(t, found)
+
+	cache.Put(name, "3", "checksum", `{"schemaVersion": "2.2"}`)
+	content, found := cache.Get(name, "3", "checksum")
+	assert.True(t, found)
+	assert.Equal(t, `{"schemaVersion": "2.2"}`, content)
+
+	_, found = cache.Get(name, "4", "checksum")
+	assert.False(t, found, "versions are cached independently")
+}
+
+func TestGetMissesRecreatedDocument(t *testing.T) {
+	cache := newTestCache(t)
+	cache.Put("Document", "1", "checksum", "deleted document")
+
+	// the document was deleted and created again, its version restarts at 1 and the association checksum changes
+	_, found := cache.Get("Document", "1", "new checksum")
+
+	assert.False(t, found)
+}
+
+func TestPutIgnoresUnpinnedVersionAndMissingChecksum(t *testing.T) {
+	cache := newTestCache(t)
+
+	cache.Put("AWS-RunShellScript", "$LATEST", "checksum", "content")
+	cache.Put("AWS-RunShellScript", "1", "", "content")
+
+	files, _ := ioutil.ReadDir(cache.directory)
+	assert.Empty(t, files)
+}
+
+func TestGetDiscardsTamperedEntry(t *testing.T) {
+	cache := newTestCache(t)
+	cache.Put("Document", "1", "checksum", `{"mainSteps": []}`)
+	path := cache.entryPath("Document", "1", "checksum")
+	data, _ := ioutil.ReadFile(path)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(strings.Replace(string(data), "mainSteps", "otherSteps", 1)), 0600))
+
+	_, found := cache.Get("Document", "1", "checksum")
+
+	assert.False(t, found)
+	assert.NoFileExists(t, path)
+}
+
+func TestPutRemovesExpiredEntries(t *testing.T) {
+	cache := newTestCache(t)
+	cache.Put("Old", "1", "checksum", "old content")
+	expired := time.Now().Add(-retention - time.Hour)
+	assert.Nil(t, os.Chtimes(cache.entryPath("Old", "1", "checksum"), expired, expired))
+
+	cache.Put("New", "1", "checksum", "new content")
+
+	assert.NoFileExists(t, cache.entryPath("Old", "1", "checksum"))
+	assert.FileExists(t, cache.entryPath("New", "1", "checksum"))
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package executionlock persists the named locks held by the association runs that must not overlap, the locks
+// survive agent restarts so that a run resumed after a reboot still holds its lock.
+package executionlock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// Lock describes the holder of a named lock
+type Lock struct {
+	Name          string
+	AssociationID string
+	AcquiredAt    time.Time
+}
+
+// Locker acquires and releases the named locks
+type Locker struct {
+	log       log.T
+	directory string
+	// maxAge is the time after which a lock is considered abandoned and can be taken over
+	maxAge time.Duration
+}
+
+// NewLocker returns the locker of the instance, locks held for longer than maxAge are taken over
+func NewLocker(context context.T, maxAge time.Duration) (*Locker, error) {
+	instanceID, err := context.Identity().InstanceID()
+	if err != nil {
+		return nil, err
+	}
+	return New(context.Log(), filepath.Join(appconfig.DefaultDataStorePath, instanceID, appconfig.AssociationLocksRootDirName), maxAge), nil
+}
+
+// New returns the locker storing its locks in directory
+func New(log log.T, directory string, maxAge time.Duration) *Locker {
+	return &Locker{
+		log:       log,
+		directory: directory,
+		maxAge:    maxAge,
+	}
+}
+
+// lockPath returns the path of the file of a lock, lock names are chosen by users so the file is named after
+// the hash of the name
+func (l *Locker) lockPath(name string) string {
+	key := sha256.Sum256([]byte(name))
+	return filepath.Join(l.directory, hex.EncodeToString(key[:])+".json")
+}
+
+// TryAcquire takes the named lock for the association. When the lock is held by another run, acquired is false
+// and holder describes the current holder. An association already holding the lock acquires it again.
+func (l *Locker) TryAcquire(name string, associationID string) (acquired bool, holder Lock, err error) {
+	if err = fileutil.MakeDirs(l.directory); err != nil {
+		return false, holder, err
+	}
+	path := l.lockPath(name)
+	lock := Lock{Name: name, AssociationID: associationID, AcquiredAt: time.Now().UTC()}
+	data, _ := json.Marshal(lock)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		// the lock file is created exclusively so that a single run can acquire the lock
+		var file *os.File
+		if file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, appconfig.ReadWriteAccess); err == nil {
+			_, err = file.Write(data)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = fileutil.DeleteFile(path)
+				return false, holder, err
+			}
+			return true, lock, nil
+		}
+		if !os.IsExist(err) {
+			return false, holder, err
+		}
+
+		var found bool
+		if holder, found = l.read(path); found && holder.AssociationID == associationID {
+			return true, holder, nil
+		}
+		// a lock file that cannot be read yet may still be being written, its age is given by the file
+		acquiredAt := holder.AcquiredAt
+		if info, statErr := os.Stat(path); !found && statErr == nil {
+			acquiredAt = info.ModTime()
+		}
+		if time.Since(acquiredAt) < l.maxAge {
+			return false, holder, nil
+		}
+
+		// the lock is unreadable or abandoned, it is removed and acquired again
+		l.log.Warnf("Taking over execution lock %v acquired by association %v at %v", name, holder.AssociationID, holder.AcquiredAt)
+		if err = fileutil.DeleteFile(path); err != nil && !os.IsNotExist(err) {
+			return false, holder, err
+		}
+	}
+	return false, holder, nil
+}
+
+// ReleaseHeldBy releases all the locks held by the association
+func (l *Locker) ReleaseHeldBy(associationID string) {
+	files, err := ioutil.ReadDir(l.directory)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		path := filepath.Join(l.directory, file.Name())
+		if lock, found := l.read(path); found && lock.AssociationID == associationID {
+			l.log.Debugf("Releasing execution lock %v held by association %v", lock.Name, associationID)
+			if err = fileutil.DeleteFile(path); err != nil {
+				l.log.Warnf("Unable to release execution lock %v: %v", lock.Name, err)
+			}
+		}
+	}
+}
+
+// read returns the lock stored in the file, found is false if the file cannot be read
+func (l *Locker) read(path string) (lock Lock, found bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return lock, false
+	}
+	if err = json.Unmarshal(data, &lock); err != nil {
+		return lock, false
+	}
+	return lock, true
+}
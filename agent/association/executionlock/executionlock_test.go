@@ -0,0 +1,104 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package executionlock
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLocker(t *testing.T) *Locker {
+	directory, err := ioutil.TempDir("", "executionlock")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(directory) })
+	return New(log.NewMockLog(), directory, time.Hour)
+}
+
+func TestTryAcquire(t *testing.T) {
+	locker := newTestLocker(t)
+
+	acquired, holder, err := locker.TryAcquire("patching", "association-1")
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "association-1", holder.AssociationID)
+
+	// the lock is held until released
+	acquired, holder, err = locker.TryAcquire("patching", "association-2")
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Equal(t, "association-1", holder.AssociationID)
+	assert.Equal(t, "patching", holder.Name)
+
+	// the holder acquires its lock again and other locks are independent
+	acquired, _, _ = locker.TryAcquire("patching", "association-1")
+	assert.True(t, acquired)
+	acquired, _, _ = locker.TryAcquire("inventory", "association-2")
+	assert.True(t, acquired)
+
+	locker.ReleaseHeldBy("association-1")
+	acquired, holder, err = locker.TryAcquire("patching", "association-2")
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "association-2", holder.AssociationID)
+}
+
+func TestTryAcquireTakesOverAbandonedLock(t *testing.T) {
+	locker := newTestLocker(t)
+	assert.NoError(t, os.MkdirAll(locker.directory, 0700))
+
+	abandoned, _ := json.Marshal(Lock{Name: "patching", AssociationID: "association-1", AcquiredAt: time.Now().Add(-2 * time.Hour)})
+	assert.NoError(t, ioutil.WriteFile(locker.lockPath("patching"), abandoned, 0600))
+
+	acquired, holder, err := locker.TryAcquire("patching", "association-2")
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "association-2", holder.AssociationID)
+}
+
+func TestTryAcquireWithUnreadableLock(t *testing.T) {
+	locker := newTestLocker(t)
+	assert.NoError(t, os.MkdirAll(locker.directory, 0700))
+	path := locker.lockPath("patching")
+
+	// a recent lock file that cannot be read yet is still held
+	assert.NoError(t, ioutil.WriteFile(path, []byte(""), 0600))
+	acquired, _, err := locker.TryAcquire("patching", "association-2")
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	old := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(path, old, old))
+	acquired, _, err = locker.TryAcquire("patching", "association-2")
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestLocksArePersisted(t *testing.T) {
+	locker := newTestLocker(t)
+	acquired, _, _ := locker.TryAcquire("patching", "association-1")
+	assert.True(t, acquired)
+
+	// a locker created after an agent restart sees the held lock
+	restarted := New(log.NewMockLog(), locker.directory, time.Hour)
+	acquired, holder, err := restarted.TryAcquire("patching", "association-2")
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Equal(t, "association-1", holder.AssociationID)
+}
@@ -35,12 +35,21 @@ type InstanceAssociation struct {
 	ParsedExpression  scheduleexpression.ScheduleExpression
 	Document          *string
 	Errors            []error
+	// ScheduleTimeZone is the time zone the cron expression is evaluated in, UTC when empty
+	ScheduleTimeZone string
 }
 
 // ParseExpression parses the expression with the given association
 func (newAssoc *InstanceAssociation) ParseExpression(log log.T) error {
 
-	parsedScheduleExpression, err := scheduleexpression.CreateScheduleExpression(log, *newAssoc.Association.ScheduleExpression)
+	var location *time.Location
+	if newAssoc.ScheduleTimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(newAssoc.ScheduleTimeZone); err != nil {
+			return fmt.Errorf("Failed to load time zone %v of schedule expression %v, %v", newAssoc.ScheduleTimeZone, *newAssoc.Association.ScheduleExpression, err)
+		}
+	}
+	parsedScheduleExpression, err := scheduleexpression.CreateScheduleExpressionInLocation(log, *newAssoc.Association.ScheduleExpression, location)
 
 	if err != nil {
 		return fmt.Errorf("Failed to parse schedule expression %v, %v", *newAssoc.Association.ScheduleExpression, err)
@@ -384,3 +384,46 @@ func TestNextScheduledDateIsNilWhenAssociationExpressionTypeIsUnknown(t *testing
 	// Assert
 	assert.Nil(t, assocRawData.NextScheduledDate)
 }
+
+func TestNextScheduledDateIsEvaluatedInTheScheduleTimeZone(t *testing.T) {
+
+	// Assemble
+	logger := logger.DefaultLogger()
+
+	assocRawData := InstanceAssociation{ScheduleTimeZone: "Asia/Tokyo"}
+	assocRawData.Association = &ssm.InstanceAssociationSummary{}
+	testAssociationName := "Test"
+	assocRawData.Association.Name = &testAssociationName
+	assocId := "b2f71a28-cbe1-4429-b848-26c7e1f5ad0d"
+	assocRawData.Association.AssociationId = &assocId
+	testCronExpression := "cron(0 0 9 ? * * *)" // every day at 9:00 in Tokyo
+	assocRawData.Association.ScheduleExpression = &testCronExpression
+
+	lastExecutionDateTime := time.Date(2009, 11, 17, 20, 34, 58, 0, time.UTC)
+	assocRawData.Association.LastExecutionDate = &lastExecutionDateTime
+
+	// Act
+	assocRawData.SetNextScheduledDate(logger)
+
+	// Assert
+	expectedNextScheduledDateTime := time.Date(2009, 11, 18, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, expectedNextScheduledDateTime, *assocRawData.NextScheduledDate)
+}
+
+func TestParseExpressionReturnsErrorWhenScheduleTimeZoneIsUnknown(t *testing.T) {
+
+	// Assemble
+	logger := logger.DefaultLogger()
+
+	assocRawData := InstanceAssociation{ScheduleTimeZone: "Mars/Olympus_Mons"}
+	assocRawData.Association = &ssm.InstanceAssociationSummary{}
+	testCronExpression := "cron(0 0 9 ? * * *)"
+	assocRawData.Association.ScheduleExpression = &testCronExpression
+
+	// Act
+	err := assocRawData.ParseExpression(logger)
+
+	// Assert
+	assert.NotNil(t, err)
+	assert.Nil(t, assocRawData.ParsedExpression)
+}
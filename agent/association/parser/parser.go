@@ -82,7 +82,7 @@ func InitializeDocumentState(context context.T,
 	s3KeyPrefix := path.Join(payload.OutputS3KeyPrefix, documentInfo.InstanceID, documentInfo.AssociationID, documentInfo.RunID)
 
 	orchestrationRootDir := filepath.Join(
-		appconfig.DefaultDataStorePath,
+		appconfig.OrchestrationStorePath,
 		documentInfo.InstanceID,
 		appconfig.DefaultDocumentRootDirName,
 		context.AppConfig().Agent.OrchestrationRootDir)
@@ -122,6 +122,7 @@ func newDocumentInfo(rawData *model.InstanceAssociation, payload *messageContrac
 	documentInfo.DocumentName = payload.DocumentName
 	documentInfo.DocumentVersion = *(rawData.Association.DocumentVersion)
 	documentInfo.DocumentStatus = contracts.ResultStatusInProgress
+	documentInfo.CorrelationID = contracts.NewCorrelationID()
 
 	return *documentInfo
 }
@@ -0,0 +1,162 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// bootAssociation is an association due at boot with its resolved ordering hints
+type bootAssociation struct {
+	assoc     *model.InstanceAssociation
+	priority  int
+	dependsOn []string
+}
+
+// bootScheduler orders the associations due at boot by their declared dependencies and priorities.
+// Associations without pending dependencies are applied in parallel up to the workers limit.
+type bootScheduler struct {
+	mutex        sync.Mutex
+	workersLimit int
+	createDate   time.Time
+	pending      []*bootAssociation
+	running      map[string]struct{}
+}
+
+// newBootScheduler creates a bootScheduler for the associations due at the given time
+func newBootScheduler(log log.T, assocs []*model.InstanceAssociation, hints []appconfig.BootAssociationHint, workersLimit int, now time.Time) *bootScheduler {
+	scheduler := &bootScheduler{
+		workersLimit: workersLimit,
+		createDate:   now,
+		running:      make(map[string]struct{}),
+	}
+
+	var due []*model.InstanceAssociation
+	for _, assoc := range assocs {
+		if assoc.NextScheduledDate != nil && !assoc.NextScheduledDate.After(now) {
+			due = append(due, assoc)
+		}
+	}
+
+	for _, assoc := range due {
+		bootAssoc := &bootAssociation{assoc: assoc}
+		for _, hint := range hints {
+			if !matchesHint(assoc, hint.Association) {
+				continue
+			}
+			bootAssoc.priority = hint.Priority
+			for _, dependency := range hint.DependsOn {
+				// dependencies not due at boot do not delay the association
+				for _, other := range due {
+					if other != assoc && matchesHint(other, dependency) {
+						bootAssoc.dependsOn = append(bootAssoc.dependsOn, *other.Association.AssociationId)
+					}
+				}
+			}
+			break
+		}
+		scheduler.pending = append(scheduler.pending, bootAssoc)
+	}
+
+	// keep the list order for associations with the same priority
+	sort.SliceStable(scheduler.pending, func(i, j int) bool {
+		return scheduler.pending[i].priority > scheduler.pending[j].priority
+	})
+
+	log.Infof("Boot scheduler created for %v associations with workers limit %v", len(scheduler.pending), workersLimit)
+	return scheduler
+}
+
+// matchesHint returns true if the hint refers to the association by its association id or document name
+func matchesHint(assoc *model.InstanceAssociation, hint string) bool {
+	return (assoc.Association.AssociationId != nil && *assoc.Association.AssociationId == hint) ||
+		(assoc.Association.Name != nil && *assoc.Association.Name == hint)
+}
+
+// next returns the associations to apply now and marks them as running.
+// If dependencies can never be satisfied because of a cycle, the pending association with the highest priority
+// is applied regardless of its dependencies.
+func (s *bootScheduler) next(log log.T) []*model.InstanceAssociation {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var ready []*model.InstanceAssociation
+	var remaining []*bootAssociation
+	for _, bootAssoc := range s.pending {
+		if len(s.running) < s.workersLimit && s.isReady(bootAssoc) {
+			s.running[*bootAssoc.assoc.Association.AssociationId] = struct{}{}
+			ready = append(ready, bootAssoc.assoc)
+			continue
+		}
+		remaining = append(remaining, bootAssoc)
+	}
+	s.pending = remaining
+
+	if len(ready) == 0 && len(s.running) == 0 && len(s.pending) > 0 {
+		bootAssoc := s.pending[0]
+		log.Warnf("Dependencies of association %v cannot be satisfied, applying it", *bootAssoc.assoc.Association.AssociationId)
+		s.running[*bootAssoc.assoc.Association.AssociationId] = struct{}{}
+		ready = append(ready, bootAssoc.assoc)
+		s.pending = s.pending[1:]
+	}
+
+	return ready
+}
+
+// isReady returns true if none of the dependencies of the association are pending or running
+func (s *bootScheduler) isReady(bootAssoc *bootAssociation) bool {
+	for _, dependency := range bootAssoc.dependsOn {
+		if _, running := s.running[dependency]; running {
+			return false
+		}
+		for _, pending := range s.pending {
+			if *pending.assoc.Association.AssociationId == dependency {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// complete marks the association as completed, it returns false if the association is not applied by the scheduler
+func (s *bootScheduler) complete(associationID string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, running := s.running[associationID]; !running {
+		return false
+	}
+	delete(s.running, associationID)
+	return true
+}
+
+// isDone returns true once all the associations due at boot are completed
+func (s *bootScheduler) isDone() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.pending) == 0 && len(s.running) == 0
+}
+
+// isExpired returns true if the associations due at boot are not completed within the document timeout,
+// so associations stuck at InProgress do not block the associations scheduled later
+func (s *bootScheduler) isExpired(now time.Time) bool {
+	return s.createDate.Add(documentLevelTimeOutDurationHour * time.Hour).Before(now)
+}
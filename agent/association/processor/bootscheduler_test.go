@@ -0,0 +1,113 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+var bootTime = time.Date(2022, 6, 1, 8, 0, 0, 0, time.UTC)
+
+func createBootAssociation(associationID string, documentName string, nextScheduledDate time.Time) *model.InstanceAssociation {
+	return &model.InstanceAssociation{
+		NextScheduledDate: aws.Time(nextScheduledDate),
+		Association: &ssm.InstanceAssociationSummary{
+			AssociationId: aws.String(associationID),
+			Name:          aws.String(documentName),
+		},
+	}
+}
+
+func associationIDs(assocs []*model.InstanceAssociation) []string {
+	ids := []string{}
+	for _, assoc := range assocs {
+		ids = append(ids, *assoc.Association.AssociationId)
+	}
+	return ids
+}
+
+func TestBootSchedulerRunsIndependentAssociationsInParallel(t *testing.T) {
+	assocs := []*model.InstanceAssociation{
+		createBootAssociation("a", "AWS-GatherSoftwareInventory", bootTime),
+		createBootAssociation("b", "AWS-UpdateSSMAgent", bootTime),
+		createBootAssociation("c", "AWS-RunPatchBaseline", bootTime),
+		createBootAssociation("later", "Custom-Document", bootTime.Add(time.Hour)),
+	}
+	scheduler := newBootScheduler(log.NewMockLog(), assocs, nil, 2, bootTime)
+
+	assert.Equal(t, []string{"a", "b"}, associationIDs(scheduler.next(log.NewMockLog())))
+	assert.Empty(t, scheduler.next(log.NewMockLog()))
+
+	assert.True(t, scheduler.complete("b"))
+	assert.Equal(t, []string{"c"}, associationIDs(scheduler.next(log.NewMockLog())))
+
+	assert.True(t, scheduler.complete("a"))
+	assert.True(t, scheduler.complete("c"))
+	assert.False(t, scheduler.complete("later"))
+	assert.True(t, scheduler.isDone())
+}
+
+func TestBootSchedulerOrdersByDependenciesAndPriorities(t *testing.T) {
+	assocs := []*model.InstanceAssociation{
+		createBootAssociation("inventory", "AWS-GatherSoftwareInventory", bootTime),
+		createBootAssociation("patch", "AWS-RunPatchBaseline", bootTime),
+		createBootAssociation("agent", "AWS-UpdateSSMAgent", bootTime),
+	}
+	hints := []appconfig.BootAssociationHint{
+		{Association: "AWS-RunPatchBaseline", DependsOn: []string{"agent"}},
+		{Association: "agent", Priority: 10},
+		{Association: "inventory", DependsOn: []string{"AWS-RunPatchBaseline", "NotDueAtBoot"}},
+	}
+	scheduler := newBootScheduler(log.NewMockLog(), assocs, hints, 3, bootTime)
+
+	assert.Equal(t, []string{"agent"}, associationIDs(scheduler.next(log.NewMockLog())))
+	scheduler.complete("agent")
+	assert.Equal(t, []string{"patch"}, associationIDs(scheduler.next(log.NewMockLog())))
+	scheduler.complete("patch")
+	assert.Equal(t, []string{"inventory"}, associationIDs(scheduler.next(log.NewMockLog())))
+	scheduler.complete("inventory")
+	assert.True(t, scheduler.isDone())
+}
+
+func TestBootSchedulerBreaksDependencyCycles(t *testing.T) {
+	assocs := []*model.InstanceAssociation{
+		createBootAssociation("a", "DocumentA", bootTime),
+		createBootAssociation("b", "DocumentB", bootTime),
+	}
+	hints := []appconfig.BootAssociationHint{
+		{Association: "a", DependsOn: []string{"b"}},
+		{Association: "b", DependsOn: []string{"a"}, Priority: 1},
+	}
+	scheduler := newBootScheduler(log.NewMockLog(), assocs, hints, 2, bootTime)
+
+	assert.Equal(t, []string{"b"}, associationIDs(scheduler.next(log.NewMockLog())))
+	scheduler.complete("b")
+	assert.Equal(t, []string{"a"}, associationIDs(scheduler.next(log.NewMockLog())))
+}
+
+func TestBootSchedulerIsExpired(t *testing.T) {
+	scheduler := newBootScheduler(log.NewMockLog(), nil, nil, 1, bootTime)
+
+	assert.True(t, scheduler.isDone())
+	assert.False(t, scheduler.isExpired(bootTime.Add(time.Hour)))
+	assert.True(t, scheduler.isExpired(bootTime.Add(documentLevelTimeOutDurationHour*time.Hour+time.Second)))
+}
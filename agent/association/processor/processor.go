@@ -25,6 +25,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/association/cache"
 	complianceUploader "github.com/aws/amazon-ssm-agent/agent/association/compliance/uploader"
+	"github.com/aws/amazon-ssm-agent/agent/association/executionlock"
 	"github.com/aws/amazon-ssm-agent/agent/association/frequentcollector"
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
@@ -60,6 +61,12 @@ type Processor struct {
 	proc               processor.Processor
 	resChan            chan contracts.DocumentResult
 	onBoot             bool
+	bootScheduler      *bootScheduler
+	bootProc           processor.Processor
+	bootSchedulerLock  sync.Mutex
+	locker             *executionlock.Locker
+	queuedAssociations map[string]struct{}
+	singletonLock      sync.Mutex
 }
 
 var lock sync.RWMutex
@@ -83,9 +90,8 @@ func NewAssociationProcessor(context context.T) *Processor {
 	uploader := complianceUploader.NewComplianceUploader(context)
 
 	//TODO Rename everything to service and move package to framework
-	startWorker := processor.NewWorkerProcessorSpec(assocContext, documentWorkersLimit, contracts.Association, 0)
-	terminateWorker := processor.NewWorkerProcessorSpec(assocContext, documentWorkersLimit, "", 0) //association has no cancel worker
-	proc := processor.NewEngineProcessor(assocContext, startWorker, terminateWorker)
+	// associations are applied one at a time, the associations due at boot are applied in parallel by a separate processor
+	proc := newAssociationEngineProcessor(assocContext, documentWorkersLimit)
 	return &Processor{
 		context:            assocContext,
 		assocSvc:           assocSvc,
@@ -96,6 +102,13 @@ func NewAssociationProcessor(context context.T) *Processor {
 	}
 }
 
+// newAssociationEngineProcessor creates a processor applying up to workersLimit associations at a time
+var newAssociationEngineProcessor = func(context context.T, workersLimit int) processor.Processor {
+	startWorker := processor.NewWorkerProcessorSpec(context, workersLimit, contracts.Association, 0)
+	terminateWorker := processor.NewWorkerProcessorSpec(context, documentWorkersLimit, "", 0) //association has no cancel worker
+	return processor.NewEngineProcessor(context, startWorker, terminateWorker)
+}
+
 func (p *Processor) ModuleExecute() {
 	log := p.context.Log()
 	associationFrequenceMinutes := p.context.AppConfig().Ssm.AssociationFrequencyMinutes
@@ -115,6 +128,7 @@ func (p *Processor) ModuleExecute() {
 func (p *Processor) ModuleStop() (err error) {
 	assocScheduler.Stop(p.pollJob)
 	signal.Stop()
+	p.releaseBootProcessor()
 	p.proc.Stop()
 	return nil
 }
@@ -130,7 +144,7 @@ func (p *Processor) InitializeAssociationProcessor() {
 	}
 
 	log.Info("Launching response handler")
-	go p.listenToResponses()
+	go p.listenToResponses(p.resChan)
 
 	if err := p.proc.InitialProcessing(false); err != nil {
 		log.Errorf("initial processing in EngineProcessor encountered error: %v", err)
@@ -168,6 +182,7 @@ func (p *Processor) ProcessAssociation() {
 		return
 	}
 
+	isBoot := p.onBoot
 	// to account for any tag expansion delays on boot, call list associations again
 	if p.onBoot {
 		p.onBoot = false
@@ -224,6 +239,7 @@ func (p *Processor) ProcessAssociation() {
 		}
 
 		if !assoc.IsRunOnceAssociation() {
+			assoc.ScheduleTimeZone = findTimeZone(assoc, p.context.AppConfig().Ssm.AssociationTimeZones)
 			if err = assoc.ParseExpression(log); err != nil {
 				message := fmt.Sprintf("Encountered error while parsing expression for association %v", *assoc.Association.AssociationId)
 				log.Errorf("%v, %v", message, err)
@@ -253,6 +269,10 @@ func (p *Processor) ProcessAssociation() {
 
 	schedulemanager.Refresh(log, associations)
 
+	if isBoot {
+		p.startBootScheduler(log)
+	}
+
 	log.Debug("ProcessAssociation is triggering execution")
 
 	signal.ExecuteAssociation(log)
@@ -274,6 +294,10 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 		}
 	}()
 
+	if p.runBootAssociations(log) {
+		return
+	}
+
 	var (
 		scheduledAssociation *model.InstanceAssociation
 		err                  error
@@ -298,10 +322,20 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 	// stop previous wait timer if there is scheduled association
 	signal.StopWaitTimerForNextScheduledAssociation()
 
+	if p.isAssociationInProgress(log, scheduledAssociation) {
+		return
+	}
+
+	p.submitAssociation(log, p.proc, scheduledAssociation)
+}
+
+// isAssociationInProgress returns true if the association is still being applied.
+// Associations stuck at InProgress are reported as failed.
+func (p *Processor) isAssociationInProgress(log log.T, scheduledAssociation *model.InstanceAssociation) bool {
 	if schedulemanager.IsAssociationInProgress(*scheduledAssociation.Association.AssociationId) {
 		log.Debug("runScheduledAssociation is InProgress")
 		if isAssociationTimedOut(scheduledAssociation) {
-			err = fmt.Errorf("Association stuck at InProgress for longer than %v hours", documentLevelTimeOutDurationHour)
+			err := fmt.Errorf("Association stuck at InProgress for longer than %v hours", documentLevelTimeOutDurationHour)
 			log.Error(err)
 			p.assocSvc.UpdateInstanceAssociationStatus(
 				log,
@@ -323,7 +357,16 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 
 		}
 
-		return
+		return true
+	}
+
+	return false
+}
+
+// submitAssociation submits the association to the given processor, it returns false if the association cannot be parsed
+func (p *Processor) submitAssociation(log log.T, proc processor.Processor, scheduledAssociation *model.InstanceAssociation) bool {
+	if !p.acquireExecutionLock(log, scheduledAssociation) {
+		return false
 	}
 
 	log.Debugf("Update association %v to pending ", *scheduledAssociation.Association.AssociationId)
@@ -339,8 +382,8 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 		contracts.AssociationPendingMessage,
 		service.NoOutputUrl)
 
-	var docState *contracts.DocumentState
-	if docState, err = p.parseAssociation(scheduledAssociation); err != nil {
+	docState, err := p.parseAssociation(scheduledAssociation)
+	if err != nil {
 		err = fmt.Errorf("Encountered error while parsing association %v, %v",
 			docState.DocumentInformation.AssociationID,
 			err)
@@ -362,7 +405,8 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 			*scheduledAssociation.Association.DocumentVersion,
 			contracts.AssociationStatusFailed,
 			time.Now().UTC())
-		return
+		p.releaseExecutionLocks(log, *scheduledAssociation.Association.AssociationId)
+		return false
 	}
 
 	updatePluginAssociationInstances(*scheduledAssociation.Association.AssociationId, docState)
@@ -381,7 +425,7 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 
 	log.Debug("runScheduledAssociation submitting document")
 
-	p.proc.Submit(*docState)
+	proc.Submit(*docState)
 
 	log.Debug("runScheduledAssociation submitted document")
 
@@ -394,6 +438,103 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 			frequentCollector.StartFrequentCollector(p.context, docState, scheduledAssociation)
 		}
 	}
+	return true
+}
+
+// startBootScheduler starts ordering the associations due at boot when parallel apply or ordering hints are configured.
+// Parallel apply uses a boot processor sized by the boot workers limit, which is released once the boot associations
+// are applied, so the associations scheduled later are still applied one at a time.
+func (p *Processor) startBootScheduler(log log.T) {
+	appConfig := p.context.AppConfig()
+	if appConfig.Ssm.BootAssociationWorkersLimit <= documentWorkersLimit && len(appConfig.Ssm.BootAssociationHints) == 0 {
+		return
+	}
+
+	p.bootSchedulerLock.Lock()
+	defer p.bootSchedulerLock.Unlock()
+	if appConfig.Ssm.BootAssociationWorkersLimit > documentWorkersLimit {
+		bootProc := newAssociationEngineProcessor(p.context, appConfig.Ssm.BootAssociationWorkersLimit)
+		resChan, err := bootProc.Start()
+		if err != nil {
+			log.Errorf("starting boot EngineProcessor encountered error: %v, applying associations one at a time", err)
+			return
+		}
+		go p.listenToResponses(resChan)
+		p.bootProc = bootProc
+	}
+	p.bootScheduler = newBootScheduler(
+		log,
+		schedulemanager.Schedules(),
+		appConfig.Ssm.BootAssociationHints,
+		appConfig.Ssm.BootAssociationWorkersLimit,
+		time.Now().UTC())
+}
+
+// runBootAssociations submits the associations released by the boot scheduler.
+// It returns false when no boot scheduler is running, so the scheduled associations are applied one at a time.
+// This operation is locked by runScheduledAssociation
+func (p *Processor) runBootAssociations(log log.T) bool {
+	p.bootSchedulerLock.Lock()
+	defer p.bootSchedulerLock.Unlock()
+
+	if p.bootScheduler == nil {
+		return false
+	}
+	if p.bootScheduler.isDone() {
+		log.Info("Associations due at boot are applied")
+		p.bootScheduler = nil
+		go p.releaseBootProcessor()
+		return false
+	}
+	if p.bootScheduler.isExpired(time.Now().UTC()) {
+		log.Warnf("Associations due at boot are not applied after %v hours, applying associations one at a time", documentLevelTimeOutDurationHour)
+		p.bootScheduler = nil
+		go p.releaseBootProcessor()
+		return false
+	}
+
+	signal.StopWaitTimerForNextScheduledAssociation()
+	resubmit := false
+	for _, assoc := range p.bootScheduler.next(log) {
+		// associations resumed by the processor on boot are completed by their document result
+		if p.isAssociationInProgress(log, assoc) {
+			continue
+		}
+		proc := p.proc
+		if p.bootProc != nil {
+			proc = p.bootProc
+		}
+		if !p.submitAssociation(log, proc, assoc) {
+			p.bootScheduler.complete(*assoc.Association.AssociationId)
+			resubmit = true
+		}
+	}
+	if resubmit {
+		signal.ExecuteAssociation(log)
+	}
+	return true
+}
+
+// releaseBootProcessor stops the boot processor, associations it still applies are cancelled
+func (p *Processor) releaseBootProcessor() {
+	p.bootSchedulerLock.Lock()
+	bootProc := p.bootProc
+	p.bootProc = nil
+	p.bootSchedulerLock.Unlock()
+
+	if bootProc != nil {
+		bootProc.Stop()
+	}
+}
+
+// completeBootAssociation notifies the boot scheduler that the association completed
+func (p *Processor) completeBootAssociation(associationID string) {
+	p.bootSchedulerLock.Lock()
+	defer p.bootSchedulerLock.Unlock()
+
+	if p.bootScheduler != nil {
+		p.bootScheduler.complete(associationID)
+	}
 }
 
 func isAssociationTimedOut(assoc *model.InstanceAssociation) bool {
@@ -527,7 +668,7 @@ func (r *Processor) associationExecutionReport(
 		time.Now().UTC())
 }
 
-func (r *Processor) listenToResponses() {
+func (r *Processor) listenToResponses(resChan chan contracts.DocumentResult) {
 	log := r.context.Log()
 	defer func() {
 		if r := recover(); r != nil {
@@ -535,7 +676,11 @@ func (r *Processor) listenToResponses() {
 			log.Errorf("Stacktrace:\n%s", debug.Stack())
 		}
 	}()
-	for res := range r.resChan {
+	for res := range resChan {
+		// associations do not stream their output
+		if res.OutputFrame != nil {
+			continue
+		}
 		if res.LastPlugin != "" {
 			log.Infof("update association status upon plugin $v completion", res.LastPlugin)
 			r.pluginExecutionReport(log, res.AssociationID, res.LastPlugin, res.PluginResults, res.NPlugins)
@@ -595,6 +740,8 @@ func (r *Processor) listenToResponses() {
 				r.context.AppConfig().Ssm.AssociationLogsRetentionDurationHours)
 			//TODO move this part to service
 			schedulemanager.UpdateNextScheduledDate(log, res.AssociationID)
+			r.releaseExecutionLocks(log, res.AssociationID)
+			r.completeBootAssociation(res.AssociationID)
 			signal.ExecuteAssociation(log)
 		}
 	}
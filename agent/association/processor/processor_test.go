@@ -19,12 +19,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	processor2 "github.com/aws/amazon-ssm-agent/agent/association/mocks/processor"
 	"github.com/aws/amazon-ssm-agent/agent/association/mocks/service"
 	complianceUploader "github.com/aws/amazon-ssm-agent/agent/association/mocks/uploader"
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
+	contextpkg "github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	processorpkg "github.com/aws/amazon-ssm-agent/agent/framework/processor"
 	processormock "github.com/aws/amazon-ssm-agent/agent/framework/processor/mock"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
@@ -224,6 +227,62 @@ func TestProcessAssociationSuccessful(t *testing.T) {
 	assert.True(t, complianceUploader.AssertNumberOfCalls(t, "UpdateAssociationCompliance", 0))
 }
 
+func TestBootProcessorReleasedOnceBootAssociationsAreApplied(t *testing.T) {
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.BootAssociationWorkersLimit = 3
+	processor := &Processor{context: context.NewMockDefaultWithConfig(config)}
+	processorMock := &processormock.MockedProcessor{}
+	processor.proc = processorMock
+
+	bootProcessorMock := &processormock.MockedProcessor{}
+	ch := make(chan contracts.DocumentResult)
+	bootProcessorMock.On("Start").Return(ch, nil)
+	stopped := make(chan struct{})
+	bootProcessorMock.On("Stop").Run(func(mock.Arguments) { close(stopped) }).Return()
+	realNewAssociationEngineProcessor := newAssociationEngineProcessor
+	defer func() { newAssociationEngineProcessor = realNewAssociationEngineProcessor }()
+	var workersLimits []int
+	newAssociationEngineProcessor = func(_ contextpkg.T, workersLimit int) processorpkg.Processor {
+		workersLimits = append(workersLimits, workersLimit)
+		return bootProcessorMock
+	}
+
+	processor.startBootScheduler(log.NewMockLog())
+	assert.Equal(t, []int{3}, workersLimits)
+	assert.Equal(t, bootProcessorMock, processor.bootProc)
+
+	// no association is due at boot, so the boot processor is released and scheduled associations use the processor
+	assert.False(t, processor.runBootAssociations(log.NewMockLog()))
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		assert.Fail(t, "boot processor was not stopped")
+	}
+	close(ch)
+	assert.Nil(t, processor.bootScheduler)
+	assert.Nil(t, processor.bootProc)
+	bootProcessorMock.AssertExpectations(t)
+	processorMock.AssertNotCalled(t, "Stop")
+}
+
+func TestBootProcessorNotCreatedForOrderingHintsOnly(t *testing.T) {
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.BootAssociationWorkersLimit = 1
+	config.Ssm.BootAssociationHints = []appconfig.BootAssociationHint{{Association: "Test-Association", Priority: 1}}
+	processor := &Processor{context: context.NewMockDefaultWithConfig(config)}
+	realNewAssociationEngineProcessor := newAssociationEngineProcessor
+	defer func() { newAssociationEngineProcessor = realNewAssociationEngineProcessor }()
+	newAssociationEngineProcessor = func(contextpkg.T, int) processorpkg.Processor {
+		assert.Fail(t, "boot processor must not be created")
+		return nil
+	}
+
+	processor.startBootScheduler(log.NewMockLog())
+
+	assert.NotNil(t, processor.bootScheduler)
+	assert.Nil(t, processor.bootProc)
+}
+
 // make sure this operation is thread safe
 func TestUpdatePluginAssociationInstances(t *testing.T) {
 	testAssociationID := "testAssociationID"
@@ -123,6 +123,7 @@ func (p *Processor) refreshAssociation(log log.T, associationIds []string, orche
 		// validate association expression, fail association if expression cannot be passed
 		// Note: we do not want to fail runcommand with out.MarkAsFailed
 		if !assoc.IsRunOnceAssociation() {
+			assoc.ScheduleTimeZone = findTimeZone(assoc, p.context.AppConfig().Ssm.AssociationTimeZones)
 			if err := assoc.ParseExpression(log); err != nil {
 				message := fmt.Sprintf("Encountered error while parsing expression for association %v", *assoc.Association.AssociationId)
 				log.Errorf("%v, %v", message, err)
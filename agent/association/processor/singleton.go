@@ -0,0 +1,165 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/executionlock"
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
+	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager/signal"
+	"github.com/aws/amazon-ssm-agent/agent/association/service"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/times"
+)
+
+const (
+	singletonSkippedMessage = "Association run skipped, execution lock %v is held by association %v since %v"
+	singletonQueuedMessage  = "Association run queued, execution lock %v is held by association %v since %v"
+)
+
+var newExecutionLocker = executionlock.NewLocker
+
+// findSingleton returns the singleton declaration of the association, if any
+func findSingleton(assoc *model.InstanceAssociation, singletons []appconfig.SingletonAssociation) (appconfig.SingletonAssociation, bool) {
+	for _, singleton := range singletons {
+		if matchesHint(assoc, singleton.Association) {
+			return singleton, true
+		}
+	}
+	return appconfig.SingletonAssociation{}, false
+}
+
+// executionLocker returns the locker of the execution locks, it is created on first use
+func (p *Processor) executionLocker() (*executionlock.Locker, error) {
+	p.singletonLock.Lock()
+	defer p.singletonLock.Unlock()
+
+	if p.locker == nil {
+		// a lock held for longer than the document timeout belongs to an association stuck at InProgress
+		locker, err := newExecutionLocker(p.context, documentLevelTimeOutDurationHour*time.Hour)
+		if err != nil {
+			return nil, err
+		}
+		p.locker = locker
+	}
+	return p.locker, nil
+}
+
+// acquireExecutionLock returns true if the association can run now. Singleton associations take their execution
+// lock, when it is held by a previous run the new run is skipped or queued according to the overlap policy and the
+// decision is reported in the association status.
+func (p *Processor) acquireExecutionLock(log log.T, assoc *model.InstanceAssociation) bool {
+	singleton, found := findSingleton(assoc, p.context.AppConfig().Ssm.SingletonAssociations)
+	if !found {
+		return true
+	}
+	associationID := *assoc.Association.AssociationId
+	mutex := singleton.Mutex
+	if mutex == "" {
+		mutex = associationID
+	}
+
+	locker, err := p.executionLocker()
+	if err != nil {
+		log.Warnf("Unable to load the execution locks, running association %v without lock: %v", associationID, err)
+		return true
+	}
+	acquired, holder, err := locker.TryAcquire(mutex, associationID)
+	if err != nil {
+		log.Warnf("Unable to acquire execution lock %v, running association %v without lock: %v", mutex, associationID, err)
+		return true
+	}
+	if acquired {
+		log.Debugf("Association %v acquired execution lock %v", associationID, mutex)
+		p.setQueued(associationID, false)
+		return true
+	}
+
+	acquiredAt := times.ToIso8601UTC(holder.AcquiredAt)
+	if singleton.OverlapPolicy == appconfig.SingletonOverlapPolicyQueue {
+		// the queued run is reported once, it is submitted when the holder of the lock completes
+		if p.setQueued(associationID, true) {
+			log.Infof(singletonQueuedMessage, mutex, holder.AssociationID, acquiredAt)
+			p.assocSvc.UpdateInstanceAssociationStatus(
+				log,
+				associationID,
+				*assoc.Association.Name,
+				*assoc.Association.InstanceId,
+				contracts.AssociationStatusPending,
+				contracts.AssociationErrorCodeNoError,
+				times.ToIso8601UTC(time.Now()),
+				fmt.Sprintf(singletonQueuedMessage, mutex, holder.AssociationID, acquiredAt),
+				service.NoOutputUrl)
+		}
+		return false
+	}
+
+	message := fmt.Sprintf(singletonSkippedMessage, mutex, holder.AssociationID, acquiredAt)
+	log.Info(message)
+	p.assocSvc.UpdateInstanceAssociationStatus(
+		log,
+		associationID,
+		*assoc.Association.Name,
+		*assoc.Association.InstanceId,
+		string(contracts.ResultStatusSkipped),
+		contracts.AssociationErrorCodeNoError,
+		times.ToIso8601UTC(time.Now()),
+		message,
+		service.NoOutputUrl)
+	p.complianceUploader.UpdateAssociationCompliance(
+		associationID,
+		*assoc.Association.InstanceId,
+		*assoc.Association.Name,
+		*assoc.Association.DocumentVersion,
+		string(contracts.ResultStatusSkipped),
+		time.Now().UTC())
+	schedulemanager.UpdateNextScheduledDate(log, associationID)
+	signal.ExecuteAssociation(log)
+	return false
+}
+
+// releaseExecutionLocks releases the execution locks held by the association once its run completed
+func (p *Processor) releaseExecutionLocks(log log.T, associationID string) {
+	if len(p.context.AppConfig().Ssm.SingletonAssociations) == 0 {
+		return
+	}
+	locker, err := p.executionLocker()
+	if err != nil {
+		log.Warnf("Unable to load the execution locks to release: %v", err)
+		return
+	}
+	locker.ReleaseHeldBy(associationID)
+}
+
+// setQueued records whether a run of the association is queued, it returns true if the state changed
+func (p *Processor) setQueued(associationID string, queued bool) bool {
+	p.singletonLock.Lock()
+	defer p.singletonLock.Unlock()
+
+	if p.queuedAssociations == nil {
+		p.queuedAssociations = make(map[string]struct{})
+	}
+	_, wasQueued := p.queuedAssociations[associationID]
+	if queued {
+		p.queuedAssociations[associationID] = struct{}{}
+	} else {
+		delete(p.queuedAssociations, associationID)
+	}
+	return wasQueued != queued
+}
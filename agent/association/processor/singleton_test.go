@@ -0,0 +1,127 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/executionlock"
+	"github.com/aws/amazon-ssm-agent/agent/association/mocks/service"
+	complianceUploader "github.com/aws/amazon-ssm-agent/agent/association/mocks/uploader"
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func createSingletonProcessor(t *testing.T, singletons []appconfig.SingletonAssociation) (*Processor, *service.AssociationServiceMock, *complianceUploader.ComplianceUploaderMock) {
+	directory, err := ioutil.TempDir("", "associationlocks")
+	assert.NoError(t, err)
+	origNewExecutionLocker := newExecutionLocker
+	t.Cleanup(func() {
+		newExecutionLocker = origNewExecutionLocker
+		_ = os.RemoveAll(directory)
+	})
+	newExecutionLocker = func(context context.T, maxAge time.Duration) (*executionlock.Locker, error) {
+		return executionlock.New(context.Log(), directory, maxAge), nil
+	}
+
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.SingletonAssociations = singletons
+	svcMock := service.NewMockDefault()
+	svcMock.On(
+		"UpdateInstanceAssociationStatus",
+		mock.Anything,
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("*ssm.InstanceAssociationExecutionResult"))
+	uploaderMock := complianceUploader.NewMockDefault()
+	uploaderMock.On(
+		"UpdateAssociationCompliance",
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("time.Time")).Return(nil)
+
+	processor := &Processor{
+		context:            contextmocks.NewMockDefaultWithConfig(config),
+		assocSvc:           svcMock,
+		complianceUploader: uploaderMock,
+	}
+	return processor, svcMock, uploaderMock
+}
+
+func createSingletonAssociation(associationID string, documentName string) *model.InstanceAssociation {
+	assoc := createBootAssociation(associationID, documentName, time.Now().UTC())
+	assoc.Association.InstanceId = assoc.Association.AssociationId
+	assoc.Association.DocumentVersion = assoc.Association.AssociationId
+	return assoc
+}
+
+func TestAcquireExecutionLockWithoutSingleton(t *testing.T) {
+	processor, svcMock, _ := createSingletonProcessor(t, nil)
+
+	assert.True(t, processor.acquireExecutionLock(log.NewMockLog(), createSingletonAssociation("assoc-1", "AWS-RunPatchBaseline")))
+	assert.True(t, processor.acquireExecutionLock(log.NewMockLog(), createSingletonAssociation("assoc-1", "AWS-RunPatchBaseline")))
+	svcMock.AssertNotCalled(t, "UpdateInstanceAssociationStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAcquireExecutionLockSkipsOverlappingRun(t *testing.T) {
+	processor, svcMock, uploaderMock := createSingletonProcessor(t, []appconfig.SingletonAssociation{
+		{Association: "AWS-RunPatchBaseline", Mutex: "maintenance", OverlapPolicy: appconfig.SingletonOverlapPolicySkip},
+		{Association: "assoc-2", Mutex: "maintenance", OverlapPolicy: appconfig.SingletonOverlapPolicySkip},
+	})
+	first := createSingletonAssociation("assoc-1", "AWS-RunPatchBaseline")
+	second := createSingletonAssociation("assoc-2", "AWS-ApplyChefRecipes")
+
+	assert.True(t, processor.acquireExecutionLock(log.NewMockLog(), first))
+	assert.False(t, processor.acquireExecutionLock(log.NewMockLog(), second))
+	svcMock.AssertNumberOfCalls(t, "UpdateInstanceAssociationStatus", 1)
+	uploaderMock.AssertNumberOfCalls(t, "UpdateAssociationCompliance", 1)
+
+	// the lock is released once the run of the holder completes
+	processor.releaseExecutionLocks(log.NewMockLog(), "assoc-1")
+	assert.True(t, processor.acquireExecutionLock(log.NewMockLog(), second))
+	svcMock.AssertNumberOfCalls(t, "UpdateInstanceAssociationStatus", 1)
+}
+
+func TestAcquireExecutionLockQueuesOverlappingRun(t *testing.T) {
+	processor, svcMock, uploaderMock := createSingletonProcessor(t, []appconfig.SingletonAssociation{
+		{Association: "AWS-RunPatchBaseline", Mutex: "maintenance", OverlapPolicy: appconfig.SingletonOverlapPolicyQueue},
+		{Association: "AWS-ApplyChefRecipes", Mutex: "maintenance", OverlapPolicy: appconfig.SingletonOverlapPolicyQueue},
+	})
+	first := createSingletonAssociation("assoc-1", "AWS-RunPatchBaseline")
+	second := createSingletonAssociation("assoc-2", "AWS-ApplyChefRecipes")
+
+	assert.True(t, processor.acquireExecutionLock(log.NewMockLog(), first))
+	assert.False(t, processor.acquireExecutionLock(log.NewMockLog(), second))
+	assert.False(t, processor.acquireExecutionLock(log.NewMockLog(), second))
+
+	// the queued run is reported once and is not recorded as skipped
+	svcMock.AssertNumberOfCalls(t, "UpdateInstanceAssociationStatus", 1)
+	uploaderMock.AssertNotCalled(t, "UpdateAssociationCompliance", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	processor.releaseExecutionLocks(log.NewMockLog(), "assoc-1")
+	assert.True(t, processor.acquireExecutionLock(log.NewMockLog(), second))
+	assert.Empty(t, processor.queuedAssociations)
+}
@@ -0,0 +1,37 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+)
+
+// allAssociations is the association of the time zone applying to the associations that no other entry names
+const allAssociations = "*"
+
+// findTimeZone returns the time zone the schedule of the association is evaluated in, an entry naming the
+// association takes precedence over the entry applying to all the associations
+func findTimeZone(assoc *model.InstanceAssociation, timeZones []appconfig.AssociationTimeZone) string {
+	var defaultTimeZone string
+	for _, timeZone := range timeZones {
+		if matchesHint(assoc, timeZone.Association) {
+			return timeZone.TimeZone
+		}
+		if timeZone.Association == allAssociations && defaultTimeZone == "" {
+			defaultTimeZone = timeZone.TimeZone
+		}
+	}
+	return defaultTimeZone
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindTimeZone(t *testing.T) {
+	patching := &model.InstanceAssociation{Association: &ssm.InstanceAssociationSummary{
+		AssociationId: aws.String("patching-id"),
+		Name:          aws.String("AWS-RunPatchBaseline"),
+	}}
+	inventory := &model.InstanceAssociation{Association: &ssm.InstanceAssociationSummary{
+		AssociationId: aws.String("inventory-id"),
+		Name:          aws.String("AWS-GatherSoftwareInventory"),
+	}}
+
+	assert.Empty(t, findTimeZone(patching, nil))
+
+	timeZones := []appconfig.AssociationTimeZone{
+		{Association: "*", TimeZone: "Europe/Paris"},
+		{Association: "AWS-RunPatchBaseline", TimeZone: "America/New_York"},
+	}
+	assert.Equal(t, "America/New_York", findTimeZone(patching, timeZones))
+	assert.Equal(t, "Europe/Paris", findTimeZone(inventory, timeZones))
+
+	timeZones = []appconfig.AssociationTimeZone{{Association: "inventory-id", TimeZone: "Asia/Tokyo"}}
+	assert.Equal(t, "Asia/Tokyo", findTimeZone(inventory, timeZones))
+	assert.Empty(t, findTimeZone(patching, timeZones))
+}
@@ -15,14 +15,13 @@
 package recorder
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
-	"path"
 	"sync"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
-	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/statestore"
 )
 
 // AssociatedDocumentName represents file recording the name of the last associated document
@@ -35,18 +34,24 @@ type AssociatedDocument struct {
 
 var lock sync.RWMutex
 
+// folder returns the folder of the association state of the instance in the configured state backend
+var folder = func(instanceID string) statestore.Folder {
+	config, _ := appconfig.Config(false)
+	return statestore.NewFolder(config, instanceID, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfAssociation)
+}
+
 // HasExecuted returns if given document has been executed
 func HasExecuted(instanceID string, associationName string) bool {
 	lock.Lock()
 	defer lock.Unlock()
 
-	fileName := getFileName(instanceID)
-	if !fileutil.Exists(fileName) {
+	content, err := folder(instanceID).Read(AssociatedDocumentName)
+	if err != nil {
 		return false
 	}
 
 	var assoDoc AssociatedDocument
-	if err := jsonutil.UnmarshalFile(fileName, &assoDoc); err != nil {
+	if err := json.Unmarshal(content, &assoDoc); err != nil {
 		return false
 	}
 
@@ -60,45 +65,15 @@ func UpdateAssociatedDocument(InstanceID string, associationName string) error {
 	var err error
 	var content string
 
-	fileName := getFileName(InstanceID)
-	location := getLocation(InstanceID)
-
-	//verify if parent folder exist
-	if !fileutil.Exists(location) {
-		if err = fileutil.MakeDirs(location); err != nil {
-			return fmt.Errorf("cannot make directory of %v because: %v", location, err)
-		}
-	}
-
 	associatedDoc := AssociatedDocument{}
 	associatedDoc.AssociationID = associationName
 	if content, err = jsonutil.Marshal(associatedDoc); err != nil {
 		return err
 	}
 
-	//it's fine even if we overwrite the content of previous file
-	if _, err = fileutil.WriteIntoFileWithPermissions(
-		fileName,
-		content,
-		os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
-		return err
+	//it's fine even if we overwrite the content of previous state
+	if err = folder(InstanceID).Write(AssociatedDocumentName, []byte(content)); err != nil {
+		return fmt.Errorf("cannot record the associated document of %v because: %v", InstanceID, err)
 	}
 	return nil
 }
-
-// getLocation returns the full path for recording last associated document.
-func getLocation(instanceID string) string {
-	return path.Join(appconfig.DefaultDataStorePath,
-		instanceID,
-		appconfig.DefaultDocumentRootDirName,
-		appconfig.DefaultLocationOfAssociation)
-}
-
-// getFileName returns the full file name of the last associated document.
-func getFileName(instanceID string) string {
-	return path.Join(appconfig.DefaultDataStorePath,
-		instanceID,
-		appconfig.DefaultDocumentRootDirName,
-		appconfig.DefaultLocationOfAssociation,
-		AssociatedDocumentName)
-}
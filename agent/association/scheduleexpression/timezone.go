@@ -0,0 +1,77 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduleexpression
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// maxZonedIterations bounds the search of a run time that exists in the time zone and is after the given time
+const maxZonedIterations = 4
+
+// zonedCronExpression evaluates a cron expression on the wall clock of a time zone
+type zonedCronExpression struct {
+	expression ScheduleExpression
+	location   *time.Location
+}
+
+// CreateScheduleExpressionInLocation creates a schedule expression whose cron expression is evaluated on the wall
+// clock of the given time zone. Rate expressions do not depend on the time zone and are returned as is.
+func CreateScheduleExpressionInLocation(log log.T, scheduleExpression string, location *time.Location) (ScheduleExpression, error) {
+	expression, err := CreateScheduleExpression(log, scheduleExpression)
+	if err != nil || location == nil || !strings.HasPrefix(strings.ToLower(scheduleExpression), expressionTypeCron) {
+		return expression, err
+	}
+	return &zonedCronExpression{expression: expression, location: location}, nil
+}
+
+// Next returns the first run time after fromTime. The cron expression is evaluated on wall clock times without
+// daylight saving transitions so that:
+// - a run scheduled in the hour skipped when the clocks go forward happens that much later, e.g. 2:30 runs at 3:30
+// - a run scheduled in the hour repeated when the clocks go back happens only once, at the first occurrence
+func (z *zonedCronExpression) Next(fromTime time.Time) time.Time {
+	from := wallClock(fromTime.In(z.location))
+	for i := 0; i < maxZonedIterations; i++ {
+		next := z.expression.Next(from)
+		if next.IsZero() {
+			return next
+		}
+		runTime := inLocation(next, z.location)
+		if runTime.After(fromTime) {
+			return runTime
+		}
+		from = next
+	}
+	return time.Time{}
+}
+
+// wallClock returns the UTC time showing the same wall clock as the given time
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// inLocation returns the time showing the given wall clock in the location. A wall clock skipped by a daylight
+// saving transition is moved forward by the length of the transition.
+func inLocation(wall time.Time, location *time.Location) time.Time {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), location)
+	if wallClock(t).Equal(wall) {
+		return t
+	}
+	// the skipped wall clock read with the offset in effect before the transition
+	_, offsetBefore := wall.Add(-12 * time.Hour).In(location).Zone()
+	return wall.Add(-time.Duration(offsetBefore) * time.Second).In(location)
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduleexpression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func newYork(t *testing.T) *time.Location {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	return location
+}
+
+func TestZonedCronExpression_EvaluatesWallClock(t *testing.T) {
+	location := newYork(t)
+	expression, err := CreateScheduleExpressionInLocation(logger.DefaultLogger(), "cron(0 0 2 ? * * *)", location)
+	assert.NoError(t, err)
+
+	next := expression.Next(time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2022, 6, 2, 6, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestZonedCronExpression_SkippedHourRunsAfterTheTransition(t *testing.T) {
+	location := newYork(t)
+	expression, err := CreateScheduleExpressionInLocation(logger.DefaultLogger(), "cron(0 30 2 ? * * *)", location)
+	assert.NoError(t, err)
+
+	// the clocks go from 2:00 EST to 3:00 EDT on March 13, 2:30 runs at 3:30 EDT
+	next := expression.Next(time.Date(2022, 3, 12, 8, 0, 0, 0, location))
+	assert.Equal(t, time.Date(2022, 3, 13, 3, 30, 0, 0, location), next)
+	assert.Equal(t, time.Date(2022, 3, 14, 2, 30, 0, 0, location), expression.Next(next))
+}
+
+func TestZonedCronExpression_RepeatedHourRunsOnce(t *testing.T) {
+	location := newYork(t)
+	expression, err := CreateScheduleExpressionInLocation(logger.DefaultLogger(), "cron(0 30 1 ? * * *)", location)
+	assert.NoError(t, err)
+
+	// the clocks go from 2:00 EDT back to 1:00 EST on November 6, 1:30 runs at 1:30 EDT only
+	first := expression.Next(time.Date(2022, 11, 6, 0, 0, 0, 0, location))
+	assert.Equal(t, time.Date(2022, 11, 6, 5, 30, 0, 0, time.UTC), first.UTC())
+	second := expression.Next(first)
+	assert.Equal(t, time.Date(2022, 11, 7, 1, 30, 0, 0, location), second)
+
+	// from the repeated hour the next run is the next day as well
+	assert.Equal(t, second, expression.Next(time.Date(2022, 11, 6, 6, 10, 0, 0, time.UTC)))
+}
+
+func TestZonedCronExpression_RateAndUTCExpressionsUnchanged(t *testing.T) {
+	location := newYork(t)
+	expression, err := CreateScheduleExpressionInLocation(logger.DefaultLogger(), "rate(30 minutes)", location)
+	assert.NoError(t, err)
+	_, zoned := expression.(*zonedCronExpression)
+	assert.False(t, zoned)
+
+	expression, err = CreateScheduleExpressionInLocation(logger.DefaultLogger(), "cron(0 0 2 ? * * *)", nil)
+	assert.NoError(t, err)
+	_, zoned = expression.(*zonedCronExpression)
+	assert.False(t, zoned)
+
+	_, err = CreateScheduleExpressionInLocation(logger.DefaultLogger(), "cron(foo)", location)
+	assert.Error(t, err)
+}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/association/cache"
+	"github.com/aws/amazon-ssm-agent/agent/association/documentcache"
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -290,6 +291,9 @@ func (s *AssociationService) IsInstanceAssociationApiMode() bool {
 	return currentAssociationApiMode == instanceAssociationMode
 }
 
+// newDocumentCache opens the document cache of the instance
+var newDocumentCache = documentcache.NewDocumentCache
+
 // LoadAssociationDetail loads document contents and parameters for the given association
 func (s *AssociationService) LoadAssociationDetail(log log.T, assoc *model.InstanceAssociation) error {
 	associationCache := cache.GetCache()
@@ -308,14 +312,30 @@ func (s *AssociationService) LoadAssociationDetail(log log.T, assoc *model.Insta
 		err              error
 	)
 
+	// the content of pinned document versions never changes, it is read from the document cache when available
+	documentName, documentVersion := *assoc.Association.Name, *assoc.Association.DocumentVersion
+	checksum := aws.StringValue(assoc.Association.Checksum)
+	var documentCache *documentcache.DocumentCache
+	if documentcache.IsPinnedVersion(documentVersion) {
+		if documentCache, err = newDocumentCache(s.context); err != nil {
+			log.Debugf("unable to open the document cache, %v", err)
+		} else if content, found := documentCache.Get(documentName, documentVersion, checksum); found {
+			assoc.Document = &content
+			return associationCache.Add(*associationID, assoc)
+		}
+	}
+
 	// TODO: add a retry here
 	// Call getDocument and retrieve the document json string
-	if documentResponse, err = s.ssmSvc.GetDocument(log, *assoc.Association.Name, *assoc.Association.DocumentVersion); err != nil {
+	if documentResponse, err = s.ssmSvc.GetDocument(log, documentName, documentVersion); err != nil {
 		log.Errorf("unable to retrieve document, %v", err)
 		return err
 	}
 
 	assoc.Document = documentResponse.Content
+	if documentCache != nil && documentResponse.Content != nil {
+		documentCache.Put(documentName, documentVersion, checksum, *documentResponse.Content)
+	}
 
 	if err = associationCache.Add(*associationID, assoc); err != nil {
 		return err
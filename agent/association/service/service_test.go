@@ -17,7 +17,9 @@ package service
 import (
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/association/documentcache"
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	agentContext "github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
@@ -100,6 +102,39 @@ func TestLoadAssociationDetails(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestLoadAssociationDetailsUsesDocumentCache(t *testing.T) {
+	cacheDirectory := t.TempDir()
+	newDocumentCache = func(agentContext.T) (*documentcache.DocumentCache, error) {
+		return documentcache.New(logMock, cacheDirectory), nil
+	}
+	defer func() { newDocumentCache = documentcache.NewDocumentCache }()
+	ssmMock := ssmSvc.NewMockDefault()
+	service := AssociationService{
+		ssmSvc:     ssmMock,
+		stopPolicy: &sdkutil.StopPolicy{},
+	}
+	documentContent := "pinned document content"
+	ssmMock.On("GetDocument", mock.AnythingOfType("*log.Mock"), "pinned", "2").Return(&ssm.GetDocumentOutput{Content: &documentContent}, nil)
+
+	// the document of the last association was created again under the same name, the checksum changed
+	for associationID, checksum := range map[string]string{"assoc-pinned-1": "checksum", "assoc-pinned-2": "checksum", "assoc-pinned-3": "new checksum"} {
+		assocRawData := model.InstanceAssociation{}
+		assocRawData.Association = &ssm.InstanceAssociationSummary{
+			Name:            aws.String("pinned"),
+			AssociationId:   aws.String(associationID),
+			InstanceId:      &instanceID,
+			DocumentVersion: aws.String("2"),
+			Checksum:        aws.String(checksum),
+		}
+
+		err := service.LoadAssociationDetail(logMock, &assocRawData)
+
+		assert.NoError(t, err)
+		assert.Equal(t, documentContent, *assocRawData.Document)
+	}
+	ssmMock.AssertNumberOfCalls(t, "GetDocument", 2)
+}
+
 func TestUpdateAssociationStatus(t *testing.T) {
 	service := AssociationService{
 		ssmSvc:     ssmMock,
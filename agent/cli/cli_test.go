@@ -68,3 +68,13 @@ func TestCliCmdExecSuccess(t *testing.T) {
 	assert.Equal(t, cliutil.CLI_SUCCESS_EXITCODE, exitCode, "command execution success return exit code 0")
 	cliCmdMock.AssertExpectations(t)
 }
+
+func TestParseCommandWithSubcommands(t *testing.T) {
+	args := []string{"ssm-cli", "toolbox", "Hash", "--path", "/tmp/file", "--algorithm", "md5"}
+	err, options, command, subcommands, parameters := parseCommand(args)
+	assert.NoError(t, err)
+	assert.Empty(t, options)
+	assert.Equal(t, "toolbox", command)
+	assert.Equal(t, []string{"hash"}, subcommands)
+	assert.Equal(t, map[string][]string{"path": {"/tmp/file"}, "algorithm": {"md5"}}, parameters)
+}
@@ -0,0 +1,193 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/cli/statebundle"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+)
+
+const (
+	exportStateCommand = "export-state"
+	stateOutputFlag    = "output"
+	stateExcludeFlag   = "exclude"
+)
+
+const exportStateCommandHelp = `NAME:
+    {{.ExportStateCommandName}}
+
+DESCRIPTION
+    Exports the identity and the state of the agent to a bundle that {{.ImportStateCommandName}} restores.
+    The bundle holds the registration of the instance (its id, region and private key),
+    its hardware fingerprint and the state of its associations.
+    Keep the bundles holding the registration secret, they grant the permissions of the instance.
+
+    To create a golden image, exclude the identity: the instances cloned from the image
+    register on their own and start from the association state of the bundle.
+    To restore an instance, include the identity.
+
+SYNOPSIS
+    {{.ExportStateCommandName}}
+    {{.OutputFlag}}
+    [{{.ExcludeFlag}}]
+
+PARAMETERS
+    {{.OutputFlag}} (string) Path of the bundle to write.
+
+    {{.ExcludeFlag}} (list) Categories of the state left out of the bundle:
+        identity (the registration and the fingerprint), registration, fingerprint or association.
+
+EXAMPLES
+    This example exports the association state for a golden image.
+
+    Command:
+
+      {{.SsmCliName}} {{.ExportStateCommandName}} {{.OutputFlag}} /tmp/ssm-state.tar.gz {{.ExcludeFlag}} identity
+
+    Output:
+
+      {
+        "FormatVersion": 1,
+        "AgentVersion": "3.1.0.0",
+        "CreatedAt": "2022-06-01T12:00:00Z",
+        "ShortInstanceID": "mi-1234567890abcdef0",
+        "Categories": [
+          "association"
+        ]
+      }
+
+OUTPUT
+    The manifest of the bundle in JSON format
+`
+
+type exportStateHelpParams struct {
+	SsmCliName             string
+	ExportStateCommandName string
+	ImportStateCommandName string
+	OutputFlag             string
+	ExcludeFlag            string
+}
+
+func init() {
+	cliutil.Register(&ExportStateCommand{})
+}
+
+type ExportStateCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the export-state cli command
+func (c *ExportStateCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, output, excluded := c.validateExportStateCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	// load the storage locations of the app config
+	if _, err := appconfig.Config(false); err != nil {
+		return err, ""
+	}
+
+	var shortInstanceID string
+	if !excluded[statebundle.CategoryAssociation] {
+		agentIdentity, err := cliutil.GetAgentIdentity()
+		if err != nil {
+			return err, ""
+		}
+		if shortInstanceID, err = agentIdentity.ShortInstanceID(); err != nil {
+			return err, ""
+		}
+	}
+
+	file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, appconfig.ReadWriteAccess)
+	if err != nil {
+		return err, ""
+	}
+	manifest, err := statebundle.Export(file, shortInstanceID, excluded)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(output)
+		return err, ""
+	}
+
+	result, err := jsonutil.Marshal(manifest)
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(result)
+}
+
+// Help prints help for the export-state cli command
+func (c *ExportStateCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("ExportStateCommandHelp").Parse(exportStateCommandHelp)
+		params := exportStateHelpParams{cliutil.SsmCliName, exportStateCommand, importStateCommand,
+			cliutil.FormatFlag(stateOutputFlag), cliutil.FormatFlag(stateExcludeFlag)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (ExportStateCommand) Name() string {
+	return exportStateCommand
+}
+
+// validateExportStateCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (ExportStateCommand) validateExportStateCommandInput(subcommands []string, parameters map[string][]string) (validation []string, output string, excluded map[string]bool) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", exportStateCommand, subcommands), "")
+		return validation, "", nil
+	}
+
+	// look for required parameters
+	if values, exists := parameters[stateOutputFlag]; !exists {
+		validation = append(validation, fmt.Sprintf("%v is required", cliutil.FormatFlag(stateOutputFlag)))
+	} else if len(values) != 1 {
+		validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(stateOutputFlag)))
+	} else {
+		output = values[0]
+	}
+
+	var err error
+	if excluded, err = statebundle.ParseCategories(parameters[stateExcludeFlag]); err != nil {
+		validation = append(validation, fmt.Sprintf("invalid value for parameter %v: %v", cliutil.FormatFlag(stateExcludeFlag), err))
+	} else if len(excluded) == len(statebundle.Categories) {
+		validation = append(validation, "all the state categories are excluded")
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != stateOutputFlag && key != stateExcludeFlag {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, output, excluded
+}
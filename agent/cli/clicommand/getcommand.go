@@ -25,6 +25,8 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
 )
 
 const (
@@ -96,7 +98,12 @@ func (c *GetOfflineCommand) Execute(subcommands []string, parameters map[string]
 		return err, ""
 	}
 
-	return c.getCommandStatus(instanceID, commandID, showDetails)
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return err, ""
+	}
+
+	return c.getCommandStatus(config, instanceID, commandID, showDetails)
 }
 
 // Help prints help for the get-offline-command-invocation cli command
@@ -155,19 +162,19 @@ func (GetOfflineCommand) validateGetCommandInput(subcommands []string, parameter
 }
 
 // getCommandStatus looks for the command in the local orchestration folders and returns status and optionally details
-func (c *GetOfflineCommand) getCommandStatus(instanceID, commandID string, showDetails bool) (error, string) {
+func (c *GetOfflineCommand) getCommandStatus(config appconfig.SsmagentConfig, instanceID, commandID string, showDetails bool) (error, string) {
 	// Look for file with commandID as name in each orchestration folder
 	// If found, return status (or lots of details if showDetails is set)
 	if c.isCommandCompleted(commandID) {
 		return nil, "Complete"
 	}
-	if c.isCommandInState(instanceID, appconfig.DefaultLocationOfPending, commandID) {
+	if c.isCommandInState(config, instanceID, appconfig.DefaultLocationOfPending, commandID) {
 		return nil, "Pending"
 	}
-	if c.isCommandInState(instanceID, appconfig.DefaultLocationOfCurrent, commandID) {
+	if c.isCommandInState(config, instanceID, appconfig.DefaultLocationOfCurrent, commandID) {
 		return nil, "In Progress"
 	}
-	if c.isCommandInState(instanceID, appconfig.DefaultLocationOfCorrupt, commandID) {
+	if c.isCommandInState(config, instanceID, appconfig.DefaultLocationOfCorrupt, commandID) {
 		return nil, "Corrupt"
 	}
 
@@ -179,11 +186,7 @@ func (c *GetOfflineCommand) isCommandCompleted(commandID string) bool {
 	return fileutil.Exists(path.Join(appconfig.LocalCommandRootCompleted, commandID))
 }
 
-func (GetOfflineCommand) isCommandInState(instanceID, stateFolder, commandID string) bool {
-	return fileutil.Exists(path.Join(appconfig.DefaultDataStorePath,
-		instanceID,
-		appconfig.DefaultDocumentRootDirName,
-		appconfig.DefaultLocationOfState,
-		stateFolder,
-		commandID))
+// isCommandInState returns true if the document state of the command is in the state folder, in the configured state backend
+func (GetOfflineCommand) isCommandInState(config appconfig.SsmagentConfig, instanceID, stateFolder, commandID string) bool {
+	return docmanager.DocumentStateExists(logger.NewSilentLogger(), config, instanceID, stateFolder, commandID)
 }
@@ -0,0 +1,153 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+)
+
+const (
+	getCommandTransportCommand = "get-command-transport"
+)
+
+const getCommandTransportCommandHelp = `NAME:
+    {{.GetCommandTransportCommandName}}
+DESCRIPTION
+    Returns the transport the agent currently receives the Run Command documents from and why it was selected.
+    The agent uses the first healthy transport of the CommandTransportOrder agent configuration and falls back
+    to the next transports while the preferred ones are unhealthy.
+SYNOPSIS
+    {{.GetCommandTransportCommandName}}
+EXAMPLES
+    Command:
+
+      {{.SsmCliName}} {{.GetCommandTransportCommandName}}
+
+    Output:
+      {
+        "active-transport": "MDS",
+        "order": [
+          "MGS",
+          "MDS"
+        ],
+        "reason": "fallback because MGS is unhealthy: the control channel is not connected",
+        "transports": [
+          {
+            "Name": "MGS",
+            "Healthy": false,
+            "Reason": "the control channel is not connected"
+          },
+          {
+            "Name": "MDS",
+            "Healthy": true
+          }
+        ],
+        "updated-at": "2022-06-01T12:00:00Z"
+      }
+
+OUTPUT
+    Command transport information in JSON format
+`
+
+type getCommandTransportHelpParams struct {
+	SsmCliName                     string
+	GetCommandTransportCommandName string
+}
+
+func init() {
+	cliutil.Register(&GetCommandTransportCommand{})
+}
+
+type GetCommandTransportCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-command-transport cli command
+func (c *GetCommandTransportCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateGetCommandTransportCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	runtimeConfigClient := runtimeconfig.NewCommandTransportRuntimeConfigClient()
+	if exists, err := runtimeConfigClient.ConfigExists(); err != nil {
+		return err, ""
+	} else if !exists {
+		return errors.New("the agent has not selected a command transport yet"), ""
+	}
+
+	config, err := runtimeConfigClient.GetConfig()
+	if err != nil {
+		return err, ""
+	}
+
+	information := map[string]interface{}{
+		"order":            config.Order,
+		"active-transport": config.ActiveTransport,
+		"reason":           config.Reason,
+		"transports":       config.Transports,
+	}
+	if !config.UpdatedAt.IsZero() {
+		information["updated-at"] = config.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+
+	result, err := jsonutil.Marshal(information)
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(result)
+}
+
+// Help prints help for the get-command-transport cli command
+func (c *GetCommandTransportCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetCommandTransportCommandHelp").Parse(getCommandTransportCommandHelp)
+		params := getCommandTransportHelpParams{cliutil.SsmCliName, getCommandTransportCommand}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetCommandTransportCommand) Name() string {
+	return getCommandTransportCommand
+}
+
+// validateGetCommandTransportCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (GetCommandTransportCommand) validateGetCommandTransportCommandInput(subcommands []string, parameters map[string][]string) []string {
+	validation := make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getCommandTransportCommand, subcommands), "")
+		return validation
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+	}
+	return validation
+}
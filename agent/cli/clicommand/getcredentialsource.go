@@ -0,0 +1,142 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+)
+
+const (
+	getCredentialSourceCommand = "get-credential-source"
+)
+
+const getCredentialSourceCommandHelp = `NAME:
+    {{.GetCredentialSourceCommandName}}
+DESCRIPTION
+    Returns the source of the credentials currently used by the agent and why it was selected.
+    On EC2 instances the agent uses the instance profile role when it can connect to Systems Manager
+    and falls back to Default Host Management Configuration credentials otherwise.
+SYNOPSIS
+    {{.GetCredentialSourceCommandName}}
+EXAMPLES
+    Command:
+
+      {{.SsmCliName}} {{.GetCredentialSourceCommandName}}
+
+    Output:
+      {
+        "credential-source": "Systems Manager",
+        "credentials-expire-at": "2022-06-01T13:00:00Z",
+        "credentials-retrieved-at": "2022-06-01T12:00:00Z",
+        "identity-type": "EC2",
+        "reason": "using Default Host Management Configuration credentials, no instance profile role is attached to the instance"
+      }
+
+OUTPUT
+    Credential source information in JSON format
+`
+
+type getCredentialSourceHelpParams struct {
+	SsmCliName                     string
+	GetCredentialSourceCommandName string
+}
+
+func init() {
+	cliutil.Register(&GetCredentialSourceCommand{})
+}
+
+type GetCredentialSourceCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-credential-source cli command
+func (c *GetCredentialSourceCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateGetCredentialSourceCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	runtimeConfigClient := runtimeconfig.NewIdentityRuntimeConfigClient()
+	if exists, err := runtimeConfigClient.ConfigExists(); err != nil {
+		return err, ""
+	} else if !exists {
+		return errors.New("the agent has not retrieved credentials yet"), ""
+	}
+
+	config, err := runtimeConfigClient.GetConfig()
+	if err != nil {
+		return err, ""
+	}
+
+	information := map[string]string{
+		"identity-type":     config.IdentityType,
+		"credential-source": config.CredentialSource,
+		"reason":            config.CredentialSourceReason,
+	}
+	if !config.CredentialsRetrievedAt.IsZero() {
+		information["credentials-retrieved-at"] = config.CredentialsRetrievedAt.UTC().Format(time.RFC3339)
+	}
+	if !config.CredentialsExpiresAt.IsZero() {
+		information["credentials-expire-at"] = config.CredentialsExpiresAt.UTC().Format(time.RFC3339)
+	}
+
+	result, err := jsonutil.Marshal(information)
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(result)
+}
+
+// Help prints help for the get-credential-source cli command
+func (c *GetCredentialSourceCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetCredentialSourceCommandHelp").Parse(getCredentialSourceCommandHelp)
+		params := getCredentialSourceHelpParams{cliutil.SsmCliName, getCredentialSourceCommand}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetCredentialSourceCommand) Name() string {
+	return getCredentialSourceCommand
+}
+
+// validateGetCredentialSourceCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (GetCredentialSourceCommand) validateGetCredentialSourceCommandInput(subcommands []string, parameters map[string][]string) []string {
+	validation := make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getCredentialSourceCommand, subcommands), "")
+		return validation
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+	}
+	return validation
+}
@@ -105,6 +105,11 @@ EXAMPLES
                     "Status": "Success",
                     "Note": "monitoring.us-east-1.amazonaws.com is reachable"
                 },
+                {
+                    "Check": "Host firewall",
+                    "Status": "Success",
+                    "Note": "Outbound port 443 is open to ssm.us-east-1.amazonaws.com, ec2messages.us-east-1.amazonaws.com, ssmmessages.us-east-1.amazonaws.com"
+                },
                 {
                     "Check": "AWS Credentials",
                     "Status": "Success",
@@ -162,6 +167,10 @@ EXAMPLES
         ├───────────────────────────────────────┼─────────┼─────────────────────────────────────────────────────────────────────┤
         │ Connectivity to monitoring endpoint   │ Success │ monitoring.us-east-1.amazonaws.com is reachable                     │
         ├───────────────────────────────────────┼─────────┼─────────────────────────────────────────────────────────────────────┤
+        │ Host firewall                         │ Success │ Outbound port 443 is open to ssm.us-east-1.amazonaws.com,           │
+        │                                       │         │ ec2messages.us-east-1.amazonaws.com,                                │
+        │                                       │         │ ssmmessages.us-east-1.amazonaws.com                                 │
+        ├───────────────────────────────────────┼─────────┼─────────────────────────────────────────────────────────────────────┤
         │ AWS Credentials                       │ Success │ Credentials are for                                                 │
         │                                       │         │ arn:aws:sts::123456789012:assumed-role/SSM-Role/i-0123456789abcdefa │
         │                                       │         │ and will expire at 2021-09-02 13:24:42 +0000 UTC                    │
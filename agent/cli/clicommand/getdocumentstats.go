@@ -0,0 +1,154 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/messagemetrics"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+)
+
+const (
+	getDocumentStatsCommand = "get-document-stats"
+	documentStatsNameFlag   = "document"
+)
+
+const getDocumentStatsCommandHelp = `NAME:
+    {{.GetDocumentStatsCommandName}}
+
+DESCRIPTION
+    Lists the runs, failures and mean duration of the documents executed on this instance during the last
+    7 days, the documents with the most failures first. The statistics are kept by the agent across restarts
+    and are also served on the message metrics socket when Agent.MessageMetricsSocketPath is configured.
+
+SYNOPSIS
+    {{.GetDocumentStatsCommandName}}
+    [{{.DocumentFlag}}]
+
+PARAMETERS
+    {{.DocumentFlag}} (string) Lists only the statistics of this document.
+
+EXAMPLES
+    Command:
+
+      {{.SsmCliName}} {{.GetDocumentStatsCommandName}}
+
+    Output:
+
+      [
+        {
+          "documentName": "AWS-RunPatchBaseline",
+          "runs": 14,
+          "failures": 12,
+          "failureRate": 0.8571428571428571,
+          "meanDurationMillis": 95120,
+          "lastStatus": "Failed",
+          "lastRun": "2022-06-02T08:35:00Z",
+          "lastCommandId": "1f2e3d4c-5b6a-7988-8776-655443322110"
+        }
+      ]
+
+OUTPUT
+    The document statistics in JSON format
+`
+
+type getDocumentStatsHelpParams struct {
+	SsmCliName                  string
+	GetDocumentStatsCommandName string
+	DocumentFlag                string
+}
+
+func init() {
+	cliutil.Register(&GetDocumentStatsCommand{})
+}
+
+type GetDocumentStatsCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-document-stats cli command
+func (c *GetDocumentStatsCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, documentName := c.validateGetDocumentStatsCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	stats, err := messagemetrics.ReadDocumentStats()
+	if err != nil {
+		return err, ""
+	}
+
+	result := make([]messagemetrics.DocumentStats, 0, len(stats))
+	for _, document := range stats {
+		if documentName == "" || strings.EqualFold(document.DocumentName, documentName) {
+			result = append(result, document)
+		}
+	}
+
+	output, err := jsonutil.Marshal(result)
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(output)
+}
+
+// Help prints help for the get-document-stats cli command
+func (c *GetDocumentStatsCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetDocumentStatsCommandHelp").Parse(getDocumentStatsCommandHelp)
+		params := getDocumentStatsHelpParams{cliutil.SsmCliName, getDocumentStatsCommand, cliutil.FormatFlag(documentStatsNameFlag)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetDocumentStatsCommand) Name() string {
+	return getDocumentStatsCommand
+}
+
+// validateGetDocumentStatsCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (GetDocumentStatsCommand) validateGetDocumentStatsCommandInput(subcommands []string, parameters map[string][]string) (validation []string, documentName string) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getDocumentStatsCommand, subcommands), "")
+		return validation, ""
+	}
+
+	if values, exists := parameters[documentStatsNameFlag]; exists {
+		if len(values) != 1 || values[0] == "" {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(documentStatsNameFlag)))
+		} else {
+			documentName = values[0]
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != documentStatsNameFlag {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, documentName
+}
@@ -0,0 +1,205 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+)
+
+const (
+	getErrorFingerprintsCommand = "get-error-fingerprints"
+	fingerprintModuleFlag       = "module"
+	fingerprintClassFlag        = "class"
+)
+
+const getErrorFingerprintsCommandHelp = `NAME:
+    {{.GetErrorFingerprintsCommandName}}
+
+DESCRIPTION
+    Lists the categorized fingerprints of the errors logged by the agent, read from the audit logs kept on
+    this instance, the most frequent first. A fingerprint identifies the module logging the error, the class
+    of the error and a hash of its message, the messages themselves are not recorded.
+    The fingerprints have to be enabled with Agent.ErrorFingerprints.Enabled in the agent configuration.
+
+SYNOPSIS
+    {{.GetErrorFingerprintsCommandName}}
+    [{{.ModuleFlag}}]
+    [{{.ClassFlag}}]
+
+PARAMETERS
+    {{.ModuleFlag}} (string) Lists only the fingerprints of this module.
+
+    {{.ClassFlag}} (string) Lists only the fingerprints of this error class: {{.Classes}}.
+
+EXAMPLES
+    Command:
+
+      {{.SsmCliName}} {{.GetErrorFingerprintsCommandName}} {{.ClassFlag}} Network
+
+    Output:
+
+      {
+        "Enabled": true,
+        "Fingerprints": [
+          {
+            "ID": "MGSInteractor.Network.5c6b1f3a",
+            "Module": "MGSInteractor",
+            "Class": "Network",
+            "Hash": "5c6b1f3a",
+            "Count": 12,
+            "FirstSeen": "2022-06-01",
+            "LastSeen": "2022-06-02 08:35:00"
+          }
+        ]
+      }
+
+OUTPUT
+    The fingerprints in JSON format
+`
+
+// errorFingerprintClasses are the error classes accepted by the class parameter
+var errorFingerprintClasses = []string{
+	logger.ErrorClassPanic,
+	logger.ErrorClassTimeout,
+	logger.ErrorClassAccessDenied,
+	logger.ErrorClassCredentials,
+	logger.ErrorClassThrottling,
+	logger.ErrorClassNetwork,
+	logger.ErrorClassNotFound,
+	logger.ErrorClassInvalidInput,
+	logger.ErrorClassOther,
+}
+
+type getErrorFingerprintsHelpParams struct {
+	SsmCliName                      string
+	GetErrorFingerprintsCommandName string
+	ModuleFlag                      string
+	ClassFlag                       string
+	Classes                         string
+}
+
+// errorFingerprintsResult is the output of the get-error-fingerprints cli command
+type errorFingerprintsResult struct {
+	Enabled      bool
+	Fingerprints []logger.ErrorFingerprint
+}
+
+func init() {
+	cliutil.Register(&GetErrorFingerprintsCommand{})
+}
+
+type GetErrorFingerprintsCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-error-fingerprints cli command
+func (c *GetErrorFingerprintsCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, module, class := c.validateGetErrorFingerprintsCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return err, ""
+	}
+	fingerprints, err := logger.ReadErrorFingerprints(filepath.Join(logger.LogDir(), "audits"))
+	if err != nil {
+		return err, ""
+	}
+
+	result := errorFingerprintsResult{
+		Enabled:      config.Agent.ErrorFingerprints.Enabled,
+		Fingerprints: make([]logger.ErrorFingerprint, 0, len(fingerprints)),
+	}
+	for _, fingerprint := range fingerprints {
+		if (module == "" || strings.EqualFold(fingerprint.Module, module)) && (class == "" || fingerprint.Class == class) {
+			result.Fingerprints = append(result.Fingerprints, fingerprint)
+		}
+	}
+
+	output, err := jsonutil.Marshal(result)
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(output)
+}
+
+// Help prints help for the get-error-fingerprints cli command
+func (c *GetErrorFingerprintsCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetErrorFingerprintsCommandHelp").Parse(getErrorFingerprintsCommandHelp)
+		params := getErrorFingerprintsHelpParams{cliutil.SsmCliName, getErrorFingerprintsCommand,
+			cliutil.FormatFlag(fingerprintModuleFlag), cliutil.FormatFlag(fingerprintClassFlag), strings.Join(errorFingerprintClasses, ", ")}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetErrorFingerprintsCommand) Name() string {
+	return getErrorFingerprintsCommand
+}
+
+// validateGetErrorFingerprintsCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (GetErrorFingerprintsCommand) validateGetErrorFingerprintsCommandInput(subcommands []string, parameters map[string][]string) (validation []string, module string, class string) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getErrorFingerprintsCommand, subcommands), "")
+		return validation, "", ""
+	}
+
+	if values, exists := parameters[fingerprintModuleFlag]; exists {
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(fingerprintModuleFlag)))
+		} else {
+			module = values[0]
+		}
+	}
+
+	if values, exists := parameters[fingerprintClassFlag]; exists {
+		if len(values) == 1 {
+			for _, known := range errorFingerprintClasses {
+				if strings.EqualFold(values[0], known) {
+					class = known
+				}
+			}
+		}
+		if class == "" {
+			validation = append(validation, fmt.Sprintf("%v must be one of %v", cliutil.FormatFlag(fingerprintClassFlag), strings.Join(errorFingerprintClasses, ", ")))
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != fingerprintModuleFlag && key != fingerprintClassFlag {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, module, class
+}
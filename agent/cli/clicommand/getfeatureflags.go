@@ -0,0 +1,120 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/featureflag"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+)
+
+const (
+	getFeatureFlagsCommand = "get-feature-flags"
+)
+
+const getFeatureFlagsCommandHelp = `NAME:
+    {{.GetFeatureFlagsCommandName}}
+DESCRIPTION
+    Returns the state of the runtime feature flags of the agent and where each value comes from.
+SYNOPSIS
+    {{.GetFeatureFlagsCommandName}}
+EXAMPLES
+    Command:
+
+      {{.SsmCliName}} {{.GetFeatureFlagsCommandName}}
+
+    Output:
+      {
+        "NewIPC": {
+          "enabled": true,
+          "source": "appconfig"
+        }
+      }
+
+OUTPUT
+    Feature flag states in JSON format
+`
+
+type getFeatureFlagsHelpParams struct {
+	SsmCliName                 string
+	GetFeatureFlagsCommandName string
+}
+
+func init() {
+	cliutil.Register(&GetFeatureFlagsCommand{})
+}
+
+type GetFeatureFlagsCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-feature-flags cli command
+func (c *GetFeatureFlagsCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateGetFeatureFlagsCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return err, ""
+	}
+
+	result, err := jsonutil.Marshal(featureflag.Resolve(config))
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(result)
+}
+
+// Help prints help for the get-feature-flags cli command
+func (c *GetFeatureFlagsCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetFeatureFlagsCommandHelp").Parse(getFeatureFlagsCommandHelp)
+		params := getFeatureFlagsHelpParams{cliutil.SsmCliName, getFeatureFlagsCommand}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetFeatureFlagsCommand) Name() string {
+	return getFeatureFlagsCommand
+}
+
+// validateGetFeatureFlagsCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (GetFeatureFlagsCommand) validateGetFeatureFlagsCommandInput(subcommands []string, parameters map[string][]string) []string {
+	validation := make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getFeatureFlagsCommand, subcommands), "")
+		return validation
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+	}
+	return validation
+}
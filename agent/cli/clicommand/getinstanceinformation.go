@@ -24,6 +24,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/version"
+	"github.com/aws/amazon-ssm-agent/common/identity"
 )
 
 const (
@@ -33,7 +34,8 @@ const (
 const getInstanceInformationCommandHelp = `NAME:
 EXAMPLES
     This example returns basic information about the instance this agent is running on,
-    including AWS region name, instance id and release version of this CLI.
+    including AWS region name and the source it was resolved from, instance id and release
+    version of this CLI.
 
     Note: release version of this CLI should match the release version of the SSM agent,
     since in normal case, CLI and agent are compiled from same source files; in rare
@@ -47,12 +49,13 @@ EXAMPLES
     Output:
       {
         "region" : "us-west-2",
+        "region-source" : "Identity",
         "instance-id" : "i-12345678",
         "release-version" : "1.0.0"
       }
 
 OUTPUT
-    Instance information containing region, instance ID and version in JSON format
+    Instance information containing region, region source, instance ID and version in JSON format
 `
 
 type getInstanceInformationHelpParams struct {
@@ -88,6 +91,10 @@ func (c *GetInstanceInformationCommand) Execute(subcommands []string, parameters
 		information["region"] = region
 	}
 
+	if reporter, ok := agentIdentity.(identity.IRegionSourceReporter); ok {
+		information["region-source"] = reporter.RegionSource()
+	}
+
 	if instanceId, err := agentIdentity.InstanceID(); err != nil {
 		return err, ""
 	} else {
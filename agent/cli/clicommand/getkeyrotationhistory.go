@@ -0,0 +1,137 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/aws/amazon-ssm-agent/agent/managedInstances/registration"
+)
+
+const (
+	getKeyRotationHistoryCommand = "get-key-rotation-history"
+)
+
+const getKeyRotationHistoryCommandHelp = `NAME:
+    {{.GetKeyRotationHistoryCommandName}}
+DESCRIPTION
+    Returns the last rotations of the private key of a managed instance registered with an activation,
+    the oldest first. A failed rotation keeps the previous private key.
+SYNOPSIS
+    {{.GetKeyRotationHistoryCommandName}}
+EXAMPLES
+    Command:
+
+      {{.SsmCliName}} {{.GetKeyRotationHistoryCommandName}}
+
+    Output:
+      [
+        {
+          "time": "2022-06-01T12:00:00Z",
+          "reason": "KeyAge",
+          "status": "Failed",
+          "keyType": "Ecdsa",
+          "publicKeyFingerprint": "3f1c5b0e...",
+          "error": "new key could not be validated, old key restored: AccessDeniedException"
+        },
+        {
+          "time": "2022-06-01T13:00:00Z",
+          "reason": "KeyAge",
+          "status": "Succeeded",
+          "keyType": "Rsa",
+          "publicKeyFingerprint": "9a4d27c1..."
+        }
+      ]
+
+OUTPUT
+    Key rotation history in JSON format
+`
+
+type getKeyRotationHistoryHelpParams struct {
+	SsmCliName                       string
+	GetKeyRotationHistoryCommandName string
+}
+
+func init() {
+	cliutil.Register(&GetKeyRotationHistoryCommand{})
+}
+
+type GetKeyRotationHistoryCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-key-rotation-history cli command
+func (c *GetKeyRotationHistoryCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateGetKeyRotationHistoryCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	log := logger.NewSilentLogger()
+	if !registration.HasManagedInstancesCredentials(log, "", registration.RegVaultKey) {
+		return errors.New("the instance is not registered with an activation"), ""
+	}
+
+	history := registration.KeyRotationHistory(log, "", registration.RegVaultKey)
+	if history == nil {
+		history = []registration.KeyRotationRecord{}
+	}
+
+	result, err := jsonutil.Marshal(history)
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(result)
+}
+
+// Help prints help for the get-key-rotation-history cli command
+func (c *GetKeyRotationHistoryCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetKeyRotationHistoryCommandHelp").Parse(getKeyRotationHistoryCommandHelp)
+		params := getKeyRotationHistoryHelpParams{cliutil.SsmCliName, getKeyRotationHistoryCommand}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetKeyRotationHistoryCommand) Name() string {
+	return getKeyRotationHistoryCommand
+}
+
+// validateGetKeyRotationHistoryCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (GetKeyRotationHistoryCommand) validateGetKeyRotationHistoryCommandInput(subcommands []string, parameters map[string][]string) []string {
+	validation := make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getKeyRotationHistoryCommand, subcommands), "")
+		return validation
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+	}
+	return validation
+}
@@ -0,0 +1,191 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/aws/amazon-ssm-agent/common/channel"
+	"github.com/aws/amazon-ssm-agent/common/channel/utils"
+	"github.com/aws/amazon-ssm-agent/common/message"
+	"go.nanomsg.org/mangos/v3"
+	_ "go.nanomsg.org/mangos/v3/transport/ipc"
+)
+
+const (
+	getLongRunningPluginsCommand = "get-long-running-plugins"
+
+	// longRunningPluginsQueryAttempts is the number of times the status is queried before giving up, the agent
+	// connects to the status channel after ssm-cli starts listening on it and can miss the first queries
+	longRunningPluginsQueryAttempts = 5
+
+	// longRunningPluginsQueryTimeout is the time the agent is given to answer a query
+	longRunningPluginsQueryTimeout = 2 * time.Second
+)
+
+const getLongRunningPluginsCommandHelp = `NAME:
+    {{.GetLongRunningPluginsCommandName}}
+DESCRIPTION
+    Returns the status of the long running plugins the agent is running, like the CloudWatch plugin and the
+    executables declared in the long running plugins folder. The status is queried from the running agent.
+SYNOPSIS
+    {{.GetLongRunningPluginsCommandName}}
+EXAMPLES
+    Command:
+
+      {{.SsmCliName}} {{.GetLongRunningPluginsCommandName}}
+
+    Output:
+      [
+        {
+          "Name": "collector",
+          "Pid": 4242,
+          "UptimeSeconds": 86400,
+          "Restarts": 1,
+          "LastHealthCheck": {
+            "Time": "2022-06-01T12:00:00Z",
+            "Healthy": true
+          }
+        }
+      ]
+
+OUTPUT
+    Long running plugin status in JSON format, the last health check is omitted until the agent checks the plugin
+`
+
+type getLongRunningPluginsHelpParams struct {
+	SsmCliName                       string
+	GetLongRunningPluginsCommandName string
+}
+
+func init() {
+	cliutil.Register(&GetLongRunningPluginsCommand{})
+}
+
+type GetLongRunningPluginsCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-long-running-plugins cli command
+func (c *GetLongRunningPluginsCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateGetLongRunningPluginsCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	agentIdentity, err := cliutil.GetAgentIdentity()
+	if err != nil {
+		return err, ""
+	}
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return err, ""
+	}
+
+	log := logger.NewSilentLogger()
+	statusChannel := channel.GetChannelCreator(log, config, agentIdentity)(log, agentIdentity)
+	if err = statusChannel.Initialize(utils.Surveyor); err != nil {
+		return fmt.Errorf("failed to create the long running plugin status channel: %v", err), ""
+	}
+	defer statusChannel.Close()
+	if err = statusChannel.Listen(message.LongRunningStatusChannel); err != nil {
+		return fmt.Errorf("failed to listen on the long running plugin status channel: %v", err), ""
+	}
+	if err = statusChannel.SetOption(mangos.OptionSurveyTime, longRunningPluginsQueryTimeout); err != nil {
+		return err, ""
+	}
+
+	for attempt := 0; attempt < longRunningPluginsQueryAttempts; attempt++ {
+		var plugins []message.LongRunningPluginStatus
+		if plugins, err = queryLongRunningPlugins(statusChannel); err != nil {
+			continue
+		}
+		result, err := jsonutil.Marshal(plugins)
+		if err != nil {
+			return err, ""
+		}
+		return nil, jsonutil.Indent(result)
+	}
+	return fmt.Errorf("the agent did not answer, make sure it is running: %v", err), ""
+}
+
+// queryLongRunningPlugins sends a status query on the channel and returns the status of the plugins answered
+func queryLongRunningPlugins(statusChannel channel.IChannel) ([]message.LongRunningPluginStatus, error) {
+	if err := statusChannel.Send(message.CreateLongRunningPluginStatusRequest()); err != nil {
+		return nil, err
+	}
+	msg, err := statusChannel.Recv()
+	if err != nil {
+		return nil, err
+	}
+	var result message.Message
+	if err = json.Unmarshal(msg, &result); err != nil {
+		return nil, err
+	}
+	if result.Topic != message.GetLongRunningPluginStatusResult {
+		return nil, fmt.Errorf("unexpected answer %v", result.Topic)
+	}
+	var payload message.LongRunningPluginStatusPayload
+	if err = json.Unmarshal(result.Payload, &payload); err != nil {
+		return nil, err
+	}
+	if payload.Plugins == nil {
+		payload.Plugins = []message.LongRunningPluginStatus{}
+	}
+	return payload.Plugins, nil
+}
+
+// Help prints help for the get-long-running-plugins cli command
+func (c *GetLongRunningPluginsCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetLongRunningPluginsCommandHelp").Parse(getLongRunningPluginsCommandHelp)
+		params := getLongRunningPluginsHelpParams{cliutil.SsmCliName, getLongRunningPluginsCommand}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetLongRunningPluginsCommand) Name() string {
+	return getLongRunningPluginsCommand
+}
+
+// validateGetLongRunningPluginsCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (GetLongRunningPluginsCommand) validateGetLongRunningPluginsCommandInput(subcommands []string, parameters map[string][]string) []string {
+	validation := make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getLongRunningPluginsCommand, subcommands), "")
+		return validation
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+	}
+	return validation
+}
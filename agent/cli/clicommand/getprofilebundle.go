@@ -0,0 +1,202 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/profiling"
+)
+
+const (
+	getProfileBundleCommand = "get-profile-bundle"
+	profileOutputFlag       = "output"
+	profileProcessFlag      = "process"
+	profileDurationFlag     = "duration"
+)
+
+const getProfileBundleCommandHelp = `NAME:
+    {{.GetProfileBundleCommandName}}
+
+DESCRIPTION
+    Captures a CPU profile, the heap and allocation profiles and the goroutine stacks of an agent process
+    to a tar.gz bundle, to diagnose the performance issues of the agent.
+    The profiling endpoints have to be enabled with Agent.Profiling.Enabled in the agent configuration,
+    they are served on a local socket, a named pipe on windows, only root or the administrators can connect to.
+
+SYNOPSIS
+    {{.GetProfileBundleCommandName}}
+    {{.OutputFlag}}
+    [{{.ProcessFlag}}]
+    [{{.DurationFlag}}]
+
+PARAMETERS
+    {{.OutputFlag}} (string) Path of the bundle to write.
+
+    {{.ProcessFlag}} (string) Process to profile: worker (ssm-agent-worker) or core (amazon-ssm-agent).
+        Defaults to worker.
+
+    {{.DurationFlag}} (integer) Duration of the CPU profile in seconds, at most {{.MaxDurationSeconds}}.
+        Defaults to {{.DefaultDurationSeconds}}.
+
+EXAMPLES
+    Command:
+
+      {{.SsmCliName}} {{.GetProfileBundleCommandName}} {{.OutputFlag}} /tmp/ssm-profile.tar.gz
+
+    Output:
+
+      {
+        "AgentVersion": "3.1.0.0",
+        "Process": "worker",
+        "CreatedAt": "2022-06-01T12:00:00Z",
+        "DurationSeconds": 30,
+        "Profiles": [
+          "cpu.pprof",
+          "heap.pprof",
+          "allocs.pprof",
+          "goroutine.txt"
+        ]
+      }
+
+OUTPUT
+    The manifest of the bundle in JSON format
+`
+
+type getProfileBundleHelpParams struct {
+	SsmCliName                  string
+	GetProfileBundleCommandName string
+	OutputFlag                  string
+	ProcessFlag                 string
+	DurationFlag                string
+	MaxDurationSeconds          int
+	DefaultDurationSeconds      int
+}
+
+func init() {
+	cliutil.Register(&GetProfileBundleCommand{})
+}
+
+type GetProfileBundleCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-profile-bundle cli command
+func (c *GetProfileBundleCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, output, process, duration := c.validateGetProfileBundleCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return err, ""
+	}
+	if !config.Agent.Profiling.Enabled {
+		return fmt.Errorf("profiling is not enabled in the agent configuration"), ""
+	}
+	file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, appconfig.ReadWriteAccess)
+	if err != nil {
+		return err, ""
+	}
+	manifest, err := profiling.CaptureBundle(file, process, duration)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(output)
+		return err, ""
+	}
+
+	result, err := jsonutil.Marshal(manifest)
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(result)
+}
+
+// Help prints help for the get-profile-bundle cli command
+func (c *GetProfileBundleCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetProfileBundleCommandHelp").Parse(getProfileBundleCommandHelp)
+		params := getProfileBundleHelpParams{cliutil.SsmCliName, getProfileBundleCommand,
+			cliutil.FormatFlag(profileOutputFlag), cliutil.FormatFlag(profileProcessFlag), cliutil.FormatFlag(profileDurationFlag),
+			profiling.MaxDurationSeconds, profiling.DefaultDurationSeconds}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetProfileBundleCommand) Name() string {
+	return getProfileBundleCommand
+}
+
+// validateGetProfileBundleCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (GetProfileBundleCommand) validateGetProfileBundleCommandInput(subcommands []string, parameters map[string][]string) (validation []string, output string, process string, duration int) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getProfileBundleCommand, subcommands), "")
+		return validation, "", "", 0
+	}
+
+	// look for required parameters
+	if values, exists := parameters[profileOutputFlag]; !exists {
+		validation = append(validation, fmt.Sprintf("%v is required", cliutil.FormatFlag(profileOutputFlag)))
+	} else if len(values) != 1 {
+		validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(profileOutputFlag)))
+	} else {
+		output = values[0]
+	}
+
+	process = profiling.WorkerProcess
+	if values, exists := parameters[profileProcessFlag]; exists {
+		if len(values) != 1 || (values[0] != profiling.WorkerProcess && values[0] != profiling.CoreProcess) {
+			validation = append(validation, fmt.Sprintf("%v must be %v or %v", cliutil.FormatFlag(profileProcessFlag), profiling.WorkerProcess, profiling.CoreProcess))
+		} else {
+			process = values[0]
+		}
+	}
+
+	duration = profiling.DefaultDurationSeconds
+	if values, exists := parameters[profileDurationFlag]; exists {
+		var err error
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(profileDurationFlag)))
+		} else if duration, err = strconv.Atoi(values[0]); err != nil || duration < 1 || duration > profiling.MaxDurationSeconds {
+			validation = append(validation, fmt.Sprintf("%v must be a number of seconds between 1 and %v", cliutil.FormatFlag(profileDurationFlag), profiling.MaxDurationSeconds))
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != profileOutputFlag && key != profileProcessFlag && key != profileDurationFlag {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, output, process, duration
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/cli/statebundle"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+)
+
+const (
+	importStateCommand = "import-state"
+	stateInputFlag     = "input"
+	stateForceFlag     = "force"
+)
+
+const importStateCommandHelp = `NAME:
+    {{.ImportStateCommandName}}
+
+DESCRIPTION
+    Restores the identity and the state of the agent from a bundle written by {{.ExportStateCommandName}}.
+    Stop the agent before restoring a bundle and start it once the bundle is restored.
+
+    The association state is restored for the instance of the bundle when its registration is restored,
+    for the instance the agent runs as otherwise.
+    The restore fails when the instance already has state of a restored category, unless {{.ForceFlag}} is set.
+    Restoring a registration on an EC2 instance replaces the registration of its EC2 identity.
+
+SYNOPSIS
+    {{.ImportStateCommandName}}
+    {{.InputFlag}}
+    [{{.ExcludeFlag}}]
+    [{{.ForceFlag}}]
+
+PARAMETERS
+    {{.InputFlag}} (string) Path of the bundle to restore.
+
+    {{.ExcludeFlag}} (list) Categories of the bundle that are not restored:
+        identity (the registration and the fingerprint), registration, fingerprint or association.
+
+    {{.ForceFlag}} (boolean) Replaces the existing state of the restored categories, true if provided.
+
+EXAMPLES
+    This example restores the registration and the state of an instance.
+
+    Command:
+
+      {{.SsmCliName}} {{.ImportStateCommandName}} {{.InputFlag}} /tmp/ssm-state.tar.gz {{.ForceFlag}}
+
+    Output:
+
+      {
+        "FormatVersion": 1,
+        "AgentVersion": "3.1.0.0",
+        "CreatedAt": "2022-06-01T12:00:00Z",
+        "ShortInstanceID": "mi-1234567890abcdef0",
+        "Categories": [
+          "registration",
+          "fingerprint",
+          "association"
+        ]
+      }
+
+OUTPUT
+    The manifest of the bundle listing the restored categories in JSON format
+`
+
+type importStateHelpParams struct {
+	SsmCliName             string
+	ImportStateCommandName string
+	ExportStateCommandName string
+	InputFlag              string
+	ExcludeFlag            string
+	ForceFlag              string
+}
+
+func init() {
+	cliutil.Register(&ImportStateCommand{})
+}
+
+type ImportStateCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the import-state cli command
+func (c *ImportStateCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, input, excluded, force := c.validateImportStateCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	// load the storage locations of the app config
+	if _, err := appconfig.Config(false); err != nil {
+		return err, ""
+	}
+
+	file, err := os.Open(input)
+	if err != nil {
+		return err, ""
+	}
+	defer file.Close()
+
+	manifest, err := statebundle.Import(file, excluded, force, func() (string, error) {
+		agentIdentity, err := cliutil.GetAgentIdentity()
+		if err != nil {
+			return "", err
+		}
+		return agentIdentity.ShortInstanceID()
+	})
+	if err != nil {
+		return err, ""
+	}
+
+	result, err := jsonutil.Marshal(manifest)
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(result)
+}
+
+// Help prints help for the import-state cli command
+func (c *ImportStateCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("ImportStateCommandHelp").Parse(importStateCommandHelp)
+		params := importStateHelpParams{cliutil.SsmCliName, importStateCommand, exportStateCommand,
+			cliutil.FormatFlag(stateInputFlag), cliutil.FormatFlag(stateExcludeFlag), cliutil.FormatFlag(stateForceFlag)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (ImportStateCommand) Name() string {
+	return importStateCommand
+}
+
+// validateImportStateCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (ImportStateCommand) validateImportStateCommandInput(subcommands []string, parameters map[string][]string) (validation []string, input string, excluded map[string]bool, force bool) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", importStateCommand, subcommands), "")
+		return validation, "", nil, false
+	}
+
+	// look for required parameters
+	if values, exists := parameters[stateInputFlag]; !exists {
+		validation = append(validation, fmt.Sprintf("%v is required", cliutil.FormatFlag(stateInputFlag)))
+	} else if len(values) != 1 {
+		validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(stateInputFlag)))
+	} else {
+		input = values[0]
+	}
+
+	var err error
+	if excluded, err = statebundle.ParseCategories(parameters[stateExcludeFlag]); err != nil {
+		validation = append(validation, fmt.Sprintf("invalid value for parameter %v: %v", cliutil.FormatFlag(stateExcludeFlag), err))
+	}
+
+	_, force = parameters[stateForceFlag]
+	if force && len(parameters[stateForceFlag]) > 0 {
+		validation = append(validation, fmt.Sprintf("flag %v should not have any values", cliutil.FormatFlag(stateForceFlag)))
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != stateInputFlag && key != stateExcludeFlag && key != stateForceFlag {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, input, excluded, force
+}
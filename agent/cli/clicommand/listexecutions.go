@@ -0,0 +1,262 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+)
+
+const (
+	listExecutionsCommand          = "list-executions"
+	listExecutionsStatusFlag       = "status"
+	listExecutionsSinceFlag        = "since"
+	listExecutionsDocumentFlag     = "document"
+	listExecutionsMaxResultsFlag   = "max-results"
+	listExecutionsDefaultMaxResult = 50
+)
+
+const listExecutionsCommandHelp = `NAME:
+    {{.ListExecutionsCommandName}}
+
+DESCRIPTION
+    Lists the document executions completed on this instance, the most recent first. The executions are read
+    from the execution history kept by the agent with the document states, its size is set with
+    Ssm.ExecutionHistoryMaxEntries in the agent configuration.
+
+SYNOPSIS
+    {{.ListExecutionsCommandName}}
+    [{{.StatusFlag}}]
+    [{{.SinceFlag}}]
+    [{{.DocumentFlag}}]
+    [{{.MaxResultsFlag}}]
+
+PARAMETERS
+    {{.StatusFlag}} (string list) Lists only the executions that ended with one of these statuses: {{.Statuses}}.
+
+    {{.SinceFlag}} (string) Lists only the executions that ended during this period, for example 90m, 24h or 7d.
+
+    {{.DocumentFlag}} (string) Lists only the executions of this document.
+
+    {{.MaxResultsFlag}} (integer) The number of executions listed, {{.DefaultMaxResults}} by default.
+
+EXAMPLES
+    Command:
+
+      {{.SsmCliName}} {{.ListExecutionsCommandName}} {{.StatusFlag}} Failed {{.SinceFlag}} 24h
+
+    Output:
+
+      [
+        {
+          "ExecutionID": "1f2e3d4c-5b6a-7988-8776-655443322110",
+          "CommandID": "1f2e3d4c-5b6a-7988-8776-655443322110",
+          "DocumentName": "AWS-RunShellScript",
+          "DocumentType": "SendCommand",
+          "Status": "Failed",
+          "StartDateTime": "2022-06-02T08:34:12Z",
+          "EndDateTime": "2022-06-02T08:35:00Z",
+          "DurationMillis": 48000
+        }
+      ]
+
+OUTPUT
+    The executions in JSON format
+`
+
+// listExecutionsStatuses are the statuses accepted by the status parameter
+var listExecutionsStatuses = []contracts.ResultStatus{
+	contracts.ResultStatusSuccess,
+	contracts.ResultStatusFailed,
+	contracts.ResultStatusTimedOut,
+	contracts.ResultStatusCancelled,
+	contracts.ResultStatusSkipped,
+}
+
+type listExecutionsHelpParams struct {
+	SsmCliName                string
+	ListExecutionsCommandName string
+	StatusFlag                string
+	SinceFlag                 string
+	DocumentFlag              string
+	MaxResultsFlag            string
+	Statuses                  string
+	DefaultMaxResults         int
+}
+
+func init() {
+	cliutil.Register(&ListExecutionsCommand{})
+}
+
+type ListExecutionsCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the list-executions cli command
+func (c *ListExecutionsCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, filter := c.validateListExecutionsCommandInput(subcommands, parameters, time.Now())
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	agentIdentity, err := cliutil.GetAgentIdentity()
+	if err != nil {
+		return err, ""
+	}
+	instanceID, err := agentIdentity.ShortInstanceID()
+	if err != nil {
+		return err, ""
+	}
+
+	executions, err := docmanager.ListExecutions(instanceID, filter)
+	if err != nil {
+		return err, ""
+	}
+
+	output, err := jsonutil.Marshal(executions)
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(output)
+}
+
+// Help prints help for the list-executions cli command
+func (c *ListExecutionsCommand) Help() string {
+	if len(c.helpText) == 0 {
+		statuses := make([]string, 0, len(listExecutionsStatuses))
+		for _, status := range listExecutionsStatuses {
+			statuses = append(statuses, string(status))
+		}
+		t, _ := template.New("ListExecutionsCommandHelp").Parse(listExecutionsCommandHelp)
+		params := listExecutionsHelpParams{cliutil.SsmCliName, listExecutionsCommand,
+			cliutil.FormatFlag(listExecutionsStatusFlag), cliutil.FormatFlag(listExecutionsSinceFlag),
+			cliutil.FormatFlag(listExecutionsDocumentFlag), cliutil.FormatFlag(listExecutionsMaxResultsFlag),
+			strings.Join(statuses, ", "), listExecutionsDefaultMaxResult}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (ListExecutionsCommand) Name() string {
+	return listExecutionsCommand
+}
+
+// validateListExecutionsCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (ListExecutionsCommand) validateListExecutionsCommandInput(subcommands []string, parameters map[string][]string, now time.Time) (validation []string, filter docmanager.ExecutionFilter) {
+	validation = make([]string, 0)
+	filter.MaxResults = listExecutionsDefaultMaxResult
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", listExecutionsCommand, subcommands), "")
+		return validation, filter
+	}
+
+	if values, exists := parameters[listExecutionsStatusFlag]; exists {
+		if len(values) == 0 {
+			validation = append(validation, fmt.Sprintf("expected at least 1 value for parameter %v", cliutil.FormatFlag(listExecutionsStatusFlag)))
+		}
+		for _, value := range values {
+			if status, valid := parseExecutionStatus(value); valid {
+				filter.Statuses = append(filter.Statuses, status)
+			} else {
+				validation = append(validation, fmt.Sprintf("invalid status %v for parameter %v", value, cliutil.FormatFlag(listExecutionsStatusFlag)))
+			}
+		}
+	}
+
+	if values, exists := parameters[listExecutionsSinceFlag]; exists {
+		if period, err := parseExecutionPeriod(values); err != nil {
+			validation = append(validation, fmt.Sprintf("invalid value for parameter %v: %v", cliutil.FormatFlag(listExecutionsSinceFlag), err))
+		} else {
+			filter.Since = now.Add(-period)
+		}
+	}
+
+	if values, exists := parameters[listExecutionsDocumentFlag]; exists {
+		if len(values) != 1 || values[0] == "" {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(listExecutionsDocumentFlag)))
+		} else {
+			filter.DocumentName = values[0]
+		}
+	}
+
+	if values, exists := parameters[listExecutionsMaxResultsFlag]; exists {
+		maxResults := 0
+		if len(values) == 1 {
+			maxResults, _ = strconv.Atoi(values[0])
+		}
+		if maxResults <= 0 {
+			validation = append(validation, fmt.Sprintf("%v must be a positive integer", cliutil.FormatFlag(listExecutionsMaxResultsFlag)))
+		} else {
+			filter.MaxResults = maxResults
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != listExecutionsStatusFlag && key != listExecutionsSinceFlag && key != listExecutionsDocumentFlag && key != listExecutionsMaxResultsFlag {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, filter
+}
+
+// parseExecutionStatus returns the status matching the value regardless of its case
+func parseExecutionStatus(value string) (contracts.ResultStatus, bool) {
+	for _, status := range listExecutionsStatuses {
+		if strings.EqualFold(value, string(status)) {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// parseExecutionPeriod parses a positive duration, the periods in days are written with the d unit
+func parseExecutionPeriod(values []string) (time.Duration, error) {
+	if len(values) != 1 {
+		return 0, errors.New("expected 1 value")
+	}
+	value := values[0]
+	var period time.Duration
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("%v is not a period", value)
+		}
+		period = time.Duration(days) * 24 * time.Hour
+	} else {
+		var err error
+		if period, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("%v is not a period", value)
+		}
+	}
+	if period <= 0 {
+		return 0, fmt.Errorf("%v is not a positive period", value)
+	}
+	return period, nil
+}
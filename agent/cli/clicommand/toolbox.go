@@ -0,0 +1,278 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/aws/amazon-ssm-agent/agent/toolbox"
+)
+
+const (
+	toolboxCommand = "toolbox"
+
+	toolboxDownload       = "download"
+	toolboxUnzip          = "unzip"
+	toolboxHash           = "hash"
+	toolboxRestartService = "restart-service"
+
+	toolboxURLFlag         = "url"
+	toolboxOutputFlag      = "output"
+	toolboxSha256Flag      = "sha256"
+	toolboxSourceFlag      = "source"
+	toolboxDestinationFlag = "destination"
+	toolboxPathFlag        = "path"
+	toolboxAlgorithmFlag   = "algorithm"
+	toolboxNameFlag        = "name"
+)
+
+// toolboxFlags lists the required and optional flags of each helper of the toolbox
+var toolboxFlags = map[string]struct{ required, optional []string }{
+	toolboxDownload:       {required: []string{toolboxURLFlag, toolboxOutputFlag}, optional: []string{toolboxSha256Flag}},
+	toolboxUnzip:          {required: []string{toolboxSourceFlag, toolboxDestinationFlag}},
+	toolboxHash:           {required: []string{toolboxPathFlag}, optional: []string{toolboxAlgorithmFlag}},
+	toolboxRestartService: {required: []string{toolboxNameFlag}},
+}
+
+const toolboxCommandHelp = `NAME:
+    {{.ToolboxCommandName}}
+
+DESCRIPTION
+    Runs one of the helpers built into the agent, the documents can use them on the hosts
+    missing curl, unzip or the service wrappers. The scripts of aws:runShellScript and
+    aws:runPowerShellScript find the path of {{.SsmCliName}} in the SSM_TOOLBOX environment variable.
+
+SYNOPSIS
+    {{.ToolboxCommandName}} {{.Download}} {{.URLFlag}} <url> {{.OutputFlag}} <path> [{{.Sha256Flag}} <checksum>]
+    {{.ToolboxCommandName}} {{.Unzip}} {{.SourceFlag}} <path> {{.DestinationFlag}} <directory>
+    {{.ToolboxCommandName}} {{.Hash}} {{.PathFlag}} <path> [{{.AlgorithmFlag}} sha256|sha1|md5]
+    {{.ToolboxCommandName}} {{.RestartService}} {{.NameFlag}} <service>
+
+PARAMETERS
+    {{.URLFlag}} (string) Url of the file to download, https or S3.
+
+    {{.OutputFlag}} (string) Path the downloaded file is written to.
+
+    {{.Sha256Flag}} (string) Checksum of the file to download, the file is not written when it does not match.
+
+    {{.SourceFlag}} (string) Path of the zip archive to extract.
+
+    {{.DestinationFlag}} (string) Directory the archive is extracted to.
+
+    {{.PathFlag}} (string) Path of the file to hash.
+
+    {{.AlgorithmFlag}} (string) Hash algorithm, sha256 by default.
+
+    {{.NameFlag}} (string) Name of the service to restart.
+
+EXAMPLES
+    Command:
+
+      "$SSM_TOOLBOX" {{.ToolboxCommandName}} {{.Hash}} {{.PathFlag}} /tmp/package.zip
+
+    Output:
+
+      {
+        "Algorithm": "sha256",
+        "Hash": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+      }
+
+OUTPUT
+    The result of the helper in JSON format
+`
+
+type toolboxHelpParams struct {
+	SsmCliName         string
+	ToolboxCommandName string
+	Download           string
+	Unzip              string
+	Hash               string
+	RestartService     string
+	URLFlag            string
+	OutputFlag         string
+	Sha256Flag         string
+	SourceFlag         string
+	DestinationFlag    string
+	PathFlag           string
+	AlgorithmFlag      string
+	NameFlag           string
+}
+
+// toolboxHashResult is the output of the hash helper
+type toolboxHashResult struct {
+	Algorithm string
+	Hash      string
+}
+
+// toolboxPathResult is the output of the download and unzip helpers
+type toolboxPathResult struct {
+	Path string
+}
+
+// toolboxServiceResult is the output of the restart-service helper
+type toolboxServiceResult struct {
+	Service   string
+	Restarted bool
+}
+
+func init() {
+	cliutil.Register(&ToolboxCommand{})
+}
+
+type ToolboxCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the toolbox cli command
+func (c *ToolboxCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateToolboxCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	flag := func(name string) string {
+		if values := parameters[name]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	log := logger.NewSilentLogger()
+	var result interface{}
+	var err error
+	switch subcommands[0] {
+	case toolboxDownload:
+		config, configErr := appconfig.Config(false)
+		if configErr != nil {
+			return configErr, ""
+		}
+		// the https downloads work on the hosts where the agent is not registered
+		agentIdentity, _ := cliutil.GetAgentIdentity()
+		err = toolbox.Download(context.Default(log, config, agentIdentity), flag(toolboxURLFlag), flag(toolboxOutputFlag), flag(toolboxSha256Flag))
+		result = toolboxPathResult{Path: flag(toolboxOutputFlag)}
+	case toolboxUnzip:
+		err = toolbox.Unzip(flag(toolboxSourceFlag), flag(toolboxDestinationFlag))
+		result = toolboxPathResult{Path: flag(toolboxDestinationFlag)}
+	case toolboxHash:
+		algorithm := strings.ToLower(flag(toolboxAlgorithmFlag))
+		if algorithm == "" {
+			algorithm = toolbox.HashSha256
+		}
+		var hash string
+		hash, err = toolbox.Hash(flag(toolboxPathFlag), algorithm)
+		result = toolboxHashResult{Algorithm: algorithm, Hash: hash}
+	case toolboxRestartService:
+		err = toolbox.RestartService(log, flag(toolboxNameFlag))
+		result = toolboxServiceResult{Service: flag(toolboxNameFlag), Restarted: true}
+	}
+	if err != nil {
+		return err, ""
+	}
+
+	output, err := jsonutil.Marshal(result)
+	if err != nil {
+		return err, ""
+	}
+	return nil, jsonutil.Indent(output)
+}
+
+// Help prints help for the toolbox cli command
+func (c *ToolboxCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("ToolboxCommandHelp").Parse(toolboxCommandHelp)
+		params := toolboxHelpParams{
+			cliutil.SsmCliName,
+			toolboxCommand,
+			toolboxDownload,
+			toolboxUnzip,
+			toolboxHash,
+			toolboxRestartService,
+			cliutil.FormatFlag(toolboxURLFlag),
+			cliutil.FormatFlag(toolboxOutputFlag),
+			cliutil.FormatFlag(toolboxSha256Flag),
+			cliutil.FormatFlag(toolboxSourceFlag),
+			cliutil.FormatFlag(toolboxDestinationFlag),
+			cliutil.FormatFlag(toolboxPathFlag),
+			cliutil.FormatFlag(toolboxAlgorithmFlag),
+			cliutil.FormatFlag(toolboxNameFlag),
+		}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (ToolboxCommand) Name() string {
+	return toolboxCommand
+}
+
+// validateToolboxCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (ToolboxCommand) validateToolboxCommandInput(subcommands []string, parameters map[string][]string) []string {
+	validation := make([]string, 0)
+	if len(subcommands) != 1 {
+		helpers := make([]string, 0, len(toolboxFlags))
+		for helper := range toolboxFlags {
+			helpers = append(helpers, helper)
+		}
+		sort.Strings(helpers)
+		validation = append(validation, fmt.Sprintf("%v requires one of the subcommands %v", toolboxCommand, strings.Join(helpers, ", ")))
+		return validation
+	}
+	flags, supported := toolboxFlags[subcommands[0]]
+	if !supported {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", toolboxCommand, subcommands[0]))
+		return validation
+	}
+
+	for _, name := range flags.required {
+		if values := parameters[name]; len(values) != 1 || strings.TrimSpace(values[0]) == "" {
+			validation = append(validation, fmt.Sprintf("%v requires a single value", cliutil.FormatFlag(name)))
+		}
+	}
+	for _, name := range flags.optional {
+		if values, exists := parameters[name]; exists && len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("%v requires a single value", cliutil.FormatFlag(name)))
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if !containsFlag(flags.required, key) && !containsFlag(flags.optional, key) {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation
+}
+
+func containsFlag(flags []string, name string) bool {
+	for _, flag := range flags {
+		if flag == name {
+			return true
+		}
+	}
+	return false
+}
@@ -22,6 +22,7 @@ import (
 	"sync"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log/logger"
 	"github.com/aws/amazon-ssm-agent/common/identity"
 	identity2 "github.com/aws/amazon-ssm-agent/common/identity/identity"
@@ -112,11 +113,25 @@ func ValidUrl(s string) bool {
 	return false
 }
 
+// applyRegionConfig copies the region settings of the agent configuration so the cli resolves the region of the agent,
+// the configuration is read directly as loading it through appconfig writes to the standard output
+func applyRegionConfig(config *appconfig.SsmagentConfig) {
+	var agentConfig appconfig.SsmagentConfig
+	if err := jsonutil.UnmarshalFile(appconfig.AppConfigPath, &agentConfig); err != nil {
+		return
+	}
+	config.Agent.Region = agentConfig.Agent.Region
+	if len(agentConfig.Identity.RegionResolutionOrder) > 0 {
+		config.Identity.RegionResolutionOrder = agentConfig.Identity.RegionResolutionOrder
+	}
+}
+
 // GetAgentIdentity returns the agent identity and only initializes it once
 func GetAgentIdentity() (identity.IAgentIdentity, error) {
 	agentIdentityOnce.Do(func() {
 		log := logger.NewSilentLogger()
 		config := appconfig.DefaultConfig()
+		applyRegionConfig(&config)
 
 		selector := identity2.NewRuntimeConfigIdentitySelector(log)
 		agentIdentity, agentIdentityErr = identity2.NewAgentIdentity(log, &config, selector)
@@ -0,0 +1,88 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diagnostics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/diagnosticsutil"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/circuitbreaker"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+)
+
+const (
+	circuitBreakerCheckStrName       = "AWS endpoint circuit breakers"
+	circuitBreakerCheckStrNoReport   = "The agent has not reported its circuit breakers yet"
+	circuitBreakerCheckStrReadFailed = "Failed to read the circuit breaker states: %v"
+	circuitBreakerCheckStrClosed     = "The circuit breakers of %d endpoints were closed at %v"
+	circuitBreakerCheckStrOpen       = "Calls to endpoints failing repeatedly were suspended at %v: %v"
+	circuitBreakerCheckStrEndpoint   = "%v is %v until %v after %d consecutive failures (%v)."
+)
+
+type circuitBreakerCheckQuery struct{}
+
+func (q circuitBreakerCheckQuery) GetName() string {
+	return circuitBreakerCheckStrName
+}
+
+func (circuitBreakerCheckQuery) GetPriority() int {
+	return 10
+}
+
+func (q circuitBreakerCheckQuery) Execute() diagnosticsutil.DiagnosticOutput {
+	configClient := runtimeconfig.NewCircuitBreakerRuntimeConfigClient()
+	if exists, err := configClient.ConfigExists(); err != nil || !exists {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusSkipped,
+			Note:   circuitBreakerCheckStrNoReport,
+		}
+	}
+
+	report, err := configClient.GetConfig()
+	if err != nil {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusFailed,
+			Note:   fmt.Sprintf(circuitBreakerCheckStrReadFailed, err),
+		}
+	}
+
+	updatedAt := report.UpdatedAt.UTC().Format(time.RFC3339)
+	var openBreakers []string
+	for _, breaker := range report.Breakers {
+		if breaker.State != circuitbreaker.StateClosed {
+			openBreakers = append(openBreakers, fmt.Sprintf(circuitBreakerCheckStrEndpoint,
+				breaker.Endpoint, breaker.State, breaker.OpenUntil.UTC().Format(time.RFC3339), breaker.ConsecutiveFailures, breaker.LastFailure))
+		}
+	}
+	if len(openBreakers) > 0 {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusFailed,
+			Note:   fmt.Sprintf(circuitBreakerCheckStrOpen, updatedAt, strings.Join(openBreakers, " ")),
+		}
+	}
+	return diagnosticsutil.DiagnosticOutput{
+		Check:  q.GetName(),
+		Status: diagnosticsutil.DiagnosticsStatusSuccess,
+		Note:   fmt.Sprintf(circuitBreakerCheckStrClosed, len(report.Breakers), updatedAt),
+	}
+}
+
+func init() {
+	diagnosticsutil.RegisterDiagnosticQuery(circuitBreakerCheckQuery{})
+}
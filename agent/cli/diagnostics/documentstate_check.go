@@ -0,0 +1,82 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diagnostics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/cli/diagnosticsutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+)
+
+const (
+	documentStateCheckStrName        = "Document states"
+	documentStateCheckStrNoIdentity  = "Failed to get the identity of the agent: %v"
+	documentStateCheckStrNoCorrupt   = "No corrupt document state was quarantined"
+	documentStateCheckStrQuarantined = "%d corrupt document states were quarantined: %v"
+	documentStateCheckStrReport      = "%v of %v document %v at %v (%v)."
+)
+
+type documentStateCheckQuery struct{}
+
+func (q documentStateCheckQuery) GetName() string {
+	return documentStateCheckStrName
+}
+
+func (documentStateCheckQuery) GetPriority() int {
+	return 11
+}
+
+func (q documentStateCheckQuery) Execute() diagnosticsutil.DiagnosticOutput {
+	agentIdentity, err := cliutil.GetAgentIdentity()
+	if err == nil {
+		var instanceID string
+		if instanceID, err = agentIdentity.ShortInstanceID(); err == nil {
+			return q.check(docmanager.ReadCorruptionReports(logger.NewSilentLogger(), instanceID))
+		}
+	}
+	return diagnosticsutil.DiagnosticOutput{
+		Check:  q.GetName(),
+		Status: diagnosticsutil.DiagnosticsStatusSkipped,
+		Note:   fmt.Sprintf(documentStateCheckStrNoIdentity, err),
+	}
+}
+
+func (q documentStateCheckQuery) check(reports []docmanager.CorruptionReport) diagnosticsutil.DiagnosticOutput {
+	if len(reports) == 0 {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusSuccess,
+			Note:   documentStateCheckStrNoCorrupt,
+		}
+	}
+	var descriptions []string
+	for _, report := range reports {
+		descriptions = append(descriptions, fmt.Sprintf(documentStateCheckStrReport,
+			report.FileName, report.Recovered.DocumentType, report.Recovered.DocumentName, report.QuarantinedAt.UTC().Format(time.RFC3339), report.Error))
+	}
+	return diagnosticsutil.DiagnosticOutput{
+		Check:  q.GetName(),
+		Status: diagnosticsutil.DiagnosticsStatusFailed,
+		Note:   fmt.Sprintf(documentStateCheckStrQuarantined, len(reports), strings.Join(descriptions, " ")),
+	}
+}
+
+func init() {
+	diagnosticsutil.RegisterDiagnosticQuery(documentStateCheckQuery{})
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd || darwin || windows
+// +build freebsd linux netbsd openbsd darwin windows
+
+package diagnostics
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/cli/diagnosticsutil"
+	"github.com/aws/amazon-ssm-agent/agent/proxyconfig"
+)
+
+const (
+	firewallCheckStrName              = "Host firewall"
+	firewallCheckStrFailRegion        = "Unable to fetch AWS region details"
+	firewallCheckStrProxy             = "Outbound traffic goes through the configured proxy, the local firewall rules were not checked"
+	firewallCheckStrSuccess           = "Outbound port 443 is open to %s"
+	firewallCheckStrBlocked           = "Outbound port 443 is blocked to %s, set Agent.ManageFirewallRules to true to let ssm-cli add allow rules for the agent processes"
+	firewallCheckStrFailRemediation   = "Outbound port 443 is blocked to %s and the allow rules could not be added: %v"
+	firewallCheckStrRemediated        = "Outbound port 443 is blocked to %s, added the firewall rules allowing the agent processes: %s"
+	firewallCheckStrRulesExist        = "Outbound port 443 is blocked to %s while the firewall rules allowing the agent processes exist, the traffic may be blocked outside of the host"
+	firewallCheckStrFailReadingConfig = "Unable to read the agent configuration: %v"
+)
+
+// firewallCheckServices are the services the agent cannot work without
+var firewallCheckServices = []string{"ssm", "ec2messages", "ssmmessages"}
+
+type firewallQuery struct{}
+
+func (firewallQuery) GetName() string {
+	return firewallCheckStrName
+}
+
+func (firewallQuery) GetPriority() int {
+	return 3
+}
+
+// unreachableEndpoints returns the endpoints that cannot be reached directly on port 443
+func unreachableEndpoints(addresses []string) (unreachable []string) {
+	for _, address := range addresses {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("%v:443", address), time.Second)
+		if err != nil {
+			unreachable = append(unreachable, address)
+			continue
+		}
+		conn.Close()
+	}
+	return unreachable
+}
+
+func (q firewallQuery) Execute() diagnosticsutil.DiagnosticOutput {
+	agentIdentity, err := cliutil.GetAgentIdentity()
+	if err != nil {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusSkipped,
+			Note:   firewallCheckStrFailRegion,
+		}
+	}
+
+	if isProxyDefined(proxyconfig.GetProxyConfig()) {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusSkipped,
+			Note:   firewallCheckStrProxy,
+		}
+	}
+
+	var addresses []string
+	for _, service := range firewallCheckServices {
+		addresses = append(addresses, agentIdentity.GetServiceEndpoint(service))
+	}
+
+	unreachable := unreachableEndpoints(addresses)
+	if len(unreachable) == 0 {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusSuccess,
+			Note:   fmt.Sprintf(firewallCheckStrSuccess, strings.Join(addresses, ", ")),
+		}
+	}
+
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusFailed,
+			Note:   fmt.Sprintf(firewallCheckStrFailReadingConfig, err),
+		}
+	}
+
+	if !config.Agent.ManageFirewallRules {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusFailed,
+			Note:   fmt.Sprintf(firewallCheckStrBlocked, strings.Join(unreachable, ", ")),
+		}
+	}
+
+	addedRules, err := addFirewallAllowRules()
+	if err != nil {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusFailed,
+			Note:   fmt.Sprintf(firewallCheckStrFailRemediation, strings.Join(unreachable, ", "), err),
+		}
+	}
+
+	// the rules only apply to the agent processes, the connections of ssm-cli cannot verify them
+	if len(addedRules) == 0 {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusFailed,
+			Note:   fmt.Sprintf(firewallCheckStrRulesExist, strings.Join(unreachable, ", ")),
+		}
+	}
+
+	return diagnosticsutil.DiagnosticOutput{
+		Check:  q.GetName(),
+		Status: diagnosticsutil.DiagnosticsStatusSuccess,
+		Note:   fmt.Sprintf(firewallCheckStrRemediated, strings.Join(unreachable, ", "), strings.Join(addedRules, ", ")),
+	}
+}
+
+func init() {
+	diagnosticsutil.RegisterDiagnosticQuery(firewallQuery{})
+}
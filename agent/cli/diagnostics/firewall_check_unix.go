@@ -0,0 +1,61 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package diagnostics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/diagnosticsutil"
+)
+
+const (
+	iptablesCommand     = "iptables"
+	iptablesRuleComment = "amazon-ssm-agent outbound HTTPS"
+	iptablesTimeout     = 10 * time.Second
+)
+
+// addFirewallAllowRules inserts an iptables rule accepting the connections to port 443 of the processes in the
+// cgroup of the agent service, nothing is added when the rule already exists
+func addFirewallAllowRules() (addedRules []string, err error) {
+	cgroupPath, err := diagnosticsutil.GetAgentCgroupPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the cgroup of the agent: %v", err)
+	}
+	if cgroupPath == "" {
+		return nil, fmt.Errorf("the agent runs in the root cgroup, a rule for its processes would apply to all the processes")
+	}
+
+	rule := []string{
+		"OUTPUT",
+		"-p", "tcp",
+		"--dport", "443",
+		"-m", "cgroup", "--path", cgroupPath,
+		"-m", "comment", "--comment", iptablesRuleComment,
+		"-j", "ACCEPT",
+	}
+	if _, err = diagnosticsutil.ExecuteCommandWithTimeout(iptablesTimeout, iptablesCommand, append([]string{"-C"}, rule...)...); err == nil {
+		return nil, nil
+	}
+
+	// the rule is inserted first to take precedence over the rules rejecting the traffic
+	insertArgs := append([]string{"-I", rule[0], "1"}, rule[1:]...)
+	if output, err := diagnosticsutil.ExecuteCommandWithTimeout(iptablesTimeout, iptablesCommand, insertArgs...); err != nil {
+		return nil, fmt.Errorf("failed to insert the iptables rule: %v %s", err, output)
+	}
+	return []string{fmt.Sprintf("iptables OUTPUT rule accepting tcp port 443 for cgroup %s", cgroupPath)}, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package diagnostics
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/diagnosticsutil"
+)
+
+const (
+	netshCommand           = "netsh"
+	firewallRuleNameFormat = "Amazon SSM Agent outbound HTTPS (%s)"
+	netshTimeout           = 10 * time.Second
+)
+
+// addFirewallAllowRules adds a Windows Defender Firewall rule allowing each agent executable to connect to port 443,
+// the rules that already exist are left untouched
+func addFirewallAllowRules() (addedRules []string, err error) {
+	programs := []string{
+		appconfig.DefaultSSMAgentBinaryPath,
+		appconfig.DefaultSSMAgentWorker,
+		appconfig.DefaultDocumentWorker,
+		appconfig.DefaultSessionWorker,
+	}
+	for _, program := range programs {
+		ruleName := fmt.Sprintf(firewallRuleNameFormat, filepath.Base(program))
+		if _, err = diagnosticsutil.ExecuteCommandWithTimeout(netshTimeout, netshCommand, "advfirewall", "firewall", "show", "rule", "name="+ruleName); err == nil {
+			continue
+		}
+
+		if output, err := diagnosticsutil.ExecuteCommandWithTimeout(netshTimeout, netshCommand, "advfirewall", "firewall", "add", "rule",
+			"name="+ruleName,
+			"dir=out",
+			"action=allow",
+			"program="+program,
+			"protocol=TCP",
+			"remoteport=443",
+			"enable=yes"); err != nil {
+			return addedRules, fmt.Errorf("failed to add firewall rule %s: %v %s", ruleName, err, output)
+		}
+		addedRules = append(addedRules, ruleName)
+	}
+	return addedRules, nil
+}
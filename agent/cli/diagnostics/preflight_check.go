@@ -0,0 +1,90 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diagnostics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/diagnosticsutil"
+	"github.com/aws/amazon-ssm-agent/agent/preflight"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+)
+
+const (
+	preflightCheckStrName       = "Agent preflight checks"
+	preflightCheckStrNoReport   = "The agent has not run its preflight checks yet"
+	preflightCheckStrReadFailed = "Failed to read the preflight report: %v"
+	preflightCheckStrPassed     = "%d preflight checks passed at %v"
+	preflightCheckStrFailed     = "Preflight checks failed at %v: %v"
+)
+
+type preflightCheckQuery struct{}
+
+func (q preflightCheckQuery) GetName() string {
+	return preflightCheckStrName
+}
+
+func (preflightCheckQuery) GetPriority() int {
+	return 8
+}
+
+func (q preflightCheckQuery) Execute() diagnosticsutil.DiagnosticOutput {
+	configClient := runtimeconfig.NewPreflightRuntimeConfigClient()
+	if exists, err := configClient.ConfigExists(); err != nil || !exists {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusSkipped,
+			Note:   preflightCheckStrNoReport,
+		}
+	}
+
+	report, err := configClient.GetConfig()
+	if err != nil {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusFailed,
+			Note:   fmt.Sprintf(preflightCheckStrReadFailed, err),
+		}
+	}
+
+	checkedAt := report.CheckedAt.UTC().Format(time.RFC3339)
+	passed := 0
+	var failures []string
+	for _, result := range report.Checks {
+		switch result.Status {
+		case preflight.StatusPassed:
+			passed++
+		case preflight.StatusFailed:
+			failures = append(failures, fmt.Sprintf("%v: %v. %v", result.Name, result.Message, result.Remediation))
+		}
+	}
+	if len(failures) > 0 {
+		return diagnosticsutil.DiagnosticOutput{
+			Check:  q.GetName(),
+			Status: diagnosticsutil.DiagnosticsStatusFailed,
+			Note:   fmt.Sprintf(preflightCheckStrFailed, checkedAt, strings.Join(failures, " ")),
+		}
+	}
+	return diagnosticsutil.DiagnosticOutput{
+		Check:  q.GetName(),
+		Status: diagnosticsutil.DiagnosticsStatusSuccess,
+		Note:   fmt.Sprintf(preflightCheckStrPassed, passed, checkedAt),
+	}
+}
+
+func init() {
+	diagnosticsutil.RegisterDiagnosticQuery(preflightCheckQuery{})
+}
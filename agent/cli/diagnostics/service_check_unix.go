@@ -23,6 +23,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/cli/diagnosticsutil"
 )
 
@@ -82,7 +83,7 @@ func isServiceRunningSystemctl() (bool, error) {
 	if _, err := exec.LookPath("systemctl"); err != nil {
 		return false, nil
 	}
-	_, err := diagnosticsutil.ExecuteCommandWithTimeout(serviceManagerTimeoutSeconds*time.Second, "systemctl", "status", "amazon-ssm-agent.service")
+	_, err := diagnosticsutil.ExecuteCommandWithTimeout(serviceManagerTimeoutSeconds*time.Second, "systemctl", "status", appconfig.InstanceServiceName("amazon-ssm-agent")+".service")
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			if exitError.ExitCode() == systemctlServiceStopExitCode {
@@ -19,6 +19,7 @@ package diagnostics
 import (
 	"fmt"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
 )
@@ -52,7 +53,7 @@ func windowsServiceStatusToString(state svc.State) string {
 }
 
 func isServiceRunning() error {
-	serviceName := "AmazonSSMAgent"
+	serviceName := appconfig.InstanceServiceName(appconfig.ServiceName)
 
 	manager, err := mgr.Connect()
 	if err != nil {
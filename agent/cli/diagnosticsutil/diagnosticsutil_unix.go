@@ -127,3 +127,24 @@ func getAgentFilePath() (string, error) {
 func getAgentProcessPath() (string, error) {
 	return getAgentFilePath()
 }
+
+// GetAgentCgroupPath returns the unified cgroup hierarchy path of the running agent process,
+// the agent workers started by the service share this cgroup
+func GetAgentCgroupPath() (string, error) {
+	pid, err := getRunningAgentPid()
+	if err != nil {
+		return "", err
+	}
+
+	cgroupBytes, err := ioutil.ReadFile(path.Join("/proc", fmt.Sprintf("%v", pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(cgroupBytes), newlineCharacter) {
+		if strings.HasPrefix(line, "0::") {
+			return strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(line, "0::")), "/"), nil
+		}
+	}
+	return "", fmt.Errorf("agent process %v is not in a cgroup v2 hierarchy", pid)
+}
@@ -0,0 +1,497 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package statebundle exports the identity and the state of the agent to an archive and restores them from it,
+// to restore an instance or to clone an instance from a golden image.
+package statebundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/statestore"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+)
+
+const (
+	// CategoryRegistration is the registration of the instance: its id, region and private key
+	CategoryRegistration = "registration"
+	// CategoryFingerprint is the hardware fingerprint of the registered instance
+	CategoryFingerprint = "fingerprint"
+	// CategoryAssociation is the state of the associations: the last associated document and the cached documents
+	CategoryAssociation = "association"
+	// CategoryIdentity groups the registration and the fingerprint
+	CategoryIdentity = "identity"
+
+	// FormatVersion is the version of the bundle format
+	FormatVersion = 1
+
+	manifestName           = "manifest.json"
+	fingerprintVaultKey    = "InstanceFingerprint"
+	vaultDirName           = "Vault"
+	vaultStoreDirName      = "Store"
+	registrationFileName   = "registration"
+	maxHardenedFileContent = 10 * 1024 * 1024
+)
+
+// shortInstanceIDPattern matches the ids of the EC2 and managed instances
+var shortInstanceIDPattern = regexp.MustCompile(`^m?i-[0-9a-f]{8,17}$`)
+
+// Categories are the categories of state a bundle can hold
+var Categories = []string{CategoryRegistration, CategoryFingerprint, CategoryAssociation}
+
+// Manifest describes the content of a bundle
+type Manifest struct {
+	FormatVersion int
+	AgentVersion  string
+	CreatedAt     time.Time
+	// ShortInstanceID is the instance whose association state is bundled
+	ShortInstanceID string `json:",omitempty"`
+	Categories      []string
+}
+
+// location is a file or a directory of the agent state
+type location struct {
+	category string
+	// name is the path of the location in the bundle
+	name string
+	path func(shortInstanceID string) string
+	// include returns true if the file of a directory location belongs to the category, all files do when nil
+	include func(relativePath string) bool
+	// hardened locations hold secrets, their files are written with hardened permissions
+	hardened bool
+	// states is the location under the instance folder of the states kept in the configured state backend, the
+	// states of the database are bundled as files of the location and restored as files moved to the database
+	// when the agent starts
+	states []string
+}
+
+// stateBackendConfig returns the config selecting the state backend of the bundled states
+var stateBackendConfig = func() appconfig.SsmagentConfig {
+	config, _ := appconfig.Config(false)
+	return config
+}
+
+var locations = []location{
+	{
+		category: CategoryRegistration,
+		name:     "registration/vault",
+		path:     func(string) string { return vaultDir() },
+		include: func(relativePath string) bool {
+			return relativePath != filepath.Join(vaultStoreDirName, fingerprintVaultKey)
+		},
+		hardened: true,
+	},
+	{
+		category: CategoryRegistration,
+		name:     "registration/registration",
+		path: func(string) string {
+			return filepath.Join(appconfig.DefaultDataStorePath, registrationFileName)
+		},
+		hardened: true,
+	},
+	{
+		category: CategoryFingerprint,
+		name:     "fingerprint/" + fingerprintVaultKey,
+		path:     func(string) string { return filepath.Join(vaultDir(), vaultStoreDirName, fingerprintVaultKey) },
+		hardened: true,
+	},
+	{
+		category: CategoryAssociation,
+		name:     "association/state",
+		path: func(shortInstanceID string) string {
+			return filepath.Join(appconfig.DefaultDataStorePath, shortInstanceID, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfAssociation)
+		},
+		states: []string{appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfAssociation},
+	},
+	{
+		category: CategoryAssociation,
+		name:     "association/documentcache",
+		path: func(shortInstanceID string) string {
+			return filepath.Join(appconfig.DefaultDataStorePath, shortInstanceID, appconfig.DocumentCacheRootDirName)
+		},
+	},
+}
+
+// vaultDir returns the directory of the vault, the vault stays in the built-in data store
+func vaultDir() string {
+	return filepath.Join(appconfig.DefaultDataStorePath, vaultDirName)
+}
+
+// ParseCategories returns the categories matching the names, identity names the registration and the fingerprint
+func ParseCategories(names []string) (map[string]bool, error) {
+	categories := make(map[string]bool)
+	for _, name := range names {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case CategoryIdentity:
+			categories[CategoryRegistration] = true
+			categories[CategoryFingerprint] = true
+		case CategoryRegistration, CategoryFingerprint, CategoryAssociation:
+			categories[name] = true
+		default:
+			return nil, fmt.Errorf("unknown state category %v, expected %v, %v, %v or %v",
+				name, CategoryIdentity, CategoryRegistration, CategoryFingerprint, CategoryAssociation)
+		}
+	}
+	return categories, nil
+}
+
+// Export writes the bundle of the categories that are not excluded. shortInstanceID is the instance of the
+// association state, it is only needed when the association state is exported.
+func Export(w io.Writer, shortInstanceID string, excluded map[string]bool) (manifest Manifest, err error) {
+	manifest = Manifest{
+		FormatVersion: FormatVersion,
+		AgentVersion:  version.Version,
+		CreatedAt:     time.Now().UTC(),
+	}
+	for _, category := range Categories {
+		if !excluded[category] {
+			manifest.Categories = append(manifest.Categories, category)
+		}
+	}
+	if !excluded[CategoryAssociation] {
+		if shortInstanceID == "" {
+			return manifest, fmt.Errorf("the instance id is needed to export the %v state", CategoryAssociation)
+		}
+		manifest.ShortInstanceID = shortInstanceID
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	content, _ := json.Marshal(manifest)
+	if err = tarWriter.WriteHeader(&tar.Header{Name: manifestName, Mode: int64(appconfig.ReadWriteAccess), Size: int64(len(content)), ModTime: manifest.CreatedAt}); err != nil {
+		return
+	}
+	if _, err = tarWriter.Write(content); err != nil {
+		return
+	}
+	for _, loc := range locations {
+		if excluded[loc.category] {
+			continue
+		}
+		if err = exportLocation(tarWriter, loc, loc.path(shortInstanceID)); err != nil {
+			return manifest, fmt.Errorf("failed to export %v: %v", loc.name, err)
+		}
+		if folder := databaseStates(loc, shortInstanceID); folder != nil {
+			if err = exportStates(tarWriter, loc, folder, manifest.CreatedAt); err != nil {
+				return manifest, fmt.Errorf("failed to export the %v states of the database: %v", loc.name, err)
+			}
+		}
+	}
+	if err = tarWriter.Close(); err != nil {
+		return
+	}
+	return manifest, gzipWriter.Close()
+}
+
+// exportLocation adds the files of a location to the bundle, a missing location is skipped
+func exportLocation(tarWriter *tar.Writer, loc location, root string) error {
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		name := loc.name
+		if relativePath != "." {
+			if loc.include != nil && !loc.include(relativePath) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			name = path.Join(loc.name, filepath.ToSlash(relativePath))
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			// links and devices are not agent state
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+			return tarWriter.WriteHeader(header)
+		}
+		if err = tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+}
+
+// databaseStates returns the states of the location kept in the database, nil if the states are kept in files
+func databaseStates(loc location, shortInstanceID string) statestore.Folder {
+	config := stateBackendConfig()
+	if loc.states == nil || !statestore.Enabled(config) {
+		return nil
+	}
+	return statestore.NewFolder(config, shortInstanceID, loc.states...)
+}
+
+// exportStates adds the states of the database to the bundle as files of the location
+func exportStates(tarWriter *tar.Writer, loc location, folder statestore.Folder, modTime time.Time) error {
+	names, err := folder.Names()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		content, err := folder.Read(name)
+		if err != nil {
+			return err
+		}
+		header := &tar.Header{
+			Name:     path.Join(loc.name, name),
+			Typeflag: tar.TypeReg,
+			Mode:     int64(appconfig.ReadWriteAccess),
+			Size:     int64(len(content)),
+			ModTime:  modTime,
+		}
+		if err = tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err = tarWriter.Write(content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import restores the categories of the bundle that are not excluded. The association state is restored for the
+// instance of the bundle when its registration is restored, for the instance returned by localShortInstanceID
+// otherwise. The existing state of a restored category is only replaced when force is true.
+func Import(r io.Reader, excluded map[string]bool, force bool, localShortInstanceID func() (string, error)) (manifest Manifest, err error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, fmt.Errorf("the bundle is not a gzip archive: %v", err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	header, err := tarReader.Next()
+	if err != nil || header.Name != manifestName {
+		return manifest, fmt.Errorf("the bundle has no %v", manifestName)
+	}
+	if err = json.NewDecoder(tarReader).Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("the bundle manifest cannot be parsed: %v", err)
+	}
+	if manifest.FormatVersion > FormatVersion {
+		return manifest, fmt.Errorf("the bundle has format version %d, this agent supports version %d", manifest.FormatVersion, FormatVersion)
+	}
+
+	restored := make(map[string]bool)
+	for _, category := range manifest.Categories {
+		if !excluded[category] {
+			restored[category] = true
+		}
+	}
+	shortInstanceID := manifest.ShortInstanceID
+	if restored[CategoryAssociation] && !restored[CategoryRegistration] {
+		if shortInstanceID, err = localShortInstanceID(); err != nil {
+			return manifest, fmt.Errorf("the instance id is needed to import the %v state: %v", CategoryAssociation, err)
+		}
+	}
+	// the instance id names the folder of the restored association state, it must not escape the data store
+	if restored[CategoryAssociation] && !shortInstanceIDPattern.MatchString(shortInstanceID) {
+		return manifest, fmt.Errorf("%q is not a valid instance id", shortInstanceID)
+	}
+
+	targets := make(map[string]string)
+	for _, loc := range locations {
+		if !restored[loc.category] {
+			continue
+		}
+		targets[loc.name] = loc.path(shortInstanceID)
+		if (hasContent(loc, targets[loc.name]) || hasStates(databaseStates(loc, shortInstanceID))) && !force {
+			return manifest, fmt.Errorf("%v state already exists in %v, use force to replace it", loc.category, targets[loc.name])
+		}
+	}
+	for _, loc := range locations {
+		if target, found := targets[loc.name]; found {
+			if err = clearLocation(loc, target); err != nil {
+				return manifest, fmt.Errorf("failed to replace %v: %v", target, err)
+			}
+			if err = clearStates(databaseStates(loc, shortInstanceID)); err != nil {
+				return manifest, fmt.Errorf("failed to replace the %v states of the database: %v", loc.name, err)
+			}
+		}
+	}
+
+	for {
+		if header, err = tarReader.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return manifest, fmt.Errorf("the bundle cannot be read: %v", err)
+		}
+		loc, target, err := entryTarget(header.Name, targets)
+		if err != nil {
+			return manifest, err
+		}
+		if loc == nil {
+			// the category is excluded
+			continue
+		}
+		if err = importEntry(tarReader, header, *loc, target); err != nil {
+			return manifest, fmt.Errorf("failed to restore %v: %v", target, err)
+		}
+	}
+	manifest.Categories = manifest.Categories[:0]
+	for _, category := range Categories {
+		if restored[category] {
+			manifest.Categories = append(manifest.Categories, category)
+		}
+	}
+	manifest.ShortInstanceID = shortInstanceID
+	return manifest, nil
+}
+
+// entryTarget returns the location of a bundle entry and the path it is restored to
+func entryTarget(name string, targets map[string]string) (*location, string, error) {
+	for i, loc := range locations {
+		if name != loc.name && name != loc.name+"/" && !strings.HasPrefix(name, loc.name+"/") {
+			continue
+		}
+		target, found := targets[loc.name]
+		if !found {
+			return nil, "", nil
+		}
+		relativePath := strings.TrimPrefix(strings.TrimPrefix(name, loc.name), "/")
+		if relativePath == "" {
+			return &locations[i], target, nil
+		}
+		cleaned := path.Clean(relativePath)
+		if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return nil, "", fmt.Errorf("the bundle entry %v is outside of its location", name)
+		}
+		if loc.include != nil && !loc.include(filepath.FromSlash(cleaned)) {
+			return nil, "", fmt.Errorf("the bundle entry %v does not belong to %v", name, loc.category)
+		}
+		return &locations[i], filepath.Join(target, filepath.FromSlash(cleaned)), nil
+	}
+	return nil, "", fmt.Errorf("the bundle entry %v is unknown", name)
+}
+
+// importEntry writes a directory or a file of the bundle
+func importEntry(tarReader *tar.Reader, header *tar.Header, loc location, target string) error {
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, appconfig.ReadWriteExecuteAccess)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(target), appconfig.ReadWriteExecuteAccess); err != nil {
+			return err
+		}
+		if loc.hardened {
+			content, err := ioutil.ReadAll(io.LimitReader(tarReader, maxHardenedFileContent))
+			if err != nil {
+				return err
+			}
+			return fileutil.HardenedWriteFile(target, content)
+		}
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, appconfig.ReadWriteAccess)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(file, tarReader); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	default:
+		return fmt.Errorf("the bundle entry %v is not a file or a directory", header.Name)
+	}
+}
+
+// hasContent returns true if the location holds files of its category
+func hasContent(loc location, root string) (found bool) {
+	filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		relativePath, _ := filepath.Rel(root, filePath)
+		if loc.include == nil || relativePath == "." || loc.include(relativePath) {
+			found = true
+			return io.EOF
+		}
+		return nil
+	})
+	return found
+}
+
+// hasStates returns true if the database holds states of the location
+func hasStates(folder statestore.Folder) bool {
+	if folder == nil {
+		return false
+	}
+	names, _ := folder.Names()
+	return len(names) > 0
+}
+
+// clearStates deletes the states of the location from the database
+func clearStates(folder statestore.Folder) error {
+	if folder == nil {
+		return nil
+	}
+	names, err := folder.Names()
+	for _, name := range names {
+		if err == nil {
+			err = folder.Delete(name)
+		}
+	}
+	return err
+}
+
+// clearLocation deletes the files of the location category
+func clearLocation(loc location, root string) error {
+	if loc.include == nil {
+		return os.RemoveAll(root)
+	}
+	var files []string
+	filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			if relativePath, _ := filepath.Rel(root, filePath); loc.include(relativePath) {
+				files = append(files, filePath)
+			}
+		}
+		return nil
+	})
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package statebundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/statestore"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	sourceInstanceID = "mi-0123456789abcdef0"
+	cloneInstanceID  = "mi-0fedcba9876543210"
+)
+
+// setupDataStore points the data store of the agent to a temporary directory
+func setupDataStore(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "statebundle")
+	assert.NoError(t, err)
+	originalDataStorePath := appconfig.DefaultDataStorePath
+	t.Cleanup(func() {
+		appconfig.DefaultDataStorePath = originalDataStorePath
+		os.RemoveAll(dir)
+	})
+	appconfig.DefaultDataStorePath = dir
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), appconfig.ReadWriteExecuteAccess))
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), appconfig.ReadWriteAccess))
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+// writeSourceState writes the registration, the fingerprint and the association state of the source instance
+func writeSourceState(t *testing.T, dir string) {
+	writeFile(t, filepath.Join(dir, "Vault", "Manifest"), "manifest")
+	writeFile(t, filepath.Join(dir, "Vault", "Store", "RegistrationKey"), "key")
+	writeFile(t, filepath.Join(dir, "Vault", "Store", "InstanceFingerprint"), "fingerprint")
+	writeFile(t, filepath.Join(dir, "registration"), "registration")
+	writeFile(t, filepath.Join(dir, sourceInstanceID, "document", "association", "document"), "association")
+	writeFile(t, filepath.Join(dir, sourceInstanceID, "documentcache", "cached.json"), "cached")
+}
+
+func noLocalInstanceID() (string, error) {
+	return "", errors.New("not registered")
+}
+
+func TestExportImport_DisasterRecovery(t *testing.T) {
+	dir := setupDataStore(t)
+	writeSourceState(t, dir)
+	var bundle bytes.Buffer
+	manifest, err := Export(&bundle, sourceInstanceID, map[string]bool{})
+	assert.NoError(t, err)
+	assert.Equal(t, Categories, manifest.Categories)
+
+	// restore on a new host
+	assert.NoError(t, os.RemoveAll(dir))
+	manifest, err = Import(bytes.NewReader(bundle.Bytes()), map[string]bool{}, false, noLocalInstanceID)
+	assert.NoError(t, err)
+	assert.Equal(t, sourceInstanceID, manifest.ShortInstanceID)
+	assert.Equal(t, "manifest", readFile(t, filepath.Join(dir, "Vault", "Manifest")))
+	assert.Equal(t, "key", readFile(t, filepath.Join(dir, "Vault", "Store", "RegistrationKey")))
+	assert.Equal(t, "fingerprint", readFile(t, filepath.Join(dir, "Vault", "Store", "InstanceFingerprint")))
+	assert.Equal(t, "registration", readFile(t, filepath.Join(dir, "registration")))
+	assert.Equal(t, "association", readFile(t, filepath.Join(dir, sourceInstanceID, "document", "association", "document")))
+	assert.Equal(t, "cached", readFile(t, filepath.Join(dir, sourceInstanceID, "documentcache", "cached.json")))
+}
+
+func TestExportImport_GoldenImageWithoutIdentity(t *testing.T) {
+	dir := setupDataStore(t)
+	writeSourceState(t, dir)
+	var bundle bytes.Buffer
+	excluded, err := ParseCategories([]string{"identity"})
+	assert.NoError(t, err)
+	manifest, err := Export(&bundle, sourceInstanceID, excluded)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{CategoryAssociation}, manifest.Categories)
+
+	// the clone has its own registration, the association state is restored for it
+	assert.NoError(t, os.RemoveAll(dir))
+	writeFile(t, filepath.Join(dir, "Vault", "Store", "RegistrationKey"), "clone key")
+	manifest, err = Import(bytes.NewReader(bundle.Bytes()), map[string]bool{}, false, func() (string, error) { return cloneInstanceID, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, cloneInstanceID, manifest.ShortInstanceID)
+	assert.Equal(t, "clone key", readFile(t, filepath.Join(dir, "Vault", "Store", "RegistrationKey")))
+	assert.NoFileExists(t, filepath.Join(dir, "registration"))
+	assert.Equal(t, "association", readFile(t, filepath.Join(dir, cloneInstanceID, "document", "association", "document")))
+}
+
+func TestImport_ExistingStateNeedsForce(t *testing.T) {
+	dir := setupDataStore(t)
+	writeSourceState(t, dir)
+	var bundle bytes.Buffer
+	_, err := Export(&bundle, sourceInstanceID, map[string]bool{CategoryAssociation: true})
+	assert.NoError(t, err)
+
+	writeFile(t, filepath.Join(dir, "Vault", "Store", "RegistrationKey"), "other key")
+	writeFile(t, filepath.Join(dir, "Vault", "Store", "EC2RegistrationKey"), "ec2 key")
+	_, err = Import(bytes.NewReader(bundle.Bytes()), map[string]bool{}, false, noLocalInstanceID)
+	assert.Error(t, err)
+	assert.Equal(t, "other key", readFile(t, filepath.Join(dir, "Vault", "Store", "RegistrationKey")))
+
+	// the fingerprint is kept when only the registration is replaced
+	writeFile(t, filepath.Join(dir, "Vault", "Store", "InstanceFingerprint"), "local fingerprint")
+	_, err = Import(bytes.NewReader(bundle.Bytes()), map[string]bool{CategoryFingerprint: true}, true, noLocalInstanceID)
+	assert.NoError(t, err)
+	assert.Equal(t, "key", readFile(t, filepath.Join(dir, "Vault", "Store", "RegistrationKey")))
+	assert.NoFileExists(t, filepath.Join(dir, "Vault", "Store", "EC2RegistrationKey"))
+	assert.Equal(t, "local fingerprint", readFile(t, filepath.Join(dir, "Vault", "Store", "InstanceFingerprint")))
+}
+
+func TestExportImport_AssociationStateInDatabase(t *testing.T) {
+	dir := setupDataStore(t)
+	config := appconfig.DefaultConfig()
+	config.Storage.StateBackend = appconfig.StateBackendBoltDB
+	previousConfig := stateBackendConfig
+	stateBackendConfig = func() appconfig.SsmagentConfig { return config }
+	defer func() { stateBackendConfig = previousConfig }()
+
+	sourceStates := statestore.NewFolder(config, sourceInstanceID, "document", "association")
+	assert.NoError(t, sourceStates.Write("InstanceDocument.json", []byte("association")))
+	var bundle bytes.Buffer
+	_, err := Export(&bundle, sourceInstanceID, map[string]bool{CategoryRegistration: true, CategoryFingerprint: true})
+	assert.NoError(t, err)
+
+	// the existing state of the clone is in its database
+	cloneStates := statestore.NewFolder(config, cloneInstanceID, "document", "association")
+	assert.NoError(t, cloneStates.Write("InstanceDocument.json", []byte("clone association")))
+	localInstanceID := func() (string, error) { return cloneInstanceID, nil }
+	_, err = Import(bytes.NewReader(bundle.Bytes()), map[string]bool{}, false, localInstanceID)
+	assert.Error(t, err)
+
+	_, err = Import(bytes.NewReader(bundle.Bytes()), map[string]bool{}, true, localInstanceID)
+	assert.NoError(t, err)
+	// the restored state is moved to the database when the agent starts
+	assert.Equal(t, "association", readFile(t, filepath.Join(dir, cloneInstanceID, "document", "association", "InstanceDocument.json")))
+	names, err := cloneStates.Names()
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestImport_EntryOutsideOfLocationRejected(t *testing.T) {
+	dir := setupDataStore(t)
+	var bundle bytes.Buffer
+	gzipWriter := gzip.NewWriter(&bundle)
+	tarWriter := tar.NewWriter(gzipWriter)
+	manifest := []byte(`{"FormatVersion":1,"Categories":["registration"]}`)
+	assert.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: manifestName, Mode: 0600, Size: int64(len(manifest)), Typeflag: tar.TypeReg}))
+	_, _ = tarWriter.Write(manifest)
+	assert.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "registration/vault/../../escaped", Mode: 0600, Size: 1, Typeflag: tar.TypeReg}))
+	_, _ = tarWriter.Write([]byte("x"))
+	assert.NoError(t, tarWriter.Close())
+	assert.NoError(t, gzipWriter.Close())
+
+	_, err := Import(bytes.NewReader(bundle.Bytes()), map[string]bool{}, false, noLocalInstanceID)
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(dir), "escaped"))
+}
+
+func TestImport_InvalidInstanceIDRejected(t *testing.T) {
+	dir := setupDataStore(t)
+	writeFile(t, filepath.Join(filepath.Dir(dir), "outside", "documentcache", "kept"), "kept")
+	defer os.RemoveAll(filepath.Join(filepath.Dir(dir), "outside"))
+
+	for _, instanceID := range []string{"../outside", "..", "mi-0123456789abcdef0/../../outside", ""} {
+		var bundle bytes.Buffer
+		gzipWriter := gzip.NewWriter(&bundle)
+		tarWriter := tar.NewWriter(gzipWriter)
+		manifest, _ := json.Marshal(Manifest{FormatVersion: FormatVersion, ShortInstanceID: instanceID, Categories: []string{CategoryRegistration, CategoryAssociation}})
+		assert.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: manifestName, Mode: 0600, Size: int64(len(manifest)), Typeflag: tar.TypeReg}))
+		_, _ = tarWriter.Write(manifest)
+		assert.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "association/documentcache/cached.json", Mode: 0600, Size: 1, Typeflag: tar.TypeReg}))
+		_, _ = tarWriter.Write([]byte("x"))
+		assert.NoError(t, tarWriter.Close())
+		assert.NoError(t, gzipWriter.Close())
+
+		_, err := Import(bytes.NewReader(bundle.Bytes()), map[string]bool{}, true, noLocalInstanceID)
+		assert.Error(t, err, instanceID)
+		assert.Equal(t, "kept", readFile(t, filepath.Join(filepath.Dir(dir), "outside", "documentcache", "kept")))
+	}
+}
+
+func TestParseCategories(t *testing.T) {
+	categories, err := ParseCategories([]string{"Identity", "association"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{CategoryRegistration: true, CategoryFingerprint: true, CategoryAssociation: true}, categories)
+
+	_, err = ParseCategories([]string{"logs"})
+	assert.Error(t, err)
+}
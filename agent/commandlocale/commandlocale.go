@@ -0,0 +1,74 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package commandlocale forces the locale of the commands executed by the agent, so that the plugins parsing their
+// output do not depend on the language of the operating system.
+package commandlocale
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+const (
+	envVarLang  = "LANG"
+	envVarLcAll = "LC_ALL"
+)
+
+var (
+	// localeRegex matches POSIX locale names such as C, POSIX, C.UTF-8 or en_US.UTF-8@euro
+	localeRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z0-9_-]+)?(@[A-Za-z0-9_]+)?$`)
+	// cultureRegex matches .NET culture names such as en-US or zh-Hans-CN
+	cultureRegex = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+)
+
+// Validate returns an error if the locale or the culture is not a valid name
+func Validate(locale appconfig.CommandLocale) error {
+	if locale.Locale != "" && !localeRegex.MatchString(locale.Locale) {
+		return fmt.Errorf("locale %v is not a valid locale name", locale.Locale)
+	}
+	if locale.Culture != "" && !cultureRegex.MatchString(locale.Culture) {
+		return fmt.Errorf("culture %v is not a valid culture name", locale.Culture)
+	}
+	return nil
+}
+
+// Environment returns the environment variables setting the locale of a command, nothing is returned when no
+// locale is configured
+func Environment(locale appconfig.CommandLocale) map[string]string {
+	if locale.Locale == "" {
+		return nil
+	}
+	return map[string]string{
+		envVarLang:  locale.Locale,
+		envVarLcAll: locale.Locale,
+	}
+}
+
+// PowerShellPreamble returns the commands setting the culture and UI culture of a PowerShell script, the default
+// thread cultures are set as well since Windows PowerShell restores the culture of the thread after each pipeline
+func PowerShellPreamble(locale appconfig.CommandLocale) []string {
+	if locale.Culture == "" {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("$ssmCommandCulture = [System.Globalization.CultureInfo]::GetCultureInfo('%v')", locale.Culture),
+		"[System.Globalization.CultureInfo]::DefaultThreadCurrentCulture = $ssmCommandCulture",
+		"[System.Globalization.CultureInfo]::DefaultThreadCurrentUICulture = $ssmCommandCulture",
+		"[System.Threading.Thread]::CurrentThread.CurrentCulture = $ssmCommandCulture",
+		"[System.Threading.Thread]::CurrentThread.CurrentUICulture = $ssmCommandCulture",
+		"Remove-Variable -Name ssmCommandCulture",
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package commandlocale
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(appconfig.CommandLocale{}))
+	assert.NoError(t, Validate(appconfig.CommandLocale{Locale: "C"}))
+	assert.NoError(t, Validate(appconfig.CommandLocale{Locale: "C.UTF-8", Culture: "en-US"}))
+	assert.NoError(t, Validate(appconfig.CommandLocale{Locale: "de_DE.ISO-8859-15@euro", Culture: "zh-Hans-CN"}))
+
+	assert.Error(t, Validate(appconfig.CommandLocale{Locale: "en_US.UTF-8; rm -rf /"}))
+	assert.Error(t, Validate(appconfig.CommandLocale{Culture: "en-US'); Remove-Item C:\\ #"}))
+	assert.Error(t, Validate(appconfig.CommandLocale{Culture: "e"}))
+}
+
+func TestEnvironment(t *testing.T) {
+	assert.Nil(t, Environment(appconfig.CommandLocale{Culture: "en-US"}))
+	assert.Equal(t, map[string]string{"LANG": "C.UTF-8", "LC_ALL": "C.UTF-8"}, Environment(appconfig.CommandLocale{Locale: "C.UTF-8"}))
+}
+
+func TestPowerShellPreamble(t *testing.T) {
+	assert.Nil(t, PowerShellPreamble(appconfig.CommandLocale{Locale: "C.UTF-8"}))
+
+	preamble := PowerShellPreamble(appconfig.CommandLocale{Culture: "en-US"})
+	assert.Contains(t, preamble[0], "GetCultureInfo('en-US')")
+	assert.Contains(t, strings.Join(preamble, "\n"), "CurrentThread.CurrentUICulture")
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package commandsandbox runs the commands executed by the plugins in Linux namespaces, with a read-only root file
+// system and explicit bind mounts, or with a low integrity or AppContainer token on Windows, so that Run Command
+// scripts can be contained without a container runtime.
+package commandsandbox
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// capabilitySids maps the lower cased names of the AppContainer capabilities to their well known SIDs
+var capabilitySids = map[string]string{
+	"internetclient":             "S-1-15-3-1",
+	"internetclientserver":       "S-1-15-3-2",
+	"privatenetworkclientserver": "S-1-15-3-3",
+	"pictureslibrary":            "S-1-15-3-4",
+	"videoslibrary":              "S-1-15-3-5",
+	"musiclibrary":               "S-1-15-3-6",
+	"documentslibrary":           "S-1-15-3-7",
+	"enterpriseauthentication":   "S-1-15-3-8",
+	"sharedusercertificates":     "S-1-15-3-9",
+	"removablestorage":           "S-1-15-3-10",
+}
+
+// SelectedProfile returns the profile the commands run in, nil when the commands run without sandbox.
+// An error is returned when the profile is missing or invalid, the command must not run in that case.
+func SelectedProfile(sandbox appconfig.CommandSandbox) (*appconfig.CommandSandboxProfile, error) {
+	if sandbox.DefaultProfile == "" {
+		return nil, nil
+	}
+	for i := range sandbox.Profiles {
+		if sandbox.Profiles[i].Name == sandbox.DefaultProfile {
+			profile := sandbox.Profiles[i]
+			if err := Validate(profile); err != nil {
+				return nil, fmt.Errorf("sandbox profile %v is invalid: %v", profile.Name, err)
+			}
+			return &profile, nil
+		}
+	}
+	return nil, fmt.Errorf("sandbox profile %v is not configured", sandbox.DefaultProfile)
+}
+
+// Validate returns an error if the profile cannot isolate the commands as configured
+func Validate(profile appconfig.CommandSandboxProfile) error {
+	if len(profile.Namespaces) == 0 && profile.IntegrityLevel == "" && !profile.AppContainer {
+		return fmt.Errorf("no namespace, integrity level or AppContainer is configured")
+	}
+	for _, namespace := range profile.Namespaces {
+		switch namespace {
+		case appconfig.CommandSandboxNamespaceMount, appconfig.CommandSandboxNamespacePid, appconfig.CommandSandboxNamespaceNetwork:
+		default:
+			return fmt.Errorf("namespace %v is not supported", namespace)
+		}
+	}
+	if (profile.ReadOnlyRoot || len(profile.BindMounts) > 0) && !hasNamespace(profile, appconfig.CommandSandboxNamespaceMount) {
+		return fmt.Errorf("the read-only root and the bind mounts require the %v namespace", appconfig.CommandSandboxNamespaceMount)
+	}
+	for _, bindMount := range profile.BindMounts {
+		for _, path := range []string{bindMount.Source, bindMount.Destination} {
+			if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+				return fmt.Errorf("bind mount path %q must be a clean absolute path", path)
+			}
+		}
+	}
+	if profile.IntegrityLevel != "" && profile.IntegrityLevel != appconfig.CommandSandboxIntegrityLevelLow {
+		return fmt.Errorf("integrity level %v is not supported", profile.IntegrityLevel)
+	}
+	if len(profile.Capabilities) > 0 && !profile.AppContainer {
+		return fmt.Errorf("the capabilities require an AppContainer")
+	}
+	for _, capability := range profile.Capabilities {
+		if _, err := capabilitySid(capability); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// capabilitySid returns the SID of a capability given by name or SID
+func capabilitySid(capability string) (string, error) {
+	if strings.HasPrefix(strings.ToUpper(capability), "S-1-15-3-") {
+		return strings.ToUpper(capability), nil
+	}
+	if sid, found := capabilitySids[strings.ToLower(capability)]; found {
+		return sid, nil
+	}
+	return "", fmt.Errorf("capability %v is not supported", capability)
+}
+
+// hasWindowsSettings returns true if the profile isolates the commands with their Windows token
+func hasWindowsSettings(profile appconfig.CommandSandboxProfile) bool {
+	return profile.IntegrityLevel != "" || profile.AppContainer
+}
+
+// hasLinuxSettings returns true if the profile isolates the commands in Linux namespaces
+func hasLinuxSettings(profile appconfig.CommandSandboxProfile) bool {
+	return len(profile.Namespaces) > 0 || profile.ReadOnlyRoot || len(profile.BindMounts) > 0
+}
+
+func hasNamespace(profile appconfig.CommandSandboxProfile, namespace string) bool {
+	for _, profileNamespace := range profile.Namespaces {
+		if profileNamespace == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteShString quotes a string for the sandbox setup script
+func quoteShString(str string) string {
+	return "'" + strings.Replace(str, "'", `'\''`, -1) + "'"
+}
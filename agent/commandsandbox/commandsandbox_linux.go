@@ -0,0 +1,85 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package commandsandbox
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+const unshareCommand = "unshare"
+
+var lookPath = exec.LookPath
+
+// Prepare changes the command to run it in the namespaces of the profile. The command is started by unshare and a
+// setup script mounting the bind mounts and remounting the root read-only before it execs the command.
+func Prepare(profile *appconfig.CommandSandboxProfile, command *exec.Cmd) (release func(), err error) {
+	if profile == nil {
+		return func() {}, nil
+	}
+	if hasWindowsSettings(*profile) {
+		return nil, fmt.Errorf("sandbox profile %v isolates the Windows token of the commands, it cannot be used on Linux", profile.Name)
+	}
+	unshare, err := lookPath(unshareCommand)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox profile %v requires %v: %v", profile.Name, unshareCommand, err)
+	}
+
+	var arguments []string
+	if hasNamespace(*profile, appconfig.CommandSandboxNamespaceMount) {
+		arguments = append(arguments, "--mount", "--propagation", "private")
+	}
+	if hasNamespace(*profile, appconfig.CommandSandboxNamespacePid) {
+		// the command is the init process of the namespace, /proc is mounted again to only show its processes
+		arguments = append(arguments, "--pid", "--fork")
+		if hasNamespace(*profile, appconfig.CommandSandboxNamespaceMount) {
+			arguments = append(arguments, "--mount-proc")
+		}
+	}
+	if hasNamespace(*profile, appconfig.CommandSandboxNamespaceNetwork) {
+		arguments = append(arguments, "--net")
+	}
+	arguments = append(arguments, "--", "/bin/sh", "-c", setupScript(*profile), "ssm-sandbox", command.Path)
+	command.Args = append(append([]string{unshare}, arguments...), command.Args[1:]...)
+	command.Path = unshare
+	return func() {}, nil
+}
+
+// setupScript returns the script preparing the namespaces before it execs the command given as arguments
+func setupScript(profile appconfig.CommandSandboxProfile) string {
+	lines := []string{"set -e"}
+	for _, bindMount := range profile.BindMounts {
+		lines = append(lines, fmt.Sprintf("mount --bind %v %v", quoteShString(bindMount.Source), quoteShString(bindMount.Destination)))
+		mode := "rw"
+		if bindMount.ReadOnly {
+			mode = "ro"
+		}
+		// bind mounts inherit the read-only flag of the source mount
+		lines = append(lines, fmt.Sprintf("mount -o remount,bind,%v %v", mode, quoteShString(bindMount.Destination)))
+	}
+	if profile.ReadOnlyRoot {
+		lines = append(lines, "mount -o remount,bind,ro /")
+	}
+	if hasNamespace(profile, appconfig.CommandSandboxNamespaceNetwork) {
+		lines = append(lines, "if command -v ip >/dev/null 2>&1; then ip link set lo up; fi")
+	}
+	lines = append(lines, `exec "$@"`)
+	return strings.Join(lines, "\n")
+}
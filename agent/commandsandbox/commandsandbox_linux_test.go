@@ -0,0 +1,102 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package commandsandbox
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeLookPath(t *testing.T, err error) {
+	originalLookPath := lookPath
+	lookPath = func(file string) (string, error) {
+		return "/usr/bin/" + file, err
+	}
+	t.Cleanup(func() { lookPath = originalLookPath })
+}
+
+func TestPrepare_NoProfile(t *testing.T) {
+	command := exec.Command("/bin/sh", "-c", "id")
+
+	release, err := Prepare(nil, command)
+
+	assert.NoError(t, err)
+	release()
+	assert.Equal(t, "/bin/sh", command.Path)
+	assert.Equal(t, []string{"/bin/sh", "-c", "id"}, command.Args)
+}
+
+func TestPrepare_AllNamespaces(t *testing.T) {
+	fakeLookPath(t, nil)
+	profile := &appconfig.CommandSandboxProfile{
+		Name:         "default",
+		Namespaces:   []string{appconfig.CommandSandboxNamespaceMount, appconfig.CommandSandboxNamespacePid, appconfig.CommandSandboxNamespaceNetwork},
+		ReadOnlyRoot: true,
+		BindMounts:   []appconfig.CommandSandboxBindMount{{Source: "/var/lib/scripts", Destination: "/opt/scripts", ReadOnly: true}},
+	}
+
+	command := exec.Command("/bin/sh", "-c", "id")
+
+	_, err := Prepare(profile, command)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/usr/bin/unshare", command.Path)
+	assert.Equal(t, []string{"/usr/bin/unshare", "--mount", "--propagation", "private", "--pid", "--fork", "--mount-proc", "--net",
+		"--", "/bin/sh", "-c", setupScript(*profile), "ssm-sandbox", "/bin/sh", "-c", "id"}, command.Args)
+}
+
+func TestPrepare_UnshareMissing(t *testing.T) {
+	fakeLookPath(t, fmt.Errorf("not found"))
+
+	_, err := Prepare(&appconfig.CommandSandboxProfile{Name: "default", Namespaces: []string{appconfig.CommandSandboxNamespacePid}}, exec.Command("/bin/sh"))
+
+	assert.Error(t, err)
+}
+
+func TestPrepare_WindowsSettings(t *testing.T) {
+	fakeLookPath(t, nil)
+	command := exec.Command("/bin/sh")
+
+	_, err := Prepare(&appconfig.CommandSandboxProfile{Name: "lowbox", IntegrityLevel: appconfig.CommandSandboxIntegrityLevelLow}, command)
+
+	assert.Error(t, err)
+	assert.Equal(t, "/bin/sh", command.Path)
+}
+
+func TestSetupScript(t *testing.T) {
+	profile := appconfig.CommandSandboxProfile{
+		Namespaces:   []string{appconfig.CommandSandboxNamespaceMount, appconfig.CommandSandboxNamespaceNetwork},
+		ReadOnlyRoot: true,
+		BindMounts: []appconfig.CommandSandboxBindMount{
+			{Source: "/var/lib/scripts", Destination: "/opt/scripts", ReadOnly: true},
+			{Source: "/var/tmp/it's", Destination: "/tmp"},
+		},
+	}
+
+	assert.Equal(t, `set -e
+mount --bind '/var/lib/scripts' '/opt/scripts'
+mount -o remount,bind,ro '/opt/scripts'
+mount --bind '/var/tmp/it'\''s' '/tmp'
+mount -o remount,bind,rw '/tmp'
+mount -o remount,bind,ro /
+if command -v ip >/dev/null 2>&1; then ip link set lo up; fi
+exec "$@"`, setupScript(profile))
+}
@@ -0,0 +1,33 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package commandsandbox
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// Prepare leaves the command unchanged when no profile is selected, the sandbox relies on Linux namespaces and
+// Windows tokens and the commands selecting a profile fail on the other platforms
+func Prepare(profile *appconfig.CommandSandboxProfile, command *exec.Cmd) (release func(), err error) {
+	if profile == nil {
+		return func() {}, nil
+	}
+	return nil, fmt.Errorf("sandbox profile %v cannot be used, the command sandbox is only supported on Linux and Windows", profile.Name)
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package commandsandbox
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectedProfile(t *testing.T) {
+	profiles := []appconfig.CommandSandboxProfile{
+		{Name: "default", Namespaces: []string{appconfig.CommandSandboxNamespaceMount}, ReadOnlyRoot: true},
+		{Name: "invalid", Namespaces: []string{"User"}},
+	}
+
+	profile, err := SelectedProfile(appconfig.CommandSandbox{Profiles: profiles})
+	assert.NoError(t, err)
+	assert.Nil(t, profile)
+
+	profile, err = SelectedProfile(appconfig.CommandSandbox{DefaultProfile: "default", Profiles: profiles})
+	assert.NoError(t, err)
+	assert.Equal(t, profiles[0], *profile)
+
+	_, err = SelectedProfile(appconfig.CommandSandbox{DefaultProfile: "invalid", Profiles: profiles})
+	assert.Error(t, err)
+
+	_, err = SelectedProfile(appconfig.CommandSandbox{DefaultProfile: "missing", Profiles: profiles})
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	mount := []string{appconfig.CommandSandboxNamespaceMount}
+	testCases := []struct {
+		name    string
+		profile appconfig.CommandSandboxProfile
+		valid   bool
+	}{
+		{"NoNamespace", appconfig.CommandSandboxProfile{}, false},
+		{"UnsupportedNamespace", appconfig.CommandSandboxProfile{Namespaces: []string{"User"}}, false},
+		{"AllNamespaces", appconfig.CommandSandboxProfile{Namespaces: []string{appconfig.CommandSandboxNamespaceMount, appconfig.CommandSandboxNamespacePid, appconfig.CommandSandboxNamespaceNetwork}}, true},
+		{"ReadOnlyRootWithoutMount", appconfig.CommandSandboxProfile{Namespaces: []string{appconfig.CommandSandboxNamespacePid}, ReadOnlyRoot: true}, false},
+		{"BindMount", appconfig.CommandSandboxProfile{Namespaces: mount, BindMounts: []appconfig.CommandSandboxBindMount{{Source: "/var/scripts", Destination: "/opt/scripts"}}}, true},
+		{"RelativeBindMount", appconfig.CommandSandboxProfile{Namespaces: mount, BindMounts: []appconfig.CommandSandboxBindMount{{Source: "scripts", Destination: "/opt/scripts"}}}, false},
+		{"LowIntegrity", appconfig.CommandSandboxProfile{IntegrityLevel: appconfig.CommandSandboxIntegrityLevelLow}, true},
+		{"UnsupportedIntegrityLevel", appconfig.CommandSandboxProfile{IntegrityLevel: "Untrusted"}, false},
+		{"AppContainer", appconfig.CommandSandboxProfile{AppContainer: true, Capabilities: []string{"internetClient", "S-1-15-3-1024-1"}}, true},
+		{"CapabilitiesWithoutAppContainer", appconfig.CommandSandboxProfile{IntegrityLevel: appconfig.CommandSandboxIntegrityLevelLow, Capabilities: []string{"internetClient"}}, false},
+		{"UnknownCapability", appconfig.CommandSandboxProfile{AppContainer: true, Capabilities: []string{"lpacCom"}}, false},
+		{"UncleanBindMount", appconfig.CommandSandboxProfile{Namespaces: mount, BindMounts: []appconfig.CommandSandboxBindMount{{Source: "/var/scripts", Destination: "/opt/../etc"}}}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.valid, Validate(tc.profile) == nil)
+		})
+	}
+}
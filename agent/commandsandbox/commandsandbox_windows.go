@@ -0,0 +1,200 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package commandsandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// appContainerPrefix prefixes the names of the AppContainers of the sandbox profiles
+	appContainerPrefix = "AmazonSSMAgent.Sandbox."
+
+	disableMaxPrivilege = 0x1
+)
+
+var (
+	advapi32                          = windows.NewLazySystemDLL("advapi32.dll")
+	procCreateRestrictedToken         = advapi32.NewProc("CreateRestrictedToken")
+	userenv                           = windows.NewLazySystemDLL("userenv.dll")
+	procDeriveAppContainerSidFromName = userenv.NewProc("DeriveAppContainerSidFromAppContainerName")
+	ntdll                             = windows.NewLazySystemDLL("ntdll.dll")
+	procNtCreateLowBoxToken           = ntdll.NewProc("NtCreateLowBoxToken")
+)
+
+// Prepare sets the token the command is started with. The privileges of the token of the agent are removed and its
+// integrity level lowered, or it is turned into the token of the AppContainer of the profile. The AppContainer is
+// granted the access to the files given as arguments, e.g. the script, since it cannot read the agent folders.
+func Prepare(profile *appconfig.CommandSandboxProfile, command *exec.Cmd) (release func(), err error) {
+	if profile == nil {
+		return func() {}, nil
+	}
+	if hasLinuxSettings(*profile) {
+		return nil, fmt.Errorf("sandbox profile %v uses Linux namespaces, it cannot be used on Windows", profile.Name)
+	}
+
+	token, packageSid, err := restrictedToken(*profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the token of sandbox profile %v: %v", profile.Name, err)
+	}
+	if packageSid != nil {
+		for _, argument := range command.Args[1:] {
+			if !filepath.IsAbs(argument) {
+				continue
+			}
+			if info, statErr := os.Stat(argument); statErr != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if err = grantFileAccess(argument, packageSid); err != nil {
+				token.Close()
+				return nil, fmt.Errorf("failed to grant sandbox profile %v access to %v: %v", profile.Name, argument, err)
+			}
+		}
+	}
+
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.Token = syscall.Token(token)
+	return func() { token.Close() }, nil
+}
+
+// restrictedToken returns the primary token of the commands of the profile and the SID of its AppContainer, if any
+func restrictedToken(profile appconfig.CommandSandboxProfile) (token windows.Token, packageSid *windows.SID, err error) {
+	var processToken windows.Token
+	if err = windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_ALL_ACCESS, &processToken); err != nil {
+		return 0, nil, err
+	}
+	defer processToken.Close()
+
+	if ret, _, callErr := procCreateRestrictedToken.Call(
+		uintptr(processToken),
+		disableMaxPrivilege,
+		0, 0, 0, 0, 0, 0,
+		uintptr(unsafe.Pointer(&token))); ret == 0 {
+		return 0, nil, fmt.Errorf("CreateRestrictedToken failed: %v", callErr)
+	}
+
+	if profile.IntegrityLevel == appconfig.CommandSandboxIntegrityLevelLow {
+		if err = setLowIntegrityLevel(token); err != nil {
+			token.Close()
+			return 0, nil, err
+		}
+	}
+	if !profile.AppContainer {
+		return token, nil, nil
+	}
+
+	lowBoxToken, packageSid, err := lowBoxToken(token, profile)
+	token.Close()
+	if err != nil {
+		return 0, nil, err
+	}
+	return lowBoxToken, packageSid, nil
+}
+
+// setLowIntegrityLevel sets the mandatory label of the token to the low integrity level
+func setLowIntegrityLevel(token windows.Token) error {
+	lowSid, err := windows.CreateWellKnownSid(windows.WinLowLabelSid)
+	if err != nil {
+		return err
+	}
+	label := windows.Tokenmandatorylabel{Label: windows.SIDAndAttributes{Sid: lowSid, Attributes: windows.SE_GROUP_INTEGRITY}}
+	return windows.SetTokenInformation(token, windows.TokenIntegrityLevel, (*byte)(unsafe.Pointer(&label)), label.Size())
+}
+
+// lowBoxToken returns the AppContainer token created from the given token with the capabilities of the profile
+func lowBoxToken(token windows.Token, profile appconfig.CommandSandboxProfile) (windows.Token, *windows.SID, error) {
+	name, err := windows.UTF16PtrFromString(appContainerPrefix + profile.Name)
+	if err != nil {
+		return 0, nil, err
+	}
+	var derivedSid *windows.SID
+	if ret, _, _ := procDeriveAppContainerSidFromName.Call(uintptr(unsafe.Pointer(name)), uintptr(unsafe.Pointer(&derivedSid))); ret != 0 {
+		return 0, nil, fmt.Errorf("DeriveAppContainerSidFromAppContainerName failed: %v", syscall.Errno(ret))
+	}
+	packageSid, err := derivedSid.Copy()
+	windows.FreeSid(derivedSid)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	capabilities := make([]windows.SIDAndAttributes, 0, len(profile.Capabilities))
+	for _, capability := range profile.Capabilities {
+		sidString, err := capabilitySid(capability)
+		if err != nil {
+			return 0, nil, err
+		}
+		sid, err := windows.StringToSid(sidString)
+		if err != nil {
+			return 0, nil, err
+		}
+		capabilities = append(capabilities, windows.SIDAndAttributes{Sid: sid, Attributes: windows.SE_GROUP_ENABLED})
+	}
+	var capabilitiesPtr uintptr
+	if len(capabilities) > 0 {
+		capabilitiesPtr = uintptr(unsafe.Pointer(&capabilities[0]))
+	}
+
+	var lowBoxToken windows.Token
+	if status, _, _ := procNtCreateLowBoxToken.Call(
+		uintptr(unsafe.Pointer(&lowBoxToken)),
+		uintptr(token),
+		windows.TOKEN_ALL_ACCESS,
+		0,
+		uintptr(unsafe.Pointer(packageSid)),
+		uintptr(len(capabilities)),
+		capabilitiesPtr,
+		0, 0); status != 0 {
+		return 0, nil, fmt.Errorf("NtCreateLowBoxToken failed with status 0x%x", status)
+	}
+	return lowBoxToken, packageSid, nil
+}
+
+// grantFileAccess grants the AppContainer read and execute access to the file
+func grantFileAccess(path string, packageSid *windows.SID) error {
+	securityDescriptor, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return err
+	}
+	dacl, _, err := securityDescriptor.DACL()
+	if err != nil {
+		return err
+	}
+	updatedDacl, err := windows.ACLFromEntries([]windows.EXPLICIT_ACCESS{{
+		AccessPermissions: windows.GENERIC_READ | windows.GENERIC_EXECUTE,
+		AccessMode:        windows.GRANT_ACCESS,
+		Inheritance:       windows.NO_INHERITANCE,
+		Trustee: windows.TRUSTEE{
+			TrusteeForm:  windows.TRUSTEE_IS_SID,
+			TrusteeType:  windows.TRUSTEE_IS_UNKNOWN,
+			TrusteeValue: windows.TrusteeValueFromSID(packageSid),
+		},
+	}}, dacl)
+	if err != nil {
+		return err
+	}
+	return windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION, nil, nil, updatedDacl, nil)
+}
@@ -82,3 +82,21 @@ func (c *defaultContext) AppConstants() *appconfig.AppConstants {
 func (c *defaultContext) Identity() identity.IAgentIdentity {
 	return c.identity
 }
+
+// WithAppConfig returns a context carrying the given agent configuration instead of the configuration of its parent
+func WithAppConfig(parent T, ssmAppconfig appconfig.SsmagentConfig) T {
+	return &appConfigContext{T: parent, appconfig: ssmAppconfig}
+}
+
+type appConfigContext struct {
+	T
+	appconfig appconfig.SsmagentConfig
+}
+
+func (c *appConfigContext) With(logContext string) T {
+	return WithAppConfig(c.T.With(logContext), c.appconfig)
+}
+
+func (c *appConfigContext) AppConfig() appconfig.SsmagentConfig {
+	return c.appconfig
+}
@@ -42,6 +42,8 @@ func prepareRuntimeStatus(log log.T, pluginResult PluginResult) PluginRuntimeSta
 		EndDateTime:    times.ToIso8601UTC(pluginResult.EndDateTime),
 		StandardOutput: pluginResult.StandardOutput,
 		StandardError:  pluginResult.StandardError,
+		ErrorCategory:  pluginResult.ErrorCategory,
+		ErrorCode:      pluginResult.ErrorCode,
 	}
 
 	if pluginResult.OutputS3BucketName != "" {
@@ -55,6 +55,12 @@ func TestPrepareRuntimeStatus(t *testing.T) {
 	pluginResult := PluginResult{Error: fmt.Sprintf("Plugin failed with error code 1")}
 	runtimeStatus := prepareRuntimeStatus(logger, pluginResult)
 	assert.Equal(t, pluginResult.Error, runtimeStatus.Output)
+
+	// test that the category of the error is part of the runtime status
+	pluginResult = PluginResult{Status: ResultStatusFailed, ErrorCategory: ErrorCategoryTimeout, ErrorCode: ErrorCodeTimedOut}
+	runtimeStatus = prepareRuntimeStatus(logger, pluginResult)
+	assert.Equal(t, ErrorCategoryTimeout, runtimeStatus.ErrorCategory)
+	assert.Equal(t, ErrorCodeTimedOut, runtimeStatus.ErrorCode)
 	return
 }
 
@@ -0,0 +1,39 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package contracts contains all necessary interface and models
+// necessary for communication and sharing within the agent.
+package contracts
+
+import (
+	"github.com/twinj/uuid"
+)
+
+// correlationLogPrefix is the prefix used for correlation id in log context
+const correlationLogPrefix = "cid:"
+
+// NewCorrelationID generates a new correlation id for a received message.
+// The correlation id is persisted with the document state and follows the document
+// through processor, executer, worker processes and replies.
+var NewCorrelationID = func() string {
+	return uuid.NewV4().String()
+}
+
+// CorrelationLogContext returns the log context string for the given correlation id.
+// An empty string is returned when no correlation id is present.
+func CorrelationLogContext(correlationID string) string {
+	if correlationID == "" {
+		return ""
+	}
+	return "[" + correlationLogPrefix + correlationID + "]"
+}
@@ -0,0 +1,32 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package contracts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCorrelationIDIsUnique(t *testing.T) {
+	first := NewCorrelationID()
+	second := NewCorrelationID()
+	assert.NotEmpty(t, first)
+	assert.NotEqual(t, first, second)
+}
+
+func TestCorrelationLogContext(t *testing.T) {
+	assert.Equal(t, "", CorrelationLogContext(""))
+	assert.Equal(t, "[cid:abc-123]", CorrelationLogContext("abc-123"))
+}
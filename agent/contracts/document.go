@@ -86,6 +86,8 @@ type DocumentInfo struct {
 	ClientId        string
 	RunAsUser       string
 	SessionOwner    string
+	// CorrelationID is generated per received message and is used to correlate logs and replies
+	CorrelationID string
 }
 
 // CloudWatchConfiguration represents information relevant to command output in cloudWatch
@@ -101,6 +103,12 @@ type IOConfiguration struct {
 	OutputS3BucketName     string
 	OutputS3KeyPrefix      string
 	CloudWatchConfig       CloudWatchConfiguration
+	StreamOutput           bool
+	StripAnsiEscapeCodes   bool
+	KeepRawOutput          bool
+	CombinedOutput         bool
+	// CorrelationID is the correlation id of the document the output belongs to
+	CorrelationID string
 }
 
 // DocumentState represents information relevant to a command that gets executed by agent
@@ -135,6 +143,10 @@ type CancelCommandInfo struct {
 
 // UpdateDocState updates the current document state
 func UpdateDocState(docResult *DocumentResult, docState *DocumentState) {
+	// output frames do not change the state of the document
+	if docResult.OutputFrame != nil {
+		return
+	}
 	docState.DocumentInformation.DocumentStatus = docResult.Status
 	pluginID := docResult.LastPlugin
 	if pluginID != "" {
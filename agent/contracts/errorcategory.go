@@ -0,0 +1,119 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package contracts
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// ErrorCategory classifies the failure of a plugin so that automation can branch on the kind of failure
+type ErrorCategory string
+
+const (
+	// ErrorCategoryNetwork represents the failures to reach an endpoint
+	ErrorCategoryNetwork ErrorCategory = "Network"
+	// ErrorCategoryPermission represents the failures due to missing permissions, local or on AWS
+	ErrorCategoryPermission ErrorCategory = "Permission"
+	// ErrorCategoryTimeout represents the steps and calls that did not complete in time
+	ErrorCategoryTimeout ErrorCategory = "Timeout"
+	// ErrorCategoryValidation represents the invalid documents, parameters and plugin inputs
+	ErrorCategoryValidation ErrorCategory = "Validation"
+	// ErrorCategoryPluginInternal represents the other failures of the plugins
+	ErrorCategoryPluginInternal ErrorCategory = "PluginInternal"
+)
+
+// Error codes set when the error does not carry a more specific code
+const (
+	ErrorCodeNetworkFailure   = "NetworkFailure"
+	ErrorCodePermissionDenied = "PermissionDenied"
+	ErrorCodeTimedOut         = "TimedOut"
+	ErrorCodeInvalidInput     = "InvalidInput"
+	ErrorCodePluginFailure    = "PluginFailure"
+	ErrorCodePluginCrashed    = "PluginCrashed"
+	ErrorCodeUnsupported      = "UnsupportedStep"
+)
+
+// permissionErrorCodes are the AWS error codes of the calls denied by IAM
+var permissionErrorCodes = map[string]struct{}{
+	"AccessDenied":          {},
+	"AccessDeniedException": {},
+	"UnauthorizedOperation": {},
+	"Forbidden":             {},
+	"InvalidAccessKeyId":    {},
+	"ExpiredToken":          {},
+	"SignatureDoesNotMatch": {},
+}
+
+// CategorizedError is an error with its category and a machine readable code, plugins return it to
+// MarkAsFailed when they know why they failed
+type CategorizedError struct {
+	Category ErrorCategory
+	Code     string
+	Err      error
+}
+
+// NewCategorizedError returns the error with the given category and code
+func NewCategorizedError(category ErrorCategory, code string, err error) *CategorizedError {
+	return &CategorizedError{Category: category, Code: code, Err: err}
+}
+
+// Error returns the message of the wrapped error
+func (e *CategorizedError) Error() string {
+	if e.Err == nil {
+		return e.Code
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *CategorizedError) Unwrap() error {
+	return e.Err
+}
+
+// CategorizeError returns the category and code of the error. The errors that are not categorized by their plugin
+// are classified from their type, the unknown ones are plugin internal errors.
+func CategorizeError(err error) (ErrorCategory, string) {
+	var categorized *CategorizedError
+	if errors.As(err, &categorized) {
+		return categorized.Category, categorized.Code
+	}
+
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		if _, found := permissionErrorCodes[awsErr.Code()]; found {
+			return ErrorCategoryPermission, awsErr.Code()
+		}
+		if awsErr.Code() == "RequestError" {
+			return ErrorCategoryNetwork, ErrorCodeNetworkFailure
+		}
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrorCategoryTimeout, ErrorCodeTimedOut
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorCategoryTimeout, ErrorCodeTimedOut
+	case errors.As(err, &netErr):
+		return ErrorCategoryNetwork, ErrorCodeNetworkFailure
+	case errors.Is(err, os.ErrPermission):
+		return ErrorCategoryPermission, ErrorCodePermissionDenied
+	}
+	return ErrorCategoryPluginInternal, ErrorCodePluginFailure
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategorizeError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		category ErrorCategory
+		code     string
+	}{
+		{"categorized", NewCategorizedError(ErrorCategoryValidation, "InvalidCommands", fmt.Errorf("no commands")), ErrorCategoryValidation, "InvalidCommands"},
+		{"wrapped categorized", fmt.Errorf("step failed: %w", NewCategorizedError(ErrorCategoryNetwork, "S3Unreachable", nil)), ErrorCategoryNetwork, "S3Unreachable"},
+		{"aws access denied", awserr.New("AccessDenied", "access denied", nil), ErrorCategoryPermission, "AccessDenied"},
+		{"aws request error", awserr.New("RequestError", "send request failed", fmt.Errorf("dial tcp")), ErrorCategoryNetwork, ErrorCodeNetworkFailure},
+		{"deadline exceeded", fmt.Errorf("download: %w", context.DeadlineExceeded), ErrorCategoryTimeout, ErrorCodeTimedOut},
+		{"network", &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")}, ErrorCategoryNetwork, ErrorCodeNetworkFailure},
+		{"local permission", &os.PathError{Op: "open", Path: "/root/script.sh", Err: os.ErrPermission}, ErrorCategoryPermission, ErrorCodePermissionDenied},
+		{"unknown", fmt.Errorf("something went wrong"), ErrorCategoryPluginInternal, ErrorCodePluginFailure},
+	}
+	for _, tst := range testCases {
+		t.Run(tst.name, func(t *testing.T) {
+			category, code := CategorizeError(tst.err)
+			assert.Equal(t, tst.category, category)
+			assert.Equal(t, tst.code, code)
+		})
+	}
+}
+
+func TestCategorizedError_Message(t *testing.T) {
+	err := NewCategorizedError(ErrorCategoryValidation, "InvalidCommands", fmt.Errorf("no commands"))
+	assert.Equal(t, "no commands", err.Error())
+	assert.Equal(t, "InvalidCommands", NewCategorizedError(ErrorCategoryValidation, "InvalidCommands", nil).Error())
+}
@@ -210,6 +210,14 @@ type DocumentContent struct {
 	RuntimeConfig map[string]*PluginConfig `json:"runtimeConfig" yaml:"runtimeConfig"`
 	MainSteps     []*InstancePluginConfig  `json:"mainSteps" yaml:"mainSteps"`
 	Parameters    map[string]*Parameter    `json:"parameters" yaml:"parameters"`
+	// StreamOutput streams the output of the steps to the message gateway while they run
+	StreamOutput bool `json:"streamOutput,omitempty" yaml:"streamOutput,omitempty"`
+	// StripAnsiEscapeCodes removes the ANSI color and cursor codes from the output of the steps before it is stored and uploaded
+	StripAnsiEscapeCodes bool `json:"stripAnsiEscapeCodes,omitempty" yaml:"stripAnsiEscapeCodes,omitempty"`
+	// KeepRawOutput keeps the output with its escape codes in the orchestration directory when they are stripped
+	KeepRawOutput bool `json:"keepRawOutput,omitempty" yaml:"keepRawOutput,omitempty"`
+	// CombinedOutput captures stdout and stderr of the steps interleaved in a third output, uploaded along with them
+	CombinedOutput bool `json:"combinedOutput,omitempty" yaml:"combinedOutput,omitempty"`
 
 	// InvokedPlugin field is set when document is invoked from any other plugin.
 	// Currently, InvokedPlugin is set only in runDocument Plugin
@@ -252,6 +260,7 @@ type AdditionalInfo struct {
 	DateTime            string         `json:"dateTime"`
 	RunID               string         `json:"runId"`
 	RuntimeStatusCounts map[string]int `json:"runtimeStatusCounts"`
+	CorrelationID       string         `json:"correlationId,omitempty"`
 }
 
 // AgentInfo represents the agent response
@@ -276,6 +285,9 @@ type PluginRuntimeStatus struct {
 	StepName           string       `json:"stepName"`
 	StandardOutput     string       `json:"standardOutput"`
 	StandardError      string       `json:"standardError"`
+	// ErrorCategory and ErrorCode classify the failure of the plugin
+	ErrorCategory ErrorCategory `json:"errorCategory,omitempty"`
+	ErrorCode     string        `json:"errorCode,omitempty"`
 }
 
 // AgentConfiguration is a struct that stores information about the agent and instance
@@ -297,6 +309,9 @@ type DocumentResult struct {
 	UpstreamServiceName UpstreamServiceName
 	ResultType          ResultType
 	RelatedDocumentType DocumentType
+	CorrelationID       string
+	// OutputFrame is set when the result only carries the output a running plugin wrote since the previous frame
+	OutputFrame *OutputFrame `json:",omitempty"`
 }
 
 // ResultType represents document Result types
@@ -307,6 +322,8 @@ const (
 	RunCommandResult ResultType = "RunCommandResult"
 	// SessionResult represents result sent by session worker to service
 	SessionResult ResultType = "SessionResult"
+	// RunCommandOutputResult represents the output streamed by document worker while running runCommand documents
+	RunCommandOutputResult ResultType = "RunCommandOutputResult"
 )
 
 // StatusComm is a struct that holds channels to pass status
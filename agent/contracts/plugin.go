@@ -41,6 +41,20 @@ type PluginResult struct {
 	Error              string       `json:"error"`
 	StandardOutput     string       `json:"standardOutput"`
 	StandardError      string       `json:"standardError"`
+	OutputFrame        *OutputFrame `json:"outputFrame,omitempty"`
+	// ErrorCategory and ErrorCode classify the failure of the plugin
+	ErrorCategory ErrorCategory `json:"errorCategory,omitempty"`
+	ErrorCode     string        `json:"errorCode,omitempty"`
+}
+
+// OutputFrame holds the output a running plugin wrote since the previous frame
+type OutputFrame struct {
+	// Sequence orders the frames of a plugin, it starts at 1
+	Sequence int    `json:"sequence"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	// Truncated is true when part of the output written since the previous frame was dropped
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // IPlugin is interface for authoring a functionality of work.
@@ -28,10 +28,13 @@ import (
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/commandlocale"
+	"github.com/aws/amazon-ssm-agent/agent/commandsandbox"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/processpriority"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
 
@@ -199,6 +202,22 @@ func (ShellCommandExecuter) StartExe(
 	return
 }
 
+// StartPreparedExe starts the exe like StartExe once the given preparation is applied to its command, e.g. to start
+// it as another user.
+func (ShellCommandExecuter) StartPreparedExe(
+	context context.T,
+	workingDir string,
+	stdoutWriter io.Writer,
+	stderrWriter io.Writer,
+	cancelFlag task.CancelFlag,
+	commandName string,
+	commandArguments []string,
+	prepare func(command *exec.Cmd) error,
+) (process *os.Process, exitCode int, err error) {
+	process, exitCode, err = StartCommand(context, cancelFlag, workingDir, stdoutWriter, stderrWriter, commandName, commandArguments, prepare)
+	return
+}
+
 // CreateScriptFile creates a script containing the given commands.
 func CreateScriptFile(scriptPath string, commands []string) (err error) {
 	// create script
@@ -265,6 +284,13 @@ func ExecuteCommand(
 ) (exitCode int, err error) {
 	log := context.Log()
 
+	// the command runs in the selected sandbox profile, it does not run at all when the profile is unusable
+	sandboxProfile, err := commandsandbox.SelectedProfile(context.AppConfig().Ssm.CommandSandbox)
+	if err != nil {
+		log.Errorf("Failed to select the command sandbox: %v", err)
+		return 1, err
+	}
+
 	stdoutInterruptable, stopStdout := newWriter(stdoutWriter)
 	stderrInterruptable, stopStderr := newWriter(stderrWriter)
 
@@ -291,10 +317,20 @@ func ExecuteCommand(
 
 	// configure OS-specific process settings
 	prepareProcess(command)
+	cancellation := context.AppConfig().Ssm.ScriptCancellation
+	prepareCancellation(command, cancellation)
 
 	// configure environment variables
 	prepareEnvironment(context, command, envVars)
 
+	releaseSandbox, err := commandsandbox.Prepare(sandboxProfile, command)
+	if err != nil {
+		log.Errorf("Failed to prepare the command sandbox: %v", err)
+		exitCode = 1
+		return
+	}
+	defer releaseSandbox()
+
 	log.Debugf("Running in directory %v, command: %v %v", workingDir, commandName, commandArguments)
 
 	quiesce()
@@ -303,6 +339,7 @@ func ExecuteCommand(
 		exitCode = 1
 		return
 	}
+	applyProcessPriority(context, command.Process)
 
 	signal := timeoutSignal{}
 
@@ -323,9 +360,10 @@ func ExecuteCommand(
 
 	select {
 	case <-time.After(time.Duration(executionTimeout) * time.Second):
+		err = stopProcess(log, command.Process, cancellation, done, &signal)
 		stopStdout <- true
 		stopStderr <- true
-		if err = killProcess(command.Process, &signal); err != nil {
+		if err != nil {
 			exitCode = 1
 			log.Error(err)
 		} else {
@@ -337,9 +375,10 @@ func ExecuteCommand(
 	case <-cancelled:
 		// task has been asked to cancel, kill process
 		log.Debug("Process cancelled. Attempting to stop process.")
+		err = stopProcess(log, command.Process, cancellation, done, &signal)
 		stopStdout <- true
 		stopStderr <- true
-		if err = killProcess(command.Process, &signal); err != nil {
+		if err != nil {
 			exitCode = 1
 			log.Error(err)
 		} else {
@@ -390,7 +429,8 @@ func ExecuteCommand(
 }
 
 // StartCommand starts the given commands using the given working directory.
-// Standard output and standard error are sent to the given writers.
+// Standard output and standard error are sent to the given writers. The preparations are applied to the command
+// once its environment is set.
 func StartCommand(context context.T,
 	cancelFlag task.CancelFlag,
 	workingDir string,
@@ -398,6 +438,7 @@ func StartCommand(context context.T,
 	stderrWriter io.Writer,
 	commandName string,
 	commandArguments []string,
+	preparations ...func(command *exec.Cmd) error,
 ) (process *os.Process, exitCode int, err error) {
 	log := context.Log()
 	command := exec.Command(commandName, commandArguments...)
@@ -412,6 +453,14 @@ func StartCommand(context context.T,
 	// configure environment variables
 	prepareEnvironment(context, command, make(map[string]string))
 
+	for _, prepare := range preparations {
+		if err = prepare(command); err != nil {
+			log.Error("error occurred preparing the command: ", err)
+			exitCode = 1
+			return
+		}
+	}
+
 	log.Debugf("Running in directory %v, command: %v %v", workingDir, commandName, commandArguments)
 
 	quiesce()
@@ -420,6 +469,7 @@ func StartCommand(context context.T,
 		exitCode = 1
 		return
 	}
+	applyProcessPriority(context, command.Process)
 
 	process = command.Process
 	signal := timeoutSignal{}
@@ -432,6 +482,44 @@ func StartCommand(context context.T,
 	return
 }
 
+// stopProcess stops the process of a cancelled or timed out command. When the cancellation signal lets the process
+// clean up, the signal is sent first and the process is only killed if it does not exit within the grace period.
+func stopProcess(log log.T, process *os.Process, cancellation appconfig.ScriptCancellation, done chan error, signal *timeoutSignal) error {
+	if cancellation.Signal == appconfig.CancellationSignalTerminate || cancellation.Signal == appconfig.CancellationSignalInterrupt {
+		if err := interruptProcess(process, cancellation.Signal); err != nil {
+			log.Warnf("Unable to send %v to the process, killing it: %v", cancellation.Signal, err)
+		} else {
+			gracePeriod := time.Duration(cancellation.GracePeriodSeconds) * time.Second
+			select {
+			case waitErr := <-done:
+				log.Debugf("Process exited after %v: %v", cancellation.Signal, waitErr)
+				// the process is gone, make sure its sub processes are stopped as well
+				_ = killProcess(process, signal)
+				return nil
+			case <-time.After(gracePeriod):
+				log.Infof("Process still running %v after %v, killing it", gracePeriod, cancellation.Signal)
+			}
+		}
+	}
+	return killProcess(process, signal)
+}
+
+// ValidateCancellation returns an error if the cancellation signal or grace period is not supported
+func ValidateCancellation(cancellation appconfig.ScriptCancellation) error {
+	switch cancellation.Signal {
+	case appconfig.CancellationSignalKill, appconfig.CancellationSignalTerminate, appconfig.CancellationSignalInterrupt:
+	default:
+		return fmt.Errorf("signal %v must be %v, %v or %v", cancellation.Signal,
+			appconfig.CancellationSignalKill, appconfig.CancellationSignalTerminate, appconfig.CancellationSignalInterrupt)
+	}
+	if cancellation.Signal != appconfig.CancellationSignalKill &&
+		(cancellation.GracePeriodSeconds < appconfig.DefaultCancellationGracePeriodSecondsMin || cancellation.GracePeriodSeconds > appconfig.DefaultCancellationGracePeriodSecondsMax) {
+		return fmt.Errorf("grace period %v must be between %v and %v seconds", cancellation.GracePeriodSeconds,
+			appconfig.DefaultCancellationGracePeriodSecondsMin, appconfig.DefaultCancellationGracePeriodSecondsMax)
+	}
+	return nil
+}
+
 // killProcessOnCancel waits for a cancel request.
 // If a cancel request is received, this method kills the underlying
 // process of the command. This will unblock the command.Wait() call.
@@ -461,11 +549,28 @@ func killProcessOnCancel(log log.T, command *exec.Cmd, cancelStdout chan bool, c
 	}
 }
 
+// applyProcessPriority sets the process priority configured in the context, the command keeps running with the
+// inherited priority when it cannot be set
+func applyProcessPriority(context context.T, process *os.Process) {
+	if err := processpriority.Apply(process.Pid, context.AppConfig().Ssm.ProcessPriority); err != nil {
+		context.Log().Warnf("Unable to set the priority of the command process: %v", err)
+	}
+}
+
 // prepareEnvironment adds ssm agent standard environment variables or environment variables defined by customer/other plugins to the command
 func prepareEnvironment(context context.T, command *exec.Cmd, envVars map[string]string) {
 	log := context.Log()
 	env := os.Environ()
 
+	// the environment variables of the document take precedence over the locale of the agent configuration
+	locale := context.AppConfig().Ssm.CommandLocale
+	if err := commandlocale.Validate(locale); err != nil {
+		log.Warnf("Ignoring the command locale: %v", err)
+	} else {
+		for key, val := range commandlocale.Environment(locale) {
+			env = append(env, fmtEnvVariable(key, val))
+		}
+	}
 	for key, val := range envVars {
 		env = append(env, fmtEnvVariable(key, val))
 	}
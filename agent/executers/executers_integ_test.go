@@ -184,6 +184,7 @@ func getTestContext() context.T {
 	contextMock := &contextmocks.Mock{}
 	contextMock.On("Identity").Return(identityMock)
 	contextMock.On("Log").Return(logger)
+	contextMock.On("AppConfig").Return(appconfig.DefaultConfig())
 
 	return contextMock
 }
@@ -311,6 +312,27 @@ func testCommandInvokerCancel(t *testing.T, invoke CommandInvoker, cancelFlag ta
 	assert.Equal(t, exitCode, testCase.ExpectedExitCode)
 }
 
+// TestExecuteCommand_cancelWithSignal tests that a cancelled script trapping the cancellation signal can clean up
+// before it exits.
+func TestExecuteCommand_cancelWithSignal(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Ssm.ScriptCancellation = appconfig.ScriptCancellation{Signal: appconfig.CancellationSignalTerminate, GracePeriodSeconds: 5}
+	ctx := context.WithAppConfig(getTestContext(), config)
+	cancelFlag := task.NewChanneledCancelFlag()
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		cancelFlag.Set(task.Canceled)
+	}()
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	exitCode, err := ExecuteCommand(ctx, cancelFlag, "", stdout, stderr, 60, "sh", []string{"-c", "trap 'echo cleaned up; exit 0' TERM; sleep 30 & wait"}, make(map[string]string))
+
+	assert.IsType(t, &exec.ExitError{}, err)
+	assert.Equal(t, appconfig.CommandStoppedPreemptivelyExitCode, exitCode)
+	assert.Equal(t, "cleaned up\n", stdout.String())
+}
+
 // echoToStdout returns a shell command that outputs a message to the standard output stream.
 func echoToStdout(msg string) string {
 	return fmt.Sprintf(`echo "%v"`, msg)
@@ -15,11 +15,13 @@
 package executers
 
 import (
+	"errors"
 	"os"
 	"os/exec"
 	"strings"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	mockIdentity "github.com/aws/amazon-ssm-agent/common/identity/mocks"
 	"github.com/stretchr/testify/assert"
@@ -75,6 +77,26 @@ func TestEnvironmentVariables_None(t *testing.T) {
 	assert.Empty(t, getEnvVariableValue(command.Env, mockIdentity.MockRegion))
 }
 
+func TestEnvironmentVariables_CommandLocale(t *testing.T) {
+	os.Clearenv()
+	config := appconfig.DefaultConfig()
+	config.Ssm.CommandLocale = appconfig.CommandLocale{Locale: "C.UTF-8"}
+	context := context.NewMockDefaultWithConfig(config)
+
+	command := getTestCommand()
+	prepareEnvironment(context, command, map[string]string{"LC_ALL": "de_DE.UTF-8"})
+
+	assert.Equal(t, "C.UTF-8", getEnvVariableValue(command.Env, "LANG"))
+	// the last value of a variable is the one the process gets, the document environment wins
+	var lcAll []string
+	for _, envVariable := range command.Env {
+		if strings.HasPrefix(envVariable, "LC_ALL=") {
+			lcAll = append(lcAll, envVariable)
+		}
+	}
+	assert.Equal(t, []string{"LC_ALL=C.UTF-8", "LC_ALL=de_DE.UTF-8"}, lcAll)
+}
+
 func TestQuoteShString(t *testing.T) {
 	var result string
 
@@ -117,3 +139,42 @@ func TestQuotePsStringWithQuotes(t *testing.T) {
 	result = QuotePsString("`abc`")
 	assert.Equal(t, "\"``abc``\"", result)
 }
+
+func TestValidateCancellation(t *testing.T) {
+	assert.NoError(t, ValidateCancellation(appconfig.ScriptCancellation{Signal: appconfig.CancellationSignalKill}))
+	assert.NoError(t, ValidateCancellation(appconfig.ScriptCancellation{Signal: appconfig.CancellationSignalTerminate, GracePeriodSeconds: 30}))
+	assert.NoError(t, ValidateCancellation(appconfig.ScriptCancellation{Signal: appconfig.CancellationSignalInterrupt, GracePeriodSeconds: 1}))
+
+	assert.Error(t, ValidateCancellation(appconfig.ScriptCancellation{Signal: "SIGHUP", GracePeriodSeconds: 30}))
+	assert.Error(t, ValidateCancellation(appconfig.ScriptCancellation{Signal: appconfig.CancellationSignalTerminate}))
+	assert.Error(t, ValidateCancellation(appconfig.ScriptCancellation{Signal: appconfig.CancellationSignalInterrupt, GracePeriodSeconds: 601}))
+}
+
+// TestExecuteCommand_InvalidSandboxProfile tests that the command does not run when its sandbox profile is not configured
+func TestExecuteCommand_InvalidSandboxProfile(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Ssm.CommandSandbox = appconfig.CommandSandbox{DefaultProfile: "missing"}
+	context := context.NewMockDefaultWithConfig(config)
+
+	exitCode, err := ExecuteCommand(context, nil, "", nil, nil, 10, "echo", []string{"unsandboxed"}, map[string]string{})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, exitCode)
+}
+
+// TestStartPreparedExe_PreparationFailed tests that the exe does not start when its preparation fails
+func TestStartPreparedExe_PreparationFailed(t *testing.T) {
+	context := context.NewMockDefault()
+	prepared := false
+	prepare := func(command *exec.Cmd) error {
+		prepared = true
+		return errors.New(testError)
+	}
+
+	process, exitCode, err := ShellCommandExecuter{}.StartPreparedExe(context, "", nil, nil, nil, "echo", []string{"unprepared"}, prepare)
+
+	assert.True(t, prepared)
+	assert.EqualError(t, err, testError)
+	assert.Equal(t, 1, exitCode)
+	assert.Nil(t, process)
+}
@@ -32,6 +32,10 @@ func prepareProcess(command *exec.Cmd) {
 	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
 
+func prepareCancellation(command *exec.Cmd, cancellation appconfig.ScriptCancellation) {
+	// nothing to do, the signal is sent to the process group created by prepareProcess
+}
+
 func quiesce() {
 	if runtime.GOOS != "darwin" {
 		return
@@ -52,6 +56,14 @@ func killProcess(process *os.Process, signal *timeoutSignal) error {
 	return syscall.Kill(-process.Pid, syscall.SIGKILL) // note the minus sign
 }
 
+// interruptProcess sends the cancellation signal to all the processes in the process group of the process
+func interruptProcess(process *os.Process, signal string) error {
+	if signal == appconfig.CancellationSignalInterrupt {
+		return syscall.Kill(-process.Pid, syscall.SIGINT)
+	}
+	return syscall.Kill(-process.Pid, syscall.SIGTERM)
+}
+
 // Running powershell on linux erquired the HOME env variable to be set and to remove the TERM env variable
 func validateEnvironmentVariables(command *exec.Cmd) {
 
@@ -17,18 +17,47 @@
 package executers
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"golang.org/x/sys/windows"
 )
 
 const (
 	CWConfigIndex = 2
+
+	// ctrlBreakHelperEnvVar holds the process id in the environment of the helper process sending a CTRL_BREAK event
+	ctrlBreakHelperEnvVar = "SSM_AGENT_CTRL_BREAK_PID"
+)
+
+var (
+	kernel32                  = windows.NewLazySystemDLL("kernel32.dll")
+	procAttachConsole         = kernel32.NewProc("AttachConsole")
+	procGetConsoleWindow      = kernel32.NewProc("GetConsoleWindow")
+	procSetConsoleCtrlHandler = kernel32.NewProc("SetConsoleCtrlHandler")
 )
 
+// init turns the process into the helper sending a CTRL_BREAK event when it is started by SendCtrlBreak, the helper
+// exits before the agent starts
+func init() {
+	if pid, isHelper := os.LookupEnv(ctrlBreakHelperEnvVar); isHelper {
+		os.Exit(runCtrlBreakHelper(pid))
+	}
+}
+
 func prepareProcess(command *exec.Cmd) {
 	// nothing to do on windows
 }
 
+func prepareCancellation(command *exec.Cmd, cancellation appconfig.ScriptCancellation) {
+	// the agent runs without a console, the script gets a console of its own the CTRL_BREAK event is sent to
+}
+
 func quiesce() {
 	// not needed for Darwin workaround
 }
@@ -40,6 +69,56 @@ func killProcess(process *os.Process, signal *timeoutSignal) error {
 	return process.Kill()
 }
 
+// interruptProcess sends a CTRL_BREAK event to the console of the process, CTRL_C events cannot be sent to
+// another process group so both signals are delivered as CTRL_BREAK
+func interruptProcess(process *os.Process, signal string) error {
+	return SendCtrlBreak(process.Pid)
+}
+
+// SendCtrlBreak sends a CTRL_BREAK event to the console of the process. Attaching to the console of the process would
+// change the console of the whole agent process, the processes the agent starts meanwhile would inherit the console
+// and receive the event. The event is sent by a copy of the agent executable started without a console instead.
+func SendCtrlBreak(pid int) error {
+	// the processes the agent starts share its console when it runs interactively, the agent would receive the event
+	if window, _, _ := procGetConsoleWindow.Call(); window != 0 {
+		return fmt.Errorf("the agent has a console, CTRL_BREAK cannot be sent to process %v", pid)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	helper := exec.Command(executable)
+	helper.Env = append(os.Environ(), fmt.Sprintf("%v=%v", ctrlBreakHelperEnvVar, pid))
+	helper.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.DETACHED_PROCESS}
+	if output, err := helper.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to send CTRL_BREAK to process %v: %v %s", pid, err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// runCtrlBreakHelper attaches to the console of the process and sends the event to all the processes of the console
+// while ignoring it itself, it returns the exit code of the helper
+func runCtrlBreakHelper(value string) int {
+	pid, err := strconv.Atoi(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid process id %v", value)
+		return 2
+	}
+	if ok, _, err := procAttachConsole.Call(uintptr(pid)); ok == 0 {
+		fmt.Fprintf(os.Stderr, "failed to attach to the console of process %v: %v", pid, err)
+		return 1
+	}
+	if ok, _, err := procSetConsoleCtrlHandler.Call(0, 1); ok == 0 {
+		fmt.Fprintf(os.Stderr, "failed to ignore the console control events: %v", err)
+		return 1
+	}
+	if err = windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, 0); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to generate the CTRL_BREAK event: %v", err)
+		return 1
+	}
+	return 0
+}
+
 // Running powershell on linux required the HOME env variable to be set and to remove the TERM env variable
 func validateEnvironmentVariables(command *exec.Cmd) {
 }
@@ -1,4 +1,4 @@
-// Copyright 2020 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
 //
 // Licensed under the Apache License, Version 2.0 (the "License"). You may not
 // use this file except in compliance with the License. A copy of the
@@ -14,9 +14,14 @@
 //go:build windows
 // +build windows
 
-// Package model contains data objects for session utility
-package model
+package executers
 
-const (
-	AddUserCommand = ""
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
+
+func TestRunCtrlBreakHelper_InvalidProcessID(t *testing.T) {
+	assert.Equal(t, 2, runCtrlBreakHelper("not a pid"))
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package featureflag resolves the runtime feature flags that gate new agent behaviors.
+// Flag values set in appconfig override the defaults.
+package featureflag
+
+import (
+	"sort"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// Flag is the name of a feature flag
+type Flag string
+
+const (
+	// NewIPC gates the named pipe channel between the core agent and its workers on windows,
+	// which falls back to the file channel when the flag is off
+	NewIPC Flag = "NewIPC"
+)
+
+// Source denotes where the value of a flag comes from
+type Source string
+
+const (
+	// SourceDefault is used when the flag is not overridden
+	SourceDefault Source = "default"
+	// SourceAppConfig is used when the flag value is set in the agent appconfig
+	SourceAppConfig Source = "appconfig"
+)
+
+// State represents the resolved state of a flag
+type State struct {
+	Enabled bool   `json:"enabled"`
+	Source  Source `json:"source"`
+}
+
+// defaultFlags holds every known flag and its default value. All risky behaviors are off by default.
+var defaultFlags = map[Flag]bool{
+	NewIPC: false,
+}
+
+// IsEnabled returns true if the given flag is enabled
+func IsEnabled(config appconfig.SsmagentConfig, flag Flag) bool {
+	state, found := Resolve(config)[flag]
+	return found && state.Enabled
+}
+
+// Resolve returns the state of all known flags
+func Resolve(config appconfig.SsmagentConfig) map[Flag]State {
+	states := make(map[Flag]State, len(defaultFlags))
+	for flag, enabled := range defaultFlags {
+		states[flag] = State{Enabled: enabled, Source: SourceDefault}
+	}
+
+	for name, enabled := range config.FeatureFlags.Flags {
+		if _, known := defaultFlags[Flag(name)]; known {
+			states[Flag(name)] = State{Enabled: enabled, Source: SourceAppConfig}
+		}
+	}
+	return states
+}
+
+// LogFlags logs the state of all known flags and warns about unknown flags in appconfig
+func LogFlags(log log.T, config appconfig.SsmagentConfig) {
+	states := Resolve(config)
+	flags := make([]string, 0, len(states))
+	for flag := range states {
+		flags = append(flags, string(flag))
+	}
+	sort.Strings(flags)
+	for _, flag := range flags {
+		state := states[Flag(flag)]
+		log.Infof("Feature flag %v enabled: %v (source: %v)", flag, state.Enabled, state.Source)
+	}
+
+	for name := range config.FeatureFlags.Flags {
+		if _, known := defaultFlags[Flag(name)]; !known {
+			log.Warnf("Ignoring unknown feature flag %v in appconfig", name)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package featureflag
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveDefaults(t *testing.T) {
+	states := Resolve(appconfig.DefaultConfig())
+	assert.Equal(t, len(defaultFlags), len(states))
+	for _, state := range states {
+		assert.False(t, state.Enabled)
+		assert.Equal(t, SourceDefault, state.Source)
+	}
+}
+
+func TestResolveAppConfigOverride(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.FeatureFlags.Flags = map[string]bool{string(NewIPC): true, "Unknown": true}
+
+	states := Resolve(config)
+	assert.Equal(t, State{Enabled: true, Source: SourceAppConfig}, states[NewIPC])
+	assert.True(t, IsEnabled(config, NewIPC))
+	_, found := states["Unknown"]
+	assert.False(t, found)
+}
+
+func TestLogFlags(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.FeatureFlags.Flags = map[string]bool{"Unknown": true}
+	mockLog := log.NewMockLog()
+
+	LogFlags(mockLog, config)
+	mockLog.AssertCalled(t, "Warnf", "Ignoring unknown feature flag %v in appconfig", []interface{}{"Unknown"})
+}
@@ -52,10 +52,20 @@ type DownloadInput struct {
 	SourceURL            string
 	DestinationDirectory string
 	SourceChecksums      map[string]string
+	// Resumable downloads the file with ranged requests and checkpoints so that a failed download resumes from
+	// its last checkpoint instead of restarting from zero
+	Resumable bool
+	// Progress is called as a resumable download progresses, it may be nil
+	Progress ProgressFunc
+	// Mirrors are tried before the source url, the mirrors of the agent configuration are used when nil
+	Mirrors []appconfig.ArtifactMirror
+	// CacheByChecksum keeps the downloaded file in the checksum cache so that the downloads of a file with the same
+	// sha256 checksum, from any url, reuse it after verifying its integrity
+	CacheByChecksum bool
 }
 
-// httpDownload attempts to download a file via http/s call
-func httpDownload(ctx context.T, fileURL string, destFile string) (output DownloadOutput, err error) {
+// httpDownload attempts to download a file via http/s call, the headers are added to the request
+func httpDownload(ctx context.T, fileURL string, headers map[string]string, destFile string) (output DownloadOutput, err error) {
 	log := ctx.Log()
 	log.Debugf("attempting to download as http/https download from %v to %v", fileURL, destFile)
 
@@ -72,21 +82,14 @@ func httpDownload(ctx context.T, fileURL string, destFile string) (output Downlo
 		if err != nil {
 			return
 		}
+		addHeaders(httpRequest, headers)
 		if fileutil.Exists(destFile) == true && fileutil.Exists(eTagFile) == true {
 			log.Debugf("destFile exists at %v, etag file exists at %v", destFile, eTagFile)
 			var existingETag string
 			existingETag, err = fileutil.ReadAllText(eTagFile)
 			httpRequest.Header.Add("If-None-Match", existingETag)
 		}
-		customTransport := network.GetDefaultTransport(log, ctx.AppConfig())
-		customTransport.TLSHandshakeTimeout = 20 * time.Second
-		check = http.Client{
-			CheckRedirect: func(r *http.Request, via []*http.Request) error {
-				r.URL.Opaque = r.URL.Path
-				return nil
-			},
-			Transport: customTransport,
-		}
+		check = newHttpClient(ctx)
 
 		var resp *http.Response
 		resp, err = check.Do(httpRequest)
@@ -139,6 +142,26 @@ func httpDownload(ctx context.T, fileURL string, destFile string) (output Downlo
 	return
 }
 
+// addHeaders adds the headers to the request
+func addHeaders(request *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+}
+
+// newHttpClient returns the client used for http/s downloads
+func newHttpClient(ctx context.T) http.Client {
+	customTransport := network.GetDefaultTransport(ctx.Log(), ctx.AppConfig())
+	customTransport.TLSHandshakeTimeout = 20 * time.Second
+	return http.Client{
+		CheckRedirect: func(r *http.Request, via []*http.Request) error {
+			r.URL.Opaque = r.URL.Path
+			return nil
+		},
+		Transport: customTransport,
+	}
+}
+
 // CanGetS3Object returns true if it is possible to fetch an object because it exists, is not deleted, and read permissions exist for this request
 func CanGetS3Object(context context.T, amazonS3URL s3util.AmazonS3URL) bool {
 	log := context.Log()
@@ -371,22 +394,12 @@ func Download(context context.T, input DownloadInput) (output DownloadOutput, er
 		// Generating a hash_filename will also help against attackers
 		// from specifying a directory and filename to overwrite any ami/built-in files.
 		urlHash := sha1.Sum([]byte(fileURL.String()))
-		output.LocalFilePath = filepath.Join(destinationDir, fmt.Sprintf("%x", urlHash))
+		destFile := filepath.Join(destinationDir, fmt.Sprintf("%x", urlHash))
 
-		amazonS3URL := s3util.ParseAmazonS3URL(log, fileURL)
-		if amazonS3URL.IsBucketAndKeyPresent() {
-			var tempOutput DownloadOutput
-			tempOutput, err = s3Download(context, amazonS3URL, output.LocalFilePath)
-			if err != nil {
-				log.Info("An error occurred when attempting s3 download. Attempting http/https download as fallback.")
-				tempOutput, err = httpDownload(context, input.SourceURL, output.LocalFilePath)
-			}
-			output = tempOutput
-		} else {
-			output, err = httpDownload(context, input.SourceURL, output.LocalFilePath)
+		if checksum := cacheChecksum(input); checksum != "" {
+			return downloadCached(context, input, fileURL, checksum, destFile)
 		}
-
-		if err != nil {
+		if output, err = downloadFile(context, input, fileURL, destFile); err != nil {
 			return
 		}
 
@@ -399,6 +412,41 @@ func Download(context context.T, input DownloadInput) (output DownloadOutput, er
 	return
 }
 
+// downloadFile downloads the file from its mirrors, falling back to the source url
+func downloadFile(context context.T, input DownloadInput, fileURL *url.URL, destFile string) (output DownloadOutput, err error) {
+	mirrors := input.Mirrors
+	if mirrors == nil {
+		mirrors = context.AppConfig().Agent.ArtifactMirrors
+	}
+	healthyMirrors, failedMirrors := mirrorSources(mirrors, input.SourceURL, time.Now())
+	if output, err = downloadFromMirrors(context, input, healthyMirrors, destFile); err != nil {
+		if output, err = downloadFromSource(context, input, fileURL, destFile); err != nil && len(failedMirrors) > 0 {
+			context.Log().Infof("Failed to download %v, retrying the mirrors which failed recently: %v", input.SourceURL, err)
+			output, err = downloadFromMirrors(context, input, failedMirrors, destFile)
+		}
+	}
+	return
+}
+
+// downloadFromSource downloads the file from the source url, the s3 urls are downloaded with the aws sdk
+// falling back to http/s
+func downloadFromSource(context context.T, input DownloadInput, fileURL *url.URL, destFile string) (output DownloadOutput, err error) {
+	log := context.Log()
+	amazonS3URL := s3util.ParseAmazonS3URL(log, fileURL)
+	if input.Resumable {
+		output, err = resumableDownloadFile(context, input, amazonS3URL, destFile)
+	} else if amazonS3URL.IsBucketAndKeyPresent() {
+		output, err = s3Download(context, amazonS3URL, destFile)
+		if err != nil {
+			log.Info("An error occurred when attempting s3 download. Attempting http/https download as fallback.")
+			output, err = httpDownload(context, input.SourceURL, nil, destFile)
+		}
+	} else {
+		output, err = httpDownload(context, input.SourceURL, nil, destFile)
+	}
+	return
+}
+
 // VerifyHash verifies the hash of the url file as per specified hash algorithm type and its value
 func VerifyHash(log log.T, input DownloadInput, output DownloadOutput) (bool, error) {
 	hasMatchingHash := false
@@ -45,9 +45,9 @@ var (
 		{
 			// validate sha256
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"sha256": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				}},
 			DownloadOutput{
@@ -58,9 +58,9 @@ var (
 		{
 			// validate incorrect sha256 fails
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"sha256": "111111111",
 				}},
 			DownloadOutput{
@@ -71,9 +71,9 @@ var (
 		{
 			// validate md5
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"md5": "e84913ff3a8eef39238b32170e657ba8",
 				}},
 			DownloadOutput{
@@ -85,9 +85,9 @@ var (
 		{
 			// validate incorrect md5 fails
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"md5": "222222222",
 				}},
 			DownloadOutput{
@@ -98,9 +98,9 @@ var (
 		{
 			// ensure default is sha256
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				}},
 			DownloadOutput{
@@ -111,9 +111,9 @@ var (
 		{
 			// relative url is not supported
 			DownloadInput{
-				"IamRelativeFilePath",
-				downloadFolder,
-				map[string]string{
+				SourceURL:            "IamRelativeFilePath",
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				}},
 			DownloadOutput{
@@ -124,9 +124,9 @@ var (
 		{
 			// relative url is not supported
 			DownloadInput{
-				"IamRelativeFilePath/IdontExist",
-				downloadFolder,
-				map[string]string{
+				SourceURL:            "IamRelativeFilePath/IdontExist",
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				}},
 			DownloadOutput{
@@ -137,9 +137,9 @@ var (
 		{
 			// s3 download error
 			DownloadInput{
-				"https://s3.amazonaws.com/ssmnotsuchbucket/ssmnosuchfile.txt",
-				downloadFolder,
-				map[string]string{
+				SourceURL:            "https://s3.amazonaws.com/ssmnotsuchbucket/ssmnosuchfile.txt",
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"": "",
 				}},
 			DownloadOutput{
@@ -150,9 +150,9 @@ var (
 		{
 			// ensure empty map is valid
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{},
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums:      map[string]string{},
 			},
 			DownloadOutput{
 				localPathExist,
@@ -162,9 +162,9 @@ var (
 		{
 			// ensure empty value is valid; this is important for the agent updater itself
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{"sha256": ""},
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums:      map[string]string{"sha256": ""},
 			},
 			DownloadOutput{
 				localPathExist,
@@ -174,9 +174,9 @@ var (
 		{
 			// first checksum fails, the second one succeeds
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"md5":    "111111111",
 					"sha256": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				},
@@ -189,9 +189,9 @@ var (
 		{
 			// none of the provided algorithms are supported
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"sha512": "111111111",
 					"sha1":   "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				},
@@ -204,9 +204,9 @@ var (
 		{
 			// one supported algorithm and one not supported
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"foo":    "123456789",
 					"sha256": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				},
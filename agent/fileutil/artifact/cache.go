@@ -0,0 +1,141 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	checksumCacheDirName = "cache"
+	// checksumCacheMaxAge is the duration after which a cached file that was not reused is removed from the cache
+	checksumCacheMaxAge = 30 * 24 * time.Hour
+)
+
+// checksumCacheDir returns the directory of the files cached by checksum
+var checksumCacheDir = func() string {
+	return filepath.Join(appconfig.DownloadRoot, checksumCacheDirName)
+}
+
+// cacheChecksum returns the lower cased sha256 checksum naming the file in the checksum cache, it is empty when the
+// file is not cached
+func cacheChecksum(input DownloadInput) string {
+	if !input.CacheByChecksum {
+		return ""
+	}
+	for algorithm, value := range input.SourceChecksums {
+		if !strings.EqualFold(algorithm, "sha256") {
+			continue
+		}
+		// the checksum names the cached file, anything but a sha256 could point out of the cache
+		value = strings.ToLower(strings.TrimSpace(value))
+		if decoded, err := hex.DecodeString(value); err == nil && len(decoded) == sha256.Size {
+			return value
+		}
+	}
+	return ""
+}
+
+// downloadCached copies the cached file of the checksum to destFile if its content still matches the checksum,
+// otherwise the file is downloaded and added to the cache
+func downloadCached(context context.T, input DownloadInput, fileURL *url.URL, checksum string, destFile string) (output DownloadOutput, err error) {
+	log := context.Log()
+	cacheDir := checksumCacheDir()
+	if err = fileutil.MakeDirs(cacheDir); err != nil {
+		return output, fmt.Errorf("failed to create directory=%v, err=%v", cacheDir, err)
+	}
+	pruneChecksumCache(log, cacheDir, time.Now())
+
+	cachedFile := filepath.Join(cacheDir, checksum)
+	if fileutil.Exists(cachedFile) {
+		if hash, hashErr := Sha256HashValue(log, cachedFile); hashErr != nil || hash != checksum {
+			log.Warnf("The cached copy of %v does not match its checksum, downloading it again", input.SourceURL)
+			os.Remove(cachedFile)
+		} else if err = copyFile(log, cachedFile, destFile); err != nil {
+			log.Warnf("Failed to copy the cached copy of %v, downloading it again: %v", input.SourceURL, err)
+		} else {
+			now := time.Now()
+			os.Chtimes(cachedFile, now, now)
+			log.Infof("Reusing the cached copy of %v", input.SourceURL)
+			return DownloadOutput{LocalFilePath: destFile, IsHashMatched: true}, nil
+		}
+	}
+
+	if output, err = downloadFile(context, input, fileURL, destFile); err != nil {
+		return
+	}
+	if output.IsHashMatched, err = VerifyHash(log, input, output); err != nil || !output.IsHashMatched {
+		return
+	}
+	if cacheErr := addToChecksumCache(log, output.LocalFilePath, cachedFile); cacheErr != nil {
+		log.Warnf("Failed to cache %v: %v", input.SourceURL, cacheErr)
+	}
+	return
+}
+
+// addToChecksumCache copies the file to the cache through a temporary file, the concurrent downloads of the
+// same file never see a partial copy
+func addToChecksumCache(log log.T, filePath string, cachedFile string) error {
+	temp, err := ioutil.TempFile(filepath.Dir(cachedFile), "."+filepath.Base(cachedFile))
+	if err != nil {
+		return err
+	}
+	temp.Close()
+	if err = copyFile(log, filePath, temp.Name()); err == nil {
+		err = os.Rename(temp.Name(), cachedFile)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+	}
+	return err
+}
+
+// pruneChecksumCache removes the cached files which were not reused for checksumCacheMaxAge
+func pruneChecksumCache(log log.T, cacheDir string, now time.Time) {
+	files, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		log.Debugf("Failed to read checksum cache %v: %v", cacheDir, err)
+		return
+	}
+	for _, file := range files {
+		if file.Mode().IsRegular() && now.Sub(file.ModTime()) > checksumCacheMaxAge {
+			log.Debugf("Removing %v from the checksum cache", file.Name())
+			os.Remove(filepath.Join(cacheDir, file.Name()))
+		}
+	}
+}
+
+// copyFile copies the content of the source file to the destination file
+func copyFile(log log.T, source string, destination string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = FileCopy(log, destination, file)
+	return err
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func useTestChecksumCache(t *testing.T) string {
+	cacheDir, defaultCacheDir := t.TempDir(), checksumCacheDir
+	checksumCacheDir = func() string { return cacheDir }
+	t.Cleanup(func() { checksumCacheDir = defaultCacheDir })
+	return cacheDir
+}
+
+func testContentChecksum() string {
+	sum := sha256.Sum256(testContent)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloadCachedByChecksum(t *testing.T) {
+	var ranges []string
+	server := newTestServer(&ranges)
+	defer server.Close()
+	cacheDir := useTestChecksumCache(t)
+	checksum := testContentChecksum()
+	download := func(sourceURL string) DownloadOutput {
+		output, err := Download(context.NewMockDefault(), DownloadInput{
+			SourceURL:            sourceURL,
+			DestinationDirectory: t.TempDir(),
+			SourceChecksums:      map[string]string{"sha256": checksum},
+			CacheByChecksum:      true,
+		})
+		assert.NoError(t, err)
+		assert.True(t, output.IsHashMatched)
+		content, _ := ioutil.ReadFile(output.LocalFilePath)
+		assert.Equal(t, testContent, content)
+		return output
+	}
+
+	output := download(server.URL + "/package.zip?signature=1")
+	assert.True(t, output.IsUpdated)
+	assert.Len(t, ranges, 1)
+	assert.FileExists(t, filepath.Join(cacheDir, checksum))
+
+	// another url of the same file reuses the cached copy
+	output = download(server.URL + "/package.zip?signature=2")
+	assert.False(t, output.IsUpdated)
+	assert.Len(t, ranges, 1)
+
+	// a corrupted cached copy is downloaded again
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(cacheDir, checksum), []byte("corrupted"), 0600))
+	output = download(server.URL + "/package.zip?signature=3")
+	assert.True(t, output.IsUpdated)
+	assert.Len(t, ranges, 2)
+	content, _ := ioutil.ReadFile(filepath.Join(cacheDir, checksum))
+	assert.Equal(t, testContent, content)
+}
+
+func TestDownloadNotCachedWithMismatchedChecksum(t *testing.T) {
+	var ranges []string
+	server := newTestServer(&ranges)
+	defer server.Close()
+	cacheDir := useTestChecksumCache(t)
+	checksum := hex.EncodeToString(make([]byte, sha256.Size))
+
+	_, err := Download(context.NewMockDefault(), DownloadInput{
+		SourceURL:            server.URL + "/package.zip",
+		DestinationDirectory: t.TempDir(),
+		SourceChecksums:      map[string]string{"sha256": checksum},
+		CacheByChecksum:      true,
+	})
+
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(cacheDir, checksum))
+}
+
+func TestCacheChecksum(t *testing.T) {
+	checksum := testContentChecksum()
+	assert.Equal(t, checksum, cacheChecksum(DownloadInput{SourceChecksums: map[string]string{"SHA256": " " + checksum + " "}, CacheByChecksum: true}))
+	assert.Empty(t, cacheChecksum(DownloadInput{SourceChecksums: map[string]string{"sha256": checksum}}))
+	assert.Empty(t, cacheChecksum(DownloadInput{SourceChecksums: map[string]string{"md5": checksum}, CacheByChecksum: true}))
+	assert.Empty(t, cacheChecksum(DownloadInput{SourceChecksums: map[string]string{"sha256": "../../etc/passwd"}, CacheByChecksum: true}))
+}
+
+func TestPruneChecksumCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	now := time.Now()
+	recent, old := filepath.Join(cacheDir, "recent"), filepath.Join(cacheDir, "old")
+	assert.NoError(t, ioutil.WriteFile(recent, []byte("recent"), 0600))
+	assert.NoError(t, ioutil.WriteFile(old, []byte("old"), 0600))
+	assert.NoError(t, os.Chtimes(old, now.Add(-checksumCacheMaxAge-time.Hour), now.Add(-checksumCacheMaxAge-time.Hour)))
+
+	pruneChecksumCache(log.NewMockLog(), cacheDir, now)
+
+	assert.FileExists(t, recent)
+	assert.NoFileExists(t, old)
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+// mirrorRetryInterval is the time during which a mirror which failed a download is only tried after the source
+const mirrorRetryInterval = 15 * time.Minute
+
+// mirrorHealth holds the time of the last failed download of the mirrors by mirror url
+var mirrorHealth = struct {
+	sync.Mutex
+	failedAt map[string]time.Time
+}{failedAt: make(map[string]time.Time)}
+
+// mirrorSource is the url of an artifact on a mirror
+type mirrorSource struct {
+	url    string
+	mirror appconfig.ArtifactMirror
+}
+
+// mirrorSources returns the urls of the artifact on the mirrors serving it in their configured order,
+// the mirrors which failed a download recently are returned separately
+func mirrorSources(mirrors []appconfig.ArtifactMirror, sourceURL string, now time.Time) (healthy []mirrorSource, failed []mirrorSource) {
+	mirrorHealth.Lock()
+	defer mirrorHealth.Unlock()
+	for _, mirror := range mirrors {
+		if !strings.HasPrefix(sourceURL, mirror.SourcePrefix) {
+			continue
+		}
+		source := mirrorSource{
+			url:    mirror.URL + strings.TrimPrefix(sourceURL, mirror.SourcePrefix),
+			mirror: mirror,
+		}
+		if failedAt, found := mirrorHealth.failedAt[mirror.URL]; found && now.Sub(failedAt) < mirrorRetryInterval {
+			failed = append(failed, source)
+		} else {
+			healthy = append(healthy, source)
+		}
+	}
+	return
+}
+
+// setMirrorHealth records whether the last download from the mirror succeeded
+func setMirrorHealth(mirror appconfig.ArtifactMirror, succeeded bool, now time.Time) {
+	mirrorHealth.Lock()
+	defer mirrorHealth.Unlock()
+	if succeeded {
+		delete(mirrorHealth.failedAt, mirror.URL)
+	} else {
+		mirrorHealth.failedAt[mirror.URL] = now
+	}
+}
+
+// downloadFromMirrors downloads the file from the first of the mirrors serving it with the expected checksums
+func downloadFromMirrors(context context.T, input DownloadInput, sources []mirrorSource, destFile string) (output DownloadOutput, err error) {
+	log := context.Log()
+	if len(sources) == 0 {
+		return output, fmt.Errorf("no mirror serves %v", input.SourceURL)
+	}
+	for _, source := range sources {
+		log.Infof("Downloading %v from mirror %v", input.SourceURL, source.mirror.URL)
+		if output, err = downloadFromMirror(context, input, source, destFile); err == nil {
+			if output.IsHashMatched, err = VerifyHash(log, input, output); err == nil {
+				setMirrorHealth(source.mirror, true, time.Now())
+				return output, nil
+			}
+			// the file served by the mirror is not the expected one
+			fileutil.DeleteFile(destFile)
+			fileutil.DeleteFile(destFile + ".etag")
+		}
+		log.Warnf("Failed to download %v from mirror %v: %v", input.SourceURL, source.mirror.URL, err)
+		setMirrorHealth(source.mirror, false, time.Now())
+	}
+	return
+}
+
+// downloadFromMirror downloads the file via http/s from the mirror, the mirror headers are added to the requests
+func downloadFromMirror(context context.T, input DownloadInput, source mirrorSource, destFile string) (DownloadOutput, error) {
+	if input.Resumable {
+		return retryResumableHttpDownload(context, source.url, source.mirror.Headers, newResumableDownload(context.Log(), destFile, input.Progress))
+	}
+	return httpDownload(context, source.url, source.mirror.Headers, destFile)
+}
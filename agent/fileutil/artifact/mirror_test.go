@@ -0,0 +1,124 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMirrorServer serves testContent to the requests with the expected authorization header and counts the requests
+func newMirrorServer(requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		http.ServeContent(w, r, "package.zip", time.Time{}, bytes.NewReader(testContent))
+	}))
+}
+
+func resetMirrorHealth() {
+	mirrorHealth.Lock()
+	defer mirrorHealth.Unlock()
+	mirrorHealth.failedAt = make(map[string]time.Time)
+}
+
+func testDownloadInput(t *testing.T, sourceURL string, mirrors []appconfig.ArtifactMirror) DownloadInput {
+	sum := sha256.Sum256(testContent)
+	return DownloadInput{
+		SourceURL:            sourceURL,
+		DestinationDirectory: t.TempDir(),
+		SourceChecksums:      map[string]string{"sha256": hex.EncodeToString(sum[:])},
+		Mirrors:              mirrors,
+	}
+}
+
+func TestMirrorSources(t *testing.T) {
+	defer resetMirrorHealth()
+	now := time.Now()
+	first := appconfig.ArtifactMirror{SourcePrefix: "https://source/ssm/", URL: "https://first/"}
+	second := appconfig.ArtifactMirror{SourcePrefix: "https://source/ssm/", URL: "https://second/ssm/"}
+	other := appconfig.ArtifactMirror{SourcePrefix: "https://other/", URL: "https://third/"}
+	mirrors := []appconfig.ArtifactMirror{first, second, other}
+
+	healthy, failed := mirrorSources(mirrors, "https://source/ssm/linux/package.zip", now)
+	assert.Equal(t, []mirrorSource{{"https://first/linux/package.zip", first}, {"https://second/ssm/linux/package.zip", second}}, healthy)
+	assert.Empty(t, failed)
+
+	setMirrorHealth(first, false, now)
+	healthy, failed = mirrorSources(mirrors, "https://source/ssm/linux/package.zip", now.Add(time.Minute))
+	assert.Equal(t, []mirrorSource{{"https://second/ssm/linux/package.zip", second}}, healthy)
+	assert.Equal(t, []mirrorSource{{"https://first/linux/package.zip", first}}, failed)
+
+	// the failed mirror is tried first again after the retry interval
+	healthy, failed = mirrorSources(mirrors, "https://source/ssm/linux/package.zip", now.Add(mirrorRetryInterval))
+	assert.Equal(t, 2, len(healthy))
+	assert.Empty(t, failed)
+}
+
+func TestDownloadFromMirror(t *testing.T) {
+	defer resetMirrorHealth()
+	mirrorRequests := 0
+	mirror := newMirrorServer(&mirrorRequests)
+	defer mirror.Close()
+
+	mirrors := []appconfig.ArtifactMirror{{
+		SourcePrefix: "http://unreachable.invalid/ssm/",
+		URL:          mirror.URL + "/",
+		Headers:      map[string]string{"Authorization": "Bearer token"},
+	}}
+	output, err := Download(context.NewMockDefault(), testDownloadInput(t, "http://unreachable.invalid/ssm/package.zip", mirrors))
+	assert.NoError(t, err)
+	assert.True(t, output.IsHashMatched)
+	assert.Equal(t, 1, mirrorRequests)
+	content, _ := ioutil.ReadFile(output.LocalFilePath)
+	assert.Equal(t, testContent, content)
+}
+
+func TestDownloadFailsOverToSource(t *testing.T) {
+	defer resetMirrorHealth()
+	mirrorRequests := 0
+	mirror := newMirrorServer(&mirrorRequests)
+	defer mirror.Close()
+	var ranges []string
+	source := newTestServer(&ranges)
+	defer source.Close()
+
+	// the mirror refuses the requests without the authorization header
+	mirrors := []appconfig.ArtifactMirror{{SourcePrefix: source.URL + "/", URL: mirror.URL + "/"}}
+	output, err := Download(context.NewMockDefault(), testDownloadInput(t, source.URL+"/package.zip", mirrors))
+	assert.NoError(t, err)
+	assert.True(t, output.IsHashMatched)
+	assert.Equal(t, 1, mirrorRequests)
+	assert.Equal(t, 1, len(ranges))
+
+	// the failed mirror is tried after the source
+	output, err = Download(context.NewMockDefault(), testDownloadInput(t, source.URL+"/package.zip", mirrors))
+	assert.NoError(t, err)
+	assert.True(t, output.IsHashMatched)
+	assert.Equal(t, 1, mirrorRequests)
+	assert.Equal(t, 2, len(ranges))
+}
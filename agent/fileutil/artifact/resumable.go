@@ -0,0 +1,466 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/backoffconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/s3util"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	// checkpointInterval is the number of bytes downloaded between two checkpoints
+	checkpointInterval = 8 * 1024 * 1024
+
+	// progressStepPercent is the download progress reported between two calls of the ProgressFunc
+	progressStepPercent = 10
+
+	partialFileSuffix    = ".partial"
+	checkpointFileSuffix = ".checkpoint"
+)
+
+// ProgressFunc is called as a resumable download progresses with the number of bytes downloaded so far and the
+// total size of the file, total is 0 when the server did not report the size of the file
+type ProgressFunc func(downloaded int64, total int64)
+
+// downloadCheckpoint records how much of a file has been downloaded and the hash of the downloaded bytes
+type downloadCheckpoint struct {
+	ETag   string
+	Offset int64
+	Total  int64
+	Sha256 string
+}
+
+// resumableDownload writes a file to a partial file next to its destination and checkpoints its progress so that
+// a failed download restarts from its last checkpoint instead of from zero
+type resumableDownload struct {
+	log        log.T
+	destFile   string
+	checkpoint downloadCheckpoint
+	hasher     hash.Hash
+	progress   ProgressFunc
+	reported   int64
+}
+
+// newResumableDownload returns the download of destFile, resumed from the checkpoint of a previous attempt if any
+func newResumableDownload(log log.T, destFile string, progress ProgressFunc) *resumableDownload {
+	download := &resumableDownload{
+		log:      log,
+		destFile: destFile,
+		hasher:   sha256.New(),
+		progress: progress,
+	}
+	download.resume()
+	return download
+}
+
+func (d *resumableDownload) partialFile() string {
+	return d.destFile + partialFileSuffix
+}
+
+func (d *resumableDownload) checkpointFile() string {
+	return d.destFile + checkpointFileSuffix
+}
+
+// resume loads the checkpoint of a previous attempt, the partial file is only kept if the hash of its bytes up to
+// the checkpoint matches the hash recorded in the checkpoint
+func (d *resumableDownload) resume() {
+	if !fileutil.Exists(d.checkpointFile()) {
+		d.reset()
+		return
+	}
+
+	var checkpoint downloadCheckpoint
+	content, err := fileutil.ReadAllText(d.checkpointFile())
+	if err == nil {
+		err = json.Unmarshal([]byte(content), &checkpoint)
+	}
+	if err != nil || checkpoint.Offset <= 0 || checkpoint.ETag == "" {
+		d.log.Debugf("Ignoring download checkpoint %v: %v", d.checkpointFile(), err)
+		d.reset()
+		return
+	}
+
+	file, err := os.Open(d.partialFile())
+	if err != nil {
+		d.log.Debugf("Partial download %v cannot be read, restarting the download: %v", d.partialFile(), err)
+		d.reset()
+		return
+	}
+	hasher := sha256.New()
+	_, err = io.CopyN(hasher, file, checkpoint.Offset)
+	file.Close()
+	if err != nil || hex.EncodeToString(hasher.Sum(nil)) != checkpoint.Sha256 {
+		d.log.Warnf("Partial download %v does not match its checkpoint, restarting the download", d.partialFile())
+		d.reset()
+		return
+	}
+	// the bytes written after the checkpoint were never verified, they are downloaded again
+	if err = os.Truncate(d.partialFile(), checkpoint.Offset); err != nil {
+		d.log.Debugf("Partial download %v cannot be truncated, restarting the download: %v", d.partialFile(), err)
+		d.reset()
+		return
+	}
+
+	d.log.Infof("Resuming download of %v from byte %v", d.destFile, checkpoint.Offset)
+	d.checkpoint = checkpoint
+	d.hasher = hasher
+}
+
+// reset discards the partial file and its checkpoint
+func (d *resumableDownload) reset() {
+	_ = fileutil.DeleteFile(d.partialFile())
+	_ = fileutil.DeleteFile(d.checkpointFile())
+	d.checkpoint = downloadCheckpoint{}
+	d.hasher = sha256.New()
+	d.reported = 0
+}
+
+// isResuming returns true if the download continues from a checkpoint
+func (d *resumableDownload) isResuming() bool {
+	return d.checkpoint.Offset > 0
+}
+
+// resumable returns true if the bytes after the checkpoint can be requested from the server, which requires the
+// etag of the file and a partial file holding exactly the bytes up to the checkpoint. The download is discarded
+// and restarts from zero otherwise.
+func (d *resumableDownload) resumable() bool {
+	if !d.isResuming() {
+		return false
+	}
+	if info, err := os.Stat(d.partialFile()); err == nil && d.checkpoint.ETag != "" && info.Size() == d.checkpoint.Offset {
+		return true
+	}
+	d.log.Infof("Download of %v cannot be resumed from byte %v, restarting the download", d.destFile, d.checkpoint.Offset)
+	d.reset()
+	return false
+}
+
+// rangeHeader returns the value of the Range header requesting the bytes after the checkpoint
+func (d *resumableDownload) rangeHeader() string {
+	return fmt.Sprintf("bytes=%d-", d.checkpoint.Offset)
+}
+
+// write copies body to the partial file and renames it to the destination file once complete. resumed is true
+// when body starts at the checkpoint, the download restarts from zero otherwise. total is the size of the whole
+// file, 0 if unknown.
+func (d *resumableDownload) write(body io.Reader, resumed bool, eTag string, total int64) (err error) {
+	if d.isResuming() && !resumed {
+		d.log.Infof("Server did not resume the download of %v, restarting the download", d.destFile)
+		d.reset()
+	}
+	d.checkpoint.ETag = eTag
+	d.checkpoint.Total = total
+
+	var file *os.File
+	if file, err = os.OpenFile(d.partialFile(), os.O_WRONLY|os.O_CREATE, appconfig.ReadWriteAccess); err != nil {
+		return fmt.Errorf("failed to open partial download %v: %v", d.partialFile(), err)
+	}
+	// the bytes written after the last checkpoint were never verified, they are downloaded again
+	if err = file.Truncate(d.checkpoint.Offset); err == nil {
+		_, err = file.Seek(d.checkpoint.Offset, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to prepare partial download %v: %v", d.partialFile(), err)
+	}
+
+	buffer := make([]byte, 32*1024)
+	var sinceCheckpoint int64
+	for {
+		n, readErr := body.Read(buffer)
+		if n > 0 {
+			if _, err = file.Write(buffer[:n]); err != nil {
+				file.Close()
+				return fmt.Errorf("failed to write partial download %v: %v", d.partialFile(), err)
+			}
+			d.hasher.Write(buffer[:n])
+			d.checkpoint.Offset += int64(n)
+			sinceCheckpoint += int64(n)
+			if sinceCheckpoint >= checkpointInterval {
+				if err = d.saveCheckpoint(file); err != nil {
+					file.Close()
+					return err
+				}
+				sinceCheckpoint = 0
+			}
+			d.reportProgress()
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			d.log.Infof("Download of %v interrupted at byte %v: %v", d.destFile, d.checkpoint.Offset, readErr)
+			checkpointErr := d.saveCheckpoint(file)
+			file.Close()
+			if checkpointErr != nil {
+				d.log.Debugf("Unable to checkpoint the download: %v", checkpointErr)
+				d.reset()
+			}
+			return readErr
+		}
+	}
+
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("failed to close partial download %v: %v", d.partialFile(), err)
+	}
+	if total > 0 && d.checkpoint.Offset != total {
+		err = fmt.Errorf("download of %v ended at byte %v of %v", d.destFile, d.checkpoint.Offset, total)
+		d.reset()
+		return err
+	}
+	if err = os.Rename(d.partialFile(), d.destFile); err != nil {
+		return fmt.Errorf("failed to move partial download to %v: %v", d.destFile, err)
+	}
+	_ = fileutil.DeleteFile(d.checkpointFile())
+	if d.progress != nil {
+		d.progress(d.checkpoint.Offset, d.checkpoint.Offset)
+	}
+	d.log.Infof("%s with %v bytes downloaded", d.destFile, d.checkpoint.Offset)
+	return nil
+}
+
+// saveCheckpoint flushes the partial file and records the number of bytes written and their hash
+func (d *resumableDownload) saveCheckpoint(file *os.File) error {
+	if d.checkpoint.ETag == "" {
+		// the download cannot be resumed without knowing whether the file changed on the server
+		return nil
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to flush partial download %v: %v", d.partialFile(), err)
+	}
+	d.checkpoint.Sha256 = hex.EncodeToString(d.hasher.Sum(nil))
+	content, _ := json.Marshal(d.checkpoint)
+	if _, err := fileutil.WriteIntoFileWithPermissions(d.checkpointFile(), string(content), appconfig.ReadWriteAccess); err != nil {
+		return fmt.Errorf("failed to write download checkpoint %v: %v", d.checkpointFile(), err)
+	}
+	return nil
+}
+
+// reportProgress calls the ProgressFunc each time another progressStepPercent of the file is downloaded, or at
+// each checkpoint when the size of the file is unknown
+func (d *resumableDownload) reportProgress() {
+	if d.progress == nil {
+		return
+	}
+	if d.checkpoint.Total <= 0 {
+		if step := d.checkpoint.Offset / checkpointInterval; step > d.reported {
+			d.reported = step
+			d.progress(d.checkpoint.Offset, 0)
+		}
+		return
+	}
+	if step := d.checkpoint.Offset * 100 / d.checkpoint.Total / progressStepPercent; step > d.reported && d.checkpoint.Offset < d.checkpoint.Total {
+		d.reported = step
+		d.progress(d.checkpoint.Offset, d.checkpoint.Total)
+	}
+}
+
+// resumableDownloadFile downloads the file from s3 when the url is an s3 url, falling back to http/s, each attempt
+// resumes the download from the last checkpoint of the previous attempts
+func resumableDownloadFile(ctx context.T, input DownloadInput, amazonS3URL s3util.AmazonS3URL, destFile string) (output DownloadOutput, err error) {
+	log := ctx.Log()
+	download := newResumableDownload(log, destFile, input.Progress)
+	if amazonS3URL.IsBucketAndKeyPresent() {
+		if output, err = resumableS3Download(ctx, amazonS3URL, download); err == nil {
+			return
+		}
+		log.Info("An error occurred when attempting s3 download. Attempting http/https download as fallback.")
+	}
+
+	return retryResumableHttpDownload(ctx, input.SourceURL, nil, download)
+}
+
+// retryResumableHttpDownload downloads the file via http/s, each attempt resumes the download from the last
+// checkpoint of the previous attempts
+func retryResumableHttpDownload(ctx context.T, fileURL string, headers map[string]string, download *resumableDownload) (output DownloadOutput, err error) {
+	exponentialBackoff, err := backoffconfig.GetExponentialBackoff(200*time.Millisecond, 5)
+	if err != nil {
+		return
+	}
+	err = backoff.Retry(func() (err error) {
+		output, err = resumableHttpDownload(ctx, fileURL, headers, download)
+		return
+	}, exponentialBackoff)
+	return
+}
+
+// resumableHttpDownload downloads the file via http/s, requesting only the bytes after the checkpoint when the
+// download is resumed, the headers are added to the request
+func resumableHttpDownload(ctx context.T, fileURL string, headers map[string]string, download *resumableDownload) (output DownloadOutput, err error) {
+	log := ctx.Log()
+	log.Debugf("attempting to download as resumable http/https download from %v to %v", fileURL, download.destFile)
+	var httpRequest *http.Request
+	if httpRequest, err = http.NewRequest("GET", fileURL, nil); err != nil {
+		return
+	}
+	addHeaders(httpRequest, headers)
+	if download.resumable() {
+		httpRequest.Header.Set("Range", download.rangeHeader())
+		httpRequest.Header.Set("If-Range", download.checkpoint.ETag)
+	} else if existingETag := readETag(download.destFile); existingETag != "" {
+		httpRequest.Header.Add("If-None-Match", existingETag)
+	}
+
+	client := newHttpClient(ctx)
+	var resp *http.Response
+	if resp, err = client.Do(httpRequest); err != nil {
+		log.Debugf("failed to download from http/https: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	eTag := resp.Header.Get("Etag")
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		log.Debugf("Unchanged file.")
+		output.LocalFilePath = download.destFile
+		return output, nil
+	case http.StatusOK:
+		err = download.write(resp.Body, false, eTag, resp.ContentLength)
+	case http.StatusPartialContent:
+		var total int64
+		if total, err = download.resumedTotal(resp.Header.Get("Content-Range")); err == nil {
+			err = download.write(resp.Body, true, download.checkpoint.ETag, total)
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		download.reset()
+		err = fmt.Errorf("server cannot resume the download of %v", fileURL)
+	default:
+		err = fmt.Errorf("http request failed. status:%v statuscode:%v", resp.Status, resp.StatusCode)
+		// skip backoff logic if permission denied to the URL
+		if resp.StatusCode == http.StatusForbidden {
+			return output, &backoff.PermanentError{Err: err}
+		}
+	}
+	if err != nil {
+		return
+	}
+	return download.completed(eTag), nil
+}
+
+// resumableS3Download downloads the file via the aws sdk, requesting only the bytes after the checkpoint when the
+// download is resumed
+func resumableS3Download(ctx context.T, amazonS3URL s3util.AmazonS3URL, download *resumableDownload) (output DownloadOutput, err error) {
+	log := ctx.Log()
+	log.Debugf("attempting to download as resumable s3 download %v", download.destFile)
+	params := &s3.GetObjectInput{
+		Bucket: aws.String(amazonS3URL.Bucket),
+		Key:    aws.String(amazonS3URL.Key),
+	}
+	resuming := download.resumable()
+	if resuming {
+		params.Range = aws.String(download.rangeHeader())
+		params.IfMatch = aws.String(download.checkpoint.ETag)
+	} else if existingETag := readETag(download.destFile); existingETag != "" {
+		params.IfNoneMatch = aws.String(existingETag)
+	}
+
+	sess, err := s3util.GetS3CrossRegionCapableSession(ctx, amazonS3URL.Bucket)
+	if err != nil {
+		log.Errorf("failed to get S3 session: %v", err)
+		return
+	}
+	req, resp := s3.New(sess).GetObjectRequest(params)
+	if err = req.Send(); err != nil {
+		if req.HTTPResponse != nil {
+			switch req.HTTPResponse.StatusCode {
+			case http.StatusNotModified:
+				log.Debugf("Unchanged file.")
+				output.LocalFilePath = download.destFile
+				return output, nil
+			case http.StatusPreconditionFailed, http.StatusRequestedRangeNotSatisfiable:
+				// the object changed since the checkpoint
+				download.reset()
+			}
+		}
+		log.Debug("failed to download from s3, ", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	eTag := aws.StringValue(resp.ETag)
+	if resuming {
+		var total int64
+		if total, err = download.resumedTotal(aws.StringValue(resp.ContentRange)); err == nil {
+			err = download.write(resp.Body, true, download.checkpoint.ETag, total)
+		}
+	} else {
+		err = download.write(resp.Body, false, eTag, aws.Int64Value(resp.ContentLength))
+	}
+	if err != nil {
+		return
+	}
+	return download.completed(eTag), nil
+}
+
+// resumedTotal parses the Content-Range of a resumed download and returns the total size of the file, the
+// partial file is discarded if the range does not start at the checkpoint
+func (d *resumableDownload) resumedTotal(contentRange string) (total int64, err error) {
+	// bytes <start>-<end>/<total>, total is * when unknown
+	var start int64
+	rangeAndTotal := strings.SplitN(strings.TrimPrefix(contentRange, "bytes "), "/", 2)
+	if len(rangeAndTotal) == 2 {
+		start, err = strconv.ParseInt(strings.SplitN(rangeAndTotal[0], "-", 2)[0], 10, 64)
+		if err == nil && rangeAndTotal[1] != "*" {
+			total, err = strconv.ParseInt(rangeAndTotal[1], 10, 64)
+		}
+	}
+	if len(rangeAndTotal) != 2 || err != nil || start != d.checkpoint.Offset {
+		d.reset()
+		return 0, fmt.Errorf("unexpected content range %v resuming the download of %v", contentRange, d.destFile)
+	}
+	return total, nil
+}
+
+// completed records the etag of the downloaded file, later downloads of an unchanged file are skipped
+func (d *resumableDownload) completed(eTag string) (output DownloadOutput) {
+	eTagFile := d.destFile + ".etag"
+	if eTag == "" {
+		_ = fileutil.DeleteFile(eTagFile)
+	} else if err := fileutil.WriteAllText(eTagFile, eTag); err != nil {
+		d.log.Warnf("failed to write eTagfile %v, %v ", eTagFile, err)
+	}
+	output.LocalFilePath = d.destFile
+	output.IsUpdated = true
+	return
+}
+
+// readETag returns the etag of a previously downloaded file, empty if the file or its etag is missing
+func readETag(destFile string) string {
+	eTagFile := destFile + ".etag"
+	if !fileutil.Exists(destFile) || !fileutil.Exists(eTagFile) {
+		return ""
+	}
+	eTag, _ := fileutil.ReadAllText(eTagFile)
+	return eTag
+}
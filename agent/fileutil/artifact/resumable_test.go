@@ -0,0 +1,200 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const testETag = `"v1"`
+
+var testContent = bytes.Repeat([]byte("0123456789abcdef"), 1024)
+
+// newTestServer serves testContent with its etag and records the Range header of each request
+func newTestServer(ranges *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		w.Header().Set("Etag", testETag)
+		http.ServeContent(w, r, "package.zip", time.Time{}, bytes.NewReader(testContent))
+	}))
+}
+
+func writeTestCheckpoint(t *testing.T, destFile string, partial []byte, hashed []byte) {
+	assert.Nil(t, ioutil.WriteFile(destFile+partialFileSuffix, partial, 0600))
+	sum := sha256.Sum256(hashed)
+	content, _ := json.Marshal(downloadCheckpoint{ETag: testETag, Offset: int64(len(hashed)), Sha256: hex.EncodeToString(sum[:])})
+	assert.Nil(t, ioutil.WriteFile(destFile+checkpointFileSuffix, content, 0600))
+}
+
+func TestResumableHttpDownload(t *testing.T) {
+	var ranges []string
+	server := newTestServer(&ranges)
+	defer server.Close()
+	destFile := filepath.Join(t.TempDir(), "package")
+	var progress []int64
+
+	output, err := resumableHttpDownload(context.NewMockDefault(), server.URL, nil, newResumableDownload(log.NewMockLog(), destFile, func(downloaded int64, total int64) {
+		assert.Equal(t, int64(len(testContent)), total)
+		progress = append(progress, downloaded)
+	}))
+
+	assert.Nil(t, err)
+	assert.True(t, output.IsUpdated)
+	assert.Equal(t, destFile, output.LocalFilePath)
+	assert.Equal(t, []string{""}, ranges)
+	content, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, testContent, content)
+	assert.NoFileExists(t, destFile+partialFileSuffix)
+	assert.NoFileExists(t, destFile+checkpointFileSuffix)
+	assert.NotEmpty(t, progress)
+	assert.Equal(t, int64(len(testContent)), progress[len(progress)-1])
+
+	// the unchanged file is not downloaded again
+	output, err = resumableHttpDownload(context.NewMockDefault(), server.URL, nil, newResumableDownload(log.NewMockLog(), destFile, nil))
+	assert.Nil(t, err)
+	assert.False(t, output.IsUpdated)
+	assert.Equal(t, destFile, output.LocalFilePath)
+}
+
+func TestResumableHttpDownloadResumesFromCheckpoint(t *testing.T) {
+	var ranges []string
+	server := newTestServer(&ranges)
+	defer server.Close()
+	destFile := filepath.Join(t.TempDir(), "package")
+	// the bytes written after the checkpoint are unverified and downloaded again
+	writeTestCheckpoint(t, destFile, append(append([]byte{}, testContent[:4096]...), []byte("garbage")...), testContent[:4096])
+
+	output, err := resumableHttpDownload(context.NewMockDefault(), server.URL, nil, newResumableDownload(log.NewMockLog(), destFile, nil))
+
+	assert.Nil(t, err)
+	assert.True(t, output.IsUpdated)
+	assert.Equal(t, []string{"bytes=4096-"}, ranges)
+	content, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, testContent, content)
+	assert.NoFileExists(t, destFile+checkpointFileSuffix)
+}
+
+func TestResumableHttpDownloadRestartsCorruptedDownload(t *testing.T) {
+	var ranges []string
+	server := newTestServer(&ranges)
+	defer server.Close()
+	destFile := filepath.Join(t.TempDir(), "package")
+	partial := append([]byte{}, testContent[:4096]...)
+	partial[10] = 'x'
+	writeTestCheckpoint(t, destFile, partial, testContent[:4096])
+
+	_, err := resumableHttpDownload(context.NewMockDefault(), server.URL, nil, newResumableDownload(log.NewMockLog(), destFile, nil))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{""}, ranges)
+	content, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, testContent, content)
+}
+
+// failingReader returns the first bytes of the content then fails
+type failingReader struct {
+	reader io.Reader
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestResumableDownloadCheckpointsInterruptedDownload(t *testing.T) {
+	destFile := filepath.Join(t.TempDir(), "package")
+	download := newResumableDownload(log.NewMockLog(), destFile, nil)
+
+	err := download.write(&failingReader{bytes.NewReader(testContent[:1000])}, false, testETag, int64(len(testContent)))
+	assert.NotNil(t, err)
+	assert.NoFileExists(t, destFile)
+
+	resumed := newResumableDownload(log.NewMockLog(), destFile, nil)
+	assert.True(t, resumed.isResuming())
+	assert.Equal(t, "bytes=1000-", resumed.rangeHeader())
+
+	total, err := resumed.resumedTotal("bytes 1000-16383/16384")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(testContent)), total)
+	assert.Nil(t, resumed.write(bytes.NewReader(testContent[1000:]), true, testETag, total))
+	content, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, testContent, content)
+}
+
+func TestResumableHttpDownloadRestartsInterruptedDownloadWithoutETag(t *testing.T) {
+	var ranges []string
+	server := newTestServer(&ranges)
+	defer server.Close()
+	destFile := filepath.Join(t.TempDir(), "package")
+	download := newResumableDownload(log.NewMockLog(), destFile, nil)
+
+	// the previous attempt got no etag, the server cannot tell whether the bytes after the offset still match
+	err := download.write(&failingReader{bytes.NewReader(testContent[:1000])}, false, "", int64(len(testContent)))
+	assert.NotNil(t, err)
+
+	_, err = resumableHttpDownload(context.NewMockDefault(), server.URL, nil, download)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{""}, ranges)
+	content, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, testContent, content)
+}
+
+func TestResumableDownloadRestartsWhenPartialFileDoesNotMatchOffset(t *testing.T) {
+	destFile := filepath.Join(t.TempDir(), "package")
+	download := newResumableDownload(log.NewMockLog(), destFile, nil)
+	err := download.write(&failingReader{bytes.NewReader(testContent[:1000])}, false, testETag, int64(len(testContent)))
+	assert.NotNil(t, err)
+	assert.True(t, download.resumable())
+
+	assert.Nil(t, ioutil.WriteFile(destFile+partialFileSuffix, testContent[:500], 0600))
+
+	assert.False(t, download.resumable())
+	assert.False(t, download.isResuming())
+	assert.NoFileExists(t, destFile+checkpointFileSuffix)
+}
+
+func TestResumedTotal(t *testing.T) {
+	destFile := filepath.Join(t.TempDir(), "package")
+	writeTestCheckpoint(t, destFile, testContent[:100], testContent[:100])
+	download := newResumableDownload(log.NewMockLog(), destFile, nil)
+
+	total, err := download.resumedTotal("bytes 100-199/*")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), total)
+
+	_, err = download.resumedTotal("bytes 0-199/200")
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "unexpected content range"))
+	assert.False(t, download.isResuming(), "mismatching range discards the partial download")
+}
@@ -15,11 +15,14 @@
 package coremodules
 
 import (
+	"fmt"
+
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/health"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/manager"
 	"github.com/aws/amazon-ssm-agent/agent/messageservice"
+	"github.com/aws/amazon-ssm-agent/agent/patchscan"
 	"github.com/aws/amazon-ssm-agent/agent/runcommand"
 	"github.com/aws/amazon-ssm-agent/agent/ssm"
 )
@@ -38,30 +41,50 @@ func RegisteredCoreModules(context context.T) *ModuleRegistry {
 	return &registeredCoreModules
 }
 
-// register core modules here
+// register core modules here, the modules are restarted with a new instance when one of their goroutines panics
 func loadCoreModules(context context.T) {
+	log := context.Log()
 	if !context.AppConfig().Agent.ContainerMode {
-		registeredCoreModules = append(registeredCoreModules, NewCoreModuleWrapper(context.Log(), health.NewHealthCheck(context, ssm.NewService(context))))
+		newHealthCheck := func() (contracts.ICoreModule, error) {
+			return health.NewHealthCheck(context, ssm.NewService(context)), nil
+		}
+		healthCheck, _ := newHealthCheck()
+		registeredCoreModules = append(registeredCoreModules, NewRestartableCoreModuleWrapper(log, healthCheck, newHealthCheck))
 	}
 
-	messageServiceCoreModule := messageservice.NewService(context)
-	if messageServiceCoreModule != nil {
-		registeredCoreModules = append(registeredCoreModules, NewCoreModuleWrapper(context.Log(), messageServiceCoreModule))
+	newMessageService := func() (contracts.ICoreModule, error) {
+		if messageServiceCoreModule := messageservice.NewService(context); messageServiceCoreModule != nil {
+			return messageServiceCoreModule, nil
+		}
+		return nil, fmt.Errorf("message service could not be created")
+	}
+	if messageServiceCoreModule, err := newMessageService(); err == nil {
+		registeredCoreModules = append(registeredCoreModules, NewRestartableCoreModuleWrapper(log, messageServiceCoreModule, newMessageService))
 	}
 
 	if !context.AppConfig().Agent.ContainerMode {
-		if offlineProcessor, err := runcommand.NewOfflineService(context); err == nil {
-			registeredCoreModules = append(registeredCoreModules, NewCoreModuleWrapper(context.Log(), offlineProcessor))
+		newOfflineProcessor := func() (contracts.ICoreModule, error) {
+			return runcommand.NewOfflineService(context)
+		}
+		if offlineProcessor, err := newOfflineProcessor(); err == nil {
+			registeredCoreModules = append(registeredCoreModules, NewRestartableCoreModuleWrapper(log, offlineProcessor, newOfflineProcessor))
+			// patch scans are submitted as offline command documents
+			newPatchScan := func() (contracts.ICoreModule, error) {
+				return patchscan.NewPatchScan(context), nil
+			}
+			patchScan, _ := newPatchScan()
+			registeredCoreModules = append(registeredCoreModules, NewRestartableCoreModuleWrapper(log, patchScan, newPatchScan))
 		} else {
-			context.Log().Errorf("Failed to start offline command document processor")
+			log.Errorf("Failed to start offline command document processor")
 		}
 
 		// registering the long running plugin manager as a core module
+		// the manager is a singleton whose task pools cannot be started again once stopped, it is not restarted
 		manager.EnsureInitialization(context)
 		if lrpm, err := manager.GetInstance(); err == nil {
-			registeredCoreModules = append(registeredCoreModules, NewCoreModuleWrapper(context.Log(), lrpm))
+			registeredCoreModules = append(registeredCoreModules, NewCoreModuleWrapper(log, lrpm))
 		} else {
-			context.Log().Errorf("Something went wrong during initialization of long running plugin manager")
+			log.Errorf("Something went wrong during initialization of long running plugin manager")
 		}
 	}
 }
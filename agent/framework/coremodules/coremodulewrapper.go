@@ -2,14 +2,29 @@ package coremodules
 
 import (
 	"fmt"
+	"path/filepath"
 	"runtime/debug"
 	"sync"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/moduleguard"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
+const (
+	// maxModuleRestarts is the number of times a core module is restarted after a panic
+	maxModuleRestarts = 5
+	// moduleRestartDelay is the delay before the first restart of a core module, it doubles with each restart
+	moduleRestartDelay = 10 * time.Second
+	// moduleRestartStopTimeout is the time given to a crashed core module to stop before it is restarted
+	moduleRestartStopTimeout = 15 * time.Second
+)
+
+// ModuleFactory creates a new instance of a core module, it is used to restart the module after a panic
+type ModuleFactory func() (contracts.ICoreModule, error)
+
 type CoreModuleWrapper struct {
 	module      contracts.ICoreModule
 	log         log.T
@@ -19,16 +34,32 @@ type CoreModuleWrapper struct {
 	started     bool
 	stopStarted bool
 	stopErr     error
+
+	name           string
+	newModule      ModuleFactory
+	moduleLock     sync.RWMutex
+	stopRequested  chan struct{}
+	restarting     bool
+	restartCount   int
+	restartDelay   time.Duration
+	crashReportDir string
+}
+
+func (c *CoreModuleWrapper) currentModule() contracts.ICoreModule {
+	c.moduleLock.RLock()
+	defer c.moduleLock.RUnlock()
+	return c.module
 }
 
 func (c *CoreModuleWrapper) stop() {
+	module := c.currentModule()
 	defer func() {
 		if err := recover(); err != nil {
-			c.log.Errorf("stop on %s panic with error: %v", c.module.ModuleName(), err)
+			c.log.Errorf("stop on %s panic with error: %v", module.ModuleName(), err)
 			c.log.Errorf("Stacktrace:\n%s", debug.Stack())
 		}
 	}()
-	c.stopErr = c.module.ModuleStop()
+	c.stopErr = module.ModuleStop()
 	close(c.stoppedChan)
 }
 
@@ -36,10 +67,11 @@ func (c *CoreModuleWrapper) stop() {
 func (c *CoreModuleWrapper) ModuleStop(waitTime time.Duration) (err error) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
+	module := c.currentModule()
 
 	defer func() {
 		if r := recover(); r != nil {
-			c.log.Errorf("moduleStop on %s panic with error: %v", c.module.ModuleName(), r)
+			c.log.Errorf("moduleStop on %s panic with error: %v", module.ModuleName(), r)
 			c.log.Errorf("Stacktrace:\n%s", debug.Stack())
 			err = fmt.Errorf("%v", r)
 		}
@@ -47,18 +79,21 @@ func (c *CoreModuleWrapper) ModuleStop(waitTime time.Duration) (err error) {
 
 	// No need to stop if module never started
 	if !c.started {
-		return fmt.Errorf("cant stop module %s, module has never been started", c.module.ModuleName())
+		return fmt.Errorf("cant stop module %s, module has never been started", module.ModuleName())
 	}
 
 	// If we have not started the stop processes, attempt to stop the module
 	if !c.stopStarted {
 		c.stopStarted = true
+		if c.stopRequested != nil {
+			close(c.stopRequested)
+		}
 		go c.stop()
 	} else {
 		// Check if module has already been stopped
 		select {
 		case <-c.stoppedChan:
-			c.log.Debugf("Module %s already stopped", c.module.ModuleName())
+			c.log.Debugf("Module %s already stopped", module.ModuleName())
 			return c.stopErr
 		default:
 		}
@@ -71,27 +106,150 @@ func (c *CoreModuleWrapper) ModuleStop(waitTime time.Duration) (err error) {
 		return c.stopErr
 	case <-time.After(waitTime):
 		// module stop timed out
-		return fmt.Errorf("timeout stopping module %s", c.module.ModuleName())
+		return fmt.Errorf("timeout stopping module %s", module.ModuleName())
 	}
 }
 
 func (c *CoreModuleWrapper) ModuleName() string {
-	return c.module.ModuleName()
+	return c.currentModule().ModuleName()
 }
 
 func (c *CoreModuleWrapper) ModuleExecute() error {
 	c.started = true
-	return c.module.ModuleExecute()
+	return c.execute(c.currentModule())
+}
+
+// execute executes the module, a panic of the execution is handled like the panics of the module goroutines
+func (c *CoreModuleWrapper) execute(module contracts.ICoreModule) error {
+	defer moduleguard.Recover(c.log, c.name)
+	return module.ModuleExecute()
+}
+
+// onPanic records the crash of the module then restarts it
+func (c *CoreModuleWrapper) onPanic(panicValue interface{}, stack []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Errorf("restart of %s panic with error: %v", c.name, r)
+			c.log.Errorf("Stacktrace:\n%s", debug.Stack())
+		}
+	}()
+
+	c.mtx.Lock()
+	restartCount := c.restartCount
+	c.mtx.Unlock()
+	writeCrashReport(c.log, c.crashReportDir, crashReport{
+		ModuleName:   c.name,
+		Time:         time.Now().UTC(),
+		Panic:        fmt.Sprintf("%v", panicValue),
+		Stack:        string(stack),
+		RestartCount: restartCount,
+	})
+	c.restart()
+}
+
+// restart stops the crashed module and executes a new instance of it once the restart delay elapsed.
+// The module is not restarted when the agent stops it, when it is already restarting or when it reached
+// its maximum number of restarts.
+func (c *CoreModuleWrapper) restart() {
+	c.mtx.Lock()
+	if c.stopStarted || c.restarting {
+		c.mtx.Unlock()
+		return
+	}
+	if c.newModule == nil {
+		c.mtx.Unlock()
+		c.log.Errorf("Core module %s does not support restarts, it may not work until the agent restarts", c.name)
+		return
+	}
+	if c.restartCount >= maxModuleRestarts {
+		c.mtx.Unlock()
+		c.log.Errorf("Core module %s reached its maximum of %d restarts, it may not work until the agent restarts", c.name, maxModuleRestarts)
+		return
+	}
+	c.restartCount++
+	c.restarting = true
+	delay := c.restartDelay * time.Duration(1<<uint(c.restartCount-1))
+	crashedModule := c.currentModule()
+	c.log.Warnf("Restarting core module %s in %v, restart %d of %d", c.name, delay, c.restartCount, maxModuleRestarts)
+	c.mtx.Unlock()
+
+	defer func() {
+		c.mtx.Lock()
+		c.restarting = false
+		c.mtx.Unlock()
+	}()
+
+	select {
+	case <-time.After(delay):
+	case <-c.stopRequested:
+		return
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer func() {
+			if r := recover(); r != nil {
+				c.log.Errorf("stop of crashed module %s panic with error: %v", c.name, r)
+			}
+		}()
+		if err := crashedModule.ModuleStop(); err != nil {
+			c.log.Warnf("Crashed core module %s failed to stop: %v", c.name, err)
+		}
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(moduleRestartStopTimeout):
+		c.log.Warnf("Timeout stopping crashed core module %s, restarting it anyway", c.name)
+	}
+
+	c.mtx.Lock()
+	if c.stopStarted {
+		c.mtx.Unlock()
+		return
+	}
+	module, err := c.newModule()
+	if err != nil {
+		c.mtx.Unlock()
+		c.log.Errorf("Unable to create a new instance of core module %s: %v", c.name, err)
+		return
+	}
+	c.moduleLock.Lock()
+	c.module = module
+	c.moduleLock.Unlock()
+	c.mtx.Unlock()
+
+	c.log.Infof("Executing restarted core module %s", c.name)
+	// the restarted module can fail again while it executes, the restart is done at this point
+	go func() {
+		if err := c.execute(module); err != nil {
+			c.log.Errorf("error occurred trying to restart core module %s: %v", c.name, err)
+		}
+	}()
 }
 
 func NewCoreModuleWrapper(log log.T, module contracts.ICoreModule) contracts.ICoreModuleWrapper {
-	return &CoreModuleWrapper{
-		module:      module,
-		log:         log.WithContext("CoreModuleWrapper"),
-		mtx:         &sync.Mutex{},
-		started:     false,
-		stopStarted: false,
-		stoppedChan: make(chan struct{}),
-		stopErr:     nil,
+	return NewRestartableCoreModuleWrapper(log, module, nil)
+}
+
+// NewRestartableCoreModuleWrapper creates the wrapper of a core module restarted with a new instance created
+// by newModule when one of its goroutines panics, the crashes are recorded whether the module can be restarted
+// or not
+func NewRestartableCoreModuleWrapper(log log.T, module contracts.ICoreModule, newModule ModuleFactory) contracts.ICoreModuleWrapper {
+	wrapper := &CoreModuleWrapper{
+		module:         module,
+		log:            log.WithContext("CoreModuleWrapper"),
+		mtx:            &sync.Mutex{},
+		started:        false,
+		stopStarted:    false,
+		stoppedChan:    make(chan struct{}),
+		stopErr:        nil,
+		name:           module.ModuleName(),
+		newModule:      newModule,
+		stopRequested:  make(chan struct{}),
+		restartDelay:   moduleRestartDelay,
+		crashReportDir: filepath.Join(appconfig.DefaultDataStorePath, appconfig.CrashReportsRootDirName),
 	}
+	moduleguard.SetPanicHandler(wrapper.name, wrapper.onPanic)
+	return wrapper
 }
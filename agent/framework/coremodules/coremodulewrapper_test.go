@@ -2,7 +2,10 @@ package coremodules
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -212,3 +215,109 @@ func TestCoreModuleWrapper_ModuleName(t *testing.T) {
 	assert.Equal(t, "SomeName", name)
 	module.AssertExpectations(t)
 }
+
+// createRestartableTestModuleWrapper creates the wrapper of a module restarted with the modules created by
+// newModule, the crash reports are written in a temporary directory
+func createRestartableTestModuleWrapper(t *testing.T, module contracts.ICoreModule, newModule ModuleFactory) *CoreModuleWrapper {
+	crashReportDir, err := ioutil.TempDir("", "crashreports")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(crashReportDir) })
+
+	wrapper := NewRestartableCoreModuleWrapper(logger.NewMockLog(), module, newModule).(*CoreModuleWrapper)
+	wrapper.restartDelay = time.Millisecond
+	wrapper.crashReportDir = crashReportDir
+	return wrapper
+}
+
+// panickingModule returns a module whose execution panics
+func panickingModule(name string) *mocks.ICoreModule {
+	module := &mocks.ICoreModule{}
+	module.On("ModuleName").Return(name)
+	module.On("ModuleExecute").Return(func() error { panic("module bug") })
+	module.On("ModuleStop").Return(nil)
+	return module
+}
+
+func crashReportCount(t *testing.T, dir string) int {
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	return len(files)
+}
+
+func TestCoreModuleWrapper_PanicRestartsModule(t *testing.T) {
+	crashed := panickingModule("RestartedModule")
+	executed := make(chan struct{})
+	restarted := &mocks.ICoreModule{}
+	restarted.On("ModuleExecute").Return(func() error {
+		close(executed)
+		return nil
+	}).Once()
+
+	wrapper := createRestartableTestModuleWrapper(t, crashed, func() (contracts.ICoreModule, error) {
+		return restarted, nil
+	})
+	assert.NoError(t, wrapper.ModuleExecute())
+
+	select {
+	case <-executed:
+	case <-time.After(time.Second):
+		t.Fatal("the module was not restarted")
+	}
+	crashed.AssertCalled(t, "ModuleStop")
+	restarted.AssertExpectations(t)
+	assert.Equal(t, 1, crashReportCount(t, wrapper.crashReportDir))
+}
+
+func TestCoreModuleWrapper_RestartsAreCapped(t *testing.T) {
+	var created int32
+	wrapper := createRestartableTestModuleWrapper(t, panickingModule("CrashingModule"), func() (contracts.ICoreModule, error) {
+		atomic.AddInt32(&created, 1)
+		return panickingModule("CrashingModule"), nil
+	})
+	wrapper.ModuleExecute()
+
+	// the delays of the capped restarts add up to 31ms
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal(t, int32(maxModuleRestarts), atomic.LoadInt32(&created))
+	assert.Equal(t, maxModuleRestarts+1, crashReportCount(t, wrapper.crashReportDir))
+}
+
+func TestCoreModuleWrapper_NoRestartOnceStopped(t *testing.T) {
+	var created int32
+	wrapper := createRestartableTestModuleWrapper(t, panickingModule("StoppedModule"), func() (contracts.ICoreModule, error) {
+		atomic.AddInt32(&created, 1)
+		return panickingModule("StoppedModule"), nil
+	})
+	wrapper.restartDelay = 100 * time.Millisecond
+	wrapper.ModuleExecute()
+	assert.NoError(t, wrapper.ModuleStop(time.Second))
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&created))
+}
+
+func TestCoreModuleWrapper_PanicRecordedWithoutFactory(t *testing.T) {
+	wrapper := createRestartableTestModuleWrapper(t, panickingModule("SingletonModule"), nil)
+
+	assert.NotPanics(t, func() { wrapper.ModuleExecute() })
+
+	assert.Eventually(t, func() bool {
+		return crashReportCount(t, wrapper.crashReportDir) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWriteCrashReport_KeepsLatestReports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crashreports")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxCrashReports+3; i++ {
+		writeCrashReport(logger.NewMockLog(), dir, crashReport{ModuleName: "SomeModule", Time: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, maxCrashReports)
+	assert.Equal(t, "20220101T000300.000000000Z-SomeModule.json", files[0].Name())
+}
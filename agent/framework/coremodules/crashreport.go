@@ -0,0 +1,69 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package coremodules
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// maxCrashReports is the number of crash reports kept, the oldest reports are deleted first
+const maxCrashReports = 20
+
+// crashReport records a panic recovered in a core module
+type crashReport struct {
+	ModuleName string
+	Time       time.Time
+	Panic      string
+	Stack      string
+	// RestartCount is the number of times the module was restarted before this crash
+	RestartCount int
+}
+
+// writeCrashReport writes the report in the crash report directory and deletes the oldest reports
+func writeCrashReport(log log.T, dir string, report crashReport) {
+	if err := fileutil.MakeDirs(dir); err != nil {
+		log.Warnf("Unable to create the crash report directory: %v", err)
+		return
+	}
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Warnf("Unable to marshal the crash report of %s: %v", report.ModuleName, err)
+		return
+	}
+	// the report names sort by time
+	path := filepath.Join(dir, report.Time.Format("20060102T150405.000000000Z")+"-"+report.ModuleName+".json")
+	if _, err = fileutil.WriteIntoFileWithPermissions(path, string(content), appconfig.ReadWriteAccess); err != nil {
+		log.Warnf("Unable to write the crash report of %s: %v", report.ModuleName, err)
+		return
+	}
+	log.Infof("Crash report of %s written to %s", report.ModuleName, path)
+
+	names, err := fileutil.GetFileNames(dir)
+	if err != nil || len(names) <= maxCrashReports {
+		return
+	}
+	sort.Strings(names)
+	for _, name := range names[:len(names)-maxCrashReports] {
+		if err = fileutil.DeleteFile(filepath.Join(dir, name)); err != nil {
+			log.Debugf("Unable to delete crash report %s: %v", name, err)
+		}
+	}
+}
@@ -15,7 +15,8 @@
 package docmanager
 
 import (
-	"os"
+	"encoding/json"
+	"io/ioutil"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -30,12 +31,17 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/session/utility"
+	"github.com/aws/amazon-ssm-agent/agent/statefile"
 )
 
 const (
 	maxOrchestrationDirectoryDeletions int = 100
 )
 
+// DocumentStateSchema is the format of the document state files, a migration is appended to it when the
+// DocumentState contract changes in a way the previous files cannot be parsed with
+var DocumentStateSchema = statefile.Schema{Name: "document"}
+
 type validString func(string) bool
 type modifyString func(string) string
 
@@ -44,6 +50,8 @@ type DocumentMgr interface {
 	PersistDocumentState(fileName, locationFolder string, state contracts.DocumentState)
 	GetDocumentState(fileName, locationFolder string) contracts.DocumentState
 	RemoveDocumentState(fileName, locationFolder string)
+	RecordExecution(record ExecutionRecord)
+	ClaimCorruptionReports(documentType contracts.DocumentType) []CorruptionReport
 }
 
 // TODO use class lock instead of global lock?
@@ -72,19 +80,7 @@ func (d *DocumentFileMgr) MoveDocumentState(fileName, srcLocationFolder, dstLoca
 		log.Errorf("Failed to get short instanceID for MoveDocumentState: %v", err)
 	}
 
-	absoluteSource := path.Join(d.dataStorePath,
-		instanceID,
-		d.rootDirName,
-		d.stateLocation,
-		srcLocationFolder)
-
-	absoluteDestination := path.Join(d.dataStorePath,
-		instanceID,
-		d.rootDirName,
-		d.stateLocation,
-		dstLocationFolder)
-
-	if s, err := fileutil.MoveFile(fileName, absoluteSource, absoluteDestination); s && err == nil {
+	if err := d.store(instanceID).move(fileName, srcLocationFolder, dstLocationFolder); err == nil {
 		log.Debugf("moved file %v from %v to %v successfully", fileName, srcLocationFolder, dstLocationFolder)
 	} else {
 		log.Debugf("moving file %v from %v to %v failed with error %v", fileName, srcLocationFolder, dstLocationFolder, err)
@@ -99,21 +95,12 @@ func (d *DocumentFileMgr) PersistDocumentState(fileName, locationFolder string,
 		log.Errorf("Failed to get short instanceID for PersistDocumentState: %v", err)
 	}
 
-	absoluteFileName := path.Join(path.Join(d.dataStorePath,
-		instanceID,
-		d.rootDirName,
-		d.stateLocation,
-		locationFolder), fileName)
-
-	content, err := jsonutil.Marshal(state)
+	content, err := marshalDocumentState(state)
 	if err != nil {
 		log.Errorf("encountered error with message %v while marshalling %v to string", err, state)
 	} else {
-		if fileutil.Exists(absoluteFileName) {
-			log.Debugf("overwriting contents of %v", absoluteFileName)
-		}
-		log.Tracef("persisting interim state %v in file %v", jsonutil.Indent(content), absoluteFileName)
-		if s, err := fileutil.WriteIntoFileWithPermissions(absoluteFileName, jsonutil.Indent(content), os.FileMode(int(appconfig.ReadWriteAccess))); s && err == nil {
+		log.Tracef("persisting interim state %v in file %v", jsonutil.Indent(content), fileName)
+		if err := d.store(instanceID).write(locationFolder, fileName, jsonutil.Indent(content)); err == nil {
 			log.Debugf("successfully persisted interim state in %v", locationFolder)
 		} else {
 			log.Debugf("persisting interim state in %v failed with error %v", locationFolder, err)
@@ -128,27 +115,21 @@ func (d *DocumentFileMgr) GetDocumentState(fileName, locationFolder string) cont
 		log.Errorf("Failed to get short instanceID for GetDocumentState: %v", err)
 	}
 
-	filepath := path.Join(d.dataStorePath,
-		instanceID,
-		d.rootDirName,
-		d.stateLocation,
-		locationFolder)
-
-	absoluteFileName := path.Join(filepath, fileName)
-
 	var commandState contracts.DocumentState
 	var count, retryLimit int = 0, 3
+	var parseErr error
 
-	if fileExists, _ := fileutil.LocalFileExist(absoluteFileName); !fileExists {
-		log.Warnf("file not found in the docState directory %v", absoluteFileName)
+	store := d.store(instanceID)
+	if !store.exists(locationFolder, fileName) {
+		log.Warnf("document state %v not found in the docState %v directory", fileName, locationFolder)
 		return commandState
 	}
 
 	// retry to avoid sync problem, which arises when OfflineService and MessageDeliveryService try to access the file at the same time
 	for count < retryLimit {
-		err := jsonutil.UnmarshalFile(absoluteFileName, &commandState)
-		if err != nil {
-			log.Errorf("encountered error with message %v while reading Interim state of command from file - %v", err, fileName)
+		parseErr = store.read(locationFolder, fileName, &commandState)
+		if parseErr != nil {
+			log.Errorf("encountered error with message %v while reading Interim state of command from file - %v", parseErr, fileName)
 			count += 1
 			time.Sleep(500 * time.Millisecond)
 			continue
@@ -165,9 +146,13 @@ func (d *DocumentFileMgr) GetDocumentState(fileName, locationFolder string) cont
 	}
 
 	if count >= retryLimit {
-		if fileExists, _ := fileutil.LocalFileExist(absoluteFileName); fileExists {
-			if documentContents, err := fileutil.ReadAllText(absoluteFileName); err == nil {
-				log.Infof("Document contents: %v", documentContents)
+		if store.exists(locationFolder, fileName) {
+			if d.context.AppConfig().Ssm.DocumentStateQuarantine.Enabled {
+				d.quarantineDocumentState(instanceID, fileName, locationFolder, parseErr)
+				return contracts.DocumentState{}
+			}
+			if documentContents, err := store.content(locationFolder, fileName); err == nil {
+				log.Infof("Document contents: %v", string(documentContents))
 			}
 
 			d.MoveDocumentState(fileName, locationFolder, appconfig.DefaultLocationOfCorrupt)
@@ -179,6 +164,30 @@ func (d *DocumentFileMgr) GetDocumentState(fileName, locationFolder string) cont
 }
 
 // RemoveData deletes the fileName from locationFolder under defaultLogDir/instanceID
+// marshalDocumentState serializes the document state with the version of its format
+func marshalDocumentState(state contracts.DocumentState) (string, error) {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	if content, err = DocumentStateSchema.Stamp(content); err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// unmarshalDocumentStateFile parses a document state file, upgrading the format of the files written by a previous agent
+func unmarshalDocumentStateFile(absoluteFileName string, state *contracts.DocumentState) error {
+	content, err := ioutil.ReadFile(absoluteFileName)
+	if err != nil {
+		return err
+	}
+	if content, _, err = DocumentStateSchema.Upgrade(content); err != nil {
+		return err
+	}
+	return json.Unmarshal(content, state)
+}
+
 func (d *DocumentFileMgr) RemoveDocumentState(commandID, locationFolder string) {
 	log := d.context.Log()
 	instanceID, err := d.context.Identity().ShortInstanceID()
@@ -188,7 +197,7 @@ func (d *DocumentFileMgr) RemoveDocumentState(commandID, locationFolder string)
 
 	absoluteFileName := docStateFileName(commandID, instanceID, locationFolder)
 
-	err = fileutil.DeleteFile(absoluteFileName)
+	err = d.store(instanceID).remove(locationFolder, commandID)
 	if err != nil {
 		log.Errorf("encountered error %v while deleting file %v", err, absoluteFileName)
 	} else {
@@ -206,16 +215,37 @@ func DocumentStateDir(instanceID, locationFolder string) string {
 		locationFolder)
 }
 
+// DocumentStateNames returns the names of the document states of the instance in the location folder, in the
+// configured state backend
+func DocumentStateNames(log log.T, appConfig appconfig.SsmagentConfig, instanceID, locationFolder string) ([]string, error) {
+	return defaultDocumentStateStore(log, appConfig, instanceID).names(locationFolder)
+}
+
+// DocumentStateExists returns true if the document state of the instance is in the location folder
+func DocumentStateExists(log log.T, appConfig appconfig.SsmagentConfig, instanceID, locationFolder, fileName string) bool {
+	return defaultDocumentStateStore(log, appConfig, instanceID).exists(locationFolder, fileName)
+}
+
+// ReadDocumentState parses the document state of the instance in the location folder
+func ReadDocumentState(log log.T, appConfig appconfig.SsmagentConfig, instanceID, locationFolder, fileName string, state *contracts.DocumentState) error {
+	return defaultDocumentStateStore(log, appConfig, instanceID).read(locationFolder, fileName, state)
+}
+
+// defaultDocumentStateStore returns the store of the document states of the instance under the data store
+func defaultDocumentStateStore(log log.T, appConfig appconfig.SsmagentConfig, instanceID string) documentStateStore {
+	return newDocumentStateStore(log, appConfig, appconfig.DefaultDataStorePath, instanceID, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+}
+
 // orchestrationDir returns the absolute path of the orchestration directory
 func orchestrationDir(instanceID, orchestrationRootDirName string, folderType string) string {
 	switch folderType {
 	case appconfig.DefaultSessionRootDirName:
-		return path.Join(appconfig.DefaultDataStorePath,
+		return path.Join(appconfig.OrchestrationStorePath,
 			instanceID,
 			appconfig.DefaultSessionRootDirName,
 			orchestrationRootDirName)
 	default:
-		return path.Join(appconfig.DefaultDataStorePath,
+		return path.Join(appconfig.OrchestrationStorePath,
 			instanceID,
 			appconfig.DefaultDocumentRootDirName,
 			orchestrationRootDirName)
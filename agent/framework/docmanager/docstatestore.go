@@ -0,0 +1,231 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/statestore"
+)
+
+const (
+	// documentStateLockFileName is the file locked in the state folder while a document state is accessed
+	documentStateLockFileName = ".lock"
+
+	documentStateLockTimeout      = 10 * time.Second
+	documentStateLockPollInterval = 10 * time.Millisecond
+)
+
+var errDocumentStateLockTimeout = errors.New("timed out waiting for the document states lock")
+
+// documentStateDirLocks holds the in process locks of the state folders, the file lock only serializes the processes
+var documentStateDirLocks = struct {
+	sync.Mutex
+	dirs map[string]*sync.Mutex
+}{dirs: make(map[string]*sync.Mutex)}
+
+// documentStateStore persists the document states of the location folders of a state folder, the agent worker and
+// the document workers access them concurrently
+type documentStateStore interface {
+	// exists returns true if the document state is in the location folder
+	exists(locationFolder, fileName string) bool
+	// names returns the names of the document states in the location folder
+	names(locationFolder string) ([]string, error)
+	// content returns the serialized document state in the location folder
+	content(locationFolder, fileName string) ([]byte, error)
+	// read parses the document state in the location folder
+	read(locationFolder, fileName string, state *contracts.DocumentState) error
+	// write replaces the document state in the location folder with the serialized state
+	write(locationFolder, fileName, content string) error
+	// move moves the document state from the source folder to the destination folder
+	move(fileName, srcLocationFolder, dstLocationFolder string) error
+	// remove deletes the document state from the location folder
+	remove(locationFolder, fileName string) error
+	// export moves the document state out of the store to a file of the given folder
+	export(locationFolder, fileName, dir string) error
+}
+
+// store returns the store of the document states of the instance in the configured state backend
+func (d *DocumentFileMgr) store(instanceID string) documentStateStore {
+	return newDocumentStateStore(d.context.Log(), d.context.AppConfig(), d.dataStorePath, instanceID, d.rootDirName, d.stateLocation)
+}
+
+func newDocumentStateStore(log log.T, appConfig appconfig.SsmagentConfig, dataStorePath, instanceID, rootDirName, stateLocation string) documentStateStore {
+	if statestore.Enabled(appConfig) {
+		return &dbDocumentStateStore{
+			db:       statestore.NewDB(statestore.DatabasePath(dataStorePath, instanceID)),
+			location: []string{rootDirName, stateLocation},
+		}
+	}
+	return &fileDocumentStateStore{
+		log:         log,
+		dir:         filepath.Join(dataStorePath, instanceID, rootDirName, stateLocation),
+		lockTimeout: documentStateLockTimeout,
+	}
+}
+
+// fileDocumentStateStore keeps each document state in a file and serializes the accesses to the files of a state
+// folder. A state is never updated in place: the new state is written to a copy which replaces the file once synced,
+// a crash or a concurrent reader never sees a half written state.
+type fileDocumentStateStore struct {
+	log log.T
+	// dir is the state folder holding the pending, current, completed and corrupt folders
+	dir         string
+	lockTimeout time.Duration
+}
+
+func (s *fileDocumentStateStore) exists(locationFolder, fileName string) bool {
+	return fileutil.Exists(filepath.Join(s.dir, locationFolder, fileName))
+}
+
+func (s *fileDocumentStateStore) names(locationFolder string) (names []string, err error) {
+	fileNames, err := fileutil.GetFileNames(filepath.Join(s.dir, locationFolder))
+	for _, fileName := range fileNames {
+		if IsDocumentStateFileName(fileName) {
+			names = append(names, fileName)
+		}
+	}
+	return names, err
+}
+
+func (s *fileDocumentStateStore) content(locationFolder, fileName string) (content []byte, err error) {
+	err = s.withLock(func() error {
+		content, err = ioutil.ReadFile(filepath.Join(s.dir, locationFolder, fileName))
+		return err
+	})
+	return
+}
+
+func (s *fileDocumentStateStore) read(locationFolder, fileName string, state *contracts.DocumentState) error {
+	return s.withLock(func() error {
+		return unmarshalDocumentStateFile(filepath.Join(s.dir, locationFolder, fileName), state)
+	})
+}
+
+func (s *fileDocumentStateStore) write(locationFolder, fileName, content string) error {
+	absoluteFileName := filepath.Join(s.dir, locationFolder, fileName)
+	return s.withLock(func() error {
+		removeStaleCopies(s.log, absoluteFileName)
+		return writeFileAtomically(absoluteFileName, []byte(content))
+	})
+}
+
+func (s *fileDocumentStateStore) move(fileName, srcLocationFolder, dstLocationFolder string) error {
+	return s.withLock(func() error {
+		_, err := fileutil.MoveFile(fileName, filepath.Join(s.dir, srcLocationFolder), filepath.Join(s.dir, dstLocationFolder))
+		return err
+	})
+}
+
+func (s *fileDocumentStateStore) remove(locationFolder, fileName string) error {
+	return s.withLock(func() error {
+		return fileutil.DeleteFile(filepath.Join(s.dir, locationFolder, fileName))
+	})
+}
+
+func (s *fileDocumentStateStore) export(locationFolder, fileName, dir string) error {
+	return s.withLock(func() error {
+		_, err := fileutil.MoveFile(fileName, filepath.Join(s.dir, locationFolder), dir)
+		return err
+	})
+}
+
+// withLock runs the access holding the lock of the state folder. The access still runs if the lock is not acquired
+// before the timeout, a lost state is worse than a concurrent access.
+func (s *fileDocumentStateStore) withLock(access func() error) error {
+	dirLock := documentStateDirLock(s.dir)
+	dirLock.Lock()
+	defer dirLock.Unlock()
+
+	lockFile, err := s.lockFile()
+	if err != nil {
+		s.log.Warnf("Accessing the document states of %v without lock: %v", s.dir, err)
+		return access()
+	}
+	defer func() {
+		if err := unlockDocumentStateFile(lockFile); err != nil {
+			s.log.Warnf("Failed to unlock the document states of %v: %v", s.dir, err)
+		}
+		lockFile.Close()
+	}()
+	return access()
+}
+
+// lockFile opens and locks the lock file of the state folder, another process releases the lock when it exits
+func (s *fileDocumentStateStore) lockFile() (*os.File, error) {
+	if err := fileutil.MakeDirs(s.dir); err != nil {
+		return nil, err
+	}
+	lockFile, err := os.OpenFile(filepath.Join(s.dir, documentStateLockFileName), os.O_RDWR|os.O_CREATE, appconfig.ReadWriteAccess)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(s.lockTimeout)
+	for {
+		locked, err := tryLockDocumentStateFile(lockFile)
+		if err == nil && !locked && time.Now().Before(deadline) {
+			time.Sleep(documentStateLockPollInterval)
+			continue
+		}
+		if err == nil && !locked {
+			err = errDocumentStateLockTimeout
+		}
+		if err != nil {
+			lockFile.Close()
+			return nil, err
+		}
+		return lockFile, nil
+	}
+}
+
+// documentStateDirLock returns the in process lock of the state folder
+func documentStateDirLock(dir string) *sync.Mutex {
+	documentStateDirLocks.Lock()
+	defer documentStateDirLocks.Unlock()
+	dirLock, found := documentStateDirLocks.dirs[dir]
+	if !found {
+		dirLock = &sync.Mutex{}
+		documentStateDirLocks.dirs[dir] = dirLock
+	}
+	return dirLock
+}
+
+// removeStaleCopies deletes the copies of the file left by a process that stopped while writing it, the caller holds
+// the lock so no other writer uses them
+func removeStaleCopies(log log.T, path string) {
+	copies, err := filepath.Glob(filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+"*"))
+	if err != nil {
+		return
+	}
+	for _, copy := range copies {
+		log.Debugf("Removing stale copy %v of document state %v", copy, path)
+		os.Remove(copy)
+	}
+}
+
+// IsDocumentStateFileName returns false for the files of a state folder that are not document states, such as the
+// copies being written
+func IsDocumentStateFileName(fileName string) bool {
+	return !strings.HasPrefix(fileName, ".")
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/statestore"
+)
+
+// dbDocumentStateStore keeps the document states in the state database of the instance, a location folder is a
+// bucket and a move between two folders is a single transaction
+type dbDocumentStateStore struct {
+	db *statestore.DB
+	// location is the path of the buckets holding the pending, current and corrupt buckets
+	location []string
+}
+
+func (s *dbDocumentStateStore) exists(locationFolder, fileName string) (found bool) {
+	s.db.View(func(tx *statestore.Tx) error {
+		_, found = tx.Get(s.folder(locationFolder), fileName)
+		return nil
+	})
+	return
+}
+
+func (s *dbDocumentStateStore) names(locationFolder string) (names []string, err error) {
+	err = s.db.View(func(tx *statestore.Tx) error {
+		names = tx.Names(s.folder(locationFolder))
+		return nil
+	})
+	return
+}
+
+func (s *dbDocumentStateStore) content(locationFolder, fileName string) (content []byte, err error) {
+	err = s.db.View(func(tx *statestore.Tx) error {
+		content, err = s.get(tx, locationFolder, fileName)
+		return err
+	})
+	return
+}
+
+// read parses the document state, the states written by a previous agent are upgraded to the current format
+func (s *dbDocumentStateStore) read(locationFolder, fileName string, state *contracts.DocumentState) error {
+	content, err := s.content(locationFolder, fileName)
+	if err != nil {
+		return err
+	}
+	if content, _, err = DocumentStateSchema.Upgrade(content); err != nil {
+		return err
+	}
+	return json.Unmarshal(content, state)
+}
+
+func (s *dbDocumentStateStore) write(locationFolder, fileName, content string) error {
+	return s.db.Update(func(tx *statestore.Tx) error {
+		return tx.Put(s.folder(locationFolder), fileName, []byte(content))
+	})
+}
+
+func (s *dbDocumentStateStore) move(fileName, srcLocationFolder, dstLocationFolder string) error {
+	return s.db.Update(func(tx *statestore.Tx) error {
+		content, err := s.get(tx, srcLocationFolder, fileName)
+		if err != nil {
+			return err
+		}
+		if err = tx.Put(s.folder(dstLocationFolder), fileName, content); err != nil {
+			return err
+		}
+		return tx.Delete(s.folder(srcLocationFolder), fileName)
+	})
+}
+
+func (s *dbDocumentStateStore) remove(locationFolder, fileName string) error {
+	return s.db.Update(func(tx *statestore.Tx) error {
+		if _, err := s.get(tx, locationFolder, fileName); err != nil {
+			return err
+		}
+		return tx.Delete(s.folder(locationFolder), fileName)
+	})
+}
+
+// export writes the document state to the file before deleting it, the state is kept if the file is not written
+func (s *dbDocumentStateStore) export(locationFolder, fileName, dir string) error {
+	return s.db.Update(func(tx *statestore.Tx) error {
+		content, err := s.get(tx, locationFolder, fileName)
+		if err != nil {
+			return err
+		}
+		if err = fileutil.MakeDirs(dir); err != nil {
+			return err
+		}
+		if err = writeFileAtomically(filepath.Join(dir, fileName), content); err != nil {
+			return err
+		}
+		return tx.Delete(s.folder(locationFolder), fileName)
+	})
+}
+
+// get returns the document state, the error satisfies os.IsNotExist if there is none
+func (s *dbDocumentStateStore) get(tx *statestore.Tx, locationFolder, fileName string) ([]byte, error) {
+	content, found := tx.Get(s.folder(locationFolder), fileName)
+	if !found {
+		return nil, &os.PathError{Op: "get", Path: filepath.Join(append(s.folder(locationFolder), fileName)...), Err: os.ErrNotExist}
+	}
+	return content, nil
+}
+
+// folder returns the path of the bucket of the location folder
+func (s *dbDocumentStateStore) folder(locationFolder string) []string {
+	return append(append([]string{}, s.location...), locationFolder)
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/statestore"
+	identityMocks "github.com/aws/amazon-ssm-agent/common/identity/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDocumentFileMgr(t *testing.T) *DocumentFileMgr {
+	return newTestDocumentFileMgrWithConfig(t, appconfig.DefaultConfig())
+}
+
+func newTestDocumentFileMgrWithConfig(t *testing.T, config appconfig.SsmagentConfig) *DocumentFileMgr {
+	dataStorePath, err := ioutil.TempDir("", "docmanager")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dataStorePath) })
+
+	ctx := contextmocks.NewMockDefaultWithConfig(config)
+	return NewDocumentFileMgr(ctx, dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+}
+
+func newTestBoltDocumentFileMgr(t *testing.T) *DocumentFileMgr {
+	config := appconfig.DefaultConfig()
+	config.Storage.StateBackend = appconfig.StateBackendBoltDB
+	return newTestDocumentFileMgrWithConfig(t, config)
+}
+
+func TestDocumentFileMgr_PersistMoveAndRemoveDocumentState(t *testing.T) {
+	docMgr := newTestDocumentFileMgr(t)
+	state := newCommandDocumentState()
+
+	docMgr.PersistDocumentState("commandID", appconfig.DefaultLocationOfPending, state)
+	assert.Equal(t, state, docMgr.GetDocumentState("commandID", appconfig.DefaultLocationOfPending))
+	assert.FileExists(t, filepath.Join(docMgr.stateDir(identityMocks.MockShortInstanceID, ""), documentStateLockFileName))
+
+	currentDir := docMgr.stateDir(identityMocks.MockShortInstanceID, appconfig.DefaultLocationOfCurrent)
+	assert.NoError(t, os.MkdirAll(currentDir, appconfig.ReadWriteExecuteAccess))
+	docMgr.MoveDocumentState("commandID", appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
+	assert.Equal(t, state, docMgr.GetDocumentState("commandID", appconfig.DefaultLocationOfCurrent))
+
+	docMgr.RemoveDocumentState("commandID", appconfig.DefaultLocationOfCurrent)
+	files, err := ioutil.ReadDir(currentDir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestDocumentStateStore_WriteRemovesStaleCopies(t *testing.T) {
+	docMgr := newTestDocumentFileMgr(t)
+	store := docMgr.store(identityMocks.MockShortInstanceID).(*fileDocumentStateStore)
+	currentDir := filepath.Join(store.dir, appconfig.DefaultLocationOfCurrent)
+	assert.NoError(t, os.MkdirAll(currentDir, appconfig.ReadWriteExecuteAccess))
+
+	// a copy left by a process that stopped while writing the state
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(currentDir, ".commandID123456"), []byte(`{"Docum`), appconfig.ReadWriteAccess))
+
+	assert.NoError(t, store.write(appconfig.DefaultLocationOfCurrent, "commandID", `{"DocumentType":"SendCommand"}`))
+	files, err := ioutil.ReadDir(currentDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "commandID", files[0].Name())
+	assert.False(t, IsDocumentStateFileName(".commandID123456"))
+	assert.True(t, IsDocumentStateFileName("commandID"))
+}
+
+func TestDocumentStateStore_ConcurrentWritesKeepAValidState(t *testing.T) {
+	docMgr := newTestDocumentFileMgr(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			state := newCommandDocumentState()
+			state.DocumentInformation.DocumentStatus = contracts.ResultStatus(fmt.Sprintf("status%v", i))
+			docMgr.PersistDocumentState("commandID", appconfig.DefaultLocationOfCurrent, state)
+			// a worker reading the state while the others write it never sees a half written state
+			var read contracts.DocumentState
+			assert.NoError(t, docMgr.store(identityMocks.MockShortInstanceID).read(appconfig.DefaultLocationOfCurrent, "commandID", &read))
+			assert.Equal(t, "commandID", read.DocumentInformation.CommandID)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestDocumentStateStore_AccessesWithoutLockAfterTimeout(t *testing.T) {
+	docMgr := newTestDocumentFileMgr(t)
+	store := docMgr.store(identityMocks.MockShortInstanceID).(*fileDocumentStateStore)
+	store.lockTimeout = 50 * time.Millisecond
+
+	// another process holds the lock
+	holder, err := store.lockFile()
+	assert.NoError(t, err)
+	defer func() {
+		unlockDocumentStateFile(holder)
+		holder.Close()
+	}()
+
+	_, err = store.lockFile()
+	assert.Equal(t, errDocumentStateLockTimeout, err)
+
+	accessed := false
+	assert.NoError(t, store.withLock(func() error {
+		accessed = true
+		return nil
+	}))
+	assert.True(t, accessed)
+}
+
+func TestDocumentFileMgr_BoltDBBackend(t *testing.T) {
+	docMgr := newTestBoltDocumentFileMgr(t)
+	state := newCommandDocumentState()
+
+	docMgr.PersistDocumentState("commandID", appconfig.DefaultLocationOfPending, state)
+	assert.Equal(t, state, docMgr.GetDocumentState("commandID", appconfig.DefaultLocationOfPending))
+	assert.FileExists(t, statestore.DatabasePath(docMgr.dataStorePath, identityMocks.MockShortInstanceID))
+	// no state file is written
+	assert.NoDirExists(t, docMgr.stateDir(identityMocks.MockShortInstanceID, appconfig.DefaultLocationOfPending))
+
+	docMgr.MoveDocumentState("commandID", appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
+	store := docMgr.store(identityMocks.MockShortInstanceID)
+	assert.False(t, store.exists(appconfig.DefaultLocationOfPending, "commandID"))
+	assert.Equal(t, state, docMgr.GetDocumentState("commandID", appconfig.DefaultLocationOfCurrent))
+	names, err := store.names(appconfig.DefaultLocationOfCurrent)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"commandID"}, names)
+
+	docMgr.RemoveDocumentState("commandID", appconfig.DefaultLocationOfCurrent)
+	assert.Equal(t, contracts.DocumentState{}, docMgr.GetDocumentState("commandID", appconfig.DefaultLocationOfCurrent))
+	assert.Error(t, store.move("commandID", appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt))
+	assert.False(t, store.exists(appconfig.DefaultLocationOfCorrupt, "commandID"))
+}
+
+func TestDocumentFileMgr_BoltDBBackendQuarantinesCorruptState(t *testing.T) {
+	docMgr := newTestBoltDocumentFileMgr(t)
+	store := docMgr.store(identityMocks.MockShortInstanceID)
+	assert.NoError(t, store.write(appconfig.DefaultLocationOfCurrent, "commandID", `{"DocumentType":"SendCommand","DocumentInformation":{"MessageID":"aws.ssm.commandID.instanceID"`))
+
+	assert.Equal(t, contracts.DocumentState{}, docMgr.GetDocumentState("commandID", appconfig.DefaultLocationOfCurrent))
+	assert.False(t, store.exists(appconfig.DefaultLocationOfCurrent, "commandID"))
+	quarantineDir := docMgr.stateDir(identityMocks.MockShortInstanceID, appconfig.DefaultLocationOfQuarantine)
+	assert.FileExists(t, filepath.Join(quarantineDir, "commandID"))
+	reports := readCorruptionReports(docMgr.context.Log(), quarantineDir)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "aws.ssm.commandID.instanceID", reports[0].Recovered.MessageID)
+}
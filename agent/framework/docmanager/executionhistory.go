@@ -0,0 +1,231 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/statefile"
+)
+
+// ExecutionHistorySchema is the format of the execution history file
+var ExecutionHistorySchema = statefile.Schema{Name: "executionhistory"}
+
+// executionHistoryLock serializes the updates of the execution history file within the agent process
+var executionHistoryLock sync.Mutex
+
+// ExecutionRecord is the outcome of a document execution kept in the execution history. The ExecutionID is the
+// DocumentID of the execution, the CommandID for Run Command and AssociationID.RunID for the associations.
+type ExecutionRecord struct {
+	ExecutionID    string
+	CommandID      string
+	DocumentName   string
+	DocumentType   contracts.DocumentType
+	AssociationID  string `json:",omitempty"`
+	Status         contracts.ResultStatus
+	StartDateTime  time.Time
+	EndDateTime    time.Time
+	DurationMillis int64
+}
+
+// ExecutionFilter selects executions of the history, its zero value selects all of them
+type ExecutionFilter struct {
+	// Statuses the executions ended with, any status when empty
+	Statuses []contracts.ResultStatus
+	// DocumentName of the executions, any document when empty
+	DocumentName string
+	// Since selects the executions that ended at or after this time
+	Since time.Time
+	// MaxResults is the number of most recent executions returned, all of them when 0
+	MaxResults int
+}
+
+// executionHistory is the content of the execution history file, the records are ordered by end time so that the
+// executions ended since a given time are found with a binary search
+type executionHistory struct {
+	Records []ExecutionRecord
+}
+
+// ExecutionHistoryPath returns the path of the execution history file of an instance
+func ExecutionHistoryPath(instanceID string) string {
+	return filepath.Join(DocumentStateDir(instanceID, appconfig.DefaultLocationOfHistory), appconfig.ExecutionHistoryFileName)
+}
+
+// RecordExecution adds an execution to the execution history kept with the document states. The execution replaces
+// a previous record of the same execution and the oldest executions are dropped above Ssm.ExecutionHistoryMaxEntries.
+func (d *DocumentFileMgr) RecordExecution(record ExecutionRecord) {
+	log := d.context.Log()
+	maxEntries := d.context.AppConfig().Ssm.ExecutionHistoryMaxEntries
+	if maxEntries <= 0 || record.ExecutionID == "" {
+		return
+	}
+	instanceID, err := d.context.Identity().ShortInstanceID()
+	if err != nil {
+		log.Errorf("Failed to get short instanceID for RecordExecution: %v", err)
+		return
+	}
+
+	path := filepath.Join(d.dataStorePath,
+		instanceID,
+		d.rootDirName,
+		d.stateLocation,
+		appconfig.DefaultLocationOfHistory,
+		appconfig.ExecutionHistoryFileName)
+	if err = recordExecution(log, path, maxEntries, record); err != nil {
+		log.Warnf("Failed to record execution %v in the execution history: %v", record.ExecutionID, err)
+	}
+}
+
+func recordExecution(log log.T, path string, maxEntries int, record ExecutionRecord) error {
+	executionHistoryLock.Lock()
+	defer executionHistoryLock.Unlock()
+
+	history, err := readExecutionHistory(path)
+	if err != nil {
+		log.Warnf("Failed to read the execution history %v, starting a new history: %v", path, err)
+		history = executionHistory{}
+	}
+	history.add(record, maxEntries)
+	return writeExecutionHistory(path, history)
+}
+
+// ListExecutions returns the executions of the history of an instance selected by the filter, the most recent first
+func ListExecutions(instanceID string, filter ExecutionFilter) ([]ExecutionRecord, error) {
+	history, err := readExecutionHistory(ExecutionHistoryPath(instanceID))
+	if err != nil {
+		return nil, err
+	}
+	return history.list(filter), nil
+}
+
+// add inserts the record at the position of its end time after removing the previous record of its execution
+func (h *executionHistory) add(record ExecutionRecord, maxEntries int) {
+	for i := range h.Records {
+		if h.Records[i].ExecutionID == record.ExecutionID {
+			h.Records = append(h.Records[:i], h.Records[i+1:]...)
+			break
+		}
+	}
+	position := sort.Search(len(h.Records), func(i int) bool {
+		return h.Records[i].EndDateTime.After(record.EndDateTime)
+	})
+	h.Records = append(h.Records, ExecutionRecord{})
+	copy(h.Records[position+1:], h.Records[position:])
+	h.Records[position] = record
+
+	if len(h.Records) > maxEntries {
+		h.Records = h.Records[len(h.Records)-maxEntries:]
+	}
+}
+
+func (h *executionHistory) list(filter ExecutionFilter) []ExecutionRecord {
+	first := 0
+	if !filter.Since.IsZero() {
+		first = sort.Search(len(h.Records), func(i int) bool {
+			return !h.Records[i].EndDateTime.Before(filter.Since)
+		})
+	}
+
+	records := make([]ExecutionRecord, 0)
+	for i := len(h.Records) - 1; i >= first; i-- {
+		record := h.Records[i]
+		if filter.DocumentName != "" && record.DocumentName != filter.DocumentName {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !hasStatus(filter.Statuses, record.Status) {
+			continue
+		}
+		records = append(records, record)
+		if filter.MaxResults > 0 && len(records) == filter.MaxResults {
+			break
+		}
+	}
+	return records
+}
+
+func hasStatus(statuses []contracts.ResultStatus, status contracts.ResultStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// readExecutionHistory parses the execution history file, a missing file is an empty history
+func readExecutionHistory(path string) (history executionHistory, err error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return history, err
+	}
+	if content, _, err = ExecutionHistorySchema.Upgrade(content); err != nil {
+		return history, err
+	}
+	err = json.Unmarshal(content, &history)
+	return history, err
+}
+
+// writeExecutionHistory replaces the execution history file
+func writeExecutionHistory(path string, history executionHistory) error {
+	content, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	if content, err = ExecutionHistorySchema.Stamp(content); err != nil {
+		return err
+	}
+	return writeFileAtomically(path, content)
+}
+
+// writeFileAtomically writes the content next to the file then replaces it once synced, the file is never left half
+// written
+func writeFileAtomically(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), appconfig.ReadWriteExecuteAccess); err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(content)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), appconfig.ReadWriteAccess)
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var historyStart = time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newRecord(id string, documentName string, status contracts.ResultStatus, endMinutes int) ExecutionRecord {
+	end := historyStart.Add(time.Duration(endMinutes) * time.Minute)
+	return ExecutionRecord{
+		ExecutionID:    id,
+		CommandID:      id,
+		DocumentName:   documentName,
+		Status:         status,
+		StartDateTime:  end.Add(-time.Minute),
+		EndDateTime:    end,
+		DurationMillis: 60000,
+	}
+}
+
+func TestExecutionHistory_KeepsRecordsOrderedAndBounded(t *testing.T) {
+	history := executionHistory{}
+	history.add(newRecord("command1", "AWS-RunShellScript", contracts.ResultStatusSuccess, 0), 3)
+	history.add(newRecord("command3", "AWS-RunShellScript", contracts.ResultStatusFailed, 20), 3)
+	// a record ending before the last one is inserted at its position
+	history.add(newRecord("command2", "AWS-RunPatchBaseline", contracts.ResultStatusFailed, 10), 3)
+	assert.Equal(t, []string{"command1", "command2", "command3"}, executionIDs(history.Records))
+
+	// a new record of an execution replaces the previous one
+	history.add(newRecord("command1", "AWS-RunShellScript", contracts.ResultStatusFailed, 30), 3)
+	assert.Equal(t, []string{"command2", "command3", "command1"}, executionIDs(history.Records))
+
+	// the oldest records are dropped above the maximum
+	history.add(newRecord("command4", "AWS-RunShellScript", contracts.ResultStatusSuccess, 40), 3)
+	assert.Equal(t, []string{"command3", "command1", "command4"}, executionIDs(history.Records))
+}
+
+func TestExecutionHistory_ListAppliesFilter(t *testing.T) {
+	history := executionHistory{}
+	for i, status := range []contracts.ResultStatus{contracts.ResultStatusSuccess, contracts.ResultStatusFailed, contracts.ResultStatusTimedOut, contracts.ResultStatusFailed} {
+		history.add(newRecord(fmt.Sprintf("command%d", i), "AWS-RunShellScript", status, i*10), 10)
+	}
+	history.add(newRecord("command4", "AWS-RunPatchBaseline", contracts.ResultStatusFailed, 40), 10)
+
+	assert.Equal(t, []string{"command4", "command3", "command2", "command1", "command0"}, executionIDs(history.list(ExecutionFilter{})))
+	assert.Equal(t, []string{"command4", "command3", "command1"},
+		executionIDs(history.list(ExecutionFilter{Statuses: []contracts.ResultStatus{contracts.ResultStatusFailed}})))
+	assert.Equal(t, []string{"command3", "command2"},
+		executionIDs(history.list(ExecutionFilter{DocumentName: "AWS-RunShellScript", Since: historyStart.Add(20 * time.Minute)})))
+	assert.Equal(t, []string{"command4", "command3"}, executionIDs(history.list(ExecutionFilter{MaxResults: 2})))
+	assert.Empty(t, history.list(ExecutionFilter{Since: historyStart.Add(time.Hour)}))
+}
+
+func TestExecutionHistory_PersistsRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executionhistory")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "history", "executions.json")
+
+	assert.NoError(t, recordExecution(log.NewMockLog(), path, 10, newRecord("command1", "AWS-RunShellScript", contracts.ResultStatusFailed, 0)))
+	assert.NoError(t, recordExecution(log.NewMockLog(), path, 10, newRecord("command2", "AWS-RunShellScript", contracts.ResultStatusSuccess, 10)))
+
+	history, err := readExecutionHistory(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"command1", "command2"}, executionIDs(history.Records))
+	assert.Equal(t, historyStart, history.Records[0].EndDateTime)
+
+	// a corrupted history is replaced by a new one
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{not json"), 0600))
+	assert.NoError(t, recordExecution(log.NewMockLog(), path, 10, newRecord("command3", "AWS-RunShellScript", contracts.ResultStatusSuccess, 20)))
+	history, err = readExecutionHistory(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"command3"}, executionIDs(history.Records))
+}
+
+func executionIDs(records []ExecutionRecord) []string {
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ExecutionID)
+	}
+	return ids
+}
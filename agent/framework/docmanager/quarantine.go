@@ -0,0 +1,278 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// quarantineLock serializes the updates of the corruption reports within the agent process
+var quarantineLock sync.Mutex
+
+// CorruptionReport describes a document state file that could not be parsed and was moved to the quarantine folder
+type CorruptionReport struct {
+	FileName      string
+	SourceFolder  string
+	QuarantinedAt time.Time
+	Error         string
+	SizeBytes     int64
+	// Recovered holds the fields read from the file before the parse error
+	Recovered RecoveredDocumentState
+	// ResultClaimed is set once a processor claimed the report to send a Failed result for the recovered command
+	ResultClaimed bool
+}
+
+// RecoveredDocumentState holds the fields of a corrupt document state that could be read
+type RecoveredDocumentState struct {
+	DocumentID          string                        `json:",omitempty"`
+	CommandID           string                        `json:",omitempty"`
+	MessageID           string                        `json:",omitempty"`
+	AssociationID       string                        `json:",omitempty"`
+	DocumentName        string                        `json:",omitempty"`
+	DocumentVersion     string                        `json:",omitempty"`
+	DocumentType        contracts.DocumentType        `json:",omitempty"`
+	UpstreamServiceName contracts.UpstreamServiceName `json:",omitempty"`
+	Plugins             []RecoveredPlugin             `json:",omitempty"`
+}
+
+// RecoveredPlugin identifies a plugin of a corrupt document state
+type RecoveredPlugin struct {
+	ID   string
+	Name string
+}
+
+// stateDir returns the document state folder of the given location
+func (d *DocumentFileMgr) stateDir(instanceID, locationFolder string) string {
+	return filepath.Join(d.dataStorePath,
+		instanceID,
+		d.rootDirName,
+		d.stateLocation,
+		locationFolder)
+}
+
+// quarantineDocumentState moves a document state that cannot be parsed to the quarantine folder next to a report
+// holding the parse error and the fields read before it
+func (d *DocumentFileMgr) quarantineDocumentState(instanceID, fileName, locationFolder string, parseErr error) {
+	log := d.context.Log()
+	store := d.store(instanceID)
+	content, err := store.content(locationFolder, fileName)
+	if err != nil {
+		log.Errorf("Failed to read corrupt document state %v: %v", fileName, err)
+	}
+	report := CorruptionReport{
+		FileName:      fileName,
+		SourceFolder:  locationFolder,
+		QuarantinedAt: time.Now().UTC(),
+		Error:         parseErr.Error(),
+		SizeBytes:     int64(len(content)),
+		Recovered:     partialParseDocumentState(content),
+	}
+
+	quarantineLock.Lock()
+	defer quarantineLock.Unlock()
+
+	quarantineDir := d.stateDir(instanceID, appconfig.DefaultLocationOfQuarantine)
+	d.deleteExpiredQuarantine(log, quarantineDir)
+	if err = fileutil.MakeDirs(quarantineDir); err == nil {
+		err = store.export(locationFolder, fileName, quarantineDir)
+	}
+	if err != nil {
+		log.Errorf("Failed to quarantine corrupt document state %v, moving it to the corrupt folder: %v", fileName, err)
+		d.MoveDocumentState(fileName, locationFolder, appconfig.DefaultLocationOfCorrupt)
+		return
+	}
+	if err = writeCorruptionReport(quarantineDir, report); err != nil {
+		log.Errorf("Failed to write the corruption report of document state %v: %v", fileName, err)
+	}
+	log.Warnf("Quarantined corrupt document state %v of %v document %v (message %v): %v",
+		fileName, report.Recovered.DocumentType, report.Recovered.DocumentName, report.Recovered.MessageID, parseErr)
+}
+
+// ClaimCorruptionReports returns the reports of the quarantined document states of the given type whose command
+// was not reported to the service yet, they are marked claimed so that each command is reported once
+func (d *DocumentFileMgr) ClaimCorruptionReports(documentType contracts.DocumentType) (claimed []CorruptionReport) {
+	log := d.context.Log()
+	instanceID, err := d.context.Identity().ShortInstanceID()
+	if err != nil {
+		log.Errorf("Failed to get short instanceID for ClaimCorruptionReports: %v", err)
+		return
+	}
+
+	quarantineLock.Lock()
+	defer quarantineLock.Unlock()
+
+	quarantineDir := d.stateDir(instanceID, appconfig.DefaultLocationOfQuarantine)
+	d.deleteExpiredQuarantine(log, quarantineDir)
+	for _, report := range readCorruptionReports(log, quarantineDir) {
+		if report.ResultClaimed || report.Recovered.DocumentType != documentType {
+			continue
+		}
+		report.ResultClaimed = true
+		if err = writeCorruptionReport(quarantineDir, report); err != nil {
+			log.Errorf("Failed to claim the corruption report of document state %v: %v", report.FileName, err)
+			continue
+		}
+		claimed = append(claimed, report)
+	}
+	return
+}
+
+// deleteExpiredQuarantine deletes the quarantined document states and reports older than the retention
+func (d *DocumentFileMgr) deleteExpiredQuarantine(log log.T, quarantineDir string) {
+	retention := time.Duration(d.context.AppConfig().Ssm.DocumentStateQuarantine.RetentionDays) * 24 * time.Hour
+	files, err := ioutil.ReadDir(quarantineDir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if time.Since(file.ModTime()) > retention {
+			log.Debugf("Deleting expired quarantined document state file %v", file.Name())
+			os.Remove(filepath.Join(quarantineDir, file.Name()))
+		}
+	}
+}
+
+// ReadCorruptionReports returns the reports of the document states quarantined on an instance
+func ReadCorruptionReports(log log.T, instanceID string) []CorruptionReport {
+	return readCorruptionReports(log, DocumentStateDir(instanceID, appconfig.DefaultLocationOfQuarantine))
+}
+
+func readCorruptionReports(log log.T, quarantineDir string) (reports []CorruptionReport) {
+	files, err := ioutil.ReadDir(quarantineDir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), appconfig.DocumentStateCorruptionReportSuffix) {
+			continue
+		}
+		var report CorruptionReport
+		content, err := ioutil.ReadFile(filepath.Join(quarantineDir, file.Name()))
+		if err == nil {
+			err = json.Unmarshal(content, &report)
+		}
+		if err != nil {
+			log.Warnf("Failed to read corruption report %v: %v", file.Name(), err)
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return
+}
+
+func writeCorruptionReport(quarantineDir string, report CorruptionReport) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(filepath.Join(quarantineDir, report.FileName+appconfig.DocumentStateCorruptionReportSuffix), content)
+}
+
+// partialParseFrame is an object or array enclosing the json tokens read by partialParseDocumentState
+type partialParseFrame struct {
+	array bool
+	// key of the object field whose value is read, expectKey is set when the next token is a key
+	key       string
+	expectKey bool
+}
+
+// partialParseDocumentState reads the identifiers of a document state from its json tokens until the first
+// syntax error, so that the command of a truncated or partially overwritten file can still be reported
+func partialParseDocumentState(content []byte) (recovered RecoveredDocumentState) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	var stack []*partialParseFrame
+	valueRead := func() {
+		if len(stack) > 0 && !stack[len(stack)-1].array {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+		if delim, isDelim := token.(json.Delim); isDelim {
+			switch delim {
+			case '{':
+				stack = append(stack, &partialParseFrame{expectKey: true})
+			case '[':
+				stack = append(stack, &partialParseFrame{array: true})
+			default:
+				stack = stack[:len(stack)-1]
+				valueRead()
+			}
+			continue
+		}
+		if len(stack) > 0 && stack[len(stack)-1].expectKey {
+			stack[len(stack)-1].key, _ = token.(string)
+			stack[len(stack)-1].expectKey = false
+			continue
+		}
+		if value, isString := token.(string); isString {
+			path := make([]string, 0, len(stack))
+			for _, frame := range stack {
+				if frame.array {
+					path = append(path, "[]")
+				} else {
+					path = append(path, frame.key)
+				}
+			}
+			recovered.record(strings.Join(path, "."), value)
+		}
+		valueRead()
+	}
+}
+
+// record keeps the value of a document state field identified by its path
+func (r *RecoveredDocumentState) record(path string, value string) {
+	switch path {
+	case "DocumentInformation.DocumentID":
+		r.DocumentID = value
+	case "DocumentInformation.CommandID":
+		r.CommandID = value
+	case "DocumentInformation.MessageID":
+		r.MessageID = value
+	case "DocumentInformation.AssociationID":
+		r.AssociationID = value
+	case "DocumentInformation.DocumentName":
+		r.DocumentName = value
+	case "DocumentInformation.DocumentVersion":
+		r.DocumentVersion = value
+	case "DocumentType":
+		r.DocumentType = contracts.DocumentType(value)
+	case "UpstreamServiceName":
+		r.UpstreamServiceName = contracts.UpstreamServiceName(value)
+	case "InstancePluginsInformation.[].Name":
+		// the name of a plugin is written before its id
+		r.Plugins = append(r.Plugins, RecoveredPlugin{Name: value})
+	case "InstancePluginsInformation.[].Id":
+		if last := len(r.Plugins) - 1; last >= 0 && r.Plugins[last].ID == "" {
+			r.Plugins[last].ID = value
+		} else {
+			r.Plugins = append(r.Plugins, RecoveredPlugin{ID: value})
+		}
+	}
+}
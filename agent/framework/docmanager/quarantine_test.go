@@ -0,0 +1,121 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	identityMocks "github.com/aws/amazon-ssm-agent/common/identity/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCommandDocumentState() contracts.DocumentState {
+	state := contracts.DocumentState{
+		DocumentType:        contracts.SendCommand,
+		UpstreamServiceName: contracts.MessageGatewayService,
+	}
+	state.DocumentInformation.DocumentID = "commandID"
+	state.DocumentInformation.CommandID = "commandID"
+	state.DocumentInformation.MessageID = "aws.ssm.commandID.instanceID"
+	state.DocumentInformation.DocumentName = "AWS-RunShellScript"
+	state.InstancePluginsInformation = []contracts.PluginState{
+		{Name: "aws:runShellScript", Id: "runShellScript1", Configuration: contracts.Configuration{PluginName: "aws:runShellScript"}},
+		{Name: "aws:runShellScript", Id: "runShellScript2"},
+	}
+	return state
+}
+
+func TestPartialParseDocumentState_RecoversTruncatedState(t *testing.T) {
+	content, err := marshalDocumentState(newCommandDocumentState())
+	assert.NoError(t, err)
+
+	// the file is cut in the middle of the object following the plugins
+	truncated := content[:strings.Index(content, `"CancelInformation"`)+len(`"CancelInformation":{"Can`)]
+	assert.Error(t, json.Unmarshal([]byte(truncated), &contracts.DocumentState{}))
+
+	recovered := partialParseDocumentState([]byte(truncated))
+	assert.Equal(t, "commandID", recovered.DocumentID)
+	assert.Equal(t, "aws.ssm.commandID.instanceID", recovered.MessageID)
+	assert.Equal(t, "AWS-RunShellScript", recovered.DocumentName)
+	assert.Equal(t, contracts.SendCommand, recovered.DocumentType)
+	assert.Equal(t, []RecoveredPlugin{{ID: "runShellScript1", Name: "aws:runShellScript"}, {ID: "runShellScript2", Name: "aws:runShellScript"}}, recovered.Plugins)
+
+	// nothing is recovered from garbage
+	assert.Equal(t, RecoveredDocumentState{}, partialParseDocumentState([]byte("\x00\x00garbage")))
+}
+
+func TestGetDocumentState_QuarantinesCorruptState(t *testing.T) {
+	dataStorePath, err := ioutil.TempDir("", "docmanager")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataStorePath)
+
+	ctx := contextmocks.NewMockDefaultWithConfig(appconfig.DefaultConfig())
+	docMgr := NewDocumentFileMgr(ctx, dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	currentDir := docMgr.stateDir(identityMocks.MockShortInstanceID, appconfig.DefaultLocationOfCurrent)
+	assert.NoError(t, os.MkdirAll(currentDir, appconfig.ReadWriteExecuteAccess))
+	content, err := marshalDocumentState(newCommandDocumentState())
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(currentDir, "commandID"), []byte(content[:len(content)/2]), appconfig.ReadWriteAccess))
+
+	state := docMgr.GetDocumentState("commandID", appconfig.DefaultLocationOfCurrent)
+	assert.Equal(t, contracts.DocumentState{}, state)
+	_, err = os.Stat(filepath.Join(currentDir, "commandID"))
+	assert.True(t, os.IsNotExist(err))
+
+	quarantineDir := docMgr.stateDir(identityMocks.MockShortInstanceID, appconfig.DefaultLocationOfQuarantine)
+	_, err = os.Stat(filepath.Join(quarantineDir, "commandID"))
+	assert.NoError(t, err)
+	reports := readCorruptionReports(ctx.Log(), quarantineDir)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, appconfig.DefaultLocationOfCurrent, reports[0].SourceFolder)
+	assert.Equal(t, int64(len(content)/2), reports[0].SizeBytes)
+	assert.NotEmpty(t, reports[0].Error)
+	assert.Equal(t, "aws.ssm.commandID.instanceID", reports[0].Recovered.MessageID)
+
+	// the reports are claimed once by the processor of their document type
+	assert.Empty(t, docMgr.ClaimCorruptionReports(contracts.Association))
+	claimed := docMgr.ClaimCorruptionReports(contracts.SendCommand)
+	assert.Len(t, claimed, 1)
+	assert.True(t, claimed[0].ResultClaimed)
+	assert.Empty(t, docMgr.ClaimCorruptionReports(contracts.SendCommand))
+}
+
+func TestGetDocumentState_MovesCorruptStateWhenQuarantineDisabled(t *testing.T) {
+	dataStorePath, err := ioutil.TempDir("", "docmanager")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataStorePath)
+
+	config := appconfig.DefaultConfig()
+	config.Ssm.DocumentStateQuarantine.Enabled = false
+	docMgr := NewDocumentFileMgr(contextmocks.NewMockDefaultWithConfig(config), dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	currentDir := docMgr.stateDir(identityMocks.MockShortInstanceID, appconfig.DefaultLocationOfCurrent)
+	corruptDir := docMgr.stateDir(identityMocks.MockShortInstanceID, appconfig.DefaultLocationOfCorrupt)
+	assert.NoError(t, os.MkdirAll(currentDir, appconfig.ReadWriteExecuteAccess))
+	assert.NoError(t, os.MkdirAll(corruptDir, appconfig.ReadWriteExecuteAccess))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(currentDir, "commandID"), []byte("{"), appconfig.ReadWriteAccess))
+
+	docMgr.GetDocumentState("commandID", appconfig.DefaultLocationOfCurrent)
+	_, err = os.Stat(filepath.Join(corruptDir, "commandID"))
+	assert.NoError(t, err)
+	_, err = os.Stat(docMgr.stateDir(identityMocks.MockShortInstanceID, appconfig.DefaultLocationOfQuarantine))
+	assert.True(t, os.IsNotExist(err))
+}
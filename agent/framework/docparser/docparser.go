@@ -91,6 +91,10 @@ func (docContent *DocContent) GetIOConfiguration(parserInfo DocumentParserInfo)
 		OutputS3BucketName:     parserInfo.S3Bucket,
 		OutputS3KeyPrefix:      parserInfo.S3Prefix,
 		CloudWatchConfig:       parserInfo.CloudWatchConfig,
+		StreamOutput:           docContent.StreamOutput,
+		StripAnsiEscapeCodes:   docContent.StripAnsiEscapeCodes,
+		KeepRawOutput:          docContent.KeepRawOutput,
+		CombinedOutput:         docContent.CombinedOutput,
 	}
 }
 
@@ -103,6 +107,9 @@ func (docContent *DocContent) ParseDocument(context context.T,
 	if err = validateSchema(docContent.SchemaVersion); err != nil {
 		return
 	}
+	if err = validateDocumentStructure(docContent); err != nil {
+		return
+	}
 	if err = getValidatedParameters(context, params, docContent); err != nil {
 		return
 	}
@@ -175,6 +175,78 @@ func TestInitializeDocState_Valid(t *testing.T) {
 	assert.Equal(t, testLogStreamPrefix, docState.IOConfig.CloudWatchConfig.LogStreamPrefix)
 }
 
+func TestInitializeDocState_StreamOutput(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{
+		OrchestrationDir: testOrchDir,
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+	}
+
+	var testDocContent DocContent
+	validdocument := loadFile(t, filepath.Join("..", "..", "runcommand", "mds", "testdata", "validcommand12.json"))
+	err := json.Unmarshal(validdocument, &testDocContent)
+	assert.Nil(t, err)
+
+	docState, err := InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+	assert.Nil(t, err)
+	assert.False(t, docState.IOConfig.StreamOutput)
+
+	testDocContent.StreamOutput = true
+	docState, err = InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+	assert.Nil(t, err)
+	assert.True(t, docState.IOConfig.StreamOutput)
+}
+
+func TestInitializeDocState_StripAnsiEscapeCodes(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{
+		OrchestrationDir: testOrchDir,
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+	}
+
+	var testDocContent DocContent
+	validdocument := loadFile(t, filepath.Join("..", "..", "runcommand", "mds", "testdata", "validcommand12.json"))
+	err := json.Unmarshal(validdocument, &testDocContent)
+	assert.Nil(t, err)
+
+	docState, err := InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+	assert.Nil(t, err)
+	assert.False(t, docState.IOConfig.StripAnsiEscapeCodes)
+	assert.False(t, docState.IOConfig.KeepRawOutput)
+
+	testDocContent.StripAnsiEscapeCodes = true
+	testDocContent.KeepRawOutput = true
+	docState, err = InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+	assert.Nil(t, err)
+	assert.True(t, docState.IOConfig.StripAnsiEscapeCodes)
+	assert.True(t, docState.IOConfig.KeepRawOutput)
+}
+
+func TestInitializeDocState_CombinedOutput(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{
+		OrchestrationDir: testOrchDir,
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+	}
+
+	var testDocContent DocContent
+	validdocument := loadFile(t, filepath.Join("..", "..", "runcommand", "mds", "testdata", "validcommand12.json"))
+	err := json.Unmarshal(validdocument, &testDocContent)
+	assert.Nil(t, err)
+
+	docState, err := InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+	assert.Nil(t, err)
+	assert.False(t, docState.IOConfig.CombinedOutput)
+
+	testDocContent.CombinedOutput = true
+	docState, err = InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+	assert.Nil(t, err)
+	assert.True(t, docState.IOConfig.CombinedOutput)
+}
+
 func TestInitializeDocStateForStartSessionDocument_Valid(t *testing.T) {
 	context := context.NewMockDefault()
 
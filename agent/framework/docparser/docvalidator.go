@@ -0,0 +1,152 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// preconditionOperatorArgCount is the number of arguments of every precondition operator
+const preconditionOperatorArgCount = 2
+
+// validateDocumentStructure checks the structure of the document content before any of its steps is parsed,
+// the returned error locates the invalid field, e.g. mainSteps[1].name, so that the command fails with a
+// precise message instead of failing once the plugins are executed
+func validateDocumentStructure(docContent *DocContent) error {
+	switch docContent.SchemaVersion {
+	case "1.0", "1.2":
+		return validateRuntimeConfig(docContent)
+	case "2.0", "2.0.1", "2.0.2", "2.0.3", "2.2":
+		return validateMainSteps(docContent)
+	}
+	return nil
+}
+
+// validateRuntimeConfig checks the plugins of documents using the schema versions 1.x
+func validateRuntimeConfig(docContent *DocContent) error {
+	if len(docContent.RuntimeConfig) == 0 {
+		return structureError("runtimeConfig is required by schema version %v", docContent.SchemaVersion)
+	}
+	if len(docContent.MainSteps) != 0 {
+		return structureError("mainSteps is not supported by schema version %v", docContent.SchemaVersion)
+	}
+
+	// plugins are sorted to always report the same error for a given document
+	pluginNames := make([]string, 0, len(docContent.RuntimeConfig))
+	for pluginName := range docContent.RuntimeConfig {
+		pluginNames = append(pluginNames, pluginName)
+	}
+	sort.Strings(pluginNames)
+
+	for _, pluginName := range pluginNames {
+		pluginConfig := docContent.RuntimeConfig[pluginName]
+		location := fmt.Sprintf("runtimeConfig[%v]", pluginName)
+		if strings.TrimSpace(pluginName) == "" {
+			return structureError("runtimeConfig contains a plugin with an empty name")
+		}
+		if pluginConfig == nil {
+			return structureError("%v must be an object", location)
+		}
+		switch pluginConfig.Properties.(type) {
+		case nil, string, []interface{}, map[string]interface{}:
+		default:
+			return structureError("%v.properties must be an object, a list or a string", location)
+		}
+	}
+	return nil
+}
+
+// validateMainSteps checks the steps of documents using the schema versions 2.x
+func validateMainSteps(docContent *DocContent) error {
+	if len(docContent.MainSteps) == 0 {
+		return structureError("mainSteps is required by schema version %v", docContent.SchemaVersion)
+	}
+	if len(docContent.RuntimeConfig) != 0 {
+		return structureError("runtimeConfig is not supported by schema version %v", docContent.SchemaVersion)
+	}
+
+	preconditionEnabled := isPreconditionEnabled(docContent.SchemaVersion)
+	stepIndexes := make(map[string]int)
+	for index, step := range docContent.MainSteps {
+		location := fmt.Sprintf("mainSteps[%d]", index)
+		if step == nil {
+			return structureError("%v must be an object", location)
+		}
+		if strings.TrimSpace(step.Action) == "" {
+			return structureError("%v.action is required", location)
+		}
+		if strings.TrimSpace(step.Name) == "" {
+			return structureError("%v.name is required", location)
+		}
+		// step names are used as the name of the orchestration directory of the step
+		if strings.ContainsAny(step.Name, `/\`) || step.Name == "." || step.Name == ".." {
+			return structureError("%v.name %v is not a valid step name", location, step.Name)
+		}
+		if previous, found := stepIndexes[step.Name]; found {
+			return structureError("%v.name %v is already used by mainSteps[%d]", location, step.Name, previous)
+		}
+		stepIndexes[step.Name] = index
+
+		if step.MaxAttempts < 0 {
+			return structureError("%v.maxAttempts must not be negative", location)
+		}
+		if step.Timeout < 0 {
+			return structureError("%v.timeoutSeconds must not be negative", location)
+		}
+		switch step.Inputs.(type) {
+		case nil, map[string]interface{}:
+		default:
+			return structureError("%v.inputs must be an object", location)
+		}
+		if err := validateStepPreconditions(location, step.Preconditions, preconditionEnabled, docContent.SchemaVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateStepPreconditions checks the operators of the preconditions of a step, the arguments themselves are
+// resolved and evaluated when the step is executed
+func validateStepPreconditions(location string, preconditions map[string][]string, preconditionEnabled bool, schemaVersion string) error {
+	if len(preconditions) == 0 {
+		return nil
+	}
+	if !preconditionEnabled {
+		return structureError("%v.precondition is not supported by schema version %v, it requires schema version %v", location, schemaVersion, preconditionSchemaVersion)
+	}
+	for operator, arguments := range preconditions {
+		if _, isSupported := appconfig.SupportedPreconditionOperators[operator]; !isSupported {
+			return structureError("%v.precondition operator %v is not supported", location, operator)
+		}
+		if len(arguments) != preconditionOperatorArgCount {
+			return structureError("%v.precondition.%v accepts exactly %d arguments", location, operator, preconditionOperatorArgCount)
+		}
+		// percentages given as document parameters are checked once the parameters are resolved
+		if operator == appconfig.PreconditionOperatorInstancePercentage && !strings.Contains(arguments[1], "{{") {
+			if percentage, err := strconv.ParseFloat(strings.TrimSpace(arguments[1]), 64); err != nil || percentage < 0 || percentage > 100 {
+				return structureError("%v.precondition.%v percentage %v must be a number between 0 and 100", location, operator, arguments[1])
+			}
+		}
+	}
+	return nil
+}
+
+func structureError(format string, args ...interface{}) error {
+	return fmt.Errorf("Unsupported schema format: "+format, args...)
+}
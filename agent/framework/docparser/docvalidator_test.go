@@ -0,0 +1,144 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDocumentStructure(t *testing.T) {
+	testCases := []struct {
+		name     string
+		document string
+		err      string
+	}{
+		{
+			name:     "valid runtimeConfig",
+			document: `{"schemaVersion":"1.2","runtimeConfig":{"aws:runShellScript":{"properties":[{"id":"0.aws:runShellScript","runCommand":["ls"]}]}}}`,
+		},
+		{
+			name:     "valid mainSteps",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"first","inputs":{"runCommand":["ls"]},"precondition":{"StringEquals":["platformType","Linux"]}},{"action":"aws:runShellScript","name":"second"}]}`,
+		},
+		{
+			name:     "missing runtimeConfig",
+			document: `{"schemaVersion":"1.2"}`,
+			err:      "runtimeConfig is required by schema version 1.2",
+		},
+		{
+			name:     "mainSteps in schema 1.2",
+			document: `{"schemaVersion":"1.2","runtimeConfig":{"aws:runShellScript":{}},"mainSteps":[{"action":"aws:runShellScript","name":"first"}]}`,
+			err:      "mainSteps is not supported by schema version 1.2",
+		},
+		{
+			name:     "null plugin",
+			document: `{"schemaVersion":"1.2","runtimeConfig":{"aws:runShellScript":null}}`,
+			err:      "runtimeConfig[aws:runShellScript] must be an object",
+		},
+		{
+			name:     "invalid properties",
+			document: `{"schemaVersion":"1.2","runtimeConfig":{"aws:runShellScript":{"properties":3}}}`,
+			err:      "runtimeConfig[aws:runShellScript].properties must be an object, a list or a string",
+		},
+		{
+			name:     "missing mainSteps",
+			document: `{"schemaVersion":"2.0"}`,
+			err:      "mainSteps is required by schema version 2.0",
+		},
+		{
+			name:     "missing action",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"first"},{"name":"second"}]}`,
+			err:      "mainSteps[1].action is required",
+		},
+		{
+			name:     "missing name",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript"}]}`,
+			err:      "mainSteps[0].name is required",
+		},
+		{
+			name:     "name with path separator",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"../first"}]}`,
+			err:      "mainSteps[0].name ../first is not a valid step name",
+		},
+		{
+			name:     "duplicated name",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"first"},{"action":"aws:runShellScript","name":"second"},{"action":"aws:runShellScript","name":"first"}]}`,
+			err:      "mainSteps[2].name first is already used by mainSteps[0]",
+		},
+		{
+			name:     "negative timeout",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"first","timeoutSeconds":-1}]}`,
+			err:      "mainSteps[0].timeoutSeconds must not be negative",
+		},
+		{
+			name:     "invalid inputs",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"first","inputs":["ls"]}]}`,
+			err:      "mainSteps[0].inputs must be an object",
+		},
+		{
+			name:     "precondition in schema 2.0",
+			document: `{"schemaVersion":"2.0","mainSteps":[{"action":"aws:runShellScript","name":"first","precondition":{"StringEquals":["platformType","Linux"]}}]}`,
+			err:      "mainSteps[0].precondition is not supported by schema version 2.0, it requires schema version 2.2",
+		},
+		{
+			name:     "unsupported precondition operator",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"first","precondition":{"StringMatches":["platformType","Linux"]}}]}`,
+			err:      "mainSteps[0].precondition operator StringMatches is not supported",
+		},
+		{
+			name:     "instance percentage",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"first","precondition":{"InstancePercentage":["canary","{{ percentage }}"]}},{"action":"aws:runShellScript","name":"second","precondition":{"InstancePercentage":["canary","5"]}}]}`,
+		},
+		{
+			name:     "invalid instance percentage",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"first","precondition":{"InstancePercentage":["canary","150"]}}]}`,
+			err:      "mainSteps[0].precondition.InstancePercentage percentage 150 must be a number between 0 and 100",
+		},
+		{
+			name:     "precondition argument count",
+			document: `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"first","precondition":{"StringEquals":["platformType"]}}]}`,
+			err:      "mainSteps[0].precondition.StringEquals accepts exactly 2 arguments",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var docContent DocContent
+			assert.NoError(t, json.Unmarshal([]byte(testCase.document), &docContent))
+
+			err := validateDocumentStructure(&docContent)
+			if testCase.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, "Unsupported schema format: "+testCase.err)
+			}
+		})
+	}
+}
+
+func TestParseDocument_InvalidStructureIsReportedBeforeParsing(t *testing.T) {
+	var docContent DocContent
+	document := `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"first","inputs":{"runCommand":"{{ ssm:missing }}"}},{"name":"second"}]}`
+	assert.NoError(t, json.Unmarshal([]byte(document), &docContent))
+
+	// the parameter store would be called if the structure was not validated first
+	_, err := docContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.EqualError(t, err, "Unsupported schema format: mainSteps[1].action is required")
+}
@@ -0,0 +1,234 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package messagemetrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/times"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+)
+
+const (
+	// documentStatsWindowDays is the number of days, today included, the document executions are aggregated over
+	documentStatsWindowDays = 7
+	// maxTrackedDocuments is the number of documents kept in the statistics, the least recently run are dropped first
+	maxTrackedDocuments = 200
+
+	documentStatsDayLayout = "2006-01-02"
+)
+
+// DocumentStats holds the executions of a document completed during the statistics window
+type DocumentStats struct {
+	DocumentName       string    `json:"documentName"`
+	Runs               int       `json:"runs"`
+	Failures           int       `json:"failures"`
+	FailureRate        float64   `json:"failureRate"`
+	MeanDurationMillis int64     `json:"meanDurationMillis"`
+	LastStatus         string    `json:"lastStatus"`
+	LastRun            time.Time `json:"lastRun"`
+	LastCommandID      string    `json:"lastCommandId,omitempty"`
+}
+
+// documentStatsStore aggregates the document executions by day and persists them in the runtime config,
+// so that the statistics survive the agent restarts
+type documentStatsStore struct {
+	clock  times.Clock
+	client runtimeconfig.IDocumentStatsRuntimeConfigClient
+	mutex  sync.Mutex
+
+	loaded    bool
+	documents map[string]*runtimeconfig.DocumentRunStats
+}
+
+var defaultDocumentStats = newDocumentStatsStore(times.DefaultClock, runtimeconfig.NewDocumentStatsRuntimeConfigClient())
+
+func newDocumentStatsStore(clock times.Clock, client runtimeconfig.IDocumentStatsRuntimeConfigClient) *documentStatsStore {
+	return &documentStatsStore{
+		clock:     clock,
+		client:    client,
+		documents: make(map[string]*runtimeconfig.DocumentRunStats),
+	}
+}
+
+// RecordDocumentRun records the completion of a document execution that took the given duration
+func RecordDocumentRun(log log.T, documentName string, commandID string, status contracts.ResultStatus, duration time.Duration) {
+	defaultDocumentStats.record(log, documentName, commandID, status, duration)
+}
+
+// ReadDocumentStats returns the statistics persisted by the agent, the documents with the most failures first
+func ReadDocumentStats() ([]DocumentStats, error) {
+	client := runtimeconfig.NewDocumentStatsRuntimeConfigClient()
+	if exists, err := client.ConfigExists(); err != nil || !exists {
+		return []DocumentStats{}, err
+	}
+	config, err := client.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return summarizeDocumentStats(config.Documents, times.DefaultClock.Now()), nil
+}
+
+func (s *documentStatsStore) record(log log.T, documentName string, commandID string, status contracts.ResultStatus, duration time.Duration) {
+	if documentName == "" {
+		return
+	}
+	if duration < 0 {
+		duration = 0
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.load(log)
+
+	now := s.clock.Now().UTC()
+	document, found := s.documents[documentName]
+	if !found {
+		document = &runtimeconfig.DocumentRunStats{DocumentName: documentName}
+		s.documents[documentName] = document
+	}
+	document.LastStatus = string(status)
+	document.LastRun = now
+	document.LastCommandID = commandID
+
+	today := now.Format(documentStatsDayLayout)
+	if len(document.Days) == 0 || document.Days[len(document.Days)-1].Day != today {
+		document.Days = append(document.Days, runtimeconfig.DocumentRunDay{Day: today})
+	}
+	day := &document.Days[len(document.Days)-1]
+	day.Runs++
+	day.TotalDurationMillis += toMillis(duration)
+	if !status.IsSuccess() {
+		day.Failures++
+	}
+
+	s.prune(now)
+	if err := s.client.SaveConfig(s.config(now)); err != nil {
+		log.Warnf("Failed to persist the document statistics: %v", err)
+	}
+}
+
+// stats returns the statistics of the documents run during the statistics window
+func (s *documentStatsStore) stats(log log.T) []DocumentStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.load(log)
+	now := s.clock.Now()
+	return summarizeDocumentStats(s.config(now).Documents, now)
+}
+
+// load reads the statistics persisted by a previous agent run the first time they are needed
+func (s *documentStatsStore) load(log log.T) {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	if exists, err := s.client.ConfigExists(); err != nil || !exists {
+		return
+	}
+	config, err := s.client.GetConfig()
+	if err != nil {
+		log.Warnf("Failed to read the persisted document statistics, starting from empty statistics: %v", err)
+		return
+	}
+	for i := range config.Documents {
+		document := config.Documents[i]
+		s.documents[document.DocumentName] = &document
+	}
+}
+
+// prune drops the days out of the statistics window and the least recently run documents above the tracking limit
+func (s *documentStatsStore) prune(now time.Time) {
+	oldest := windowStartDay(now)
+	for name, document := range s.documents {
+		first := 0
+		for first < len(document.Days) && document.Days[first].Day < oldest {
+			first++
+		}
+		document.Days = document.Days[first:]
+		if len(document.Days) == 0 {
+			delete(s.documents, name)
+		}
+	}
+
+	if len(s.documents) <= maxTrackedDocuments {
+		return
+	}
+	documents := make([]*runtimeconfig.DocumentRunStats, 0, len(s.documents))
+	for _, document := range s.documents {
+		documents = append(documents, document)
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		return documents[i].LastRun.Before(documents[j].LastRun)
+	})
+	for _, document := range documents[:len(documents)-maxTrackedDocuments] {
+		delete(s.documents, document.DocumentName)
+	}
+}
+
+func (s *documentStatsStore) config(now time.Time) runtimeconfig.DocumentStatsRuntimeConfig {
+	config := runtimeconfig.DocumentStatsRuntimeConfig{
+		Documents: make([]runtimeconfig.DocumentRunStats, 0, len(s.documents)),
+		UpdatedAt: now,
+	}
+	for _, document := range s.documents {
+		config.Documents = append(config.Documents, *document)
+	}
+	return config
+}
+
+// summarizeDocumentStats aggregates the days of the statistics window, the documents with the most failures first
+func summarizeDocumentStats(documents []runtimeconfig.DocumentRunStats, now time.Time) []DocumentStats {
+	oldest := windowStartDay(now)
+	stats := make([]DocumentStats, 0, len(documents))
+	for _, document := range documents {
+		summary := DocumentStats{
+			DocumentName:  document.DocumentName,
+			LastStatus:    document.LastStatus,
+			LastRun:       document.LastRun,
+			LastCommandID: document.LastCommandID,
+		}
+		var totalDurationMillis int64
+		for _, day := range document.Days {
+			if day.Day < oldest {
+				continue
+			}
+			summary.Runs += day.Runs
+			summary.Failures += day.Failures
+			totalDurationMillis += day.TotalDurationMillis
+		}
+		if summary.Runs == 0 {
+			continue
+		}
+		summary.FailureRate = float64(summary.Failures) / float64(summary.Runs)
+		summary.MeanDurationMillis = totalDurationMillis / int64(summary.Runs)
+		stats = append(stats, summary)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Failures != stats[j].Failures {
+			return stats[i].Failures > stats[j].Failures
+		}
+		return stats[i].DocumentName < stats[j].DocumentName
+	})
+	return stats
+}
+
+// windowStartDay returns the first day of the statistics window ending at the given time
+func windowStartDay(now time.Time) string {
+	return now.UTC().AddDate(0, 0, 1-documentStatsWindowDays).Format(documentStatsDayLayout)
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package messagemetrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDocumentStatsClient keeps the document statistics runtime config in memory
+type fakeDocumentStatsClient struct {
+	config *runtimeconfig.DocumentStatsRuntimeConfig
+	saves  int
+}
+
+func (c *fakeDocumentStatsClient) ConfigExists() (bool, error) {
+	return c.config != nil, nil
+}
+
+func (c *fakeDocumentStatsClient) GetConfig() (runtimeconfig.DocumentStatsRuntimeConfig, error) {
+	return *c.config, nil
+}
+
+func (c *fakeDocumentStatsClient) SaveConfig(config runtimeconfig.DocumentStatsRuntimeConfig) error {
+	c.config = &config
+	c.saves++
+	return nil
+}
+
+func TestDocumentStats_AggregatesRunsPerDocument(t *testing.T) {
+	clock := newFakeClock()
+	client := &fakeDocumentStatsClient{}
+	store := newDocumentStatsStore(clock, client)
+
+	store.record(log.NewMockLog(), "AWS-RunShellScript", "command1", contracts.ResultStatusSuccess, 2*time.Second)
+	store.record(log.NewMockLog(), "AWS-RunShellScript", "command2", contracts.ResultStatusFailed, 4*time.Second)
+	store.record(log.NewMockLog(), "AWS-RunPatchBaseline", "command3", contracts.ResultStatusSuccess, time.Minute)
+	// documents without name are not tracked
+	store.record(log.NewMockLog(), "", "command4", contracts.ResultStatusFailed, time.Second)
+
+	stats := store.stats(log.NewMockLog())
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "AWS-RunShellScript", stats[0].DocumentName)
+	assert.Equal(t, 2, stats[0].Runs)
+	assert.Equal(t, 1, stats[0].Failures)
+	assert.Equal(t, 0.5, stats[0].FailureRate)
+	assert.Equal(t, int64(3000), stats[0].MeanDurationMillis)
+	assert.Equal(t, string(contracts.ResultStatusFailed), stats[0].LastStatus)
+	assert.Equal(t, "command2", stats[0].LastCommandID)
+	assert.Equal(t, "AWS-RunPatchBaseline", stats[1].DocumentName)
+	assert.Equal(t, 0, stats[1].Failures)
+	assert.Equal(t, 3, client.saves)
+}
+
+func TestDocumentStats_LoadsPersistedStats(t *testing.T) {
+	clock := newFakeClock()
+	client := &fakeDocumentStatsClient{}
+	newDocumentStatsStore(clock, client).record(log.NewMockLog(), "AWS-RunShellScript", "command1", contracts.ResultStatusFailed, time.Second)
+
+	// a new agent run starts from the persisted statistics
+	store := newDocumentStatsStore(clock, client)
+	store.record(log.NewMockLog(), "AWS-RunShellScript", "command2", contracts.ResultStatusTimedOut, time.Second)
+
+	stats := store.stats(log.NewMockLog())
+	assert.Len(t, stats, 1)
+	assert.Equal(t, 2, stats[0].Runs)
+	assert.Equal(t, 2, stats[0].Failures)
+}
+
+func TestDocumentStats_DropsDaysOutOfWindow(t *testing.T) {
+	clock := newFakeClock()
+	store := newDocumentStatsStore(clock, &fakeDocumentStatsClient{})
+
+	store.record(log.NewMockLog(), "AWS-RunShellScript", "command1", contracts.ResultStatusFailed, time.Second)
+	store.record(log.NewMockLog(), "AWS-ConfigureAWSPackage", "command2", contracts.ResultStatusFailed, time.Second)
+	clock.advance(3 * 24 * time.Hour)
+	store.record(log.NewMockLog(), "AWS-RunShellScript", "command3", contracts.ResultStatusSuccess, time.Second)
+
+	stats := store.stats(log.NewMockLog())
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "AWS-ConfigureAWSPackage", stats[0].DocumentName)
+	assert.Equal(t, "AWS-RunShellScript", stats[1].DocumentName)
+	assert.Equal(t, 2, stats[1].Runs)
+
+	// the first day leaves the window a week after it started
+	clock.advance(4 * 24 * time.Hour)
+	store.record(log.NewMockLog(), "AWS-RunShellScript", "command4", contracts.ResultStatusSuccess, time.Second)
+	stats = store.stats(log.NewMockLog())
+	assert.Len(t, stats, 1)
+	assert.Equal(t, "AWS-RunShellScript", stats[0].DocumentName)
+	assert.Equal(t, 2, stats[0].Runs)
+	assert.Equal(t, 0, stats[0].Failures)
+}
+
+func TestDocumentStats_DropsLeastRecentlyRunDocuments(t *testing.T) {
+	clock := newFakeClock()
+	store := newDocumentStatsStore(clock, &fakeDocumentStatsClient{})
+
+	for i := 0; i <= maxTrackedDocuments; i++ {
+		store.record(log.NewMockLog(), fmt.Sprintf("document%03d", i), "", contracts.ResultStatusSuccess, time.Second)
+		clock.advance(time.Second)
+	}
+
+	stats := store.stats(log.NewMockLog())
+	assert.Len(t, stats, maxTrackedDocuments)
+	assert.Equal(t, "document001", stats[0].DocumentName)
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package messagemetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/ipc/localsocket"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// endpointWriteTimeout is the time given to a client to read the metrics before it is disconnected
+	endpointWriteTimeout = time.Second
+)
+
+// endpoint writes the current metrics as a single JSON line to each client connecting to a local socket
+type endpoint struct {
+	log       log.T
+	listener  net.Listener
+	recorder  *Recorder
+	documents *documentStatsStore
+}
+
+// newEndpoint starts listening for clients on the given socket path, a named pipe on windows.
+// Only one agent process serves the socket, the others return an error.
+func newEndpoint(log log.T, socketPath string, recorder *Recorder, documents *documentStatsStore) (*endpoint, error) {
+	listener, err := localsocket.Listen(socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serve message metrics on %v: %v", socketPath, err)
+	}
+
+	e := &endpoint{
+		log:       log,
+		listener:  listener,
+		recorder:  recorder,
+		documents: documents,
+	}
+	go e.serve()
+	return e, nil
+}
+
+// serve answers the clients until the listener is closed
+func (e *endpoint) serve() {
+	defer func() {
+		if r := recover(); r != nil {
+			e.log.Errorf("Message metrics endpoint panic: %v", r)
+		}
+	}()
+	for {
+		conn, err := e.listener.Accept()
+		if err != nil {
+			return
+		}
+		e.write(conn)
+	}
+}
+
+func (e *endpoint) write(conn net.Conn) {
+	defer conn.Close()
+	snapshot := e.recorder.Snapshot()
+	snapshot.Documents = e.documents.stats(e.log)
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(endpointWriteTimeout))
+	conn.Write(append(content, '\n'))
+}
+
+// close stops serving the metrics
+func (e *endpoint) close() {
+	if e == nil {
+		return
+	}
+	e.listener.Close()
+}
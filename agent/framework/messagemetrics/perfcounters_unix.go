@@ -0,0 +1,33 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package messagemetrics
+
+import (
+	"errors"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// performanceCounters is not used as the performance counters only exist on Windows
+type performanceCounters struct{}
+
+// startPerformanceCounters returns an error as the performance counters only exist on Windows
+func startPerformanceCounters(log log.T, recorder *Recorder) (*performanceCounters, error) {
+	return nil, errors.New("performance counters are only available on Windows")
+}
+
+func (c *performanceCounters) stop() {
+}
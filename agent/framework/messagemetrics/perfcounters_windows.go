@@ -0,0 +1,248 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//go:build windows
+// +build windows
+
+package messagemetrics
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	// perfCountersRefreshInterval is the interval at which the counter values are updated from the recorder
+	perfCountersRefreshInterval = 5 * time.Second
+
+	perfCountersManifestFileName = "amazon-ssm-agent-counters.man"
+	perfProvidersRegistryPath    = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Perflib\_V2Providers\`
+
+	perfCounterRawCount      = 0x00010000 // PERF_COUNTER_RAWCOUNT
+	perfDetailNovice         = 100        // PERF_DETAIL_NOVICE
+	perfCounterSetSingleType = 0          // PERF_COUNTERSET_SINGLE_INSTANCE
+)
+
+// ids of the counters of the agent counter set, as declared in the manifest
+const (
+	commandsInProgressCounter uint32 = iota + 1
+	sessionsActiveCounter
+	mgsConnectedCounter
+	repliesQueuedCounter
+	counterCount = iota
+)
+
+var (
+	perfProviderGUID   = windows.GUID{Data1: 0x6554edd3, Data2: 0x73bb, Data3: 0x48ce, Data4: [8]byte{0xbe, 0x91, 0x7e, 0x80, 0xc1, 0x6e, 0x15, 0x19}}
+	perfCounterSetGUID = windows.GUID{Data1: 0x295008f8, Data2: 0x8690, Data3: 0x44d8, Data4: [8]byte{0x8f, 0x0d, 0xe0, 0xfd, 0xb7, 0x7c, 0x56, 0xac}}
+
+	advapi32                     = windows.NewLazySystemDLL("advapi32.dll")
+	procPerfStartProvider        = advapi32.NewProc("PerfStartProvider")
+	procPerfStopProvider         = advapi32.NewProc("PerfStopProvider")
+	procPerfSetCounterSetInfo    = advapi32.NewProc("PerfSetCounterSetInfo")
+	procPerfCreateInstance       = advapi32.NewProc("PerfCreateInstance")
+	procPerfDeleteInstance       = advapi32.NewProc("PerfDeleteInstance")
+	procPerfSetULongCounterValue = advapi32.NewProc("PerfSetULongCounterValue")
+)
+
+// perfCountersManifest declares the agent counter set, it is registered with lodctr
+const perfCountersManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<instrumentationManifest xmlns="http://schemas.microsoft.com/win/2004/08/events" xmlns:win="http://manifests.microsoft.com/win/2004/08/windows/events" xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <instrumentation>
+    <counters xmlns="http://schemas.microsoft.com/win/2005/12/counters" schemaVersion="2.0">
+      <provider callback="custom" applicationIdentity="%s" providerType="userMode" providerGuid="%s" symbol="SSMAgentProvider">
+        <counterSet guid="%s" uri="Amazon.SSMAgent" name="Amazon SSM Agent" symbol="SSMAgentCounterSet" description="Documents and sessions executed by the Amazon SSM Agent" instances="single">
+          <counter id="1" uri="Amazon.SSMAgent.CommandsInProgress" name="Commands In Progress" symbol="CommandsInProgress" description="Number of command documents executing" type="perf_counter_rawcount" detailLevel="standard"/>
+          <counter id="2" uri="Amazon.SSMAgent.SessionsActive" name="Sessions Active" symbol="SessionsActive" description="Number of Session Manager sessions open on the instance" type="perf_counter_rawcount" detailLevel="standard"/>
+          <counter id="3" uri="Amazon.SSMAgent.MGSConnected" name="MGS Connected" symbol="MGSConnected" description="1 when the control channel to the Message Gateway Service is connected, 0 otherwise" type="perf_counter_rawcount" detailLevel="standard"/>
+          <counter id="4" uri="Amazon.SSMAgent.RepliesQueued" name="Replies Queued" symbol="RepliesQueued" description="Number of document replies waiting on disk to be sent again to the Message Gateway Service" type="perf_counter_rawcount" detailLevel="standard"/>
+        </counterSet>
+      </provider>
+    </counters>
+  </instrumentation>
+</instrumentationManifest>
+`
+
+// perfCounterSetInfo is the PERF_COUNTERSET_INFO header of the counter set template
+type perfCounterSetInfo struct {
+	CounterSetGUID windows.GUID
+	ProviderGUID   windows.GUID
+	NumCounters    uint32
+	InstanceType   uint32
+}
+
+// perfCounterInfo is the PERF_COUNTER_INFO describing a counter of the counter set template
+type perfCounterInfo struct {
+	CounterID   uint32
+	Type        uint32
+	Attrib      uint64
+	Size        uint32
+	DetailLevel uint32
+	Scale       int32
+	Offset      uint32
+}
+
+type perfCounterSetTemplate struct {
+	Info     perfCounterSetInfo
+	Counters [counterCount]perfCounterInfo
+}
+
+// performanceCounters publishes the recorder metrics in the agent counter set of the Windows performance counters
+type performanceCounters struct {
+	log      log.T
+	recorder *Recorder
+	provider windows.Handle
+	instance uintptr
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// startPerformanceCounters registers the agent counter set when it is not registered yet, creates its instance
+// and updates its counters until stopped
+func startPerformanceCounters(log log.T, recorder *Recorder) (*performanceCounters, error) {
+	if err := registerCounterSet(log); err != nil {
+		return nil, fmt.Errorf("failed to register the performance counters: %v", err)
+	}
+
+	c := &performanceCounters{
+		log:      log,
+		recorder: recorder,
+		stopChan: make(chan struct{}),
+	}
+	if ret, _, _ := procPerfStartProvider.Call(uintptr(unsafe.Pointer(&perfProviderGUID)), 0, uintptr(unsafe.Pointer(&c.provider))); ret != 0 {
+		return nil, fmt.Errorf("PerfStartProvider failed: %v", windows.Errno(ret))
+	}
+
+	template := perfCounterSetTemplate{
+		Info: perfCounterSetInfo{
+			CounterSetGUID: perfCounterSetGUID,
+			ProviderGUID:   perfProviderGUID,
+			NumCounters:    counterCount,
+			InstanceType:   perfCounterSetSingleType,
+		},
+	}
+	for i := range template.Counters {
+		template.Counters[i] = perfCounterInfo{
+			CounterID:   uint32(i) + 1,
+			Type:        perfCounterRawCount,
+			Size:        4,
+			DetailLevel: perfDetailNovice,
+			Offset:      uint32(i) * 4,
+		}
+	}
+	if ret, _, _ := procPerfSetCounterSetInfo.Call(uintptr(c.provider), uintptr(unsafe.Pointer(&template)), unsafe.Sizeof(template)); ret != 0 {
+		c.stopProvider()
+		return nil, fmt.Errorf("PerfSetCounterSetInfo failed: %v", windows.Errno(ret))
+	}
+
+	instanceName, _ := windows.UTF16PtrFromString("_Default")
+	instance, _, err := procPerfCreateInstance.Call(uintptr(c.provider), uintptr(unsafe.Pointer(&perfCounterSetGUID)), uintptr(unsafe.Pointer(instanceName)), 0)
+	if instance == 0 {
+		c.stopProvider()
+		return nil, fmt.Errorf("PerfCreateInstance failed: %v", err)
+	}
+	c.instance = instance
+
+	c.update()
+	go c.refreshLoop()
+	return c, nil
+}
+
+// registerCounterSet writes the manifest of the counter set next to the agent executable and loads it with lodctr,
+// the counter set stays registered across agent restarts
+func registerCounterSet(log log.T) error {
+	if key, err := registry.OpenKey(registry.LOCAL_MACHINE, perfProvidersRegistryPath+perfProviderGUID.String(), registry.QUERY_VALUE); err == nil {
+		key.Close()
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	manifestPath := filepath.Join(filepath.Dir(executable), perfCountersManifestFileName)
+	manifest := fmt.Sprintf(perfCountersManifest, executable, perfProviderGUID.String(), perfCounterSetGUID.String())
+	if err = ioutil.WriteFile(manifestPath, []byte(manifest), appconfig.ReadWriteAccess); err != nil {
+		return err
+	}
+	log.Infof("Registering the performance counters declared in %v", manifestPath)
+	if output, err := exec.Command("lodctr", "/m:"+manifestPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("lodctr failed: %v, %s", err, output)
+	}
+	return nil
+}
+
+func (c *performanceCounters) refreshLoop() {
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Errorf("Performance counters panic: %v", r)
+			c.log.Errorf("Stacktrace:\n%s", debug.Stack())
+		}
+	}()
+	ticker := time.NewTicker(perfCountersRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-ticker.C:
+			c.update()
+		}
+	}
+}
+
+// update sets the counters from the current metrics of the recorder
+func (c *performanceCounters) update() {
+	snapshot := c.recorder.Snapshot()
+	mgsConnected := 0
+	if snapshot.MGSConnected {
+		mgsConnected = 1
+	}
+	c.set(commandsInProgressCounter, snapshot.CommandsInProgress)
+	c.set(sessionsActiveCounter, snapshot.SessionsActive)
+	c.set(mgsConnectedCounter, mgsConnected)
+	c.set(repliesQueuedCounter, snapshot.RepliesQueued)
+}
+
+func (c *performanceCounters) set(counterID uint32, value int) {
+	if ret, _, _ := procPerfSetULongCounterValue.Call(uintptr(c.provider), c.instance, uintptr(counterID), uintptr(uint32(value))); ret != 0 {
+		c.log.Debugf("Failed to set performance counter %v: %v", counterID, windows.Errno(ret))
+	}
+}
+
+// stop deletes the counter set instance, perfmon no longer shows the agent counters until the agent starts again
+func (c *performanceCounters) stop() {
+	if c == nil {
+		return
+	}
+	c.stopOnce.Do(func() {
+		close(c.stopChan)
+		procPerfDeleteInstance.Call(uintptr(c.provider), c.instance)
+		c.stopProvider()
+	})
+}
+
+func (c *performanceCounters) stopProvider() {
+	procPerfStopProvider.Call(uintptr(c.provider))
+}
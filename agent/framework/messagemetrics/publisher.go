@@ -0,0 +1,164 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package messagemetrics
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/session/telemetry/metrics"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+const (
+	// publishInterval is the aggregation window of the pickup latencies published to CloudWatch
+	publishInterval = 5 * time.Minute
+
+	metricUnitCount        = "Count"
+	metricUnitMilliseconds = "Milliseconds"
+
+	averagePickupLatencyMetric = "MessagePickupLatencyAverage"
+	maxPickupLatencyMetric     = "MessagePickupLatencyMaximum"
+	queueDepthMetric           = "ExecutionQueueDepth"
+	backlogAgeMetric           = "ExecutionBacklogAge"
+)
+
+// Publisher publishes the message metrics to CloudWatch, serves them on the local metrics socket and
+// in the Windows performance counters
+type Publisher struct {
+	context           context.T
+	recorder          *Recorder
+	documents         *documentStatsStore
+	cloudWatchService metrics.ICloudWatchService
+	endpoint          *endpoint
+	counters          *performanceCounters
+	interval          time.Duration
+	stopChan          chan struct{}
+	startOnce         sync.Once
+	stopOnce          sync.Once
+}
+
+// NewPublisher creates a publisher of the metrics recorded by the agent
+func NewPublisher(context context.T) *Publisher {
+	return &Publisher{
+		context:   context,
+		recorder:  defaultRecorder,
+		documents: defaultDocumentStats,
+		interval:  publishInterval,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start opens the local metrics socket when one is configured, registers the performance counters when they are
+// enabled and publishes the metrics to CloudWatch when the agent telemetry metrics to CloudWatch are enabled
+func (p *Publisher) Start() {
+	p.startOnce.Do(p.start)
+}
+
+func (p *Publisher) start() {
+	log := p.context.Log()
+	agentConfig := p.context.AppConfig().Agent
+	if socketPath := agentConfig.MessageMetricsSocketPath; socketPath != "" {
+		endpoint, err := newEndpoint(log, socketPath, p.recorder, p.documents)
+		if err != nil {
+			log.Warnf("Failed to start the message metrics endpoint: %v", err)
+		} else {
+			log.Infof("Serving message metrics on %v", socketPath)
+			p.endpoint = endpoint
+		}
+	}
+	if agentConfig.PerformanceCounters {
+		counters, err := startPerformanceCounters(log, p.recorder)
+		if err != nil {
+			log.Warnf("Failed to start the performance counters: %v", err)
+		} else {
+			log.Info("Publishing the agent performance counters")
+			p.counters = counters
+		}
+	}
+	if agentConfig.TelemetryMetricsToCloudWatch && p.cloudWatchService == nil {
+		p.cloudWatchService = metrics.NewCloudWatchService(p.context)
+	}
+	go p.publishLoop()
+}
+
+// Stop stops the publication of the metrics, closes the local metrics socket and removes the performance counters
+func (p *Publisher) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+		p.endpoint.close()
+		p.counters.stop()
+	})
+}
+
+func (p *Publisher) publishLoop() {
+	log := p.context.Log()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Message metrics publisher panic: %v", r)
+			log.Errorf("Stacktrace:\n%s", debug.Stack())
+		}
+	}()
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+			p.publish()
+		}
+	}
+}
+
+// publish ends the current aggregation window and sends its metrics to CloudWatch
+func (p *Publisher) publish() {
+	log := p.context.Log()
+	snapshot := p.recorder.rotate()
+	log.Debugf("Message metrics: %v", describe(snapshot))
+	if p.cloudWatchService == nil || !p.cloudWatchService.IsCloudWatchEnabled() {
+		return
+	}
+
+	var metricData []*cloudwatch.MetricDatum
+	if snapshot.MessagesReceived > 0 {
+		metricData = append(metricData,
+			p.metric(averagePickupLatencyMetric, snapshot.AveragePickupLatencyMillis, metricUnitMilliseconds),
+			p.metric(maxPickupLatencyMetric, snapshot.MaxPickupLatencyMillis, metricUnitMilliseconds))
+	}
+	metricData = append(metricData,
+		p.metric(queueDepthMetric, int64(snapshot.QueueDepth), metricUnitCount),
+		p.metric(backlogAgeMetric, snapshot.BacklogAgeMillis, metricUnitMilliseconds))
+	if err := p.cloudWatchService.PutMetrics(metricData); err != nil {
+		log.Warnf("Failed to publish the message metrics to CloudWatch: %v", err)
+	}
+}
+
+func (p *Publisher) metric(name string, value int64, unit string) *cloudwatch.MetricDatum {
+	return p.cloudWatchService.GenerateTelemetryMetricsWithUnit(name, float64(value), unit, version.Version)
+}
+
+func describe(snapshot Snapshot) string {
+	return fmt.Sprintf("%d messages received since %v with %dms average and %dms maximum pickup latency, %d documents queued for %dms at most",
+		snapshot.MessagesReceived,
+		snapshot.WindowStart.Format(time.RFC3339),
+		snapshot.AveragePickupLatencyMillis,
+		snapshot.MaxPickupLatencyMillis,
+		snapshot.QueueDepth,
+		snapshot.BacklogAgeMillis)
+}
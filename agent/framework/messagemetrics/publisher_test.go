@@ -0,0 +1,119 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package messagemetrics
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/session/telemetry/metrics/mocks"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPublisher_PublishesMetricsToCloudWatch(t *testing.T) {
+	clock := newFakeClock()
+	recorder := NewRecorder(clock)
+	recorder.RecordPickup(clock.now.Add(-3 * time.Second))
+	recorder.RecordQueued("job1")
+	clock.advance(2 * time.Second)
+
+	cloudWatchService := &mocks.ICloudWatchService{}
+	cloudWatchService.On("IsCloudWatchEnabled").Return(true)
+	for name, value := range map[string]float64{
+		averagePickupLatencyMetric: 3000,
+		maxPickupLatencyMetric:     3000,
+		backlogAgeMetric:           2000,
+	} {
+		cloudWatchService.On("GenerateTelemetryMetricsWithUnit", name, value, metricUnitMilliseconds, mock.Anything).Return(&cloudwatch.MetricDatum{})
+	}
+	cloudWatchService.On("GenerateTelemetryMetricsWithUnit", queueDepthMetric, float64(1), metricUnitCount, mock.Anything).Return(&cloudwatch.MetricDatum{})
+	cloudWatchService.On("PutMetrics", mock.MatchedBy(func(data []*cloudwatch.MetricDatum) bool {
+		return len(data) == 4
+	})).Return(nil)
+
+	publisher := &Publisher{
+		context:           contextmocks.NewMockDefault(),
+		recorder:          recorder,
+		cloudWatchService: cloudWatchService,
+	}
+	publisher.publish()
+	cloudWatchService.AssertExpectations(t)
+
+	// the pickup latencies are not published when no message was received during the window
+	cloudWatchService = &mocks.ICloudWatchService{}
+	cloudWatchService.On("IsCloudWatchEnabled").Return(true)
+	cloudWatchService.On("GenerateTelemetryMetricsWithUnit", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.MetricDatum{})
+	cloudWatchService.On("PutMetrics", mock.MatchedBy(func(data []*cloudwatch.MetricDatum) bool {
+		return len(data) == 2
+	})).Return(nil)
+	publisher.cloudWatchService = cloudWatchService
+	publisher.publish()
+	cloudWatchService.AssertExpectations(t)
+}
+
+func TestEndpoint_ServesSnapshotAsJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "messagemetrics")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "metrics.sock")
+
+	recorder := NewRecorder(newFakeClock())
+	recorder.RecordQueued("job1")
+	recorder.RecordQueued("job2")
+	documents := newDocumentStatsStore(recorder.clock, &fakeDocumentStatsClient{})
+	documents.record(log.NewMockLog(), "AWS-RunShellScript", "command1", contracts.ResultStatusFailed, time.Second)
+
+	metricsEndpoint, err := newEndpoint(log.NewMockLog(), socketPath, recorder, documents)
+	assert.NoError(t, err)
+	defer metricsEndpoint.close()
+
+	conn, err := net.Dial("unix", socketPath)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	assert.NoError(t, err)
+
+	var snapshot Snapshot
+	assert.NoError(t, json.Unmarshal(line, &snapshot))
+	assert.Equal(t, 2, snapshot.QueueDepth)
+	assert.Len(t, snapshot.Documents, 1)
+	assert.Equal(t, 1, snapshot.Documents[0].Failures)
+}
+
+func TestEndpoint_FailsWhenSocketIsServed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "messagemetrics")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "metrics.sock")
+
+	metricsEndpoint, err := newEndpoint(log.NewMockLog(), socketPath, NewRecorder(newFakeClock()), newDocumentStatsStore(newFakeClock(), &fakeDocumentStatsClient{}))
+	assert.NoError(t, err)
+	defer metricsEndpoint.close()
+
+	_, err = newEndpoint(log.NewMockLog(), socketPath, NewRecorder(newFakeClock()), newDocumentStatsStore(newFakeClock(), &fakeDocumentStatsClient{}))
+	assert.Error(t, err)
+}
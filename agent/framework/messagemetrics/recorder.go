@@ -0,0 +1,229 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package messagemetrics tracks the time the messages take to reach the agent and the documents waiting for an
+// execution worker, so that polling or connectivity degradation is visible before commands are reported stuck.
+package messagemetrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/times"
+)
+
+// Snapshot holds the message metrics at a given time. The pickup latencies are aggregated over the messages
+// received since WindowStart, the queue depth and backlog age describe the documents waiting at Time.
+// Documents holds the executions of each document over the last days when the snapshot is served on the endpoint.
+// The commands in progress, active sessions, MGS connection state and queued replies describe the agent at Time.
+type Snapshot struct {
+	Time                       time.Time       `json:"time"`
+	WindowStart                time.Time       `json:"windowStart"`
+	MessagesReceived           int             `json:"messagesReceived"`
+	LastPickupLatencyMillis    int64           `json:"lastPickupLatencyMillis"`
+	AveragePickupLatencyMillis int64           `json:"averagePickupLatencyMillis"`
+	MaxPickupLatencyMillis     int64           `json:"maxPickupLatencyMillis"`
+	QueueDepth                 int             `json:"queueDepth"`
+	BacklogAgeMillis           int64           `json:"backlogAgeMillis"`
+	CommandsInProgress         int             `json:"commandsInProgress"`
+	SessionsActive             int             `json:"sessionsActive"`
+	MGSConnected               bool            `json:"mgsConnected"`
+	RepliesQueued              int             `json:"repliesQueued"`
+	Documents                  []DocumentStats `json:"documents,omitempty"`
+}
+
+// Recorder records the pickup latency of the received messages and the documents queued for execution
+type Recorder struct {
+	clock times.Clock
+	mutex sync.Mutex
+
+	windowStart       time.Time
+	messagesReceived  int
+	totalLatency      time.Duration
+	maxLatency        time.Duration
+	lastPickupLatency time.Duration
+	// queued maps the id of the documents waiting for an execution worker to the time they were queued
+	queued map[string]time.Time
+
+	commandsInProgress int
+	sessionsActive     int
+	mgsStatus          MGSStatusProbe
+}
+
+// MGSStatusProbe returns whether the control channel to MGS is connected and the number of replies waiting to be
+// delivered to MGS
+type MGSStatusProbe func() (connected bool, repliesQueued int)
+
+var defaultRecorder = NewRecorder(times.DefaultClock)
+
+// NewRecorder creates a recorder whose first aggregation window starts now
+func NewRecorder(clock times.Clock) *Recorder {
+	return &Recorder{
+		clock:       clock,
+		windowStart: clock.Now(),
+		queued:      make(map[string]time.Time),
+	}
+}
+
+// RecordPickup records a message published at the given time and received by the agent now
+func RecordPickup(published time.Time) {
+	defaultRecorder.RecordPickup(published)
+}
+
+// RecordQueued records a document waiting for an execution worker
+func RecordQueued(jobID string) {
+	defaultRecorder.RecordQueued(jobID)
+}
+
+// RecordDequeued records a document that started its execution or that was dropped from the queue
+func RecordDequeued(jobID string) {
+	defaultRecorder.RecordDequeued(jobID)
+}
+
+// RecordExecutionStarted records a document picked up by an execution worker
+func RecordExecutionStarted(documentType contracts.DocumentType) {
+	defaultRecorder.RecordExecutionStarted(documentType)
+}
+
+// RecordExecutionEnded records a document whose execution ended, sessions end when they are terminated
+func RecordExecutionEnded(documentType contracts.DocumentType) {
+	defaultRecorder.RecordExecutionEnded(documentType)
+}
+
+// RegisterMGSStatus sets the probe reporting the MGS connection state and the replies waiting to be delivered
+func RegisterMGSStatus(probe MGSStatusProbe) {
+	defaultRecorder.RegisterMGSStatus(probe)
+}
+
+// RecordPickup records a message published at the given time and received by the agent now,
+// a publish time in the future because of clock skew counts as no latency
+func (r *Recorder) RecordPickup(published time.Time) {
+	if published.IsZero() {
+		return
+	}
+	latency := r.clock.Now().Sub(published)
+	if latency < 0 {
+		latency = 0
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.messagesReceived++
+	r.totalLatency += latency
+	r.lastPickupLatency = latency
+	if latency > r.maxLatency {
+		r.maxLatency = latency
+	}
+}
+
+// RecordQueued records a document waiting for an execution worker
+func (r *Recorder) RecordQueued(jobID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.queued[jobID] = r.clock.Now()
+}
+
+// RecordDequeued records a document that started its execution or that was dropped from the queue
+func (r *Recorder) RecordDequeued(jobID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.queued, jobID)
+}
+
+// RecordExecutionStarted records a document picked up by an execution worker
+func (r *Recorder) RecordExecutionStarted(documentType contracts.DocumentType) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if documentType == contracts.StartSession {
+		r.sessionsActive++
+	} else {
+		r.commandsInProgress++
+	}
+}
+
+// RecordExecutionEnded records a document whose execution ended
+func (r *Recorder) RecordExecutionEnded(documentType contracts.DocumentType) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if documentType == contracts.StartSession {
+		if r.sessionsActive > 0 {
+			r.sessionsActive--
+		}
+	} else if r.commandsInProgress > 0 {
+		r.commandsInProgress--
+	}
+}
+
+// RegisterMGSStatus sets the probe reporting the MGS connection state and the replies waiting to be delivered
+func (r *Recorder) RegisterMGSStatus(probe MGSStatusProbe) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.mgsStatus = probe
+}
+
+// Snapshot returns the current metrics
+func (r *Recorder) Snapshot() Snapshot {
+	r.mutex.Lock()
+	snapshot, probe := r.snapshot(), r.mgsStatus
+	r.mutex.Unlock()
+	return withMGSStatus(snapshot, probe)
+}
+
+// rotate returns the current metrics and starts a new aggregation window for the pickup latencies
+func (r *Recorder) rotate() Snapshot {
+	r.mutex.Lock()
+	snapshot, probe := r.snapshot(), r.mgsStatus
+	r.windowStart = snapshot.Time
+	r.messagesReceived = 0
+	r.totalLatency = 0
+	r.maxLatency = 0
+	r.mutex.Unlock()
+	return withMGSStatus(snapshot, probe)
+}
+
+// withMGSStatus adds the MGS status to the snapshot, the probe is called without holding the recorder lock
+// as it reads the state of the MGS interactor
+func withMGSStatus(snapshot Snapshot, probe MGSStatusProbe) Snapshot {
+	if probe != nil {
+		snapshot.MGSConnected, snapshot.RepliesQueued = probe()
+	}
+	return snapshot
+}
+
+func (r *Recorder) snapshot() Snapshot {
+	now := r.clock.Now()
+	snapshot := Snapshot{
+		Time:                    now,
+		WindowStart:             r.windowStart,
+		MessagesReceived:        r.messagesReceived,
+		LastPickupLatencyMillis: toMillis(r.lastPickupLatency),
+		MaxPickupLatencyMillis:  toMillis(r.maxLatency),
+		QueueDepth:              len(r.queued),
+		CommandsInProgress:      r.commandsInProgress,
+		SessionsActive:          r.sessionsActive,
+	}
+	if r.messagesReceived > 0 {
+		snapshot.AveragePickupLatencyMillis = toMillis(r.totalLatency / time.Duration(r.messagesReceived))
+	}
+	for _, queuedAt := range r.queued {
+		if age := toMillis(now.Sub(queuedAt)); age > snapshot.BacklogAgeMillis {
+			snapshot.BacklogAgeMillis = age
+		}
+	}
+	return snapshot
+}
+
+func toMillis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
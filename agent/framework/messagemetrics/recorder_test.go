@@ -0,0 +1,131 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package messagemetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a clock whose time only moves when the test advances it
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func TestRecorder_PickupLatency(t *testing.T) {
+	clock := newFakeClock()
+	recorder := NewRecorder(clock)
+
+	recorder.RecordPickup(clock.now.Add(-2 * time.Second))
+	recorder.RecordPickup(clock.now.Add(-4 * time.Second))
+	// a message published in the future because of clock skew counts as no latency
+	recorder.RecordPickup(clock.now.Add(time.Second))
+	// a message without publish time is ignored
+	recorder.RecordPickup(time.Time{})
+
+	snapshot := recorder.Snapshot()
+	assert.Equal(t, 3, snapshot.MessagesReceived)
+	assert.Equal(t, int64(0), snapshot.LastPickupLatencyMillis)
+	assert.Equal(t, int64(2000), snapshot.AveragePickupLatencyMillis)
+	assert.Equal(t, int64(4000), snapshot.MaxPickupLatencyMillis)
+}
+
+func TestRecorder_Backlog(t *testing.T) {
+	clock := newFakeClock()
+	recorder := NewRecorder(clock)
+
+	recorder.RecordQueued("job1")
+	clock.advance(3 * time.Second)
+	recorder.RecordQueued("job2")
+	clock.advance(time.Second)
+
+	snapshot := recorder.Snapshot()
+	assert.Equal(t, 2, snapshot.QueueDepth)
+	assert.Equal(t, int64(4000), snapshot.BacklogAgeMillis)
+
+	recorder.RecordDequeued("job1")
+	snapshot = recorder.Snapshot()
+	assert.Equal(t, 1, snapshot.QueueDepth)
+	assert.Equal(t, int64(1000), snapshot.BacklogAgeMillis)
+
+	recorder.RecordDequeued("job2")
+	snapshot = recorder.Snapshot()
+	assert.Equal(t, 0, snapshot.QueueDepth)
+	assert.Equal(t, int64(0), snapshot.BacklogAgeMillis)
+}
+
+func TestRecorder_RotateStartsNewLatencyWindow(t *testing.T) {
+	clock := newFakeClock()
+	recorder := NewRecorder(clock)
+	recorder.RecordPickup(clock.now.Add(-time.Second))
+	recorder.RecordQueued("job1")
+	clock.advance(time.Minute)
+
+	snapshot := recorder.rotate()
+	assert.Equal(t, 1, snapshot.MessagesReceived)
+	assert.Equal(t, int64(1000), snapshot.MaxPickupLatencyMillis)
+
+	snapshot = recorder.Snapshot()
+	assert.Equal(t, clock.now, snapshot.WindowStart)
+	assert.Equal(t, 0, snapshot.MessagesReceived)
+	assert.Equal(t, int64(0), snapshot.AveragePickupLatencyMillis)
+	assert.Equal(t, int64(0), snapshot.MaxPickupLatencyMillis)
+	// the last latency and the backlog are not part of the window
+	assert.Equal(t, int64(1000), snapshot.LastPickupLatencyMillis)
+	assert.Equal(t, 1, snapshot.QueueDepth)
+	assert.Equal(t, int64(60000), snapshot.BacklogAgeMillis)
+}
+
+func TestRecorder_ExecutionsAndMGSStatus(t *testing.T) {
+	recorder := NewRecorder(newFakeClock())
+	recorder.RecordExecutionStarted(contracts.SendCommand)
+	recorder.RecordExecutionStarted(contracts.SendCommand)
+	recorder.RecordExecutionStarted(contracts.StartSession)
+	recorder.RecordExecutionEnded(contracts.SendCommand)
+
+	snapshot := recorder.Snapshot()
+	assert.Equal(t, 1, snapshot.CommandsInProgress)
+	assert.Equal(t, 1, snapshot.SessionsActive)
+	assert.False(t, snapshot.MGSConnected)
+	assert.Equal(t, 0, snapshot.RepliesQueued)
+
+	recorder.RegisterMGSStatus(func() (bool, int) { return true, 3 })
+	recorder.RecordExecutionEnded(contracts.StartSession)
+	// an unbalanced end does not make the counts negative
+	recorder.RecordExecutionEnded(contracts.StartSession)
+
+	snapshot = recorder.rotate()
+	assert.Equal(t, 0, snapshot.SessionsActive)
+	assert.True(t, snapshot.MGSConnected)
+	assert.Equal(t, 3, snapshot.RepliesQueued)
+}
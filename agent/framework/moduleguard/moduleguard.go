@@ -0,0 +1,79 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package moduleguard isolates the panics of the core module goroutines, a recovered panic is reported to the
+// handler of its core module instead of taking the agent down.
+package moduleguard
+
+import (
+	"runtime/debug"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// PanicHandler is notified of the panics recovered in the goroutines of a core module
+type PanicHandler func(panicValue interface{}, stack []byte)
+
+var (
+	handlers     = make(map[string]PanicHandler)
+	handlersLock sync.RWMutex
+)
+
+// SetPanicHandler sets the handler notified of the panics of the given core module, a nil handler removes it
+func SetPanicHandler(moduleName string, handler PanicHandler) {
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	if handler == nil {
+		delete(handlers, moduleName)
+		return
+	}
+	handlers[moduleName] = handler
+}
+
+// Go runs the function in a new goroutine recovering its panics
+func Go(log log.T, moduleName string, fn func()) {
+	go func() {
+		defer Recover(log, moduleName)
+		fn()
+	}()
+}
+
+// Wrap returns the function recovering the panics of fn, it is meant for the jobs run by the scheduler
+func Wrap(log log.T, moduleName string, fn func()) func() {
+	return func() {
+		defer Recover(log, moduleName)
+		fn()
+	}
+}
+
+// Recover recovers the panic of the calling goroutine and reports it, it must be deferred
+func Recover(log log.T, moduleName string) {
+	if r := recover(); r != nil {
+		ReportPanic(log, moduleName, r, debug.Stack())
+	}
+}
+
+// ReportPanic logs a panic already recovered in a goroutine of the core module and notifies the module handler.
+// The handler is notified asynchronously, it may stop the module and wait for the goroutine that panicked.
+func ReportPanic(log log.T, moduleName string, panicValue interface{}, stack []byte) {
+	log.Errorf("%v panic: %v", moduleName, panicValue)
+	log.Errorf("Stacktrace:\n%s", stack)
+
+	handlersLock.RLock()
+	handler := handlers[moduleName]
+	handlersLock.RUnlock()
+	if handler != nil {
+		go handler(panicValue, stack)
+	}
+}
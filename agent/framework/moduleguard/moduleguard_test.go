@@ -0,0 +1,79 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package moduleguard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectPanic sets a handler of the module forwarding the panics it is notified of
+func expectPanic(t *testing.T, moduleName string) chan interface{} {
+	panics := make(chan interface{}, 1)
+	SetPanicHandler(moduleName, func(panicValue interface{}, stack []byte) {
+		assert.NotEmpty(t, stack)
+		panics <- panicValue
+	})
+	t.Cleanup(func() { SetPanicHandler(moduleName, nil) })
+	return panics
+}
+
+func waitForPanic(t *testing.T, panics chan interface{}) interface{} {
+	select {
+	case panicValue := <-panics:
+		return panicValue
+	case <-time.After(time.Second):
+		t.Fatal("the panic was not reported")
+		return nil
+	}
+}
+
+func TestGo_ReportsPanic(t *testing.T) {
+	panics := expectPanic(t, "TestModule")
+
+	Go(log.NewMockLog(), "TestModule", func() { panic("module bug") })
+
+	assert.Equal(t, "module bug", waitForPanic(t, panics))
+}
+
+func TestWrap_ReportsPanic(t *testing.T) {
+	panics := expectPanic(t, "TestModule")
+
+	assert.NotPanics(t, Wrap(log.NewMockLog(), "TestModule", func() { panic("module bug") }))
+
+	assert.Equal(t, "module bug", waitForPanic(t, panics))
+}
+
+func TestRecover_ReportsToModuleHandlerOnly(t *testing.T) {
+	panics := expectPanic(t, "TestModule")
+	otherPanics := expectPanic(t, "OtherModule")
+
+	func() {
+		defer Recover(log.NewMockLog(), "OtherModule")
+		panic("other module bug")
+	}()
+
+	assert.Equal(t, "other module bug", waitForPanic(t, otherPanics))
+	assert.Empty(t, panics)
+}
+
+func TestRecover_WithoutHandler(t *testing.T) {
+	assert.NotPanics(t, func() {
+		defer Recover(log.NewMockLog(), "ModuleWithoutHandler")
+		panic("module bug")
+	})
+}
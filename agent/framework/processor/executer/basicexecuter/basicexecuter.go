@@ -73,6 +73,22 @@ func run(context context.T,
 		}()
 		results := make(map[string]*contracts.PluginResult)
 		for res := range statusChan {
+			if res.OutputFrame != nil {
+				// output frames are forwarded as they are, they do not change the plugin results
+				frame := res
+				resChan <- contracts.DocumentResult{
+					Status:          contracts.ResultStatusInProgress,
+					PluginResults:   map[string]*contracts.PluginResult{res.PluginID: &frame},
+					LastPlugin:      res.PluginID,
+					AssociationID:   associationID,
+					MessageID:       messageID,
+					NPlugins:        nPlugins,
+					DocumentName:    documentName,
+					DocumentVersion: documentVersion,
+					OutputFrame:     res.OutputFrame,
+				}
+				continue
+			}
 			results[res.PluginID] = &res
 			//TODO decompose this function to return only Status
 			status, _, _, _ := contracts.DocumentResultAggregator(context.Log(), res.PluginID, results)
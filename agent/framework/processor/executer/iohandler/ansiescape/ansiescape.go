@@ -0,0 +1,113 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ansiescape removes the ANSI escape sequences, such as the color and cursor codes, from the plugin output.
+package ansiescape
+
+const (
+	escape = 0x1B
+	bell   = 0x07
+
+	// maxSequenceLength is the length above which an unterminated sequence is considered to be text again
+	maxSequenceLength = 1024
+)
+
+type state int
+
+const (
+	stateText state = iota
+	// stateEscape follows an escape byte
+	stateEscape
+	// stateEscapeIntermediate follows the intermediate bytes of an escape sequence, e.g. ESC ( B
+	stateEscapeIntermediate
+	// stateCSI is a control sequence, e.g. ESC [ 1 ; 31 m
+	stateCSI
+	// stateString is an operating system command or a device control string, e.g. ESC ] 0 ; title BEL
+	stateString
+	// stateStringEscape follows an escape byte in a string, ESC \ terminates the string
+	stateStringEscape
+)
+
+// Stripper removes the ANSI escape sequences from a stream of output, the sequences may be split between writes.
+type Stripper struct {
+	state          state
+	sequenceLength int
+}
+
+// NewStripper returns a new Stripper
+func NewStripper() *Stripper {
+	return &Stripper{}
+}
+
+// Strip returns the output without the escape sequences
+func (s *Stripper) Strip(output []byte) []byte {
+	stripped := make([]byte, 0, len(output))
+	for _, b := range output {
+		if s.state != stateText {
+			s.sequenceLength++
+			if s.sequenceLength > maxSequenceLength {
+				s.state = stateText
+			}
+		}
+
+		switch s.state {
+		case stateText:
+			if b == escape {
+				s.state = stateEscape
+				s.sequenceLength = 0
+			} else {
+				stripped = append(stripped, b)
+			}
+		case stateEscape:
+			switch {
+			case b == '[':
+				s.state = stateCSI
+			case b == ']' || b == 'P' || b == 'X' || b == '^' || b == '_':
+				s.state = stateString
+			case b >= 0x20 && b <= 0x2F:
+				s.state = stateEscapeIntermediate
+			case b == escape:
+				// a new sequence starts
+			default:
+				// two bytes sequence, e.g. ESC 7 saving the cursor position
+				s.state = stateText
+			}
+		case stateEscapeIntermediate:
+			if b < 0x20 || b > 0x2F {
+				s.state = stateText
+			}
+		case stateCSI:
+			// the parameter and intermediate bytes are followed by a final byte between @ and ~
+			if b >= 0x40 && b <= 0x7E {
+				s.state = stateText
+			} else if b < 0x20 || b > 0x3F {
+				// not a control sequence, the byte is kept
+				s.state = stateText
+				stripped = append(stripped, b)
+			}
+		case stateString:
+			if b == bell {
+				s.state = stateText
+			} else if b == escape {
+				s.state = stateStringEscape
+			}
+		case stateStringEscape:
+			if b == '\\' {
+				s.state = stateText
+			} else if b != escape {
+				s.state = stateString
+			}
+		}
+	}
+	return stripped
+}
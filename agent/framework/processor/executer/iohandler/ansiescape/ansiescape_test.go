@@ -0,0 +1,60 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ansiescape
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stripTest struct {
+	name     string
+	chunks   []string
+	expected string
+}
+
+var stripTests = []stripTest{
+	{"PlainText", []string{"hello\r\nworld"}, "hello\r\nworld"},
+	{"Colors", []string{"\x1b[1;31mred\x1b[0m text"}, "red text"},
+	{"CursorMovement", []string{"50%\x1b[2K\x1b[1G100%"}, "50%100%"},
+	{"PrivateMode", []string{"\x1b[?25lhidden cursor\x1b[?25h"}, "hidden cursor"},
+	{"WindowTitle", []string{"\x1b]0;title\x07text"}, "text"},
+	{"StringTerminator", []string{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"}, "link"},
+	{"CharacterSet", []string{"\x1b(Bline\x1b7\x1b8"}, "line"},
+	{"SplitSequence", []string{"\x1b", "[3", "2mgreen\x1b[", "0m"}, "green"},
+	{"InvalidControlSequence", []string{"\x1b[1\nnext"}, "\nnext"},
+	{"UTF8", []string{"\x1b[33mGrüße\x1b[0m"}, "Grüße"},
+}
+
+func TestStrip(t *testing.T) {
+	for _, test := range stripTests {
+		t.Run(test.name, func(t *testing.T) {
+			stripper := NewStripper()
+			stripped := ""
+			for _, chunk := range test.chunks {
+				stripped += string(stripper.Strip([]byte(chunk)))
+			}
+			assert.Equal(t, test.expected, stripped)
+		})
+	}
+}
+
+func TestStripUnterminatedSequence(t *testing.T) {
+	stripper := NewStripper()
+	// the escape byte is followed by maxSequenceLength bytes
+	unterminated := "\x1b]0;" + string(make([]byte, maxSequenceLength-3))
+	assert.Empty(t, stripper.Strip([]byte(unterminated)))
+	assert.Equal(t, "text", string(stripper.Strip([]byte("text"))))
+}
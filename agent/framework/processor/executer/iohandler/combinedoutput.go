@@ -0,0 +1,76 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iohandler
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter"
+)
+
+// combinedTimeFormat is the format of the time prefixing each line of the combined output
+const combinedTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+var now = time.Now
+
+// combinedOutput interleaves the stdout and stderr of the plugin in the order they are written to,
+// each line is prefixed with the time it was written at and its stream
+type combinedOutput struct {
+	lock   sync.Mutex
+	writer multiwriter.DocumentIOMultiWriter
+	// stream is the stream of the last line written
+	stream string
+	// lineStarted is true when the last line written is not terminated
+	lineStarted bool
+}
+
+func newCombinedOutput(writer multiwriter.DocumentIOMultiWriter) *combinedOutput {
+	return &combinedOutput{writer: writer}
+}
+
+// write writes the output of the given stream, a line of the other stream which is not terminated yet is
+// terminated first so that the lines of both streams are never mixed
+func (c *combinedOutput) write(stream string, output []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var buffer bytes.Buffer
+	for len(output) > 0 {
+		if c.lineStarted && c.stream != stream {
+			buffer.WriteByte('\n')
+			c.lineStarted = false
+		}
+		if !c.lineStarted {
+			buffer.WriteString(now().UTC().Format(combinedTimeFormat) + " [" + stream + "] ")
+			c.stream = stream
+			c.lineStarted = true
+		}
+		line := output
+		if i := bytes.IndexByte(output, '\n'); i >= 0 {
+			line = output[:i+1]
+			c.lineStarted = false
+		}
+		buffer.Write(line)
+		output = output[len(line):]
+	}
+	if buffer.Len() > 0 {
+		c.writer.Write(buffer.Bytes())
+	}
+}
+
+// Close closes the combined output writer
+func (c *combinedOutput) Close() error {
+	return c.writer.Close()
+}
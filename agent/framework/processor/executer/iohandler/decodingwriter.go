@@ -0,0 +1,97 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iohandler
+
+import (
+	"io"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/ansiescape"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/outputencoding"
+)
+
+// decodingWriter converts the output the plugin processes write to UTF-8 before writing it to the multi-writer,
+// the ANSI escape codes are removed from the output when a stripper is set
+type decodingWriter struct {
+	multiwriter.DocumentIOMultiWriter
+	lock     sync.Mutex
+	decoder  *outputencoding.Decoder
+	stripper *ansiescape.Stripper
+	// raw receives the decoded output with its escape codes
+	raw io.WriteCloser
+	// combined receives the output along with the output of the other stream when set
+	combined *combinedOutput
+	stream   string
+}
+
+func newDecodingWriter(multiWriter multiwriter.DocumentIOMultiWriter, codePage int, stream string) *decodingWriter {
+	return &decodingWriter{
+		DocumentIOMultiWriter: multiWriter,
+		decoder:               outputencoding.NewDecoder(codePage),
+		stream:                stream,
+	}
+}
+
+// Write converts the output to UTF-8 and writes it to the multi-writer
+func (w *decodingWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if err := w.write(w.decoder.Decode(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// write strips the decoded output when required and writes it to the multi-writer
+func (w *decodingWriter) write(decoded []byte) error {
+	if len(decoded) == 0 {
+		return nil
+	}
+	if w.raw != nil {
+		w.raw.Write(decoded)
+	}
+	if w.stripper != nil {
+		if decoded = w.stripper.Strip(decoded); len(decoded) == 0 {
+			return nil
+		}
+	}
+	if w.combined != nil {
+		w.combined.write(w.stream, decoded)
+	}
+	_, err := w.DocumentIOMultiWriter.Write(decoded)
+	return err
+}
+
+// WriteString converts the output to UTF-8 and writes it to the multi-writer
+func (w *decodingWriter) WriteString(message string) (int, error) {
+	return w.Write([]byte(message))
+}
+
+// Flush writes the output kept by the decoder to the multi-writer and closes the raw output
+func (w *decodingWriter) Flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.write(w.decoder.Flush())
+	if w.raw != nil {
+		w.raw.Close()
+		w.raw = nil
+	}
+}
+
+// Close flushes the decoder and closes the multi-writer
+func (w *decodingWriter) Close() error {
+	w.Flush()
+	return w.DocumentIOMultiWriter.Close()
+}
@@ -18,12 +18,16 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"runtime/debug"
 
 	"github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher"
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/ansiescape"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter"
 )
@@ -45,6 +49,9 @@ type PluginConfig struct {
 	StderrFileName        string
 	StdoutConsoleFileName string
 	StderrConsoleFileName string
+	StdoutRawFileName     string
+	StderrRawFileName     string
+	CombinedFileName      string
 	MaxStdoutLength       int
 	MaxStderrLength       int
 	OutputTruncatedSuffix string
@@ -57,6 +64,9 @@ func DefaultOutputConfig() PluginConfig {
 		StderrFileName:        "stderr",
 		StdoutConsoleFileName: "stdoutConsole",
 		StderrConsoleFileName: "stderrConsole",
+		StdoutRawFileName:     "stdoutRaw",
+		StderrRawFileName:     "stderrRaw",
+		CombinedFileName:      "combined",
 		MaxStdoutLength:       24000,
 		MaxStderrLength:       8000,
 		OutputTruncatedSuffix: "--output truncated--",
@@ -112,11 +122,26 @@ type DefaultIOHandler struct {
 	// List of Writers attached to the IOHandler instance
 	StdoutWriter multiwriter.DocumentIOMultiWriter
 	StderrWriter multiwriter.DocumentIOMultiWriter
+
+	// the plugin processes write through these writers which convert their output to UTF-8
+	stdoutDecoder *decodingWriter
+	stderrDecoder *decodingWriter
+
+	// combined interleaves stdout and stderr when the document asks for a combined output
+	combined *combinedOutput
+
+	// outputSink receives the output while the plugin runs when the document streams its output
+	outputSink iomodule.OutputSink
+
+	// errorCategory and errorCode classify the first error the plugin failed with
+	errorCategory contracts.ErrorCategory
+	errorCode     string
 }
 
 // NewDefaultIOHandler returns a new instance of the IOHandler
 func NewDefaultIOHandler(context context.T, ioConfig contracts.IOConfiguration) *DefaultIOHandler {
 
+	context = withCorrelationLogContext(context, ioConfig.CorrelationID)
 	context.Log().Debugf("IOHandler Initialization with config: %v", ioConfig)
 	out := new(DefaultIOHandler)
 	out.context = context
@@ -125,6 +150,21 @@ func NewDefaultIOHandler(context context.T, ioConfig contracts.IOConfiguration)
 	return out
 }
 
+// withCorrelationLogContext adds the log context of the correlation id to the context, unless the context
+// already carries it, so the output modules log with the correlation id of the document
+func withCorrelationLogContext(context context.T, correlationID string) context.T {
+	logContext := contracts.CorrelationLogContext(correlationID)
+	if logContext == "" {
+		return context
+	}
+	for _, current := range context.CurrentContext() {
+		if current == logContext {
+			return context
+		}
+	}
+	return context.With(logContext)
+}
+
 // Init initializes the plugin output object by creating the necessary writers
 func (out *DefaultIOHandler) Init(filePath ...string) {
 	log := out.context.Log()
@@ -170,7 +210,7 @@ func (out *DefaultIOHandler) Init(filePath ...string) {
 	log.Debug("Initializing the Stdout Multi-writer with file and console listeners")
 	// Get a multi-writer for standard output
 	out.StdoutWriter = multiwriter.NewDocumentIOMultiWriter()
-	out.RegisterOutputSource(out.StdoutWriter, stdoutFile, stdoutConsole)
+	out.RegisterOutputSource(out.StdoutWriter, append([]iomodule.IOModule{stdoutFile, stdoutConsole}, out.outputStreams(iomodule.StdoutStream)...)...)
 
 	// Initialize file error module
 	stderrFile := iomodule.File{
@@ -192,7 +232,66 @@ func (out *DefaultIOHandler) Init(filePath ...string) {
 	log.Debug("Initializing the Stderr Multi-writer with file and console listeners")
 	// Get a multi-writer for standard error
 	out.StderrWriter = multiwriter.NewDocumentIOMultiWriter()
-	out.RegisterOutputSource(out.StderrWriter, stderrFile, stderrConsole)
+	out.RegisterOutputSource(out.StderrWriter, append([]iomodule.IOModule{stderrFile, stderrConsole}, out.outputStreams(iomodule.StderrStream)...)...)
+
+	codePage := out.context.AppConfig().Ssm.OutputSourceCodePage
+	out.stdoutDecoder = newDecodingWriter(out.StdoutWriter, codePage, iomodule.StdoutStream)
+	out.stderrDecoder = newDecodingWriter(out.StderrWriter, codePage, iomodule.StderrStream)
+	if out.ioConfig.CombinedOutput {
+		// Initialize file combined output module, it is uploaded to s3 along with stdout and stderr
+		combinedFile := iomodule.File{
+			FileName:               pluginConfig.CombinedFileName,
+			OrchestrationDirectory: fullPath,
+			OutputS3BucketName:     out.ioConfig.OutputS3BucketName,
+			OutputS3KeyPrefix:      s3KeyPrefix,
+		}
+
+		log.Debug("Initializing the combined output Multi-writer with file listener")
+		combinedWriter := multiwriter.NewDocumentIOMultiWriter()
+		out.RegisterOutputSource(combinedWriter, combinedFile)
+		out.combined = newCombinedOutput(combinedWriter)
+		out.stdoutDecoder.combined = out.combined
+		out.stderrDecoder.combined = out.combined
+	}
+	if out.ioConfig.StripAnsiEscapeCodes {
+		out.stripAnsiEscapeCodes(out.stdoutDecoder, fullPath, pluginConfig.StdoutRawFileName)
+		out.stripAnsiEscapeCodes(out.stderrDecoder, fullPath, pluginConfig.StderrRawFileName)
+	}
+}
+
+// stripAnsiEscapeCodes removes the escape codes from the output written to the decoder, the output with its
+// escape codes is kept in the given file of the orchestration directory when the document asks for it
+func (out *DefaultIOHandler) stripAnsiEscapeCodes(decoder *decodingWriter, orchestrationDirectory string, rawFileName string) {
+	log := out.context.Log()
+	decoder.stripper = ansiescape.NewStripper()
+	if !out.ioConfig.KeepRawOutput {
+		return
+	}
+	if err := fileutil.MakeDirs(orchestrationDirectory); err != nil {
+		log.Errorf("failed to create orchestrationDir directory at %v: %v", orchestrationDirectory, err)
+		return
+	}
+	filePath := filepath.Join(orchestrationDirectory, rawFileName)
+	rawFile, err := os.OpenFile(filePath, appconfig.FileFlagsCreateOrAppend, appconfig.ReadWriteAccess)
+	if err != nil {
+		log.Errorf("Failed to open the file at %v: %v", filePath, err)
+		return
+	}
+	decoder.raw = rawFile
+}
+
+// SetOutputSink sets the sink receiving the output while the plugin runs, it is only used when the
+// document streams its output and must be set before Init
+func (out *DefaultIOHandler) SetOutputSink(sink iomodule.OutputSink) {
+	out.outputSink = sink
+}
+
+// outputStreams returns the module forwarding the given stream to the output sink, if any
+func (out *DefaultIOHandler) outputStreams(stream string) []iomodule.IOModule {
+	if !out.ioConfig.StreamOutput || out.outputSink == nil {
+		return nil
+	}
+	return []iomodule.IOModule{iomodule.OutputStream{Stream: stream, Sink: out.outputSink}}
 }
 
 // RegisterOutputSource returns a new output source by creating a multiwriter for the output modules.
@@ -228,6 +327,12 @@ func (out *DefaultIOHandler) RegisterOutputSource(multiWriter multiwriter.Docume
 func (out *DefaultIOHandler) Close() {
 	log := out.context.Log()
 	log.Debug("IOHandler closing all subscribed writers.")
+	if out.stdoutDecoder != nil {
+		out.stdoutDecoder.Flush()
+	}
+	if out.stderrDecoder != nil {
+		out.stderrDecoder.Flush()
+	}
 	if out.StdoutWriter != nil {
 		out.StdoutWriter.Close()
 	}
@@ -235,6 +340,10 @@ func (out *DefaultIOHandler) Close() {
 	if out.StderrWriter != nil {
 		out.StderrWriter.Close()
 	}
+
+	if out.combined != nil {
+		out.combined.Close()
+	}
 }
 
 // String returns the output by concatenating stdout and stderr
@@ -281,13 +390,19 @@ func (out DefaultIOHandler) GetIOConfig() contracts.IOConfiguration {
 	return out.ioConfig
 }
 
-// GetStdoutWriter returns the stdout writer
+// GetStdoutWriter returns the stdout writer, the output written to it is converted to UTF-8
 func (out DefaultIOHandler) GetStdoutWriter() multiwriter.DocumentIOMultiWriter {
+	if out.stdoutDecoder != nil {
+		return out.stdoutDecoder
+	}
 	return out.StdoutWriter
 }
 
-// GetStderrWriter returns the stderr writer
+// GetStderrWriter returns the stderr writer, the output written to it is converted to UTF-8
 func (out DefaultIOHandler) GetStderrWriter() multiwriter.DocumentIOMultiWriter {
+	if out.stderrDecoder != nil {
+		return out.stderrDecoder
+	}
 	return out.StderrWriter
 }
 
@@ -338,6 +453,9 @@ func (out *DefaultIOHandler) Merge(mergeOutput *DefaultIOHandler) {
 	if out.ExitCode == 0 {
 		out.ExitCode = mergeOutput.GetExitCode()
 	}
+	if out.errorCategory == "" {
+		out.errorCategory, out.errorCode = mergeOutput.GetError()
+	}
 	out.Status = contracts.MergeResultStatus(out.Status, mergeOutput.GetStatus())
 }
 
@@ -349,10 +467,18 @@ func (out *DefaultIOHandler) MarkAsFailed(err error) {
 	}
 	out.Status = contracts.ResultStatusFailed
 	if err != nil {
+		if out.errorCategory == "" {
+			out.errorCategory, out.errorCode = contracts.CategorizeError(err)
+		}
 		out.AppendError(err.Error())
 	}
 }
 
+// GetError returns the category and code of the first error the plugin failed with
+func (out *DefaultIOHandler) GetError() (contracts.ErrorCategory, string) {
+	return out.errorCategory, out.errorCode
+}
+
 // MarkAsSucceeded marks plugin as Successful.
 func (out *DefaultIOHandler) MarkAsSucceeded() {
 	out.ExitCode = 0
@@ -15,12 +15,16 @@ package iohandler
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
 	iomodulemock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule/mock"
 	multiwritermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter/mock"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
@@ -77,6 +81,20 @@ func TestTruncateOutput(t *testing.T) {
 
 var logger = log.NewMockLog()
 
+func TestNewDefaultIOHandlerAddsCorrelationLogContext(t *testing.T) {
+	ctx := context.NewMockDefault()
+	NewDefaultIOHandler(ctx, contracts.IOConfiguration{CorrelationID: "abc-123"})
+	ctx.AssertCalled(t, "With", "[cid:abc-123]")
+}
+
+func TestNewDefaultIOHandlerKeepsExistingCorrelationLogContext(t *testing.T) {
+	ctx := new(context.Mock)
+	ctx.On("Log").Return(log.NewMockLog())
+	ctx.On("CurrentContext").Return([]string{"[cid:abc-123]"})
+	NewDefaultIOHandler(ctx, contracts.IOConfiguration{CorrelationID: "abc-123"})
+	ctx.AssertNotCalled(t, "With", mock.Anything)
+}
+
 func TestRegisterOutputSource(t *testing.T) {
 	mockDocumentIOMultiWriter := new(multiwritermock.MockDocumentIOMultiWriter)
 	mockContext := context.NewMockDefault()
@@ -102,6 +120,104 @@ func TestRegisterOutputSource(t *testing.T) {
 	time.Sleep(250 * time.Millisecond)
 }
 
+func TestInitWithOutputSink(t *testing.T) {
+	for _, streamOutput := range []bool{true, false} {
+		orchestrationDir, err := ioutil.TempDir("", "iohandler")
+		assert.NoError(t, err)
+		defer os.RemoveAll(orchestrationDir)
+
+		var lock sync.Mutex
+		streamed := make(map[string]string)
+		output := NewDefaultIOHandler(context.NewMockDefault(), contracts.IOConfiguration{
+			OrchestrationDirectory: orchestrationDir,
+			StreamOutput:           streamOutput,
+		})
+		output.SetOutputSink(func(stream string, chunk []byte) {
+			lock.Lock()
+			defer lock.Unlock()
+			streamed[stream] += string(chunk)
+		})
+
+		output.Init("plugin")
+		output.GetStdoutWriter().WriteString("sample output")
+		output.GetStderrWriter().WriteString("sample error")
+		output.Close()
+
+		assert.Equal(t, "sample output", output.GetStdout())
+		assert.Equal(t, "sample error", output.GetStderr())
+		if streamOutput {
+			assert.Equal(t, map[string]string{iomodule.StdoutStream: "sample output", iomodule.StderrStream: "sample error"}, streamed)
+		} else {
+			assert.Empty(t, streamed)
+		}
+	}
+}
+
+func TestInitConvertsOutputToUTF8(t *testing.T) {
+	orchestrationDir, err := ioutil.TempDir("", "iohandler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(orchestrationDir)
+
+	output := NewDefaultIOHandler(context.NewMockDefault(), contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+	output.Init("plugin")
+	// "Grüße" in UTF-16LE with a byte order mark, written in two chunks
+	output.GetStdoutWriter().Write([]byte{0xFF, 0xFE, 'G', 0, 'r', 0, 0xFC})
+	output.GetStdoutWriter().Write([]byte{0, 0xDF, 0, 'e', 0})
+	output.GetStderrWriter().Write([]byte("\xEF\xBB\xBFerror"))
+	output.Close()
+
+	assert.Equal(t, "Grüße", output.GetStdout())
+	assert.Equal(t, "error", output.GetStderr())
+}
+
+func TestInitStripsAnsiEscapeCodes(t *testing.T) {
+	orchestrationDir, err := ioutil.TempDir("", "iohandler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(orchestrationDir)
+
+	ioConfig := contracts.IOConfiguration{
+		OrchestrationDirectory: orchestrationDir,
+		StripAnsiEscapeCodes:   true,
+		KeepRawOutput:          true,
+	}
+	output := NewDefaultIOHandler(context.NewMockDefault(), ioConfig)
+	output.Init("plugin")
+	output.GetStdoutWriter().Write([]byte("\x1b[1;3"))
+	output.GetStdoutWriter().Write([]byte("2mok\x1b[0m\n"))
+	output.GetStderrWriter().Write([]byte("\x1b[31merror\x1b[0m"))
+	output.Close()
+
+	assert.Equal(t, "ok\n", output.GetStdout())
+	assert.Equal(t, "error", output.GetStderr())
+	raw, err := ioutil.ReadFile(filepath.Join(orchestrationDir, "plugin", "stdoutRaw"))
+	assert.NoError(t, err)
+	assert.Equal(t, "\x1b[1;32mok\x1b[0m\n", string(raw))
+}
+
+func TestInitCombinedOutput(t *testing.T) {
+	orchestrationDir, err := ioutil.TempDir("", "iohandler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(orchestrationDir)
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2022, 1, 2, 3, 4, 5, 6000000, time.UTC) }
+
+	output := NewDefaultIOHandler(context.NewMockDefault(), contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir, CombinedOutput: true})
+	output.Init("plugin")
+	output.GetStdoutWriter().Write([]byte("first\nsecond"))
+	output.GetStderrWriter().Write([]byte("error\n"))
+	output.GetStdoutWriter().Write([]byte("third\n"))
+	output.Close()
+
+	assert.Equal(t, "first\nsecondthird\n", output.GetStdout())
+	assert.Equal(t, "error\n", output.GetStderr())
+	combined, err := ioutil.ReadFile(filepath.Join(orchestrationDir, "plugin", "combined"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-01-02T03:04:05.006Z [stdout] first\n"+
+		"2022-01-02T03:04:05.006Z [stdout] second\n"+
+		"2022-01-02T03:04:05.006Z [stderr] error\n"+
+		"2022-01-02T03:04:05.006Z [stdout] third\n", string(combined))
+}
+
 func TestSucceeded(t *testing.T) {
 	output := DefaultIOHandler{}
 
@@ -123,6 +239,26 @@ func TestFailed(t *testing.T) {
 	assert.Contains(t, output.GetStderr(), "Error message")
 	assert.False(t, output.Status.IsSuccess())
 	assert.False(t, output.Status.IsReboot())
+	category, code := output.GetError()
+	assert.Equal(t, contracts.ErrorCategoryPluginInternal, category)
+	assert.Equal(t, contracts.ErrorCodePluginFailure, code)
+}
+
+func TestFailedKeepsTheFirstErrorCategory(t *testing.T) {
+	output := DefaultIOHandler{}
+
+	output.MarkAsFailed(contracts.NewCategorizedError(contracts.ErrorCategoryValidation, "InvalidCommands", fmt.Errorf("no commands")))
+	output.MarkAsFailed(fmt.Errorf("Error message"))
+
+	category, code := output.GetError()
+	assert.Equal(t, contracts.ErrorCategoryValidation, category)
+	assert.Equal(t, "InvalidCommands", code)
+
+	merged := DefaultIOHandler{}
+	merged.Merge(&output)
+	category, code = merged.GetError()
+	assert.Equal(t, contracts.ErrorCategoryValidation, category)
+	assert.Equal(t, "InvalidCommands", code)
 }
 
 func TestMarkAsInProgress(t *testing.T) {
@@ -0,0 +1,60 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iomodule
+
+import (
+	"io"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+)
+
+const (
+	// StdoutStream names the standard output of a plugin
+	StdoutStream = "stdout"
+	// StderrStream names the standard error of a plugin
+	StderrStream = "stderr"
+
+	// outputStreamBufferSize is the maximum number of bytes read from the pipe at once
+	outputStreamBufferSize = 4096
+)
+
+// OutputSink receives the output of a plugin as it is written, it must not block.
+type OutputSink func(stream string, chunk []byte)
+
+// OutputStream handles forwarding output to a sink while the plugin runs.
+type OutputStream struct {
+	Stream string
+	Sink   OutputSink
+}
+
+func (o OutputStream) Read(context context.T, reader *io.PipeReader, exitCode int) {
+	log := context.Log()
+	defer func() { reader.Close() }()
+
+	buffer := make([]byte, outputStreamBufferSize)
+	for {
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			chunk := make([]byte, n)
+			copy(chunk, buffer[:n])
+			o.Sink(o.Stream, chunk)
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Errorf("Error while reading the %v stream: %v", o.Stream, err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package iomodule
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOutputStream tests the OutputStream module forwards everything written to the sink
+func TestOutputStream(t *testing.T) {
+	context := contextmocks.NewMockDefault()
+	var TestInputCases = [...]string{
+		"Test input text.",
+		"A sample \ninput text.",
+		"\b5Ὂg̀9! ℃ᾭG",
+		strings.Repeat("0123456789", 1000),
+	}
+
+	for _, testCase := range TestInputCases {
+		var streamed []byte
+		var streams []string
+		stdoutStream := OutputStream{
+			Stream: StdoutStream,
+			Sink: func(stream string, chunk []byte) {
+				streams = append(streams, stream)
+				streamed = append(streamed, chunk...)
+			},
+		}
+
+		r, w := io.Pipe()
+		wg := new(sync.WaitGroup)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stdoutStream.Read(context, r, appconfig.SuccessExitCode)
+		}()
+
+		w.Write([]byte(testCase))
+		w.Close()
+		wg.Wait()
+
+		assert.Equal(t, testCase, string(streamed))
+		for _, stream := range streams {
+			assert.Equal(t, StdoutStream, stream)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package outputencoding
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	codePageWindows1252 = 1252
+	codePageLatin1      = 28591
+)
+
+// windows1252 maps the bytes 0x80 to 0x9F of the Windows-1252 code page, the other bytes map to the same code point
+// as in Latin-1
+var windows1252 = [32]rune{
+	'€', utf8.RuneError, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', utf8.RuneError, 'Ž', utf8.RuneError,
+	utf8.RuneError, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', utf8.RuneError, 'ž', 'Ÿ',
+}
+
+// decodeCodePage converts the output from the given code page, only Windows-1252 and Latin-1 are supported and
+// there is no default code page
+func decodeCodePage(codePage int, output []byte) (string, error) {
+	if codePage != codePageWindows1252 && codePage != codePageLatin1 {
+		return "", fmt.Errorf("code page %v is not supported", codePage)
+	}
+	var text strings.Builder
+	for _, b := range output {
+		if codePage == codePageWindows1252 && b >= 0x80 && b <= 0x9F {
+			text.WriteRune(windows1252[b-0x80])
+		} else {
+			text.WriteRune(rune(b))
+		}
+	}
+	return text.String(), nil
+}
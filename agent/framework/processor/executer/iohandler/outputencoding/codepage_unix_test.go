@@ -0,0 +1,33 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package outputencoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCodePage(t *testing.T) {
+	// "Grüße 99€" in Windows-1252
+	output := []byte{'G', 'r', 0xFC, 0xDF, 'e', ' ', '9', '9', 0x80}
+	assert.Equal(t, "Grüße 99€", decodeAll(NewDecoder(codePageWindows1252), output, 3))
+	assert.Equal(t, "Grüße 99\u0080", decodeAll(NewDecoder(codePageLatin1), output, 1024))
+
+	// the output is kept with its invalid bytes replaced when the code page is not supported
+	assert.Equal(t, "Gr\uFFFDe 99\uFFFD", decodeAll(NewDecoder(0), output, 1024))
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package outputencoding
+
+import (
+	"unicode/utf16"
+
+	"golang.org/x/sys/windows"
+)
+
+var procGetOEMCP = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetOEMCP")
+
+// decodeCodePage converts the output from the given code page, the console programs write their output with the
+// OEM code page when it is 0
+func decodeCodePage(codePage int, output []byte) (string, error) {
+	if len(output) == 0 {
+		return "", nil
+	}
+	if codePage == 0 {
+		oemCodePage, _, _ := procGetOEMCP.Call()
+		codePage = int(oemCodePage)
+	}
+	n, err := windows.MultiByteToWideChar(uint32(codePage), 0, &output[0], int32(len(output)), nil, 0)
+	if err != nil {
+		return "", err
+	}
+	units := make([]uint16, n)
+	if n, err = windows.MultiByteToWideChar(uint32(codePage), 0, &output[0], int32(len(output)), &units[0], n); err != nil {
+		return "", err
+	}
+	return string(utf16.Decode(units[:n])), nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package outputencoding converts the output of the plugins to UTF-8.
+package outputencoding
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type encodingMode int
+
+const (
+	modeUndetermined encodingMode = iota
+	// modeText is UTF-8, or the source code page for the output that is not valid UTF-8
+	modeText
+	modeUTF16LE
+	modeUTF16BE
+)
+
+const (
+	// sniffLength is the number of bytes needed to detect the encoding of the output
+	sniffLength = 4
+	// maxSniffedUnits is the number of UTF-16 code units looked at by the UTF-16 detection
+	maxSniffedUnits = 128
+	// byteOrderMark is the character UTF-16 output starts with, each process writing to the output may write one
+	byteOrderMark = "\uFEFF"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+
+	// activeCodePage matches the line chcp writes when it switches the code page of the console,
+	// e.g. "Active code page: 65001" or "Aktive Codepage: 850."
+	activeCodePage = regexp.MustCompile(`(?im)^[^\r\n:]{0,40}(?:code ?page|page de codes)[^\r\n:]{0,20}:\s*(\d{3,5})\.?[ \t]*\r?$`)
+)
+
+// Decoder converts a stream of output to UTF-8. The encoding is detected from the start of the stream: UTF-8 or
+// UTF-16 when it starts with a byte order mark, UTF-16 when most of its first characters have a null byte, and
+// UTF-8 otherwise. The parts of the output which are not valid UTF-8 are converted from the source code page.
+type Decoder struct {
+	mode     encodingMode
+	codePage int
+	pending  []byte
+}
+
+// NewDecoder returns a decoder converting the output which is not valid UTF-8 from the given code page,
+// 0 uses the default code page of the platform
+func NewDecoder(codePage int) *Decoder {
+	return &Decoder{codePage: codePage}
+}
+
+// Decode returns the UTF-8 text of the output, the bytes of a character which is not complete yet are kept until
+// the next call
+func (d *Decoder) Decode(output []byte) []byte {
+	d.pending = append(d.pending, output...)
+	if d.mode == modeUndetermined {
+		if len(d.pending) < sniffLength {
+			return nil
+		}
+		d.sniff()
+	}
+	return d.decode(false)
+}
+
+// Flush returns the UTF-8 text of the output kept by the decoder
+func (d *Decoder) Flush() []byte {
+	if len(d.pending) == 0 {
+		return nil
+	}
+	if d.mode == modeUndetermined {
+		d.sniff()
+	}
+	return d.decode(true)
+}
+
+// sniff detects the encoding of the output from its first bytes
+func (d *Decoder) sniff() {
+	switch {
+	case bytes.HasPrefix(d.pending, bomUTF8):
+		d.mode = modeText
+		d.pending = d.pending[len(bomUTF8):]
+	case bytes.HasPrefix(d.pending, bomUTF16LE):
+		d.mode = modeUTF16LE
+		d.pending = d.pending[len(bomUTF16LE):]
+	case bytes.HasPrefix(d.pending, bomUTF16BE):
+		d.mode = modeUTF16BE
+		d.pending = d.pending[len(bomUTF16BE):]
+	default:
+		d.mode = sniffUTF16(d.pending)
+	}
+}
+
+// sniffUTF16 returns the UTF-16 mode when at least 80% of the first characters have a null high byte,
+// UTF-8 and the code pages never write null bytes in text
+func sniffUTF16(output []byte) encodingMode {
+	units := len(output) / 2
+	if units > maxSniffedUnits {
+		units = maxSniffedUnits
+	}
+	if units < 2 {
+		return modeText
+	}
+	littleEndian, bigEndian := 0, 0
+	for i := 0; i < units; i++ {
+		low, high := output[2*i], output[2*i+1]
+		if low != 0 && high == 0 {
+			littleEndian++
+		} else if low == 0 && high != 0 {
+			bigEndian++
+		}
+	}
+	switch {
+	case littleEndian*10 >= units*8:
+		return modeUTF16LE
+	case bigEndian*10 >= units*8:
+		return modeUTF16BE
+	}
+	return modeText
+}
+
+// decode converts the pending output, the last incomplete character is kept unless final is true
+func (d *Decoder) decode(final bool) []byte {
+	if d.mode == modeText {
+		return d.decodeText(final)
+	}
+	return d.decodeUTF16(final)
+}
+
+func (d *Decoder) decodeText(final bool) []byte {
+	complete := len(d.pending)
+	if !final {
+		complete = completeRunesLength(d.pending)
+	}
+	chunk := d.pending[:complete]
+	d.pending = append([]byte(nil), d.pending[complete:]...)
+
+	decoded := chunk
+	if !utf8.Valid(chunk) {
+		if text, err := decodeCodePage(d.codePage, chunk); err == nil {
+			decoded = []byte(text)
+		} else {
+			decoded = []byte(strings.ToValidUTF8(string(chunk), string(utf8.RuneError)))
+		}
+	}
+	// the output that follows a chcp is written with the code page it switched to
+	if matches := activeCodePage.FindAllSubmatch(chunk, -1); len(matches) > 0 {
+		if codePage, err := strconv.Atoi(string(matches[len(matches)-1][1])); err == nil {
+			d.codePage = codePage
+		}
+	}
+	return decoded
+}
+
+func (d *Decoder) decodeUTF16(final bool) []byte {
+	units := make([]uint16, len(d.pending)/2)
+	for i := range units {
+		low, high := d.pending[2*i], d.pending[2*i+1]
+		if d.mode == modeUTF16BE {
+			low, high = high, low
+		}
+		units[i] = uint16(low) | uint16(high)<<8
+	}
+	complete := len(units)
+	// keep the high surrogate until the low surrogate of the pair is there
+	if !final && complete > 0 && utf16.IsSurrogate(rune(units[complete-1])) && units[complete-1] < 0xDC00 {
+		complete--
+	}
+	if final {
+		// an odd byte at the end of the output cannot be decoded
+		d.pending = nil
+	} else {
+		d.pending = append([]byte(nil), d.pending[2*complete:]...)
+	}
+	// each process writing to the output may start with a byte order mark
+	return []byte(strings.Replace(string(utf16.Decode(units[:complete])), byteOrderMark, "", -1))
+}
+
+// completeRunesLength returns the length of the output without its last character if the bytes of this character
+// are not all there
+func completeRunesLength(output []byte) int {
+	for i := len(output) - 1; i >= 0 && i >= len(output)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(output[i]) {
+			if !utf8.FullRune(output[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(output)
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package outputencoding
+
+import (
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func utf16LE(text string, bom bool) (output []byte) {
+	if bom {
+		output = append(output, bomUTF16LE...)
+	}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		output = append(output, byte(unit), byte(unit>>8))
+	}
+	return
+}
+
+func utf16BE(text string) (output []byte) {
+	output = append(output, bomUTF16BE...)
+	for _, unit := range utf16.Encode([]rune(text)) {
+		output = append(output, byte(unit>>8), byte(unit))
+	}
+	return
+}
+
+// decodeAll decodes the output written in chunks of the given size
+func decodeAll(decoder *Decoder, output []byte, chunkSize int) string {
+	var decoded []byte
+	for start := 0; start < len(output); start += chunkSize {
+		end := start + chunkSize
+		if end > len(output) {
+			end = len(output)
+		}
+		decoded = append(decoded, decoder.Decode(output[start:end])...)
+	}
+	return string(append(decoded, decoder.Flush()...))
+}
+
+func TestDecode(t *testing.T) {
+	text := "Grüße, 世界 😀\r\nline 2"
+	testCases := []struct {
+		name   string
+		output []byte
+	}{
+		{"UTF-8", []byte(text)},
+		{"UTF-8 with byte order mark", append(append([]byte(nil), bomUTF8...), text...)},
+		{"UTF-16LE with byte order mark", utf16LE(text, true)},
+		{"UTF-16LE without byte order mark", utf16LE(text, false)},
+		{"UTF-16BE with byte order mark", utf16BE(text)},
+	}
+	for _, testCase := range testCases {
+		for _, chunkSize := range []int{1, 3, 1024} {
+			assert.Equal(t, text, decodeAll(NewDecoder(0), testCase.output, chunkSize), "%v in chunks of %v", testCase.name, chunkSize)
+		}
+	}
+}
+
+func TestDecodeShortOutput(t *testing.T) {
+	decoder := NewDecoder(0)
+	assert.Empty(t, decoder.Decode([]byte("ok")))
+	assert.Equal(t, "ok", string(decoder.Flush()))
+	assert.Empty(t, decoder.Flush())
+}
+
+func TestDecodeUTF16ByteOrderMarkOfEachProcess(t *testing.T) {
+	output := append(utf16LE("first\n", true), utf16LE("second\n", true)...)
+	assert.Equal(t, "first\nsecond\n", decodeAll(NewDecoder(0), output, 5))
+}
+
+func TestSniffUTF16(t *testing.T) {
+	assert.Equal(t, modeUTF16LE, sniffUTF16(utf16LE("plain ascii", false)))
+	assert.Equal(t, modeText, sniffUTF16([]byte("plain ascii")))
+	assert.Equal(t, modeText, sniffUTF16([]byte{'a'}))
+}
+
+func TestActiveCodePage(t *testing.T) {
+	for _, line := range []string{"Active code page: 65001", "Aktive Codepage: 850.", "Page de codes active : 437\r"} {
+		assert.True(t, activeCodePage.MatchString(line), line)
+	}
+	for _, line := range []string{"Homepage: 1234", "The code page is 850 and more: 1"} {
+		assert.False(t, activeCodePage.MatchString(line), line)
+	}
+
+	decoder := NewDecoder(1252)
+	decodeAll(decoder, []byte("Active code page: 28591\r\n"), 1024)
+	assert.Equal(t, 28591, decoder.codePage)
+}
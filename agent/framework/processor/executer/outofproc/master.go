@@ -13,6 +13,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/messaging"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/proc"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/processpriority"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/common/filewatcherbasedipc"
 	"github.com/aws/amazon-ssm-agent/common/identity"
@@ -211,6 +212,12 @@ func (e *OutOfProcExecuter) initialize(stopTimer chan bool) (ipc filewatcherbase
 		} else {
 			log.Debugf("successfully launched new process: %v", process.Pid())
 		}
+		if workerName == appconfig.DefaultDocumentWorker {
+			// session workers keep the agent priority, interactive sessions must stay responsive
+			if priorityErr := processpriority.Apply(process.Pid(), e.ctx.AppConfig().Ssm.ProcessPriority); priorityErr != nil {
+				log.Warnf("unable to set the priority of the document worker: %v", priorityErr)
+			}
+		}
 		e.docState.DocumentInformation.ProcInfo = contracts.OSProcInfo{
 			Pid:       process.Pid(),
 			StartTime: process.StartTime(),
@@ -153,7 +153,11 @@ func (p *WorkerBackend) Process(datagram string) error {
 		log.Debugf("unmarshal plugin config: %+v", docState)
 		p.once.Do(func() {
 			statusChan := make(chan contracts.PluginResult)
-			go p.runner(p.ctx, docState, statusChan, p.cancelFlag)
+			runnerCtx := p.ctx
+			if correlationID := docState.DocumentInformation.CorrelationID; correlationID != "" {
+				runnerCtx = runnerCtx.With(contracts.CorrelationLogContext(correlationID))
+			}
+			go p.runner(runnerCtx, docState, statusChan, p.cancelFlag)
 			go p.pluginListener(statusChan)
 		})
 
@@ -196,6 +200,17 @@ func (p *WorkerBackend) pluginListener(statusChan chan contracts.PluginResult) {
 
 	for res := range statusChan {
 		var result = res
+		if res.OutputFrame != nil {
+			// output frames are forwarded as they are, they do not change the plugin results
+			frameMessage, _ := CreateDatagram(MessageTypeReply, contracts.DocumentResult{
+				Status:        contracts.ResultStatusInProgress,
+				PluginResults: map[string]*contracts.PluginResult{res.PluginID: &result},
+				LastPlugin:    res.PluginID,
+				OutputFrame:   res.OutputFrame,
+			})
+			p.input <- frameMessage
+			continue
+		}
 		results[res.PluginID] = &result
 		//TODO move the aggregator under executer package and protect it, there's global lock in this package
 		status, _, _, _ := contracts.DocumentResultAggregator(log, res.PluginID, results)
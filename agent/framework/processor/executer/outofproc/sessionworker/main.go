@@ -28,6 +28,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/agent/version"
 	"github.com/aws/amazon-ssm-agent/common/filewatcherbasedipc"
+	"github.com/aws/amazon-ssm-agent/common/identity/credentialrotation"
 )
 
 const (
@@ -73,6 +74,7 @@ func main() {
 
 	ctx := context.Default(logger, *cfg, agentIdentity).With(defaultSessionWorkerContextName).With("[" + channelName + "]")
 	logger = ctx.Log()
+	credentialrotation.NewWatcher(logger, agentIdentity.Credentials()).Start()
 
 	createFileChannelAndExecutePlugin(ctx, channelName)
 	logger.Info("Session worker closed")
@@ -15,6 +15,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/agent/version"
 	"github.com/aws/amazon-ssm-agent/common/filewatcherbasedipc"
+	"github.com/aws/amazon-ssm-agent/common/identity/credentialrotation"
 )
 
 const (
@@ -54,6 +55,7 @@ func main() {
 
 	ctx := context.Default(logger, *cfg, agentIdentity).With(defaultWorkerContextName).With("[" + channelName + "]")
 	logger = ctx.Log()
+	credentialrotation.NewWatcher(logger, agentIdentity.Credentials()).Start()
 
 	logger.Infof("document: %v worker started", channelName)
 	//create channel from the given handle identifier by master
@@ -31,7 +31,9 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/refreshassociation"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/rundocument"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/runscript"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/stagepatches"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/updatessmagent"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/forcedcommand"
 	"github.com/aws/amazon-ssm-agent/agent/session/plugins/interactivecommands"
 	"github.com/aws/amazon-ssm-agent/agent/session/plugins/noninteractivecommands"
 	"github.com/aws/amazon-ssm-agent/agent/session/plugins/port"
@@ -59,6 +61,8 @@ var allPlugins = map[string]struct{}{
 	appconfig.PluginNameRefreshAssociation:     {},
 	appconfig.PluginDownloadContent:            {},
 	appconfig.PluginRunDocument:                {},
+	appconfig.PluginNameAwsStagePatches:        {},
+	appconfig.PluginNameAwsInstallMacPackage:   {},
 }
 
 var once sync.Once
@@ -136,6 +140,13 @@ func (r RunDocumentFactory) Create(context context.T) (runpluginutil.T, error) {
 	return rundocument.NewPlugin(context)
 }
 
+type StagePatchesFactory struct {
+}
+
+func (f StagePatchesFactory) Create(context context.T) (runpluginutil.T, error) {
+	return stagepatches.NewPlugin(context)
+}
+
 type SessionPluginFactory struct {
 	newPluginFunc sessionplugin.NewPluginFunc
 }
@@ -206,6 +217,9 @@ func loadSessionPlugins() {
 	nonInteractiveCommandsPluginName := appconfig.PluginNameNonInteractiveCommands
 	sessionPlugins[nonInteractiveCommandsPluginName] = SessionPluginFactory{noninteractivecommands.NewPlugin}
 
+	forcedCommandPluginName := appconfig.PluginNameForcedCommand
+	sessionPlugins[forcedCommandPluginName] = SessionPluginFactory{forcedcommand.NewPlugin}
+
 	registeredPlugins = &sessionPlugins
 }
 
@@ -248,5 +262,9 @@ func loadPlatformIndependentPlugins(context context.T) runpluginutil.PluginRegis
 	runDocumentPluginName := rundocument.Name()
 	workerPlugins[runDocumentPluginName] = RunDocumentFactory{}
 
+	//registering aws:stagePatches
+	stagePatchesPluginName := stagepatches.Name()
+	workerPlugins[stagePatchesPluginName] = StagePatchesFactory{}
+
 	return workerPlugins
 }
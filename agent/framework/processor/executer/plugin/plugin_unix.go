@@ -11,8 +11,8 @@
 // either express or implied. See the License for the specific language governing
 // permissions and limitations under the License.
 //
-//go:build darwin || freebsd || linux || netbsd || openbsd
-// +build darwin freebsd linux netbsd openbsd
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
 
 package plugin
 
@@ -16,7 +16,6 @@ package processor
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"runtime/debug"
 	"sync"
@@ -27,9 +26,11 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/framework/messagemetrics"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/winevent"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/manager"
 	"github.com/aws/amazon-ssm-agent/agent/rebooter"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -209,6 +210,8 @@ func (p *EngineProcessor) InitialProcessing(skipDocumentIfExpired bool) (err err
 	p.processInProgressDocuments(skipDocumentIfExpired)
 	//deal with the pending jobs that have not picked up by worker yet
 	p.processPendingDocuments(pendingFiles)
+	//report the commands whose document state could not be parsed
+	p.reportQuarantinedDocuments()
 	return
 }
 
@@ -297,6 +300,7 @@ func (p *EngineProcessor) submit(docState *contracts.DocumentState, isInProgress
 		if r := recover(); r != nil {
 			errorCode = SubmissionPanic
 			p.cleanUpDocSubmissionOnError(docState) // call this function only after acquiring token successfully
+			messagemetrics.RecordDequeued(jobID)
 			log.Errorf("document %v submission panicked", jobID)
 			log.Errorf("stacktrace:\n%s", debug.Stack())
 		}
@@ -305,7 +309,12 @@ func (p *EngineProcessor) submit(docState *contracts.DocumentState, isInProgress
 		p.documentMgr.PersistDocumentState(docState.DocumentInformation.DocumentID, appconfig.DefaultLocationOfPending, *docState)
 	}
 	//TODO this is a hack, in future jobID should be managed by Processing engine itself, instead of inferring from job's internal field
+	// the document is part of the execution backlog until a worker of the pool starts it
+	messagemetrics.RecordQueued(jobID)
 	err := p.sendCommandPool.Submit(log, jobID, func(cancelFlag task.CancelFlag) {
+		messagemetrics.RecordDequeued(jobID)
+		messagemetrics.RecordExecutionStarted(docState.DocumentType)
+		defer messagemetrics.RecordExecutionEnded(docState.DocumentType)
 		processCommand(
 			p.context,
 			p.executerCreator,
@@ -322,6 +331,7 @@ func (p *EngineProcessor) submit(docState *contracts.DocumentState, isInProgress
 		//   1) When job is in the job queue buffer and not yet processed - This case is not possible as we do not receive commands already in "job queue buffer".
 		//   2) When job is released from job queue buffer and started processing - This case is also not possible as we do not receive commands already in "job store".
 		p.cleanUpDocSubmissionOnError(docState)
+		messagemetrics.RecordDequeued(jobID)
 		log.Error("Document Submission failed: ", err)
 		//move the fail-to-submit document to corrupt folder
 		p.documentMgr.MoveDocumentState(docState.DocumentInformation.DocumentID, appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCorrupt)
@@ -460,14 +470,13 @@ func (p *EngineProcessor) Stop() {
 	p.context.Log().Info("processor closed")
 }
 
-// TODO remove the direct file dependency once we encapsulate docmanager package
-func (p *EngineProcessor) processPendingDocuments(files []os.FileInfo) {
+func (p *EngineProcessor) processPendingDocuments(fileNames []string) {
 	log := p.context.Log()
 	//iterate through all pending messages
-	for _, f := range files {
-		log.Infof("Found pending document - %v", f.Name())
+	for _, fileName := range fileNames {
+		log.Infof("Found pending document - %v", fileName)
 		//inspect document state
-		docState := p.documentMgr.GetDocumentState(f.Name(), appconfig.DefaultLocationOfPending)
+		docState := p.documentMgr.GetDocumentState(fileName, appconfig.DefaultLocationOfPending)
 
 		if p.isSupportedDocumentType(docState.DocumentType) {
 			p.pushPersistedDocToJobPool(docState, appconfig.DefaultLocationOfPending, false)
@@ -475,27 +484,82 @@ func (p *EngineProcessor) processPendingDocuments(files []os.FileInfo) {
 	}
 }
 
-func (p *EngineProcessor) getDocStateFiles(log log.T, docStateDir string) []os.FileInfo {
-	var files []os.FileInfo
+// getDocStateFiles returns the names of the document states in the state folder, in the configured state backend
+func (p *EngineProcessor) getDocStateFiles(log log.T, docStateDir string) []string {
 	instanceID, err := p.context.Identity().ShortInstanceID()
 	if err != nil {
 		log.Errorf("Failed to get short instanceID for process %v Documents: %v", docStateDir, err)
-		return files
+		return nil
 	}
 
 	// process older documents from state folder
-	docsLocation := docmanager.DocumentStateDir(instanceID, docStateDir)
+	fileNames, err := docmanager.DocumentStateNames(log, p.context.AppConfig(), instanceID, docStateDir)
+	if err != nil {
+		log.Errorf("skipping reading %v documents. unexpected error encountered - %v", docStateDir, err)
+	}
+	if len(fileNames) == 0 {
+		log.Debugf("No %v documents to process", docStateDir)
+	}
+	return fileNames
+}
 
-	if isDirectoryEmpty, _ := fileutil.IsDirEmpty(docsLocation); isDirectoryEmpty {
-		log.Debugf("No %v documents to process from %v", docStateDir, docsLocation)
-		return files
+// reportQuarantinedDocuments sends a Failed result for the commands recovered from the document states quarantined
+// because they could not be parsed, so that these commands do not stay in progress in the service
+func (p *EngineProcessor) reportQuarantinedDocuments() {
+	log := p.context.Log()
+	quarantine := p.context.AppConfig().Ssm.DocumentStateQuarantine
+	documentType := p.startWorker.assignedDocType
+	if !quarantine.Enabled || !quarantine.SendFailedResult || documentType == "" || documentType == contracts.StartSession {
+		return
 	}
+	for _, report := range p.documentMgr.ClaimCorruptionReports(documentType) {
+		res, err := newQuarantinedDocumentResult(report)
+		if err != nil {
+			log.Warnf("Unable to report the command of quarantined document state %v: %v", report.FileName, err)
+			continue
+		}
+		log.Infof("Reporting document %v recovered from quarantined document state %v as failed", res.MessageID, report.FileName)
+		p.resChan <- res
+	}
+}
 
-	//get all messages
-	if files, err = fileutil.ReadDir(docsLocation); err != nil {
-		log.Errorf("skipping reading %v documents from %v. unexpected error encountered - %v", docStateDir, docsLocation, err)
+// newQuarantinedDocumentResult returns the Failed result of the command recovered from a quarantined document state
+func newQuarantinedDocumentResult(report docmanager.CorruptionReport) (res contracts.DocumentResult, err error) {
+	recovered := report.Recovered
+	if recovered.MessageID == "" {
+		return res, fmt.Errorf("the message id could not be recovered")
+	}
+	output := fmt.Sprintf("the agent could not read the state of the document, it was quarantined as %v: %v", report.FileName, report.Error)
+	res = contracts.DocumentResult{
+		DocumentName:        recovered.DocumentName,
+		DocumentVersion:     recovered.DocumentVersion,
+		MessageID:           recovered.MessageID,
+		AssociationID:       recovered.AssociationID,
+		PluginResults:       make(map[string]*contracts.PluginResult),
+		Status:              contracts.ResultStatusFailed,
+		UpstreamServiceName: recovered.UpstreamServiceName,
+		RelatedDocumentType: recovered.DocumentType,
+	}
+	for _, plugin := range recovered.Plugins {
+		if plugin.ID == "" {
+			continue
+		}
+		res.PluginResults[plugin.ID] = &contracts.PluginResult{
+			PluginID:      plugin.ID,
+			PluginName:    plugin.Name,
+			Status:        contracts.ResultStatusFailed,
+			Code:          1,
+			Output:        output,
+			StandardError: output,
+			StartDateTime: report.QuarantinedAt,
+			EndDateTime:   report.QuarantinedAt,
+		}
+	}
+	if len(res.PluginResults) == 0 {
+		return res, fmt.Errorf("no plugin of message %v could be recovered", recovered.MessageID)
 	}
-	return files
+	res.NPlugins = len(res.PluginResults)
+	return res, nil
 }
 
 // ProcessInProgressDocuments processes InProgress documents that have already dequeued and entered job pool
@@ -505,16 +569,16 @@ func (p *EngineProcessor) processInProgressDocuments(skipDocumentIfExpired bool)
 	files := p.getDocStateFiles(log, appconfig.DefaultLocationOfCurrent)
 
 	//iterate through all InProgress docs
-	for _, f := range files {
-		log.Infof("Found in-progress document - %v", f.Name())
+	for _, fileName := range files {
+		log.Infof("Found in-progress document - %v", fileName)
 
 		//inspect document state
-		docState := p.documentMgr.GetDocumentState(f.Name(), appconfig.DefaultLocationOfCurrent)
+		docState := p.documentMgr.GetDocumentState(fileName, appconfig.DefaultLocationOfCurrent)
 
 		if p.isSupportedDocumentType(docState.DocumentType) {
 			retryLimit := config.Mds.CommandRetryLimit
 			if docState.DocumentInformation.RunCount >= retryLimit {
-				p.documentMgr.MoveDocumentState(f.Name(), appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
+				p.documentMgr.MoveDocumentState(fileName, appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
 				continue
 			}
 
@@ -530,7 +594,7 @@ func (p *EngineProcessor) processInProgressDocuments(skipDocumentIfExpired bool)
 				// Do not resume in-progress document is create date is 48 hours ago.
 				if createDate.Add(maxDocumentTimeOutHour).Before(time.Now().UTC()) {
 					log.Infof("Document %v expired %v, skipping", docState.DocumentInformation.DocumentID, docState.DocumentInformation.CreatedDate)
-					p.documentMgr.MoveDocumentState(f.Name(), appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
+					p.documentMgr.MoveDocumentState(fileName, appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
 					continue
 				}
 			}
@@ -583,6 +647,11 @@ func (p *EngineProcessor) isSupportedDocumentType(documentType contracts.Documen
 }
 
 func processCommand(context context.T, executerCreator ExecuterCreator, cancelFlag task.CancelFlag, resChan chan contracts.DocumentResult, docState *contracts.DocumentState, docMgr docmanager.DocumentMgr) {
+	correlationID := docState.DocumentInformation.CorrelationID
+	if correlationID != "" {
+		context = context.With(contracts.CorrelationLogContext(correlationID))
+		docState.IOConfig.CorrelationID = correlationID
+	}
 	log := context.Log()
 	//persist the current running document
 	docMgr.MoveDocumentState(
@@ -590,6 +659,8 @@ func processCommand(context context.T, executerCreator ExecuterCreator, cancelFl
 		appconfig.DefaultLocationOfPending,
 		appconfig.DefaultLocationOfCurrent)
 	log.Debug("Running executer...")
+	// a document resumed after a reboot is timed from its resumption
+	startTime := time.Now()
 	documentID := docState.DocumentInformation.DocumentID
 	messageID := docState.DocumentInformation.MessageID
 	e := executerCreator(context)
@@ -609,20 +680,26 @@ func processCommand(context context.T, executerCreator ExecuterCreator, cancelFl
 				}
 			}()
 
-			if res.LastPlugin == "" {
+			if res.OutputFrame != nil {
+				log.Debugf("sending output frame %v for plugin: %v", res.OutputFrame.Sequence, res.LastPlugin)
+			} else if res.LastPlugin == "" {
 				log.Infof("sending document: %v complete response", documentID)
 			} else {
 				log.Infof("sending reply for plugin update: %v", res.LastPlugin)
 			}
 
-			final = &res
-			handleCloudwatchPlugin(context, res.PluginResults, documentID)
+			if res.OutputFrame == nil {
+				final = &res
+				handleCloudwatchPlugin(context, res.PluginResults, documentID)
+			}
 			// when receiving the reply from workers, we do not have UpstreamServiceName populated
 			// whenever we receive a response, we populate with the appropriate Upstream service
 			// this is added to avoid changes in the workers
 			res.UpstreamServiceName = docState.UpstreamServiceName
 			// used to add topic to the payload in agent reply message in MGS interactor
 			res.RelatedDocumentType = docState.DocumentType
+			// used to correlate the reply with the received message
+			res.CorrelationID = correlationID
 			//hand off the message to Service
 			resChan <- res
 
@@ -647,6 +724,43 @@ func processCommand(context context.T, executerCreator ExecuterCreator, cancelFl
 		documentID,
 		appconfig.DefaultLocationOfCurrent)
 
+	if docState.DocumentType != contracts.StartSession {
+		writeCommandExecutedEvent(log, docState, final.Status)
+		messagemetrics.RecordDocumentRun(log, docState.DocumentInformation.DocumentName, docState.DocumentInformation.CommandID, final.Status, time.Since(startTime))
+		docMgr.RecordExecution(newExecutionRecord(docState, final.Status, startTime, time.Now()))
+	}
+}
+
+// newExecutionRecord returns the execution history record of a completed document
+func newExecutionRecord(docState *contracts.DocumentState, status contracts.ResultStatus, startTime, endTime time.Time) docmanager.ExecutionRecord {
+	return docmanager.ExecutionRecord{
+		ExecutionID:    docState.DocumentInformation.DocumentID,
+		CommandID:      docState.DocumentInformation.CommandID,
+		DocumentName:   docState.DocumentInformation.DocumentName,
+		DocumentType:   docState.DocumentType,
+		AssociationID:  docState.DocumentInformation.AssociationID,
+		Status:         status,
+		StartDateTime:  startTime.UTC(),
+		EndDateTime:    endTime.UTC(),
+		DurationMillis: int64(endTime.Sub(startTime) / time.Millisecond),
+	}
+}
+
+// writeCommandExecutedEvent writes the completion of a command document to the operational event log
+func writeCommandExecutedEvent(log log.T, docState *contracts.DocumentState, status contracts.ResultStatus) {
+	fields := winevent.Fields{
+		"documentType":  string(docState.DocumentType),
+		"documentName":  docState.DocumentInformation.DocumentName,
+		"commandId":     docState.DocumentInformation.CommandID,
+		"associationId": docState.DocumentInformation.AssociationID,
+		"status":        string(status),
+		"correlationId": docState.DocumentInformation.CorrelationID,
+	}
+	if status.IsSuccess() {
+		winevent.Write(log, winevent.CommandExecuted, fields)
+	} else {
+		winevent.WriteWarning(log, winevent.CommandExecuted, fields)
+	}
 }
 
 // TODO CancelCommand is currently treated as a special type of Command by the Processor, but in general Cancel operation should be seen as a probe to existing commands
@@ -687,7 +801,7 @@ func handleCloudwatchPlugin(context context.T, pluginResults map[string]*contrac
 		if pluginRes.PluginName == appconfig.PluginNameCloudWatch {
 			log.Infof("Found %v to invoke lrpm invoker", pluginRes.PluginName)
 			orchestrationRootDir := filepath.Join(
-				appconfig.DefaultDataStorePath,
+				appconfig.OrchestrationStorePath,
 				instanceID,
 				appconfig.DefaultDocumentRootDirName,
 				context.AppConfig().Agent.OrchestrationRootDir)
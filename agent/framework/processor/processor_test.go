@@ -17,10 +17,12 @@ package processor
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	executermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/mock"
 	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
@@ -146,6 +148,9 @@ func TestProcessCommand(t *testing.T) {
 	docMock := new(DocumentMgrMock)
 	docMock.On("MoveDocumentState", "documentID", appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
 	docMock.On("RemoveDocumentState", "documentID", appconfig.DefaultLocationOfCurrent)
+	docMock.On("RecordExecution", mock.MatchedBy(func(record docmanager.ExecutionRecord) bool {
+		return record.ExecutionID == "documentID" && record.Status == contracts.ResultStatusSuccess
+	}))
 	processCommand(ctx, creator, cancelFlag, resChan, &docState, docMock)
 	executerMock.AssertExpectations(t)
 	docMock.AssertExpectations(t)
@@ -341,6 +346,60 @@ func TestProcessCancelCommand_Success(t *testing.T) {
 
 }
 
+func TestReportQuarantinedDocuments(t *testing.T) {
+	ctx := contextmocks.NewMockDefaultWithConfig(appconfig.DefaultConfig())
+	resChan := make(chan contracts.DocumentResult, 2)
+	quarantinedAt := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	recovered := docmanager.CorruptionReport{
+		FileName:      "commandID",
+		QuarantinedAt: quarantinedAt,
+		Error:         "unexpected end of JSON input",
+		Recovered: docmanager.RecoveredDocumentState{
+			MessageID:    "aws.ssm.commandID.instanceID",
+			DocumentName: "AWS-RunShellScript",
+			DocumentType: contracts.SendCommand,
+			Plugins:      []docmanager.RecoveredPlugin{{ID: "aws:runShellScript", Name: "aws:runShellScript"}},
+		},
+	}
+	// the command of a document state whose message id is lost cannot be reported
+	unrecoverable := docmanager.CorruptionReport{FileName: "otherCommandID"}
+
+	docMock := new(DocumentMgrMock)
+	docMock.On("ClaimCorruptionReports", contracts.SendCommand).Return([]docmanager.CorruptionReport{recovered, unrecoverable})
+	processor := EngineProcessor{
+		context:     ctx,
+		documentMgr: docMock,
+		resChan:     resChan,
+		startWorker: NewWorkerProcessorSpec(ctx, 1, contracts.SendCommand, 0),
+	}
+	processor.reportQuarantinedDocuments()
+	docMock.AssertExpectations(t)
+
+	assert.Len(t, resChan, 1)
+	res := <-resChan
+	assert.Equal(t, "aws.ssm.commandID.instanceID", res.MessageID)
+	assert.Equal(t, contracts.ResultStatusFailed, res.Status)
+	assert.Equal(t, "", res.LastPlugin)
+	assert.Equal(t, 1, res.NPlugins)
+	assert.Equal(t, contracts.ResultStatusFailed, res.PluginResults["aws:runShellScript"].Status)
+	assert.Equal(t, quarantinedAt, res.PluginResults["aws:runShellScript"].EndDateTime)
+}
+
+func TestReportQuarantinedDocuments_Disabled(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Ssm.DocumentStateQuarantine.SendFailedResult = false
+	ctx := contextmocks.NewMockDefaultWithConfig(config)
+	docMock := new(DocumentMgrMock)
+	processor := EngineProcessor{
+		context:     ctx,
+		documentMgr: docMock,
+		resChan:     make(chan contracts.DocumentResult, 1),
+		startWorker: NewWorkerProcessorSpec(ctx, 1, contracts.SendCommand, 0),
+	}
+	processor.reportQuarantinedDocuments()
+	docMock.AssertNotCalled(t, "ClaimCorruptionReports", mock.Anything)
+}
+
 type DocumentMgrMock struct {
 	mock.Mock
 }
@@ -364,3 +423,13 @@ func (m *DocumentMgrMock) RemoveDocumentState(documentID, location string) {
 	m.Called(documentID, location)
 	return
 }
+
+func (m *DocumentMgrMock) RecordExecution(record docmanager.ExecutionRecord) {
+	m.Called(record)
+	return
+}
+
+func (m *DocumentMgrMock) ClaimCorruptionReports(documentType contracts.DocumentType) []docmanager.CorruptionReport {
+	args := m.Called(documentType)
+	return args.Get(0).([]docmanager.CorruptionReport)
+}
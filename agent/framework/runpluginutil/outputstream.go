@@ -0,0 +1,140 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
+)
+
+// outputFlushInterval is the time between two output frames of a running plugin
+var outputFlushInterval = time.Second
+
+// maxOutputFrameLength is the maximum number of bytes of each stream sent in one output frame, it keeps the
+// frames under the size limit of the replies sent over the control channel
+const maxOutputFrameLength = 16000
+
+// outputStream buffers the output of a running plugin until it is sent as an output frame
+type outputStream struct {
+	lock      sync.Mutex
+	stdout    []byte
+	stderr    []byte
+	truncated bool
+	sequence  int
+}
+
+// write appends a chunk of a stream to the buffered output, the oldest output is dropped once the buffer of the
+// stream holds more than the output frame length
+func (o *outputStream) write(stream string, chunk []byte) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	buffer := &o.stdout
+	if stream == iomodule.StderrStream {
+		buffer = &o.stderr
+	}
+	*buffer = append(*buffer, chunk...)
+	if excess := len(*buffer) - maxOutputFrameLength; excess > 0 {
+		// drop whole characters only
+		for excess < len(*buffer) && !utf8.RuneStart((*buffer)[excess]) {
+			excess++
+		}
+		*buffer = (*buffer)[excess:]
+		o.truncated = true
+	}
+}
+
+// frame returns the output buffered since the previous frame, found is false if there is no new output.
+// A character whose bytes are not all written yet stays buffered for the next frame.
+func (o *outputStream) frame() (frame contracts.OutputFrame, found bool) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	var stdout, stderr []byte
+	stdout, o.stdout = splitIncompleteRune(o.stdout)
+	stderr, o.stderr = splitIncompleteRune(o.stderr)
+	if len(stdout) == 0 && len(stderr) == 0 {
+		return frame, false
+	}
+	o.sequence++
+	frame = contracts.OutputFrame{
+		Sequence:  o.sequence,
+		Stdout:    string(stdout),
+		Stderr:    string(stderr),
+		Truncated: o.truncated,
+	}
+	o.truncated = false
+	return frame, true
+}
+
+// splitIncompleteRune splits the output before its last character if the bytes of this character are not all there
+func splitIncompleteRune(output []byte) (complete []byte, rest []byte) {
+	for i := len(output) - 1; i >= 0 && i >= len(output)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(output[i]) {
+			if !utf8.FullRune(output[i:]) {
+				return output[:i], append([]byte(nil), output[i:]...)
+			}
+			break
+		}
+	}
+	return output, nil
+}
+
+// watchOutput sends the output written by a plugin as in progress plugin results carrying an output frame until
+// the returned function is called, the returned sink is nil when the document does not stream its output
+func watchOutput(context context.T, pluginResult contracts.PluginResult, ioConfig contracts.IOConfiguration, resChan chan contracts.PluginResult) (sink iomodule.OutputSink, stop func()) {
+	if !ioConfig.StreamOutput {
+		return nil, func() {}
+	}
+	log := context.Log()
+	stream := &outputStream{}
+	send := func() {
+		frame, found := stream.frame()
+		if !found {
+			return
+		}
+		log.Debugf("Sending output frame %v of plugin %v", frame.Sequence, pluginResult.PluginID)
+		update := pluginResult
+		update.Status = contracts.ResultStatusInProgress
+		update.OutputFrame = &frame
+		resChan <- update
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(outputFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				send()
+			}
+		}
+	}()
+
+	return stream.write, func() {
+		close(done)
+		wg.Wait()
+		// the plugin has returned, its remaining output is sent in a last frame
+		send()
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputStreamFrame(t *testing.T) {
+	stream := &outputStream{}
+	_, found := stream.frame()
+	assert.False(t, found)
+
+	stream.write(iomodule.StdoutStream, []byte("line 1\n"))
+	stream.write(iomodule.StderrStream, []byte("warning\n"))
+	stream.write(iomodule.StdoutStream, []byte("line 2\n"))
+	frame, found := stream.frame()
+	assert.True(t, found)
+	assert.Equal(t, contracts.OutputFrame{Sequence: 1, Stdout: "line 1\nline 2\n", Stderr: "warning\n"}, frame)
+
+	// the bytes of a character split between two chunks are kept for the next frame
+	euro := []byte("€")
+	stream.write(iomodule.StdoutStream, append([]byte("price: "), euro[:1]...))
+	frame, found = stream.frame()
+	assert.True(t, found)
+	assert.Equal(t, contracts.OutputFrame{Sequence: 2, Stdout: "price: "}, frame)
+	stream.write(iomodule.StdoutStream, euro[1:])
+	frame, found = stream.frame()
+	assert.True(t, found)
+	assert.Equal(t, contracts.OutputFrame{Sequence: 3, Stdout: "€"}, frame)
+}
+
+func TestOutputStreamTruncated(t *testing.T) {
+	stream := &outputStream{}
+	stream.write(iomodule.StdoutStream, []byte(strings.Repeat("a", maxOutputFrameLength)))
+	stream.write(iomodule.StdoutStream, []byte("€end"))
+	frame, found := stream.frame()
+	assert.True(t, found)
+	assert.True(t, frame.Truncated)
+	assert.Equal(t, maxOutputFrameLength, len(frame.Stdout))
+	assert.True(t, strings.HasSuffix(frame.Stdout, "€end"))
+
+	stream.write(iomodule.StdoutStream, []byte("next"))
+	frame, _ = stream.frame()
+	assert.False(t, frame.Truncated)
+}
+
+func TestWatchOutput(t *testing.T) {
+	origInterval := outputFlushInterval
+	outputFlushInterval = 10 * time.Millisecond
+	defer func() { outputFlushInterval = origInterval }()
+
+	resChan := make(chan contracts.PluginResult, 10)
+	pluginResult := contracts.PluginResult{PluginID: "step1", PluginName: "aws:runShellScript", Status: contracts.ResultStatusNotStarted}
+	sink, stop := watchOutput(context.NewMockDefault(), pluginResult, contracts.IOConfiguration{StreamOutput: true}, resChan)
+
+	sink(iomodule.StdoutStream, []byte("first"))
+	select {
+	case update := <-resChan:
+		assert.Equal(t, "step1", update.PluginID)
+		assert.Equal(t, contracts.ResultStatusInProgress, update.Status)
+		assert.Equal(t, &contracts.OutputFrame{Sequence: 1, Stdout: "first"}, update.OutputFrame)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "output frame was not sent")
+	}
+
+	// the output left when the plugin returns is sent on stop at the latest
+	sink(iomodule.StderrStream, []byte("last"))
+	stop()
+	assert.Len(t, resChan, 1)
+	update := <-resChan
+	assert.Equal(t, "last", update.OutputFrame.Stderr)
+}
+
+func TestWatchOutputDisabled(t *testing.T) {
+	resChan := make(chan contracts.PluginResult, 10)
+	sink, stop := watchOutput(context.NewMockDefault(), contracts.PluginResult{PluginID: "step1"}, contracts.IOConfiguration{}, resChan)
+	assert.Nil(t, sink)
+	stop()
+	assert.Len(t, resChan, 0)
+}
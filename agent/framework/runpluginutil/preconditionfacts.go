@@ -0,0 +1,228 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/featureflag"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/versionutil"
+	"github.com/aws/amazon-ssm-agent/common/identity/identity"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Variables of the preconditions resolved from the instance running the document
+const (
+	platformTypeVariable    = "platformType"
+	platformNameVariable    = "platformName"
+	platformVersionVariable = "platformVersion"
+	kernelVersionVariable   = "kernelVersion"
+
+	// tagVariablePrefix is followed by the key of an instance tag, tags must be allowed in the instance metadata
+	tagVariablePrefix = "tag:"
+	// featureFlagVariablePrefix is followed by the name of an agent feature flag
+	featureFlagVariablePrefix = "featureFlag:"
+	// packageInstalledVariablePrefix is followed by the name of a package installed by the system package manager
+	packageInstalledVariablePrefix = "packageInstalled:"
+
+	instanceTagMetadataPath = "tags/instance/"
+
+	// instancePercentageBuckets is the number of buckets the instances are spread into by their hash, which allows
+	// selecting percentages with two decimals
+	instancePercentageBuckets = 10000
+)
+
+var (
+	packageNamePattern    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ._+:@-]*$`)
+	leadingVersionPattern = regexp.MustCompile(`^\d+(\.\d+)*`)
+
+	// decouples for easy testability
+	isOnPremInstance            = identity.IsOnPremInstance
+	queryInstanceTag            = queryInstanceTagFromMetadata
+	queryKernelVersion          = getKernelVersion
+	queryPackageInstalled       = isPackageInstalled
+	preconditionCommandExecutor = executePreconditionCommand
+)
+
+// preconditionFacts resolves the precondition variables, the values are cached for the evaluation of a step
+type preconditionFacts struct {
+	context context.T
+	values  map[string]string
+}
+
+func newPreconditionFacts(context context.T) *preconditionFacts {
+	return &preconditionFacts{
+		context: context,
+		values:  make(map[string]string),
+	}
+}
+
+// isFactVariable returns true if the argument is a variable resolved from the instance
+func isFactVariable(argument string) bool {
+	switch argument {
+	case platformTypeVariable, platformNameVariable, platformVersionVariable, kernelVersionVariable:
+		return true
+	}
+	for _, prefix := range []string{tagVariablePrefix, featureFlagVariablePrefix, packageInstalledVariablePrefix} {
+		if strings.HasPrefix(argument, prefix) && len(argument) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveArgument returns the value of a precondition argument, variables are only recognized in the argument
+// as written in the document so that document parameters cannot introduce them
+func (facts *preconditionFacts) resolveArgument(argument contracts.PreconditionArgument) (string, error) {
+	if isFactVariable(argument.InitialArgumentValue) {
+		return facts.resolve(argument.InitialArgumentValue)
+	}
+	return argument.ResolvedArgumentValue, nil
+}
+
+// resolve returns the value of a variable on this instance
+func (facts *preconditionFacts) resolve(variable string) (value string, err error) {
+	if value, found := facts.values[variable]; found {
+		return value, nil
+	}
+
+	log := facts.context.Log()
+	switch {
+	case variable == platformTypeVariable:
+		value, err = platform.PlatformType(log)
+	case variable == platformNameVariable:
+		value, err = platform.PlatformName(log)
+	case variable == platformVersionVariable:
+		value, err = platform.PlatformVersion(log)
+	case variable == kernelVersionVariable:
+		value, err = queryKernelVersion(log)
+	case strings.HasPrefix(variable, tagVariablePrefix):
+		value, err = facts.resolveInstanceTag(strings.TrimPrefix(variable, tagVariablePrefix))
+	case strings.HasPrefix(variable, featureFlagVariablePrefix):
+		flag := featureflag.Flag(strings.TrimPrefix(variable, featureFlagVariablePrefix))
+		value = strconv.FormatBool(featureflag.IsEnabled(facts.context.AppConfig(), flag))
+	case strings.HasPrefix(variable, packageInstalledVariablePrefix):
+		packageName := strings.TrimPrefix(variable, packageInstalledVariablePrefix)
+		if !packageNamePattern.MatchString(packageName) {
+			return "", fmt.Errorf("%v is not a valid package name", packageName)
+		}
+		var installed bool
+		installed, err = queryPackageInstalled(log, packageName)
+		value = strconv.FormatBool(installed)
+	default:
+		return "", fmt.Errorf("unknown variable %v", variable)
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve %v: %v", variable, err)
+	}
+
+	value = strings.TrimSpace(value)
+	log.Debugf("Precondition variable %v resolved to %v", variable, value)
+	facts.values[variable] = value
+	return value, nil
+}
+
+// resolveInstanceTag returns the value of an instance tag, or an empty string when the instance does not have
+// the tag or does not expose its tags in the instance metadata
+func (facts *preconditionFacts) resolveInstanceTag(key string) (string, error) {
+	if isOnPremInstance(facts.context.Identity()) {
+		facts.context.Log().Debugf("Instance tag %v is not available on an on-premises instance", key)
+		return "", nil
+	}
+	return queryInstanceTag(key)
+}
+
+func queryInstanceTagFromMetadata(key string) (string, error) {
+	metadata := ec2metadata.New(session.New(aws.NewConfig().WithMaxRetries(3)))
+	value, err := metadata.GetMetadata(instanceTagMetadataPath + key)
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == 404 {
+		return "", nil
+	}
+	return value, err
+}
+
+// isInstanceSelected returns true if the instance belongs to the given percentage of the instances. The instance id is
+// hashed with the selection key so that a given instance is always selected by the same key while different keys
+// select different instances.
+func (facts *preconditionFacts) isInstanceSelected(key, percentage string) (bool, error) {
+	threshold, err := strconv.ParseFloat(strings.TrimSpace(percentage), 64)
+	if err != nil || threshold < 0 || threshold > 100 {
+		return false, fmt.Errorf("%v is not a percentage between 0 and 100", percentage)
+	}
+	instanceID, err := facts.context.Identity().InstanceID()
+	if err != nil {
+		return false, fmt.Errorf("unable to get the instance id: %v", err)
+	}
+
+	bucket := instancePercentageBucket(key, instanceID)
+	facts.context.Log().Debugf("Instance is in bucket %v of %v for the selection key %v", bucket, instancePercentageBuckets, key)
+	return float64(bucket) < threshold*instancePercentageBuckets/100, nil
+}
+
+// instancePercentageBucket returns the bucket of the instance for a selection key
+func instancePercentageBucket(key, instanceID string) uint64 {
+	hash := sha256.Sum256([]byte(key + "\x00" + instanceID))
+	return binary.BigEndian.Uint64(hash[:8]) % instancePercentageBuckets
+}
+
+// compareArguments evaluates an operator with the resolved values of its arguments
+func compareArguments(operator, left, right string) (bool, error) {
+	switch operator {
+	case appconfig.PreconditionOperatorStringEquals:
+		return left == right, nil
+	case appconfig.PreconditionOperatorStringNotEquals:
+		return left != right, nil
+	case appconfig.PreconditionOperatorStringLike:
+		return matchesWildcardPattern(left, right), nil
+	case appconfig.PreconditionOperatorVersionGreaterThanOrEqual, appconfig.PreconditionOperatorVersionLessThan:
+		leftVersion := leadingVersionPattern.FindString(left)
+		rightVersion := leadingVersionPattern.FindString(right)
+		if leftVersion == "" || rightVersion == "" {
+			return false, fmt.Errorf("operator's arguments must be versions")
+		}
+		result := versionutil.Compare(leftVersion, rightVersion, false)
+		if operator == appconfig.PreconditionOperatorVersionLessThan {
+			return result < 0, nil
+		}
+		return result >= 0, nil
+	}
+	return false, fmt.Errorf("unrecognized operator")
+}
+
+// matchesWildcardPattern returns true if the value matches the pattern where * matches any sequence of
+// characters and ? matches a single character
+func matchesWildcardPattern(value, pattern string) bool {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.Replace(expression, `\*`, ".*", -1)
+	expression = strings.Replace(expression, `\?`, ".", -1)
+	matched, _ := regexp.MatchString("^"+expression+"$", value)
+	return matched
+}
+
+func executePreconditionCommand(command string, args ...string) ([]byte, error) {
+	return exec.Command(command, args...).Output()
+}
@@ -0,0 +1,36 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package runpluginutil
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// getKernelVersion returns the release of the running Darwin kernel
+func getKernelVersion(log log.T) (string, error) {
+	output, err := preconditionCommandExecutor("uname", "-r")
+	return string(output), err
+}
+
+// isPackageInstalled returns true if the installer receipts contain the package identifier
+func isPackageInstalled(log log.T, packageName string) (bool, error) {
+	if _, err := preconditionCommandExecutor("pkgutil", "--pkg-info", packageName); err != nil {
+		log.Debugf("Package %v has no installer receipt: %v", packageName, err)
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/common/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func setPreconditionFactsMocks(t *testing.T, onPrem bool) {
+	origIsOnPremInstance, origQueryInstanceTag := isOnPremInstance, queryInstanceTag
+	origQueryKernelVersion, origQueryPackageInstalled := queryKernelVersion, queryPackageInstalled
+	t.Cleanup(func() {
+		isOnPremInstance, queryInstanceTag = origIsOnPremInstance, origQueryInstanceTag
+		queryKernelVersion, queryPackageInstalled = origQueryKernelVersion, origQueryPackageInstalled
+	})
+
+	isOnPremInstance = func(identity.IAgentIdentity) bool { return onPrem }
+	queryInstanceTag = func(key string) (string, error) {
+		switch key {
+		case "Environment":
+			return "prod-eu", nil
+		case "Broken":
+			return "", fmt.Errorf("metadata unavailable")
+		}
+		return "", nil
+	}
+	queryKernelVersion = func(log.T) (string, error) { return "5.15.0-1019-aws\n", nil }
+	queryPackageInstalled = func(log log.T, packageName string) (bool, error) { return packageName == "nginx", nil }
+}
+
+func literal(value string) contracts.PreconditionArgument {
+	return contracts.PreconditionArgument{InitialArgumentValue: value, ResolvedArgumentValue: value}
+}
+
+func parameter(value string) contracts.PreconditionArgument {
+	return contracts.PreconditionArgument{InitialArgumentValue: "{{ param }}", ResolvedArgumentValue: value}
+}
+
+func TestEvaluatePreconditionsWithFacts(t *testing.T) {
+	testCases := []struct {
+		name      string
+		operator  string
+		arguments []contracts.PreconditionArgument
+		onPrem    bool
+		allowed   bool
+		messages  []string
+	}{
+		{
+			name:      "tag equals",
+			operator:  appconfig.PreconditionOperatorStringEquals,
+			arguments: []contracts.PreconditionArgument{literal("tag:Environment"), literal("prod-eu")},
+			allowed:   true,
+		},
+		{
+			name:      "tag equals with value first",
+			operator:  appconfig.PreconditionOperatorStringEquals,
+			arguments: []contracts.PreconditionArgument{literal("dev"), literal("tag:Environment")},
+			allowed:   false,
+			messages:  []string{"\"StringEquals\": [dev, tag:Environment]"},
+		},
+		{
+			name:      "tag like",
+			operator:  appconfig.PreconditionOperatorStringLike,
+			arguments: []contracts.PreconditionArgument{literal("tag:Environment"), literal("prod-*")},
+			allowed:   true,
+		},
+		{
+			name:      "missing tag",
+			operator:  appconfig.PreconditionOperatorStringNotEquals,
+			arguments: []contracts.PreconditionArgument{literal("tag:Team"), literal("")},
+			allowed:   false,
+			messages:  []string{"\"StringNotEquals\": [tag:Team, ]"},
+		},
+		{
+			name:      "tag on an on-premises instance",
+			operator:  appconfig.PreconditionOperatorStringEquals,
+			arguments: []contracts.PreconditionArgument{literal("tag:Environment"), literal("prod-eu")},
+			onPrem:    true,
+			allowed:   false,
+			messages:  []string{"\"StringEquals\": [tag:Environment, prod-eu]"},
+		},
+		{
+			name:      "tag error",
+			operator:  appconfig.PreconditionOperatorStringEquals,
+			arguments: []contracts.PreconditionArgument{literal("tag:Broken"), literal("value")},
+			allowed:   true,
+			messages:  []string{"\"StringEquals\": unable to resolve tag:Broken: metadata unavailable"},
+		},
+		{
+			name:      "feature flag",
+			operator:  appconfig.PreconditionOperatorStringEquals,
+			arguments: []contracts.PreconditionArgument{literal("featureFlag:NewIPC"), literal("false")},
+			allowed:   true,
+		},
+		{
+			name:      "kernel version greater than or equal",
+			operator:  appconfig.PreconditionOperatorVersionGreaterThanOrEqual,
+			arguments: []contracts.PreconditionArgument{literal("kernelVersion"), literal("5.10")},
+			allowed:   true,
+		},
+		{
+			name:      "kernel version less than",
+			operator:  appconfig.PreconditionOperatorVersionLessThan,
+			arguments: []contracts.PreconditionArgument{literal("kernelVersion"), literal("5.4")},
+			allowed:   false,
+			messages:  []string{"\"VersionLessThan\": [kernelVersion, 5.4]"},
+		},
+		{
+			name:      "kernel version compared to a parameter",
+			operator:  appconfig.PreconditionOperatorVersionLessThan,
+			arguments: []contracts.PreconditionArgument{literal("kernelVersion"), parameter("6.1")},
+			allowed:   true,
+		},
+		{
+			name:      "invalid version",
+			operator:  appconfig.PreconditionOperatorVersionLessThan,
+			arguments: []contracts.PreconditionArgument{literal("kernelVersion"), literal("latest")},
+			allowed:   true,
+			messages:  []string{"\"VersionLessThan\": operator's arguments must be versions"},
+		},
+		{
+			name:      "package installed",
+			operator:  appconfig.PreconditionOperatorStringEquals,
+			arguments: []contracts.PreconditionArgument{literal("packageInstalled:nginx"), literal("true")},
+			allowed:   true,
+		},
+		{
+			name:      "package not installed",
+			operator:  appconfig.PreconditionOperatorStringEquals,
+			arguments: []contracts.PreconditionArgument{literal("packageInstalled:httpd"), literal("true")},
+			allowed:   false,
+			messages:  []string{"\"StringEquals\": [packageInstalled:httpd, true]"},
+		},
+		{
+			name:      "invalid package name",
+			operator:  appconfig.PreconditionOperatorStringEquals,
+			arguments: []contracts.PreconditionArgument{literal("packageInstalled:nginx'; rm"), literal("true")},
+			allowed:   true,
+			messages:  []string{"\"StringEquals\": nginx'; rm is not a valid package name"},
+		},
+		{
+			name:      "parameter not equals",
+			operator:  appconfig.PreconditionOperatorStringNotEquals,
+			arguments: []contracts.PreconditionArgument{parameter("dev"), literal("prod")},
+			allowed:   true,
+		},
+		{
+			name:      "variable introduced by a parameter",
+			operator:  appconfig.PreconditionOperatorStringNotEquals,
+			arguments: []contracts.PreconditionArgument{parameter("tag:Environment"), literal("tag:Environment")},
+			allowed:   true,
+		},
+		{
+			name:      "constants only",
+			operator:  appconfig.PreconditionOperatorStringNotEquals,
+			arguments: []contracts.PreconditionArgument{literal("dev"), literal("prod")},
+			allowed:   true,
+			messages:  []string{"\"StringNotEquals\": at least one of operator's arguments must contain a valid document parameter or variable"},
+		},
+		{
+			name:      "ssm parameter",
+			operator:  appconfig.PreconditionOperatorStringLike,
+			arguments: []contracts.PreconditionArgument{literal("{{ssm:environment}}"), literal("prod*")},
+			allowed:   true,
+			messages:  []string{"\"StringLike\": operator's arguments can't contain SSM parameters"},
+		},
+		{
+			name:      "wrong number of arguments",
+			operator:  appconfig.PreconditionOperatorVersionGreaterThanOrEqual,
+			arguments: []contracts.PreconditionArgument{literal("kernelVersion")},
+			allowed:   true,
+			messages:  []string{"\"VersionGreaterThanOrEqual\": operator accepts exactly 2 arguments"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			setPreconditionFactsMocks(t, testCase.onPrem)
+
+			allowed, messages := evaluatePreconditions(contextmocks.NewMockDefault(), map[string][]contracts.PreconditionArgument{
+				testCase.operator: testCase.arguments,
+			})
+
+			assert.Equal(t, testCase.allowed, allowed)
+			assert.Equal(t, testCase.messages, messages)
+		})
+	}
+}
+
+func TestPreconditionFactsAreCached(t *testing.T) {
+	setPreconditionFactsMocks(t, false)
+	calls := 0
+	queryKernelVersion = func(log.T) (string, error) {
+		calls++
+		return "5.10.0", nil
+	}
+
+	allowed, messages := evaluatePreconditions(contextmocks.NewMockDefault(), map[string][]contracts.PreconditionArgument{
+		appconfig.PreconditionOperatorVersionGreaterThanOrEqual: {literal("kernelVersion"), literal("5.4")},
+		appconfig.PreconditionOperatorVersionLessThan:           {literal("kernelVersion"), literal("6.0")},
+	})
+
+	assert.True(t, allowed)
+	assert.Empty(t, messages)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMatchesWildcardPattern(t *testing.T) {
+	assert.True(t, matchesWildcardPattern("prod-eu-west-1", "prod-*"))
+	assert.True(t, matchesWildcardPattern("web01", "web??"))
+	assert.True(t, matchesWildcardPattern("a.b", "a.b"))
+	assert.False(t, matchesWildcardPattern("axb", "a.b"))
+	assert.False(t, matchesWildcardPattern("staging", "prod*"))
+}
+
+func TestEvaluateInstancePercentagePrecondition(t *testing.T) {
+	context := contextmocks.NewMockDefault()
+	instanceID, _ := context.Identity().InstanceID()
+	bucket := instancePercentageBucket("canary", instanceID)
+	included := fmt.Sprintf("%v", float64(bucket+1)/100)
+	excluded := fmt.Sprintf("%v", float64(bucket)/100)
+
+	allowed, messages := evaluatePreconditions(context, map[string][]contracts.PreconditionArgument{
+		appconfig.PreconditionOperatorInstancePercentage: {literal("canary"), parameter(included)},
+	})
+	assert.True(t, allowed)
+	assert.Empty(t, messages)
+
+	allowed, messages = evaluatePreconditions(context, map[string][]contracts.PreconditionArgument{
+		appconfig.PreconditionOperatorInstancePercentage: {literal("canary"), literal(excluded)},
+	})
+	assert.False(t, allowed)
+	assert.Equal(t, []string{fmt.Sprintf("\"InstancePercentage\": [canary, %v]", excluded)}, messages)
+
+	allowed, messages = evaluatePreconditions(context, map[string][]contracts.PreconditionArgument{
+		appconfig.PreconditionOperatorInstancePercentage: {literal("canary"), parameter("all")},
+	})
+	assert.True(t, allowed)
+	assert.Equal(t, []string{"\"InstancePercentage\": all is not a percentage between 0 and 100"}, messages)
+}
+
+func TestInstancePercentageBucket(t *testing.T) {
+	// the bucket of an instance is stable for a key
+	assert.Equal(t, instancePercentageBucket("canary", "i-1234567890abcdef0"), instancePercentageBucket("canary", "i-1234567890abcdef0"))
+
+	// the selected share of the instances follows the percentage
+	selected := 0
+	for i := 0; i < 10000; i++ {
+		if instancePercentageBucket("canary", fmt.Sprintf("i-%017x", i)) < 500 {
+			selected++
+		}
+	}
+	assert.InDelta(t, 500, selected, 100)
+
+	// all the instances are selected at 100% and none at 0%
+	facts := newPreconditionFacts(contextmocks.NewMockDefault())
+	isSelected, err := facts.isInstanceSelected("canary", "100")
+	assert.NoError(t, err)
+	assert.True(t, isSelected)
+	isSelected, err = facts.isInstanceSelected("canary", "0")
+	assert.NoError(t, err)
+	assert.False(t, isSelected)
+}
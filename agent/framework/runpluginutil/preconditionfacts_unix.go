@@ -0,0 +1,57 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package runpluginutil
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	dpkgQueryCmd = "dpkg-query"
+	rpmCmd       = "rpm"
+)
+
+// getKernelVersion returns the release of the running kernel
+func getKernelVersion(log log.T) (string, error) {
+	output, err := preconditionCommandExecutor("uname", "-r")
+	return string(output), err
+}
+
+// isPackageInstalled returns true if the package is installed by dpkg or rpm, depending on the package
+// manager available on the instance
+func isPackageInstalled(log log.T, packageName string) (bool, error) {
+	if _, err := exec.LookPath(dpkgQueryCmd); err == nil {
+		output, err := preconditionCommandExecutor(dpkgQueryCmd, "-W", "-f=${Status}", packageName)
+		if err != nil {
+			log.Debugf("Package %v is not known by dpkg: %v", packageName, err)
+			return false, nil
+		}
+		return strings.HasSuffix(strings.TrimSpace(string(output)), "installed"), nil
+	}
+	if _, err := exec.LookPath(rpmCmd); err == nil {
+		if _, err := preconditionCommandExecutor(rpmCmd, "-q", packageName); err != nil {
+			log.Debugf("Package %v is not installed by rpm: %v", packageName, err)
+			return false, nil
+		}
+		return true, nil
+	}
+	log.Debugf("No supported package manager found to query package %v", packageName)
+	return false, nil
+}
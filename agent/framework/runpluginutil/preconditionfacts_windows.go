@@ -0,0 +1,44 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package runpluginutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+)
+
+// installedProgramScript looks for the display name of the programs registered for both architectures
+const installedProgramScript = `$names = Get-ItemProperty -Path 'HKLM:\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall\*', 'HKLM:\SOFTWARE\Wow6432Node\Microsoft\Windows\CurrentVersion\Uninstall\*' -ErrorAction SilentlyContinue | ForEach-Object { $_.DisplayName }
+if ($names -contains '%v') { 'true' } else { 'false' }`
+
+// getKernelVersion returns the version of the Windows kernel, which is the version of the operating system
+func getKernelVersion(log log.T) (string, error) {
+	return platform.PlatformVersion(log)
+}
+
+// isPackageInstalled returns true if a program with the given display name is registered as installed
+func isPackageInstalled(log log.T, packageName string) (bool, error) {
+	output, err := preconditionCommandExecutor(appconfig.PowerShellPluginCommandName, "-NoProfile", "-NonInteractive", "-Command", fmt.Sprintf(installedProgramScript, packageName))
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(output)) == "true", nil
+}
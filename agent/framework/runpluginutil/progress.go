@@ -0,0 +1,129 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+)
+
+// progressPollInterval is the time between two reads of the progress file of a running plugin
+var progressPollInterval = 5 * time.Second
+
+// maxProgressPhaseLength is the length above which the phase reported by a script is truncated
+const maxProgressPhaseLength = 200
+
+// progress is the last progress reported by the scripts of a plugin
+type progress struct {
+	Percent int
+	Phase   string
+}
+
+// String returns the progress as reported in the output of the in progress plugin result
+func (p progress) String() string {
+	if p.Phase == "" {
+		return fmt.Sprintf("%d%% complete", p.Percent)
+	}
+	return fmt.Sprintf("%v (%d%% complete)", p.Phase, p.Percent)
+}
+
+// parseProgress returns the progress of the last complete line of the progress file content, found is false
+// if the content has no valid progress line
+func parseProgress(content string) (current progress, found bool) {
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	// the last line is only complete once it ends with a new line, the script may still be writing it
+	for i := len(lines) - 2; i >= 0; i-- {
+		fields := strings.SplitN(strings.TrimSpace(lines[i]), " ", 2)
+		percent, err := strconv.Atoi(strings.TrimSuffix(fields[0], "%"))
+		if err != nil {
+			continue
+		}
+		if percent < 0 {
+			percent = 0
+		} else if percent > 100 {
+			percent = 100
+		}
+		current.Percent = percent
+		if len(fields) == 2 {
+			current.Phase = pluginutil.StringPrefix(strings.TrimSpace(fields[1]), maxProgressPhaseLength, "...")
+		}
+		return current, true
+	}
+	return current, false
+}
+
+// watchProgress relays the progress the scripts of a plugin write to their progress file as in progress plugin
+// results until the returned function is called
+func watchProgress(context context.T, pluginResult contracts.PluginResult, orchestrationDirectory string, resChan chan contracts.PluginResult) (stop func()) {
+	if orchestrationDirectory == "" {
+		return func() {}
+	}
+	log := context.Log()
+	progressFile := pluginutil.ProgressFilePath(orchestrationDirectory)
+	// the progress of a previous run of the plugin is not relevant anymore
+	if err := os.Remove(progressFile); err != nil && !os.IsNotExist(err) {
+		log.Debugf("Unable to remove progress file %v: %v", progressFile, err)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(progressPollInterval)
+		defer ticker.Stop()
+
+		var reported progress
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			content, err := ioutil.ReadFile(progressFile)
+			if err != nil {
+				continue
+			}
+			current, found := parseProgress(string(content))
+			if !found || current == reported {
+				continue
+			}
+			reported = current
+			log.Debugf("Plugin %v progress: %v", pluginResult.PluginID, current)
+
+			update := pluginResult
+			update.Status = contracts.ResultStatusInProgress
+			update.Output = current.String()
+			select {
+			case resChan <- update:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProgress(t *testing.T) {
+	testCases := []struct {
+		content  string
+		expected progress
+		found    bool
+	}{
+		{"", progress{}, false},
+		{"40 Installing", progress{}, false},
+		{"40 Installing\n", progress{Percent: 40, Phase: "Installing"}, true},
+		{"10 Downloading\r\n40 Installing packages\r\n", progress{Percent: 40, Phase: "Installing packages"}, true},
+		{"10 Downloading\n40 Install", progress{Percent: 10, Phase: "Downloading"}, true},
+		{"75%\n", progress{Percent: 75}, true},
+		{"20 Starting\nnot a progress\n", progress{Percent: 20, Phase: "Starting"}, true},
+		{"150 Done\n", progress{Percent: 100, Phase: "Done"}, true},
+		{"-5 Rolling back\n", progress{Percent: 0, Phase: "Rolling back"}, true},
+	}
+	for _, testCase := range testCases {
+		current, found := parseProgress(testCase.content)
+		assert.Equal(t, testCase.found, found, testCase.content)
+		assert.Equal(t, testCase.expected, current, testCase.content)
+	}
+}
+
+func TestProgressString(t *testing.T) {
+	assert.Equal(t, "Installing (40% complete)", progress{Percent: 40, Phase: "Installing"}.String())
+	assert.Equal(t, "40% complete", progress{Percent: 40}.String())
+}
+
+func TestWatchProgress(t *testing.T) {
+	orchestrationDirectory, err := ioutil.TempDir("", "progress")
+	assert.NoError(t, err)
+	defer os.RemoveAll(orchestrationDirectory)
+	origInterval := progressPollInterval
+	progressPollInterval = 10 * time.Millisecond
+	defer func() { progressPollInterval = origInterval }()
+
+	// the progress of a previous run is discarded
+	progressFile := pluginutil.ProgressFilePath(orchestrationDirectory)
+	assert.NoError(t, ioutil.WriteFile(progressFile, []byte("100 Done\n"), 0600))
+
+	resChan := make(chan contracts.PluginResult, 10)
+	pluginResult := contracts.PluginResult{PluginID: "step1", PluginName: "aws:runShellScript", Status: contracts.ResultStatusNotStarted}
+	stop := watchProgress(context.NewMockDefault(), pluginResult, orchestrationDirectory, resChan)
+
+	assert.NoError(t, ioutil.WriteFile(progressFile, []byte("40 Installing\n"), 0600))
+	select {
+	case update := <-resChan:
+		assert.Equal(t, "step1", update.PluginID)
+		assert.Equal(t, contracts.ResultStatusInProgress, update.Status)
+		assert.Equal(t, "Installing (40% complete)", update.Output)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "progress was not reported")
+	}
+
+	// the same progress is only reported once
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	assert.Len(t, resChan, 0)
+}
@@ -26,8 +26,8 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
-	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
@@ -86,6 +86,7 @@ var allSessionPlugins = map[string]struct{}{
 	appconfig.PluginNameInteractiveCommands:    {},
 	appconfig.PluginNamePort:                   {},
 	appconfig.PluginNameNonInteractiveCommands: {},
+	appconfig.PluginNameForcedCommand:          {},
 }
 
 // Assign method to global variables to allow unittest to override
@@ -179,10 +180,11 @@ func RunPlugins(
 			pluginHandlerFound bool
 			isKnown            bool
 			isSupported        bool
+			platformMessage    string
 		)
 
 		pluginFactory, pluginHandlerFound = registry[pluginName]
-		isKnown, isSupported, _ = isSupportedPlugin(log, pluginName)
+		isKnown, isSupported, platformMessage = isSupportedPlugin(log, pluginName)
 		// checking if a prior step returned exit codes 168 or 169 to exit document.
 		// If so we need to skip every other step
 		shouldSkipStepDueToPriorFailedStep := getShouldPluginSkipBasedOnControlFlow(
@@ -193,11 +195,12 @@ func RunPlugins(
 		)
 
 		operation, logMessage := getStepExecutionOperation(
-			log,
+			context,
 			pluginName,
 			pluginID,
 			isKnown,
 			isSupported,
+			platformMessage,
 			pluginHandlerFound,
 			configuration.IsPreconditionEnabled,
 			configuration.Preconditions,
@@ -206,7 +209,11 @@ func RunPlugins(
 		switch operation {
 		case executeStep:
 			log.Infof("Running plugin %s %s", pluginName, pluginID)
-			r = runPlugin(context, pluginFactory, pluginName, configuration, cancelFlag, ioConfig)
+			stopProgress := watchProgress(context, *pluginOutputs[pluginID], configuration.OrchestrationDirectory, resChan)
+			outputSink, stopOutput := watchOutput(context, *pluginOutputs[pluginID], ioConfig, resChan)
+			r = runPlugin(context, pluginFactory, pluginName, configuration, cancelFlag, ioConfig, outputSink)
+			stopOutput()
+			stopProgress()
 			pluginOutputs[pluginID].Code = r.Code
 			pluginOutputs[pluginID].Status = r.Status
 			pluginOutputs[pluginID].Error = r.Error
@@ -214,6 +221,8 @@ func RunPlugins(
 			pluginOutputs[pluginID].StandardOutput = r.StandardOutput
 			pluginOutputs[pluginID].Output = r.Output
 			pluginOutputs[pluginID].StepName = r.StepName
+			pluginOutputs[pluginID].ErrorCategory = r.ErrorCategory
+			pluginOutputs[pluginID].ErrorCode = r.ErrorCode
 
 			onFailureProp := getStringPropByName(pluginState.Configuration.Properties, contracts.OnFailureModifier)
 			hasOnFailureProp := onFailureProp == contracts.ModifierValueExit || onFailureProp == contracts.ModifierValueSuccessAndExit
@@ -222,6 +231,8 @@ func RunPlugins(
 				outputAddition = "\nStep exited with code 168. Therefore, marking step as succeeded. Further document steps will be skipped."
 				pluginOutputs[pluginID].Status = contracts.ResultStatusSuccess
 				pluginOutputs[pluginID].Error = ""
+				pluginOutputs[pluginID].ErrorCategory = ""
+				pluginOutputs[pluginID].ErrorCode = ""
 				pluginOutputs[pluginID].StandardError = ""
 				pluginOutputs[pluginID].StandardOutput = r.StandardOutput + outputAddition
 			} else if pluginOutputs[pluginID].Code == contracts.ExitWithFailure {
@@ -235,6 +246,8 @@ func RunPlugins(
 				if onFailureProp == contracts.ModifierValueSuccessAndExit {
 					pluginOutputs[pluginID].Status = contracts.ResultStatusSuccess
 					pluginOutputs[pluginID].Code = contracts.ExitWithSuccess
+					pluginOutputs[pluginID].ErrorCategory = ""
+					pluginOutputs[pluginID].ErrorCode = ""
 				}
 			}
 
@@ -247,11 +260,15 @@ func RunPlugins(
 			err := fmt.Errorf(logMessage)
 			pluginOutputs[pluginID].Status = contracts.ResultStatusFailed
 			pluginOutputs[pluginID].Error = err.Error()
+			pluginOutputs[pluginID].ErrorCategory = contracts.ErrorCategoryValidation
+			pluginOutputs[pluginID].ErrorCode = contracts.ErrorCodeUnsupported
 			log.Error(err)
 		default:
 			err := fmt.Errorf("Unknown error, Operation: %s, Plugin name: %s", operation, pluginName)
 			pluginOutputs[pluginID].Status = contracts.ResultStatusFailed
 			pluginOutputs[pluginID].Error = err.Error()
+			pluginOutputs[pluginID].ErrorCategory = contracts.ErrorCategoryPluginInternal
+			pluginOutputs[pluginID].ErrorCode = contracts.ErrorCodePluginFailure
 			log.Error(err)
 		}
 
@@ -309,7 +326,8 @@ var runPlugin = func(
 	pluginName string,
 	config contracts.Configuration,
 	cancelFlag task.CancelFlag,
-	ioConfig contracts.IOConfiguration) (res contracts.PluginResult) {
+	ioConfig contracts.IOConfiguration,
+	outputSink iomodule.OutputSink) (res contracts.PluginResult) {
 	// create a new context that includes plugin ID
 	context = context.With("[pluginName=" + pluginName + "]")
 
@@ -323,6 +341,7 @@ var runPlugin = func(
 			res.Status = contracts.ResultStatusFailed
 			res.Code = 1
 			res.Error = fmt.Errorf("Plugin crashed with message %v!", err).Error()
+			res.ErrorCategory, res.ErrorCode = contracts.ErrorCategoryPluginInternal, contracts.ErrorCodePluginCrashed
 			log.Error(res.Error)
 			log.Errorf("Stacktrace:\n%s", debug.Stack())
 		}
@@ -335,6 +354,7 @@ var runPlugin = func(
 		res.Status = contracts.ResultStatusFailed
 		res.Code = 1
 		res.Error = fmt.Errorf("failed to create plugin %v", err).Error()
+		res.ErrorCategory, res.ErrorCode = contracts.CategorizeError(err)
 		log.Error(res.Error)
 		return
 	}
@@ -343,6 +363,7 @@ var runPlugin = func(
 	defer func() { res.EndDateTime = time.Now() }()
 
 	output := iohandler.NewDefaultIOHandler(context, ioConfig)
+	output.SetOutputSink(outputSink)
 	//check if properties is a list. If true, then unroll
 	switch config.Properties.(type) {
 	case []interface{}:
@@ -354,10 +375,11 @@ var runPlugin = func(
 		for _, prop := range properties {
 			config.Properties = prop
 			propOutput := iohandler.NewDefaultIOHandler(context, ioConfig)
+			propOutput.SetOutputSink(outputSink)
 			stepName, err = getStepName(pluginName, config)
 			if err != nil {
 				errorString := fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err)
-				output.MarkAsFailed(errorString)
+				output.MarkAsFailed(contracts.NewCategorizedError(contracts.ErrorCategoryValidation, contracts.ErrorCodeInvalidInput, errorString))
 			} else {
 				executePlugin(plugin, pluginName, stepName, config, cancelFlag, propOutput)
 			}
@@ -369,7 +391,7 @@ var runPlugin = func(
 		stepName, err = getStepName(pluginName, config)
 		if err != nil {
 			errorString := fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err)
-			output.MarkAsFailed(errorString)
+			output.MarkAsFailed(contracts.NewCategorizedError(contracts.ErrorCategoryValidation, contracts.ErrorCodeInvalidInput, errorString))
 		} else {
 			executePlugin(plugin, pluginName, stepName, config, cancelFlag, output)
 		}
@@ -388,10 +410,26 @@ var runPlugin = func(
 	res.Output = output.GetOutput()
 	res.StandardOutput = output.GetStdout()
 	res.StandardError = output.GetStderr()
+	res.ErrorCategory, res.ErrorCode = resultErrorCategory(res.Status, output)
 
 	return
 }
 
+// resultErrorCategory returns the category and code of the failure of the plugin, the plugins that fail without
+// an error are categorized from their status
+func resultErrorCategory(status contracts.ResultStatus, output *iohandler.DefaultIOHandler) (contracts.ErrorCategory, string) {
+	switch status {
+	case contracts.ResultStatusTimedOut:
+		return contracts.ErrorCategoryTimeout, contracts.ErrorCodeTimedOut
+	case contracts.ResultStatusFailed:
+		if category, code := output.GetError(); category != "" {
+			return category, code
+		}
+		return contracts.ErrorCategoryPluginInternal, contracts.ErrorCodePluginFailure
+	}
+	return "", ""
+}
+
 // executePlugin executes the plugin that's passed in and initializes the necessary writers
 func executePlugin(
 	plugin T,
@@ -417,16 +455,18 @@ func GetPropertyName(rawPluginInput interface{}) (propertyName string, err error
 
 // Checks plugin compatibility and step precondition and returns if it should be executed, skipped or failed
 func getStepExecutionOperation(
-	log log.T,
+	context context.T,
 	pluginName string,
 	pluginId string,
 	isKnown bool,
 	isSupported bool,
+	platformMessage string,
 	isPluginHandlerFound bool,
 	isPreconditionEnabled bool,
 	preconditions map[string][]contracts.PreconditionArgument,
 	shouldSkipStepDueToPriorFailedStep bool,
 ) (string, string) {
+	log := context.Log()
 	log.Debugf("isSupported flag = %t", isSupported)
 	log.Debugf("isPluginHandlerFound flag = %t", isPluginHandlerFound)
 	log.Debugf("isPreconditionEnabled flag = %t", isPreconditionEnabled)
@@ -447,8 +487,9 @@ func getStepExecutionOperation(
 				pluginId)
 		} else if !isSupported {
 			return failStep, fmt.Sprintf(
-				"Plugin with name %s is not supported in current platform. Step name: %s",
+				"Plugin with name %s is not supported in current platform%s. Step name: %s",
 				pluginName,
+				unsupportedDetail(platformMessage),
 				pluginId)
 		} else if len(preconditions) > 0 {
 			// if 1.x or 2.0 document contains precondition or plugin not found, failStep
@@ -476,6 +517,12 @@ func getStepExecutionOperation(
 					pluginId)
 			} else if isSupported && isPluginHandlerFound {
 				return executeStep, ""
+			} else if !isSupported {
+				return skipStep, fmt.Sprintf(
+					"Step execution skipped due to unsupported plugin: %s%s. Step name: %s",
+					pluginName,
+					unsupportedDetail(platformMessage),
+					pluginId)
 			} else {
 				return skipStep, fmt.Sprintf(
 					"Step execution skipped due to unsupported plugin: %s. Step name: %s",
@@ -485,7 +532,7 @@ func getStepExecutionOperation(
 		} else {
 			log.Debugf("Cross-platform Precondition is present, precondition = %v", preconditions)
 
-			isAllowed, unrecognizedPreconditionList := evaluatePreconditions(log, preconditions)
+			isAllowed, unrecognizedPreconditionList := evaluatePreconditions(context, preconditions)
 
 			if isAllowed && !isKnown {
 				return failStep, fmt.Sprintf(
@@ -494,8 +541,9 @@ func getStepExecutionOperation(
 					pluginId)
 			} else if !isSupported || !isPluginHandlerFound {
 				return skipStep, fmt.Sprintf(
-					"Step execution skipped due to unsupported plugin: %s. Step name: %s",
+					"Step execution skipped due to unsupported plugin: %s%s. Step name: %s",
 					pluginName,
+					unsupportedDetail(platformMessage),
 					pluginId)
 			} else if !isAllowed {
 				return skipStep, fmt.Sprintf(
@@ -514,20 +562,30 @@ func getStepExecutionOperation(
 	}
 }
 
+// unsupportedDetail formats the platform message explaining why a plugin is not supported for the step output
+func unsupportedDetail(platformMessage string) string {
+	if platformMessage == "" {
+		return ""
+	}
+	return " on " + platformMessage
+}
+
 // Evaluate precondition and return precondition result and unrecognized preconditions (if any)
 func evaluatePreconditions(
-	log log.T,
+	context context.T,
 	preconditions map[string][]contracts.PreconditionArgument,
 ) (bool, []string) {
 
 	var isAllowed = true
 	var unrecognizedPreconditionList []string
+	log := context.Log()
+	facts := newPreconditionFacts(context)
 
-	// For current release, we only support "StringEquals" operator and "platformType"
-	// operand, so explicitly checking for those and number of operands must be 2
+	// All operators take exactly 2 operands, the "platformType" variable keeps its original StringEquals rules
+	// and the other variables are resolved from the instance by the precondition facts
 	for key, value := range preconditions {
 		switch key {
-		case "StringEquals":
+		case appconfig.PreconditionOperatorStringEquals:
 			if len(value) != 2 {
 				unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator accepts exactly 2 arguments", key))
 			} else {
@@ -539,10 +597,8 @@ func evaluatePreconditions(
 						// hide customer's parameters and constants
 						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator's arguments can't be identical", key))
 					}
-				} else if ssmparameterresolver.TextContainsSsmParameters(value[0].InitialArgumentValue) || ssmparameterresolver.TextContainsSsmParameters(value[1].InitialArgumentValue) {
-					unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator's arguments can't contain SSM parameters", key))
-				} else if ssmparameterresolver.TextContainsSecureSsmParameters(value[0].InitialArgumentValue) || ssmparameterresolver.TextContainsSecureSsmParameters(value[1].InitialArgumentValue) {
-					unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator's arguments can't contain secure SSM parameters", key))
+				} else if message := validatePreconditionArguments(key, value); message != "" {
+					unrecognizedPreconditionList = append(unrecognizedPreconditionList, message)
 				} else if strings.Compare(value[0].InitialArgumentValue, "platformType") == 0 || strings.Compare(value[1].InitialArgumentValue, "platformType") == 0 {
 					// keep original logic for platformType variable
 					// Platform type of OS on the instance
@@ -568,6 +624,14 @@ func evaluatePreconditions(
 						isAllowed = false
 						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
 					}
+				} else if isFactVariable(value[0].InitialArgumentValue) || isFactVariable(value[1].InitialArgumentValue) {
+					isSatisfied, message := evaluateFactPrecondition(facts, key, value)
+					if message != "" {
+						unrecognizedPreconditionList = append(unrecognizedPreconditionList, message)
+					} else if !isSatisfied {
+						isAllowed = false
+						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
+					}
 				} else if strings.Compare(value[0].InitialArgumentValue, value[0].ResolvedArgumentValue) == 0 && strings.Compare(value[1].InitialArgumentValue, value[1].ResolvedArgumentValue) == 0 {
 					unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": at least one of operator's arguments must contain a valid document parameter", key))
 				} else {
@@ -578,6 +642,34 @@ func evaluatePreconditions(
 					}
 				}
 			}
+		case appconfig.PreconditionOperatorStringNotEquals,
+			appconfig.PreconditionOperatorStringLike,
+			appconfig.PreconditionOperatorVersionGreaterThanOrEqual,
+			appconfig.PreconditionOperatorVersionLessThan:
+			if len(value) != 2 {
+				unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator accepts exactly 2 arguments", key))
+			} else if message := validatePreconditionArguments(key, value); message != "" {
+				unrecognizedPreconditionList = append(unrecognizedPreconditionList, message)
+			} else if isSatisfied, message := evaluateFactPrecondition(facts, key, value); message != "" {
+				unrecognizedPreconditionList = append(unrecognizedPreconditionList, message)
+			} else if !isSatisfied {
+				// if the precondition is not satisfied, mark step for skip
+				isAllowed = false
+				unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
+			}
+		case appconfig.PreconditionOperatorInstancePercentage:
+			// the first argument is the selection key, the second one the percentage of the instances to select
+			if len(value) != 2 {
+				unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator accepts exactly 2 arguments", key))
+			} else if message := validatePreconditionArguments(key, value); message != "" {
+				unrecognizedPreconditionList = append(unrecognizedPreconditionList, message)
+			} else if isSelected, err := facts.isInstanceSelected(value[0].ResolvedArgumentValue, value[1].ResolvedArgumentValue); err != nil {
+				unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": %v", key, err))
+			} else if !isSelected {
+				// if the instance is not part of the selected percentage, mark step for skip
+				isAllowed = false
+				unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
+			}
 		default:
 			// mark for unrecognizedPrecondition (which is a form of failure)
 			unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("unrecognized operator: \"%s\"", key))
@@ -587,6 +679,55 @@ func evaluatePreconditions(
 	return isAllowed, unrecognizedPreconditionList
 }
 
+// validatePreconditionArguments returns the reason why the arguments of an operator are not accepted, if any
+func validatePreconditionArguments(operator string, arguments []contracts.PreconditionArgument) string {
+	for _, argument := range arguments {
+		if ssmparameterresolver.TextContainsSsmParameters(argument.InitialArgumentValue) {
+			return fmt.Sprintf("\"%s\": operator's arguments can't contain SSM parameters", operator)
+		}
+	}
+	for _, argument := range arguments {
+		if ssmparameterresolver.TextContainsSecureSsmParameters(argument.InitialArgumentValue) {
+			return fmt.Sprintf("\"%s\": operator's arguments can't contain secure SSM parameters", operator)
+		}
+	}
+	return ""
+}
+
+// evaluateFactPrecondition evaluates an operator whose first argument is compared to the second one, at least one
+// of them must be a variable or contain a document parameter. A message is returned when it cannot be evaluated.
+func evaluateFactPrecondition(facts *preconditionFacts, operator string, arguments []contracts.PreconditionArgument) (bool, string) {
+	hasVariable, isPlatformType := false, false
+	values := make([]string, len(arguments))
+	for index, argument := range arguments {
+		isVariable := isFactVariable(argument.InitialArgumentValue)
+		isPlatformType = isPlatformType || argument.InitialArgumentValue == platformTypeVariable
+		if !isVariable && argument.InitialArgumentValue == argument.ResolvedArgumentValue {
+			values[index] = argument.ResolvedArgumentValue
+			continue
+		}
+		hasVariable = true
+		value, err := facts.resolveArgument(argument)
+		if err != nil {
+			return false, fmt.Sprintf("\"%s\": %v", operator, err)
+		}
+		values[index] = value
+	}
+	if !hasVariable {
+		return false, fmt.Sprintf("\"%s\": at least one of operator's arguments must contain a valid document parameter or variable", operator)
+	}
+
+	// platform types are compared case insensitively, as by the StringEquals operator
+	if isPlatformType {
+		values[0], values[1] = strings.ToLower(values[0]), strings.ToLower(values[1])
+	}
+	isSatisfied, err := compareArguments(operator, values[0], values[1])
+	if err != nil {
+		return false, fmt.Sprintf("\"%s\": %v", operator, err)
+	}
+	return isSatisfied, ""
+}
+
 // Returns the Property's ID field from v1.2 documents or the Name field of a Step in v2.x documents.
 // This is required to generate the correct stdout/stderr s3 url
 func getStepName(pluginName string, config contracts.Configuration) (stepName string, err error) {
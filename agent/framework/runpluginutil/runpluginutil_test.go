@@ -25,6 +25,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -195,6 +196,8 @@ func TestRunPluginsWithMissingPluginHandler(t *testing.T) {
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 
 		pluginConfigs2[index] = pluginConfigs[name]
@@ -1112,6 +1115,8 @@ func TestRunPluginsWithMoreThanOnePrecondition(t *testing.T) {
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 		pluginFactory := new(PluginFactoryMock)
 		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
@@ -1227,6 +1232,8 @@ func TestRunPluginsWithUnrecognizedPreconditionOperator(t *testing.T) {
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 		pluginFactory := new(PluginFactoryMock)
 		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
@@ -1336,6 +1343,8 @@ func TestRunPluginsWithUnrecognizedPreconditionDuplicateVariable(t *testing.T) {
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -1446,6 +1455,8 @@ func TestRunPluginsWithUnrecognizedPreconditionDuplicateParameter(t *testing.T)
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -1556,6 +1567,8 @@ func TestRunPluginsWithUnrecognizedPreconditionDuplicateConstant(t *testing.T) {
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -1666,6 +1679,8 @@ func TestRunPluginsWithUnrecognizedPreconditionSSMParameter(t *testing.T) {
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -1776,6 +1791,8 @@ func TestRunPluginsWithUnrecognizedPreconditionSecureSSMParameter(t *testing.T)
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -1886,6 +1903,8 @@ func TestRunPluginsWithUnrecognizedPreconditionNoDocumentParameters(t *testing.T
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -1996,6 +2015,8 @@ func TestRunPluginsWithUnrecognizedPreconditionUnrecognizedParameter(t *testing.
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -2106,6 +2127,8 @@ func TestRunPluginsPlatformPreconditionWithDocumentParameters(t *testing.T) {
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -2944,6 +2967,8 @@ func TestRunPluginsWithMoreThanTwoPreconditionOperands(t *testing.T) {
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusFailed,
 			Error:          pluginError,
+			ErrorCategory:  contracts.ErrorCategoryValidation,
+			ErrorCode:      contracts.ErrorCodeUnsupported,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -3057,6 +3082,8 @@ func TestRunPluginsWithUnknownPlugin(t *testing.T) {
 				StandardError:  defaultOutput,
 				Status:         contracts.ResultStatusFailed,
 				Error:          pluginError,
+				ErrorCategory:  contracts.ErrorCategoryValidation,
+				ErrorCode:      contracts.ErrorCodeUnsupported,
 			}
 		} else {
 			pluginResults[name] = &contracts.PluginResult{
@@ -3230,6 +3257,19 @@ func TestGetStepNameError(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestGetStepExecutionOperationReportsUnsupportedPlatformReason(t *testing.T) {
+	ctx := contextmocks.NewMockDefault()
+	platformMessage := "Microsoft Windows Server 2019 Datacenter (Nano Server) v10.0.17763: Nano Server can only join a domain offline with djoin.exe"
+
+	operation, output := getStepExecutionOperation(ctx, appconfig.PluginNameDomainJoin, "joinDomain", true, false, platformMessage, true, false, nil, false)
+	assert.Equal(t, failStep, operation)
+	assert.Equal(t, "Plugin with name "+appconfig.PluginNameDomainJoin+" is not supported in current platform on "+platformMessage+". Step name: joinDomain", output)
+
+	operation, output = getStepExecutionOperation(ctx, appconfig.PluginNameDomainJoin, "joinDomain", true, false, platformMessage, true, true, nil, false)
+	assert.Equal(t, skipStep, operation)
+	assert.Equal(t, "Step execution skipped due to unsupported plugin: "+appconfig.PluginNameDomainJoin+" on "+platformMessage+". Step name: joinDomain", output)
+}
+
 func TestGetShouldPluginSkipBasedOnControlFlow(t *testing.T) {
 	pluginNames := []string{testPlugin0, testPlugin1, testPlugin2, testUnknownPlugin}
 	plugins := make([]contracts.PluginState, len(pluginNames))
@@ -3408,6 +3448,7 @@ func TestRunPluginWithOnFailureProperty168(t *testing.T) {
 			config contracts.Configuration,
 			cancelFlag task.CancelFlag,
 			ioConfig contracts.IOConfiguration,
+			outputSink iomodule.OutputSink,
 		) (res contracts.PluginResult) {
 			res.Code = defaultCode
 			res.Status = defaultStatus
@@ -3503,6 +3544,7 @@ func TestRunPluginWithOnFailureProperty169(t *testing.T) {
 			config contracts.Configuration,
 			cancelFlag task.CancelFlag,
 			ioConfig contracts.IOConfiguration,
+			outputSink iomodule.OutputSink,
 		) (res contracts.PluginResult) {
 			res.Code = defaultCode
 			res.Status = defaultStatus
@@ -3603,6 +3645,7 @@ func TestRunPluginWithOnFailureProperty1(t *testing.T) {
 			config contracts.Configuration,
 			cancelFlag task.CancelFlag,
 			ioConfig contracts.IOConfiguration,
+			outputSink iomodule.OutputSink,
 		) (res contracts.PluginResult) {
 			res.Code = defaultCode
 			res.Status = defaultStatus
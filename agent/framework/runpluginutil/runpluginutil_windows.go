@@ -30,7 +30,15 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 )
 
+// nanoServerUnsupportedPlugins maps the plugins that cannot run on Nano Server to the reason why
+var nanoServerUnsupportedPlugins = map[string]string{
+	appconfig.PluginNameDomainJoin:      "Nano Server can only join a domain offline with djoin.exe",
+	appconfig.PluginNameCloudWatch:      "AWS.CloudWatch.exe requires the full .NET Framework",
+	appconfig.PluginNameAwsApplications: "Windows Installer is not available",
+}
+
 // IsPluginSupportedForCurrentPlatform returns true if current platform supports the plugin with given name.
+// The message explains why an unsupported plugin cannot run.
 func IsPluginSupportedForCurrentPlatform(log log.T, pluginName string) (isKnown bool, isSupported bool, message string) {
 	platformName, _ := platform.PlatformName(log)
 	platformVersion, _ := platform.PlatformVersion(log)
@@ -42,9 +50,8 @@ func IsPluginSupportedForCurrentPlatform(log log.T, pluginName string) (isKnown
 	_, known := allPlugins[pluginName]
 	if isPlatformNanoServer, err := platform.IsPlatformNanoServer(log); err == nil && isPlatformNanoServer {
 		//if the current OS is Nano server, SSM Agent doesn't support the following plugins.
-		if pluginName == appconfig.PluginNameDomainJoin ||
-			pluginName == appconfig.PluginNameCloudWatch {
-			return known, false, fmt.Sprintf("%s (Nano Server) v%s", platformName, platformVersion)
+		if reason, unsupported := nanoServerUnsupportedPlugins[pluginName]; unsupported {
+			return known, false, fmt.Sprintf("%s (Nano Server) v%s: %s", platformName, platformVersion, reason)
 		}
 	}
 	return known, true, fmt.Sprintf("%s v%s", platformName, platformVersion)
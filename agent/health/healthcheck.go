@@ -21,14 +21,17 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/framework/moduleguard"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/circuitbreaker"
 	"github.com/aws/amazon-ssm-agent/agent/ssm"
 	"github.com/aws/amazon-ssm-agent/agent/version"
 	"github.com/aws/amazon-ssm-agent/common/identity"
 	"github.com/aws/amazon-ssm-agent/common/identity/availableidentities/ec2"
 	"github.com/aws/amazon-ssm-agent/common/identity/availableidentities/ecs"
 	"github.com/aws/amazon-ssm-agent/common/identity/availableidentities/onprem"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
 
 	"github.com/carlescere/scheduler"
 )
@@ -56,6 +59,8 @@ const (
 
 var healthModule *HealthCheck
 
+var newCircuitBreakerConfigClient = runtimeconfig.NewCircuitBreakerRuntimeConfigClient
+
 var newEC2Identity = func(log log.T) identity.IAgentIdentityInner {
 	if identityRef := ec2.NewEC2Identity(log); identityRef != nil {
 		return identityRef
@@ -106,7 +111,7 @@ func NewHealthCheck(context context.T, svc ssm.Service) *HealthCheck {
 // schedules recurrent updateHealth calls
 func (h *HealthCheck) scheduleUpdateHealth() {
 	var err error
-	if h.healthJob, err = scheduler.Every(h.scheduleInMinutes()).Minutes().Run(h.updateHealth); err != nil {
+	if h.healthJob, err = scheduler.Every(h.scheduleInMinutes()).Minutes().Run(moduleguard.Wrap(h.context.Log(), name, h.updateHealth)); err != nil {
 		h.context.Log().Errorf("unable to schedule health update. %v", err)
 	}
 	return
@@ -115,13 +120,6 @@ func (h *HealthCheck) scheduleUpdateHealth() {
 // updates SSM with the instance health information
 func (h *HealthCheck) updateHealth() {
 	log := h.context.Log()
-	defer func() {
-		if r := recover(); r != nil {
-			log.Errorf("Update health panic: \n%v", r)
-			log.Errorf("Stacktrace:\n%s", debug.Stack())
-		}
-	}()
-
 	log.Infof("%s reporting agent health.", name)
 
 	appConfig := h.context.AppConfig()
@@ -150,9 +148,32 @@ func (h *HealthCheck) updateHealth() {
 		h.healthCheckStopPolicy.ResetErrorCount()
 	}
 
+	h.reportCircuitBreakers()
 	return
 }
 
+// reportCircuitBreakers logs the circuit breakers of the endpoints that are not closed and saves the state of
+// the breakers for ssm-cli get-diagnostics
+func (h *HealthCheck) reportCircuitBreakers() {
+	log := h.context.Log()
+	states := circuitbreaker.States()
+	if len(states) == 0 {
+		return
+	}
+
+	config := runtimeconfig.CircuitBreakerRuntimeConfig{UpdatedAt: time.Now().UTC()}
+	for _, state := range states {
+		if state.State != circuitbreaker.StateClosed {
+			log.Warnf("Circuit breaker of %v is %v until %v, %v calls rejected",
+				state.Endpoint, state.State, state.OpenUntil.UTC().Format(time.RFC3339), state.RejectedCalls)
+		}
+		config.Breakers = append(config.Breakers, runtimeconfig.CircuitBreakerStatus(state))
+	}
+	if err := newCircuitBreakerConfigClient().SaveConfig(config); err != nil {
+		log.Debugf("Unable to save the circuit breaker states: %v", err)
+	}
+}
+
 // scheduleInMinutes Run Schedule In Minutes
 func (h *HealthCheck) scheduleInMinutes() int {
 	updateHealthFrequencyMins := 5
@@ -182,7 +203,7 @@ func (h *HealthCheck) ModuleName() string {
 func (h *HealthCheck) ModuleExecute() (err error) {
 	defer func() {
 		if msg := recover(); msg != nil {
-			h.context.Log().Errorf("health check ModuleExecute run panic: %v", msg)
+			moduleguard.ReportPanic(h.context.Log(), name, msg, debug.Stack())
 		}
 	}()
 	rand.Seed(time.Now().UTC().UnixNano())
@@ -191,16 +212,16 @@ func (h *HealthCheck) ModuleExecute() (err error) {
 	randomSeconds := rand.Intn(scheduleInMinutes * 60)
 
 	// First call updateHealth once
-	go h.updateHealth()
+	moduleguard.Go(h.context.Log(), name, h.updateHealth)
 
 	// Wait randomSeconds and schedule recurrent updateHealth calls
 	next := time.Duration(randomSeconds) * time.Second
-	go func(h *HealthCheck) {
+	moduleguard.Go(h.context.Log(), name, func() {
 		select {
 		case <-time.After(next):
-			go h.scheduleUpdateHealth()
+			h.scheduleUpdateHealth()
 		}
-	}(h)
+	})
 
 	return
 }
@@ -17,11 +17,17 @@
 // Package hibernation is responsible for the agent in hibernate mode.
 package hibernation
 
+import (
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
 func getHibernateSeelogConfig() string {
+	seelogConfigFilePath := appconfig.InstanceFolder("/var/log/amazon/ssm") + "/" + hibernateLogFile
+
 	var seelogConfig = `<seelog type="adaptive" mininterval="2000000" maxinterval="100000000" critmsgcount="500" minlevel="debug">
 		<outputs formatid="fmtinfo">
 			<console formatid="fmtinfo"/>
-			<rollingfile type="size" filename="/var/log/amazon/ssm/hibernate.log" maxsize="30000" maxrolls="2"/>
+			<rollingfile type="size" filename="` + seelogConfigFilePath + `" maxsize="30000" maxrolls="2"/>
 		</outputs>
 		<formats>
 			<format id="fmtinfo" format="%Date %Time %LEVEL %Msg%n"/>
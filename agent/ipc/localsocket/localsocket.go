@@ -0,0 +1,22 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package localsocket serves the local endpoints of the agent, such as the telemetry event stream, the message
+// metrics and the profiling endpoints, on a unix domain socket only root can connect to or on a named pipe only the
+// local system and the administrators can connect to.
+package localsocket
+
+import "time"
+
+// dialTimeout is the time used to check whether another process already serves the socket
+const dialTimeout = time.Second
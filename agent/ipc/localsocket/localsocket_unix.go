@@ -0,0 +1,67 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package localsocket
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// Listen listens on the unix domain socket at the path. The directory of the socket is created restricted to root, an
+// existing directory other users can access is refused since the socket is accessible with the permissions of the
+// umask until it is restricted. Listening fails when the socket is already served by another process.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("socket %v is already served by another process", path)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, appconfig.ReadWriteExecuteAccess); err != nil {
+		return nil, err
+	}
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if dirInfo.Mode().Perm()&^appconfig.ReadWriteExecuteAccess != 0 {
+		return nil, fmt.Errorf("directory %v of socket %v must only be accessible by root", dir, path)
+	}
+	// remove the stale socket file left by a previous agent run
+	if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(path, appconfig.ReadWriteAccess); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Dial connects to the unix domain socket at the path
+func Dial(path string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", path, timeout)
+}
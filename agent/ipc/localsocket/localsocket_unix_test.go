@@ -0,0 +1,69 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package localsocket
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListen_RootOnlySocket(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sockets")
+	path := filepath.Join(dir, "endpoint.sock")
+
+	listener, err := Listen(path)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	dirInfo, err := os.Stat(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(appconfig.ReadWriteExecuteAccess), dirInfo.Mode().Perm())
+	socketInfo, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.ModeSocket, socketInfo.Mode()&os.ModeSocket)
+	assert.Equal(t, os.FileMode(appconfig.ReadWriteAccess), socketInfo.Mode().Perm())
+
+	conn, err := Dial(path, dialTimeout)
+	assert.NoError(t, err)
+	conn.Close()
+}
+
+func TestListen_AlreadyServed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sockets", "endpoint.sock")
+	listener, err := Listen(path)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	_, err = Listen(path)
+
+	assert.Error(t, err)
+}
+
+func TestListen_SharedDirectoryRefused(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shared")
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	assert.NoError(t, os.Chmod(dir, 0755))
+
+	_, err := Listen(filepath.Join(dir, "endpoint.sock"))
+
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "endpoint.sock"))
+}
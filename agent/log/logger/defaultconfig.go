@@ -23,7 +23,7 @@ import (
 )
 
 func DefaultConfig() []byte {
-	return LoadLog(DefaultLogDir, LogFile, seelog.InfoStr)
+	return LoadLog(LogDir(), LogFile, seelog.InfoStr)
 }
 
 func LoadLog(defaultLogDir string, logFile string, debugStatus string) []byte {
@@ -0,0 +1,283 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package log is used to initialize the logger(main logger and event logger). This package should be imported once, usually from main, then call GetLogger.
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errorFingerprintFlushInterval is the interval at which the error counts are written to the audit log
+const errorFingerprintFlushInterval = 5 * time.Minute
+
+// Error classes of the fingerprints
+const (
+	ErrorClassPanic        = "Panic"
+	ErrorClassTimeout      = "Timeout"
+	ErrorClassAccessDenied = "AccessDenied"
+	ErrorClassCredentials  = "Credentials"
+	ErrorClassThrottling   = "Throttling"
+	ErrorClassNetwork      = "Network"
+	ErrorClassNotFound     = "NotFound"
+	ErrorClassInvalidInput = "InvalidInput"
+	ErrorClassOther        = "Other"
+
+	unknownErrorModule = "agent"
+)
+
+// errorClassKeywords lists the lower case keywords identifying each error class, the first matching class is used
+var errorClassKeywords = []struct {
+	class    string
+	keywords []string
+}{
+	{ErrorClassPanic, []string{"panic", "stacktrace"}},
+	{ErrorClassTimeout, []string{"timeout", "timed out", "deadline exceeded"}},
+	{ErrorClassAccessDenied, []string{"accessdenied", "access denied", "access is denied", "permission denied", "unauthorized", "forbidden"}},
+	{ErrorClassCredentials, []string{"credential", "token", "expired", "signature"}},
+	{ErrorClassThrottling, []string{"throttl", "rate exceeded", "too many requests"}},
+	{ErrorClassNetwork, []string{"connection", "dial tcp", "no such host", "eof", "tls", "websocket", "network"}},
+	{ErrorClassNotFound, []string{"not found", "notfound", "no such file", "does not exist", "cannot find"}},
+	{ErrorClassInvalidInput, []string{"invalid", "malformed", "unmarshal", "parse", "unexpected"}},
+}
+
+var (
+	// patterns of the variable parts of the error messages, replaced before hashing them
+	uuidPattern         = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	resourceIdPattern   = regexp.MustCompile(`\b[a-z]{1,4}-[0-9a-f]{8,17}\b`)
+	urlPattern          = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"']+`)
+	pathPattern         = regexp.MustCompile(`(?:[a-zA-Z]:\\|/)[^\s"':]*[/\\][^\s"':]*`)
+	quotedPattern       = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+	hexPattern          = regexp.MustCompile(`(?i)\b(?:0x)?[0-9a-f]*[0-9][0-9a-f]*[a-f][0-9a-f]*\b|\b[0-9a-f]*[a-f][0-9a-f]*[0-9][0-9a-f]*\b`)
+	numberPattern       = regexp.MustCompile(`\d+`)
+	identifierPattern   = regexp.MustCompile(`(?i)[0-9a-f]{8,}|\d{4,}`)
+	formatVerbPattern   = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	moduleInvalidChars  = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+	fingerprintIdSuffix = regexp.MustCompile(`^(.+)\.([A-Za-z]+)\.([0-9a-f]{8})$`)
+)
+
+// ErrorFingerprint is the count of the errors sharing a fingerprint in the audit logs
+type ErrorFingerprint struct {
+	ID        string
+	Module    string
+	Class     string
+	Hash      string
+	Count     int
+	FirstSeen string // date of the first audit log holding the fingerprint
+	LastSeen  string // date and time the fingerprint was last written to the audit logs
+}
+
+// errorFingerprintRecorder counts the logged errors by fingerprint until they are written to the audit log
+type errorFingerprintRecorder struct {
+	eventLog *EventLog
+	mutex    sync.Mutex
+	counts   map[string]int
+}
+
+// newErrorFingerprintRecorder creates a recorder writing the error counts to the event log
+func newErrorFingerprintRecorder(eventLog *EventLog) *errorFingerprintRecorder {
+	return &errorFingerprintRecorder{
+		eventLog: eventLog,
+		counts:   make(map[string]int),
+	}
+}
+
+// start writes the error counts to the audit log at each flush interval
+func (r *errorFingerprintRecorder) start() {
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				fmt.Println("Error fingerprint recorder panic: ", err)
+			}
+		}()
+		for range time.Tick(errorFingerprintFlushInterval) {
+			r.flush()
+		}
+	}()
+}
+
+// record counts an error logged with the given context, template is the message without its variable parts
+func (r *errorFingerprintRecorder) record(context []string, template string, message string) {
+	if r == nil {
+		return
+	}
+	id := errorFingerprintID(errorModule(context), classifyError(message), template)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.counts[id]++
+}
+
+// flush writes the error counts to the audit log, one event per fingerprint
+func (r *errorFingerprintRecorder) flush() {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	counts := r.counts
+	r.counts = make(map[string]int)
+	r.mutex.Unlock()
+
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		r.eventLog.loadEvent(AgentErrorFingerprintMessage, "", id+":"+strconv.Itoa(counts[id]))
+	}
+}
+
+// recordErrorf counts an error logged with a format string
+func (r *errorFingerprintRecorder) recordErrorf(context []string, format string, params []interface{}) {
+	if r == nil {
+		return
+	}
+	message := fmt.Sprintf(format, params...)
+	template := format
+	// formats like "%v" carry no information about the error, the message is used instead
+	if len(strings.TrimSpace(formatVerbPattern.ReplaceAllString(format, ""))) < 3 {
+		template = normalizeErrorMessage(message)
+	}
+	r.record(context, template, message)
+}
+
+// recordError counts an error logged with the default formats of its operands
+func (r *errorFingerprintRecorder) recordError(context []string, v []interface{}) {
+	if r == nil {
+		return
+	}
+	message := fmt.Sprint(v...)
+	r.record(context, normalizeErrorMessage(message), message)
+}
+
+// errorModule returns the innermost logger context naming a module, e.g. CredentialRefresher for
+// [CredentialRefresher]. Contexts holding identifiers like [associationId=...] are skipped.
+func errorModule(context []string) string {
+	for i := len(context) - 1; i >= 0; i-- {
+		name := strings.Trim(strings.TrimSpace(context[i]), "[]")
+		if name == "" || strings.Contains(name, "=") || identifierPattern.MatchString(name) {
+			continue
+		}
+		return moduleInvalidChars.ReplaceAllString(name, "_")
+	}
+	return unknownErrorModule
+}
+
+// classifyError returns the class of the error message
+func classifyError(message string) string {
+	message = strings.ToLower(message)
+	for _, rule := range errorClassKeywords {
+		for _, keyword := range rule.keywords {
+			if strings.Contains(message, keyword) {
+				return rule.class
+			}
+		}
+	}
+	return ErrorClassOther
+}
+
+// normalizeErrorMessage replaces the identifiers, paths, quoted values and numbers of the message by placeholders
+func normalizeErrorMessage(message string) string {
+	message = uuidPattern.ReplaceAllString(message, "<id>")
+	message = resourceIdPattern.ReplaceAllString(message, "<id>")
+	message = urlPattern.ReplaceAllString(message, "<url>")
+	message = pathPattern.ReplaceAllString(message, "<path>")
+	message = quotedPattern.ReplaceAllString(message, "<value>")
+	message = hexPattern.ReplaceAllString(message, "<hex>")
+	message = numberPattern.ReplaceAllString(message, "<n>")
+	return singleSpacePattern.ReplaceAllString(strings.TrimSpace(message), " ")
+}
+
+// errorFingerprintID returns the fingerprint <module>.<class>.<hash of the template>
+func errorFingerprintID(module string, class string, template string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(template))
+	return fmt.Sprintf("%s.%s.%08x", module, class, hash.Sum32())
+}
+
+// ReadErrorFingerprints returns the error fingerprints of the audit logs in the given directory, the most frequent first
+func ReadErrorFingerprints(auditDir string) ([]ErrorFingerprint, error) {
+	files, err := filepath.Glob(filepath.Join(auditDir, EventLogFile+"-*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	fingerprints := make(map[string]*ErrorFingerprint)
+	for _, file := range files {
+		date := strings.TrimPrefix(filepath.Base(file), EventLogFile+"-")
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			continue
+		}
+		if err := readErrorFingerprintEvents(file, date, fingerprints); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]ErrorFingerprint, 0, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		result = append(result, *fingerprint)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// readErrorFingerprintEvents adds the counts of the error fingerprint events of an audit log
+func readErrorFingerprintEvents(fileName string, date string, fingerprints map[string]*ErrorFingerprint) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// event lines are <type> <fingerprint>:<count> <version> <time>
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[0] != AgentErrorFingerprintMessage {
+			continue
+		}
+		separator := strings.LastIndex(fields[1], ":")
+		if separator < 0 {
+			continue
+		}
+		id := fields[1][:separator]
+		count, err := strconv.Atoi(fields[1][separator+1:])
+		parts := fingerprintIdSuffix.FindStringSubmatch(id)
+		if err != nil || count <= 0 || parts == nil {
+			continue
+		}
+		fingerprint, found := fingerprints[id]
+		if !found {
+			fingerprint = &ErrorFingerprint{ID: id, Module: parts[1], Class: parts[2], Hash: parts[3], FirstSeen: date}
+			fingerprints[id] = fingerprint
+		}
+		fingerprint.Count += count
+		fingerprint.LastSeen = date + " " + fields[3]
+	}
+	return scanner.Err()
+}
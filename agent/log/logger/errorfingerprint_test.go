@@ -0,0 +1,137 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package log is used to test the error fingerprints
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	testCases := map[string]string{
+		"Agent Telemetry panicked with: runtime error":                              ErrorClassPanic,
+		"failed to call ssm: RequestError: dial tcp 10.0.0.1:443: i/o timeout":      ErrorClassTimeout,
+		"AccessDeniedException: User is not authorized to perform ssm:UpdateStatus": ErrorClassAccessDenied,
+		"ExpiredTokenException: The security token included in the request expired": ErrorClassCredentials,
+		"ThrottlingException: Rate exceeded":                                        ErrorClassThrottling,
+		"dial tcp: lookup ssm.us-east-1.amazonaws.com: no such host":                ErrorClassNetwork,
+		"open /var/lib/amazon/ssm/document: no such file or directory":              ErrorClassNotFound,
+		"invalid character 'a' looking for beginning of value":                      ErrorClassInvalidInput,
+		"document worker exited with code 1":                                        ErrorClassOther,
+	}
+	for message, class := range testCases {
+		assert.Equal(t, class, classifyError(message), message)
+	}
+}
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	assert.Equal(t,
+		"failed to process command <id> on <id>: open <path>: no such file",
+		normalizeErrorMessage("failed to process command 2b196342-d7d4-436e-8f09-3883a1116ac3 on i-0123456789abcdef0: open /var/lib/amazon/ssm/file.json: no such file"))
+	assert.Equal(t,
+		"request to <url> failed after <n> attempts with <value>",
+		normalizeErrorMessage("request to https://ssm.us-east-1.amazonaws.com/ failed after 3 attempts with \"Bad Gateway\""))
+	assert.Equal(t,
+		normalizeErrorMessage("exit status 1 after 25 ms"),
+		normalizeErrorMessage("exit status 127 after 3 ms"))
+}
+
+func TestErrorModule(t *testing.T) {
+	assert.Equal(t, "CredentialRefresher", errorModule([]string{"[ssm-agent-worker]", "[CredentialRefresher]"}))
+	assert.Equal(t, "ssm-agent-worker", errorModule([]string{"[ssm-agent-worker]", "[instanceID=i-0123456789abcdef0]"}))
+	assert.Equal(t, "MessageService", errorModule([]string{"[MessageService]", "[2b196342-d7d4-436e-8f09-3883a1116ac3]"}))
+	assert.Equal(t, "Test_Module", errorModule([]string{"[Test Module]"}))
+	assert.Equal(t, unknownErrorModule, errorModule(nil))
+}
+
+func TestErrorFingerprintRecorder_RecordAndFlush(t *testing.T) {
+	eventLog := &EventLog{eventChannel: make(chan string, 10), timePattern: "15:04:05"}
+	recorder := newErrorFingerprintRecorder(eventLog)
+	context := []string{"[ssm-agent-worker]", "[MessageService]"}
+
+	recorder.recordErrorf(context, "failed to acknowledge message %v: %v", []interface{}{"1", "connection reset"})
+	recorder.recordErrorf(context, "failed to acknowledge message %v: %v", []interface{}{"2", "connection reset"})
+	recorder.recordErrorf(context, "%v", []interface{}{"cannot parse document 2b196342-d7d4-436e-8f09-3883a1116ac3"})
+	recorder.recordError(context, []interface{}{"cannot parse document ", "a8d3c1f2-1b2e-4c1f-9d1a-0f6b4e2d7c3a"})
+	recorder.flush()
+	recorder.flush()
+
+	assert.Len(t, eventLog.eventChannel, 2)
+	events := []string{<-eventLog.eventChannel, <-eventLog.eventChannel}
+	connectionId := errorFingerprintID("MessageService", ErrorClassNetwork, "failed to acknowledge message %v: %v")
+	parseId := errorFingerprintID("MessageService", ErrorClassInvalidInput, "cannot parse document <id>")
+	for _, event := range events {
+		fields := strings.Fields(event)
+		assert.Len(t, fields, 4)
+		assert.Equal(t, AgentErrorFingerprintMessage, fields[0])
+		assert.Contains(t, []string{connectionId + ":2", parseId + ":2"}, fields[1])
+	}
+	assert.NotEqual(t, events[0], events[1])
+}
+
+func TestErrorFingerprintRecorder_Disabled(t *testing.T) {
+	var recorder *errorFingerprintRecorder
+	assert.NotPanics(t, func() {
+		recorder.recordErrorf(nil, "failed %v", []interface{}{1})
+		recorder.recordError(nil, []interface{}{"failed"})
+		recorder.flush()
+	})
+}
+
+func TestReadErrorFingerprints(t *testing.T) {
+	auditDir, _ := ioutil.TempDir("", "audits")
+	defer os.RemoveAll(auditDir)
+
+	id1 := errorFingerprintID("MessageService", ErrorClassNetwork, "failed to acknowledge message %v: %v")
+	id2 := errorFingerprintID("ssm-agent-worker", ErrorClassOther, "document worker exited")
+	ioutil.WriteFile(filepath.Join(auditDir, EventLogFile+"-2022-05-01"), []byte("SchemaVersion=1\n"+
+		"agent_telemetry amazon-ssm-agent.start 3.1.0.0 10:00:00\n"+
+		AgentErrorFingerprintMessage+" "+id1+":3 3.1.0.0 10:05:00\n"+
+		AgentErrorFingerprintMessage+" "+id2+":1 3.1.0.0 10:05:00\n"+
+		"AuditSent=000000100"), 0600)
+	ioutil.WriteFile(filepath.Join(auditDir, EventLogFile+"-2022-05-02"), []byte("SchemaVersion=1\n"+
+		AgentErrorFingerprintMessage+" "+id1+":2 3.1.0.0 08:00:00\n"+
+		AgentErrorFingerprintMessage+" invalid:2 3.1.0.0 08:00:00\n"+
+		AgentErrorFingerprintMessage+" "+id2+":x 3.1.0.0 08:00:00\n"), 0600)
+	ioutil.WriteFile(filepath.Join(auditDir, EventLogFile+"-invalid"), []byte(AgentErrorFingerprintMessage+" "+id2+":5 3.1.0.0 08:00:00\n"), 0600)
+
+	fingerprints, err := ReadErrorFingerprints(auditDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []ErrorFingerprint{
+		{
+			ID:        id1,
+			Module:    "MessageService",
+			Class:     ErrorClassNetwork,
+			Hash:      id1[len(id1)-8:],
+			Count:     5,
+			FirstSeen: "2022-05-01",
+			LastSeen:  "2022-05-02 08:00:00",
+		},
+		{
+			ID:        id2,
+			Module:    "ssm-agent-worker",
+			Class:     ErrorClassOther,
+			Hash:      id2[len(id2)-8:],
+			Count:     1,
+			FirstSeen: "2022-05-01",
+			LastSeen:  "2022-05-01 10:05:00",
+		},
+	}, fingerprints)
+}
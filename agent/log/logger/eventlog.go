@@ -40,9 +40,13 @@ func GetEventLog(logFilePath string, logFileName string) (eventLog *EventLog) {
 		return eventLogInst
 	}
 	var maxRollsDay int = appconfig.DefaultAuditExpirationDay
+	var socketPath string
+	var errorFingerprints bool
 	config, err := appconfig.Config(true)
 	if err == nil {
 		maxRollsDay = config.Agent.AuditExpirationDay
+		socketPath = config.Agent.TelemetryEventSocketPath
+		errorFingerprints = config.Agent.ErrorFingerprints.Enabled
 	}
 	eventLogInstance := EventLog{
 		eventChannel:     make(chan string, 2),
@@ -56,6 +60,11 @@ func GetEventLog(logFilePath string, logFileName string) (eventLog *EventLog) {
 	}
 	eventLogInstance.init()
 	eventLogInstance.rotateEventLog()
+	eventLogInstance.startEventStreamer(socketPath)
+	if errorFingerprints {
+		eventLogInstance.fingerprints = newErrorFingerprintRecorder(&eventLogInstance)
+		eventLogInstance.fingerprints.start()
+	}
 	eventLogInst = &eventLogInstance
 	return eventLogInst
 }
@@ -70,6 +79,8 @@ type EventLog struct {
 	datePattern      string      // Date Pattern used for creating files
 	fileSystem       filesystem.IFileSystem
 	timePattern      string
+	streamer         *eventStreamer            // Streams events to local socket clients, nil when streaming is disabled
+	fingerprints     *errorFingerprintRecorder // Counts the logged errors by fingerprint, nil when the error fingerprints are disabled
 
 	currentFileName string // Name of File currently being used for logging in this instance. On app startup, it will be empty
 	nextFileName    string // Current day's log file name
@@ -115,13 +126,33 @@ func (e *EventLog) loadEvent(eventType string, agentVersion string, eventContent
 	if agentVersion == "" {
 		agentVersion = version.Version
 	}
+	e.streamer.publish(StreamedEvent{
+		EventType:    eventType,
+		Event:        eventContent,
+		AgentVersion: agentVersion,
+		Time:         time.Now(),
+	})
 	eventContent = eventType + " " + eventContent + " " + agentVersion + " " + time.Now().Format(e.timePattern) + "\n"
 	e.eventChannel <- eventContent
 }
 
-// close closes the buffered channel
+// close closes the buffered channel and the event streamer
 func (e *EventLog) close() {
 	close(e.eventChannel)
+	e.streamer.close()
+}
+
+// startEventStreamer starts streaming events to the local socket when a socket path is configured
+func (e *EventLog) startEventStreamer(socketPath string) {
+	if socketPath == "" {
+		return
+	}
+	streamer, err := newEventStreamer(socketPath)
+	if err != nil {
+		fmt.Println("Failed to start the telemetry event stream.", err)
+		return
+	}
+	e.streamer = streamer
 }
 
 // rotateEventLog checks for the deletion of files and deleted it
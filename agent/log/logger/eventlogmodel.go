@@ -15,15 +15,17 @@
 package logger
 
 const (
-	AmazonAgentStartEvent       = "amazon-ssm-agent.start" // Amazon core agent Start Event
-	AmazonAgentWorkerStartEvent = "ssm-agent-worker.start" //Amazon agent worker start event
+	AmazonAgentStartEvent       = "amazon-ssm-agent.start"      // Amazon core agent Start Event
+	AmazonAgentWorkerStartEvent = "ssm-agent-worker.start"      //Amazon agent worker start event
+	AmazonAgentRoleChangeEvent  = "amazon-ssm-agent.rolechange" // Amazon core agent instance profile role change event
 
 	AuditSentSuccessFooter = "AuditSent="
 	SchemaVersionHeader    = "SchemaVersion="
 
 	// Message types for the event log chunks created
-	AgentTelemetryMessage    = "agent_telemetry"     // AgentTelemetryMessage represents message type for number Legacy Agent/Agent Reboot
-	AgentUpdateResultMessage = "agent_update_result" // AgentUpdateResultMessage represents message type for number Agent update result
+	AgentTelemetryMessage        = "agent_telemetry"         // AgentTelemetryMessage represents message type for number Legacy Agent/Agent Reboot
+	AgentUpdateResultMessage     = "agent_update_result"     // AgentUpdateResultMessage represents message type for number Agent update result
+	AgentErrorFingerprintMessage = "agent_error_fingerprint" // AgentErrorFingerprintMessage represents message type for the error fingerprint counts
 
 	BytePatternLen = 9 // BytePatternLen represents length of last read byte section in footer of audit file. Considered the audit file max file size to be 999.99MB
 
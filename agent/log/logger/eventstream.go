@@ -0,0 +1,153 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/ipc/localsocket"
+)
+
+const (
+	// eventStreamWriteTimeout is the time given to a client to accept an event before it is disconnected
+	eventStreamWriteTimeout = 100 * time.Millisecond
+	// eventStreamBufferSize is the number of events waiting to be written to the clients, new events are
+	// dropped while the buffer is full
+	eventStreamBufferSize = 256
+)
+
+// StreamedEvent is the JSON representation of an event written to the telemetry event socket
+type StreamedEvent struct {
+	EventType    string    `json:"eventType"`
+	Event        string    `json:"event"`
+	AgentVersion string    `json:"agentVersion"`
+	Time         time.Time `json:"time"`
+}
+
+// eventStreamer broadcasts events to the clients connected to a local socket, a unix domain socket
+// or a named pipe on windows. Events are queued in a bounded buffer and written to the clients by a
+// separate go routine, so that event logging is never blocked by a client. Slow or disconnected clients
+// are dropped.
+type eventStreamer struct {
+	listener net.Listener
+	events   chan []byte
+	done     chan struct{}
+	doneOnce sync.Once
+	mutex    sync.Mutex
+	clients  map[net.Conn]struct{}
+}
+
+// newEventStreamer starts listening for clients on the given socket path.
+// Only one agent process serves the socket, the others return an error.
+func newEventStreamer(socketPath string) (*eventStreamer, error) {
+	listener, err := localsocket.Listen(socketPath)
+	if err != nil {
+		return nil, err
+	}
+
+	streamer := &eventStreamer{
+		listener: listener,
+		events:   make(chan []byte, eventStreamBufferSize),
+		done:     make(chan struct{}),
+		clients:  make(map[net.Conn]struct{}),
+	}
+	go streamer.acceptClients()
+	go streamer.broadcastEvents()
+	return streamer, nil
+}
+
+// acceptClients registers new clients until the listener is closed
+func (s *eventStreamer) acceptClients() {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println("Event streamer panic: ", r)
+		}
+	}()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.clients[conn] = struct{}{}
+		s.mutex.Unlock()
+	}
+}
+
+// publish queues the event as a single JSON line for the connected clients, the event is dropped
+// when the buffer is full
+func (s *eventStreamer) publish(event StreamedEvent) {
+	if s == nil {
+		return
+	}
+	content, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	content = append(content, '\n')
+
+	select {
+	case s.events <- content:
+	default:
+	}
+}
+
+// broadcastEvents writes the queued events to all connected clients until the streamer is closed
+func (s *eventStreamer) broadcastEvents() {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println("Event streamer panic: ", r)
+		}
+	}()
+	for {
+		select {
+		case <-s.done:
+			return
+		case content := <-s.events:
+			s.write(content)
+		}
+	}
+}
+
+// write writes the content to all connected clients, disconnecting the ones failing to accept it in time
+func (s *eventStreamer) write(content []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.clients {
+		conn.SetWriteDeadline(time.Now().Add(eventStreamWriteTimeout))
+		if _, err := conn.Write(content); err != nil {
+			conn.Close()
+			delete(s.clients, conn)
+		}
+	}
+}
+
+// close stops accepting clients and disconnects the connected ones, it can be called more than once
+func (s *eventStreamer) close() {
+	if s == nil {
+		return
+	}
+	s.listener.Close()
+	s.doneOnce.Do(func() { close(s.done) })
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.clients {
+		conn.Close()
+		delete(s.clients, conn)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStreamer_DropsEventsWhenBufferIsFull(t *testing.T) {
+	streamer := &eventStreamer{events: make(chan []byte, 1)}
+	streamer.publish(StreamedEvent{EventType: AgentTelemetryMessage, Event: AmazonAgentStartEvent})
+	streamer.publish(StreamedEvent{EventType: AgentTelemetryMessage, Event: AmazonAgentStartEvent})
+	assert.Equal(t, 1, len(streamer.events))
+}
+
+func TestEventStreamer_NilStreamerIsNoop(t *testing.T) {
+	var streamer *eventStreamer
+	streamer.publish(StreamedEvent{EventType: AgentTelemetryMessage})
+	streamer.close()
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStreamer_PublishesEventsAsJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventstream")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "events.sock")
+
+	streamer, err := newEventStreamer(socketPath)
+	assert.NoError(t, err)
+	defer streamer.close()
+
+	conn, err := net.Dial("unix", socketPath)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// wait for the client to be registered
+	for i := 0; i < 100; i++ {
+		streamer.mutex.Lock()
+		count := len(streamer.clients)
+		streamer.mutex.Unlock()
+		if count > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	streamer.publish(StreamedEvent{EventType: AgentTelemetryMessage, Event: AmazonAgentStartEvent, AgentVersion: "3.1.0.0"})
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	assert.NoError(t, err)
+
+	var event StreamedEvent
+	assert.NoError(t, json.Unmarshal(line, &event))
+	assert.Equal(t, AgentTelemetryMessage, event.EventType)
+	assert.Equal(t, AmazonAgentStartEvent, event.Event)
+	assert.Equal(t, "3.1.0.0", event.AgentVersion)
+}
+
+func TestEventStreamer_FailsWhenSocketIsServed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventstream")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "events.sock")
+
+	streamer, err := newEventStreamer(socketPath)
+	assert.NoError(t, err)
+	defer streamer.close()
+
+	_, err = newEventStreamer(socketPath)
+	assert.Error(t, err)
+}
+
+func TestEventStreamer_CloseTwice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventstream")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	streamer, err := newEventStreamer(filepath.Join(dir, "events.sock"))
+	assert.NoError(t, err)
+	streamer.close()
+	assert.NotPanics(t, streamer.close)
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/cihub/seelog"
 )
@@ -31,6 +32,14 @@ const (
 var loadedLogger log.T
 var PkgMutex = new(sync.RWMutex)
 
+// LogDir returns the directory of the agent logs, the Storage.LogPath of the app config when it is set
+func LogDir() string {
+	if config, err := appconfig.Config(false); err == nil && config.Storage.LogPath != "" {
+		return config.Storage.LogPath
+	}
+	return DefaultLogDir
+}
+
 func DefaultLogger() log.T {
 	if loadedLogger == nil {
 		fmt.Println("Initializing new default seelog logger")
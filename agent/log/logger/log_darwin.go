@@ -29,7 +29,7 @@ var (
 	// See Seelog documentation to customize the logger
 	DefaultSeelogConfigFilePath = appconfig.DefaultProgramFolder + appconfig.SeelogConfigFileName
 
-	DefaultLogDir = "/var/log/amazon/ssm"
+	DefaultLogDir = appconfig.InstanceFolder("/var/log/amazon/ssm")
 )
 
 // getLogConfigBytes reads and returns the seelog configs from the config file path if present
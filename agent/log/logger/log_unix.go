@@ -24,7 +24,7 @@ import (
 )
 
 var (
-	DefaultLogDir = "/var/log/amazon/ssm"
+	DefaultLogDir = appconfig.InstanceFolder("/var/log/amazon/ssm")
 )
 
 // DefaultSeelogConfigFilePath specifies the default seelog location
@@ -53,7 +53,7 @@ func getLogConfigBytes() (logConfigBytes []byte) {
 }
 
 func defaultConfigForExe() []byte {
-	return LoadLog(DefaultLogDir, exeLogFileName()+".log", seelog.InfoStr)
+	return LoadLog(LogDir(), exeLogFileName()+".log", seelog.InfoStr)
 }
 
 func exeLogFileName() string {
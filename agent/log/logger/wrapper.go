@@ -100,6 +100,7 @@ func (w *Wrapper) Warnf(format string, params ...interface{}) error {
 // Errorf formats message according to format specifier
 // and writes to log with level = Error.
 func (w *Wrapper) Errorf(format string, params ...interface{}) error {
+	w.errorFingerprints().recordErrorf(w.context(), format, params)
 	format, params = w.Format.Filterf(format, params...)
 
 	w.M.RLock()
@@ -110,6 +111,7 @@ func (w *Wrapper) Errorf(format string, params ...interface{}) error {
 // Criticalf formats message according to format specifier
 // and writes to log with level = Critical.
 func (w *Wrapper) Criticalf(format string, params ...interface{}) error {
+	w.errorFingerprints().recordErrorf(w.context(), format, params)
 	format, params = w.Format.Filterf(format, params...)
 
 	w.M.RLock()
@@ -159,6 +161,7 @@ func (w *Wrapper) Warn(v ...interface{}) error {
 // Error formats message using the default formats for its operands
 // and writes to log with level = Error
 func (w *Wrapper) Error(v ...interface{}) error {
+	w.errorFingerprints().recordError(w.context(), v)
 	v = w.Format.Filter(v...)
 
 	w.M.RLock()
@@ -169,6 +172,7 @@ func (w *Wrapper) Error(v ...interface{}) error {
 // Critical formats message using the default formats for its operands
 // and writes to log with level = Critical
 func (w *Wrapper) Critical(v ...interface{}) error {
+	w.errorFingerprints().recordError(w.context(), v)
 	v = w.Format.Filter(v...)
 
 	w.M.RLock()
@@ -178,6 +182,7 @@ func (w *Wrapper) Critical(v ...interface{}) error {
 
 // Flush flushes all the messages in the logger.
 func (w *Wrapper) Flush() {
+	w.errorFingerprints().flush()
 	w.M.Lock()
 	defer w.M.Unlock()
 	w.Delegate.BaseLoggerInstance.Flush()
@@ -215,3 +220,19 @@ func (w *Wrapper) ReplaceDelegate(newLogger log.BasicT) {
 	w.Delegate.BaseLoggerInstance = newLogger
 	w.Delegate.BaseLoggerInstance.Info("Logger Replaced. New Logger Used to log the message")
 }
+
+// errorFingerprints returns the recorder of the error fingerprints, nil when they are disabled
+func (w *Wrapper) errorFingerprints() *errorFingerprintRecorder {
+	if w.EventLogger == nil {
+		return nil
+	}
+	return w.EventLogger.fingerprints
+}
+
+// context returns the context of the logger
+func (w *Wrapper) context() []string {
+	if filter, ok := w.Format.(*ContextFormatFilter); ok {
+		return filter.Context
+	}
+	return nil
+}
@@ -69,7 +69,7 @@ func withContext(logger seelog.LoggerInterface, context ...string) (contextLogge
 	contextLogger = &logpkg.Wrapper{Format: formatFilter,
 		M:           pkgMutex,
 		Delegate:    loggerInstance,
-		EventLogger: logpkg.GetEventLog(logpkg.DefaultLogDir, logpkg.EventLogFile),
+		EventLogger: logpkg.GetEventLog(logpkg.LogDir(), logpkg.EventLogFile),
 	}
 	setStackDepth(logger)
 	return contextLogger
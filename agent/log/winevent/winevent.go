@@ -0,0 +1,104 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package winevent writes the agent operational events to a dedicated Windows Event Log channel.
+// Writing events is a no-op on other platforms.
+package winevent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// LogName is the name of the dedicated event log shown in Event Viewer
+	LogName = "Amazon SSM Agent"
+	// SourceName is the name of the event source registered in the dedicated event log
+	SourceName = "AmazonSSMAgent"
+)
+
+// EventID identifies the type of an operational event
+type EventID uint32
+
+const (
+	// ServiceStarted is written when the agent service is started
+	ServiceStarted EventID = 1000
+	// RegistrationChanged is written when the instance registration is created or cleared
+	RegistrationChanged EventID = 1001
+	// CommandExecuted is written when a command document completes
+	CommandExecuted EventID = 1002
+	// UpdateApplied is written when an agent update completes
+	UpdateApplied EventID = 1003
+	// SessionOpened is written when a session is opened
+	SessionOpened EventID = 1004
+	// RoleChanged is written when the instance profile role attached to the instance changes
+	RoleChanged EventID = 1005
+	// PluginCrashed is written when the process of a long running plugin crashes
+	PluginCrashed EventID = 1006
+)
+
+var eventDescriptions = map[EventID]string{
+	ServiceStarted:      "Amazon SSM Agent service started",
+	RegistrationChanged: "Amazon SSM Agent registration changed",
+	CommandExecuted:     "Amazon SSM Agent executed a command",
+	UpdateApplied:       "Amazon SSM Agent update completed",
+	SessionOpened:       "Amazon SSM Agent opened a session",
+	RoleChanged:         "Amazon SSM Agent detected an instance profile role change",
+	PluginCrashed:       "Amazon SSM Agent detected a crash of a long running plugin",
+}
+
+// Fields holds the structured data of an event
+type Fields map[string]string
+
+// Level is the severity of an event
+type Level int
+
+const (
+	// Info is used for successful operations
+	Info Level = iota
+	// Warning is used for operations that did not succeed
+	Warning
+)
+
+// Write writes an informational operational event to the agent event log
+func Write(log log.T, id EventID, fields Fields) {
+	write(log, Info, id, formatMessage(id, fields))
+}
+
+// WriteWarning writes a warning operational event to the agent event log
+func WriteWarning(log log.T, id EventID, fields Fields) {
+	write(log, Warning, id, formatMessage(id, fields))
+}
+
+// formatMessage formats the event as its description followed by one sorted key=value line per field
+func formatMessage(id EventID, fields Fields) string {
+	var builder strings.Builder
+	description, found := eventDescriptions[id]
+	if !found {
+		description = fmt.Sprintf("Amazon SSM Agent event %d", id)
+	}
+	builder.WriteString(description)
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		builder.WriteString(fmt.Sprintf("\n%s=%s", key, fields[key]))
+	}
+	return builder.String()
+}
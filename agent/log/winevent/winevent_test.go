@@ -0,0 +1,44 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package winevent
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatMessage(t *testing.T) {
+	message := formatMessage(CommandExecuted, Fields{
+		"status":       "Success",
+		"commandId":    "cmd-id",
+		"documentName": "AWS-RunShellScript",
+	})
+
+	assert.Equal(t, "Amazon SSM Agent executed a command\ncommandId=cmd-id\ndocumentName=AWS-RunShellScript\nstatus=Success", message)
+}
+
+func TestFormatMessage_UnknownEvent(t *testing.T) {
+	assert.Equal(t, "Amazon SSM Agent event 42", formatMessage(EventID(42), nil))
+}
+
+func TestWrite(t *testing.T) {
+	logMock := log.NewMockLog()
+
+	assert.NotPanics(t, func() {
+		Write(logMock, ServiceStarted, Fields{"version": "3.1.0.0"})
+		WriteWarning(logMock, UpdateApplied, Fields{"status": "Failed"})
+	})
+}
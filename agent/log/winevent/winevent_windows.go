@@ -0,0 +1,83 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package winevent
+
+import (
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+const (
+	eventLogRegistryPath = `SYSTEM\CurrentControlSet\Services\EventLog\`
+	eventMessageFile     = `%SystemRoot%\System32\EventCreate.exe`
+	eventTypesSupported  = eventlog.Error | eventlog.Warning | eventlog.Info
+)
+
+var (
+	eventLog     *eventlog.Log
+	eventLogErr  error
+	eventLogOnce sync.Once
+)
+
+// write writes the event to the dedicated event log, the event source is registered on first use
+func write(log log.T, level Level, id EventID, message string) {
+	eventLogOnce.Do(func() {
+		if eventLogErr = install(); eventLogErr == nil {
+			eventLog, eventLogErr = eventlog.Open(SourceName)
+		}
+	})
+	if eventLogErr != nil {
+		log.Debugf("Windows event log is not available: %v", eventLogErr)
+		return
+	}
+
+	var err error
+	if level == Warning {
+		err = eventLog.Warning(uint32(id), message)
+	} else {
+		err = eventLog.Info(uint32(id), message)
+	}
+	if err != nil {
+		log.Debugf("Failed to write event %v to the Windows event log: %v", id, err)
+	}
+}
+
+// install registers the event source in the dedicated event log if it does not exist yet
+func install() error {
+	sourceKeyPath := eventLogRegistryPath + LogName + `\` + SourceName
+	if key, err := registry.OpenKey(registry.LOCAL_MACHINE, sourceKeyPath, registry.QUERY_VALUE); err == nil {
+		key.Close()
+		return nil
+	}
+
+	key, _, err := registry.CreateKey(registry.LOCAL_MACHINE, sourceKeyPath, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+
+	if err = key.SetExpandStringValue("EventMessageFile", eventMessageFile); err != nil {
+		return err
+	}
+	if err = key.SetDWordValue("TypesSupported", eventTypesSupported); err != nil {
+		return err
+	}
+	return key.SetDWordValue("CustomSource", 1)
+}
@@ -0,0 +1,250 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package crashdump captures the crash dumps of the processes of the long running plugins. The dumps are written
+// by Windows Error Reporting on windows and by the kernel, or the program it pipes the cores to, on the other
+// platforms. They are copied into the diagnostics folder of the plugin, capped to the configured size.
+package crashdump
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// maxDumps is the number of dumps kept in the diagnostics folder of a plugin, the oldest are removed
+const maxDumps = 5
+
+var (
+	// dumpWaitTimeout is the time given to the dump of a crashed process to be written once it exited
+	dumpWaitTimeout = 30 * time.Second
+	// dumpPollInterval is the interval at which the dump of a crashed process is looked for
+	dumpPollInterval = time.Second
+)
+
+// Process describes a started process of a plugin
+type Process struct {
+	Pid        int
+	Executable string
+	// WorkingDir is the directory the process was started in, the relative core file names are relative to it
+	WorkingDir string
+}
+
+// Error is returned for the exit of a crashed process, it references the dump captured for the crash
+type Error struct {
+	Err error
+	// DumpPath is the captured dump, it is empty when no dump was captured
+	DumpPath string
+}
+
+// Error describes the exit of the process and its dump
+func (e *Error) Error() string {
+	if e.DumpPath == "" {
+		return fmt.Sprintf("%v, no crash dump was captured", e.Err)
+	}
+	return fmt.Sprintf("%v, crash dump %v", e.Err, e.DumpPath)
+}
+
+// CrashDump returns the captured dump
+func (e *Error) CrashDump() string {
+	return e.DumpPath
+}
+
+// Collector captures the crash dumps of the processes of a plugin
+type Collector struct {
+	log  log.T
+	name string
+	// Dir is the diagnostics folder of the plugin
+	Dir string
+	// MaxSize is the size in bytes above which the dumps are truncated, no dump is captured when 0
+	MaxSize int64
+}
+
+// NewCollector returns the collector of the crash dumps of the named plugin
+func NewCollector(context context.T, name string) *Collector {
+	shortInstanceID, _ := context.Identity().ShortInstanceID()
+	return &Collector{
+		log:  context.Log(),
+		name: name,
+		Dir: fileutil.BuildPath(appconfig.DefaultDataStorePath,
+			shortInstanceID,
+			appconfig.LongRunningPluginsLocation,
+			appconfig.LongRunningPluginsDiagnostics,
+			name),
+		MaxSize: int64(context.AppConfig().Agent.LongRunningCrashDumpMaxSizeMB) * 1024 * 1024,
+	}
+}
+
+func (c *Collector) enabled() bool {
+	return c != nil && c.MaxSize > 0
+}
+
+// Prepare asks the system to write the dumps of the executable before it is started
+func (c *Collector) Prepare(executable string) {
+	if !c.enabled() {
+		return
+	}
+	if err := fileutil.MakeDirsWithExecuteAccess(c.Dir); err != nil {
+		c.log.Warnf("Failed to create the diagnostics folder of %v: %v", c.name, err)
+		return
+	}
+	if err := prepare(c, executable); err != nil {
+		c.log.Warnf("Crash dumps of %v may not be captured: %v", c.name, err)
+	}
+}
+
+// Started limits the size of the dumps of a started process
+func (c *Collector) Started(process Process) {
+	if !c.enabled() {
+		return
+	}
+	if err := limitDumpSize(process.Pid, c.MaxSize); err != nil {
+		c.log.Debugf("Failed to limit the size of the crash dumps of %v: %v", c.name, err)
+	}
+}
+
+// Collect captures the dump of an exited process which crashed and returns its exit error referencing the dump,
+// the exit error is returned unchanged when the process did not crash
+func (c *Collector) Collect(process Process, state *os.ProcessState, exitErr error) error {
+	if state == nil || !crashed(state) {
+		return exitErr
+	}
+	if exitErr == nil {
+		exitErr = fmt.Errorf("exit code %v", state.ExitCode())
+	}
+	crashErr := &Error{Err: exitErr}
+	if !c.enabled() {
+		return crashErr
+	}
+
+	source, err := c.waitForDump(process)
+	if err == nil {
+		crashErr.DumpPath, err = c.capture(process, source)
+	}
+	if err != nil {
+		c.log.Warnf("No crash dump was captured for process %v of %v: %v", process.Pid, c.name, err)
+		return crashErr
+	}
+	c.log.Infof("Captured crash dump %v of process %v of %v", crashErr.DumpPath, process.Pid, c.name)
+	c.prune()
+	return crashErr
+}
+
+// waitForDump returns the dump written for the process once its size stopped growing
+func (c *Collector) waitForDump(process Process) (string, error) {
+	patterns, err := dumpPatterns(c, process)
+	if err != nil {
+		return "", err
+	}
+	deadline := time.Now().Add(dumpWaitTimeout)
+	var candidate string
+	lastSize := int64(-1)
+	for {
+		if found := newestMatch(patterns); found != "" {
+			if info, err := os.Stat(found); err == nil {
+				if found == candidate && info.Size() == lastSize && info.Size() > 0 {
+					return found, nil
+				}
+				candidate, lastSize = found, info.Size()
+			}
+		}
+		if time.Now().After(deadline) {
+			if candidate != "" {
+				return candidate, nil
+			}
+			return "", fmt.Errorf("no dump matching %v was written", strings.Join(patterns, ", "))
+		}
+		time.Sleep(dumpPollInterval)
+	}
+}
+
+// newestMatch returns the last modified file matching one of the glob patterns
+func newestMatch(patterns []string) (newest string) {
+	var newestTime time.Time
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			if newest == "" || info.ModTime().After(newestTime) {
+				newest, newestTime = match, info.ModTime()
+			}
+		}
+	}
+	return newest
+}
+
+// capture copies the dump into the diagnostics folder, truncated to the maximum size, the dumps already written
+// in the diagnostics folder are renamed and truncated in place
+func (c *Collector) capture(process Process, source string) (string, error) {
+	destination := filepath.Join(c.Dir, fmt.Sprintf("%v.%v", time.Now().UTC().Format("20060102T150405Z"), filepath.Base(source)))
+	if filepath.Dir(source) == filepath.Clean(c.Dir) {
+		if err := os.Rename(source, destination); err != nil {
+			return "", err
+		}
+		if info, err := os.Stat(destination); err == nil && info.Size() > c.MaxSize {
+			c.log.Warnf("Crash dump of process %v of %v is truncated to %v bytes", process.Pid, c.name, c.MaxSize)
+			return destination, os.Truncate(destination, c.MaxSize)
+		}
+		return destination, nil
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, appconfig.ReadWriteAccess)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	written, err := io.CopyN(out, in, c.MaxSize)
+	if err == io.EOF {
+		return destination, nil
+	}
+	if err != nil {
+		os.Remove(destination)
+		return "", err
+	}
+	if written == c.MaxSize {
+		c.log.Warnf("Crash dump of process %v of %v is truncated to %v bytes", process.Pid, c.name, c.MaxSize)
+	}
+	return destination, nil
+}
+
+// prune removes the oldest dumps of the diagnostics folder beyond maxDumps
+func (c *Collector) prune() {
+	names, err := fileutil.GetFileNames(c.Dir)
+	if err != nil || len(names) <= maxDumps {
+		return
+	}
+	// the captured dumps are prefixed with their capture time
+	sort.Strings(names)
+	for _, name := range names[:len(names)-maxDumps] {
+		if err := os.Remove(filepath.Join(c.Dir, name)); err != nil {
+			c.log.Debugf("Failed to remove the crash dump %v of %v: %v", name, c.name, err)
+		}
+	}
+}
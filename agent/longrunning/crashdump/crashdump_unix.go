@@ -0,0 +1,127 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package crashdump
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var (
+	// corePatternFile holds the template of the core file names on linux, a template starting with a pipe sends
+	// the cores to a program instead
+	corePatternFile = "/proc/sys/kernel/core_pattern"
+	// coreUsesPidFile appends the process id to the core file names which do not have it when it holds 1
+	coreUsesPidFile = "/proc/sys/kernel/core_uses_pid"
+	// systemdCoredumpDir is where systemd-coredump stores the cores piped to it
+	systemdCoredumpDir = "/var/lib/systemd/coredump"
+)
+
+// crashSignals are the signals killing a process because of a fault of the process itself
+var crashSignals = map[syscall.Signal]bool{
+	syscall.SIGABRT: true,
+	syscall.SIGBUS:  true,
+	syscall.SIGFPE:  true,
+	syscall.SIGILL:  true,
+	syscall.SIGQUIT: true,
+	syscall.SIGSEGV: true,
+	syscall.SIGSYS:  true,
+	syscall.SIGTRAP: true,
+}
+
+// corePatternTokens matches the specifiers of the core file name templates
+var corePatternTokens = regexp.MustCompile(`%.`)
+
+// crashed returns true if the process was killed by a fault signal or dumped a core
+func crashed(state *os.ProcessState) bool {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && (crashSignals[status.Signal()] || status.CoreDump())
+}
+
+// prepare has nothing to do, the kernel writes the cores of all processes
+func prepare(c *Collector, executable string) error {
+	return nil
+}
+
+// dumpPatterns returns the glob patterns of the core file written for the process
+func dumpPatterns(c *Collector, process Process) ([]string, error) {
+	pattern, err := corePattern()
+	if err != nil {
+		return nil, err
+	}
+	pid := strconv.Itoa(process.Pid)
+	if strings.HasPrefix(pattern, "|") {
+		if !strings.Contains(pattern, "systemd-coredump") {
+			return nil, fmt.Errorf("cores are sent to %v", strings.TrimPrefix(pattern, "|"))
+		}
+		return []string{filepath.Join(systemdCoredumpDir, "core.*."+pid+".*")}, nil
+	}
+
+	hasPid := false
+	glob := corePatternTokens.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch token {
+		case "%%":
+			return "%"
+		case "%p", "%P":
+			hasPid = true
+			return pid
+		default:
+			return "*"
+		}
+	})
+	if !hasPid && usesPid() {
+		glob += "." + pid
+	}
+	if !filepath.IsAbs(glob) {
+		workingDir := process.WorkingDir
+		if workingDir == "" {
+			workingDir, _ = os.Getwd()
+		}
+		glob = filepath.Join(workingDir, glob)
+	}
+	return []string{glob}, nil
+}
+
+// corePattern returns the template of the core file names
+func corePattern() (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		content, err := ioutil.ReadFile(corePatternFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(content)), nil
+	case "darwin":
+		return "/cores/core.%P", nil
+	default:
+		// default kern.corefile of the bsd systems
+		return "%N.core", nil
+	}
+}
+
+// usesPid returns true if linux appends the process id to the core file names
+func usesPid() bool {
+	content, err := ioutil.ReadFile(coreUsesPidFile)
+	return err == nil && strings.TrimSpace(string(content)) == "1"
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package crashdump
+
+import (
+	"errors"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCollector(t *testing.T, corePattern string) *Collector {
+	if runtime.GOOS != "linux" {
+		t.Skip("the core pattern is only read on linux")
+	}
+	patternFile := filepath.Join(t.TempDir(), "core_pattern")
+	assert.NoError(t, ioutil.WriteFile(patternFile, []byte(corePattern+"\n"), 0600))
+	previousPatternFile, previousUsesPidFile := corePatternFile, coreUsesPidFile
+	previousTimeout, previousInterval := dumpWaitTimeout, dumpPollInterval
+	corePatternFile, coreUsesPidFile = patternFile, filepath.Join(t.TempDir(), "missing")
+	dumpWaitTimeout, dumpPollInterval = 100*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() {
+		corePatternFile, coreUsesPidFile = previousPatternFile, previousUsesPidFile
+		dumpWaitTimeout, dumpPollInterval = previousTimeout, previousInterval
+	})
+	return &Collector{log: logmocks.NewMockLog(), name: "collector", Dir: t.TempDir(), MaxSize: 4}
+}
+
+// runProcess runs the shell command in the working directory and returns its process
+func runProcess(t *testing.T, workingDir, command string) (Process, *exec.Cmd, error) {
+	cmd := exec.Command("/bin/sh", "-c", command)
+	cmd.Dir = workingDir
+	err := cmd.Run()
+	return Process{Pid: cmd.Process.Pid, Executable: "/bin/sh", WorkingDir: workingDir}, cmd, err
+}
+
+func TestCollect_CapturesTruncatedCore(t *testing.T) {
+	c := newTestCollector(t, "core.%e.%p")
+	workingDir := t.TempDir()
+	process, cmd, exitErr := runProcess(t, workingDir, "kill -SEGV $$")
+	// the core written by the kernel
+	core := filepath.Join(workingDir, "core.sh."+strconv.Itoa(process.Pid))
+	assert.NoError(t, ioutil.WriteFile(core, []byte("ELF core"), 0600))
+
+	err := c.Collect(process, cmd.ProcessState, exitErr)
+
+	var crashErr *Error
+	assert.True(t, errors.As(err, &crashErr))
+	assert.Equal(t, exitErr, crashErr.Err)
+	assert.Equal(t, c.Dir, filepath.Dir(crashErr.DumpPath))
+	assert.Contains(t, err.Error(), crashErr.DumpPath)
+	content, _ := ioutil.ReadFile(crashErr.DumpPath)
+	assert.Equal(t, "ELF ", string(content))
+}
+
+func TestCollect_NoCoreWritten(t *testing.T) {
+	c := newTestCollector(t, "core.%p")
+	process, cmd, exitErr := runProcess(t, t.TempDir(), "kill -ABRT $$")
+
+	err := c.Collect(process, cmd.ProcessState, exitErr)
+
+	var crashErr *Error
+	assert.True(t, errors.As(err, &crashErr))
+	assert.Empty(t, crashErr.DumpPath)
+	assert.Contains(t, err.Error(), "no crash dump was captured")
+}
+
+func TestCollect_NotCrashed(t *testing.T) {
+	c := newTestCollector(t, "core")
+	process, cmd, exitErr := runProcess(t, t.TempDir(), "exit 3")
+	assert.Equal(t, exitErr, c.Collect(process, cmd.ProcessState, exitErr))
+
+	process, cmd, exitErr = runProcess(t, t.TempDir(), "kill -KILL $$")
+	assert.Equal(t, exitErr, c.Collect(process, cmd.ProcessState, exitErr))
+}
+
+func TestDumpPatterns(t *testing.T) {
+	process := Process{Pid: 42, WorkingDir: "/opt/collector"}
+
+	c := newTestCollector(t, "|/usr/lib/systemd/systemd-coredump %P %u %g %s %t %c %h")
+	patterns, err := dumpPatterns(c, process)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(systemdCoredumpDir, "core.*.42.*")}, patterns)
+
+	c = newTestCollector(t, "|/usr/share/apport/apport %p %s %c")
+	_, err = dumpPatterns(c, process)
+	assert.Error(t, err)
+
+	c = newTestCollector(t, "/var/crash/core.%e.%p.%%")
+	patterns, err = dumpPatterns(c, process)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/var/crash/core.*.42.%"}, patterns)
+
+	c = newTestCollector(t, "core")
+	usesPid := filepath.Join(t.TempDir(), "core_uses_pid")
+	assert.NoError(t, ioutil.WriteFile(usesPid, []byte("1\n"), 0600))
+	coreUsesPidFile = usesPid
+	patterns, err = dumpPatterns(c, process)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/opt/collector/core.42"}, patterns)
+}
+
+func TestPrune_KeepsNewestDumps(t *testing.T) {
+	c := &Collector{log: logmocks.NewMockLog(), name: "collector", Dir: t.TempDir(), MaxSize: 4}
+	for i := 0; i < maxDumps+2; i++ {
+		name := "2026010" + strconv.Itoa(i) + "T000000Z.core.1"
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(c.Dir, name), nil, 0600))
+	}
+
+	c.prune()
+
+	names, _ := ioutil.ReadDir(c.Dir)
+	assert.Len(t, names, maxDumps)
+	assert.Equal(t, "20260102T000000Z.core.1", names[0].Name())
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package crashdump
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	// localDumpsKey configures the dumps Windows Error Reporting writes for the crashed processes of an executable
+	localDumpsKey = `SOFTWARE\Microsoft\Windows\Windows Error Reporting\LocalDumps\`
+
+	// miniDump is the dump type of the executables, a full dump holds the whole memory of the process
+	miniDump = 1
+
+	// ntStatusError is the severity of the NTSTATUS exception codes a crashed process exits with
+	ntStatusError = 0xC0000000
+)
+
+// setLocalDumps is replaced by the tests
+var setLocalDumps = func(executableName, dumpFolder string) error {
+	key, _, err := registry.CreateKey(registry.LOCAL_MACHINE, localDumpsKey+executableName, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+	if err = key.SetExpandStringValue("DumpFolder", dumpFolder); err != nil {
+		return err
+	}
+	// the dumps beyond maxDumps are removed from the folder by the collector
+	if err = key.SetDWordValue("DumpCount", maxDumps+1); err != nil {
+		return err
+	}
+	return key.SetDWordValue("DumpType", miniDump)
+}
+
+// crashed returns true if the process exited with an exception code
+func crashed(state *os.ProcessState) bool {
+	return uint32(state.ExitCode())&ntStatusError == ntStatusError
+}
+
+// prepare asks Windows Error Reporting to write the dumps of the executable in the diagnostics folder
+func prepare(c *Collector, executable string) error {
+	if err := setLocalDumps(filepath.Base(executable), c.Dir); err != nil {
+		return fmt.Errorf("failed to configure the local dumps of Windows Error Reporting: %v", err)
+	}
+	return nil
+}
+
+// dumpPatterns returns the glob pattern of the dump Windows Error Reporting writes for the process
+func dumpPatterns(c *Collector, process Process) ([]string, error) {
+	return []string{filepath.Join(c.Dir, fmt.Sprintf("%v.%v.dmp", filepath.Base(process.Executable), process.Pid))}, nil
+}
+
+// limitDumpSize has nothing to do, the mini dumps are small and larger dumps are truncated once captured
+func limitDumpSize(pid int, maxSize int64) error {
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package crashdump
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepare_ConfiguresLocalDumps(t *testing.T) {
+	previous := setLocalDumps
+	t.Cleanup(func() { setLocalDumps = previous })
+	var executableName, dumpFolder string
+	setLocalDumps = func(name, folder string) error {
+		executableName, dumpFolder = name, folder
+		return nil
+	}
+	c := &Collector{log: logmocks.NewMockLog(), name: "collector", Dir: t.TempDir(), MaxSize: 4}
+
+	c.Prepare(`C:\Program Files\Collector\collector.exe`)
+
+	assert.Equal(t, "collector.exe", executableName)
+	assert.Equal(t, c.Dir, dumpFolder)
+}
+
+func TestCapture_TruncatesDumpOfDiagnosticsFolder(t *testing.T) {
+	c := &Collector{log: logmocks.NewMockLog(), name: "collector", Dir: t.TempDir(), MaxSize: 4}
+	process := Process{Pid: 42, Executable: `C:\Program Files\Collector\collector.exe`}
+	patterns, err := dumpPatterns(c, process)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(c.Dir, "collector.exe.42.dmp")}, patterns)
+	// the dump written by Windows Error Reporting
+	assert.NoError(t, ioutil.WriteFile(patterns[0], []byte("MDMP dump"), 0600))
+
+	path, err := c.capture(process, patterns[0])
+
+	assert.NoError(t, err)
+	assert.Equal(t, c.Dir, filepath.Dir(path))
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "MDMP", string(content))
+	assert.NoFileExists(t, patterns[0])
+}
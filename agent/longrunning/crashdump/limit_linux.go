@@ -0,0 +1,27 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package crashdump
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// limitDumpSize sets the core size limit of the process, the kernel truncates the larger cores
+func limitDumpSize(pid int, maxSize int64) error {
+	limit := &unix.Rlimit{Cur: uint64(maxSize), Max: uint64(maxSize)}
+	return unix.Prlimit(pid, unix.RLIMIT_CORE, limit, nil)
+}
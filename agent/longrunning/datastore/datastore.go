@@ -15,7 +15,9 @@
 package datastore
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"sync"
@@ -24,8 +26,43 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
+	"github.com/aws/amazon-ssm-agent/agent/statefile"
 )
 
+// Schema is the format of the long running plugins data store. The version 1 is the map of the plugins by name,
+// the version 2 holds this map in its Plugins field to leave room for the version field. The version 3 also records
+// the stopped plugins, their desired state tells them apart from the running ones.
+var Schema = statefile.Schema{
+	Name: "long running plugins",
+	Migrations: []statefile.Migration{
+		func(state map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"Plugins": state}, nil
+		},
+		func(state map[string]interface{}) (map[string]interface{}, error) {
+			// the previous versions only recorded the running plugins
+			plugins, _ := state["Plugins"].(map[string]interface{})
+			for name, info := range plugins {
+				pluginInfo, ok := info.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("plugin %v is not an object", name)
+				}
+				pluginState, _ := pluginInfo["State"].(map[string]interface{})
+				if pluginState == nil {
+					pluginState = map[string]interface{}{}
+					pluginInfo["State"] = pluginState
+				}
+				pluginState["DesiredState"] = plugin.DesiredStateRunning
+			}
+			return state, nil
+		},
+	},
+}
+
+// pluginsState is the content of the data store file
+type pluginsState struct {
+	Plugins map[string]plugin.PluginInfo
+}
+
 // DataStore is the interface to provide utilities to read & write from a data store
 type DataStore interface {
 	Write(data map[string]plugin.PluginInfo, location, fileName string) error
@@ -56,9 +93,14 @@ func (fs *FsStore) Write(data map[string]plugin.PluginInfo, location, fileName s
 		}
 	}
 
-	if s, err = jsonutil.Marshal(data); err != nil {
+	if s, err = jsonutil.Marshal(pluginsState{Plugins: data}); err != nil {
 		return err
 	}
+	var stamped []byte
+	if stamped, err = Schema.Stamp([]byte(s)); err != nil {
+		return err
+	}
+	s = string(stamped)
 
 	//it's fine even if we overwrite the content of previous file
 	if _, err = fileutil.WriteIntoFileWithPermissions(fileName, s, os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
@@ -94,9 +136,17 @@ func (fs *FsStore) load(fileName string) (map[string]plugin.PluginInfo, error) {
 		return data, nil
 	}
 
-	err = jsonutil.UnmarshalFile(fileName, &data)
+	var content []byte
+	if content, err = ioutil.ReadFile(fileName); err != nil {
+		return data, err
+	}
+	if content, _, err = Schema.Upgrade(content); err != nil {
+		return data, err
+	}
+	var state pluginsState
+	err = json.Unmarshal(content, &state)
 
-	return data, err
+	return state.Plugins, err
 }
 
 // dataStoreFileExist returns true if the dataStore file exists in the given location
@@ -0,0 +1,77 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead_Version1DataStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "datastore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "longrunningplugins.json")
+	// data store written by the agents before the format was versioned
+	content := `{"awsCloudWatch":{"Name":"awsCloudWatch","Configuration":"{}","State":{"IsEnabled":true}}}`
+	assert.NoError(t, ioutil.WriteFile(fileName, []byte(content), 0600))
+
+	fs := &FsStore{}
+	data, err := fs.load(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]plugin.PluginInfo{
+		"awsCloudWatch": {Name: "awsCloudWatch", Configuration: "{}", State: plugin.PluginState{IsEnabled: true, DesiredState: plugin.DesiredStateRunning}},
+	}, data)
+}
+
+func TestRead_Version2DataStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "datastore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "longrunningplugins.json")
+	// the version 2 only recorded the running plugins, without their desired state
+	content := `{"StateVersion":2,"Plugins":{"collector":{"Name":"collector","Configuration":"","State":{"IsEnabled":true}},"nostate":{"Name":"nostate"}}}`
+	assert.NoError(t, ioutil.WriteFile(fileName, []byte(content), 0600))
+
+	fs := &FsStore{}
+	data, err := fs.load(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]plugin.PluginInfo{
+		"collector": {Name: "collector", State: plugin.PluginState{IsEnabled: true, DesiredState: plugin.DesiredStateRunning}},
+		"nostate":   {Name: "nostate", State: plugin.PluginState{DesiredState: plugin.DesiredStateRunning}},
+	}, data)
+}
+
+func TestWriteRead_CurrentVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "datastore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "longrunningplugins.json")
+	data := map[string]plugin.PluginInfo{"awsCloudWatch": {Name: "awsCloudWatch"}}
+
+	fs := &FsStore{}
+	assert.NoError(t, fs.Write(data, dir, fileName))
+	content, err := ioutil.ReadFile(fileName)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"StateVersion":3`)
+
+	read, err := fs.load(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, data, read)
+}
@@ -28,12 +28,14 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/moduleguard"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/datastore"
 	managerContracts "github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin/cloudwatch"
+	"github.com/aws/amazon-ssm-agent/agent/session/telemetry/metrics"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/agent/times"
 	"github.com/carlescere/scheduler"
@@ -64,6 +66,7 @@ type T interface {
 	contracts.ICoreModule
 	GetRegisteredPlugins() map[string]managerContracts.Plugin
 	StopPlugin(name string, cancelFlag task.CancelFlag) (err error)
+	StopPluginWithOptions(name string, options managerContracts.StopOptions, cancelFlag task.CancelFlag) (err error)
 	StartPlugin(name, configuration string, orchestrationDir string, cancelFlag task.CancelFlag, out iohandler.IOHandler) (err error)
 	EnsurePluginRegistered(name string, plugin managerContracts.Plugin) (err error)
 }
@@ -83,6 +86,9 @@ type Manager struct {
 	//stores all writeable information about currently long running plugins
 	runningPlugins map[string]managerContracts.PluginInfo
 
+	//stores the plugins stopped by a document, they are not started again with the agent
+	stoppedPlugins map[string]managerContracts.PluginInfo
+
 	//stores references of all the registered long running plugins
 	registeredPlugins map[string]managerContracts.Plugin
 
@@ -94,6 +100,24 @@ type Manager struct {
 
 	//ec2config's configuration xml parser
 	ec2ConfigXmlParser cloudwatch.Ec2ConfigXmlParser
+
+	//publishes the pipeline status of long running plugins with the agent telemetry metrics
+	metricsService metrics.ICloudWatchService
+
+	//time until which the plugins started at boot wait for their readiness gates
+	readinessDeadline time.Time
+
+	//interrupts the wait for the readiness gates when the manager stops
+	readinessCancelFlag task.CancelFlag
+
+	//stores the result of the last health check of the long running plugins
+	healthChecks healthChecks
+
+	//stores when the long running plugins were last checked and their consecutive failed health checks
+	healthCheckSchedule healthCheckSchedule
+
+	//stops answering the status queries of ssm-cli when the manager stops
+	statusQueryStop chan struct{}
 }
 
 var singletonInstance *Manager
@@ -140,9 +164,12 @@ func EnsureInitialization(context context.T) {
 			startPlugin:        startPluginPool,
 			stopPlugin:         stopPluginPool,
 			runningPlugins:     plugins,
+			stoppedPlugins:     map[string]managerContracts.PluginInfo{},
 			registeredPlugins:  regPlugins,
 			fileSysUtil:        fileSysUtil,
 			ec2ConfigXmlParser: ec2ConfigXmlParser,
+
+			readinessCancelFlag: task.NewChanneledCancelFlag(),
 		}
 	})
 
@@ -175,22 +202,22 @@ func (m *Manager) ModuleExecute() (err error) {
 	log := m.context.Log()
 	defer func() {
 		if msg := recover(); msg != nil {
-			log.Errorf("long running manager ModuleExecute run panic: %v", msg)
+			moduleguard.ReportPanic(log, Name, msg, debug.Stack())
 		}
 	}()
 	log.Infof("starting long running plugin manager")
+	m.readinessDeadline = time.Now().Add(time.Duration(m.context.AppConfig().Agent.LongRunningReadinessTimeoutSeconds) * time.Second)
 	//read from data store to determine if there were any previously long running plugins which need to be started again
 	var dataStoreMap map[string]managerContracts.PluginInfo
 	dataStoreMap, err = m.dataStore.Read()
-	if len(dataStoreMap) != 0 {
-		m.runningPlugins = dataStoreMap
-	}
-
 	if err != nil {
 		log.Errorf("%s is exiting - unable to read from data store", m.ModuleName())
 		return
 	}
 
+	//adopt or kill the processes left running by the previous agent
+	adopted := m.reconcilePlugins(dataStoreMap)
+
 	//revive older long running plugins if they were running before
 	if len(m.runningPlugins) > 0 {
 		for pluginName, pluginInfo := range m.runningPlugins {
@@ -206,6 +233,11 @@ func (m *Manager) ModuleExecute() (err error) {
 				//skip CW plugin since it'll be handled later
 				continue
 			}
+			if adopted[pluginName] {
+				log.Infof("Detected %s as a previously executing long running plugin. Its process is still running", p.Info.Name)
+				m.registeredPlugins[pluginName] = p
+				continue
+			}
 			log.Infof("Detected %s as a previously executing long running plugin. Starting that plugin again", p.Info.Name)
 			//submit the work of long running plugin to the task pool
 			/*
@@ -215,7 +247,7 @@ func (m *Manager) ModuleExecute() (err error) {
 			//todo: orchestrationDir should be set accordingly - 3rd parameter for Start
 			shortInstanceID, _ := m.context.Identity().ShortInstanceID()
 			orchestrationRootDir := filepath.Join(
-				appconfig.DefaultDataStorePath,
+				appconfig.OrchestrationStorePath,
 				shortInstanceID,
 				appconfig.DefaultDocumentRootDirName,
 				m.context.AppConfig().Agent.OrchestrationRootDir)
@@ -229,28 +261,83 @@ func (m *Manager) ModuleExecute() (err error) {
 			out := iohandler.NewDefaultIOHandler(m.context, ioConfig)
 			defer out.Close()
 			out.Init(p.Info.Name)
+			m.waitForReadiness(pluginName, p.Handler)
 			p.Handler.Start(p.Info.Configuration, "", task.NewChanneledCancelFlag(), out)
 			out.Close()
+			markStarted(log, p.Handler, &pluginInfo, time.Now())
+			m.runningPlugins[pluginName] = pluginInfo
 			m.registeredPlugins[pluginName] = p
 		}
 	} else {
 		log.Infof("there aren't any long running plugin to execute")
 
 	}
+	if len(dataStoreMap) != 0 {
+		if err = m.dataStore.Write(m.pluginRecords()); err != nil {
+			log.Errorf("Failed to update datastore - because of %s", err)
+		}
+	}
+
+	m.startAgentStartedPlugins()
 
 	//if no previous CW has been found, start a new one based on the json config
 	if isPlatformSupported(log, appconfig.PluginNameCloudWatch) {
 		m.configCloudWatch()
 	}
 
+	//answer the status queries of ssm-cli
+	m.statusQueryStop = make(chan struct{})
+	statusQueryStop, createStatusChannel := m.statusQueryStop, statusChannelCreator(m.context)
+	moduleguard.Go(log, Name, func() { m.serveStatusQueries(statusQueryStop, createStatusChannel) })
+
 	//schedule periodic health check of all long running plugins
-	if m.managingLifeCycleJob, err = scheduler.Every(PollFrequencyMinutes).Minutes().Run(m.ensurePluginsAreRunning); err != nil {
+	if m.managingLifeCycleJob, err = scheduler.Every(int(m.healthCheckTick() / time.Second)).Seconds().Run(moduleguard.Wrap(log, Name, m.ensurePluginsAreRunning)); err != nil {
 		log.Errorf("unable to schedule long running plugins manager. %v", err)
 	}
 
 	return
 }
 
+// agentStartedPlugin is implemented by the long running plugins started by the manager without a document,
+// like the executables declared under the plugin folder
+type agentStartedPlugin interface {
+	StartsWithAgent() bool
+}
+
+// startAgentStartedPlugins starts the registered plugins started without a document which are not running yet
+func (m *Manager) startAgentStartedPlugins() {
+	log := m.context.Log()
+	for pluginName, p := range m.registeredPlugins {
+		if _, isRunning := m.runningPlugins[pluginName]; isRunning {
+			continue
+		}
+		if !startsWithAgent(p.Handler) {
+			continue
+		}
+		if _, isStopped := m.stoppedPlugins[pluginName]; isStopped {
+			log.Infof("Long running plugin %s was stopped by a document, it is not started with the agent", pluginName)
+			continue
+		}
+		m.waitForReadiness(pluginName, p.Handler)
+		log.Infof("Starting long running plugin %s with the agent", pluginName)
+		shortInstanceID, _ := m.context.Identity().ShortInstanceID()
+		orchestrationDir := fileutil.BuildPath(filepath.Join(
+			appconfig.OrchestrationStorePath,
+			shortInstanceID,
+			appconfig.DefaultDocumentRootDirName,
+			m.context.AppConfig().Agent.OrchestrationRootDir))
+		ioConfig := contracts.IOConfiguration{
+			OrchestrationDirectory: orchestrationDir,
+		}
+		out := iohandler.NewDefaultIOHandler(m.context, ioConfig)
+		out.Init(pluginName)
+		if err := m.StartPlugin(pluginName, p.Info.Configuration, "", task.NewChanneledCancelFlag(), out); err != nil {
+			log.Errorf("Failed to start long running plugin %s: %v", pluginName, err)
+		}
+		out.Close()
+	}
+}
+
 // RequestStop handles the termination of the long running plugin manager
 func (m *Manager) ModuleStop() (err error) {
 	var wg sync.WaitGroup
@@ -258,6 +345,16 @@ func (m *Manager) ModuleStop() (err error) {
 	// stop lifecycle management job that monitors execution of all long running plugins
 	m.stopLifeCycleManagementJob()
 
+	// stop waiting for the readiness gates of the plugins started at boot
+	if m.readinessCancelFlag != nil {
+		m.readinessCancelFlag.Set(task.Canceled)
+	}
+
+	// stop answering the status queries
+	if m.statusQueryStop != nil {
+		close(m.statusQueryStop)
+	}
+
 	//there is no need to stop all individual plugins - because when the task pools are shutdown - all corresponding
 	//jobs are also shutdown accordingly.
 
@@ -391,6 +488,9 @@ func (m *Manager) configCloudWatch() {
 		out := iohandler.NewDefaultIOHandler(m.context, ioConfig)
 		defer out.Close()
 		out.Init(appconfig.PluginNameCloudWatch)
+		if p, isRegistered := m.registeredPlugins[appconfig.PluginNameCloudWatch]; isRegistered {
+			m.waitForReadiness(appconfig.PluginNameCloudWatch, p.Handler)
+		}
 		if err = m.StartPlugin(
 			appconfig.PluginNameCloudWatch,
 			config,
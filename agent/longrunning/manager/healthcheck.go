@@ -0,0 +1,134 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
+)
+
+// defaultHealthCheckInterval is the interval of the health checks of the plugins without a configured one
+const defaultHealthCheckInterval = PollFrequencyMinutes * time.Minute
+
+// healthCheckPolicy is how the health of a plugin is checked, no timeout is applied when the timeout is 0
+type healthCheckPolicy struct {
+	interval           time.Duration
+	timeout            time.Duration
+	unhealthyThreshold int
+}
+
+// healthCheckConfigurer is implemented by the long running plugins applying the health check timeout and unhealthy
+// threshold themselves, like the executables killing their timed out health check command
+type healthCheckConfigurer interface {
+	ConfigureHealthCheck(timeout time.Duration, unhealthyThreshold int)
+}
+
+// healthCheckSchedule holds when the plugins were last checked and their consecutive failed health checks
+type healthCheckSchedule struct {
+	lock       sync.Mutex
+	lastChecks map[string]time.Time
+	failures   map[string]int
+}
+
+// healthCheckPolicy returns the health check of the agent configuration for the plugin, the defaults are used for
+// the values it leaves to 0
+func (m *Manager) healthCheckPolicy(name string) healthCheckPolicy {
+	policy := healthCheckPolicy{interval: defaultHealthCheckInterval, unhealthyThreshold: 1}
+	healthCheck, found := m.context.AppConfig().Mds.PluginHealthCheck(name)
+	if !found {
+		return policy
+	}
+	if healthCheck.IntervalSeconds > 0 {
+		policy.interval = time.Duration(healthCheck.IntervalSeconds) * time.Second
+	}
+	if healthCheck.UnhealthyThreshold > 0 {
+		policy.unhealthyThreshold = healthCheck.UnhealthyThreshold
+	}
+	policy.timeout = time.Duration(healthCheck.TimeoutSeconds) * time.Second
+	return policy
+}
+
+// healthCheckTick returns the interval of the job checking the health of the plugins, each plugin is checked on
+// the first tick after its interval elapsed
+func (m *Manager) healthCheckTick() time.Duration {
+	tick := defaultHealthCheckInterval
+	for _, healthCheck := range m.context.AppConfig().Mds.LongRunningPluginHealthChecks {
+		if interval := time.Duration(healthCheck.IntervalSeconds) * time.Second; interval > 0 && interval < tick {
+			tick = interval
+		}
+	}
+	return tick
+}
+
+// isHealthCheckDue returns true if the interval of the plugin elapsed since it was last checked and records the
+// check, half a tick is tolerated for the jitter of the job
+func (m *Manager) isHealthCheckDue(name string, policy healthCheckPolicy, tick time.Duration, now time.Time) bool {
+	m.healthCheckSchedule.lock.Lock()
+	defer m.healthCheckSchedule.lock.Unlock()
+	if m.healthCheckSchedule.lastChecks == nil {
+		m.healthCheckSchedule.lastChecks = map[string]time.Time{}
+	}
+	if lastCheck, checked := m.healthCheckSchedule.lastChecks[name]; checked && now.Sub(lastCheck) < policy.interval-tick/2 {
+		return false
+	}
+	m.healthCheckSchedule.lastChecks[name] = now
+	return true
+}
+
+// checkHealth returns the result of the health check of the plugin and whether it reached its unhealthy threshold
+// and has to be restarted. The plugins not applying the policy themselves are given the timeout of the policy,
+// a health check still running afterwards is abandoned and counted as failed.
+func (m *Manager) checkHealth(name string, handler plugin.LongRunningPlugin, policy healthCheckPolicy) (healthy bool, restart bool) {
+	if configurer, ok := handler.(healthCheckConfigurer); ok {
+		configurer.ConfigureHealthCheck(policy.timeout, policy.unhealthyThreshold)
+		healthy = handler.IsRunning()
+		return healthy, !healthy
+	}
+
+	healthy = isRunningWithin(handler, policy.timeout)
+	m.healthCheckSchedule.lock.Lock()
+	defer m.healthCheckSchedule.lock.Unlock()
+	if m.healthCheckSchedule.failures == nil {
+		m.healthCheckSchedule.failures = map[string]int{}
+	}
+	if healthy {
+		delete(m.healthCheckSchedule.failures, name)
+		return true, false
+	}
+	m.healthCheckSchedule.failures[name]++
+	failures := m.healthCheckSchedule.failures[name]
+	m.context.Log().Warnf("Health check %d of %d of %v failed", failures, policy.unhealthyThreshold, name)
+	if failures < policy.unhealthyThreshold {
+		return false, false
+	}
+	delete(m.healthCheckSchedule.failures, name)
+	return false, true
+}
+
+// isRunningWithin returns the result of IsRunning, false when it does not return within the timeout
+func isRunningWithin(handler plugin.LongRunningPlugin, timeout time.Duration) bool {
+	if timeout <= 0 {
+		return handler.IsRunning()
+	}
+	result := make(chan bool, 1)
+	go func() { result <- handler.IsRunning() }()
+	select {
+	case running := <-result:
+		return running
+	case <-time.After(timeout):
+		return false
+	}
+}
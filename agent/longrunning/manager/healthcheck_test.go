@@ -0,0 +1,118 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSlowPlugin struct {
+	fakeStoppedPlugin
+	delay time.Duration
+}
+
+func (f *fakeSlowPlugin) IsRunning() bool {
+	time.Sleep(f.delay)
+	return true
+}
+
+type fakeConfiguredPlugin struct {
+	fakeStoppedPlugin
+	timeout            time.Duration
+	unhealthyThreshold int
+}
+
+func (f *fakeConfiguredPlugin) ConfigureHealthCheck(timeout time.Duration, unhealthyThreshold int) {
+	f.timeout, f.unhealthyThreshold = timeout, unhealthyThreshold
+}
+
+func newHealthCheckManager(healthChecks ...appconfig.LongRunningPluginHealthCheck) *Manager {
+	config := appconfig.DefaultConfig()
+	config.Mds.LongRunningPluginHealthChecks = healthChecks
+	return &Manager{context: contextmocks.NewMockDefaultWithConfig(config)}
+}
+
+func TestHealthCheckPolicy(t *testing.T) {
+	m := newHealthCheckManager(
+		appconfig.LongRunningPluginHealthCheck{Plugin: "aws:cloudWatch", IntervalSeconds: 120, TimeoutSeconds: 30, UnhealthyThreshold: 3},
+		appconfig.LongRunningPluginHealthCheck{Plugin: "exporter", UnhealthyThreshold: 2})
+
+	assert.Equal(t, healthCheckPolicy{interval: 2 * time.Minute, timeout: 30 * time.Second, unhealthyThreshold: 3}, m.healthCheckPolicy("AWS:CloudWatch"))
+	assert.Equal(t, healthCheckPolicy{interval: defaultHealthCheckInterval, unhealthyThreshold: 2}, m.healthCheckPolicy("exporter"))
+	assert.Equal(t, healthCheckPolicy{interval: defaultHealthCheckInterval, unhealthyThreshold: 1}, m.healthCheckPolicy("other"))
+	assert.Equal(t, 2*time.Minute, m.healthCheckTick())
+	assert.Equal(t, defaultHealthCheckInterval, newHealthCheckManager().healthCheckTick())
+}
+
+func TestIsHealthCheckDue(t *testing.T) {
+	m := newHealthCheckManager()
+	policy := healthCheckPolicy{interval: 10 * time.Minute, unhealthyThreshold: 1}
+	tick := 2 * time.Minute
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, m.isHealthCheckDue("exporter", policy, tick, now))
+	assert.False(t, m.isHealthCheckDue("exporter", policy, tick, now.Add(8*time.Minute)))
+	// the tick running a little early still checks the plugin
+	assert.True(t, m.isHealthCheckDue("exporter", policy, tick, now.Add(10*time.Minute-time.Second)))
+	assert.True(t, m.isHealthCheckDue("other", policy, tick, now.Add(11*time.Minute)))
+}
+
+func TestCheckHealth_UnhealthyThreshold(t *testing.T) {
+	m := newHealthCheckManager()
+	handler := &fakeStoppedPlugin{stopped: true}
+	policy := healthCheckPolicy{interval: time.Minute, unhealthyThreshold: 2}
+
+	healthy, restart := m.checkHealth("exporter", handler, policy)
+	assert.False(t, healthy)
+	assert.False(t, restart, "the first failed health check is tolerated")
+
+	// a successful health check resets the count of failed health checks
+	handler.stopped = false
+	_, restart = m.checkHealth("exporter", handler, policy)
+	assert.False(t, restart)
+	handler.stopped = true
+	_, restart = m.checkHealth("exporter", handler, policy)
+	assert.False(t, restart)
+
+	_, restart = m.checkHealth("exporter", handler, policy)
+	assert.True(t, restart)
+}
+
+func TestCheckHealth_Timeout(t *testing.T) {
+	m := newHealthCheckManager()
+	policy := healthCheckPolicy{interval: time.Minute, timeout: 10 * time.Millisecond, unhealthyThreshold: 1}
+
+	healthy, restart := m.checkHealth("slow", &fakeSlowPlugin{delay: time.Second}, policy)
+	assert.False(t, healthy)
+	assert.True(t, restart)
+
+	healthy, _ = m.checkHealth("slow", &fakeSlowPlugin{}, policy)
+	assert.True(t, healthy)
+}
+
+func TestCheckHealth_AppliedByPlugin(t *testing.T) {
+	m := newHealthCheckManager()
+	handler := &fakeConfiguredPlugin{fakeStoppedPlugin: fakeStoppedPlugin{stopped: true}}
+
+	healthy, restart := m.checkHealth("exporter", handler, healthCheckPolicy{interval: time.Minute, timeout: time.Minute, unhealthyThreshold: 3})
+	assert.False(t, healthy)
+	assert.True(t, restart, "the plugin counts its failed health checks itself")
+	assert.Equal(t, time.Minute, handler.timeout)
+	assert.Equal(t, 3, handler.unhealthyThreshold)
+}
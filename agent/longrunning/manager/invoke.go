@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -8,6 +9,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
 
@@ -55,8 +57,21 @@ func Invoke(context context.T, pluginID string, res *contracts.PluginResult, orc
 		enablePlugin(context, orchestrationDir, pluginID, lrpm, cancelFlag, property, res)
 
 	case "Disabled":
+		// the output of the invoker holds the stop options of the document when disabling
+		var options plugin.StopOptions
+		if property != "" {
+			if err = json.Unmarshal([]byte(property), &options); err != nil {
+				log.Debugf("No stop options in %s output, stopping it right away: %v", pluginID, err)
+				options = plugin.StopOptions{}
+			}
+		}
+		if err = options.Validate(); err != nil {
+			log.Error(err)
+			CreateResult(err.Error(), contracts.ResultStatusFailed, res)
+			return
+		}
 		log.Infof("Disabling %s", lrpName)
-		if err = lrpm.StopPlugin(lrpName, cancelFlag); err != nil {
+		if err = lrpm.StopPluginWithOptions(lrpName, options, cancelFlag); err != nil {
 			log.Errorf("Unable to stop the plugin - %s: %s", pluginID, err.Error())
 			CreateResult(fmt.Sprintf("Encountered error while stopping the plugin: %s", err.Error()),
 				contracts.ResultStatusFailed, res)
@@ -82,7 +97,7 @@ func enablePlugin(context context.T, orchestrationDirectory string, pluginID str
 
 	//loading properties as string since aws:cloudWatch uses properties as string. Properties has new configuration for cloudwatch plugin.
 	//For more details refer to AWS-ConfigureCloudWatch
-	// TODO cannot check if string is a valid json for cloudwatch
+	// the cloudwatch plugin validates the configuration before starting cloudwatch.exe
 	//stop the plugin before reconfiguring it
 	log.Debugf("Stopping %s - before applying new configuration", lrpName)
 	if err := lrpm.StopPlugin(lrpName, cancelFlag); err != nil {
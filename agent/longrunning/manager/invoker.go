@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin/cloudwatch"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -32,6 +34,12 @@ import (
 
 // StopPlugin stops a given plugin from executing
 func (m *Manager) StopPlugin(name string, cancelFlag task.CancelFlag) (err error) {
+	return m.StopPluginWithOptions(name, plugin.StopOptions{StopType: plugin.StopTypeKill}, cancelFlag)
+}
+
+// StopPluginWithOptions stops a given plugin from executing, the plugins which cannot drain are killed
+// even when a drain is requested
+func (m *Manager) StopPluginWithOptions(name string, options plugin.StopOptions, cancelFlag task.CancelFlag) (err error) {
 
 	//todo: if plugin wasn't even running then stop will have no effect -> for those cases we can return something for a better plugin level status
 
@@ -40,27 +48,35 @@ func (m *Manager) StopPlugin(name string, cancelFlag task.CancelFlag) (err error
 
 	log := m.context.Log()
 	p, isRegisteredPlugin := m.registeredPlugins[name]
-	_, isRunningPlugin := m.runningPlugins[name]
+	info, isRunningPlugin := m.runningPlugins[name]
 
 	if isRegisteredPlugin && isRunningPlugin {
 		//stop the plugin
-		if err = p.Handler.Stop(cancelFlag); err != nil {
+		if err = stopHandler(log, name, p.Handler, options, cancelFlag); err != nil {
 			// check if cloud watch exe process has been terminated manually
 			if p.Handler.IsRunning() {
 				log.Errorf("Failed to stop long running plugin - %s because of %s", name, err)
 				return
 			}
 		}
-		//remove the entry from the map of running plugins
+		//move the entry to the map of stopped plugins, the plugin is not started again with the agent
 		delete(m.runningPlugins, name)
+		info.State.DesiredState = plugin.DesiredStateStopped
+		info.State.ProcessID, info.State.ProcessExecutable = 0, ""
+		if m.stoppedPlugins == nil {
+			m.stoppedPlugins = make(map[string]plugin.PluginInfo)
+		}
+		m.stoppedPlugins[name] = info
 
-		if err = m.dataStore.Write(m.runningPlugins); err != nil {
+		if err = m.dataStore.Write(m.pluginRecords()); err != nil {
 			log.Errorf("Failed to update datastore - because of %s", err)
 		}
 
 		// Update the config file to "IsEnabled": "false"
-		if err = cloudwatch.Instance().Disable(); err != nil {
-			log.Errorf("Failed to update config file - because of %s", err)
+		if name == appconfig.PluginNameCloudWatch {
+			if err = cloudwatch.Instance().Disable(); err != nil {
+				log.Errorf("Failed to update config file - because of %s", err)
+			}
 		}
 
 		return
@@ -70,6 +86,20 @@ func (m *Manager) StopPlugin(name string, cancelFlag task.CancelFlag) (err error
 	return nil
 }
 
+// stopHandler drains the plugin when requested and supported, it kills it otherwise
+func stopHandler(log log.T, name string, handler plugin.LongRunningPlugin, options plugin.StopOptions, cancelFlag task.CancelFlag) error {
+	if options.StopType != plugin.StopTypeDrain {
+		return handler.Stop(cancelFlag)
+	}
+	drainable, ok := handler.(plugin.DrainablePlugin)
+	if !ok {
+		log.Warnf("%s cannot drain, stopping it right away", name)
+		return handler.Stop(cancelFlag)
+	}
+	log.Infof("Draining %s for up to %v before stopping it", name, options.DrainTimeout())
+	return drainable.StopWithDrain(cancelFlag, options.DrainTimeout())
+}
+
 // StartPlugin starts the given plugin with the given configuration
 func (m *Manager) StartPlugin(name, configuration string, orchestrationDir string, cancelFlag task.CancelFlag, out iohandler.IOHandler) (err error) {
 	lock.Lock()
@@ -94,21 +124,28 @@ func (m *Manager) StartPlugin(name, configuration string, orchestrationDir strin
 	}
 
 	//edit the plugin info
+	startTime := time.Now()
 	p.Info.State = plugin.PluginState{
-		LastConfigurationModifiedTime: time.Now(),
+		LastConfigurationModifiedTime: startTime,
 		IsEnabled:                     true,
 	}
+	markStarted(log, p.Handler, &p.Info, startTime)
 
 	// TODO move persisting out of executing logic
 	m.runningPlugins[name] = p.Info
+	delete(m.stoppedPlugins, name)
 	log.Debugf("Persisting info about %s in datastore", p.Info.Name)
 
 	// TODO separate persist part and actual running part
-	if err = m.dataStore.Write(m.runningPlugins); err != nil {
+	if err = m.dataStore.Write(m.pluginRecords()); err != nil {
 		err = fmt.Errorf("Failed to persist info about %s in datastore because : %s", p.Info.Name, err.Error())
 		log.Errorf(err.Error())
 	}
 
+	if name != appconfig.PluginNameCloudWatch {
+		return
+	}
+
 	// Update the config file with new configuration
 	var engineConfigurationParser cloudwatch.EngineConfigurationParser
 	json.Unmarshal([]byte(p.Info.Configuration), &engineConfigurationParser)
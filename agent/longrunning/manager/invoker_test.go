@@ -0,0 +1,83 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStoppedPlugin struct {
+	stopped bool
+}
+
+func (f *fakeStoppedPlugin) IsRunning() bool { return !f.stopped }
+
+func (f *fakeStoppedPlugin) Start(string, string, task.CancelFlag, iohandler.IOHandler) error {
+	return nil
+}
+
+func (f *fakeStoppedPlugin) Stop(task.CancelFlag) error {
+	f.stopped = true
+	return nil
+}
+
+type fakeDrainedPlugin struct {
+	fakeStoppedPlugin
+	drainTimeout time.Duration
+}
+
+func (f *fakeDrainedPlugin) StopWithDrain(cancelFlag task.CancelFlag, drainTimeout time.Duration) error {
+	f.drainTimeout = drainTimeout
+	return nil
+}
+
+func TestStopHandler_Kill(t *testing.T) {
+	handler := &fakeDrainedPlugin{}
+	assert.NoError(t, stopHandler(logmocks.NewMockLog(), "collector", handler, plugin.StopOptions{}, task.NewChanneledCancelFlag()))
+	assert.True(t, handler.stopped)
+	assert.Zero(t, handler.drainTimeout)
+}
+
+func TestStopHandler_Drain(t *testing.T) {
+	handler := &fakeDrainedPlugin{}
+	options := plugin.StopOptions{StopType: plugin.StopTypeDrain, DrainTimeoutSeconds: 30}
+	assert.NoError(t, stopHandler(logmocks.NewMockLog(), "collector", handler, options, task.NewChanneledCancelFlag()))
+	assert.False(t, handler.stopped)
+	assert.Equal(t, 30*time.Second, handler.drainTimeout)
+
+	options.DrainTimeoutSeconds = 0
+	assert.NoError(t, stopHandler(logmocks.NewMockLog(), "collector", handler, options, task.NewChanneledCancelFlag()))
+	assert.Equal(t, plugin.DefaultDrainTimeoutSeconds*time.Second, handler.drainTimeout)
+}
+
+func TestStopHandler_DrainNotSupported(t *testing.T) {
+	handler := &fakeStoppedPlugin{}
+	options := plugin.StopOptions{StopType: plugin.StopTypeDrain}
+	assert.NoError(t, stopHandler(logmocks.NewMockLog(), "collector", handler, options, task.NewChanneledCancelFlag()))
+	assert.True(t, handler.stopped)
+}
+
+func TestStopOptionsValidate(t *testing.T) {
+	assert.NoError(t, plugin.StopOptions{}.Validate())
+	assert.NoError(t, plugin.StopOptions{StopType: plugin.StopTypeDrain, DrainTimeoutSeconds: plugin.MaxDrainTimeoutSeconds}.Validate())
+	assert.Error(t, plugin.StopOptions{StopType: "Graceful"}.Validate())
+	assert.Error(t, plugin.StopOptions{StopType: plugin.StopTypeDrain, DrainTimeoutSeconds: -1}.Validate())
+	assert.Error(t, plugin.StopOptions{StopType: plugin.StopTypeDrain, DrainTimeoutSeconds: plugin.MaxDrainTimeoutSeconds + 1}.Validate())
+}
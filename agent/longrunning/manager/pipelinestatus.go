@@ -0,0 +1,86 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin/cloudwatch"
+	"github.com/aws/amazon-ssm-agent/agent/session/telemetry/metrics"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+	awscloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+const (
+	// pipelineStallThreshold is the lag above which the data pipeline of a long running plugin is reported stalled
+	pipelineStallThreshold = 2 * PollFrequencyMinutes * time.Minute
+
+	pipelineLagMetric    = "CloudWatchPluginPipelineLag"
+	pipelineErrorsMetric = "CloudWatchPluginPipelineErrors"
+
+	metricUnitSeconds = "Seconds"
+	metricUnitCount   = "Count"
+)
+
+// pipelineStatusReporter is implemented by the long running plugins able to report the progress of the data they ship
+type pipelineStatusReporter interface {
+	PipelineStatus() (cloudwatch.PipelineStatus, error)
+}
+
+var newMetricsService = func(m *Manager) metrics.ICloudWatchService {
+	return metrics.NewCloudWatchService(m.context)
+}
+
+// reportPipelineStatus logs the pipeline status of a running plugin, warns when its pipeline looks stalled and
+// publishes the status with the agent telemetry metrics when they are sent to CloudWatch
+func (m *Manager) reportPipelineStatus(name string, reporter pipelineStatusReporter) {
+	log := m.context.Log()
+	status, err := reporter.PipelineStatus()
+	if err != nil {
+		log.Debugf("Unable to read the pipeline status of %v: %v", name, err)
+		return
+	}
+	if status.Entries == 0 {
+		log.Debugf("%v has not logged its pipeline activity yet", name)
+		return
+	}
+
+	lastUpload := "never"
+	if !status.LastUpload.IsZero() {
+		lastUpload = status.LastUpload.Format(time.RFC3339)
+	}
+	if status.Lag > pipelineStallThreshold {
+		log.Warnf("The pipeline of %v looks stalled, last delivery %v, lag %v, %d errors in the last %v",
+			name, lastUpload, status.Lag.Round(time.Second), status.ErrorCount, PollFrequencyMinutes*time.Minute)
+	} else if status.ErrorCount > 0 {
+		log.Warnf("%v logged %d pipeline errors recently, last delivery %v, lag %v",
+			name, status.ErrorCount, lastUpload, status.Lag.Round(time.Second))
+	} else {
+		log.Infof("Pipeline of %v is healthy, last delivery %v, lag %v", name, lastUpload, status.Lag.Round(time.Second))
+	}
+
+	if !m.context.AppConfig().Agent.TelemetryMetricsToCloudWatch {
+		return
+	}
+	if m.metricsService == nil {
+		m.metricsService = newMetricsService(m)
+	}
+	metricData := []*awscloudwatch.MetricDatum{
+		m.metricsService.GenerateTelemetryMetricsWithUnit(pipelineLagMetric, status.Lag.Seconds(), metricUnitSeconds, version.Version),
+		m.metricsService.GenerateTelemetryMetricsWithUnit(pipelineErrorsMetric, float64(status.ErrorCount), metricUnitCount, version.Version),
+	}
+	if err = m.metricsService.PutMetrics(metricData); err != nil {
+		log.Warnf("Failed to publish the pipeline status of %v: %v", name, err)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin/cloudwatch"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	metricsmocks "github.com/aws/amazon-ssm-agent/agent/session/telemetry/metrics/mocks"
+	awscloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type fakePipelineStatusReporter struct {
+	status cloudwatch.PipelineStatus
+	err    error
+}
+
+func (f fakePipelineStatusReporter) PipelineStatus() (cloudwatch.PipelineStatus, error) {
+	return f.status, f.err
+}
+
+func newPipelineStatusManager(telemetryToCloudWatch bool) *Manager {
+	config := appconfig.DefaultConfig()
+	config.Agent.TelemetryMetricsToCloudWatch = telemetryToCloudWatch
+	return &Manager{context: contextmocks.NewMockDefaultWithConfig(config)}
+}
+
+func TestReportPipelineStatus_PublishesMetrics(t *testing.T) {
+	m := newPipelineStatusManager(true)
+	metricsService := &metricsmocks.ICloudWatchService{}
+	m.metricsService = metricsService
+	metricsService.On("GenerateTelemetryMetricsWithUnit", pipelineLagMetric, float64(3600), metricUnitSeconds, mock.Anything).Return(&awscloudwatch.MetricDatum{})
+	metricsService.On("GenerateTelemetryMetricsWithUnit", pipelineErrorsMetric, float64(4), metricUnitCount, mock.Anything).Return(&awscloudwatch.MetricDatum{})
+	metricsService.On("PutMetrics", mock.MatchedBy(func(data []*awscloudwatch.MetricDatum) bool {
+		return len(data) == 2
+	})).Return(nil)
+
+	m.reportPipelineStatus("aws:cloudWatch", fakePipelineStatusReporter{
+		status: cloudwatch.PipelineStatus{Entries: 10, Lag: time.Hour, ErrorCount: 4},
+	})
+
+	metricsService.AssertExpectations(t)
+}
+
+func TestReportPipelineStatus_TelemetryDisabled(t *testing.T) {
+	m := newPipelineStatusManager(false)
+	metricsService := &metricsmocks.ICloudWatchService{}
+	m.metricsService = metricsService
+
+	m.reportPipelineStatus("aws:cloudWatch", fakePipelineStatusReporter{
+		status: cloudwatch.PipelineStatus{Entries: 10, Lag: time.Hour},
+	})
+
+	metricsService.AssertNotCalled(t, "PutMetrics", mock.Anything)
+}
+
+func TestReportPipelineStatus_SkipsUnreadableOrEmptyStatus(t *testing.T) {
+	m := newPipelineStatusManager(true)
+	metricsService := &metricsmocks.ICloudWatchService{}
+	m.metricsService = metricsService
+
+	m.reportPipelineStatus("aws:cloudWatch", fakePipelineStatusReporter{err: errors.New("log not found")})
+	m.reportPipelineStatus("aws:cloudWatch", fakePipelineStatusReporter{})
+
+	assert.Empty(t, metricsService.Calls)
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/readiness"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// readinessGatedPlugin is implemented by the long running plugins depending on system conditions, like the network
+// or another service, which have to be met before they are started at boot
+type readinessGatedPlugin interface {
+	ReadinessGates() []readiness.Gate
+}
+
+// waitForReadiness waits until the readiness gates of the plugin are met or the boot readiness timeout elapses.
+// The plugin is started either way, its start failures are then handled like before by the health checks.
+func (m *Manager) waitForReadiness(name string, handler interface{}) {
+	log := m.context.Log()
+	gated, ok := handler.(readinessGatedPlugin)
+	if !ok {
+		return
+	}
+	gates := gated.ReadinessGates()
+	if len(gates) == 0 {
+		return
+	}
+	cancelFlag := m.readinessCancelFlag
+	if cancelFlag == nil {
+		cancelFlag = task.NewChanneledCancelFlag()
+	}
+
+	log.Infof("Waiting for the readiness gates %v of %s", gates, name)
+	waitStart := time.Now()
+	if err := readiness.Wait(log, gates, m.readinessDeadline, cancelFlag); err != nil {
+		log.Warnf("Starting %s anyway, %v", name, err)
+		return
+	}
+	log.Infof("Readiness gates of %s met after %v", name, time.Since(waitStart).Round(time.Second))
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/readiness"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeGatedPlugin struct {
+	gates []readiness.Gate
+}
+
+func (f fakeGatedPlugin) ReadinessGates() []readiness.Gate {
+	return f.gates
+}
+
+func TestWaitForReadiness_GatesMet(t *testing.T) {
+	m := newPipelineStatusManager(false)
+	m.readinessDeadline = time.Now().Add(time.Hour)
+
+	start := time.Now()
+	m.waitForReadiness("collector", fakeGatedPlugin{gates: []readiness.Gate{{Type: readiness.GatePathExists, Path: t.TempDir()}}})
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestWaitForReadiness_DeadlinePassed(t *testing.T) {
+	m := newPipelineStatusManager(false)
+	m.readinessDeadline = time.Now()
+
+	start := time.Now()
+	m.waitForReadiness("collector", fakeGatedPlugin{gates: []readiness.Gate{{Type: readiness.GatePathExists, Path: filepath.Join(t.TempDir(), "missing")}}})
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestWaitForReadiness_ManagerStopping(t *testing.T) {
+	m := newPipelineStatusManager(false)
+	m.readinessDeadline = time.Now().Add(time.Hour)
+	m.readinessCancelFlag = task.NewChanneledCancelFlag()
+	m.readinessCancelFlag.Set(task.Canceled)
+
+	done := make(chan struct{})
+	go func() {
+		m.waitForReadiness("collector", fakeGatedPlugin{gates: []readiness.Gate{{Type: readiness.GatePathExists, Path: filepath.Join(t.TempDir(), "missing")}}})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Minute):
+		t.Fatal("waitForReadiness did not return once the manager stopped")
+	}
+}
+
+func TestWaitForReadiness_NotGated(t *testing.T) {
+	m := newPipelineStatusManager(false)
+	m.readinessDeadline = time.Now().Add(time.Hour)
+
+	m.waitForReadiness("collector", struct{}{})
+	m.waitForReadiness("collector", fakeGatedPlugin{})
+}
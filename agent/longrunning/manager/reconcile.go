@@ -0,0 +1,190 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
+	"github.com/aws/amazon-ssm-agent/core/executor"
+)
+
+// listProcesses and killProcess act on the processes of the instance, they are replaced by the tests
+var listProcesses = func(log log.T) ([]executor.OsProcess, error) {
+	return executor.NewProcessExecutor(log).Processes()
+}
+
+var killProcess = func(log log.T, pid int) error {
+	return executor.NewProcessExecutor(log).Kill(pid)
+}
+
+// pluginRecords returns the running and stopped plugins persisted in the data store
+func (m *Manager) pluginRecords() map[string]plugin.PluginInfo {
+	records := make(map[string]plugin.PluginInfo, len(m.runningPlugins)+len(m.stoppedPlugins))
+	for name, info := range m.stoppedPlugins {
+		records[name] = info
+	}
+	for name, info := range m.runningPlugins {
+		records[name] = info
+	}
+	return records
+}
+
+// markStarted records that the plugin was started with the configuration of its info
+func markStarted(log log.T, handler plugin.LongRunningPlugin, info *plugin.PluginInfo, startTime time.Time) {
+	info.State.DesiredState = plugin.DesiredStateRunning
+	info.State.LastStartTime = startTime
+	info.State.ConfigurationHash = plugin.ConfigurationHash(info.Configuration)
+	recordProcess(log, handler, &info.State)
+}
+
+// recordProcess records the process of an adoptable plugin, it is adopted or killed when the agent restarts
+func recordProcess(log log.T, handler plugin.LongRunningPlugin, state *plugin.PluginState) {
+	state.ProcessID, state.ProcessExecutable = 0, ""
+	adoptable, ok := handler.(plugin.AdoptablePlugin)
+	if !ok {
+		return
+	}
+	pid := adoptable.ProcessID()
+	if pid == 0 {
+		return
+	}
+	if process, found := findProcess(log, pid); found {
+		state.ProcessID, state.ProcessExecutable = pid, process.Executable
+	}
+}
+
+// recordProcesses records the current process of the adoptable running plugins, their supervisor restarts them
+// without the manager knowing
+func (m *Manager) recordProcesses() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	log := m.context.Log()
+	changed := false
+	for name, info := range m.runningPlugins {
+		p, isRegistered := m.registeredPlugins[name]
+		if !isRegistered {
+			continue
+		}
+		if _, ok := p.Handler.(plugin.AdoptablePlugin); !ok {
+			continue
+		}
+		state := info.State
+		recordProcess(log, p.Handler, &state)
+		if state.ProcessID != info.State.ProcessID {
+			info.State = state
+			m.runningPlugins[name] = info
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := m.dataStore.Write(m.pluginRecords()); err != nil {
+		log.Errorf("Failed to update datastore - because of %s", err)
+	}
+}
+
+// findProcess returns the live process with the given id
+func findProcess(log log.T, pid int) (executor.OsProcess, bool) {
+	processes, err := listProcesses(log)
+	if err != nil {
+		log.Warnf("Unable to list the processes: %v", err)
+		return executor.OsProcess{}, false
+	}
+	for _, process := range processes {
+		if process.Pid == pid && process.State != "Z" {
+			return process, true
+		}
+	}
+	return executor.OsProcess{}, false
+}
+
+// orphanedProcess returns the process recorded for a plugin by the previous agent, if it is still running.
+// The executable of the process tells it apart from a process which reused its id.
+func orphanedProcess(log log.T, state plugin.PluginState) (executor.OsProcess, bool) {
+	if state.ProcessID == 0 {
+		return executor.OsProcess{}, false
+	}
+	process, found := findProcess(log, state.ProcessID)
+	if !found || process.Executable != state.ProcessExecutable {
+		return executor.OsProcess{}, false
+	}
+	return process, true
+}
+
+// reconcilePlugins compares the plugins recorded by the previous agent with the processes it left running. The
+// plugins stopped by a document are kept out of the running plugins and their orphaned process is killed. The
+// orphaned process of a running plugin is adopted when the plugin can adopt it and still has the configuration it
+// was started with, it is killed otherwise and the plugin is started again. It returns the adopted plugins.
+func (m *Manager) reconcilePlugins(records map[string]plugin.PluginInfo) (adopted map[string]bool) {
+	log := m.context.Log()
+	adopted = make(map[string]bool)
+	m.runningPlugins = make(map[string]plugin.PluginInfo)
+	m.stoppedPlugins = make(map[string]plugin.PluginInfo)
+
+	for name, info := range records {
+		if info.State.DesiredState == plugin.DesiredStateStopped {
+			m.stoppedPlugins[name] = info
+			if orphan, found := orphanedProcess(log, info.State); found {
+				killOrphan(log, name, orphan, "the plugin was stopped")
+			}
+			continue
+		}
+
+		p, isRegistered := m.registeredPlugins[name]
+		if isRegistered && startsWithAgent(p.Handler) {
+			// the plugins started with the agent are started again with their registered configuration
+			info.Configuration = p.Info.Configuration
+		}
+		m.runningPlugins[name] = info
+
+		orphan, found := orphanedProcess(log, info.State)
+		if !found {
+			continue
+		}
+		adoptable, canAdopt := p.Handler.(plugin.AdoptablePlugin)
+		switch {
+		case !isRegistered:
+			killOrphan(log, name, orphan, "the plugin is no longer registered")
+		case !canAdopt:
+			killOrphan(log, name, orphan, "the plugin cannot adopt it")
+		case info.State.ConfigurationHash != plugin.ConfigurationHash(info.Configuration):
+			killOrphan(log, name, orphan, "the configuration of the plugin changed")
+		default:
+			if err := adoptable.Adopt(orphan.Pid); err != nil {
+				killOrphan(log, name, orphan, err.Error())
+				continue
+			}
+			adopted[name] = true
+		}
+	}
+	return adopted
+}
+
+// startsWithAgent returns true for the plugins started by the manager without a document
+func startsWithAgent(handler interface{}) bool {
+	agentStarted, ok := handler.(agentStartedPlugin)
+	return ok && agentStarted.StartsWithAgent()
+}
+
+// killOrphan kills a process left running by the previous agent which cannot be adopted
+func killOrphan(log log.T, name string, orphan executor.OsProcess, reason string) {
+	log.Infof("Killing the orphaned process %v of %s, %s", orphan.Pid, name, reason)
+	if err := killProcess(log, orphan.Pid); err != nil {
+		log.Warnf("Failed to kill the orphaned process %v of %s: %v", orphan.Pid, name, err)
+	}
+}
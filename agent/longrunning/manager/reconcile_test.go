@@ -0,0 +1,182 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/core/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAdoptablePlugin struct {
+	fakeStoppedPlugin
+	pid       int
+	adoptErr  error
+	agentBorn bool
+}
+
+func (f *fakeAdoptablePlugin) ProcessID() int { return f.pid }
+
+func (f *fakeAdoptablePlugin) Adopt(pid int) error {
+	if f.adoptErr != nil {
+		return f.adoptErr
+	}
+	f.pid = pid
+	return nil
+}
+
+func (f *fakeAdoptablePlugin) StartsWithAgent() bool { return f.agentBorn }
+
+type fakeDataStore struct {
+	written map[string]plugin.PluginInfo
+}
+
+func (f *fakeDataStore) Write(data map[string]plugin.PluginInfo) error {
+	f.written = data
+	return nil
+}
+
+func (f *fakeDataStore) Read() (map[string]plugin.PluginInfo, error) {
+	return f.written, nil
+}
+
+// stubProcesses replaces the processes of the instance and returns the processes killed by the test
+func stubProcesses(t *testing.T, processes ...executor.OsProcess) *[]int {
+	killed := &[]int{}
+	previousList, previousKill := listProcesses, killProcess
+	listProcesses = func(log.T) ([]executor.OsProcess, error) { return processes, nil }
+	killProcess = func(_ log.T, pid int) error {
+		*killed = append(*killed, pid)
+		return nil
+	}
+	t.Cleanup(func() { listProcesses, killProcess = previousList, previousKill })
+	return killed
+}
+
+func newReconciledManager(handler plugin.LongRunningPlugin, configuration string) *Manager {
+	m := newPipelineStatusManager(false)
+	m.dataStore = &fakeDataStore{}
+	m.registeredPlugins = map[string]plugin.Plugin{
+		"collector": {Info: plugin.PluginInfo{Name: "collector", Configuration: configuration}, Handler: handler},
+	}
+	return m
+}
+
+func recordedPlugin(desiredState, configuration string) map[string]plugin.PluginInfo {
+	return map[string]plugin.PluginInfo{"collector": {
+		Name:          "collector",
+		Configuration: configuration,
+		State: plugin.PluginState{
+			DesiredState:      desiredState,
+			ConfigurationHash: plugin.ConfigurationHash(configuration),
+			ProcessID:         42,
+			ProcessExecutable: "collector",
+		},
+	}}
+}
+
+func TestReconcilePlugins_AdoptsOrphan(t *testing.T) {
+	killed := stubProcesses(t, executor.OsProcess{Pid: 42, Executable: "collector"})
+	handler := &fakeAdoptablePlugin{}
+	m := newReconciledManager(handler, "")
+
+	adopted := m.reconcilePlugins(recordedPlugin(plugin.DesiredStateRunning, "{}"))
+
+	assert.True(t, adopted["collector"])
+	assert.Equal(t, 42, handler.pid)
+	assert.Empty(t, *killed)
+	assert.Contains(t, m.runningPlugins, "collector")
+}
+
+func TestReconcilePlugins_KillsOrphanWithChangedConfiguration(t *testing.T) {
+	killed := stubProcesses(t, executor.OsProcess{Pid: 42, Executable: "collector"})
+	// the plugins started with the agent are compared with their registered configuration
+	m := newReconciledManager(&fakeAdoptablePlugin{agentBorn: true}, `{"Name":"collector","StartCommand":["/new"]}`)
+
+	adopted := m.reconcilePlugins(recordedPlugin(plugin.DesiredStateRunning, `{"Name":"collector","StartCommand":["/old"]}`))
+
+	assert.Empty(t, adopted)
+	assert.Equal(t, []int{42}, *killed)
+	assert.Equal(t, `{"Name":"collector","StartCommand":["/new"]}`, m.runningPlugins["collector"].Configuration)
+}
+
+func TestReconcilePlugins_KillsOrphanWhenAdoptionFails(t *testing.T) {
+	killed := stubProcesses(t, executor.OsProcess{Pid: 42, Executable: "collector"})
+	m := newReconciledManager(&fakeAdoptablePlugin{adoptErr: fmt.Errorf("already running")}, "")
+
+	adopted := m.reconcilePlugins(recordedPlugin(plugin.DesiredStateRunning, "{}"))
+
+	assert.Empty(t, adopted)
+	assert.Equal(t, []int{42}, *killed)
+}
+
+func TestReconcilePlugins_IgnoresReusedProcessID(t *testing.T) {
+	killed := stubProcesses(t, executor.OsProcess{Pid: 42, Executable: "sshd"})
+	m := newReconciledManager(&fakeAdoptablePlugin{}, "")
+
+	adopted := m.reconcilePlugins(recordedPlugin(plugin.DesiredStateRunning, "{}"))
+
+	assert.Empty(t, adopted)
+	assert.Empty(t, *killed)
+	assert.Contains(t, m.runningPlugins, "collector")
+}
+
+func TestReconcilePlugins_KillsOrphanOfStoppedPlugin(t *testing.T) {
+	killed := stubProcesses(t, executor.OsProcess{Pid: 42, Executable: "collector"})
+	m := newReconciledManager(&fakeAdoptablePlugin{}, "")
+
+	adopted := m.reconcilePlugins(recordedPlugin(plugin.DesiredStateStopped, "{}"))
+
+	assert.Empty(t, adopted)
+	assert.Equal(t, []int{42}, *killed)
+	assert.NotContains(t, m.runningPlugins, "collector")
+	assert.Contains(t, m.stoppedPlugins, "collector")
+}
+
+func TestStartAndStopPlugin_RecordDesiredState(t *testing.T) {
+	stubProcesses(t, executor.OsProcess{Pid: 7, Executable: "collector"})
+	handler := &fakeAdoptablePlugin{pid: 7}
+	m := newReconciledManager(handler, "")
+	m.runningPlugins = map[string]plugin.PluginInfo{}
+	dataStore := m.dataStore.(*fakeDataStore)
+
+	assert.NoError(t, m.StartPlugin("collector", "{}", "", task.NewChanneledCancelFlag(), nil))
+	state := dataStore.written["collector"].State
+	assert.Equal(t, plugin.DesiredStateRunning, state.DesiredState)
+	assert.Equal(t, plugin.ConfigurationHash("{}"), state.ConfigurationHash)
+	assert.Equal(t, 7, state.ProcessID)
+	assert.Equal(t, "collector", state.ProcessExecutable)
+	assert.False(t, state.LastStartTime.IsZero())
+
+	assert.NoError(t, m.StopPlugin("collector", task.NewChanneledCancelFlag()))
+	state = dataStore.written["collector"].State
+	assert.Equal(t, plugin.DesiredStateStopped, state.DesiredState)
+	assert.Equal(t, 0, state.ProcessID)
+	assert.NotContains(t, m.runningPlugins, "collector")
+}
+
+func TestRecordProcesses_UpdatesRestartedProcess(t *testing.T) {
+	stubProcesses(t, executor.OsProcess{Pid: 8, Executable: "collector"})
+	m := newReconciledManager(&fakeAdoptablePlugin{pid: 8}, "")
+	m.runningPlugins = recordedPlugin(plugin.DesiredStateRunning, "{}")
+
+	m.recordProcesses()
+
+	assert.Equal(t, 8, m.dataStore.(*fakeDataStore).written["collector"].State.ProcessID)
+}
@@ -0,0 +1,187 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
+	"github.com/aws/amazon-ssm-agent/common/channel"
+	"github.com/aws/amazon-ssm-agent/common/channel/utils"
+	"github.com/aws/amazon-ssm-agent/common/identity"
+	"github.com/aws/amazon-ssm-agent/common/message"
+	"go.nanomsg.org/mangos/v3"
+	_ "go.nanomsg.org/mangos/v3/transport/ipc"
+)
+
+// statusQueryRetryInterval is the interval at which the status channel is dialed until ssm-cli listens on it,
+// it is also the time given to ssm-cli to read an answer before the channel is closed
+var statusQueryRetryInterval = time.Second
+
+// statusChannelCreator returns the function creating the channels the status queries of ssm-cli are received from.
+// Resolving it checks whether named pipes can be used, it is resolved once when the manager starts.
+var statusChannelCreator = func(context context.T) func(log.T, identity.IAgentIdentity) channel.IChannel {
+	return channel.GetChannelCreator(context.Log(), context.AppConfig(), context.Identity())
+}
+
+// healthChecks holds the result of the last health check of each long running plugin
+type healthChecks struct {
+	lock    sync.Mutex
+	results map[string]message.LongRunningPluginHealthCheck
+}
+
+// healthCheckReporter is implemented by the long running plugins telling why their last health check failed
+type healthCheckReporter interface {
+	LastHealthCheckError() error
+}
+
+// processReporter is implemented by the long running plugins running a process
+type processReporter interface {
+	ProcessID() int
+}
+
+// recordHealthCheck records the result of a health check of a plugin for the status queries
+func (m *Manager) recordHealthCheck(name string, handler plugin.LongRunningPlugin, healthy bool, checkedAt time.Time) {
+	result := message.LongRunningPluginHealthCheck{Time: checkedAt.UTC(), Healthy: healthy}
+	if reporter, ok := handler.(healthCheckReporter); ok {
+		if err := reporter.LastHealthCheckError(); err != nil {
+			result.Healthy = false
+			result.Error = err.Error()
+		}
+	}
+	if !result.Healthy && result.Error == "" {
+		result.Error = "the plugin is not running"
+	}
+
+	m.healthChecks.lock.Lock()
+	defer m.healthChecks.lock.Unlock()
+	if m.healthChecks.results == nil {
+		m.healthChecks.results = map[string]message.LongRunningPluginHealthCheck{}
+	}
+	m.healthChecks.results[name] = result
+}
+
+// pluginStatuses returns the status of the running plugins ordered by name
+func (m *Manager) pluginStatuses(now time.Time) []message.LongRunningPluginStatus {
+	lock.RLock()
+	defer lock.RUnlock()
+	m.healthChecks.lock.Lock()
+	defer m.healthChecks.lock.Unlock()
+
+	statuses := make([]message.LongRunningPluginStatus, 0, len(m.runningPlugins))
+	for name, info := range m.runningPlugins {
+		status := message.LongRunningPluginStatus{Name: name, Pid: info.State.ProcessID}
+		if !info.State.LastStartTime.IsZero() {
+			status.UptimeSeconds = int64(now.Sub(info.State.LastStartTime).Seconds())
+		}
+		if p, isRegistered := m.registeredPlugins[name]; isRegistered {
+			if reporter, ok := p.Handler.(processReporter); ok {
+				status.Pid = reporter.ProcessID()
+			}
+			if reporter, ok := p.Handler.(restartReporter); ok {
+				status.Restarts = reporter.SupervisorStatus().Restarts
+			}
+		}
+		if result, checked := m.healthChecks.results[name]; checked {
+			status.LastHealthCheck = &result
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// serveStatusQueries answers the status queries of ssm-cli until stop is closed. ssm-cli listens on the status
+// channel only while it queries, the channel is dialed again after each query.
+func (m *Manager) serveStatusQueries(stop chan struct{}, createChannel func(log.T, identity.IAgentIdentity) channel.IChannel) {
+	log := m.context.Log()
+	for {
+		statusChannel := createChannel(log, m.context.Identity())
+		if err := statusChannel.Initialize(utils.Respondent); err != nil {
+			log.Errorf("Failed to create the long running plugin status channel: %v", err)
+			return
+		}
+		// named pipes connect once ssm-cli listens, file channels can only be dialed after it does
+		_ = statusChannel.SetOption(mangos.OptionDialAsynch, true)
+		for statusChannel.Dial(message.LongRunningStatusChannel) != nil {
+			if !waitOrStop(stop, statusQueryRetryInterval) {
+				_ = statusChannel.Close()
+				return
+			}
+		}
+
+		m.answerStatusQuery(statusChannel, stop)
+		stopped := !waitOrStop(stop, statusQueryRetryInterval)
+		_ = statusChannel.Close()
+		if stopped {
+			return
+		}
+	}
+}
+
+// answerStatusQuery waits for a status query on the channel and sends the status of the plugins back
+func (m *Manager) answerStatusQuery(statusChannel channel.IChannel, stop chan struct{}) {
+	log := m.context.Log()
+	received := make(chan []byte, 1)
+	go func() {
+		// the receive is interrupted by the close of the channel when the manager stops
+		defer close(received)
+		if msg, err := statusChannel.Recv(); err == nil {
+			received <- msg
+		}
+	}()
+
+	var msg []byte
+	select {
+	case <-stop:
+		return
+	case msg = <-received:
+		if msg == nil {
+			return
+		}
+	}
+
+	var request *message.Message
+	if err := json.Unmarshal(msg, &request); err != nil {
+		log.Warnf("Failed to unmarshal long running plugin status query: %v", err)
+		return
+	}
+	if request.Topic != message.GetLongRunningPluginStatusRequest {
+		log.Warnf("Received invalid message on long running plugin status channel, %s", request.Topic)
+		return
+	}
+	result, err := message.CreateLongRunningPluginStatusResult(m.pluginStatuses(time.Now()))
+	if err != nil {
+		log.Errorf("Failed to create long running plugin status response: %v", err)
+		return
+	}
+	if err = statusChannel.Send(result); err != nil {
+		log.Warnf("Failed to send long running plugin status response: %v", err)
+	}
+}
+
+// waitOrStop waits for the given duration, it returns false if stop is closed before
+func waitOrStop(stop chan struct{}, duration time.Duration) bool {
+	select {
+	case <-stop:
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
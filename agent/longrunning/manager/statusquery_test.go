@@ -0,0 +1,146 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/supervisor"
+	"github.com/aws/amazon-ssm-agent/common/channel"
+	channelmocks "github.com/aws/amazon-ssm-agent/common/channel/mocks"
+	"github.com/aws/amazon-ssm-agent/common/identity"
+	"github.com/aws/amazon-ssm-agent/common/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type fakeStatusPlugin struct {
+	fakeStoppedPlugin
+	pid            int
+	restarts       int
+	healthCheckErr error
+}
+
+func (f *fakeStatusPlugin) ProcessID() int { return f.pid }
+
+func (f *fakeStatusPlugin) SupervisorStatus() supervisor.Status {
+	return supervisor.Status{Supervising: true, Restarts: f.restarts}
+}
+
+func (f *fakeStatusPlugin) LastHealthCheckError() error { return f.healthCheckErr }
+
+func newStatusManager(handlers map[string]plugin.LongRunningPlugin, startedAt time.Time) *Manager {
+	m := newPipelineStatusManager(false)
+	m.runningPlugins = map[string]plugin.PluginInfo{}
+	m.registeredPlugins = map[string]plugin.Plugin{}
+	for name, handler := range handlers {
+		info := plugin.PluginInfo{Name: name, State: plugin.PluginState{LastStartTime: startedAt, ProcessID: 7}}
+		m.runningPlugins[name] = info
+		m.registeredPlugins[name] = plugin.Plugin{Info: info, Handler: handler}
+	}
+	return m
+}
+
+func TestPluginStatuses(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := newStatusManager(map[string]plugin.LongRunningPlugin{
+		"collector":      &fakeStatusPlugin{pid: 42, restarts: 2},
+		"aws:cloudWatch": &fakeStoppedPlugin{},
+	}, now.Add(-90*time.Second))
+	m.recordHealthCheck("collector", m.registeredPlugins["collector"].Handler, true, now.Add(-time.Minute))
+
+	statuses := m.pluginStatuses(now)
+
+	assert.Equal(t, []message.LongRunningPluginStatus{
+		// the plugins without a process reporter have the process id recorded at their start
+		{Name: "aws:cloudWatch", Pid: 7, UptimeSeconds: 90},
+		{Name: "collector", Pid: 42, UptimeSeconds: 90, Restarts: 2,
+			LastHealthCheck: &message.LongRunningPluginHealthCheck{Time: now.Add(-time.Minute), Healthy: true}},
+	}, statuses)
+}
+
+func TestRecordHealthCheck_Failed(t *testing.T) {
+	now := time.Now()
+	handler := &fakeStatusPlugin{healthCheckErr: errors.New("exit code 1 instead of 0")}
+	m := newStatusManager(map[string]plugin.LongRunningPlugin{"collector": handler, "stopped": &fakeStoppedPlugin{}}, now)
+
+	// a failed health check below the unhealthy threshold still reports the plugin running
+	m.recordHealthCheck("collector", handler, true, now)
+	m.recordHealthCheck("stopped", m.registeredPlugins["stopped"].Handler, false, now)
+
+	assert.Equal(t, message.LongRunningPluginHealthCheck{Time: now.UTC(), Error: "exit code 1 instead of 0"}, m.healthChecks.results["collector"])
+	assert.Equal(t, message.LongRunningPluginHealthCheck{Time: now.UTC(), Error: "the plugin is not running"}, m.healthChecks.results["stopped"])
+}
+
+func TestAnswerStatusQuery(t *testing.T) {
+	m := newStatusManager(map[string]plugin.LongRunningPlugin{"collector": &fakeStatusPlugin{pid: 42}}, time.Now())
+	request, _ := json.Marshal(message.CreateLongRunningPluginStatusRequest())
+	statusChannel := &channelmocks.IChannel{}
+	statusChannel.On("Recv").Return(request, nil)
+	var result *message.Message
+	statusChannel.On("Send", mock.Anything).Run(func(args mock.Arguments) {
+		result = args.Get(0).(*message.Message)
+	}).Return(nil)
+
+	m.answerStatusQuery(statusChannel, make(chan struct{}))
+
+	assert.Equal(t, message.GetLongRunningPluginStatusResult, result.Topic)
+	var payload message.LongRunningPluginStatusPayload
+	assert.NoError(t, json.Unmarshal(result.Payload, &payload))
+	assert.Len(t, payload.Plugins, 1)
+	assert.Equal(t, 42, payload.Plugins[0].Pid)
+}
+
+func TestAnswerStatusQuery_InvalidTopic(t *testing.T) {
+	m := newStatusManager(nil, time.Now())
+	request, _ := json.Marshal(message.CreateHealthRequest())
+	statusChannel := &channelmocks.IChannel{}
+	statusChannel.On("Recv").Return(request, nil)
+
+	m.answerStatusQuery(statusChannel, make(chan struct{}))
+
+	statusChannel.AssertNotCalled(t, "Send", mock.Anything)
+}
+
+func TestServeStatusQueries_Stop(t *testing.T) {
+	previousInterval := statusQueryRetryInterval
+	t.Cleanup(func() { statusQueryRetryInterval = previousInterval })
+	statusQueryRetryInterval = time.Millisecond
+	statusChannel := &channelmocks.IChannel{}
+	statusChannel.On("Initialize", mock.Anything).Return(nil)
+	statusChannel.On("SetOption", mock.Anything, mock.Anything).Return(nil)
+	statusChannel.On("Dial", message.LongRunningStatusChannel).Return(errors.New("ssm-cli is not listening"))
+	statusChannel.On("Close").Return(nil)
+
+	m := newStatusManager(nil, time.Now())
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		m.serveStatusQueries(stop, func(log.T, identity.IAgentIdentity) channel.IChannel { return statusChannel })
+		close(done)
+	}()
+	close(stop)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the status queries are still served after the stop")
+	}
+	statusChannel.AssertCalled(t, "Close")
+}
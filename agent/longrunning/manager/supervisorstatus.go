@@ -0,0 +1,57 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package manager
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/supervisor"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+	awscloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+const restartsMetric = "LongRunningPluginRestarts"
+
+// restartReporter is implemented by the long running plugins restarting their process when it exits unexpectedly
+type restartReporter interface {
+	SupervisorStatus() supervisor.Status
+}
+
+// reportSupervisorStatus logs the restarts of a plugin process and publishes them with the agent telemetry metrics
+// when they are sent to CloudWatch, it returns true if the plugin restarts its process itself
+func (m *Manager) reportSupervisorStatus(name string, reporter restartReporter) (supervised bool) {
+	log := m.context.Log()
+	status := reporter.SupervisorStatus()
+	switch {
+	case status.GaveUp:
+		log.Errorf("%v crashed %d times in a row and is no longer restarted, last exit at %v: %v",
+			name, status.ConsecutiveRestarts, status.LastExit, status.LastExitError)
+	case status.Restarts > 0:
+		log.Warnf("%v was restarted %d times, %d times in a row, last exit at %v: %v",
+			name, status.Restarts, status.ConsecutiveRestarts, status.LastExit, status.LastExitError)
+	}
+	if status.LastCrashDump != "" {
+		log.Warnf("Crash dump of the last crash of %v: %v", name, status.LastCrashDump)
+	}
+
+	if status.Supervising && m.context.AppConfig().Agent.TelemetryMetricsToCloudWatch {
+		if m.metricsService == nil {
+			m.metricsService = newMetricsService(m)
+		}
+		metricData := []*awscloudwatch.MetricDatum{
+			m.metricsService.GenerateTelemetryMetricsWithUnit(restartsMetric, float64(status.Restarts), metricUnitCount, version.Version),
+		}
+		if err := m.metricsService.PutMetrics(metricData); err != nil {
+			log.Warnf("Failed to publish the restarts of %v: %v", name, err)
+		}
+	}
+	return status.Supervising || status.GaveUp
+}
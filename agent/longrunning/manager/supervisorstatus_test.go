@@ -0,0 +1,71 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package manager
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/supervisor"
+	metricsmocks "github.com/aws/amazon-ssm-agent/agent/session/telemetry/metrics/mocks"
+	awscloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type fakeRestartReporter struct {
+	status supervisor.Status
+}
+
+func (f fakeRestartReporter) SupervisorStatus() supervisor.Status {
+	return f.status
+}
+
+func TestReportSupervisorStatus_PublishesRestarts(t *testing.T) {
+	m := newPipelineStatusManager(true)
+	metricsService := &metricsmocks.ICloudWatchService{}
+	m.metricsService = metricsService
+	metricsService.On("GenerateTelemetryMetricsWithUnit", restartsMetric, float64(3), metricUnitCount, mock.Anything).Return(&awscloudwatch.MetricDatum{})
+	metricsService.On("PutMetrics", mock.Anything).Return(nil)
+
+	supervised := m.reportSupervisorStatus("aws:cloudWatch", fakeRestartReporter{
+		status: supervisor.Status{Supervising: true, Restarts: 3, ConsecutiveRestarts: 1},
+	})
+
+	assert.True(t, supervised)
+	metricsService.AssertExpectations(t)
+}
+
+func TestReportSupervisorStatus_GaveUp(t *testing.T) {
+	m := newPipelineStatusManager(true)
+	metricsService := &metricsmocks.ICloudWatchService{}
+	m.metricsService = metricsService
+
+	supervised := m.reportSupervisorStatus("aws:cloudWatch", fakeRestartReporter{
+		status: supervisor.Status{GaveUp: true, Restarts: 10, ConsecutiveRestarts: 10},
+	})
+
+	// the health check does not restart a plugin which crashed too many times
+	assert.True(t, supervised)
+	metricsService.AssertNotCalled(t, "PutMetrics", mock.Anything)
+}
+
+func TestReportSupervisorStatus_NotSupervised(t *testing.T) {
+	m := newPipelineStatusManager(false)
+	metricsService := &metricsmocks.ICloudWatchService{}
+	m.metricsService = metricsService
+
+	supervised := m.reportSupervisorStatus("aws:cloudWatch", fakeRestartReporter{})
+
+	assert.False(t, supervised)
+	metricsService.AssertNotCalled(t, "PutMetrics", mock.Anything)
+}
@@ -17,6 +17,7 @@ package manager
 import (
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -35,20 +36,39 @@ var (
 func (m *Manager) ensurePluginsAreRunning() {
 
 	log := m.context.Log()
+	// runs once the read lock is released, the supervised plugins may have been restarted since they were recorded
+	defer m.recordProcesses()
 
 	lock.RLock()
 	defer lock.RUnlock()
 
 	if len(m.runningPlugins) > 0 {
+		tick := m.healthCheckTick()
 		for n := range m.runningPlugins {
+			// the plugins are checked at their own interval, the job runs at the shortest one
+			policy := m.healthCheckPolicy(n)
+			if !m.isHealthCheckDue(n, policy, tick, time.Now()) {
+				continue
+			}
 			p, isRegistered := m.registeredPlugins[n]
-			if isRegistered && !p.Handler.IsRunning() {
+			// plugins supervising their process restart it themselves, their health is still checked
+			supervised := false
+			if reporter, ok := p.Handler.(restartReporter); isRegistered && ok {
+				supervised = m.reportSupervisorStatus(n, reporter)
+			}
+			restart := false
+			if isRegistered {
+				var healthy bool
+				healthy, restart = m.checkHealth(n, p.Handler, policy)
+				m.recordHealthCheck(n, p.Handler, healthy, time.Now())
+			}
+			if restart && !supervised {
 				log.Infof("Starting %s since it wasn't running before")
 				//todo: we arent using task pools anymore -> change the following implementation
 				m.startPlugin.Submit(m.context.Log(), n, func(cancelFlag task.CancelFlag) {
 					shortInstanceID, _ := m.context.Identity().ShortInstanceID()
 					orchestrationRootDir := filepath.Join(
-						appconfig.DefaultDataStorePath,
+						appconfig.OrchestrationStorePath,
 						shortInstanceID,
 						appconfig.DefaultDocumentRootDirName,
 						m.context.AppConfig().Agent.OrchestrationRootDir)
@@ -64,6 +84,8 @@ func (m *Manager) ensurePluginsAreRunning() {
 					p.Handler.Start(p.Info.Configuration, "", cancelFlag, out)
 					out.Close()
 				})
+			} else if reporter, ok := p.Handler.(pipelineStatusReporter); isRegistered && ok {
+				m.reportPipelineStatus(n, reporter)
 			}
 		}
 	} else {
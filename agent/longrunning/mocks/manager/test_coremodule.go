@@ -47,6 +47,7 @@ func NewMockDefault() *Mock {
 	mgr.On("Execute", mock.AnythingOfType("context.T")).Return(nil)
 	mgr.On("RequestStop", mock.AnythingOfType("string")).Return(nil)
 	mgr.On("StopPlugin", mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	mgr.On("StopPluginWithOptions", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(nil)
 	mgr.On("StartPlugin", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("task.CancelFlag")).Return(nil)
 	return mgr
 }
@@ -79,6 +80,11 @@ func (m *Mock) StopPlugin(name string, cancelFlag task.CancelFlag) (err error) {
 	return nil
 }
 
+// StopPluginWithOptions stops a given plugin as requested by the options and returns encountered error - returns nil here for testing
+func (m *Mock) StopPluginWithOptions(name string, options managerContracts.StopOptions, cancelFlag task.CancelFlag) (err error) {
+	return nil
+}
+
 // StartPlugin starts the given plugin with the given configuration and returns encountered error - returns nil here for testing
 func (m *Mock) StartPlugin(name, configuration, orchestrationDir string, cancelFlag task.CancelFlag, out iohandler.IOHandler) (err error) {
 	return nil
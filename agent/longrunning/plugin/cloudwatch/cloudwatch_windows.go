@@ -18,21 +18,22 @@
 package cloudwatch
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/executers"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
-	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/crashdump"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/readiness"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/supervisor"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
@@ -46,19 +47,25 @@ type Plugin struct {
 	ExeLocation                        string
 	Name                               string
 	DefaultHealthCheckOrchestrationDir string
+	// supervisor restarts cloudwatch.exe when it exits unexpectedly
+	supervisor *supervisor.Supervisor
+	// crashDumps captures the dumps of cloudwatch.exe when it crashes
+	crashDumps *crashdump.Collector
+	// runAsAccount is the account cloudwatch.exe runs as, it runs as LocalSystem when nil
+	runAsAccount *runAsAccount
 }
 
 const (
-	//TODO: Change the way the output is being returned to return exit codes
-	IsProcessRunning = "$ProcessActive = Get-Process -Name %v -ErrorAction SilentlyContinue ; $ProcessActive -ne $null"
-	GetPidOfExe      = "Get-Process -Name %v -ErrorAction SilentlyContinue | Select ProcessName, Id | ConvertTo-Json"
-	ProcessNotFound  = "Process not found"
+	ProcessNotFound = "Process not found"
 	// CloudWatchProcessName represents CloudWatch Exe Absolute Path
 	CloudWatchProcessName = "AWS.CloudWatch"
 	// CloudWatchExeName represents the name of the executable file of cloud watch
 	CloudWatchExeName = "AWS.CloudWatch.exe"
 	// CloudWatchFolderName represents the default folder name for cloud watch plugin
 	CloudWatchFolderName = "awsCloudWatch"
+
+	// eventLogServiceName is the windows service cloudwatch.exe reads the event logs from
+	eventLogServiceName = "EventLog"
 )
 
 // CloudwatchProcessInfo is a structure for info returned by Cloudwatch process
@@ -76,6 +83,21 @@ var killProcess = func(process *os.Process) error {
 	return process.Kill()
 }
 
+// watchProcess returns the function waiting for the started process to exit, the dump of the process is captured
+// when it crashes
+var watchProcess = func(process *os.Process, crashDumps *crashdump.Collector, executable string) supervisor.WaitFunc {
+	return func() error {
+		state, err := process.Wait()
+		if err != nil {
+			return err
+		}
+		if !state.Success() {
+			return crashDumps.Collect(crashdump.Process{Pid: process.Pid, Executable: executable}, state, fmt.Errorf("exit code %v", state.ExitCode()))
+		}
+		return nil
+	}
+}
+
 // var createScript = pluginutil.CreateScriptFile
 
 // todo: honor cancel flag for Start
@@ -102,6 +124,7 @@ func NewPlugin(context context.T, pluginConfig iohandler.PluginConfig) (*Plugin,
 		plugin.Name)
 	_ = fileutil.MakeDirsWithExecuteAccess(plugin.DefaultHealthCheckOrchestrationDir)
 	plugin.CommandExecuter = exec
+	plugin.crashDumps = crashdump.NewCollector(context, plugin.Name)
 
 	return &plugin, nil
 }
@@ -113,16 +136,44 @@ func Name() string {
 
 // IsRunning returns if the said plugin is running or not
 func (p *Plugin) IsRunning() bool {
-	//working directory here doesn't really matter much since we run a powershell script to determine if exe is running
 	return p.IsCloudWatchExeRunning(p.DefaultHealthCheckOrchestrationDir, p.DefaultHealthCheckOrchestrationDir, task.NewChanneledCancelFlag())
 }
 
+// ReadinessGates returns the conditions cloudwatch.exe needs at boot: it sends the logs and metrics over the
+// network, reads the event logs and is installed with the agent
+func (p *Plugin) ReadinessGates() []readiness.Gate {
+	return []readiness.Gate{
+		{Type: readiness.GateNetworkOnline},
+		{Type: readiness.GateServiceRunning, Name: eventLogServiceName},
+		{Type: readiness.GatePathExists, Path: p.ExeLocation},
+	}
+}
+
+// SupervisorStatus returns the restarts of cloudwatch.exe since it was last started by the plugin
+func (p *Plugin) SupervisorStatus() supervisor.Status {
+	if p.supervisor == nil {
+		return supervisor.Status{}
+	}
+	return p.supervisor.Status()
+}
+
+// PipelineStatus returns the status of the data pipeline of cloudwatch.exe computed from the log it writes in its working directory
+func (p *Plugin) PipelineStatus() (PipelineStatus, error) {
+	return ReadPipelineStatus(filepath.Join(p.WorkingDir, PipelineLogFileName), time.Now())
+}
+
 // Start starts the executable file and returns encountered errors
 func (p *Plugin) Start(configuration string, orchestrationDir string, cancelFlag task.CancelFlag, out iohandler.IOHandler) (err error) {
 	log := p.Context.Log()
 	logFormatConfig := logger.PrintCWConfig(configuration, log)
 	log.Infof("CloudWatch Configuration to be applied - %s ", logFormatConfig)
 
+	// reject the configurations cloudwatch.exe cannot load, its own errors are not surfaced to the document
+	if err = ValidateConfiguration(configuration); err != nil {
+		log.Error(err)
+		return err
+	}
+
 	//check if the exe is located
 	if !fileExist(p.ExeLocation) {
 		errorMessage := "unable to locate cloudwatch.exe"
@@ -160,6 +211,11 @@ func (p *Plugin) Start(configuration string, orchestrationDir string, cancelFlag
 		p.Stop(cancelFlag)
 	}
 
+	// the exe is updated while cloudwatch.exe is stopped, the installed exe is started when the update fails
+	if _, updateErr := updateExe(p.Context, p.ExeLocation); updateErr != nil {
+		log.Warnf("Failed to update cloudwatch.exe, starting the installed exe: %v", updateErr)
+	}
+
 	/*
 		In general exec.Execute -> waits for the command to finish with added attribute to timeout and cancel the command
 		We don't want that for Cloudwatch.exe -> because we simply launch the exe and forget about it, hence we are using
@@ -184,7 +240,15 @@ func (p *Plugin) Start(configuration string, orchestrationDir string, cancelFlag
 		return
 	}
 
-	commandArguments = append(commandArguments, instanceId, instanceRegion, getFileName())
+	// the flow files of the config folder are merged into a separate configuration
+	configFile := getFileName()
+	if mergedConfigFile, mergeErr := writeMergedConfiguration(log, configuration, getLocation()); mergeErr != nil {
+		log.Warnf("Failed to merge the cloud watch flow files, starting with the plugin configuration: %v", mergeErr)
+	} else {
+		configFile = mergedConfigFile
+	}
+
+	commandArguments = append(commandArguments, instanceId, instanceRegion, configFile)
 
 	value, _, err := pluginutil.LocalRegistryKeyGetStringsValue(appconfig.ItemPropertyPath, appconfig.ItemPropertyName)
 	if err != nil {
@@ -211,7 +275,12 @@ func (p *Plugin) Start(configuration string, orchestrationDir string, cancelFlag
 	fileutil.DeleteFile(stdoutFilePath)
 	fileutil.DeleteFile(stderrFilePath)
 
-	process, exitCode, err := p.CommandExecuter.StartExe(p.Context, p.WorkingDir, out.GetStdoutWriter(), out.GetStderrWriter(), cancelFlag, commandName, commandArguments)
+	p.crashDumps.Prepare(commandName)
+	if err = p.prepareRunAsAccount(); err != nil {
+		log.Errorf("Failed to prepare the account cloudwatch.exe runs as: %v", err)
+		return err
+	}
+	process, exitCode, err := p.startExe(out.GetStdoutWriter(), out.GetStderrWriter(), cancelFlag, commandName, commandArguments)
 	if err != nil || exitCode != 0 {
 		return fmt.Errorf("Errors occurred while starting Cloudwatch exit code %v, error %v", exitCode, err)
 	}
@@ -220,12 +289,62 @@ func (p *Plugin) Start(configuration string, orchestrationDir string, cancelFlag
 	p.Process = process
 	log.Infof("Process id of cloudwatch.exe -> %v", p.Process.Pid)
 
+	// the restarted processes write their output to the files of the orchestration directory, the output of the
+	// plugin is closed once started
+	p.supervisor = supervisor.New(log, CloudWatchExeName, func() (supervisor.WaitFunc, error) {
+		return p.restart(stdoutFilePath, stderrFilePath, cancelFlag, commandName, commandArguments)
+	})
+	p.supervisor.Supervise(watchProcess(process, p.crashDumps, commandName))
 	return nil
 }
 
+// restart starts cloudwatch.exe again after it exited unexpectedly, its output is rotated once the files reach
+// the maximum size of the agent configuration
+func (p *Plugin) restart(stdoutFilePath, stderrFilePath string, cancelFlag task.CancelFlag, commandName string, commandArguments []string) (supervisor.WaitFunc, error) {
+	config := p.Context.AppConfig().Agent
+	maxSize := int64(config.CloudWatchOutputMaxSizeMB) * 1024 * 1024
+	stdout, err := openRotatedOutput(stdoutFilePath, maxSize, config.CloudWatchOutputMaxRotatedFiles)
+	if err != nil {
+		return nil, err
+	}
+	defer stdout.Close()
+	stderr, err := openRotatedOutput(stderrFilePath, maxSize, config.CloudWatchOutputMaxRotatedFiles)
+	if err != nil {
+		return nil, err
+	}
+	defer stderr.Close()
+
+	process, exitCode, err := p.startExe(stdout, stderr, cancelFlag, commandName, commandArguments)
+	if err != nil || exitCode != 0 {
+		return nil, fmt.Errorf("Errors occurred while starting Cloudwatch exit code %v, error %v", exitCode, err)
+	}
+	p.Process = process
+	p.Context.Log().Infof("Process id of the restarted cloudwatch.exe -> %v", process.Pid)
+	return watchProcess(process, p.crashDumps, commandName), nil
+}
+
+// StopWithDrain waits for cloudwatch.exe to deliver the data it collected, up to the drain timeout, then stops it
+func (p *Plugin) StopWithDrain(cancelFlag task.CancelFlag, drainTimeout time.Duration) error {
+	// cloudwatch.exe is not restarted while draining
+	if p.supervisor != nil {
+		p.supervisor.Stop()
+	}
+	if p.IsRunning() {
+		waitForFlush(p.Context.Log(), func(now time.Time) (PipelineStatus, error) {
+			return ReadPipelineStatus(filepath.Join(p.WorkingDir, PipelineLogFileName), now)
+		}, drainTimeout, cancelFlag)
+	}
+	return p.Stop(cancelFlag)
+}
+
 // Stop returns true if it successfully killed the cloudwatch exe or else it returns false
 func (p *Plugin) Stop(cancelFlag task.CancelFlag) (err error) {
 	log := p.Context.Log()
+	// cloudwatch.exe is not restarted once stopped
+	if p.supervisor != nil {
+		p.supervisor.Stop()
+	}
+	defer p.releaseRunAsAccount()
 
 	var cwProcInfo []CloudwatchProcessInfo
 	if cwProcInfo, err = p.GetProcInfoOfCloudWatchExe(
@@ -237,11 +356,12 @@ func (p *Plugin) Stop(cancelFlag task.CancelFlag) (err error) {
 	}
 
 	log.Info("The number of cloudwatch processes running are ", len(cwProcInfo))
+	stopTimeout := time.Duration(p.Context.AppConfig().Agent.CloudWatchStopTimeoutSeconds) * time.Second
 	var processKillError error
 	var currentProcess *os.Process
 	processKillError = nil
 	//Iterating through the cwProcess info to in case multiple Cloudwatch processes are running.
-	//All existing processes must be killed
+	//All existing processes must be stopped
 	for _, cloudwatchInfo := range cwProcInfo {
 		//Assigning existing cloudwatch process Id to currentProcess in order to kill that process.
 		log.Debug("PID of Cloudwatch is ", cloudwatchInfo.PId)
@@ -253,6 +373,11 @@ func (p *Plugin) Stop(cancelFlag task.CancelFlag) (err error) {
 			continue
 		}
 
+		// killing cloudwatch.exe can truncate the metric and log batches it is sending
+		if stopTimeout > 0 && p.stopGracefully(currentProcess, stopTimeout) {
+			continue
+		}
+
 		if err = killProcess(currentProcess); err != nil {
 			// Continuing here without returning to kill whatever processes can be killed even if something
 			// goes wrong. Return on error later
@@ -271,103 +396,44 @@ func (p *Plugin) Stop(cancelFlag task.CancelFlag) (err error) {
 	return nil
 }
 
-// IsCloudWatchExeRunning runs a powershell script to determine if the given process is running
-func (p *Plugin) IsCloudWatchExeRunning(workingDirectory, orchestrationDir string, cancelFlag task.CancelFlag) bool {
-	/*
-		Since most functions in "os" package in GoLang isn't implemented for Windows platform, we run a powershell
-		script (using Get-Process) to get process details in Windows.
-	*/
+// stopGracefully asks cloudwatch.exe to shut down and returns true if it exits within the stop timeout
+func (p *Plugin) stopGracefully(process *os.Process, stopTimeout time.Duration) bool {
 	log := p.Context.Log()
-	//constructing the powershell command to execute
-	var commandArguments []string
-	var err error
-	cloudwatchProcessName := CloudWatchProcessName
-	cmdIsExeRunning := fmt.Sprintf(IsProcessRunning, cloudwatchProcessName)
-	log.Debugf("Final cmd to check if process is still running is", cmdIsExeRunning)
-	commandArguments = append(commandArguments, cmdIsExeRunning)
-
-	// execute the command
-	var commandOutput string
-	if commandOutput, err = p.runPowerShell(workingDirectory, cancelFlag, commandArguments); err != nil {
-		//TODO Returning false here because we are unsure if Cloudwatch is running. Trying to kill PID will lead to error. Handle this situation
+	if err := requestShutdown(process.Pid); err != nil {
+		log.Warnf("Unable to ask cloudwatch process %v to shut down, killing it: %v", process.Pid, err)
 		return false
 	}
-
-	log.Debugf("The output of IsCloudwatchExeRunning is %s", commandOutput)
-	//Get-Process returned the Pid -> means it was not null
-	if strings.Contains(commandOutput, "True") {
-		log.Infof("Process %s is running", cloudwatchProcessName)
-		return true
-	} else if !strings.Contains(commandOutput, "False") {
-		log.Infof("Multiple processes of %s running. Command output is ", cloudwatchProcessName, commandOutput)
-		return true
+	if !waitForExit(process, stopTimeout) {
+		log.Warnf("Cloudwatch process %v did not exit %v after it was asked to shut down, killing it", process.Pid, stopTimeout)
+		return false
 	}
-
-	log.Infof("Process %s is not running", cloudwatchProcessName)
-	return false
+	log.Infof("Cloudwatch process %v shut down gracefully", process.Pid)
+	return true
 }
 
-// GetProcInfoOfCloudWatchExe runs a powershell script to determine the process ID of the Cloudwatch process. It should be called only after confirming that cloudwatch is running
-func (p *Plugin) GetProcInfoOfCloudWatchExe(orchestrationDir, workingDirectory string, cancelFlag task.CancelFlag) (cwProcInfo []CloudwatchProcessInfo, err error) {
+// IsCloudWatchExeRunning determines if the cloudwatch executable is running by enumerating the processes natively,
+// without depending on powershell and its execution policy
+func (p *Plugin) IsCloudWatchExeRunning(workingDirectory, orchestrationDir string, cancelFlag task.CancelFlag) bool {
 	log := p.Context.Log()
-	//constructing the powershell command to execute
-	var commandArguments []string
-	cmdGetPidOfCW := fmt.Sprintf(GetPidOfExe, CloudWatchProcessName)
-	log.Debugf("Command to get the PID info is ", cmdGetPidOfCW)
-	commandArguments = append(commandArguments, cmdGetPidOfCW)
-
-	// execute the command
-	var commandOutput string
-	if commandOutput, err = p.runPowerShell(workingDirectory, cancelFlag, commandArguments); err != nil {
-		return cwProcInfo, err
-	}
-
-	//Since output is returned as a Json, checking to see if output is not in the form of an array
-	//Output will be in the form of an array only in case of multiple Cloudwatch instances running
-	if !strings.HasPrefix(commandOutput, "[") && !strings.HasSuffix(commandOutput, "]") {
-		commandOutput = "[" + commandOutput + "]"
+	processes, err := listProcesses(CloudWatchExeName)
+	if err != nil {
+		//TODO Returning false here because we are unsure if Cloudwatch is running. Trying to kill PID will lead to error. Handle this situation
+		log.Errorf("Unable to list the %s processes: %v", CloudWatchProcessName, err)
+		return false
 	}
 
-	//Unmarshal the result into json obj.
-	if err = jsonutil.Unmarshal(commandOutput, &cwProcInfo); err != nil {
-		log.Errorf("Error unmarshalling Cloudwatch process information is %v", err)
-		return cwProcInfo, err
+	if len(processes) == 0 {
+		log.Infof("Process %s is not running", CloudWatchProcessName)
+		return false
 	}
-
-	return cwProcInfo, err
+	log.Infof("%d process(es) of %s running", len(processes), CloudWatchProcessName)
+	return true
 }
 
-// runPowerShell is a wrapper around Execute command to run powershell script
-func (p *Plugin) runPowerShell(workingDirectory string, cancelFlag task.CancelFlag, commandArguments []string) (commandOutput string, err error) {
-	log := p.Context.Log()
-	commandName := pluginutil.GetShellCommand()
-	log.Infof("commandName: %s", commandName)
-	log.Infof("arguments passed: %s", commandArguments)
-
-	//If the stdoutFile and stderrFile path is empty, p.CommandExecuter.Execute return the output as a buffer
-	stdoutFilePath := ""
-	stderrFilePath := ""
-	//executionTimeout -> determining if a process is running or not shouldn't take more than 60 seconds
-	executionTimeout := pluginutil.ValidateExecutionTimeout(log, 60)
-
-	//execute the command
-	stdout, stderr, exitCode, errs := p.CommandExecuter.Execute(p.Context, workingDirectory, stdoutFilePath,
-		stderrFilePath, cancelFlag, executionTimeout, commandName, commandArguments, make(map[string]string))
-
-	stdOutBuf := new(bytes.Buffer)
-	stdOutBuf.ReadFrom(stdout)
-	commandOutput = stdOutBuf.String()
-	stdErrBuf := new(bytes.Buffer)
-	stdErrBuf.ReadFrom(stderr)
-	commandOutputError := stdErrBuf.String()
-
-	//We don't expect any errors because the powershell script that we run has error action set as SilentlyContinue
-	if commandOutputError != "" {
-		log.Errorf("Powershell script to get process ID of the Cloudwatch executable currently running failed with error - %v", commandOutputError)
+// GetProcInfoOfCloudWatchExe returns the process IDs of the running cloudwatch executables
+func (p *Plugin) GetProcInfoOfCloudWatchExe(orchestrationDir, workingDirectory string, cancelFlag task.CancelFlag) (cwProcInfo []CloudwatchProcessInfo, err error) {
+	if cwProcInfo, err = listProcesses(CloudWatchExeName); err != nil {
+		p.Context.Log().Errorf("Unable to list the %s processes: %v", CloudWatchProcessName, err)
 	}
-
-	log.Debugf("exitCode - %v", exitCode)
-	log.Debugf("errs - %v", errs)
-
-	return commandOutput, nil
+	return cwProcInfo, err
 }
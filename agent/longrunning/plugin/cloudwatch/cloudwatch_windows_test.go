@@ -18,15 +18,17 @@
 package cloudwatch
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
-	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
 	multiwritermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter/mock"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/crashdump"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/supervisor"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/executers"
 	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
@@ -45,13 +47,40 @@ var pluginConfig = iohandler.PluginConfig{
 	OutputTruncatedSuffix: "cw",
 }
 
+// stubListProcesses makes the successive process listings return the given processes, the last ones are returned
+// by the later listings
+func stubListProcesses(t *testing.T, listings ...[]CloudwatchProcessInfo) {
+	origListProcesses := listProcesses
+	t.Cleanup(func() { listProcesses = origListProcesses })
+	listProcesses = func(exeName string) ([]CloudwatchProcessInfo, error) {
+		assert.Equal(t, CloudWatchExeName, exeName)
+		processes := listings[0]
+		if len(listings) > 1 {
+			listings = listings[1:]
+		}
+		return processes, nil
+	}
+}
+
+// stubWatchProcess makes the started processes run until the returned channel is closed
+func stubWatchProcess(t *testing.T) chan struct{} {
+	exit := make(chan struct{})
+	origWatchProcess := watchProcess
+	t.Cleanup(func() { watchProcess = origWatchProcess })
+	watchProcess = func(process *os.Process, crashDumps *crashdump.Collector, executable string) supervisor.WaitFunc {
+		return func() error {
+			<-exit
+			return nil
+		}
+	}
+	return exit
+}
+
 // TestStartFailFileNotExist tests the Start method, which returns nil when start the executable file successfully.
 func TestStartSuccess(t *testing.T) {
 	context := context.NewMockDefault()
 	cancelFlag := taskmocks.NewMockDefault()
 	execMock := &executers.MockCommandExecuter{}
-	stdout := strings.NewReader("False")
-	stderr := strings.NewReader("")
 	ioHandler := &iohandlermocks.MockIOHandler{}
 	testPid := 1986
 	findProcessCalled := false
@@ -64,15 +93,8 @@ func TestStartSuccess(t *testing.T) {
 	cancelFlag.On("Canceled").Return(false)
 	ioHandler.On("GetStdoutWriter").Return(&multiwritermock.MockDocumentIOMultiWriter{})
 	ioHandler.On("GetStderrWriter").Return(&multiwritermock.MockDocumentIOMultiWriter{})
-	execMock.On("Execute", mock.Anything,
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.Anything,
-		mock.AnythingOfType("int"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string"),
-		mock.AnythingOfType("map[string]string")).Return(stdout, stderr, 0, []error{})
+	stubListProcesses(t, nil)
+	exit := stubWatchProcess(t)
 
 	execMock.On("StartExe", mock.Anything,
 		mock.AnythingOfType("string"),
@@ -100,11 +122,18 @@ func TestStartSuccess(t *testing.T) {
 
 	p, _ := NewPlugin(context, pluginConfig)
 	p.CommandExecuter = execMock
-	res := p.Start("", "C:\\abc", cancelFlag, ioHandler)
+	res := p.Start(flowsBaseConfiguration, "C:\\abc", cancelFlag, ioHandler)
 
 	assert.Equal(t, nil, res)
 	assert.False(t, findProcessCalled)
 	assert.False(t, killProcessCalled)
+
+	// the expected exit that follows Stop does not restart cloudwatch.exe
+	p.supervisor.Stop()
+	close(exit)
+	<-p.supervisor.Done()
+	assert.Equal(t, supervisor.Status{}, p.SupervisorStatus())
+	execMock.AssertNumberOfCalls(t, "StartExe", 1)
 }
 
 // TestStartFailFileNotExist tests the Start method, which returns error when system cannot find the executable file.
@@ -117,11 +146,29 @@ func TestStartFailFileNotExist(t *testing.T) {
 	cancelFlag := taskmocks.NewMockDefault()
 
 	p, _ := NewPlugin(context, pluginConfig)
-	res := p.Start("", "", cancelFlag, ioHandler)
+	res := p.Start(flowsBaseConfiguration, "", cancelFlag, ioHandler)
 	expectErr := errors.New("unable to locate cloudwatch.exe")
 	assert.Equal(t, expectErr, res)
 }
 
+// TestStartFailInvalidConfiguration tests the Start method, which returns the configuration problems without
+// starting cloudwatch.exe.
+func TestStartFailInvalidConfiguration(t *testing.T) {
+	fileExist = func(filePath string) bool {
+		return true
+	}
+	ioHandler := &iohandlermocks.MockIOHandler{}
+	context := context.NewMockDefault()
+	cancelFlag := taskmocks.NewMockDefault()
+	execMock := &executers.MockCommandExecuter{}
+
+	p, _ := NewPlugin(context, pluginConfig)
+	p.CommandExecuter = execMock
+	res := p.Start(`{"EngineConfiguration": {"Components": []}}`, "", cancelFlag, ioHandler)
+	assert.IsType(t, &ConfigurationError{}, res)
+	execMock.AssertNotCalled(t, "StartExe")
+}
+
 func TestStopSuccess(t *testing.T) {
 	cancelFlag := taskmocks.NewMockDefault()
 	context := context.NewMockDefault()
@@ -134,10 +181,6 @@ func TestStopSuccess(t *testing.T) {
 		PId: testPid,
 	}
 
-	procInfoJSON, _ := json.Marshal(cwProcInfo)
-	stdout := strings.NewReader(string(procInfoJSON))
-	stderr := strings.NewReader("")
-
 	p, _ := NewPlugin(context, pluginConfig)
 	process := &os.Process{
 		Pid: testPid,
@@ -155,15 +198,7 @@ func TestStopSuccess(t *testing.T) {
 		return nil
 	}
 
-	execMock.On("Execute", mock.Anything,
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.Anything,
-		mock.AnythingOfType("int"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string"),
-		mock.AnythingOfType("map[string]string")).Return(stdout, stderr, 0, []error{})
+	stubListProcesses(t, []CloudwatchProcessInfo{cwProcInfo}, nil)
 
 	p.CommandExecuter = execMock
 	p.Process = process
@@ -185,10 +220,6 @@ func TestStopFail_FailedToFindCloudWatchProcess(t *testing.T) {
 		PId: testPid,
 	}
 
-	procInfoJSON, _ := json.Marshal(cwProcInfo)
-	stdout := strings.NewReader(string(procInfoJSON))
-	stderr := strings.NewReader("")
-
 	p, _ := NewPlugin(context, pluginConfig)
 	process := &os.Process{
 		Pid: testPid,
@@ -205,15 +236,7 @@ func TestStopFail_FailedToFindCloudWatchProcess(t *testing.T) {
 		return nil
 	}
 
-	execMock.On("Execute", mock.Anything,
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.Anything,
-		mock.AnythingOfType("int"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string"),
-		mock.AnythingOfType("map[string]string")).Return(stdout, stderr, 0, []error{})
+	stubListProcesses(t, []CloudwatchProcessInfo{cwProcInfo})
 
 	p.CommandExecuter = execMock
 	p.Process = process
@@ -237,10 +260,6 @@ func TestStopFail_FailedToKillProcess(t *testing.T) {
 		PId: testPid,
 	}
 
-	procInfoJSON, _ := json.Marshal(cwProcInfo)
-	stdout := strings.NewReader(string(procInfoJSON))
-	stderr := strings.NewReader("")
-
 	p, _ := NewPlugin(context, pluginConfig)
 	process := &os.Process{
 		Pid: testPid,
@@ -258,15 +277,7 @@ func TestStopFail_FailedToKillProcess(t *testing.T) {
 		return expProcessKillError
 	}
 
-	execMock.On("Execute", mock.Anything,
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.Anything,
-		mock.AnythingOfType("int"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string"),
-		mock.AnythingOfType("map[string]string")).Return(stdout, stderr, 0, []error{})
+	stubListProcesses(t, []CloudwatchProcessInfo{cwProcInfo})
 
 	p.CommandExecuter = execMock
 	p.Process = process
@@ -277,6 +288,69 @@ func TestStopFail_FailedToKillProcess(t *testing.T) {
 	assert.True(t, killProcessCalled)
 }
 
+// stubShutdown makes the shutdown requests fail with the given error and the processes exit when asked if exits is true
+func stubShutdown(t *testing.T, requestErr error, exits bool) (requested *[]int) {
+	origRequestShutdown, origWaitForExit := requestShutdown, waitForExit
+	t.Cleanup(func() { requestShutdown, waitForExit = origRequestShutdown, origWaitForExit })
+	requested = &[]int{}
+	requestShutdown = func(pid int) error {
+		*requested = append(*requested, pid)
+		return requestErr
+	}
+	waitForExit = func(process *os.Process, timeout time.Duration) bool {
+		assert.Equal(t, 45*time.Second, timeout)
+		return exits
+	}
+	return requested
+}
+
+func newGracefulStopPlugin(t *testing.T, testPid int) (p *Plugin, killed *bool) {
+	config := appconfig.DefaultConfig()
+	config.Agent.CloudWatchStopTimeoutSeconds = 45
+	p, _ = NewPlugin(context.NewMockDefaultWithConfig(config), pluginConfig)
+	process := &os.Process{Pid: testPid}
+	p.Process = process
+
+	origFindProcess, origKillProcess := findProcess, killProcess
+	t.Cleanup(func() { findProcess, killProcess = origFindProcess, origKillProcess })
+	findProcess = func(pid int) (*os.Process, error) {
+		return process, nil
+	}
+	killed = new(bool)
+	killProcess = func(*os.Process) error {
+		*killed = true
+		return nil
+	}
+	stubListProcesses(t, []CloudwatchProcessInfo{{PId: testPid}}, nil)
+	return p, killed
+}
+
+func TestStopGracefully(t *testing.T) {
+	requested := stubShutdown(t, nil, true)
+	p, killed := newGracefulStopPlugin(t, 1986)
+
+	assert.NoError(t, p.Stop(taskmocks.NewMockDefault()))
+	assert.Equal(t, []int{1986}, *requested)
+	assert.False(t, *killed)
+}
+
+func TestStopGracefully_KilledAfterTimeout(t *testing.T) {
+	requested := stubShutdown(t, nil, false)
+	p, killed := newGracefulStopPlugin(t, 1986)
+
+	assert.NoError(t, p.Stop(taskmocks.NewMockDefault()))
+	assert.Equal(t, []int{1986}, *requested)
+	assert.True(t, *killed)
+}
+
+func TestStopGracefully_KilledWhenShutdownCannotBeRequested(t *testing.T) {
+	stubShutdown(t, errors.New("access denied"), true)
+	p, killed := newGracefulStopPlugin(t, 1986)
+
+	assert.NoError(t, p.Stop(taskmocks.NewMockDefault()))
+	assert.True(t, *killed)
+}
+
 // TestIsCloudWatchExeRunning tests the IsCloudWatchExeRunning method, which returns true when the cloud watch exe is running.
 func TestIsCloudWatchExeRunningTrue(t *testing.T) {
 	context := context.NewMockDefault()
@@ -284,18 +358,8 @@ func TestIsCloudWatchExeRunningTrue(t *testing.T) {
 	cancelFlag.On("Wait").Return(task.Completed)
 	cancelFlag.On("Canceled").Return(false)
 	execMock := &executers.MockCommandExecuter{}
-	stdout := strings.NewReader("True")
-	stderr := strings.NewReader("")
 
-	execMock.On("Execute", mock.Anything,
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.Anything,
-		mock.AnythingOfType("int"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string"),
-		mock.AnythingOfType("map[string]string")).Return(stdout, stderr, 0, []error{})
+	stubListProcesses(t, []CloudwatchProcessInfo{{ProcessName: CloudWatchProcessName, PId: 1234}})
 
 	fileExist = func(filePath string) bool {
 		return true
@@ -312,19 +376,7 @@ func TestIsCloudWatchExeRunningFalse(t *testing.T) {
 	cancelFlag := taskmocks.NewMockDefault()
 	cancelFlag.On("Wait").Return(task.Completed)
 	cancelFlag.On("Canceled").Return(false)
-	execMock := &executers.MockCommandExecuter{}
-	stdout := strings.NewReader("False")
-	stderr := strings.NewReader("")
-
-	execMock.On("Execute", mock.Anything,
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.Anything,
-		mock.AnythingOfType("int"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string"),
-		mock.AnythingOfType("map[string]string")).Return(stdout, stderr, 0, []error{})
+	stubListProcesses(t, nil)
 
 	fileExist = func(filePath string) bool {
 		return true
@@ -336,6 +388,29 @@ func TestIsCloudWatchExeRunningFalse(t *testing.T) {
 
 }
 
+// TestIsCloudWatchExeRunningListError tests that the cloudwatch exe is not reported running when the processes cannot be listed,
+// powershell is never run.
+func TestIsCloudWatchExeRunningListError(t *testing.T) {
+	origListProcesses := listProcesses
+	defer func() { listProcesses = origListProcesses }()
+	listProcesses = func(exeName string) ([]CloudwatchProcessInfo, error) {
+		assert.Equal(t, CloudWatchExeName, exeName)
+		return nil, errors.New("access denied")
+	}
+	cancelFlag := taskmocks.NewMockDefault()
+	execMock := &executers.MockCommandExecuter{}
+	fileExist = func(filePath string) bool {
+		return true
+	}
+
+	var p, _ = NewPlugin(context.NewMockDefault(), pluginConfig)
+	p.CommandExecuter = execMock
+	assert.False(t, p.IsCloudWatchExeRunning("", "", cancelFlag))
+	_, err := p.GetProcInfoOfCloudWatchExe("", "", cancelFlag)
+	assert.Error(t, err)
+	execMock.AssertNotCalled(t, "Execute")
+}
+
 // TestGetPidOfCloudWatchExe tests the GetPidOfCloudWatchExe method, which returns if the said plugin is running or not.
 func TestGetPidOfCloudWatchExeSuccess(t *testing.T) {
 	context := context.NewMockDefault()
@@ -348,18 +423,7 @@ func TestGetPidOfCloudWatchExeSuccess(t *testing.T) {
 		PId: testPid,
 	}
 
-	procInfoJSON, _ := json.Marshal(cwProcInfo)
-	stdout := strings.NewReader(string(procInfoJSON))
-	stderr := strings.NewReader("")
-	execMock.On("Execute", mock.Anything,
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("string"),
-		mock.Anything,
-		mock.AnythingOfType("int"),
-		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string"),
-		mock.AnythingOfType("map[string]string")).Return(stdout, stderr, 0, []error{})
+	stubListProcesses(t, []CloudwatchProcessInfo{cwProcInfo})
 
 	fileExist = func(filePath string) bool {
 		return true
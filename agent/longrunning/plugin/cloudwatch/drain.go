@@ -0,0 +1,56 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package cloudwatch
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// drainPollInterval is the time between two reads of the pipeline log while draining
+var drainPollInterval = 2 * time.Second
+
+// waitForFlush waits until cloudwatch.exe logs a delivery made after the drain started, which shows the data
+// collected before the stop request was shipped. It returns false when the timeout elapses or the drain is canceled
+// first, or when the pipeline log cannot be read.
+func waitForFlush(log log.T, readStatus func(now time.Time) (PipelineStatus, error), timeout time.Duration, cancelFlag task.CancelFlag) bool {
+	drainStart := time.Now()
+	deadline := drainStart.Add(timeout)
+	for {
+		status, err := readStatus(time.Now())
+		if err != nil {
+			log.Warnf("Unable to read the cloudwatch pipeline log while draining: %v", err)
+			return false
+		}
+		// the log has a precision of a second, the deliveries logged the second the drain started may precede it
+		if status.LastUpload.After(drainStart.Truncate(time.Second)) {
+			log.Infof("cloudwatch.exe delivered its pending data at %v", status.LastUpload)
+			return true
+		}
+		if cancelFlag.Canceled() {
+			log.Infof("Drain of cloudwatch.exe was canceled")
+			return false
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			log.Warnf("cloudwatch.exe did not deliver its pending data within %v, last delivery at %v", timeout, status.LastUpload)
+			return false
+		}
+		if wait > drainPollInterval {
+			wait = drainPollInterval
+		}
+		time.Sleep(wait)
+	}
+}
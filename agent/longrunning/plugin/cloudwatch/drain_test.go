@@ -0,0 +1,74 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package cloudwatch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubDrainPollInterval(t *testing.T) {
+	previous := drainPollInterval
+	drainPollInterval = time.Millisecond
+	t.Cleanup(func() { drainPollInterval = previous })
+}
+
+func TestWaitForFlush_Delivered(t *testing.T) {
+	stubDrainPollInterval(t)
+	reads := 0
+	flushed := waitForFlush(logmocks.NewMockLog(), func(now time.Time) (PipelineStatus, error) {
+		reads++
+		if reads < 3 {
+			// a delivery logged before the drain does not flush the data collected since
+			return PipelineStatus{Time: now, LastUpload: now.Add(-time.Minute)}, nil
+		}
+		return PipelineStatus{Time: now, LastUpload: now.Add(time.Second)}, nil
+	}, time.Minute, task.NewChanneledCancelFlag())
+
+	assert.True(t, flushed)
+	assert.Equal(t, 3, reads)
+}
+
+func TestWaitForFlush_Timeout(t *testing.T) {
+	stubDrainPollInterval(t)
+	flushed := waitForFlush(logmocks.NewMockLog(), func(now time.Time) (PipelineStatus, error) {
+		return PipelineStatus{Time: now}, nil
+	}, 20*time.Millisecond, task.NewChanneledCancelFlag())
+
+	assert.False(t, flushed)
+}
+
+func TestWaitForFlush_Canceled(t *testing.T) {
+	stubDrainPollInterval(t)
+	cancelFlag := task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.Canceled)
+	flushed := waitForFlush(logmocks.NewMockLog(), func(now time.Time) (PipelineStatus, error) {
+		return PipelineStatus{Time: now}, nil
+	}, time.Hour, cancelFlag)
+
+	assert.False(t, flushed)
+}
+
+func TestWaitForFlush_UnreadableLog(t *testing.T) {
+	stubDrainPollInterval(t)
+	flushed := waitForFlush(logmocks.NewMockLog(), func(now time.Time) (PipelineStatus, error) {
+		return PipelineStatus{Time: now}, errors.New("file not found")
+	}, time.Hour, task.NewChanneledCancelFlag())
+
+	assert.False(t, flushed)
+}
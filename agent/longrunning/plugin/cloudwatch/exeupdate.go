@@ -0,0 +1,109 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cloudwatch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// exeUpdateFolderName is the folder of the download root the updates of the exe are downloaded to
+const exeUpdateFolderName = "awsCloudWatch"
+
+var downloadArtifact = artifact.Download
+
+// updateExe replaces the exe with the one published at the update source of the agent configuration when their
+// checksums differ, the exe must not be running. It returns true if the exe was replaced.
+func updateExe(context context.T, exePath string) (bool, error) {
+	log := context.Log()
+	source := context.AppConfig().Agent.CloudWatchExeUpdateSource
+	checksum := context.AppConfig().Agent.CloudWatchExeUpdateSHA256
+	if source == "" {
+		return false, nil
+	}
+	if checksum == "" {
+		return false, fmt.Errorf("the update of %v from %v cannot be verified, CloudWatchExeUpdateSHA256 is not set", exePath, source)
+	}
+	if current, err := artifact.Sha256HashValue(log, exePath); err == nil && strings.EqualFold(current, checksum) {
+		log.Debugf("%v is up to date with %v", exePath, source)
+		return false, nil
+	}
+
+	output, err := downloadArtifact(context, artifact.DownloadInput{
+		SourceURL:            source,
+		DestinationDirectory: filepath.Join(appconfig.DownloadRoot, exeUpdateFolderName),
+		SourceChecksums:      map[string]string{"sha256": checksum},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to download %v: %v", source, err)
+	}
+	// local sources are not copied to the download directory
+	if output.LocalFilePath != source {
+		defer fileutil.DeleteFile(output.LocalFilePath)
+	}
+	if !output.IsHashMatched {
+		return false, fmt.Errorf("the checksum of %v does not match CloudWatchExeUpdateSHA256", source)
+	}
+	if err = swapExe(log, exePath, output.LocalFilePath, checksum); err != nil {
+		return false, err
+	}
+	log.Infof("Updated %v from %v", exePath, source)
+	return true, nil
+}
+
+// swapExe copies the downloaded exe next to the exe and renames it in place, the previous exe is restored when the
+// new one cannot be renamed
+func swapExe(log log.T, exePath, downloadedPath, checksum string) error {
+	newPath, backupPath := exePath+".new", exePath+".old"
+	src, err := os.Open(downloadedPath)
+	if err != nil {
+		return err
+	}
+	_, err = artifact.FileCopy(log, newPath, src)
+	src.Close()
+	defer os.Remove(newPath)
+	if err != nil {
+		return fmt.Errorf("failed to copy the update of %v: %v", exePath, err)
+	}
+	// the download directory is not protected like the plugin folder, the copy is verified again
+	if copied, err := artifact.Sha256HashValue(log, newPath); err != nil || !strings.EqualFold(copied, checksum) {
+		return fmt.Errorf("the copy of the update of %v does not match CloudWatchExeUpdateSHA256", exePath)
+	}
+
+	os.Remove(backupPath)
+	movedAside := true
+	if err = os.Rename(exePath, backupPath); os.IsNotExist(err) {
+		movedAside = false
+	} else if err != nil {
+		return fmt.Errorf("failed to move %v aside, it may still be running: %v", exePath, err)
+	}
+	if err = os.Rename(newPath, exePath); err != nil {
+		if movedAside {
+			if restoreErr := os.Rename(backupPath, exePath); restoreErr != nil {
+				log.Errorf("Failed to restore %v: %v", exePath, restoreErr)
+			}
+		}
+		return fmt.Errorf("failed to replace %v: %v", exePath, err)
+	}
+	os.Remove(backupPath)
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cloudwatch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+const updateSource = "https://example.com/AWS.CloudWatch.exe"
+
+func checksumOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func newUpdateContext(source, checksum string) *contextmocks.Mock {
+	config := appconfig.DefaultConfig()
+	config.Agent.CloudWatchExeUpdateSource = source
+	config.Agent.CloudWatchExeUpdateSHA256 = checksum
+	return contextmocks.NewMockDefaultWithConfig(config)
+}
+
+// stubDownload serves the given content as the download of the update source and returns the number of downloads
+func stubDownload(t *testing.T, content string, hashMatched bool) *int {
+	downloads := 0
+	downloadPath := filepath.Join(t.TempDir(), "download")
+	previous := downloadArtifact
+	t.Cleanup(func() { downloadArtifact = previous })
+	downloadArtifact = func(context context.T, input artifact.DownloadInput) (artifact.DownloadOutput, error) {
+		downloads++
+		assert.Equal(t, updateSource, input.SourceURL)
+		err := ioutil.WriteFile(downloadPath, []byte(content), appconfig.ReadWriteAccess)
+		return artifact.DownloadOutput{LocalFilePath: downloadPath, IsUpdated: true, IsHashMatched: hashMatched}, err
+	}
+	return &downloads
+}
+
+func writeExe(t *testing.T, content string) string {
+	exePath := filepath.Join(t.TempDir(), "AWS.CloudWatch.exe")
+	assert.NoError(t, ioutil.WriteFile(exePath, []byte(content), appconfig.ReadWriteAccess))
+	return exePath
+}
+
+func TestUpdateExe_NoSource(t *testing.T) {
+	downloads := stubDownload(t, "new", true)
+	updated, err := updateExe(newUpdateContext("", ""), writeExe(t, "bundled"))
+
+	assert.NoError(t, err)
+	assert.False(t, updated)
+	assert.Equal(t, 0, *downloads)
+}
+
+func TestUpdateExe_NoChecksum(t *testing.T) {
+	downloads := stubDownload(t, "new", true)
+	updated, err := updateExe(newUpdateContext(updateSource, ""), writeExe(t, "bundled"))
+
+	// the update is never applied without a checksum to verify it
+	assert.Error(t, err)
+	assert.False(t, updated)
+	assert.Equal(t, 0, *downloads)
+}
+
+func TestUpdateExe_UpToDate(t *testing.T) {
+	downloads := stubDownload(t, "new", true)
+	updated, err := updateExe(newUpdateContext(updateSource, checksumOf("new")), writeExe(t, "new"))
+
+	assert.NoError(t, err)
+	assert.False(t, updated)
+	assert.Equal(t, 0, *downloads)
+}
+
+func TestUpdateExe_Updated(t *testing.T) {
+	downloads := stubDownload(t, "new", true)
+	exePath := writeExe(t, "bundled")
+	updated, err := updateExe(newUpdateContext(updateSource, checksumOf("new")), exePath)
+
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, 1, *downloads)
+	content, _ := ioutil.ReadFile(exePath)
+	assert.Equal(t, "new", string(content))
+	files, _ := ioutil.ReadDir(filepath.Dir(exePath))
+	assert.Len(t, files, 1, "the previous exe and the copy of the update are removed")
+}
+
+func TestUpdateExe_ChecksumMismatch(t *testing.T) {
+	stubDownload(t, "tampered", false)
+	exePath := writeExe(t, "bundled")
+	updated, err := updateExe(newUpdateContext(updateSource, checksumOf("new")), exePath)
+
+	assert.Error(t, err)
+	assert.False(t, updated)
+	content, _ := ioutil.ReadFile(exePath)
+	assert.Equal(t, "bundled", string(content))
+}
+
+func TestSwapExe_CopyDoesNotMatch(t *testing.T) {
+	exePath := writeExe(t, "bundled")
+	downloadPath := writeExe(t, "tampered")
+
+	assert.Error(t, swapExe(contextmocks.NewMockDefault().Log(), exePath, downloadPath, checksumOf("new")))
+	content, _ := ioutil.ReadFile(exePath)
+	assert.Equal(t, "bundled", string(content))
+	assert.False(t, fileutil.Exists(exePath+".new"))
+}
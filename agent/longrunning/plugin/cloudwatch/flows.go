@@ -0,0 +1,198 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package cloudwatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// FlowsFolderName is the folder of the cloud watch config folder holding the flow files merged into the
+	// engine configuration
+	FlowsFolderName = "flows"
+	// MergedConfigFileName is the configuration passed to cloudwatch.exe when flow files are present
+	MergedConfigFileName = "AWS.EC2.Windows.CloudWatch.Merged.json"
+	// FlowValidationReportFileName holds the validation summary of each flow file of the last merge
+	FlowValidationReportFileName = "FlowValidationReport.json"
+
+	flowFileExtension = ".json"
+	componentIDKey    = "Id"
+)
+
+// flowFile declares components and the flows between them, in the format of the engine configuration
+type flowFile struct {
+	Components []map[string]interface{} `json:"Components"`
+	Flows      []string                 `json:"Flows"`
+}
+
+// flowValidationReport summarizes the validation of a flow file, a file with errors is not merged
+type flowValidationReport struct {
+	File       string   `json:"File"`
+	Components int      `json:"Components"`
+	Flows      int      `json:"Flows"`
+	Merged     bool     `json:"Merged"`
+	Errors     []string `json:"Errors,omitempty"`
+}
+
+// writeMergedConfiguration merges the flow files of the config folder into the engine configuration of the
+// given configuration and writes the result for cloudwatch.exe along with the validation report of the flow files.
+// The path of the configuration to pass to cloudwatch.exe is returned, it is the plugin config file when there are
+// no flow files.
+func writeMergedConfiguration(log log.T, configuration string, location string) (configFile string, err error) {
+	flowsDir := filepath.Join(location, FlowsFolderName)
+	if !fileutil.Exists(flowsDir) {
+		return filepath.Join(location, ConfigFileName), nil
+	}
+
+	var parser EngineConfigurationParser
+	if err = json.Unmarshal([]byte(configuration), &parser); err != nil {
+		return "", fmt.Errorf("failed to parse the cloud watch configuration: %v", err)
+	}
+	merged, reports := mergeFlowFiles(parser.EngineConfiguration, flowsDir)
+	for _, report := range reports {
+		if report.Merged {
+			log.Infof("Merged flow file %v: %d components, %d flows", report.File, report.Components, report.Flows)
+		} else {
+			log.Warnf("Skipped flow file %v: %v", report.File, strings.Join(report.Errors, "; "))
+		}
+	}
+
+	var content string
+	if content, err = jsonutil.MarshalIndent(reports); err != nil {
+		return "", err
+	}
+	if _, err = fileutil.WriteIntoFileWithPermissions(filepath.Join(location, FlowValidationReportFileName), content, os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
+		return "", err
+	}
+
+	configFile = filepath.Join(location, MergedConfigFileName)
+	if content, err = jsonutil.MarshalIndent(CloudWatchConfigImpl{IsEnabled: true, EngineConfiguration: merged}); err != nil {
+		return "", err
+	}
+	if _, err = fileutil.WriteIntoFileWithPermissions(configFile, content, os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
+		return "", err
+	}
+	return configFile, nil
+}
+
+// mergeFlowFiles adds the components and flows of the flow files, in the order of their names, to the engine
+// configuration. A flow file is skipped when it cannot be parsed, declares a component id already declared or has
+// a flow referencing an undeclared component.
+func mergeFlowFiles(engineConfiguration interface{}, flowsDir string) (merged map[string]interface{}, reports []flowValidationReport) {
+	merged, ok := engineConfiguration.(map[string]interface{})
+	if !ok || merged == nil {
+		merged = make(map[string]interface{})
+	}
+	components, _ := merged["Components"].([]interface{})
+	flowsSection, ok := merged["Flows"].(map[string]interface{})
+	if !ok {
+		flowsSection = make(map[string]interface{})
+	}
+	flows, _ := flowsSection["Flows"].([]interface{})
+
+	// declaredBy maps the declared component ids to the file declaring them
+	declaredBy := make(map[string]string)
+	for _, component := range components {
+		if id := componentID(component); id != "" {
+			declaredBy[id] = ConfigFileName
+		}
+	}
+
+	fileNames, _ := fileutil.GetFileNames(flowsDir)
+	for _, fileName := range fileNames {
+		if !strings.EqualFold(filepath.Ext(fileName), flowFileExtension) {
+			continue
+		}
+		report := flowValidationReport{File: fileName}
+		var file flowFile
+		if err := jsonutil.UnmarshalFile(filepath.Join(flowsDir, fileName), &file); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("invalid flow file: %v", err))
+			reports = append(reports, report)
+			continue
+		}
+		report.Components, report.Flows = len(file.Components), len(file.Flows)
+		report.Errors = validateFlowFile(file, fileName, declaredBy)
+		if len(report.Errors) == 0 {
+			for _, component := range file.Components {
+				declaredBy[componentID(component)] = fileName
+				components = append(components, component)
+			}
+			for _, flow := range file.Flows {
+				flows = append(flows, flow)
+			}
+			report.Merged = true
+		}
+		reports = append(reports, report)
+	}
+
+	merged["Components"] = components
+	flowsSection["Flows"] = flows
+	merged["Flows"] = flowsSection
+	return merged, reports
+}
+
+// validateFlowFile returns the id collisions of the components of the flow file and the references of its flows to
+// undeclared components
+func validateFlowFile(file flowFile, fileName string, declaredBy map[string]string) (errors []string) {
+	declaredHere := make(map[string]bool)
+	for i, component := range file.Components {
+		id := componentID(component)
+		if id == "" {
+			errors = append(errors, fmt.Sprintf("component %d has no %v", i, componentIDKey))
+			continue
+		}
+		if declaredHere[id] {
+			errors = append(errors, fmt.Sprintf("component %v is declared twice", id))
+		} else if owner, found := declaredBy[id]; found {
+			errors = append(errors, fmt.Sprintf("component %v is already declared in %v", id, owner))
+		}
+		declaredHere[id] = true
+	}
+	for _, flow := range file.Flows {
+		for _, id := range flowComponentIDs(flow) {
+			if _, found := declaredBy[id]; !found && !declaredHere[id] {
+				errors = append(errors, fmt.Sprintf("flow %v references the undeclared component %v", flow, id))
+			}
+		}
+	}
+	return errors
+}
+
+// componentID returns the id of a component of the engine configuration
+func componentID(component interface{}) string {
+	if fields, ok := component.(map[string]interface{}); ok {
+		if id, ok := fields[componentIDKey].(string); ok {
+			return strings.TrimSpace(id)
+		}
+	}
+	return ""
+}
+
+// flowComponentIDs returns the component ids of a flow, e.g. (ApplicationEventLog,SystemEventLog),CloudWatchLogs
+func flowComponentIDs(flow string) (ids []string) {
+	for _, id := range strings.Split(strings.NewReplacer("(", "", ")", "").Replace(flow), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
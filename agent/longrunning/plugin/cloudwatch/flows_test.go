@@ -0,0 +1,133 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package cloudwatch
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const flowsBaseConfiguration = `{"EngineConfiguration": {
+	"PollInterval": "00:00:15",
+	"Components": [
+		{"Id": "ApplicationEventLog", "FullName": "AWS.EC2.Windows.CloudWatch.EventLog.EventLogInputComponent,AWS.EC2.Windows.CloudWatch"},
+		{"Id": "CloudWatchLogs", "FullName": "AWS.EC2.Windows.CloudWatch.CloudWatchLogsOutput,AWS.EC2.Windows.CloudWatch"}
+	],
+	"Flows": {"Flows": ["ApplicationEventLog,CloudWatchLogs"]}
+}}`
+
+func writeFlowFile(t *testing.T, flowsDir, name, content string) {
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(flowsDir, name), []byte(content), 0600))
+}
+
+func newFlowsLocation(t *testing.T) (location, flowsDir string) {
+	location = t.TempDir()
+	flowsDir = filepath.Join(location, FlowsFolderName)
+	assert.NoError(t, os.Mkdir(flowsDir, 0700))
+	return
+}
+
+func TestWriteMergedConfiguration_NoFlowsFolder(t *testing.T) {
+	location := t.TempDir()
+
+	configFile, err := writeMergedConfiguration(logmocks.NewMockLog(), flowsBaseConfiguration, location)
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(location, ConfigFileName), configFile)
+	assert.NoFileExists(t, filepath.Join(location, MergedConfigFileName))
+}
+
+func TestWriteMergedConfiguration_MergesValidFlowFiles(t *testing.T) {
+	location, flowsDir := newFlowsLocation(t)
+	writeFlowFile(t, flowsDir, "10-system.json", `{
+		"Components": [{"Id": "SystemEventLog", "FullName": "AWS.EC2.Windows.CloudWatch.EventLog.EventLogInputComponent,AWS.EC2.Windows.CloudWatch"}],
+		"Flows": ["(ApplicationEventLog,SystemEventLog),CloudWatchLogs"]
+	}`)
+	writeFlowFile(t, flowsDir, "20-iis.json", `{
+		"Components": [{"Id": "IISLogs"}, {"Id": "CloudWatchIIS"}],
+		"Flows": ["IISLogs,CloudWatchIIS", "SystemEventLog,CloudWatchIIS"]
+	}`)
+	writeFlowFile(t, flowsDir, "README.txt", "not a flow file")
+
+	configFile, err := writeMergedConfiguration(logmocks.NewMockLog(), flowsBaseConfiguration, location)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(location, MergedConfigFileName), configFile)
+
+	var merged CloudWatchConfigImpl
+	content, _ := ioutil.ReadFile(configFile)
+	assert.NoError(t, json.Unmarshal(content, &merged))
+	assert.True(t, merged.IsEnabled)
+	engineConfiguration := merged.EngineConfiguration.(map[string]interface{})
+	assert.Equal(t, "00:00:15", engineConfiguration["PollInterval"])
+	assert.Len(t, engineConfiguration["Components"], 5)
+	assert.Equal(t, []interface{}{
+		"ApplicationEventLog,CloudWatchLogs",
+		"(ApplicationEventLog,SystemEventLog),CloudWatchLogs",
+		"IISLogs,CloudWatchIIS",
+		"SystemEventLog,CloudWatchIIS",
+	}, engineConfiguration["Flows"].(map[string]interface{})["Flows"])
+
+	var reports []flowValidationReport
+	content, _ = ioutil.ReadFile(filepath.Join(location, FlowValidationReportFileName))
+	assert.NoError(t, json.Unmarshal(content, &reports))
+	assert.Equal(t, []flowValidationReport{
+		{File: "10-system.json", Components: 1, Flows: 1, Merged: true},
+		{File: "20-iis.json", Components: 2, Flows: 2, Merged: true},
+	}, reports)
+}
+
+func TestMergeFlowFiles_SkipsInvalidFiles(t *testing.T) {
+	_, flowsDir := newFlowsLocation(t)
+	writeFlowFile(t, flowsDir, "a-collision.json", `{
+		"Components": [{"Id": "CloudWatchLogs"}, {"Id": "Twice"}, {"Id": "Twice"}, {"FullName": "NoId"}]
+	}`)
+	writeFlowFile(t, flowsDir, "b-undeclared.json", `{
+		"Components": [{"Id": "SecurityEventLog"}],
+		"Flows": ["SecurityEventLog,MissingOutput"]
+	}`)
+	writeFlowFile(t, flowsDir, "c-invalid.json", `{"Components": `)
+
+	var parser EngineConfigurationParser
+	json.Unmarshal([]byte(flowsBaseConfiguration), &parser)
+	merged, reports := mergeFlowFiles(parser.EngineConfiguration, flowsDir)
+
+	assert.Len(t, merged["Components"], 2)
+	assert.Len(t, reports, 3)
+	assert.False(t, reports[0].Merged)
+	assert.Equal(t, []string{
+		"component CloudWatchLogs is already declared in " + ConfigFileName,
+		"component Twice is declared twice",
+		"component 3 has no Id",
+	}, reports[0].Errors)
+	assert.False(t, reports[1].Merged)
+	assert.Equal(t, []string{"flow SecurityEventLog,MissingOutput references the undeclared component MissingOutput"}, reports[1].Errors)
+	assert.False(t, reports[2].Merged)
+	assert.Len(t, reports[2].Errors, 1)
+}
+
+func TestMergeFlowFiles_EmptyEngineConfiguration(t *testing.T) {
+	_, flowsDir := newFlowsLocation(t)
+	writeFlowFile(t, flowsDir, "flow.json", `{"Components": [{"Id": "A"}, {"Id": "B"}], "Flows": ["A,B"]}`)
+
+	merged, reports := mergeFlowFiles(nil, flowsDir)
+
+	assert.Len(t, merged["Components"], 2)
+	assert.Equal(t, []interface{}{"A,B"}, merged["Flows"].(map[string]interface{})["Flows"])
+	assert.True(t, reports[0].Merged)
+}
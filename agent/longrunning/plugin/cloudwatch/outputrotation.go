@@ -0,0 +1,137 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cloudwatch
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// rotatingFile is a file rotated once it reaches its maximum size, the rotated files are named after the file with
+// the suffixes .1, the most recent, to .maxRotatedFiles and the oldest one is deleted. The file is truncated instead
+// when no rotated file is kept, and never rotated when its maximum size is 0.
+type rotatingFile struct {
+	path            string
+	maxSize         int64
+	maxRotatedFiles int
+
+	lock sync.Mutex
+	file *os.File
+	size int64
+}
+
+// openRotatingFile opens the file to append to it, it is rotated by the first write if it is already full
+func openRotatingFile(path string, maxSize int64, maxRotatedFiles int) (*rotatingFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, appconfig.ReadWriteAccess)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &rotatingFile{path: path, maxSize: maxSize, maxRotatedFiles: maxRotatedFiles, file: file, size: info.Size()}, nil
+}
+
+// Write appends the data to the file, the data which does not fit is written to the file once rotated
+func (f *rotatingFile) Write(data []byte) (written int, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for len(data) > 0 {
+		chunk := data
+		if f.maxSize > 0 {
+			if f.size >= f.maxSize {
+				if err = f.rotate(); err != nil {
+					return written, err
+				}
+			}
+			if remaining := f.maxSize - f.size; int64(len(chunk)) > remaining {
+				chunk = chunk[:remaining]
+			}
+		}
+		var n int
+		n, err = f.file.Write(chunk)
+		written += n
+		f.size += int64(n)
+		if err != nil {
+			return written, err
+		}
+		data = data[n:]
+	}
+	return written, nil
+}
+
+// Close closes the file
+func (f *rotatingFile) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.file.Close()
+}
+
+// rotate shifts the rotated files and renames the file to the most recent one, the file is closed first as open
+// files cannot be renamed on windows
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if f.maxRotatedFiles > 0 {
+		os.Remove(f.rotatedPath(f.maxRotatedFiles))
+		for index := f.maxRotatedFiles - 1; index > 0; index-- {
+			os.Rename(f.rotatedPath(index), f.rotatedPath(index+1))
+		}
+		if err := os.Rename(f.path, f.rotatedPath(1)); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, appconfig.ReadWriteAccess)
+	if err != nil {
+		return err
+	}
+	f.file, f.size = file, 0
+	return nil
+}
+
+func (f *rotatingFile) rotatedPath(index int) string {
+	return fmt.Sprintf("%v.%d", f.path, index)
+}
+
+// openRotatedOutput returns the write end of a pipe copied to the rotating file, the process is given the pipe
+// instead of the file for its output to be rotated. The pipe and the file are closed once the write end is closed
+// by the caller and the process, the output is discarded when it cannot be written to the file so that the process
+// never blocks on it.
+func openRotatedOutput(path string, maxSize int64, maxRotatedFiles int) (*os.File, error) {
+	file, err := openRotatingFile(path, maxSize, maxRotatedFiles)
+	if err != nil {
+		return nil, err
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	go func() {
+		if _, err := io.Copy(file, reader); err != nil {
+			io.Copy(ioutil.Discard, reader)
+		}
+		reader.Close()
+		file.Close()
+	}()
+	return writer, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cloudwatch
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestRotatingFile_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout")
+	file, err := openRotatingFile(path, 4, 2)
+	assert.NoError(t, err)
+
+	// the data which does not fit is written to the file once rotated
+	n, err := file.Write([]byte("aaaabb"))
+	assert.NoError(t, err)
+	assert.Equal(t, 6, n)
+	_, err = file.Write([]byte("bbccccdd"))
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	assert.Equal(t, "dd", readFile(t, path))
+	assert.Equal(t, "cccc", readFile(t, path+".1"))
+	assert.Equal(t, "bbbb", readFile(t, path+".2"))
+	assert.False(t, fileutil.Exists(path+".3"), "the oldest rotated file is deleted")
+}
+
+func TestRotatingFile_AppendsAndTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stderr")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("abc"), 0600))
+	file, err := openRotatingFile(path, 4, 0)
+	assert.NoError(t, err)
+
+	_, err = file.Write([]byte("def"))
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	assert.Equal(t, "ef", readFile(t, path))
+	assert.False(t, fileutil.Exists(path+".1"))
+}
+
+func TestRotatingFile_Unbounded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout")
+	file, err := openRotatingFile(path, 0, 2)
+	assert.NoError(t, err)
+
+	_, err = file.Write([]byte("abcdef"))
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	assert.Equal(t, "abcdef", readFile(t, path))
+}
+
+func TestOpenRotatedOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout")
+	writer, err := openRotatedOutput(path, 4, 1)
+	assert.NoError(t, err)
+
+	_, err = writer.Write([]byte("aaaabb"))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	// the output is copied to the file until the write end of the pipe is closed
+	assert.Eventually(t, func() bool {
+		content, _ := ioutil.ReadFile(path)
+		return string(content) == "bb"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "aaaa", readFile(t, path+".1"))
+}
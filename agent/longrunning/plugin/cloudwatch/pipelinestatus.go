@@ -0,0 +1,146 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cloudwatch
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// PipelineLogFileName is the name of the log file the cloudwatch executable writes in its working directory
+	PipelineLogFileName = "AWS.CloudWatch.log"
+
+	// pipelineLogTailBytes is the size of the end of the log file read to compute the pipeline status
+	pipelineLogTailBytes = 1024 * 1024
+
+	// pipelineErrorWindow is the period before the status time during which the logged errors are counted
+	pipelineErrorWindow = 15 * time.Minute
+
+	pipelineLogTimeLayout = "2006-01-02 15:04:05"
+)
+
+// pipelineLogEntry matches the entries of the cloudwatch executable log, e.g.
+// 2022-03-01 10:15:30,123 [Error] Failed to publish the metrics
+var pipelineLogEntry = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2})(?:[.,]\d+)?\s+\[?([A-Za-z]+)\]?\s*(.*)$`)
+
+// uploadSuccessMarkers are the lower cased phrases logged by the cloudwatch executable when it delivered data
+var uploadSuccessMarkers = []string{
+	"successfully published",
+	"successfully uploaded",
+	"successfully sent",
+	"putmetricdata succeeded",
+	"putlogevents succeeded",
+}
+
+// PipelineStatus summarizes the progress of the data shipped by the cloudwatch executable
+type PipelineStatus struct {
+	// Time is the time the status was computed at
+	Time time.Time
+	// LastUpload is the time of the last delivery logged, it is zero when none is found in the log
+	LastUpload time.Time
+	// Lag is the time elapsed since the last delivery, or since the first log entry when no delivery is found
+	Lag time.Duration
+	// ErrorCount is the number of errors logged during the window preceding Time
+	ErrorCount int
+	// Entries is the number of log entries read
+	Entries int
+}
+
+// ReadPipelineStatus computes the pipeline status from the end of the log file of the cloudwatch executable
+func ReadPipelineStatus(path string, now time.Time) (status PipelineStatus, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return PipelineStatus{Time: now}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return PipelineStatus{Time: now}, err
+	}
+	var reader io.Reader = file
+	if offset := info.Size() - pipelineLogTailBytes; offset > 0 {
+		if _, err = file.Seek(offset, io.SeekStart); err != nil {
+			return PipelineStatus{Time: now}, err
+		}
+		// the first line read is likely truncated
+		buffered := bufio.NewReader(file)
+		if _, err = buffered.ReadString('\n'); err != nil && err != io.EOF {
+			return PipelineStatus{Time: now}, err
+		}
+		reader = buffered
+	}
+	return ParsePipelineLog(reader, now)
+}
+
+// ParsePipelineLog computes the pipeline status from the entries of the cloudwatch executable log,
+// the lines that are not entries, such as stack traces, are ignored
+func ParsePipelineLog(reader io.Reader, now time.Time) (status PipelineStatus, err error) {
+	status.Time = now
+	var firstEntry time.Time
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), pipelineLogTailBytes)
+	for scanner.Scan() {
+		match := pipelineLogEntry.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		entryTime, parseErr := time.ParseInLocation(pipelineLogTimeLayout, strings.Replace(match[1], "T", " ", 1), now.Location())
+		if parseErr != nil {
+			continue
+		}
+		status.Entries++
+		if firstEntry.IsZero() {
+			firstEntry = entryTime
+		}
+
+		level, message := strings.ToLower(match[2]), strings.ToLower(match[3])
+		switch {
+		case level == "error" || level == "fatal":
+			if now.Sub(entryTime) <= pipelineErrorWindow {
+				status.ErrorCount++
+			}
+		case isUploadSuccess(message) && entryTime.After(status.LastUpload):
+			status.LastUpload = entryTime
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return status, err
+	}
+
+	switch {
+	case !status.LastUpload.IsZero():
+		status.Lag = now.Sub(status.LastUpload)
+	case !firstEntry.IsZero():
+		status.Lag = now.Sub(firstEntry)
+	}
+	if status.Lag < 0 {
+		status.Lag = 0
+	}
+	return status, nil
+}
+
+func isUploadSuccess(message string) bool {
+	for _, marker := range uploadSuccessMarkers {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cloudwatch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var pipelineStatusNow = time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestParsePipelineLog_ComputesLagAndErrors(t *testing.T) {
+	content := `2022-03-01 10:00:00,100 [Info] Starting the pipeline
+2022-03-01 11:30:00,200 [Info] Successfully published 20 metrics
+2022-03-01 11:40:00,300 [Error] Failed to publish the metrics
+   at AWS.CloudWatch.Publisher.Send()
+2022-03-01 11:50:00,400 [Error] Failed to publish the metrics
+2022-03-01 11:55:00 ERROR Failed to publish the metrics
+`
+	status, err := ParsePipelineLog(strings.NewReader(content), pipelineStatusNow)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, status.Entries)
+	assert.Equal(t, time.Date(2022, 3, 1, 11, 30, 0, 0, time.UTC), status.LastUpload)
+	assert.Equal(t, 30*time.Minute, status.Lag)
+	// the error logged at 11:40 is outside of the window
+	assert.Equal(t, 2, status.ErrorCount)
+}
+
+func TestParsePipelineLog_NoUploadMeasuresLagFromFirstEntry(t *testing.T) {
+	content := `2022-03-01T11:00:00.000 [Info] Starting the pipeline
+2022-03-01T11:10:00.000 [Warn] Retrying the connection
+`
+	status, err := ParsePipelineLog(strings.NewReader(content), pipelineStatusNow)
+
+	assert.NoError(t, err)
+	assert.True(t, status.LastUpload.IsZero())
+	assert.Equal(t, time.Hour, status.Lag)
+	assert.Equal(t, 0, status.ErrorCount)
+}
+
+func TestParsePipelineLog_EmptyLog(t *testing.T) {
+	status, err := ParsePipelineLog(strings.NewReader("not a log entry\n"), pipelineStatusNow)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, status.Entries)
+	assert.Equal(t, time.Duration(0), status.Lag)
+}
+
+func TestReadPipelineStatus_ReadsTheEndOfLargeLogs(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cloudwatch")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, PipelineLogFileName)
+
+	var content strings.Builder
+	line := "2022-03-01 09:00:00,000 [Error] Failed to publish the metrics\n"
+	for content.Len() < pipelineLogTailBytes+len(line) {
+		content.WriteString(line)
+	}
+	content.WriteString("2022-03-01 11:59:00,000 [Info] Successfully uploaded 3 log events\n")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content.String()), 0600))
+
+	status, err := ReadPipelineStatus(path, pipelineStatusNow)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, status.Lag)
+	assert.Equal(t, pipelineLogTailBytes/len(line), status.Entries)
+}
+
+func TestReadPipelineStatus_MissingLog(t *testing.T) {
+	_, err := ReadPipelineStatus(filepath.Join(os.TempDir(), "missing", PipelineLogFileName), pipelineStatusNow)
+
+	assert.Error(t, err)
+}
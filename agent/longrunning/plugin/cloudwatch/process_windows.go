@@ -0,0 +1,90 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package cloudwatch
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"golang.org/x/sys/windows"
+)
+
+// stopEventNamePrefix is the prefix of the named event, suffixed by the process id, which the versions of
+// cloudwatch.exe handling it create and wait on to shut down gracefully
+const stopEventNamePrefix = `Global\AWS.CloudWatch.Stop.`
+
+// listProcesses, requestShutdown and waitForExit are assigned to global variables to allow unittest to override
+var (
+	listProcesses   = listProcessesFromSnapshot
+	requestShutdown = requestShutdownOfProcess
+	waitForExit     = waitForExitOfProcess
+)
+
+// requestShutdownOfProcess asks cloudwatch.exe to flush its batches and exit, with its stop event when it created
+// one and with a CTRL_BREAK event sent to its console otherwise
+func requestShutdownOfProcess(pid int) error {
+	name, err := windows.UTF16PtrFromString(fmt.Sprintf("%v%v", stopEventNamePrefix, pid))
+	if err != nil {
+		return err
+	}
+	if event, err := windows.OpenEvent(windows.EVENT_MODIFY_STATE, false, name); err == nil {
+		defer windows.CloseHandle(event)
+		return windows.SetEvent(event)
+	}
+	return executers.SendCtrlBreak(pid)
+}
+
+// waitForExitOfProcess returns true if the process exits within the timeout
+func waitForExitOfProcess(process *os.Process, timeout time.Duration) bool {
+	handle, err := windows.OpenProcess(windows.SYNCHRONIZE, false, uint32(process.Pid))
+	if err != nil {
+		// the process id is invalid once the process exited
+		return err == windows.ERROR_INVALID_PARAMETER
+	}
+	defer windows.CloseHandle(handle)
+	event, err := windows.WaitForSingleObject(handle, uint32(timeout/time.Millisecond))
+	return err == nil && event == windows.WAIT_OBJECT_0
+}
+
+// listProcessesFromSnapshot returns the processes whose executable file has the given name using a tool help snapshot
+func listProcessesFromSnapshot(exeName string) (processes []CloudwatchProcessInfo, err error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snapshot)
+
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		name := windows.UTF16ToString(entry.ExeFile[:])
+		if strings.EqualFold(name, exeName) {
+			processes = append(processes, CloudwatchProcessInfo{
+				ProcessName: strings.TrimSuffix(name, ".exe"),
+				PId:         int(entry.ProcessID),
+			})
+		}
+	}
+	if err == windows.ERROR_NO_MORE_FILES {
+		err = nil
+	}
+	return processes, err
+}
@@ -0,0 +1,258 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package cloudwatch
+
+import (
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/session/utility"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"golang.org/x/sys/windows"
+)
+
+// runAsUserNameRegex matches the local account names, which are at most 20 characters long
+var runAsUserNameRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,19}$`)
+
+// runAsUserComment is the comment of the accounts the agent creates for cloudwatch.exe, the agent only resets the
+// password of the accounts it created
+const runAsUserComment = "Account created by the SSM Agent to run cloudwatch.exe"
+
+// runAsUserGroups are the builtin groups the account is added to when it is created, cloudwatch.exe reads the
+// performance counters and the event logs
+var runAsUserGroups = []windows.WELL_KNOWN_SID_TYPE{
+	windows.WinBuiltinPerfMonitoringUsersSid,
+	windows.WinBuiltinEventLogReadersGroup,
+}
+
+// accountManager creates the local accounts and reads their attributes
+type accountManager interface {
+	AddNewUserWithComment(username string, password string, comment string) (userExists bool, err error)
+	GetUserComment(username string) (string, error)
+	IsUserMemberOfBuiltInGroup(username string, groupSidType windows.WELL_KNOWN_SID_TYPE) (bool, error)
+}
+
+// preparedExecuter is implemented by the executers able to prepare the command of the exe before starting it
+type preparedExecuter interface {
+	StartPreparedExe(context.T, string, io.Writer, io.Writer, task.CancelFlag, string, []string, func(*osexec.Cmd) error) (*os.Process, int, error)
+}
+
+// runAsAccount is the logged on account cloudwatch.exe runs as
+type runAsAccount struct {
+	name    string
+	token   syscall.Token
+	profile syscall.Handle
+}
+
+// logonRunAsAccount and grantAccess are assigned to global variables to allow unittest to override
+var (
+	logonRunAsAccount = logonAccount
+	grantAccess       = grantDirectoryAccess
+)
+
+// validateRunAsUser returns why the account cannot be managed by the agent for cloudwatch.exe
+func validateRunAsUser(name string) error {
+	if !runAsUserNameRegex.MatchString(name) {
+		return fmt.Errorf("%v is not a valid local account name", name)
+	}
+	if strings.EqualFold(name, appconfig.DefaultRunAsUserName) {
+		return fmt.Errorf("%v is the administrator account of Session Manager, a dedicated account is required", name)
+	}
+	return nil
+}
+
+// validateExistingAccount returns why the existing account cannot be managed by the agent: the agent does not reset
+// the password of an account it did not create nor run cloudwatch.exe as an administrator
+func validateExistingAccount(accounts accountManager, name string) error {
+	comment, err := accounts.GetUserComment(name)
+	if err != nil {
+		return fmt.Errorf("failed to read the account %v: %v", name, err)
+	}
+	if comment != runAsUserComment {
+		return fmt.Errorf("%v was not created by the agent, a dedicated account is required", name)
+	}
+	isAdministrator, err := accounts.IsUserMemberOfBuiltInGroup(name, windows.WinBuiltinAdministratorsSid)
+	if err != nil {
+		return fmt.Errorf("failed to read the groups of %v: %v", name, err)
+	}
+	if isAdministrator {
+		return fmt.Errorf("%v is a member of the Administrators group, cloudwatch.exe does not run as an administrator", name)
+	}
+	return nil
+}
+
+// logonAccount creates the account when it is missing, replaces its password by a new one it logs on with and loads
+// its profile. An existing account is only logged on if the agent created it.
+func logonAccount(log log.T, name string) (*runAsAccount, error) {
+	sessionUtil := &utility.SessionUtil{}
+	if sessionUtil.IsInstanceADomainController(log) {
+		return nil, fmt.Errorf("the instance is a domain controller, cloudwatch.exe cannot run as the local account %v", name)
+	}
+
+	password, err := sessionUtil.GeneratePasswordForDefaultUser()
+	if err != nil {
+		return nil, err
+	}
+	userExists, err := sessionUtil.AddNewUserWithComment(name, password, runAsUserComment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %v: %v", name, err)
+	}
+	if userExists {
+		if err = validateExistingAccount(sessionUtil, name); err != nil {
+			return nil, err
+		}
+		if _, err = sessionUtil.ChangePassword(name, password); err != nil {
+			return nil, fmt.Errorf("failed to change the password of %v: %v", name, err)
+		}
+	} else {
+		log.Infof("Created the account %v cloudwatch.exe runs as", name)
+		for _, group := range runAsUserGroups {
+			if groupName, err := sessionUtil.AddUserToBuiltInGroup(name, group); err != nil {
+				log.Warnf("Failed to add %v to a builtin group, cloudwatch.exe may not read all its sources: %v", name, err)
+			} else {
+				log.Infof("Added %v to %v group", name, groupName)
+			}
+		}
+	}
+
+	token, profile, err := sessionUtil.LoadUserProfile(name, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log on as %v: %v", name, err)
+	}
+	return &runAsAccount{name: name, token: token, profile: profile}, nil
+}
+
+// prepare starts the command with the token of the account and the environment of its profile, the variables set
+// by the agent are kept
+func (a *runAsAccount) prepare(command *osexec.Cmd) error {
+	env, err := windows.Token(a.token).Environ(false)
+	if err != nil {
+		return fmt.Errorf("failed to read the environment of %v: %v", a.name, err)
+	}
+	defined := make(map[string]bool, len(env))
+	for _, variable := range env {
+		defined[strings.ToUpper(strings.SplitN(variable, "=", 2)[0])] = true
+	}
+	for _, variable := range command.Env {
+		if !defined[strings.ToUpper(strings.SplitN(variable, "=", 2)[0])] {
+			env = append(env, variable)
+		}
+	}
+	command.Env = env
+
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.Token = a.token
+	return nil
+}
+
+// release unloads the profile of the account and closes its token
+func (a *runAsAccount) release(log log.T) {
+	sessionUtil := &utility.SessionUtil{}
+	sessionUtil.UnloadUserProfile(log, a.token, a.profile)
+}
+
+// grantDirectoryAccess grants the account the modify access to the directory and the files it contains
+func grantDirectoryAccess(path string, name string) error {
+	sid, _, _, err := windows.LookupSID("", name)
+	if err != nil {
+		return err
+	}
+	securityDescriptor, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return err
+	}
+	dacl, _, err := securityDescriptor.DACL()
+	if err != nil {
+		return err
+	}
+	updatedDacl, err := windows.ACLFromEntries([]windows.EXPLICIT_ACCESS{{
+		AccessPermissions: windows.GENERIC_READ | windows.GENERIC_WRITE | windows.GENERIC_EXECUTE | windows.DELETE,
+		AccessMode:        windows.GRANT_ACCESS,
+		Inheritance:       windows.SUB_CONTAINERS_AND_OBJECTS_INHERIT,
+		Trustee: windows.TRUSTEE{
+			TrusteeForm:  windows.TRUSTEE_IS_SID,
+			TrusteeType:  windows.TRUSTEE_IS_USER,
+			TrusteeValue: windows.TrusteeValueFromSID(sid),
+		},
+	}}, dacl)
+	if err != nil {
+		return err
+	}
+	return windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION, nil, nil, updatedDacl, nil)
+}
+
+// prepareRunAsAccount logs on as the account of the agent configuration and grants it the access to the working
+// directory and to the crash dumps folder, cloudwatch.exe runs as LocalSystem when no account is configured
+func (p *Plugin) prepareRunAsAccount() error {
+	log := p.Context.Log()
+	p.releaseRunAsAccount()
+	name := p.Context.AppConfig().Agent.CloudWatchRunAsUser
+	if name == "" {
+		return nil
+	}
+	if err := validateRunAsUser(name); err != nil {
+		return err
+	}
+	if _, ok := p.CommandExecuter.(preparedExecuter); !ok {
+		return fmt.Errorf("the command executer cannot start cloudwatch.exe as %v", name)
+	}
+
+	account, err := logonRunAsAccount(log, name)
+	if err != nil {
+		return err
+	}
+	directories := []string{p.WorkingDir}
+	if p.crashDumps != nil && fileExist(p.crashDumps.Dir) {
+		directories = append(directories, p.crashDumps.Dir)
+	}
+	for _, directory := range directories {
+		if err = grantAccess(directory, name); err != nil {
+			account.release(log)
+			return fmt.Errorf("failed to grant %v access to %v: %v", name, directory, err)
+		}
+	}
+	log.Infof("cloudwatch.exe runs as %v", name)
+	p.runAsAccount = account
+	return nil
+}
+
+// releaseRunAsAccount releases the account cloudwatch.exe was started as
+func (p *Plugin) releaseRunAsAccount() {
+	if p.runAsAccount != nil {
+		p.runAsAccount.release(p.Context.Log())
+		p.runAsAccount = nil
+	}
+}
+
+// startExe starts cloudwatch.exe as LocalSystem or as the account it runs as
+func (p *Plugin) startExe(stdout io.Writer, stderr io.Writer, cancelFlag task.CancelFlag, commandName string, commandArguments []string) (*os.Process, int, error) {
+	if p.runAsAccount == nil {
+		return p.CommandExecuter.StartExe(p.Context, p.WorkingDir, stdout, stderr, cancelFlag, commandName, commandArguments)
+	}
+	executer := p.CommandExecuter.(preparedExecuter)
+	return executer.StartPreparedExe(p.Context, p.WorkingDir, stdout, stderr, cancelFlag, commandName, commandArguments, p.runAsAccount.prepare)
+}
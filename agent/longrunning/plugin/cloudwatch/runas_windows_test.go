@@ -0,0 +1,148 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package cloudwatch
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	multiwritermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/executers"
+	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/sys/windows"
+)
+
+// stubRunAsAccount makes the logons succeed without creating the account and returns the directories it is
+// granted access to
+func stubRunAsAccount(t *testing.T, logonErr error) *[]string {
+	granted := &[]string{}
+	origLogon, origGrant := logonRunAsAccount, grantAccess
+	t.Cleanup(func() { logonRunAsAccount, grantAccess = origLogon, origGrant })
+	logonRunAsAccount = func(log log.T, name string) (*runAsAccount, error) {
+		if logonErr != nil {
+			return nil, logonErr
+		}
+		return &runAsAccount{name: name}, nil
+	}
+	grantAccess = func(path string, name string) error {
+		*granted = append(*granted, path)
+		return nil
+	}
+	return granted
+}
+
+func newRunAsPlugin(t *testing.T, runAsUser string) (*Plugin, *executers.MockCommandExecuter) {
+	config := appconfig.DefaultConfig()
+	config.Agent.CloudWatchRunAsUser = runAsUser
+	p, _ := NewPlugin(context.NewMockDefaultWithConfig(config), pluginConfig)
+	execMock := &executers.MockCommandExecuter{}
+	p.CommandExecuter = execMock
+	origFileExist := fileExist
+	t.Cleanup(func() { fileExist = origFileExist })
+	fileExist = func(filePath string) bool {
+		return true
+	}
+	stubListProcesses(t, nil)
+	return p, execMock
+}
+
+func startRunAsPlugin(p *Plugin) error {
+	cancelFlag := taskmocks.NewMockDefault()
+	cancelFlag.On("Wait").Return(task.Completed)
+	cancelFlag.On("Canceled").Return(false)
+	ioHandler := &iohandlermocks.MockIOHandler{}
+	ioHandler.On("GetStdoutWriter").Return(&multiwritermock.MockDocumentIOMultiWriter{})
+	ioHandler.On("GetStderrWriter").Return(&multiwritermock.MockDocumentIOMultiWriter{})
+	return p.Start(flowsBaseConfiguration, "C:\\abc", cancelFlag, ioHandler)
+}
+
+func TestValidateRunAsUser(t *testing.T) {
+	assert.NoError(t, validateRunAsUser("ssm-cloudwatch"))
+	assert.Error(t, validateRunAsUser(appconfig.DefaultRunAsUserName))
+	assert.Error(t, validateRunAsUser(`DOMAIN\cloudwatch`))
+	assert.Error(t, validateRunAsUser("cloudwatch@example.com"))
+	assert.Error(t, validateRunAsUser("a-local-account-name-too-long"))
+}
+
+// accountManagerStub is an existing account with the given comment and administrators membership
+type accountManagerStub struct {
+	comment         string
+	isAdministrator bool
+}
+
+func (a accountManagerStub) AddNewUserWithComment(username string, password string, comment string) (bool, error) {
+	return true, nil
+}
+
+func (a accountManagerStub) GetUserComment(username string) (string, error) {
+	return a.comment, nil
+}
+
+func (a accountManagerStub) IsUserMemberOfBuiltInGroup(username string, groupSidType windows.WELL_KNOWN_SID_TYPE) (bool, error) {
+	return a.isAdministrator && groupSidType == windows.WinBuiltinAdministratorsSid, nil
+}
+
+func TestValidateExistingAccount(t *testing.T) {
+	assert.NoError(t, validateExistingAccount(accountManagerStub{comment: runAsUserComment}, "ssm-cloudwatch"))
+	// an account of a user
+	assert.Error(t, validateExistingAccount(accountManagerStub{comment: ""}, "alice"))
+	// an agent account added to the administrators
+	assert.Error(t, validateExistingAccount(accountManagerStub{comment: runAsUserComment, isAdministrator: true}, "ssm-cloudwatch"))
+}
+
+func TestStart_RunAsUser(t *testing.T) {
+	granted := stubRunAsAccount(t, nil)
+	exit := stubWatchProcess(t)
+	p, execMock := newRunAsPlugin(t, "ssm-cloudwatch")
+	execMock.On("StartPreparedExe", mock.Anything, p.WorkingDir, mock.Anything, mock.Anything, mock.Anything,
+		p.ExeLocation, mock.AnythingOfType("[]string"), mock.Anything).Return(&os.Process{Pid: 1986}, 0, nil)
+
+	assert.NoError(t, startRunAsPlugin(p))
+
+	assert.Equal(t, "ssm-cloudwatch", p.runAsAccount.name)
+	assert.Contains(t, *granted, p.WorkingDir)
+	execMock.AssertNotCalled(t, "StartExe", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	p.supervisor.Stop()
+	close(exit)
+	<-p.supervisor.Done()
+}
+
+func TestStart_RunAsUserLogonFailed(t *testing.T) {
+	stubRunAsAccount(t, errors.New("the instance is a domain controller"))
+	p, execMock := newRunAsPlugin(t, "ssm-cloudwatch")
+
+	// cloudwatch.exe never falls back to LocalSystem when its account cannot be used
+	assert.Error(t, startRunAsPlugin(p))
+
+	execMock.AssertNotCalled(t, "StartExe", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStart_InvalidRunAsUser(t *testing.T) {
+	stubRunAsAccount(t, nil)
+	p, _ := newRunAsPlugin(t, appconfig.DefaultRunAsUserName)
+
+	assert.Error(t, startRunAsPlugin(p))
+	assert.Nil(t, p.runAsAccount)
+}
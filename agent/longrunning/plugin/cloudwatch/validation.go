@@ -0,0 +1,168 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package cloudwatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	componentFullNameKey   = "FullName"
+	componentParametersKey = "Parameters"
+	pollIntervalKey        = "PollInterval"
+	componentsKey          = "Components"
+	flowsKey               = "Flows"
+)
+
+// pollIntervalPattern matches the .NET time spans accepted by cloudwatch.exe, e.g. 00:00:15 or 1.00:00:00
+var pollIntervalPattern = regexp.MustCompile(`^(\d+\.)?(\d{1,2}):(\d{2}):(\d{2})(\.\d+)?$`)
+
+// ConfigurationError lists the problems found in a cloud watch configuration
+type ConfigurationError struct {
+	Problems []string
+}
+
+// Error returns the problems of the configuration, one per line
+func (e *ConfigurationError) Error() string {
+	return fmt.Sprintf("invalid cloud watch configuration:\n%v", strings.Join(e.Problems, "\n"))
+}
+
+// ValidateConfiguration parses the engine configuration of the given plugin configuration and returns
+// a ConfigurationError listing the components and flows cloudwatch.exe would not be able to load
+func ValidateConfiguration(configuration string) error {
+	var parser EngineConfigurationParser
+	if err := json.Unmarshal([]byte(configuration), &parser); err != nil {
+		return &ConfigurationError{Problems: []string{fmt.Sprintf("the configuration is not valid json: %v", err)}}
+	}
+
+	// legacy configurations hold the engine configuration as a string or nested in another engine configuration
+	engineConfiguration := parser.EngineConfiguration
+	if legacy, ok := engineConfiguration.(string); ok {
+		if err := json.Unmarshal([]byte(legacy), &engineConfiguration); err != nil {
+			return &ConfigurationError{Problems: []string{fmt.Sprintf("EngineConfiguration is not valid json: %v", err)}}
+		}
+	}
+	if fields, ok := engineConfiguration.(map[string]interface{}); ok {
+		if nested, found := fields["EngineConfiguration"]; found {
+			engineConfiguration = nested
+		}
+	}
+
+	fields, ok := engineConfiguration.(map[string]interface{})
+	if !ok {
+		return &ConfigurationError{Problems: []string{"EngineConfiguration is missing or is not an object"}}
+	}
+
+	problems := validatePollInterval(fields[pollIntervalKey])
+	declared, componentProblems := validateComponents(fields[componentsKey])
+	problems = append(problems, componentProblems...)
+	problems = append(problems, validateFlows(fields[flowsKey], declared)...)
+	if len(problems) > 0 {
+		return &ConfigurationError{Problems: problems}
+	}
+	return nil
+}
+
+// validatePollInterval checks the optional poll interval is a positive time span
+func validatePollInterval(value interface{}) (problems []string) {
+	if value == nil {
+		return nil
+	}
+	interval, ok := value.(string)
+	if !ok {
+		return []string{fmt.Sprintf("EngineConfiguration.%v must be a time span string such as 00:00:15", pollIntervalKey)}
+	}
+	match := pollIntervalPattern.FindStringSubmatch(strings.TrimSpace(interval))
+	if match == nil {
+		return []string{fmt.Sprintf("EngineConfiguration.%v %q is not a time span such as 00:00:15", pollIntervalKey, interval)}
+	}
+	hours, _ := strconv.Atoi(match[2])
+	minutes, _ := strconv.Atoi(match[3])
+	seconds, _ := strconv.Atoi(match[4])
+	if hours > 23 || minutes > 59 || seconds > 59 {
+		return []string{fmt.Sprintf("EngineConfiguration.%v %q is out of range", pollIntervalKey, interval)}
+	}
+	if strings.Trim(match[1]+match[2]+match[3]+match[4]+match[5], "0.") == "" {
+		return []string{fmt.Sprintf("EngineConfiguration.%v must be greater than zero", pollIntervalKey)}
+	}
+	return nil
+}
+
+// validateComponents checks each component has a unique id and a full name, the declared ids are returned
+func validateComponents(value interface{}) (declared map[string]bool, problems []string) {
+	declared = make(map[string]bool)
+	components, ok := value.([]interface{})
+	if !ok || len(components) == 0 {
+		return declared, []string{fmt.Sprintf("EngineConfiguration.%v must be a non empty list of components", componentsKey)}
+	}
+	for i, component := range components {
+		path := fmt.Sprintf("EngineConfiguration.%v[%d]", componentsKey, i)
+		fields, ok := component.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%v is not an object", path))
+			continue
+		}
+		id := componentID(component)
+		if id == "" {
+			problems = append(problems, fmt.Sprintf("%v has no %v", path, componentIDKey))
+		} else if declared[id] {
+			problems = append(problems, fmt.Sprintf("%v declares the component %v a second time", path, id))
+		} else {
+			declared[id] = true
+		}
+		if fullName, _ := fields[componentFullNameKey].(string); strings.TrimSpace(fullName) == "" {
+			problems = append(problems, fmt.Sprintf("%v has no %v, e.g. AWS.EC2.Windows.CloudWatch.CloudWatchLogsOutput,AWS.EC2.Windows.CloudWatch", path, componentFullNameKey))
+		}
+		if parameters, found := fields[componentParametersKey]; found {
+			if _, ok := parameters.(map[string]interface{}); !ok {
+				problems = append(problems, fmt.Sprintf("%v.%v is not an object", path, componentParametersKey))
+			}
+		}
+	}
+	return declared, problems
+}
+
+// validateFlows checks each flow links declared source components to a declared destination component
+func validateFlows(value interface{}, declared map[string]bool) (problems []string) {
+	section, ok := value.(map[string]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("EngineConfiguration.%v must be an object holding a %v list", flowsKey, flowsKey)}
+	}
+	flows, ok := section[flowsKey].([]interface{})
+	if !ok || len(flows) == 0 {
+		return []string{fmt.Sprintf("EngineConfiguration.%v.%v must be a non empty list of flows", flowsKey, flowsKey)}
+	}
+	for i, value := range flows {
+		path := fmt.Sprintf("EngineConfiguration.%v.%v[%d]", flowsKey, flowsKey, i)
+		flow, ok := value.(string)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%v is not a string", path))
+			continue
+		}
+		ids := flowComponentIDs(flow)
+		if len(ids) < 2 {
+			problems = append(problems, fmt.Sprintf("%v %q must link sources to a destination, e.g. (ApplicationEventLog,SystemEventLog),CloudWatchLogs", path, flow))
+			continue
+		}
+		for _, id := range ids {
+			if !declared[id] {
+				problems = append(problems, fmt.Sprintf("%v %q references the undeclared component %v", path, flow, id))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package cloudwatch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validationProblems(t *testing.T, configuration string) []string {
+	err := ValidateConfiguration(configuration)
+	if !assert.IsType(t, &ConfigurationError{}, err) {
+		return nil
+	}
+	return err.(*ConfigurationError).Problems
+}
+
+func TestValidateConfiguration_Valid(t *testing.T) {
+	assert.NoError(t, ValidateConfiguration(flowsBaseConfiguration))
+}
+
+func TestValidateConfiguration_LegacyFormats(t *testing.T) {
+	nested := `{"EngineConfiguration": {"EngineConfiguration": {
+		"Components": [{"Id": "A", "FullName": "a"}, {"Id": "B", "FullName": "b"}],
+		"Flows": {"Flows": ["A,B"]}}}}`
+	assert.NoError(t, ValidateConfiguration(nested))
+
+	asString := `{"EngineConfiguration": "{\"Components\": [{\"Id\": \"A\", \"FullName\": \"a\"}, {\"Id\": \"B\", \"FullName\": \"b\"}], \"Flows\": {\"Flows\": [\"(A),B\"]}}"}`
+	assert.NoError(t, ValidateConfiguration(asString))
+}
+
+func TestValidateConfiguration_NotJson(t *testing.T) {
+	problems := validationProblems(t, "{")
+	assert.Len(t, problems, 1)
+	assert.Contains(t, problems[0], "not valid json")
+
+	problems = validationProblems(t, `{"IsEnabled": true}`)
+	assert.Equal(t, []string{"EngineConfiguration is missing or is not an object"}, problems)
+}
+
+func TestValidateConfiguration_PollInterval(t *testing.T) {
+	base := `{"EngineConfiguration": {"PollInterval": %v,
+		"Components": [{"Id": "A", "FullName": "a"}, {"Id": "B", "FullName": "b"}],
+		"Flows": {"Flows": ["A,B"]}}}`
+	for _, valid := range []string{`"00:00:15"`, `"1.00:00:00"`, `"0:05:00.5"`} {
+		assert.NoError(t, ValidateConfiguration(fmt.Sprintf(base, valid)), valid)
+	}
+	for _, invalid := range []string{`15`, `"15s"`, `"00:61:00"`, `"00:00:00"`} {
+		problems := validationProblems(t, fmt.Sprintf(base, invalid))
+		assert.Len(t, problems, 1, invalid)
+	}
+}
+
+func TestValidateConfiguration_Components(t *testing.T) {
+	problems := validationProblems(t, `{"EngineConfiguration": {
+		"Components": [
+			{"Id": "A", "FullName": "a"},
+			{"Id": "A", "FullName": "a"},
+			{"FullName": "c"},
+			{"Id": "D", "Parameters": "none"},
+			"E"
+		],
+		"Flows": {"Flows": ["A,D"]}}}`)
+	assert.Equal(t, []string{
+		"EngineConfiguration.Components[1] declares the component A a second time",
+		"EngineConfiguration.Components[2] has no Id",
+		"EngineConfiguration.Components[3] has no FullName, e.g. AWS.EC2.Windows.CloudWatch.CloudWatchLogsOutput,AWS.EC2.Windows.CloudWatch",
+		"EngineConfiguration.Components[3].Parameters is not an object",
+		"EngineConfiguration.Components[4] is not an object",
+	}, problems)
+
+	problems = validationProblems(t, `{"EngineConfiguration": {"Components": [], "Flows": {"Flows": ["A,B"]}}}`)
+	assert.Contains(t, problems, "EngineConfiguration.Components must be a non empty list of components")
+}
+
+func TestValidateConfiguration_Flows(t *testing.T) {
+	problems := validationProblems(t, `{"EngineConfiguration": {
+		"Components": [{"Id": "A", "FullName": "a"}, {"Id": "B", "FullName": "b"}],
+		"Flows": {"Flows": ["(A,C),B", "A", 5]}}}`)
+	assert.Equal(t, []string{
+		`EngineConfiguration.Flows.Flows[0] "(A,C),B" references the undeclared component C`,
+		`EngineConfiguration.Flows.Flows[1] "A" must link sources to a destination, e.g. (ApplicationEventLog,SystemEventLog),CloudWatchLogs`,
+		"EngineConfiguration.Flows.Flows[2] is not a string",
+	}, problems)
+
+	problems = validationProblems(t, `{"EngineConfiguration": {"Components": [{"Id": "A", "FullName": "a"}], "Flows": ["A,A"]}}`)
+	assert.Equal(t, []string{"EngineConfiguration.Flows must be an object holding a Flows list"}, problems)
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+// Package executable implements the long running plugins running the executables declared by the customers
+package executable
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/readiness"
+)
+
+// reservedNamePrefix is the prefix of the plugins provided by the agent
+const reservedNamePrefix = "aws:"
+
+var validName = regexp.MustCompile(`^[a-zA-Z_]+(([-.])?[a-zA-Z0-9_]+)*$`)
+
+// Definition declares a user executable managed as a long running plugin
+type Definition struct {
+	// Name identifies the plugin, it must be usable as a file name
+	Name string
+	// StartCommand is the executable and its arguments, the process is expected to keep running
+	StartCommand []string
+	// StopCommand asks the executable to exit, it is killed if it is still running after the stop timeout.
+	// The executable is killed right away when no command is declared.
+	StopCommand []string
+	// HealthCheckCommand reports the executable healthy when it exits with the expected exit code and its output
+	// matches the expected pattern. The executable is healthy while its process runs when no command is declared.
+	HealthCheckCommand []string
+	// HealthCheckExitCode is the exit code of the health check command when the executable is healthy, 0 by default
+	HealthCheckExitCode int
+	// HealthCheckOutputPattern is a regular expression the combined output of the health check command matches
+	// when the executable is healthy, the output is not checked when empty
+	HealthCheckOutputPattern string
+	// UnhealthyThreshold is the number of consecutive failed health checks after which the executable is restarted,
+	// 1 by default
+	UnhealthyThreshold int
+	// WorkingDirectory is the directory the commands run in
+	WorkingDirectory string
+	// Environment holds the variables added to the agent environment for the commands
+	Environment map[string]string
+	// ReadinessGates are the system conditions waited on before the executable is started at boot
+	ReadinessGates []readiness.Gate
+}
+
+// definitionsFile is the content of the file declaring the executables
+type definitionsFile struct {
+	Executables []Definition
+}
+
+// DefinitionsFilePath returns the path of the file declaring the executables
+func DefinitionsFilePath() string {
+	return filepath.Join(appconfig.DefaultPluginPath, appconfig.LongRunningExecutablesFileName)
+}
+
+// LoadDefinitions reads the executables declared in the given file, no executable is declared when the file does
+// not exist. The invalid definitions are returned as errors and left out.
+func LoadDefinitions(path string) (definitions []Definition, errs []error) {
+	if !fileutil.Exists(path) {
+		return nil, nil
+	}
+	var file definitionsFile
+	if err := jsonutil.UnmarshalFile(path, &file); err != nil {
+		return nil, []error{fmt.Errorf("failed to read %v: %v", path, err)}
+	}
+
+	names := make(map[string]bool)
+	for _, definition := range file.Executables {
+		if err := ValidateDefinition(definition); err != nil {
+			errs = append(errs, fmt.Errorf("executable %v is invalid: %v", definition.Name, err))
+			continue
+		}
+		if names[definition.Name] {
+			errs = append(errs, fmt.Errorf("executable %v is declared more than once", definition.Name))
+			continue
+		}
+		names[definition.Name] = true
+		definitions = append(definitions, definition)
+	}
+	return definitions, errs
+}
+
+// ValidateDefinition validates the definition of an executable
+func ValidateDefinition(definition Definition) error {
+	if definition.Name == "" {
+		return errors.New("name is missing")
+	}
+	if strings.HasPrefix(strings.ToLower(definition.Name), reservedNamePrefix) {
+		return fmt.Errorf("the %v prefix is reserved to the agent plugins", reservedNamePrefix)
+	}
+	if !validName.MatchString(definition.Name) {
+		return errors.New("name must start with letter or _; end with letter, number, or _; and contain only letters, numbers, -, _, or single . characters")
+	}
+	if len(definition.StartCommand) == 0 {
+		return errors.New("start command is missing")
+	}
+	for _, command := range [][]string{definition.StartCommand, definition.StopCommand, definition.HealthCheckCommand} {
+		if len(command) > 0 && !filepath.IsAbs(command[0]) {
+			return fmt.Errorf("executable %v of a command is not an absolute path", command[0])
+		}
+	}
+	if definition.WorkingDirectory != "" && !fileutil.Exists(definition.WorkingDirectory) {
+		return fmt.Errorf("working directory %v does not exist", definition.WorkingDirectory)
+	}
+	if len(definition.HealthCheckCommand) == 0 && (definition.HealthCheckExitCode != 0 || definition.HealthCheckOutputPattern != "") {
+		return errors.New("health check exit code and output pattern require a health check command")
+	}
+	if _, err := regexp.Compile(definition.HealthCheckOutputPattern); err != nil {
+		return fmt.Errorf("health check output pattern is invalid: %v", err)
+	}
+	if definition.UnhealthyThreshold < 0 {
+		return errors.New("unhealthy threshold cannot be negative")
+	}
+	for _, gate := range definition.ReadinessGates {
+		if err := gate.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package executable
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	agentContext "github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/crashdump"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/readiness"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/supervisor"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	// commandTimeout is the time given to the stop and health check commands to complete
+	commandTimeout = 30 * time.Second
+	// stopTimeout is the time given to the executable to exit after its stop command before it is killed
+	stopTimeout = 10 * time.Second
+
+	stdoutFileName = "stdout"
+	stderrFileName = "stderr"
+)
+
+// Plugin runs a user executable as a long running plugin
+type Plugin struct {
+	Context    agentContext.T
+	Definition Definition
+	// OutputDir holds the stdout and stderr files of the executable
+	OutputDir string
+
+	lock       sync.Mutex
+	process    *process
+	supervisor *supervisor.Supervisor
+	// generation changes each time the plugin is started or stopped, a pending restart of the previous
+	// supervisor is then abandoned
+	generation int
+	// failedHealthChecks is the number of consecutive failed health checks
+	failedHealthChecks int
+	// lastHealthCheckError is why the last health check failed, it is nil if it succeeded
+	lastHealthCheckError error
+	// healthCheckTimeout and unhealthyThreshold are configured by the manager, commandTimeout and the threshold
+	// of the definition are used when 0
+	healthCheckTimeout time.Duration
+	unhealthyThreshold int
+	// crashDumps captures the dumps of the crashed processes
+	crashDumps *crashdump.Collector
+}
+
+// adoptedPollInterval is the interval at which an adopted process is checked, it is not a child of the agent
+// and cannot be waited for outside of windows
+var adoptedPollInterval = time.Second
+
+// process is a started or adopted executable
+type process struct {
+	handle *os.Process
+	exited chan struct{}
+	err    error
+}
+
+// NewPlugin returns the long running plugin of a declared executable
+func NewPlugin(context agentContext.T, definition Definition) *Plugin {
+	shortInstanceID, _ := context.Identity().ShortInstanceID()
+	return &Plugin{
+		Context:    context,
+		Definition: definition,
+		OutputDir: fileutil.BuildPath(appconfig.DefaultDataStorePath,
+			shortInstanceID,
+			appconfig.LongRunningPluginsLocation,
+			definition.Name),
+		crashDumps: crashdump.NewCollector(context, definition.Name),
+	}
+}
+
+// StartsWithAgent returns true as the declared executables are started by the long running plugin manager
+// without a document
+func (p *Plugin) StartsWithAgent() bool {
+	return true
+}
+
+// ReadinessGates returns the gates declared by the definition of the executable
+func (p *Plugin) ReadinessGates() []readiness.Gate {
+	return p.Definition.ReadinessGates
+}
+
+// IsRunning runs the health check command of the executable, when none is declared it returns true while the
+// process of the executable runs. The process is killed for its supervisor to restart it once the unhealthy
+// threshold is reached, the failed health checks before are only logged.
+func (p *Plugin) IsRunning() bool {
+	log := p.Context.Log()
+	if len(p.Definition.HealthCheckCommand) == 0 {
+		return p.isProcessRunning()
+	}
+	p.lock.Lock()
+	timeout, threshold := p.healthCheckTimeout, p.unhealthyThreshold
+	p.lock.Unlock()
+	if timeout == 0 {
+		timeout = commandTimeout
+	}
+	err := p.checkHealth(timeout)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.lastHealthCheckError = err
+	if err == nil {
+		p.failedHealthChecks = 0
+		return true
+	}
+	p.failedHealthChecks++
+	if threshold == 0 {
+		threshold = p.Definition.UnhealthyThreshold
+	}
+	if threshold == 0 {
+		threshold = 1
+	}
+	log.Warnf("Health check %d of %d of %v failed: %v", p.failedHealthChecks, threshold, p.Definition.Name, err)
+	if p.failedHealthChecks < threshold {
+		return true
+	}
+	p.failedHealthChecks = 0
+	if p.process != nil && p.process.running() {
+		log.Warnf("Killing the unhealthy process of %v", p.Definition.Name)
+		p.process.handle.Kill()
+	}
+	return false
+}
+
+// ConfigureHealthCheck sets the time given to the health check command and the number of consecutive failed health
+// checks after which the executable is restarted, the defaults are used for the values left to 0
+func (p *Plugin) ConfigureHealthCheck(timeout time.Duration, unhealthyThreshold int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.healthCheckTimeout = timeout
+	p.unhealthyThreshold = unhealthyThreshold
+}
+
+// LastHealthCheckError returns why the last health check command failed, it is nil if it succeeded or
+// none is declared
+func (p *Plugin) LastHealthCheckError() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.lastHealthCheckError
+}
+
+// checkHealth runs the health check command and returns why the executable is unhealthy
+func (p *Plugin) checkHealth(timeout time.Duration) error {
+	output, err := p.runCommand(p.Definition.HealthCheckCommand, timeout)
+	exitCode := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		return err
+	}
+	if exitCode != p.Definition.HealthCheckExitCode {
+		return fmt.Errorf("exit code %d instead of %d, %s", exitCode, p.Definition.HealthCheckExitCode, output)
+	}
+	if p.Definition.HealthCheckOutputPattern == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(p.Definition.HealthCheckOutputPattern)
+	if err != nil {
+		return err
+	}
+	if !pattern.Match(output) {
+		return fmt.Errorf("output does not match %v, %s", p.Definition.HealthCheckOutputPattern, output)
+	}
+	return nil
+}
+
+// Start starts the executable and restarts it when it exits unexpectedly, nothing is done if it is already running
+func (p *Plugin) Start(configuration string, orchestrationDir string, cancelFlag task.CancelFlag, out iohandler.IOHandler) error {
+	log := p.Context.Log()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.process != nil && p.process.running() {
+		log.Infof("%v is already running", p.Definition.Name)
+		return nil
+	}
+	if p.supervisor != nil {
+		p.supervisor.Stop()
+	}
+	p.generation++
+
+	proc, err := p.startProcess()
+	if err != nil {
+		return err
+	}
+	p.process = proc
+	log.Infof("Started %v with process id %v", p.Definition.Name, proc.handle.Pid)
+
+	generation := p.generation
+	p.supervisor = supervisor.New(log, p.Definition.Name, func() (supervisor.WaitFunc, error) {
+		return p.restart(generation)
+	})
+	p.supervisor.Supervise(proc.wait)
+	return nil
+}
+
+// ProcessID returns the process of the executable, 0 when it is not running
+func (p *Plugin) ProcessID() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.process == nil || !p.process.running() {
+		return 0
+	}
+	return p.process.handle.Pid
+}
+
+// Adopt supervises the process of the executable left running by the previous agent, it is restarted like a
+// started process once it exits
+func (p *Plugin) Adopt(pid int) error {
+	log := p.Context.Log()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.process != nil && p.process.running() {
+		return fmt.Errorf("%v is already running with process id %v", p.Definition.Name, p.process.handle.Pid)
+	}
+	handle, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find process %v of %v: %v", pid, p.Definition.Name, err)
+	}
+	if p.supervisor != nil {
+		p.supervisor.Stop()
+	}
+	p.generation++
+
+	proc := &process{handle: handle, exited: make(chan struct{})}
+	go func() {
+		proc.err = waitForAdopted(handle)
+		close(proc.exited)
+	}()
+	p.process = proc
+	log.Infof("Adopted %v with process id %v", p.Definition.Name, pid)
+
+	generation := p.generation
+	p.supervisor = supervisor.New(log, p.Definition.Name, func() (supervisor.WaitFunc, error) {
+		return p.restart(generation)
+	})
+	p.supervisor.Supervise(proc.wait)
+	return nil
+}
+
+// Stop runs the stop command of the executable and kills it if it is still running after the stop timeout
+func (p *Plugin) Stop(cancelFlag task.CancelFlag) error {
+	return p.stop(stopTimeout, false)
+}
+
+// StopWithDrain gives the executable the drain timeout to exit after its stop command, or after an interrupt signal
+// when it has no stop command, and kills it if it is still running then
+func (p *Plugin) StopWithDrain(cancelFlag task.CancelFlag, drainTimeout time.Duration) error {
+	return p.stop(drainTimeout, true)
+}
+
+func (p *Plugin) stop(timeout time.Duration, interrupt bool) error {
+	log := p.Context.Log()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.supervisor != nil {
+		p.supervisor.Stop()
+	}
+	p.generation++
+	proc := p.process
+	if proc == nil || !proc.running() {
+		return nil
+	}
+
+	asked := false
+	if len(p.Definition.StopCommand) > 0 {
+		if output, err := p.runCommand(p.Definition.StopCommand, commandTimeout); err != nil {
+			log.Warnf("Stop command of %v failed: %v, %s", p.Definition.Name, err, output)
+		}
+		asked = true
+	} else if interrupt {
+		// interrupts are not supported on windows, the executable is killed right away there
+		asked = proc.handle.Signal(os.Interrupt) == nil
+	}
+	if asked {
+		select {
+		case <-proc.exited:
+			log.Infof("Stopped %v", p.Definition.Name)
+			return nil
+		case <-time.After(timeout):
+			log.Warnf("%v is still running %v after it was asked to stop, killing it", p.Definition.Name, timeout)
+		}
+	}
+	if err := proc.handle.Kill(); err != nil && proc.running() {
+		return fmt.Errorf("failed to kill %v: %v", p.Definition.Name, err)
+	}
+	<-proc.exited
+	log.Infof("Stopped %v", p.Definition.Name)
+	return nil
+}
+
+// SupervisorStatus returns the restarts of the executable since it was last started by the plugin
+func (p *Plugin) SupervisorStatus() supervisor.Status {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.supervisor == nil {
+		return supervisor.Status{}
+	}
+	return p.supervisor.Status()
+}
+
+// restart starts the executable again after it exited unexpectedly
+func (p *Plugin) restart(generation int) (supervisor.WaitFunc, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if generation != p.generation {
+		return nil, fmt.Errorf("%v was started or stopped again", p.Definition.Name)
+	}
+	proc, err := p.startProcess()
+	if err != nil {
+		return nil, err
+	}
+	p.process = proc
+	p.Context.Log().Infof("Restarted %v with process id %v", p.Definition.Name, proc.handle.Pid)
+	return proc.wait, nil
+}
+
+func (p *Plugin) isProcessRunning() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.process != nil && p.process.running()
+}
+
+// startProcess starts the start command, its output is appended to the stdout and stderr files of the output dir
+func (p *Plugin) startProcess() (*process, error) {
+	if err := fileutil.MakeDirsWithExecuteAccess(p.OutputDir); err != nil {
+		return nil, fmt.Errorf("failed to create the output directory of %v: %v", p.Definition.Name, err)
+	}
+	stdout, err := os.OpenFile(filepath.Join(p.OutputDir, stdoutFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, appconfig.ReadWriteAccess)
+	if err != nil {
+		return nil, err
+	}
+	defer stdout.Close()
+	stderr, err := os.OpenFile(filepath.Join(p.OutputDir, stderrFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, appconfig.ReadWriteAccess)
+	if err != nil {
+		return nil, err
+	}
+	defer stderr.Close()
+
+	cmd := p.command(context.Background(), p.Definition.StartCommand)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	p.crashDumps.Prepare(cmd.Path)
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %v: %v", p.Definition.Name, err)
+	}
+	started := crashdump.Process{Pid: cmd.Process.Pid, Executable: cmd.Path, WorkingDir: cmd.Dir}
+	p.crashDumps.Started(started)
+
+	proc := &process{handle: cmd.Process, exited: make(chan struct{})}
+	go func() {
+		err := cmd.Wait()
+		proc.err = p.crashDumps.Collect(started, cmd.ProcessState, err)
+		close(proc.exited)
+	}()
+	return proc, nil
+}
+
+// runCommand runs a stop or health check command and returns its combined output
+func (p *Plugin) runCommand(command []string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return p.command(ctx, command).CombinedOutput()
+}
+
+func (p *Plugin) command(ctx context.Context, command []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = p.Definition.WorkingDirectory
+	cmd.Env = os.Environ()
+	for name, value := range p.Definition.Environment {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	return cmd
+}
+
+// waitForAdopted waits for a process which is not a child of the agent to exit. Only windows can wait for such a
+// process, it is polled until it is gone on the other platforms.
+func waitForAdopted(handle *os.Process) error {
+	if runtime.GOOS == "windows" {
+		_, err := handle.Wait()
+		return err
+	}
+	for handle.Signal(syscall.Signal(0)) == nil {
+		time.Sleep(adoptedPollInterval)
+	}
+	return fmt.Errorf("adopted process %v exited", handle.Pid)
+}
+
+// wait waits for the process to exit, it is the wait of its supervisor
+func (proc *process) wait() error {
+	<-proc.exited
+	return proc.err
+}
+
+func (proc *process) running() bool {
+	select {
+	case <-proc.exited:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package executable
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/readiness"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPlugin(t *testing.T, definition Definition) *Plugin {
+	p := NewPlugin(contextmocks.NewMockDefault(), definition)
+	p.OutputDir = t.TempDir()
+	t.Cleanup(func() { p.Stop(taskmocks.NewMockDefault()) })
+	return p
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "LongRunningExecutables.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"Executables": [
+		{"Name": "collector", "StartCommand": ["/opt/collector/bin/collector", "--config", "/etc/collector.yml"]},
+		{"Name": "collector", "StartCommand": ["/opt/collector/bin/collector"]},
+		{"Name": "aws:cloudWatch", "StartCommand": ["/opt/cw"]},
+		{"Name": "relative", "StartCommand": ["collector"]},
+		{"Name": "nostart"}
+	]}`), 0600))
+
+	definitions, errs := LoadDefinitions(path)
+
+	assert.Len(t, definitions, 1)
+	assert.Equal(t, []string{"/opt/collector/bin/collector", "--config", "/etc/collector.yml"}, definitions[0].StartCommand)
+	assert.Len(t, errs, 4)
+	assert.Contains(t, errs[0].Error(), "declared more than once")
+	assert.Contains(t, errs[1].Error(), "reserved")
+	assert.Contains(t, errs[2].Error(), "not an absolute path")
+	assert.Contains(t, errs[3].Error(), "start command is missing")
+}
+
+func TestLoadDefinitions_MissingFile(t *testing.T) {
+	definitions, errs := LoadDefinitions(filepath.Join(t.TempDir(), "missing.json"))
+
+	assert.Empty(t, definitions)
+	assert.Empty(t, errs)
+}
+
+func TestValidateDefinition_WorkingDirectory(t *testing.T) {
+	definition := Definition{Name: "collector", StartCommand: []string{"/bin/sleep", "10"}, WorkingDirectory: "/does/not/exist"}
+	assert.Error(t, ValidateDefinition(definition))
+
+	definition.WorkingDirectory = t.TempDir()
+	assert.NoError(t, ValidateDefinition(definition))
+}
+
+func TestValidateDefinition_ReadinessGates(t *testing.T) {
+	definition := Definition{Name: "collector", StartCommand: []string{"/bin/sleep", "10"}, ReadinessGates: []readiness.Gate{
+		{Type: readiness.GateNetworkOnline},
+		{Type: readiness.GateServiceRunning},
+	}}
+	assert.Error(t, ValidateDefinition(definition))
+
+	definition.ReadinessGates[1].Name = "docker"
+	assert.NoError(t, ValidateDefinition(definition))
+	assert.Equal(t, definition.ReadinessGates, NewPlugin(contextmocks.NewMockDefault(), definition).ReadinessGates())
+}
+
+func TestStartAndStop(t *testing.T) {
+	p := newTestPlugin(t, Definition{
+		Name:         "sleeper",
+		StartCommand: []string{"/bin/sh", "-c", "echo $GREETING; exec sleep 60"},
+		Environment:  map[string]string{"GREETING": "hello"},
+	})
+
+	assert.NoError(t, p.Start("", "", taskmocks.NewMockDefault(), nil))
+	assert.True(t, p.IsRunning())
+	assert.True(t, p.SupervisorStatus().Supervising)
+	// starting a running executable does nothing
+	assert.NoError(t, p.Start("", "", taskmocks.NewMockDefault(), nil))
+
+	assert.Eventually(t, func() bool {
+		output, _ := ioutil.ReadFile(filepath.Join(p.OutputDir, stdoutFileName))
+		return string(output) == "hello\n"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, p.Stop(taskmocks.NewMockDefault()))
+	assert.False(t, p.IsRunning())
+	assert.Equal(t, 0, p.SupervisorStatus().Restarts)
+}
+
+func TestAdopt(t *testing.T) {
+	adoptedPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { adoptedPollInterval = time.Second })
+	// the process left by the previous agent, it is reaped by the test as it would be by init
+	orphan := exec.Command("/bin/sleep", "60")
+	assert.NoError(t, orphan.Start())
+	reaped := make(chan struct{})
+	go func() {
+		orphan.Wait()
+		close(reaped)
+	}()
+	p := newTestPlugin(t, Definition{Name: "orphan", StartCommand: []string{"/bin/sleep", "60"}})
+
+	assert.NoError(t, p.Adopt(orphan.Process.Pid))
+	assert.Equal(t, orphan.Process.Pid, p.ProcessID())
+	assert.True(t, p.IsRunning())
+	assert.True(t, p.SupervisorStatus().Supervising)
+	assert.Error(t, p.Adopt(orphan.Process.Pid))
+
+	assert.NoError(t, p.Stop(taskmocks.NewMockDefault()))
+	<-reaped
+	assert.Equal(t, 0, p.ProcessID())
+	assert.Equal(t, 0, p.SupervisorStatus().Restarts)
+}
+
+func TestCrashIsReported(t *testing.T) {
+	p := newTestPlugin(t, Definition{Name: "crasher", StartCommand: []string{"/bin/sh", "-c", "kill -SEGV $$"}})
+
+	assert.NoError(t, p.Start("", "", taskmocks.NewMockDefault(), nil))
+
+	// no dump is captured as the crash dumps are disabled by the test configuration
+	assert.Eventually(t, func() bool {
+		return strings.Contains(p.SupervisorStatus().LastExitError, "no crash dump was captured")
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestStopCommand(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	p := newTestPlugin(t, Definition{
+		Name:         "graceful",
+		StartCommand: []string{"/bin/sh", "-c", "echo $$ > " + pidFile + "; exec sleep 60"},
+		StopCommand:  []string{"/bin/sh", "-c", "kill $(cat " + pidFile + ")"},
+	})
+	assert.NoError(t, p.Start("", "", taskmocks.NewMockDefault(), nil))
+	assert.Eventually(t, func() bool {
+		content, _ := ioutil.ReadFile(pidFile)
+		return len(content) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, p.Stop(taskmocks.NewMockDefault()))
+	assert.False(t, p.process.running())
+}
+
+func TestHealthCheckCommand(t *testing.T) {
+	healthFile := filepath.Join(t.TempDir(), "healthy")
+	p := newTestPlugin(t, Definition{
+		Name:               "checked",
+		StartCommand:       []string{"/bin/sleep", "60"},
+		HealthCheckCommand: []string{"/bin/sh", "-c", "test -f " + healthFile},
+	})
+	assert.NoError(t, p.Start("", "", taskmocks.NewMockDefault(), nil))
+	process := p.process
+
+	assert.NoError(t, ioutil.WriteFile(healthFile, nil, 0600))
+	assert.True(t, p.IsRunning())
+	assert.NoError(t, p.LastHealthCheckError())
+
+	// the unhealthy process is killed for its supervisor to restart it
+	assert.NoError(t, os.Remove(healthFile))
+	assert.False(t, p.IsRunning())
+	assert.Error(t, p.LastHealthCheckError())
+	<-process.exited
+}
+
+func TestStopWithDrain_Interrupt(t *testing.T) {
+	drainedFile := filepath.Join(t.TempDir(), "drained")
+	p := newTestPlugin(t, Definition{
+		Name:         "draining",
+		StartCommand: []string{"/bin/sh", "-c", "trap 'echo flushed > " + drainedFile + "; exit 0' INT; echo ready; while true; do sleep 0.1; done"},
+	})
+	assert.NoError(t, p.Start("", "", taskmocks.NewMockDefault(), nil))
+	assert.Eventually(t, func() bool {
+		output, _ := ioutil.ReadFile(filepath.Join(p.OutputDir, stdoutFileName))
+		return string(output) == "ready\n"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, p.StopWithDrain(taskmocks.NewMockDefault(), 5*time.Second))
+	assert.False(t, p.process.running())
+	content, err := ioutil.ReadFile(drainedFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "flushed\n", string(content))
+}
+
+func TestHealthCheckExitCodeAndOutput(t *testing.T) {
+	statusFile := filepath.Join(t.TempDir(), "status")
+	p := newTestPlugin(t, Definition{
+		Name:                     "checked",
+		StartCommand:             []string{"/bin/sleep", "60"},
+		HealthCheckCommand:       []string{"/bin/sh", "-c", "cat " + statusFile + "; exit 3"},
+		HealthCheckExitCode:      3,
+		HealthCheckOutputPattern: `status: (ok|degraded)`,
+		UnhealthyThreshold:       2,
+	})
+	assert.NoError(t, p.Start("", "", taskmocks.NewMockDefault(), nil))
+	process := p.process
+
+	assert.NoError(t, ioutil.WriteFile(statusFile, []byte("status: degraded\n"), 0600))
+	assert.True(t, p.IsRunning())
+
+	// the first failed health check is tolerated
+	assert.NoError(t, ioutil.WriteFile(statusFile, []byte("status: failing\n"), 0600))
+	assert.True(t, p.IsRunning())
+	assert.True(t, process.running())
+
+	// a successful health check resets the count of failed health checks
+	assert.NoError(t, ioutil.WriteFile(statusFile, []byte("status: ok\n"), 0600))
+	assert.True(t, p.IsRunning())
+	assert.NoError(t, ioutil.WriteFile(statusFile, []byte("status: failing\n"), 0600))
+	assert.True(t, p.IsRunning())
+
+	assert.False(t, p.IsRunning())
+	<-process.exited
+}
+
+func TestConfigureHealthCheck(t *testing.T) {
+	p := newTestPlugin(t, Definition{
+		Name:               "slow",
+		StartCommand:       []string{"/bin/sleep", "60"},
+		HealthCheckCommand: []string{"/bin/sleep", "10"},
+	})
+	p.ConfigureHealthCheck(50*time.Millisecond, 2)
+	assert.NoError(t, p.Start("", "", taskmocks.NewMockDefault(), nil))
+	process := p.process
+
+	// the timed out health check fails and the configured threshold tolerates it once
+	assert.True(t, p.IsRunning())
+	assert.Error(t, p.LastHealthCheckError())
+	assert.True(t, process.running())
+
+	assert.False(t, p.IsRunning())
+	<-process.exited
+}
+
+func TestValidateDefinition_HealthCheck(t *testing.T) {
+	definition := Definition{Name: "checked", StartCommand: []string{"/bin/sleep", "10"}, HealthCheckOutputPattern: "ok"}
+	assert.Error(t, ValidateDefinition(definition))
+
+	definition.HealthCheckCommand = []string{"/bin/true"}
+	assert.NoError(t, ValidateDefinition(definition))
+
+	definition.HealthCheckOutputPattern = "(ok"
+	assert.Error(t, ValidateDefinition(definition))
+
+	definition.HealthCheckOutputPattern = ""
+	definition.UnhealthyThreshold = -1
+	assert.Error(t, ValidateDefinition(definition))
+}
@@ -15,6 +15,9 @@
 package plugin
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"path/filepath"
 	"time"
 
@@ -23,6 +26,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin/executable"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin/rundaemon"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
@@ -31,6 +35,29 @@ import (
 type PluginState struct {
 	LastConfigurationModifiedTime time.Time
 	IsEnabled                     bool
+	// DesiredState is Running until the plugin is stopped by a document, it is Stopped then
+	DesiredState string
+	// LastStartTime is the last time the plugin was started by the manager
+	LastStartTime time.Time
+	// ConfigurationHash identifies the configuration the plugin was started with
+	ConfigurationHash string
+	// ProcessID and ProcessExecutable identify the process of an adoptable plugin, the process id alone could
+	// have been reused by another process since the agent recorded it
+	ProcessID         int
+	ProcessExecutable string
+}
+
+const (
+	// DesiredStateRunning is the desired state of the plugins restarted with the agent
+	DesiredStateRunning = "Running"
+	// DesiredStateStopped is the desired state of the plugins stopped by a document
+	DesiredStateStopped = "Stopped"
+)
+
+// ConfigurationHash returns the hash recorded for the configuration of a plugin
+func ConfigurationHash(configuration string) string {
+	sum := sha256.Sum256([]byte(configuration))
+	return hex.EncodeToString(sum[:])
 }
 
 // PluginInfo reflects information about long running plugins
@@ -54,6 +81,59 @@ type LongRunningPlugin interface {
 	Stop(cancelFlag task.CancelFlag) error
 }
 
+const (
+	// StopTypeKill stops the plugin right away
+	StopTypeKill = "Kill"
+	// StopTypeDrain gives the plugin time to flush its pending data before it is stopped
+	StopTypeDrain = "Drain"
+
+	// DefaultDrainTimeoutSeconds is the time given to a plugin to drain when the document does not set it
+	DefaultDrainTimeoutSeconds = 60
+	// MaxDrainTimeoutSeconds is the longest time a document can give to a plugin to drain
+	MaxDrainTimeoutSeconds = 600
+)
+
+// StopOptions tells how a long running plugin is stopped
+type StopOptions struct {
+	// StopType is Kill or Drain, the plugin is killed when it is empty
+	StopType string
+	// DrainTimeoutSeconds is the time given to a draining plugin before it is killed
+	DrainTimeoutSeconds int
+}
+
+// Validate returns an error naming the allowed values when the options are invalid
+func (o StopOptions) Validate() error {
+	if o.StopType != "" && o.StopType != StopTypeKill && o.StopType != StopTypeDrain {
+		return fmt.Errorf("Allowed Values of StopType: %v | %v but provided value is: %v", StopTypeKill, StopTypeDrain, o.StopType)
+	}
+	if o.DrainTimeoutSeconds < 0 || o.DrainTimeoutSeconds > MaxDrainTimeoutSeconds {
+		return fmt.Errorf("DrainTimeoutSeconds must be between 0 and %v but provided value is: %v", MaxDrainTimeoutSeconds, o.DrainTimeoutSeconds)
+	}
+	return nil
+}
+
+// DrainTimeout returns the time given to the plugin to drain
+func (o StopOptions) DrainTimeout() time.Duration {
+	if o.DrainTimeoutSeconds == 0 {
+		return DefaultDrainTimeoutSeconds * time.Second
+	}
+	return time.Duration(o.DrainTimeoutSeconds) * time.Second
+}
+
+// DrainablePlugin is implemented by the long running plugins able to flush their pending data before they stop
+type DrainablePlugin interface {
+	StopWithDrain(cancelFlag task.CancelFlag, drainTimeout time.Duration) error
+}
+
+// AdoptablePlugin is implemented by the long running plugins whose process outlives the agent, the manager records
+// their process and hands it back to them when the agent restarts
+type AdoptablePlugin interface {
+	// ProcessID returns the process of the plugin, 0 when it is not running
+	ProcessID() int
+	// Adopt supervises the process left running by the previous agent
+	Adopt(pid int) error
+}
+
 // PluginSettings reflects settings that can be applied to long running plugins like aws:cloudWatch
 type PluginSettings struct {
 	StartType string
@@ -99,9 +179,50 @@ func loadPlatformIndependentPlugins(context context.T) map[string]Plugin {
 		longrunningplugins[key] = value
 	}
 
+	for key, value := range loadExecutablePlugins(context) {
+		if _, exists := longrunningplugins[key]; exists {
+			context.Log().Errorf("Executable %v has the name of an ssm daemon, it is not registered", key)
+			continue
+		}
+		context.Log().Debugf("Adding long-running plugin for %v", key)
+		longrunningplugins[key] = value
+	}
+
 	return longrunningplugins
 }
 
+// loadExecutablePlugins registers long running plugin handlers for the user executables declared under the
+// plugin folder
+func loadExecutablePlugins(context context.T) map[string]Plugin {
+	executablePlugins := make(map[string]Plugin)
+
+	log := context.Log()
+	definitionsFilePath := executable.DefinitionsFilePath()
+	definitions, errs := executable.LoadDefinitions(definitionsFilePath)
+	for _, err := range errs {
+		log.Errorf("Invalid long-running executable in %v: %v", definitionsFilePath, err)
+	}
+	for _, definition := range definitions {
+		log.Infof("Registering long-running plugin for executable %v", definition.Name)
+		executablePlugins[definition.Name] = Plugin{
+			Info: PluginInfo{
+				Name:          definition.Name,
+				Configuration: definitionConfiguration(definition),
+				State:         PluginState{IsEnabled: true},
+			},
+			Handler: executable.NewPlugin(context, definition),
+		}
+	}
+	return executablePlugins
+}
+
+// definitionConfiguration returns the definition of an executable as its configuration, the executable process left
+// by the previous agent is then adopted only when its definition did not change
+func definitionConfiguration(definition executable.Definition) string {
+	configuration, _ := jsonutil.Marshal(definition)
+	return configuration
+}
+
 // loadDaemonPlugins registers long running plugin handlers for ssm daemons
 func loadDaemonPlugins(context context.T) map[string]Plugin {
 	//long running daemon plugins that can be started/stopped/removed/configured by long running plugin manager
@@ -0,0 +1,169 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package readiness implements the system readiness gates long running plugins wait on before they are started
+// at boot
+package readiness
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	// GateNetworkOnline is met once a network interface other than the loopback has a global unicast address
+	GateNetworkOnline = "NetworkOnline"
+	// GateServiceRunning is met once the service of the given name is running
+	GateServiceRunning = "ServiceRunning"
+	// GatePathExists is met once the given path exists
+	GatePathExists = "PathExists"
+)
+
+// Gate is a condition of the system a long running plugin depends on
+type Gate struct {
+	// Type is one of NetworkOnline, ServiceRunning or PathExists
+	Type string
+	// Name is the name of the service of the ServiceRunning gates
+	Name string `json:",omitempty"`
+	// Path is the path of the PathExists gates
+	Path string `json:",omitempty"`
+}
+
+// String returns a description of the gate for the logs
+func (g Gate) String() string {
+	switch {
+	case strings.EqualFold(g.Type, GateServiceRunning):
+		return fmt.Sprintf("%v(%v)", GateServiceRunning, g.Name)
+	case strings.EqualFold(g.Type, GatePathExists):
+		return fmt.Sprintf("%v(%v)", GatePathExists, g.Path)
+	}
+	return g.Type
+}
+
+// Validate returns an error when the gate type is unknown or misses its parameter
+func (g Gate) Validate() error {
+	switch {
+	case strings.EqualFold(g.Type, GateNetworkOnline):
+		return nil
+	case strings.EqualFold(g.Type, GateServiceRunning):
+		if strings.TrimSpace(g.Name) == "" {
+			return fmt.Errorf("%v gate has no service name", GateServiceRunning)
+		}
+		return nil
+	case strings.EqualFold(g.Type, GatePathExists):
+		if strings.TrimSpace(g.Path) == "" {
+			return fmt.Errorf("%v gate has no path", GatePathExists)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown readiness gate type %q, expecting %v, %v or %v", g.Type, GateNetworkOnline, GateServiceRunning, GatePathExists)
+}
+
+// pollInterval is the time between two checks of the gates not met yet
+var pollInterval = 5 * time.Second
+
+// isNetworkOnline, isServiceRunning and pathExists check the gates, they are replaced in the tests
+var (
+	isNetworkOnline  = networkOnline
+	isServiceRunning = serviceRunning
+	pathExists       = fileutil.Exists
+)
+
+// Check returns an error explaining why the gate is not met
+func (g Gate) Check() error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	switch {
+	case strings.EqualFold(g.Type, GateNetworkOnline):
+		if !isNetworkOnline() {
+			return errors.New("no network interface is online")
+		}
+	case strings.EqualFold(g.Type, GateServiceRunning):
+		if running, err := isServiceRunning(g.Name); err != nil {
+			return fmt.Errorf("service %v state is unknown: %v", g.Name, err)
+		} else if !running {
+			return fmt.Errorf("service %v is not running", g.Name)
+		}
+	case strings.EqualFold(g.Type, GatePathExists):
+		if !pathExists(g.Path) {
+			return fmt.Errorf("path %v does not exist", g.Path)
+		}
+	}
+	return nil
+}
+
+// Wait checks the gates until they are all met, the deadline passes or the cancel flag is set. The reasons the
+// gates still not met are returned in the error.
+func Wait(log log.T, gates []Gate, deadline time.Time, cancelFlag task.CancelFlag) error {
+	pending := gates
+	for {
+		var notMet []Gate
+		var reasons []string
+		for _, gate := range pending {
+			if err := gate.Check(); err != nil {
+				notMet = append(notMet, gate)
+				reasons = append(reasons, err.Error())
+			}
+		}
+		if len(notMet) == 0 {
+			return nil
+		}
+		if len(notMet) != len(pending) {
+			log.Debugf("Waiting for the readiness gates %v", notMet)
+		}
+		pending = notMet
+
+		if cancelFlag.Canceled() {
+			return fmt.Errorf("stopped waiting for the readiness gates: %v", strings.Join(reasons, "; "))
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return fmt.Errorf("readiness gates not met: %v", strings.Join(reasons, "; "))
+		}
+		if wait > pollInterval {
+			wait = pollInterval
+		}
+		time.Sleep(wait)
+	}
+}
+
+// networkOnline returns true when a network interface other than the loopback is up with a global unicast address
+func networkOnline() bool {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return false
+	}
+	for _, networkInterface := range interfaces {
+		if networkInterface.Flags&net.FlagUp == 0 || networkInterface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addresses, err := networkInterface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, address := range addresses {
+			if ipNet, ok := address.(*net.IPNet); ok && ipNet.IP.IsGlobalUnicast() {
+				return true
+			}
+		}
+	}
+	return false
+}
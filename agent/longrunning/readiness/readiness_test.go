@@ -0,0 +1,99 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package readiness
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubGates(t *testing.T, networkOnline func() bool, serviceRunning func(string) (bool, error), exists func(string) bool) {
+	previousNetwork, previousService, previousPath, previousInterval := isNetworkOnline, isServiceRunning, pathExists, pollInterval
+	t.Cleanup(func() {
+		isNetworkOnline, isServiceRunning, pathExists, pollInterval = previousNetwork, previousService, previousPath, previousInterval
+	})
+	isNetworkOnline, isServiceRunning, pathExists, pollInterval = networkOnline, serviceRunning, exists, time.Millisecond
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Gate{Type: "networkonline"}.Validate())
+	assert.NoError(t, Gate{Type: GateServiceRunning, Name: "EventLog"}.Validate())
+	assert.NoError(t, Gate{Type: GatePathExists, Path: "/var/lib"}.Validate())
+	assert.Error(t, Gate{Type: GateServiceRunning}.Validate())
+	assert.Error(t, Gate{Type: GatePathExists, Path: " "}.Validate())
+	assert.Error(t, Gate{Type: "DiskMounted"}.Validate())
+}
+
+func TestCheck(t *testing.T) {
+	stubGates(t,
+		func() bool { return false },
+		func(name string) (bool, error) {
+			if name == "unknown" {
+				return false, errors.New("not installed")
+			}
+			return name == "running", nil
+		},
+		func(path string) bool { return path == "/exists" })
+
+	assert.EqualError(t, Gate{Type: GateNetworkOnline}.Check(), "no network interface is online")
+	assert.NoError(t, Gate{Type: GateServiceRunning, Name: "running"}.Check())
+	assert.EqualError(t, Gate{Type: GateServiceRunning, Name: "stopped"}.Check(), "service stopped is not running")
+	assert.EqualError(t, Gate{Type: GateServiceRunning, Name: "unknown"}.Check(), "service unknown state is unknown: not installed")
+	assert.NoError(t, Gate{Type: GatePathExists, Path: "/exists"}.Check())
+	assert.EqualError(t, Gate{Type: GatePathExists, Path: "/missing"}.Check(), "path /missing does not exist")
+}
+
+func TestWait_GatesMet(t *testing.T) {
+	checks := 0
+	stubGates(t,
+		func() bool {
+			checks++
+			return checks >= 3
+		},
+		func(string) (bool, error) { return true, nil },
+		func(string) bool { return true })
+
+	gates := []Gate{{Type: GateNetworkOnline}, {Type: GateServiceRunning, Name: "EventLog"}}
+	err := Wait(logmocks.NewMockLog(), gates, time.Now().Add(time.Minute), task.NewChanneledCancelFlag())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, checks)
+}
+
+func TestWait_Deadline(t *testing.T) {
+	stubGates(t,
+		func() bool { return true },
+		func(string) (bool, error) { return true, nil },
+		func(string) bool { return false })
+
+	gates := []Gate{{Type: GateNetworkOnline}, {Type: GatePathExists, Path: "/missing"}}
+	err := Wait(logmocks.NewMockLog(), gates, time.Now().Add(20*time.Millisecond), task.NewChanneledCancelFlag())
+	assert.EqualError(t, err, "readiness gates not met: path /missing does not exist")
+}
+
+func TestWait_Canceled(t *testing.T) {
+	stubGates(t,
+		func() bool { return false },
+		func(string) (bool, error) { return true, nil },
+		func(string) bool { return true })
+
+	cancelFlag := task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.Canceled)
+	err := Wait(logmocks.NewMockLog(), []Gate{{Type: GateNetworkOnline}}, time.Now().Add(time.Hour), cancelFlag)
+	assert.EqualError(t, err, "stopped waiting for the readiness gates: no network interface is online")
+}
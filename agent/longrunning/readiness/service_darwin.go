@@ -0,0 +1,32 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package readiness
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// serviceRunning asks launchd for the process id of the service, the service runs when it has one
+func serviceRunning(name string) (bool, error) {
+	output, err := exec.Command("launchctl", "list", name).Output()
+	if err != nil {
+		// launchctl fails when the service is not loaded
+		return false, nil
+	}
+	return strings.Contains(string(output), `"PID" =`), nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package readiness
+
+import (
+	"os/exec"
+)
+
+// serviceRunning asks systemd, or the service command on the systems without systemd, if the service is running
+func serviceRunning(name string) (bool, error) {
+	if systemctl, err := exec.LookPath("systemctl"); err == nil {
+		// is-active exits with 0 only when the unit is active
+		return exec.Command(systemctl, "is-active", "--quiet", name).Run() == nil, nil
+	}
+	service, err := exec.LookPath("service")
+	if err != nil {
+		return false, err
+	}
+	return exec.Command(service, name, "status").Run() == nil, nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package supervisor restarts the processes of the long running plugins when they exit unexpectedly
+package supervisor
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/winevent"
+)
+
+const (
+	// defaultInitialBackoff is the delay before the first restart of a process that exited unexpectedly
+	defaultInitialBackoff = 5 * time.Second
+
+	// defaultMaxBackoff caps the delay between the restarts, the delay doubles after each restart
+	defaultMaxBackoff = 5 * time.Minute
+
+	// defaultMaxRestarts is the number of consecutive restarts after which the supervisor gives up
+	defaultMaxRestarts = 10
+
+	// defaultStableRuntime is the run time after which an exit is no longer counted as part of a crash loop,
+	// the backoff and the consecutive restarts are reset
+	defaultStableRuntime = 10 * time.Minute
+)
+
+// Status reports the restarts of the supervised process
+type Status struct {
+	// Restarts is the number of restarts since the process was started
+	Restarts int
+	// ConsecutiveRestarts is the number of restarts since the process last ran for defaultStableRuntime
+	ConsecutiveRestarts int
+	// LastExit is the time the process last exited unexpectedly, it is zero if it never did
+	LastExit time.Time
+	// LastExitError describes how the process last exited unexpectedly
+	LastExitError string
+	// LastCrashDump is the dump captured when the process last crashed, it is empty if no dump was captured
+	LastCrashDump string
+	// Supervising is true while the supervisor watches the process and restarts it when it exits
+	Supervising bool
+	// GaveUp is true when the process crashed defaultMaxRestarts times in a row and is no longer restarted
+	GaveUp bool
+}
+
+// crashReport is implemented by the exit errors of the processes which crashed
+type crashReport interface {
+	CrashDump() string
+}
+
+// WaitFunc waits for a started process to exit
+type WaitFunc func() error
+
+// StartFunc starts the process again and returns the function waiting for it to exit
+type StartFunc func() (WaitFunc, error)
+
+// Supervisor restarts a process which exits unexpectedly with an exponential backoff, until it is stopped or
+// the process crashed defaultMaxRestarts times in a row
+type Supervisor struct {
+	log   log.T
+	name  string
+	start StartFunc
+
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxRestarts    int
+	stableRuntime  time.Duration
+	now            func() time.Time
+	after          func(time.Duration) <-chan time.Time
+
+	lock    sync.Mutex
+	status  Status
+	stop    chan struct{}
+	stopped bool
+	done    chan struct{}
+}
+
+// New returns a supervisor restarting the named process with start
+func New(log log.T, name string, start StartFunc) *Supervisor {
+	return &Supervisor{
+		log:            log,
+		name:           name,
+		start:          start,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		maxRestarts:    defaultMaxRestarts,
+		stableRuntime:  defaultStableRuntime,
+		now:            time.Now,
+		after:          time.After,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+// Supervise watches the started process in a goroutine
+func (s *Supervisor) Supervise(wait WaitFunc) {
+	s.lock.Lock()
+	s.status.Supervising = true
+	s.lock.Unlock()
+	go s.run(wait)
+}
+
+// Stop ends the supervision, the exit of the process that follows is expected and it is not restarted
+func (s *Supervisor) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+}
+
+// Done returns a channel closed once the supervisor no longer watches the process
+func (s *Supervisor) Done() <-chan struct{} {
+	return s.done
+}
+
+// Status returns the restarts of the supervised process
+func (s *Supervisor) Status() Status {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.status
+}
+
+func (s *Supervisor) isStopped() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stopped
+}
+
+// reportCrash writes the health event of a crash of the process
+func (s *Supervisor) reportCrash(err error, dumpPath string) {
+	fields := winevent.Fields{"plugin": s.name, "error": err.Error()}
+	if dumpPath != "" {
+		fields["dump"] = dumpPath
+	}
+	winevent.WriteWarning(s.log, winevent.PluginCrashed, fields)
+}
+
+func (s *Supervisor) run(wait WaitFunc) {
+	defer close(s.done)
+	defer func() {
+		s.lock.Lock()
+		s.status.Supervising = false
+		s.lock.Unlock()
+	}()
+	backoff := s.initialBackoff
+	for {
+		started := s.now()
+		err := wait()
+		if s.isStopped() {
+			return
+		}
+
+		s.lock.Lock()
+		if s.now().Sub(started) >= s.stableRuntime {
+			backoff = s.initialBackoff
+			s.status.ConsecutiveRestarts = 0
+		}
+		s.status.LastExit = s.now()
+		s.status.LastExitError = "exited"
+		if err != nil {
+			s.status.LastExitError = err.Error()
+		}
+		var crash crashReport
+		if errors.As(err, &crash) {
+			s.status.LastCrashDump = crash.CrashDump()
+			s.reportCrash(err, crash.CrashDump())
+		}
+		if s.status.ConsecutiveRestarts >= s.maxRestarts {
+			s.status.GaveUp = true
+			s.lock.Unlock()
+			s.log.Errorf("%v exited unexpectedly (%v) after %d consecutive restarts, it is no longer restarted", s.name, err, s.maxRestarts)
+			return
+		}
+		s.lock.Unlock()
+
+		s.log.Warnf("%v exited unexpectedly (%v), restarting it in %v", s.name, err, backoff)
+		select {
+		case <-s.stop:
+			return
+		case <-s.after(backoff):
+		}
+		if backoff *= 2; backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+
+		s.lock.Lock()
+		s.status.Restarts++
+		s.status.ConsecutiveRestarts++
+		s.lock.Unlock()
+		var startErr error
+		if wait, startErr = s.start(); startErr != nil {
+			s.log.Errorf("Failed to restart %v: %v", s.name, startErr)
+			wait = func() error { return startErr }
+		} else {
+			s.log.Infof("Restarted %v", s.name)
+		}
+	}
+}
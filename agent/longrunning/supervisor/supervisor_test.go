@@ -0,0 +1,172 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package supervisor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is advanced by the waits of the supervised processes and records the restart delays
+type fakeClock struct {
+	now    time.Time
+	delays []time.Duration
+}
+
+func (c *fakeClock) after(delay time.Duration) <-chan time.Time {
+	c.delays = append(c.delays, delay)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// crashAfter returns a wait which advances the clock by runtime before the process exits with an error
+func (c *fakeClock) crashAfter(runtime time.Duration) WaitFunc {
+	return func() error {
+		c.now = c.now.Add(runtime)
+		return errors.New("exit code 1")
+	}
+}
+
+func newTestSupervisor(clock *fakeClock, start StartFunc) *Supervisor {
+	s := New(logmocks.NewMockLog(), "daemon", start)
+	s.initialBackoff = time.Second
+	s.maxBackoff = 4 * time.Second
+	s.maxRestarts = 4
+	s.now = func() time.Time { return clock.now }
+	s.after = clock.after
+	return s
+}
+
+func TestSupervisor_RestartsWithBackoffUntilCeiling(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)}
+	starts := 0
+	s := newTestSupervisor(clock, func() (WaitFunc, error) {
+		starts++
+		return clock.crashAfter(time.Second), nil
+	})
+
+	s.Supervise(clock.crashAfter(time.Second))
+	<-s.done
+
+	assert.Equal(t, 4, starts)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}, clock.delays)
+	status := s.Status()
+	assert.Equal(t, 4, status.Restarts)
+	assert.Equal(t, 4, status.ConsecutiveRestarts)
+	assert.True(t, status.GaveUp)
+	assert.False(t, status.Supervising)
+	assert.Equal(t, "exit code 1", status.LastExitError)
+	assert.Equal(t, clock.now, status.LastExit)
+}
+
+func TestSupervisor_StartErrorCountsAsCrash(t *testing.T) {
+	clock := &fakeClock{}
+	s := newTestSupervisor(clock, func() (WaitFunc, error) {
+		return nil, errors.New("file not found")
+	})
+
+	s.Supervise(clock.crashAfter(time.Second))
+	<-s.done
+
+	status := s.Status()
+	assert.Equal(t, 4, status.Restarts)
+	assert.True(t, status.GaveUp)
+	assert.Equal(t, "file not found", status.LastExitError)
+}
+
+func TestSupervisor_StableRuntimeResetsBackoff(t *testing.T) {
+	clock := &fakeClock{}
+	runtimes := []time.Duration{time.Second, time.Second, defaultStableRuntime, time.Second, time.Second, time.Second, time.Second}
+	s := newTestSupervisor(clock, func() (WaitFunc, error) {
+		runtime := runtimes[0]
+		runtimes = runtimes[1:]
+		return clock.crashAfter(runtime), nil
+	})
+
+	s.Supervise(clock.crashAfter(time.Second))
+	<-s.done
+
+	// the third restart ran long enough for the following crashes to start a new crash loop
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}, clock.delays)
+	status := s.Status()
+	assert.Equal(t, 7, status.Restarts)
+	assert.Equal(t, 4, status.ConsecutiveRestarts)
+	assert.True(t, status.GaveUp)
+}
+
+func TestSupervisor_StopPreventsRestart(t *testing.T) {
+	exit := make(chan struct{})
+	starts := 0
+	s := New(logmocks.NewMockLog(), "daemon", func() (WaitFunc, error) {
+		starts++
+		return nil, nil
+	})
+
+	s.Supervise(func() error {
+		<-exit
+		return errors.New("killed")
+	})
+	s.Stop()
+	close(exit)
+	<-s.done
+
+	assert.Equal(t, 0, starts)
+	assert.Equal(t, Status{}, s.Status())
+}
+
+func TestSupervisor_StopDuringBackoff(t *testing.T) {
+	starts := 0
+	s := New(logmocks.NewMockLog(), "daemon", func() (WaitFunc, error) {
+		starts++
+		return nil, nil
+	})
+	s.after = func(time.Duration) <-chan time.Time {
+		s.Stop()
+		return make(chan time.Time)
+	}
+
+	s.Supervise(func() error { return errors.New("exit code 1") })
+	<-s.done
+
+	assert.Equal(t, 0, starts)
+	assert.Equal(t, 0, s.Status().Restarts)
+	assert.False(t, s.Status().GaveUp)
+}
+
+type fakeCrashError struct {
+	dump string
+}
+
+func (e fakeCrashError) Error() string     { return "signal: segmentation fault (core dumped)" }
+func (e fakeCrashError) CrashDump() string { return e.dump }
+
+func TestSupervisor_RecordsCrashDump(t *testing.T) {
+	clock := &fakeClock{}
+	s := newTestSupervisor(clock, func() (WaitFunc, error) {
+		return clock.crashAfter(time.Second), nil
+	})
+	s.maxRestarts = 1
+
+	s.Supervise(func() error { return fakeCrashError{dump: "/var/lib/amazon/ssm/diagnostics/collector/core"} })
+	<-s.done
+
+	// the dump of the crash stays referenced after the next exit which did not crash
+	status := s.Status()
+	assert.Equal(t, "/var/lib/amazon/ssm/diagnostics/collector/core", status.LastCrashDump)
+	assert.Equal(t, "exit code 1", status.LastExitError)
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+)
+
+const (
+	// EcdsaKeyType returns the ECDSA Key Type
+	EcdsaKeyType = "Ecdsa"
+)
+
+type EcdsaKey struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+// CreateEcdsaKeypair creates a new ECDSA keypair on the P-256 curve
+func CreateEcdsaKeypair() (ecdsaKey EcdsaKey, err error) {
+	ecdsaKey.privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	return
+}
+
+// EncodePublicKey encodes a public key to a base 64 DER encoded string
+func (ecdsaKey *EcdsaKey) EncodePublicKey() (publicKey string, err error) {
+	var publicKeyBytes []byte
+	publicKeyBytes, err = x509.MarshalPKIXPublicKey(&ecdsaKey.privateKey.PublicKey)
+	if err != nil {
+		return
+	}
+	publicKey = base64.StdEncoding.EncodeToString(publicKeyBytes)
+
+	return
+}
+
+// EncodePrivateKey encodes a private key to a base 64 SEC 1 DER encoded string
+func (ecdsaKey *EcdsaKey) EncodePrivateKey() (privateKey string, err error) {
+	var privateKeyBytes []byte
+	privateKeyBytes, err = x509.MarshalECPrivateKey(ecdsaKey.privateKey)
+	if err != nil {
+		return
+	}
+	privateKey = base64.StdEncoding.EncodeToString(privateKeyBytes)
+
+	return
+}
+
+// KeyType returns the type of the key
+func (ecdsaKey *EcdsaKey) KeyType() string {
+	return EcdsaKeyType
+}
+
+// Sign creates the ASN.1 encoded signature of the SHA-256 hash of a message
+func (ecdsaKey *EcdsaKey) Sign(message string) (signature string, err error) {
+	messageHash := sha256.Sum256([]byte(message))
+
+	var signatureBytes []byte
+	signatureBytes, err = ecdsa.SignASN1(rand.Reader, ecdsaKey.privateKey, messageHash[:])
+	if err != nil {
+		return
+	}
+	signature = base64.StdEncoding.EncodeToString(signatureBytes)
+
+	return
+}
+
+// VerifySignature verifies the signature of a message
+func (ecdsaKey *EcdsaKey) VerifySignature(message string, signature string) (err error) {
+	if ecdsaKey.privateKey == nil {
+		return errors.New("privateKey is nil")
+	}
+	messageHash := sha256.Sum256([]byte(message))
+
+	var signatureBytes []byte
+	signatureBytes, err = base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return
+	}
+
+	if !ecdsa.VerifyASN1(&ecdsaKey.privateKey.PublicKey, messageHash[:], signatureBytes) {
+		return errors.New("crypto/ecdsa: verification error")
+	}
+	return nil
+}
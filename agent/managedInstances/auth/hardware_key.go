@@ -0,0 +1,143 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// KeyStorageFile keeps the private key with the registration information
+	KeyStorageFile = "File"
+	// KeyStorageHardware keeps the private key in the TPM or the Windows platform crypto provider,
+	// only a reference to the key is kept with the registration information
+	KeyStorageHardware = "Hardware"
+
+	// hardwareKeyPrefix starts the references of the keys kept in hardware
+	hardwareKeyPrefix = "hardware:"
+)
+
+// hardwareKeyStore creates, opens and deletes the keys of the secure element of the platform
+type hardwareKeyStore interface {
+	create() (reference string, err error)
+	publicKey(reference string) (*rsa.PublicKey, error)
+	signDigest(reference string, digest []byte) ([]byte, error)
+	delete(reference string) error
+}
+
+// HardwareKey is an RSA key that never leaves the secure element, the signatures are computed by the secure element
+type HardwareKey struct {
+	reference string
+	public    *rsa.PublicKey
+}
+
+// IsHardwareKey returns true if the encoded private key references a key kept in hardware
+func IsHardwareKey(privateKey string) bool {
+	return strings.HasPrefix(privateKey, hardwareKeyPrefix)
+}
+
+// CreateKeyInStorage creates a new keypair of the given key type in the given storage
+func CreateKeyInStorage(keyType, keyStorage string) (Key, error) {
+	switch keyStorage {
+	case "", KeyStorageFile:
+		return CreateKey(keyType)
+	case KeyStorageHardware:
+		if keyType != KeyType {
+			return nil, fmt.Errorf("key type %v cannot be kept in hardware, only %v keys are supported", keyType, KeyType)
+		}
+		reference, err := hardwareKeys.create()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create hardware key: %v", err)
+		}
+		return openHardwareKey(reference)
+	default:
+		return nil, fmt.Errorf("unsupported key storage %v", keyStorage)
+	}
+}
+
+// DeleteKey releases the secure element key referenced by the encoded private key, nothing is done for the other keys
+func DeleteKey(privateKey string) error {
+	if !IsHardwareKey(privateKey) {
+		return nil
+	}
+	return hardwareKeys.delete(privateKey)
+}
+
+func openHardwareKey(reference string) (Key, error) {
+	public, err := hardwareKeys.publicKey(reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hardware key %v: %v", reference, err)
+	}
+	return &HardwareKey{reference: reference, public: public}, nil
+}
+
+// EncodePublicKey encodes the public key to a base 64 DER encoded string
+func (hardwareKey *HardwareKey) EncodePublicKey() (publicKey string, err error) {
+	var publicKeyBytes []byte
+	publicKeyBytes, err = x509.MarshalPKIXPublicKey(hardwareKey.public)
+	if err != nil {
+		return
+	}
+	publicKey = base64.StdEncoding.EncodeToString(publicKeyBytes)
+
+	return
+}
+
+// EncodePrivateKey returns the reference of the key, the private key cannot be exported
+func (hardwareKey *HardwareKey) EncodePrivateKey() (string, error) {
+	return hardwareKey.reference, nil
+}
+
+// KeyType returns the type of the key
+func (hardwareKey *HardwareKey) KeyType() string {
+	return KeyType
+}
+
+// Sign has the secure element create the RSA PSS signature of the SHA-256 hash of a message
+func (hardwareKey *HardwareKey) Sign(message string) (signature string, err error) {
+	hasher := crypto.SHA256.New()
+	hasher.Write([]byte(message))
+
+	var signatureBytes []byte
+	signatureBytes, err = hardwareKeys.signDigest(hardwareKey.reference, hasher.Sum(nil))
+	if err != nil {
+		return
+	}
+	signature = base64.StdEncoding.EncodeToString(signatureBytes)
+
+	return
+}
+
+// VerifySignature verifies the signature of a message with the public key
+func (hardwareKey *HardwareKey) VerifySignature(message string, signature string) (err error) {
+	if hardwareKey.public == nil {
+		return errors.New("publicKey is nil")
+	}
+	hasher := crypto.SHA256.New()
+	hasher.Write([]byte(message))
+
+	var signatureBytes []byte
+	signatureBytes, err = base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return
+	}
+
+	return rsa.VerifyPSS(hardwareKey.public, crypto.SHA256, hasher.Sum(nil), signatureBytes, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+}
@@ -0,0 +1,186 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// tpmReferencePrefix starts the references of the keys persisted in the TPM, the handle follows
+	tpmReferencePrefix = hardwareKeyPrefix + "tpm:"
+
+	// the agent keys are persisted in the first 16 owner handles of the persistent range
+	tpmFirstPersistentHandle = 0x81010100
+	tpmPersistentHandleCount = 16
+
+	tpmKeyAttributes = "fixedtpm|fixedparent|sensitivedataorigin|userwithauth|sign"
+)
+
+// tpmCommand runs a command of the tpm2-tools
+var tpmCommand = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+var hardwareKeys hardwareKeyStore = tpmKeyStore{}
+
+// tpmKeyStore persists the keys in the TPM with the tpm2-tools
+type tpmKeyStore struct{}
+
+func runTpmCommand(name string, args ...string) ([]byte, error) {
+	output, err := tpmCommand(name, args...)
+	if err != nil {
+		return output, fmt.Errorf("%v failed: %v %v", name, err, strings.TrimSpace(string(output)))
+	}
+	return output, nil
+}
+
+func (tpmKeyStore) create() (reference string, err error) {
+	var handle string
+	if handle, err = freeTpmHandle(); err != nil {
+		return
+	}
+
+	var workDir string
+	if workDir, err = ioutil.TempDir("", "ssm-tpm"); err != nil {
+		return
+	}
+	defer os.RemoveAll(workDir)
+	primaryContext := filepath.Join(workDir, "primary.ctx")
+	keyContext := filepath.Join(workDir, "key.ctx")
+	publicPart := filepath.Join(workDir, "key.pub")
+	privatePart := filepath.Join(workDir, "key.priv")
+
+	if _, err = runTpmCommand("tpm2_createprimary", "-C", "o", "-c", primaryContext); err != nil {
+		return
+	}
+	if _, err = runTpmCommand("tpm2_create", "-C", primaryContext, "-G", "rsa2048", "-a", tpmKeyAttributes, "-u", publicPart, "-r", privatePart); err != nil {
+		return
+	}
+	if _, err = runTpmCommand("tpm2_load", "-C", primaryContext, "-u", publicPart, "-r", privatePart, "-c", keyContext); err != nil {
+		return
+	}
+	if _, err = runTpmCommand("tpm2_evictcontrol", "-C", "o", "-c", keyContext, handle); err != nil {
+		return
+	}
+	return tpmReferencePrefix + handle, nil
+}
+
+func (tpmKeyStore) publicKey(reference string) (*rsa.PublicKey, error) {
+	handle, err := parseTpmReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := ioutil.TempDir("", "ssm-tpm")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+	publicKeyFile := filepath.Join(workDir, "public.der")
+
+	if _, err = runTpmCommand("tpm2_readpublic", "-c", handle, "-f", "der", "-o", publicKeyFile); err != nil {
+		return nil, err
+	}
+	der, err := ioutil.ReadFile(publicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	public, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaPublic, ok := public.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the key of handle %v is not an RSA key", handle)
+	}
+	return rsaPublic, nil
+}
+
+func (tpmKeyStore) signDigest(reference string, digest []byte) ([]byte, error) {
+	handle, err := parseTpmReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := ioutil.TempDir("", "ssm-tpm")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+	digestFile := filepath.Join(workDir, "digest")
+	signatureFile := filepath.Join(workDir, "signature")
+
+	if err = ioutil.WriteFile(digestFile, digest, 0600); err != nil {
+		return nil, err
+	}
+	if _, err = runTpmCommand("tpm2_sign", "-c", handle, "-g", "sha256", "-s", "rsapss", "-d", "-f", "plain", "-o", signatureFile, digestFile); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(signatureFile)
+}
+
+func (tpmKeyStore) delete(reference string) error {
+	handle, err := parseTpmReference(reference)
+	if err != nil {
+		return err
+	}
+	_, err = runTpmCommand("tpm2_evictcontrol", "-C", "o", "-c", handle)
+	return err
+}
+
+// freeTpmHandle returns the first handle of the agent range not used by a persistent object
+func freeTpmHandle() (string, error) {
+	output, err := runTpmCommand("tpm2_getcap", "handles-persistent")
+	if err != nil {
+		return "", err
+	}
+	used := make(map[uint64]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-"))
+		if handle, err := strconv.ParseUint(value, 0, 32); err == nil {
+			used[handle] = true
+		}
+	}
+	for handle := uint64(tpmFirstPersistentHandle); handle < tpmFirstPersistentHandle+tpmPersistentHandleCount; handle++ {
+		if !used[handle] {
+			return fmt.Sprintf("0x%x", handle), nil
+		}
+	}
+	return "", fmt.Errorf("no free TPM persistent handle between 0x%x and 0x%x",
+		tpmFirstPersistentHandle, tpmFirstPersistentHandle+tpmPersistentHandleCount-1)
+}
+
+// parseTpmReference returns the persistent handle of a TPM key reference
+func parseTpmReference(reference string) (string, error) {
+	if !strings.HasPrefix(reference, tpmReferencePrefix) {
+		return "", fmt.Errorf("%v is not a TPM key reference", reference)
+	}
+	handle := strings.TrimPrefix(reference, tpmReferencePrefix)
+	if value, err := strconv.ParseUint(handle, 0, 32); err != nil || value < tpmFirstPersistentHandle || value >= tpmFirstPersistentHandle+tpmPersistentHandleCount {
+		return "", fmt.Errorf("%v is not a TPM persistent handle of the agent", handle)
+	}
+	return handle, nil
+}
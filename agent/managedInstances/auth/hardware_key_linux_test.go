@@ -0,0 +1,53 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package auth
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTpmReference(t *testing.T) {
+	handle, err := parseTpmReference("hardware:tpm:0x81010102")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x81010102", handle)
+
+	for _, reference := range []string{"hardware:ncrypt:key", "hardware:tpm:0x81000001", "hardware:tpm:primary"} {
+		_, err = parseTpmReference(reference)
+		assert.Error(t, err, reference)
+	}
+}
+
+func TestFreeTpmHandle(t *testing.T) {
+	tpmCommandBackup := tpmCommand
+	defer func() { tpmCommand = tpmCommandBackup }()
+
+	tpmCommand = func(name string, args ...string) ([]byte, error) {
+		return []byte("- 0x81000001\n- 0x81010100\n- 0x81010101\n"), nil
+	}
+	handle, err := freeTpmHandle()
+	assert.NoError(t, err)
+	assert.Equal(t, "0x81010102", handle)
+
+	tpmCommand = func(name string, args ...string) ([]byte, error) {
+		return []byte("ERROR: Esys_Initialize"), fmt.Errorf("exit status 1")
+	}
+	_, err = freeTpmHandle()
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+)
+
+var errHardwareKeyUnsupported = errors.New("hardware key storage is only supported on Linux and Windows")
+
+var hardwareKeys hardwareKeyStore = unsupportedKeyStore{}
+
+// unsupportedKeyStore is used on the platforms without a supported secure element
+type unsupportedKeyStore struct{}
+
+func (unsupportedKeyStore) create() (string, error) {
+	return "", errHardwareKeyUnsupported
+}
+
+func (unsupportedKeyStore) publicKey(reference string) (*rsa.PublicKey, error) {
+	return nil, errHardwareKeyUnsupported
+}
+
+func (unsupportedKeyStore) signDigest(reference string, digest []byte) ([]byte, error) {
+	return nil, errHardwareKeyUnsupported
+}
+
+func (unsupportedKeyStore) delete(reference string) error {
+	return errHardwareKeyUnsupported
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// softwareKeyStore keeps the keys in memory in place of the secure element
+type softwareKeyStore struct {
+	keys    map[string]*rsa.PrivateKey
+	deleted []string
+}
+
+func (store *softwareKeyStore) create() (string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", err
+	}
+	reference := fmt.Sprintf("%vtest:%v", hardwareKeyPrefix, len(store.keys))
+	store.keys[reference] = key
+	return reference, nil
+}
+
+func (store *softwareKeyStore) publicKey(reference string) (*rsa.PublicKey, error) {
+	if key, found := store.keys[reference]; found {
+		return &key.PublicKey, nil
+	}
+	return nil, fmt.Errorf("key %v not found", reference)
+}
+
+func (store *softwareKeyStore) signDigest(reference string, digest []byte) ([]byte, error) {
+	return rsa.SignPSS(rand.Reader, store.keys[reference], crypto.SHA256, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+}
+
+func (store *softwareKeyStore) delete(reference string) error {
+	delete(store.keys, reference)
+	store.deleted = append(store.deleted, reference)
+	return nil
+}
+
+func useSoftwareKeyStore(t *testing.T) *softwareKeyStore {
+	store := &softwareKeyStore{keys: make(map[string]*rsa.PrivateKey)}
+	hardwareKeysBackup := hardwareKeys
+	hardwareKeys = store
+	t.Cleanup(func() { hardwareKeys = hardwareKeysBackup })
+	return store
+}
+
+func TestCreateKeyInStorage_Hardware(t *testing.T) {
+	store := useSoftwareKeyStore(t)
+
+	key, err := CreateKeyInStorage(KeyType, KeyStorageHardware)
+	assert.NoError(t, err)
+	assert.Equal(t, KeyType, key.KeyType())
+	assert.NoError(t, ValidateKey(key))
+
+	reference, _ := key.EncodePrivateKey()
+	assert.True(t, IsHardwareKey(reference))
+	decodedKey, err := DecodeKey(reference)
+	assert.NoError(t, err)
+	publicKey, _ := key.EncodePublicKey()
+	decodedPublicKey, _ := decodedKey.EncodePublicKey()
+	assert.Equal(t, publicKey, decodedPublicKey)
+
+	assert.NoError(t, DeleteKey(reference))
+	assert.Equal(t, []string{reference}, store.deleted)
+	_, err = DecodeKey(reference)
+	assert.Error(t, err)
+}
+
+func TestCreateKeyInStorage_UnsupportedCombinations(t *testing.T) {
+	useSoftwareKeyStore(t)
+
+	_, err := CreateKeyInStorage(EcdsaKeyType, KeyStorageHardware)
+	assert.Error(t, err)
+	_, err = CreateKeyInStorage(KeyType, "Smartcard")
+	assert.Error(t, err)
+}
+
+func TestDeleteKey_SoftwareKeyIsIgnored(t *testing.T) {
+	store := useSoftwareKeyStore(t)
+
+	key, _ := CreateKeyInStorage(KeyType, KeyStorageFile)
+	encodedKey, _ := key.EncodePrivateKey()
+	assert.False(t, IsHardwareKey(encodedKey))
+	assert.NoError(t, DeleteKey(encodedKey))
+	assert.Empty(t, store.deleted)
+}
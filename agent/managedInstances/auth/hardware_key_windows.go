@@ -0,0 +1,229 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+	"unsafe"
+
+	"github.com/twinj/uuid"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// ncryptReferencePrefix starts the references of the keys persisted by the platform crypto provider, the key name follows
+	ncryptReferencePrefix = hardwareKeyPrefix + "ncrypt:"
+	ncryptKeyNamePrefix   = "AmazonSSMAgent-"
+
+	platformCryptoProvider = "Microsoft Platform Crypto Provider"
+
+	ncryptMachineKeyFlag = 0x20
+	ncryptSilentFlag     = 0x40
+	bcryptPadPss         = 0x8
+
+	rsaKeyLength      = 2048
+	rsaPublicBlobType = "RSAPUBLICBLOB"
+	sha256DigestSize  = 32
+)
+
+var (
+	ncrypt                        = windows.NewLazySystemDLL("ncrypt.dll")
+	procNCryptOpenStorageProvider = ncrypt.NewProc("NCryptOpenStorageProvider")
+	procNCryptCreatePersistedKey  = ncrypt.NewProc("NCryptCreatePersistedKey")
+	procNCryptOpenKey             = ncrypt.NewProc("NCryptOpenKey")
+	procNCryptSetProperty         = ncrypt.NewProc("NCryptSetProperty")
+	procNCryptFinalizeKey         = ncrypt.NewProc("NCryptFinalizeKey")
+	procNCryptExportKey           = ncrypt.NewProc("NCryptExportKey")
+	procNCryptSignHash            = ncrypt.NewProc("NCryptSignHash")
+	procNCryptDeleteKey           = ncrypt.NewProc("NCryptDeleteKey")
+	procNCryptFreeObject          = ncrypt.NewProc("NCryptFreeObject")
+)
+
+var hardwareKeys hardwareKeyStore = ncryptKeyStore{}
+
+// ncryptKeyStore persists the machine keys with the platform crypto provider, backed by the TPM
+type ncryptKeyStore struct{}
+
+// bcryptPssPaddingInfo is the BCRYPT_PSS_PADDING_INFO structure
+type bcryptPssPaddingInfo struct {
+	algorithm *uint16
+	saltSize  uint32
+}
+
+// bcryptRsaKeyBlobHeader is the BCRYPT_RSAKEY_BLOB structure, the exponent and the modulus follow it
+type bcryptRsaKeyBlobHeader struct {
+	Magic     uint32
+	BitLength uint32
+	PublicExp uint32
+	Modulus   uint32
+	Prime1    uint32
+	Prime2    uint32
+}
+
+// callNCrypt calls an NCrypt function, a non zero SECURITY_STATUS is returned as an error
+func callNCrypt(proc *windows.LazyProc, args ...uintptr) error {
+	if err := proc.Find(); err != nil {
+		return err
+	}
+	status, _, _ := proc.Call(args...)
+	if status != 0 {
+		return fmt.Errorf("%v failed with status 0x%x", proc.Name, uint32(status))
+	}
+	return nil
+}
+
+func openPlatformCryptoProvider() (provider uintptr, err error) {
+	name, err := windows.UTF16PtrFromString(platformCryptoProvider)
+	if err != nil {
+		return
+	}
+	err = callNCrypt(procNCryptOpenStorageProvider, uintptr(unsafe.Pointer(&provider)), uintptr(unsafe.Pointer(name)), 0)
+	return
+}
+
+func freeNCryptObject(object uintptr) {
+	procNCryptFreeObject.Call(object)
+}
+
+// openNCryptKey opens the key of a reference, the caller frees the key handle
+func openNCryptKey(reference string) (key uintptr, err error) {
+	if !strings.HasPrefix(reference, ncryptReferencePrefix+ncryptKeyNamePrefix) {
+		return 0, fmt.Errorf("%v is not a platform crypto provider key reference of the agent", reference)
+	}
+	name, err := windows.UTF16PtrFromString(strings.TrimPrefix(reference, ncryptReferencePrefix))
+	if err != nil {
+		return
+	}
+	provider, err := openPlatformCryptoProvider()
+	if err != nil {
+		return
+	}
+	defer freeNCryptObject(provider)
+
+	err = callNCrypt(procNCryptOpenKey, provider, uintptr(unsafe.Pointer(&key)), uintptr(unsafe.Pointer(name)), 0, ncryptMachineKeyFlag|ncryptSilentFlag)
+	return
+}
+
+func (ncryptKeyStore) create() (reference string, err error) {
+	keyName := ncryptKeyNamePrefix + uuid.NewV4().String()
+	name, err := windows.UTF16PtrFromString(keyName)
+	if err != nil {
+		return
+	}
+	algorithm, _ := windows.UTF16PtrFromString("RSA")
+	lengthProperty, _ := windows.UTF16PtrFromString("Length")
+
+	provider, err := openPlatformCryptoProvider()
+	if err != nil {
+		return
+	}
+	defer freeNCryptObject(provider)
+
+	var key uintptr
+	if err = callNCrypt(procNCryptCreatePersistedKey, provider, uintptr(unsafe.Pointer(&key)), uintptr(unsafe.Pointer(algorithm)), uintptr(unsafe.Pointer(name)), 0, ncryptMachineKeyFlag); err != nil {
+		return
+	}
+	length := uint32(rsaKeyLength)
+	if err = callNCrypt(procNCryptSetProperty, key, uintptr(unsafe.Pointer(lengthProperty)), uintptr(unsafe.Pointer(&length)), unsafe.Sizeof(length), ncryptSilentFlag); err == nil {
+		err = callNCrypt(procNCryptFinalizeKey, key, ncryptSilentFlag)
+	}
+	if err != nil {
+		// the key is not persisted until it is finalized, it only has to be freed
+		freeNCryptObject(key)
+		return
+	}
+	freeNCryptObject(key)
+
+	return ncryptReferencePrefix + keyName, nil
+}
+
+func (ncryptKeyStore) publicKey(reference string) (*rsa.PublicKey, error) {
+	key, err := openNCryptKey(reference)
+	if err != nil {
+		return nil, err
+	}
+	defer freeNCryptObject(key)
+
+	blobType, _ := windows.UTF16PtrFromString(rsaPublicBlobType)
+	var size uint32
+	if err = callNCrypt(procNCryptExportKey, key, 0, uintptr(unsafe.Pointer(blobType)), 0, 0, 0, uintptr(unsafe.Pointer(&size)), 0); err != nil {
+		return nil, err
+	}
+	blob := make([]byte, size)
+	if err = callNCrypt(procNCryptExportKey, key, 0, uintptr(unsafe.Pointer(blobType)), 0, uintptr(unsafe.Pointer(&blob[0])), uintptr(size), uintptr(unsafe.Pointer(&size)), 0); err != nil {
+		return nil, err
+	}
+	return parseRsaPublicBlob(blob[:size])
+}
+
+func (ncryptKeyStore) signDigest(reference string, digest []byte) ([]byte, error) {
+	key, err := openNCryptKey(reference)
+	if err != nil {
+		return nil, err
+	}
+	defer freeNCryptObject(key)
+
+	hashAlgorithm, _ := windows.UTF16PtrFromString("SHA256")
+	padding := bcryptPssPaddingInfo{algorithm: hashAlgorithm, saltSize: sha256DigestSize}
+	var size uint32
+	if err = callNCrypt(procNCryptSignHash, key, uintptr(unsafe.Pointer(&padding)), uintptr(unsafe.Pointer(&digest[0])), uintptr(len(digest)), 0, 0, uintptr(unsafe.Pointer(&size)), bcryptPadPss); err != nil {
+		return nil, err
+	}
+	signature := make([]byte, size)
+	if err = callNCrypt(procNCryptSignHash, key, uintptr(unsafe.Pointer(&padding)), uintptr(unsafe.Pointer(&digest[0])), uintptr(len(digest)), uintptr(unsafe.Pointer(&signature[0])), uintptr(size), uintptr(unsafe.Pointer(&size)), bcryptPadPss); err != nil {
+		return nil, err
+	}
+	return signature[:size], nil
+}
+
+func (ncryptKeyStore) delete(reference string) error {
+	key, err := openNCryptKey(reference)
+	if err != nil {
+		return err
+	}
+	// NCryptDeleteKey frees the key handle when it succeeds
+	if err = callNCrypt(procNCryptDeleteKey, key, ncryptSilentFlag); err != nil {
+		freeNCryptObject(key)
+	}
+	return err
+}
+
+// parseRsaPublicBlob parses a BCRYPT_RSAKEY_BLOB holding a public key, the exponent and the modulus are big endian
+func parseRsaPublicBlob(blob []byte) (*rsa.PublicKey, error) {
+	var header bcryptRsaKeyBlobHeader
+	headerSize := int(unsafe.Sizeof(header))
+	if len(blob) < headerSize {
+		return nil, fmt.Errorf("RSA public key blob is too short")
+	}
+	header.Magic = binary.LittleEndian.Uint32(blob[0:])
+	header.BitLength = binary.LittleEndian.Uint32(blob[4:])
+	header.PublicExp = binary.LittleEndian.Uint32(blob[8:])
+	header.Modulus = binary.LittleEndian.Uint32(blob[12:])
+	if len(blob) < headerSize+int(header.PublicExp)+int(header.Modulus) {
+		return nil, fmt.Errorf("RSA public key blob is too short")
+	}
+	exponent := new(big.Int).SetBytes(blob[headerSize : headerSize+int(header.PublicExp)])
+	modulus := new(big.Int).SetBytes(blob[headerSize+int(header.PublicExp) : headerSize+int(header.PublicExp)+int(header.Modulus)])
+	if !exponent.IsInt64() || exponent.Int64() > int64(^uint32(0)>>1) {
+		return nil, fmt.Errorf("RSA public exponent is out of range")
+	}
+	return &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package auth
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+)
+
+// validationMessage is signed by the new keys to validate them before they are used
+const validationMessage = "amazon-ssm-agent key validation"
+
+// Key is a private key the managed instances sign their requests with
+type Key interface {
+	EncodePublicKey() (string, error)
+	EncodePrivateKey() (string, error)
+	KeyType() string
+	Sign(message string) (string, error)
+	VerifySignature(message string, signature string) error
+}
+
+// KeyType returns the type of the key
+func (rsaKey *RsaKey) KeyType() string {
+	return KeyType
+}
+
+// IsSupportedKeyType returns true if keys of the given type can be created
+func IsSupportedKeyType(keyType string) bool {
+	return keyType == KeyType || keyType == EcdsaKeyType
+}
+
+// CreateKey creates a new keypair of the given key type
+func CreateKey(keyType string) (Key, error) {
+	switch keyType {
+	case KeyType:
+		rsaKey, err := CreateKeypair()
+		return &rsaKey, err
+	case EcdsaKeyType:
+		ecdsaKey, err := CreateEcdsaKeypair()
+		return &ecdsaKey, err
+	default:
+		return nil, fmt.Errorf("unsupported key type %v", keyType)
+	}
+}
+
+// DecodeKey decodes a private key of any supported type from a base 64 DER encoded string,
+// the references of the keys kept in hardware open the key in the secure element
+func DecodeKey(privateKey string) (Key, error) {
+	if IsHardwareKey(privateKey) {
+		return openHardwareKey(privateKey)
+	}
+	privateKeyBytes, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if rsaPrivateKey, err := x509.ParsePKCS1PrivateKey(privateKeyBytes); err == nil {
+		return &RsaKey{privateKey: rsaPrivateKey}, nil
+	}
+	ecdsaPrivateKey, err := x509.ParseECPrivateKey(privateKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("private key is neither a PKCS1 RSA key nor a SEC 1 EC key")
+	}
+	return &EcdsaKey{privateKey: ecdsaPrivateKey}, nil
+}
+
+// ValidateKey checks that the key can sign a message and verify the signature and that it survives encoding
+func ValidateKey(key Key) error {
+	encodedPrivateKey, err := key.EncodePrivateKey()
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %v", err)
+	}
+	decodedKey, err := DecodeKey(encodedPrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to decode private key: %v", err)
+	}
+	if decodedKey.KeyType() != key.KeyType() {
+		return fmt.Errorf("private key decoded as %v instead of %v", decodedKey.KeyType(), key.KeyType())
+	}
+
+	signature, err := decodedKey.Sign(validationMessage)
+	if err != nil {
+		return fmt.Errorf("failed to sign with the private key: %v", err)
+	}
+	if err = key.VerifySignature(validationMessage, signature); err != nil {
+		return fmt.Errorf("failed to verify the signature of the private key: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAndDecodeKeys(t *testing.T) {
+	for _, keyType := range []string{KeyType, EcdsaKeyType} {
+		key, err := CreateKey(keyType)
+		assert.NoError(t, err)
+		assert.Equal(t, keyType, key.KeyType())
+		assert.NoError(t, ValidateKey(key))
+
+		encodedKey, err := key.EncodePrivateKey()
+		assert.NoError(t, err)
+		decodedKey, err := DecodeKey(encodedKey)
+		assert.NoError(t, err)
+		assert.Equal(t, keyType, decodedKey.KeyType())
+
+		publicKey, _ := key.EncodePublicKey()
+		decodedPublicKey, _ := decodedKey.EncodePublicKey()
+		assert.Equal(t, publicKey, decodedPublicKey)
+	}
+}
+
+func TestCreateKeyUnsupportedType(t *testing.T) {
+	_, err := CreateKey("Dsa")
+	assert.Error(t, err)
+	assert.False(t, IsSupportedKeyType("Dsa"))
+}
+
+func TestDecodeKeyInvalid(t *testing.T) {
+	_, err := DecodeKey("bm90IGEga2V5")
+	assert.Error(t, err)
+}
+
+func TestEcdsaSignVerifyDifferentStrings(t *testing.T) {
+	key, err := CreateEcdsaKeypair()
+	assert.NoError(t, err)
+
+	signature, err := key.Sign("This is a test string to sign")
+	assert.NoError(t, err)
+
+	assert.EqualError(t, key.VerifySignature("This is a different test string to verify", signature), "crypto/ecdsa: verification error")
+}
@@ -26,8 +26,12 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/managedInstances/auth"
 	"github.com/aws/amazon-ssm-agent/agent/managedInstances/fingerprint"
+	"github.com/aws/amazon-ssm-agent/agent/statefile"
 )
 
+// Schema is the format of the instance info stored in the vault
+var Schema = statefile.Schema{Name: "registration", VersionField: "stateVersion"}
+
 type instanceInfo struct {
 	InstanceID            string `json:"instanceID"`
 	Region                string `json:"region"`
@@ -36,6 +40,8 @@ type instanceInfo struct {
 	PrivateKey            string `json:"privateKey"`
 	PrivateKeyType        string `json:"privateKeyType"`
 	PrivateKeyCreatedDate string `json:"privateKeyCreatedDate"`
+	// KeyRotationHistory holds the last rotations of the private key, the oldest first
+	KeyRotationHistory []KeyRotationRecord `json:"keyRotationHistory,omitempty"`
 }
 
 var (
@@ -94,10 +100,27 @@ func HasManagedInstancesCredentials(log log.T, manifestFileNamePrefix, vaultKey
 // UpdatePrivateKey saves the private key into the registration persistence store
 func UpdatePrivateKey(log log.T, privateKey, privateKeyType, manifestFileNamePrefix, vaultKey string) (err error) {
 	info := getInstanceInfo(log, manifestFileNamePrefix, vaultKey)
+	oldPrivateKey := info.PrivateKey
 	info.PrivateKey = privateKey
 	info.PrivateKeyType = privateKeyType
 	info.PrivateKeyCreatedDate = time.Now().Format(defaultDateStringFormat)
-	return updateServerInfo(info, "", vaultKey)
+	if err = updateServerInfo(info, "", vaultKey); err != nil {
+		return
+	}
+
+	// the previous key is no longer referenced, the secure element can release it
+	if oldPrivateKey != privateKey {
+		ReleasePrivateKey(log, oldPrivateKey)
+	}
+	return
+}
+
+// ReleasePrivateKey deletes the secure element key referenced by a private key kept in hardware,
+// nothing is done for the private keys kept in the registration information
+func ReleasePrivateKey(log log.T, privateKey string) {
+	if err := auth.DeleteKey(privateKey); err != nil {
+		log.Warnf("Failed to release hardware private key: %v", err)
+	}
 }
 
 // ShouldRotatePrivateKey returns true if serviceSaysRotate or private key has surpassed privateKeyMaxDaysAge
@@ -133,13 +156,13 @@ func ShouldRotatePrivateKey(log log.T, executableToRotateKey string, privateKeyM
 }
 
 func GeneratePublicKey(privateKey string) (publicKey string, err error) {
-	var rsaKey auth.RsaKey
-	rsaKey, err = auth.DecodePrivateKey(privateKey)
+	var key auth.Key
+	key, err = auth.DecodeKey(privateKey)
 	if err != nil {
 		return
 	}
 
-	return rsaKey.EncodePublicKey()
+	return key.EncodePublicKey()
 }
 
 // UpdateServerInfo saves the instance info into the registration persistence store
@@ -155,11 +178,17 @@ func UpdateServerInfo(instanceID, region, privateKey, privateKeyType, manifestFi
 	return updateServerInfo(info, manifestFileNamePrefix, vaultKey)
 }
 
-// GenerateKeyPair generate a new keypair
+// GenerateKeyPair generate a new RSA keypair
 func GenerateKeyPair() (publicKey, privateKey, keyType string, err error) {
-	var keyPair auth.RsaKey
+	return GenerateKeyPairOfType(auth.KeyType, auth.KeyStorageFile)
+}
 
-	keyPair, err = auth.CreateKeypair()
+// GenerateKeyPairOfType generate a new keypair of the given key type in the given key storage,
+// the keypair is validated before it is returned
+func GenerateKeyPairOfType(requestedKeyType, keyStorage string) (publicKey, privateKey, keyType string, err error) {
+	var keyPair auth.Key
+
+	keyPair, err = auth.CreateKeyInStorage(requestedKeyType, keyStorage)
 	if err != nil {
 		return
 	}
@@ -169,12 +198,21 @@ func GenerateKeyPair() (publicKey, privateKey, keyType string, err error) {
 		return
 	}
 
+	if err = auth.ValidateKey(keyPair); err != nil {
+		err = fmt.Errorf("generated %v key is invalid: %v", requestedKeyType, err)
+		if deleteErr := auth.DeleteKey(privateKey); deleteErr != nil {
+			err = fmt.Errorf("%v, failed to release the key: %v", err, deleteErr)
+		}
+		privateKey = ""
+		return
+	}
+
 	publicKey, err = keyPair.EncodePublicKey()
 	if err != nil {
 		return
 	}
 
-	keyType = auth.KeyType
+	keyType = keyPair.KeyType()
 	return
 }
 
@@ -186,6 +224,9 @@ func updateServerInfo(info instanceInfo, manifestFileNamePrefix, vaultKey string
 	if data, err = json.Marshal(info); err != nil {
 		return fmt.Errorf("failed to marshal instance info. %v", err)
 	}
+	if data, err = Schema.Stamp(data); err != nil {
+		return fmt.Errorf("failed to marshal instance info. %v", err)
+	}
 
 	//call vault apis here and update the refId
 	if err = vault.Store(manifestFileNamePrefix, vaultKey, data); err != nil {
@@ -212,6 +253,9 @@ func loadServerInfo(manifestFileNamePrefix, vaultKey string) (loadErr error) {
 	if d, err := vault.Retrieve(manifestFileNamePrefix, vaultKey); err != nil {
 		return fmt.Errorf("Failed to load instance info from vault. %v", err)
 	} else {
+		if d, _, err = Schema.Upgrade(d); err != nil {
+			return fmt.Errorf("Failed to upgrade instance info. %v", err)
+		}
 		if err = json.Unmarshal(d, &info); err != nil {
 			return fmt.Errorf("Failed to unmarshal instance info. %v", err)
 		}
@@ -223,6 +267,30 @@ func loadServerInfo(manifestFileNamePrefix, vaultKey string) (loadErr error) {
 	return nil
 }
 
+// UpgradeInstanceInfo stores the instance info in the current format when it was stored by a previous agent
+func UpgradeInstanceInfo(log log.T, manifestFileNamePrefix, vaultKey string) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if !vault.IsManifestExists(manifestFileNamePrefix) {
+		return nil
+	}
+	data, err := vault.Retrieve(manifestFileNamePrefix, vaultKey)
+	if err != nil {
+		// the manifest may not hold this key
+		return nil
+	}
+	upgraded, version, err := Schema.Upgrade(data)
+	if err != nil || version >= Schema.Version() {
+		return err
+	}
+	if err = vault.Store(manifestFileNamePrefix, vaultKey, upgraded); err != nil {
+		return fmt.Errorf("failed to store instance info in vault. %v", err)
+	}
+	log.Infof("Upgraded %v instance info from version %d to %d", vaultKey, version, Schema.Version())
+	return nil
+}
+
 func getInstanceInfo(log log.T, manifestFileNamePrefix, vaultKey string) instanceInfo {
 	if loadedServerInfo.InstanceID == "" || loadedServerManifestPrefix != manifestFileNamePrefix || loadedServerInfoKey != vaultKey {
 		if err := loadServerInfo(manifestFileNamePrefix, vaultKey); err != nil {
@@ -251,11 +319,15 @@ type IOnpremRegistrationInfo interface {
 	PrivateKeyType(log.T, string, string) string
 	Fingerprint(log.T) (string, error)
 	GenerateKeyPair() (string, string, string, error)
+	GenerateKeyPairOfType(string, string) (string, string, string, error)
 	UpdatePrivateKey(log.T, string, string, string, string) error
+	ReleasePrivateKey(log.T, string)
 	HasManagedInstancesCredentials(log.T, string, string) bool
 	GeneratePublicKey(string) (string, error)
 	ShouldRotatePrivateKey(log.T, string, int, bool, string, string) (bool, error)
 	ReloadInstanceInfo(log.T, string, string)
+	KeyRotationHistory(log.T, string, string) []KeyRotationRecord
+	AppendKeyRotationRecord(log.T, KeyRotationRecord, string, string) error
 }
 
 type onpremRegistation struct{}
@@ -288,6 +360,16 @@ func (onpremRegistation) GenerateKeyPair() (publicKey, privateKey, keyType strin
 	return GenerateKeyPair()
 }
 
+// GenerateKeyPairOfType generate a new keypair of the given key type in the given key storage
+func (onpremRegistation) GenerateKeyPairOfType(keyType, keyStorage string) (publicKey, privateKey, newKeyType string, err error) {
+	return GenerateKeyPairOfType(keyType, keyStorage)
+}
+
+// ReleasePrivateKey deletes the secure element key referenced by a private key kept in hardware
+func (onpremRegistation) ReleasePrivateKey(log log.T, privateKey string) {
+	ReleasePrivateKey(log, privateKey)
+}
+
 // UpdatePrivateKey saves the private key into the registration persistence store
 func (onpremRegistation) UpdatePrivateKey(log log.T, privateKey, privateKeyType, manifestFileNamePrefix, vaultKey string) (err error) {
 	return UpdatePrivateKey(log, privateKey, privateKeyType, manifestFileNamePrefix, vaultKey)
@@ -312,3 +394,13 @@ func (onpremRegistation) GeneratePublicKey(privateKey string) (string, error) {
 func (onpremRegistation) ReloadInstanceInfo(log log.T, manifestFileNamePrefix string, vaultKey string) {
 	ReloadInstanceInfo(log, manifestFileNamePrefix, vaultKey)
 }
+
+// KeyRotationHistory returns the last rotations of the private key
+func (onpremRegistation) KeyRotationHistory(log log.T, manifestFileNamePrefix, vaultKey string) []KeyRotationRecord {
+	return KeyRotationHistory(log, manifestFileNamePrefix, vaultKey)
+}
+
+// AppendKeyRotationRecord adds a private key rotation to the registration persistence store
+func (onpremRegistation) AppendKeyRotationRecord(log log.T, record KeyRotationRecord, manifestFileNamePrefix, vaultKey string) error {
+	return AppendKeyRotationRecord(log, record, manifestFileNamePrefix, vaultKey)
+}
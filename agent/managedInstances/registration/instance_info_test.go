@@ -85,6 +85,39 @@ func TestGeneratePublicKey(t *testing.T) {
 	assert.Equal(t, p1, p2)
 }
 
+func TestGeneratePublicKeyEcdsa(t *testing.T) {
+	p1, privateKey, keyType, err := GenerateKeyPairOfType("Ecdsa", "File")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ecdsa", keyType)
+
+	p2, err := GeneratePublicKey(privateKey)
+	assert.NoError(t, err)
+	assert.Equal(t, p1, p2)
+	assert.Len(t, PublicKeyFingerprint(p1), 64)
+}
+
+func TestGenerateKeyPairOfUnsupportedType(t *testing.T) {
+	_, _, _, err := GenerateKeyPairOfType("Dsa", "File")
+	assert.Error(t, err)
+}
+
+func TestAppendKeyRotationRecord(t *testing.T) {
+	vault = vaultStub{rKey: sampleRegistrationKey, data: sampleJson, exists: true}
+	loadServerInfo("", RegVaultKey)
+
+	start := time.Now()
+	for i := 0; i < maxKeyRotationHistory+2; i++ {
+		record := KeyRotationRecord{Time: start.Add(time.Duration(i) * time.Minute), Reason: KeyRotationReasonKeyAge, Status: KeyRotationSucceeded}
+		assert.NoError(t, AppendKeyRotationRecord(log.NewMockLog(), record, "", RegVaultKey))
+	}
+
+	history := KeyRotationHistory(log.NewMockLog(), "", RegVaultKey)
+	assert.Len(t, history, maxKeyRotationHistory)
+	assert.True(t, history[0].Time.Equal(start.Add(2*time.Minute)))
+	assert.True(t, history[maxKeyRotationHistory-1].Time.Equal(start.Add((maxKeyRotationHistory+1)*time.Minute)))
+	assert.Equal(t, sampleID, InstanceID(log.NewMockLog(), "", RegVaultKey))
+}
+
 func TestShouldRotatePrivateKey(t *testing.T) {
 	var rotate bool
 	var err error
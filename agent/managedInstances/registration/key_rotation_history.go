@@ -0,0 +1,77 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package registration
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// KeyRotationSucceeded is the status of a rotation that replaced the private key
+	KeyRotationSucceeded = "Succeeded"
+	// KeyRotationFailed is the status of a rotation that kept the previous private key
+	KeyRotationFailed = "Failed"
+
+	// KeyRotationReasonServiceRequest is the reason of a rotation requested by Systems Manager
+	KeyRotationReasonServiceRequest = "ServiceRequest"
+	// KeyRotationReasonKeyAge is the reason of a rotation of a private key older than the rotation interval
+	KeyRotationReasonKeyAge = "KeyAge"
+
+	// maxKeyRotationHistory is the number of rotations kept in the history
+	maxKeyRotationHistory = 20
+)
+
+// KeyRotationRecord describes an attempt to rotate the private key of the managed instance
+type KeyRotationRecord struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+	Status string    `json:"status"`
+	// KeyType and PublicKeyFingerprint describe the key the rotation registered, or tried to register
+	KeyType              string `json:"keyType,omitempty"`
+	PublicKeyFingerprint string `json:"publicKeyFingerprint,omitempty"`
+	Error                string `json:"error,omitempty"`
+}
+
+// PublicKeyFingerprint returns the hex encoded SHA-256 hash of a base 64 DER encoded public key
+func PublicKeyFingerprint(publicKey string) string {
+	publicKeyBytes, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(publicKeyBytes) == 0 {
+		return ""
+	}
+	hash := sha256.Sum256(publicKeyBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// KeyRotationHistory returns the last rotations of the private key, the oldest first
+func KeyRotationHistory(log log.T, manifestFileNamePrefix, vaultKey string) []KeyRotationRecord {
+	instance := getInstanceInfo(log, manifestFileNamePrefix, vaultKey)
+	return instance.KeyRotationHistory
+}
+
+// AppendKeyRotationRecord adds a private key rotation to the registration persistence store,
+// only the last maxKeyRotationHistory rotations are kept
+func AppendKeyRotationRecord(log log.T, record KeyRotationRecord, manifestFileNamePrefix, vaultKey string) error {
+	info := getInstanceInfo(log, manifestFileNamePrefix, vaultKey)
+	history := append(append([]KeyRotationRecord{}, info.KeyRotationHistory...), record)
+	if len(history) > maxKeyRotationHistory {
+		history = history[len(history)-maxKeyRotationHistory:]
+	}
+	info.KeyRotationHistory = history
+	return updateServerInfo(info, manifestFileNamePrefix, vaultKey)
+}
@@ -6,6 +6,8 @@ import (
 	log "github.com/aws/amazon-ssm-agent/agent/log"
 	mock "github.com/stretchr/testify/mock"
 
+	registration "github.com/aws/amazon-ssm-agent/agent/managedInstances/registration"
+
 	testing "testing"
 )
 
@@ -14,6 +16,20 @@ type IOnpremRegistrationInfo struct {
 	mock.Mock
 }
 
+// AppendKeyRotationRecord provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *IOnpremRegistrationInfo) AppendKeyRotationRecord(_a0 log.T, _a1 registration.KeyRotationRecord, _a2 string, _a3 string) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(log.T, registration.KeyRotationRecord, string, string) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Fingerprint provides a mock function with given fields: _a0
 func (_m *IOnpremRegistrationInfo) Fingerprint(_a0 log.T) (string, error) {
 	ret := _m.Called(_a0)
@@ -70,6 +86,41 @@ func (_m *IOnpremRegistrationInfo) GenerateKeyPair() (string, string, string, er
 	return r0, r1, r2, r3
 }
 
+// GenerateKeyPairOfType provides a mock function with given fields: _a0, _a1
+func (_m *IOnpremRegistrationInfo) GenerateKeyPairOfType(_a0 string, _a1 string) (string, string, string, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, string) string); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 string
+	if rf, ok := ret.Get(2).(func(string, string) string); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Get(2).(string)
+	}
+
+	var r3 error
+	if rf, ok := ret.Get(3).(func(string, string) error); ok {
+		r3 = rf(_a0, _a1)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
 // GeneratePublicKey provides a mock function with given fields: _a0
 func (_m *IOnpremRegistrationInfo) GeneratePublicKey(_a0 string) (string, error) {
 	ret := _m.Called(_a0)
@@ -119,6 +170,22 @@ func (_m *IOnpremRegistrationInfo) InstanceID(_a0 log.T, _a1 string, _a2 string)
 	return r0
 }
 
+// KeyRotationHistory provides a mock function with given fields: _a0, _a1, _a2
+func (_m *IOnpremRegistrationInfo) KeyRotationHistory(_a0 log.T, _a1 string, _a2 string) []registration.KeyRotationRecord {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []registration.KeyRotationRecord
+	if rf, ok := ret.Get(0).(func(log.T, string, string) []registration.KeyRotationRecord); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]registration.KeyRotationRecord)
+		}
+	}
+
+	return r0
+}
+
 // PrivateKey provides a mock function with given fields: _a0, _a1, _a2
 func (_m *IOnpremRegistrationInfo) PrivateKey(_a0 log.T, _a1 string, _a2 string) string {
 	ret := _m.Called(_a0, _a1, _a2)
@@ -161,6 +228,11 @@ func (_m *IOnpremRegistrationInfo) Region(_a0 log.T, _a1 string, _a2 string) str
 	return r0
 }
 
+// ReleasePrivateKey provides a mock function with given fields: _a0, _a1
+func (_m *IOnpremRegistrationInfo) ReleasePrivateKey(_a0 log.T, _a1 string) {
+	_m.Called(_a0, _a1)
+}
+
 // ReloadInstanceInfo provides a mock function with given fields: _a0, _a1, _a2
 func (_m *IOnpremRegistrationInfo) ReloadInstanceInfo(_a0 log.T, _a1 string, _a2 string) {
 	_m.Called(_a0, _a1, _a2)
@@ -0,0 +1,222 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package commandtransport selects the transport the Run Command documents are received from when both MGS and
+// MDS deliver them, following the preference order of the agent configuration and the health of each transport.
+package commandtransport
+
+import (
+	"errors"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+)
+
+// evaluationInterval is the frequency at which the health of the transports is checked
+const evaluationInterval = 30 * time.Second
+
+// HealthProbe returns nil when the transport can deliver documents, or the reason why it cannot
+type HealthProbe func() error
+
+// Selector decides whether the documents received from a transport are processed. The documents delivered by MDS
+// are always processed, MDS delivers them again until they are acknowledged. The documents delivered by MGS are
+// dropped while a transport preferred over MGS is healthy, MDS then delivers them.
+// A nil selector processes the documents of all the transports.
+type Selector struct {
+	context      context.T
+	order        []string
+	configClient runtimeconfig.ICommandTransportRuntimeConfigClient
+	mutex        sync.Mutex
+	probes       map[string]HealthProbe
+	status       runtimeconfig.CommandTransportRuntimeConfig
+	stopChan     chan struct{}
+	startOnce    sync.Once
+	stopOnce     sync.Once
+}
+
+// NewSelector creates a selector following the command transport order of the agent configuration,
+// the default order is used when the configuration has none
+func NewSelector(context context.T) *Selector {
+	configuredOrder := context.AppConfig().Agent.CommandTransportOrder
+	if len(configuredOrder) == 0 {
+		configuredOrder = appconfig.DefaultCommandTransportOrder
+	}
+	var order []string
+	seen := make(map[string]bool)
+	for _, transport := range configuredOrder {
+		if !seen[transport] {
+			seen[transport] = true
+			order = append(order, transport)
+		}
+	}
+	return &Selector{
+		context:      context,
+		order:        order,
+		configClient: runtimeconfig.NewCommandTransportRuntimeConfigClient(),
+		probes:       make(map[string]HealthProbe),
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// IsEnabled returns true if the transport is part of the command transport order
+func (s *Selector) IsEnabled(transport string) bool {
+	if s == nil {
+		return true
+	}
+	for _, name := range s.order {
+		if name == transport {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterHealthProbe registers the function reporting the health of the transport
+func (s *Selector) RegisterHealthProbe(transport string, probe HealthProbe) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.probes[transport] = probe
+}
+
+// Accepts returns true if the documents received from the transport have to be processed
+func (s *Selector) Accepts(transport string) bool {
+	if s == nil {
+		return true
+	}
+	if !s.IsEnabled(transport) {
+		return false
+	}
+	if transport != appconfig.CommandTransportMGS {
+		return true
+	}
+	status := s.evaluate()
+	for _, transportStatus := range status.Transports {
+		if transportStatus.Name == transport {
+			return true
+		}
+		if transportStatus.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Start checks the health of the transports periodically to keep the command transport status up to date
+func (s *Selector) Start() {
+	if s == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.evaluate()
+		go s.evaluationLoop()
+	})
+}
+
+// Stop stops checking the health of the transports
+func (s *Selector) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() { close(s.stopChan) })
+}
+
+func (s *Selector) evaluationLoop() {
+	log := s.context.Log()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Command transport evaluation panic: %v", r)
+			log.Errorf("Stacktrace:\n%s", debug.Stack())
+		}
+	}()
+	ticker := time.NewTicker(evaluationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.evaluate()
+		}
+	}
+}
+
+// evaluate checks the health of the transports, the active transport is the first healthy transport of the order.
+// The status is logged and saved for ssm-cli when it changes.
+func (s *Selector) evaluate() runtimeconfig.CommandTransportRuntimeConfig {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	status := runtimeconfig.CommandTransportRuntimeConfig{Order: s.order}
+	for _, transport := range s.order {
+		transportStatus := runtimeconfig.CommandTransportStatus{Name: transport, Healthy: true}
+		probe, found := s.probes[transport]
+		if !found {
+			probe = func() error { return errors.New("the transport is not running") }
+		}
+		if err := probe(); err != nil {
+			transportStatus.Healthy = false
+			transportStatus.Reason = err.Error()
+		}
+		status.Transports = append(status.Transports, transportStatus)
+	}
+	status.ActiveTransport, status.Reason = activeTransport(status.Transports)
+
+	if !sameStatus(s.status, status) {
+		status.UpdatedAt = time.Now().UTC()
+		s.context.Log().Infof("Receiving Run Command documents from %v: %v", status.ActiveTransport, status.Reason)
+		if err := s.configClient.SaveConfig(status); err != nil {
+			s.context.Log().Warnf("Failed to save the command transport status: %v", err)
+		}
+		s.status = status
+	}
+	return s.status
+}
+
+// activeTransport returns the first healthy transport and why it is used
+func activeTransport(transports []runtimeconfig.CommandTransportStatus) (string, string) {
+	var unhealthy []string
+	for _, transport := range transports {
+		if transport.Healthy {
+			if len(unhealthy) == 0 {
+				return transport.Name, "preferred transport"
+			}
+			return transport.Name, "fallback because " + strings.Join(unhealthy, ", ")
+		}
+		unhealthy = append(unhealthy, transport.Name+" is unhealthy: "+transport.Reason)
+	}
+	if len(transports) == 0 {
+		return "", "no transport is configured"
+	}
+	return transports[0].Name, "no transport is healthy, " + strings.Join(unhealthy, ", ")
+}
+
+// sameStatus returns true if the transports and their health did not change
+func sameStatus(previous, current runtimeconfig.CommandTransportRuntimeConfig) bool {
+	if previous.ActiveTransport != current.ActiveTransport || len(previous.Transports) != len(current.Transports) {
+		return false
+	}
+	for i := range previous.Transports {
+		if previous.Transports[i] != current.Transports[i] {
+			return false
+		}
+	}
+	return true
+}
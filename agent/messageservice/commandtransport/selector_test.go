@@ -0,0 +1,130 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package commandtransport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConfigClient keeps the saved command transport status in memory
+type fakeConfigClient struct {
+	saved []runtimeconfig.CommandTransportRuntimeConfig
+}
+
+func (c *fakeConfigClient) ConfigExists() (bool, error) {
+	return len(c.saved) > 0, nil
+}
+
+func (c *fakeConfigClient) GetConfig() (runtimeconfig.CommandTransportRuntimeConfig, error) {
+	if len(c.saved) == 0 {
+		return runtimeconfig.CommandTransportRuntimeConfig{}, errors.New("no config")
+	}
+	return c.saved[len(c.saved)-1], nil
+}
+
+func (c *fakeConfigClient) SaveConfig(config runtimeconfig.CommandTransportRuntimeConfig) error {
+	c.saved = append(c.saved, config)
+	return nil
+}
+
+func newTestSelector(order ...string) (*Selector, *fakeConfigClient) {
+	config := appconfig.DefaultConfig()
+	config.Agent.CommandTransportOrder = order
+	selector := NewSelector(contextmocks.NewMockDefaultWithConfig(config))
+	configClient := &fakeConfigClient{}
+	selector.configClient = configClient
+	return selector, configClient
+}
+
+func healthy() error {
+	return nil
+}
+
+func unhealthy() error {
+	return errors.New("connection lost")
+}
+
+func TestSelector_NilAcceptsAllTransports(t *testing.T) {
+	var selector *Selector
+	selector.RegisterHealthProbe(appconfig.CommandTransportMGS, healthy)
+	assert.True(t, selector.IsEnabled(appconfig.CommandTransportMDS))
+	assert.True(t, selector.Accepts(appconfig.CommandTransportMGS))
+	assert.True(t, selector.Accepts(appconfig.CommandTransportMDS))
+}
+
+func TestSelector_MGSFirst(t *testing.T) {
+	selector, configClient := newTestSelector(appconfig.CommandTransportMGS, appconfig.CommandTransportMDS)
+	selector.RegisterHealthProbe(appconfig.CommandTransportMGS, healthy)
+	selector.RegisterHealthProbe(appconfig.CommandTransportMDS, healthy)
+
+	assert.True(t, selector.Accepts(appconfig.CommandTransportMGS))
+	assert.True(t, selector.Accepts(appconfig.CommandTransportMDS))
+	assert.Len(t, configClient.saved, 1)
+	assert.Equal(t, appconfig.CommandTransportMGS, configClient.saved[0].ActiveTransport)
+	assert.Equal(t, "preferred transport", configClient.saved[0].Reason)
+}
+
+func TestSelector_MDSFirstDropsMGSWhileMDSIsHealthy(t *testing.T) {
+	mdsHealth := healthy
+	selector, configClient := newTestSelector(appconfig.CommandTransportMDS, appconfig.CommandTransportMGS)
+	selector.RegisterHealthProbe(appconfig.CommandTransportMGS, healthy)
+	selector.RegisterHealthProbe(appconfig.CommandTransportMDS, func() error { return mdsHealth() })
+
+	assert.False(t, selector.Accepts(appconfig.CommandTransportMGS))
+	assert.True(t, selector.Accepts(appconfig.CommandTransportMDS))
+
+	// MGS takes over while MDS is unhealthy
+	mdsHealth = unhealthy
+	assert.True(t, selector.Accepts(appconfig.CommandTransportMGS))
+	status, _ := configClient.GetConfig()
+	assert.Equal(t, appconfig.CommandTransportMGS, status.ActiveTransport)
+	assert.Equal(t, "fallback because MDS is unhealthy: connection lost", status.Reason)
+	assert.False(t, status.Transports[0].Healthy)
+
+	// and hands back when MDS recovers
+	mdsHealth = healthy
+	assert.False(t, selector.Accepts(appconfig.CommandTransportMGS))
+	assert.Len(t, configClient.saved, 3)
+}
+
+func TestSelector_EmptyOrderUsesDefault(t *testing.T) {
+	selector, _ := newTestSelector()
+	assert.Equal(t, appconfig.DefaultCommandTransportOrder, selector.order)
+}
+
+func TestSelector_TransportNotInOrder(t *testing.T) {
+	selector, _ := newTestSelector(appconfig.CommandTransportMGS, appconfig.CommandTransportMGS)
+	selector.RegisterHealthProbe(appconfig.CommandTransportMGS, unhealthy)
+
+	assert.Equal(t, []string{appconfig.CommandTransportMGS}, selector.order)
+	assert.False(t, selector.IsEnabled(appconfig.CommandTransportMDS))
+	assert.False(t, selector.Accepts(appconfig.CommandTransportMDS))
+	assert.True(t, selector.Accepts(appconfig.CommandTransportMGS))
+}
+
+func TestSelector_NoTransportHealthy(t *testing.T) {
+	selector, configClient := newTestSelector(appconfig.CommandTransportMDS, appconfig.CommandTransportMGS)
+	selector.RegisterHealthProbe(appconfig.CommandTransportMGS, unhealthy)
+
+	assert.True(t, selector.Accepts(appconfig.CommandTransportMGS))
+	status, _ := configClient.GetConfig()
+	assert.Equal(t, appconfig.CommandTransportMDS, status.ActiveTransport)
+	assert.Equal(t, "no transport is healthy, MDS is unhealthy: the transport is not running, MGS is unhealthy: connection lost", status.Reason)
+}
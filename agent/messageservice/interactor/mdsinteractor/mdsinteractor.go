@@ -16,6 +16,7 @@ package mdsinteractor
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"path/filepath"
@@ -27,7 +28,9 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/messagemetrics"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/messageservice/commandtransport"
 	model "github.com/aws/amazon-ssm-agent/agent/messageservice/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/messageservice/interactor"
 	messageHandler "github.com/aws/amazon-ssm-agent/agent/messageservice/messagehandler"
@@ -54,6 +57,9 @@ type MDSInteractor struct {
 	messageHandler       messageHandler.IMessageHandler
 	replyChan            chan contracts.DocumentResult
 	ackSkipCodes         map[messageHandler.ErrorCode]struct{}
+	replyRetryTracker    *utils.FailedReplyRetryTracker
+	pollStrategy         *pollStrategy
+	pollError            error
 }
 
 const (
@@ -69,7 +75,7 @@ const (
 )
 
 // New initiates and returns MDS Interactor when needed
-func New(context context.T, msgHandler messageHandler.IMessageHandler, service mdsService.Service) (interactor.IInteractor, error) {
+func New(context context.T, msgHandler messageHandler.IMessageHandler, service mdsService.Service, transportSelector *commandtransport.Selector) (interactor.IInteractor, error) {
 	mdsContext := context.With("[" + Name + "]")
 	log := mdsContext.Log()
 
@@ -104,7 +110,7 @@ func New(context context.T, msgHandler messageHandler.IMessageHandler, service m
 	stopPolicy := newStopPolicy(Name)
 
 	shortInstanceId, _ := identity.ShortInstanceID()
-	orchestrationRootDir := filepath.Join(appconfig.DefaultDataStorePath, shortInstanceId, appconfig.DefaultDocumentRootDirName, config.Agent.OrchestrationRootDir)
+	orchestrationRootDir := filepath.Join(appconfig.OrchestrationStorePath, shortInstanceId, appconfig.DefaultDocumentRootDirName, config.Agent.OrchestrationRootDir)
 
 	// initialize ack skip code
 	ackSkipCodes := map[messageHandler.ErrorCode]struct{}{
@@ -124,7 +130,11 @@ func New(context context.T, msgHandler messageHandler.IMessageHandler, service m
 		replyChan:            make(chan contracts.DocumentResult),
 		messageHandler:       msgHandler,
 		ackSkipCodes:         ackSkipCodes,
+		replyRetryTracker:    utils.NewFailedReplyRetryTracker(utils.SendFailedReplyFrequencyMinutes * time.Minute),
+		pollStrategy:         newPollStrategy(config.Mds),
+		pollError:            errors.New("no message poll has completed yet"),
 	}
+	transportSelector.RegisterHealthProbe(appconfig.CommandTransportMDS, mdsInteract.commandTransportHealth)
 	// registers reply chan to message handler for receiving replies with UpstreamServiceName as MessageDeliveryService
 	msgHandler.RegisterReply(contracts.MessageDeliveryService, mdsInteract.replyChan)
 	return mdsInteract, nil
@@ -219,6 +229,7 @@ func (mds *MDSInteractor) listenReply() {
 		log.Debugf("start processing reply: %v", result.MessageID)
 		pluginID := result.LastPlugin
 		payloadDoc := utils.PrepareReplyPayloadFromIntermediatePluginResults(mds.context.Log(), pluginID, mds.config.AgentInfo, result.PluginResults)
+		payloadDoc.AdditionalInfo.CorrelationID = result.CorrelationID
 		mds.processSendReply(result.MessageID, payloadDoc)
 		log.Debugf("ended processing reply: %v", result.MessageID)
 	}
@@ -238,8 +249,10 @@ func (mds *MDSInteractor) preDocumentProcessorClose() {
 		mds.sendReplyJob.Quit <- true
 	}
 
-	// Stop any ongoing calls
+	// Stop any ongoing calls and wake up the poll loop waiting for its next poll
 	mds.service.Stop()
+	mds.pollStrategy.stop()
+	mds.updatePollError(errors.New("message polling is stopped"))
 
 	// Wait for ongoing messagePoll loops to terminate
 	log.Debugf("waiting for polling function to return")
@@ -267,10 +280,11 @@ func (mds *MDSInteractor) messagePollLoop() {
 	mds.updateLastPollTime(pollStartTime)
 
 	if err := mds.checkStopPolicy(); err != nil {
+		mds.updatePollError(err)
 		return
 	}
 
-	mds.pollOnce()
+	messagesReceived := mds.pollOnce()
 	log.Debugf("%v's stoppolicy after polling is %v", Name, mds.processorStopPolicy)
 
 	// Slow down a bit in case GetMessages returns
@@ -280,6 +294,15 @@ func (mds *MDSInteractor) messagePollLoop() {
 		time.Sleep(time.Duration(2000+rand.Intn(500)) * time.Millisecond)
 	}
 
+	// wait for the configured poll interval, which grows while the polls receive no message
+	if remaining := mds.pollStrategy.next(messagesReceived) - time.Since(pollStartTime); remaining > 0 {
+		log.Debugf("Next message poll in %v", remaining)
+		if !mds.pollStrategy.wait(remaining) {
+			log.Debug("Message polling stopped")
+			return
+		}
+	}
+
 	// check if any other poll loop has started in the meantime
 	// to prevent any possible race condition due to the scheduler
 	if pollStartTime.Equal(mds.getLastPollTime()) {
@@ -302,6 +325,19 @@ func (mds *MDSInteractor) updateLastPollTime(currentTime time.Time) {
 	mds.lastPollTime = currentTime
 }
 
+// commandTransportHealth returns the error of the last message poll
+func (mds *MDSInteractor) commandTransportHealth() error {
+	mds.mutex.RLock()
+	defer mds.mutex.RUnlock()
+	return mds.pollError
+}
+
+func (mds *MDSInteractor) updatePollError(err error) {
+	mds.mutex.Lock()
+	defer mds.mutex.Unlock()
+	mds.pollError = err
+}
+
 func (mds *MDSInteractor) processMessage(msg *ssmmds.Message) {
 	var (
 		docState *contracts.DocumentState
@@ -316,6 +352,9 @@ func (mds *MDSInteractor) processMessage(msg *ssmmds.Message) {
 		log.Error("message not valid, ignoring: ", err)
 		return
 	}
+	if published, err := time.Parse(time.RFC3339, *msg.CreatedDate); err == nil {
+		messagemetrics.RecordPickup(published)
+	}
 
 	if strings.HasPrefix(*msg.Topic, string(utils.SendCommandTopicPrefix)) {
 		docState, err = utils.ParseSendCommandMessage(mdsContext, toInstanceMessage(msg), mds.orchestrationRootDir, contracts.MessageDeliveryService)
@@ -393,14 +432,15 @@ func (mds *MDSInteractor) checkStopPolicy() (err error) {
 	return
 }
 
-// pollOnce calls GetMessages once and processes the result.
-func (mds *MDSInteractor) pollOnce() {
+// pollOnce calls GetMessages once and processes the result, it returns the number of messages received.
+func (mds *MDSInteractor) pollOnce() int {
 	log := mds.context.Log()
 	log.Debug("Polling for messages")
 	messages, err := mds.service.GetMessages(log, mds.config.InstanceID)
+	mds.updatePollError(err)
 	if err != nil {
 		sdkutil.HandleAwsError(log, err, mds.processorStopPolicy)
-		return
+		return 0
 	}
 
 	if len(messages.Messages) > 0 {
@@ -411,6 +451,7 @@ func (mds *MDSInteractor) pollOnce() {
 		mds.processMessage(msg)
 	}
 	log.Debugf("Finished message poll")
+	return len(messages.Messages)
 }
 
 // loop sends replies to MDS
@@ -444,11 +485,26 @@ func (mds *MDSInteractor) sendFailedReplies() {
 	}
 
 	log.Infof("Found document replies that need to be sent to the service")
+	appConfig := mds.context.AppConfig()
+	maxAge := time.Duration(appConfig.Agent.FailedReplyMaxAgeHours) * time.Hour
+	evictedReplies := make(map[string]struct{})
+	for _, reply := range utils.GetRepliesOverLimit(replies, contracts.MessageDeliveryService, appConfig.Agent.FailedReplyQueueLimit) {
+		log.Warnf("Reply queue limit reached, deleting the oldest reply %v", reply)
+		mds.deleteFailedReply(reply)
+		evictedReplies[reply] = struct{}{}
+	}
 	for _, reply := range replies {
+		if _, evicted := evictedReplies[reply]; evicted {
+			continue
+		}
 		log.Debug("Loading reply ", reply)
-		if utils.IsValidReplyRequest(reply, contracts.MessageDeliveryService) == false {
+		if utils.IsReplyRequestWithinMaxAge(reply, contracts.MessageDeliveryService, maxAge) == false {
 			log.Debug("Reply is old, document execution must have timed out. Deleting the reply")
-			mds.service.DeleteFailedReply(log, reply)
+			mds.deleteFailedReply(reply)
+			continue
+		}
+		if !mds.replyRetryTracker.ShouldRetry(reply) {
+			log.Debugf("Skipping reply %v until its retry backoff expires", reply)
 			continue
 		}
 		sendReplyRequest, err := mds.service.GetFailedReply(log, reply)
@@ -458,15 +514,22 @@ func (mds *MDSInteractor) sendFailedReplies() {
 		}
 
 		log.Info("Sending reply ", reply)
+		mds.replyRetryTracker.RecordAttempt(reply)
 		if err = mds.service.SendReplyWithInput(log, sendReplyRequest); err != nil {
 			sdkutil.HandleAwsError(log, err, mds.processorStopPolicy)
 			break
 		}
 		log.Infof("Sending reply %v succeeded, deleting the reply file from disk", reply)
-		mds.service.DeleteFailedReply(log, reply)
+		mds.deleteFailedReply(reply)
 	}
 }
 
+// deleteFailedReply deletes the reply from disk and stops tracking its retries
+func (mds *MDSInteractor) deleteFailedReply(reply string) {
+	mds.service.DeleteFailedReply(mds.context.Log(), reply)
+	mds.replyRetryTracker.Remove(reply)
+}
+
 func (mds *MDSInteractor) sendDocLevelResponse(messageID string, resultStatus contracts.ResultStatus, documentTraceOutput string) {
 	payloadDoc := utils.PrepareReplyPayloadToUpdateDocumentStatus(mds.config.AgentInfo, resultStatus, documentTraceOutput)
 	mds.processSendReply(messageID, payloadDoc)
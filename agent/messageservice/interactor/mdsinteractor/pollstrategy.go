@@ -0,0 +1,86 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package mdsinteractor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// idlePollBackoffMin is the first interval used when an idle backoff starts from a zero poll interval
+const idlePollBackoffMin = time.Second
+
+// pollStrategy computes the time between the start of two GetMessages calls. When an idle interval is configured
+// the interval doubles after each poll that received no message, up to the idle interval, and goes back to the
+// poll interval as soon as a message is received.
+type pollStrategy struct {
+	interval        time.Duration
+	idleIntervalMax time.Duration
+	current         time.Duration
+	stopChan        chan struct{}
+	stopOnce        sync.Once
+}
+
+// newPollStrategy creates the poll strategy configured for MDS
+func newPollStrategy(config appconfig.MdsCfg) *pollStrategy {
+	interval := time.Duration(config.PollIntervalMillis) * time.Millisecond
+	return &pollStrategy{
+		interval:        interval,
+		idleIntervalMax: time.Duration(config.IdlePollIntervalMaxMillis) * time.Millisecond,
+		current:         interval,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// next returns the time between the start of the last poll and the start of the next one
+func (s *pollStrategy) next(messagesReceived int) time.Duration {
+	if messagesReceived > 0 || s.idleIntervalMax <= s.interval {
+		s.current = s.interval
+		return s.current
+	}
+	next := s.current * 2
+	if next < idlePollBackoffMin {
+		next = idlePollBackoffMin
+	}
+	if next > s.idleIntervalMax {
+		next = s.idleIntervalMax
+	}
+	s.current = next
+	return s.current
+}
+
+// wait sleeps for the given duration, it returns false when the polling is stopped in the meantime
+func (s *pollStrategy) wait(duration time.Duration) bool {
+	if duration <= 0 {
+		return true
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.stopChan:
+		return false
+	}
+}
+
+// stop wakes up the poll loop waiting for its next poll
+func (s *pollStrategy) stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() { close(s.stopChan) })
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package mdsinteractor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPollStrategy_DefaultPollsContinuously(t *testing.T) {
+	strategy := newPollStrategy(appconfig.MdsCfg{})
+	assert.Equal(t, time.Duration(0), strategy.next(0))
+	assert.Equal(t, time.Duration(0), strategy.next(1))
+}
+
+func TestPollStrategy_FixedInterval(t *testing.T) {
+	strategy := newPollStrategy(appconfig.MdsCfg{PollIntervalMillis: 5000})
+	assert.Equal(t, 5*time.Second, strategy.next(0))
+	assert.Equal(t, 5*time.Second, strategy.next(0))
+	assert.Equal(t, 5*time.Second, strategy.next(2))
+}
+
+func TestPollStrategy_IdleBackoff(t *testing.T) {
+	strategy := newPollStrategy(appconfig.MdsCfg{IdlePollIntervalMaxMillis: 5000})
+	assert.Equal(t, time.Second, strategy.next(0))
+	assert.Equal(t, 2*time.Second, strategy.next(0))
+	assert.Equal(t, 4*time.Second, strategy.next(0))
+	assert.Equal(t, 5*time.Second, strategy.next(0))
+	assert.Equal(t, 5*time.Second, strategy.next(0))
+	// a received message resets the interval
+	assert.Equal(t, time.Duration(0), strategy.next(1))
+	assert.Equal(t, time.Second, strategy.next(0))
+
+	strategy = newPollStrategy(appconfig.MdsCfg{PollIntervalMillis: 3000, IdlePollIntervalMaxMillis: 10000})
+	assert.Equal(t, 6*time.Second, strategy.next(0))
+	assert.Equal(t, 10*time.Second, strategy.next(0))
+	assert.Equal(t, 3*time.Second, strategy.next(1))
+}
+
+func TestPollStrategy_StopInterruptsWait(t *testing.T) {
+	strategy := newPollStrategy(appconfig.MdsCfg{})
+	assert.True(t, strategy.wait(time.Millisecond))
+
+	strategy.stop()
+	strategy.stop()
+	assert.False(t, strategy.wait(time.Hour))
+
+	var noStrategy *pollStrategy
+	noStrategy.stop()
+}
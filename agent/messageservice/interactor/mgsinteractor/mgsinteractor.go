@@ -17,6 +17,7 @@ package mgsinteractor
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"path/filepath"
@@ -27,8 +28,10 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/messagemetrics"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/messageservice/commandtransport"
 	"github.com/aws/amazon-ssm-agent/agent/messageservice/interactor"
 	"github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mgsinteractor/replytypes"
 	interactorutils "github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mgsinteractor/utils"
@@ -64,10 +67,11 @@ type MGSInteractor struct {
 	ackSkipCodes             map[messagehandler.ErrorCode]struct{}
 	listenReplyThreadEnded   chan struct{}
 	mutex                    sync.Mutex
+	transportSelector        *commandtransport.Selector
 }
 
 // New initiates and returns MGS Interactor when needed
-func New(context context.T, messageHandler messagehandler.IMessageHandler) (interactor.IInteractor, error) {
+func New(context context.T, messageHandler messagehandler.IMessageHandler, transportSelector *commandtransport.Selector) (interactor.IInteractor, error) {
 	mgsContext := context.With("[" + Name + "]")
 	log := mgsContext.Log()
 	appConfig := context.AppConfig()
@@ -118,6 +122,8 @@ func New(context context.T, messageHandler messagehandler.IMessageHandler) (inte
 		replyThreadDone: make(chan struct{}),
 		reply:           make(chan *agentReplyLocalContract),
 		allReplyClosed:  make(chan struct{}, 1),
+		// persisted replies are retried with exponential backoff starting from the failed reply job frequency
+		replyRetryTracker: utils.NewFailedReplyRetryTracker(utils.SendFailedReplyFrequencyMinutes * time.Minute),
 	}
 
 	mgsInteract := &MGSInteractor{
@@ -130,7 +136,9 @@ func New(context context.T, messageHandler messagehandler.IMessageHandler) (inte
 		sendReplyProp:            sendReplyProp,
 		messageHandler:           messageHandler,
 		replyChan:                make(chan contracts.DocumentResult),
+		transportSelector:        transportSelector,
 	}
+	transportSelector.RegisterHealthProbe(appconfig.CommandTransportMGS, mgsInteract.commandTransportHealth)
 
 	// the below line makes sure that the interactor receives all the replies from documents with
 	// upstream service name as contracts.MessageGatewayService in this replyChan
@@ -185,6 +193,7 @@ func (mgs *MGSInteractor) Initialize() (err error) {
 
 	log.Info("SSM Agent is trying to setup control channel for MGSInteractor")
 	mgs.controlChannel, err = setupControlChannel(mgs.context, mgs.mgsService, mgs.agentConfig.InstanceID, mgs.incomingAgentMessageChan)
+	messagemetrics.RegisterMGSStatus(mgs.status)
 	if err != nil {
 		log.Errorf("Error setting up control channel: %v", err)
 		return err
@@ -218,6 +227,24 @@ func (mgs *MGSInteractor) isChannelOpenForAgentJobMsgs() bool {
 	return mgs.channelOpen
 }
 
+// commandTransportHealth returns the reason why the job messages cannot be received from MGS, if any
+func (mgs *MGSInteractor) commandTransportHealth() error {
+	if !mgs.isChannelOpenForAgentJobMsgs() {
+		return errors.New("the channel is not open for job messages")
+	}
+	if mgs.controlChannel == nil || !mgs.controlChannel.IsConnected() {
+		return errors.New("the control channel is not connected")
+	}
+	return nil
+}
+
+// status returns whether the control channel is connected and the number of replies persisted after a failed
+// delivery, waiting to be sent again to MGS
+func (mgs *MGSInteractor) status() (connected bool, repliesQueued int) {
+	failedReplies, _ := failedReplyFolder(mgs.context).Names()
+	return mgs.controlChannel != nil && mgs.controlChannel.IsConnected(), len(failedReplies)
+}
+
 func (mgs *MGSInteractor) setChannelOpenVal(openVal bool) {
 	mgs.mutex.Lock()
 	defer mgs.mutex.Unlock()
@@ -311,8 +338,10 @@ func (mgs *MGSInteractor) listenIncomingAgentMessages() {
 		log.Infof("Processing AgentMessage: MessageType - %s, Id - %s", agentMessage.MessageType, agentMessage.MessageId)
 		switch agentMessage.MessageType {
 		case mgsContracts.AgentJobMessage:
+			recordPickup(agentMessage)
 			mgs.processAgentJobMessage(agentMessage)
 		case mgsContracts.InteractiveShellMessage, mgsContracts.ChannelClosedMessage:
+			recordPickup(agentMessage)
 			mgs.processSessionRelatedMessages(agentMessage)
 		case mgsContracts.TaskAcknowledgeMessage:
 			mgs.processTaskAcknowledgeMessage(agentMessage)
@@ -329,7 +358,7 @@ func (mgs *MGSInteractor) processSessionRelatedMessages(agentMessage mgsContract
 	blockChan := make(chan struct{})
 	log := mgs.context.Log()
 	shortInstanceId, _ := mgs.context.Identity().ShortInstanceID()
-	sessionOrchestrationRootDir := filepath.Join(appconfig.DefaultDataStorePath, shortInstanceId, appconfig.DefaultSessionRootDirName, appConfig.Agent.OrchestrationRootDir)
+	sessionOrchestrationRootDir := filepath.Join(appconfig.OrchestrationStorePath, shortInstanceId, appconfig.DefaultSessionRootDirName, appConfig.Agent.OrchestrationRootDir)
 	docState, err := agentMessage.ParseAgentMessage(mgs.context, sessionOrchestrationRootDir, mgs.agentConfig.InstanceID)
 	if err != nil {
 		log.Errorf("Cannot parse AgentTask message to documentState: %s, err: %v.", agentMessage.MessageId, err)
@@ -388,8 +417,13 @@ func (mgs *MGSInteractor) processAgentJobMessage(agentMessage mgsContracts.Agent
 		log.Errorf("dropping message because job messages are not supported for containers: %s", agentMessage.MessageId.String())
 		return
 	}
+	// MDS delivers the dropped messages when it is the active command transport
+	if !mgs.transportSelector.Accepts(appconfig.CommandTransportMGS) {
+		log.Infof("dropping message because MGS is not the active command transport: %s", agentMessage.MessageId.String())
+		return
+	}
 	shortInstanceId, _ := mgs.context.Identity().ShortInstanceID()
-	commandOrchestrationRootDir := filepath.Join(appconfig.DefaultDataStorePath, shortInstanceId, appconfig.DefaultDocumentRootDirName, appConfig.Agent.OrchestrationRootDir)
+	commandOrchestrationRootDir := filepath.Join(appconfig.OrchestrationStorePath, shortInstanceId, appconfig.DefaultDocumentRootDirName, appConfig.Agent.OrchestrationRootDir)
 	docState, err := agentMessage.ParseAgentMessage(mgs.context, commandOrchestrationRootDir, mgs.agentConfig.InstanceID)
 	// just dropping all errors - MDS will take care of these messages
 	// we should handle few errors differently in future
@@ -541,6 +575,14 @@ func getMgsEndpoint(context context.T, region string) (string, error) {
 	return endpointBuilder.String(), nil
 }
 
+// recordPickup records the time the message took from its creation by MGS to its processing by the agent
+func recordPickup(agentMessage mgsContracts.AgentMessage) {
+	if agentMessage.CreatedDate == 0 {
+		return
+	}
+	messagemetrics.RecordPickup(time.Unix(0, int64(agentMessage.CreatedDate)*int64(time.Millisecond)))
+}
+
 // convert uint64 to ISO-8601 time stamp
 func toISO8601(createdDate uint64) string {
 	timeVal := time.Unix(0, int64(createdDate)*int64(time.Millisecond)).UTC()
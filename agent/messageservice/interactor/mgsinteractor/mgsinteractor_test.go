@@ -58,7 +58,7 @@ func (suite *MGSInteractorTestSuite) TestInitialize() {
 	mockContext := contextmocks.NewMockDefault()
 	messageHandlerMock := &mocks.IMessageHandler{}
 	messageHandlerMock.On("RegisterReply", mock.Anything, mock.Anything)
-	mgsInteractorRef, err := New(mockContext, messageHandlerMock)
+	mgsInteractorRef, err := New(mockContext, messageHandlerMock, nil)
 	assert.Nil(suite.T(), err, "initialize passed")
 	mgsInteractor := mgsInteractorRef.(*MGSInteractor)
 	defer func() {
@@ -80,7 +80,7 @@ func (suite *MGSInteractorTestSuite) TestListenTaskAcknowledgeMsgDoesExist() {
 	mockContext := contextmocks.NewMockDefault()
 	messageHandlerMock := &mocks.IMessageHandler{}
 	messageHandlerMock.On("RegisterReply", mock.Anything, mock.Anything)
-	mgsInteractorRef, err := New(mockContext, messageHandlerMock)
+	mgsInteractorRef, err := New(mockContext, messageHandlerMock, nil)
 	assert.Nil(suite.T(), err, "initialize passed")
 	mgsInteractor := mgsInteractorRef.(*MGSInteractor)
 	ackChan := make(chan bool, 1)
@@ -105,7 +105,7 @@ func (suite *MGSInteractorTestSuite) TestListenTaskAcknowledgeMsgDoesNotExist()
 	mockContext := contextmocks.NewMockDefault()
 	messageHandlerMock := &mocks.IMessageHandler{}
 	messageHandlerMock.On("RegisterReply", mock.Anything, mock.Anything)
-	mgsInteractorRef, err := New(mockContext, messageHandlerMock)
+	mgsInteractorRef, err := New(mockContext, messageHandlerMock, nil)
 	assert.Nil(suite.T(), err, "initialize passed")
 	mgsInteractor := mgsInteractorRef.(*MGSInteractor)
 	ackChan := make(chan bool, 1)
@@ -135,7 +135,7 @@ func (suite *MGSInteractorTestSuite) TestModuleStopClosingAlreadyClosedChannel()
 	mockContext := contextmocks.NewMockDefault()
 	messageHandlerMock := &mocks.IMessageHandler{}
 	messageHandlerMock.On("RegisterReply", mock.Anything, mock.Anything)
-	mgsInteractorRef, err := New(mockContext, messageHandlerMock)
+	mgsInteractorRef, err := New(mockContext, messageHandlerMock, nil)
 	assert.Nil(suite.T(), err, "initialize passed")
 	mgsInteractor := mgsInteractorRef.(*MGSInteractor)
 	mgsInteractor.listenReplyThreadEnded = make(chan struct{}, 1)
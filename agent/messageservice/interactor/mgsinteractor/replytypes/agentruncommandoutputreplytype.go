@@ -0,0 +1,132 @@
+// Copyright 2021 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing`
+// permissions and limitations under the License.
+
+// Package replytypes will be responsible for handling agent run command output reply type from the processor
+package replytypes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mgsinteractor/utils"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/times"
+	"github.com/twinj/uuid"
+)
+
+// NewAgentRunCommandOutputReplyType returns new Agent Run Command Output reply type
+func NewAgentRunCommandOutputReplyType(ctx context.T, res contracts.DocumentResult, replyId uuid.UUID, retryNumber int) IReplyType {
+	return &AgentRunCommandOutputReplyType{
+		context:               ctx,
+		agentResult:           res,
+		noOfContinuousRetries: 1,
+		backOffSecond:         1,
+		// the output is also part of the plugin results, a lost frame is not sent again
+		shouldPersist: false,
+		replyId:       replyId,
+		retryNumber:   retryNumber,
+	}
+}
+
+// AgentRunCommandOutputReplyType defines methods and properties to handle RunCommandOutputResult
+type AgentRunCommandOutputReplyType struct {
+	context               context.T
+	agentResult           contracts.DocumentResult
+	backOffSecond         int
+	noOfContinuousRetries int
+	shouldPersist         bool
+	replyId               uuid.UUID
+	retryNumber           int
+}
+
+// GetName return name of the reply type
+func (ao *AgentRunCommandOutputReplyType) GetName() contracts.ResultType {
+	return contracts.RunCommandOutputResult
+}
+
+// ConvertToAgentMessage converts result to agent message
+func (ao *AgentRunCommandOutputReplyType) ConvertToAgentMessage() (*mgsContracts.AgentMessage, error) {
+	return ao.constructMessage(&ao.agentResult)
+}
+
+// GetMessageUUID returns message UUID
+// used for logging and persistence in the interactors
+func (ao *AgentRunCommandOutputReplyType) GetMessageUUID() uuid.UUID {
+	return ao.replyId
+}
+
+// GetResult get agent result
+func (ao *AgentRunCommandOutputReplyType) GetResult() contracts.DocumentResult {
+	return ao.agentResult
+}
+
+// GetRetryNumber denotes how many times the message was retried sending
+// this includes only continuous retries
+func (ao *AgentRunCommandOutputReplyType) GetRetryNumber() int {
+	return ao.retryNumber
+}
+
+// ShouldPersistData denotes whether the reply should be persisted
+func (ao *AgentRunCommandOutputReplyType) ShouldPersistData() bool {
+	return ao.shouldPersist
+}
+
+// GetBackOffSecond returns the backoff time to wait till the agent
+func (ao *AgentRunCommandOutputReplyType) GetBackOffSecond() int {
+	return ao.backOffSecond
+}
+
+// GetNumberOfContinuousRetries represents the number of continuous retries needed during send reply failure
+func (ao *AgentRunCommandOutputReplyType) GetNumberOfContinuousRetries() int {
+	return ao.noOfContinuousRetries
+}
+
+// IncrementRetries increment retry number
+func (ao *AgentRunCommandOutputReplyType) IncrementRetries() int {
+	ao.retryNumber++
+	return ao.retryNumber
+}
+
+// constructMessage constructs agent message with the output frame as payload
+func (ao *AgentRunCommandOutputReplyType) constructMessage(result *contracts.DocumentResult) (*mgsContracts.AgentMessage, error) {
+	if result.OutputFrame == nil {
+		return nil, fmt.Errorf("no output frame found in the result of %v", result.MessageID)
+	}
+	appConfig := ao.context.AppConfig()
+	outputPayload := messageContracts.SendOutputPayload{
+		AdditionalInfo: contracts.AdditionalInfo{
+			Agent: contracts.AgentInfo{
+				Lang:      appConfig.Os.Lang,
+				Name:      appConfig.Agent.Name,
+				Version:   appConfig.Agent.Version,
+				Os:        appConfig.Os.Name,
+				OsVersion: appConfig.Os.Version,
+			},
+			DateTime:      times.ToIso8601UTC(time.Now()),
+			CorrelationID: result.CorrelationID,
+		},
+		PluginID:       result.LastPlugin,
+		Sequence:       result.OutputFrame.Sequence,
+		StandardOutput: result.OutputFrame.Stdout,
+		StandardError:  result.OutputFrame.Stderr,
+		Truncated:      result.OutputFrame.Truncated,
+	}
+	if pluginResult, ok := result.PluginResults[result.LastPlugin]; ok {
+		outputPayload.Name = pluginResult.PluginName
+	}
+	commandTopic := utils.GetTopicFromDocResult(result.ResultType, result.RelatedDocumentType)
+	return utils.GenerateAgentJobOutputPayload(ao.context.Log(), ao.replyId, result.MessageID, outputPayload, commandTopic)
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replytypes
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	mgsUtils "github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mgsinteractor/utils"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/twinj/uuid"
+)
+
+type AgentRunCommandOutputReplyTestSuite struct {
+	suite.Suite
+}
+
+// Execute the test suite
+func TestAgentRunCommandOutputReplyTestSuite(t *testing.T) {
+	suite.Run(t, new(AgentRunCommandOutputReplyTestSuite))
+}
+
+func (suite *AgentRunCommandOutputReplyTestSuite) TestAgentRunCommandOutputReply_InitializeSuccess() {
+	ctx := context.NewMockDefault()
+	docResult := contracts.DocumentResult{ResultType: contracts.RunCommandOutputResult}
+	uuid := uuid.NewV4()
+	replyType, err := GetReplyTypeObject(ctx, docResult, uuid, 0)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), contracts.RunCommandOutputResult, replyType.GetName())
+	assert.Equal(suite.T(), uuid.String(), replyType.GetMessageUUID().String())
+	assert.Equal(suite.T(), 1, replyType.GetNumberOfContinuousRetries())
+	assert.Equal(suite.T(), false, replyType.ShouldPersistData())
+	assert.Equal(suite.T(), 0, replyType.GetRetryNumber())
+}
+
+func (suite *AgentRunCommandOutputReplyTestSuite) TestAgentRunCommandOutputReply_AgentMessageGenerationCheck() {
+	ctx := context.NewMockDefault()
+	pluginResults := map[string]*contracts.PluginResult{
+		"step1": {PluginID: "step1", PluginName: "aws:runShellScript", Status: contracts.ResultStatusInProgress},
+	}
+	docResult := contracts.DocumentResult{
+		MessageID:     "messageId",
+		ResultType:    contracts.RunCommandOutputResult,
+		LastPlugin:    "step1",
+		PluginResults: pluginResults,
+		OutputFrame:   &contracts.OutputFrame{Sequence: 3, Stdout: "partial output", Stderr: "warning"},
+	}
+	uuid := uuid.NewV4()
+	agentMessage, err := NewAgentRunCommandOutputReplyType(ctx, docResult, uuid, 0).ConvertToAgentMessage()
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), uuid.String(), agentMessage.MessageId.String())
+	assert.Equal(suite.T(), mgsContracts.AgentJobReply, agentMessage.MessageType)
+
+	replyContent := mgsContracts.AgentJobReplyContent{}
+	err = json.Unmarshal(agentMessage.Payload, &replyContent)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), string(mgsUtils.SendCommandOutputTopic), replyContent.Topic)
+	assert.Equal(suite.T(), "messageId", replyContent.JobId)
+
+	outputPayload := messageContracts.SendOutputPayload{}
+	err = json.Unmarshal([]byte(replyContent.Content), &outputPayload)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "step1", outputPayload.PluginID)
+	assert.Equal(suite.T(), "aws:runShellScript", outputPayload.Name)
+	assert.Equal(suite.T(), 3, outputPayload.Sequence)
+	assert.Equal(suite.T(), "partial output", outputPayload.StandardOutput)
+	assert.Equal(suite.T(), "warning", outputPayload.StandardError)
+}
+
+func (suite *AgentRunCommandOutputReplyTestSuite) TestAgentRunCommandOutputReply_NoOutputFrame() {
+	ctx := context.NewMockDefault()
+	docResult := contracts.DocumentResult{MessageID: "messageId", ResultType: contracts.RunCommandOutputResult}
+	agentMessage, err := NewAgentRunCommandOutputReplyType(ctx, docResult, uuid.NewV4(), 0).ConvertToAgentMessage()
+	assert.NotNil(suite.T(), err)
+	assert.Nil(suite.T(), agentMessage)
+}
@@ -108,6 +108,7 @@ func (ad *AgentRunCommandReplyType) constructMessage(result *contracts.DocumentR
 		OsVersion: appConfig.Os.Version,
 	}
 	replyPayload := runcommand.FormatPayload(log, result.LastPlugin, agentInfo, result.PluginResults)
+	replyPayload.AdditionalInfo.CorrelationID = result.CorrelationID
 	commandTopic := utils.GetTopicFromDocResult(result.ResultType, result.RelatedDocumentType)
 	return utils.GenerateAgentJobReplyPayload(log, ad.replyId, result.MessageID, replyPayload, commandTopic)
 }
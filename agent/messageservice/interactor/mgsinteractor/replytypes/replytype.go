@@ -29,8 +29,10 @@ var replyTypes map[contracts.ResultType]ReplyTypeGenerator
 func init() {
 	runCommandReplyFn := NewAgentRunCommandReplyType
 	sessionCompleteReplyFn := NewSessionCompleteType
+	runCommandOutputReplyFn := NewAgentRunCommandOutputReplyType
 	// In the future, we can register this based on doc type to support different cases
 	registerReplyTypes(contracts.RunCommandResult, runCommandReplyFn)
+	registerReplyTypes(contracts.RunCommandOutputResult, runCommandOutputReplyFn)
 	registerReplyTypes(contracts.SessionResult, sessionCompleteReplyFn) // For now, for all results, we send result with just 1 topic which is session complete
 }
 
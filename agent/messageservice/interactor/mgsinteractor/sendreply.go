@@ -16,20 +16,18 @@ package mgsinteractor
 
 import (
 	"fmt"
-	"os"
-	"path"
 	"runtime/debug"
 	"strings"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
-	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mgsinteractor/replytypes"
 	"github.com/aws/amazon-ssm-agent/agent/messageservice/utils"
-	"github.com/aws/amazon-ssm-agent/common/identity"
+	"github.com/aws/amazon-ssm-agent/agent/statestore"
 	"github.com/carlescere/scheduler"
 	"github.com/gorilla/websocket"
 	"github.com/twinj/uuid"
@@ -39,33 +37,30 @@ const (
 	failedReplyProcessingLimit = 50
 )
 
-var (
-	writeIntoFile = fileutil.WriteIntoFileWithPermissions
-	getFileNames  = fileutil.GetFileNames
-)
+// failedReplyFolder returns the mgs replies folder in the configured state backend
+var failedReplyFolder = func(context context.T) statestore.Folder {
+	shortInstanceID, _ := context.Identity().ShortInstanceID()
+	return statestore.NewFolder(context.AppConfig(), shortInstanceID, appconfig.RepliesMGSRootDirName)
+}
 
-// loadFailedReplies loads failed replies from local mgs replies folder on disk
+// loadFailedReplies loads failed replies from local mgs replies folder
 func (mgs *MGSInteractor) loadFailedReplies(log log.T) []string {
 	log.Debug("Checking MGS Replies folder for failed sent replies")
-	absoluteDirPath := getFailedReplyDirectory(mgs.context.Identity())
-	files, err := getFileNames(absoluteDirPath)
+	files, err := failedReplyFolder(mgs.context).Names()
 	if err != nil {
-		log.Errorf("encountered error %v while listing mgs replies in %v", err, absoluteDirPath)
+		log.Errorf("encountered error %v while listing mgs replies", err)
 	}
 	return files
 }
 
-// deleteFailedReply deletes failed mgs replies from local replies folder on disk
+// deleteFailedReply deletes failed mgs replies from local replies folder
 func (mgs *MGSInteractor) deleteFailedReply(log log.T, fileName string) {
-	absoluteFileName := getFailedReplyLocation(mgs.context.Identity(), fileName)
-	if fileutil.Exists(absoluteFileName) {
-		err := fileutil.DeleteFile(absoluteFileName)
-		if err != nil {
-			log.Errorf("encountered error %v while deleting file %v", err, absoluteFileName)
-		} else {
-			log.Debugf("successfully deleted file %v", absoluteFileName)
-		}
+	if err := failedReplyFolder(mgs.context).Delete(fileName); err != nil {
+		log.Errorf("encountered error %v while deleting reply %v", err, fileName)
+	} else {
+		log.Debugf("successfully deleted reply %v", fileName)
 	}
+	mgs.sendReplyProp.replyRetryTracker.Remove(fileName)
 }
 
 // sendFailedReplies loads replies from local disk and send it again to the service, if it fails no action is needed
@@ -90,7 +85,22 @@ func (mgs *MGSInteractor) sendFailedReplies() {
 	}
 	replyProcessingLimit := failedReplyProcessingLimit
 	log.Info("Found document replies that need to be sent to the service")
+	appConfig := mgs.context.AppConfig()
+	maxAge := time.Duration(appConfig.Agent.FailedReplyMaxAgeHours) * time.Hour
+	evictedReplies := make(map[string]struct{})
+	for _, reply := range utils.GetRepliesOverLimit(replies, contracts.MessageGatewayService, appConfig.Agent.FailedReplyQueueLimit) {
+		log.Warnf("Reply queue limit reached, deleting the oldest reply %v", reply)
+		mgs.deleteFailedReply(log, reply)
+		evictedReplies[reply] = struct{}{}
+	}
 	for _, reply := range replies {
+		if _, evicted := evictedReplies[reply]; evicted {
+			continue
+		}
+		if !mgs.sendReplyProp.replyRetryTracker.ShouldRetry(reply) {
+			log.Debugf("Skipping reply %v until its retry backoff expires", reply)
+			continue
+		}
 		log.Debug("Loading reply ", reply)
 		docPersistData, err := mgs.getFailedReply(log, reply)
 		if err != nil {
@@ -98,7 +108,7 @@ func (mgs *MGSInteractor) sendFailedReplies() {
 			continue
 		}
 		// sending it at least once after the first failure
-		if utils.IsValidReplyRequest(reply, contracts.MessageGatewayService) == false && docPersistData.RetryNumber > 1 {
+		if utils.IsReplyRequestWithinMaxAge(reply, contracts.MessageGatewayService, maxAge) == false && docPersistData.RetryNumber > 1 {
 			log.Debug("Reply is old, document execution must have timed out. Deleting the reply")
 			mgs.deleteFailedReply(log, reply)
 			continue
@@ -124,6 +134,7 @@ func (mgs *MGSInteractor) sendFailedReplies() {
 			break
 		}
 		mgs.sendReplyProp.reply <- agentReplyContract
+		mgs.sendReplyProp.replyRetryTracker.RecordAttempt(reply)
 		replyProcessingLimit--
 		if replyProcessingLimit == 0 {
 			log.Infof("failed reply processing ended")
@@ -161,28 +172,25 @@ func (mgs *MGSInteractor) closeSendFailedReplyJob() {
 // getFailedReply load documentResultPersistData object from replies folder given the message id of the object
 func (mgs *MGSInteractor) getFailedReply(log log.T, fileName string) (*AgentResultLocalStoreData, error) {
 	var sendReply AgentResultLocalStoreData
-	absoluteFileName := getFailedReplyLocation(mgs.context.Identity(), fileName)
-	err := jsonutil.UnmarshalFile(absoluteFileName, &sendReply)
+	content, err := failedReplyFolder(mgs.context).Read(fileName)
+	if err == nil {
+		err = jsonutil.Unmarshal(string(content), &sendReply)
+	}
 	if err != nil {
-		log.Errorf("encountered error with message %v while reading reply input from file - %v", err, absoluteFileName)
+		log.Errorf("encountered error with message %v while reading reply input - %v", err, fileName)
 	} else {
-		//logging reply as read from the file
+		//logging reply as read from the replies folder
 		jsonString, err := jsonutil.Marshal(sendReply)
 		if err != nil {
 			log.Errorf("encountered error with message %v while marshalling %v to string", err, sendReply)
 		} else {
-			log.Tracef("Send reply input read from file-system - %v", jsonutil.Indent(jsonString))
+			log.Tracef("Send reply input read from replies folder - %v", jsonutil.Indent(jsonString))
 		}
 	}
 	return &sendReply, err
 }
 
-// getFailedReplyLocation returns path to reply file
-func getFailedReplyLocation(identity identity.IAgentIdentity, fileName string) string {
-	return path.Join(getFailedReplyDirectory(identity), fileName)
-}
-
-// persistResult saves agent message in the local disk
+// persistResult saves agent message in the local replies folder
 func (mgs *MGSInteractor) persistResult(replyBytes AgentResultLocalStoreData) (err error) {
 	log := mgs.context.Log()
 	log.Debugf("persisting result %+v", replyBytes)
@@ -190,7 +198,8 @@ func (mgs *MGSInteractor) persistResult(replyBytes AgentResultLocalStoreData) (e
 	if err != nil {
 		log.Errorf("encountered error with message %v while marshalling %v to string", err)
 	} else {
-		files, _ := getFileNames(getFailedReplyDirectory(mgs.context.Identity()))
+		folder := failedReplyFolder(mgs.context)
+		files, _ := folder.Names()
 		persistTime := time.Now().UTC()
 		fileName := fmt.Sprintf("%v_%v", persistTime.Format("2006-01-02T15-04-05"), replyBytes.ReplyId) //changing the format a bit from MDS replies to support proper sorting
 		for fileIndex := len(files) - 1; fileIndex >= 0; fileIndex-- {
@@ -201,25 +210,16 @@ func (mgs *MGSInteractor) persistResult(replyBytes AgentResultLocalStoreData) (e
 				break
 			}
 		}
-		absoluteFileName := getFailedReplyLocation(mgs.context.Identity(), fileName)
-		log.Tracef("persisting reply %v in file %v", jsonutil.Indent(content), absoluteFileName)
-		if s, err := writeIntoFile(absoluteFileName, jsonutil.Indent(content), os.FileMode(appconfig.ReadWriteAccess)); s && err == nil {
-			log.Debugf("successfully persisted reply in %v", absoluteFileName)
+		log.Tracef("persisting reply %v in %v", jsonutil.Indent(content), fileName)
+		if err := folder.Write(fileName, []byte(jsonutil.Indent(content))); err == nil {
+			log.Debugf("successfully persisted reply in %v", fileName)
 		} else {
-			log.Debugf("persisting reply in %v failed with error %v", absoluteFileName, err)
+			log.Debugf("persisting reply in %v failed with error %v", fileName, err)
 		}
 	}
 	return err
 }
 
-// getFailedReplyDirectory returns path to mgs replies folder
-func getFailedReplyDirectory(identity identity.IAgentIdentity) string {
-	shortInstanceID, _ := identity.ShortInstanceID()
-	return path.Join(appconfig.DefaultDataStorePath,
-		shortInstanceID,
-		appconfig.RepliesMGSRootDirName)
-}
-
 // processReply processes the reply received from the reply queue
 func (mgs *MGSInteractor) processReply(result *agentReplyLocalContract) {
 	// send reply
@@ -246,7 +246,10 @@ externalLoop:
 		}
 		if mgs.isTempError(err) { // do not retry or wait when we see these errors
 			log.Debugf("skipping wait after send reply due to the following temporary error %v", err)
-			mgs.persistResult(persist)
+			// output frames are only relevant while the plugin runs, they are not sent again later
+			if persist.AgentResult.OutputFrame == nil {
+				mgs.persistResult(persist)
+			}
 			break
 		}
 		if err != nil {
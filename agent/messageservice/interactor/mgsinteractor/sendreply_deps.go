@@ -19,6 +19,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	replytypesutils "github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mgsinteractor/replytypes"
+	"github.com/aws/amazon-ssm-agent/agent/messageservice/utils"
 	"github.com/carlescere/scheduler"
 )
 
@@ -45,4 +46,5 @@ type sendReplyProperties struct {
 	replyAckChan       sync.Map
 	sendFailedReplyJob *scheduler.Job
 	allReplyClosed     chan struct{}
+	replyRetryTracker  *utils.FailedReplyRetryTracker
 }
@@ -4,11 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
-	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	agentContext "github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	replytypesmock "github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mgsinteractor/replytypes/mocks"
@@ -16,6 +17,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	controlChannelMock "github.com/aws/amazon-ssm-agent/agent/session/controlchannel/mocks"
+	"github.com/aws/amazon-ssm-agent/agent/statestore"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -25,6 +27,44 @@ import (
 
 type SendReplyTestSuite struct {
 	suite.Suite
+	replies *replyFolderStub
+}
+
+// replyFolderStub keeps the mgs replies in memory
+type replyFolderStub struct {
+	replies map[string][]byte
+}
+
+func (f *replyFolderStub) Names() (names []string, err error) {
+	for name := range f.replies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+func (f *replyFolderStub) Read(name string) ([]byte, error) {
+	if content, found := f.replies[name]; found {
+		return content, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (f *replyFolderStub) Write(name string, content []byte) error {
+	f.replies[name] = content
+	return nil
+}
+
+func (f *replyFolderStub) Delete(name string) error {
+	delete(f.replies, name)
+	return nil
+}
+
+func (suite *SendReplyTestSuite) SetupTest() {
+	suite.replies = &replyFolderStub{replies: make(map[string][]byte)}
+	failedReplyFolder = func(agentContext.T) statestore.Folder {
+		return suite.replies
+	}
 }
 
 // Execute the test suite
@@ -40,7 +80,7 @@ func (suite *SendReplyTestSuite) TestSendReplyToMGS() {
 	messageHandlerMock := &mocks.IMessageHandler{}
 	messageHandlerMock.On("RegisterReply", mock.Anything, mock.Anything)
 	messageHandlerMock.On("GetMessageUUID", mock.Anything, mock.Anything)
-	mgsInteractorRef, err := New(mockContext, messageHandlerMock)
+	mgsInteractorRef, err := New(mockContext, messageHandlerMock, nil)
 	assert.Nil(suite.T(), err, "initialize passed")
 	mgsInteractor := mgsInteractorRef.(*MGSInteractor)
 	mgsInteractor.controlChannel = mockControlChannel
@@ -78,7 +118,7 @@ func (suite *SendReplyTestSuite) TestTaskAgentCompleteWithRetry() {
 	messageHandlerMock.On("RegisterReply", mock.Anything, mock.Anything)
 	messageHandlerMock.On("GetMessageUUID", mock.Anything, mock.Anything)
 
-	mgsInteractorRef, err := New(mockContext, messageHandlerMock)
+	mgsInteractorRef, err := New(mockContext, messageHandlerMock, nil)
 	assert.Nil(suite.T(), err, "initialize passed")
 	mgsInteractor := mgsInteractorRef.(*MGSInteractor)
 	mgsInteractor.controlChannel = mockControlChannel
@@ -121,7 +161,7 @@ func (suite *SendReplyTestSuite) TestTaskAgentCompleteWithSecondRetryAckReceive(
 	messageHandlerMock := &mocks.IMessageHandler{}
 	messageHandlerMock.On("RegisterReply", mock.Anything, mock.Anything)
 	messageHandlerMock.On("GetMessageUUID", mock.Anything, mock.Anything)
-	mgsInteractorRef, err := New(mockContext, messageHandlerMock)
+	mgsInteractorRef, err := New(mockContext, messageHandlerMock, nil)
 	assert.Nil(suite.T(), err, "initialize passed")
 	mgsInteractor := mgsInteractorRef.(*MGSInteractor)
 	mgsInteractor.controlChannel = mockControlChannel
@@ -221,7 +261,7 @@ func (suite *SendReplyTestSuite) TestTaskAgentCompleteWithNormalAckReceive() {
 	messageHandlerMock := &mocks.IMessageHandler{}
 	messageHandlerMock.On("RegisterReply", mock.Anything, mock.Anything)
 	messageHandlerMock.On("GetMessageUUID", mock.Anything, mock.Anything)
-	mgsInteractorRef, err := New(mockContext, messageHandlerMock)
+	mgsInteractorRef, err := New(mockContext, messageHandlerMock, nil)
 	assert.Nil(suite.T(), err, "initialize passed")
 	mgsInteractor := mgsInteractorRef.(*MGSInteractor)
 	mgsInteractor.controlChannel = mockControlChannel
@@ -268,49 +308,45 @@ func (suite *SendReplyTestSuite) TestPersistResult_FileNotPresentAlready_Success
 	reply := suite.getDocumentResultObject()
 	replyId := reply.ReplyId
 	mgsInteractor := suite.getMGSInteractorRef(nil)
-	writeFileCheck := false
-	writeIntoFile = func(absolutePath, content string, perm os.FileMode) (result bool, err error) {
-		if !strings.HasSuffix(absolutePath, replyId) {
-			return false, nil
-		}
-		if val, err := jsonutil.Marshal(reply); err != nil || jsonutil.Indent(val) != content {
-			return false, nil
-		}
-		if perm != os.FileMode(appconfig.ReadWriteAccess) {
-			return false, nil
-		}
-		writeFileCheck = true
-		return true, nil
-	}
 	mgsInteractor.persistResult(reply)
-	assert.True(suite.T(), writeFileCheck, "reply is saved successfully")
+
+	val, err := jsonutil.Marshal(reply)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), suite.replies.replies, 1, "reply is saved successfully")
+	for name, content := range suite.replies.replies {
+		assert.True(suite.T(), strings.HasSuffix(name, replyId))
+		assert.Equal(suite.T(), jsonutil.Indent(val), string(content))
+	}
 }
 
 func (suite *SendReplyTestSuite) TestPersistResult_FilePresentAlready_SuccessfulSave() {
 	reply := suite.getDocumentResultObject()
 	replyId := reply.ReplyId
 	mgsInteractor := suite.getMGSInteractorRef(nil)
-	getFileNames = func(srcPath string) (files []string, err error) {
-		fileList := make([]string, 0)
-		fileList = append(fileList, replyId)
-		return fileList, nil
-	}
-	writeFileCheck := false
-	writeIntoFile = func(absolutePath, content string, perm os.FileMode) (result bool, err error) {
-		if !strings.HasSuffix(absolutePath, replyId) {
-			return false, nil
-		}
-		if val, err := jsonutil.Marshal(reply); err != nil || jsonutil.Indent(val) != content {
-			return false, nil
-		}
-		if perm != os.FileMode(appconfig.ReadWriteAccess) {
-			return false, nil
-		}
-		writeFileCheck = true
-		return true, nil
-	}
+	suite.replies.replies[replyId] = []byte("previous reply")
+	mgsInteractor.persistResult(reply)
+
+	val, err := jsonutil.Marshal(reply)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), suite.replies.replies, 1, "reply is updated successfully")
+	assert.Equal(suite.T(), jsonutil.Indent(val), string(suite.replies.replies[replyId]))
+}
+
+func (suite *SendReplyTestSuite) TestFailedReplies_LoadAndDelete() {
+	mgsInteractor := suite.getMGSInteractorRef(nil)
+	reply := suite.getDocumentResultObject()
 	mgsInteractor.persistResult(reply)
-	assert.True(suite.T(), writeFileCheck, "reply is saved successfully")
+
+	replies := mgsInteractor.loadFailedReplies(mgsInteractor.context.Log())
+	assert.Len(suite.T(), replies, 1)
+	loaded, err := mgsInteractor.getFailedReply(mgsInteractor.context.Log(), replies[0])
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), reply.ReplyId, loaded.ReplyId)
+
+	mgsInteractor.deleteFailedReply(mgsInteractor.context.Log(), replies[0])
+	assert.Empty(suite.T(), mgsInteractor.loadFailedReplies(mgsInteractor.context.Log()))
+	_, err = mgsInteractor.getFailedReply(mgsInteractor.context.Log(), replies[0])
+	assert.NotNil(suite.T(), err)
 }
 
 func (suite *SendReplyTestSuite) getMGSInteractorRef(sendControlChannelErr error) *MGSInteractor {
@@ -320,7 +356,7 @@ func (suite *SendReplyTestSuite) getMGSInteractorRef(sendControlChannelErr error
 	messageHandlerMock := &mocks.IMessageHandler{}
 	messageHandlerMock.On("RegisterReply", mock.Anything, mock.Anything)
 	messageHandlerMock.On("GetMessageUUID", mock.Anything, mock.Anything)
-	mgsInteractorRef, err := New(mockContext, messageHandlerMock)
+	mgsInteractorRef, err := New(mockContext, messageHandlerMock, nil)
 	assert.Nil(suite.T(), err, "initialize passed")
 	mgsInteractor := mgsInteractorRef.(*MGSInteractor)
 	mgsInteractor.controlChannel = mockControlChannel
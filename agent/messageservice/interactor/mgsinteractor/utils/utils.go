@@ -19,6 +19,9 @@ const (
 	// SendCommandTopic represents the topic added in the agent message payload for the document replies
 	// for documents executed with topic aws.ssm.sendCommand
 	SendCommandTopic CommandTopic = "aws.ssm.sendCommand"
+	// SendCommandOutputTopic represents the topic added in the agent message payload for the output streamed
+	// while the documents executed with topic aws.ssm.sendCommand run
+	SendCommandOutputTopic CommandTopic = "aws.ssm.sendCommand.output"
 
 	// CancelCommandTopic represents the topic added in the agent message payload for the document replies
 	// for documents executed with topic aws.ssm.cancelCommand
@@ -41,6 +44,8 @@ func GetTopicFromDocResult(resultType contracts.ResultType, documentType contrac
 		} else {
 			commandTopic = SendCommandTopic // use send command as default
 		}
+	} else if resultType == contracts.RunCommandOutputResult {
+		return SendCommandOutputTopic
 	} else if resultType == contracts.SessionResult {
 		return SessionCompleteTopic
 	}
@@ -49,13 +54,28 @@ func GetTopicFromDocResult(resultType contracts.ResultType, documentType contrac
 
 // GenerateAgentJobReplyPayload generates AgentJobReply agent message
 func GenerateAgentJobReplyPayload(log log.T, agentMessageUUID uuid.UUID, messageID string, replyPayload messageContracts.SendReplyPayload, topic CommandTopic) (*mgsContracts.AgentMessage, error) {
+	return generateAgentJobReply(log, agentMessageUUID, messageID, replyPayload, topic)
+}
+
+// GenerateAgentJobOutputPayload generates AgentJobReply agent message carrying the output streamed by a running plugin
+func GenerateAgentJobOutputPayload(log log.T, agentMessageUUID uuid.UUID, messageID string, outputPayload messageContracts.SendOutputPayload, topic CommandTopic) (*mgsContracts.AgentMessage, error) {
+	return generateAgentJobReply(log, agentMessageUUID, messageID, outputPayload, topic)
+}
+
+// generateAgentJobReply generates AgentJobReply agent message with the given payload as content
+func generateAgentJobReply(log log.T, agentMessageUUID uuid.UUID, messageID string, replyPayload interface{}, topic CommandTopic) (*mgsContracts.AgentMessage, error) {
 	payloadB, err := json.Marshal(replyPayload)
 	if err != nil {
 		log.Error("could not marshal reply payload!", err)
 		return nil, err
 	}
 	payload := string(payloadB)
-	log.Info("Sending reply ", jsonutil.Indent(payload))
+	if topic == SendCommandOutputTopic {
+		// output frames are sent every second while the plugin runs, their content is not logged
+		log.Debugf("Sending output frame %v", agentMessageUUID.String())
+	} else {
+		log.Info("Sending reply ", jsonutil.Indent(payload))
+	}
 	if len(payloadB) > ControlChannelAgentReplyPayloadSizeLimit {
 		return nil, fmt.Errorf("dropping reply message %v because it is too large to send over control channel", agentMessageUUID.String())
 	}
@@ -182,6 +182,15 @@ externalLabel:
 				break externalLabel
 			}
 
+			if res.OutputFrame != nil {
+				// output frames are only streamed to the message gateway, they are dropped for the other services
+				if resultChanRef, ok := outputChan[contracts.MessageGatewayService]; ok && res.UpstreamServiceName == contracts.MessageGatewayService {
+					res.ResultType = contracts.RunCommandOutputResult
+					resultChanRef <- res
+				}
+				break
+			}
+
 			if cpw.assocProcessor != nil {
 				cpw.handleSpecialPlugin(res.LastPlugin, res.PluginResults, res.MessageID)
 			}
@@ -226,7 +235,7 @@ func (cpw *CommandWorkerProcessorWrapper) handleSpecialPlugin(lastPluginID strin
 			log.Infof("Found %v to invoke refresh association immediately", pluginRes.PluginName)
 			commandID, _ := runCommandContracts.GetCommandID(messageID)
 			shortInstanceID, _ := cpw.context.Identity().ShortInstanceID()
-			orchestrationDir := filepath.Join(appconfig.DefaultDataStorePath,
+			orchestrationDir := filepath.Join(appconfig.OrchestrationStorePath,
 				shortInstanceID,
 				appconfig.DefaultDocumentRootDirName,
 				cpw.context.AppConfig().Agent.OrchestrationRootDir,
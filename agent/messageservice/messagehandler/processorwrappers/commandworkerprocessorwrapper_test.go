@@ -134,6 +134,49 @@ func (suite *CommandProcessorWrapperTestSuite) TestListenReply() {
 	}
 }
 
+func (suite *CommandProcessorWrapperTestSuite) TestListenReplyOutputFrame() {
+	pluginResult := contracts.PluginResult{
+		PluginID:   "plugin",
+		PluginName: "plugin",
+		Status:     contracts.ResultStatusInProgress,
+	}
+	frame := contracts.DocumentResult{
+		Status:              contracts.ResultStatusInProgress,
+		PluginResults:       map[string]*contracts.PluginResult{"plugin": &pluginResult},
+		LastPlugin:          "plugin",
+		MessageID:           uuid.NewV4().String(),
+		UpstreamServiceName: contracts.MessageGatewayService,
+		OutputFrame:         &contracts.OutputFrame{Sequence: 1, Stdout: "partial output"},
+	}
+	resultChan := make(chan contracts.DocumentResult)
+	mgsChan := make(chan contracts.DocumentResult, 1)
+	mdsChan := make(chan contracts.DocumentResult, 1)
+	outputMap := map[contracts.UpstreamServiceName]chan contracts.DocumentResult{
+		contracts.MessageGatewayService:  mgsChan,
+		contracts.MessageDeliveryService: mdsChan,
+	}
+
+	go suite.commmandWorkerProcessorWrapper.listenReply(resultChan, outputMap)
+	resultChan <- frame
+	select {
+	case res := <-mgsChan:
+		assert.Equal(suite.T(), contracts.RunCommandOutputResult, res.ResultType)
+		assert.Equal(suite.T(), frame.OutputFrame, res.OutputFrame)
+	case <-time.After(100 * time.Millisecond):
+		assert.Fail(suite.T(), "output frame should have been passed to MGS")
+	}
+
+	// the output frames of the documents received from MDS are dropped
+	frame.UpstreamServiceName = contracts.MessageDeliveryService
+	resultChan <- frame
+	close(resultChan)
+	select {
+	case <-mdsChan:
+		assert.Fail(suite.T(), "output frame should not be passed to MDS")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 func TestCommandProcessorWrapperTestSuite(t *testing.T) {
 	suite.Run(t, new(CommandProcessorWrapperTestSuite))
 }
@@ -21,8 +21,12 @@ import (
 	"runtime/debug"
 	"sync"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/messagemetrics"
+	"github.com/aws/amazon-ssm-agent/agent/framework/moduleguard"
+	"github.com/aws/amazon-ssm-agent/agent/messageservice/commandtransport"
 	"github.com/aws/amazon-ssm-agent/agent/messageservice/interactor"
 	"github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mdsinteractor"
 	"github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mgsinteractor"
@@ -51,6 +55,10 @@ type MessageService struct {
 	messageHandler  messagehandler.IMessageHandler
 	interactors     []interactor.IInteractor
 	msgServiceMutex sync.Mutex
+	// metricsPublisher publishes the message latency and execution backlog metrics
+	metricsPublisher *messagemetrics.Publisher
+	// transportSelector selects the transport the Run Command documents are received from
+	transportSelector *commandtransport.Selector
 }
 
 // NewService instantiates MessageService object and assigns value if needed
@@ -69,18 +77,20 @@ func NewService(context context.T) contracts.ICoreModule {
 		name:           ServiceName,
 		messageHandler: messagehandler.NewMessageHandler(messageContext),
 	}
+	messageService.metricsPublisher = messagemetrics.NewPublisher(messageContext)
+	messageService.transportSelector = commandtransport.NewSelector(messageContext)
 
 	isNanoServer, _ := isPlatformNanoServer(log)
 	if !isNanoServer {
 		log.Info("Appending MGSInteractor to MessageService interactors")
-		mgsRef, err := mgsinteractor.New(messageContext, messageService.messageHandler)
+		mgsRef, err := mgsinteractor.New(messageContext, messageService.messageHandler, messageService.transportSelector)
 		if err == nil {
 			messageService.interactors = append(messageService.interactors, mgsRef)
 		}
 	}
-	if !messageContext.AppConfig().Agent.ContainerMode {
+	if !messageContext.AppConfig().Agent.ContainerMode && messageService.transportSelector.IsEnabled(appconfig.CommandTransportMDS) {
 		log.Info("Appending MDSInteractor to MessageService interactors")
-		mdsRef, err := mdsinteractor.New(messageContext, messageService.messageHandler, nil)
+		mdsRef, err := mdsinteractor.New(messageContext, messageService.messageHandler, nil, messageService.transportSelector)
 		if err == nil {
 			messageService.interactors = append(messageService.interactors, mdsRef)
 		}
@@ -101,6 +111,12 @@ func (msgSvc *MessageService) GetMessageHandler() messagehandler.IMessageHandler
 	return msgSvc.messageHandler
 }
 
+// GetTransportSelector returns the selector of the transports the commands are accepted from
+// TODO remove once we start doing stress tests using service mock framework
+func (msgSvc *MessageService) GetTransportSelector() *commandtransport.Selector {
+	return msgSvc.transportSelector
+}
+
 // ICoreModule implementation
 
 // ModuleName returns the name of module
@@ -114,6 +130,8 @@ func (msgSvc *MessageService) ModuleExecute() (err error) {
 	log.Info("starting MessageService")
 	// initialize message handler
 	msgSvc.messageHandler.Initialize()
+	msgSvc.metricsPublisher.Start()
+	msgSvc.transportSelector.Start()
 
 	var wg sync.WaitGroup
 	errArr := make([]error, 0)
@@ -131,8 +149,8 @@ func (msgSvc *MessageService) ModuleExecute() (err error) {
 				wg.Done()
 				log.Infof("%v initialization completed", interactorName)
 				if msg := recover(); msg != nil {
-					log.Errorf("%v initialization panicked: %v", interactorName, msg)
-					log.Errorf("stacktrace:\n%s", debug.Stack())
+					log.Errorf("%v initialization panicked", interactorName)
+					moduleguard.ReportPanic(log, msgSvc.name, msg, debug.Stack())
 				}
 			}()
 			// In MGS Interactor, control channel connection may retry indefinitely
@@ -177,8 +195,8 @@ func (msgSvc *MessageService) initializeProcessor(interactorRef interactor.IInte
 				wg.Done()
 				log.Infof("processor initialization completed for worker %v belonging to %v", worker, interactorRef.GetName())
 				if msg := recover(); msg != nil {
-					log.Errorf("%v processor initialization panicked: %v", worker, msg)
-					log.Errorf("stacktrace:\n%s", debug.Stack())
+					log.Errorf("%v processor initialization panicked", worker)
+					moduleguard.ReportPanic(log, msgSvc.name, msg, debug.Stack())
 				}
 			}()
 			if _, ok := processorWorkerConfigs[worker]; !ok {
@@ -237,6 +255,8 @@ func (msgSvc *MessageService) ModuleStop() error {
 		}(interactRef)
 	}
 	wg.Wait()
+	msgSvc.metricsPublisher.Stop()
+	msgSvc.transportSelector.Stop()
 	log.Infof("Stopped %v", msgSvc.name)
 	return nil
 }
@@ -103,6 +103,7 @@ func newDocumentInfo(msg model.InstanceMessage, parsedMsg messageContracts.SendC
 	documentInfo.CreatedDate = msg.CreatedDate
 	documentInfo.DocumentName = parsedMsg.DocumentName
 	documentInfo.DocumentStatus = contracts.ResultStatusInProgress
+	documentInfo.CorrelationID = contracts.NewCorrelationID()
 
 	return *documentInfo
 }
@@ -129,6 +130,7 @@ func ParseCancelCommandMessage(context context.T, msg model.InstanceMessage, ups
 		RunID:          times.ToIsoDashUTC(times.DefaultClock.Now()),
 		CreatedDate:    msg.CreatedDate,
 		DocumentStatus: contracts.ResultStatusInProgress,
+		CorrelationID:  contracts.NewCorrelationID(),
 	}
 
 	cancelCommand := new(contracts.CancelCommandInfo)
@@ -216,6 +218,7 @@ func ParseSendCommandMessage(context context.T, msg model.InstanceMessage, messa
 
 	documentType := contracts.SendCommand
 	documentInfo := newDocumentInfo(msg, parsedMessage)
+	log.Infof("Assigned correlation id %v to message %v", documentInfo.CorrelationID, documentInfo.MessageID)
 	parserInfo := docparser.DocumentParserInfo{
 		OrchestrationDir: messageOrchestrationDirectory,
 		S3Bucket:         parsedMessage.OutputS3BucketName,
@@ -285,24 +288,7 @@ func ParseSendCommandMessage(context context.T, msg model.InstanceMessage, messa
 
 // IsValidReplyRequest checks whether the reply is valid and had timed or not
 func IsValidReplyRequest(filename string, name contracts.UpstreamServiceName) bool {
-	splitFileName := strings.Split(filename, "_")
-	if len(splitFileName) < 2 {
-		return false
-	}
-	timeInFileName := ""
-	if name == contracts.MessageGatewayService { // MGS uses this format to have proper time based sorting
-		timeInFileName = splitFileName[0]
-	} else {
-		timeInFileName = splitFileName[1]
-	}
-	t, _ := time.Parse("2006-01-02T15-04-05", timeInFileName)
-	curTime := time.Now().UTC()
-	delta := curTime.Sub(t).Hours()
-	if delta > documentLevelTimeOutDurationHour {
-		return false
-	} else {
-		return true
-	}
+	return IsReplyRequestWithinMaxAge(filename, name, documentLevelTimeOutDurationHour*time.Hour)
 }
 
 // PrepareReplyPayloadToUpdateDocumentStatus creates the payload object for SendReply based on document status change.
@@ -0,0 +1,144 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package utils provides utility functions to be used by interactors
+package utils
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+const (
+	// failedReplyTimeFormat is the time format used in the persisted failed reply file names
+	failedReplyTimeFormat = "2006-01-02T15-04-05"
+
+	// maxFailedReplyRetryInterval is the upper bound of the backoff between two retries of the same reply
+	maxFailedReplyRetryInterval = 2 * time.Hour
+)
+
+// FailedReplyRetryTracker keeps track of retry attempts of persisted replies
+// and decides when a reply is due for the next retry using exponential backoff.
+type FailedReplyRetryTracker struct {
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	attempts        map[string]replyRetryState
+	mutex           sync.Mutex
+	now             func() time.Time
+}
+
+type replyRetryState struct {
+	count       int
+	nextAttempt time.Time
+}
+
+// NewFailedReplyRetryTracker returns a new retry tracker.
+// initialInterval is the wait time after the first failed retry, doubled after every further failure.
+func NewFailedReplyRetryTracker(initialInterval time.Duration) *FailedReplyRetryTracker {
+	return &FailedReplyRetryTracker{
+		initialInterval: initialInterval,
+		maxInterval:     maxFailedReplyRetryInterval,
+		attempts:        make(map[string]replyRetryState),
+		now:             time.Now,
+	}
+}
+
+// ShouldRetry returns true when the reply has never been retried or its backoff period has elapsed
+func (tracker *FailedReplyRetryTracker) ShouldRetry(reply string) bool {
+	if tracker == nil {
+		return true
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	state, found := tracker.attempts[reply]
+	return !found || !tracker.now().Before(state.nextAttempt)
+}
+
+// RecordAttempt records a retry attempt for the reply and computes the time of the next attempt
+func (tracker *FailedReplyRetryTracker) RecordAttempt(reply string) {
+	if tracker == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	state := tracker.attempts[reply]
+	state.count++
+	state.nextAttempt = tracker.now().Add(tracker.backoffInterval(state.count))
+	tracker.attempts[reply] = state
+}
+
+// Remove stops tracking the reply, it should be called once the reply is delivered or deleted
+func (tracker *FailedReplyRetryTracker) Remove(reply string) {
+	if tracker == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	delete(tracker.attempts, reply)
+}
+
+// backoffInterval returns the wait time after the given number of attempts
+func (tracker *FailedReplyRetryTracker) backoffInterval(attempts int) time.Duration {
+	interval := tracker.initialInterval
+	for i := 1; i < attempts; i++ {
+		interval *= 2
+		if interval >= tracker.maxInterval {
+			return tracker.maxInterval
+		}
+	}
+	return interval
+}
+
+// GetReplyPersistTime returns the time embedded in the persisted reply file name
+func GetReplyPersistTime(filename string, name contracts.UpstreamServiceName) (time.Time, bool) {
+	splitFileName := strings.Split(filename, "_")
+	if len(splitFileName) < 2 {
+		return time.Time{}, false
+	}
+	timeInFileName := ""
+	if name == contracts.MessageGatewayService { // MGS uses this format to have proper time based sorting
+		timeInFileName = splitFileName[0]
+	} else {
+		timeInFileName = splitFileName[1]
+	}
+	t, err := time.Parse(failedReplyTimeFormat, timeInFileName)
+	return t, err == nil
+}
+
+// IsReplyRequestWithinMaxAge checks whether the persisted reply is younger than the given maximum age
+func IsReplyRequestWithinMaxAge(filename string, name contracts.UpstreamServiceName, maxAge time.Duration) bool {
+	t, ok := GetReplyPersistTime(filename, name)
+	if !ok {
+		return false
+	}
+	return time.Now().UTC().Sub(t) <= maxAge
+}
+
+// GetRepliesOverLimit returns the oldest persisted replies which exceed the reply queue limit
+func GetRepliesOverLimit(filenames []string, name contracts.UpstreamServiceName, limit int) []string {
+	if limit <= 0 || len(filenames) <= limit {
+		return []string{}
+	}
+	sorted := make([]string, len(filenames))
+	copy(sorted, filenames)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		first, _ := GetReplyPersistTime(sorted[i], name)
+		second, _ := GetReplyPersistTime(sorted[j], name)
+		return first.Before(second)
+	})
+	return sorted[:len(sorted)-limit]
+}
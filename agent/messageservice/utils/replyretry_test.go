@@ -0,0 +1,84 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailedReplyRetryTrackerBackoff(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := NewFailedReplyRetryTracker(5 * time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	assert.True(t, tracker.ShouldRetry("reply1"))
+	tracker.RecordAttempt("reply1")
+	assert.False(t, tracker.ShouldRetry("reply1"))
+
+	now = now.Add(5 * time.Minute)
+	assert.True(t, tracker.ShouldRetry("reply1"))
+	tracker.RecordAttempt("reply1")
+
+	// second failure doubles the interval
+	now = now.Add(5 * time.Minute)
+	assert.False(t, tracker.ShouldRetry("reply1"))
+	now = now.Add(5 * time.Minute)
+	assert.True(t, tracker.ShouldRetry("reply1"))
+
+	tracker.Remove("reply1")
+	tracker.RecordAttempt("reply1")
+	now = now.Add(5 * time.Minute)
+	assert.True(t, tracker.ShouldRetry("reply1"))
+}
+
+func TestFailedReplyRetryTrackerBackoffIsCapped(t *testing.T) {
+	tracker := NewFailedReplyRetryTracker(5 * time.Minute)
+	assert.Equal(t, 5*time.Minute, tracker.backoffInterval(1))
+	assert.Equal(t, 40*time.Minute, tracker.backoffInterval(4))
+	assert.Equal(t, maxFailedReplyRetryInterval, tracker.backoffInterval(20))
+}
+
+func TestNilFailedReplyRetryTracker(t *testing.T) {
+	var tracker *FailedReplyRetryTracker
+	tracker.RecordAttempt("reply1")
+	tracker.Remove("reply1")
+	assert.True(t, tracker.ShouldRetry("reply1"))
+}
+
+func TestIsReplyRequestWithinMaxAge(t *testing.T) {
+	recent := time.Now().UTC().Add(-1 * time.Hour).Format(failedReplyTimeFormat)
+	old := time.Now().UTC().Add(-5 * time.Hour).Format(failedReplyTimeFormat)
+
+	assert.True(t, IsReplyRequestWithinMaxAge("replyId_"+recent, contracts.MessageDeliveryService, 2*time.Hour))
+	assert.False(t, IsReplyRequestWithinMaxAge("replyId_"+old, contracts.MessageDeliveryService, 2*time.Hour))
+	assert.True(t, IsReplyRequestWithinMaxAge("replyId_"+old, contracts.MessageDeliveryService, 6*time.Hour))
+	assert.True(t, IsReplyRequestWithinMaxAge(recent+"_replyId", contracts.MessageGatewayService, 2*time.Hour))
+	assert.False(t, IsReplyRequestWithinMaxAge("invalid", contracts.MessageGatewayService, 2*time.Hour))
+}
+
+func TestGetRepliesOverLimit(t *testing.T) {
+	replies := []string{
+		"2022-01-01T00-00-03_reply3",
+		"2022-01-01T00-00-01_reply1",
+		"2022-01-01T00-00-02_reply2",
+	}
+	assert.Equal(t, []string{"2022-01-01T00-00-01_reply1"}, GetRepliesOverLimit(replies, contracts.MessageGatewayService, 2))
+	assert.Empty(t, GetRepliesOverLimit(replies, contracts.MessageGatewayService, 3))
+	assert.Equal(t, []string{"reply1_2022-01-01T00-00-01", "reply2_2022-01-01T00-00-02"},
+		GetRepliesOverLimit([]string{"reply3_2022-01-01T00-00-03", "reply2_2022-01-01T00-00-02", "reply1_2022-01-01T00-00-01"}, contracts.MessageDeliveryService, 1))
+}
@@ -16,6 +16,7 @@ package executers
 import (
 	"io"
 	"os"
+	"os/exec"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -75,3 +76,18 @@ func (m *MockCommandExecuter) StartExe(
 	args := m.Called(context, workingDir, stdoutWriter, stderrWriter, cancelFlag, commandName, commandArguments)
 	return args.Get(0).(*os.Process), args.Get(1).(int), args.Error(2)
 }
+
+// StartPreparedExe is a mocked method that just returns what mock tells it to.
+func (m *MockCommandExecuter) StartPreparedExe(
+	context context.T,
+	workingDir string,
+	stdoutWriter io.Writer,
+	stderrWriter io.Writer,
+	cancelFlag task.CancelFlag,
+	commandName string,
+	commandArguments []string,
+	prepare func(command *exec.Cmd) error,
+) (process *os.Process, exitCode int, errs error) {
+	args := m.Called(context, workingDir, stdoutWriter, stderrWriter, cancelFlag, commandName, commandArguments, prepare)
+	return args.Get(0).(*os.Process), args.Get(1).(int), args.Error(2)
+}
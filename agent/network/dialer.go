@@ -0,0 +1,221 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// DialContextFunc dials an outbound connection
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// interfaceAddrs returns the addresses of the network interface with the given name
+var interfaceAddrs = func(name string) ([]net.Addr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return iface.Addrs()
+}
+
+// GetDialContext returns the dial function of the outbound connections following the appconfig network config.
+// The connections are bound to the configured source address or interface, the source is resolved on every dial
+// so that an interface getting its address after the agent started is used and the dial fails if the source
+// cannot be resolved. The host names are resolved by the agent resolver when it caches the resolutions or queries
+// its own DNS servers, and when IPv6 is preferred or forced. The IPv6 addresses of the destination are dialed
+// first when IPv6 is preferred, and only when IPv6 is forced. Connections to loopback addresses, like a local
+// proxy, are left untouched.
+func GetDialContext(appConfig appconfig.SsmagentConfig, dialer *net.Dialer) DialContextFunc {
+	networkCfg := appConfig.Network
+	dial := dialer.DialContext
+	if networkCfg.SourceAddress != "" || networkCfg.SourceInterface != "" {
+		dial = sourceBoundDial(networkCfg, dialer)
+	}
+	agentResolver := getResolver(appConfig)
+	if agentResolver.isEnabled() || networkCfg.IPMode == appconfig.NetworkIPModePreferIPv6 || networkCfg.IPMode == appconfig.NetworkIPModeIPv6Only {
+		dial = resolvedDial(networkCfg.IPMode, agentResolver.LookupIP, dial)
+	}
+	return dial
+}
+
+// sourceBoundDial returns the dial function binding the connections to the configured source
+func sourceBoundDial(networkCfg appconfig.NetworkCfg, dialer *net.Dialer) DialContextFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if isLoopbackAddress(address) {
+			return dialer.DialContext(ctx, network, address)
+		}
+		sourceIP, err := resolveSourceIP(networkCfg, network)
+		if err != nil {
+			return nil, err
+		}
+		boundDialer := *dialer
+		boundDialer.LocalAddr = &net.TCPAddr{IP: sourceIP}
+		if networkCfg.SourceAddress == "" {
+			boundDialer.Control = bindToInterface(networkCfg.SourceInterface)
+		}
+		return boundDialer.DialContext(ctx, network, address)
+	}
+}
+
+// resolvedDial returns the dial function resolving the host of the destination then dialing its addresses in
+// turn. The IPv6 addresses are dialed first when IPv6 is preferred, and only when IPv6 is forced.
+func resolvedDial(ipMode string, lookupIP func(ctx context.Context, host string) ([]net.IP, error), dial DialContextFunc) DialContextFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || isLoopbackAddress(address) {
+			return dial(ctx, network, address)
+		}
+		ips := []net.IP{net.ParseIP(host)}
+		if ips[0] == nil {
+			if ips, err = lookupIP(ctx, host); err != nil {
+				return nil, err
+			}
+		}
+
+		candidates := dialCandidates(ipMode, network, ips)
+		if len(candidates) == 0 {
+			if ipMode == appconfig.NetworkIPModeIPv6Only || network == "tcp6" {
+				return nil, fmt.Errorf("no IPv6 address found for %v", host)
+			}
+			return nil, fmt.Errorf("no suitable address found for %v", host)
+		}
+
+		var lastErr error
+		for _, candidate := range candidates {
+			conn, err := dial(ctx, candidate.network, net.JoinHostPort(candidate.ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, lastErr
+	}
+}
+
+// dialCandidate is an address of the destination and the network it is dialed with
+type dialCandidate struct {
+	network string
+	ip      net.IP
+}
+
+// dialCandidates orders the addresses of the destination following the IP mode, the addresses that do not
+// match the network are dropped
+func dialCandidates(ipMode, network string, ips []net.IP) []dialCandidate {
+	var ipv6, ipv4 []dialCandidate
+	for _, ip := range ips {
+		if ip.To4() == nil {
+			ipv6 = append(ipv6, dialCandidate{"tcp6", ip})
+		} else {
+			ipv4 = append(ipv4, dialCandidate{"tcp4", ip})
+		}
+	}
+	if ipMode == appconfig.NetworkIPModeIPv6Only || network == "tcp6" {
+		return ipv6
+	}
+	if network == "tcp4" {
+		return ipv4
+	}
+	if ipMode == appconfig.NetworkIPModePreferIPv6 {
+		return append(ipv6, ipv4...)
+	}
+
+	// keep the order of the resolution
+	candidates := make([]dialCandidate, 0, len(ips))
+	for _, ip := range ips {
+		candidates = append(candidates, dialCandidate{network, ip})
+	}
+	return candidates
+}
+
+// resolveSourceIP returns the configured source address, or the address of the configured interface matching
+// the network. IPv4 addresses are preferred when the network accepts both families.
+func resolveSourceIP(networkCfg appconfig.NetworkCfg, network string) (net.IP, error) {
+	if networkCfg.SourceAddress != "" {
+		sourceIP := net.ParseIP(networkCfg.SourceAddress)
+		if sourceIP == nil {
+			return nil, fmt.Errorf("invalid source address %v", networkCfg.SourceAddress)
+		}
+		return sourceIP, nil
+	}
+
+	addrs, err := interfaceAddrs(networkCfg.SourceInterface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the addresses of source interface %v: %v", networkCfg.SourceInterface, err)
+	}
+	var ipv6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			if network != "tcp6" {
+				return ipNet.IP, nil
+			}
+		} else if ipv6 == nil && network != "tcp4" {
+			ipv6 = ipNet.IP
+		}
+	}
+	if ipv6 == nil {
+		return nil, fmt.Errorf("source interface %v has no usable address for %v", networkCfg.SourceInterface, network)
+	}
+	return ipv6, nil
+}
+
+// isLoopbackAddress returns true if the host of the address is localhost or a loopback IP
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// LogNetworkConfig logs the IP mode and the source the outbound connections are bound to, it is called during
+// agent startup
+func LogNetworkConfig(log log.T, appConfig appconfig.SsmagentConfig) {
+	networkCfg := appConfig.Network
+	if networkCfg.IPMode != appconfig.NetworkIPModeAuto {
+		log.Infof("Outbound connections IP mode: %v", networkCfg.IPMode)
+	}
+	if len(networkCfg.DNSServers) > 0 {
+		log.Infof("Outbound connections resolve host names with DNS servers %v, over TLS: %v", strings.Join(networkCfg.DNSServers, ", "), networkCfg.DNSOverTLS)
+	}
+	switch {
+	case networkCfg.SourceAddress != "":
+		if networkCfg.SourceInterface != "" {
+			log.Warnf("Ignoring source interface %v, the source address is set", networkCfg.SourceInterface)
+		}
+		log.Infof("Outbound connections are bound to source address %v", networkCfg.SourceAddress)
+	case networkCfg.SourceInterface != "":
+		if _, err := resolveSourceIP(networkCfg, "tcp"); err != nil {
+			log.Warnf("Outbound connections are bound to source interface %v: %v", networkCfg.SourceInterface, err)
+		} else {
+			log.Infof("Outbound connections are bound to source interface %v", networkCfg.SourceInterface)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build integration
+// +build integration
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const aaaaOnlyHost = "aaaa-only.ssm-agent.test"
+
+// newIPv6Server starts an http server listening on the IPv6 loopback address
+func newIPv6Server(t *testing.T) *httptest.Server {
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 is not available: %v", err)
+	}
+	server := &httptest.Server{
+		Listener: listener,
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "ok")
+		})},
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// resolveOnlyAAAA makes the agent resolve the test host to the IPv6 loopback address only
+func resolveOnlyAAAA(t *testing.T) {
+	original := systemLookupIPAddr
+	systemLookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host == aaaaOnlyHost {
+			return []net.IPAddr{{IP: net.IPv6loopback}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	t.Cleanup(func() { systemLookupIPAddr = original })
+}
+
+func TestDefaultTransport_AAAAOnlyResolution(t *testing.T) {
+	server := newIPv6Server(t)
+	resolveOnlyAAAA(t)
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	for _, ipMode := range []string{appconfig.NetworkIPModePreferIPv6, appconfig.NetworkIPModeIPv6Only} {
+		appConfig := appconfig.DefaultConfig()
+		appConfig.Network.IPMode = ipMode
+		client := &http.Client{Transport: GetDefaultTransport(log.NewMockLog(), appConfig)}
+
+		response, err := client.Get("http://" + net.JoinHostPort(aaaaOnlyHost, port))
+		if assert.NoError(t, err, ipMode) {
+			response.Body.Close()
+			assert.Equal(t, http.StatusOK, response.StatusCode, ipMode)
+		}
+	}
+}
+
+func TestDialContext_IPv6OnlyRejectsARecordOnlyResolution(t *testing.T) {
+	original := systemLookupIPAddr
+	systemLookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+	}
+	defer func() { systemLookupIPAddr = original }()
+
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Network.IPMode = appconfig.NetworkIPModeIPv6Only
+	_, err := GetDialContext(appConfig, &net.Dialer{})(context.Background(), "tcp", "a-only.ssm-agent.test:443")
+	assert.EqualError(t, err, "no IPv6 address found for a-only.ssm-agent.test")
+}
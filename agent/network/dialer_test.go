@@ -0,0 +1,189 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockInterfaceAddrs(t *testing.T, addrs []net.Addr, err error) {
+	original := interfaceAddrs
+	interfaceAddrs = func(name string) ([]net.Addr, error) {
+		assert.Equal(t, "eth1", name)
+		return addrs, err
+	}
+	t.Cleanup(func() { interfaceAddrs = original })
+}
+
+func ipNet(ip string) *net.IPNet {
+	return &net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(24, 32)}
+}
+
+func TestResolveSourceIP_SourceAddress(t *testing.T) {
+	sourceIP, err := resolveSourceIP(appconfig.NetworkCfg{SourceAddress: "10.0.0.12", SourceInterface: "eth1"}, "tcp")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.12", sourceIP.String())
+}
+
+func TestResolveSourceIP_SourceInterface(t *testing.T) {
+	mockInterfaceAddrs(t, []net.Addr{ipNet("fe80::1"), ipNet("2001:db8::12"), ipNet("192.168.1.12")}, nil)
+	networkCfg := appconfig.NetworkCfg{SourceInterface: "eth1"}
+
+	sourceIP, err := resolveSourceIP(networkCfg, "tcp")
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.1.12", sourceIP.String())
+
+	sourceIP, err = resolveSourceIP(networkCfg, "tcp6")
+	assert.NoError(t, err)
+	assert.Equal(t, "2001:db8::12", sourceIP.String())
+}
+
+func TestResolveSourceIP_SourceInterfaceWithoutUsableAddress(t *testing.T) {
+	mockInterfaceAddrs(t, []net.Addr{ipNet("fe80::1")}, nil)
+
+	_, err := resolveSourceIP(appconfig.NetworkCfg{SourceInterface: "eth1"}, "tcp")
+	assert.Error(t, err)
+}
+
+func TestResolveSourceIP_UnknownSourceInterface(t *testing.T) {
+	mockInterfaceAddrs(t, nil, errors.New("no such network interface"))
+
+	_, err := resolveSourceIP(appconfig.NetworkCfg{SourceInterface: "eth1"}, "tcp")
+	assert.Error(t, err)
+}
+
+func TestGetDialContext_FailsWhenSourceCannotBeResolved(t *testing.T) {
+	mockInterfaceAddrs(t, nil, errors.New("no such network interface"))
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Network.SourceInterface = "eth1"
+
+	_, err := GetDialContext(appConfig, &net.Dialer{})(context.Background(), "tcp", "ssm.us-east-1.amazonaws.com:443")
+	assert.Error(t, err)
+}
+
+func TestGetDialContext_LoopbackNotBound(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("unable to listen on loopback: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Network.SourceAddress = "192.0.2.1"
+	// binding to a source address not assigned to the host would fail the dial
+	conn, err := GetDialContext(appConfig, &net.Dialer{})(context.Background(), "tcp", listener.Addr().String())
+	assert.NoError(t, err)
+	conn.Close()
+}
+
+func TestIsLoopbackAddress(t *testing.T) {
+	assert.True(t, isLoopbackAddress("localhost:3128"))
+	assert.True(t, isLoopbackAddress("127.0.0.1:3128"))
+	assert.True(t, isLoopbackAddress("[::1]:3128"))
+	assert.False(t, isLoopbackAddress("10.0.0.1:3128"))
+	assert.False(t, isLoopbackAddress("ssm.us-east-1.amazonaws.com:443"))
+}
+
+// staticLookupIP returns a lookup function resolving all the hosts to the given addresses
+func staticLookupIP(ips ...string) func(ctx context.Context, host string) ([]net.IP, error) {
+	return func(ctx context.Context, host string) ([]net.IP, error) {
+		var resolved []net.IP
+		for _, ip := range ips {
+			resolved = append(resolved, net.ParseIP(ip))
+		}
+		return resolved, nil
+	}
+}
+
+// recordingDial records the dialed networks and addresses and fails all the dials but the accepted address
+func recordingDial(accepted string, dialed *[]string) DialContextFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		*dialed = append(*dialed, network+" "+address)
+		if address == accepted {
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		}
+		return nil, errors.New("connection refused")
+	}
+}
+
+func TestResolvedDial_PreferIPv6FallsBackToIPv4(t *testing.T) {
+	var dialed []string
+
+	conn, err := resolvedDial(appconfig.NetworkIPModePreferIPv6, staticLookupIP("192.0.2.10", "2001:db8::10"), recordingDial("192.0.2.10:443", &dialed))(context.Background(), "tcp", "ssm.us-east-1.api.aws:443")
+
+	assert.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, []string{"tcp6 [2001:db8::10]:443", "tcp4 192.0.2.10:443"}, dialed)
+}
+
+func TestResolvedDial_IPv6OnlySkipsIPv4(t *testing.T) {
+	var dialed []string
+
+	_, err := resolvedDial(appconfig.NetworkIPModeIPv6Only, staticLookupIP("192.0.2.10", "2001:db8::10"), recordingDial("192.0.2.10:443", &dialed))(context.Background(), "tcp", "ssm.us-east-1.api.aws:443")
+
+	assert.Error(t, err)
+	assert.Equal(t, []string{"tcp6 [2001:db8::10]:443"}, dialed)
+}
+
+func TestResolvedDial_IPv6OnlyWithoutAAAARecord(t *testing.T) {
+	var dialed []string
+
+	_, err := resolvedDial(appconfig.NetworkIPModeIPv6Only, staticLookupIP("192.0.2.10"), recordingDial("192.0.2.10:443", &dialed))(context.Background(), "tcp", "ssm.us-east-1.amazonaws.com:443")
+
+	assert.EqualError(t, err, "no IPv6 address found for ssm.us-east-1.amazonaws.com")
+	assert.Empty(t, dialed)
+}
+
+func TestResolvedDial_LoopbackUntouched(t *testing.T) {
+	var dialed []string
+
+	conn, err := resolvedDial(appconfig.NetworkIPModeIPv6Only, staticLookupIP(), recordingDial("127.0.0.1:3128", &dialed))(context.Background(), "tcp", "127.0.0.1:3128")
+
+	assert.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, []string{"tcp 127.0.0.1:3128"}, dialed)
+}
+
+func TestResolvedDial_AutoKeepsResolutionOrder(t *testing.T) {
+	var dialed []string
+
+	conn, err := resolvedDial(appconfig.NetworkIPModeAuto, staticLookupIP("192.0.2.10", "2001:db8::10"), recordingDial("[2001:db8::10]:443", &dialed))(context.Background(), "tcp", "ssm.us-east-1.amazonaws.com:443")
+
+	assert.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, []string{"tcp 192.0.2.10:443", "tcp [2001:db8::10]:443"}, dialed)
+}
+
+func TestResolvedDial_AutoFiltersNetworkFamily(t *testing.T) {
+	var dialed []string
+
+	_, err := resolvedDial(appconfig.NetworkIPModeAuto, staticLookupIP("2001:db8::10"), recordingDial("", &dialed))(context.Background(), "tcp4", "ssm.us-east-1.amazonaws.com:443")
+
+	assert.EqualError(t, err, "no suitable address found for ssm.us-east-1.amazonaws.com")
+	assert.Empty(t, dialed)
+}
@@ -0,0 +1,243 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	dnsPort          = "53"
+	dnsOverTLSPort   = "853"
+	dnsQueryTimeout  = 5 * time.Second
+	dnsMaxUDPPayload = 512
+)
+
+var errDNSNoSuchHost = errors.New("no such host")
+
+// dnsClient queries a DNS server for the A and AAAA records of host names
+type dnsClient struct {
+	server    string
+	overTLS   bool
+	tlsConfig appconfig.TLSCfg
+}
+
+// newDNSClient creates the client of a DNS server, ip or ip:port
+func newDNSClient(server string, overTLS bool, tlsConfig appconfig.TLSCfg) *dnsClient {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		port := dnsPort
+		if overTLS {
+			port = dnsOverTLSPort
+		}
+		server = net.JoinHostPort(strings.Trim(server, "[]"), port)
+	}
+	return &dnsClient{server: server, overTLS: overTLS, tlsConfig: tlsConfig}
+}
+
+// lookup returns the IPv4 and IPv6 addresses of the host and the lowest TTL of their records
+func (c *dnsClient) lookup(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid host name %v: %v", host, err)
+	}
+
+	var ips []net.IP
+	var ttl time.Duration
+	var lastErr error
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		answerIPs, answerTTL, err := c.query(ctx, name, qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(answerIPs) > 0 && (len(ips) == 0 || answerTTL < ttl) {
+			ttl = answerTTL
+		}
+		ips = append(ips, answerIPs...)
+	}
+	if len(ips) == 0 {
+		if lastErr == nil {
+			lastErr = errDNSNoSuchHost
+		}
+		return nil, 0, fmt.Errorf("lookup %v on %v: %v", host, c.server, lastErr)
+	}
+	return ips, ttl, nil
+}
+
+// query sends a query to the DNS server, over UDP then TCP when the response is truncated, or over TLS
+func (c *dnsClient) query(ctx context.Context, name dnsmessage.Name, qtype dnsmessage.Type) ([]net.IP, time.Duration, error) {
+	id := uint16(rand.Intn(1 << 16))
+	query, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dnsQueryTimeout)
+	defer cancel()
+
+	var response []byte
+	if c.overTLS {
+		response, err = c.exchangeStream(ctx, query, true)
+	} else {
+		response, err = c.exchangeUDP(ctx, query)
+		if err == nil && isTruncated(response) {
+			response, err = c.exchangeStream(ctx, query, false)
+		}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return parseDNSResponse(response, id)
+}
+
+func (c *dnsClient) exchangeUDP(ctx context.Context, query []byte) ([]byte, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", c.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err = conn.Write(query); err != nil {
+		return nil, err
+	}
+	response := make([]byte, dnsMaxUDPPayload)
+	n, err := conn.Read(response)
+	if err != nil {
+		return nil, err
+	}
+	return response[:n], nil
+}
+
+// exchangeStream sends the query over TCP or TLS, the messages are prefixed by their length
+func (c *dnsClient) exchangeStream(ctx context.Context, query []byte, overTLS bool) ([]byte, error) {
+	var conn net.Conn
+	var err error
+	if overTLS {
+		host, _, _ := net.SplitHostPort(c.server)
+		tlsConfig := &tls.Config{ServerName: host}
+		applyTLSPolicy(tlsConfig, c.tlsConfig)
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", c.server)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", c.server)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	message := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(message, uint16(len(query)))
+	copy(message[2:], query)
+	if _, err = conn.Write(message); err != nil {
+		return nil, err
+	}
+
+	length := make([]byte, 2)
+	if _, err = io.ReadFull(conn, length); err != nil {
+		return nil, err
+	}
+	response := make([]byte, binary.BigEndian.Uint16(length))
+	if _, err = io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func isTruncated(response []byte) bool {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(response)
+	return err == nil && header.Truncated
+}
+
+// parseDNSResponse returns the addresses of the A and AAAA records of the response, whatever their name so that
+// the addresses of the canonical name are returned, and the lowest TTL of these records
+func parseDNSResponse(response []byte, id uint16) ([]net.IP, time.Duration, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(response)
+	if err != nil {
+		return nil, 0, err
+	}
+	if header.ID != id {
+		return nil, 0, errors.New("unexpected DNS response id")
+	}
+	switch header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, 0, errDNSNoSuchHost
+	default:
+		return nil, 0, fmt.Errorf("DNS server returned %v", header.RCode)
+	}
+	if err = parser.SkipAllQuestions(); err != nil {
+		return nil, 0, err
+	}
+
+	var ips []net.IP
+	var ttl time.Duration
+	for {
+		answer, err := parser.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		var ip net.IP
+		switch answer.Type {
+		case dnsmessage.TypeA:
+			resource, err := parser.AResource()
+			if err != nil {
+				return nil, 0, err
+			}
+			ip = net.IP(resource.A[:])
+		case dnsmessage.TypeAAAA:
+			resource, err := parser.AAAAResource()
+			if err != nil {
+				return nil, 0, err
+			}
+			ip = net.IP(resource.AAAA[:])
+		default:
+			if err = parser.SkipAnswer(); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		answerTTL := time.Duration(answer.TTL) * time.Second
+		if len(ips) == 0 || answerTTL < ttl {
+			ttl = answerTTL
+		}
+		ips = append(ips, ip)
+	}
+	return ips, ttl, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startDNSServer starts a UDP DNS server answering the A and AAAA queries of the given host
+func startDNSServer(t *testing.T, host string, ttl uint32) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, dnsMaxUDPPayload)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err = query.Unpack(buffer[:n]); err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			if response, err := dnsResponse(query, host, ttl); err == nil {
+				conn.WriteTo(response, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func dnsResponse(query dnsmessage.Message, host string, ttl uint32) ([]byte, error) {
+	question := query.Questions[0]
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true},
+		Questions: query.Questions,
+	}
+	if question.Name.String() != host+"." {
+		response.RCode = dnsmessage.RCodeNameError
+		return response.Pack()
+	}
+	resourceHeader := dnsmessage.ResourceHeader{Name: question.Name, Type: question.Type, Class: dnsmessage.ClassINET, TTL: ttl}
+	switch question.Type {
+	case dnsmessage.TypeA:
+		response.Answers = []dnsmessage.Resource{{Header: resourceHeader, Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 10}}}}
+	case dnsmessage.TypeAAAA:
+		resourceHeader.TTL = ttl * 2
+		response.Answers = []dnsmessage.Resource{{Header: resourceHeader, Body: &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 0x10}}}}
+	}
+	return response.Pack()
+}
+
+func TestNewDNSClient_DefaultPort(t *testing.T) {
+	assert.Equal(t, "10.0.0.2:53", newDNSClient("10.0.0.2", false, appconfig.TLSCfg{}).server)
+	assert.Equal(t, "10.0.0.2:853", newDNSClient("10.0.0.2", true, appconfig.TLSCfg{}).server)
+	assert.Equal(t, "[2001:db8::53]:853", newDNSClient("2001:db8::53", true, appconfig.TLSCfg{}).server)
+	assert.Equal(t, "10.0.0.2:5353", newDNSClient("10.0.0.2:5353", true, appconfig.TLSCfg{}).server)
+}
+
+func TestDNSClient_Lookup(t *testing.T) {
+	server := startDNSServer(t, "ssm.us-east-1.amazonaws.com", 60)
+
+	ips, ttl, err := newDNSClient(server, false, appconfig.TLSCfg{}).lookup(context.Background(), "ssm.us-east-1.amazonaws.com")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.0.2.10", "2001:db8::10"}, []string{ips[0].String(), ips[1].String()})
+	assert.Equal(t, 60*time.Second, ttl)
+}
+
+func TestDNSClient_LookupUnknownHost(t *testing.T) {
+	server := startDNSServer(t, "ssm.us-east-1.amazonaws.com", 60)
+
+	_, _, err := newDNSClient(server, false, appconfig.TLSCfg{}).lookup(context.Background(), "unknown.amazonaws.com")
+
+	assert.EqualError(t, err, "lookup unknown.amazonaws.com on "+server+": no such host")
+}
+
+func TestParseDNSResponse_UnexpectedID(t *testing.T) {
+	response, _ := (&dnsmessage.Message{Header: dnsmessage.Header{ID: 1, Response: true}}).Pack()
+
+	_, _, err := parseDNSResponse(response, 2)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"os"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// imdsEndpointModeEnvVar selects the endpoint of the instance metadata service used by the aws sdk
+	imdsEndpointModeEnvVar = "AWS_EC2_METADATA_SERVICE_ENDPOINT_MODE"
+	imdsEndpointModeIPv6   = "IPv6"
+)
+
+// SetIMDSEndpointMode makes the instance metadata service clients of the agent, and of the processes it starts,
+// use the IPv6 endpoint of the instance metadata service when IPv6 is forced. An endpoint mode already set in the
+// environment is kept. It is called during agent startup before any instance metadata service client is created.
+func SetIMDSEndpointMode(log log.T, appConfig appconfig.SsmagentConfig) {
+	if appConfig.Network.IPMode != appconfig.NetworkIPModeIPv6Only {
+		return
+	}
+	if mode := os.Getenv(imdsEndpointModeEnvVar); mode != "" {
+		log.Infof("Keeping instance metadata service endpoint mode %v from environment variable %v", mode, imdsEndpointModeEnvVar)
+		return
+	}
+	if err := os.Setenv(imdsEndpointModeEnvVar, imdsEndpointModeIPv6); err != nil {
+		log.Warnf("Failed to select the IPv6 instance metadata service endpoint: %v", err)
+		return
+	}
+	log.Infof("Using the IPv6 instance metadata service endpoint")
+}
@@ -0,0 +1,57 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// setIMDSEndpointModeEnv sets the endpoint mode environment variable for the duration of the test
+func setIMDSEndpointModeEnv(t *testing.T, value string) {
+	original, found := os.LookupEnv(imdsEndpointModeEnvVar)
+	os.Setenv(imdsEndpointModeEnvVar, value)
+	t.Cleanup(func() {
+		if found {
+			os.Setenv(imdsEndpointModeEnvVar, original)
+		} else {
+			os.Unsetenv(imdsEndpointModeEnvVar)
+		}
+	})
+}
+
+func TestSetIMDSEndpointMode(t *testing.T) {
+	setIMDSEndpointModeEnv(t, "")
+	appConfig := appconfig.DefaultConfig()
+
+	SetIMDSEndpointMode(log.NewMockLog(), appConfig)
+	assert.Equal(t, "", os.Getenv(imdsEndpointModeEnvVar))
+
+	appConfig.Network.IPMode = appconfig.NetworkIPModeIPv6Only
+	SetIMDSEndpointMode(log.NewMockLog(), appConfig)
+	assert.Equal(t, imdsEndpointModeIPv6, os.Getenv(imdsEndpointModeEnvVar))
+}
+
+func TestSetIMDSEndpointMode_KeepsEnvironment(t *testing.T) {
+	setIMDSEndpointModeEnv(t, "IPv4")
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Network.IPMode = appconfig.NetworkIPModeIPv6Only
+
+	SetIMDSEndpointMode(log.NewMockLog(), appConfig)
+	assert.Equal(t, "IPv4", os.Getenv(imdsEndpointModeEnvVar))
+}
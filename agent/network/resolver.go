@@ -0,0 +1,187 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// systemLookupIPAddr resolves the IP addresses of a host with the system resolver
+var systemLookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// resolverConfig holds the appconfig settings of the agent resolver
+type resolverConfig struct {
+	dnsServers      []string
+	dnsOverTLS      bool
+	tlsCfg          appconfig.TLSCfg
+	cacheDuration   time.Duration
+	staleEntryLimit time.Duration
+}
+
+// resolver resolves the host names of the outbound connections with the configured DNS servers, then with the
+// system resolver. The resolutions are cached and shared by the concurrent lookups of a host, and the last
+// resolution of a host is used when it cannot be resolved anymore.
+type resolver struct {
+	config     resolverConfig
+	dnsClients []*dnsClient
+	now        func() time.Time
+	mutex      sync.Mutex
+	cache      map[string]resolverCacheEntry
+	inflight   map[string]*resolverLookup
+}
+
+type resolverCacheEntry struct {
+	ips      []net.IP
+	resolved time.Time
+	expires  time.Time
+}
+
+// resolverLookup is an ongoing lookup of a host, its result is shared once done is closed
+type resolverLookup struct {
+	done chan struct{}
+	ips  []net.IP
+	err  error
+}
+
+var (
+	sharedResolver      *resolver
+	sharedResolverMutex sync.Mutex
+)
+
+// getResolver returns the resolver shared by the outbound connections, it is recreated when the appconfig
+// network settings change
+func getResolver(appConfig appconfig.SsmagentConfig) *resolver {
+	config := resolverConfig{
+		dnsServers:    appConfig.Network.DNSServers,
+		dnsOverTLS:    appConfig.Network.DNSOverTLS,
+		tlsCfg:        appConfig.TLS,
+		cacheDuration: time.Duration(appConfig.Network.DNSCacheSeconds) * time.Second,
+		// the last resolution of a host is used for a day when the host cannot be resolved anymore
+		staleEntryLimit: 24 * time.Hour,
+	}
+
+	sharedResolverMutex.Lock()
+	defer sharedResolverMutex.Unlock()
+	if sharedResolver == nil || !reflect.DeepEqual(sharedResolver.config, config) {
+		sharedResolver = newResolver(config)
+	}
+	return sharedResolver
+}
+
+// LookupHost returns the IP addresses of the host resolved like the host names of the outbound connections
+func LookupHost(ctx context.Context, appConfig appconfig.SsmagentConfig, host string) ([]net.IP, error) {
+	return getResolver(appConfig).LookupIP(ctx, host)
+}
+
+func newResolver(config resolverConfig) *resolver {
+	r := &resolver{
+		config:   config,
+		now:      time.Now,
+		cache:    make(map[string]resolverCacheEntry),
+		inflight: make(map[string]*resolverLookup),
+	}
+	for _, server := range config.dnsServers {
+		r.dnsClients = append(r.dnsClients, newDNSClient(server, config.dnsOverTLS, config.tlsCfg))
+	}
+	return r
+}
+
+// isEnabled returns true if the resolver caches the resolutions or queries its own DNS servers
+func (r *resolver) isEnabled() bool {
+	return r.config.cacheDuration > 0 || len(r.dnsClients) > 0
+}
+
+// LookupIP returns the IP addresses of the host
+func (r *resolver) LookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	r.mutex.Lock()
+	if entry, found := r.cache[host]; found && r.now().Before(entry.expires) {
+		r.mutex.Unlock()
+		return entry.ips, nil
+	}
+	lookup, found := r.inflight[host]
+	if !found {
+		lookup = &resolverLookup{done: make(chan struct{})}
+		r.inflight[host] = lookup
+		go r.resolve(host, lookup)
+	}
+	r.mutex.Unlock()
+
+	select {
+	case <-lookup.done:
+		return lookup.ips, lookup.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolve runs the lookup shared by the callers resolving the host, it is not canceled with their contexts
+func (r *resolver) resolve(host string, lookup *resolverLookup) {
+	ips, ttl, err := r.lookup(context.Background(), host)
+
+	r.mutex.Lock()
+	delete(r.inflight, host)
+	now := r.now()
+	if err == nil {
+		if cacheDuration := r.cacheDuration(ttl); cacheDuration > 0 {
+			r.cache[host] = resolverCacheEntry{ips: ips, resolved: now, expires: now.Add(cacheDuration)}
+		}
+	} else if entry, found := r.cache[host]; found && now.Sub(entry.resolved) < r.config.staleEntryLimit {
+		ips, err = entry.ips, nil
+	}
+	r.mutex.Unlock()
+
+	lookup.ips, lookup.err = ips, err
+	close(lookup.done)
+}
+
+// cacheDuration returns the time a resolution is cached, the TTL of the records is respected when it is known
+func (r *resolver) cacheDuration(ttl time.Duration) time.Duration {
+	if ttl >= 0 && ttl < r.config.cacheDuration {
+		return ttl
+	}
+	return r.config.cacheDuration
+}
+
+// lookup resolves the host with the configured DNS servers, then with the system resolver. The TTL is negative
+// when it is unknown.
+func (r *resolver) lookup(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	var dnsServerErr error
+	for _, client := range r.dnsClients {
+		ips, ttl, err := client.lookup(ctx, host)
+		if err == nil {
+			return ips, ttl, nil
+		}
+		dnsServerErr = err
+	}
+
+	addrs, err := systemLookupIPAddr(ctx, host)
+	if err != nil {
+		if dnsServerErr != nil {
+			return nil, 0, fmt.Errorf("%v, %v", dnsServerErr, err)
+		}
+		return nil, 0, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+	return ips, -1, nil
+}
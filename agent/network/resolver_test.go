@@ -0,0 +1,150 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSystemLookup makes the system resolver return the result of lookup and counts its calls
+func mockSystemLookup(t *testing.T, lookup func(host string) ([]net.IPAddr, error)) *int32 {
+	var calls int32
+	original := systemLookupIPAddr
+	systemLookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		atomic.AddInt32(&calls, 1)
+		return lookup(host)
+	}
+	t.Cleanup(func() { systemLookupIPAddr = original })
+	return &calls
+}
+
+// newTestResolver creates a resolver with a clock moved by the test
+func newTestResolver(config resolverConfig) (*resolver, *time.Time) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newResolver(config)
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestResolver_CachesResolution(t *testing.T) {
+	calls := mockSystemLookup(t, func(host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("192.0.2.10")}}, nil
+	})
+	r, now := newTestResolver(resolverConfig{cacheDuration: time.Minute, staleEntryLimit: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		ips, err := r.LookupIP(context.Background(), "ssm.us-east-1.amazonaws.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "192.0.2.10", ips[0].String())
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	*now = now.Add(time.Minute)
+	_, err := r.LookupIP(context.Background(), "ssm.us-east-1.amazonaws.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestResolver_RespectsTTL(t *testing.T) {
+	server := startDNSServer(t, "ssm.us-east-1.amazonaws.com", 10)
+	calls := mockSystemLookup(t, func(host string) ([]net.IPAddr, error) {
+		return nil, errors.New("system resolver is broken")
+	})
+	r, now := newTestResolver(resolverConfig{dnsServers: []string{server}, cacheDuration: time.Minute, staleEntryLimit: time.Hour})
+	r.LookupIP(context.Background(), "ssm.us-east-1.amazonaws.com")
+
+	assert.Equal(t, now.Add(10*time.Second), r.cache["ssm.us-east-1.amazonaws.com"].expires)
+	assert.Equal(t, int32(0), atomic.LoadInt32(calls))
+}
+
+func TestResolver_FallsBackToSystemResolver(t *testing.T) {
+	calls := mockSystemLookup(t, func(host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("192.0.2.10")}}, nil
+	})
+	// nothing listens on the discard port
+	r, _ := newTestResolver(resolverConfig{dnsServers: []string{"127.0.0.1:9"}, staleEntryLimit: time.Hour})
+
+	ips, err := r.LookupIP(context.Background(), "ssm.us-east-1.amazonaws.com")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "192.0.2.10", ips[0].String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestResolver_UsesStaleEntryWhenLookupFails(t *testing.T) {
+	failing := false
+	mockSystemLookup(t, func(host string) ([]net.IPAddr, error) {
+		if failing {
+			return nil, errors.New("no such host")
+		}
+		return []net.IPAddr{{IP: net.ParseIP("192.0.2.10")}}, nil
+	})
+	r, now := newTestResolver(resolverConfig{cacheDuration: time.Minute, staleEntryLimit: time.Hour})
+	r.LookupIP(context.Background(), "ssm.us-east-1.amazonaws.com")
+	failing = true
+
+	*now = now.Add(30 * time.Minute)
+	ips, err := r.LookupIP(context.Background(), "ssm.us-east-1.amazonaws.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "192.0.2.10", ips[0].String())
+
+	*now = now.Add(time.Hour)
+	_, err = r.LookupIP(context.Background(), "ssm.us-east-1.amazonaws.com")
+	assert.Error(t, err)
+}
+
+func TestResolver_SharesConcurrentLookups(t *testing.T) {
+	release := make(chan struct{})
+	calls := mockSystemLookup(t, func(host string) ([]net.IPAddr, error) {
+		<-release
+		return []net.IPAddr{{IP: net.ParseIP("192.0.2.10")}}, nil
+	})
+	r, _ := newTestResolver(resolverConfig{staleEntryLimit: time.Hour})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.LookupIP(context.Background(), "ssm.us-east-1.amazonaws.com")
+			assert.NoError(t, err)
+		}()
+	}
+	// let the lookups start before the system resolver answers
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestGetResolver_RecreatedWhenConfigChanges(t *testing.T) {
+	appConfig := appconfig.DefaultConfig()
+	first := getResolver(appConfig)
+	assert.True(t, first == getResolver(appConfig))
+
+	appConfig.Network.DNSServers = []string{"10.0.0.2"}
+	second := getResolver(appConfig)
+	assert.False(t, first == second)
+	assert.True(t, second.isEnabled())
+}
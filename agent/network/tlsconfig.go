@@ -42,6 +42,7 @@ func GetDefaultTLSConfig(log log.T, appConfig appconfig.SsmagentConfig) *tls.Con
 		tlsConfig = &tls.Config{}
 	}
 	tlsConfigCopy := tlsConfig.Clone()
+	applyTLSPolicy(tlsConfigCopy, appConfig.TLS)
 
 	retryCount := 0
 	for retryCount < maxRetryCount {
@@ -0,0 +1,89 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"crypto/tls"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+var tlsVersions = map[string]uint16{
+	appconfig.TLSVersion12: tls.VersionTLS12,
+	appconfig.TLSVersion13: tls.VersionTLS13,
+}
+
+// tlsPolicy is the TLS policy resolved from appconfig
+type tlsPolicy struct {
+	minVersion          uint16
+	cipherSuites        []uint16
+	cipherSuiteNames    []string
+	invalidCipherSuites []string
+}
+
+// getTLSPolicy resolves the configured minimum TLS version and cipher suites.
+// Unknown and insecure cipher suites are dropped, the go defaults are used when no configured cipher suite is valid.
+func getTLSPolicy(tlsCfg appconfig.TLSCfg) tlsPolicy {
+	policy := tlsPolicy{minVersion: tls.VersionTLS12}
+	if version, found := tlsVersions[tlsCfg.MinVersion]; found {
+		policy.minVersion = version
+	}
+
+	secureCipherSuites := make(map[string]uint16)
+	for _, cipherSuite := range tls.CipherSuites() {
+		secureCipherSuites[cipherSuite.Name] = cipherSuite.ID
+	}
+	for _, name := range tlsCfg.CipherSuites {
+		name = strings.TrimSpace(name)
+		if id, found := secureCipherSuites[name]; found {
+			policy.cipherSuites = append(policy.cipherSuites, id)
+			policy.cipherSuiteNames = append(policy.cipherSuiteNames, name)
+		} else {
+			policy.invalidCipherSuites = append(policy.invalidCipherSuites, name)
+		}
+	}
+	return policy
+}
+
+// applyTLSPolicy applies the appconfig TLS policy to the given TLS config
+func applyTLSPolicy(config *tls.Config, tlsCfg appconfig.TLSCfg) {
+	policy := getTLSPolicy(tlsCfg)
+	config.MinVersion = policy.minVersion
+	config.CipherSuites = policy.cipherSuites
+}
+
+// LogTLSPolicy validates the appconfig TLS policy and logs the effective policy, it is called during agent startup
+func LogTLSPolicy(log log.T, appConfig appconfig.SsmagentConfig) {
+	policy := getTLSPolicy(appConfig.TLS)
+	for _, name := range policy.invalidCipherSuites {
+		log.Warnf("Ignoring unknown or insecure TLS cipher suite %v in appconfig", name)
+	}
+
+	minVersion := appconfig.TLSVersion12
+	if policy.minVersion == tls.VersionTLS13 {
+		minVersion = appconfig.TLSVersion13
+	}
+	log.Infof("TLS minimum version: %v", minVersion)
+
+	switch {
+	case policy.minVersion == tls.VersionTLS13:
+		log.Info("TLS cipher suites: TLS 1.3 defaults, TLS 1.3 cipher suites are not configurable")
+	case len(policy.cipherSuites) > 0:
+		log.Infof("TLS cipher suites: %v", strings.Join(policy.cipherSuiteNames, ", "))
+	default:
+		log.Info("TLS cipher suites: defaults")
+	}
+}
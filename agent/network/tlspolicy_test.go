@@ -0,0 +1,64 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTLSPolicy_Defaults(t *testing.T) {
+	policy := getTLSPolicy(appconfig.TLSCfg{})
+
+	assert.Equal(t, uint16(tls.VersionTLS12), policy.minVersion)
+	assert.Nil(t, policy.cipherSuites)
+	assert.Nil(t, policy.invalidCipherSuites)
+}
+
+func TestGetTLSPolicy_CipherSuites(t *testing.T) {
+	policy := getTLSPolicy(appconfig.TLSCfg{
+		MinVersion: appconfig.TLSVersion12,
+		CipherSuites: []string{
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			"TLS_RSA_WITH_RC4_128_SHA",
+			"UNKNOWN_CIPHER",
+		},
+	})
+
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, policy.cipherSuites)
+	assert.Equal(t, []string{"TLS_RSA_WITH_RC4_128_SHA", "UNKNOWN_CIPHER"}, policy.invalidCipherSuites)
+}
+
+func TestGetDefaultTLSConfig_AppliesPolicy(t *testing.T) {
+	appConfig := appconfig.DefaultConfig()
+	appConfig.TLS.MinVersion = appconfig.TLSVersion13
+
+	config := GetDefaultTLSConfig(log.NewMockLog(), appConfig)
+
+	assert.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+}
+
+func TestLogTLSPolicy_WarnsInvalidCipherSuites(t *testing.T) {
+	logMock := log.NewMockLog()
+	appConfig := appconfig.DefaultConfig()
+	appConfig.TLS.CipherSuites = []string{"UNKNOWN_CIPHER"}
+
+	LogTLSPolicy(logMock, appConfig)
+
+	logMock.AssertCalled(t, "Warnf", "Ignoring unknown or insecure TLS cipher suite %v in appconfig", []interface{}{"UNKNOWN_CIPHER"})
+}
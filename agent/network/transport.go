@@ -1,14 +1,24 @@
 package network
 
 import (
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
+// GetDefaultTransport returns the transport shared by agent network calls.
+// Proxies are taken from the proxy environment variables set up by the agent, including credentials
+// embedded in the proxy url and the no_proxy bypass list.
+// Connections are bound to the source address or interface of the appconfig network config.
 func GetDefaultTransport(log log.T, appConfig appconfig.SsmagentConfig) *http.Transport {
 	result := http.DefaultTransport.(*http.Transport).Clone()
 	result.TLSClientConfig = GetDefaultTLSConfig(log, appConfig)
+	result.DialContext = GetDialContext(appConfig, &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	})
 	return result
 }
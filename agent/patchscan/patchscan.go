@@ -0,0 +1,186 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package patchscan periodically submits patch compliance scans as local command documents, independently
+// of the maintenance windows and of the patch install operations.
+package patchscan
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/moduleguard"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/rundocument"
+	"github.com/carlescere/scheduler"
+)
+
+const (
+	name = "PatchScan"
+
+	// scanDocumentName is the name of the local command document submitting the scan, it is also the
+	// topic of the command processed by the offline command service
+	scanDocumentName = "AWS-PatchScan"
+	// lastScanFileName is the file whose modification time records the last scan submission
+	lastScanFileName = "lastPatchScan"
+	// maxInitialDelayMinutes bounds the random delay spreading the first scan of the fleet
+	maxInitialDelayMinutes = 60
+)
+
+// PatchScan encapsulates the logic on scheduling the patch compliance scans
+type PatchScan struct {
+	context context.T
+	scanJob *scheduler.Job
+	stop    chan bool
+}
+
+// NewPatchScan creates a new patch scan core module
+func NewPatchScan(context context.T) *PatchScan {
+	return &PatchScan{
+		context: context.With("[" + name + "]"),
+		stop:    make(chan bool, 1),
+	}
+}
+
+// ICoreModule implementation
+
+// ModuleName returns the module name
+func (p *PatchScan) ModuleName() string {
+	return name
+}
+
+// ModuleExecute schedules the patch scans when a scan frequency is configured
+func (p *PatchScan) ModuleExecute() (err error) {
+	log := p.context.Log()
+	frequency := p.context.AppConfig().Ssm.PatchScan.FrequencyMinutes
+	if frequency == 0 {
+		log.Debug("Agent scheduled patch scans are disabled.")
+		return
+	}
+	log.Infof("Agent scheduled patch scans run every %d minutes.", frequency)
+
+	next := initialDelay(p.lastScanPath(), time.Duration(frequency)*time.Minute, time.Now())
+	moduleguard.Go(log, name, func() {
+		select {
+		case <-time.After(next):
+			var scheduleErr error
+			if p.scanJob, scheduleErr = scheduler.Every(frequency).Minutes().Run(moduleguard.Wrap(log, name, p.submitScan)); scheduleErr != nil {
+				log.Errorf("unable to schedule patch scan. %v", scheduleErr)
+			}
+		case <-p.stop:
+		}
+	})
+	return
+}
+
+// ModuleStop stops the patch scan schedule
+func (p *PatchScan) ModuleStop() (err error) {
+	p.stop <- true
+	if p.scanJob != nil {
+		p.context.Log().Info("stopping patch scan job.")
+		p.scanJob.Quit <- true
+	}
+	return nil
+}
+
+// initialDelay returns the delay before the first scan, the scans keep their frequency across agent restarts
+// and the first scan of an instance is delayed randomly to spread the load of the fleet
+func initialDelay(lastScanPath string, frequency time.Duration, now time.Time) time.Duration {
+	if info, err := os.Stat(lastScanPath); err == nil {
+		if delay := info.ModTime().Add(frequency).Sub(now); delay > 0 {
+			return delay
+		}
+		return 0
+	}
+	maxDelay := frequency
+	if maxDelay > maxInitialDelayMinutes*time.Minute {
+		maxDelay = maxInitialDelayMinutes * time.Minute
+	}
+	return time.Duration(rand.Int63n(int64(maxDelay)))
+}
+
+// submitScan writes the scan document in the local command folder, a scan still waiting to be picked up by
+// the offline command service is not submitted twice
+func (p *PatchScan) submitScan() {
+	log := p.context.Log()
+	documentPath := filepath.Join(appconfig.LocalCommandRoot, scanDocumentName)
+	if fileutil.Exists(documentPath) {
+		log.Infof("Previous patch scan is still pending, skipping this scan.")
+		return
+	}
+
+	content, err := jsonutil.Marshal(scanDocument(p.context.AppConfig().Ssm.PatchScan))
+	if err != nil {
+		log.Errorf("Failed to build the patch scan document: %v", err)
+		return
+	}
+
+	// the document is staged next to the local command folder and moved into it so that the offline
+	// command service never reads a partially written document
+	stagingPath := filepath.Join(filepath.Dir(appconfig.LocalCommandRoot), scanDocumentName+".tmp")
+	if err = fileutil.MakeDirs(appconfig.LocalCommandRoot); err == nil {
+		if _, err = fileutil.WriteIntoFileWithPermissions(stagingPath, content, appconfig.ReadWriteAccess); err == nil {
+			err = os.Rename(stagingPath, documentPath)
+		}
+	}
+	if err != nil {
+		log.Errorf("Failed to submit the patch scan: %v", err)
+		return
+	}
+	log.Infof("Submitted patch scan with document %v", p.context.AppConfig().Ssm.PatchScan.DocumentName)
+
+	lastScanPath := p.lastScanPath()
+	if err = fileutil.MakeDirs(filepath.Dir(lastScanPath)); err == nil {
+		_, err = fileutil.WriteIntoFileWithPermissions(lastScanPath, time.Now().UTC().Format(time.RFC3339), appconfig.ReadWriteAccess)
+	}
+	if err != nil {
+		log.Debugf("Failed to record the patch scan submission: %v", err)
+	}
+}
+
+// lastScanPath returns the path of the file recording the last scan submission
+func (p *PatchScan) lastScanPath() string {
+	instanceID, _ := p.context.Identity().InstanceID()
+	return filepath.Join(appconfig.DefaultDataStorePath, instanceID, lastScanFileName)
+}
+
+// scanDocument returns the local command document running the patch baseline document with the Scan operation
+func scanDocument(config appconfig.PatchScanCfg) contracts.DocumentContent {
+	parameters := map[string]interface{}{
+		"Operation": "Scan",
+	}
+	if config.BaselineOverride != "" {
+		parameters["BaselineOverride"] = config.BaselineOverride
+	}
+	return contracts.DocumentContent{
+		SchemaVersion: "2.2",
+		Description:   "Patch compliance scan scheduled by the agent",
+		MainSteps: []*contracts.InstancePluginConfig{
+			{
+				Action: appconfig.PluginRunDocument,
+				Name:   name,
+				Inputs: map[string]interface{}{
+					"documentType":       rundocument.SSMDocumentType,
+					"documentPath":       config.DocumentName,
+					"documentParameters": parameters,
+				},
+			},
+		},
+	}
+}
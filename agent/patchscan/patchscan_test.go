@@ -0,0 +1,75 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package patchscan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanDocument(t *testing.T) {
+	document := scanDocument(appconfig.PatchScanCfg{DocumentName: appconfig.DefaultPatchScanDocumentName})
+	assert.Equal(t, "2.2", document.SchemaVersion)
+	assert.Len(t, document.MainSteps, 1)
+	assert.Equal(t, appconfig.PluginRunDocument, document.MainSteps[0].Action)
+	assert.Equal(t, map[string]interface{}{
+		"documentType":       "SSMDocument",
+		"documentPath":       appconfig.DefaultPatchScanDocumentName,
+		"documentParameters": map[string]interface{}{"Operation": "Scan"},
+	}, document.MainSteps[0].Inputs)
+
+	document = scanDocument(appconfig.PatchScanCfg{DocumentName: "MyPatchBaselineDocument", BaselineOverride: "s3://bucket/baseline.json"})
+	inputs := document.MainSteps[0].Inputs.(map[string]interface{})
+	assert.Equal(t, "MyPatchBaselineDocument", inputs["documentPath"])
+	assert.Equal(t, map[string]interface{}{"Operation": "Scan", "BaselineOverride": "s3://bucket/baseline.json"}, inputs["documentParameters"])
+
+	// the document must be readable by the offline command service
+	content, err := jsonutil.Marshal(document)
+	assert.Nil(t, err)
+	assert.Contains(t, content, `"action":"aws:runDocument"`)
+}
+
+func TestInitialDelay(t *testing.T) {
+	now := time.Now()
+	frequency := 24 * time.Hour
+	lastScanPath := filepath.Join(t.TempDir(), lastScanFileName)
+
+	// first scan is spread over the first hour
+	delay := initialDelay(lastScanPath, frequency, now)
+	assert.True(t, delay >= 0 && delay < maxInitialDelayMinutes*time.Minute)
+
+	// the frequency is kept across restarts
+	assert.Nil(t, os.WriteFile(lastScanPath, []byte{}, 0600))
+	assert.Nil(t, os.Chtimes(lastScanPath, now.Add(-time.Hour), now.Add(-time.Hour)))
+	assert.Equal(t, 23*time.Hour, initialDelay(lastScanPath, frequency, now).Round(time.Minute))
+
+	// overdue scans run right away
+	assert.Nil(t, os.Chtimes(lastScanPath, now.Add(-48*time.Hour), now.Add(-48*time.Hour)))
+	assert.Equal(t, time.Duration(0), initialDelay(lastScanPath, frequency, now))
+}
+
+func TestModuleExecuteDisabled(t *testing.T) {
+	patchScan := NewPatchScan(contextmocks.NewMockDefault())
+	assert.Equal(t, name, patchScan.ModuleName())
+	assert.Nil(t, patchScan.ModuleExecute())
+	assert.Nil(t, patchScan.scanJob)
+	assert.Nil(t, patchScan.ModuleStop())
+}
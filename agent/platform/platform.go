@@ -162,3 +162,8 @@ func (b byIndex) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
 func IsPlatformNanoServer(log log.T) (bool, error) {
 	return isPlatformNanoServer(log)
 }
+
+// IsPlatformServerCore returns true on Windows Server installed without the desktop experience
+func IsPlatformServerCore(log log.T) (bool, error) {
+	return isPlatformServerCore(log)
+}
@@ -272,3 +272,7 @@ func fullyQualifiedDomainName(log log.T) string {
 func isPlatformNanoServer(log log.T) (bool, error) {
 	return false, nil
 }
+
+func isPlatformServerCore(log log.T) (bool, error) {
+	return false, nil
+}
@@ -24,9 +24,11 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"golang.org/x/sys/windows/registry"
 )
 
 const caption = "Caption"
@@ -42,8 +44,69 @@ const (
 	ProductStandardNanoServer = "144"
 )
 
-// IsPlatformNanoServer returns true if SKU is 143 or 144
+// currentVersionKey is the registry key describing the installed version of Windows
+const currentVersionKey = `SOFTWARE\Microsoft\Windows NT\CurrentVersion`
+
+// Installation types of Windows https://docs.microsoft.com/en-us/windows-server/administration/server-core/server-core-getting-started
+const (
+	// InstallationTypeServerCore is the installation type of Windows Server without the desktop experience
+	InstallationTypeServerCore = "Server Core"
+
+	// InstallationTypeNanoServer is the installation type of Nano Server
+	InstallationTypeNanoServer = "Nano Server"
+)
+
+var (
+	installationTypeOnce  sync.Once
+	installationTypeValue string
+	installationTypeErr   error
+)
+
+// installationType returns the installation type of Windows read from the registry, which is available on every
+// installation type unlike wmic and Windows PowerShell
+func installationType() (string, error) {
+	installationTypeOnce.Do(func() {
+		installationTypeValue, installationTypeErr = getCurrentVersionString("InstallationType")
+	})
+	return installationTypeValue, installationTypeErr
+}
+
+func getCurrentVersionString(name string) (string, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, currentVersionKey, registry.QUERY_VALUE)
+	if err != nil {
+		return "", err
+	}
+	defer key.Close()
+	value, _, err := key.GetStringValue(name)
+	return value, err
+}
+
+func getCurrentVersionInteger(name string) (uint64, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, currentVersionKey, registry.QUERY_VALUE)
+	if err != nil {
+		return 0, err
+	}
+	defer key.Close()
+	value, _, err := key.GetIntegerValue(name)
+	return value, err
+}
+
+// isPlatformServerCore returns true if Windows Server is installed without the desktop experience
+func isPlatformServerCore(log log.T) (bool, error) {
+	value, err := installationType()
+	if err != nil {
+		log.Debugf("Failed to read the installation type - %v", err)
+		return false, err
+	}
+	return value == InstallationTypeServerCore, nil
+}
+
+// IsPlatformNanoServer returns true if the installation type is Nano Server or if SKU is 143 or 144
 func isPlatformNanoServer(log log.T) (bool, error) {
+	if value, err := installationType(); err == nil {
+		return value == InstallationTypeNanoServer, nil
+	}
+
 	var sku string
 	var err error
 
@@ -62,7 +125,13 @@ func isPlatformNanoServer(log log.T) (bool, error) {
 }
 
 func getPlatformName(log log.T) (value string, err error) {
-	return getPlatformDetails(caption, log)
+	if value, err = getPlatformDetails(caption, log); err != nil {
+		// wmic is not installed on every installation type
+		if productName, registryErr := getCurrentVersionString("ProductName"); registryErr == nil {
+			return "Microsoft " + productName, nil
+		}
+	}
+	return
 }
 
 func getPlatformType(log log.T) (value string, err error) {
@@ -70,7 +139,29 @@ func getPlatformType(log log.T) (value string, err error) {
 }
 
 func getPlatformVersion(log log.T) (value string, err error) {
-	return getPlatformDetails(version, log)
+	if value, err = getPlatformDetails(version, log); err != nil {
+		if registryVersion, registryErr := getRegistryPlatformVersion(); registryErr == nil {
+			return registryVersion, nil
+		}
+	}
+	return
+}
+
+// getRegistryPlatformVersion returns the version of Windows in the major.minor.build format of wmic
+func getRegistryPlatformVersion() (string, error) {
+	major, err := getCurrentVersionInteger("CurrentMajorVersionNumber")
+	if err != nil {
+		return "", err
+	}
+	minor, err := getCurrentVersionInteger("CurrentMinorVersionNumber")
+	if err != nil {
+		return "", err
+	}
+	build, err := getCurrentVersionString("CurrentBuildNumber")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d.%d.%s", major, minor, build), nil
 }
 
 func getPlatformSku(log log.T) (value string, err error) {
@@ -21,7 +21,10 @@ package application
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -40,10 +43,21 @@ const (
 
 	// defaultWorkingDirectory represents the default working directory
 	defaultWorkingDirectory = ""
+
+	// installInProgressRetries is the number of times the installer is run again while another installation is in progress
+	installInProgressRetries = 5
+
+	// installerLogFileName is the name of the verbose msiexec log in the orchestration directory
+	installerLogFileName = "msiexec.log.txt"
 )
 
-// msiExecCommand is the command for installing msi applications
-var msiExecCommand = filepath.Join(os.Getenv("SystemRoot"), "System32", "msiexec.exe")
+var (
+	// msiExecCommand is the command for installing msi applications
+	msiExecCommand = filepath.Join(os.Getenv("SystemRoot"), "System32", "msiexec.exe")
+
+	// installInProgressRetryDelay is the delay before running the installer again while another installation is in progress
+	installInProgressRetryDelay = 30 * time.Second
+)
 
 // Plugin is the type for the applications plugin.
 type Plugin struct {
@@ -61,6 +75,14 @@ type ApplicationPluginInput struct {
 	Source         string
 	SourceHash     string
 	SourceHashType string
+	// InstallerType is Msi or Exe, it defaults to Exe for sources with the .exe extension and to Msi otherwise
+	InstallerType string
+	// Properties are the public properties passed to msiexec
+	Properties map[string]string
+	// Transforms are the local paths of the transforms applied to the msi package
+	Transforms []string
+	// ArchitectureSources are the installers by architecture (amd64, arm64), the one matching the instance is installed
+	ArchitectureSources map[string]pluginutil.ArchitectureSource
 }
 
 // NewPlugin returns a new instance of the plugin.
@@ -121,13 +143,18 @@ func (p *Plugin) runCommands(pluginID string, pluginInput ApplicationPluginInput
 		return
 	}
 
-	// Get application mode
-	mode, err := getMsiApplicationMode(log, pluginInput)
+	source, err := pluginutil.SelectArchitectureSource(log, pluginutil.ArchitectureSource{Source: pluginInput.Source, SourceHash: pluginInput.SourceHash, SourceHashType: pluginInput.SourceHashType}, pluginInput.ArchitectureSources)
 	if err != nil {
 		output.MarkAsFailed(err)
 		return
 	}
-	log.Debugf("mode is %v", mode)
+	pluginInput.Source, pluginInput.SourceHash, pluginInput.SourceHashType = source.Source, source.SourceHash, source.SourceHashType
+
+	installerType := getInstallerType(pluginInput)
+	if err = validateInstallerInput(log, installerType, pluginInput); err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
 
 	var localFilePath string
 	// Download file from source if available
@@ -140,33 +167,58 @@ func (p *Plugin) runCommands(pluginID string, pluginInput ApplicationPluginInput
 	localFilePath = downloadOutput.LocalFilePath
 	log.Debugf("local path to file is %v", localFilePath)
 
-	// Create msi related log file
-	localSourceLogFilePath := localFilePath + ".msiexec.log.txt"
-	log.Debugf("log path is %v", localSourceLogFilePath)
+	// Only delete the file if source is not a local path
+	if exists, err := fileutil.LocalFileExist(pluginInput.Source); err == nil && !exists {
+		// delete downloaded file, if it exists
+		defer func() { pluginutil.CleanupFile(log, localFilePath) }()
+
+		// downloaded files are named after the hash of their url, executables need their extension to be started
+		if installerType == InstallerTypeExe && !strings.EqualFold(filepath.Ext(localFilePath), ".exe") {
+			if err = os.Rename(localFilePath, localFilePath+".exe"); err != nil {
+				output.MarkAsFailed(fmt.Errorf("failed to prepare installer %v: %v", localFilePath, err))
+				return
+			}
+			localFilePath += ".exe"
+		}
+	}
 
 	// Construct Command Name and Arguments
-	commandName := msiExecCommand
-	commandArguments := []string{mode, localFilePath, "/quiet", "/norestart", "/log", localSourceLogFilePath}
-	if pluginInput.Parameters != "" {
-		log.Debugf("Got Parameters \"%v\"", pluginInput.Parameters)
-		params := processParams(log, pluginInput.Parameters)
-		commandArguments = append(commandArguments, params...)
+	logFilePath := filepath.Join(orchestrationDir, installerLogFileName)
+	var commandName string
+	var commandArguments []string
+	if installerType == InstallerTypeExe {
+		commandName = localFilePath
+		commandArguments = processParams(log, pluginInput.Parameters)
+	} else {
+		log.Debugf("log path is %v", logFilePath)
+		commandName = msiExecCommand
+		if commandArguments, err = getMsiExecArguments(log, pluginInput, localFilePath, logFilePath); err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+	}
+
+	// Execute Command, the installation is attempted again while another installation is in progress
+	var exitCode int
+	for attempt := 0; ; attempt++ {
+		exitCode, err = p.CommandExecuter.NewExecute(p.context, defaultWorkingDirectory, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, defaultApplicationExecutionTimeoutInSeconds, commandName, commandArguments, make(map[string]string))
+		if exitCode != ErrorInstallAlreadyRunning || attempt >= installInProgressRetries || cancelFlag.Canceled() || cancelFlag.ShutDown() {
+			break
+		}
+		output.AppendInfof("Another installation is in progress, retrying in %v", installInProgressRetryDelay)
+		time.Sleep(installInProgressRetryDelay)
 	}
 
-	// Execute Command
-	exitCode, err := p.CommandExecuter.NewExecute(p.context, defaultWorkingDirectory, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, defaultApplicationExecutionTimeoutInSeconds, commandName, commandArguments, make(map[string]string))
+	if installerType == InstallerTypeMsi {
+		appendInstallerLog(log, logFilePath, output)
+	}
 
 	// Set output status
 	output.SetExitCode(exitCode)
 	setMsiExecStatus(log, pluginInput, cancelFlag, output)
 
-	// Only delete the file if source is not a local path
-	if exists, err := fileutil.LocalFileExist(pluginInput.Source); err == nil && !exists {
-		// delete downloaded file, if it exists
-		pluginutil.CleanupFile(log, downloadOutput.LocalFilePath)
-	}
-
-	if err != nil {
+	// the exit codes of the installer are mapped to a status, only failures to run the installer are reported as errors
+	if _, isExitError := err.(*exec.ExitError); err != nil && !isExitError {
 		output.MarkAsFailed(fmt.Errorf("failed to run commands: %v", err))
 		return
 	}
@@ -0,0 +1,111 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package application
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	executermocks "github.com/aws/amazon-ssm-agent/agent/mocks/executers"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetMsiExecArguments(t *testing.T) {
+	pluginInput := ApplicationPluginInput{
+		Action:     INSTALL,
+		Parameters: "/v \"some path\"",
+		Properties: map[string]string{"INSTALLDIR": `C:\Program Files\App`, "ALLUSERS": "1"},
+		Transforms: []string{`C:\transforms\a.mst`, `C:\transforms\b.mst`},
+	}
+
+	args, err := getMsiExecArguments(log.NewMockLog(), pluginInput, `C:\download\app`, `C:\orchestration\msiexec.log.txt`)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"/i", `C:\download\app`, "/quiet", "/norestart", "/l*v", `C:\orchestration\msiexec.log.txt`,
+		"/v", "\"some path\"",
+		"ALLUSERS=1", `INSTALLDIR=C:\Program Files\App`,
+		`TRANSFORMS=C:\transforms\a.mst;C:\transforms\b.mst`,
+	}, args)
+}
+
+func TestValidateInstallerInput(t *testing.T) {
+	logger := log.NewMockLog()
+	assert.Equal(t, InstallerTypeExe, getInstallerType(ApplicationPluginInput{Source: "https://example.com/setup.EXE"}))
+	assert.Equal(t, InstallerTypeMsi, getInstallerType(ApplicationPluginInput{Source: "https://example.com/setup.msi"}))
+	assert.Equal(t, InstallerTypeMsi, getInstallerType(ApplicationPluginInput{Source: "https://example.com/setup.exe", InstallerType: InstallerTypeMsi}))
+
+	assert.Nil(t, validateInstallerInput(logger, InstallerTypeMsi, ApplicationPluginInput{Action: REPAIR, Properties: map[string]string{"REBOOT": "ReallySuppress"}}))
+	assert.NotNil(t, validateInstallerInput(logger, InstallerTypeMsi, ApplicationPluginInput{Action: INSTALL, Properties: map[string]string{"BAD NAME": "1"}}))
+	assert.NotNil(t, validateInstallerInput(logger, InstallerTypeMsi, ApplicationPluginInput{Action: "Upgrade"}))
+	assert.Nil(t, validateInstallerInput(logger, InstallerTypeExe, ApplicationPluginInput{Action: UNINSTALL}))
+	assert.NotNil(t, validateInstallerInput(logger, InstallerTypeExe, ApplicationPluginInput{Action: REPAIR}))
+	assert.NotNil(t, validateInstallerInput(logger, InstallerTypeExe, ApplicationPluginInput{Action: INSTALL, Transforms: []string{"a.mst"}}))
+	assert.NotNil(t, validateInstallerInput(logger, "Appx", ApplicationPluginInput{Action: INSTALL}))
+}
+
+func TestDecodeInstallerLog(t *testing.T) {
+	units := utf16.Encode([]rune("=== Verbose logging started ==="))
+	content := []byte{0xFF, 0xFE}
+	for _, unit := range units {
+		content = append(content, byte(unit), byte(unit>>8))
+	}
+
+	assert.Equal(t, "=== Verbose logging started ===", decodeInstallerLog(content))
+	assert.Equal(t, "ansi log", decodeInstallerLog([]byte("ansi log")))
+}
+
+func TestRunCommandsRetriesWhileInstallInProgress(t *testing.T) {
+	installInProgressRetryDelay = time.Millisecond
+	defer func() { installInProgressRetryDelay = 30 * time.Second }()
+
+	source := filepath.Join(t.TempDir(), "app.msi")
+	assert.Nil(t, ioutil.WriteFile(source, []byte("msi"), 0600))
+	orchestrationDirectory := t.TempDir()
+	logFilePath := filepath.Join(orchestrationDirectory, "app", installerLogFileName)
+
+	mockExecuter := new(executermocks.MockCommandExecuter)
+	mockExecuter.On("NewExecute", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything, defaultApplicationExecutionTimeoutInSeconds, msiExecCommand, mock.Anything, mock.Anything).
+		Return(ErrorInstallAlreadyRunning, nil).Once()
+	mockExecuter.On("NewExecute", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything, defaultApplicationExecutionTimeoutInSeconds, msiExecCommand, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ioutil.WriteFile(logFilePath, []byte("MainEngineThread is returning 3010"), 0600)
+		}).
+		Return(3010, nil).Once()
+	p := &Plugin{context: contextmocks.NewMockDefault(), CommandExecuter: mockExecuter}
+	output := iohandler.NewDefaultIOHandler(p.context, contracts.IOConfiguration{})
+	cancelFlag := taskmocks.NewMockDefault()
+	cancelFlag.On("Canceled").Return(false)
+	cancelFlag.On("ShutDown").Return(false)
+
+	p.runCommands("pluginID", ApplicationPluginInput{ID: "app", Action: INSTALL, Source: source}, orchestrationDirectory, "", cancelFlag, output)
+
+	mockExecuter.AssertExpectations(t)
+	assert.Equal(t, contracts.ResultStatusSuccessAndReboot, output.GetStatus())
+	assert.Equal(t, 3010, output.GetExitCode())
+	assert.Contains(t, output.GetStdout(), "Another installation is in progress")
+	assert.Contains(t, output.GetStdout(), "MainEngineThread is returning 3010")
+}
@@ -19,7 +19,14 @@
 package application
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -36,12 +43,118 @@ const (
 	REPAIR = "Repair"
 )
 
+const (
+	InstallerTypeMsi = "Msi"
+
+	InstallerTypeExe = "Exe"
+)
+
 const (
 	ErrorUnknownProduct = 1605
 
+	ErrorInstallAlreadyRunning = 1618
+
 	ErrorSuccessRebootInitiated = 1641
 )
 
+// maxInstallerLogBytes is the size of the end of the verbose msiexec log appended to the output
+const maxInstallerLogBytes = 256 * 1024
+
+// validPropertyName matches the names of the msi public properties
+var validPropertyName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// getInstallerType returns the type of installer of the plugin input
+func getInstallerType(pluginInput ApplicationPluginInput) string {
+	if pluginInput.InstallerType != "" {
+		return pluginInput.InstallerType
+	}
+	if strings.EqualFold(filepath.Ext(pluginInput.Source), ".exe") {
+		return InstallerTypeExe
+	}
+	return InstallerTypeMsi
+}
+
+// validateInstallerInput returns an error if the plugin input is not supported by the installer type
+func validateInstallerInput(log log.T, installerType string, pluginInput ApplicationPluginInput) error {
+	switch installerType {
+	case InstallerTypeMsi:
+		if _, err := getMsiApplicationMode(log, pluginInput); err != nil {
+			return err
+		}
+		for name := range pluginInput.Properties {
+			if !validPropertyName.MatchString(name) {
+				return fmt.Errorf("invalid msi property name: %v", name)
+			}
+		}
+		return nil
+	case InstallerTypeExe:
+		if pluginInput.Action != INSTALL && pluginInput.Action != UNINSTALL {
+			return fmt.Errorf("Action %v is not supported by exe installers", pluginInput.Action)
+		}
+		if len(pluginInput.Properties) > 0 || len(pluginInput.Transforms) > 0 {
+			return fmt.Errorf("Properties and Transforms are only supported by msi installers")
+		}
+		return nil
+	default:
+		return fmt.Errorf("InstallerType is set to unsupported value: %v", installerType)
+	}
+}
+
+// getMsiExecArguments returns the msiexec arguments running the action of the plugin input with a verbose log
+func getMsiExecArguments(log log.T, pluginInput ApplicationPluginInput, localFilePath string, logFilePath string) ([]string, error) {
+	mode, err := getMsiApplicationMode(log, pluginInput)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("mode is %v", mode)
+
+	commandArguments := []string{mode, localFilePath, "/quiet", "/norestart", "/l*v", logFilePath}
+	if pluginInput.Parameters != "" {
+		log.Debugf("Got Parameters \"%v\"", pluginInput.Parameters)
+		commandArguments = append(commandArguments, processParams(log, pluginInput.Parameters)...)
+	}
+
+	names := make([]string, 0, len(pluginInput.Properties))
+	for name := range pluginInput.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		commandArguments = append(commandArguments, fmt.Sprintf("%v=%v", name, pluginInput.Properties[name]))
+	}
+	if len(pluginInput.Transforms) > 0 {
+		commandArguments = append(commandArguments, "TRANSFORMS="+strings.Join(pluginInput.Transforms, ";"))
+	}
+	return commandArguments, nil
+}
+
+// appendInstallerLog appends the end of the verbose msiexec log to the output
+func appendInstallerLog(log log.T, logFilePath string, out iohandler.IOHandler) {
+	content, err := ioutil.ReadFile(logFilePath)
+	if err != nil {
+		log.Debugf("Unable to read msiexec log %v: %v", logFilePath, err)
+		return
+	}
+	installerLog := decodeInstallerLog(content)
+	if len(installerLog) > maxInstallerLogBytes {
+		installerLog = "...\n" + installerLog[len(installerLog)-maxInstallerLogBytes:]
+	}
+	out.AppendInfof("msiexec log:\n%v", installerLog)
+}
+
+// decodeInstallerLog returns the text of the msiexec log, which is written in UTF-16 when it starts with a byte order mark
+func decodeInstallerLog(content []byte) string {
+	if !bytes.HasPrefix(content, []byte{0xFF, 0xFE}) {
+		return string(content)
+	}
+	content = content[2:]
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = uint16(content[2*i]) | uint16(content[2*i+1])<<8
+	}
+	return string(utf16.Decode(units))
+}
+
 // getMsiApplicationMode returns the msi exec mode based on plugin input
 func getMsiApplicationMode(log log.T, pluginInput ApplicationPluginInput) (string, error) {
 	switch pluginInput.Action {
@@ -78,11 +191,11 @@ func setMsiExecStatus(log log.T, pluginInput ApplicationPluginInput, cancelFlag
 	case appconfig.CommandStoppedPreemptivelyExitCode:
 		if cancelFlag.ShutDown() {
 			out.SetStatus(contracts.ResultStatusFailed)
-		}
-		if cancelFlag.Canceled() {
+		} else if cancelFlag.Canceled() {
 			out.SetStatus(contracts.ResultStatusCancelled)
+		} else {
+			out.SetStatus(contracts.ResultStatusTimedOut)
 		}
-		out.SetStatus(contracts.ResultStatusTimedOut)
 	default:
 		isUnKnownError = true
 	}
@@ -258,6 +258,9 @@ func downloadFile(ds *PackageService, tracer trace.Tracer, file *archive.File, p
 		DestinationDirectory: appconfig.DownloadRoot,
 		// TODO don't hardcode sha256 - use multiple checksums
 		SourceChecksums: file.Info.Checksums,
+		Resumable:       true,
+		Progress:        packageservice.DownloadProgress(tracer.CurrentTrace()),
+		CacheByChecksum: true,
 	}
 
 	log := tracer.CurrentTrace().Logger
@@ -1067,8 +1067,13 @@ func TestDownloadFile(t *testing.T) {
 					SourceURL:            testdata.file.Info.DownloadLocation,
 					DestinationDirectory: appconfig.DownloadRoot,
 					SourceChecksums:      map[string]string{"sha256": "asdf"},
+					Resumable:            true,
+					CacheByChecksum:      true,
 				}
-				assert.Equal(t, input, testdata.network.downloadInput)
+				actual := testdata.network.downloadInput
+				assert.NotNil(t, actual.Progress)
+				actual.Progress = nil
+				assert.Equal(t, input, actual)
 			}
 		})
 	}
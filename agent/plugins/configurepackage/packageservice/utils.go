@@ -15,6 +15,9 @@ package packageservice
 
 import (
 	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
 )
 
 const Latest = "latest"
@@ -22,3 +25,14 @@ const Latest = "latest"
 func IsLatest(version string) bool {
 	return strings.EqualFold(version, Latest) || version == ""
 }
+
+// DownloadProgress returns the artifact.ProgressFunc appending the progress of a package download to the trace
+func DownloadProgress(trace *trace.Trace) artifact.ProgressFunc {
+	return func(downloaded int64, total int64) {
+		if total > 0 {
+			trace.AppendInfof("downloaded %v%% of the package (%v of %v bytes)", downloaded*100/total, downloaded, total)
+		} else {
+			trace.AppendInfof("downloaded %v bytes of the package", downloaded)
+		}
+	}
+}
@@ -16,6 +16,8 @@ package packageservice
 import (
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,3 +44,14 @@ func TestIsLatest(t *testing.T) {
 		})
 	}
 }
+
+func TestDownloadProgress(t *testing.T) {
+	tracer := trace.NewTracer(log.NewMockLog())
+	downloadTrace := tracer.BeginSection("download artifact")
+
+	progress := DownloadProgress(downloadTrace)
+	progress(512, 2048)
+	progress(4096, 0)
+
+	assert.Equal(t, "downloaded 25% of the package (512 of 2048 bytes)\ndownloaded 4096 bytes of the package\n", downloadTrace.InfoOut.String())
+}
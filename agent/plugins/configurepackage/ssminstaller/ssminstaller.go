@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
@@ -135,7 +136,7 @@ func (inst *Installer) getActionPath(actionName string, extension string) string
 	return filepath.Join(inst.packagePath, fmt.Sprintf("%v.%v", actionName, extension))
 }
 
-func (inst *Installer) readScriptAction(action *Action, workingDir string, orchestrationDir string, pluginName string, runCommand []interface{}, envVars map[string]string) (pluginsInfo []contracts.PluginState, err error) {
+func (inst *Installer) readScriptAction(context context.T, action *Action, workingDir string, orchestrationDir string, pluginName string, runCommand []interface{}, envVars map[string]string) (pluginsInfo []contracts.PluginState, err error) {
 	pluginsInfo = []contracts.PluginState{}
 
 	pluginFullName := fmt.Sprintf("aws:%v", pluginName)
@@ -148,6 +149,10 @@ func (inst *Installer) readScriptAction(action *Action, workingDir string, orche
 	inputs["workingDirectory"] = workingDir
 	inputs["runCommand"] = runCommand
 	inputs["environment"] = envVars
+	// the scripts of the package run with the timeout configured for configurePackage
+	if timeout, found := context.AppConfig().Ssm.PluginTimeoutSeconds(appconfig.PluginNameAwsConfigurePackage); found {
+		inputs["timeoutSeconds"] = timeout
+	}
 
 	config := contracts.Configuration{
 		Settings:                nil,
@@ -183,7 +188,7 @@ func (inst *Installer) readShAction(context context.T, action *Action, workingDi
 	runCommand = append(runCommand, fmt.Sprintf("echo Running sh %v.sh", action.actionName))
 	runCommand = append(runCommand, fmt.Sprintf("sh %v.sh", action.actionName))
 
-	return inst.readScriptAction(action, workingDir, orchestrationDir, "runShellScript", runCommand, envVars)
+	return inst.readScriptAction(context, action, workingDir, orchestrationDir, "runShellScript", runCommand, envVars)
 }
 
 // readPs1Action turns an ps1 action into a set of SSM Document Plugins to execute
@@ -196,7 +201,7 @@ func (inst *Installer) readPs1Action(context context.T, action *Action, workingD
 	runCommand = append(runCommand, fmt.Sprintf("echo 'Running %v.ps1'", action.actionName))
 	runCommand = append(runCommand, fmt.Sprintf(".\\%v.ps1; exit $LASTEXITCODE", action.actionName))
 
-	return inst.readScriptAction(action, workingDir, orchestrationDir, "runPowerShellScript", runCommand, envVars)
+	return inst.readScriptAction(context, action, workingDir, orchestrationDir, "runPowerShellScript", runCommand, envVars)
 }
 
 // resolveAction checks if there are multiple installer files for the same action type
@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
@@ -204,6 +205,25 @@ func TestReadShActionWithEnvVars(t *testing.T) {
 	assert.Equal(t, envVars, pluginInputMap["environment"])
 }
 
+func TestReadShActionWithConfiguredTimeout(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Ssm.PluginTimeouts = []appconfig.PluginTimeout{{Plugin: appconfig.PluginNameAwsConfigurePackage, TimeoutSeconds: 10800}}
+	inst := Installer{filesysdep: &MockedFileSys{}, packagePath: testPackagePath, envdetectCollector: &envdetectmocks.CollectorMock{}}
+	action := &Action{actionName: "install", actionType: ACTION_TYPE_SH}
+
+	pluginsInfo, err := inst.readShAction(contextmocks.NewMockDefaultWithConfig(config), action, "Foo", "", envVars)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pluginsInfo))
+	pluginInputMap, _ := pluginsInfo[0].Configuration.Properties.(map[string]interface{})
+	assert.Equal(t, 10800, pluginInputMap["timeoutSeconds"])
+
+	// the scripts keep the default timeout of the script plugins otherwise
+	pluginsInfo, err = inst.readShAction(contextMock, action, "Foo", "", envVars)
+	assert.Nil(t, err)
+	pluginInputMap, _ = pluginsInfo[0].Configuration.Properties.(map[string]interface{})
+	assert.NotContains(t, pluginInputMap, "timeoutSeconds")
+}
+
 func TestReadPs1ActionWithEnvVars(t *testing.T) {
 	mockFileSys := MockedFileSys{}
 	mockEnvdetectCollector := &envdetectmocks.CollectorMock{}
@@ -166,6 +166,8 @@ func downloadPackageFromS3(context context.T, tracer trace.Tracer, packageS3Sour
 	// TODO: deduplicate with birdwatcher download
 	downloadInput := artifact.DownloadInput{
 		SourceURL: packageS3Source,
+		Resumable: true,
+		Progress:  packageservice.DownloadProgress(tracer.CurrentTrace()),
 	}
 
 	downloadOutput, downloadErr := networkdep.Download(context, downloadInput)
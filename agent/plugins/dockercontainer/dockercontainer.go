@@ -285,7 +285,7 @@ func (p *Plugin) runCommands(pluginID string, pluginInput DockerContainerPluginI
 		return
 	}
 
-	executionTimeout := pluginutil.ValidateExecutionTimeout(log, pluginInput.TimeoutSeconds)
+	executionTimeout := pluginutil.GetExecutionTimeout(log, p.context.AppConfig(), Name(), pluginInput.TimeoutSeconds)
 
 	// Execute Command
 	exitCode, err := p.CommandExecuter.NewExecute(p.context, pluginInput.WorkingDirectory, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments, make(map[string]string))
@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -36,9 +37,11 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/github/privategithub"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/privategit"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/httpresource"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/ociresource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/s3resource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/ssmdocresource"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
@@ -49,6 +52,7 @@ const (
 	GitHub      = "GitHub"      //Github represents the source type "GitHub" from where the resource can be downloaded
 	S3          = "S3"          //S3 represents the source type "S3" from where the resource is being downloaded
 	SSMDocument = "SSMDocument" //SSMDocument represents the source type as SSM Document
+	OCI         = "OCI"         //OCI represents an artifact stored in an OCI registry from where the resource can be downloaded
 
 	downloadsDir = "downloads" //Directory under the orchestration directory where the downloaded resource resides
 
@@ -62,6 +66,7 @@ var sourceTypes = map[string]bool{
 	GitHub:      true,
 	S3:          true,
 	SSMDocument: true,
+	OCI:         true,
 }
 
 var SetPermission = SetFilePermissions
@@ -87,6 +92,8 @@ type DownloadContentPlugin struct {
 	SourceType      string `json:"sourceType"`
 	SourceInfo      string `json:"sourceInfo"`
 	DestinationPath string `json:"destinationPath"`
+	// TimeoutSeconds bounds the download, it defaults to the timeout configured for the plugin in the agent configuration
+	TimeoutSeconds interface{} `json:"timeoutSeconds"`
 	// TODO: 08/25/2017 meloniam@ Change the type of SourceInfo and documentParameters to map[string]interface{}
 	// TODO: https://amazon.awsapps.com/workdocs/index.html#/document/7d56a42ea5b040a7c33548d77dc98040f0fb380bbbfb2fd580c861225e2ee1c7
 }
@@ -109,6 +116,9 @@ func newRemoteResource(context context.T, SourceType string, SourceInfo string)
 	case Git:
 		ssmParameterResolverBridge := ssmparameterresolver.NewSsmParameterResolverBridge(ssmparameterresolver.NewService(context))
 		return privategit.NewGitResource(context, SourceInfo, ssmParameterResolverBridge)
+	case OCI:
+		ssmParameterResolverBridge := ssmparameterresolver.NewSsmParameterResolverBridge(ssmparameterresolver.NewService(context))
+		return ociresource.NewOCIResource(context, SourceInfo, ssmParameterResolverBridge)
 	default:
 		return nil, fmt.Errorf("Invalid SourceType - %v", SourceType)
 	}
@@ -171,8 +181,12 @@ func (p *Plugin) runCopyContent(log log.T, input *DownloadContentPlugin, config
 
 	var result *remoteresource.DownloadResult
 	log.Debug("Downloading resource")
-	if err, result = remoteResource.DownloadRemoteResource(p.filesys, destinationPath); err != nil {
+	timeout := p.downloadTimeout(log, input)
+	if err, result = downloadRemoteResource(remoteResource, p.filesys, destinationPath, timeout); err != nil {
 		output.MarkAsFailed(err)
+		if category, _ := contracts.CategorizeError(err); category == contracts.ErrorCategoryTimeout {
+			output.SetStatus(contracts.ResultStatusTimedOut)
+		}
 		return
 	}
 
@@ -186,6 +200,44 @@ func (p *Plugin) runCopyContent(log log.T, input *DownloadContentPlugin, config
 	return
 }
 
+// downloadTimeout returns the timeout of the download, the timeout of the document wins over the timeout configured
+// for the plugin and the download is not bounded when none is set
+func (p *Plugin) downloadTimeout(log log.T, input *DownloadContentPlugin) time.Duration {
+	if input.TimeoutSeconds != nil {
+		return time.Duration(pluginutil.GetExecutionTimeout(log, p.context.AppConfig(), Name(), input.TimeoutSeconds)) * time.Second
+	}
+	if timeout, found := p.context.AppConfig().Ssm.PluginTimeoutSeconds(Name()); found {
+		return time.Duration(timeout) * time.Second
+	}
+	return 0
+}
+
+// downloadRemoteResource downloads the resource, a timeout error is returned when the download does not complete
+// in time. The download cannot be interrupted, it completes in the background.
+func downloadRemoteResource(remoteResource remoteresource.RemoteResource, filesys filemanager.FileSystem, destinationPath string, timeout time.Duration) (error, *remoteresource.DownloadResult) {
+	if timeout <= 0 {
+		return remoteResource.DownloadRemoteResource(filesys, destinationPath)
+	}
+
+	type download struct {
+		err    error
+		result *remoteresource.DownloadResult
+	}
+	done := make(chan download, 1)
+	go func() {
+		err, result := remoteResource.DownloadRemoteResource(filesys, destinationPath)
+		done <- download{err: err, result: result}
+	}()
+
+	select {
+	case completed := <-done:
+		return completed.err, completed.result
+	case <-time.After(timeout):
+		return contracts.NewCategorizedError(contracts.ErrorCategoryTimeout, contracts.ErrorCodeTimedOut,
+			fmt.Errorf("download to %v did not complete in %v", destinationPath, timeout)), nil
+	}
+}
+
 func setPermissions(log log.T, result *remoteresource.DownloadResult) error {
 	for _, path := range result.Files {
 		log.Infof("Setting permission for file %v", path)
@@ -23,8 +23,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
 	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
 	"github.com/aws/amazon-ssm-agent/agent/log"
@@ -432,6 +434,55 @@ func TestParseAndValidateInput_SourceInfoJsonInput_CamelCase_Success(t *testing.
 	assert.Equal(t, downloadContent.SourceType, sourceTypeTest)
 }
 
+func TestDownloadTimeout(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	p := Plugin{context: contextmocks.NewMockDefaultWithConfig(config)}
+
+	// the download is not bounded by default
+	assert.Equal(t, time.Duration(0), p.downloadTimeout(logger, &DownloadContentPlugin{}))
+
+	config.Ssm.PluginTimeouts = []appconfig.PluginTimeout{{Plugin: appconfig.PluginDownloadContent, TimeoutSeconds: 7200}}
+	p = Plugin{context: contextmocks.NewMockDefaultWithConfig(config)}
+	assert.Equal(t, 2*time.Hour, p.downloadTimeout(logger, &DownloadContentPlugin{}))
+
+	// the timeout of the document wins
+	assert.Equal(t, 10*time.Minute, p.downloadTimeout(logger, &DownloadContentPlugin{TimeoutSeconds: "600"}))
+}
+
+// blockingRemoteResource is a remote resource whose download completes when release is closed
+type blockingRemoteResource struct {
+	release chan struct{}
+}
+
+func (r blockingRemoteResource) DownloadRemoteResource(filesys filemanager.FileSystem, destinationDir string) (error, *remoteresource.DownloadResult) {
+	<-r.release
+	return nil, resourcemock.NewEmptyDownloadResult()
+}
+
+func (r blockingRemoteResource) ValidateLocationInfo() (bool, error) {
+	return true, nil
+}
+
+func TestDownloadRemoteResource_TimesOut(t *testing.T) {
+	resource := blockingRemoteResource{release: make(chan struct{})}
+	defer close(resource.release)
+
+	err, result := downloadRemoteResource(resource, nil, "destination", 10*time.Millisecond)
+	assert.Nil(t, result)
+	category, code := contracts.CategorizeError(err)
+	assert.Equal(t, contracts.ErrorCategoryTimeout, category)
+	assert.Equal(t, contracts.ErrorCodeTimedOut, code)
+}
+
+func TestDownloadRemoteResource_CompletesInTime(t *testing.T) {
+	resource := blockingRemoteResource{release: make(chan struct{})}
+	close(resource.release)
+
+	err, result := downloadRemoteResource(resource, nil, "destination", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, resourcemock.NewEmptyDownloadResult(), result)
+}
+
 // Mock and stub functions
 func fakeRemoteResource(context context.T, locationType string, locationInfo string) (remoteresource.RemoteResource, error) {
 
@@ -26,6 +26,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/github/privategithub"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/github/privategithub/githubclient"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
@@ -56,7 +57,7 @@ func NewGitHubResource(context context.T, info string, token privategithub.Priva
 	}
 	// Get the access token from Parameter store - GetAccessToken
 	// Create https client - https://github.com/google/go-github#authentication
-	var httpClient *http.Client
+	httpClient := &http.Client{Transport: network.GetDefaultTransport(context.Log(), context.AppConfig())}
 
 	if gitInfo.TokenInfo != "" {
 		if httpClient, err = token.GetOAuthClient(context.Log(), gitInfo.TokenInfo); err != nil {
@@ -23,7 +23,10 @@ import (
 )
 
 // OAuthClient is a wrapper around github.Client. This is done for mocking
-type OAuthClient struct{}
+type OAuthClient struct {
+	// Transport is the base transport of the oauth client, http.DefaultTransport is used when nil
+	Transport http.RoundTripper
+}
 
 // IOAuthClient is an interface for oauth access of Github
 type IOAuthClient interface {
@@ -34,6 +37,9 @@ type IOAuthClient interface {
 // implementation of this has been taken from https://github.com/google/go-github#authentication
 func (git OAuthClient) GetGithubOauthClient(token string) *http.Client {
 	ctx := gitcontext.Background()
+	if git.Transport != nil {
+		ctx = gitcontext.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: git.Transport})
+	}
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
@@ -24,6 +24,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/parameterstore"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/github/privategithub/githubclient"
 	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
 )
@@ -119,6 +120,6 @@ func NewTokenInfoImpl(context context.T) TokenInfoImpl {
 	return TokenInfoImpl{
 		SsmParameter:   getSSMParameter,
 		paramAccess:    parameterService,
-		gitoauthclient: githubclient.OAuthClient{},
+		gitoauthclient: githubclient.OAuthClient{Transport: network.GetDefaultTransport(context.Log(), context.AppConfig())},
 	}
 }
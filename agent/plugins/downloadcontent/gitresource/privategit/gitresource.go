@@ -18,12 +18,14 @@ package privategit
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/privategit/handler"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/privategit/handler/core"
@@ -35,6 +37,12 @@ import (
 var collectFilesAndRebaseFunction = fileutil.CollectFilesAndRebase
 var moveFilesFunction = fileutil.MoveFiles
 
+// newCloneTransport creates the transport repositories are cloned through over http(s), which honors the
+// agent proxy and TLS configuration
+var newCloneTransport = func(context context.T) http.RoundTripper {
+	return network.GetDefaultTransport(context.Log(), context.AppConfig())
+}
+
 // GitResource represents a git repository
 type GitResource struct {
 	Handler handler.IGitHandler
@@ -72,7 +80,7 @@ func NewGitResource(context context.T, info string, bridge ssmparameterresolver.
 		Password:            gitInfo.Password,
 	}
 
-	gitHandler, err := handler.NewGitHandler(gitInfo.Repository, authConfig, *getOptions, bridge)
+	gitHandler, err := handler.NewGitHandler(gitInfo.Repository, authConfig, *getOptions, bridge, newCloneTransport(context))
 	if err != nil {
 		return nil, err
 	}
@@ -18,10 +18,12 @@ package privategit
 import (
 	"errors"
 	"fmt"
+	nethttp "net/http"
 	"os"
 	"path/filepath"
 	"testing"
 
+	agentcontext "github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
@@ -56,6 +58,11 @@ func getString(obj interface{}) string {
 }
 
 func TestNewGitResource(t *testing.T) {
+	transport := &nethttp.Transport{}
+	originalNewCloneTransport := newCloneTransport
+	newCloneTransport = func(agentcontext.T) nethttp.RoundTripper { return transport }
+	defer func() { newCloneTransport = originalNewCloneTransport }()
+
 	testGitHandler, err := handler.NewGitHandler("private-git-repo", handler.GitAuthConfig{
 		PrivateSSHKey:       "private-ssh-key",
 		SkipHostKeyChecking: true,
@@ -64,7 +71,7 @@ func TestNewGitResource(t *testing.T) {
 	}, gitresource.CheckoutOptions{
 		Branch:   "master",
 		CommitID: "",
-	}, bm)
+	}, bm, transport)
 
 	assert.NoError(t, err)
 
@@ -17,8 +17,10 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	nethttp "net/http"
 	"os"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
@@ -29,12 +31,35 @@ import (
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"golang.org/x/crypto/ssh"
 )
 
-var plainCloneMethod = gogit.PlainClone
+var plainCloneMethod = gogit.PlainCloneContext
+
+// cloneTransportKey is the key of the clone context value holding the transport used by the clone
+type cloneTransportKey struct{}
+
+// cloneTransport sends the http requests of a clone through the transport held by the request context.
+// go-git resolves its http protocol from process wide state, it is installed once with this transport so
+// that concurrent clones each use their own transport without replacing the protocol.
+type cloneTransport struct{}
+
+// RoundTrip sends the request through the transport of the clone, or the default transport if there is none
+func (cloneTransport) RoundTrip(request *nethttp.Request) (*nethttp.Response, error) {
+	if roundTripper, ok := request.Context().Value(cloneTransportKey{}).(nethttp.RoundTripper); ok {
+		return roundTripper.RoundTrip(request)
+	}
+	return nethttp.DefaultTransport.RoundTrip(request)
+}
+
+func init() {
+	httpClient := http.NewClient(&nethttp.Client{Transport: cloneTransport{}})
+	client.InstallProtocol("https", httpClient)
+	client.InstallProtocol("http", httpClient)
+}
 
 // GitAuthConfig defines the attributes used to perform authentication over SSH or HTTP
 type GitAuthConfig struct {
@@ -59,14 +84,16 @@ type gitHandler struct {
 	authConfig                 GitAuthConfig
 	getOptions                 gitresource.CheckoutOptions
 	ssmParameterResolverBridge ssmparameterresolver.ISsmParameterResolverBridge
+	transport                  nethttp.RoundTripper
 }
 
-// NewGitHandler creates a new git handler object
+// NewGitHandler creates a new git handler object, repositories are cloned over http(s) through the given transport
 func NewGitHandler(
 	repository string,
 	authConfig GitAuthConfig,
 	options gitresource.CheckoutOptions,
-	bridge ssmparameterresolver.ISsmParameterResolverBridge) (IGitHandler, error) {
+	bridge ssmparameterresolver.ISsmParameterResolverBridge,
+	httpTransport nethttp.RoundTripper) (IGitHandler, error) {
 	parsedURL, err := transport.NewEndpoint(repository)
 	if err != nil {
 		return nil, fmt.Errorf("Invalid repository url format: %s", err.Error())
@@ -78,6 +105,7 @@ func NewGitHandler(
 		authConfig:                 authConfig,
 		getOptions:                 options,
 		ssmParameterResolverBridge: bridge,
+		transport:                  httpTransport,
 	}, nil
 }
 
@@ -100,7 +128,11 @@ func (handler *gitHandler) CloneRepository(log log.T, authMethod transport.AuthM
 		Auth:     authMethod,
 	}
 
-	repository, err = plainCloneMethod(destPath, false, &cloneOptions)
+	cloneContext := context.Background()
+	if handler.transport != nil {
+		cloneContext = context.WithValue(cloneContext, cloneTransportKey{}, handler.transport)
+	}
+	repository, err = plainCloneMethod(cloneContext, destPath, false, &cloneOptions)
 	if err != nil {
 		log.Errorf(err.Error())
 		if err.Error() == "ssh: handshake failed: knownhosts: key is unknown" {
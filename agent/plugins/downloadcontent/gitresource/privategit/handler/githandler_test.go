@@ -16,12 +16,14 @@
 package handler
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	nethttp "net/http"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
@@ -62,7 +64,7 @@ var parameterStoreParameters = map[string]string{
 	"{{ssm-secure:privateSSHKey}}": privateSSHKey,
 }
 
-func PlainClone(path string, isBare bool, o *gogit.CloneOptions) (*gogit.Repository, error) {
+func PlainClone(ctx context.Context, path string, isBare bool, o *gogit.CloneOptions) (*gogit.Repository, error) {
 	switch path {
 	case "unknown-host":
 		return nil, fmt.Errorf("ssh: handshake failed: knownhosts: key is unknown")
@@ -145,6 +147,7 @@ func TestNewGitHandlerURLParsing(t *testing.T) {
 			GitAuthConfig{},
 			gitresource.CheckoutOptions{},
 			bridgemock.GetSsmParamResolverBridge(parameterStoreParameters),
+			nil,
 		)
 
 		if test.err != nil {
@@ -673,7 +676,27 @@ func TestGitHandler_CloneRepository(t *testing.T) {
 		}
 	}
 
-	plainCloneMethod = gogit.PlainClone
+	plainCloneMethod = gogit.PlainCloneContext
+}
+
+type roundTripperFunc func(*nethttp.Request) (*nethttp.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *nethttp.Request) (*nethttp.Response, error) {
+	return f(request)
+}
+
+func TestCloneTransport_UsesTheTransportOfTheClone(t *testing.T) {
+	response := &nethttp.Response{StatusCode: nethttp.StatusOK}
+	cloneRoundTripper := roundTripperFunc(func(*nethttp.Request) (*nethttp.Response, error) {
+		return response, nil
+	})
+	ctx := context.WithValue(context.Background(), cloneTransportKey{}, nethttp.RoundTripper(cloneRoundTripper))
+	request, _ := nethttp.NewRequest(nethttp.MethodGet, "https://private-git-repo/info/refs", nil)
+
+	result, err := cloneTransport{}.RoundTrip(request.WithContext(ctx))
+
+	assert.NoError(t, err)
+	assert.Equal(t, response, result)
 }
 
 func TestGitHandler_performCheckout_FailedWorktreeRetrieval(t *testing.T) {
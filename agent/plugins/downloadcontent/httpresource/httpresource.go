@@ -28,12 +28,18 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/httpresource/handler"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/types"
 	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
 )
 
+// newHTTPClient creates the client used for the download, which honors the agent proxy configuration
+var newHTTPClient = func(context context.T) http.Client {
+	return http.Client{Transport: network.GetDefaultTransport(context.Log(), context.AppConfig())}
+}
+
 // HTTPResource represents an HTTP(s) resource
 type HTTPResource struct {
 	context context.T
@@ -61,7 +67,7 @@ func NewHTTPResource(context context.T, info string, bridge ssmparameterresolver
 		return nil, fmt.Errorf("Invalid URL format: %s", err.Error())
 	}
 
-	httpClient := http.Client{}
+	httpClient := newHTTPClient(context)
 	httpClient.CloseIdleConnections()
 
 	return &HTTPResource{
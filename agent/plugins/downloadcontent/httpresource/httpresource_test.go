@@ -26,6 +26,7 @@ import (
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	agentContext "github.com/aws/amazon-ssm-agent/agent/context"
 	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/httpresource/handler"
@@ -39,6 +40,7 @@ import (
 var contextMock = context.NewMockDefault()
 var logMock = contextMock.Log()
 var bm = bridgemock.GetSsmParamResolverBridge(map[string]string{})
+var realNewHTTPClient = newHTTPClient
 
 func getString(obj interface{}) string {
 	return fmt.Sprintf("%v", obj)
@@ -108,6 +110,11 @@ func TestNewHTTPResource(t *testing.T) {
 		},
 	}
 
+	newHTTPClient = func(agentContext.T) http.Client {
+		return http.Client{}
+	}
+	defer func() { newHTTPClient = realNewHTTPClient }()
+
 	for _, test := range tests {
 		resource, err := NewHTTPResource(contextMock, test.sourceInfo, bm)
 
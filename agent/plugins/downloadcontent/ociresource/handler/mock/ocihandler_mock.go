@@ -0,0 +1,36 @@
+/*
+ * Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"). You may not
+ * use this file except in compliance with the License. A copy of the
+ * License is located at
+ *
+ * http://aws.amazon.com/apache2.0/
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package mock
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/mock"
+)
+
+type OCIHandlerMock struct {
+	mock.Mock
+}
+
+func (mock *OCIHandlerMock) Download(log log.T, fileSystem filemanager.FileSystem, downloadDir string) ([]string, error) {
+	args := mock.Called(log, fileSystem, downloadDir)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (mock *OCIHandlerMock) Validate() (bool, error) {
+	args := mock.Called()
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,454 @@
+/*
+ * Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"). You may not
+ * use this file except in compliance with the License. A copy of the
+ * License is located at
+ *
+ * http://aws.amazon.com/apache2.0/
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+// Package handler provides methods to pull artifacts from OCI registries
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/types"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
+)
+
+const (
+	// MediaTypeOCIManifest is the media type of an OCI image manifest
+	MediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"
+	// MediaTypeDockerManifest is the media type of a docker v2 image manifest
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	// AnnotationTitle is the annotation used by ORAS to store the file name of a layer
+	AnnotationTitle = "org.opencontainers.image.title"
+
+	defaultTag         = "latest"
+	dockerHubRegistry  = "docker.io"
+	dockerHubEndpoint  = "registry-1.docker.io"
+	sha256DigestPrefix = "sha256:"
+)
+
+var ioCopy = io.Copy
+
+var (
+	repositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagPattern        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestPattern     = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	challengePattern  = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// Reference represents a parsed OCI artifact reference: registry/repository[:tag][@digest]
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// String returns the reference in its canonical form
+func (ref Reference) String() string {
+	if ref.Digest != "" {
+		return fmt.Sprintf("%s/%s@%s", ref.Registry, ref.Repository, ref.Digest)
+	}
+	return fmt.Sprintf("%s/%s:%s", ref.Registry, ref.Repository, ref.Tag)
+}
+
+// ParseReference parses an OCI artifact reference. The registry host must be part of the reference.
+func ParseReference(reference string) (ref Reference, err error) {
+	reference = strings.TrimSpace(reference)
+	slashIndex := strings.Index(reference, "/")
+	if slashIndex <= 0 {
+		return ref, fmt.Errorf("Invalid OCI reference %s: registry and repository must be specified", reference)
+	}
+	ref.Registry = reference[:slashIndex]
+	remainder := reference[slashIndex+1:]
+
+	if atIndex := strings.Index(remainder, "@"); atIndex >= 0 {
+		ref.Digest = remainder[atIndex+1:]
+		remainder = remainder[:atIndex]
+		if !digestPattern.MatchString(ref.Digest) {
+			return ref, fmt.Errorf("Invalid OCI reference %s: digest must be of the form sha256:<hex>", reference)
+		}
+	}
+	if colonIndex := strings.LastIndex(remainder, ":"); colonIndex >= 0 {
+		ref.Tag = remainder[colonIndex+1:]
+		remainder = remainder[:colonIndex]
+		if !tagPattern.MatchString(ref.Tag) {
+			return ref, fmt.Errorf("Invalid OCI reference %s: invalid tag %s", reference, ref.Tag)
+		}
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+
+	ref.Repository = remainder
+	if !repositoryPattern.MatchString(ref.Repository) {
+		return ref, fmt.Errorf("Invalid OCI reference %s: invalid repository %s", reference, ref.Repository)
+	}
+	return ref, nil
+}
+
+// OCIAuthConfig defines the attributes used to authenticate against the registry
+type OCIAuthConfig struct {
+	Username types.TrimmedString
+	Password types.TrimmedString
+}
+
+// Descriptor describes a content addressable blob of an OCI manifest
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is the subset of the OCI image manifest used to pull artifacts
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+// IOCIHandler defines methods to interact with OCI registries
+type IOCIHandler interface {
+	Download(log log.T, fileSystem filemanager.FileSystem, downloadDir string) ([]string, error)
+	Validate() (bool, error)
+}
+
+// ociHandler is used to pull a specific artifact from an OCI registry
+type ociHandler struct {
+	client                     http.Client
+	reference                  Reference
+	allowInsecureRegistry      bool
+	authConfig                 OCIAuthConfig
+	ssmParameterResolverBridge ssmparameterresolver.ISsmParameterResolverBridge
+	authorization              string
+}
+
+// NewOCIHandler creates a new oci handler object
+func NewOCIHandler(
+	client http.Client,
+	reference Reference,
+	allowInsecureRegistry bool,
+	authConfig OCIAuthConfig,
+	bridge ssmparameterresolver.ISsmParameterResolverBridge,
+) IOCIHandler {
+	return &ociHandler{
+		client:                     client,
+		reference:                  reference,
+		allowInsecureRegistry:      allowInsecureRegistry,
+		authConfig:                 authConfig,
+		ssmParameterResolverBridge: bridge,
+	}
+}
+
+// Download pulls every layer of the artifact into the download directory and returns the downloaded files
+func (handler *ociHandler) Download(log log.T, fileSystem filemanager.FileSystem, downloadDir string) ([]string, error) {
+	log.Debugf("Pulling OCI artifact %s", handler.reference.String())
+	manifest, err := handler.getManifest(log)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve manifest of %s: %s", handler.reference.String(), err.Error())
+	}
+
+	var files []string
+	for _, layer := range manifest.Layers {
+		fileName, err := layerFileName(layer)
+		if err != nil {
+			return files, err
+		}
+		filePath := filepath.Join(downloadDir, fileName)
+		if err = handler.downloadBlob(log, fileSystem, layer, filePath); err != nil {
+			return files, fmt.Errorf("Failed to download layer %s: %s", layer.Digest, err.Error())
+		}
+		files = append(files, filePath)
+	}
+	return files, nil
+}
+
+// Validate validates handler's attributes values
+func (handler *ociHandler) Validate() (bool, error) {
+	if handler.reference.Registry == "" || handler.reference.Repository == "" {
+		return false, errors.New("Reference for OCI resource type must be specified")
+	}
+
+	if (handler.authConfig.Username == "") != (handler.authConfig.Password == "") {
+		return false, errors.New("Both username and password must be provided to authenticate against the registry")
+	}
+
+	return true, nil
+}
+
+// registryURL returns the url of the given registry api path of the repository
+func (handler *ociHandler) registryURL(path string) string {
+	scheme := "https"
+	if handler.allowInsecureRegistry {
+		scheme = "http"
+	}
+	host := handler.reference.Registry
+	if host == dockerHubRegistry {
+		host = dockerHubEndpoint
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, host, handler.reference.Repository, path)
+}
+
+// getManifest retrieves the manifest of the artifact and makes sure it matches the requested digest
+func (handler *ociHandler) getManifest(log log.T) (*Manifest, error) {
+	reference := handler.reference.Tag
+	if handler.reference.Digest != "" {
+		reference = handler.reference.Digest
+	}
+
+	response, err := handler.get(log, handler.registryURL("manifests/"+reference), MediaTypeOCIManifest+", "+MediaTypeDockerManifest)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if handler.reference.Digest != "" {
+		if digest := sha256Digest(content); digest != handler.reference.Digest {
+			return nil, fmt.Errorf("manifest digest %s does not match the requested digest", digest)
+		}
+	}
+
+	var manifest Manifest
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %s", err.Error())
+	}
+	if manifest.MediaType != "" && manifest.MediaType != MediaTypeOCIManifest && manifest.MediaType != MediaTypeDockerManifest {
+		return nil, fmt.Errorf("unsupported manifest media type %s", manifest.MediaType)
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, errors.New("manifest does not contain any layer")
+	}
+	return &manifest, nil
+}
+
+// downloadBlob downloads the given layer to the file path and verifies its digest
+func (handler *ociHandler) downloadBlob(log log.T, fileSystem filemanager.FileSystem, layer Descriptor, filePath string) error {
+	if !digestPattern.MatchString(layer.Digest) {
+		return fmt.Errorf("unsupported digest %s", layer.Digest)
+	}
+
+	response, err := handler.get(log, handler.registryURL("blobs/"+layer.Digest), "")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	out, err := fileSystem.CreateFile(filePath)
+	if err != nil {
+		return fmt.Errorf("Cannot create destinaton file: %s", err.Error())
+	}
+	defer out.Close()
+
+	hash := sha256.New()
+	if _, err = ioCopy(io.MultiWriter(out, hash), response.Body); err != nil {
+		return fmt.Errorf("An error occurred during data transfer: %s", err.Error())
+	}
+
+	if digest := sha256DigestPrefix + hex.EncodeToString(hash.Sum(nil)); digest != layer.Digest {
+		return fmt.Errorf("downloaded content digest %s does not match the layer digest", digest)
+	}
+	return nil
+}
+
+// get executes a GET request against the registry and authenticates when the registry requests it
+func (handler *ociHandler) get(log log.T, requestURL string, accept string) (*http.Response, error) {
+	response, err := handler.do(requestURL, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusUnauthorized && handler.authorization == "" {
+		challenge := response.Header.Get("WWW-Authenticate")
+		response.Body.Close()
+		if err = handler.authenticate(log, challenge); err != nil {
+			return nil, fmt.Errorf("Failed to authenticate against the registry: %s", err.Error())
+		}
+		if response, err = handler.do(requestURL, accept); err != nil {
+			return nil, err
+		}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("Status: %s", response.Status)
+	}
+	return response, nil
+}
+
+// do executes a GET request with the current authorization
+func (handler *ociHandler) do(requestURL string, accept string) (*http.Response, error) {
+	request, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	if handler.authorization != "" {
+		request.Header.Set("Authorization", handler.authorization)
+	}
+
+	response, err := handler.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot execute request: %s", err.Error())
+	}
+	return response, nil
+}
+
+// authenticate answers the registry challenge with either basic credentials or a bearer token
+func (handler *ociHandler) authenticate(log log.T, challenge string) error {
+	username, password, err := handler.getCredentials(log)
+	if err != nil {
+		return err
+	}
+
+	// the credentials, or the token obtained with them, would be sent in clear text to the registry
+	if username != "" && handler.allowInsecureRegistry {
+		return errors.New("refusing to send credentials to an insecure registry")
+	}
+
+	scheme := strings.ToLower(strings.SplitN(strings.TrimSpace(challenge), " ", 2)[0])
+	switch scheme {
+	case "basic":
+		if username == "" {
+			return errors.New("registry requires credentials")
+		}
+		handler.authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+		return nil
+	case "bearer":
+		token, err := handler.requestToken(challenge, username, password)
+		if err != nil {
+			return err
+		}
+		handler.authorization = "Bearer " + token
+		return nil
+	default:
+		return fmt.Errorf("unsupported authentication challenge %s", challenge)
+	}
+}
+
+// requestToken requests a pull token from the token service named in the bearer challenge
+func (handler *ociHandler) requestToken(challenge string, username string, password string) (string, error) {
+	params := make(map[string]string)
+	for _, match := range challengePattern.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid bearer challenge %s", challenge)
+	}
+	if username != "" && realm.Scheme != "https" {
+		return "", fmt.Errorf("refusing to send credentials to the insecure token service %s", realm.Host)
+	}
+
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", handler.reference.Repository)
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	request, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		request.SetBasicAuth(username, password)
+	}
+
+	response, err := handler.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("Cannot execute token request: %s", err.Error())
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request status: %s", response.Status)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("invalid token response: %s", err.Error())
+	}
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+	if tokenResponse.AccessToken != "" {
+		return tokenResponse.AccessToken, nil
+	}
+	return "", errors.New("token response does not contain a token")
+}
+
+// getCredentials resolves the configured credentials, which can be parameter store references
+func (handler *ociHandler) getCredentials(log log.T) (username string, password string, err error) {
+	username = handler.authConfig.Username.Val()
+	if handler.ssmParameterResolverBridge.IsValidParameterStoreReference(username) {
+		username, err = handler.ssmParameterResolverBridge.GetParameterFromSsmParameterStore(log, username)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	password = handler.authConfig.Password.Val()
+	if handler.ssmParameterResolverBridge.IsValidParameterStoreReference(password) {
+		password, err = handler.ssmParameterResolverBridge.GetParameterFromSsmParameterStore(log, password)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return username, password, nil
+}
+
+// layerFileName returns the file name of the layer, which must not escape the download directory
+func layerFileName(layer Descriptor) (string, error) {
+	name := layer.Annotations[AnnotationTitle]
+	if name == "" {
+		name = strings.TrimPrefix(layer.Digest, sha256DigestPrefix)
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return "", fmt.Errorf("Invalid file name %s for layer %s", name, layer.Digest)
+	}
+	return name, nil
+}
+
+// sha256Digest returns the sha256 digest of the content
+func sha256Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return sha256DigestPrefix + hex.EncodeToString(sum[:])
+}
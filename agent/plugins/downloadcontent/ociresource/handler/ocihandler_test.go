@@ -0,0 +1,214 @@
+/*
+ * Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"). You may not
+ * use this file except in compliance with the License. A copy of the
+ * License is located at
+ *
+ * http://aws.amazon.com/apache2.0/
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/types"
+	bridgemock "github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var logMock = logmocks.NewMockLog()
+
+const testDigest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		expected  Reference
+		isError   bool
+	}{
+		{
+			"registry.example.com/org/artifact:v1.0",
+			Reference{Registry: "registry.example.com", Repository: "org/artifact", Tag: "v1.0"},
+			false,
+		},
+		{
+			"localhost:5000/artifact",
+			Reference{Registry: "localhost:5000", Repository: "artifact", Tag: "latest"},
+			false,
+		},
+		{
+			"registry.example.com/artifact@" + testDigest,
+			Reference{Registry: "registry.example.com", Repository: "artifact", Digest: testDigest},
+			false,
+		},
+		{
+			"artifact:v1",
+			Reference{},
+			true,
+		},
+		{
+			"registry.example.com/Artifact:v1",
+			Reference{},
+			true,
+		},
+		{
+			"registry.example.com/artifact@sha256:abc",
+			Reference{},
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		ref, err := ParseReference(test.reference)
+		if test.isError {
+			assert.Error(t, err, test.reference)
+		} else {
+			assert.NoError(t, err, test.reference)
+			assert.Equal(t, test.expected, ref, test.reference)
+		}
+	}
+}
+
+func TestOCIHandler_Validate(t *testing.T) {
+	tests := []struct {
+		authConfig OCIAuthConfig
+		isValid    bool
+	}{
+		{OCIAuthConfig{}, true},
+		{OCIAuthConfig{Username: types.NewTrimmedString("user"), Password: types.NewTrimmedString("{{ssm-secure:password}}")}, true},
+		{OCIAuthConfig{Username: types.NewTrimmedString("user")}, false},
+	}
+
+	for _, test := range tests {
+		handler := NewOCIHandler(http.Client{}, Reference{Registry: "registry.example.com", Repository: "artifact", Tag: "v1"},
+			false, test.authConfig, bridgemock.GetSsmParamResolverBridge(map[string]string{}))
+		isValid, err := handler.Validate()
+		assert.Equal(t, test.isValid, isValid)
+		assert.Equal(t, test.isValid, err == nil)
+	}
+}
+
+func TestLayerFileName(t *testing.T) {
+	name, err := layerFileName(Descriptor{Digest: testDigest, Annotations: map[string]string{AnnotationTitle: "install.sh"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "install.sh", name)
+
+	name, err = layerFileName(Descriptor{Digest: testDigest})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimPrefix(testDigest, "sha256:"), name)
+
+	for _, invalid := range []string{"..", "../install.sh", "dir/install.sh", `dir\install.sh`} {
+		_, err = layerFileName(Descriptor{Digest: testDigest, Annotations: map[string]string{AnnotationTitle: invalid}})
+		assert.Error(t, err, invalid)
+	}
+}
+
+// newTestRegistry creates a registry serving a single artifact with one layer behind a bearer token service
+func newTestRegistry(t *testing.T, layerContent string, layerDigest string) *httptest.Server {
+	manifest, _ := json.Marshal(Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIManifest,
+		Layers: []Descriptor{
+			{MediaType: "application/octet-stream", Digest: layerDigest, Annotations: map[string]string{AnnotationTitle: "install.sh"}},
+		},
+	})
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/token":
+			username, password, ok := req.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "user", username)
+			assert.Equal(t, "secret", password)
+			assert.Equal(t, "repository:org/artifact:pull", req.URL.Query().Get("scope"))
+			res.Write([]byte(`{"token":"testtoken"}`))
+		case req.Header.Get("Authorization") != "Bearer testtoken":
+			res.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+			res.WriteHeader(http.StatusUnauthorized)
+		case req.URL.Path == "/v2/org/artifact/manifests/v1":
+			assert.Contains(t, req.Header.Get("Accept"), MediaTypeOCIManifest)
+			res.Write(manifest)
+		case req.URL.Path == "/v2/org/artifact/blobs/"+layerDigest:
+			res.Write([]byte(layerContent))
+		default:
+			res.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func TestOCIHandler_Download(t *testing.T) {
+	tests := []struct {
+		layerDigest string
+		isError     bool
+	}{
+		{sha256Digest([]byte("echo hello")), false},
+		{testDigest, true},
+	}
+
+	for _, test := range tests {
+		server := newTestRegistry(t, "echo hello", test.layerDigest)
+		serverURL, _ := url.Parse(server.URL)
+		downloadDir, _ := ioutil.TempDir("", "ocihandler")
+
+		handler := NewOCIHandler(*server.Client(),
+			Reference{Registry: serverURL.Host, Repository: "org/artifact", Tag: "v1"},
+			false,
+			OCIAuthConfig{Username: types.NewTrimmedString("user"), Password: types.NewTrimmedString("{{ssm-secure:password}}")},
+			bridgemock.GetSsmParamResolverBridge(map[string]string{"{{ssm-secure:password}}": "secret"}))
+
+		files, err := handler.Download(logMock, filemanager.FileSystemImpl{}, downloadDir)
+		if test.isError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, []string{filepath.Join(downloadDir, "install.sh")}, files)
+			content, _ := ioutil.ReadFile(files[0])
+			assert.Equal(t, "echo hello", string(content))
+		}
+
+		server.Close()
+		os.RemoveAll(downloadDir)
+	}
+}
+
+func TestOCIHandler_DownloadRefusesCredentialsOverInsecureRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Empty(t, req.Header.Get("Authorization"))
+		res.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+		res.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	downloadDir, _ := ioutil.TempDir("", "ocihandler")
+	defer os.RemoveAll(downloadDir)
+
+	handler := NewOCIHandler(*server.Client(),
+		Reference{Registry: serverURL.Host, Repository: "org/artifact", Tag: "v1"},
+		true,
+		OCIAuthConfig{Username: types.NewTrimmedString("user"), Password: types.NewTrimmedString("secret")},
+		bridgemock.GetSsmParamResolverBridge(map[string]string{}))
+
+	_, err := handler.Download(logMock, filemanager.FileSystemImpl{}, downloadDir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "refusing to send credentials to an insecure registry")
+}
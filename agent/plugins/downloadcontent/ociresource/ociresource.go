@@ -0,0 +1,117 @@
+/*
+ * Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"). You may not
+ * use this file except in compliance with the License. A copy of the
+ * License is located at
+ *
+ * http://aws.amazon.com/apache2.0/
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+// Package ociresource provides methods to download artifacts stored in OCI registries
+package ociresource
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/ociresource/handler"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/types"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
+)
+
+// registryRequestTimeout bounds each request to the registry, including the download of a layer
+const registryRequestTimeout = 30 * time.Minute
+
+// newHTTPClient creates the client used to talk to the registry, which honors the agent proxy configuration
+var newHTTPClient = func(context context.T) http.Client {
+	return http.Client{
+		Transport: network.GetDefaultTransport(context.Log(), context.AppConfig()),
+		Timeout:   registryRequestTimeout,
+	}
+}
+
+// OCIResource represents an artifact stored in an OCI registry
+type OCIResource struct {
+	context context.T
+	Handler handler.IOCIHandler
+}
+
+// OCIInfo defines the accepted SourceInfo attributes and their json definition
+type OCIInfo struct {
+	Reference             types.TrimmedString `json:"reference"`
+	Username              types.TrimmedString `json:"username"`
+	Password              types.TrimmedString `json:"password"`
+	AllowInsecureRegistry bool                `json:"allowInsecureRegistry"`
+}
+
+// NewOCIResource creates a new OCI resource
+func NewOCIResource(context context.T, info string, bridge ssmparameterresolver.ISsmParameterResolverBridge) (resource *OCIResource, err error) {
+	var ociInfo OCIInfo
+	if ociInfo, err = parseSourceInfo(info); err != nil {
+		return nil, err
+	}
+
+	reference, err := handler.ParseReference(ociInfo.Reference.Val())
+	if err != nil {
+		return nil, err
+	}
+
+	return &OCIResource{
+		context: context,
+		Handler: handler.NewOCIHandler(newHTTPClient(context), reference, ociInfo.AllowInsecureRegistry, handler.OCIAuthConfig{
+			Username: ociInfo.Username,
+			Password: ociInfo.Password,
+		}, bridge),
+	}, nil
+}
+
+// DownloadRemoteResource pulls the layers of an OCI artifact into a specific download directory
+func (resource *OCIResource) DownloadRemoteResource(fileSystem filemanager.FileSystem, downloadPath string) (err error, result *remoteresource.DownloadResult) {
+	log := resource.context.Log()
+	if downloadPath == "" {
+		downloadPath = appconfig.DownloadRoot
+	}
+
+	err = fileSystem.MakeDirs(downloadPath)
+	if err != nil {
+		return fmt.Errorf("Cannot create download path %s: %v", downloadPath, err.Error()), nil
+	}
+
+	log.Debug("Destination path to download into - ", downloadPath)
+
+	files, err := resource.Handler.Download(log, fileSystem, downloadPath)
+	if err != nil {
+		return err, nil
+	}
+
+	return nil, &remoteresource.DownloadResult{
+		Files: files,
+	}
+}
+
+// ValidateLocationInfo validates attribute values of an OCI resource
+func (resource *OCIResource) ValidateLocationInfo() (isValid bool, err error) {
+	return resource.Handler.Validate()
+}
+
+// parseSourceInfo unmarshalls the provided SourceInfo input
+func parseSourceInfo(sourceInfo string) (ociInfo OCIInfo, err error) {
+	if err = jsonutil.Unmarshal(sourceInfo, &ociInfo); err != nil {
+		return ociInfo, fmt.Errorf("SourceInfo could not be unmarshalled for source type OCI: %s", err.Error())
+	}
+
+	return ociInfo, nil
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"). You may not
+ * use this file except in compliance with the License. A copy of the
+ * License is located at
+ *
+ * http://aws.amazon.com/apache2.0/
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+package ociresource
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	ociMock "github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/ociresource/handler/mock"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/types"
+	bridgemock "github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var contextMock = context.NewMockDefault()
+var logMock = contextMock.Log()
+var bm = bridgemock.GetSsmParamResolverBridge(map[string]string{})
+
+func TestNewOCIResource(t *testing.T) {
+	tests := []struct {
+		sourceInfo string
+		isError    bool
+	}{
+		{
+			`{
+				"reference": "registry.example.com/org/artifact:v1",
+				"username": "admin",
+				"password": "{{ssm-secure:password}}"
+			}`,
+			false,
+		},
+		{
+			`{"reference": "artifact"}`,
+			true,
+		},
+		{
+			`{"reference": `,
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		resource, err := NewOCIResource(contextMock, test.sourceInfo, bm)
+		if test.isError {
+			assert.Error(t, err, test.sourceInfo)
+			assert.Nil(t, resource, test.sourceInfo)
+		} else {
+			assert.NoError(t, err, test.sourceInfo)
+			assert.NotNil(t, resource.Handler, test.sourceInfo)
+		}
+	}
+}
+
+func TestOCIResource_parseSourceInfo(t *testing.T) {
+	info, err := parseSourceInfo(`{
+		"reference": " registry.example.com/artifact:v1 ",
+		"allowInsecureRegistry": true
+	}`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, OCIInfo{
+		Reference:             types.NewTrimmedString("registry.example.com/artifact:v1"),
+		AllowInsecureRegistry: true,
+	}, info)
+}
+
+func TestOCIResource_ValidateLocationInfo(t *testing.T) {
+	ociHandlerMock := ociMock.OCIHandlerMock{}
+	ociHandlerMock.On("Validate").Return(true, nil).Once()
+
+	resource := OCIResource{
+		context: contextMock,
+		Handler: &ociHandlerMock,
+	}
+
+	_, _ = resource.ValidateLocationInfo()
+	ociHandlerMock.AssertExpectations(t)
+}
+
+func TestOCIResource_DownloadRemoteResource(t *testing.T) {
+	destPath := filepath.Join(os.TempDir(), "artifact")
+	files := []string{filepath.Join(destPath, "install.sh")}
+
+	fileSystemMock := filemock.FileSystemMock{}
+	fileSystemMock.On("MakeDirs", destPath).Return(nil)
+
+	ociHandlerMock := ociMock.OCIHandlerMock{}
+	ociHandlerMock.On("Download", logMock, &fileSystemMock, destPath).Return(files, nil).Once()
+
+	resource := OCIResource{
+		context: contextMock,
+		Handler: &ociHandlerMock,
+	}
+	err, result := resource.DownloadRemoteResource(&fileSystemMock, destPath)
+
+	assert.NoError(t, err)
+	assert.Equal(t, files, result.Files)
+
+	fileSystemMock.AssertExpectations(t)
+	ociHandlerMock.AssertExpectations(t)
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package collectionwindow evaluates the time windows during which the inventory gatherers are allowed to run.
+package collectionwindow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/scheduleexpression"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// IsCollectionAllowed returns true if the gatherer can run at the given time. The collection is forbidden during
+// the Deny windows and, when Allow windows apply to the gatherer, outside of these windows.
+// An error is returned when one of the windows applying to the gatherer is invalid.
+func IsCollectionAllowed(log log.T, windows []appconfig.InventoryCollectionWindow, gathererName string, now time.Time) (bool, error) {
+	hasAllowWindow, inAllowWindow := false, false
+	for _, window := range windows {
+		if !appliesTo(window, gathererName) {
+			continue
+		}
+
+		active, err := isActive(log, window, now)
+		if err != nil {
+			return false, err
+		}
+		if window.Type == appconfig.InventoryCollectionWindowAllow {
+			hasAllowWindow = true
+			inAllowWindow = inAllowWindow || active
+		} else if active {
+			log.Infof("Inventory collection window %v forbids %v", window.Schedule, gathererName)
+			return false, nil
+		}
+	}
+
+	if hasAllowWindow && !inAllowWindow {
+		log.Infof("%v is outside of its allowed inventory collection windows", gathererName)
+		return false, nil
+	}
+	return true, nil
+}
+
+// appliesTo returns true if the window has no gatherers listed or lists the given gatherer
+func appliesTo(window appconfig.InventoryCollectionWindow, gathererName string) bool {
+	if len(window.Gatherers) == 0 {
+		return true
+	}
+	for _, name := range window.Gatherers {
+		if strings.EqualFold(name, gathererName) {
+			return true
+		}
+	}
+	return false
+}
+
+// isActive returns true if the window opened less than its duration before the given time
+func isActive(log log.T, window appconfig.InventoryCollectionWindow, now time.Time) (bool, error) {
+	if !strings.HasPrefix(strings.ToLower(window.Schedule), "cron(") {
+		return false, fmt.Errorf("inventory collection window schedule %v is not a cron expression", window.Schedule)
+	}
+	location := time.UTC
+	if window.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(window.TimeZone); err != nil {
+			return false, fmt.Errorf("inventory collection window time zone %v is invalid: %v", window.TimeZone, err)
+		}
+	}
+	schedule, err := scheduleexpression.CreateScheduleExpressionInLocation(log, window.Schedule, location)
+	if err != nil {
+		return false, fmt.Errorf("inventory collection window schedule %v is invalid: %v", window.Schedule, err)
+	}
+
+	// the window is active if it opened in the last DurationMinutes
+	duration := time.Duration(window.DurationMinutes) * time.Minute
+	opening := schedule.Next(now.Add(-duration))
+	return !opening.IsZero() && !opening.After(now), nil
+}
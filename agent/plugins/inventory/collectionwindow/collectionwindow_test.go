@@ -0,0 +1,95 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package collectionwindow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// businessHours allows the collection on weekdays from 9:00 to 17:00
+var businessHours = appconfig.InventoryCollectionWindow{
+	Schedule:        "cron(0 9 ? * MON-FRI *)",
+	DurationMinutes: 480,
+	Type:            appconfig.InventoryCollectionWindowAllow,
+}
+
+// tradingHours forbids the application gatherer from 14:30 to 21:00
+var tradingHours = appconfig.InventoryCollectionWindow{
+	Schedule:        "cron(30 14 * * ? *)",
+	DurationMinutes: 390,
+	Type:            appconfig.InventoryCollectionWindowDeny,
+	Gatherers:       []string{"AWS:Application"},
+}
+
+func TestIsCollectionAllowed(t *testing.T) {
+	monday := time.Date(2022, time.May, 9, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		windows  []appconfig.InventoryCollectionWindow
+		gatherer string
+		time     time.Time
+		allowed  bool
+	}{
+		{nil, "AWS:Application", monday, true},
+		{[]appconfig.InventoryCollectionWindow{businessHours}, "AWS:Application", monday.Add(10 * time.Hour), true},
+		{[]appconfig.InventoryCollectionWindow{businessHours}, "AWS:Application", monday.Add(9 * time.Hour), true},
+		{[]appconfig.InventoryCollectionWindow{businessHours}, "AWS:Application", monday.Add(17 * time.Hour), false},
+		{[]appconfig.InventoryCollectionWindow{businessHours}, "AWS:Application", monday.Add(-14 * time.Hour), false},
+		{[]appconfig.InventoryCollectionWindow{tradingHours}, "AWS:Application", monday.Add(15 * time.Hour), false},
+		{[]appconfig.InventoryCollectionWindow{tradingHours}, "aws:application", monday.Add(15 * time.Hour), false},
+		{[]appconfig.InventoryCollectionWindow{tradingHours}, "AWS:Network", monday.Add(15 * time.Hour), true},
+		{[]appconfig.InventoryCollectionWindow{tradingHours}, "AWS:Application", monday.Add(21 * time.Hour), true},
+		{[]appconfig.InventoryCollectionWindow{businessHours, tradingHours}, "AWS:Application", monday.Add(15 * time.Hour), false},
+		{[]appconfig.InventoryCollectionWindow{businessHours, tradingHours}, "AWS:Application", monday.Add(11 * time.Hour), true},
+	}
+
+	for _, test := range tests {
+		allowed, err := IsCollectionAllowed(log.NewMockLog(), test.windows, test.gatherer, test.time)
+		assert.Nil(t, err)
+		assert.Equal(t, test.allowed, allowed, "%v at %v", test.gatherer, test.time)
+	}
+}
+
+func TestIsCollectionAllowedTimeZone(t *testing.T) {
+	window := businessHours
+	window.TimeZone = "America/New_York"
+	windows := []appconfig.InventoryCollectionWindow{window}
+
+	// 13:00 UTC is 9:00 in New York during daylight saving time
+	allowed, err := IsCollectionAllowed(log.NewMockLog(), windows, "AWS:Application", time.Date(2022, time.May, 9, 13, 30, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = IsCollectionAllowed(log.NewMockLog(), windows, "AWS:Application", time.Date(2022, time.May, 9, 10, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+}
+
+func TestIsCollectionAllowedInvalidWindow(t *testing.T) {
+	invalidWindows := []appconfig.InventoryCollectionWindow{
+		{Schedule: "rate(1 hour)", DurationMinutes: 60},
+		{Schedule: "cron(invalid)", DurationMinutes: 60},
+		{Schedule: "cron(0 9 * * ? *)", DurationMinutes: 60, TimeZone: "Mars/Olympus_Mons"},
+	}
+
+	for _, window := range invalidWindows {
+		allowed, err := IsCollectionAllowed(log.NewMockLog(), []appconfig.InventoryCollectionWindow{window}, "AWS:Application", time.Now())
+		assert.Error(t, err, window.Schedule)
+		assert.False(t, allowed)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package application
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// maxIncrementalSubKeys is the number of changed sub keys above which all the sub keys of an uninstall key are
+// queried again, it keeps the powershell command line under its length limit
+const maxIncrementalSubKeys = 100
+
+// uninstallKeyState maps the lower cased name of each sub key of an uninstall registry key to its last write time
+type uninstallKeyState map[string]int64
+
+// uninstallKeyCache holds the applications declared under the sub keys of an uninstall registry key
+type uninstallKeyCache struct {
+	// ProductsFingerprint identifies the installed windows installer products used to filter the applications
+	ProductsFingerprint string
+	SubKeys             map[string]cachedSubKey
+}
+
+// cachedSubKey holds the last write time of an uninstall sub key and the application it declares, if any
+type cachedSubKey struct {
+	ModTime     int64
+	Application *model.ApplicationData `json:",omitempty"`
+}
+
+// applicationCache holds the uninstall key caches by registry path and architecture
+type applicationCache map[string]*uninstallKeyCache
+
+// applicationCachePath returns the path of the file persisting the application cache between collections
+func applicationCachePath(context context.T) (string, error) {
+	machineID, err := context.Identity().InstanceID()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appconfig.DefaultDataStorePath,
+		machineID,
+		appconfig.InventoryRootDirName,
+		appconfig.ApplicationCacheFileName), nil
+}
+
+// loadApplicationCache reads the application cache, an empty cache is returned when it cannot be read
+func loadApplicationCache(log log.T, path string) applicationCache {
+	cache := applicationCache{}
+	if !fileutil.Exists(path) {
+		return cache
+	}
+	content, err := fileutil.ReadAllText(path)
+	if err == nil {
+		err = json.Unmarshal([]byte(content), &cache)
+	}
+	if err != nil {
+		log.Debugf("Unable to read application cache %v, all applications will be queried: %v", path, err)
+		return applicationCache{}
+	}
+	return cache
+}
+
+// saveApplicationCache persists the application cache
+func saveApplicationCache(log log.T, path string, cache applicationCache) {
+	dataB, _ := json.Marshal(cache)
+	if err := fileutil.MakeDirs(filepath.Dir(path)); err != nil {
+		log.Debugf("Unable to create the application cache directory: %v", err)
+		return
+	}
+	if _, err := fileutil.WriteIntoFileWithPermissions(path, string(dataB), appconfig.ReadWriteAccess); err != nil {
+		log.Debugf("Unable to persist application cache %v: %v", path, err)
+	}
+}
+
+// changedSubKeys returns the sorted names of the sub keys added or modified since the cache was built,
+// full is true when all the sub keys have to be queried again
+func changedSubKeys(cached *uninstallKeyCache, current uninstallKeyState, productsFingerprint string) (changed []string, full bool) {
+	if cached == nil || cached.ProductsFingerprint != productsFingerprint {
+		return nil, true
+	}
+	for name, modTime := range current {
+		if subKey, found := cached.SubKeys[name]; !found || subKey.ModTime != modTime {
+			changed = append(changed, name)
+		}
+	}
+	if len(changed) > maxIncrementalSubKeys {
+		return nil, true
+	}
+	sort.Strings(changed)
+	return changed, false
+}
+
+// updateUninstallKeyCache returns the cache of the current sub keys, the sub keys declaring one of the queried
+// applications take it and the unchanged sub keys keep their cached application
+func updateUninstallKeyCache(cached *uninstallKeyCache, current uninstallKeyState, productsFingerprint string, queried []model.ApplicationData) *uninstallKeyCache {
+	queriedBySubKey := make(map[string]model.ApplicationData)
+	for _, application := range queried {
+		queriedBySubKey[strings.ToLower(application.PackageId)] = application
+	}
+
+	updated := &uninstallKeyCache{
+		ProductsFingerprint: productsFingerprint,
+		SubKeys:             make(map[string]cachedSubKey),
+	}
+	for name, modTime := range current {
+		subKey := cachedSubKey{ModTime: modTime}
+		if application, found := queriedBySubKey[name]; found {
+			subKey.Application = &application
+		} else if cached != nil {
+			if cachedSubKey, found := cached.SubKeys[name]; found && cachedSubKey.ModTime == modTime {
+				subKey.Application = cachedSubKey.Application
+			}
+		}
+		updated.SubKeys[name] = subKey
+	}
+	return updated
+}
+
+// applications returns the applications of the cache ordered by sub key name
+func (cache *uninstallKeyCache) applications() (data []model.ApplicationData) {
+	names := make([]string, 0, len(cache.SubKeys))
+	for name := range cache.SubKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if application := cache.SubKeys[name].Application; application != nil {
+			data = append(data, *application)
+		}
+	}
+	return
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package application
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedSubKeys(t *testing.T) {
+	cached := &uninstallKeyCache{
+		ProductsFingerprint: "products",
+		SubKeys: map[string]cachedSubKey{
+			"notepad++": {ModTime: 1},
+			"removed":   {ModTime: 1},
+			"updated":   {ModTime: 1},
+		},
+	}
+	current := uninstallKeyState{"notepad++": 1, "updated": 2, "added": 1}
+
+	changed, full := changedSubKeys(cached, current, "products")
+	assert.False(t, full)
+	assert.Equal(t, []string{"added", "updated"}, changed)
+
+	_, full = changedSubKeys(nil, current, "products")
+	assert.True(t, full, "all sub keys are queried without cache")
+
+	_, full = changedSubKeys(cached, current, "other")
+	assert.True(t, full, "all sub keys are queried when the installer products change")
+
+	manyChanges := uninstallKeyState{}
+	for i := 0; i <= maxIncrementalSubKeys; i++ {
+		manyChanges["app"+strconv.Itoa(i)] = 1
+	}
+	_, full = changedSubKeys(cached, manyChanges, "products")
+	assert.True(t, full, "all sub keys are queried when too many changed")
+}
+
+func TestUpdateUninstallKeyCache(t *testing.T) {
+	notepad := model.ApplicationData{Name: "Notepad++", PackageId: "Notepad++"}
+	golang := model.ApplicationData{Name: "Go", PackageId: "{854BC448-6940-4253-9E50-E433E8C2E96A}"}
+	cached := &uninstallKeyCache{
+		ProductsFingerprint: "products",
+		SubKeys: map[string]cachedSubKey{
+			"notepad++": {ModTime: 1, Application: &notepad},
+			"removed":   {ModTime: 1, Application: &model.ApplicationData{Name: "Removed"}},
+			"hotfix":    {ModTime: 1, Application: &model.ApplicationData{Name: "Previous"}},
+		},
+	}
+	current := uninstallKeyState{"notepad++": 1, "hotfix": 2, "{854bc448-6940-4253-9e50-e433e8c2e96a}": 1}
+
+	updated := updateUninstallKeyCache(cached, current, "products", []model.ApplicationData{golang})
+
+	assert.Equal(t, "products", updated.ProductsFingerprint)
+	assert.Len(t, updated.SubKeys, 3)
+	assert.Nil(t, updated.SubKeys["hotfix"].Application, "changed sub keys no longer declaring an application are dropped")
+	assert.Equal(t, []model.ApplicationData{notepad, golang}, updated.applications())
+
+	full := updateUninstallKeyCache(nil, current, "products", []model.ApplicationData{golang})
+	assert.Equal(t, []model.ApplicationData{golang}, full.applications())
+}
+
+func TestApplicationCachePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory", "applicationCache")
+	logger := log.NewMockLog()
+
+	assert.Empty(t, loadApplicationCache(logger, path))
+
+	cache := applicationCache{
+		registryPathKey: &uninstallKeyCache{
+			ProductsFingerprint: "products",
+			SubKeys: map[string]cachedSubKey{
+				"notepad++": {ModTime: 1, Application: &model.ApplicationData{Name: "Notepad++", PackageId: "Notepad++"}},
+				"hotfix":    {ModTime: 2},
+			},
+		},
+	}
+	saveApplicationCache(logger, path, cache)
+	assert.Equal(t, cache, loadApplicationCache(logger, path))
+}
+
+const registryPathKey = `HKLM:\Software\Microsoft\Windows\CurrentVersion\Uninstall\*:x86_64`
@@ -19,17 +19,21 @@
 package application
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"golang.org/x/sys/windows/registry"
 )
 
 const (
@@ -87,6 +91,7 @@ const (
 				      Select -expand PSChildName
 
 				     `
+	RegistryPathInstallerProducts                  = `Software\Classes\Installer\Products`
 	RegistryPathCurrentVersionUninstall            = `HKLM:\Software\Microsoft\Windows\CurrentVersion\Uninstall\*`
 	RegistryPathWow6432NodeCurrentVersionUninstall = `HKLM:\Software\Wow6432Node\Microsoft\Windows\CurrentVersion\Uninstall\*`
 	ArgsToReadRegistryApplications                 = `
@@ -174,11 +179,24 @@ func collectPlatformDependentApplicationData(context context.T) []model.Applicat
 
 	//it will enable us to run other complicated queries too.
 
+	/*
+		The applications declared under each uninstall key are cached along with the last write time of their sub key,
+		only the sub keys added or modified since the previous collection are queried again. All the sub keys are
+		queried when the installed windows installer products change, since they filter the applications.
+	*/
+
 	var data, apps []model.ApplicationData
-	var cmd string
 
 	log := context.Log()
 
+	cache := applicationCache{}
+	cachePath, err := applicationCachePath(context)
+	if err != nil {
+		log.Debugf("Unable to locate the application cache, all applications will be queried: %v", err)
+	} else {
+		cache = loadApplicationCache(log, cachePath)
+	}
+
 	//detecting process architecture
 	exeArch := runtime.GOARCH
 	log.Infof("Exe architecture as detected by golang runtime - %v", exeArch)
@@ -192,8 +210,7 @@ func collectPlatformDependentApplicationData(context context.T) []model.Applicat
 		if exeArch != Architecture64BitReportedByGoRuntime {
 			//exe architecture is also 32 bit
 			//since both exe & os are 32 bit - we need to detect only 32 bit apps
-			cmd = ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + ArgsToReadRegistryFromWindowsCurrentVersionUninstall
-			apps = executePowershellCommands(context, PowershellCmd, cmd, model.Arch32Bit)
+			apps = collectUninstallKeyApplications(context, cache, PowershellCmd, RegistryPathCurrentVersionUninstall, model.Arch32Bit)
 			data = append(data, apps...)
 		} else {
 			log.Error("Detected an unsupported scenario of 64 bit amazon ssm agent running on 32 bit windows OS - nothing to report")
@@ -204,35 +221,143 @@ func collectPlatformDependentApplicationData(context context.T) []model.Applicat
 			//both exe & os architecture is 64 bit
 
 			//detecting 32 bit apps by querying Wow6432Node path in registry
-			cmd = ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + ArgsToReadRegistryFromWow6432Node
-			apps = executePowershellCommands(context, PowershellCmd, cmd, model.Arch32Bit)
+			apps = collectUninstallKeyApplications(context, cache, PowershellCmd, RegistryPathWow6432NodeCurrentVersionUninstall, model.Arch32Bit)
 			data = append(data, apps...)
 
 			//detecting 64 bit apps by querying normal registry path
-			cmd = ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + ArgsToReadRegistryFromWindowsCurrentVersionUninstall
-			apps = executePowershellCommands(context, PowershellCmd, cmd, model.Arch64Bit)
+			apps = collectUninstallKeyApplications(context, cache, PowershellCmd, RegistryPathCurrentVersionUninstall, model.Arch64Bit)
 			data = append(data, apps...)
 		} else {
 			//exe architecture is 32 bit - all queries to registry path will be redirected to wow6432 so need to use sysnative
 			//reference: https://blogs.msdn.microsoft.com/david.wang/2006/03/27/howto-detect-process-bitness/
 
 			//detecting 32 bit apps by querying Wow632 registry node
-			cmd = ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + ArgsToReadRegistryFromWow6432Node
-			apps = executePowershellCommands(context, PowershellCmd, cmd, model.Arch32Bit)
+			apps = collectUninstallKeyApplications(context, cache, PowershellCmd, RegistryPathWow6432NodeCurrentVersionUninstall, model.Arch32Bit)
 			data = append(data, apps...)
 
 			//detecting 64 bit apps by using sysnative for reading registry to avoid path redirection
-			cmd = ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + ArgsToReadRegistryFromWindowsCurrentVersionUninstall
-			apps = executePowershellCommands(context, SysnativePowershellCmd, cmd, model.Arch64Bit)
+			apps = collectUninstallKeyApplications(context, cache, SysnativePowershellCmd, RegistryPathCurrentVersionUninstall, model.Arch64Bit)
 			data = append(data, apps...)
 		}
 	} else {
 		log.Error("Can't find application data because unable to detect OS architecture - nothing to report")
 	}
 
+	if cachePath != "" {
+		saveApplicationCache(log, cachePath, cache)
+	}
+
 	return data
 }
 
+// collectUninstallKeyApplications returns the applications declared under the given uninstall key, only the sub keys
+// changed since they were cached are queried
+func collectUninstallKeyApplications(context context.T, cache applicationCache, command, registryPath, arch string) (data []model.ApplicationData) {
+	log := context.Log()
+	cacheKey := registryPath + ":" + arch
+	fullArgs := ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + fmt.Sprintf(ArgsToReadRegistryApplications, registryPath)
+
+	current, err := readUninstallKeyState(registryPath)
+	var productsFingerprint string
+	if err == nil {
+		productsFingerprint, err = readProductsFingerprint()
+	}
+	if err != nil {
+		log.Debugf("Unable to detect the changes of %v, all applications will be queried: %v", registryPath, err)
+		delete(cache, cacheKey)
+		data, _ = executePowershellCommands(context, command, fullArgs, arch)
+		return
+	}
+
+	cached := cache[cacheKey]
+	changed, full := changedSubKeys(cached, current, productsFingerprint)
+	if !full && len(changed) > 0 {
+		log.Infof("Querying %d changed sub keys of %v", len(changed), registryPath)
+		args := ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + fmt.Sprintf(ArgsToReadRegistryApplications, literalSubKeyPaths(registryPath, changed))
+		if data, err = executePowershellCommands(context, command, args, arch); err != nil {
+			full = true
+		}
+	} else if !full {
+		log.Infof("No change detected under %v, using cached applications", registryPath)
+	}
+
+	if full {
+		cached = nil
+		if data, err = executePowershellCommands(context, command, fullArgs, arch); err != nil {
+			delete(cache, cacheKey)
+			return
+		}
+	}
+
+	cache[cacheKey] = updateUninstallKeyCache(cached, current, productsFingerprint, data)
+	return cache[cacheKey].applications()
+}
+
+// literalSubKeyPaths returns the powershell -LiteralPath parameter for the given sub keys of an uninstall key
+func literalSubKeyPaths(registryPath string, subKeys []string) string {
+	parent := strings.TrimSuffix(registryPath, `*`)
+	paths := make([]string, len(subKeys))
+	for i, subKey := range subKeys {
+		paths[i] = "'" + strings.Replace(parent+subKey, "'", "''", -1) + "'"
+	}
+	return "-LiteralPath " + strings.Join(paths, ",")
+}
+
+// readUninstallKeyState reads the last write time of the sub keys of an uninstall key; decouple for unit test
+var readUninstallKeyState = readUninstallKeyStateFunc
+
+func readUninstallKeyStateFunc(registryPath string) (uninstallKeyState, error) {
+	path := strings.TrimSuffix(strings.TrimPrefix(registryPath, `HKLM:\`), `\*`)
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.ENUMERATE_SUB_KEYS|registry.QUERY_VALUE|registry.WOW64_64KEY)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+
+	names, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil, err
+	}
+	state := make(uninstallKeyState)
+	for _, name := range names {
+		subKey, err := registry.OpenKey(key, name, registry.QUERY_VALUE|registry.WOW64_64KEY)
+		if err != nil {
+			return nil, err
+		}
+		info, err := subKey.Stat()
+		subKey.Close()
+		if err != nil {
+			return nil, err
+		}
+		state[strings.ToLower(name)] = info.ModTime().UnixNano()
+	}
+	return state, nil
+}
+
+// readProductsFingerprint returns a digest of the installed windows installer products; decouple for unit test
+var readProductsFingerprint = readProductsFingerprintFunc
+
+func readProductsFingerprintFunc() (string, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, RegistryPathInstallerProducts, registry.ENUMERATE_SUB_KEYS|registry.QUERY_VALUE|registry.WOW64_64KEY)
+	if err == registry.ErrNotExist {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	defer key.Close()
+
+	names, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		return "", err
+	}
+	for i, name := range names {
+		names[i] = strings.ToLower(name)
+	}
+	sort.Strings(names)
+	digest := sha256.Sum256([]byte(strings.Join(names, "\n")))
+	return hex.EncodeToString(digest[:]), nil
+}
+
 // detectOSArch detects OS architecture; decouple for unit test
 var detectOSArch = detectOSArchFun
 
@@ -264,10 +389,9 @@ func detectOSArchFun(context context.T, command, args string) (osArch string) {
 }
 
 // executePowershellCommands executes commands in powershell to get all windows applications installed.
-func executePowershellCommands(context context.T, command, args, arch string) (data []model.ApplicationData) {
+func executePowershellCommands(context context.T, command, args, arch string) (data []model.ApplicationData, err error) {
 
 	var output []byte
-	var err error
 	log := context.Log()
 
 	log.Infof("Getting all %v windows applications", arch)
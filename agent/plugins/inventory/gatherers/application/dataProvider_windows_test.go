@@ -19,8 +19,10 @@
 package application
 
 import (
+	"strings"
 	"testing"
 
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 	"github.com/stretchr/testify/assert"
 )
@@ -131,3 +133,52 @@ func getDataWithArchitecture(data []model.ApplicationData, architecture string)
 	}
 	return
 }
+
+func TestLiteralSubKeyPaths(t *testing.T) {
+	assert.Equal(t,
+		`-LiteralPath 'HKLM:\Software\Microsoft\Windows\CurrentVersion\Uninstall\notepad++','HKLM:\Software\Microsoft\Windows\CurrentVersion\Uninstall\o''brien'`,
+		literalSubKeyPaths(RegistryPathCurrentVersionUninstall, []string{"notepad++", "o'brien"}))
+}
+
+func TestCollectUninstallKeyApplications(t *testing.T) {
+	defer func() {
+		cmdExecutor = executeCommand
+		readUninstallKeyState = readUninstallKeyStateFunc
+		readProductsFingerprint = readProductsFingerprintFunc
+	}()
+	context := contextmocks.NewMockDefault()
+	readProductsFingerprint = func() (string, error) { return "products", nil }
+	readUninstallKeyState = func(registryPath string) (uninstallKeyState, error) {
+		return uninstallKeyState{"notepad++": 1}, nil
+	}
+	var queries []string
+	cmdExecutor = func(command string, args ...string) ([]byte, error) {
+		queries = append(queries, args[0])
+		return []byte(`{"Name":"Notepad++","PackageId":"Notepad++","Version":"6.9.2","Publisher":"Notepad++ Team","InstalledTime":""},`), nil
+	}
+
+	cache := applicationCache{}
+	data := collectUninstallKeyApplications(context, cache, PowershellCmd, RegistryPathCurrentVersionUninstall, mockArch)
+	assert.Len(t, data, 1)
+	assert.Len(t, queries, 1)
+	assert.Contains(t, queries[0], "Get-ItemProperty "+RegistryPathCurrentVersionUninstall)
+
+	// unchanged sub keys are not queried again
+	data = collectUninstallKeyApplications(context, cache, PowershellCmd, RegistryPathCurrentVersionUninstall, mockArch)
+	assert.Len(t, data, 1)
+	assert.Equal(t, "Notepad++", data[0].Name)
+	assert.Len(t, queries, 1)
+
+	// only the added sub key is queried
+	readUninstallKeyState = func(registryPath string) (uninstallKeyState, error) {
+		return uninstallKeyState{"notepad++": 1, "golang": 1}, nil
+	}
+	cmdExecutor = func(command string, args ...string) ([]byte, error) {
+		queries = append(queries, args[0])
+		return []byte(`{"Name":"Go","PackageId":"golang","Version":"1.8.3","Publisher":"https://golang.org","InstalledTime":""},`), nil
+	}
+	data = collectUninstallKeyApplications(context, cache, PowershellCmd, RegistryPathCurrentVersionUninstall, mockArch)
+	assert.Len(t, data, 2)
+	assert.Len(t, queries, 2)
+	assert.True(t, strings.Contains(queries[1], `-LiteralPath 'HKLM:\Software\Microsoft\Windows\CurrentVersion\Uninstall\golang'`))
+}
@@ -0,0 +1,165 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fileintegrity
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// FileCountLimit is the maximum number of files reported, to keep the item under the inventory size limit
+	FileCountLimit = 1000
+	// FileCountLimitExceeded is the error message when more than FileCountLimit files are configured
+	FileCountLimitExceeded = "File Count Limit Exceeded"
+
+	// StatusPresent is reported for the files that were hashed
+	StatusPresent = "Present"
+	// StatusMissing is reported for the configured paths that do not exist
+	StatusMissing = "Missing"
+	// StatusTooLarge is reported for the files larger than the configured maximum size, they are not hashed
+	StatusTooLarge = "TooLarge"
+	// StatusUnreadable is reported for the files that could not be read
+	StatusUnreadable = "Unreadable"
+)
+
+var FileCountLimitError = errors.New(FileCountLimitExceeded)
+
+var hashFileFunc = hashFile
+
+// collectFileIntegrityData hashes the regular files found under the configured paths. The configured paths are
+// resolved, the symbolic links found under them are not followed.
+func collectFileIntegrityData(context context.T) (data []model.FileIntegrityData, err error) {
+	log := context.Log()
+	config := context.AppConfig().Ssm.FileIntegrityInventory
+	maxFileSize := int64(config.MaxFileSizeMB) * 1024 * 1024
+	visited := make(map[string]bool)
+
+	// add reports a path once, every item counts toward the limit
+	add := func(item model.FileIntegrityData) error {
+		if visited[item.Path] {
+			return nil
+		}
+		if len(data) >= FileCountLimit {
+			return FileCountLimitError
+		}
+		visited[item.Path] = true
+		data = append(data, item)
+		return nil
+	}
+	accessFailed := func(path string, accessErr error) error {
+		log.Debugf("Failed to access %v: %v", path, accessErr)
+		status := StatusUnreadable
+		if os.IsNotExist(accessErr) {
+			status = StatusMissing
+		}
+		return add(model.FileIntegrityData{Path: path, Status: status})
+	}
+
+	for _, configuredPath := range config.Paths {
+		root := filepath.Clean(configuredPath)
+		err = walkConfiguredPath(root, func(path string, info os.FileInfo, walkErr error) error {
+			if walkErr != nil {
+				return accessFailed(path, walkErr)
+			}
+			if !info.Mode().IsRegular() || visited[path] {
+				return nil
+			}
+			return add(getFileIntegrityData(log, path, info, config.HashAlgorithm, maxFileSize))
+		})
+		if err != nil {
+			log.Errorf("Found more than limit of %d files", FileCountLimit)
+			return nil, err
+		}
+	}
+	log.Infof("Collected the digests of %d files", len(data))
+	return
+}
+
+// walkConfiguredPath walks the files under the configured path, the path is resolved when it is a symbolic link to a
+// file or a directory and the files are reported under the configured path
+func walkConfiguredPath(root string, walkFn filepath.WalkFunc) error {
+	rootInfo, err := os.Stat(root)
+	if err != nil {
+		return walkFn(root, nil, err)
+	}
+	if !rootInfo.IsDir() {
+		return walkFn(root, rootInfo, nil)
+	}
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return walkFn(root, nil, err)
+	}
+	return filepath.Walk(resolvedRoot, func(path string, info os.FileInfo, walkErr error) error {
+		if relativePath, err := filepath.Rel(resolvedRoot, path); err == nil {
+			path = filepath.Join(root, relativePath)
+		}
+		return walkFn(path, info, walkErr)
+	})
+}
+
+// getFileIntegrityData returns the digest and metadata of a file
+func getFileIntegrityData(log log.T, path string, info os.FileInfo, algorithm string, maxFileSize int64) model.FileIntegrityData {
+	data := model.FileIntegrityData{
+		Path:             path,
+		Status:           StatusPresent,
+		Size:             strconv.FormatInt(info.Size(), 10),
+		ModificationTime: info.ModTime().UTC().Format(time.RFC3339),
+		HashAlgorithm:    algorithm,
+	}
+	if info.Size() > maxFileSize {
+		data.Status = StatusTooLarge
+		return data
+	}
+
+	var err error
+	if data.Digest, err = hashFileFunc(path, algorithm); err != nil {
+		log.Debugf("Failed to hash %v: %v", path, err)
+		data.Status = StatusUnreadable
+	}
+	return data
+}
+
+// hashFile returns the hex encoded digest of a file
+func hashFile(path string, algorithm string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var digest hash.Hash
+	if algorithm == appconfig.FileIntegrityHashAlgorithmSHA512 {
+		digest = sha512.New()
+	} else {
+		digest = sha256.New()
+	}
+	if _, err = io.Copy(digest, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
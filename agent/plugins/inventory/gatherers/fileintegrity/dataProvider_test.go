@@ -0,0 +1,148 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fileintegrity
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFile(t *testing.T, path string, content []byte) {
+	assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0700))
+	assert.Nil(t, ioutil.WriteFile(path, content, 0600))
+}
+
+func TestCollectFileIntegrityData(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "agent.conf")
+	nestedFile := filepath.Join(dir, "conf.d", "nested.conf")
+	writeTestFile(t, configFile, []byte("config"))
+	writeTestFile(t, nestedFile, []byte("nested"))
+	missingFile := filepath.Join(dir, "missing.conf")
+
+	contextMock := contextWithPaths(dir, configFile, missingFile)
+	data, err := collectFileIntegrityData(contextMock)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(data))
+
+	configDigest := sha256.Sum256([]byte("config"))
+	assert.Equal(t, configFile, data[0].Path)
+	assert.Equal(t, StatusPresent, data[0].Status)
+	assert.Equal(t, "6", data[0].Size)
+	assert.Equal(t, appconfig.FileIntegrityHashAlgorithmSHA256, data[0].HashAlgorithm)
+	assert.Equal(t, hex.EncodeToString(configDigest[:]), data[0].Digest)
+	assert.NotEmpty(t, data[0].ModificationTime)
+
+	assert.Equal(t, nestedFile, data[1].Path)
+	assert.Equal(t, StatusPresent, data[1].Status)
+
+	assert.Equal(t, model.FileIntegrityData{Path: missingFile, Status: StatusMissing}, data[2])
+}
+
+func TestCollectFileIntegrityDataSHA512(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "agent.conf")
+	writeTestFile(t, file, []byte("config"))
+
+	config := appconfig.DefaultConfig()
+	config.Ssm.FileIntegrityInventory.Paths = []string{file}
+	config.Ssm.FileIntegrityInventory.HashAlgorithm = appconfig.FileIntegrityHashAlgorithmSHA512
+	data, err := collectFileIntegrityData(contextmocks.NewMockDefaultWithConfig(config))
+	assert.Nil(t, err)
+
+	digest := sha512.Sum512([]byte("config"))
+	assert.Equal(t, hex.EncodeToString(digest[:]), data[0].Digest)
+	assert.Equal(t, appconfig.FileIntegrityHashAlgorithmSHA512, data[0].HashAlgorithm)
+}
+
+func TestCollectFileIntegrityDataTooLargeOrUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	largeFile := filepath.Join(dir, "large.bin")
+	unreadableFile := filepath.Join(dir, "unreadable.conf")
+	writeTestFile(t, largeFile, make([]byte, 1024*1024+1))
+	writeTestFile(t, unreadableFile, []byte("config"))
+
+	hashFileFunc = func(path string, algorithm string) (string, error) {
+		assert.Equal(t, unreadableFile, path)
+		return "", errors.New("access denied")
+	}
+	defer func() { hashFileFunc = hashFile }()
+
+	config := appconfig.DefaultConfig()
+	config.Ssm.FileIntegrityInventory.Paths = []string{dir}
+	config.Ssm.FileIntegrityInventory.MaxFileSizeMB = 1
+	data, err := collectFileIntegrityData(contextmocks.NewMockDefaultWithConfig(config))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(data))
+	assert.Equal(t, StatusTooLarge, data[0].Status)
+	assert.Equal(t, "", data[0].Digest)
+	assert.Equal(t, StatusUnreadable, data[1].Status)
+}
+
+func TestCollectFileIntegrityDataLimitExceeded(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i <= FileCountLimit; i++ {
+		writeTestFile(t, filepath.Join(dir, fmt.Sprintf("file%d.conf", i)), nil)
+	}
+
+	data, err := collectFileIntegrityData(contextWithPaths(dir))
+	assert.Equal(t, FileCountLimitError, err)
+	assert.Nil(t, data)
+}
+
+func TestCollectFileIntegrityDataSymlinkRoots(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target", "agent.conf")
+	writeTestFile(t, target, []byte("config"))
+	fileLink := filepath.Join(dir, "agent.conf")
+	dirLink := filepath.Join(dir, "conf")
+	if err := os.Symlink(target, fileLink); err != nil {
+		t.Skipf("symbolic links are not supported: %v", err)
+	}
+	assert.Nil(t, os.Symlink(filepath.Dir(target), dirLink))
+
+	data, err := collectFileIntegrityData(contextWithPaths(fileLink, dirLink))
+	assert.Nil(t, err)
+
+	digest := sha256.Sum256([]byte("config"))
+	assert.Equal(t, 2, len(data))
+	assert.Equal(t, fileLink, data[0].Path)
+	assert.Equal(t, StatusPresent, data[0].Status)
+	assert.Equal(t, hex.EncodeToString(digest[:]), data[0].Digest)
+	assert.Equal(t, filepath.Join(dirLink, "agent.conf"), data[1].Path)
+	assert.Equal(t, StatusPresent, data[1].Status)
+}
+
+func TestCollectFileIntegrityDataLimitAppliesToMissingPaths(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i <= FileCountLimit; i++ {
+		paths = append(paths, filepath.Join(dir, fmt.Sprintf("missing%d.conf", i)))
+	}
+
+	data, err := collectFileIntegrityData(contextWithPaths(paths...))
+	assert.Equal(t, FileCountLimitError, err)
+	assert.Nil(t, data)
+}
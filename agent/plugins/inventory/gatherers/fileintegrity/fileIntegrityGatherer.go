@@ -0,0 +1,74 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package fileintegrity hashes the files configured on the instance and reports their digests as inventory.
+package fileintegrity
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// GathererName captures name of file integrity gatherer
+	GathererName = "AWS:FileIntegrity"
+	// TypeName is the custom inventory type reported by the file integrity gatherer
+	TypeName = "Custom:FileIntegrity"
+	// SchemaVersionOfFileIntegrityGatherer represents schema version of file integrity gatherer
+	SchemaVersionOfFileIntegrityGatherer = "1.0"
+)
+
+// T represents file integrity gatherer
+type T struct{}
+
+// Gatherer returns new file integrity gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+var collectData = collectFileIntegrityData
+
+// Name returns name of file integrity gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// Run executes file integrity gatherer and returns list of inventory.Item comprising of file digests
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+	if len(context.AppConfig().Ssm.FileIntegrityInventory.Paths) == 0 {
+		context.Log().Infof("No files are configured for file integrity inventory")
+		return
+	}
+
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	captureTime := time.Now().UTC().Format(time.RFC3339)
+	var data []model.FileIntegrityData
+	if data, err = collectData(context); err != nil {
+		return
+	}
+
+	items = append(items, model.Item{
+		Name:          TypeName,
+		SchemaVersion: SchemaVersionOfFileIntegrityGatherer,
+		Content:       data,
+		CaptureTime:   captureTime,
+	})
+	return
+}
+
+// RequestStop stops the execution of file integrity gatherer.
+func (t *T) RequestStop() error {
+	return nil
+}
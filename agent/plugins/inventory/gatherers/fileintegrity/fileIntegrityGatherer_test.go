@@ -0,0 +1,64 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fileintegrity
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFileIntegrity = []model.FileIntegrityData{
+	{
+		Path:             "/etc/ssh/sshd_config",
+		Status:           StatusPresent,
+		Size:             "3289",
+		ModificationTime: "2022-05-10T18:15:37Z",
+		HashAlgorithm:    appconfig.FileIntegrityHashAlgorithmSHA256,
+		Digest:           "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	},
+}
+
+func testCollectFileIntegrityData(context context.T) ([]model.FileIntegrityData, error) {
+	return testFileIntegrity, nil
+}
+
+func contextWithPaths(paths ...string) *contextmocks.Mock {
+	config := appconfig.DefaultConfig()
+	config.Ssm.FileIntegrityInventory.Paths = paths
+	return contextmocks.NewMockDefaultWithConfig(config)
+}
+
+func TestGatherer(t *testing.T) {
+	contextMock := contextWithPaths("/etc/ssh")
+	gatherer := Gatherer(contextMock)
+	collectData = testCollectFileIntegrityData
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, TypeName, items[0].Name)
+	assert.Equal(t, SchemaVersionOfFileIntegrityGatherer, items[0].SchemaVersion)
+	assert.Equal(t, testFileIntegrity, items[0].Content)
+}
+
+func TestGathererWithoutPaths(t *testing.T) {
+	contextMock := contextWithPaths()
+	items, err := Gatherer(contextMock).Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Empty(t, items)
+}
@@ -0,0 +1,175 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package localaccount
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// sudoersFile is the main sudoers file on unix platforms
+	sudoersFile = "/etc/sudoers"
+	// maxSudoersIncludeDepth is the maximum depth of nested sudoers includes, as enforced by sudo
+	maxSudoersIncludeDepth = 128
+)
+
+// accountData holds the local accounts collected on the instance
+type accountData struct {
+	Users          []model.LocalUserData
+	Groups         []model.LocalGroupData
+	SudoersEntries []model.SudoersEntryData
+}
+
+// decoupling for easy testability
+var readFileFunc = ioutil.ReadFile
+var readDirFunc = ioutil.ReadDir
+
+// collectSudoersEntries returns the entries of a sudoers file and of the files it includes
+func collectSudoersEntries(log log.T, path string, depth int) (entries []model.SudoersEntryData) {
+	content, err := readFileFunc(path)
+	if err != nil {
+		log.Debugf("Failed to read sudoers file %v: %v", path, err)
+		return
+	}
+
+	for _, line := range sudoersLines(string(content)) {
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "#include", "@include":
+			if len(fields) > 1 && depth < maxSudoersIncludeDepth {
+				entries = append(entries, collectSudoersEntries(log, includePath(path, fields[1]), depth+1)...)
+			}
+		case "#includedir", "@includedir":
+			if len(fields) > 1 && depth < maxSudoersIncludeDepth {
+				entries = append(entries, collectSudoersDir(log, includePath(path, fields[1]), depth+1)...)
+			}
+		default:
+			entries = append(entries, model.SudoersEntryData{Source: path, Entry: line})
+		}
+	}
+	return
+}
+
+// collectSudoersDir returns the entries of the files of an included sudoers directory, files ending in ~ or
+// containing a . are skipped like sudo does
+func collectSudoersDir(log log.T, dir string, depth int) (entries []model.SudoersEntryData) {
+	files, err := readDirFunc(dir)
+	if err != nil {
+		log.Debugf("Failed to read sudoers directory %v: %v", dir, err)
+		return
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasSuffix(name, "~") || strings.Contains(name, ".") {
+			continue
+		}
+		entries = append(entries, collectSudoersEntries(log, filepath.Join(dir, name), depth)...)
+	}
+	return
+}
+
+// includePath resolves an included path relative to the directory of the including file
+func includePath(path, included string) string {
+	if filepath.IsAbs(included) {
+		return included
+	}
+	return filepath.Join(filepath.Dir(path), included)
+}
+
+// sudoersLines returns the non empty lines of a sudoers file without comments, with continuation lines joined
+func sudoersLines(content string) (lines []string) {
+	var current string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasSuffix(line, `\`) {
+			current += strings.TrimSuffix(line, `\`) + " "
+			continue
+		}
+		line = strings.TrimSpace(current + line)
+		current = ""
+		if line == "" || isSudoersComment(line) {
+			continue
+		}
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	return
+}
+
+// isSudoersComment returns true for comment lines, # also starts the include directives and the user ids
+func isSudoersComment(line string) bool {
+	if !strings.HasPrefix(line, "#") {
+		return false
+	}
+	directive := strings.Fields(line)[0]
+	if directive == "#include" || directive == "#includedir" {
+		return false
+	}
+	_, err := strconv.Atoi(strings.TrimPrefix(directive, "#"))
+	return err != nil
+}
+
+// sudoersPrincipals returns the users and groups granted privileges by the sudoers user specifications
+func sudoersPrincipals(entries []model.SudoersEntryData) (users map[string]bool, groups map[string]bool) {
+	users = make(map[string]bool)
+	groups = make(map[string]bool)
+	for _, entry := range entries {
+		fields := strings.Fields(entry.Entry)
+		if strings.HasPrefix(fields[0], "Defaults") || strings.HasSuffix(fields[0], "_Alias") || !strings.Contains(entry.Entry, "=") {
+			continue
+		}
+		for _, principal := range strings.Split(fields[0], ",") {
+			if strings.HasPrefix(principal, "%") {
+				groups[strings.TrimPrefix(principal, "%")] = true
+			} else if principal != "" && !strings.HasPrefix(principal, "!") {
+				users[principal] = true
+			}
+		}
+	}
+	return
+}
+
+// markAdministrators flags the users with uid 0 and the users granted privileges in sudoers, directly or
+// through one of their groups
+func markAdministrators(data *accountData) {
+	sudoUsers, sudoGroups := sudoersPrincipals(data.SudoersEntries)
+	for i := range data.Users {
+		user := &data.Users[i]
+		isAdministrator := user.Id == "0" || sudoUsers[user.Name]
+		for _, group := range data.Groups {
+			if isAdministrator {
+				break
+			}
+			if sudoGroups[group.Name] && (group.Id == user.GroupId || isMember(group, user.Name)) {
+				isAdministrator = true
+			}
+		}
+		user.Administrator = strconv.FormatBool(isAdministrator)
+	}
+}
+
+// isMember returns true if the user is listed in the members of the group
+func isMember(group model.LocalGroupData, userName string) bool {
+	for _, member := range strings.Split(group.Members, ",") {
+		if member == userName {
+			return true
+		}
+	}
+	return false
+}
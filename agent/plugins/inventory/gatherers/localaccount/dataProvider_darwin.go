@@ -0,0 +1,110 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package localaccount
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	dsclCmd = "dscl"
+
+	sudoersSupported = true
+)
+
+var cmdExecutor = executeCommand
+
+func executeCommand(command string, args ...string) ([]byte, error) {
+	return exec.Command(command, args...).Output()
+}
+
+// collectAccountData reads the local users and groups from the local directory service
+func collectAccountData(context context.T) (data accountData, err error) {
+	log := context.Log()
+	var output []byte
+	if output, err = cmdExecutor(dsclCmd, ".", "-readall", "/Users", "RecordName", "UniqueID", "PrimaryGroupID", "NFSHomeDirectory", "UserShell", "RealName"); err != nil {
+		return data, fmt.Errorf("failed to list the local users: %v", err)
+	}
+	for _, record := range parseDsclRecords(string(output)) {
+		data.Users = append(data.Users, model.LocalUserData{
+			Name:          firstValue(record["RecordName"]),
+			Id:            record["UniqueID"],
+			GroupId:       record["PrimaryGroupID"],
+			Description:   record["RealName"],
+			HomeDirectory: record["NFSHomeDirectory"],
+			Shell:         record["UserShell"],
+		})
+	}
+
+	if output, err = cmdExecutor(dsclCmd, ".", "-readall", "/Groups", "RecordName", "PrimaryGroupID", "GroupMembership"); err != nil {
+		return data, fmt.Errorf("failed to list the local groups: %v", err)
+	}
+	for _, record := range parseDsclRecords(string(output)) {
+		data.Groups = append(data.Groups, model.LocalGroupData{
+			Name:    firstValue(record["RecordName"]),
+			Id:      record["PrimaryGroupID"],
+			Members: strings.Join(strings.Fields(record["GroupMembership"]), ","),
+		})
+	}
+
+	data.SudoersEntries = collectSudoersEntries(log, sudoersFile, 0)
+	markAdministrators(&data)
+	log.Infof("Collected %d local users, %d local groups and %d sudoers entries", len(data.Users), len(data.Groups), len(data.SudoersEntries))
+	return
+}
+
+// parseDsclRecords parses the output of dscl -readall, records are separated by a - line and the values
+// that do not fit on the attribute line are written on the following lines indented by a space
+func parseDsclRecords(output string) (records []map[string]string) {
+	record := make(map[string]string)
+	var attribute string
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "-":
+			records = appendRecord(records, record)
+			record = make(map[string]string)
+		case strings.HasPrefix(line, " ") && attribute != "":
+			record[attribute] = strings.TrimSpace(record[attribute] + " " + strings.TrimSpace(line))
+		default:
+			if parts := strings.SplitN(line, ":", 2); len(parts) == 2 {
+				attribute = parts[0]
+				record[attribute] = strings.TrimSpace(parts[1])
+			}
+		}
+	}
+	return appendRecord(records, record)
+}
+
+func appendRecord(records []map[string]string, record map[string]string) []map[string]string {
+	if record["RecordName"] == "" {
+		return records
+	}
+	return append(records, record)
+}
+
+// firstValue returns the first of the space separated values of an attribute
+func firstValue(value string) string {
+	if fields := strings.Fields(value); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package localaccount
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var sampleDsclUsers = `NFSHomeDirectory: /var/root
+PrimaryGroupID: 0
+RealName:
+ System Administrator
+RecordName: root BUILTIN\Local System
+UniqueID: 0
+UserShell: /bin/sh
+-
+NFSHomeDirectory: /Users/ec2-user
+PrimaryGroupID: 20
+RealName: ec2-user
+RecordName: ec2-user
+UniqueID: 501
+UserShell: /bin/zsh
+`
+
+func TestParseDsclRecords(t *testing.T) {
+	records := parseDsclRecords(sampleDsclUsers)
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, "System Administrator", records[0]["RealName"])
+	assert.Equal(t, "root", firstValue(records[0]["RecordName"]))
+	assert.Equal(t, "501", records[1]["UniqueID"])
+	assert.Equal(t, "/bin/zsh", records[1]["UserShell"])
+}
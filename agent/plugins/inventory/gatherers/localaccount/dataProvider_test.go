@@ -0,0 +1,107 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package localaccount
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleSudoers = `
+## Allow root to run any commands anywhere
+Defaults    env_reset
+Defaults    secure_path = /sbin:/bin:/usr/sbin:/usr/bin
+User_Alias  OPERATORS = alice, bob
+root    ALL=(ALL)   ALL
+%wheel  ALL=(ALL) \
+        NOPASSWD: ALL
+#1001   ALL=(ALL) ALL
+#includedir /etc/sudoers.d
+`
+
+func TestSudoersLines(t *testing.T) {
+	assert.Equal(t, []string{
+		"Defaults env_reset",
+		"Defaults secure_path = /sbin:/bin:/usr/sbin:/usr/bin",
+		"User_Alias OPERATORS = alice, bob",
+		"root ALL=(ALL) ALL",
+		"%wheel ALL=(ALL) NOPASSWD: ALL",
+		"#1001 ALL=(ALL) ALL",
+		"#includedir /etc/sudoers.d",
+	}, sudoersLines(sampleSudoers))
+}
+
+func TestCollectSudoersEntries(t *testing.T) {
+	dir := t.TempDir()
+	includedDir := filepath.Join(dir, "sudoers.d")
+	assert.Nil(t, os.MkdirAll(includedDir, 0700))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "sudoers"), []byte("root ALL=(ALL) ALL\n@includedir sudoers.d\n#include missing\n"), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(includedDir, "90-cloud-init-users"), []byte("ec2-user ALL=(ALL) NOPASSWD:ALL\n"), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(includedDir, "README.txt"), []byte("skipped ALL=(ALL) ALL\n"), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(includedDir, "backup~"), []byte("skipped ALL=(ALL) ALL\n"), 0600))
+
+	entries := collectSudoersEntries(log.NewMockLog(), filepath.Join(dir, "sudoers"), 0)
+	assert.Equal(t, []model.SudoersEntryData{
+		{Source: filepath.Join(dir, "sudoers"), Entry: "root ALL=(ALL) ALL"},
+		{Source: filepath.Join(includedDir, "90-cloud-init-users"), Entry: "ec2-user ALL=(ALL) NOPASSWD:ALL"},
+	}, entries)
+}
+
+func TestCollectSudoersEntriesIncludeLoop(t *testing.T) {
+	readFileFunc = func(path string) ([]byte, error) {
+		if path == "/etc/sudoers" {
+			return []byte("#include /etc/sudoers\n"), nil
+		}
+		return nil, errors.New("not found")
+	}
+	defer func() { readFileFunc = ioutil.ReadFile }()
+
+	assert.Empty(t, collectSudoersEntries(log.NewMockLog(), "/etc/sudoers", 0))
+}
+
+func TestMarkAdministrators(t *testing.T) {
+	data := accountData{
+		Users: []model.LocalUserData{
+			{Name: "root", Id: "0", GroupId: "0"},
+			{Name: "ec2-user", Id: "1000", GroupId: "1000"},
+			{Name: "alice", Id: "1001", GroupId: "10"},
+			{Name: "bob", Id: "1002", GroupId: "1002"},
+			{Name: "carol", Id: "1003", GroupId: "1003"},
+		},
+		Groups: []model.LocalGroupData{
+			{Name: "wheel", Id: "10", Members: ""},
+			{Name: "admins", Id: "20", Members: "ec2-user,bob"},
+		},
+		SudoersEntries: []model.SudoersEntryData{
+			{Entry: "Defaults env_reset"},
+			{Entry: "User_Alias OPERATORS = carol"},
+			{Entry: "%wheel ALL=(ALL) ALL"},
+			{Entry: "%admins,ec2-user ALL=(ALL) NOPASSWD: ALL"},
+		},
+	}
+	markAdministrators(&data)
+
+	administrators := map[string]string{}
+	for _, user := range data.Users {
+		administrators[user.Name] = user.Administrator
+	}
+	assert.Equal(t, map[string]string{"root": "true", "ec2-user": "true", "alice": "true", "bob": "true", "carol": "false"}, administrators)
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package localaccount
+
+import (
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	passwdFile = "/etc/passwd"
+	groupFile  = "/etc/group"
+
+	sudoersSupported = true
+)
+
+// collectAccountData reads the local users and groups from the passwd and group files
+func collectAccountData(context context.T) (data accountData, err error) {
+	log := context.Log()
+	var content []byte
+	if content, err = readFileFunc(passwdFile); err != nil {
+		log.Errorf("Failed to read %v: %v", passwdFile, err)
+		return
+	}
+	data.Users = parsePasswd(string(content))
+
+	if content, err = readFileFunc(groupFile); err != nil {
+		log.Errorf("Failed to read %v: %v", groupFile, err)
+		return
+	}
+	data.Groups = parseGroup(string(content))
+
+	data.SudoersEntries = collectSudoersEntries(log, sudoersFile, 0)
+	markAdministrators(&data)
+	log.Infof("Collected %d local users, %d local groups and %d sudoers entries", len(data.Users), len(data.Groups), len(data.SudoersEntries))
+	return
+}
+
+// parsePasswd parses the entries of a passwd file, name:password:uid:gid:gecos:home:shell
+func parsePasswd(content string) (users []model.LocalUserData) {
+	for _, fields := range databaseEntries(content, 7) {
+		users = append(users, model.LocalUserData{
+			Name:          fields[0],
+			Id:            fields[2],
+			GroupId:       fields[3],
+			Description:   fields[4],
+			HomeDirectory: fields[5],
+			Shell:         fields[6],
+		})
+	}
+	return
+}
+
+// parseGroup parses the entries of a group file, name:password:gid:members
+func parseGroup(content string) (groups []model.LocalGroupData) {
+	for _, fields := range databaseEntries(content, 4) {
+		groups = append(groups, model.LocalGroupData{
+			Name:    fields[0],
+			Id:      fields[2],
+			Members: fields[3],
+		})
+	}
+	return
+}
+
+// databaseEntries returns the fields of the entries of a colon separated database file, comments, NIS
+// compat entries and malformed entries are skipped
+func databaseEntries(content string, fieldCount int) (entries [][]string) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if fields := strings.Split(line, ":"); len(fields) == fieldCount {
+			entries = append(entries, fields)
+		}
+	}
+	return
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package localaccount
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var samplePasswd = `root:x:0:0:root:/root:/bin/bash
+# comment
++nisuser::::::
+ec2-user:x:1000:1000:EC2 Default User:/home/ec2-user:/bin/bash
+malformed:x:1001
+`
+
+var sampleGroup = `root:x:0:
+wheel:x:10:ec2-user,alice
+`
+
+func TestCollectAccountData(t *testing.T) {
+	files := map[string]string{
+		passwdFile:  samplePasswd,
+		groupFile:   sampleGroup,
+		sudoersFile: "%wheel ALL=(ALL) ALL\n",
+	}
+	readFileFunc = func(path string) ([]byte, error) {
+		if content, found := files[path]; found {
+			return []byte(content), nil
+		}
+		return nil, errors.New("not found")
+	}
+	defer func() { readFileFunc = ioutil.ReadFile }()
+
+	data, err := collectAccountData(contextmocks.NewMockDefault())
+	assert.Nil(t, err)
+	assert.Equal(t, []model.LocalUserData{
+		{Name: "root", Id: "0", GroupId: "0", Description: "root", HomeDirectory: "/root", Shell: "/bin/bash", Administrator: "true"},
+		{Name: "ec2-user", Id: "1000", GroupId: "1000", Description: "EC2 Default User", HomeDirectory: "/home/ec2-user", Shell: "/bin/bash", Administrator: "true"},
+	}, data.Users)
+	assert.Equal(t, []model.LocalGroupData{
+		{Name: "root", Id: "0", Members: ""},
+		{Name: "wheel", Id: "10", Members: "ec2-user,alice"},
+	}, data.Groups)
+	assert.Equal(t, []model.SudoersEntryData{{Source: sudoersFile, Entry: "%wheel ALL=(ALL) ALL"}}, data.SudoersEntries)
+}
+
+func TestCollectAccountDataPasswdError(t *testing.T) {
+	readFileFunc = func(path string) ([]byte, error) {
+		return nil, errors.New("permission denied")
+	}
+	defer func() { readFileFunc = ioutil.ReadFile }()
+
+	_, err := collectAccountData(contextmocks.NewMockDefault())
+	assert.Error(t, err)
+}
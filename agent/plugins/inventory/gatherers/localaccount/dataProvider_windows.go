@@ -0,0 +1,103 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package localaccount
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/twinj/uuid"
+)
+
+const sudoersSupported = false
+
+var (
+	PowershellCmd = appconfig.PowerShellPluginCommandName
+
+	startMarker            = "<start" + randomString(8) + ">"
+	endMarker              = "<end" + randomString(8) + ">"
+	localAccountInfoScript = `
+[Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+function Get-Members($group) {
+  try { return @(Get-LocalGroupMember -Group $group -ErrorAction Stop) } catch { return @() }
+}
+$administrators = @(Get-Members (Get-LocalGroup -SID "S-1-5-32-544") | ForEach-Object { $_.SID.Value })
+$users = @()
+foreach ($u in Get-LocalUser) {
+$Name = $u.Name
+$Id = $u.SID.Value
+$Enabled = $u.Enabled.ToString().ToLower()
+$Description = $u.Description
+$Administrator = ($administrators -contains $Id).ToString().ToLower()
+$users += @"
+{"Name": "` + mark(`$Name`) + `", "Id": "$Id", "Enabled": "$Enabled", "Description": "` + mark(`$Description`) + `", "Administrator": "$Administrator"}
+"@
+}
+$groups = @()
+foreach ($g in Get-LocalGroup) {
+$Name = $g.Name
+$Id = $g.SID.Value
+$Members = (Get-Members $g | ForEach-Object { $_.Name }) -join ","
+$groups += @"
+{"Name": "` + mark(`$Name`) + `", "Id": "$Id", "Members": "` + mark(`$Members`) + `"}
+"@
+}
+$result = '{"Users": [' + ($users -join ",") + '], "Groups": [' + ($groups -join ",") + ']}'
+[Console]::WriteLine($result)
+`
+)
+
+func randomString(length int) string {
+	return uuid.NewV4().String()[:length]
+}
+
+func mark(s string) string {
+	return startMarker + s + endMarker
+}
+
+var cmdExecutor = executeCommand
+
+func executeCommand(command string, args ...string) ([]byte, error) {
+	return exec.Command(command, args...).CombinedOutput()
+}
+
+// collectAccountData reads the local users and groups, the administrators are the members of the
+// builtin Administrators group
+func collectAccountData(context context.T) (data accountData, err error) {
+	log := context.Log()
+	var output []byte
+	if output, err = cmdExecutor(PowershellCmd, localAccountInfoScript); err != nil {
+		log.Debugf("Command Stderr: %v", string(output))
+		return data, fmt.Errorf("Command failed with error: %v", string(output))
+	}
+
+	var cleanOutput string
+	if cleanOutput, err = pluginutil.ReplaceMarkedFields(pluginutil.CleanupNewLines(string(output)), startMarker, endMarker, pluginutil.CleanupJSONField); err != nil {
+		return
+	}
+	log.Debugf("Command output: %v", cleanOutput)
+
+	if err = json.Unmarshal([]byte(cleanOutput), &data); err != nil {
+		return data, fmt.Errorf("Unable to parse command output - %v", err.Error())
+	}
+	log.Infof("Collected %d local users and %d local groups", len(data.Users), len(data.Groups))
+	return
+}
@@ -0,0 +1,39 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package localaccount
+
+import (
+	"testing"
+
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectAccountData(t *testing.T) {
+	output := `{"Users": [{"Name": "` + mark("Administrator") + `", "Id": "S-1-5-21-1-500", "Enabled": "true", "Description": "` + mark("Built-in account") + `", "Administrator": "true"}], ` +
+		`"Groups": [{"Name": "` + mark("Administrators") + `", "Id": "S-1-5-32-544", "Members": "` + mark(`EC2AMAZ\Administrator`) + `"}]}`
+	cmdExecutor = func(command string, args ...string) ([]byte, error) {
+		return []byte(output), nil
+	}
+	defer func() { cmdExecutor = executeCommand }()
+
+	data, err := collectAccountData(contextmocks.NewMockDefault())
+	assert.Nil(t, err)
+	assert.Equal(t, []model.LocalUserData{{Name: "Administrator", Id: "S-1-5-21-1-500", Enabled: "true", Description: "Built-in account", Administrator: "true"}}, data.Users)
+	assert.Equal(t, []model.LocalGroupData{{Name: "Administrators", Id: "S-1-5-32-544", Members: `EC2AMAZ\Administrator`}}, data.Groups)
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package localaccount collects the local users, groups and sudoers entries of the instance.
+package localaccount
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// GathererName captures name of local account gatherer
+	GathererName = "AWS:LocalAccounts"
+	// UserTypeName is the custom inventory type reporting the local users
+	UserTypeName = "Custom:LocalUser"
+	// GroupTypeName is the custom inventory type reporting the local groups and their members
+	GroupTypeName = "Custom:LocalGroup"
+	// SudoersEntryTypeName is the custom inventory type reporting the sudoers entries
+	SudoersEntryTypeName = "Custom:SudoersEntry"
+	// SchemaVersionOfLocalAccountGatherer represents schema version of local account gatherer
+	SchemaVersionOfLocalAccountGatherer = "1.0"
+)
+
+// T represents local account gatherer
+type T struct{}
+
+// Gatherer returns new local account gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+var collectData = collectAccountData
+
+// Name returns name of local account gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// Run executes local account gatherer and returns the users, groups and sudoers entries inventory items
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+	var data accountData
+	if data, err = collectData(context); err != nil {
+		return
+	}
+
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	captureTime := time.Now().UTC().Format(time.RFC3339)
+	items = append(items,
+		model.Item{
+			Name:          UserTypeName,
+			SchemaVersion: SchemaVersionOfLocalAccountGatherer,
+			Content:       data.Users,
+			CaptureTime:   captureTime,
+		},
+		model.Item{
+			Name:          GroupTypeName,
+			SchemaVersion: SchemaVersionOfLocalAccountGatherer,
+			Content:       data.Groups,
+			CaptureTime:   captureTime,
+		})
+	if sudoersSupported {
+		items = append(items, model.Item{
+			Name:          SudoersEntryTypeName,
+			SchemaVersion: SchemaVersionOfLocalAccountGatherer,
+			Content:       data.SudoersEntries,
+			CaptureTime:   captureTime,
+		})
+	}
+	return
+}
+
+// RequestStop stops the execution of local account gatherer.
+func (t *T) RequestStop() error {
+	return nil
+}
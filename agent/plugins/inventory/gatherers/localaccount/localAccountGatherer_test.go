@@ -0,0 +1,53 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package localaccount
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testAccountData = accountData{
+	Users:          []model.LocalUserData{{Name: "ec2-user", Id: "1000", GroupId: "1000", Administrator: "true"}},
+	Groups:         []model.LocalGroupData{{Name: "wheel", Id: "10", Members: "ec2-user"}},
+	SudoersEntries: []model.SudoersEntryData{{Source: "/etc/sudoers", Entry: "%wheel ALL=(ALL) ALL"}},
+}
+
+func testCollectAccountData(context context.T) (accountData, error) {
+	return testAccountData, nil
+}
+
+func TestGatherer(t *testing.T) {
+	contextMock := contextmocks.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	collectData = testCollectAccountData
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, UserTypeName, items[0].Name)
+	assert.Equal(t, testAccountData.Users, items[0].Content)
+	assert.Equal(t, GroupTypeName, items[1].Name)
+	assert.Equal(t, testAccountData.Groups, items[1].Content)
+	assert.Equal(t, SchemaVersionOfLocalAccountGatherer, items[1].SchemaVersion)
+	if sudoersSupported {
+		assert.Equal(t, 3, len(items))
+		assert.Equal(t, SudoersEntryTypeName, items[2].Name)
+		assert.Equal(t, testAccountData.SudoersEntries, items[2].Content)
+	} else {
+		assert.Equal(t, 2, len(items))
+	}
+}
@@ -23,8 +23,11 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/billinginfo"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/custom"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/file"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/fileintegrity"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/instancedetailedinformation"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/localaccount"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/productcode"
 )
 
 var supportedGathererNames = []string{
@@ -34,5 +37,8 @@ var supportedGathererNames = []string{
 	billinginfo.GathererName,
 	network.GathererName,
 	file.GathererName,
+	fileintegrity.GathererName,
 	instancedetailedinformation.GathererName,
+	localaccount.GathererName,
+	productcode.GathererName,
 }
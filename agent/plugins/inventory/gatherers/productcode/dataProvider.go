@@ -0,0 +1,116 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package productcode
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/aws/amazon-ssm-agent/common/identity/identity"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// TenancyDefault is reported for the instances that do not run on a Dedicated Host
+	TenancyDefault = "default"
+	// TenancyHost is reported for the instances running on a Dedicated Host
+	TenancyHost = "host"
+
+	hostIdMetadataPath = "placement/host-id"
+)
+
+var isOnPremInstance = identity.IsOnPremInstance
+
+// decouples for easy testability
+var queryIdentityDocument = queryInstanceIdentityDocument
+var queryHostId = queryInstanceHostId
+
+// collectProductCodeData reads the product codes of the instance from its identity document, on-premises
+// instances have nothing to report
+func collectProductCodeData(context context.T) (data []model.ProductCodeData, err error) {
+	log := context.Log()
+	log.Infof("Getting %v data", GathererName)
+
+	if isOnPremInstance(context.Identity()) {
+		log.Infof("No product codes to report for an on-premises instance")
+		return
+	}
+
+	identityDocument, err := queryIdentityDocument()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the instance identity document: %v", err)
+	}
+	hostId, err := queryHostId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the dedicated host id: %v", err)
+	}
+
+	data = append(data, parseProductCodeData(identityDocument, hostId))
+	log.Debugf("Collected %v data %v", GathererName, data)
+	return
+}
+
+// parseProductCodeData builds the product code entry of the instance, the instance metadata only exposes the
+// dedicated host id so the dedicated instances running outside of a Dedicated Host report the default tenancy
+func parseProductCodeData(identityDocument ec2metadata.EC2InstanceIdentityDocument, hostId string) model.ProductCodeData {
+	data := model.ProductCodeData{
+		MarketplaceProductCodes: joinCodes(identityDocument.MarketplaceProductCodes),
+		DevpayProductCodes:      joinCodes(identityDocument.DevpayProductCodes),
+		BillingProducts:         joinCodes(identityDocument.BillingProducts),
+		LicenseIncluded:         strconv.FormatBool(len(identityDocument.BillingProducts) > 0),
+		Tenancy:                 TenancyDefault,
+		ImageId:                 identityDocument.ImageID,
+		InstanceType:            identityDocument.InstanceType,
+	}
+	if hostId != "" {
+		data.Tenancy = TenancyHost
+		data.HostId = hostId
+	}
+	return data
+}
+
+// joinCodes returns the comma separated list of the codes
+func joinCodes(codes []string) string {
+	var trimmed []string
+	for _, code := range codes {
+		if code = strings.TrimSpace(code); code != "" {
+			trimmed = append(trimmed, code)
+		}
+	}
+	return strings.Join(trimmed, ",")
+}
+
+func newMetadataService() *ec2metadata.EC2Metadata {
+	return ec2metadata.New(session.New(aws.NewConfig().WithMaxRetries(3)))
+}
+
+func queryInstanceIdentityDocument() (ec2metadata.EC2InstanceIdentityDocument, error) {
+	return newMetadataService().GetInstanceIdentityDocument()
+}
+
+// queryInstanceHostId returns the id of the Dedicated Host running the instance, or an empty string when
+// the instance does not run on a Dedicated Host
+func queryInstanceHostId() (string, error) {
+	hostId, err := newMetadataService().GetMetadata(hostIdMetadataPath)
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == 404 {
+		return "", nil
+	}
+	return strings.TrimSpace(hostId), err
+}
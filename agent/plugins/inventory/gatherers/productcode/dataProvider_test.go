@@ -0,0 +1,89 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package productcode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/aws/amazon-ssm-agent/common/identity"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+var originalIsOnPremInstance = isOnPremInstance
+
+var sampleIdentityDocument = ec2metadata.EC2InstanceIdentityDocument{
+	MarketplaceProductCodes: []string{"89bab4k3h9x4rkojcm2tj8j4l"},
+	BillingProducts:         []string{"bp-6ba54002", " bp-23478 "},
+	InstanceType:            "m5.large",
+	ImageID:                 "ami-0de53d8956e8dcf80",
+}
+
+func TestParseProductCodeData(t *testing.T) {
+	data := parseProductCodeData(sampleIdentityDocument, "")
+	assert.Equal(t, model.ProductCodeData{
+		MarketplaceProductCodes: "89bab4k3h9x4rkojcm2tj8j4l",
+		BillingProducts:         "bp-6ba54002,bp-23478",
+		LicenseIncluded:         "true",
+		Tenancy:                 TenancyDefault,
+		ImageId:                 "ami-0de53d8956e8dcf80",
+		InstanceType:            "m5.large",
+	}, data)
+
+	data = parseProductCodeData(ec2metadata.EC2InstanceIdentityDocument{}, "h-0123456789abcdef0")
+	assert.Equal(t, "false", data.LicenseIncluded)
+	assert.Equal(t, TenancyHost, data.Tenancy)
+	assert.Equal(t, "h-0123456789abcdef0", data.HostId)
+}
+
+func TestCollectProductCodeData(t *testing.T) {
+	defer func() {
+		isOnPremInstance = originalIsOnPremInstance
+		queryIdentityDocument = queryInstanceIdentityDocument
+		queryHostId = queryInstanceHostId
+	}()
+	mockContext := context.NewMockDefault()
+	isOnPremInstance = func(identity.IAgentIdentity) bool { return false }
+	queryIdentityDocument = func() (ec2metadata.EC2InstanceIdentityDocument, error) {
+		return sampleIdentityDocument, nil
+	}
+	queryHostId = func() (string, error) { return "", nil }
+
+	data, err := collectProductCodeData(mockContext)
+	assert.Nil(t, err)
+	assert.Equal(t, []model.ProductCodeData{parseProductCodeData(sampleIdentityDocument, "")}, data)
+
+	queryHostId = func() (string, error) { return "", fmt.Errorf("random error") }
+	_, err = collectProductCodeData(mockContext)
+	assert.NotNil(t, err)
+
+	queryIdentityDocument = func() (ec2metadata.EC2InstanceIdentityDocument, error) {
+		return ec2metadata.EC2InstanceIdentityDocument{}, fmt.Errorf("random error")
+	}
+	_, err = collectProductCodeData(mockContext)
+	assert.NotNil(t, err)
+}
+
+func TestCollectProductCodeDataWithOnPremInstance(t *testing.T) {
+	defer func() { isOnPremInstance = originalIsOnPremInstance }()
+	mockContext := context.NewMockDefault()
+	isOnPremInstance = func(identity.IAgentIdentity) bool { return true }
+
+	data, err := collectProductCodeData(mockContext)
+	assert.Nil(t, err)
+	assert.Empty(t, data)
+}
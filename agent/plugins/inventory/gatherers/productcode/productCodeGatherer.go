@@ -0,0 +1,69 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package productcode reports the marketplace product codes, billing products and tenancy of the instance as inventory.
+package productcode
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// GathererName captures name of product code gatherer
+	GathererName = "AWS:ProductCode"
+	// TypeName is the custom inventory type reported by the product code gatherer
+	TypeName = "Custom:ProductCode"
+	// SchemaVersionOfProductCodeGatherer represents schema version of product code gatherer
+	SchemaVersionOfProductCodeGatherer = "1.0"
+)
+
+// T represents product code gatherer
+type T struct{}
+
+// Gatherer returns new product code gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+var collectData = collectProductCodeData
+
+// Name returns name of product code gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// Run executes product code gatherer and returns list of inventory.Item comprising of product code data
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	captureTime := time.Now().UTC().Format(time.RFC3339)
+	var data []model.ProductCodeData
+	if data, err = collectData(context); err != nil || len(data) == 0 {
+		return
+	}
+
+	items = append(items, model.Item{
+		Name:          TypeName,
+		SchemaVersion: SchemaVersionOfProductCodeGatherer,
+		Content:       data,
+		CaptureTime:   captureTime,
+	})
+	return
+}
+
+// RequestStop stops the execution of product code gatherer.
+func (t *T) RequestStop() error {
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package productcode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testProductCodes = []model.ProductCodeData{
+	{
+		MarketplaceProductCodes: "89bab4k3h9x4rkojcm2tj8j4l",
+		LicenseIncluded:         "false",
+		Tenancy:                 TenancyDefault,
+	},
+}
+
+func TestGatherer(t *testing.T) {
+	defer func() { collectData = collectProductCodeData }()
+	contextMock := contextmocks.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	collectData = func(context context.T) ([]model.ProductCodeData, error) {
+		return testProductCodes, nil
+	}
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, TypeName, items[0].Name)
+	assert.Equal(t, SchemaVersionOfProductCodeGatherer, items[0].SchemaVersion)
+	assert.Equal(t, testProductCodes, items[0].Content)
+}
+
+func TestGathererNothingToReport(t *testing.T) {
+	defer func() { collectData = collectProductCodeData }()
+	contextMock := contextmocks.NewMockDefault()
+	collectData = func(context context.T) ([]model.ProductCodeData, error) {
+		return nil, nil
+	}
+	items, err := Gatherer(contextMock).Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Empty(t, items)
+
+	collectData = func(context context.T) ([]model.ProductCodeData, error) {
+		return nil, fmt.Errorf("metadata unavailable")
+	}
+	items, err = Gatherer(contextMock).Run(contextMock, model.Config{})
+	assert.NotNil(t, err)
+	assert.Empty(t, items)
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package registrykeyset
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// MaxValueCountLimit is the maximum number of values collected for one key set
+	MaxValueCountLimit = 250
+	// RedactedValue replaces the data of the redacted values
+	RedactedValue = "REDACTED"
+)
+
+// ValueCountLimitExceeded is returned when a key set contains more values than MaxValueCountLimit
+var ValueCountLimitExceeded = errors.New("Exceeded registry value count limit")
+
+// hiveNames maps the supported hive names and abbreviations to the hive name reported in the inventory
+var hiveNames = map[string]string{
+	"HKEY_LOCAL_MACHINE":  "HKEY_LOCAL_MACHINE",
+	"HKLM":                "HKEY_LOCAL_MACHINE",
+	"HKEY_CURRENT_USER":   "HKEY_CURRENT_USER",
+	"HKCU":                "HKEY_CURRENT_USER",
+	"HKEY_USERS":          "HKEY_USERS",
+	"HKU":                 "HKEY_USERS",
+	"HKEY_CLASSES_ROOT":   "HKEY_CLASSES_ROOT",
+	"HKCR":                "HKEY_CLASSES_ROOT",
+	"HKEY_CURRENT_CONFIG": "HKEY_CURRENT_CONFIG",
+	"HKCC":                "HKEY_CURRENT_CONFIG",
+}
+
+var readRegistryKey = readKey
+
+// collectKeySetData reads the values of all the paths of a key set and redacts the configured values
+func collectKeySetData(context context.T, keySet appconfig.RegistryInventoryKeySet) (data []model.RegistryData, err error) {
+	log := context.Log()
+	for _, keyPath := range keySet.Paths {
+		var hive, subKey string
+		if hive, subKey, err = splitKeyPath(keyPath); err != nil {
+			return nil, err
+		}
+
+		var values []model.RegistryData
+		values, err = readRegistryKey(log, hive, subKey, keySet.Recursive, keySet.ValueNames, MaxValueCountLimit-len(data))
+		data = append(data, values...)
+		if err == ValueCountLimitExceeded {
+			return nil, err
+		} else if err != nil {
+			log.Warnf("Skipping registry key %v: %v", keyPath, err)
+			err = nil
+		}
+	}
+
+	for i := range data {
+		if isRedacted(data[i].ValueName, keySet.RedactedValueNames) {
+			data[i].Value = RedactedValue
+		}
+	}
+	log.Debugf("Collected %d registry values for key set %v", len(data), keySet.TypeName)
+	return
+}
+
+// splitKeyPath splits a registry key path into the hive name and the path of the key in the hive
+func splitKeyPath(keyPath string) (hive string, subKey string, err error) {
+	keyPath = strings.TrimPrefix(strings.TrimSpace(keyPath), "Registry::")
+	keyPath = strings.Trim(strings.Replace(keyPath, "/", `\`, -1), `\`)
+	parts := strings.SplitN(keyPath, `\`, 2)
+
+	var found bool
+	if hive, found = hiveNames[strings.ToUpper(strings.TrimSuffix(parts[0], ":"))]; !found {
+		return "", "", fmt.Errorf("registry key path %v does not start with a supported hive", keyPath)
+	}
+	if len(parts) > 1 {
+		subKey = parts[1]
+	}
+	return
+}
+
+// isRedacted returns true if the value name matches one of the redacted value name patterns, the match is case insensitive
+func isRedacted(valueName string, patterns []string) bool {
+	valueName = strings.ToLower(valueName)
+	for _, pattern := range patterns {
+		if matched, err := path.Match(strings.ToLower(pattern), valueName); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// joinKeyPath returns the full path of a registry key as reported in the inventory
+func joinKeyPath(hive, subKey string) string {
+	if subKey == "" {
+		return hive
+	}
+	return hive + `\` + subKey
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package registrykeyset
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitKeyPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		hive   string
+		subKey string
+	}{
+		{`HKEY_LOCAL_MACHINE\SOFTWARE\Amazon`, "HKEY_LOCAL_MACHINE", `SOFTWARE\Amazon`},
+		{`HKLM:\SOFTWARE\Amazon\`, "HKEY_LOCAL_MACHINE", `SOFTWARE\Amazon`},
+		{`Registry::HKEY_USERS\.DEFAULT`, "HKEY_USERS", `.DEFAULT`},
+		{`hkcu/Software/Policies`, "HKEY_CURRENT_USER", `Software\Policies`},
+		{`HKCR`, "HKEY_CLASSES_ROOT", ``},
+	}
+
+	for _, test := range tests {
+		hive, subKey, err := splitKeyPath(test.path)
+		assert.Nil(t, err, test.path)
+		assert.Equal(t, test.hive, hive, test.path)
+		assert.Equal(t, test.subKey, subKey, test.path)
+	}
+
+	_, _, err := splitKeyPath(`SOFTWARE\Amazon`)
+	assert.Error(t, err)
+}
+
+func TestIsRedacted(t *testing.T) {
+	patterns := []string{"Password", "*Token*"}
+	assert.True(t, isRedacted("password", patterns))
+	assert.True(t, isRedacted("RegistrationToken", patterns))
+	assert.False(t, isRedacted("Version", patterns))
+	assert.False(t, isRedacted("Password", nil))
+}
+
+func TestCollectKeySetData(t *testing.T) {
+	readPaths := []string{}
+	readRegistryKey = func(log log.T, hive, subKey string, recursive bool, valueNames []string, limit int) ([]model.RegistryData, error) {
+		readPaths = append(readPaths, joinKeyPath(hive, subKey))
+		assert.True(t, recursive)
+		assert.Equal(t, MaxValueCountLimit, limit)
+		if subKey == "Missing" {
+			return nil, errors.New("The system cannot find the file specified.")
+		}
+		return []model.RegistryData{
+			{KeyPath: joinKeyPath(hive, subKey), ValueName: "Version", ValueType: "REG_SZ", Value: "1.0"},
+			{KeyPath: joinKeyPath(hive, subKey), ValueName: "ApiKey", ValueType: "REG_SZ", Value: "secret"},
+		}, nil
+	}
+	defer func() { readRegistryKey = readKey }()
+
+	keySet := appconfig.RegistryInventoryKeySet{
+		TypeName:           "Agents",
+		Paths:              []string{`HKLM\Missing`, `HKLM\SOFTWARE\Agent`},
+		Recursive:          true,
+		RedactedValueNames: []string{"*key"},
+	}
+	data, err := collectKeySetData(contextmocks.NewMockDefault(), keySet)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`HKEY_LOCAL_MACHINE\Missing`, `HKEY_LOCAL_MACHINE\SOFTWARE\Agent`}, readPaths)
+	assert.Equal(t, []model.RegistryData{
+		{KeyPath: `HKEY_LOCAL_MACHINE\SOFTWARE\Agent`, ValueName: "Version", ValueType: "REG_SZ", Value: "1.0"},
+		{KeyPath: `HKEY_LOCAL_MACHINE\SOFTWARE\Agent`, ValueName: "ApiKey", ValueType: "REG_SZ", Value: RedactedValue},
+	}, data)
+}
+
+func TestCollectKeySetDataLimitExceeded(t *testing.T) {
+	readRegistryKey = func(log log.T, hive, subKey string, recursive bool, valueNames []string, limit int) ([]model.RegistryData, error) {
+		return nil, ValueCountLimitExceeded
+	}
+	defer func() { readRegistryKey = readKey }()
+
+	keySet := appconfig.RegistryInventoryKeySet{TypeName: "Agents", Paths: []string{`HKLM\SOFTWARE`}}
+	_, err := collectKeySetData(contextmocks.NewMockDefault(), keySet)
+	assert.Equal(t, ValueCountLimitExceeded, err)
+
+	keySet.Paths = []string{`SOFTWARE`}
+	_, err = collectKeySetData(contextmocks.NewMockDefault(), keySet)
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package registrykeyset
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"golang.org/x/sys/windows/registry"
+)
+
+// maxBinaryValueLength is the length above which binary values are not reported
+const maxBinaryValueLength = 2048
+
+var rootKeys = map[string]registry.Key{
+	"HKEY_LOCAL_MACHINE":  registry.LOCAL_MACHINE,
+	"HKEY_CURRENT_USER":   registry.CURRENT_USER,
+	"HKEY_USERS":          registry.USERS,
+	"HKEY_CLASSES_ROOT":   registry.CLASSES_ROOT,
+	"HKEY_CURRENT_CONFIG": registry.CURRENT_CONFIG,
+}
+
+// readKey reads the values of a registry key, and of its sub keys when recursive is set
+func readKey(log log.T, hive, subKey string, recursive bool, valueNames []string, limit int) (data []model.RegistryData, err error) {
+	key, err := registry.OpenKey(rootKeys[hive], subKey, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+
+	keyPath := joinKeyPath(hive, subKey)
+	names := valueNames
+	if len(names) == 0 {
+		if names, err = key.ReadValueNames(-1); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range names {
+		value, valueType, valueErr := readValue(key, name)
+		if valueErr == registry.ErrNotExist {
+			continue
+		} else if valueErr != nil {
+			log.Debugf("Failed to read registry value %v of %v: %v", name, keyPath, valueErr)
+			continue
+		}
+		if len(data) >= limit {
+			return data, ValueCountLimitExceeded
+		}
+		data = append(data, model.RegistryData{
+			KeyPath:   keyPath,
+			ValueName: name,
+			ValueType: valueType,
+			Value:     value,
+		})
+	}
+
+	if !recursive {
+		return data, nil
+	}
+
+	subKeyNames, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		return data, err
+	}
+	for _, subKeyName := range subKeyNames {
+		subKeyData, subKeyErr := readKey(log, hive, strings.TrimPrefix(subKey+`\`+subKeyName, `\`), true, valueNames, limit-len(data))
+		data = append(data, subKeyData...)
+		if subKeyErr == ValueCountLimitExceeded {
+			return data, subKeyErr
+		} else if subKeyErr != nil {
+			log.Debugf("Failed to read registry key %v\\%v: %v", keyPath, subKeyName, subKeyErr)
+		}
+	}
+	return data, nil
+}
+
+// readValue returns the data of a registry value formatted as string and the name of its type
+func readValue(key registry.Key, name string) (value string, valueType string, err error) {
+	var valtype uint32
+	if _, valtype, err = key.GetValue(name, nil); err != nil {
+		return
+	}
+
+	switch valtype {
+	case registry.SZ, registry.EXPAND_SZ:
+		value, _, err = key.GetStringValue(name)
+	case registry.DWORD, registry.QWORD:
+		var integer uint64
+		integer, _, err = key.GetIntegerValue(name)
+		value = strconv.FormatUint(integer, 10)
+	case registry.MULTI_SZ:
+		var values []string
+		values, _, err = key.GetStringsValue(name)
+		value = strings.Join(values, ",")
+	case registry.BINARY:
+		var binary []byte
+		binary, _, err = key.GetBinaryValue(name)
+		if len(binary) > maxBinaryValueLength {
+			value = "BinaryValue"
+		} else {
+			value = fmt.Sprintf("%X", binary)
+		}
+	}
+	return value, getTypeName(valtype), err
+}
+
+// getTypeName returns the name of a registry value type
+func getTypeName(valtype uint32) string {
+	switch valtype {
+	case registry.NONE:
+		return "None"
+	case registry.SZ:
+		return "REG_SZ"
+	case registry.EXPAND_SZ:
+		return "REG_EXPAND_SZ"
+	case registry.BINARY:
+		return "REG_BINARY"
+	case registry.DWORD:
+		return "REG_DWORD"
+	case registry.MULTI_SZ:
+		return "REG_MULTI_SZ"
+	case registry.QWORD:
+		return "REG_QWORD"
+	default:
+		return "Unknown"
+	}
+}
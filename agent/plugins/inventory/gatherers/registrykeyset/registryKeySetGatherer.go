@@ -0,0 +1,109 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package registrykeyset collects the registry key sets configured on the instance and reports each set
+// as a custom inventory type.
+package registrykeyset
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/custom"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// GathererName captures name of registry key set gatherer
+	GathererName = "AWS:WindowsRegistryKeySets"
+	// SchemaVersionOfRegistryKeySetGatherer represents schema version of registry key set gatherer
+	SchemaVersionOfRegistryKeySetGatherer = "1.0"
+)
+
+// T represents registry key set gatherer
+type T struct{}
+
+// Gatherer returns new registry key set gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+var collectData = collectKeySetData
+
+// Name returns name of registry key set gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// Run executes registry key set gatherer and returns one custom inventory item per configured key set
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+	log := context.Log()
+	keySets := context.AppConfig().Ssm.RegistryInventoryKeySets
+	if len(keySets) == 0 {
+		log.Infof("No registry key sets are configured for inventory")
+		return
+	}
+	if len(keySets) > custom.CustomInventoryCountLimit {
+		return nil, fmt.Errorf("%v registry key sets are configured, the limit is %v", len(keySets), custom.CustomInventoryCountLimit)
+	}
+
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	captureTime := time.Now().UTC().Format(time.RFC3339)
+	setTypeName := make(map[string]bool)
+	for _, keySet := range keySets {
+		var typeName string
+		if typeName, err = formatTypeName(keySet.TypeName); err != nil {
+			return nil, err
+		}
+		if setTypeName[typeName] {
+			return nil, fmt.Errorf("registry key set type name %v is configured more than once", typeName)
+		}
+		setTypeName[typeName] = true
+
+		var data []model.RegistryData
+		if data, err = collectData(context, keySet); err != nil {
+			return nil, fmt.Errorf("failed to collect registry key set %v: %v", typeName, err)
+		}
+
+		items = append(items, model.Item{
+			Name:          typeName,
+			SchemaVersion: SchemaVersionOfRegistryKeySetGatherer,
+			Content:       data,
+			CaptureTime:   captureTime,
+		})
+	}
+	return
+}
+
+// RequestStop stops the execution of registry key set gatherer.
+func (t *T) RequestStop() error {
+	return nil
+}
+
+// formatTypeName returns the custom inventory type name of a key set
+func formatTypeName(typeName string) (string, error) {
+	typeName = strings.TrimSpace(typeName)
+	if typeName == "" || typeName == custom.CustomInventoryTypeNamePrefix {
+		return "", fmt.Errorf("registry key set has missed or empty TypeName")
+	}
+	if !strings.HasPrefix(typeName, custom.CustomInventoryTypeNamePrefix) {
+		typeName = custom.CustomInventoryTypeNamePrefix + typeName
+	}
+	if len(typeName) > custom.TypeNameLengthLimit {
+		return "", fmt.Errorf("registry key set TypeName (%v)'s length %v exceeded the limit: %v",
+			typeName, len(typeName), custom.TypeNameLengthLimit)
+	}
+	return typeName, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package registrykeyset
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testRegistry = []model.RegistryData{
+	{
+		ValueName: "Version",
+		ValueType: "REG_SZ",
+		KeyPath:   "HKEY_LOCAL_MACHINE\\SOFTWARE\\Amazon\\AmazonCloudWatchAgent",
+		Value:     "1.247350",
+	},
+}
+
+func testCollectKeySetData(context context.T, keySet appconfig.RegistryInventoryKeySet) ([]model.RegistryData, error) {
+	return testRegistry, nil
+}
+
+func contextWithKeySets(keySets ...appconfig.RegistryInventoryKeySet) *contextmocks.Mock {
+	config := appconfig.DefaultConfig()
+	config.Ssm.RegistryInventoryKeySets = keySets
+	return contextmocks.NewMockDefaultWithConfig(config)
+}
+
+func TestGatherer(t *testing.T) {
+	contextMock := contextWithKeySets(
+		appconfig.RegistryInventoryKeySet{TypeName: "InstalledAgents"},
+		appconfig.RegistryInventoryKeySet{TypeName: "Custom:PolicyValues"})
+	gatherer := Gatherer(contextMock)
+	collectData = testCollectKeySetData
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, "Custom:InstalledAgents", items[0].Name)
+	assert.Equal(t, "Custom:PolicyValues", items[1].Name)
+	assert.Equal(t, SchemaVersionOfRegistryKeySetGatherer, items[0].SchemaVersion)
+	assert.Equal(t, testRegistry, items[0].Content)
+}
+
+func TestGathererWithoutKeySets(t *testing.T) {
+	contextMock := contextWithKeySets()
+	items, err := Gatherer(contextMock).Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Empty(t, items)
+}
+
+func TestGathererInvalidKeySets(t *testing.T) {
+	collectData = testCollectKeySetData
+	tests := [][]appconfig.RegistryInventoryKeySet{
+		{{TypeName: ""}},
+		{{TypeName: "Custom:"}},
+		{{TypeName: "Agents"}, {TypeName: "Custom:Agents"}},
+		make([]appconfig.RegistryInventoryKeySet, 21),
+	}
+	tests[3][0].TypeName = "Agents"
+
+	for _, keySets := range tests {
+		contextMock := contextWithKeySets(keySets...)
+		items, err := Gatherer(contextMock).Run(contextMock, model.Config{})
+		assert.Error(t, err)
+		assert.Nil(t, items)
+	}
+}
@@ -17,17 +17,17 @@ package inventory
 import (
 	"encoding/json"
 	"fmt"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
-	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/collectionwindow"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/datauploader"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/application"
@@ -35,9 +35,13 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/billinginfo"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/custom"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/file"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/fileintegrity"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/instancedetailedinformation"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/localaccount"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/productcode"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/registry"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/registrykeyset"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/role"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/service"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/windowsUpdate"
@@ -70,12 +74,16 @@ type PluginInput struct {
 	AWSComponents               string
 	NetworkConfig               string
 	BillingInfo                 string
+	ProductCodes                string
 	Files                       string
+	FileIntegrity               string
 	WindowsRoles                string
 	Services                    string
 	WindowsRegistry             string
+	WindowsRegistryKeySets      string
 	WindowsUpdates              string
 	InstanceDetailedInformation string
+	LocalAccounts               string
 	CustomInventory             string
 	CustomInventoryDirectory    string
 }
@@ -505,8 +513,12 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 		service.GathererName:                     input.Services,
 		network.GathererName:                     input.NetworkConfig,
 		billinginfo.GathererName:                 input.BillingInfo,
+		productcode.GathererName:                 input.ProductCodes,
 		windowsUpdate.GathererName:               input.WindowsUpdates,
 		instancedetailedinformation.GathererName: input.InstanceDetailedInformation,
+		localaccount.GathererName:                input.LocalAccounts,
+		fileintegrity.GathererName:               input.FileIntegrity,
+		registrykeyset.GathererName:              input.WindowsRegistryKeySets,
 	}
 
 	predefinedGatherersWithFilters := map[string]string{
@@ -558,9 +570,20 @@ func (p *Plugin) RunGatherers(gatherers map[gatherers.T]model.Config) (items []m
 	var gItems []model.Item
 
 	log := p.context.Log()
+	collectionWindows := p.context.AppConfig().Ssm.InventoryCollectionWindows
 
 	for gatherer, config := range gatherers {
 		name := gatherer.Name()
+
+		var allowed bool
+		if allowed, err = collectionwindow.IsCollectionAllowed(log, collectionWindows, name, time.Now()); err != nil {
+			err = fmt.Errorf("Unable to evaluate the inventory collection windows of %v. Error - %v", name, err.Error())
+			break
+		} else if !allowed {
+			log.Infof("Skipping gatherer %v outside of its inventory collection windows", name)
+			continue
+		}
+
 		log.Infof("Invoking gatherer - %v", name)
 		start := time.Now()
 
@@ -639,32 +662,21 @@ func (p *Plugin) IsMulitpleAssociationPresent(currentAssociationID string, confi
 // IsInventoryBeingInvokedAsAssociation returns true if inventory plugin is invoked via ssm-associate or else it returns false.
 // It throws error if the detection itself fails
 func (p *Plugin) IsInventoryBeingInvokedAsAssociation(fileName string) (status bool, err error) {
-	var content string
 	var docState contracts.DocumentState
 	log := p.context.Log()
 
 	//since the document is still getting executed - it must be in Current folder
-	path := filepath.Join(appconfig.DefaultDataStorePath,
-		p.machineID,
-		appconfig.DefaultDocumentRootDirName,
-		appconfig.DefaultLocationOfState,
-		appconfig.DefaultLocationOfCurrent)
-
-	absPathOfDoc := filepath.Join(path, fileName)
-
-	//read file & then determine if document is of association type
-	if fileutil.Exists(absPathOfDoc) {
-		log.Debugf("Found the document that's executing inventory plugin - %v", absPathOfDoc)
-
-		//read file
-		if content, err = fileutil.ReadAllText(absPathOfDoc); err == nil {
-			if err = json.Unmarshal([]byte(content), &docState); err == nil {
-				status = docState.IsAssociation()
-			}
+	if docmanager.DocumentStateExists(log, p.context.AppConfig(), p.machineID, appconfig.DefaultLocationOfCurrent, fileName) {
+		log.Debugf("Found the document that's executing inventory plugin - %v", fileName)
+
+		//read the document state & then determine if document is of association type
+		if err = docmanager.ReadDocumentState(log, p.context.AppConfig(), p.machineID, appconfig.DefaultLocationOfCurrent, fileName, &docState); err == nil {
+			status = docState.IsAssociation()
 		}
 
 	} else {
-		err = fmt.Errorf("Inventory plugin could not locate the execution document which invoked it. The document is expected to be in the location - %v", absPathOfDoc)
+		err = fmt.Errorf("Inventory plugin could not locate the execution document which invoked it. The document is expected to be in the location - %v",
+			docmanager.DocumentStateDir(p.machineID, appconfig.DefaultLocationOfCurrent))
 	}
 
 	return
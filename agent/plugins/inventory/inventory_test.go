@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
@@ -229,6 +230,30 @@ func TestRunGatherers(t *testing.T) {
 	assert.NotNil(t, err, "%v should throw errors", errorProneGatherer)
 }
 
+func TestRunGatherersOutsideCollectionWindow(t *testing.T) {
+	blockedGathererName := "Blocked-1"
+	p, _ := MockInventoryPlugin([]string{blockedGathererName}, []string{blockedGathererName})
+	agentConfig := appconfig.DefaultConfig()
+	agentConfig.Ssm.InventoryCollectionWindows = []appconfig.InventoryCollectionWindow{
+		{Schedule: "cron(* * * * ? *)", DurationMinutes: 60, Type: appconfig.InventoryCollectionWindowDeny, Gatherers: []string{blockedGathererName}},
+	}
+	p.context = context.NewMockDefaultWithConfig(agentConfig)
+
+	blockedGatherer := gatherers2.NewMockDefault()
+	blockedGatherer.On("Name").Return(blockedGathererName)
+	testGathererConfig := map[gatherers.T]model.Config{blockedGatherer: {Collection: "Enabled"}}
+
+	items, err := p.RunGatherers(testGathererConfig)
+	assert.Nil(t, err)
+	assert.Empty(t, items)
+	blockedGatherer.AssertNotCalled(t, "Run", p.context, model.Config{Collection: "Enabled"})
+
+	agentConfig.Ssm.InventoryCollectionWindows[0].Schedule = "rate(1 hour)"
+	p.context = context.NewMockDefaultWithConfig(agentConfig)
+	_, err = p.RunGatherers(testGathererConfig)
+	assert.NotNil(t, err)
+}
+
 func TestVerifyInventoryDataSize(t *testing.T) {
 	var smallItem, largeItem model.Item
 	var items []model.Item
@@ -106,6 +106,41 @@ type FileData struct {
 	ProductLanguage  string
 }
 
+// FileIntegrityData captures all attributes present in Custom:FileIntegrity inventory type
+type FileIntegrityData struct {
+	Path             string
+	Status           string
+	Size             string
+	ModificationTime string
+	HashAlgorithm    string
+	Digest           string
+}
+
+// LocalUserData captures all attributes present in Custom:LocalUser inventory type
+type LocalUserData struct {
+	Name          string
+	Id            string
+	GroupId       string `json:",omitempty"`
+	HomeDirectory string `json:",omitempty"`
+	Shell         string `json:",omitempty"`
+	Description   string
+	Enabled       string `json:",omitempty"`
+	Administrator string
+}
+
+// LocalGroupData captures all attributes present in Custom:LocalGroup inventory type
+type LocalGroupData struct {
+	Name    string
+	Id      string
+	Members string
+}
+
+// SudoersEntryData captures all attributes present in Custom:SudoersEntry inventory type
+type SudoersEntryData struct {
+	Source string
+	Entry  string
+}
+
 type RoleData struct {
 	Name                      string
 	DisplayName               string
@@ -142,6 +177,18 @@ type BillingInfoData struct {
 	BillingProductId string
 }
 
+// ProductCodeData captures all attributes present in Custom:ProductCode inventory type
+type ProductCodeData struct {
+	MarketplaceProductCodes string
+	DevpayProductCodes      string
+	BillingProducts         string
+	LicenseIncluded         string
+	Tenancy                 string
+	HostId                  string `json:",omitempty"`
+	ImageId                 string
+	InstanceType            string
+}
+
 // NetworkData captures all attributes present in AWS:Network inventory type
 type NetworkData struct {
 	Name       string
@@ -23,6 +23,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	managerContracts "github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
 
@@ -35,8 +36,15 @@ type Plugin struct {
 // LongRunningPluginSettings represents startType configuration of long running plugin
 type LongRunningPluginSettings struct {
 	StartType string
+	// StopType is Kill or Drain, it tells how the plugin is stopped when disabled
+	StopType string
+	// DrainTimeoutSeconds is the time given to the plugin to drain before it is stopped
+	DrainTimeoutSeconds int
 }
 
+// startTypeDisabled is the start type of the documents stopping the long running plugin
+const startTypeDisabled = "Disabled"
+
 // InvokerInput represents input to lrpm invoker
 type InvokerInput struct {
 	ID         string      `json:"id"`
@@ -83,6 +91,13 @@ func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelF
 		output.MarkAsCancelled()
 	} else {
 		property := p.prepareForStart(config, cancelFlag, output)
+		if setting.StartType == startTypeDisabled {
+			// the properties are not used to stop the plugin, the output carries the stop options instead
+			property, _ = jsonutil.Marshal(managerContracts.StopOptions{
+				StopType:            setting.StopType,
+				DrainTimeoutSeconds: setting.DrainTimeoutSeconds,
+			})
+		}
 		output.SetOutput(property)
 		output.AppendInfo(setting.StartType)
 	}
@@ -0,0 +1,333 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package macpackage implements the aws:installMacPackage plugin, which installs .pkg and .dmg payloads on macOS.
+package macpackage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	PackageTypePkg = "pkg"
+
+	PackageTypeDmg = "dmg"
+
+	// payloadTypeApp is the type of the application bundles found in disk images, they are copied to the
+	// applications directory
+	payloadTypeApp = "app"
+
+	defaultInstallTarget = "/"
+
+	mountDirectoryName = "mount"
+)
+
+var (
+	installerCommand = "/usr/sbin/installer"
+	hdiutilCommand   = "/usr/bin/hdiutil"
+	pkgutilCommand   = "/usr/sbin/pkgutil"
+	spctlCommand     = "/usr/sbin/spctl"
+	dittoCommand     = "/usr/bin/ditto"
+
+	applicationsDirectory = "/Applications"
+
+	// notarizedSources are the spctl assessment sources of the payloads that are installed by default
+	notarizedSources = []string{"Notarized Developer ID", "Apple System", "Apple", "Mac App Store"}
+)
+
+// Plugin is the type for the installMacPackage plugin.
+type Plugin struct {
+	context context.T
+	// CommandExecuter is an object that can execute commands.
+	CommandExecuter executers.T
+}
+
+// MacPackagePluginInput represents the input of the installMacPackage plugin.
+type MacPackagePluginInput struct {
+	contracts.PluginInput
+	ID             string
+	Source         string
+	SourceHash     string
+	SourceHashType string
+	// ArchitectureSources are the payloads by architecture (amd64, arm64), the one matching the instance is installed
+	ArchitectureSources map[string]pluginutil.ArchitectureSource
+	// PackageType is pkg or dmg, it defaults to the extension of the source
+	PackageType string
+	// PackageId is the identifier of the package receipt, the installation is skipped when the receipt is found
+	// with the expected Version, or any version if Version is empty
+	PackageId string
+	Version   string
+	// Target is the volume the pkg is installed on
+	Target string
+	// AllowUnnotarized installs payloads that are not notarized by Apple
+	AllowUnnotarized bool
+	TimeoutSeconds   interface{}
+}
+
+// queryCommand runs the commands whose output is parsed by the plugin
+var queryCommand = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// NewPlugin returns a new instance of the plugin.
+func NewPlugin(context context.T) (*Plugin, error) {
+	return &Plugin{
+		context:         context,
+		CommandExecuter: executers.ShellCommandExecuter{},
+	}, nil
+}
+
+// Name returns the name of the plugin
+func Name() string {
+	return appconfig.PluginNameAwsInstallMacPackage
+}
+
+// Execute installs the payload of the plugin input unless its receipt shows it is already installed
+func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := p.context.Log()
+	log.Infof("%v started with configuration %v", Name(), config)
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+		return
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+		return
+	}
+
+	var pluginInput MacPackagePluginInput
+	if err := jsonutil.Remarshal(config.Properties, &pluginInput); err != nil {
+		output.MarkAsFailed(fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err))
+		return
+	}
+	executionTimeout := pluginutil.GetExecutionTimeout(log, p.context.AppConfig(), Name(), pluginInput.TimeoutSeconds)
+
+	restartRequired, err := p.install(log, config.OrchestrationDirectory, pluginInput, executionTimeout, cancelFlag, output)
+	if err != nil {
+		output.MarkAsFailed(fmt.Errorf("failed to install %v: %v", pluginInput.Source, err))
+		return
+	}
+	if restartRequired {
+		output.AppendInfo("The package requires a restart to complete the installation")
+		output.MarkAsSuccessWithReboot()
+		return
+	}
+	output.MarkAsSucceeded()
+}
+
+// install downloads the payload, checks its notarization and installs it, restartRequired is true if the
+// installed package requires a restart
+func (p *Plugin) install(log log.T, orchestrationDirectory string, pluginInput MacPackagePluginInput, executionTimeout int, cancelFlag task.CancelFlag, output iohandler.IOHandler) (restartRequired bool, err error) {
+	source, err := pluginutil.SelectArchitectureSource(log, pluginutil.ArchitectureSource{Source: pluginInput.Source, SourceHash: pluginInput.SourceHash, SourceHashType: pluginInput.SourceHashType}, pluginInput.ArchitectureSources)
+	if err != nil {
+		return false, err
+	}
+	pluginInput.Source, pluginInput.SourceHash, pluginInput.SourceHashType = source.Source, source.SourceHash, source.SourceHashType
+
+	packageType, err := getPackageType(pluginInput)
+	if err != nil {
+		return false, err
+	}
+	if pluginInput.PackageId != "" {
+		if version, installed := installedVersion(log, pluginInput.PackageId); installed && (pluginInput.Version == "" || version == pluginInput.Version) {
+			output.AppendInfof("%v %v is already installed", pluginInput.PackageId, version)
+			return false, nil
+		}
+	}
+
+	orchestrationDir := fileutil.BuildPath(orchestrationDirectory, pluginInput.ID)
+	if err = fileutil.MakeDirs(orchestrationDir); err != nil {
+		return false, fmt.Errorf("failed to create orchestration directory %v: %v", orchestrationDir, err)
+	}
+
+	downloadOutput, err := pluginutil.DownloadFileFromSource(p.context, pluginInput.Source, pluginInput.SourceHash, pluginInput.SourceHashType)
+	if err != nil || !downloadOutput.IsHashMatched || downloadOutput.LocalFilePath == "" {
+		return false, fmt.Errorf("failed to download file reliably %v", pluginInput.Source)
+	}
+	payload := downloadOutput.LocalFilePath
+	if exists, err := fileutil.LocalFileExist(pluginInput.Source); err == nil && !exists {
+		defer func() { pluginutil.CleanupFile(log, downloadOutput.LocalFilePath) }()
+
+		// downloaded files are named after the hash of their url, installer only opens files with the pkg extension
+		if packageType == PackageTypePkg {
+			if err = os.Rename(payload, payload+".pkg"); err != nil {
+				return false, fmt.Errorf("failed to prepare package %v: %v", payload, err)
+			}
+			downloadOutput.LocalFilePath = payload + ".pkg"
+			payload = downloadOutput.LocalFilePath
+		}
+	}
+
+	payloadType := packageType
+	if packageType == PackageTypeDmg {
+		mountPoint := filepath.Join(orchestrationDir, mountDirectoryName)
+		if err = p.runCommand(executionTimeout, cancelFlag, output, hdiutilCommand, "attach", payload, "-nobrowse", "-readonly", "-noautoopen", "-mountpoint", mountPoint); err != nil {
+			return false, err
+		}
+		defer detach(log, mountPoint)
+		if payload, payloadType, err = findDmgPayload(mountPoint); err != nil {
+			return false, err
+		}
+	}
+
+	if pluginInput.AllowUnnotarized {
+		output.AppendInfof("Skipping the notarization check of %v", filepath.Base(payload))
+	} else if err = checkNotarization(payloadType, payload); err != nil {
+		return false, err
+	}
+
+	if payloadType == payloadTypeApp {
+		destination := filepath.Join(applicationsDirectory, filepath.Base(payload))
+		output.AppendInfof("Copying %v to %v", filepath.Base(payload), destination)
+		return false, p.runCommand(executionTimeout, cancelFlag, output, dittoCommand, payload, destination)
+	}
+
+	target := pluginInput.Target
+	if target == "" {
+		target = defaultInstallTarget
+	}
+	restartRequired = requiresRestart(log, payload)
+	if err = p.runCommand(executionTimeout, cancelFlag, output, installerCommand, "-pkg", payload, "-target", target, "-verboseR"); err != nil {
+		return false, err
+	}
+	if pluginInput.PackageId != "" {
+		if _, installed := installedVersion(log, pluginInput.PackageId); !installed {
+			return false, fmt.Errorf("no receipt found for %v after the installation", pluginInput.PackageId)
+		}
+	}
+	return restartRequired, nil
+}
+
+// runCommand runs a command writing its output to the plugin output
+func (p *Plugin) runCommand(executionTimeout int, cancelFlag task.CancelFlag, output iohandler.IOHandler, commandName string, commandArguments ...string) error {
+	exitCode, err := p.CommandExecuter.NewExecute(p.context, "", output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments, make(map[string]string))
+	if err != nil {
+		return err
+	} else if exitCode != 0 {
+		return fmt.Errorf("%v exited with code %d", filepath.Base(commandName), exitCode)
+	}
+	return nil
+}
+
+// getPackageType returns the type of package of the plugin input
+func getPackageType(pluginInput MacPackagePluginInput) (string, error) {
+	packageType := strings.ToLower(pluginInput.PackageType)
+	if packageType == "" {
+		packageType = strings.ToLower(strings.TrimPrefix(filepath.Ext(pluginInput.Source), "."))
+	}
+	switch packageType {
+	case PackageTypePkg, PackageTypeDmg:
+		return packageType, nil
+	default:
+		return "", fmt.Errorf("PackageType is set to unsupported value: %v", packageType)
+	}
+}
+
+// installedVersion returns the version of the package receipt, installed is false if there is no receipt
+func installedVersion(log log.T, packageId string) (version string, installed bool) {
+	output, err := queryCommand(pkgutilCommand, "--pkg-info", packageId)
+	if err != nil {
+		log.Debugf("No receipt found for %v: %v", packageId, strings.TrimSpace(string(output)))
+		return "", false
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if value := strings.TrimPrefix(line, "version:"); value != line {
+			version = strings.TrimSpace(value)
+		}
+	}
+	return version, true
+}
+
+// findDmgPayload returns the first package of the disk image, or its first application bundle if it does not
+// contain any package
+func findDmgPayload(mountPoint string) (payload string, payloadType string, err error) {
+	entries, err := ioutil.ReadDir(mountPoint)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read disk image %v: %v", mountPoint, err)
+	}
+	for _, extension := range []string{".pkg", ".mpkg", ".app"} {
+		for _, entry := range entries {
+			if strings.EqualFold(filepath.Ext(entry.Name()), extension) {
+				payloadType = PackageTypePkg
+				if extension == ".app" {
+					payloadType = payloadTypeApp
+				}
+				return filepath.Join(mountPoint, entry.Name()), payloadType, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("disk image does not contain any package or application")
+}
+
+// checkNotarization returns an error unless Gatekeeper accepts the payload as notarized or signed by Apple
+func checkNotarization(payloadType string, payload string) error {
+	assessmentType := "install"
+	if payloadType == payloadTypeApp {
+		assessmentType = "execute"
+	}
+	output, err := queryCommand(spctlCommand, "--assess", "--type", assessmentType, "-vv", payload)
+	if err != nil {
+		return fmt.Errorf("%v was rejected by Gatekeeper: %v", filepath.Base(payload), strings.TrimSpace(string(output)))
+	}
+	if !isNotarized(string(output)) {
+		return fmt.Errorf("%v is not notarized: %v", filepath.Base(payload), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// isNotarized returns true if the spctl assessment reports one of the notarized sources
+func isNotarized(assessment string) bool {
+	for _, line := range strings.Split(assessment, "\n") {
+		if source := strings.TrimPrefix(strings.TrimSpace(line), "source="); source != strings.TrimSpace(line) {
+			for _, notarizedSource := range notarizedSources {
+				if source == notarizedSource {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// requiresRestart returns true if the package requires a restart once installed
+func requiresRestart(log log.T, payload string) bool {
+	output, err := queryCommand(installerCommand, "-query", "RestartAction", "-pkg", payload)
+	if err != nil {
+		log.Debugf("Unable to query the restart action of %v: %v", payload, err)
+		return false
+	}
+	return strings.TrimSpace(string(output)) == "RequireRestart"
+}
+
+// detach unmounts the disk image
+func detach(log log.T, mountPoint string) {
+	if output, err := queryCommand(hdiutilCommand, "detach", mountPoint, "-force"); err != nil {
+		log.Warnf("Failed to detach disk image %v: %v", mountPoint, strings.TrimSpace(string(output)))
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package macpackage
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	executermocks "github.com/aws/amazon-ssm-agent/agent/mocks/executers"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const notarizedAssessment = "/Volumes/App/App.pkg: accepted\nsource=Notarized Developer ID\norigin=Developer ID Installer: Example Corp (ABCDE12345)\n"
+
+// fakeQueries replaces queryCommand with canned outputs by command line and records the command lines run
+type fakeQueries struct {
+	outputs map[string]string
+	errors  map[string]bool
+	run     []string
+}
+
+func (f *fakeQueries) query(name string, args ...string) ([]byte, error) {
+	commandLine := strings.Join(append([]string{filepath.Base(name)}, args...), " ")
+	f.run = append(f.run, commandLine)
+	for prefix, output := range f.outputs {
+		if strings.HasPrefix(commandLine, prefix) {
+			if f.errors[prefix] {
+				return []byte(output), errors.New("exit status 1")
+			}
+			return []byte(output), nil
+		}
+	}
+	return nil, errors.New("exit status 1")
+}
+
+func setupFakeQueries(t *testing.T, outputs map[string]string, failing ...string) *fakeQueries {
+	fake := &fakeQueries{outputs: outputs, errors: make(map[string]bool)}
+	for _, prefix := range failing {
+		fake.errors[prefix] = true
+	}
+	original := queryCommand
+	queryCommand = fake.query
+	t.Cleanup(func() { queryCommand = original })
+	return fake
+}
+
+func newTestPlugin(executer *executermocks.MockCommandExecuter) *Plugin {
+	return &Plugin{context: contextmocks.NewMockDefault(), CommandExecuter: executer}
+}
+
+func writeSource(t *testing.T, name string) string {
+	source := filepath.Join(t.TempDir(), name)
+	assert.Nil(t, ioutil.WriteFile(source, []byte("payload"), 0600))
+	return source
+}
+
+func TestGetPackageType(t *testing.T) {
+	packageType, err := getPackageType(MacPackagePluginInput{Source: "https://example.com/App.DMG"})
+	assert.Nil(t, err)
+	assert.Equal(t, PackageTypeDmg, packageType)
+
+	packageType, err = getPackageType(MacPackagePluginInput{Source: "https://example.com/download?id=1", PackageType: "Pkg"})
+	assert.Nil(t, err)
+	assert.Equal(t, PackageTypePkg, packageType)
+
+	_, err = getPackageType(MacPackagePluginInput{Source: "https://example.com/App.zip"})
+	assert.NotNil(t, err)
+}
+
+func TestIsNotarized(t *testing.T) {
+	assert.True(t, isNotarized(notarizedAssessment))
+	assert.True(t, isNotarized("/Applications/Safari.app: accepted\nsource=Apple System\n"))
+	assert.False(t, isNotarized("/tmp/App.pkg: accepted\nsource=Developer ID\n"))
+	assert.False(t, isNotarized("/tmp/App.pkg: rejected\n"))
+}
+
+func TestFindDmgPayload(t *testing.T) {
+	mountPoint := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(mountPoint, "App.app"), 0700))
+	payload, payloadType, err := findDmgPayload(mountPoint)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(mountPoint, "App.app"), payload)
+	assert.Equal(t, payloadTypeApp, payloadType)
+
+	// packages take precedence over application bundles
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(mountPoint, "Install App.pkg"), []byte("pkg"), 0600))
+	payload, payloadType, err = findDmgPayload(mountPoint)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(mountPoint, "Install App.pkg"), payload)
+	assert.Equal(t, PackageTypePkg, payloadType)
+
+	_, _, err = findDmgPayload(t.TempDir())
+	assert.NotNil(t, err)
+}
+
+func TestInstallSkipsInstalledPackage(t *testing.T) {
+	setupFakeQueries(t, map[string]string{"pkgutil --pkg-info com.example.app": "package-id: com.example.app\nversion: 2.1.0\nvolume: /\n"})
+	executer := new(executermocks.MockCommandExecuter)
+	p := newTestPlugin(executer)
+	output := iohandler.NewDefaultIOHandler(p.context, contracts.IOConfiguration{})
+
+	restartRequired, err := p.install(log.NewMockLog(), t.TempDir(), MacPackagePluginInput{ID: "app", Source: "https://example.com/App.pkg", PackageId: "com.example.app", Version: "2.1.0"}, 3600, nil, output)
+
+	assert.Nil(t, err)
+	assert.False(t, restartRequired)
+	assert.Contains(t, output.GetStdout(), "com.example.app 2.1.0 is already installed")
+	executer.AssertNotCalled(t, "NewExecute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestInstallPkg(t *testing.T) {
+	source := writeSource(t, "App.pkg")
+	fake := setupFakeQueries(t, map[string]string{
+		"spctl --assess --type install":      notarizedAssessment,
+		"installer -query RestartAction":     "RequireRestart\n",
+		"pkgutil --pkg-info com.example.app": "package-id: com.example.app\nversion: 2.1.0\n",
+	})
+	executer := new(executermocks.MockCommandExecuter)
+	executer.On("NewExecute", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything, 3600, installerCommand, []string{"-pkg", source, "-target", "/", "-verboseR"}, mock.Anything).Return(0, nil)
+	p := newTestPlugin(executer)
+	output := iohandler.NewDefaultIOHandler(p.context, contracts.IOConfiguration{})
+
+	restartRequired, err := p.install(log.NewMockLog(), t.TempDir(), MacPackagePluginInput{ID: "app", Source: source, Version: "2.1.0"}, 3600, nil, output)
+
+	assert.Nil(t, err)
+	assert.True(t, restartRequired)
+	executer.AssertExpectations(t)
+	assert.Equal(t, []string{
+		"spctl --assess --type install -vv " + source,
+		"installer -query RestartAction -pkg " + source,
+	}, fake.run)
+	assert.FileExists(t, source, "local sources are not deleted")
+}
+
+func TestInstallRequiresArchitectureSource(t *testing.T) {
+	p := newTestPlugin(new(executermocks.MockCommandExecuter))
+	output := iohandler.NewDefaultIOHandler(p.context, contracts.IOConfiguration{})
+	architectureSources := map[string]pluginutil.ArchitectureSource{"unknown": {Source: "https://example.com/App.pkg"}}
+
+	_, err := p.install(log.NewMockLog(), t.TempDir(), MacPackagePluginInput{ID: "app", ArchitectureSources: architectureSources}, 3600, nil, output)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no source is defined for architecture")
+}
+
+func TestInstallRejectsUnnotarizedPkg(t *testing.T) {
+	source := writeSource(t, "App.pkg")
+	setupFakeQueries(t, map[string]string{"spctl --assess --type install": source + ": rejected\nsource=no usable signature\n"}, "spctl --assess --type install")
+	executer := new(executermocks.MockCommandExecuter)
+	p := newTestPlugin(executer)
+	output := iohandler.NewDefaultIOHandler(p.context, contracts.IOConfiguration{})
+
+	_, err := p.install(log.NewMockLog(), t.TempDir(), MacPackagePluginInput{ID: "app", Source: source}, 3600, nil, output)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "rejected by Gatekeeper")
+	executer.AssertNotCalled(t, "NewExecute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestInstallDmgApplication(t *testing.T) {
+	applicationsDirectory = t.TempDir()
+	defer func() { applicationsDirectory = "/Applications" }()
+	source := writeSource(t, "App.dmg")
+	orchestrationDirectory := t.TempDir()
+	mountPoint := filepath.Join(orchestrationDirectory, "app", mountDirectoryName)
+	fake := setupFakeQueries(t, map[string]string{
+		"spctl --assess --type execute": notarizedAssessment,
+		"hdiutil detach":                "",
+	})
+	executer := new(executermocks.MockCommandExecuter)
+	executer.On("NewExecute", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything, 3600, hdiutilCommand, []string{"attach", source, "-nobrowse", "-readonly", "-noautoopen", "-mountpoint", mountPoint}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			os.MkdirAll(filepath.Join(mountPoint, "App.app"), 0700)
+		}).
+		Return(0, nil)
+	executer.On("NewExecute", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything, 3600, dittoCommand, []string{filepath.Join(mountPoint, "App.app"), filepath.Join(applicationsDirectory, "App.app")}, mock.Anything).Return(0, nil)
+	p := newTestPlugin(executer)
+	output := iohandler.NewDefaultIOHandler(p.context, contracts.IOConfiguration{})
+
+	restartRequired, err := p.install(log.NewMockLog(), orchestrationDirectory, MacPackagePluginInput{ID: "app", Source: source}, 3600, nil, output)
+
+	assert.Nil(t, err)
+	assert.False(t, restartRequired)
+	executer.AssertExpectations(t)
+	assert.Equal(t, "hdiutil detach "+mountPoint+" -force", fake.run[len(fake.run)-1])
+}
@@ -16,9 +16,12 @@ package pluginutil
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 
@@ -34,8 +37,14 @@ import (
 
 const (
 	defaultExecutionTimeoutInSeconds = 3600
-	maxExecutionTimeoutInSeconds     = 172800
-	minExecutionTimeoutInSeconds     = 5
+	maxExecutionTimeoutInSeconds     = appconfig.PluginTimeoutSecondsMax
+	minExecutionTimeoutInSeconds     = appconfig.PluginTimeoutSecondsMin
+
+	// EnvVarProgressFile is the environment variable holding the path of the file scripts report their progress to
+	EnvVarProgressFile = "AWS_SSM_PROGRESS_FILE"
+	// EnvVarToolbox is the environment variable holding the path of the ssm-cli running the helpers of the toolbox
+	EnvVarToolbox    = "SSM_TOOLBOX"
+	progressFileName = "progress"
 )
 
 // StringPrefix returns the beginning part of a string, truncated to the given limit.
@@ -104,6 +113,45 @@ func DownloadFileFromSource(context context.T, source string, sourceHash string,
 	return artifact.Download(context, downloadInput)
 }
 
+// ArchitectureSource is the artifact of a plugin input for one architecture
+type ArchitectureSource struct {
+	Source         string
+	SourceHash     string
+	SourceHashType string
+}
+
+// architectureAliases maps the architecture names used in documents to the go architectures
+var architectureAliases = map[string]string{
+	"x86_64":  "amd64",
+	"x64":     "amd64",
+	"aarch64": "arm64",
+	"i386":    "386",
+	"x86":     "386",
+}
+
+// runtimeArchitecture is the architecture the agent runs on
+var runtimeArchitecture = runtime.GOARCH
+
+// SelectArchitectureSource returns the source of architectureSources matching the architecture of the agent,
+// architectureSources are keyed by go architecture (amd64, arm64) or by their usual aliases (x86_64, aarch64).
+// defaultSource is returned when no architecture source matches, an error is returned if it is empty.
+func SelectArchitectureSource(log log.T, defaultSource ArchitectureSource, architectureSources map[string]ArchitectureSource) (ArchitectureSource, error) {
+	for architecture, source := range architectureSources {
+		architecture = strings.ToLower(architecture)
+		if alias, found := architectureAliases[architecture]; found {
+			architecture = alias
+		}
+		if architecture == runtimeArchitecture {
+			log.Debugf("Selected the %v source %v", runtimeArchitecture, source.Source)
+			return source, nil
+		}
+	}
+	if len(architectureSources) > 0 && defaultSource.Source == "" {
+		return defaultSource, fmt.Errorf("no source is defined for architecture %v", runtimeArchitecture)
+	}
+	return defaultSource, nil
+}
+
 // LoadParametersAsList returns properties as a list and appropriate PluginResult if error is encountered
 func LoadParametersAsList(log log.T, prop interface{}, res *contracts.PluginResult) (properties []interface{}) {
 
@@ -135,11 +183,26 @@ func LoadParametersAsMap(log log.T, prop interface{}, out iohandler.IOHandler) (
 
 // ValidateExecutionTimeout validates the supplied input interface and converts it into a valid int value.
 func ValidateExecutionTimeout(log log.T, input interface{}) int {
+	return validateExecutionTimeout(log, input, defaultExecutionTimeoutInSeconds)
+}
+
+// GetExecutionTimeout returns the execution timeout of the plugin in seconds. The timeout of the document wins when
+// it is valid, the timeout configured for the plugin in the agent configuration applies otherwise.
+func GetExecutionTimeout(log log.T, config appconfig.SsmagentConfig, pluginName string, input interface{}) int {
+	defaultTimeout := defaultExecutionTimeoutInSeconds
+	if timeout, found := config.Ssm.PluginTimeoutSeconds(pluginName); found {
+		defaultTimeout = timeout
+	}
+	return validateExecutionTimeout(log, input, defaultTimeout)
+}
+
+// validateExecutionTimeout converts the supplied input into a valid int value, the default is used when it is invalid
+func validateExecutionTimeout(log log.T, input interface{}, defaultTimeout int) int {
 	var num int
 
 	switch input.(type) {
 	case string:
-		num = extractIntFromString(log, input.(string))
+		num = extractIntFromString(log, input.(string), defaultTimeout)
 	case int:
 		num = input.(int)
 	case float64:
@@ -147,12 +210,12 @@ func ValidateExecutionTimeout(log log.T, input interface{}) int {
 		num = int(f)
 		log.Infof("Unexpected 'TimeoutSeconds' float value %v received. Applying 'TimeoutSeconds' as %v", f, num)
 	default:
-		log.Infof("Unexpected 'TimeoutSeconds' value %v received. Setting 'TimeoutSeconds' to default value %v", input, defaultExecutionTimeoutInSeconds)
+		log.Infof("Unexpected 'TimeoutSeconds' value %v received. Setting 'TimeoutSeconds' to default value %v", input, defaultTimeout)
 	}
 
 	if num < minExecutionTimeoutInSeconds || num > maxExecutionTimeoutInSeconds {
-		log.Infof("'TimeoutSeconds' value should be between %v and %v. Setting 'TimeoutSeconds' to default value %v", minExecutionTimeoutInSeconds, maxExecutionTimeoutInSeconds, defaultExecutionTimeoutInSeconds)
-		num = defaultExecutionTimeoutInSeconds
+		log.Infof("'TimeoutSeconds' value should be between %v and %v. Setting 'TimeoutSeconds' to default value %v", minExecutionTimeoutInSeconds, maxExecutionTimeoutInSeconds, defaultTimeout)
+		num = defaultTimeout
 	}
 	return num
 }
@@ -171,7 +234,7 @@ func ParseRunCommand(input interface{}, output []string) []string {
 }
 
 // extractIntFromString extracts a valid int value from a string.
-func extractIntFromString(log log.T, input string) int {
+func extractIntFromString(log log.T, input string, defaultTimeout int) int {
 	var iNum int
 	var fNum float64
 	var err error
@@ -186,8 +249,8 @@ func extractIntFromString(log log.T, input string) int {
 		iNum = int(fNum)
 		log.Infof("Unexpected 'TimeoutSeconds' float value %v received. Applying 'TimeoutSeconds' as %v", fNum, iNum)
 	} else {
-		log.Errorf("Unexpected 'TimeoutSeconds' string value %v received. Setting 'TimeoutSeconds' to default value %v", input, defaultExecutionTimeoutInSeconds)
-		iNum = defaultExecutionTimeoutInSeconds
+		log.Errorf("Unexpected 'TimeoutSeconds' string value %v received. Setting 'TimeoutSeconds' to default value %v", input, defaultTimeout)
+		iNum = defaultTimeout
 	}
 	return iNum
 }
@@ -261,6 +324,12 @@ func CleanupJSONField(field string) string {
 	return res
 }
 
+// ProgressFilePath returns the path of the file the scripts of a plugin report their progress to.
+// Each line written to the file is a percentage optionally followed by a space and the current phase, e.g. "40 Installing".
+func ProgressFilePath(orchestrationDirectory string) string {
+	return filepath.Join(orchestrationDirectory, progressFileName)
+}
+
 // Deletes file if it exists
 func CleanupFile(log log.T, file string) {
 	if _, err := os.Stat(file); err == nil || os.IsExist(err) {
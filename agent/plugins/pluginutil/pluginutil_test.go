@@ -19,6 +19,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -110,6 +111,23 @@ func TestValidateExecutionTimeout(t *testing.T) {
 	assert.Equal(t, defaultExecutionTimeoutInSeconds, num)
 }
 
+func TestGetExecutionTimeout(t *testing.T) {
+	logger := log.NewMockLog()
+	config := appconfig.DefaultConfig()
+	config.Ssm.PluginTimeouts = []appconfig.PluginTimeout{{Plugin: appconfig.PluginNameAwsRunShellScript, TimeoutSeconds: 7200}}
+
+	// the timeout of the document wins
+	assert.Equal(t, 600, GetExecutionTimeout(logger, config, appconfig.PluginNameAwsRunShellScript, "600"))
+
+	// the configured timeout applies when the document does not set a valid one
+	assert.Equal(t, 7200, GetExecutionTimeout(logger, config, appconfig.PluginNameAwsRunShellScript, nil))
+	assert.Equal(t, 7200, GetExecutionTimeout(logger, config, appconfig.PluginNameAwsRunShellScript, 3))
+	assert.Equal(t, 7200, GetExecutionTimeout(logger, config, appconfig.PluginNameAwsRunShellScript, "test"))
+
+	// the plugins without configured timeout keep the default timeout
+	assert.Equal(t, defaultExecutionTimeoutInSeconds, GetExecutionTimeout(logger, config, appconfig.PluginNameAwsRunPowerShellScript, nil))
+}
+
 func TestGetProxySetting(t *testing.T) {
 	var input []string
 	var outUrl, outNoProxy string
@@ -201,3 +219,29 @@ func TestAddSingleQuotesAroundValue(t *testing.T) {
 		assert.Equal(t, output, result)
 	}
 }
+
+func TestSelectArchitectureSource(t *testing.T) {
+	defer func(architecture string) { runtimeArchitecture = architecture }(runtimeArchitecture)
+	logger := log.NewMockLog()
+	defaultSource := ArchitectureSource{Source: "https://example.com/app-x86_64.msi", SourceHash: "defaulthash"}
+	architectureSources := map[string]ArchitectureSource{
+		"AARCH64": {Source: "https://example.com/app-arm64.msi", SourceHash: "armhash", SourceHashType: "sha256"},
+	}
+
+	runtimeArchitecture = "arm64"
+	source, err := SelectArchitectureSource(logger, defaultSource, architectureSources)
+	assert.Nil(t, err)
+	assert.Equal(t, architectureSources["AARCH64"], source)
+
+	runtimeArchitecture = "amd64"
+	source, err = SelectArchitectureSource(logger, defaultSource, architectureSources)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultSource, source)
+
+	source, err = SelectArchitectureSource(logger, defaultSource, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultSource, source)
+
+	_, err = SelectArchitectureSource(logger, ArchitectureSource{}, architectureSources)
+	assert.NotNil(t, err)
+}
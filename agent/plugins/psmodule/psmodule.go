@@ -58,6 +58,8 @@ type PSModulePluginInput struct {
 	Source           string
 	SourceHash       string
 	SourceHashType   string
+	// ArchitectureSources are the module sources by architecture, the one matching the instance replaces Source
+	ArchitectureSources map[string]pluginutil.ArchitectureSource
 }
 
 // NewPlugin returns a new instance of the plugin.
@@ -129,6 +131,13 @@ func (p *Plugin) runCommands(pluginID string, pluginInput PSModulePluginInput, o
 		return
 	}
 
+	source, err := pluginutil.SelectArchitectureSource(log, pluginutil.ArchitectureSource{Source: pluginInput.Source, SourceHash: pluginInput.SourceHash, SourceHashType: pluginInput.SourceHashType}, pluginInput.ArchitectureSources)
+	if err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+	pluginInput.Source, pluginInput.SourceHash, pluginInput.SourceHashType = source.Source, source.SourceHash, source.SourceHashType
+
 	if pluginInput.Source != "" {
 		//change hash type to be default sha256
 		pluginInput.SourceHashType = Sha256SourceHashType
@@ -157,7 +166,7 @@ func (p *Plugin) runCommands(pluginID string, pluginInput PSModulePluginInput, o
 	}
 
 	// Set execution time
-	executionTimeout := pluginutil.ValidateExecutionTimeout(log, pluginInput.TimeoutSeconds)
+	executionTimeout := pluginutil.GetExecutionTimeout(log, p.context.AppConfig(), Name(), pluginInput.TimeoutSeconds)
 
 	// Construct Command Name and Arguments
 	commandName := pluginutil.GetShellCommand()
@@ -22,6 +22,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -143,3 +144,14 @@ func (m *NoOpDocumentMgr) RemoveDocumentState(fileName, locationFolder string) {
 	// No-op
 	m.context.Log().Debugf("NoOpDocumentMgr.RemoveDocumentState(%s, %s)", fileName, locationFolder)
 }
+
+func (m *NoOpDocumentMgr) RecordExecution(record docmanager.ExecutionRecord) {
+	// No-op
+	m.context.Log().Debugf("NoOpDocumentMgr.RecordExecution(%s)", record.ExecutionID)
+}
+
+func (m *NoOpDocumentMgr) ClaimCorruptionReports(documentType contracts.DocumentType) []docmanager.CorruptionReport {
+	// No-op
+	m.context.Log().Debugf("NoOpDocumentMgr.ClaimCorruptionReports(%s)", documentType)
+	return nil
+}
@@ -19,6 +19,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/commandlocale"
+	"github.com/aws/amazon-ssm-agent/agent/commandsandbox"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/executers"
@@ -26,8 +29,10 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/processpriority"
 	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/agent/toolbox"
 	"github.com/aws/amazon-ssm-agent/common/identity/identity"
 	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
 )
@@ -38,6 +43,8 @@ const (
 
 var getRemoteProvider = identity.GetRemoteProvider
 
+var toolboxCliPath = toolbox.CliPath
+
 // Plugin is the type for the runscript plugin.
 type Plugin struct {
 	Context context.T
@@ -60,6 +67,18 @@ type RunScriptPluginInput struct {
 	ID               string
 	WorkingDirectory string
 	TimeoutSeconds   interface{}
+	// ProcessPriority overrides the priority of the script process configured in the agent configuration
+	ProcessPriority *appconfig.ProcessPriority
+	// Cancellation overrides how the script process is stopped on cancel or timeout
+	Cancellation *appconfig.ScriptCancellation
+	// Locale overrides the locale of the script configured in the agent configuration
+	Locale *appconfig.CommandLocale
+	// SandboxProfile selects another sandbox profile of the agent configuration for the script
+	SandboxProfile *string
+	// ScriptSource executes a script downloaded from S3 or a document attachment instead of RunCommand
+	ScriptSource *ScriptSource
+	// Arguments are the positional arguments passed to the script
+	Arguments []string
 }
 
 // Execute runs multiple sets of commands and returns their outputs.
@@ -122,6 +141,79 @@ func (p *Plugin) setCommandIdEnvironment(pluginInput RunScriptPluginInput, runCo
 	}
 }
 
+// setProgressFileEnvironment gives the scripts the path of the file they can report their progress to
+func (p *Plugin) setProgressFileEnvironment(pluginInput RunScriptPluginInput, orchestrationDirectory string) {
+	if orchestrationDirectory != "" {
+		pluginInput.Environment[pluginutil.EnvVarProgressFile] = pluginutil.ProgressFilePath(orchestrationDirectory)
+	}
+}
+
+// setToolboxEnvironment gives the scripts the path of the ssm-cli running the helpers of the toolbox
+func (p *Plugin) setToolboxEnvironment(pluginInput RunScriptPluginInput) {
+	if path, found := toolboxCliPath(); found {
+		pluginInput.Environment[pluginutil.EnvVarToolbox] = path
+	}
+}
+
+// executionContext returns the context the script runs with, the process settings of the plugin input override
+// the settings of the agent configuration
+func (p *Plugin) executionContext(pluginInput RunScriptPluginInput) (context.T, error) {
+	if pluginInput.ProcessPriority == nil && pluginInput.Cancellation == nil && pluginInput.Locale == nil && pluginInput.SandboxProfile == nil {
+		return p.Context, nil
+	}
+	appConfig := p.Context.AppConfig()
+	if pluginInput.ProcessPriority != nil {
+		if err := processpriority.Validate(*pluginInput.ProcessPriority); err != nil {
+			return nil, fmt.Errorf("invalid processPriority: %v", err)
+		}
+		appConfig.Ssm.ProcessPriority = *pluginInput.ProcessPriority
+	}
+	if pluginInput.Cancellation != nil {
+		cancellation := *pluginInput.Cancellation
+		if cancellation.Signal == "" {
+			cancellation.Signal = appconfig.CancellationSignalKill
+		}
+		if cancellation.GracePeriodSeconds == 0 {
+			cancellation.GracePeriodSeconds = appconfig.DefaultCancellationGracePeriodSeconds
+		}
+		if err := executers.ValidateCancellation(cancellation); err != nil {
+			return nil, fmt.Errorf("invalid cancellation: %v", err)
+		}
+		appConfig.Ssm.ScriptCancellation = cancellation
+	}
+	if pluginInput.Locale != nil {
+		if err := commandlocale.Validate(*pluginInput.Locale); err != nil {
+			return nil, fmt.Errorf("invalid locale: %v", err)
+		}
+		appConfig.Ssm.CommandLocale = *pluginInput.Locale
+	}
+	if pluginInput.SandboxProfile != nil {
+		// a document can pick another configured profile but cannot opt out of the sandbox of the agent configuration
+		profileName := strings.TrimSpace(*pluginInput.SandboxProfile)
+		if profileName == "" && appConfig.Ssm.CommandSandbox.DefaultProfile != "" {
+			return nil, fmt.Errorf("the sandbox of the agent configuration cannot be disabled")
+		}
+		appConfig.Ssm.CommandSandbox.DefaultProfile = profileName
+		if _, err := commandsandbox.SelectedProfile(appConfig.Ssm.CommandSandbox); err != nil {
+			return nil, fmt.Errorf("invalid sandbox profile: %v", err)
+		}
+	}
+	return context.WithAppConfig(p.Context, appConfig), nil
+}
+
+// scriptCommands returns the commands written to the script file, PowerShell scripts start by setting the culture
+// of the command locale
+func (p *Plugin) scriptCommands(executionContext context.T, pluginInput RunScriptPluginInput) []string {
+	if p.ShellCommand != appconfig.PowerShellPluginCommandName {
+		return pluginInput.RunCommand
+	}
+	preamble := commandlocale.PowerShellPreamble(executionContext.AppConfig().Ssm.CommandLocale)
+	if len(preamble) == 0 {
+		return pluginInput.RunCommand
+	}
+	return append(preamble, pluginInput.RunCommand...)
+}
+
 // runCommandsRawInput executes one set of commands and returns their output.
 // The input is in the default json unmarshal format (e.g. map[string]interface{}).
 func (p *Plugin) runCommandsRawInput(pluginID string, rawPluginInput interface{}, orchestrationDirectory string, defaultWorkingDirectory string, cancelFlag task.CancelFlag, output iohandler.IOHandler, runCommandID string) {
@@ -138,6 +230,8 @@ func (p *Plugin) runCommandsRawInput(pluginID string, rawPluginInput interface{}
 	}
 
 	p.setCommandIdEnvironment(pluginInput, runCommandID)
+	p.setProgressFileEnvironment(pluginInput, orchestrationDirectory)
+	p.setToolboxEnvironment(pluginInput)
 	p.setShareCredsEnvironment(pluginInput)
 
 	p.runCommands(pluginID, pluginInput, orchestrationDirectory, defaultWorkingDirectory, cancelFlag, output)
@@ -174,21 +268,36 @@ func (p *Plugin) runCommands(pluginID string, pluginInput RunScriptPluginInput,
 	scriptPath := filepath.Join(orchestrationDir, p.ScriptName)
 	log.Debugf("Writing commands %v to file %v", pluginInput, scriptPath)
 
+	// Set script process priority, cancellation and locale
+	executionContext, err := p.executionContext(pluginInput)
+	if err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+
 	// Create script file
-	if err = pluginutil.CreateScriptFile(log, scriptPath, pluginInput.RunCommand, p.ByteOrderMark); err != nil {
+	if pluginInput.ScriptSource != nil {
+		if err = fetchScript(executionContext, *pluginInput.ScriptSource, orchestrationDir, scriptPath); err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+	} else if err = pluginutil.CreateScriptFile(log, scriptPath, p.scriptCommands(executionContext, pluginInput), p.ByteOrderMark); err != nil {
 		output.MarkAsFailed(fmt.Errorf("failed to create script file. %v", err))
 		return
 	}
 
 	// Set execution time
-	executionTimeout := pluginutil.ValidateExecutionTimeout(log, pluginInput.TimeoutSeconds)
+	executionTimeout := pluginutil.GetExecutionTimeout(log, p.Context.AppConfig(), p.Name, pluginInput.TimeoutSeconds)
 
 	// Construct Command Name and Arguments
 	commandName := p.ShellCommand
-	commandArguments := append(p.ShellArguments, scriptPath)
+	commandArguments := make([]string, 0, len(p.ShellArguments)+1+len(pluginInput.Arguments))
+	commandArguments = append(commandArguments, p.ShellArguments...)
+	commandArguments = append(commandArguments, scriptPath)
+	commandArguments = append(commandArguments, pluginInput.Arguments...)
 
 	// Execute Command
-	exitCode, err := p.CommandExecuter.NewExecute(p.Context, workingDir, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments, pluginInput.Environment)
+	exitCode, err := p.CommandExecuter.NewExecute(executionContext, workingDir, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments, pluginInput.Environment)
 
 	// Set output status
 	output.SetExitCode(exitCode)
@@ -15,6 +15,8 @@ package runscript
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
@@ -24,15 +26,23 @@ import (
 	"github.com/aws/amazon-ssm-agent/common/identity/credentialproviders"
 	credentialprovidermocks "github.com/aws/amazon-ssm-agent/common/identity/credentialproviders/mocks"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	agentContext "github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
 	multiwritermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter/mock"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/agent/toolbox"
 	"github.com/aws/amazon-ssm-agent/common/identity"
 	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
 	"github.com/aws/amazon-ssm-agent/common/runtimeconfig/mocks"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/twinj/uuid"
@@ -257,6 +267,143 @@ func TestSetCommandIdEnvironment(t *testing.T) {
 	assert.Len(t, pluginInput.Environment, 1)
 }
 
+func TestSetProgressFileEnvironment(t *testing.T) {
+	p := &Plugin{}
+	pluginInput := RunScriptPluginInput{
+		Environment: map[string]string{},
+	}
+
+	p.setProgressFileEnvironment(pluginInput, "")
+	assert.Len(t, pluginInput.Environment, 0)
+
+	p.setProgressFileEnvironment(pluginInput, orchestrationDirectory)
+	assert.Equal(t, pluginutil.ProgressFilePath(orchestrationDirectory), pluginInput.Environment[pluginutil.EnvVarProgressFile])
+}
+
+// TestRunScriptsWithProcessPriority tests that the process priority of the plugin input is used to run the script
+func TestSetToolboxEnvironment(t *testing.T) {
+	p := &Plugin{}
+	pluginInput := RunScriptPluginInput{
+		Environment: map[string]string{},
+	}
+	defer func() { toolboxCliPath = toolbox.CliPath }()
+
+	toolboxCliPath = func() (string, bool) { return "", false }
+	p.setToolboxEnvironment(pluginInput)
+	assert.Len(t, pluginInput.Environment, 0)
+
+	toolboxCliPath = func() (string, bool) { return "/usr/bin/ssm-cli", true }
+	p.setToolboxEnvironment(pluginInput)
+	assert.Equal(t, "/usr/bin/ssm-cli", pluginInput.Environment[pluginutil.EnvVarToolbox])
+}
+
+func TestRunScriptsWithProcessPriority(t *testing.T) {
+	testCase := generateTestCaseOk("0", envVars)
+	priority := appconfig.ProcessPriority{Nice: 10, IOPriorityClass: appconfig.IOPriorityClassIdle}
+	testCase.Input.ProcessPriority = &priority
+
+	runScriptTester := func(p *Plugin, mockCancelFlag *taskmocks.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+		hasPriority := mock.MatchedBy(func(executionContext agentContext.T) bool {
+			return executionContext.AppConfig().Ssm.ProcessPriority == priority
+		})
+		mockExecuter.On("NewExecute", hasPriority, testCase.Input.WorkingDirectory, testCase.Output.StdoutWriter, testCase.Output.StderrWriter, mockCancelFlag, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+			testCase.Output.ExitCode, testCase.ExecuterError)
+		setIOHandlerExpectations(mockIOHandler, testCase)
+
+		p.runCommands(pluginID, testCase.Input, orchestrationDirectory, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+	}
+
+	testExecution(t, runScriptTester)
+}
+
+// TestRunScriptsWithInvalidProcessPriority tests that the script is not run with an invalid process priority
+func TestRunScriptsWithInvalidProcessPriority(t *testing.T) {
+	testCase := generateTestCaseOk("0", envVars)
+	testCase.Input.ProcessPriority = &appconfig.ProcessPriority{Nice: 40}
+
+	runScriptTester := func(p *Plugin, mockCancelFlag *taskmocks.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+		mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+
+		p.runCommands(pluginID, testCase.Input, orchestrationDirectory, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+		mockExecuter.AssertNotCalled(t, "NewExecute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	}
+
+	testExecution(t, runScriptTester)
+}
+
+// TestExecutionContextWithCancellation tests that the cancellation of the plugin input is completed with the defaults
+func TestExecutionContextWithCancellation(t *testing.T) {
+	p := &Plugin{Context: context.NewMockDefault()}
+
+	executionContext, err := p.executionContext(RunScriptPluginInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, p.Context, executionContext)
+
+	executionContext, err = p.executionContext(RunScriptPluginInput{Cancellation: &appconfig.ScriptCancellation{Signal: appconfig.CancellationSignalTerminate}})
+	assert.NoError(t, err)
+	assert.Equal(t, appconfig.ScriptCancellation{Signal: appconfig.CancellationSignalTerminate, GracePeriodSeconds: appconfig.DefaultCancellationGracePeriodSeconds},
+		executionContext.AppConfig().Ssm.ScriptCancellation)
+
+	_, err = p.executionContext(RunScriptPluginInput{Cancellation: &appconfig.ScriptCancellation{Signal: "SIGQUIT"}})
+	assert.Error(t, err)
+}
+
+// TestExecutionContextWithLocale tests that the locale of the plugin input overrides the agent configuration
+func TestExecutionContextWithLocale(t *testing.T) {
+	p := &Plugin{Context: context.NewMockDefault()}
+
+	executionContext, err := p.executionContext(RunScriptPluginInput{Locale: &appconfig.CommandLocale{Locale: "C.UTF-8", Culture: "en-US"}})
+	assert.NoError(t, err)
+	assert.Equal(t, appconfig.CommandLocale{Locale: "C.UTF-8", Culture: "en-US"}, executionContext.AppConfig().Ssm.CommandLocale)
+
+	_, err = p.executionContext(RunScriptPluginInput{Locale: &appconfig.CommandLocale{Culture: "en-US'; exit 1; '"}})
+	assert.Error(t, err)
+}
+
+// TestExecutionContextWithSandboxProfile tests that the plugin input selects a configured sandbox profile only
+func TestExecutionContextWithSandboxProfile(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Ssm.CommandSandbox = appconfig.CommandSandbox{
+		DefaultProfile: "default",
+		Profiles: []appconfig.CommandSandboxProfile{
+			{Name: "default", Namespaces: []string{appconfig.CommandSandboxNamespaceMount}},
+			{Name: "offline", Namespaces: []string{appconfig.CommandSandboxNamespaceNetwork}},
+		},
+	}
+	p := &Plugin{Context: context.NewMockDefaultWithConfig(config)}
+
+	profile := " offline "
+	executionContext, err := p.executionContext(RunScriptPluginInput{SandboxProfile: &profile})
+	assert.NoError(t, err)
+	assert.Equal(t, "offline", executionContext.AppConfig().Ssm.CommandSandbox.DefaultProfile)
+
+	profile = "missing"
+	_, err = p.executionContext(RunScriptPluginInput{SandboxProfile: &profile})
+	assert.Error(t, err)
+
+	profile = ""
+	_, err = p.executionContext(RunScriptPluginInput{SandboxProfile: &profile})
+	assert.Error(t, err)
+}
+
+// TestScriptCommandsWithCulture tests that only the PowerShell scripts start by setting the culture
+func TestScriptCommandsWithCulture(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Ssm.CommandLocale = appconfig.CommandLocale{Culture: "en-US"}
+	executionContext := context.NewMockDefaultWithConfig(config)
+	pluginInput := RunScriptPluginInput{RunCommand: []string{"Get-Date"}}
+
+	shellPlugin := &Plugin{ShellCommand: "sh"}
+	assert.Equal(t, []string{"Get-Date"}, shellPlugin.scriptCommands(executionContext, pluginInput))
+
+	powerShellPlugin := &Plugin{ShellCommand: appconfig.PowerShellPluginCommandName}
+	commands := powerShellPlugin.scriptCommands(executionContext, pluginInput)
+	assert.Contains(t, commands[0], "en-US")
+	assert.Equal(t, "Get-Date", commands[len(commands)-1])
+
+	assert.Equal(t, []string{"Get-Date"}, powerShellPlugin.scriptCommands(context.NewMockDefault(), pluginInput))
+}
+
 // TestBucketsInDifferentRegions tests runScripts when S3Buckets are present in IAD and PDX region.
 func TestBucketsInDifferentRegions(t *testing.T) {
 	for _, testCase := range TestCases {
@@ -411,7 +558,11 @@ func testExecuteWithEnvironment(t *testing.T, testCase TestCase) {
 
 		// set expectations
 		setCancelFlagExpectations(mockCancelFlag, 1)
-		mockExecuter.On("NewExecute", mock.Anything, testCase.Input.WorkingDirectory, testCase.Output.StdoutWriter, testCase.Output.StderrWriter, mockCancelFlag, mock.Anything, mock.Anything, mock.Anything, envVars).Return(testCase.Output.ExitCode, testCase.ExecuterError)
+		expectedEnvVars := map[string]string{pluginutil.EnvVarProgressFile: pluginutil.ProgressFilePath(orchestrationDirectory)}
+		for key, value := range envVars {
+			expectedEnvVars[key] = value
+		}
+		mockExecuter.On("NewExecute", mock.Anything, testCase.Input.WorkingDirectory, testCase.Output.StdoutWriter, testCase.Output.StderrWriter, mockCancelFlag, mock.Anything, mock.Anything, mock.Anything, expectedEnvVars).Return(testCase.Output.ExitCode, testCase.ExecuterError)
 		setIOHandlerExpectations(mockIOHandler, testCase)
 
 		// prepare plugin input
@@ -485,3 +636,102 @@ func setCancelFlagExpectations(mockCancelFlag *taskmocks.MockCancelFlag, times i
 	mockCancelFlag.On("Canceled").Return(false).Times(times)
 	mockCancelFlag.On("ShutDown").Return(false).Times(times)
 }
+
+// stubScriptDownload replaces the artifact download by writing the given content to the destination directory
+func stubScriptDownload(t *testing.T, content string, hashMatched bool) *artifact.DownloadInput {
+	var received artifact.DownloadInput
+	downloadArtifact = func(context agentContext.T, input artifact.DownloadInput) (artifact.DownloadOutput, error) {
+		received = input
+		path := filepath.Join(input.DestinationDirectory, "downloaded")
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			return artifact.DownloadOutput{}, err
+		}
+		return artifact.DownloadOutput{LocalFilePath: path, IsUpdated: true, IsHashMatched: hashMatched}, nil
+	}
+	t.Cleanup(func() { downloadArtifact = artifact.Download })
+	return &received
+}
+
+// TestRunScriptsFromS3WithArguments tests that the script downloaded from S3 is run with the positional arguments
+func TestRunScriptsFromS3WithArguments(t *testing.T) {
+	testCase := generateTestCaseOk("0", envVars)
+	testCase.Input.ScriptSource = &ScriptSource{S3Url: "https://s3.amazonaws.com/bucket/script.sh", Sha256: "abc"}
+	testCase.Input.Arguments = []string{"first", "second arg"}
+	orchestrationDir := t.TempDir()
+	received := stubScriptDownload(t, "echo $1", true)
+	scriptPath := filepath.Join(fileutil.BuildPath(orchestrationDir, testCase.Input.ID), "_script.sh")
+
+	runScriptTester := func(p *Plugin, mockCancelFlag *taskmocks.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+		mockExecuter.On("NewExecute", mock.Anything, testCase.Input.WorkingDirectory, testCase.Output.StdoutWriter, testCase.Output.StderrWriter, mockCancelFlag, mock.Anything, "sh", []string{"-c", scriptPath, "first", "second arg"}, mock.Anything).Return(
+			testCase.Output.ExitCode, testCase.ExecuterError)
+		setIOHandlerExpectations(mockIOHandler, testCase)
+
+		p.runCommands(pluginID, testCase.Input, orchestrationDir, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+		assert.Equal(t, []string{"-c"}, p.ShellArguments)
+	}
+
+	testExecution(t, runScriptTester)
+	assert.Equal(t, "https://s3.amazonaws.com/bucket/script.sh", received.SourceURL)
+	assert.Equal(t, map[string]string{"sha256": "abc"}, received.SourceChecksums)
+	content, err := ioutil.ReadFile(scriptPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo $1", string(content))
+}
+
+// TestRunScriptsFromS3WithMismatchedChecksum tests that the script is not run when its checksum does not match
+func TestRunScriptsFromS3WithMismatchedChecksum(t *testing.T) {
+	testCase := generateTestCaseOk("0", envVars)
+	testCase.Input.ScriptSource = &ScriptSource{S3Url: "https://s3.amazonaws.com/bucket/script.sh", Sha256: "abc"}
+	orchestrationDir := t.TempDir()
+	stubScriptDownload(t, "echo $1", false)
+
+	runScriptTester := func(p *Plugin, mockCancelFlag *taskmocks.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+		mockIOHandler.On("MarkAsFailed", mock.MatchedBy(func(err error) bool {
+			category, _ := contracts.CategorizeError(err)
+			return category == contracts.ErrorCategoryValidation
+		})).Return()
+
+		p.runCommands(pluginID, testCase.Input, orchestrationDir, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+		mockExecuter.AssertNotCalled(t, "NewExecute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	}
+
+	testExecution(t, runScriptTester)
+}
+
+func TestScriptLocation(t *testing.T) {
+	attachments := []*ssm.AttachmentContent{
+		{Name: aws.String("other.sh"), Url: aws.String("https://attachments/other"), HashType: aws.String("Sha256"), Hash: aws.String("111")},
+		{Name: aws.String("script.sh"), Url: aws.String("https://attachments/script"), HashType: aws.String("Sha256"), Hash: aws.String("222")},
+	}
+	getDocumentAttachments = func(context agentContext.T, documentName string, documentVersion string) ([]*ssm.AttachmentContent, error) {
+		if documentName != "MyDocument" {
+			return nil, fmt.Errorf("document not found")
+		}
+		return attachments, nil
+	}
+	defer func() { getDocumentAttachments = defaultGetDocumentAttachments }()
+
+	ctx := context.NewMockDefault()
+	sourceURL, checksum, err := scriptLocation(ctx, ScriptSource{Attachment: "script.sh", DocumentName: "MyDocument"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://attachments/script", sourceURL)
+	assert.Equal(t, "222", checksum)
+
+	sourceURL, checksum, err = scriptLocation(ctx, ScriptSource{S3Url: "s3://bucket/script.sh", Sha256: "333"})
+	assert.NoError(t, err)
+	assert.Equal(t, "s3://bucket/script.sh", sourceURL)
+	assert.Equal(t, "333", checksum)
+
+	for _, source := range []ScriptSource{
+		{},
+		{S3Url: "s3://bucket/script.sh"},
+		{S3Url: "s3://bucket/script.sh", Attachment: "script.sh", Sha256: "333"},
+		{Attachment: "script.sh"},
+		{Attachment: "missing.sh", DocumentName: "MyDocument"},
+		{Attachment: "script.sh", DocumentName: "MyDocument", Sha256: "999"},
+		{Attachment: "script.sh", DocumentName: "OtherDocument"},
+	} {
+		_, _, err = scriptLocation(ctx, source)
+		assert.Error(t, err, "%+v", source)
+	}
+}
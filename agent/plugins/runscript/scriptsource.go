@@ -0,0 +1,130 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runscript
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	ssmsvc "github.com/aws/amazon-ssm-agent/agent/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ScriptSource locates a script executed in place of the commands of the document, large scripts do not fit
+// in the inline commands
+type ScriptSource struct {
+	// S3Url is the url of the script in S3
+	S3Url string
+	// Attachment is the name of the attachment of the document DocumentName holding the script
+	Attachment      string
+	DocumentName    string
+	DocumentVersion string
+	// Sha256 is the checksum of the script, it is required for the scripts in S3
+	Sha256 string
+}
+
+var downloadArtifact = artifact.Download
+
+var getDocumentAttachments = defaultGetDocumentAttachments
+
+// defaultGetDocumentAttachments returns the attachments of a document with their presigned urls
+func defaultGetDocumentAttachments(context context.T, documentName string, documentVersion string) ([]*ssm.AttachmentContent, error) {
+	response, err := ssmsvc.NewService(context).GetDocument(context.Log(), documentName, documentVersion)
+	if err != nil {
+		return nil, err
+	}
+	return response.AttachmentsContent, nil
+}
+
+// fetchScript downloads the script of the source to the script path after verifying its checksum
+func fetchScript(context context.T, source ScriptSource, orchestrationDir string, scriptPath string) error {
+	log := context.Log()
+	sourceURL, checksum, err := scriptLocation(context, source)
+	if err != nil {
+		return contracts.NewCategorizedError(contracts.ErrorCategoryValidation, contracts.ErrorCodeInvalidInput, err)
+	}
+
+	log.Infof("Downloading script from %v", sourceURL)
+	output, err := downloadArtifact(context, artifact.DownloadInput{
+		SourceURL:            sourceURL,
+		DestinationDirectory: orchestrationDir,
+		SourceChecksums:      map[string]string{"sha256": checksum},
+		// large scripts are downloaded in ranges resumed on failure and kept in the cache for the next executions
+		Resumable:       true,
+		CacheByChecksum: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to download script: %v", err)
+	}
+	if !output.IsHashMatched {
+		return contracts.NewCategorizedError(contracts.ErrorCategoryValidation, contracts.ErrorCodeInvalidInput,
+			fmt.Errorf("the checksum of the script from %v does not match", sourceURL))
+	}
+	// the script keeps the name of the inline scripts, PowerShell only runs files with the ps1 extension
+	if err = os.Rename(output.LocalFilePath, scriptPath); err != nil {
+		return fmt.Errorf("failed to move script to %v: %v", scriptPath, err)
+	}
+	return nil
+}
+
+// scriptLocation returns the url of the script of the source and its expected checksum
+func scriptLocation(context context.T, source ScriptSource) (sourceURL string, checksum string, err error) {
+	s3Url := strings.TrimSpace(source.S3Url)
+	attachment := strings.TrimSpace(source.Attachment)
+	checksum = strings.TrimSpace(source.Sha256)
+	if (s3Url == "") == (attachment == "") {
+		return "", "", fmt.Errorf("scriptSource requires either an S3Url or an Attachment")
+	}
+	if s3Url != "" {
+		if checksum == "" {
+			return "", "", fmt.Errorf("scriptSource requires the Sha256 of the script in S3")
+		}
+		return s3Url, checksum, nil
+	}
+
+	if strings.TrimSpace(source.DocumentName) == "" {
+		return "", "", fmt.Errorf("scriptSource requires the DocumentName of the attachment %v", attachment)
+	}
+	attachments, err := getDocumentAttachments(context, source.DocumentName, source.DocumentVersion)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get the attachments of document %v: %v", source.DocumentName, err)
+	}
+	for _, content := range attachments {
+		if content == nil || content.Name == nil || *content.Name != attachment || content.Url == nil {
+			continue
+		}
+		if content.HashType != nil && !strings.EqualFold(*content.HashType, ssm.AttachmentHashTypeSha256) {
+			return "", "", fmt.Errorf("attachment %v has an unsupported hash type %v", attachment, *content.HashType)
+		}
+		attachmentHash := ""
+		if content.Hash != nil {
+			attachmentHash = *content.Hash
+		}
+		if checksum != "" && attachmentHash != "" && !strings.EqualFold(checksum, attachmentHash) {
+			return "", "", fmt.Errorf("the Sha256 of the scriptSource does not match the hash of attachment %v", attachment)
+		}
+		if checksum == "" {
+			checksum = attachmentHash
+		}
+		if checksum == "" {
+			return "", "", fmt.Errorf("attachment %v has no checksum", attachment)
+		}
+		return *content.Url, checksum, nil
+	}
+	return "", "", fmt.Errorf("document %v has no attachment %v", source.DocumentName, attachment)
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stagepatches
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// repositoryFilePrefix prefixes the repository files written for a patch operation
+const repositoryFilePrefix = "ssm-patch-"
+
+var validRepositoryName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// RepositoryConfig declares a repository added for the duration of a patch operation
+type RepositoryConfig struct {
+	// Name identifies the repository
+	Name string
+	// BaseUrl is the url of the repository
+	BaseUrl string
+	// GpgKey is the ASCII armored public key the repository packages are signed with, signatures are not
+	// checked when empty
+	GpgKey string
+	// Distribution and Components locate the packages of apt repositories, e.g. jammy and main
+	Distribution string
+	Components   []string
+}
+
+// repositoryFormat describes how a package manager is configured with additional repositories
+type repositoryFormat struct {
+	// directory is where the package manager reads the repository files from
+	directory     string
+	fileExtension string
+	render        func(repository RepositoryConfig, keyPath string) string
+	// mirrorOverride returns the command arguments pointing an existing repository to a mirror, nil when
+	// the package manager does not support mirror overrides
+	mirrorOverride func(repositoryID string, url string) []string
+}
+
+// applyRepositories writes the repository files and gpg keys of the patch operation and returns the arguments
+// overriding the repository mirrors, the returned rollback removes everything that was written
+func applyRepositories(log log.T, format *repositoryFormat, repositories []RepositoryConfig, mirrorOverrides map[string]string) (args []string, rollback func(), err error) {
+	var written []string
+	rollback = func() {
+		for i := len(written) - 1; i >= 0; i-- {
+			if err := os.RemoveAll(written[i]); err != nil {
+				log.Warnf("Failed to remove patch repository configuration %v: %v", written[i], err)
+			}
+		}
+	}
+	if len(repositories) == 0 && len(mirrorOverrides) == 0 {
+		return
+	}
+	if format == nil {
+		return nil, rollback, fmt.Errorf("custom repositories are not supported by this package manager")
+	}
+
+	defer func() {
+		if err != nil {
+			rollback()
+		}
+	}()
+
+	for repositoryID, url := range mirrorOverrides {
+		if format.mirrorOverride == nil {
+			return nil, rollback, fmt.Errorf("mirror overrides are not supported by this package manager")
+		}
+		if !validRepositoryName.MatchString(repositoryID) || url == "" {
+			return nil, rollback, fmt.Errorf("invalid mirror override %v=%v", repositoryID, url)
+		}
+		args = append(args, format.mirrorOverride(repositoryID, url)...)
+	}
+
+	var keyDirectory string
+	for _, repository := range repositories {
+		if !validRepositoryName.MatchString(repository.Name) || repository.BaseUrl == "" {
+			return nil, rollback, fmt.Errorf("repository %v must have a name made of letters, digits, '.', '_' or '-' and a BaseUrl", repository.Name)
+		}
+
+		var keyPath string
+		if repository.GpgKey != "" {
+			if keyDirectory == "" {
+				if keyDirectory, err = ioutil.TempDir("", repositoryFilePrefix+"keys"); err != nil {
+					return nil, rollback, err
+				}
+				written = append(written, keyDirectory)
+			}
+			keyPath = filepath.Join(keyDirectory, repository.Name+".asc")
+			if err = ioutil.WriteFile(keyPath, []byte(repository.GpgKey), appconfig.ReadWriteAccess); err != nil {
+				return nil, rollback, err
+			}
+		}
+
+		// existing files are never overwritten since they would not be restored by the rollback
+		repositoryPath := filepath.Join(format.directory, repositoryFilePrefix+repository.Name+format.fileExtension)
+		var file *os.File
+		if file, err = os.OpenFile(repositoryPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, appconfig.ReadWriteAccess); err != nil {
+			return nil, rollback, fmt.Errorf("failed to create repository file %v: %v", repositoryPath, err)
+		}
+		written = append(written, repositoryPath)
+		_, err = file.WriteString(format.render(repository, keyPath))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, rollback, err
+		}
+		log.Infof("Added repository %v for the patch operation", repository.Name)
+	}
+	return args, rollback, nil
+}
+
+// renderRpmRepository renders the ini repository files read by yum, dnf and zypper
+func renderRpmRepository(repository RepositoryConfig, keyPath string) string {
+	lines := []string{
+		"[" + repositoryFilePrefix + repository.Name + "]",
+		"name=" + repository.Name,
+		"baseurl=" + repository.BaseUrl,
+		"enabled=1",
+	}
+	if keyPath != "" {
+		lines = append(lines, "gpgcheck=1", "gpgkey=file://"+filepath.ToSlash(keyPath))
+	} else {
+		lines = append(lines, "gpgcheck=0")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// renderAptRepository renders the one line sources.list entry read by apt
+func renderAptRepository(repository RepositoryConfig, keyPath string) string {
+	options := "trusted=yes"
+	if keyPath != "" {
+		options = "signed-by=" + keyPath
+	}
+	// repositories without distribution are flat repositories
+	distribution := repository.Distribution
+	if distribution == "" {
+		distribution = "./"
+	}
+	return strings.TrimSpace(fmt.Sprintf("deb [%v] %v %v %v", options, repository.BaseUrl, distribution, strings.Join(repository.Components, " "))) + "\n"
+}
+
+// yumMirrorOverride points a yum or dnf repository to a mirror for the command only
+func yumMirrorOverride(repositoryID string, url string) []string {
+	return []string{
+		"--setopt=" + repositoryID + ".baseurl=" + url,
+		"--setopt=" + repositoryID + ".mirrorlist=",
+		"--setopt=" + repositoryID + ".metalink=",
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stagepatches
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const testGpgKey = "-----BEGIN PGP PUBLIC KEY BLOCK-----\nmQINBGA\n-----END PGP PUBLIC KEY BLOCK-----\n"
+
+func testRepositoryFormat(directory string) *repositoryFormat {
+	return &repositoryFormat{
+		directory:      directory,
+		fileExtension:  ".repo",
+		render:         renderRpmRepository,
+		mirrorOverride: yumMirrorOverride,
+	}
+}
+
+func TestApplyRepositories(t *testing.T) {
+	directory := t.TempDir()
+	repositories := []RepositoryConfig{
+		{Name: "internal", BaseUrl: "https://mirror.example.com/internal", GpgKey: testGpgKey},
+		{Name: "tools", BaseUrl: "https://mirror.example.com/tools"},
+	}
+
+	args, rollback, err := applyRepositories(log.NewMockLog(), testRepositoryFormat(directory), repositories, map[string]string{"amzn2-core": "https://mirror.example.com/core"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"--setopt=amzn2-core.baseurl=https://mirror.example.com/core",
+		"--setopt=amzn2-core.mirrorlist=",
+		"--setopt=amzn2-core.metalink=",
+	}, args)
+
+	content, err := ioutil.ReadFile(filepath.Join(directory, "ssm-patch-internal.repo"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "baseurl=https://mirror.example.com/internal\n")
+	assert.Contains(t, string(content), "gpgcheck=1\n")
+	keyPath := strings.TrimPrefix(strings.Split(string(content), "gpgkey=")[1], "file://")
+	keyPath = filepath.FromSlash(strings.TrimSpace(keyPath))
+	key, err := ioutil.ReadFile(keyPath)
+	assert.Nil(t, err)
+	assert.Equal(t, testGpgKey, string(key))
+
+	content, err = ioutil.ReadFile(filepath.Join(directory, "ssm-patch-tools.repo"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "gpgcheck=0\n")
+
+	rollback()
+	files, _ := ioutil.ReadDir(directory)
+	assert.Empty(t, files)
+	_, err = os.Stat(keyPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestApplyRepositoriesDoesNotOverwriteExistingFile(t *testing.T) {
+	directory := t.TempDir()
+	existing := filepath.Join(directory, "ssm-patch-tools.repo")
+	assert.Nil(t, ioutil.WriteFile(existing, []byte("existing"), 0600))
+	repositories := []RepositoryConfig{
+		{Name: "internal", BaseUrl: "https://mirror.example.com/internal"},
+		{Name: "tools", BaseUrl: "https://mirror.example.com/tools"},
+	}
+
+	_, rollback, err := applyRepositories(log.NewMockLog(), testRepositoryFormat(directory), repositories, nil)
+	rollback()
+	assert.NotNil(t, err)
+	content, _ := ioutil.ReadFile(existing)
+	assert.Equal(t, "existing", string(content))
+	_, err = os.Stat(filepath.Join(directory, "ssm-patch-internal.repo"))
+	assert.True(t, os.IsNotExist(err), "repositories written before the failure are rolled back")
+}
+
+func TestApplyRepositoriesInvalidConfiguration(t *testing.T) {
+	directory := t.TempDir()
+	logger := log.NewMockLog()
+
+	_, rollback, err := applyRepositories(logger, nil, nil, nil)
+	rollback()
+	assert.Nil(t, err, "nothing to apply")
+
+	_, _, err = applyRepositories(logger, nil, []RepositoryConfig{{Name: "internal", BaseUrl: "https://mirror.example.com"}}, nil)
+	assert.NotNil(t, err)
+
+	_, _, err = applyRepositories(logger, testRepositoryFormat(directory), []RepositoryConfig{{Name: "../internal", BaseUrl: "https://mirror.example.com"}}, nil)
+	assert.NotNil(t, err)
+
+	_, _, err = applyRepositories(logger, &repositoryFormat{directory: directory, render: renderAptRepository}, nil, map[string]string{"main": "https://mirror.example.com"})
+	assert.NotNil(t, err, "apt does not support mirror overrides")
+}
+
+func TestRenderAptRepository(t *testing.T) {
+	assert.Equal(t, "deb [signed-by=/tmp/keys/internal.asc] https://mirror.example.com/ubuntu jammy main universe\n",
+		renderAptRepository(RepositoryConfig{Name: "internal", BaseUrl: "https://mirror.example.com/ubuntu", Distribution: "jammy", Components: []string{"main", "universe"}}, "/tmp/keys/internal.asc"))
+	assert.Equal(t, "deb [trusted=yes] https://mirror.example.com/debs ./\n",
+		renderAptRepository(RepositoryConfig{Name: "debs", BaseUrl: "https://mirror.example.com/debs"}, ""))
+}
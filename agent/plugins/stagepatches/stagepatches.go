@@ -0,0 +1,168 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package stagepatches implements the aws:stagePatches plugin, which downloads the applicable updates without
+// installing them so that the install window only has to apply already staged updates.
+package stagepatches
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// Plugin is the type for the stagePatches plugin.
+type Plugin struct {
+	context context.T
+	// CommandExecuter is an object that can execute commands.
+	CommandExecuter executers.T
+}
+
+// StagePatchesPluginInput represents the input of the stagePatches plugin.
+type StagePatchesPluginInput struct {
+	contracts.PluginInput
+	ID             string
+	TimeoutSeconds interface{}
+	// Repositories are added for the duration of the patch operation only
+	Repositories []RepositoryConfig
+	// MirrorOverrides points existing repositories, by id, to mirrors for the duration of the patch operation only
+	MirrorOverrides map[string]string
+}
+
+// StagingResult summarizes the updates staged by the plugin, it is written to the plugin output
+type StagingResult struct {
+	PackageManager  string
+	StagedUpdates   int
+	StagedSizeBytes int64
+	CacheDirectory  string `json:",omitempty"`
+}
+
+// stagingCommand describes how a package manager downloads the applicable updates without installing them
+type stagingCommand struct {
+	packageManager string
+	// commands are run in order, the first element of each command is the executable
+	commands [][]string
+	envVars  map[string]string
+	// cacheDirectory is where the package manager stores the downloaded packages
+	cacheDirectory string
+	// packageExtension filters the files of the cache directory, all files are packages when empty
+	packageExtension string
+	// repositories describes the repository configuration of the package manager, nil when custom
+	// repositories are not supported
+	repositories *repositoryFormat
+}
+
+// NewPlugin returns a new instance of the plugin.
+func NewPlugin(context context.T) (*Plugin, error) {
+	return &Plugin{
+		context:         context,
+		CommandExecuter: executers.ShellCommandExecuter{},
+	}, nil
+}
+
+// Name returns the name of the plugin
+func Name() string {
+	return appconfig.PluginNameAwsStagePatches
+}
+
+// Execute stages the applicable updates and writes the staging result to the output
+func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := p.context.Log()
+	log.Infof("%v started with configuration %v", Name(), config)
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+		return
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+		return
+	}
+
+	var pluginInput StagePatchesPluginInput
+	if err := jsonutil.Remarshal(config.Properties, &pluginInput); err != nil {
+		output.MarkAsFailed(fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err))
+		return
+	}
+	executionTimeout := pluginutil.GetExecutionTimeout(log, p.context.AppConfig(), Name(), pluginInput.TimeoutSeconds)
+
+	result, err := p.stagePatches(log, pluginInput, executionTimeout, cancelFlag, output)
+	if err != nil {
+		output.MarkAsFailed(fmt.Errorf("failed to stage patches: %v", err))
+		return
+	}
+
+	resultJSON, _ := jsonutil.Marshal(result)
+	log.Infof("Staged %d updates, %d bytes, with %v", result.StagedUpdates, result.StagedSizeBytes, result.PackageManager)
+	output.AppendInfo(resultJSON)
+	output.MarkAsSucceeded()
+}
+
+// runStagingCommand runs the download only commands of a package manager and measures the packages they added
+// to the package cache, the repositories of the plugin input are only configured while the commands run
+func (p *Plugin) runStagingCommand(command stagingCommand, pluginInput StagePatchesPluginInput, executionTimeout int, cancelFlag task.CancelFlag, stdout io.Writer, stderr io.Writer) (result StagingResult, err error) {
+	repositoryArgs, rollback, err := applyRepositories(p.context.Log(), command.repositories, pluginInput.Repositories, pluginInput.MirrorOverrides)
+	defer rollback()
+	if err != nil {
+		return result, err
+	}
+
+	before := cachedPackages(command.cacheDirectory, command.packageExtension)
+	for _, args := range command.commands {
+		commandArgs := append(append([]string{}, args[1:]...), repositoryArgs...)
+		exitCode, err := p.CommandExecuter.NewExecute(p.context, "", stdout, stderr, cancelFlag, executionTimeout, args[0], commandArgs, command.envVars)
+		if err != nil {
+			return result, err
+		} else if exitCode != 0 {
+			return result, fmt.Errorf("%v exited with code %d", strings.Join(args, " "), exitCode)
+		}
+	}
+	after := cachedPackages(command.cacheDirectory, command.packageExtension)
+
+	result = StagingResult{PackageManager: command.packageManager, CacheDirectory: command.cacheDirectory}
+	result.StagedUpdates, result.StagedSizeBytes = stagedPackages(before, after)
+	return result, nil
+}
+
+// cachedPackages returns the size of the package files under the cache directory by path
+func cachedPackages(cacheDirectory string, packageExtension string) map[string]int64 {
+	packages := make(map[string]int64)
+	filepath.Walk(cacheDirectory, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() && strings.HasSuffix(info.Name(), packageExtension) {
+			packages[path] = info.Size()
+		}
+		return nil
+	})
+	return packages
+}
+
+// stagedPackages returns the number and the total size of the packages added or rewritten in the cache
+func stagedPackages(before map[string]int64, after map[string]int64) (count int, size int64) {
+	for path, afterSize := range after {
+		if beforeSize, found := before[path]; !found || beforeSize != afterSize {
+			count++
+			size += afterSize
+		}
+	}
+	return
+}
@@ -0,0 +1,35 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package stagepatches
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+var softwareUpdateStagingCommand = stagingCommand{
+	packageManager: "softwareupdate",
+	commands:       [][]string{{"softwareupdate", "--download", "--all"}},
+	cacheDirectory: "/Library/Updates",
+}
+
+// stagePatches downloads the applicable macOS updates without installing them
+func (p *Plugin) stagePatches(log log.T, pluginInput StagePatchesPluginInput, executionTimeout int, cancelFlag task.CancelFlag, output iohandler.IOHandler) (StagingResult, error) {
+	log.Infof("Staging patches with %v", softwareUpdateStagingCommand.packageManager)
+	return p.runStagingCommand(softwareUpdateStagingCommand, pluginInput, executionTimeout, cancelFlag, output.GetStdoutWriter(), output.GetStderrWriter())
+}
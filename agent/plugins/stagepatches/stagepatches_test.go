@@ -0,0 +1,85 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stagepatches
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	executermocks "github.com/aws/amazon-ssm-agent/agent/mocks/executers"
+	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStagedPackages(t *testing.T) {
+	before := map[string]int64{"/cache/a.rpm": 10, "/cache/b.rpm": 20}
+	after := map[string]int64{"/cache/a.rpm": 10, "/cache/b.rpm": 25, "/cache/c.rpm": 30}
+
+	count, size := stagedPackages(before, after)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, int64(55), size)
+}
+
+func TestCachedPackages(t *testing.T) {
+	cacheDirectory := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(cacheDirectory, "repo", "packages"), 0700))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(cacheDirectory, "repo", "packages", "bash.rpm"), []byte("bash"), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(cacheDirectory, "repo", "repomd.xml"), []byte("metadata"), 0600))
+
+	assert.Equal(t, map[string]int64{filepath.Join(cacheDirectory, "repo", "packages", "bash.rpm"): 4}, cachedPackages(cacheDirectory, ".rpm"))
+	assert.Len(t, cachedPackages(cacheDirectory, ""), 2)
+	assert.Empty(t, cachedPackages(filepath.Join(cacheDirectory, "missing"), ".rpm"))
+}
+
+func TestRunStagingCommand(t *testing.T) {
+	cacheDirectory := t.TempDir()
+	command := stagingCommand{
+		packageManager:   "yum",
+		commands:         [][]string{{"yum", "-y", "update", "--downloadonly"}},
+		cacheDirectory:   cacheDirectory,
+		packageExtension: ".rpm",
+	}
+	mockExecuter := new(executermocks.MockCommandExecuter)
+	mockExecuter.On("NewExecute", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything, 3600, "yum", []string{"-y", "update", "--downloadonly"}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ioutil.WriteFile(filepath.Join(cacheDirectory, "kernel.rpm"), make([]byte, 1024), 0600)
+		}).Return(0, nil).Once()
+	p := &Plugin{context: contextmocks.NewMockDefault(), CommandExecuter: mockExecuter}
+
+	result, err := p.runStagingCommand(command, StagePatchesPluginInput{}, 3600, taskmocks.NewMockDefault(), ioutil.Discard, ioutil.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, StagingResult{PackageManager: "yum", StagedUpdates: 1, StagedSizeBytes: 1024, CacheDirectory: cacheDirectory}, result)
+
+	mockExecuter.On("NewExecute", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything, 3600, "yum", mock.Anything, mock.Anything).Return(1, nil)
+	_, err = p.runStagingCommand(command, StagePatchesPluginInput{}, 3600, taskmocks.NewMockDefault(), ioutil.Discard, ioutil.Discard)
+	assert.NotNil(t, err)
+}
+
+func TestExecuteInvalidInput(t *testing.T) {
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+	mockCancelFlag := new(taskmocks.MockCancelFlag)
+	mockCancelFlag.On("ShutDown").Return(false)
+	mockCancelFlag.On("Canceled").Return(false)
+	p, _ := NewPlugin(contextmocks.NewMockDefault())
+
+	p.Execute(contracts.Configuration{Properties: "invalid"}, mockCancelFlag, mockIOHandler)
+	mockIOHandler.AssertCalled(t, "MarkAsFailed", mock.Anything)
+}
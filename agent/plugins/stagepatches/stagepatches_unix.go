@@ -0,0 +1,98 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package stagepatches
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+var (
+	yumRepositories = &repositoryFormat{
+		directory:      "/etc/yum.repos.d",
+		fileExtension:  ".repo",
+		render:         renderRpmRepository,
+		mirrorOverride: yumMirrorOverride,
+	}
+	zypperRepositories = &repositoryFormat{
+		directory:     "/etc/zypp/repos.d",
+		fileExtension: ".repo",
+		render:        renderRpmRepository,
+	}
+	aptRepositories = &repositoryFormat{
+		directory:     "/etc/apt/sources.list.d",
+		fileExtension: ".list",
+		render:        renderAptRepository,
+	}
+)
+
+// stagingCommands lists the supported package managers, the first one found on the instance is used
+var stagingCommands = []stagingCommand{
+	{
+		packageManager:   "dnf",
+		commands:         [][]string{{"dnf", "-y", "upgrade", "--downloadonly"}},
+		cacheDirectory:   "/var/cache/dnf",
+		packageExtension: ".rpm",
+		repositories:     yumRepositories,
+	},
+	{
+		packageManager:   "yum",
+		commands:         [][]string{{"yum", "-y", "update", "--downloadonly"}},
+		cacheDirectory:   "/var/cache/yum",
+		packageExtension: ".rpm",
+		repositories:     yumRepositories,
+	},
+	{
+		packageManager:   "zypper",
+		commands:         [][]string{{"zypper", "--non-interactive", "update", "--download-only"}},
+		cacheDirectory:   "/var/cache/zypp/packages",
+		packageExtension: ".rpm",
+		repositories:     zypperRepositories,
+	},
+	{
+		packageManager:   "apt-get",
+		commands:         [][]string{{"apt-get", "-y", "update"}, {"apt-get", "-y", "--download-only", "dist-upgrade"}},
+		envVars:          map[string]string{"DEBIAN_FRONTEND": "noninteractive"},
+		cacheDirectory:   "/var/cache/apt/archives",
+		packageExtension: ".deb",
+		repositories:     aptRepositories,
+	},
+	{
+		packageManager:   "pkg",
+		commands:         [][]string{{"pkg", "upgrade", "--fetch-only", "--yes"}},
+		cacheDirectory:   "/var/cache/pkg",
+		packageExtension: ".pkg",
+	},
+}
+
+var lookPath = exec.LookPath
+
+// stagePatches downloads the applicable updates to the cache of the package manager of the instance
+func (p *Plugin) stagePatches(log log.T, pluginInput StagePatchesPluginInput, executionTimeout int, cancelFlag task.CancelFlag, output iohandler.IOHandler) (StagingResult, error) {
+	for _, command := range stagingCommands {
+		if _, err := lookPath(command.commands[0][0]); err != nil {
+			continue
+		}
+		log.Infof("Staging patches with %v", command.packageManager)
+		return p.runStagingCommand(command, pluginInput, executionTimeout, cancelFlag, output.GetStdoutWriter(), output.GetStderrWriter())
+	}
+	return StagingResult{}, fmt.Errorf("no supported package manager found")
+}
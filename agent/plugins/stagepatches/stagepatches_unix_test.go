@@ -0,0 +1,56 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package stagepatches
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	multiwritermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter/mock"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	executermocks "github.com/aws/amazon-ssm-agent/agent/mocks/executers"
+	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStagePatchesUsesAvailablePackageManager(t *testing.T) {
+	defer func() { lookPath = exec.LookPath }()
+	lookPath = func(file string) (string, error) {
+		if file == "apt-get" {
+			return "/usr/bin/apt-get", nil
+		}
+		return "", fmt.Errorf("%v not found", file)
+	}
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	mockIOHandler.On("GetStdoutWriter").Return(new(multiwritermock.MockDocumentIOMultiWriter))
+	mockIOHandler.On("GetStderrWriter").Return(new(multiwritermock.MockDocumentIOMultiWriter))
+	mockExecuter := new(executermocks.MockCommandExecuter)
+	mockExecuter.On("NewExecute", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything, 600, "apt-get", mock.Anything, map[string]string{"DEBIAN_FRONTEND": "noninteractive"}).Return(0, nil)
+	p := &Plugin{context: contextmocks.NewMockDefault(), CommandExecuter: mockExecuter}
+
+	result, err := p.stagePatches(p.context.Log(), StagePatchesPluginInput{}, 600, taskmocks.NewMockDefault(), mockIOHandler)
+	assert.Nil(t, err)
+	assert.Equal(t, "apt-get", result.PackageManager)
+	mockExecuter.AssertCalled(t, "NewExecute", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything, 600, "apt-get", []string{"-y", "--download-only", "dist-upgrade"}, mock.Anything)
+
+	lookPath = func(file string) (string, error) { return "", fmt.Errorf("%v not found", file) }
+	_, err = p.stagePatches(p.context.Log(), StagePatchesPluginInput{}, 600, taskmocks.NewMockDefault(), mockIOHandler)
+	assert.NotNil(t, err)
+}
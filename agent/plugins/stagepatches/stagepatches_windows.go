@@ -0,0 +1,94 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package stagepatches
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	windowsUpdatePackageManager = "WindowsUpdate"
+
+	// downloadUpdatesScript downloads the applicable software updates with the Windows Update Agent API
+	// without installing them, the last line reports the downloaded updates and their size
+	downloadUpdatesScript = `
+$ErrorActionPreference = 'Stop'
+$session = New-Object -ComObject Microsoft.Update.Session
+$searchResult = $session.CreateUpdateSearcher().Search("IsInstalled=0 and Type='Software' and IsHidden=0")
+$updates = New-Object -ComObject Microsoft.Update.UpdateColl
+foreach ($update in $searchResult.Updates) {
+  if (-not $update.IsDownloaded) { [void]$updates.Add($update) }
+}
+if ($updates.Count -gt 0) {
+  $downloader = $session.CreateUpdateDownloader()
+  $downloader.Updates = $updates
+  [void]$downloader.Download()
+}
+$staged = 0
+$size = 0
+foreach ($update in $updates) {
+  if ($update.IsDownloaded) {
+    $staged++
+    $size += $update.MaxDownloadSize
+    Write-Output ("Staged {0}" -f $update.Title)
+  }
+}
+Write-Output ("StagedUpdates={0};StagedSizeBytes={1}" -f $staged, $size)
+`
+)
+
+var stagingSummary = regexp.MustCompile(`StagedUpdates=(\d+);StagedSizeBytes=(\d+)`)
+
+// stagePatches downloads the applicable Windows updates without installing them
+func (p *Plugin) stagePatches(log log.T, pluginInput StagePatchesPluginInput, executionTimeout int, cancelFlag task.CancelFlag, output iohandler.IOHandler) (result StagingResult, err error) {
+	log.Infof("Staging patches with %v", windowsUpdatePackageManager)
+	if len(pluginInput.Repositories) > 0 || len(pluginInput.MirrorOverrides) > 0 {
+		return result, fmt.Errorf("custom repositories are not supported by %v", windowsUpdatePackageManager)
+	}
+
+	var stdout bytes.Buffer
+	exitCode, err := p.CommandExecuter.NewExecute(p.context, "", io.MultiWriter(output.GetStdoutWriter(), &stdout), output.GetStderrWriter(),
+		cancelFlag, executionTimeout, appconfig.PowerShellPluginCommandName, []string{"-NonInteractive", "-Command", downloadUpdatesScript}, make(map[string]string))
+	if err != nil {
+		return result, err
+	} else if exitCode != 0 {
+		return result, fmt.Errorf("update download exited with code %d", exitCode)
+	}
+	return parseStagingSummary(stdout.String())
+}
+
+// parseStagingSummary reads the number and size of the downloaded updates reported by the download script
+func parseStagingSummary(stdout string) (result StagingResult, err error) {
+	matches := stagingSummary.FindAllStringSubmatch(stdout, -1)
+	if len(matches) == 0 {
+		return result, fmt.Errorf("update download did not report the staged updates")
+	}
+	summary := matches[len(matches)-1]
+	result.PackageManager = windowsUpdatePackageManager
+	result.StagedUpdates, _ = strconv.Atoi(summary[1])
+	result.StagedSizeBytes, _ = strconv.ParseInt(summary[2], 10, 64)
+	return result, nil
+}
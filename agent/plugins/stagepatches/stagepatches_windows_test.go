@@ -0,0 +1,32 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package stagepatches
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStagingSummary(t *testing.T) {
+	result, err := parseStagingSummary("Staged 2022-10 Cumulative Update\r\nStaged Defender definitions\r\nStagedUpdates=2;StagedSizeBytes=734003200\r\n")
+	assert.Nil(t, err)
+	assert.Equal(t, StagingResult{PackageManager: windowsUpdatePackageManager, StagedUpdates: 2, StagedSizeBytes: 734003200}, result)
+
+	_, err = parseStagingSummary("Exception from HRESULT: 0x8024402C")
+	assert.NotNil(t, err)
+}
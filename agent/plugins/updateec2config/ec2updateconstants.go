@@ -63,12 +63,6 @@ const (
 
 	//Manifest Path in S3 bucket
 	ManifestPath = "/amazon-ssm-{Region}/manifest.json"
-
-	// CommonManifestURL is the URL for the manifest file in regular regions
-	CommonManifestURL = "https://s3.{Region}.amazonaws.com" + ManifestPath
-
-	// ChinaManifestURL is the URL for the manifest in regions in China
-	ChinaManifestURL = "https://s3.{Region}.amazonaws.com.cn" + ManifestPath
 )
 
 // update context constant strings
@@ -37,12 +37,12 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
-	"github.com/aws/amazon-ssm-agent/agent/s3util"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil/updateconstants"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil/updateinfo"
 	"github.com/aws/amazon-ssm-agent/agent/versionutil"
+	"github.com/aws/amazon-ssm-agent/common/identity/endpoint"
 )
 
 var (
@@ -567,20 +567,13 @@ func Name() string {
 
 // GetUpdatePluginConfig returns the default values for the update plugin
 func GetUpdatePluginConfig(context context.T) UpdatePluginConfig {
-	var manifestURL string
-	dynamicS3Endpoint := context.Identity().GetServiceEndpoint("s3")
-	if dynamicS3Endpoint != "" {
-		manifestURL = "https://" + dynamicS3Endpoint + ManifestPath
-	} else {
+	s3Endpoint := context.Identity().GetServiceEndpoint("s3")
+	if s3Endpoint == "" {
 		region, _ := context.Identity().Region()
-		if strings.HasPrefix(region, s3util.ChinaRegionPrefix) {
-			manifestURL = ChinaManifestURL
-		} else {
-			manifestURL = CommonManifestURL
-		}
+		s3Endpoint = endpoint.GetDefaultServiceEndpoint("s3", region)
 	}
 
 	return UpdatePluginConfig{
-		ManifestLocation: manifestURL,
+		ManifestLocation: "https://" + s3Endpoint + ManifestPath,
 	}
 }
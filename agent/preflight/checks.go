@@ -0,0 +1,137 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	agentContext "github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/proxyconfig"
+)
+
+const (
+	// minFreeDiskSpaceBytes is the free space the agent needs on the data store volume to save its state
+	minFreeDiskSpaceBytes = 100 * 1024 * 1024
+	resolutionTimeout     = 5 * time.Second
+)
+
+var (
+	// minimumClockTime is the earliest plausible time of the system clock, an earlier clock was never set
+	minimumClockTime = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// dataStorePath is read when the checks run, the app config may have moved the data store
+	dataStorePath    = func() string { return appconfig.DefaultDataStorePath }
+	getDiskSpaceInfo = fileutil.GetDiskSpaceInfoOfPath
+	lookupHost       = network.LookupHost
+	executablePath   = os.Executable
+	now              = time.Now
+)
+
+// checkDataStore checks that the agent can write its state in the data store
+func checkDataStore(agentContext.T) (status, message, remediation string) {
+	path := dataStorePath()
+	remediation = fmt.Sprintf("Make sure the agent runs as root or administrator and %v is on a writable file system.", path)
+	if err := fileutil.MakeDirs(path); err != nil {
+		return StatusFailed, fmt.Sprintf("data store %v cannot be created: %v", path, err), remediation
+	}
+	file, err := ioutil.TempFile(path, ".preflight")
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("data store %v is not writable: %v", path, err), remediation
+	}
+	_, err = file.WriteString("preflight")
+	file.Close()
+	os.Remove(file.Name())
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("data store %v is not writable: %v", path, err), remediation
+	}
+	return StatusPassed, fmt.Sprintf("data store %v is writable", path), ""
+}
+
+// checkDiskSpace checks the free space of the data store volume
+func checkDiskSpace(agentContext.T) (status, message, remediation string) {
+	path := dataStorePath()
+	diskSpaceInfo, err := getDiskSpaceInfo(path)
+	if err != nil {
+		return StatusSkipped, fmt.Sprintf("free disk space of %v cannot be read: %v", path, err), ""
+	}
+	available := diskSpaceInfo.AvailBytes / (1024 * 1024)
+	if diskSpaceInfo.AvailBytes < minFreeDiskSpaceBytes {
+		return StatusFailed,
+			fmt.Sprintf("%d MB available on the volume of %v, at least %d MB are needed", available, path, minFreeDiskSpaceBytes/(1024*1024)),
+			fmt.Sprintf("Free disk space on the volume of %v.", path)
+	}
+	return StatusPassed, fmt.Sprintf("%d MB available on the volume of %v", available, path), ""
+}
+
+// checkEndpointResolution checks that the host name of the Systems Manager endpoint resolves, the host names are
+// resolved by the proxy when one is configured
+func checkEndpointResolution(agentCtx agentContext.T) (status, message, remediation string) {
+	host := ssmEndpointHost(agentCtx)
+	if host == "" {
+		return StatusSkipped, "the Systems Manager endpoint is unknown", ""
+	}
+	if proxy := proxyconfig.GetProxyConfig()[proxyconfig.PROXY_VAR_HTTPS]; proxy != "" {
+		return StatusSkipped, fmt.Sprintf("%v is resolved by the proxy %v", host, proxy), ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolutionTimeout)
+	defer cancel()
+	ips, err := lookupHost(ctx, agentCtx.AppConfig(), host)
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("%v cannot be resolved: %v", host, err),
+			"Check the DNS servers of the host or set the DNSServers of the agent network configuration."
+	}
+	return StatusPassed, fmt.Sprintf("%v resolves to %v", host, ips[0]), ""
+}
+
+// ssmEndpointHost returns the host name of the Systems Manager endpoint the agent connects to
+func ssmEndpointHost(context agentContext.T) string {
+	endpoint := context.AppConfig().Ssm.Endpoint
+	if endpoint == "" {
+		endpoint = context.Identity().GetServiceEndpoint("ssm")
+	}
+	if strings.Contains(endpoint, "://") {
+		if endpointURL, err := url.Parse(endpoint); err == nil {
+			return endpointURL.Hostname()
+		}
+	}
+	return endpoint
+}
+
+// checkClock checks that the system clock is set, the clock is expected after the installation of the agent
+func checkClock(agentContext.T) (status, message, remediation string) {
+	current := now()
+	earliest := minimumClockTime
+	if path, err := executablePath(); err == nil {
+		// a day of tolerance for the clocks set in local time
+		if info, err := os.Stat(path); err == nil && info.ModTime().Add(-24*time.Hour).After(earliest) {
+			earliest = info.ModTime().Add(-24 * time.Hour)
+		}
+	}
+	if current.Before(earliest) {
+		return StatusFailed,
+			fmt.Sprintf("system clock %v is earlier than %v", current.UTC().Format(time.RFC3339), earliest.UTC().Format(time.RFC3339)),
+			"Synchronize the system clock with a time server, the requests signed with a wrong clock are rejected."
+	}
+	return StatusPassed, fmt.Sprintf("system clock is %v", current.UTC().Format(time.RFC3339)), ""
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package preflight checks the host dependencies of the agent before the core modules are registered. The failed
+// checks are reported together, in the agent log and in the report read by ssm-cli get-diagnostics.
+package preflight
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+)
+
+const (
+	StatusPassed  = "Passed"
+	StatusFailed  = "Failed"
+	StatusSkipped = "Skipped"
+)
+
+// check is a preflight check, it returns the remediation of the failure when it fails
+type check struct {
+	name string
+	run  func(context context.T) (status, message, remediation string)
+}
+
+var checks = []check{
+	{"Data store", checkDataStore},
+	{"Free disk space", checkDiskSpace},
+	{"TLS trust store", checkTrustStore},
+	{"Service endpoint resolution", checkEndpointResolution},
+	{"System clock", checkClock},
+}
+
+var newConfigClient = runtimeconfig.NewPreflightRuntimeConfigClient
+
+// Run runs the preflight checks, logs the failed checks in a single report and saves the results for ssm-cli.
+// The checks do not prevent the agent from starting, it is up to the administrator to fix the failures.
+func Run(context context.T) runtimeconfig.PreflightRuntimeConfig {
+	log := context.Log()
+	report := runtimeconfig.PreflightRuntimeConfig{CheckedAt: time.Now().UTC()}
+	var failures []string
+	for _, c := range checks {
+		result := runCheck(context, c)
+		report.Checks = append(report.Checks, result)
+		switch result.Status {
+		case StatusFailed:
+			failures = append(failures, fmt.Sprintf("- %v: %v. %v", result.Name, result.Message, result.Remediation))
+		case StatusSkipped:
+			log.Debugf("Preflight check %v skipped: %v", result.Name, result.Message)
+		}
+	}
+
+	if len(failures) > 0 {
+		log.Errorf("%d of %d preflight checks failed, the agent may not work until they are fixed:\n%v",
+			len(failures), len(checks), strings.Join(failures, "\n"))
+	} else {
+		log.Infof("All %d preflight checks passed", len(checks))
+	}
+
+	if err := newConfigClient().SaveConfig(report); err != nil {
+		log.Warnf("Unable to save the preflight report: %v", err)
+	}
+	return report
+}
+
+// runCheck runs a check, a check that panics fails
+func runCheck(context context.T, c check) (result runtimeconfig.PreflightCheckResult) {
+	result.Name = c.name
+	defer func() {
+		if r := recover(); r != nil {
+			result.Status = StatusFailed
+			result.Message = fmt.Sprintf("the check panicked: %v", r)
+			result.Remediation = "Report this issue with the agent logs."
+		}
+	}()
+	result.Status, result.Message, result.Remediation = c.run(context)
+	return
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package preflight
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	agentContext "github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeConfigClient struct {
+	saved *runtimeconfig.PreflightRuntimeConfig
+}
+
+func (c *fakeConfigClient) ConfigExists() (bool, error) {
+	return c.saved != nil, nil
+}
+
+func (c *fakeConfigClient) GetConfig() (runtimeconfig.PreflightRuntimeConfig, error) {
+	return *c.saved, nil
+}
+
+func (c *fakeConfigClient) SaveConfig(config runtimeconfig.PreflightRuntimeConfig) error {
+	c.saved = &config
+	return nil
+}
+
+// setupHostMocks mocks a healthy host whose data store is a temporary directory
+func setupHostMocks(t *testing.T) *fakeConfigClient {
+	dir, err := ioutil.TempDir("", "preflight")
+	assert.NoError(t, err)
+	configClient := &fakeConfigClient{}
+
+	originalDataStorePath, originalGetDiskSpaceInfo, originalLookupHost, originalNow, originalNewConfigClient :=
+		dataStorePath, getDiskSpaceInfo, lookupHost, now, newConfigClient
+	dataStorePath = func() string { return dir }
+	getDiskSpaceInfo = func(path string) (fileutil.DiskSpaceInfo, error) {
+		return fileutil.DiskSpaceInfo{AvailBytes: 10 * minFreeDiskSpaceBytes}, nil
+	}
+	lookupHost = func(ctx context.Context, appConfig appconfig.SsmagentConfig, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("192.0.2.10")}, nil
+	}
+	now = time.Now
+	newConfigClient = func() runtimeconfig.IPreflightRuntimeConfigClient { return configClient }
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+		dataStorePath, getDiskSpaceInfo, lookupHost, now, newConfigClient =
+			originalDataStorePath, originalGetDiskSpaceInfo, originalLookupHost, originalNow, originalNewConfigClient
+	})
+	return configClient
+}
+
+func checkResult(report runtimeconfig.PreflightRuntimeConfig, name string) runtimeconfig.PreflightCheckResult {
+	for _, result := range report.Checks {
+		if result.Name == name {
+			return result
+		}
+	}
+	return runtimeconfig.PreflightCheckResult{}
+}
+
+func TestRun_SavesReport(t *testing.T) {
+	configClient := setupHostMocks(t)
+
+	report := Run(contextmocks.NewMockDefault())
+
+	assert.Len(t, report.Checks, len(checks))
+	assert.Equal(t, &report, configClient.saved)
+	for _, name := range []string{"Data store", "Free disk space", "Service endpoint resolution", "System clock"} {
+		assert.Equal(t, StatusPassed, checkResult(report, name).Status, name)
+	}
+}
+
+func TestRun_ReportsAllFailures(t *testing.T) {
+	setupHostMocks(t)
+	getDiskSpaceInfo = func(path string) (fileutil.DiskSpaceInfo, error) {
+		return fileutil.DiskSpaceInfo{AvailBytes: minFreeDiskSpaceBytes / 2}, nil
+	}
+	lookupHost = func(ctx context.Context, appConfig appconfig.SsmagentConfig, host string) ([]net.IP, error) {
+		return nil, errors.New("no such host")
+	}
+	now = func() time.Time { return time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	report := Run(contextmocks.NewMockDefault())
+
+	for _, name := range []string{"Free disk space", "Service endpoint resolution", "System clock"} {
+		result := checkResult(report, name)
+		assert.Equal(t, StatusFailed, result.Status, name)
+		assert.NotEmpty(t, result.Remediation, name)
+	}
+	assert.Equal(t, StatusPassed, checkResult(report, "Data store").Status)
+}
+
+func TestRunCheck_PanicFailsCheck(t *testing.T) {
+	result := runCheck(contextmocks.NewMockDefault(), check{"Panicking check", func(agentCtx agentContext.T) (string, string, string) {
+		panic("check bug")
+	}})
+
+	assert.Equal(t, StatusFailed, result.Status)
+	assert.Contains(t, result.Message, "check bug")
+}
+
+func TestCheckDataStore_NotWritable(t *testing.T) {
+	setupHostMocks(t)
+	file, err := ioutil.TempFile("", "preflight")
+	assert.NoError(t, err)
+	file.Close()
+	defer os.Remove(file.Name())
+	// a file cannot hold the data store
+	dataStorePath = func() string { return file.Name() }
+
+	status, _, remediation := checkDataStore(contextmocks.NewMockDefault())
+
+	assert.Equal(t, StatusFailed, status)
+	assert.Contains(t, remediation, file.Name())
+}
+
+func TestSSMEndpointHost(t *testing.T) {
+	assert.Equal(t, "ssm.us-east-1.amazonaws.com", ssmEndpointHost(contextmocks.NewMockDefault()))
+
+	appConfig := appconfig.SsmagentConfig{}
+	appConfig.Ssm.Endpoint = "https://vpce-1234.ssm.us-east-1.vpce.amazonaws.com"
+	assert.Equal(t, "vpce-1234.ssm.us-east-1.vpce.amazonaws.com", ssmEndpointHost(contextmocks.NewMockDefaultWithConfig(appConfig)))
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !darwin && !windows
+// +build !darwin,!windows
+
+package preflight
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+)
+
+var systemCertPool = x509.SystemCertPool
+
+// checkTrustStore checks that the system trust store holds the CA certificates the service certificates are
+// verified with
+func checkTrustStore(context.T) (status, message, remediation string) {
+	remediation = "Install the CA certificates package of the distribution or set SSL_CERT_FILE to a CA bundle."
+	pool, err := systemCertPool()
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("system trust store cannot be loaded: %v", err), remediation
+	}
+	if len(pool.Subjects()) == 0 {
+		return StatusFailed, "system trust store has no CA certificate", remediation
+	}
+	return StatusPassed, fmt.Sprintf("system trust store has %d CA certificates", len(pool.Subjects())), ""
+}
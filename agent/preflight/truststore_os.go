@@ -0,0 +1,26 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || windows
+// +build darwin windows
+
+package preflight
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/context"
+)
+
+// checkTrustStore is skipped, the certificates are verified with the trust store of the operating system
+func checkTrustStore(context.T) (status, message, remediation string) {
+	return StatusSkipped, "the certificates are verified with the trust store of the operating system", ""
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package processpriority
+
+import (
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// io priority values of the ioprio_set system call, see linux/ioprio.h
+const (
+	ioPriorityWhoProcess      = 1
+	ioPriorityClassBestEffort = 2
+	ioPriorityClassIdle       = 3
+	ioPriorityClassShift      = 13
+)
+
+// setIOPriority sets the io scheduling class and level of the process
+func setIOPriority(pid int, class string, level int) error {
+	ioPriority := ioPriorityClassBestEffort<<ioPriorityClassShift | level
+	if class == appconfig.IOPriorityClassIdle {
+		// the level is not used by the idle class
+		ioPriority = ioPriorityClassIdle << ioPriorityClassShift
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioPriorityWhoProcess, uintptr(pid), uintptr(ioPriority)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package processpriority sets the scheduling priority of the processes spawned by the agent.
+package processpriority
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// Validate returns an error if one of the values of the process priority is not supported
+func Validate(priority appconfig.ProcessPriority) error {
+	if priority.Nice < appconfig.ProcessNiceMin || priority.Nice > appconfig.ProcessNiceMax {
+		return fmt.Errorf("nice %v must be between %v and %v", priority.Nice, appconfig.ProcessNiceMin, appconfig.ProcessNiceMax)
+	}
+	switch priority.IOPriorityClass {
+	case "", appconfig.IOPriorityClassBestEffort, appconfig.IOPriorityClassIdle:
+	default:
+		return fmt.Errorf("io priority class %v must be %v or %v", priority.IOPriorityClass, appconfig.IOPriorityClassBestEffort, appconfig.IOPriorityClassIdle)
+	}
+	if priority.IOPriorityLevel < appconfig.IOPriorityLevelMin || priority.IOPriorityLevel > appconfig.IOPriorityLevelMax {
+		return fmt.Errorf("io priority level %v must be between %v and %v", priority.IOPriorityLevel, appconfig.IOPriorityLevelMin, appconfig.IOPriorityLevelMax)
+	}
+	switch priority.PriorityClass {
+	case "", appconfig.ProcessPriorityClassIdle, appconfig.ProcessPriorityClassBelowNormal, appconfig.ProcessPriorityClassNormal, appconfig.ProcessPriorityClassAboveNormal:
+	default:
+		return fmt.Errorf("priority class %v must be %v, %v, %v or %v", priority.PriorityClass,
+			appconfig.ProcessPriorityClassIdle, appconfig.ProcessPriorityClassBelowNormal, appconfig.ProcessPriorityClassNormal, appconfig.ProcessPriorityClassAboveNormal)
+	}
+	return nil
+}
+
+// Apply sets the priority of the process with the given pid, the processes it spawns afterwards inherit it.
+// Nothing is done for the zero value of the priority.
+func Apply(pid int, priority appconfig.ProcessPriority) error {
+	if priority == (appconfig.ProcessPriority{}) {
+		return nil
+	}
+	if pid <= 0 {
+		return fmt.Errorf("invalid pid %v", pid)
+	}
+	if err := Validate(priority); err != nil {
+		return err
+	}
+	return apply(pid, priority)
+}
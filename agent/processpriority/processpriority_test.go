@@ -0,0 +1,53 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processpriority
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []appconfig.ProcessPriority{
+		{},
+		{Nice: appconfig.ProcessNiceMin},
+		{Nice: appconfig.ProcessNiceMax, IOPriorityClass: appconfig.IOPriorityClassIdle},
+		{IOPriorityClass: appconfig.IOPriorityClassBestEffort, IOPriorityLevel: appconfig.IOPriorityLevelMax},
+		{PriorityClass: appconfig.ProcessPriorityClassBelowNormal},
+	}
+	for _, priority := range valid {
+		assert.NoError(t, Validate(priority), "%+v", priority)
+	}
+
+	invalid := []appconfig.ProcessPriority{
+		{Nice: 20},
+		{Nice: -21},
+		{IOPriorityClass: "RealTime"},
+		{IOPriorityClass: appconfig.IOPriorityClassBestEffort, IOPriorityLevel: 8},
+		{PriorityClass: "High"},
+	}
+	for _, priority := range invalid {
+		assert.Error(t, Validate(priority), "%+v", priority)
+	}
+}
+
+func TestApply(t *testing.T) {
+	// the zero value keeps the inherited priority
+	assert.NoError(t, Apply(0, appconfig.ProcessPriority{}))
+
+	assert.Error(t, Apply(0, appconfig.ProcessPriority{Nice: 10}))
+	assert.Error(t, Apply(1, appconfig.ProcessPriority{Nice: 30}))
+}
@@ -0,0 +1,39 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package processpriority
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// apply sets the niceness and the io priority of the process, the windows priority class is ignored
+func apply(pid int, priority appconfig.ProcessPriority) error {
+	if priority.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, priority.Nice); err != nil {
+			return fmt.Errorf("failed to set the niceness of process %v to %v: %v", pid, priority.Nice, err)
+		}
+	}
+	if priority.IOPriorityClass != "" {
+		if err := setIOPriority(pid, priority.IOPriorityClass, priority.IOPriorityLevel); err != nil {
+			return fmt.Errorf("failed to set the io priority of process %v to %v: %v", pid, priority.IOPriorityClass, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package processpriority
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"golang.org/x/sys/windows"
+)
+
+var priorityClasses = map[string]uint32{
+	appconfig.ProcessPriorityClassIdle:        windows.IDLE_PRIORITY_CLASS,
+	appconfig.ProcessPriorityClassBelowNormal: windows.BELOW_NORMAL_PRIORITY_CLASS,
+	appconfig.ProcessPriorityClassNormal:      windows.NORMAL_PRIORITY_CLASS,
+	appconfig.ProcessPriorityClassAboveNormal: windows.ABOVE_NORMAL_PRIORITY_CLASS,
+}
+
+// apply sets the priority class of the process, the niceness and the io priority are ignored
+func apply(pid int, priority appconfig.ProcessPriority) error {
+	if priority.PriorityClass == "" {
+		return nil
+	}
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("failed to open process %v: %v", pid, err)
+	}
+	defer windows.CloseHandle(handle)
+
+	if err = windows.SetPriorityClass(handle, priorityClasses[priority.PriorityClass]); err != nil {
+		return fmt.Errorf("failed to set the priority class of process %v to %v: %v", pid, priority.PriorityClass, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package profiling serves the pprof endpoints of the agent processes on a local socket only the administrators can
+// connect to, a unix domain socket or a named pipe on windows, and captures profile bundles from them, so that the
+// performance issues of the agent can be diagnosed on the host.
+package profiling
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/ipc/localsocket"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+)
+
+const (
+	// CoreProcess is the amazon-ssm-agent process
+	CoreProcess = "core"
+	// WorkerProcess is the ssm-agent-worker process
+	WorkerProcess = "worker"
+
+	// DefaultDurationSeconds is the default duration of the CPU profile of a bundle
+	DefaultDurationSeconds = 30
+	// MaxDurationSeconds is the maximum duration of the CPU profile of a bundle
+	MaxDurationSeconds = 300
+
+	manifestName = "manifest.json"
+	// captureTimeoutMargin is added to the duration of the CPU profile for the timeout of the requests
+	captureTimeoutMargin = 30 * time.Second
+	// profilingDialTimeout is the time given to connect to the profiling socket of a process
+	profilingDialTimeout = time.Second
+)
+
+// Manifest describes the content of a profile bundle
+type Manifest struct {
+	AgentVersion    string
+	Process         string
+	CreatedAt       time.Time
+	DurationSeconds int
+	Profiles        []string
+}
+
+// profile is a profile of a bundle and the path of the endpoint it is read from
+type profile struct {
+	name string
+	path string
+	// timed profiles are sampled for the duration of the bundle
+	timed bool
+}
+
+var profiles = []profile{
+	{"cpu.pprof", "/debug/pprof/profile", true},
+	{"heap.pprof", "/debug/pprof/heap", false},
+	{"allocs.pprof", "/debug/pprof/allocs", false},
+	{"goroutine.txt", "/debug/pprof/goroutine?debug=2", false},
+}
+
+// SocketPath returns the path of the socket the profiling endpoint of the given process is served on
+func SocketPath(process string) (string, error) {
+	if process != CoreProcess && process != WorkerProcess {
+		return "", fmt.Errorf("unknown agent process %v", process)
+	}
+	return socketPath(process), nil
+}
+
+// Start serves the pprof endpoints of the process on its profiling socket when profiling is enabled
+func Start(log log.T, config appconfig.ProfilingCfg, process string) {
+	if !config.Enabled {
+		return
+	}
+	path, err := SocketPath(process)
+	if err != nil {
+		log.Errorf("Failed to start the profiling endpoint: %v", err)
+		return
+	}
+	listener, err := listenProfilingSocket(path)
+	if err != nil {
+		log.Errorf("Failed to start the profiling endpoint on %v: %v", path, err)
+		return
+	}
+	log.Warnf("Profiling is enabled, the pprof endpoints are served on %v", path)
+	go serve(log, listener)
+}
+
+func serve(log log.T, listener net.Listener) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Profiling endpoint panic: %v", r)
+		}
+	}()
+	// the CPU profile request lasts the duration of the profile, only the headers are timed out
+	server := &http.Server{Handler: newMux(), ReadHeaderTimeout: 10 * time.Second}
+	if err := server.Serve(listener); err != nil {
+		log.Warnf("Profiling endpoint stopped: %v", err)
+	}
+}
+
+// newMux returns the handler of the pprof endpoints, they are not registered on the default mux
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// CaptureBundle captures the profiles of the given process from its profiling socket to a tar.gz bundle
+func CaptureBundle(writer io.Writer, process string, durationSeconds int) (manifest Manifest, err error) {
+	path, err := SocketPath(process)
+	if err != nil {
+		return manifest, err
+	}
+	return captureBundle(writer, process, func() (net.Conn, error) { return localsocket.Dial(path, profilingDialTimeout) }, durationSeconds)
+}
+
+// captureBundle requests the profiles over the connections returned by dial
+func captureBundle(writer io.Writer, process string, dial func() (net.Conn, error), durationSeconds int) (manifest Manifest, err error) {
+	if durationSeconds < 1 || durationSeconds > MaxDurationSeconds {
+		return manifest, fmt.Errorf("the duration must be between 1 and %d seconds", MaxDurationSeconds)
+	}
+	manifest = Manifest{
+		AgentVersion:    version.Version,
+		Process:         process,
+		CreatedAt:       time.Now().UTC(),
+		DurationSeconds: durationSeconds,
+	}
+
+	gzipWriter := gzip.NewWriter(writer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	client := &http.Client{
+		Timeout: time.Duration(durationSeconds)*time.Second + captureTimeoutMargin,
+		Transport: &http.Transport{
+			DialContext: func(context.Context, string, string) (net.Conn, error) { return dial() },
+		},
+	}
+	for _, p := range profiles {
+		path := p.path
+		if p.timed {
+			path = fmt.Sprintf("%v?seconds=%d", path, durationSeconds)
+		}
+		content, err := fetch(client, "http://localhost"+path)
+		if err != nil {
+			return manifest, fmt.Errorf("failed to capture %v of the %v process, is profiling enabled? %v", p.name, process, err)
+		}
+		if err = addFile(tarWriter, p.name, content, manifest.CreatedAt); err != nil {
+			return manifest, err
+		}
+		manifest.Profiles = append(manifest.Profiles, p.name)
+	}
+
+	manifestContent, err := jsonutil.Marshal(manifest)
+	if err != nil {
+		return manifest, err
+	}
+	if err = addFile(tarWriter, manifestName, []byte(manifestContent), manifest.CreatedAt); err != nil {
+		return manifest, err
+	}
+	if err = tarWriter.Close(); err != nil {
+		return manifest, err
+	}
+	return manifest, gzipWriter.Close()
+}
+
+func fetch(client *http.Client, url string) ([]byte, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v: %s", response.Status, content)
+	}
+	return content, nil
+}
+
+func addFile(tarWriter *tar.Writer, name string, content []byte, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), ModTime: modTime}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tarWriter.Write(content)
+	return err
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package profiling
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readBundle(t *testing.T, bundle []byte) map[string][]byte {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(bundle))
+	assert.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+	files := map[string][]byte{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		content, err := ioutil.ReadAll(tarReader)
+		assert.NoError(t, err)
+		files[header.Name] = content
+	}
+	return files
+}
+
+// dialServer returns a dial function connecting to the test server
+func dialServer(server *httptest.Server) func() (net.Conn, error) {
+	address := server.Listener.Addr().String()
+	return func() (net.Conn, error) { return net.Dial("tcp", address) }
+}
+
+func TestSocketPath(t *testing.T) {
+	corePath, err := SocketPath(CoreProcess)
+	assert.NoError(t, err)
+	workerPath, err := SocketPath(WorkerProcess)
+	assert.NoError(t, err)
+	assert.NotEqual(t, corePath, workerPath)
+
+	_, err = SocketPath("updater")
+	assert.Error(t, err)
+}
+
+func TestCaptureBundle(t *testing.T) {
+	server := httptest.NewServer(newMux())
+	defer server.Close()
+
+	var bundle bytes.Buffer
+	manifest, err := captureBundle(&bundle, WorkerProcess, dialServer(server), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, WorkerProcess, manifest.Process)
+	assert.Equal(t, []string{"cpu.pprof", "heap.pprof", "allocs.pprof", "goroutine.txt"}, manifest.Profiles)
+	files := readBundle(t, bundle.Bytes())
+	for _, name := range manifest.Profiles {
+		assert.NotEmpty(t, files[name], name)
+	}
+	assert.Contains(t, string(files["goroutine.txt"]), "goroutine")
+	var bundledManifest Manifest
+	assert.NoError(t, json.Unmarshal(files[manifestName], &bundledManifest))
+	assert.Equal(t, manifest.Profiles, bundledManifest.Profiles)
+}
+
+func TestCaptureBundle_InvalidDuration(t *testing.T) {
+	dial := func() (net.Conn, error) { return nil, errors.New("not dialed") }
+	_, err := captureBundle(&bytes.Buffer{}, CoreProcess, dial, MaxDurationSeconds+1)
+
+	assert.Error(t, err)
+}
+
+func TestCaptureBundle_EndpointUnavailable(t *testing.T) {
+	server := httptest.NewServer(newMux())
+	server.Close()
+
+	_, err := captureBundle(&bytes.Buffer{}, CoreProcess, dialServer(server), 1)
+
+	assert.Error(t, err)
+}
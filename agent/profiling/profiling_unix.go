@@ -0,0 +1,46 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package profiling
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/ipc/localsocket"
+)
+
+// socketDir is the directory of the profiling sockets, only root can traverse it
+var socketDir = func() string { return filepath.Join(appconfig.DefaultDataStorePath, "profiling") }
+
+func socketPath(process string) string {
+	return filepath.Join(socketDir(), process+".sock")
+}
+
+// listenProfilingSocket listens on a unix domain socket only root can connect to, the profiling directory left with
+// broader permissions is restricted again
+func listenProfilingSocket(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, appconfig.ReadWriteExecuteAccess); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(dir, appconfig.ReadWriteExecuteAccess); err != nil {
+		return nil, err
+	}
+	return localsocket.Listen(path)
+}
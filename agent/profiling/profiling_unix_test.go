@@ -0,0 +1,78 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package profiling
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupSocketDir points the profiling sockets to a temporary directory
+func setupSocketDir(t *testing.T) string {
+	dir := filepath.Join(t.TempDir(), "profiling")
+	originalSocketDir := socketDir
+	t.Cleanup(func() { socketDir = originalSocketDir })
+	socketDir = func() string { return dir }
+	return dir
+}
+
+func TestStart_ServesOnRootOnlySocket(t *testing.T) {
+	dir := setupSocketDir(t)
+	// a directory left with broader permissions is restricted again
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+
+	Start(log.NewMockLog(), appconfig.ProfilingCfg{Enabled: true}, WorkerProcess)
+
+	dirInfo, err := os.Stat(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(appconfig.ReadWriteExecuteAccess), dirInfo.Mode().Perm())
+	socketInfo, err := os.Stat(filepath.Join(dir, WorkerProcess+".sock"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.ModeSocket, socketInfo.Mode()&os.ModeSocket)
+	assert.Equal(t, os.FileMode(appconfig.ReadWriteAccess), socketInfo.Mode().Perm())
+
+	var bundle bytes.Buffer
+	manifest, err := CaptureBundle(&bundle, WorkerProcess, 1)
+	assert.NoError(t, err)
+	assert.Contains(t, string(readBundle(t, bundle.Bytes())["goroutine.txt"]), "goroutine")
+	assert.Equal(t, WorkerProcess, manifest.Process)
+}
+
+func TestStart_Disabled(t *testing.T) {
+	dir := setupSocketDir(t)
+
+	Start(log.NewMockLog(), appconfig.ProfilingCfg{}, CoreProcess)
+
+	assert.NoDirExists(t, dir)
+}
+
+func TestListenProfilingSocket_AlreadyServed(t *testing.T) {
+	path := filepath.Join(setupSocketDir(t), CoreProcess+".sock")
+	listener, err := listenProfilingSocket(path)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	_, err = listenProfilingSocket(path)
+
+	assert.Error(t, err)
+}
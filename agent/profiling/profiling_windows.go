@@ -0,0 +1,33 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package profiling
+
+import (
+	"net"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/ipc/localsocket"
+)
+
+func socketPath(process string) string {
+	return `\\.\pipe\` + appconfig.InstanceServiceName("amazon-ssm-agent-pprof-"+process)
+}
+
+// listenProfilingSocket listens on a named pipe only the local system and the administrators can connect to
+func listenProfilingSocket(path string) (net.Listener, error) {
+	return localsocket.Listen(path)
+}
@@ -47,6 +47,17 @@ type SendReplyPayload struct {
 	RuntimeStatus       map[string]*contracts.PluginRuntimeStatus `json:"runtimeStatus"`
 }
 
+// SendOutputPayload represents the json structure of the output a running plugin streams to MGS.
+type SendOutputPayload struct {
+	AdditionalInfo contracts.AdditionalInfo `json:"additionalInfo"`
+	PluginID       string                   `json:"pluginID"`
+	Name           string                   `json:"name"`
+	Sequence       int                      `json:"sequence"`
+	StandardOutput string                   `json:"standardOutput"`
+	StandardError  string                   `json:"standardError"`
+	Truncated      bool                     `json:"truncated"`
+}
+
 // getCommandID gets CommandID from given MessageID
 func getCommandID(messageID string) string {
 	// MdsMessageID is in the format of : aws.ssm.CommandId.InstanceId
@@ -23,6 +23,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/framework/moduleguard"
 	mdsService "github.com/aws/amazon-ssm-agent/agent/runcommand/mds"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
 	"github.com/aws/aws-sdk-go/service/ssmmds"
@@ -55,7 +56,7 @@ func (s *RunCommandService) ModuleExecute() (err error) {
 	log := s.context.Log()
 	defer func() {
 		if msg := recover(); msg != nil {
-			log.Errorf("run command ModuleExecute run panic: %v", msg)
+			moduleguard.ReportPanic(log, s.name, msg, debug.Stack())
 		}
 	}()
 	log.Info("Starting document processing engine...")
@@ -105,6 +106,10 @@ func (s *RunCommandService) listenReply(resultChan chan contracts.DocumentResult
 				}
 			}()
 
+			if res.OutputFrame != nil {
+				// output frames are only streamed to the message gateway
+				return
+			}
 			if res.LastPlugin != "" {
 				log.Infof("received plugin: %v result from Processor", res.LastPlugin)
 			} else {
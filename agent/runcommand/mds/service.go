@@ -19,7 +19,6 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	"os"
 	"path"
 	"strings"
 	"sync"
@@ -27,11 +26,13 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
-	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/circuitbreaker"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/ratelimit"
+	"github.com/aws/amazon-ssm-agent/agent/statestore"
 	"github.com/aws/amazon-ssm-agent/common/identity"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -82,6 +83,8 @@ type sdkService struct {
 	m                sync.Mutex
 	sendSdkRequest   SendSdkRequest
 	cancelSdkRequest CancelSdkRequest
+	// longPollClient sends the GetMessages requests when their timeout differs from the other requests
+	longPollClient *http.Client
 }
 
 var clientBasedErrorMessages, serverBasedErrorMessages []string
@@ -103,14 +106,15 @@ func NewService(context context.T, connectionTimeout time.Duration) Service {
 	// capture Transport so we can use it to cancel requests
 	tr := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
-		Dial: (&net.Dialer{
+		DialContext: network.GetDialContext(context.AppConfig(), &net.Dialer{
 			Timeout:   connectionTimeout,
 			KeepAlive: 0,
-		}).Dial,
+		}),
 		TLSHandshakeTimeout: 10 * time.Second,
 		TLSClientConfig:     network.GetDefaultTLSConfig(context.Log(), context.AppConfig()),
 	}
-	config.HTTPClient = &http.Client{Transport: tr, Timeout: connectionTimeout}
+	breakerTransport := circuitbreaker.NewTransport(context.Log(), agentConfig, ratelimit.NewTransport(context.Log(), agentConfig, tr))
+	config.HTTPClient = &http.Client{Transport: breakerTransport, Timeout: connectionTimeout}
 
 	sess := session.New(config)
 	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(agentConfig.Agent.Name, agentConfig.Agent.Version))
@@ -133,7 +137,12 @@ func NewService(context context.T, connectionTimeout time.Duration) Service {
 		trans.CancelRequest(req.HTTPRequest)
 	}
 
-	return NewMdsSdkService(context, msgSvc, tr, sendMdsSdkRequest, cancelMdsSDKRequest)
+	service := &sdkService{context: context, sdk: msgSvc, tr: tr, sendSdkRequest: sendMdsSdkRequest, cancelSdkRequest: cancelMdsSDKRequest}
+	if longPollTimeout := time.Duration(agentConfig.Mds.LongPollTimeoutMillis) * time.Millisecond; longPollTimeout > 0 && longPollTimeout != connectionTimeout {
+		// the long poll client shares the transport and its connections with the other requests
+		service.longPollClient = &http.Client{Transport: breakerTransport, Timeout: longPollTimeout}
+	}
+	return service
 }
 
 func NewMdsSdkService(context context.T, msgSvc ssmmdsiface.SSMMDSAPI, tr *http.Transport, sendMdsSdkRequest SendSdkRequest, cancelMdsSDKRequest CancelSdkRequest) Service {
@@ -152,6 +161,9 @@ func (mds *sdkService) GetMessages(log log.T, instanceID string) (messages *ssmm
 	log.Debug("Calling GetMessages with params", params)
 	requestTime := time.Now()
 	req, messages := mds.sdk.GetMessagesRequest(params)
+	if mds.longPollClient != nil {
+		req.Config.HTTPClient = mds.longPollClient
+	}
 	if requestErr := mds.sendRequest(req); requestErr != nil {
 		log.Debug(requestErr)
 		if isErrorUnexpected(log, requestErr, requestTime, time.Now()) {
@@ -291,41 +303,33 @@ func (mds *sdkService) DeleteMessage(log log.T, messageID string) (err error) {
 	return
 }
 
-// LoadFailedReplies loads SendReplyInput objects from local replies folder on disk
+// LoadFailedReplies loads SendReplyInput objects from local replies folder
 func (mds *sdkService) LoadFailedReplies(log log.T) []string {
 	log.Debug("Checking Replies folder for failed sent replies")
-	shortInstanceID, _ := mds.context.Identity().ShortInstanceID()
-	absoluteDirPath := path.Join(appconfig.DefaultDataStorePath,
-		shortInstanceID,
-		appconfig.RepliesRootDirName)
-
-	files, err := fileutil.GetFileNames(absoluteDirPath)
+	files, err := failedReplyFolder(mds.context).Names()
 	if err != nil {
-		log.Errorf("encountered error %v while listing replies in %v", err, absoluteDirPath)
+		log.Errorf("encountered error %v while listing replies", err)
 	}
 	return files
 }
 
-// DeleteFailedReply deletes failed reply from local replies folder on disk
+// DeleteFailedReply deletes failed reply from local replies folder
 func (mds *sdkService) DeleteFailedReply(log log.T, fileName string) {
-	absoluteFileName := getFailedReplyLocation(mds.context.Identity(), fileName)
-	if fileutil.Exists(absoluteFileName) {
-		err := fileutil.DeleteFile(absoluteFileName)
-		if err != nil {
-			log.Errorf("encountered error %v while deleting file %v", err, absoluteFileName)
-		} else {
-			log.Debugf("successfully deleted file %v", absoluteFileName)
-		}
+	if err := failedReplyFolder(mds.context).Delete(fileName); err != nil {
+		log.Errorf("encountered error %v while deleting reply %v", err, fileName)
+	} else {
+		log.Debugf("successfully deleted reply %v", fileName)
 	}
 }
 
-// PersistFailedReply saves SendReplyInput object to local replies folder on disk
+// PersistFailedReply saves SendReplyInput object to local replies folder
 func (mds *sdkService) PersistFailedReply(log log.T, sendReply ssmmds.SendReplyInput) (err error) {
 	content, err := jsonutil.Marshal(sendReply)
 	if err != nil {
 		log.Errorf("encountered error with message %v while marshalling %v to string", err, sendReply)
 	} else {
-		files, _ := fileutil.GetFileNames(GetFailedReplyDirectory(mds.context.Identity()))
+		folder := failedReplyFolder(mds.context)
+		files, _ := folder.Names()
 		for _, file := range files {
 			if strings.HasPrefix(file, *sendReply.ReplyId) {
 				log.Debugf("Reply %v already saved in file %v, skipping", *sendReply.ReplyId, file)
@@ -334,13 +338,12 @@ func (mds *sdkService) PersistFailedReply(log log.T, sendReply ssmmds.SendReplyI
 		}
 		t := time.Now().UTC()
 		fileName := fmt.Sprintf("%v_%v", *sendReply.ReplyId, t.Format("2006-01-02T15-04-05"))
-		absoluteFileName := getFailedReplyLocation(mds.context.Identity(), fileName)
 
-		log.Tracef("persisting reply %v in file %v", jsonutil.Indent(content), absoluteFileName)
-		if s, err := fileutil.WriteIntoFileWithPermissions(absoluteFileName, jsonutil.Indent(content), os.FileMode(int(appconfig.ReadWriteAccess))); s && err == nil {
-			log.Debugf("successfully persisted reply in %v", absoluteFileName)
+		log.Tracef("persisting reply %v in %v", jsonutil.Indent(content), fileName)
+		if err := folder.Write(fileName, []byte(jsonutil.Indent(content))); err == nil {
+			log.Debugf("successfully persisted reply in %v", fileName)
 		} else {
-			log.Debugf("persisting reply in %v failed with error %v", absoluteFileName, err)
+			log.Debugf("persisting reply in %v failed with error %v", fileName, err)
 		}
 	}
 	return err
@@ -348,19 +351,20 @@ func (mds *sdkService) PersistFailedReply(log log.T, sendReply ssmmds.SendReplyI
 
 // GetFailedReply load SendReplyInput object from replies folder given the reply id of the object
 func (mds *sdkService) GetFailedReply(log log.T, fileName string) (*ssmmds.SendReplyInput, error) {
-	absoluteFileName := getFailedReplyLocation(mds.context.Identity(), fileName)
-
 	var sendReply ssmmds.SendReplyInput
-	err := jsonutil.UnmarshalFile(absoluteFileName, &sendReply)
+	content, err := failedReplyFolder(mds.context).Read(fileName)
+	if err == nil {
+		err = jsonutil.Unmarshal(string(content), &sendReply)
+	}
 	if err != nil {
-		log.Errorf("encountered error with message %v while reading reply input from file - %v", err, absoluteFileName)
+		log.Errorf("encountered error with message %v while reading reply input - %v", err, fileName)
 	} else {
-		//logging reply as read from the file
+		//logging reply as read from the replies folder
 		jsonString, err := jsonutil.Marshal(sendReply)
 		if err != nil {
 			log.Errorf("encountered error with message %v while marshalling %v to string", err, sendReply)
 		} else {
-			log.Tracef("Send reply input read from file-system - %v", jsonutil.Indent(jsonString))
+			log.Tracef("Send reply input read from replies folder - %v", jsonutil.Indent(jsonString))
 		}
 	}
 	return &sendReply, err
@@ -401,12 +405,13 @@ func (mds *sdkService) clearRequest() {
 	mds.storeRequest(nil)
 }
 
-// getFailedReplyLocation returns path to reply file
-func getFailedReplyLocation(identity identity.IAgentIdentity, fileName string) string {
-	return path.Join(GetFailedReplyDirectory(identity), fileName)
+// failedReplyFolder returns the replies folder in the configured state backend
+var failedReplyFolder = func(context context.T) statestore.Folder {
+	shortInstanceID, _ := context.Identity().ShortInstanceID()
+	return statestore.NewFolder(context.AppConfig(), shortInstanceID, appconfig.RepliesRootDirName)
 }
 
-// GetFailedReplyDirectory returns path to replies folder
+// GetFailedReplyDirectory returns path to replies folder of the file state backend
 func GetFailedReplyDirectory(identity identity.IAgentIdentity) string {
 	shortInstanceID, _ := identity.ShortInstanceID()
 	return path.Join(appconfig.DefaultDataStorePath,
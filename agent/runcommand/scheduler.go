@@ -21,6 +21,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/framework/moduleguard"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
 	"github.com/carlescere/scheduler"
@@ -62,8 +63,7 @@ func (s *RunCommandService) messagePollLoop() {
 	log := s.context.Log()
 	defer func() {
 		if msg := recover(); msg != nil {
-			log.Errorf("message poll loop panic: %v", msg)
-			log.Errorf("Stacktrace:\n%s", debug.Stack())
+			moduleguard.ReportPanic(log, s.name, msg, debug.Stack())
 		}
 	}()
 	s.messagePollWaitGroup.Add(1)
@@ -139,7 +139,7 @@ func NewService(ctx context.T,
 
 	shortInstanceId, _ := identity.ShortInstanceID()
 
-	orchestrationRootDir := filepath.Join(appconfig.DefaultDataStorePath, shortInstanceId, appconfig.DefaultDocumentRootDirName, config.Agent.OrchestrationRootDir)
+	orchestrationRootDir := filepath.Join(appconfig.OrchestrationStorePath, shortInstanceId, appconfig.DefaultDocumentRootDirName, config.Agent.OrchestrationRootDir)
 
 	// create a stop policy where we will stop after 10 consecutive errors and if time period expires.
 	stopPolicy := newStopPolicy(serviceName)
@@ -153,7 +153,9 @@ func NewService(ctx context.T,
 
 	sendResponse := func(messageID string, res contracts.DocumentResult) {
 		pluginID := res.LastPlugin
-		processSendReply(log, messageID, service, FormatPayload(log, pluginID, agentInfo, res.PluginResults), stopPolicy)
+		payloadDoc := FormatPayload(log, pluginID, agentInfo, res.PluginResults)
+		payloadDoc.AdditionalInfo.CorrelationID = res.CorrelationID
+		processSendReply(log, messageID, service, payloadDoc, stopPolicy)
 	}
 
 	return &RunCommandService{
@@ -74,6 +74,7 @@ func newDocumentInfo(msg ssmmds.Message, parsedMsg messageContracts.SendCommandP
 	documentInfo.CreatedDate = *msg.CreatedDate
 	documentInfo.DocumentName = parsedMsg.DocumentName
 	documentInfo.DocumentStatus = contracts.ResultStatusInProgress
+	documentInfo.CorrelationID = contracts.NewCorrelationID()
 
 	return *documentInfo
 }
@@ -97,6 +98,7 @@ func parseCancelCommandMessage(context context.T, msg *ssmmds.Message, messagesO
 	documentInfo.DocumentID = documentInfo.CommandID
 	documentInfo.RunID = times.ToIsoDashUTC(times.DefaultClock.Now())
 	documentInfo.DocumentStatus = contracts.ResultStatusInProgress
+	documentInfo.CorrelationID = contracts.NewCorrelationID()
 
 	cancelCommand := new(contracts.CancelCommandInfo)
 	cancelCommand.Payload = *msg.Payload
@@ -181,6 +183,7 @@ func parseSendCommandMessage(context context.T, msg *ssmmds.Message, messagesOrc
 		documentType = contracts.SendCommand
 	}
 	documentInfo := newDocumentInfo(*msg, parsedMessage)
+	log.Infof("Assigned correlation id %v to message %v", documentInfo.CorrelationID, documentInfo.MessageID)
 	parserInfo := docparser.DocumentParserInfo{
 		OrchestrationDir: messageOrchestrationDirectory,
 		S3Bucket:         parsedMessage.OutputS3BucketName,
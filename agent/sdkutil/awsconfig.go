@@ -20,6 +20,8 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/circuitbreaker"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/ratelimit"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil/retryer"
 	"github.com/aws/amazon-ssm-agent/common/identity/endpoint"
 	"github.com/aws/aws-sdk-go/aws"
@@ -47,10 +49,15 @@ func AwsConfigForEndpoint(context context.T, endpoint, region string) (awsConfig
 	return &aws.Config{
 		Retryer:    newRetryer(),
 		SleepDelay: sleepDelay,
-		Region:     aws.String(region),
-		Endpoint:   aws.String(endpoint),
+		// lets the retryer deny the retries of the endpoints whose circuit breaker is open,
+		// the breaker transport is only installed when the circuit breaker is enabled
+		EnforceShouldRetryCheck: aws.Bool(context.AppConfig().Network.CircuitBreaker.Enabled),
+		Region:                  aws.String(region),
+		Endpoint:                aws.String(endpoint),
 		HTTPClient: &http.Client{
-			Transport: network.GetDefaultTransport(context.Log(), context.AppConfig()),
+			Transport: circuitbreaker.NewTransport(context.Log(), context.AppConfig(),
+				ratelimit.NewTransport(context.Log(), context.AppConfig(),
+					network.GetDefaultTransport(context.Log(), context.AppConfig()))),
 		},
 		Credentials: context.Identity().Credentials(),
 	}
@@ -0,0 +1,40 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sdkutil
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAwsConfigForEndpoint_ShouldRetryCheckEnforcedWithCircuitBreaker(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Network.CircuitBreaker.Enabled = true
+
+	awsConfig := AwsConfigForEndpoint(contextmocks.NewMockDefaultWithConfig(config), "ssm.us-east-1.amazonaws.com", "us-east-1")
+
+	assert.True(t, *awsConfig.EnforceShouldRetryCheck)
+}
+
+func TestAwsConfigForEndpoint_ShouldRetryCheckNotEnforcedWithoutCircuitBreaker(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Network.CircuitBreaker.Enabled = false
+
+	awsConfig := AwsConfigForEndpoint(contextmocks.NewMockDefaultWithConfig(config), "ssm.us-east-1.amazonaws.com", "us-east-1")
+
+	assert.False(t, *awsConfig.EnforceShouldRetryCheck)
+}
@@ -0,0 +1,253 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package circuitbreaker limits the calls and the retries to the AWS endpoints failing repeatedly, so that a regional
+// outage of a service does not make the agent retry its calls without bounds.
+package circuitbreaker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// States of a breaker
+const (
+	// StateClosed lets the calls through
+	StateClosed = "Closed"
+	// StateOpen rejects the calls until the open time elapses
+	StateOpen = "Open"
+	// StateHalfOpen lets a single probe call through, its result closes or opens the breaker again
+	StateHalfOpen = "HalfOpen"
+)
+
+const (
+	// retryCost is the number of tokens a retry takes from the retry budget, a successful call gives back one token
+	retryCost = 5
+)
+
+var (
+	breakers     = make(map[string]*Breaker)
+	breakersLock sync.Mutex
+
+	timeNow = time.Now
+)
+
+// Status is the state of the breaker of an endpoint
+type Status struct {
+	Endpoint            string
+	State               string
+	ConsecutiveFailures int
+	// LastFailure is the last error returned by the endpoint
+	LastFailure string `json:",omitempty"`
+	// OpenUntil is the time an open breaker lets a probe call through
+	OpenUntil time.Time `json:",omitempty"`
+	// RejectedCalls is the number of calls rejected since the breaker last opened
+	RejectedCalls int
+	// RetryBudget is the number of retries the endpoint currently allows
+	RetryBudget int
+}
+
+// OpenError is returned for the calls rejected by an open breaker
+type OpenError struct {
+	Endpoint  string
+	OpenUntil time.Time
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("calls to %v are suspended until %v after repeated failures",
+		e.Endpoint, e.OpenUntil.UTC().Format(time.RFC3339))
+}
+
+// Breaker guards the calls to an endpoint
+type Breaker struct {
+	endpoint string
+	config   appconfig.CircuitBreakerCfg
+	log      log.T
+
+	lock                sync.Mutex
+	state               string
+	consecutiveFailures int
+	lastFailure         string
+	openDuration        time.Duration
+	openedAt            time.Time
+	probing             bool
+	rejectedCalls       int
+	retryTokens         int
+}
+
+// ForEndpoint returns the breaker shared by the calls of the agent process to the endpoint, a host or host:port.
+// The breaker is created with the given configuration by the first call.
+func ForEndpoint(log log.T, config appconfig.CircuitBreakerCfg, endpoint string) *Breaker {
+	endpoint = strings.ToLower(endpoint)
+	breakersLock.Lock()
+	defer breakersLock.Unlock()
+	if breaker, found := breakers[endpoint]; found {
+		return breaker
+	}
+	breaker := &Breaker{
+		endpoint:     endpoint,
+		config:       config,
+		log:          log,
+		state:        StateClosed,
+		openDuration: time.Duration(config.OpenSeconds) * time.Second,
+		retryTokens:  config.RetryBudget * retryCost,
+	}
+	breakers[endpoint] = breaker
+	return breaker
+}
+
+// Lookup returns the breaker of the endpoint, nil when no call guarded by a breaker was made to the endpoint
+func Lookup(endpoint string) *Breaker {
+	breakersLock.Lock()
+	defer breakersLock.Unlock()
+	return breakers[strings.ToLower(endpoint)]
+}
+
+// States returns the status of the breakers of the agent process ordered by endpoint
+func States() []Status {
+	breakersLock.Lock()
+	all := make([]*Breaker, 0, len(breakers))
+	for _, breaker := range breakers {
+		all = append(all, breaker)
+	}
+	breakersLock.Unlock()
+
+	states := make([]Status, 0, len(all))
+	for _, breaker := range all {
+		states = append(states, breaker.Status())
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Endpoint < states[j].Endpoint })
+	return states
+}
+
+// Status returns the status of the breaker
+func (b *Breaker) Status() Status {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	status := Status{
+		Endpoint:            b.endpoint,
+		State:               b.state,
+		ConsecutiveFailures: b.consecutiveFailures,
+		LastFailure:         b.lastFailure,
+		RejectedCalls:       b.rejectedCalls,
+		RetryBudget:         b.retryTokens / retryCost,
+	}
+	if b.state != StateClosed {
+		status.OpenUntil = b.openedAt.Add(b.openDuration)
+	}
+	return status
+}
+
+// Allow returns an OpenError when the call has to be rejected. Each allowed call has to be followed by
+// one of Success, Failure or Ignore once its result is known.
+func (b *Breaker) Allow() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state {
+	case StateOpen:
+		if timeNow().Before(b.openedAt.Add(b.openDuration)) {
+			b.rejectedCalls++
+			return &OpenError{Endpoint: b.endpoint, OpenUntil: b.openedAt.Add(b.openDuration)}
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		b.log.Infof("Sending a probe call to %v", b.endpoint)
+	case StateHalfOpen:
+		// the other calls wait for the result of the probe call
+		if b.probing {
+			b.rejectedCalls++
+			return &OpenError{Endpoint: b.endpoint, OpenUntil: b.openedAt.Add(b.openDuration)}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success records a successful call, it closes the breaker and refills the retry budget
+func (b *Breaker) Success() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.consecutiveFailures = 0
+	if b.retryTokens < b.config.RetryBudget*retryCost {
+		b.retryTokens++
+	}
+	if b.state != StateClosed {
+		b.log.Infof("Calls to %v resumed after %v rejected calls", b.endpoint, b.rejectedCalls)
+		b.state = StateClosed
+		b.probing = false
+		b.rejectedCalls = 0
+		b.openDuration = time.Duration(b.config.OpenSeconds) * time.Second
+	}
+}
+
+// Failure records a call failed because of the endpoint, e.g. a server, throttling or network error
+func (b *Breaker) Failure(err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.consecutiveFailures++
+	if err != nil {
+		b.lastFailure = err.Error()
+	}
+	switch b.state {
+	case StateHalfOpen:
+		// the endpoint still fails, the breaker stays open longer
+		b.openDuration *= 2
+		if maxOpenDuration := time.Duration(b.config.MaxOpenSeconds) * time.Second; b.openDuration > maxOpenDuration {
+			b.openDuration = maxOpenDuration
+		}
+		b.open()
+	case StateClosed:
+		if b.consecutiveFailures >= b.config.FailureThreshold {
+			b.open()
+		}
+	}
+}
+
+// Ignore records a call whose result tells nothing about the health of the endpoint, e.g. a cancelled call
+func (b *Breaker) Ignore() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state == StateHalfOpen {
+		b.probing = false
+	}
+}
+
+// AllowRetry returns true when a failed call can be retried, the retry takes from the retry budget
+func (b *Breaker) AllowRetry() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state != StateClosed {
+		return false
+	}
+	if b.retryTokens < retryCost {
+		b.log.Debugf("Retry budget of %v exhausted, the call is not retried", b.endpoint)
+		return false
+	}
+	b.retryTokens -= retryCost
+	return true
+}
+
+// open rejects the calls for the open duration, the lock has to be held by the caller
+func (b *Breaker) open() {
+	b.state = StateOpen
+	b.probing = false
+	b.openedAt = timeNow()
+	b.log.Warnf("Suspending the calls to %v for %v after %v consecutive failures, last error: %v",
+		b.endpoint, b.openDuration, b.consecutiveFailures, b.lastFailure)
+}
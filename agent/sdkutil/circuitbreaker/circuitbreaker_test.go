@@ -0,0 +1,168 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package circuitbreaker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var testConfig = appconfig.CircuitBreakerCfg{
+	Enabled:          true,
+	FailureThreshold: 3,
+	OpenSeconds:      10,
+	MaxOpenSeconds:   30,
+	RetryBudget:      2,
+}
+
+func setTime(t *testing.T, now time.Time) {
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+}
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	setTime(t, now)
+	breaker := ForEndpoint(logmocks.NewMockLog(), testConfig, "ssm.opens.amazonaws.com")
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, breaker.Allow())
+		breaker.Failure(errors.New("503 Service Unavailable"))
+	}
+	// a success resets the consecutive failures
+	assert.NoError(t, breaker.Allow())
+	breaker.Success()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, breaker.Allow())
+		breaker.Failure(errors.New("503 Service Unavailable"))
+	}
+
+	err := breaker.Allow()
+	assert.IsType(t, &OpenError{}, err)
+	status := breaker.Status()
+	assert.Equal(t, StateOpen, status.State)
+	assert.Equal(t, 3, status.ConsecutiveFailures)
+	assert.Equal(t, "503 Service Unavailable", status.LastFailure)
+	assert.Equal(t, now.Add(10*time.Second), status.OpenUntil)
+	assert.Equal(t, 1, status.RejectedCalls)
+	assert.False(t, breaker.AllowRetry())
+}
+
+func TestBreaker_ProbeClosesOrReopens(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	setTime(t, now)
+	breaker := ForEndpoint(logmocks.NewMockLog(), testConfig, "ssm.probe.amazonaws.com")
+	for i := 0; i < 3; i++ {
+		breaker.Allow()
+		breaker.Failure(errors.New("dial tcp: i/o timeout"))
+	}
+
+	// the failed probe doubles the open time
+	setTime(t, now.Add(10*time.Second))
+	assert.NoError(t, breaker.Allow())
+	assert.Equal(t, StateHalfOpen, breaker.Status().State)
+	assert.Error(t, breaker.Allow(), "only one probe call is let through")
+	breaker.Failure(errors.New("dial tcp: i/o timeout"))
+	assert.Equal(t, now.Add(30*time.Second), breaker.Status().OpenUntil)
+
+	// the open time is capped
+	setTime(t, now.Add(30*time.Second))
+	assert.NoError(t, breaker.Allow())
+	breaker.Failure(errors.New("dial tcp: i/o timeout"))
+	assert.Equal(t, now.Add(60*time.Second), breaker.Status().OpenUntil)
+
+	// an ignored probe lets another probe through
+	setTime(t, now.Add(60*time.Second))
+	assert.NoError(t, breaker.Allow())
+	breaker.Ignore()
+	assert.NoError(t, breaker.Allow())
+	breaker.Success()
+
+	status := breaker.Status()
+	assert.Equal(t, StateClosed, status.State)
+	assert.Equal(t, 0, status.ConsecutiveFailures)
+	assert.Equal(t, 0, status.RejectedCalls)
+	assert.True(t, status.OpenUntil.IsZero())
+}
+
+func TestBreaker_RetryBudget(t *testing.T) {
+	breaker := ForEndpoint(logmocks.NewMockLog(), testConfig, "ssm.budget.amazonaws.com")
+	assert.True(t, breaker.AllowRetry())
+	assert.True(t, breaker.AllowRetry())
+	assert.False(t, breaker.AllowRetry())
+	assert.Equal(t, 0, breaker.Status().RetryBudget)
+
+	// the successful calls refill the budget
+	for i := 0; i < retryCost; i++ {
+		breaker.Success()
+	}
+	assert.Equal(t, 1, breaker.Status().RetryBudget)
+	assert.True(t, breaker.AllowRetry())
+}
+
+func TestTransport_RecordsResults(t *testing.T) {
+	status := http.StatusOK
+	errorType := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if errorType != "" {
+			w.Header().Set(errorTypeHeader, errorType)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Network.CircuitBreaker = testConfig
+	client := &http.Client{Transport: NewTransport(logmocks.NewMockLog(), appConfig, http.DefaultTransport)}
+
+	// client errors do not open the breaker
+	status = http.StatusForbidden
+	for i := 0; i < 3; i++ {
+		_, err := client.Get(server.URL)
+		assert.NoError(t, err)
+	}
+	breaker := Lookup(serverUrl.Host)
+	assert.Equal(t, StateClosed, breaker.Status().State)
+
+	status, errorType = http.StatusBadRequest, "ThrottlingException:"
+	_, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	status, errorType = http.StatusServiceUnavailable, ""
+	for i := 0; i < 2; i++ {
+		_, err = client.Get(server.URL)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, StateOpen, breaker.Status().State)
+	assert.Equal(t, "503 Service Unavailable", breaker.Status().LastFailure)
+
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "are suspended until")
+	assert.Contains(t, States(), breaker.Status())
+}
+
+func TestNewTransport_Disabled(t *testing.T) {
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Network.CircuitBreaker.Enabled = false
+	assert.Equal(t, http.DefaultTransport, NewTransport(logmocks.NewMockLog(), appConfig, http.DefaultTransport))
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package circuitbreaker
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// errorTypeHeader is the header in which the AWS JSON protocol services return the type of their errors
+const errorTypeHeader = "X-Amzn-Errortype"
+
+// transport guards the requests sent through its delegate with the breakers of their endpoints
+type transport struct {
+	log      log.T
+	config   appconfig.CircuitBreakerCfg
+	delegate http.RoundTripper
+}
+
+// NewTransport returns a round tripper guarding the requests with the breakers of their endpoints,
+// the delegate is returned when the circuit breakers are disabled
+func NewTransport(log log.T, appConfig appconfig.SsmagentConfig, delegate http.RoundTripper) http.RoundTripper {
+	if !appConfig.Network.CircuitBreaker.Enabled {
+		return delegate
+	}
+	return &transport{
+		log:      log,
+		config:   appConfig.Network.CircuitBreaker,
+		delegate: delegate,
+	}
+}
+
+// RoundTrip sends the request unless the breaker of its endpoint is open and records the result
+func (t *transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	breaker := ForEndpoint(t.log, t.config, request.URL.Host)
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	response, err := t.delegate.RoundTrip(request)
+	switch {
+	case err != nil && request.Context().Err() != nil:
+		// the caller cancelled the request or its timeout, e.g. the timeout of a long poll, has expired
+		breaker.Ignore()
+	case err != nil:
+		breaker.Failure(err)
+	case isEndpointFailure(response):
+		breaker.Failure(errors.New(strings.TrimSpace(response.Status + " " + response.Header.Get(errorTypeHeader))))
+	default:
+		// the other client errors are caused by the requests, the endpoint is healthy
+		breaker.Success()
+	}
+	return response, err
+}
+
+// isEndpointFailure returns true for the server errors and the throttling errors
+func isEndpointFailure(response *http.Response) bool {
+	if response.StatusCode >= http.StatusInternalServerError || response.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return response.StatusCode == http.StatusBadRequest &&
+		strings.Contains(strings.ToLower(response.Header.Get(errorTypeHeader)), "throttl")
+}
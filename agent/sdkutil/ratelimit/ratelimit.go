@@ -0,0 +1,142 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ratelimit caps the rate of the calls the agent makes to the AWS services, for the fleets large enough
+// for the aggregated agent traffic to throttle the other workloads of the account.
+package ratelimit
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+var (
+	limiters     = make(map[string]*limiter)
+	limitersLock sync.Mutex
+
+	timeNow = time.Now
+)
+
+// limiter is a token bucket shared by the calls of the agent process to a service
+type limiter struct {
+	lock   sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// limiterFor returns the limiter of the service, it is created with the given limit by the first call
+func limiterFor(limit appconfig.ServiceRateLimit) *limiter {
+	limitersLock.Lock()
+	defer limitersLock.Unlock()
+	if existing, found := limiters[limit.Service]; found {
+		return existing
+	}
+	created := &limiter{
+		rate:   limit.CallsPerSecond,
+		burst:  float64(limit.Burst),
+		tokens: float64(limit.Burst),
+		last:   timeNow(),
+	}
+	limiters[limit.Service] = created
+	return created
+}
+
+// reserve takes a token and returns the time to wait before the call can be made. The tokens go negative
+// while calls are waiting so that they are let through in turn.
+func (l *limiter) reserve() time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := timeNow()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel gives back the token of a call that gave up waiting
+func (l *limiter) cancel() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.tokens++
+}
+
+// transport delays the requests sent through its delegate to the rate limits of their services
+type transport struct {
+	log      log.T
+	limits   []appconfig.ServiceRateLimit
+	delegate http.RoundTripper
+}
+
+// NewTransport returns a round tripper delaying the requests to the rate limits of their services,
+// the delegate is returned when no rate limit is configured
+func NewTransport(log log.T, appConfig appconfig.SsmagentConfig, delegate http.RoundTripper) http.RoundTripper {
+	if len(appConfig.Network.RateLimits) == 0 {
+		return delegate
+	}
+	return &transport{
+		log:      log,
+		limits:   appConfig.Network.RateLimits,
+		delegate: delegate,
+	}
+}
+
+// RoundTrip waits for the turn of the request before sending it
+func (t *transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	limit, found := serviceLimit(t.limits, request.URL.Hostname())
+	if !found {
+		return t.delegate.RoundTrip(request)
+	}
+
+	serviceLimiter := limiterFor(limit)
+	if wait := serviceLimiter.reserve(); wait > 0 {
+		t.log.Debugf("Delaying the call to %v by %v to respect its rate limit", request.URL.Host, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-request.Context().Done():
+			timer.Stop()
+			serviceLimiter.cancel()
+			return nil, request.Context().Err()
+		}
+	}
+	return t.delegate.RoundTrip(request)
+}
+
+// serviceLimit returns the rate limit of the service of the host, the service is the rightmost label of the host
+// naming a service with a rate limit, e.g. s3 for bucket.s3.us-east-1.amazonaws.com. The fips endpoints share
+// the limit of their service.
+func serviceLimit(limits []appconfig.ServiceRateLimit, host string) (appconfig.ServiceRateLimit, bool) {
+	labels := strings.Split(strings.ToLower(host), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := strings.TrimSuffix(labels[i], "-fips")
+		for _, limit := range limits {
+			if limit.Service == label {
+				return limit, true
+			}
+		}
+	}
+	return appconfig.ServiceRateLimit{}, false
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func setTime(t *testing.T, now *time.Time) {
+	timeNow = func() time.Time { return *now }
+	t.Cleanup(func() { timeNow = time.Now })
+}
+
+func TestLimiter_Reserve(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	setTime(t, &now)
+	bucket := limiterFor(appconfig.ServiceRateLimit{Service: "reserve", CallsPerSecond: 2, Burst: 2})
+
+	// the burst is let through, the next calls wait for their turn
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, 500*time.Millisecond, bucket.reserve())
+	assert.Equal(t, time.Second, bucket.reserve())
+
+	// a cancelled call gives its turn back
+	bucket.cancel()
+	now = now.Add(time.Second)
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+
+	// the bucket does not fill above the burst
+	now = now.Add(time.Hour)
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, 500*time.Millisecond, bucket.reserve())
+}
+
+func TestServiceLimit(t *testing.T) {
+	limits := []appconfig.ServiceRateLimit{{Service: "ssm", CallsPerSecond: 1, Burst: 1}, {Service: "s3", CallsPerSecond: 5, Burst: 5}}
+	testCases := map[string]string{
+		"ssm.us-east-1.amazonaws.com":            "ssm",
+		"ssm-fips.us-gov-west-1.amazonaws.com":   "ssm",
+		"ssm.us-east-1.api.aws":                  "ssm",
+		"ssm.s3.us-east-1.amazonaws.com":         "s3",
+		"s3.dualstack.eu-west-1.amazonaws.com":   "s3",
+		"ec2messages.us-east-1.amazonaws.com":    "",
+		"ssmmessages.us-east-1.amazonaws.com":    "",
+		"vpce-0123.ssm.us-east-1.vpce.amazonaws": "ssm",
+	}
+	for host, service := range testCases {
+		limit, found := serviceLimit(limits, host)
+		assert.Equal(t, service != "", found, host)
+		assert.Equal(t, service, limit.Service, host)
+	}
+}
+
+type countingTransport struct {
+	calls int
+}
+
+func (c *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	c.calls++
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: request}, nil
+}
+
+func TestTransport_DelaysCalls(t *testing.T) {
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Network.RateLimits = []appconfig.ServiceRateLimit{{Service: "ec2messages", CallsPerSecond: 20, Burst: 1}}
+	delegate := &countingTransport{}
+	client := &http.Client{Transport: NewTransport(logmocks.NewMockLog(), appConfig, delegate)}
+	url := "https://ec2messages.us-east-1.amazonaws.com/"
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := client.Get(url)
+		assert.NoError(t, err)
+	}
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	assert.Equal(t, 3, delegate.calls)
+
+	// a call cancelled while it waits is not sent
+	limiterFor(appConfig.Network.RateLimits[0]).reserve()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	_, err := client.Do(request)
+	assert.Error(t, err)
+	assert.Equal(t, 3, delegate.calls)
+
+	// the calls to the services without a rate limit are sent at once
+	_, err = client.Get("https://ssm.us-east-1.amazonaws.com/")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, delegate.calls)
+}
+
+func TestNewTransport_NoLimits(t *testing.T) {
+	assert.Equal(t, http.DefaultTransport, NewTransport(logmocks.NewMockLog(), appconfig.DefaultConfig(), http.DefaultTransport))
+}
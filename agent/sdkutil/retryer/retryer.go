@@ -20,6 +20,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/circuitbreaker"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/request"
 )
@@ -41,3 +42,18 @@ func (s SsmRetryer) RetryRules(r *request.Request) time.Duration {
 	delay := int(math.Pow(2, float64(r.RetryCount))) * (rand.Intn(500) + 1000)
 	return time.Duration(delay) * time.Millisecond
 }
+
+// ShouldRetry returns true if the request should be retried, the retries are denied while the circuit breaker
+// of the endpoint is open or its retry budget is exhausted
+func (s SsmRetryer) ShouldRetry(r *request.Request) bool {
+	if !s.DefaultRetryer.ShouldRetry(r) {
+		return false
+	}
+	if r.HTTPRequest == nil || r.HTTPRequest.URL == nil || r.RetryCount >= s.MaxRetries() {
+		return true
+	}
+	if breaker := circuitbreaker.Lookup(r.HTTPRequest.URL.Host); breaker != nil {
+		return breaker.AllowRetry()
+	}
+	return true
+}
@@ -16,6 +16,7 @@ package websocketutil
 
 import (
 	"errors"
+	"net"
 	"net/http"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -45,6 +46,7 @@ func NewWebsocketUtil(logger log.T, appConfig appconfig.SsmagentConfig, dialerIn
 		d := &websocket.Dialer{
 			TLSClientConfig: network.GetDefaultTLSConfig(logger, appConfig),
 			Proxy:           http.ProxyFromEnvironment,
+			NetDialContext:  network.GetDialContext(appConfig, &net.Dialer{}),
 		}
 		websocketUtil = &WebsocketUtil{
 			dialer: d,
@@ -84,6 +84,12 @@ const (
 	// LocalPortForwarding is one of types supported by port plugin and is used to differentiate handling of error
 	// with scenario of sshd server port forwarding.
 	LocalPortForwarding = "LocalPortForwarding"
+	// DatabasePortForwarding is one of types supported by port plugin, the agent authenticates each connection
+	// to the destination database with IAM credentials on behalf of the client.
+	DatabasePortForwarding = "DatabasePortForwarding"
+	// DynamicPortForwarding is one of types supported by port plugin, the agent acts as a SOCKS5 proxy connecting
+	// each connection of the client to the destination it requests.
+	DynamicPortForwarding = "DynamicPortForwarding"
 
 	CloudWatchEncryptionErrorMsg                     = "We couldn't start the session because encryption is not set up on the selected CloudWatch Logs log group. Either encrypt the log group or choose an option to enable logging without encryption."
 	UnsupportedPowerShellVersionForStreamingErrorMsg = "The PowerShell version installed on the instance doesn’t support streaming logs to CloudWatch. Updated PowerShell to version 5.1 or later to stream session data to CloudWatch."
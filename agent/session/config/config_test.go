@@ -50,3 +50,16 @@ func TestGetMgsEndpointForKnownAwsCnRegion(t *testing.T) {
 
 	assert.Equal(t, expected, endpoint)
 }
+
+func TestGetMgsEndpointPerPartition(t *testing.T) {
+	partitionEndpoints := map[string]string{
+		"us-gov-west-1":  ServiceName + ".us-gov-west-1.amazonaws.com",
+		"us-iso-east-1":  ServiceName + ".us-iso-east-1.c2s.ic.gov",
+		"us-isob-east-1": ServiceName + ".us-isob-east-1.sc2s.sgov.gov",
+	}
+
+	contextMock := context.NewMockDefault()
+	for region, expected := range partitionEndpoints {
+		assert.Equal(t, expected, GetMgsEndpoint(contextMock, region))
+	}
+}
@@ -373,6 +373,7 @@ func parseTerminateSessionMessage(context context.T, agentMessage *AgentMessage,
 		DocumentID:     channelClosed.SessionId,
 		RunID:          times.ToIsoDashUTC(times.DefaultClock.Now()),
 		DocumentStatus: contracts.ResultStatusInProgress,
+		CorrelationID:  contracts.NewCorrelationID(),
 	}
 
 	cancelSessionInfo := new(contracts.CancelCommandInfo)
@@ -497,6 +498,7 @@ func buildSessionDocumentInfo(
 		DocumentStatus: contracts.ResultStatusInProgress,
 		RunAsUser:      parsedMessagePayload.RunAsUser,
 		SessionOwner:   parsedMessagePayload.SessionOwner,
+		CorrelationID:  contracts.NewCorrelationID(),
 	}
 }
 
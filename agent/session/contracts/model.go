@@ -353,6 +353,8 @@ const (
 	KMSEncryption ActionType = "KMSEncryption"
 	// Can be used to perform session type specific actions.
 	SessionType ActionType = "SessionType"
+	// Used to send the attested host identity of the instance to the client.
+	HostIdentity ActionType = "HostIdentity"
 )
 
 type ActionStatus int
@@ -18,7 +18,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -44,6 +46,7 @@ type IControlChannel interface {
 	Reconnect(log log.T) error
 	Close(log log.T) error
 	Open(log log.T) error
+	IsConnected() bool
 }
 
 // ControlChannel used for communication between the message gateway service and the agent.
@@ -55,6 +58,8 @@ type ControlChannel struct {
 	AuditLogScheduler               telemetry.IAuditLogTelemetry
 	channelType                     string
 	agentMessageIncomingMessageChan chan mgsContracts.AgentMessage
+	connected                       bool
+	connectedLock                   sync.RWMutex
 }
 
 // Initialize populates controlchannel object and opens controlchannel to communicate with mgs.
@@ -93,6 +98,7 @@ func (controlChannel *ControlChannel) SetWebSocket(context context.T,
 		controlChannelIncomingMessageHandler(context, input, controlChannel.agentMessageIncomingMessageChan)
 	}
 	onErrorHandler := func(err error) {
+		controlChannel.setConnected(false)
 		callable := func() (channel interface{}, err error) {
 			uuid.SwitchFormat(uuid.CleanHyphen)
 			requestId := uuid.NewV4().String()
@@ -172,6 +178,7 @@ func (controlChannel *ControlChannel) Reconnect(log log.T) error {
 // Close closes controlchannel - its web socket connection.
 func (controlChannel *ControlChannel) Close(log log.T) error {
 	log.Infof("Closing controlchannel with channel Id %s", controlChannel.ChannelId)
+	controlChannel.setConnected(false)
 	if controlChannel.AuditLogScheduler != nil {
 		controlChannel.AuditLogScheduler.StopScheduler()
 	}
@@ -187,6 +194,7 @@ func (controlChannel *ControlChannel) Open(log log.T) error {
 		TLSClientConfig: network.GetDefaultTLSConfig(log, controlChannel.context.AppConfig()),
 		Proxy:           http.ProxyFromEnvironment,
 		WriteBufferSize: mgsConfig.ControlChannelWriteBufferSizeLimit,
+		NetDialContext:  network.GetDialContext(controlChannel.context.AppConfig(), &net.Dialer{}),
 	}
 	if err := controlChannel.wsChannel.Open(log, controlChannelDialerInput); err != nil {
 		return fmt.Errorf("failed to connect controlchannel with error: %s", err)
@@ -211,11 +219,25 @@ func (controlChannel *ControlChannel) Open(log log.T) error {
 	}
 
 	if err = controlChannel.SendMessage(log, jsonValue, websocket.TextMessage); err == nil {
+		controlChannel.setConnected(true)
 		controlChannel.AuditLogScheduler.SendAuditMessage()
 	}
 	return err
 }
 
+// IsConnected returns true if the controlchannel is open and its connection has not been lost since.
+func (controlChannel *ControlChannel) IsConnected() bool {
+	controlChannel.connectedLock.RLock()
+	defer controlChannel.connectedLock.RUnlock()
+	return controlChannel.connected
+}
+
+func (controlChannel *ControlChannel) setConnected(connected bool) {
+	controlChannel.connectedLock.Lock()
+	defer controlChannel.connectedLock.Unlock()
+	controlChannel.connected = connected
+}
+
 // controlChannelIncomingMessageHandler handles the incoming messages coming to the agent.
 func controlChannelIncomingMessageHandler(context context.T,
 	rawMessage []byte,
@@ -99,9 +99,11 @@ func TestOpen(t *testing.T) {
 	mockEventLog.On("SendAuditMessage")
 
 	// test open (includes SendMessage)
+	assert.False(t, controlChannel.IsConnected())
 	err := controlChannel.Open(mockLog)
 
 	assert.Nil(t, err)
+	assert.True(t, controlChannel.IsConnected())
 	assert.Equal(t, token, controlChannel.wsChannel.GetChannelToken())
 	mockWsChannel.AssertExpectations(t)
 }
@@ -128,8 +130,10 @@ func TestClose(t *testing.T) {
 	mockEventLog.On("StopScheduler")
 
 	// test close
+	controlChannel.setConnected(true)
 	err := controlChannel.Close(mockLog)
 	assert.Nil(t, err)
+	assert.False(t, controlChannel.IsConnected())
 	mockWsChannel.AssertExpectations(t)
 }
 
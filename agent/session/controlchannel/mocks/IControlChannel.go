@@ -46,6 +46,20 @@ func (_m *IControlChannel) Initialize(_a0 context.T, mgsService service.Service,
 	_m.Called(_a0, mgsService, instanceId, agentMessageIncomingMessageChan)
 }
 
+// IsConnected provides a mock function with given fields:
+func (_m *IControlChannel) IsConnected() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // Open provides a mock function with given fields: _a0
 func (_m *IControlChannel) Open(_a0 log.T) error {
 	ret := _m.Called(_a0)
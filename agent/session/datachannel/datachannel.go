@@ -70,7 +70,7 @@ type IDataChannel interface {
 	AddDataToIncomingMessageBuffer(streamMessage StreamingMessage)
 	RemoveDataFromIncomingMessageBuffer(sequenceNumber int64)
 	SkipHandshake(log log.T)
-	PerformHandshake(log log.T, kmsKeyId string, encryptionEnabled bool, sessionTypeRequest mgsContracts.SessionTypeRequest) (err error)
+	PerformHandshake(log log.T, kmsKeyId string, encryptionEnabled bool, sessionTypeRequest mgsContracts.SessionTypeRequest, clientActions ...mgsContracts.RequestedClientAction) (err error)
 	GetClientVersion() string
 	GetInstanceId() string
 	GetRegion() string
@@ -867,6 +867,8 @@ func (dataChannel *DataChannel) handleHandshakeResponse(log log.T, streamDataMes
 				break
 			case mgsContracts.SessionType:
 				break
+			case mgsContracts.HostIdentity:
+				break
 			default:
 				log.Warnf("Unknown handshake client action found, %s", action.ActionType)
 			}
@@ -938,11 +940,13 @@ var newBlockCipher = func(context context.T, kmsKeyId string) (blockCipher crypt
 	return crypto.NewBlockCipher(context, kmsKeyId)
 }
 
-// PerformHandshake performs handshake to share version string and encryption information with clients like cli/console
+// PerformHandshake performs handshake to share version string and encryption information with clients like cli/console,
+// clientActions are additional actions requested by the session plugin
 func (dataChannel *DataChannel) PerformHandshake(log log.T,
 	kmsKeyId string,
 	encryptionEnabled bool,
-	sessionTypeRequest mgsContracts.SessionTypeRequest) (err error) {
+	sessionTypeRequest mgsContracts.SessionTypeRequest,
+	clientActions ...mgsContracts.RequestedClientAction) (err error) {
 
 	if encryptionEnabled {
 		if dataChannel.blockCipher, err = newBlockCipher(dataChannel.context, kmsKeyId); err != nil {
@@ -955,7 +959,7 @@ func (dataChannel *DataChannel) PerformHandshake(log log.T,
 
 	log.Info("Initiating Handshake")
 	handshakeRequestPayload :=
-		dataChannel.buildHandshakeRequestPayload(log, dataChannel.encryptionEnabled, sessionTypeRequest)
+		dataChannel.buildHandshakeRequestPayload(log, dataChannel.encryptionEnabled, sessionTypeRequest, clientActions...)
 	if err := dataChannel.sendHandshakeRequest(log, handshakeRequestPayload); err != nil {
 		return err
 	}
@@ -1006,7 +1010,8 @@ func (dataChannel *DataChannel) PerformHandshake(log log.T,
 // buildHandshakeRequestPayload builds payload for HandshakeRequest
 func (dataChannel *DataChannel) buildHandshakeRequestPayload(log log.T,
 	encryptionRequested bool,
-	request mgsContracts.SessionTypeRequest) mgsContracts.HandshakeRequestPayload {
+	request mgsContracts.SessionTypeRequest,
+	clientActions ...mgsContracts.RequestedClientAction) mgsContracts.HandshakeRequestPayload {
 
 	handshakeRequest := mgsContracts.HandshakeRequestPayload{}
 	handshakeRequest.AgentVersion = version.Version
@@ -1023,6 +1028,7 @@ func (dataChannel *DataChannel) buildHandshakeRequestPayload(log log.T,
 					KMSKeyID: dataChannel.blockCipher.GetKMSKeyId(),
 				}})
 	}
+	handshakeRequest.RequestedClientActions = append(handshakeRequest.RequestedClientActions, clientActions...)
 
 	return handshakeRequest
 }
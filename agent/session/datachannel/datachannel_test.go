@@ -737,6 +737,20 @@ func TestDataChannelHandshakeInitiate(t *testing.T) {
 	mockChannel.AssertExpectations(t)
 }
 
+func TestBuildHandshakeRequestPayloadWithClientActions(t *testing.T) {
+	dataChannel := getDataChannel()
+	hostIdentityAction := mgsContracts.RequestedClientAction{
+		ActionType:       mgsContracts.HostIdentity,
+		ActionParameters: "attestation",
+	}
+
+	handshakeRequest := dataChannel.buildHandshakeRequestPayload(mockLog, false, sessionTypeRequest, hostIdentityAction)
+
+	assert.Equal(t, 2, len(handshakeRequest.RequestedClientActions))
+	assert.Equal(t, mgsContracts.SessionType, handshakeRequest.RequestedClientActions[0].ActionType)
+	assert.Equal(t, hostIdentityAction, handshakeRequest.RequestedClientActions[1])
+}
+
 func TestBuildHandshakeCompletePayload(t *testing.T) {
 	dataChannel := getDataChannel()
 	dataChannel.SetSeparateOutputPayload(true)
@@ -144,13 +144,20 @@ func (_m *IDataChannel) Open(_a0 log.T) error {
 	return r0
 }
 
-// PerformHandshake provides a mock function with given fields: _a0, kmsKeyId, encryptionEnabled, sessionTypeRequest
-func (_m *IDataChannel) PerformHandshake(_a0 log.T, kmsKeyId string, encryptionEnabled bool, sessionTypeRequest contracts.SessionTypeRequest) error {
-	ret := _m.Called(_a0, kmsKeyId, encryptionEnabled, sessionTypeRequest)
+// PerformHandshake provides a mock function with given fields: _a0, kmsKeyId, encryptionEnabled, sessionTypeRequest, clientActions
+func (_m *IDataChannel) PerformHandshake(_a0 log.T, kmsKeyId string, encryptionEnabled bool, sessionTypeRequest contracts.SessionTypeRequest, clientActions ...contracts.RequestedClientAction) error {
+	_va := make([]interface{}, len(clientActions))
+	for _i := range clientActions {
+		_va[_i] = clientActions[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, kmsKeyId, encryptionEnabled, sessionTypeRequest)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(log.T, string, bool, contracts.SessionTypeRequest) error); ok {
-		r0 = rf(_a0, kmsKeyId, encryptionEnabled, sessionTypeRequest)
+	if rf, ok := ret.Get(0).(func(log.T, string, bool, contracts.SessionTypeRequest, ...contracts.RequestedClientAction) error); ok {
+		r0 = rf(_a0, kmsKeyId, encryptionEnabled, sessionTypeRequest, clientActions...)
 	} else {
 		r0 = ret.Error(0)
 	}
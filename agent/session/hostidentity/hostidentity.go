@@ -0,0 +1,162 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package hostidentity provides the attested host identity used to verify SSH sessions over Session Manager.
+// The SSH host keys of the instance and a nonce chosen by the client are bound to the instance credentials by a
+// presigned sts:GetCallerIdentity request, which the agent sends to the client in the session handshake. The client
+// sends the request to STS with the signed headers: the signature only verifies with the nonce and host key
+// fingerprints the agent signed, and the session name of the assumed role returned by STS is the instance id.
+package hostidentity
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// NonceHeader is the signed header of the caller identity request holding the nonce of the client
+	NonceHeader = "X-Ssm-Host-Identity-Nonce"
+	// HostKeyFingerprintsHeader is the signed header of the caller identity request holding the comma separated
+	// SHA256 fingerprints of the SSH host keys
+	HostKeyFingerprintsHeader = "X-Ssm-Host-Key-Fingerprints"
+
+	callerIdentityRequestExpiry = 5 * time.Minute
+	hostKeyPattern              = "ssh_host_*_key.pub"
+)
+
+// noncePattern restricts the nonce to the base64 and hex alphabets, so it can be sent as a header value
+var noncePattern = regexp.MustCompile(`^[A-Za-z0-9+/=_-]{16,128}$`)
+
+// HostKey is a public SSH host key of the instance
+type HostKey struct {
+	Type            string `json:"type"`
+	Fingerprint     string `json:"fingerprint"`
+	KnownHostsEntry string `json:"knownHostsEntry"`
+}
+
+// Attestation is the attested identity of the instance sent to the client in the session handshake.
+// CallerIdentityRequestURL is a presigned sts:GetCallerIdentity request, which must be sent with the
+// CallerIdentityRequestHeaders; those include the nonce of the client and the fingerprints of HostKeys.
+type Attestation struct {
+	InstanceId                   string            `json:"instanceId"`
+	HostKeys                     []HostKey         `json:"hostKeys"`
+	CallerIdentityRequestURL     string            `json:"callerIdentityRequestUrl"`
+	CallerIdentityRequestHeaders map[string]string `json:"callerIdentityRequestHeaders"`
+}
+
+// presignCallerIdentityRequest presigns a sts:GetCallerIdentity request carrying the given headers with the
+// instance credentials, and returns its url and the headers that were signed
+var presignCallerIdentityRequest = func(context context.T, headers map[string]string) (string, http.Header, error) {
+	appConfig := context.AppConfig()
+	stsSession, err := session.NewSession(sdkutil.AwsConfig(context, "sts"))
+	if err != nil {
+		return "", nil, fmt.Errorf("Error creating new aws sdk session: %s", err)
+	}
+	stsSession.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
+
+	callerIdentityRequest, _ := sts.New(stsSession).GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	for name, value := range headers {
+		callerIdentityRequest.HTTPRequest.Header.Set(name, value)
+	}
+	return callerIdentityRequest.PresignRequest(callerIdentityRequestExpiry)
+}
+
+var readFile = ioutil.ReadFile
+
+var glob = filepath.Glob
+
+// NewAttestation returns the attested identity of the instance binding its SSH host keys to the given nonce
+func NewAttestation(context context.T, nonce string) (*Attestation, error) {
+	if !noncePattern.MatchString(nonce) {
+		return nil, fmt.Errorf("host identity nonce must be 16 to 128 base64 or hex characters")
+	}
+	instanceId, err := context.Identity().InstanceID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance id: %v", err)
+	}
+
+	hostKeys, err := getHostKeys(context.Log(), instanceId)
+	if err != nil {
+		return nil, err
+	}
+	if len(hostKeys) == 0 {
+		return nil, fmt.Errorf("no SSH host key found in %s", sshConfigDir())
+	}
+	fingerprints := make([]string, 0, len(hostKeys))
+	for _, hostKey := range hostKeys {
+		fingerprints = append(fingerprints, hostKey.Fingerprint)
+	}
+
+	requestURL, signedHeaders, err := presignCallerIdentityRequest(context, map[string]string{
+		NonceHeader:               nonce,
+		HostKeyFingerprintsHeader: strings.Join(fingerprints, ","),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the host identity: %v", err)
+	}
+
+	requestHeaders := make(map[string]string, len(signedHeaders))
+	for name := range signedHeaders {
+		requestHeaders[name] = signedHeaders.Get(name)
+	}
+	return &Attestation{
+		InstanceId:                   instanceId,
+		HostKeys:                     hostKeys,
+		CallerIdentityRequestURL:     requestURL,
+		CallerIdentityRequestHeaders: requestHeaders,
+	}, nil
+}
+
+// getHostKeys reads the public SSH host keys, the known hosts entries use the instance id as host name
+// to match the host name ssh uses when the instance id is the ProxyCommand target
+func getHostKeys(log log.T, instanceId string) ([]HostKey, error) {
+	paths, err := glob(filepath.Join(sshConfigDir(), hostKeyPattern))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	hostKeys := make([]HostKey, 0, len(paths))
+	for _, path := range paths {
+		content, err := readFile(path)
+		if err != nil {
+			log.Warnf("Failed to read SSH host key %s: %v", path, err)
+			continue
+		}
+		publicKey, _, _, _, err := ssh.ParseAuthorizedKey(content)
+		if err != nil {
+			log.Warnf("Failed to parse SSH host key %s: %v", path, err)
+			continue
+		}
+		hostKeys = append(hostKeys, HostKey{
+			Type:            publicKey.Type(),
+			Fingerprint:     ssh.FingerprintSHA256(publicKey),
+			KnownHostsEntry: instanceId + " " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))),
+		})
+	}
+	return hostKeys, nil
+}
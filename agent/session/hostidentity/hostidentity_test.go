@@ -0,0 +1,127 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hostidentity
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	identitymocks "github.com/aws/amazon-ssm-agent/common/identity/mocks"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+const testNonce = "c2Vzc2lvbi1ub25jZS0xMjM0NTY="
+
+func stubDependencies(t *testing.T, files map[string][]byte, presignErr error) map[string]string {
+	realPresign, realReadFile, realGlob := presignCallerIdentityRequest, readFile, glob
+	t.Cleanup(func() {
+		presignCallerIdentityRequest, readFile, glob = realPresign, realReadFile, realGlob
+	})
+
+	signedHeaders := map[string]string{}
+	presignCallerIdentityRequest = func(_ context.T, headers map[string]string) (string, http.Header, error) {
+		if presignErr != nil {
+			return "", nil, presignErr
+		}
+		header := http.Header{}
+		for name, value := range headers {
+			signedHeaders[name] = value
+			header.Set(name, value)
+		}
+		return "https://sts.us-east-1.amazonaws.com/?Action=GetCallerIdentity", header, nil
+	}
+	glob = func(pattern string) ([]string, error) {
+		var paths []string
+		for path := range files {
+			if matched, _ := filepath.Match(pattern, path); matched {
+				paths = append(paths, path)
+			}
+		}
+		return paths, nil
+	}
+	readFile = func(path string) ([]byte, error) {
+		return files[path], nil
+	}
+	return signedHeaders
+}
+
+func TestNewAttestation(t *testing.T) {
+	publicKey, _, _ := ed25519.GenerateKey(nil)
+	sshPublicKey, _ := ssh.NewPublicKey(publicKey)
+	authorizedKey := ssh.MarshalAuthorizedKey(sshPublicKey)
+
+	signedHeaders := stubDependencies(t,
+		map[string][]byte{
+			filepath.Join(sshConfigDir(), "ssh_host_ed25519_key.pub"): append(authorizedKey[:len(authorizedKey)-1], []byte(" root@host\n")...),
+			filepath.Join(sshConfigDir(), "ssh_host_rsa_key.pub"):     []byte("not a key"),
+			filepath.Join(sshConfigDir(), "ssh_host_ed25519_key"):     []byte("private key"),
+		}, nil)
+
+	attestation, err := NewAttestation(contextmocks.NewMockDefault(), testNonce)
+
+	assert.NoError(t, err)
+	assert.Equal(t, identitymocks.MockInstanceID, attestation.InstanceId)
+	assert.Equal(t, []HostKey{{
+		Type:            ssh.KeyAlgoED25519,
+		Fingerprint:     ssh.FingerprintSHA256(sshPublicKey),
+		KnownHostsEntry: identitymocks.MockInstanceID + " " + string(authorizedKey[:len(authorizedKey)-1]),
+	}}, attestation.HostKeys)
+	assert.Equal(t, map[string]string{
+		NonceHeader:               testNonce,
+		HostKeyFingerprintsHeader: ssh.FingerprintSHA256(sshPublicKey),
+	}, signedHeaders)
+	assert.Equal(t, "https://sts.us-east-1.amazonaws.com/?Action=GetCallerIdentity", attestation.CallerIdentityRequestURL)
+	assert.Equal(t, testNonce, attestation.CallerIdentityRequestHeaders[NonceHeader])
+	assert.Equal(t, ssh.FingerprintSHA256(sshPublicKey), attestation.CallerIdentityRequestHeaders[HostKeyFingerprintsHeader])
+}
+
+func TestNewAttestation_InvalidNonce(t *testing.T) {
+	stubDependencies(t, map[string][]byte{}, nil)
+
+	for _, nonce := range []string{"", "short", "nonce with\r\nheader injection"} {
+		attestation, err := NewAttestation(contextmocks.NewMockDefault(), nonce)
+
+		assert.Error(t, err)
+		assert.Nil(t, attestation)
+	}
+}
+
+func TestNewAttestation_NoHostKeys(t *testing.T) {
+	stubDependencies(t, map[string][]byte{}, nil)
+
+	attestation, err := NewAttestation(contextmocks.NewMockDefault(), testNonce)
+
+	assert.Error(t, err)
+	assert.Nil(t, attestation)
+}
+
+func TestNewAttestation_PresignFailure(t *testing.T) {
+	publicKey, _, _ := ed25519.GenerateKey(nil)
+	sshPublicKey, _ := ssh.NewPublicKey(publicKey)
+	stubDependencies(t,
+		map[string][]byte{
+			filepath.Join(sshConfigDir(), "ssh_host_ed25519_key.pub"): ssh.MarshalAuthorizedKey(sshPublicKey),
+		}, errors.New("no credentials"))
+
+	attestation, err := NewAttestation(contextmocks.NewMockDefault(), testNonce)
+
+	assert.Error(t, err)
+	assert.Nil(t, attestation)
+}
@@ -1,4 +1,4 @@
-// Copyright 2020 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
 //
 // Licensed under the Apache License, Version 2.0 (the "License"). You may not
 // use this file except in compliance with the License. A copy of the
@@ -11,12 +11,12 @@
 // either express or implied. See the License for the specific language governing
 // permissions and limitations under the License.
 
-//go:build darwin
-// +build darwin
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
 
-// Package model contains data objects for session utility
-package model
+package hostidentity
 
-const (
-	AddUserCommand = "sysadminctl -addUser %s -shell /usr/bin/false"
-)
+// sshConfigDir returns the directory of the SSH host keys
+func sshConfigDir() string {
+	return "/etc/ssh"
+}
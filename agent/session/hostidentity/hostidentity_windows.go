@@ -1,4 +1,4 @@
-// Copyright 2020 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
 //
 // Licensed under the Apache License, Version 2.0 (the "License"). You may not
 // use this file except in compliance with the License. A copy of the
@@ -11,12 +11,17 @@
 // either express or implied. See the License for the specific language governing
 // permissions and limitations under the License.
 
-//go:build freebsd || linux || netbsd || openbsd
-// +build freebsd linux netbsd openbsd
+//go:build windows
+// +build windows
 
-// Package model contains data objects for session utility
-package model
+package hostidentity
 
-const (
-	AddUserCommand = "useradd -m %s"
+import (
+	"os"
+	"path/filepath"
 )
+
+// sshConfigDir returns the directory of the OpenSSH for Windows host keys
+func sshConfigDir() string {
+	return filepath.Join(os.Getenv("ProgramData"), "ssh")
+}
@@ -0,0 +1,141 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package forcedcommand implements session plugin that only runs a command configured on the instance.
+package forcedcommand
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	agentContracts "github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin"
+	"github.com/aws/amazon-ssm-agent/agent/session/shell"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// commandNameProperty is the only session property accepted by the ForcedCommand plugin
+const commandNameProperty = "commandName"
+
+// ForcedCommandProperties are the session properties of the ForcedCommand plugin.
+type ForcedCommandProperties struct {
+	CommandName string `json:"commandName" yaml:"commandName"`
+}
+
+// ForcedCommandPlugin runs one of the commands configured in the agent configuration in a pty,
+// the session document can only select the command by name and the session ends when the command exits.
+type ForcedCommandPlugin struct {
+	context context.T
+	shell   shell.IShellPlugin
+}
+
+// Returns parameters required for CLI/console to start session
+func (p *ForcedCommandPlugin) GetPluginParameters(parameters interface{}) interface{} {
+	return nil
+}
+
+// ForcedCommand plugin doesn't require handshake to establish session
+func (p *ForcedCommandPlugin) RequireHandshake() bool {
+	return false
+}
+
+// NewPlugin returns a new instance of the ForcedCommand Plugin
+func NewPlugin(context context.T) (sessionplugin.ISessionPlugin, error) {
+	shellPlugin, err := shell.NewPlugin(context, appconfig.PluginNameForcedCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	var plugin = ForcedCommandPlugin{
+		context: context,
+		shell:   shellPlugin,
+	}
+	return &plugin, nil
+}
+
+// name returns the name of forced command Plugin
+func (p *ForcedCommandPlugin) name() string {
+	return appconfig.PluginNameForcedCommand
+}
+
+// Execute runs the configured command selected by the session properties via pty.
+// It reads message from cmd.stdout and writes to data channel.
+func (p *ForcedCommandPlugin) Execute(config agentContracts.Configuration,
+	cancelFlag task.CancelFlag,
+	output iohandler.IOHandler,
+	dataChannel datachannel.IDataChannel) {
+
+	logger := p.context.Log()
+	shellProps, err := p.getShellProperties(config.Properties)
+	if err != nil {
+		sessionPluginResultOutput := mgsContracts.SessionPluginResultOutput{}
+		output.SetExitCode(appconfig.ErrorExitCode)
+		output.SetStatus(agentContracts.ResultStatusFailed)
+		sessionPluginResultOutput.Output = err.Error()
+		output.SetOutput(sessionPluginResultOutput)
+		logger.Error(sessionPluginResultOutput.Output)
+		return
+	}
+
+	// streaming of logs is not supported for single commands scenario, set it to false
+	config.CloudWatchStreamingEnabled = false
+
+	p.shell.Execute(config, cancelFlag, output, dataChannel, shellProps)
+}
+
+// InputStreamMessageHandler passes payload byte stream to the command stdin
+func (p *ForcedCommandPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+	return p.shell.InputStreamMessageHandler(log, streamDataMessage)
+}
+
+// getShellProperties builds the shell properties running the configured command selected by the session properties,
+// any session property other than the command name is rejected.
+func (p *ForcedCommandPlugin) getShellProperties(properties interface{}) (shellProps mgsContracts.ShellProperties, err error) {
+	var propertiesMap map[string]interface{}
+	if err = jsonutil.Remarshal(properties, &propertiesMap); err != nil {
+		return shellProps, fmt.Errorf("Invalid format in session properties %v;\nerror %v", properties, err)
+	}
+	for key := range propertiesMap {
+		if key != commandNameProperty {
+			return shellProps, fmt.Errorf("Property %s is not allowed for session type %s", key, p.name())
+		}
+	}
+
+	var forcedCommandProps ForcedCommandProperties
+	if err = jsonutil.Remarshal(properties, &forcedCommandProps); err != nil {
+		return shellProps, fmt.Errorf("Invalid format in session properties %v;\nerror %v", properties, err)
+	}
+	if strings.TrimSpace(forcedCommandProps.CommandName) == "" {
+		return shellProps, fmt.Errorf("Command name cannot be empty for session type %s", p.name())
+	}
+
+	command, found := p.context.AppConfig().Mgs.ForcedCommands[forcedCommandProps.CommandName]
+	if !found || strings.TrimSpace(command) == "" {
+		return shellProps, fmt.Errorf("Command %s is not configured on this instance", forcedCommandProps.CommandName)
+	}
+
+	shellConfig := mgsContracts.ShellConfig{Commands: command}
+	shellProps = mgsContracts.ShellProperties{
+		Windows: shellConfig,
+		Linux:   shellConfig,
+		MacOS:   shellConfig,
+	}
+	return shellProps, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package forcedcommand
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/task"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
+	"github.com/aws/amazon-ssm-agent/agent/session/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ForcedCommandTestSuite struct {
+	suite.Suite
+	mockContext     *context.Mock
+	mockCancelFlag  *task.MockCancelFlag
+	mockDataChannel *dataChannelMock.IDataChannel
+	mockIohandler   *iohandlermocks.MockIOHandler
+	mockShellPlugin *mocks.IShellPluginMock
+	plugin          *ForcedCommandPlugin
+}
+
+func (suite *ForcedCommandTestSuite) SetupTest() {
+	config := appconfig.SsmagentConfig{}
+	config.Mgs.ForcedCommands = map[string]string{
+		"restart-app": "/opt/runbooks/restart-app.sh",
+	}
+	suite.mockContext = context.NewMockDefaultWithConfig(config)
+	suite.mockCancelFlag = &task.MockCancelFlag{}
+	suite.mockDataChannel = &dataChannelMock.IDataChannel{}
+	suite.mockIohandler = new(iohandlermocks.MockIOHandler)
+	suite.mockShellPlugin = new(mocks.IShellPluginMock)
+	suite.plugin = &ForcedCommandPlugin{context: suite.mockContext, shell: suite.mockShellPlugin}
+}
+
+// Execute the test suite
+func TestForcedCommandTestSuite(t *testing.T) {
+	suite.Run(t, new(ForcedCommandTestSuite))
+}
+
+// Testing Execute runs the configured command
+func (suite *ForcedCommandTestSuite) TestExecute() {
+	newIOHandler := iohandler.NewDefaultIOHandler(suite.mockContext, contracts.IOConfiguration{})
+	shellConfig := mgsContracts.ShellConfig{Commands: "/opt/runbooks/restart-app.sh"}
+	shellProps := mgsContracts.ShellProperties{Windows: shellConfig, Linux: shellConfig, MacOS: shellConfig}
+	suite.mockShellPlugin.On("Execute", mock.Anything, suite.mockCancelFlag, newIOHandler, suite.mockDataChannel, shellProps).Return()
+
+	suite.plugin.Execute(
+		contracts.Configuration{Properties: map[string]interface{}{"commandName": "restart-app"}},
+		suite.mockCancelFlag,
+		newIOHandler,
+		suite.mockDataChannel)
+
+	suite.mockShellPlugin.AssertExpectations(suite.T())
+	assert.Equal(suite.T(), 0, newIOHandler.GetExitCode())
+}
+
+// Testing Execute rejects invalid session properties without starting the shell
+func (suite *ForcedCommandTestSuite) TestExecuteRejectsInvalidProperties() {
+	tests := map[string]interface{}{
+		"Command name cannot be empty for session type ForcedCommand": nil,
+		"Command rm -rf / is not configured on this instance":         map[string]interface{}{"commandName": "rm -rf /"},
+		"Property linux is not allowed for session type ForcedCommand": map[string]interface{}{
+			"commandName": "restart-app",
+			"linux":       map[string]interface{}{"commands": "sh"},
+		},
+	}
+
+	for expectedOutput, properties := range tests {
+		mockIohandler := new(iohandlermocks.MockIOHandler)
+		mockIohandler.On("SetExitCode", appconfig.ErrorExitCode).Return()
+		mockIohandler.On("SetStatus", contracts.ResultStatusFailed).Return()
+		mockIohandler.On("SetOutput", mgsContracts.SessionPluginResultOutput{Output: expectedOutput}).Return()
+
+		suite.plugin.Execute(
+			contracts.Configuration{Properties: properties},
+			suite.mockCancelFlag,
+			mockIohandler,
+			suite.mockDataChannel)
+
+		mockIohandler.AssertExpectations(suite.T())
+	}
+	suite.mockShellPlugin.AssertNotCalled(suite.T(), "Execute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Testing InputStreamMessageHandler passes the input to the command
+func (suite *ForcedCommandTestSuite) TestInputStreamMessageHandler() {
+	mockLog := suite.mockContext.Log()
+	suite.mockShellPlugin.On("InputStreamMessageHandler", mockLog, mock.Anything).Return(nil)
+
+	err := suite.plugin.InputStreamMessageHandler(mockLog, mgsContracts.AgentMessage{})
+
+	suite.mockShellPlugin.AssertExpectations(suite.T())
+	assert.Nil(suite.T(), err)
+}
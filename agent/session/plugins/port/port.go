@@ -30,6 +30,7 @@ import (
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+	"github.com/aws/amazon-ssm-agent/agent/session/hostidentity"
 	"github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/agent/versionutil"
@@ -47,6 +48,14 @@ type PortParameters struct {
 	Host       string `json:"host" yaml:"host"`
 	PortNumber string `json:"portNumber" yaml:"portNumber"`
 	Type       string `json:"type"`
+
+	// DatabaseEngine, DatabaseUser and DatabaseClusterIdentifier are used by DatabasePortForwarding sessions
+	DatabaseEngine            string `json:"databaseEngine"`
+	DatabaseUser              string `json:"databaseUser"`
+	DatabaseClusterIdentifier string `json:"databaseClusterIdentifier"`
+
+	// HostIdentityNonce is chosen by the client to request the attested host identity of the instance in the handshake
+	HostIdentityNonce string `json:"hostIdentityNonce"`
 }
 
 // Plugin is the type for the port plugin.
@@ -86,6 +95,8 @@ var newECSIdentity = func(log log.T, _ *appconfig.SsmagentConfig) identity.IAgen
 
 // GetSession initializes session based on the type of the port session
 // mux for port forwarding session and if client supports multiplexing; basic otherwise
+// database port forwarding sessions always use mux and authenticate every connection to the database
+// dynamic port forwarding sessions always use mux and connect every connection to the destination it requests
 var GetSession = func(context context.T, portParameters PortParameters, cancelled chan struct{}, clientVersion string, sessionId string) (session IPortSession, err error) {
 	host := "localhost"
 	if portParameters.Host != "" {
@@ -94,6 +105,20 @@ var GetSession = func(context context.T, portParameters PortParameters, cancelle
 	}
 	destinationAddress := net.JoinHostPort(host, portParameters.PortNumber)
 
+	if portParameters.Type == mgsConfig.DynamicPortForwarding {
+		if versionutil.Compare(clientVersion, muxSupportedClientVersion, true) < 0 {
+			return nil, fmt.Errorf("Dynamic port forwarding requires client version %s or later.", muxSupportedClientVersion)
+		}
+		return NewSocksPortSession(context, clientVersion, cancelled, sessionId)
+	}
+
+	if portParameters.Type == mgsConfig.DatabasePortForwarding {
+		if versionutil.Compare(clientVersion, muxSupportedClientVersion, true) < 0 {
+			return nil, fmt.Errorf("Database port forwarding requires client version %s or later.", muxSupportedClientVersion)
+		}
+		return NewDatabasePortSession(context, clientVersion, cancelled, portParameters, host, sessionId)
+	}
+
 	if portParameters.Type == mgsConfig.LocalPortForwarding &&
 		versionutil.Compare(clientVersion, muxSupportedClientVersion, true) >= 0 {
 
@@ -118,6 +143,28 @@ func (p *PortPlugin) RequireHandshake() bool {
 	return true
 }
 
+var newHostIdentityAttestation = hostidentity.NewAttestation
+
+// GetHandshakeActions requests the client to verify the host identity when the client sent a host identity nonce
+func (p *PortPlugin) GetHandshakeActions(parameters interface{}) ([]mgsContracts.RequestedClientAction, error) {
+	var portParameters PortParameters
+	if err := jsonutil.Remarshal(parameters, &portParameters); err != nil {
+		return nil, fmt.Errorf("Unable to remarshal session properties. %v", err)
+	}
+	if portParameters.HostIdentityNonce == "" {
+		return nil, nil
+	}
+	attestation, err := newHostIdentityAttestation(p.context, portParameters.HostIdentityNonce)
+	if err != nil {
+		return nil, err
+	}
+	return []mgsContracts.RequestedClientAction{
+		{
+			ActionType:       mgsContracts.HostIdentity,
+			ActionParameters: attestation,
+		}}, nil
+}
+
 // NewPortPlugin returns a new instance of the Port Plugin.
 func NewPlugin(context context.T) (sessionplugin.ISessionPlugin, error) {
 	var plugin = PortPlugin{
@@ -283,6 +330,11 @@ func (p *PortPlugin) initializeParameters(config agentContracts.Configuration) (
 
 // validateParameters validates port plugin parameters
 func (p *PortPlugin) validateParameters(portParameters PortParameters, config agentContracts.Configuration) (err error) {
+	// the destinations of dynamic port forwarding sessions are validated as they are requested
+	if portParameters.Type == mgsConfig.DynamicPortForwarding {
+		return
+	}
+
 	if portParameters.PortNumber == "" {
 		return errors.New(fmt.Sprintf("Port number is empty in session properties. %v", config.Properties))
 	}
@@ -0,0 +1,451 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package port implements session manager's port plugin.
+package port
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/rds/rdsutils"
+	"github.com/aws/aws-sdk-go/service/redshift"
+)
+
+const (
+	// PostgresEngine is the database engine for RDS and Aurora PostgreSQL, authenticated with an IAM auth token
+	PostgresEngine = "postgres"
+	// RedshiftEngine is the database engine for Redshift clusters, authenticated with temporary cluster credentials
+	RedshiftEngine = "redshift"
+	// MySQLEngine and MariaDBEngine are the database engines for RDS and Aurora MySQL and RDS MariaDB, they are
+	// rejected since the mysql_clear_password handshake needed to inject an IAM auth token is not supported
+	MySQLEngine   = "mysql"
+	MariaDBEngine = "mariadb"
+)
+
+const (
+	postgresProtocolVersion = 196608
+	postgresSSLRequest      = 80877103
+	postgresGSSENCRequest   = 80877104
+	postgresCancelRequest   = 80877102
+
+	postgresAuthOk                = 0
+	postgresAuthCleartextPassword = 3
+	postgresAuthMD5Password       = 5
+
+	postgresMaxStartupLength = 10000
+	postgresMaxMessageLength = 1 << 20
+
+	databaseHandshakeTimeout   = 30 * time.Second
+	redshiftCredentialDuration = 900
+)
+
+// connectionAuthenticator authenticates a connection to the destination server on behalf of the client
+type connectionAuthenticator interface {
+	Authenticate(client net.Conn, server net.Conn) (net.Conn, error)
+}
+
+// databaseAuthenticator injects IAM credentials into the PostgreSQL wire protocol handshake,
+// so clients connect through the tunnel without generating auth tokens themselves.
+// Connections to the database always use TLS, the database CA must be trusted by the system or
+// configured as the agent custom certificate.
+type databaseAuthenticator struct {
+	context           context.T
+	engine            string
+	host              string
+	port              string
+	user              string
+	clusterIdentifier string
+}
+
+var buildAuthToken = rdsutils.BuildAuthToken
+
+var getClusterCredentials = func(context context.T, input *redshift.GetClusterCredentialsInput) (*redshift.GetClusterCredentialsOutput, error) {
+	appConfig := context.AppConfig()
+	redshiftSession, err := session.NewSession(sdkutil.AwsConfig(context, "redshift"))
+	if err != nil {
+		return nil, fmt.Errorf("Error creating new aws sdk session: %s", err)
+	}
+	redshiftSession.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
+	return redshift.New(redshiftSession).GetClusterCredentials(input)
+}
+
+var upgradeToTLS = func(context context.T, conn net.Conn, serverName string) (net.Conn, error) {
+	tlsConfig := network.GetDefaultTLSConfig(context.Log(), context.AppConfig())
+	tlsConfig.ServerName = serverName
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// NewDatabasePortSession returns a new instance of the MuxPortSession which authenticates
+// every connection to the destination database with IAM credentials.
+func NewDatabasePortSession(context context.T, clientVersion string, cancelled chan struct{}, portParameters PortParameters, host string, sessionId string) (IPortSession, error) {
+	authenticator, err := newDatabaseAuthenticator(context, portParameters, host)
+	if err != nil {
+		return nil, err
+	}
+	var plugin = MuxPortSession{
+		context:            context,
+		clientVersion:      clientVersion,
+		cancelled:          cancelled,
+		destinationAddress: net.JoinHostPort(host, portParameters.PortNumber),
+		sessionId:          sessionId,
+		authenticator:      authenticator}
+	return &plugin, nil
+}
+
+// newDatabaseAuthenticator validates the database parameters and returns a new databaseAuthenticator
+func newDatabaseAuthenticator(context context.T, portParameters PortParameters, host string) (*databaseAuthenticator, error) {
+	if portParameters.DatabaseUser == "" {
+		return nil, errors.New("Database user is empty in session properties.")
+	}
+	switch portParameters.DatabaseEngine {
+	case PostgresEngine:
+	case MySQLEngine, MariaDBEngine:
+		return nil, fmt.Errorf("IAM authentication token injection is not supported for %s databases, "+
+			"connect with an IAM auth token generated by the client through a LocalPortForwarding session instead.", portParameters.DatabaseEngine)
+	case RedshiftEngine:
+		if portParameters.DatabaseClusterIdentifier == "" {
+			return nil, errors.New("Database cluster identifier is empty in session properties.")
+		}
+	default:
+		return nil, fmt.Errorf("Database engine %s is not supported, supported engines are %s and %s.", portParameters.DatabaseEngine, PostgresEngine, RedshiftEngine)
+	}
+
+	return &databaseAuthenticator{
+		context:           context,
+		engine:            portParameters.DatabaseEngine,
+		host:              host,
+		port:              portParameters.PortNumber,
+		user:              portParameters.DatabaseUser,
+		clusterIdentifier: portParameters.DatabaseClusterIdentifier,
+	}, nil
+}
+
+// Authenticate completes the startup handshake with the database on behalf of the client.
+// It returns the connection to the database that the remaining client traffic is forwarded to.
+func (a *databaseAuthenticator) Authenticate(client net.Conn, server net.Conn) (conn net.Conn, err error) {
+	log := a.context.Log()
+	deadline := time.Now().Add(databaseHandshakeTimeout)
+	client.SetDeadline(deadline)
+	server.SetDeadline(deadline)
+
+	parameters, cancelRequest, err := readClientStartup(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read startup message from client: %v", err)
+	}
+	if cancelRequest != nil {
+		// cancel requests are not authenticated, they are forwarded as is
+		if _, err = server.Write(cancelRequest); err != nil {
+			return nil, err
+		}
+		return server, nil
+	}
+
+	defer func() {
+		var rejected errorResponseError
+		if err != nil && !errors.As(err, &rejected) {
+			writeErrorResponse(client, err.Error())
+		}
+	}()
+
+	user, password, err := a.credentials(parameters.get("database"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database credentials: %v", err)
+	}
+	parameters.set("user", user)
+
+	if conn, err = a.startTLS(server); err != nil {
+		return nil, err
+	}
+	if _, err = conn.Write(parameters.startupMessage()); err != nil {
+		return nil, err
+	}
+	if err = authenticateServer(client, conn, user, password); err != nil {
+		return nil, err
+	}
+	log.Debugf("Authenticated connection to database %s as user %s", a.host, user)
+
+	client.SetDeadline(time.Time{})
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// credentials returns the database user and the password injected into the handshake
+func (a *databaseAuthenticator) credentials(database string) (user string, password string, err error) {
+	if a.engine == RedshiftEngine {
+		input := &redshift.GetClusterCredentialsInput{
+			ClusterIdentifier: aws.String(a.clusterIdentifier),
+			DbUser:            aws.String(a.user),
+			DurationSeconds:   aws.Int64(redshiftCredentialDuration),
+		}
+		if database != "" {
+			input.DbName = aws.String(database)
+		}
+		output, err := getClusterCredentials(a.context, input)
+		if err != nil {
+			return "", "", err
+		}
+		return aws.StringValue(output.DbUser), aws.StringValue(output.DbPassword), nil
+	}
+
+	region, err := a.context.Identity().Region()
+	if err != nil {
+		return "", "", err
+	}
+	token, err := buildAuthToken(net.JoinHostPort(a.host, a.port), region, a.user, a.context.Identity().Credentials())
+	return a.user, token, err
+}
+
+// startTLS requests TLS from the database and upgrades the connection
+func (a *databaseAuthenticator) startTLS(server net.Conn) (net.Conn, error) {
+	sslRequest := make([]byte, 8)
+	binary.BigEndian.PutUint32(sslRequest[0:4], 8)
+	binary.BigEndian.PutUint32(sslRequest[4:8], postgresSSLRequest)
+	if _, err := server.Write(sslRequest); err != nil {
+		return nil, err
+	}
+
+	response := make([]byte, 1)
+	if _, err := io.ReadFull(server, response); err != nil {
+		return nil, err
+	}
+	if response[0] != 'S' {
+		return nil, fmt.Errorf("database %s does not accept TLS connections", a.host)
+	}
+
+	conn, err := upgradeToTLS(a.context, server, a.host)
+	if err != nil {
+		return nil, fmt.Errorf("TLS handshake with database %s failed: %v", a.host, err)
+	}
+	return conn, nil
+}
+
+// authenticateServer answers the password requests of the database until authentication completes.
+// The client never sees the password requests, it only receives the final authentication result.
+func authenticateServer(client net.Conn, server net.Conn, user string, password string) error {
+	for {
+		messageType, body, err := readMessage(server)
+		if err != nil {
+			return err
+		}
+
+		switch messageType {
+		case 'R':
+			if len(body) < 4 {
+				return errors.New("invalid authentication request from database")
+			}
+			switch authType := binary.BigEndian.Uint32(body[0:4]); authType {
+			case postgresAuthOk:
+				_, err = client.Write(message('R', body))
+				return err
+			case postgresAuthCleartextPassword:
+				err = writePasswordMessage(server, password)
+			case postgresAuthMD5Password:
+				if len(body) < 8 {
+					return errors.New("invalid md5 authentication request from database")
+				}
+				err = writePasswordMessage(server, md5Password(user, password, body[4:8]))
+			default:
+				return fmt.Errorf("database requested unsupported authentication method %d", authType)
+			}
+			if err != nil {
+				return err
+			}
+		case 'N':
+			if _, err = client.Write(message('N', body)); err != nil {
+				return err
+			}
+		case 'E':
+			// forward the error so the client reports the reason given by the database
+			client.Write(message('E', body))
+			return errorResponseError{message: errorResponseMessage(body)}
+		default:
+			return fmt.Errorf("unexpected message %q from database during authentication", messageType)
+		}
+	}
+}
+
+// errorResponseError is returned when the database rejects the connection, the error was already sent to the client
+type errorResponseError struct {
+	message string
+}
+
+func (e errorResponseError) Error() string {
+	return fmt.Sprintf("database rejected the connection: %s", e.message)
+}
+
+// startupParameters holds the startup message parameters in the order sent by the client
+type startupParameters struct {
+	keys   []string
+	values map[string]string
+}
+
+func (s *startupParameters) get(key string) string {
+	return s.values[key]
+}
+
+func (s *startupParameters) set(key string, value string) {
+	if _, found := s.values[key]; !found {
+		s.keys = append(s.keys, key)
+	}
+	s.values[key] = value
+}
+
+// startupMessage builds the protocol 3.0 startup message
+func (s *startupParameters) startupMessage() []byte {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 4))
+	binary.Write(&buf, binary.BigEndian, uint32(postgresProtocolVersion))
+	for _, key := range s.keys {
+		buf.WriteString(key)
+		buf.WriteByte(0)
+		buf.WriteString(s.values[key])
+		buf.WriteByte(0)
+	}
+	buf.WriteByte(0)
+
+	startupMessage := buf.Bytes()
+	binary.BigEndian.PutUint32(startupMessage[0:4], uint32(len(startupMessage)))
+	return startupMessage
+}
+
+// readClientStartup reads the client startup message, declining TLS and GSS encryption requests
+// since the session is already encrypted. Cancel requests are returned as is.
+func readClientStartup(client net.Conn) (parameters *startupParameters, cancelRequest []byte, err error) {
+	for {
+		header := make([]byte, 4)
+		if _, err = io.ReadFull(client, header); err != nil {
+			return nil, nil, err
+		}
+		length := binary.BigEndian.Uint32(header)
+		if length < 8 || length > postgresMaxStartupLength {
+			return nil, nil, fmt.Errorf("invalid startup message length %d", length)
+		}
+		body := make([]byte, length-4)
+		if _, err = io.ReadFull(client, body); err != nil {
+			return nil, nil, err
+		}
+
+		switch code := binary.BigEndian.Uint32(body[0:4]); code {
+		case postgresSSLRequest, postgresGSSENCRequest:
+			if _, err = client.Write([]byte{'N'}); err != nil {
+				return nil, nil, err
+			}
+		case postgresCancelRequest:
+			return nil, append(header, body...), nil
+		case postgresProtocolVersion:
+			parameters, err = parseStartupParameters(body[4:])
+			return parameters, nil, err
+		default:
+			return nil, nil, fmt.Errorf("unsupported protocol version %d", code)
+		}
+	}
+}
+
+// parseStartupParameters parses the null terminated key value pairs of a startup message
+func parseStartupParameters(data []byte) (*startupParameters, error) {
+	parameters := &startupParameters{values: make(map[string]string)}
+	fields := bytes.Split(data, []byte{0})
+	for i := 0; i+1 < len(fields); i += 2 {
+		if len(fields[i]) == 0 {
+			return parameters, nil
+		}
+		parameters.set(string(fields[i]), string(fields[i+1]))
+	}
+	return nil, errors.New("malformed startup parameters")
+}
+
+// readMessage reads a typed message from the database
+func readMessage(conn net.Conn) (messageType byte, body []byte, err error) {
+	header := make([]byte, 5)
+	if _, err = io.ReadFull(conn, header); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[1:5])
+	if length < 4 || length > postgresMaxMessageLength {
+		return 0, nil, fmt.Errorf("invalid message length %d", length)
+	}
+	body = make([]byte, length-4)
+	if _, err = io.ReadFull(conn, body); err != nil {
+		return 0, nil, err
+	}
+	return header[0], body, nil
+}
+
+// message builds a typed message
+func message(messageType byte, body []byte) []byte {
+	result := make([]byte, 5, 5+len(body))
+	result[0] = messageType
+	binary.BigEndian.PutUint32(result[1:5], uint32(len(body)+4))
+	return append(result, body...)
+}
+
+func writePasswordMessage(conn net.Conn, password string) error {
+	_, err := conn.Write(message('p', append([]byte(password), 0)))
+	return err
+}
+
+// md5Password computes the response to a md5 password request
+func md5Password(user string, password string, salt []byte) string {
+	inner := md5.Sum([]byte(password + user))
+	outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+	return "md5" + hex.EncodeToString(outer[:])
+}
+
+// writeErrorResponse sends a fatal authentication error to the client
+func writeErrorResponse(client net.Conn, errorMessage string) {
+	var buf bytes.Buffer
+	for _, field := range []struct {
+		code  byte
+		value string
+	}{
+		{'S', "FATAL"},
+		{'V', "FATAL"},
+		{'C', "28000"},
+		{'M', errorMessage},
+	} {
+		buf.WriteByte(field.code)
+		buf.WriteString(field.value)
+		buf.WriteByte(0)
+	}
+	buf.WriteByte(0)
+	client.Write(message('E', buf.Bytes()))
+}
+
+// errorResponseMessage returns the message field of an error response
+func errorResponseMessage(body []byte) string {
+	for _, field := range bytes.Split(body, []byte{0}) {
+		if len(field) > 1 && field[0] == 'M' {
+			return string(field[1:])
+		}
+	}
+	return "unknown error"
+}
@@ -0,0 +1,252 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package port implements session manager's port plugin.
+package port
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/redshift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DatabasePortTestSuite struct {
+	suite.Suite
+	mockContext       *contextmocks.Mock
+	client            net.Conn
+	clientAgentSide   net.Conn
+	server            net.Conn
+	serverAgentSide   net.Conn
+	realUpgradeToTLS  func(context.T, net.Conn, string) (net.Conn, error)
+	realAuthToken     func(string, string, string, *credentials.Credentials) (string, error)
+	realClusterCreds  func(context.T, *redshift.GetClusterCredentialsInput) (*redshift.GetClusterCredentialsOutput, error)
+	clusterCredsInput *redshift.GetClusterCredentialsInput
+}
+
+func (suite *DatabasePortTestSuite) SetupTest() {
+	suite.mockContext = contextmocks.NewMockDefault()
+	suite.client, suite.clientAgentSide = net.Pipe()
+	suite.server, suite.serverAgentSide = net.Pipe()
+
+	suite.realUpgradeToTLS, suite.realAuthToken, suite.realClusterCreds = upgradeToTLS, buildAuthToken, getClusterCredentials
+	upgradeToTLS = func(context context.T, conn net.Conn, serverName string) (net.Conn, error) {
+		return conn, nil
+	}
+	buildAuthToken = func(endpoint, region, dbUser string, creds *credentials.Credentials) (string, error) {
+		return endpoint + "/" + region + "/" + dbUser + "/token", nil
+	}
+	getClusterCredentials = func(context context.T, input *redshift.GetClusterCredentialsInput) (*redshift.GetClusterCredentialsOutput, error) {
+		suite.clusterCredsInput = input
+		return &redshift.GetClusterCredentialsOutput{
+			DbUser:     aws.String("IAM:" + aws.StringValue(input.DbUser)),
+			DbPassword: aws.String("password"),
+		}, nil
+	}
+}
+
+func (suite *DatabasePortTestSuite) TearDownTest() {
+	upgradeToTLS, buildAuthToken, getClusterCredentials = suite.realUpgradeToTLS, suite.realAuthToken, suite.realClusterCreds
+	suite.client.Close()
+	suite.server.Close()
+}
+
+// Execute the test suite
+func TestDatabasePortTestSuite(t *testing.T) {
+	suite.Run(t, new(DatabasePortTestSuite))
+}
+
+func (suite *DatabasePortTestSuite) authenticate(portParameters PortParameters) chan error {
+	authenticator, err := newDatabaseAuthenticator(suite.mockContext, portParameters, "db.example.com")
+	assert.Nil(suite.T(), err)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := authenticator.Authenticate(suite.clientAgentSide, suite.serverAgentSide)
+		result <- err
+	}()
+	return result
+}
+
+func (suite *DatabasePortTestSuite) TestAuthenticate_PostgresCleartextPassword() {
+	result := suite.authenticate(PortParameters{PortNumber: "5432", DatabaseEngine: PostgresEngine, DatabaseUser: "dbuser"})
+
+	// client asks for TLS, which is declined because the session is already encrypted
+	suite.client.Write(sslRequestMessage())
+	assert.Equal(suite.T(), []byte{'N'}, readBytes(suite.client, 1))
+	go suite.client.Write(startupMessage("user", "someone", "database", "orders"))
+
+	// agent upgrades the database connection and sends the startup message with the configured user
+	assert.Equal(suite.T(), sslRequestMessage(), readBytes(suite.server, 8))
+	suite.server.Write([]byte{'S'})
+	code, parameters := readStartup(suite.server)
+	assert.Equal(suite.T(), uint32(postgresProtocolVersion), code)
+	assert.Equal(suite.T(), "dbuser", parameters.get("user"))
+	assert.Equal(suite.T(), "orders", parameters.get("database"))
+	assert.Equal(suite.T(), []string{"user", "database"}, parameters.keys)
+
+	suite.server.Write(message('R', authRequest(postgresAuthCleartextPassword)))
+	messageType, body, _ := readMessage(suite.server)
+	assert.Equal(suite.T(), byte('p'), messageType)
+	assert.Equal(suite.T(), "db.example.com:5432/us-east-1/dbuser/token\x00", string(body))
+
+	go suite.server.Write(message('R', authRequest(postgresAuthOk)))
+	messageType, body, _ = readMessage(suite.client)
+	assert.Equal(suite.T(), byte('R'), messageType)
+	assert.Equal(suite.T(), authRequest(postgresAuthOk), body)
+	assert.Nil(suite.T(), <-result)
+}
+
+func (suite *DatabasePortTestSuite) TestAuthenticate_RedshiftMD5Password() {
+	result := suite.authenticate(PortParameters{PortNumber: "5439", DatabaseEngine: RedshiftEngine, DatabaseUser: "dbuser", DatabaseClusterIdentifier: "cluster"})
+
+	go suite.client.Write(startupMessage("user", "someone", "database", "dev"))
+	readBytes(suite.server, 8)
+	suite.server.Write([]byte{'S'})
+	_, parameters := readStartup(suite.server)
+	assert.Equal(suite.T(), "IAM:dbuser", parameters.get("user"))
+	assert.Equal(suite.T(), "cluster", aws.StringValue(suite.clusterCredsInput.ClusterIdentifier))
+	assert.Equal(suite.T(), "dev", aws.StringValue(suite.clusterCredsInput.DbName))
+
+	salt := []byte{1, 2, 3, 4}
+	suite.server.Write(message('R', append(authRequest(postgresAuthMD5Password), salt...)))
+	_, body, _ := readMessage(suite.server)
+	assert.Equal(suite.T(), md5Password("IAM:dbuser", "password", salt)+"\x00", string(body))
+
+	go suite.server.Write(message('R', authRequest(postgresAuthOk)))
+	readMessage(suite.client)
+	assert.Nil(suite.T(), <-result)
+}
+
+func (suite *DatabasePortTestSuite) TestAuthenticate_DatabaseRejectsConnection() {
+	result := suite.authenticate(PortParameters{PortNumber: "5432", DatabaseEngine: PostgresEngine, DatabaseUser: "dbuser"})
+
+	go suite.client.Write(startupMessage("user", "someone"))
+	readBytes(suite.server, 8)
+	suite.server.Write([]byte{'S'})
+	readStartup(suite.server)
+
+	go suite.server.Write(message('E', []byte("SFATAL\x00MPAM authentication failed\x00\x00")))
+	messageType, body, _ := readMessage(suite.client)
+	assert.Equal(suite.T(), byte('E'), messageType)
+	assert.Equal(suite.T(), "PAM authentication failed", errorResponseMessage(body))
+	assert.EqualError(suite.T(), <-result, "database rejected the connection: PAM authentication failed")
+}
+
+func (suite *DatabasePortTestSuite) TestAuthenticate_CredentialsError() {
+	buildAuthToken = func(endpoint, region, dbUser string, creds *credentials.Credentials) (string, error) {
+		return "", errors.New("no credentials")
+	}
+	result := suite.authenticate(PortParameters{PortNumber: "5432", DatabaseEngine: PostgresEngine, DatabaseUser: "dbuser"})
+
+	go suite.client.Write(startupMessage("user", "someone"))
+	messageType, body, _ := readMessage(suite.client)
+	assert.Equal(suite.T(), byte('E'), messageType)
+	assert.Equal(suite.T(), "failed to get database credentials: no credentials", errorResponseMessage(body))
+	assert.Error(suite.T(), <-result)
+}
+
+func (suite *DatabasePortTestSuite) TestAuthenticate_DatabaseWithoutTLS() {
+	result := suite.authenticate(PortParameters{PortNumber: "5432", DatabaseEngine: PostgresEngine, DatabaseUser: "dbuser"})
+
+	go suite.client.Write(startupMessage("user", "someone"))
+	readBytes(suite.server, 8)
+	go suite.server.Write([]byte{'N'})
+	messageType, _, _ := readMessage(suite.client)
+	assert.Equal(suite.T(), byte('E'), messageType)
+	assert.EqualError(suite.T(), <-result, "database db.example.com does not accept TLS connections")
+}
+
+func TestNewDatabaseAuthenticator(t *testing.T) {
+	mockContext := contextmocks.NewMockDefault()
+	tests := []struct {
+		parameters PortParameters
+		isError    bool
+	}{
+		{PortParameters{DatabaseEngine: PostgresEngine, DatabaseUser: "dbuser"}, false},
+		{PortParameters{DatabaseEngine: RedshiftEngine, DatabaseUser: "dbuser", DatabaseClusterIdentifier: "cluster"}, false},
+		{PortParameters{DatabaseEngine: RedshiftEngine, DatabaseUser: "dbuser"}, true},
+		{PortParameters{DatabaseEngine: PostgresEngine}, true},
+		{PortParameters{DatabaseEngine: MySQLEngine, DatabaseUser: "dbuser"}, true},
+		{PortParameters{DatabaseEngine: MariaDBEngine, DatabaseUser: "dbuser"}, true},
+		{PortParameters{DatabaseEngine: "oracle", DatabaseUser: "dbuser"}, true},
+	}
+
+	for _, test := range tests {
+		_, err := newDatabaseAuthenticator(mockContext, test.parameters, "db.example.com")
+		assert.Equal(t, test.isError, err != nil, test.parameters)
+	}
+}
+
+func TestNewDatabaseAuthenticator_RejectsMySQL(t *testing.T) {
+	_, err := newDatabaseAuthenticator(contextmocks.NewMockDefault(), PortParameters{DatabaseEngine: MySQLEngine, DatabaseUser: "dbuser"}, "db.example.com")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "IAM authentication token injection is not supported for mysql databases")
+}
+
+func TestGetSession_DatabasePortForwarding(t *testing.T) {
+	mockContext := contextmocks.NewMockDefault()
+	portParameters := PortParameters{PortNumber: "5432", Host: "db.example.com", Type: "DatabasePortForwarding", DatabaseEngine: PostgresEngine, DatabaseUser: "dbuser"}
+
+	session, err := GetSession(mockContext, portParameters, make(chan struct{}), "1.2.0.0", sessionId)
+	assert.Nil(t, err)
+	assert.IsType(t, &MuxPortSession{}, session)
+	assert.NotNil(t, session.(*MuxPortSession).authenticator)
+	assert.Equal(t, "db.example.com:5432", session.(*MuxPortSession).destinationAddress)
+
+	_, err = GetSession(mockContext, portParameters, make(chan struct{}), "1.0.0.0", sessionId)
+	assert.Error(t, err)
+}
+
+func sslRequestMessage() []byte {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgresSSLRequest)
+	return request
+}
+
+func startupMessage(keyValues ...string) []byte {
+	parameters := &startupParameters{values: make(map[string]string)}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		parameters.set(keyValues[i], keyValues[i+1])
+	}
+	return parameters.startupMessage()
+}
+
+func authRequest(authType uint32) []byte {
+	request := make([]byte, 4)
+	binary.BigEndian.PutUint32(request, authType)
+	return request
+}
+
+func readBytes(conn net.Conn, length int) []byte {
+	data := make([]byte, length)
+	io.ReadFull(conn, data)
+	return data
+}
+
+func readStartup(conn net.Conn) (uint32, *startupParameters) {
+	length := binary.BigEndian.Uint32(readBytes(conn, 4))
+	body := readBytes(conn, int(length)-4)
+	parameters, _ := parseStartupParameters(body[4:])
+	return binary.BigEndian.Uint32(body[0:4]), parameters
+}
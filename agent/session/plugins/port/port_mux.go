@@ -63,6 +63,9 @@ type MuxPortSession struct {
 	socketFile         string
 	muxServer          *MuxServer
 	mgsConn            *MgsConn
+	authenticator      connectionAuthenticator
+	// proxy connects the streams of dynamic port forwarding sessions to the destinations they request
+	proxy *socksProxy
 }
 
 func (c *MgsConn) close() {
@@ -270,6 +273,10 @@ func (p *MuxPortSession) handleServerConnections(ctx context.Context, dataChanne
 
 			log.Debugf("Started a new mux stream %d\n", stream.ID())
 
+			if p.proxy != nil {
+				go p.proxyStream(stream)
+				continue
+			}
 			if conn, err := net.Dial("tcp", p.destinationAddress); err == nil {
 				log.Tracef("Established connection to port %s", p.destinationAddress)
 				go func() {
@@ -278,6 +285,16 @@ func (p *MuxPortSession) handleServerConnections(ctx context.Context, dataChanne
 							log.Errorf("Handle data transfer crashed with message: %v", r)
 						}
 					}()
+					if p.authenticator != nil {
+						authenticatedConn, err := p.authenticator.Authenticate(stream, conn)
+						if err != nil {
+							log.Errorf("Unable to authenticate connection to server: %v", err)
+							stream.Close()
+							conn.Close()
+							return
+						}
+						conn = authenticatedConn
+					}
 					handleDataTransfer(stream, conn)
 				}()
 			} else {
@@ -291,6 +308,23 @@ func (p *MuxPortSession) handleServerConnections(ctx context.Context, dataChanne
 	}
 }
 
+// proxyStream connects the stream to the destination it requests and transfers the data between them
+func (p *MuxPortSession) proxyStream(stream *smux.Stream) {
+	log := p.context.Log()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Handle proxy stream crashed with message: %v", r)
+		}
+	}()
+	conn, err := p.proxy.Connect(stream)
+	if err != nil {
+		log.Warnf("Unable to proxy mux stream %d: %v", stream.ID(), err)
+		stream.Close()
+		return
+	}
+	handleDataTransfer(stream, conn)
+}
+
 // handleDataTransfer launches routines to transfer data between source and destination
 func handleDataTransfer(dst io.ReadWriteCloser, src io.ReadWriteCloser) {
 	var wait sync.WaitGroup
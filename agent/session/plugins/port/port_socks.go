@@ -0,0 +1,254 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package port implements session manager's port plugin.
+package port
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+)
+
+const (
+	socksVersion = 5
+
+	socksNoAuthentication     = 0
+	socksNoAcceptableMethods  = 0xff
+	socksCommandConnect       = 1
+	socksAddressIPv4          = 1
+	socksAddressDomainName    = 3
+	socksAddressIPv6          = 4
+	socksSucceeded            = 0
+	socksGeneralFailure       = 1
+	socksNotAllowedByRuleset  = 2
+	socksHostUnreachable      = 4
+	socksConnectionRefused    = 5
+	socksCommandNotSupported  = 7
+	socksAddressNotSupported  = 8
+	socksMaxHandshakeDuration = 30 * time.Second
+)
+
+// errSocksForbidden is returned for the destinations the port plugin does not forward to
+var errSocksForbidden = errors.New("forwarding to the destination is forbidden")
+
+var dialSocksDestination = func(address string) (net.Conn, error) {
+	return net.DialTimeout("tcp", address, socksMaxHandshakeDuration)
+}
+
+// socksProxy connects the streams of a dynamic port forwarding session to the destinations they request with the
+// SOCKS5 protocol, only the CONNECT command without authentication is supported as the session is authenticated.
+// The destinations are resolved on the instance and checked against the addresses port forwarding is denied to.
+type socksProxy struct {
+	context         context.T
+	deniedAddresses []net.IP
+}
+
+// NewSocksPortSession returns a new instance of the MuxPortSession which connects every stream to the destination
+// it requests with the SOCKS5 protocol
+func NewSocksPortSession(context context.T, clientVersion string, cancelled chan struct{}, sessionId string) (IPortSession, error) {
+	var plugin = MuxPortSession{
+		context:       context,
+		clientVersion: clientVersion,
+		cancelled:     cancelled,
+		sessionId:     sessionId,
+		proxy:         newSocksProxy(context)}
+	return &plugin, nil
+}
+
+// newSocksProxy returns a socksProxy denying the addresses of the agent configuration, IMDS and the VPC DNS
+func newSocksProxy(context context.T) *socksProxy {
+	appConfig := context.AppConfig()
+	dnsAddress, err := dnsRoutingAddress(context.Log(), &appConfig)
+	if err != nil {
+		context.Log().Warnf("Error retrieving vpc dns address: %v", err)
+	}
+	proxy := &socksProxy{context: context}
+	for _, address := range append(appConfig.Mgs.DeniedPortForwardingRemoteIPs, dnsAddress...) {
+		if ip := net.ParseIP(address); ip != nil {
+			proxy.deniedAddresses = append(proxy.deniedAddresses, ip)
+		}
+	}
+	return proxy
+}
+
+// Connect runs the SOCKS5 handshake of the client stream and returns the connection to the requested destination,
+// the client is sent the failure reply when the destination cannot be connected to
+func (p *socksProxy) Connect(client net.Conn) (net.Conn, error) {
+	client.SetDeadline(time.Now().Add(socksMaxHandshakeDuration))
+	defer client.SetDeadline(time.Time{})
+
+	if err := negotiateSocksMethod(client); err != nil {
+		return nil, err
+	}
+	host, port, replyCode, err := readSocksRequest(client)
+	if err != nil {
+		if replyCode != socksSucceeded {
+			writeSocksReply(client, replyCode, nil)
+		}
+		return nil, err
+	}
+
+	server, err := p.dial(host, port)
+	if err != nil {
+		writeSocksReply(client, socksReplyCode(err), nil)
+		return nil, fmt.Errorf("unable to connect to %v: %v", net.JoinHostPort(host, port), err)
+	}
+	if err = writeSocksReply(client, socksSucceeded, server.LocalAddr()); err != nil {
+		server.Close()
+		return nil, err
+	}
+	p.context.Log().Debugf("Proxying connection to %v", net.JoinHostPort(host, port))
+	return server, nil
+}
+
+// dial resolves the destination and connects to its first allowed address, the resolved address is dialed so that
+// the host cannot resolve to a denied address once checked
+func (p *socksProxy) dial(host string, port string) (conn net.Conn, err error) {
+	addresses, err := lookupHost(host)
+	if err != nil {
+		return nil, err
+	}
+	err = errSocksForbidden
+	for _, address := range addresses {
+		if p.isDenied(net.ParseIP(address)) {
+			continue
+		}
+		if conn, err = dialSocksDestination(net.JoinHostPort(address, port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// isDenied returns true if port forwarding to the address is denied, the unspecified addresses reach the instance
+// itself and are denied like the denied addresses
+func (p *socksProxy) isDenied(ip net.IP) bool {
+	if ip == nil || ip.IsUnspecified() {
+		return true
+	}
+	for _, denied := range p.deniedAddresses {
+		if ip.Equal(denied) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateSocksMethod reads the methods offered by the client and selects the one without authentication
+func negotiateSocksMethod(client net.Conn) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(client, header); err != nil {
+		return err
+	}
+	if header[0] != socksVersion {
+		return fmt.Errorf("unsupported SOCKS version %d", header[0])
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(client, methods); err != nil {
+		return err
+	}
+	for _, method := range methods {
+		if method == socksNoAuthentication {
+			_, err := client.Write([]byte{socksVersion, socksNoAuthentication})
+			return err
+		}
+	}
+	client.Write([]byte{socksVersion, socksNoAcceptableMethods})
+	return errors.New("the SOCKS client does not offer to connect without authentication")
+}
+
+// readSocksRequest reads the destination of a CONNECT request, the reply code is set when the request is rejected
+func readSocksRequest(client net.Conn) (host string, port string, replyCode byte, err error) {
+	header := make([]byte, 4)
+	if _, err = io.ReadFull(client, header); err != nil {
+		return
+	}
+	if header[0] != socksVersion {
+		return "", "", socksSucceeded, fmt.Errorf("unsupported SOCKS version %d", header[0])
+	}
+	switch header[3] {
+	case socksAddressIPv4, socksAddressIPv6:
+		address := make([]byte, net.IPv4len)
+		if header[3] == socksAddressIPv6 {
+			address = make([]byte, net.IPv6len)
+		}
+		if _, err = io.ReadFull(client, address); err != nil {
+			return
+		}
+		host = net.IP(address).String()
+	case socksAddressDomainName:
+		length := make([]byte, 1)
+		if _, err = io.ReadFull(client, length); err != nil {
+			return
+		}
+		name := make([]byte, length[0])
+		if _, err = io.ReadFull(client, name); err != nil {
+			return
+		}
+		host = string(name)
+	default:
+		return "", "", socksAddressNotSupported, fmt.Errorf("unsupported SOCKS address type %d", header[3])
+	}
+	portBytes := make([]byte, 2)
+	if _, err = io.ReadFull(client, portBytes); err != nil {
+		return
+	}
+	port = strconv.Itoa(int(binary.BigEndian.Uint16(portBytes)))
+
+	if header[1] != socksCommandConnect {
+		return "", "", socksCommandNotSupported, fmt.Errorf("unsupported SOCKS command %d", header[1])
+	}
+	return host, port, socksSucceeded, nil
+}
+
+// writeSocksReply writes the reply to a request, the bound address is the address of the agent end of the
+// connection to the destination and is left unspecified on failures
+func writeSocksReply(client net.Conn, replyCode byte, bound net.Addr) error {
+	ip, port := net.IPv4zero.To4(), 0
+	if tcpAddr, ok := bound.(*net.TCPAddr); ok {
+		ip, port = tcpAddr.IP, tcpAddr.Port
+	}
+	reply := []byte{socksVersion, replyCode, 0}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		reply = append(append(reply, socksAddressIPv4), ipv4...)
+	} else {
+		reply = append(append(reply, socksAddressIPv6), ip.To16()...)
+	}
+	reply = append(reply, byte(port>>8), byte(port))
+	_, err := client.Write(reply)
+	return err
+}
+
+// socksReplyCode returns the reply code of the failure to connect to a destination
+func socksReplyCode(err error) byte {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, errSocksForbidden):
+		return socksNotAllowedByRuleset
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return socksConnectionRefused
+	case errors.As(err, &dnsErr), errors.As(err, &netErr) && netErr.Timeout():
+		return socksHostUnreachable
+	default:
+		return socksGeneralFailure
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package port implements session manager's port plugin.
+package port
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+type socksConnectResult struct {
+	conn net.Conn
+	err  error
+}
+
+// startSocksConnect runs the handshake of the proxy with the returned client end of the stream
+func startSocksConnect(t *testing.T, proxy *socksProxy) (net.Conn, chan socksConnectResult) {
+	client, stream := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+	result := make(chan socksConnectResult, 1)
+	go func() {
+		conn, err := proxy.Connect(stream)
+		result <- socksConnectResult{conn, err}
+	}()
+	return client, result
+}
+
+func stubLookupHost(t *testing.T, addresses map[string][]string) {
+	realLookupHost := lookupHost
+	t.Cleanup(func() { lookupHost = realLookupHost })
+	lookupHost = func(host string) ([]string, error) {
+		if resolved, found := addresses[host]; found {
+			return resolved, nil
+		}
+		return []string{host}, nil
+	}
+}
+
+func newTestSocksProxy(deniedAddresses ...string) *socksProxy {
+	proxy := &socksProxy{context: contextmocks.NewMockDefault()}
+	for _, address := range deniedAddresses {
+		proxy.deniedAddresses = append(proxy.deniedAddresses, net.ParseIP(address))
+	}
+	return proxy
+}
+
+// startEchoServer returns the port of a server echoing the data it receives
+func startEchoServer(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func socksRequest(command byte, addressType byte, address []byte, port int) []byte {
+	request := []byte{socksVersion, command, 0, addressType}
+	if addressType == socksAddressDomainName {
+		request = append(request, byte(len(address)))
+	}
+	request = append(request, address...)
+	portBytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(portBytes, uint16(port))
+	return append(request, portBytes...)
+}
+
+// negotiate offers the methods to the proxy and returns the selected one
+func negotiate(t *testing.T, client net.Conn, methods ...byte) byte {
+	_, err := client.Write(append([]byte{socksVersion, byte(len(methods))}, methods...))
+	assert.NoError(t, err)
+	selected := make([]byte, 2)
+	_, err = io.ReadFull(client, selected)
+	assert.NoError(t, err)
+	return selected[1]
+}
+
+func readReplyCode(t *testing.T, client net.Conn) byte {
+	reply := make([]byte, 10)
+	_, err := io.ReadFull(client, reply)
+	assert.NoError(t, err)
+	return reply[1]
+}
+
+func TestSocksProxy_ConnectDomainName(t *testing.T) {
+	stubLookupHost(t, map[string][]string{"echo.example.com": {"127.0.0.1"}})
+	port := startEchoServer(t)
+	client, result := startSocksConnect(t, newTestSocksProxy())
+
+	assert.Equal(t, byte(socksNoAuthentication), negotiate(t, client, 2, socksNoAuthentication))
+	_, err := client.Write(socksRequest(socksCommandConnect, socksAddressDomainName, []byte("echo.example.com"), port))
+	assert.NoError(t, err)
+	assert.Equal(t, byte(socksSucceeded), readReplyCode(t, client))
+
+	connected := <-result
+	assert.NoError(t, connected.err)
+	defer connected.conn.Close()
+	assert.Equal(t, port, connected.conn.RemoteAddr().(*net.TCPAddr).Port)
+}
+
+func TestSocksProxy_DeniedAddress(t *testing.T) {
+	stubLookupHost(t, map[string][]string{"metadata.example.com": {"169.254.169.254"}})
+	client, result := startSocksConnect(t, newTestSocksProxy("169.254.169.254"))
+
+	negotiate(t, client, socksNoAuthentication)
+	// the destination is checked once resolved on the instance
+	_, err := client.Write(socksRequest(socksCommandConnect, socksAddressDomainName, []byte("metadata.example.com"), 80))
+	assert.NoError(t, err)
+	assert.Equal(t, byte(socksNotAllowedByRuleset), readReplyCode(t, client))
+	assert.Error(t, (<-result).err)
+}
+
+func TestSocksProxy_UnspecifiedAddressDenied(t *testing.T) {
+	client, result := startSocksConnect(t, newTestSocksProxy())
+
+	negotiate(t, client, socksNoAuthentication)
+	_, err := client.Write(socksRequest(socksCommandConnect, socksAddressIPv4, net.IPv4zero.To4(), 22))
+	assert.NoError(t, err)
+	assert.Equal(t, byte(socksNotAllowedByRuleset), readReplyCode(t, client))
+	assert.Error(t, (<-result).err)
+}
+
+func TestSocksProxy_ConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	client, result := startSocksConnect(t, newTestSocksProxy())
+
+	negotiate(t, client, socksNoAuthentication)
+	_, err = client.Write(socksRequest(socksCommandConnect, socksAddressIPv4, net.ParseIP("127.0.0.1").To4(), port))
+	assert.NoError(t, err)
+	assert.Equal(t, byte(socksConnectionRefused), readReplyCode(t, client))
+	assert.Error(t, (<-result).err)
+}
+
+func TestSocksProxy_AuthenticationRequired(t *testing.T) {
+	client, result := startSocksConnect(t, newTestSocksProxy())
+
+	assert.Equal(t, byte(socksNoAcceptableMethods), negotiate(t, client, 2))
+	assert.Error(t, (<-result).err)
+}
+
+func TestSocksProxy_CommandNotSupported(t *testing.T) {
+	client, result := startSocksConnect(t, newTestSocksProxy())
+
+	negotiate(t, client, socksNoAuthentication)
+	// BIND
+	_, err := client.Write(socksRequest(2, socksAddressIPv4, net.ParseIP("127.0.0.1").To4(), 80))
+	assert.NoError(t, err)
+	assert.Equal(t, byte(socksCommandNotSupported), readReplyCode(t, client))
+	assert.Error(t, (<-result).err)
+}
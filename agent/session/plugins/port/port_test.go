@@ -30,6 +30,7 @@ import (
 	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
+	"github.com/aws/amazon-ssm-agent/agent/session/hostidentity"
 	portSessionMock "github.com/aws/amazon-ssm-agent/agent/session/plugins/port/mocks"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/common/identity"
@@ -152,6 +153,52 @@ func TestInitializeParametersWhenHostIsProvided(t *testing.T) {
 	mockDataChannel.AssertExpectations(t)
 }
 
+func TestInitializeParametersWhenPortTypeIsDynamicPortForwarding(t *testing.T) {
+	mockDataChannel := &dataChannelMock.IDataChannel{}
+	mockDataChannel.On("GetClientVersion").Return(clientVersion)
+
+	portPlugin := &PortPlugin{
+		context:     contextmocks.NewMockDefaultWithConfig(appconfig.DefaultConfig()),
+		dataChannel: mockDataChannel,
+		cancelled:   make(chan struct{}),
+	}
+	mockIdentity := &identityMock.IAgentIdentityInner{}
+	newEC2Identity = func(log log.T, _ *appconfig.SsmagentConfig) identity.IAgentIdentityInner {
+		return mockIdentity
+	}
+	newECSIdentity = newEC2Identity
+	mockIdentity.On("IsIdentityEnvironment").Return(true)
+	mockMetadata := &identityMock.IMetadataIdentity{}
+	getMetadataIdentity = func(agentIdentity identity.IAgentIdentityInner) (identity.IMetadataIdentity, bool) {
+		return mockMetadata, true
+	}
+	mockMetadata.On("VpcPrimaryCIDRBlock").Return(map[string][]string{"ipv4": {"172.31.0.0/16"}}, nil)
+
+	// the port number is requested by each connection of the client
+	assert.NoError(t, portPlugin.initializeParameters(contracts.Configuration{Properties: map[string]interface{}{"type": "DynamicPortForwarding"}, SessionId: sessionId}))
+	assert.IsType(t, &MuxPortSession{}, portPlugin.session)
+	muxPortSession := portPlugin.session.(*MuxPortSession)
+	assert.NotNil(t, muxPortSession.proxy)
+	assert.True(t, muxPortSession.proxy.isDenied(net.ParseIP("172.31.0.2")))
+	assert.True(t, muxPortSession.proxy.isDenied(net.ParseIP(appconfig.DefaultDeniedPortForwardingRemoteIPs[0])))
+	assert.False(t, muxPortSession.proxy.isDenied(net.ParseIP("172.31.10.20")))
+	mockDataChannel.AssertExpectations(t)
+}
+
+func TestInitializeParametersWhenPortTypeIsDynamicPortForwardingAndOldClient(t *testing.T) {
+	mockDataChannel := &dataChannelMock.IDataChannel{}
+	mockDataChannel.On("GetClientVersion").Return("1.0.0")
+
+	portPlugin := &PortPlugin{
+		context:     contextmocks.NewMockDefault(),
+		dataChannel: mockDataChannel,
+		cancelled:   make(chan struct{}),
+	}
+
+	assert.Error(t, portPlugin.initializeParameters(contracts.Configuration{Properties: map[string]interface{}{"type": "DynamicPortForwarding"}, SessionId: sessionId}))
+	assert.Nil(t, portPlugin.session)
+}
+
 func (suite *PortTestSuite) SetupTest() {
 	mockContext := contextmocks.NewMockDefault()
 	mockCancelFlag := &taskmocks.MockCancelFlag{}
@@ -197,6 +244,51 @@ func (suite *PortTestSuite) TestGetPluginParameters() {
 }
 
 // Testing Execute
+func (suite *PortTestSuite) TestGetHandshakeActionsWithoutNonce() {
+	actions, err := suite.plugin.GetHandshakeActions(configurationPF.Properties)
+
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), actions)
+}
+
+func (suite *PortTestSuite) TestGetHandshakeActionsWithNonce() {
+	realNewHostIdentityAttestation := newHostIdentityAttestation
+	defer func() { newHostIdentityAttestation = realNewHostIdentityAttestation }()
+	attestation := &hostidentity.Attestation{InstanceId: "i-1234567890abcdef0"}
+	newHostIdentityAttestation = func(_ context.T, nonce string) (*hostidentity.Attestation, error) {
+		assert.Equal(suite.T(), "c2Vzc2lvbi1ub25jZS0xMjM0NTY=", nonce)
+		return attestation, nil
+	}
+
+	actions, err := suite.plugin.GetHandshakeActions(map[string]interface{}{
+		"portNumber":        "22",
+		"hostIdentityNonce": "c2Vzc2lvbi1ub25jZS0xMjM0NTY=",
+	})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []mgsContracts.RequestedClientAction{
+		{
+			ActionType:       mgsContracts.HostIdentity,
+			ActionParameters: attestation,
+		}}, actions)
+}
+
+func (suite *PortTestSuite) TestGetHandshakeActionsWhenAttestationFails() {
+	realNewHostIdentityAttestation := newHostIdentityAttestation
+	defer func() { newHostIdentityAttestation = realNewHostIdentityAttestation }()
+	newHostIdentityAttestation = func(context.T, string) (*hostidentity.Attestation, error) {
+		return nil, errors.New("no SSH host key found")
+	}
+
+	actions, err := suite.plugin.GetHandshakeActions(map[string]interface{}{
+		"portNumber":        "22",
+		"hostIdentityNonce": "c2Vzc2lvbi1ub25jZS0xMjM0NTY=",
+	})
+
+	assert.NotNil(suite.T(), err)
+	assert.Nil(suite.T(), actions)
+}
+
 func (suite *PortTestSuite) TestExecuteWhenCancelFlagIsShutDown() {
 	suite.mockCancelFlag.On("ShutDown").Return(true)
 	suite.mockIohandler.On("MarkAsShutdown").Return(nil)
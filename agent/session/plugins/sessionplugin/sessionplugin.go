@@ -24,6 +24,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/winevent"
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
@@ -42,6 +43,11 @@ type ISessionPlugin interface {
 	InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error
 }
 
+// IHandshakeActionsProvider is implemented by session manager plugins requesting additional client actions in the handshake
+type IHandshakeActionsProvider interface {
+	GetHandshakeActions(parameters interface{}) ([]mgsContracts.RequestedClientAction, error)
+}
+
 // SessionPlugin is the wrapper for all session manager plugins and implements all functions of Runpluginutil.T interface
 type SessionPlugin struct {
 	context       context.T
@@ -79,6 +85,11 @@ func (p *SessionPlugin) Execute(
 	if err = dataChannel.SendAgentSessionStateMessage(p.context.Log(), mgsContracts.Connected); err != nil {
 		log.Errorf("Unable to send AgentSessionState message with session status %s. %s", mgsContracts.Connected, err)
 	}
+	winevent.Write(log, winevent.SessionOpened, winevent.Fields{
+		"sessionId":   config.SessionId,
+		"sessionType": config.PluginName,
+		"runAsUser":   config.RunAsUser,
+	})
 
 	encryptionEnabled := p.isEncryptionEnabled(kmsKeyId, config.PluginName)
 	sessionTypeRequest := mgsContracts.SessionTypeRequest{
@@ -105,7 +116,16 @@ func (p *SessionPlugin) Execute(
 
 			dataChannel.SetSeparateOutputPayload(separateOutPutStream)
 		}
-		if err = dataChannel.PerformHandshake(log, kmsKeyId, encryptionEnabled, sessionTypeRequest); err != nil {
+		var clientActions []mgsContracts.RequestedClientAction
+		if actionsProvider, ok := p.sessionPlugin.(IHandshakeActionsProvider); ok {
+			if clientActions, err = actionsProvider.GetHandshakeActions(config.Properties); err != nil {
+				errorString := fmt.Errorf("Fail to get handshake actions: %v", err)
+				output.MarkAsFailed(errorString)
+				log.Error(errorString)
+				return
+			}
+		}
+		if err = dataChannel.PerformHandshake(log, kmsKeyId, encryptionEnabled, sessionTypeRequest, clientActions...); err != nil {
 			errorString := fmt.Errorf("Encountered error while initiating handshake. %s", err)
 			output.MarkAsFailed(errorString)
 			log.Error(errorString)
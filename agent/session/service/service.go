@@ -31,6 +31,8 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/circuitbreaker"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/ratelimit"
 	mgsconfig "github.com/aws/amazon-ssm-agent/agent/session/config"
 	"github.com/aws/aws-sdk-go/aws"
 	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
@@ -77,10 +79,10 @@ func NewService(context context.T, mgsConfig appconfig.MgsConfig, connectionTime
 
 	// capture Transport so we can use it to cancel requests
 	tr := network.GetDefaultTransport(log, context.AppConfig())
-	tr.DialContext = (&net.Dialer{
+	tr.DialContext = network.GetDialContext(context.AppConfig(), &net.Dialer{
 		Timeout:   connectionTimeout,
 		KeepAlive: 0,
-	}).DialContext
+	})
 
 	return &MessageGatewayService{
 		context: context,
@@ -106,7 +108,7 @@ var makeRestcall = func(log log.T, appConfig appconfig.SsmagentConfig, request [
 	tr := network.GetDefaultTransport(log, appConfig)
 	client := &http.Client{
 		Timeout:   mgsClientTimeout,
-		Transport: tr,
+		Transport: circuitbreaker.NewTransport(log, appConfig, ratelimit.NewTransport(log, appConfig, tr)),
 	}
 
 	resp, err := client.Do(httpRequest)
@@ -0,0 +1,281 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package sessioncredentials provides AWS credentials scoped to a single session,
+// so commands run in a session do not use the instance credentials.
+//
+// The credentials are served to the session by a credential_process reading a file the agent rewrites
+// before the credentials expire, so sessions outliving the role duration keep working.
+package sessioncredentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const (
+	// SessionIdTagKey is the session tag holding the id of the session the credentials are issued for
+	SessionIdTagKey = "SessionId"
+	// InstanceIdTagKey is the session tag holding the id of the instance the credentials are issued for
+	InstanceIdTagKey = "InstanceId"
+
+	roleSessionNameMaxLength = 64
+
+	credentialsFileName       = "credentials.json"
+	configFileName            = "config"
+	sharedCredentialsFileName = "credentials"
+
+	refreshRetryDelay = time.Minute
+)
+
+// roleSessionNameInvalidChars matches the characters STS does not allow in a role session name
+var roleSessionNameInvalidChars = regexp.MustCompile(`[^\w+=,.@-]`)
+
+var assumeRole = func(context context.T, input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+	appConfig := context.AppConfig()
+	stsSession, err := session.NewSession(sdkutil.AwsConfig(context, "sts"))
+	if err != nil {
+		return nil, fmt.Errorf("Error creating new aws sdk session: %s", err)
+	}
+	stsSession.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
+	return sts.New(stsSession).AssumeRole(input)
+}
+
+var chown = os.Chown
+
+var credentialsDir = os.TempDir
+
+// processCredentials is the output format of a credential_process
+type processCredentials struct {
+	Version         int
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+// SessionCredentials are the refreshed credentials of a session
+type SessionCredentials struct {
+	context   context.T
+	sessionId string
+	input     *sts.AssumeRoleInput
+	dir       string
+	uid       int
+	gid       int
+	region    string
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
+}
+
+// Start assumes the configured session role for the session and keeps refreshing the credentials until Stop is called.
+// The credential files are owned by uid and gid, -1 keeps the owner. It returns nil when no session role is configured.
+func Start(context context.T, sessionId string, uid int, gid int) (*SessionCredentials, error) {
+	config := context.AppConfig().Mgs.SessionCredentials
+	if config.RoleArn == "" {
+		return nil, nil
+	}
+
+	instanceId, err := context.Identity().InstanceID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance id: %v", err)
+	}
+	region, err := context.Identity().Region()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get region: %v", err)
+	}
+
+	dir, err := ioutil.TempDir(credentialsDir(), "ssm-session-credentials-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session credentials directory: %v", err)
+	}
+	credentials := &SessionCredentials{
+		context:   context,
+		sessionId: sessionId,
+		input: &sts.AssumeRoleInput{
+			RoleArn:         aws.String(config.RoleArn),
+			RoleSessionName: aws.String(roleSessionName(sessionId)),
+			DurationSeconds: aws.Int64(int64(config.DurationSeconds)),
+			Tags: []*sts.Tag{
+				{Key: aws.String(SessionIdTagKey), Value: aws.String(sessionId)},
+				{Key: aws.String(InstanceIdTagKey), Value: aws.String(instanceId)},
+			},
+		},
+		dir:    dir,
+		uid:    uid,
+		gid:    gid,
+		region: region,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	expiration, err := credentials.initialize()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	go credentials.refresh(expiration)
+	return credentials, nil
+}
+
+// Environment returns the environment variables pointing the AWS SDKs and CLI of the session to the credentials
+func (c *SessionCredentials) Environment() []string {
+	if c == nil {
+		return nil
+	}
+	return []string{
+		"AWS_CONFIG_FILE=" + filepath.Join(c.dir, configFileName),
+		"AWS_SHARED_CREDENTIALS_FILE=" + filepath.Join(c.dir, sharedCredentialsFileName),
+		"AWS_PROFILE=default",
+		"AWS_REGION=" + c.region,
+		"AWS_DEFAULT_REGION=" + c.region,
+	}
+}
+
+// Stop stops refreshing the credentials and removes them
+func (c *SessionCredentials) Stop() {
+	if c == nil {
+		return
+	}
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		<-c.done
+		if err := os.RemoveAll(c.dir); err != nil {
+			c.context.Log().Warnf("Failed to remove session credentials of session %s: %v", c.sessionId, err)
+		}
+	})
+}
+
+// initialize writes the profile using the credential_process and the first credentials
+func (c *SessionCredentials) initialize() (time.Time, error) {
+	if err := c.chown(c.dir); err != nil {
+		return time.Time{}, err
+	}
+	// an empty shared credentials file keeps the credentials of the session user from taking precedence
+	if err := c.writeFile(sharedCredentialsFileName, []byte{}); err != nil {
+		return time.Time{}, err
+	}
+	profile := fmt.Sprintf("[default]\ncredential_process = cat \"%s\"\nregion = %s\n",
+		filepath.Join(c.dir, credentialsFileName), c.region)
+	if err := c.writeFile(configFileName, []byte(profile)); err != nil {
+		return time.Time{}, err
+	}
+	return c.assumeRole()
+}
+
+// assumeRole assumes the session role and writes the credentials, it returns when they expire
+func (c *SessionCredentials) assumeRole() (time.Time, error) {
+	roleArn := aws.StringValue(c.input.RoleArn)
+	c.context.Log().Debugf("Assuming role %s for session %s", roleArn, c.sessionId)
+	output, err := assumeRole(c.context, c.input)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to assume session role %s: %v", roleArn, err)
+	}
+	if output.Credentials == nil {
+		return time.Time{}, fmt.Errorf("no credentials returned for session role %s", roleArn)
+	}
+
+	expiration := aws.TimeValue(output.Credentials.Expiration)
+	if expiration.IsZero() {
+		expiration = time.Now().Add(time.Duration(aws.Int64Value(c.input.DurationSeconds)) * time.Second)
+	}
+
+	content, err := json.Marshal(processCredentials{
+		Version:         1,
+		AccessKeyId:     aws.StringValue(output.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(output.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(output.Credentials.SessionToken),
+		Expiration:      expiration,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err = c.writeFile(credentialsFileName, content); err != nil {
+		return time.Time{}, err
+	}
+	return expiration, nil
+}
+
+// refresh assumes the session role again once half of the lifetime of the credentials elapsed
+func (c *SessionCredentials) refresh(expiration time.Time) {
+	defer close(c.done)
+	log := c.context.Log()
+
+	next := time.Until(expiration) / 2
+	for {
+		timer := time.NewTimer(next)
+		select {
+		case <-c.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		newExpiration, err := c.assumeRole()
+		if err != nil {
+			log.Warnf("Failed to refresh credentials of session %s: %v", c.sessionId, err)
+			next = refreshRetryDelay
+			continue
+		}
+		next = time.Until(newExpiration) / 2
+	}
+}
+
+// writeFile replaces the file in the credentials directory, readers never see a partially written file
+func (c *SessionCredentials) writeFile(name string, content []byte) error {
+	tempFile := filepath.Join(c.dir, "."+name)
+	if err := ioutil.WriteFile(tempFile, content, appconfig.ReadWriteAccess); err != nil {
+		return fmt.Errorf("failed to write session credentials: %v", err)
+	}
+	if err := c.chown(tempFile); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile, filepath.Join(c.dir, name)); err != nil {
+		return fmt.Errorf("failed to write session credentials: %v", err)
+	}
+	return nil
+}
+
+// chown hands the file to the session user, the files are only readable by their owner
+func (c *SessionCredentials) chown(path string) error {
+	if c.uid < 0 && c.gid < 0 {
+		return nil
+	}
+	if err := chown(path, c.uid, c.gid); err != nil {
+		return fmt.Errorf("failed to change owner of session credentials: %v", err)
+	}
+	return nil
+}
+
+// roleSessionName builds a role session name accepted by STS from the session id
+func roleSessionName(sessionId string) string {
+	name := roleSessionNameInvalidChars.ReplaceAllString(sessionId, "-")
+	if len(name) > roleSessionNameMaxLength {
+		name = name[:roleSessionNameMaxLength]
+	}
+	return name
+}
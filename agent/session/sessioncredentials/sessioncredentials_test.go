@@ -0,0 +1,176 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sessioncredentials
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	identityMocks "github.com/aws/amazon-ssm-agent/common/identity/mocks"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockContext(roleArn string) *contextmocks.Mock {
+	config := appconfig.SsmagentConfig{}
+	config.Mgs.SessionCredentials = appconfig.SessionCredentialsCfg{RoleArn: roleArn, DurationSeconds: 900}
+	return contextmocks.NewMockDefaultWithConfig(config)
+}
+
+func stubCredentialsDir(t *testing.T) string {
+	dir, _ := ioutil.TempDir("", "sessioncredentials")
+	realCredentialsDir, realChown, realAssumeRole := credentialsDir, chown, assumeRole
+	t.Cleanup(func() {
+		credentialsDir, chown, assumeRole = realCredentialsDir, realChown, realAssumeRole
+		os.RemoveAll(dir)
+	})
+	credentialsDir = func() string { return dir }
+	return dir
+}
+
+func readProcessCredentials(t *testing.T, credentials *SessionCredentials) processCredentials {
+	var result processCredentials
+	content, err := ioutil.ReadFile(filepath.Join(credentials.dir, credentialsFileName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(content, &result))
+	return result
+}
+
+func TestStart_NotConfigured(t *testing.T) {
+	stubCredentialsDir(t)
+	called := false
+	assumeRole = func(context context.T, input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+		called = true
+		return nil, nil
+	}
+
+	credentials, err := Start(mockContext(""), "session-id", -1, -1)
+
+	assert.Nil(t, err)
+	assert.Nil(t, credentials)
+	assert.Empty(t, credentials.Environment())
+	assert.False(t, called)
+	credentials.Stop()
+}
+
+func TestStart(t *testing.T) {
+	stubCredentialsDir(t)
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	var assumeRoleInput *sts.AssumeRoleInput
+	assumeRole = func(context context.T, input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+		assumeRoleInput = input
+		return &sts.AssumeRoleOutput{Credentials: &sts.Credentials{
+			AccessKeyId:     aws.String("accessKey"),
+			SecretAccessKey: aws.String("secretKey"),
+			SessionToken:    aws.String("token"),
+			Expiration:      aws.Time(expiration),
+		}}, nil
+	}
+	var owners []string
+	chown = func(path string, uid int, gid int) error {
+		assert.Equal(t, 1001, uid)
+		assert.Equal(t, 1002, gid)
+		owners = append(owners, filepath.Base(path))
+		return nil
+	}
+
+	credentials, err := Start(mockContext("arn:aws:iam::123456789012:role/session"), "user@example.com-0123456789abcdef0", 1001, 1002)
+
+	assert.Nil(t, err)
+	defer credentials.Stop()
+	assert.Equal(t, []string{
+		"AWS_CONFIG_FILE=" + filepath.Join(credentials.dir, "config"),
+		"AWS_SHARED_CREDENTIALS_FILE=" + filepath.Join(credentials.dir, "credentials"),
+		"AWS_PROFILE=default",
+		"AWS_REGION=" + identityMocks.MockRegion,
+		"AWS_DEFAULT_REGION=" + identityMocks.MockRegion,
+	}, credentials.Environment())
+	assert.Equal(t, []string{filepath.Base(credentials.dir), ".credentials", ".config", ".credentials.json"}, owners)
+
+	profile, _ := ioutil.ReadFile(filepath.Join(credentials.dir, "config"))
+	assert.Equal(t, "[default]\ncredential_process = cat \""+filepath.Join(credentials.dir, "credentials.json")+"\"\nregion = "+identityMocks.MockRegion+"\n", string(profile))
+	assert.Equal(t, processCredentials{
+		Version:         1,
+		AccessKeyId:     "accessKey",
+		SecretAccessKey: "secretKey",
+		SessionToken:    "token",
+		Expiration:      expiration,
+	}, readProcessCredentials(t, credentials))
+
+	assert.Equal(t, "arn:aws:iam::123456789012:role/session", aws.StringValue(assumeRoleInput.RoleArn))
+	assert.Equal(t, "user@example.com-0123456789abcdef0", aws.StringValue(assumeRoleInput.RoleSessionName))
+	assert.Equal(t, int64(900), aws.Int64Value(assumeRoleInput.DurationSeconds))
+	assert.Equal(t, []*sts.Tag{
+		{Key: aws.String(SessionIdTagKey), Value: aws.String("user@example.com-0123456789abcdef0")},
+		{Key: aws.String(InstanceIdTagKey), Value: aws.String(identityMocks.MockInstanceID)},
+	}, assumeRoleInput.Tags)
+}
+
+func TestStart_RefreshesCredentialsBeforeExpiration(t *testing.T) {
+	stubCredentialsDir(t)
+	var mutex sync.Mutex
+	calls := 0
+	assumeRole = func(context context.T, input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls++
+		return &sts.AssumeRoleOutput{Credentials: &sts.Credentials{
+			AccessKeyId: aws.String("accessKey" + strings.Repeat("x", calls)),
+			Expiration:  aws.Time(time.Now().Add(100 * time.Millisecond)),
+		}}, nil
+	}
+
+	credentials, err := Start(mockContext("arn:aws:iam::123456789012:role/session"), "session-id", -1, -1)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return calls >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NotEqual(t, "accessKeyx", readProcessCredentials(t, credentials).AccessKeyId)
+
+	credentials.Stop()
+	assert.NoDirExists(t, credentials.dir)
+}
+
+func TestStart_AssumeRoleFails(t *testing.T) {
+	dir := stubCredentialsDir(t)
+	assumeRole = func(context context.T, input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+		return nil, errors.New("AccessDenied")
+	}
+
+	credentials, err := Start(mockContext("arn:aws:iam::123456789012:role/session"), "session-id", -1, -1)
+
+	assert.EqualError(t, err, "failed to assume session role arn:aws:iam::123456789012:role/session: AccessDenied")
+	assert.Nil(t, credentials)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Empty(t, files)
+}
+
+func TestRoleSessionName(t *testing.T) {
+	assert.Equal(t, "user-name-0123", roleSessionName("user name/0123"))
+	assert.Equal(t, strings.Repeat("a", roleSessionNameMaxLength), roleSessionName(strings.Repeat("a", 100)))
+}
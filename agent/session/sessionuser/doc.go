@@ -0,0 +1,18 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package sessionuser manages the lifecycle of the local user that sessions run as on Linux and macOS when RunAs is
+// not enabled. The user is created on first use with the configured name, uid range, shell and sudo policy.
+// Session workers run in separate processes, so creation and the active session bookkeeping are serialized with a
+// lock file shared by all workers.
+package sessionuser
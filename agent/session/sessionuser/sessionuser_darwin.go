@@ -0,0 +1,81 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package sessionuser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// defaultShell disables interactive logins of the session user, sessions start their own shell
+const defaultShell = "/usr/bin/false"
+
+// createUser creates the session user with sysadminctl, which needs an explicit uid to honor the uid range
+func createUser(config appconfig.SessionUserCfg) error {
+	args := []string{"-addUser", config.Name, "-shell", getShell(config)}
+	if config.UIDMin > 0 {
+		uid, err := findFreeUID(config.UIDMin, config.UIDMax)
+		if err != nil {
+			return err
+		}
+		args = append(args, "-UID", strconv.Itoa(uid))
+	}
+	return runCommand("sysadminctl", args...)
+}
+
+// updateExistingUser resets the shell of an existing session user, older agents created it with a login shell
+func updateExistingUser(config appconfig.SessionUserCfg) error {
+	return runCommand("/usr/bin/dscl", ".", "-create", "/Users/"+config.Name, "UserShell", getShell(config))
+}
+
+func getShell(config appconfig.SessionUserCfg) string {
+	if config.Shell != "" {
+		return config.Shell
+	}
+	return defaultShell
+}
+
+var errNoFreeUID = errors.New("no free uid in the configured uid range")
+
+// findFreeUID returns the lowest uid in the range that no local user has
+func findFreeUID(uidMin int, uidMax int) (int, error) {
+	output, err := execCommand("/usr/bin/dscl", ".", "-list", "/Users", "UniqueID").Output()
+	if err != nil {
+		return 0, err
+	}
+
+	usedUIDs := make(map[int]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if uid, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+			usedUIDs[uid] = true
+		}
+	}
+
+	for uid := uidMin; uid <= uidMax; uid++ {
+		if !usedUIDs[uid] {
+			return uid, nil
+		}
+	}
+	return 0, errNoFreeUID
+}
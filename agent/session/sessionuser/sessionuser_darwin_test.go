@@ -0,0 +1,89 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package sessionuser
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+const testUserList = `_amavisd          83
+root              0
+ssm-user          501
+session-user      4000
+session-admin     4001
+`
+
+func stubExecCommand(commands *[][]string) {
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		*commands = append(*commands, append([]string{name}, args...))
+		if name == "/usr/bin/dscl" && len(args) > 1 && args[1] == "-list" {
+			return exec.Command("printf", "%s", testUserList)
+		}
+		return exec.Command("true")
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	var commands [][]string
+	stubExecCommand(&commands)
+	defer func() { execCommand = exec.Command }()
+
+	assert.Nil(t, createUser(appconfig.SessionUserCfg{Name: "ssm-user"}))
+	assert.Nil(t, createUser(appconfig.SessionUserCfg{Name: "session-user", UIDMin: 4000, UIDMax: 4999, Shell: "/bin/zsh"}))
+
+	assert.Equal(t, [][]string{
+		{"sysadminctl", "-addUser", "ssm-user", "-shell", "/usr/bin/false"},
+		{"/usr/bin/dscl", ".", "-list", "/Users", "UniqueID"},
+		{"sysadminctl", "-addUser", "session-user", "-shell", "/bin/zsh", "-UID", "4002"},
+	}, commands)
+}
+
+func TestCreateUser_NoFreeUID(t *testing.T) {
+	var commands [][]string
+	stubExecCommand(&commands)
+	defer func() { execCommand = exec.Command }()
+
+	err := createUser(appconfig.SessionUserCfg{Name: "session-user", UIDMin: 4000, UIDMax: 4001})
+
+	assert.Equal(t, errNoFreeUID, err)
+	assert.Len(t, commands, 1)
+}
+
+func TestUpdateExistingUser(t *testing.T) {
+	var commands [][]string
+	stubExecCommand(&commands)
+	defer func() { execCommand = exec.Command }()
+
+	assert.Nil(t, updateExistingUser(appconfig.SessionUserCfg{Name: "ssm-user"}))
+
+	assert.Equal(t, [][]string{
+		{"/usr/bin/dscl", ".", "-create", "/Users/ssm-user", "UserShell", "/usr/bin/false"},
+	}, commands)
+}
+
+func TestUpdateExistingUser_Fails(t *testing.T) {
+	execCommand = func(string, ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+	defer func() { execCommand = exec.Command }()
+
+	assert.Error(t, updateExistingUser(appconfig.SessionUserCfg{Name: "ssm-user"}))
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package sessionuser
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// createUser creates the session user with useradd, the uid range overrides the login.defs range
+func createUser(config appconfig.SessionUserCfg) error {
+	args := []string{"-m"}
+	if config.Shell != "" {
+		args = append(args, "-s", config.Shell)
+	}
+	if config.UIDMin > 0 {
+		args = append(args, "-K", fmt.Sprintf("UID_MIN=%d", config.UIDMin), "-K", fmt.Sprintf("UID_MAX=%d", config.UIDMax))
+	}
+	return runCommand("useradd", append(args, config.Name)...)
+}
+
+// updateExistingUser is a no-op, the settings only apply to newly created users
+func updateExistingUser(config appconfig.SessionUserCfg) error {
+	return nil
+}
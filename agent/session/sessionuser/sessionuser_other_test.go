@@ -0,0 +1,42 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package sessionuser
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateUser(t *testing.T) {
+	var commands [][]string
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		commands = append(commands, append([]string{name}, args...))
+		return exec.Command("true")
+	}
+	defer func() { execCommand = exec.Command }()
+
+	assert.Nil(t, createUser(appconfig.SessionUserCfg{Name: "ssm-user"}))
+	assert.Nil(t, createUser(appconfig.SessionUserCfg{Name: "session-user", UIDMin: 4000, UIDMax: 4999, Shell: "/bin/bash"}))
+
+	assert.Equal(t, [][]string{
+		{"useradd", "-m", "ssm-user"},
+		{"useradd", "-m", "-s", "/bin/bash", "-K", "UID_MIN=4000", "-K", "UID_MAX=4999", "session-user"},
+	}, commands)
+}
@@ -0,0 +1,343 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package sessionuser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/nightlyone/lockfile"
+)
+
+const (
+	sudoersFileCreateWriteMode = 0640
+	sudoersFileReadOnlyMode    = 0440
+
+	lockWaitTimeout = 2 * time.Minute
+	lockRetryDelay  = 100 * time.Millisecond
+
+	// sudoersFilePrefix is the name of the sudoers file older agents created for the default session user
+	sudoersFilePrefix = "ssm-agent-users"
+)
+
+var (
+	sudoersDir     = "/etc/sudoers.d"
+	stateDir       = filepath.Join(appconfig.SessionFilesPath, "sessionuser")
+	newLockfile    = lockfile.New
+	execCommand    = exec.Command
+	lookupUser     = user.Lookup
+	isProcessAlive = func(pid int) bool {
+		err := syscall.Kill(pid, 0)
+		return err == nil || err == syscall.EPERM
+	}
+)
+
+// Manager manages the session user, it is safe to use from concurrent session workers
+type Manager struct {
+	log    log.T
+	config appconfig.SessionUserCfg
+}
+
+// processLock serializes the managers of a worker, the lock file only serializes workers
+var processLock sync.Mutex
+
+// NewManager returns a new Manager for the configured session user
+func NewManager(log log.T, config appconfig.SessionUserCfg) *Manager {
+	if config.Name == "" {
+		config.Name = appconfig.DefaultRunAsUserName
+	}
+	if config.SudoPolicy == "" {
+		config.SudoPolicy = appconfig.SudoPolicyNoPassword
+	}
+	return &Manager{
+		log:    log,
+		config: config,
+	}
+}
+
+// Acquire creates the session user if it does not exist and registers the session as an active session of the user.
+// It returns the name of the session user.
+func (m *Manager) Acquire(sessionId string) (userName string, err error) {
+	if err = validateSessionId(sessionId); err != nil {
+		return "", err
+	}
+	lock, err := m.acquireLock()
+	if err != nil {
+		return "", err
+	}
+	defer m.releaseLock(lock)
+
+	if doesUserExist(m.config.Name) {
+		m.log.Infof("%s already exists.", m.config.Name)
+		if err = updateExistingUser(m.config); err != nil {
+			m.log.Warnf("Failed to update %s: %v", m.config.Name, err)
+		}
+	} else {
+		if err = createUser(m.config); err != nil {
+			m.log.Errorf("Failed to create %s: %v", m.config.Name, err)
+			return "", err
+		}
+		m.log.Infof("Successfully created %s", m.config.Name)
+
+		// only grant sudo when the user is created, so removing the sudoers file revokes it permanently
+		if m.config.SudoPolicy == appconfig.SudoPolicyNoPassword {
+			if err = m.createSudoersFileIfNotPresent(); err != nil {
+				m.log.Errorf("Failed to add %s to sudoers file: %v", m.config.Name, err)
+			}
+		}
+	}
+	if m.config.SudoPolicy == appconfig.SudoPolicyNone {
+		m.removeSudoersFile()
+	}
+
+	if err = m.registerSession(sessionId); err != nil {
+		m.log.Warnf("Failed to register session %s for %s: %v", sessionId, m.config.Name, err)
+	}
+	return m.config.Name, nil
+}
+
+// Release unregisters the session. When configured, the home directory of the session user is
+// cleaned up once no other session of the user is active.
+func (m *Manager) Release(sessionId string) (err error) {
+	if err = validateSessionId(sessionId); err != nil {
+		return err
+	}
+	lock, err := m.acquireLock()
+	if err != nil {
+		return err
+	}
+	defer m.releaseLock(lock)
+
+	if err = os.Remove(m.sessionFile(sessionId)); err != nil && !os.IsNotExist(err) {
+		m.log.Warnf("Failed to unregister session %s for %s: %v", sessionId, m.config.Name, err)
+	}
+
+	if !m.config.CleanupHomeOnSessionEnd || m.hasActiveSessions() {
+		return nil
+	}
+	return m.cleanupHome()
+}
+
+// acquireLock acquires the pid lock file shared with the other session workers, locks of exited workers are taken over
+func (m *Manager) acquireLock() (lock lockfile.Lockfile, err error) {
+	if err = os.MkdirAll(m.sessionsDir(), appconfig.ReadWriteExecuteAccess); err != nil {
+		return nil, fmt.Errorf("failed to create session user state directory: %v", err)
+	}
+	if lock, err = newLockfile(filepath.Join(stateDir, m.config.Name+".lock")); err != nil {
+		return nil, err
+	}
+
+	processLock.Lock()
+	deadline := time.Now().Add(lockWaitTimeout)
+	for {
+		err = lock.TryLock()
+		if err == nil {
+			return lock, nil
+		}
+		if _, isTemporary := err.(lockfile.TemporaryError); !isTemporary || time.Now().After(deadline) {
+			processLock.Unlock()
+			return nil, fmt.Errorf("failed to lock session user %s: %v", m.config.Name, err)
+		}
+		time.Sleep(lockRetryDelay)
+	}
+}
+
+func (m *Manager) releaseLock(lock lockfile.Lockfile) {
+	if err := lock.Unlock(); err != nil {
+		m.log.Warnf("Failed to unlock session user %s: %v", m.config.Name, err)
+	}
+	processLock.Unlock()
+}
+
+func (m *Manager) sessionsDir() string {
+	return filepath.Join(stateDir, m.config.Name)
+}
+
+func (m *Manager) sessionFile(sessionId string) string {
+	return filepath.Join(m.sessionsDir(), sessionId)
+}
+
+// validateSessionId rejects session ids that do not name a file in the sessions directory
+func validateSessionId(sessionId string) error {
+	if sessionId == "" || sessionId == "." || sessionId == ".." || filepath.Base(sessionId) != sessionId {
+		return fmt.Errorf("invalid session id %q", sessionId)
+	}
+	return nil
+}
+
+// registerSession records the session with the pid of its worker, so sessions of crashed workers can be detected
+func (m *Manager) registerSession(sessionId string) error {
+	return ioutil.WriteFile(m.sessionFile(sessionId), []byte(strconv.Itoa(os.Getpid())), appconfig.ReadWriteAccess)
+}
+
+// hasActiveSessions returns true if another session of the user is active, sessions of workers that exited are removed
+func (m *Manager) hasActiveSessions() bool {
+	files, err := ioutil.ReadDir(m.sessionsDir())
+	if err != nil {
+		// assume sessions are active to never remove the home directory of a session that is in use
+		m.log.Warnf("Failed to list active sessions of %s: %v", m.config.Name, err)
+		return true
+	}
+
+	active := false
+	for _, file := range files {
+		path := filepath.Join(m.sessionsDir(), file.Name())
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			active = true
+			continue
+		}
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(content))); err == nil && !isProcessAlive(pid) {
+			m.log.Debugf("Removing session %s of exited worker %d", file.Name(), pid)
+			os.Remove(path)
+			continue
+		}
+		active = true
+	}
+	return active
+}
+
+// cleanupHome deletes the content of the session user home directory
+func (m *Manager) cleanupHome() error {
+	sessionUser, err := lookupUser(m.config.Name)
+	if err != nil {
+		return fmt.Errorf("failed to look up home directory of %s: %v", m.config.Name, err)
+	}
+
+	home := filepath.Clean(sessionUser.HomeDir)
+	if !filepath.IsAbs(home) || home == "/" || filepath.Base(home) != m.config.Name {
+		return fmt.Errorf("refusing to clean up home directory %s of %s", home, m.config.Name)
+	}
+
+	files, err := ioutil.ReadDir(home)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err = os.RemoveAll(filepath.Join(home, file.Name())); err != nil {
+			m.log.Warnf("Failed to remove %s: %v", filepath.Join(home, file.Name()), err)
+		}
+	}
+	m.log.Infof("Cleaned up home directory %s of %s", home, m.config.Name)
+	return nil
+}
+
+// sudoersFile returns the sudoers file of the session user, the default user keeps the file older agents created.
+// sudo skips files in sudoers.d whose name contains a dot, so dots of the user name are replaced.
+func (m *Manager) sudoersFile() string {
+	if m.config.Name == appconfig.DefaultRunAsUserName {
+		return filepath.Join(sudoersDir, sudoersFilePrefix)
+	}
+	return filepath.Join(sudoersDir, sudoersFilePrefix+"-"+strings.Replace(m.config.Name, ".", "_", -1))
+}
+
+// sudoersRule returns the rule granting passwordless sudo to the session user
+func (m *Manager) sudoersRule() string {
+	return fmt.Sprintf("%s ALL=(ALL) NOPASSWD:ALL", m.config.Name)
+}
+
+// isSudoersFileOfUser returns true if the sudoers file only grants sudo to the session user
+func (m *Manager) isSudoersFileOfUser(content []byte) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") && line != m.sudoersRule() {
+			return false
+		}
+	}
+	return strings.Contains(string(content), m.sudoersRule())
+}
+
+// createSudoersFileIfNotPresent grants passwordless sudo to the session user if the sudoers file is not present
+func (m *Manager) createSudoersFileIfNotPresent() error {
+	sudoersFile := m.sudoersFile()
+
+	// Return if the file exists
+	if _, err := os.Stat(sudoersFile); err == nil {
+		m.log.Infof("File %s already exists", sudoersFile)
+		return m.changeModeOfSudoersFile(sudoersFile)
+	}
+
+	// Create a sudoers file for the session user with read/write access
+	file, err := os.OpenFile(sudoersFile, os.O_WRONLY|os.O_CREATE, sudoersFileCreateWriteMode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			m.log.Warnf("error occurred while closing file, %v", closeErr)
+		}
+	}()
+
+	if _, err = file.WriteString(fmt.Sprintf("# User rules for %s\n%s\n", m.config.Name, m.sudoersRule())); err != nil {
+		return err
+	}
+	m.log.Infof("Successfully created file %s", sudoersFile)
+	return m.changeModeOfSudoersFile(sudoersFile)
+}
+
+// changeModeOfSudoersFile changes the sudoers file mode to 0440 (read only), as required by sudo
+func (m *Manager) changeModeOfSudoersFile(sudoersFile string) error {
+	if err := os.Chmod(sudoersFile, sudoersFileReadOnlyMode); err != nil {
+		return fmt.Errorf("failed to change mode of %s to %o: %v", sudoersFile, sudoersFileReadOnlyMode, err)
+	}
+	return nil
+}
+
+// removeSudoersFile revokes sudo granted by the agent, files with rules the agent did not write are kept
+func (m *Manager) removeSudoersFile() {
+	sudoersFile := m.sudoersFile()
+	content, err := ioutil.ReadFile(sudoersFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			m.log.Warnf("Failed to read %s: %v", sudoersFile, err)
+		}
+		return
+	}
+	if !m.isSudoersFileOfUser(content) {
+		m.log.Warnf("Keeping %s since it has rules not written for %s", sudoersFile, m.config.Name)
+		return
+	}
+	if err = os.Remove(sudoersFile); err == nil {
+		m.log.Infof("Removed %s since sudo is disabled for %s", sudoersFile, m.config.Name)
+	} else if !os.IsNotExist(err) {
+		m.log.Warnf("Failed to remove %s: %v", sudoersFile, err)
+	}
+}
+
+// doesUserExist checks if the user exists
+func doesUserExist(userName string) bool {
+	return execCommand("id", userName).Run() == nil
+}
+
+// runCommand runs the command and includes its output in the error
+func runCommand(name string, args ...string) error {
+	if output, err := execCommand(name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %v %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package sessionuser
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/suite"
+)
+
+func testSudoersFile() string {
+	return filepath.Join(sudoersDir, "ssm-agent-users")
+}
+
+type SessionUserTestSuite struct {
+	suite.Suite
+	tempDir     string
+	config      appconfig.SessionUserCfg
+	mutex       sync.Mutex
+	userExists  bool
+	createCalls [][]string
+	failCreate  bool
+}
+
+func (suite *SessionUserTestSuite) SetupTest() {
+	suite.tempDir, _ = ioutil.TempDir("", "sessionuser")
+	suite.config = appconfig.SessionUserCfg{Name: "ssm-user", SudoPolicy: appconfig.SudoPolicyNoPassword}
+	suite.userExists = false
+	suite.createCalls = nil
+	suite.failCreate = false
+
+	stateDir = filepath.Join(suite.tempDir, "state")
+	sudoersDir = suite.tempDir
+	isProcessAlive = func(pid int) bool { return true }
+	lookupUser = func(name string) (*user.User, error) {
+		return &user.User{Username: name, HomeDir: filepath.Join(suite.tempDir, "home", name)}, nil
+	}
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		suite.mutex.Lock()
+		defer suite.mutex.Unlock()
+		switch name {
+		case "id":
+			if suite.userExists {
+				return exec.Command("true")
+			}
+			return exec.Command("false")
+		case "useradd", "sysadminctl":
+			suite.createCalls = append(suite.createCalls, append([]string{name}, args...))
+			if suite.failCreate {
+				return exec.Command("false")
+			}
+			suite.userExists = true
+		}
+		return exec.Command("true")
+	}
+}
+
+func (suite *SessionUserTestSuite) TearDownTest() {
+	os.RemoveAll(suite.tempDir)
+}
+
+// Execute the test suite
+func TestSessionUserTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionUserTestSuite))
+}
+
+func (suite *SessionUserTestSuite) TestAcquire_NewUser() {
+	userName, err := NewManager(logmocks.NewMockLog(), suite.config).Acquire("session-1")
+
+	suite.Nil(err)
+	suite.Equal("ssm-user", userName)
+	suite.Len(suite.createCalls, 1)
+	suite.FileExists(filepath.Join(stateDir, "ssm-user", "session-1"))
+
+	sudoers, err := ioutil.ReadFile(testSudoersFile())
+	suite.Nil(err)
+	suite.Equal("# User rules for ssm-user\nssm-user ALL=(ALL) NOPASSWD:ALL\n", string(sudoers))
+	info, _ := os.Stat(testSudoersFile())
+	suite.Equal(os.FileMode(sudoersFileReadOnlyMode), info.Mode().Perm())
+}
+
+func (suite *SessionUserTestSuite) TestAcquire_ExistingUserKeepsSudoersRemoved() {
+	suite.userExists = true
+
+	_, err := NewManager(logmocks.NewMockLog(), suite.config).Acquire("session-1")
+
+	suite.Nil(err)
+	suite.Empty(suite.createCalls)
+	suite.NoFileExists(testSudoersFile())
+}
+
+func (suite *SessionUserTestSuite) TestAcquire_SudoPolicyNone() {
+	ioutil.WriteFile(testSudoersFile(), []byte("ssm-user ALL=(ALL) NOPASSWD:ALL\n"), sudoersFileReadOnlyMode)
+	suite.config.SudoPolicy = appconfig.SudoPolicyNone
+
+	_, err := NewManager(logmocks.NewMockLog(), suite.config).Acquire("session-1")
+
+	suite.Nil(err)
+	suite.Len(suite.createCalls, 1)
+	suite.NoFileExists(testSudoersFile())
+}
+
+func (suite *SessionUserTestSuite) TestAcquire_SudoPolicyNoneKeepsRulesOfOtherUsers() {
+	ioutil.WriteFile(testSudoersFile(), []byte("admin ALL=(ALL) NOPASSWD:ALL\n"), sudoersFileReadOnlyMode)
+	suite.config.SudoPolicy = appconfig.SudoPolicyNone
+	suite.userExists = true
+
+	_, err := NewManager(logmocks.NewMockLog(), suite.config).Acquire("session-1")
+
+	suite.Nil(err)
+	suite.FileExists(testSudoersFile())
+}
+
+func (suite *SessionUserTestSuite) TestAcquire_CustomUserUsesOwnSudoersFile() {
+	ioutil.WriteFile(testSudoersFile(), []byte("ssm-user ALL=(ALL) NOPASSWD:ALL\n"), sudoersFileReadOnlyMode)
+	suite.config.Name = "session.admin"
+
+	userName, err := NewManager(logmocks.NewMockLog(), suite.config).Acquire("session-1")
+
+	suite.Nil(err)
+	suite.Equal("session.admin", userName)
+	sudoers, err := ioutil.ReadFile(filepath.Join(sudoersDir, "ssm-agent-users-session_admin"))
+	suite.Nil(err)
+	suite.Equal("# User rules for session.admin\nsession.admin ALL=(ALL) NOPASSWD:ALL\n", string(sudoers))
+
+	suite.config.SudoPolicy = appconfig.SudoPolicyNone
+	_, err = NewManager(logmocks.NewMockLog(), suite.config).Acquire("session-2")
+
+	suite.Nil(err)
+	suite.NoFileExists(filepath.Join(sudoersDir, "ssm-agent-users-session_admin"))
+	suite.FileExists(testSudoersFile())
+}
+
+func (suite *SessionUserTestSuite) TestAcquire_InvalidSessionId() {
+	manager := NewManager(logmocks.NewMockLog(), suite.config)
+	for _, sessionId := range []string{"", ".", "..", "../session-1"} {
+		_, err := manager.Acquire(sessionId)
+		suite.Error(err)
+		suite.Error(manager.Release(sessionId))
+	}
+	suite.Empty(suite.createCalls)
+	suite.DirExists(suite.tempDir)
+}
+
+func (suite *SessionUserTestSuite) TestAcquire_CreateFails() {
+	suite.failCreate = true
+
+	_, err := NewManager(logmocks.NewMockLog(), suite.config).Acquire("session-1")
+
+	suite.Error(err)
+	suite.NoFileExists(testSudoersFile())
+	suite.NoFileExists(filepath.Join(stateDir, "ssm-user", "session-1"))
+}
+
+func (suite *SessionUserTestSuite) TestAcquire_ConcurrentSessionsCreateUserOnce() {
+	var wait sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wait.Add(1)
+		go func(sessionId string) {
+			defer wait.Done()
+			_, err := NewManager(logmocks.NewMockLog(), suite.config).Acquire(sessionId)
+			suite.Nil(err)
+		}("session-" + strings.Repeat("x", i))
+	}
+	wait.Wait()
+
+	suite.Len(suite.createCalls, 1)
+	files, _ := ioutil.ReadDir(filepath.Join(stateDir, "ssm-user"))
+	suite.Len(files, 10)
+}
+
+func (suite *SessionUserTestSuite) TestRelease_CleansUpHomeAfterLastSession() {
+	suite.config.CleanupHomeOnSessionEnd = true
+	home := filepath.Join(suite.tempDir, "home", "ssm-user")
+	os.MkdirAll(filepath.Join(home, ".cache"), appconfig.ReadWriteExecuteAccess)
+	ioutil.WriteFile(filepath.Join(home, ".bash_history"), []byte("ls"), appconfig.ReadWriteAccess)
+
+	manager := NewManager(logmocks.NewMockLog(), suite.config)
+	manager.Acquire("session-1")
+	manager.Acquire("session-2")
+
+	suite.Nil(manager.Release("session-1"))
+	suite.FileExists(filepath.Join(home, ".bash_history"))
+
+	suite.Nil(manager.Release("session-2"))
+	files, _ := ioutil.ReadDir(home)
+	suite.Empty(files)
+	suite.DirExists(home)
+}
+
+func (suite *SessionUserTestSuite) TestRelease_IgnoresSessionsOfExitedWorkers() {
+	suite.config.CleanupHomeOnSessionEnd = true
+	home := filepath.Join(suite.tempDir, "home", "ssm-user")
+	os.MkdirAll(home, appconfig.ReadWriteExecuteAccess)
+	ioutil.WriteFile(filepath.Join(home, ".bash_history"), []byte("ls"), appconfig.ReadWriteAccess)
+
+	manager := NewManager(logmocks.NewMockLog(), suite.config)
+	manager.Acquire("session-1")
+	manager.Acquire("session-2")
+	isProcessAlive = func(pid int) bool { return false }
+
+	suite.Nil(manager.Release("session-1"))
+	suite.NoFileExists(filepath.Join(home, ".bash_history"))
+	suite.NoFileExists(filepath.Join(stateDir, "ssm-user", "session-2"))
+}
+
+func (suite *SessionUserTestSuite) TestRelease_WithoutCleanupKeepsHome() {
+	home := filepath.Join(suite.tempDir, "home", "ssm-user")
+	os.MkdirAll(home, appconfig.ReadWriteExecuteAccess)
+	ioutil.WriteFile(filepath.Join(home, ".bash_history"), []byte("ls"), appconfig.ReadWriteAccess)
+
+	manager := NewManager(logmocks.NewMockLog(), suite.config)
+	manager.Acquire("session-1")
+
+	suite.Nil(manager.Release("session-1"))
+	suite.FileExists(filepath.Join(home, ".bash_history"))
+	suite.NoFileExists(filepath.Join(stateDir, "ssm-user", "session-1"))
+}
+
+func (suite *SessionUserTestSuite) TestRelease_RefusesUnexpectedHome() {
+	suite.config.CleanupHomeOnSessionEnd = true
+	lookupUser = func(name string) (*user.User, error) {
+		return &user.User{Username: name, HomeDir: "/"}, nil
+	}
+
+	manager := NewManager(logmocks.NewMockLog(), suite.config)
+	manager.Acquire("session-1")
+
+	suite.Error(manager.Release("session-1"))
+}
@@ -0,0 +1,155 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// sessionLog writes the session output to the transcript until it reaches the size limit of the agent configuration.
+// The output beyond the limit is rotated into overflow files or dropped, the input is then appended to the transcript
+// so that it keeps the record of what was typed even though the shell echo is no longer written.
+type sessionLog struct {
+	mutex          sync.Mutex
+	config         appconfig.SessionLogsCfg
+	transcript     *os.File
+	transcriptSize int64
+	limitReached   bool
+	overflow       *os.File
+	overflowSize   int64
+	overflowIndex  int
+}
+
+// newSessionLog returns the session log writing to the given transcript, nil when the transcript has no size limit
+func newSessionLog(transcript *os.File, config appconfig.SessionLogsCfg) *sessionLog {
+	if config.MaxFileSizeMB <= 0 {
+		return nil
+	}
+	return &sessionLog{config: config, transcript: transcript}
+}
+
+func (l *sessionLog) maxFileSize() int64 {
+	return int64(l.config.MaxFileSizeMB) * 1024 * 1024
+}
+
+// writeOutput writes the session output to the transcript, or to the overflow files once the limit is reached
+func (l *sessionLog) writeOutput(log log.T, data []byte) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.limitReached {
+		if l.transcriptSize+int64(len(data)) <= l.maxFileSize() {
+			written, err := l.transcript.Write(data)
+			l.transcriptSize += int64(written)
+			return err
+		}
+		l.limitReached = true
+		log.Warnf("Session transcript reached its limit of %d MB, the output beyond it is handled as %v", l.config.MaxFileSizeMB, l.config.OutputBeyondLimit)
+		notice := fmt.Sprintf("\n[session log limit of %d MB reached, the output is no longer recorded, the input is]\n", l.config.MaxFileSizeMB)
+		if _, err := l.transcript.Write([]byte(notice)); err != nil {
+			return err
+		}
+	}
+	if l.config.OutputBeyondLimit == appconfig.SessionLogsOutputBeyondLimitDrop {
+		return nil
+	}
+	return l.writeOverflow(log, data)
+}
+
+// writeInput appends the session input to the transcript once its limit is reached
+func (l *sessionLog) writeInput(data []byte) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.limitReached {
+		return nil
+	}
+	_, err := l.transcript.Write(data)
+	return err
+}
+
+// writeOverflow writes the output to the current overflow file, a new one is started when it is full and the
+// oldest one is deleted when more than MaxOverflowFiles are kept
+func (l *sessionLog) writeOverflow(log log.T, data []byte) error {
+	if l.overflow == nil || l.overflowSize+int64(len(data)) > l.maxFileSize() {
+		if err := l.closeOverflow(); err != nil {
+			log.Warnf("Failed to close session overflow file: %v", err)
+		}
+		l.overflowIndex++
+		overflow, err := os.Create(l.overflowPath(l.overflowIndex))
+		if err != nil {
+			return fmt.Errorf("encountered an error while creating session overflow file: %s", err)
+		}
+		l.overflow, l.overflowSize = overflow, 0
+		if oldest := l.overflowIndex - l.config.MaxOverflowFiles; oldest > 0 {
+			if err := os.Remove(l.overflowPath(oldest)); err != nil && !os.IsNotExist(err) {
+				log.Warnf("Failed to delete session overflow file: %v", err)
+			}
+		}
+	}
+	written, err := l.overflow.Write(data)
+	l.overflowSize += int64(written)
+	return err
+}
+
+// overflowPath returns the path of an overflow file, next to the transcript and named after it
+func (l *sessionLog) overflowPath(index int) string {
+	extension := filepath.Ext(l.transcript.Name())
+	return fmt.Sprintf("%s.overflow%d%s", strings.TrimSuffix(l.transcript.Name(), extension), index, extension)
+}
+
+func (l *sessionLog) closeOverflow() error {
+	if l.overflow == nil {
+		return nil
+	}
+	err := l.overflow.Close()
+	l.overflow = nil
+	return err
+}
+
+// close closes the current overflow file, the transcript is closed by the plugin
+func (l *sessionLog) close(log log.T) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.closeOverflow(); err != nil {
+		log.Warnf("Failed to close session overflow file: %v", err)
+	}
+}
+
+// writeSessionOutput writes the session output to the transcript within the limits of the agent configuration
+func (p *ShellPlugin) writeSessionOutput(log log.T, file *os.File, data []byte) error {
+	if p.logger.sessionLog == nil {
+		_, err := file.Write(data)
+		return err
+	}
+	return p.logger.sessionLog.writeOutput(log, data)
+}
+
+// recordSessionInput records the session input in the transcript once the session output is no longer written to it
+func (p *ShellPlugin) recordSessionInput(log log.T, data []byte) {
+	if p.logger.sessionLog == nil {
+		return
+	}
+	if err := p.logger.sessionLog.writeInput(data); err != nil {
+		log.Warnf("Failed to record session input: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTranscript(t *testing.T) *os.File {
+	dir, err := ioutil.TempDir("", "sessionlog")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	transcript, err := os.Create(filepath.Join(dir, "ipcTempFile.log"))
+	assert.NoError(t, err)
+	t.Cleanup(func() { transcript.Close() })
+	return transcript
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestNewSessionLog_NoLimit(t *testing.T) {
+	assert.Nil(t, newSessionLog(createTranscript(t), appconfig.SessionLogsCfg{OutputBeyondLimit: appconfig.SessionLogsOutputBeyondLimitRotate}))
+}
+
+func TestSessionLog_DropKeepsInput(t *testing.T) {
+	mockLog := log.NewMockLog()
+	transcript := createTranscript(t)
+	sessionLog := newSessionLog(transcript, appconfig.SessionLogsCfg{MaxFileSizeMB: 1, OutputBeyondLimit: appconfig.SessionLogsOutputBeyondLimitDrop, MaxOverflowFiles: 1})
+
+	assert.NoError(t, sessionLog.writeInput([]byte("ignored")))
+	output := strings.Repeat("o", 1024*1024)
+	assert.NoError(t, sessionLog.writeOutput(mockLog, []byte(output)))
+	assert.NoError(t, sessionLog.writeOutput(mockLog, []byte("dropped")))
+	assert.NoError(t, sessionLog.writeInput([]byte("ls\r")))
+	sessionLog.close(mockLog)
+
+	content := readFile(t, transcript.Name())
+	assert.True(t, strings.HasPrefix(content, output))
+	assert.True(t, strings.HasSuffix(content, "is]\nls\r"))
+	assert.NotContains(t, content, "ignored")
+	assert.NotContains(t, content, "dropped")
+	assert.NoFileExists(t, sessionLog.overflowPath(1))
+}
+
+func TestSessionLog_RotateOverflow(t *testing.T) {
+	mockLog := log.NewMockLog()
+	transcript := createTranscript(t)
+	sessionLog := newSessionLog(transcript, appconfig.SessionLogsCfg{MaxFileSizeMB: 1, OutputBeyondLimit: appconfig.SessionLogsOutputBeyondLimitRotate, MaxOverflowFiles: 2})
+
+	chunk := strings.Repeat("o", 700*1024)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, sessionLog.writeOutput(mockLog, []byte(chunk)))
+	}
+	sessionLog.close(mockLog)
+
+	assert.Equal(t, filepath.Join(filepath.Dir(transcript.Name()), "ipcTempFile.overflow1.log"), sessionLog.overflowPath(1))
+	assert.True(t, strings.HasPrefix(readFile(t, transcript.Name()), chunk+"\n[session log limit of 1 MB reached"))
+	// each overflow file holds a single chunk, only the last two are kept
+	assert.NoFileExists(t, sessionLog.overflowPath(1))
+	assert.Equal(t, chunk, readFile(t, sessionLog.overflowPath(2)))
+	assert.Equal(t, chunk, readFile(t, sessionLog.overflowPath(3)))
+}
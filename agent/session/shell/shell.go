@@ -78,6 +78,7 @@ type logger struct {
 	streamLogsToCloudWatch      bool
 	s3Util                      s3util.IAmazonS3Util
 	cwl                         cloudwatchlogsinterface.ICloudWatchLogsService
+	sessionLog                  *sessionLog
 }
 
 type IShellPlugin interface {
@@ -281,6 +282,9 @@ func (p *ShellPlugin) execute(config agentContracts.Configuration,
 			log.Warnf("error occurred while closing ipcFile, %v", closeErr)
 		}
 	}()
+	if p.logger.sessionLog = newSessionLog(ipcFile, p.context.AppConfig().Mgs.SessionLogs); p.logger.sessionLog != nil {
+		defer p.logger.sessionLog.close(log)
+	}
 
 	go func() {
 		cancelState := cancelFlag.Wait()
@@ -823,7 +827,7 @@ func (p *ShellPlugin) processStdoutData(
 		return processedBuf, fmt.Errorf("unable to send stream data message: %s", err)
 	}
 
-	if _, err := file.Write(processedBuf.Bytes()); err != nil {
+	if err := p.writeSessionOutput(log, file, processedBuf.Bytes()); err != nil {
 		return processedBuf, fmt.Errorf("encountered an error while writing to file: %s", err)
 	}
 
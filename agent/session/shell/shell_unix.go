@@ -37,6 +37,8 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/sessioncredentials"
+	"github.com/aws/amazon-ssm-agent/agent/session/sessionuser"
 	"github.com/aws/amazon-ssm-agent/agent/session/shell/constants"
 	"github.com/aws/amazon-ssm-agent/agent/session/shell/execcmd"
 	"github.com/aws/amazon-ssm-agent/agent/session/utility"
@@ -46,6 +48,16 @@ import (
 
 var ptyFile *os.File
 
+// releaseSessionUser unregisters the session from the session user when the session ends
+var releaseSessionUser func() error
+
+var newSessionUserManager = sessionuser.NewManager
+
+// sessionCredentials are the session scoped credentials refreshed until the session ends
+var sessionCredentials *sessioncredentials.SessionCredentials
+
+var startSessionCredentials = sessioncredentials.Start
+
 const (
 	termEnvVariable       = "TERM=xterm-256color"
 	langEnvVariable       = "LANG=C.UTF-8"
@@ -110,6 +122,8 @@ func StartCommandExecutor(
 	}
 
 	var sessionUser string
+	// the session credentials keep their owner when the session runs as the agent user
+	credentialsUid, credentialsGid := -1, -1
 	if !constants.GetRunAsElevated(shellProps) && !isSessionLogger && !appConfig.Agent.ContainerMode {
 		// We get here only when its a customer shell that needs to be started in a specific user mode.
 
@@ -132,11 +146,15 @@ func StartCommandExecutor(
 			sessionUser = config.RunAsUser
 		} else {
 			if os.Geteuid() == 0 {
-				// Start as ssm-user
-				// Create ssm-user before starting a session.
-				u.CreateLocalAdminUser(log)
-
-				sessionUser = appconfig.DefaultRunAsUserName
+				// Start as the session user, which is created before starting a session if it does not exist
+				sessionUserManager := newSessionUserManager(log, appConfig.Mgs.SessionUser)
+				if sessionUser, err = sessionUserManager.Acquire(config.SessionId); err != nil {
+					return fmt.Errorf("failed to prepare session user %s: %v", appConfig.Mgs.SessionUser.Name, err)
+				}
+				sessionId := config.SessionId
+				releaseSessionUser = func() error {
+					return sessionUserManager.Release(sessionId)
+				}
 			} else {
 				user, _ := user.Current()
 				sessionUser = user.Username
@@ -152,6 +170,7 @@ func StartCommandExecutor(
 		if os.Geteuid() == 0 {
 			cmd.SysProcAttr = &syscall.SysProcAttr{}
 			cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uid, Gid: gid, Groups: groups, NoSetGroups: false}
+			credentialsUid, credentialsGid = int(uid), int(gid)
 		}
 
 		// Setting home environment variable for RunAs user
@@ -163,6 +182,14 @@ func StartCommandExecutor(
 		cmd.Env = append(cmd.Env, constants.RootHomeEnvVariable)
 	}
 
+	if !isSessionLogger {
+		// Commands run in the session use the session scoped credentials instead of the instance credentials when configured
+		if sessionCredentials, err = startSessionCredentials(plugin.context, config.SessionId, credentialsUid, credentialsGid); err != nil {
+			return fmt.Errorf("failed to get session credentials: %v", err)
+		}
+		cmd.Env = append(cmd.Env, sessionCredentials.Environment()...)
+	}
+
 	if appconfig.PluginNameNonInteractiveCommands == plugin.name {
 		if plugin.separateOutput {
 			//Open pipeline for reading only
@@ -208,8 +235,16 @@ func StartCommandExecutor(
 	return nil
 }
 
-// stop closes pty file.
+// stop closes pty file, releases the session user and stops refreshing the session credentials.
 func (p *ShellPlugin) stop(log log.T) (err error) {
+	sessionCredentials.Stop()
+	sessionCredentials = nil
+	if releaseSessionUser != nil {
+		if releaseErr := releaseSessionUser(); releaseErr != nil {
+			log.Warnf("Failed to release session user: %v", releaseErr)
+		}
+		releaseSessionUser = nil
+	}
 	if ptyFile == nil {
 		return nil
 	}
@@ -461,6 +496,7 @@ func (p *ShellPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgs
 			log.Errorf("Unable to write to stdin, err: %v.", err)
 			return err
 		}
+		p.recordSessionInput(log, streamDataMessage.Payload)
 	case mgsContracts.Size:
 		// Do not handle terminal resize for non-interactive plugin as there is no pty
 		if isPluginNonInteractive {
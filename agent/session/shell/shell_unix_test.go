@@ -888,7 +888,7 @@ func (suite *ShellTestSuite) TestGetUserCredentials() {
 
 // Test StartCommandExecutor for NonInteractiveCommand session with separate output stream enabled
 func (suite *ShellTestSuite) TestStartCommandExecutorWithNonInteractiveCommand() {
-	config := contracts.Configuration{PluginName: appconfig.PluginNameNonInteractiveCommands, RunAsEnabled: false}
+	config := contracts.Configuration{PluginName: appconfig.PluginNameNonInteractiveCommands, RunAsEnabled: false, SessionId: "session-id"}
 
 	shellConfig := mgsContracts.ShellConfig{
 		"ls", false, "true", "STD_OUT:\n", "STD_ERR:\n"}
@@ -944,7 +944,7 @@ func (suite *ShellTestSuite) TestExecuteForNonInteractiveCommandSession() {
 	}
 
 	plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{SessionId: "session-id"},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -100,6 +100,11 @@ func StartCommandExecutor(
 
 	appConfig := plugin.context.AppConfig()
 
+	if !isSessionLogger && appConfig.Mgs.SessionCredentials.RoleArn != "" {
+		// session credentials are only injected on Linux and macOS, refuse instead of running with the instance credentials
+		return errors.New("session credentials are not supported on Windows, remove Mgs.SessionCredentials.RoleArn from the agent configuration")
+	}
+
 	if !shellProps.Windows.RunAsElevated && !isSessionLogger && !appConfig.Agent.ContainerMode {
 		// Reset password for default ssm user
 		var newPassword string
@@ -604,6 +609,7 @@ func (p *ShellPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgs
 			log.Errorf("Unable to write to stdin, err: %v.", err)
 			return err
 		}
+		p.recordSessionInput(log, streamDataMessage.Payload)
 	case mgsContracts.Size:
 		// Do not handle terminal resize for non-interactive plugin as there is no pty
 		if isPluginNonInteractive {
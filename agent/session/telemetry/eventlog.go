@@ -64,6 +64,13 @@ type AgentUpdateResultDiagnosis struct {
 	TargetVersion         string `json:"TargetVersion"`
 }
 
+// AgentErrorFingerprints is the error fingerprint counts message format being used as payload for MGS message
+type AgentErrorFingerprints struct {
+	SchemaVersion int            `json:"SchemaVersion"`
+	AgentVersion  string         `json:"AgentVersion"`
+	Fingerprints  map[string]int `json:"Fingerprints"`
+}
+
 // IAuditLogTelemetry is the scheduler used for the AuditLogScheduler
 type IAuditLogTelemetry interface {
 	ScheduleAuditEvents()
@@ -196,6 +203,8 @@ func (a *AuditLogTelemetry) sendAgentHealthMessage() {
 			err = a.sendBasicAgentTelemetryMessage(eventCounts[i])
 		case logger.AgentUpdateResultMessage:
 			err = a.sendAgentUpdateResultMessage(eventCounts[i])
+		case logger.AgentErrorFingerprintMessage:
+			err = a.sendErrorFingerprintsMessage(eventCounts[i])
 		}
 
 		if err != nil {
@@ -317,6 +326,42 @@ func (a *AuditLogTelemetry) sendBasicAgentTelemetryMessage(eventCount *logger.Ev
 	return nil
 }
 
+// sendErrorFingerprintsMessage sends the error fingerprint counts to MGS when their upload is enabled
+func (a *AuditLogTelemetry) sendErrorFingerprintsMessage(eventCount *logger.EventCounter) (err error) {
+	if !a.isMGSTelemetryTransportEnable || !a.ctx.AppConfig().Agent.ErrorFingerprints.Upload {
+		return nil
+	}
+	schemaVal, _ := strconv.Atoi(eventCount.SchemaVersion)
+	errorFingerprintsJson := AgentErrorFingerprints{
+		SchemaVersion: schemaVal,
+		AgentVersion:  eventCount.AgentVersion,
+		Fingerprints:  make(map[string]int),
+	}
+	// events are <fingerprint>:<count>, the count map holds the number of occurrences of each event
+	for event, occurrences := range eventCount.CountMap {
+		separator := strings.LastIndex(event, ":")
+		if separator < 0 {
+			continue
+		}
+		count, convErr := strconv.Atoi(event[separator+1:])
+		if convErr != nil || count <= 0 {
+			continue
+		}
+		errorFingerprintsJson.Fingerprints[event[:separator]] += count * occurrences
+	}
+	if len(errorFingerprintsJson.Fingerprints) == 0 {
+		return nil
+	}
+	auditBytes, err := json.Marshal(errorFingerprintsJson)
+	if err != nil {
+		return fmt.Errorf("unable to marshal error fingerprints payload to json string: %s, err: %s", auditBytes, err)
+	}
+	if err = a.sendChannelContract(auditBytes, logger.AgentErrorFingerprintMessage); err != nil {
+		return fmt.Errorf("unable to send error fingerprints message to MGS: %s", err)
+	}
+	return nil
+}
+
 // sendChannelContract send through the web socket connection with necessary packaging
 func (a *AuditLogTelemetry) sendChannelContract(payload []byte, messageType string) error {
 	// blocks sending metrics to MGS
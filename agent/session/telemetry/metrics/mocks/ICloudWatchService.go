@@ -29,6 +29,22 @@ func (_m *ICloudWatchService) GenerateBasicTelemetryMetrics(metricName string, v
 	return r0
 }
 
+// GenerateTelemetryMetricsWithUnit provides a mock function with given fields: metricName, value, unit, version
+func (_m *ICloudWatchService) GenerateTelemetryMetricsWithUnit(metricName string, value float64, unit string, version string) *cloudwatch.MetricDatum {
+	ret := _m.Called(metricName, value, unit, version)
+
+	var r0 *cloudwatch.MetricDatum
+	if rf, ok := ret.Get(0).(func(string, float64, string, string) *cloudwatch.MetricDatum); ok {
+		r0 = rf(metricName, value, unit, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cloudwatch.MetricDatum)
+		}
+	}
+
+	return r0
+}
+
 // GenerateUpdateMetrics provides a mock function with given fields: metricName, value, sourceVersion, targetVersion
 func (_m *ICloudWatchService) GenerateUpdateMetrics(metricName string, value float64, sourceVersion string, targetVersion string) *cloudwatch.MetricDatum {
 	ret := _m.Called(metricName, value, sourceVersion, targetVersion)
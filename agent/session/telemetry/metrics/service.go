@@ -37,6 +37,7 @@ const (
 type ICloudWatchService interface {
 	GenerateUpdateMetrics(metricName string, value float64, sourceVersion string, targetVersion string) *cloudwatch.MetricDatum
 	GenerateBasicTelemetryMetrics(metricName string, value float64, version string) *cloudwatch.MetricDatum
+	GenerateTelemetryMetricsWithUnit(metricName string, value float64, unit string, version string) *cloudwatch.MetricDatum
 	PutMetrics(metricData []*cloudwatch.MetricDatum) error
 	IsCloudWatchEnabled() bool
 }
@@ -103,9 +104,14 @@ func (c *CloudWatchService) GenerateUpdateMetrics(metricName string, value float
 
 // GenerateBasicTelemetryMetrics generate metrics with instance id and AgentVersion as the dimension
 func (c *CloudWatchService) GenerateBasicTelemetryMetrics(metricName string, value float64, version string) *cloudwatch.MetricDatum {
+	return c.GenerateTelemetryMetricsWithUnit(metricName, value, "Count", version)
+}
+
+// GenerateTelemetryMetricsWithUnit generate metrics in the given unit with instance id and AgentVersion as the dimension
+func (c *CloudWatchService) GenerateTelemetryMetricsWithUnit(metricName string, value float64, unit string, version string) *cloudwatch.MetricDatum {
 	return &cloudwatch.MetricDatum{
 		MetricName: aws.String(metricName),
-		Unit:       aws.String("Count"),
+		Unit:       aws.String(unit),
 		Value:      aws.Float64(value),
 		Dimensions: []*cloudwatch.Dimension{
 			{
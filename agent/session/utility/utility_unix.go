@@ -23,7 +23,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
 	"syscall"
 	"unsafe"
 
@@ -31,26 +30,19 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
-	"github.com/aws/amazon-ssm-agent/agent/session/utility/model"
 )
 
 var (
 	ShellPluginCommandName = "sh"
 	ShellPluginCommandArgs = []string{"-c"}
 	execCommand            = exec.Command
-	osStat                 = os.Stat
-	osOpenFile             = os.OpenFile
-	osChMod                = os.Chmod
 )
 
 const (
-	sudoersFile                = "/etc/sudoers.d/ssm-agent-users"
-	sudoersFileCreateWriteMode = 0640
-	sudoersFileReadOnlyMode    = 0440
-	fs_ioc_getflags            = uintptr(0x80086601)
-	fs_ioc_setflags            = uintptr(0x40086602)
-	FS_APPEND_FL               = 0x00000020 /* writes to file may only append */
-	FS_RESET_FL                = 0x00000000 /* reset file property */
+	fs_ioc_getflags = uintptr(0x80086601)
+	fs_ioc_setflags = uintptr(0x40086602)
+	FS_APPEND_FL    = 0x00000020 /* writes to file may only append */
+	FS_RESET_FL     = 0x00000000 /* reset file property */
 
 	dsclCreateCommand = "/usr/bin/dscl . -create /Users/%s %s %s"
 )
@@ -75,29 +67,6 @@ func (u *SessionUtil) DoesUserExist(username string) (bool, error) {
 	return true, nil
 }
 
-// CreateLocalAdminUser creates a local OS user on the instance with admin permissions. The password will alway be empty
-func (u *SessionUtil) CreateLocalAdminUser(log log.T) (newPassword string, err error) {
-
-	userExists, _ := u.DoesUserExist(appconfig.DefaultRunAsUserName)
-	if userExists {
-		if runtime.GOOS == "darwin" {
-			if err = u.ChangeUserShell(); err != nil {
-				log.Warnf("Failed to change %s UserShell: %v", appconfig.DefaultRunAsUserName, err)
-				return
-			}
-		} else {
-			log.Infof("%s already exists.", appconfig.DefaultRunAsUserName)
-		}
-	} else {
-		if err = u.createLocalUser(log); err != nil {
-			return
-		}
-		// only create sudoers file when user does not exist
-		err = u.createSudoersFileIfNotPresent(log)
-	}
-	return
-}
-
 // ChangeUserShell changes userShell for DefaultRunAsUser.
 func (u *SessionUtil) ChangeUserShell() (err error) {
 	// update user shell value
@@ -110,64 +79,6 @@ func (u *SessionUtil) ChangeUserShell() (err error) {
 	return nil
 }
 
-// createLocalUser creates an OS local user.
-func (u *SessionUtil) createLocalUser(log log.T) error {
-
-	commandArgs := append(ShellPluginCommandArgs, fmt.Sprintf(model.AddUserCommand, appconfig.DefaultRunAsUserName))
-	cmd := execCommand(ShellPluginCommandName, commandArgs...)
-	if err := cmd.Run(); err != nil {
-		log.Errorf("Failed to create %s: %v", appconfig.DefaultRunAsUserName, err)
-		return err
-	}
-	log.Infof("Successfully created %s", appconfig.DefaultRunAsUserName)
-	return nil
-}
-
-// createSudoersFileIfNotPresent will create the sudoers file if not present.
-func (u *SessionUtil) createSudoersFileIfNotPresent(log log.T) error {
-
-	// Return if the file exists
-	if _, err := osStat(sudoersFile); err == nil {
-		log.Infof("File %s already exists", sudoersFile)
-		_ = u.changeModeOfSudoersFile(log)
-		return err
-	}
-
-	// Create a sudoers file for ssm-user with read/write access
-	file, err := osOpenFile(sudoersFile, os.O_WRONLY|os.O_CREATE, sudoersFileCreateWriteMode)
-	if err != nil {
-		log.Errorf("Failed to add %s to sudoers file: %v", appconfig.DefaultRunAsUserName, err)
-		return err
-	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			log.Warnf("error occurred while closing file, %v", closeErr)
-		}
-	}()
-
-	if _, err := file.WriteString(fmt.Sprintf("# User rules for %s\n", appconfig.DefaultRunAsUserName)); err != nil {
-		return err
-	}
-	if _, err := file.WriteString(fmt.Sprintf("%s ALL=(ALL) NOPASSWD:ALL\n", appconfig.DefaultRunAsUserName)); err != nil {
-		return err
-	}
-	log.Infof("Successfully created file %s", sudoersFile)
-	_ = u.changeModeOfSudoersFile(log)
-	return nil
-}
-
-// changeModeOfSudoersFile will change the sudoersFile mode to 0440 (read only).
-// This file is created with mode 0640 using os.Create() so needs to be updated to read only with chmod.
-func (u *SessionUtil) changeModeOfSudoersFile(log log.T) error {
-	fileMode := os.FileMode(sudoersFileReadOnlyMode)
-	if err := osChMod(sudoersFile, fileMode); err != nil {
-		log.Errorf("Failed to change mode of %s to %d: %v", sudoersFile, sudoersFileReadOnlyMode, err)
-		return err
-	}
-	log.Infof("Successfully changed mode of %s to %d", sudoersFile, sudoersFileReadOnlyMode)
-	return nil
-}
-
 func (u *SessionUtil) DisableLocalUser(log log.T) (err error) {
 	// Do nothing here as no password is required for unix platform local user, so that no need to disable user.
 	return nil
@@ -20,6 +20,7 @@ package utility
 import (
 	"fmt"
 	"net"
+	"strings"
 	"syscall"
 	"unsafe"
 
@@ -59,6 +60,12 @@ const (
 	levelForUserInfo1008 = 1008
 	// Level for fetching USER_INFO_1 structure data
 	levelForUserInfo1 = 1
+	// Level for fetching LOCALGROUP_USERS_INFO_0 structure data
+	levelForLocalGroupUsersInfo0 = 0
+	// Includes the local groups the user is a member of through a global group
+	lgIncludeIndirect = 1
+	// Lets the function allocate the buffer required for the data
+	maxPreferredLength = 0xFFFFFFFF
 
 	// Windows error code for user not found
 	errCodeForUserNotFound = 2221
@@ -90,6 +97,10 @@ type USER_INFO_1 struct {
 	Usri1_script_path  *uint16
 }
 
+type LOCALGROUP_USERS_INFO_0 struct {
+	Lgrui0_name *uint16
+}
+
 type USER1_FLAGS struct {
 	UF_ACCOUNTDISABLE *uint16
 }
@@ -105,6 +116,7 @@ var (
 	netUserAdd              = modNetapi32.NewProc("NetUserAdd")
 	netApiBufferFree        = modNetapi32.NewProc("NetApiBufferFree")
 	netLocalGroupAddMembers = modNetapi32.NewProc("NetLocalGroupAddMembers")
+	netUserGetLocalGroups   = modNetapi32.NewProc("NetUserGetLocalGroups")
 	advapi32                = windows.NewLazySystemDLL("advapi32.dll")
 	userenv                 = windows.NewLazySystemDLL("userenv.dll")
 	logonProc               = advapi32.NewProc("LogonUserW")
@@ -129,10 +141,16 @@ type ProfileInfo struct {
 
 // AddNewUser adds new user using NetUserAdd function of netapi32.dll on local machine
 func (u *SessionUtil) AddNewUser(username string, password string) (userExists bool, err error) {
+	return u.AddNewUserWithComment(username, password, "")
+}
+
+// AddNewUserWithComment adds new user with the given comment using NetUserAdd function of netapi32.dll on local machine
+func (u *SessionUtil) AddNewUserWithComment(username string, password string, comment string) (userExists bool, err error) {
 	var (
 		errParam uint32
 		uPointer *uint16
 		pPointer *uint16
+		cPointer *uint16
 	)
 
 	if uPointer, err = syscall.UTF16PtrFromString(username); err != nil {
@@ -143,10 +161,15 @@ func (u *SessionUtil) AddNewUser(username string, password string) (userExists b
 		return false, fmt.Errorf("Unable to encode password to UTF16")
 	}
 
+	if cPointer, err = syscall.UTF16PtrFromString(comment); err != nil {
+		return false, fmt.Errorf("Unable to encode comment to UTF16")
+	}
+
 	uInfo := USER_INFO_1{
 		Usri1_name:     uPointer,
 		Usri1_password: pPointer,
 		Usri1_priv:     userPrivUser,
+		Usri1_comment:  cPointer,
 		Usri1_flags:    ufScript,
 	}
 
@@ -219,21 +242,41 @@ func isServiceRunning(serviceName string) (isServiceRunning bool, err error) {
 
 // AddUserToLocalAdministratorsGroup adds user to local built in administrators group using NetLocalGroupAddMembers function of netapi32.dll
 func (u *SessionUtil) AddUserToLocalAdministratorsGroup(username string) (adminGroupName string, err error) {
+	if adminGroupName, err = u.getBuiltInAdministratorsGroupName(); err != nil {
+		return
+	}
+
+	return adminGroupName, addLocalGroupMember(username, adminGroupName)
+}
+
+// AddUserToBuiltInGroup adds user to the local builtin group of the given well known SID using NetLocalGroupAddMembers function of netapi32.dll
+func (u *SessionUtil) AddUserToBuiltInGroup(username string, groupSidType windows.WELL_KNOWN_SID_TYPE) (groupName string, err error) {
+	var sid *windows.SID
+	if sid, err = windows.CreateWellKnownSid(groupSidType); err != nil {
+		return
+	}
+
+	// Passing system name as empty string and LookupAccountSidW will translate it to local system
+	if groupName, _, _, err = sid.LookupAccount(""); err != nil {
+		return
+	}
+
+	return groupName, addLocalGroupMember(username, groupName)
+}
+
+// addLocalGroupMember adds user to the local group
+func addLocalGroupMember(username string, groupName string) (err error) {
 	var (
 		uPointer *uint16
 		gPointer *uint16
 	)
 
-	if adminGroupName, err = u.getBuiltInAdministratorsGroupName(); err != nil {
-		return
-	}
-
 	if uPointer, err = syscall.UTF16PtrFromString(username); err != nil {
-		return "", fmt.Errorf("Unable to encode username to UTF16")
+		return fmt.Errorf("Unable to encode username to UTF16")
 	}
 
-	if gPointer, err = syscall.UTF16PtrFromString(adminGroupName); err != nil {
-		return "", fmt.Errorf("Unable to encode adminGroupName to UTF16")
+	if gPointer, err = syscall.UTF16PtrFromString(groupName); err != nil {
+		return fmt.Errorf("Unable to encode groupName to UTF16")
 	}
 
 	localGroupMembers := make([]LOCALGROUP_MEMBERS_INFO_3, 1)
@@ -257,6 +300,90 @@ func (u *SessionUtil) AddUserToLocalAdministratorsGroup(username string) (adminG
 	return
 }
 
+// IsUserMemberOfBuiltInGroup checks if user is a direct or indirect member of the local builtin group of the given well known SID using NetUserGetLocalGroups function of netapi32.dll
+func (u *SessionUtil) IsUserMemberOfBuiltInGroup(username string, groupSidType windows.WELL_KNOWN_SID_TYPE) (isMember bool, err error) {
+	var (
+		sid          *windows.SID
+		groupName    string
+		uPointer     *uint16
+		dataPointer  *byte
+		entriesRead  uint32
+		totalEntries uint32
+	)
+	if sid, err = windows.CreateWellKnownSid(groupSidType); err != nil {
+		return
+	}
+
+	// Passing system name as empty string and LookupAccountSidW will translate it to local system
+	if groupName, _, _, err = sid.LookupAccount(""); err != nil {
+		return
+	}
+
+	if uPointer, err = syscall.UTF16PtrFromString(username); err != nil {
+		return false, fmt.Errorf("Unable to encode username to UTF16")
+	}
+
+	ret, _, _ := netUserGetLocalGroups.Call(
+		uintptr(serverNameLocalMachine),
+		uintptr(unsafe.Pointer(uPointer)),
+		uintptr(uint32(levelForLocalGroupUsersInfo0)),
+		uintptr(uint32(lgIncludeIndirect)),
+		uintptr(unsafe.Pointer(&dataPointer)),
+		uintptr(uint32(maxPreferredLength)),
+		uintptr(unsafe.Pointer(&entriesRead)),
+		uintptr(unsafe.Pointer(&totalEntries)),
+	)
+
+	if dataPointer != nil {
+		defer netApiBufferFree.Call(uintptr(unsafe.Pointer(dataPointer)))
+	}
+
+	if ret != nerrSuccess {
+		return false, fmt.Errorf("NetUserGetLocalGroups call failed. Error Code: %d", ret)
+	}
+
+	if entriesRead == 0 {
+		return false, nil
+	}
+	groups := (*[1 << 20]LOCALGROUP_USERS_INFO_0)(unsafe.Pointer(dataPointer))[:entriesRead:entriesRead]
+	for _, group := range groups {
+		if strings.EqualFold(windows.UTF16PtrToString(group.Lgrui0_name), groupName) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetUserComment returns the comment of the given user using NetUserGetInfo function of netapi32.dll on local machine
+func (u *SessionUtil) GetUserComment(username string) (comment string, err error) {
+	var (
+		uPointer    *uint16
+		dataPointer *byte
+	)
+
+	if uPointer, err = syscall.UTF16PtrFromString(username); err != nil {
+		return "", fmt.Errorf("Unable to encode username to UTF16")
+	}
+
+	ret, _, _ := netUserGetInfo.Call(
+		uintptr(serverNameLocalMachine),
+		uintptr(unsafe.Pointer(uPointer)),
+		uintptr(uint32(levelForUserInfo1)),
+		uintptr(unsafe.Pointer(&dataPointer)),
+	)
+
+	if dataPointer != nil {
+		defer netApiBufferFree.Call(uintptr(unsafe.Pointer(dataPointer)))
+	}
+
+	if ret != nerrSuccess {
+		return "", fmt.Errorf("NetUserGetInfo call failed. %d", ret)
+	}
+
+	var data = (*USER_INFO_1)(unsafe.Pointer(dataPointer))
+	return windows.UTF16PtrToString(data.Usri1_comment), nil
+}
+
 // getBuiltInAdministratorsGroupName fetches builtin local administrators group name
 func (u *SessionUtil) getBuiltInAdministratorsGroupName() (adminGroupName string, err error) {
 	var sid *windows.SID
@@ -0,0 +1,109 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package instancemanager installs and uninstalls the secondary agent instances of a host.
+package instancemanager
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/setupcli/managers/common"
+)
+
+const agentConfigFile = "amazon-ssm-agent.json"
+
+var managerHelper common.IManagerHelper = &common.ManagerHelper{}
+
+// InstallInstance creates the configuration folder and the service of a secondary agent instance, the configuration
+// of the primary instance is copied when the secondary instance has none. The instance has to be registered before
+// its service is started.
+func InstallInstance(log log.T, name string) error {
+	if err := checkPlatformSupport(); err != nil {
+		return err
+	}
+	if err := appconfig.ValidateInstanceName(name); err != nil {
+		return err
+	}
+
+	configFolder := appconfig.NamespacedFolder(primaryConfigFolder, name)
+	if err := fileutil.MakeDirs(configFolder); err != nil {
+		return fmt.Errorf("failed to create configuration folder %v: %v", configFolder, err)
+	}
+	if err := copyPrimaryConfig(log, configFolder); err != nil {
+		return err
+	}
+
+	serviceName := appconfig.NamespacedServiceName(primaryServiceName, name)
+	log.Infof("Creating service %v", serviceName)
+	if err := createService(name, serviceName); err != nil {
+		return fmt.Errorf("failed to create service %v: %v", serviceName, err)
+	}
+
+	log.Infof("Agent instance %v installed, register it with '%v=%v %v -register -code <code> -id <id> -region <region>' and start service %v",
+		name, appconfig.InstanceNameEnvVariable, name, appconfig.DefaultSSMAgentBinaryPath, serviceName)
+	return nil
+}
+
+// UninstallInstance stops and deletes the service of a secondary agent instance and removes its configuration,
+// data and logs. The registration of the instance is not deregistered from the service.
+func UninstallInstance(log log.T, name string) error {
+	if err := checkPlatformSupport(); err != nil {
+		return err
+	}
+	if err := appconfig.ValidateInstanceName(name); err != nil {
+		return err
+	}
+
+	serviceName := appconfig.NamespacedServiceName(primaryServiceName, name)
+	log.Infof("Deleting service %v", serviceName)
+	if err := deleteService(serviceName); err != nil {
+		return fmt.Errorf("failed to delete service %v: %v", serviceName, err)
+	}
+
+	for _, folder := range instanceFolders(name) {
+		if !fileutil.Exists(folder) {
+			continue
+		}
+		log.Infof("Removing %v", folder)
+		if err := fileutil.DeleteDirectory(folder); err != nil {
+			return fmt.Errorf("failed to remove %v: %v", folder, err)
+		}
+	}
+
+	log.Infof("Agent instance %v uninstalled", name)
+	return nil
+}
+
+// copyPrimaryConfig copies the app config of the primary instance to the configuration folder of a
+// secondary instance, an existing configuration of the secondary instance is kept
+func copyPrimaryConfig(log log.T, configFolder string) error {
+	destination := filepath.Join(configFolder, agentConfigFile)
+	source := filepath.Join(primaryConfigFolder, agentConfigFile)
+	if fileutil.Exists(destination) || !fileutil.Exists(source) {
+		return nil
+	}
+
+	log.Infof("Copying %v to %v", source, destination)
+	content, err := fileutil.ReadAllText(source)
+	if err != nil {
+		return fmt.Errorf("failed to read %v: %v", source, err)
+	}
+	if _, err = fileutil.WriteIntoFileWithPermissions(destination, content, appconfig.ReadWriteAccess); err != nil {
+		return fmt.Errorf("failed to write %v: %v", destination, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package instancemanager
+
+import (
+	"errors"
+)
+
+const (
+	primaryServiceName  = "com.amazon.aws.ssm"
+	primaryConfigFolder = "/opt/aws/ssm"
+)
+
+var errInstancesUnsupported = errors.New("secondary agent instances are not supported on macOS")
+
+func checkPlatformSupport() error {
+	return errInstancesUnsupported
+}
+
+func instanceFolders(name string) []string {
+	return nil
+}
+
+func createService(name, serviceName string) error {
+	return errInstancesUnsupported
+}
+
+func deleteService(serviceName string) error {
+	return errInstancesUnsupported
+}
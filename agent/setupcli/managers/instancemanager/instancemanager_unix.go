@@ -0,0 +1,115 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package instancemanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+const (
+	primaryServiceName  = "amazon-ssm-agent"
+	primaryConfigFolder = "/etc/amazon/ssm"
+	primaryDataFolder   = "/var/lib/amazon/ssm"
+	primaryLogFolder    = "/var/log/amazon/ssm"
+
+	systemdUnitFolder = "/etc/systemd/system"
+
+	unitTemplate = `[Unit]
+Description=amazon-ssm-agent instance %[1]v
+After=network-online.target
+
+[Service]
+Type=simple
+WorkingDirectory=%[4]v
+Environment=%[2]v=%[1]v
+ExecStart=%[3]v
+KillMode=process
+
+# Restart the agent regardless of whether it crashes (and returns a non-zero result code) or if
+# is terminated normally (e.g. via 'kill -HUP').  Delay restart so that the agent is less likely
+# to restart during a reboot initiated by a script. If the agent exits with status 194 (reboot
+# requested), don't restart at all.
+Restart=always
+RestartPreventExitStatus=194
+RestartSec=90
+
+[Install]
+WantedBy=multi-user.target
+`
+)
+
+func checkPlatformSupport() error {
+	return nil
+}
+
+// instanceFolders returns the configuration, data and log folders of a secondary instance
+func instanceFolders(name string) []string {
+	return []string{
+		appconfig.NamespacedFolder(primaryConfigFolder, name),
+		appconfig.NamespacedFolder(primaryDataFolder, name),
+		appconfig.NamespacedFolder(primaryLogFolder, name),
+	}
+}
+
+func unitFilePath(serviceName string) string {
+	return filepath.Join(systemdUnitFolder, serviceName+".service")
+}
+
+// unitFileContent returns the systemd unit of a secondary instance, the agent processes inherit the instance name
+func unitFileContent(name string) string {
+	binaryPath := appconfig.DefaultSSMAgentBinaryPath
+	return fmt.Sprintf(unitTemplate, name, appconfig.InstanceNameEnvVariable, binaryPath, filepath.Dir(binaryPath)+"/")
+}
+
+func createService(name, serviceName string) error {
+	if !managerHelper.IsCommandAvailable("systemctl") {
+		return fmt.Errorf("secondary agent instances require systemd")
+	}
+	if _, err := fileutil.WriteIntoFileWithPermissions(unitFilePath(serviceName), unitFileContent(name), appconfig.ReadWriteAccess); err != nil {
+		return err
+	}
+	if output, err := managerHelper.RunCommand("systemctl", "daemon-reload"); err != nil {
+		return fmt.Errorf("systemctl daemon-reload failed with output '%s' and error: %v", output, err)
+	}
+	if output, err := managerHelper.RunCommand("systemctl", "enable", serviceName); err != nil {
+		return fmt.Errorf("systemctl enable failed with output '%s' and error: %v", output, err)
+	}
+	return nil
+}
+
+func deleteService(serviceName string) error {
+	unitFile := unitFilePath(serviceName)
+	if !fileutil.Exists(unitFile) {
+		return nil
+	}
+
+	// the service may already be stopped or disabled
+	_, _ = managerHelper.RunCommand("systemctl", "stop", serviceName)
+	_, _ = managerHelper.RunCommand("systemctl", "disable", serviceName)
+	if err := os.Remove(unitFile); err != nil {
+		return err
+	}
+	if output, err := managerHelper.RunCommand("systemctl", "daemon-reload"); err != nil {
+		return fmt.Errorf("systemctl daemon-reload failed with output '%s' and error: %v", output, err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package instancemanager
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceFolders(t *testing.T) {
+	assert.Equal(t, []string{"/etc/amazon/ssm-tenantb", "/var/lib/amazon/ssm-tenantb", "/var/log/amazon/ssm-tenantb"}, instanceFolders("tenantb"))
+}
+
+func TestUnitFileContent(t *testing.T) {
+	content := unitFileContent("tenantb")
+	assert.Contains(t, content, "Environment="+appconfig.InstanceNameEnvVariable+"=tenantb\n")
+	assert.Contains(t, content, "ExecStart="+appconfig.DefaultSSMAgentBinaryPath+"\n")
+	assert.Equal(t, "/etc/systemd/system/amazon-ssm-agent-tenantb.service", unitFilePath("amazon-ssm-agent-tenantb"))
+}
+
+func TestInstallInstance_InvalidName(t *testing.T) {
+	assert.Error(t, InstallInstance(logmocks.NewMockLog(), "Tenant B"))
+	assert.Error(t, UninstallInstance(logmocks.NewMockLog(), "../ssm"))
+}
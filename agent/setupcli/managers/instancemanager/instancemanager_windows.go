@@ -0,0 +1,106 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package instancemanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const (
+	primaryServiceName = appconfig.ServiceName
+
+	serviceStopTimeout = 30 * time.Second
+)
+
+var (
+	primaryConfigFolder = filepath.Join(os.Getenv("ProgramFiles"), appconfig.SSMFolder)
+	primaryDataFolder   = filepath.Join(os.Getenv("ProgramData"), appconfig.SSMFolder)
+)
+
+func checkPlatformSupport() error {
+	return nil
+}
+
+// instanceFolders returns the configuration and data folders of a secondary instance, the logs are in the data folder
+func instanceFolders(name string) []string {
+	return []string{
+		appconfig.NamespacedFolder(primaryConfigFolder, name),
+		appconfig.NamespacedFolder(primaryDataFolder, name),
+	}
+}
+
+func createService(name, serviceName string) error {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+
+	service, err := manager.CreateService(serviceName, appconfig.DefaultSSMAgentBinaryPath, mgr.Config{
+		DisplayName: fmt.Sprintf("Amazon SSM Agent (%v)", name),
+		Description: fmt.Sprintf("Amazon SSM Agent instance %v", name),
+		StartType:   mgr.StartAutomatic,
+	})
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+
+	// the agent processes inherit the instance name from the environment of the service
+	serviceKey, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+serviceName, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer serviceKey.Close()
+	return serviceKey.SetStringsValue(appconfig.ItemPropertyName, []string{appconfig.InstanceNameEnvVariable + "=" + name})
+}
+
+func deleteService(serviceName string) error {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+
+	service, err := manager.OpenService(serviceName)
+	if err == windows.ERROR_SERVICE_DOES_NOT_EXIST {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer service.Close()
+
+	if status, err := service.Control(svc.Stop); err == nil {
+		deadline := time.Now().Add(serviceStopTimeout)
+		for status.State != svc.Stopped && time.Now().Before(deadline) {
+			time.Sleep(time.Second)
+			if status, err = service.Query(); err != nil {
+				break
+			}
+		}
+	}
+	return service.Delete()
+}
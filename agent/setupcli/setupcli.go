@@ -26,6 +26,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/managedInstances/registration"
 	"github.com/aws/amazon-ssm-agent/agent/setupcli/managers"
 	"github.com/aws/amazon-ssm-agent/agent/setupcli/managers/configurationmanager"
+	"github.com/aws/amazon-ssm-agent/agent/setupcli/managers/instancemanager"
 	"github.com/aws/amazon-ssm-agent/agent/setupcli/managers/packagemanagers"
 	"github.com/aws/amazon-ssm-agent/agent/setupcli/managers/servicemanagers"
 	"github.com/cihub/seelog"
@@ -44,12 +45,16 @@ var role string
 var tags string
 var override bool
 var help bool
+var installInstance string
+var uninstallInstance string
 
 var getPackageManager = managers.GetPackageManager
 var getConfigurationManager = managers.GetConfigurationManager
 var getServiceManager = managers.GetServiceManager
 var getRegisterManager = managers.GetRegisterManager
 var getRegistrationInfo = registration.NewOnpremRegistrationInfo
+var installAgentInstance = instancemanager.InstallInstance
+var uninstallAgentInstance = instancemanager.UninstallInstance
 var osExit = func(exitCode int, log log.T, message string, messageArgs ...interface{}) {
 	if message != "" {
 		if exitCode == 0 {
@@ -72,6 +77,12 @@ func main() {
 	setParams(log)
 	verifyParams(log)
 
+	// secondary agent instances use the installed agent, no package or service manager is needed
+	if installInstance != "" || uninstallInstance != "" {
+		manageAgentInstance(log)
+		return
+	}
+
 	if packageManager, err = getPackageManager(log); err != nil {
 		osExit(1, log, "Failed to determine package manager: %v", err)
 	}
@@ -171,6 +182,23 @@ func main() {
 	log.Close()
 }
 
+// manageAgentInstance installs or uninstalls a secondary agent instance
+func manageAgentInstance(log log.T) {
+	if installInstance != "" {
+		log.Infof("Installing agent instance %v", installInstance)
+		if err := installAgentInstance(log, installInstance); err != nil {
+			osExit(1, log, "Failed to install agent instance %v: %v", installInstance, err)
+		}
+	} else {
+		log.Infof("Uninstalling agent instance %v", uninstallInstance)
+		if err := uninstallAgentInstance(log, uninstallInstance); err != nil {
+			osExit(1, log, "Failed to uninstall agent instance %v: %v", uninstallInstance, err)
+		}
+	}
+	log.Flush()
+	log.Close()
+}
+
 func setParams(log log.T) {
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	flag.Usage = flagUsage
@@ -184,6 +212,8 @@ func setParams(log log.T) {
 	flag.BoolVar(&override, "override", false, "")
 	flag.StringVar(&tags, "tags", "", "")
 	flag.BoolVar(&help, "help", false, "")
+	flag.StringVar(&installInstance, "install-instance", "", "")
+	flag.StringVar(&uninstallInstance, "uninstall-instance", "", "")
 
 	flag.Parse()
 
@@ -246,8 +276,24 @@ func verifyParams(log log.T) {
 	log.Infof("role=%v", role)
 	log.Infof("tags=%v", tags)
 	log.Infof("override=%v", override)
+	log.Infof("installInstance=%v", installInstance)
+	log.Infof("uninstallInstance=%v", uninstallInstance)
 
 	var errMessage string
+	if installInstance != "" || uninstallInstance != "" {
+		if installInstance != "" && uninstallInstance != "" {
+			errMessage += "Only one of install-instance and uninstall-instance can be set. "
+		}
+		if install || register || shutdown {
+			errMessage += "Instance actions cannot be combined with install, register or shutdown. "
+		}
+		if errMessage != "" {
+			flagUsage()
+			osExit(1, log, "Invalid parameters - %v", errMessage)
+		}
+		return
+	}
+
 	if region == "" {
 		errMessage += "Region required. "
 	}
@@ -280,6 +326,8 @@ func flagUsage() {
 	fmt.Fprintln(os.Stderr, "\t\t-role     \tRole ssm agent will be registered with           \t(REQUIRED)")
 	fmt.Fprintln(os.Stderr, "\t\t-override \tOverride existing registration if present        \t(OPTIONAL)")
 	fmt.Fprintln(os.Stderr, "\t\t-tags     \tTags to attach to ssm instance on registrations  \t(OPTIONAL)")
+	fmt.Fprintln(os.Stderr, "\t-install-instance   \tInstall a secondary agent instance with the given name")
+	fmt.Fprintln(os.Stderr, "\t-uninstall-instance \tUninstall the secondary agent instance with the given name")
 }
 
 func initializeLogger() log.T {
@@ -42,8 +42,12 @@ func storeMockedFunctions() func() {
 	getServiceManagerStorage := getServiceManager
 	getRegisterManagerStorage := getRegisterManager
 	getRegistrationInfoStorage := getRegistrationInfo
+	installAgentInstanceStorage := installAgentInstance
+	uninstallAgentInstanceStorage := uninstallAgentInstance
 
 	return func() {
+		installAgentInstance = installAgentInstanceStorage
+		uninstallAgentInstance = uninstallAgentInstanceStorage
 		getPackageManager = getPackageManagerStorage
 		getConfigurationManager = getConfigurationManagerStorage
 		getServiceManager = getServiceManagerStorage
@@ -802,3 +806,50 @@ func TestMain_Register_AgentNotInstalled_AgentRegistered_OverrideSet_Success(t *
 	main()
 	assert.True(t, true, "Should never reach here because of exit")
 }
+
+func TestMain_InstallInstance_Success(t *testing.T) {
+	initializeArgs()
+	defer storeMockedFunctions()()
+
+	defer setArgsAndRestore("/some/path/setupcli", "-install-instance", "tenantb")()
+
+	getPackageManager = func(log.T) (packagemanagers.IPackageManager, error) {
+		assert.Fail(t, "Package manager is not needed for agent instances")
+		return nil, fmt.Errorf("SomeError")
+	}
+
+	installedInstance := ""
+	installAgentInstance = func(log log.T, name string) error {
+		installedInstance = name
+		return nil
+	}
+
+	main()
+	assert.Equal(t, "tenantb", installedInstance)
+}
+
+func TestMain_UninstallInstance_Failed(t *testing.T) {
+	initializeArgs()
+	defer storeMockedFunctions()()
+
+	defer setArgsAndRestore("/some/path/setupcli", "-uninstall-instance", "tenantb")()
+
+	uninstallAgentInstance = func(log log.T, name string) error {
+		return fmt.Errorf("SomeError")
+	}
+
+	osExit = func(exitCode int, log log.T, message string, args ...interface{}) {
+		assert.Equal(t, 1, exitCode)
+		assert.Contains(t, message, "Failed to uninstall agent instance")
+
+		panic(breakOutWithPanicMessage)
+	}
+
+	defer func() {
+		if errInterface := recover(); errInterface != nil {
+			assert.Equal(t, breakOutWithPanicMessage, errInterface)
+		}
+	}()
+	main()
+	assert.True(t, false, "Should never reach here because of exit")
+}
@@ -279,13 +279,13 @@ func (_m *Service) ListInstanceAssociations(_a0 log.T, instanceID string, nextTo
 	return r0, r1
 }
 
-// PutComplianceItems provides a mock function with given fields: _a0, executionTime, executionType, executionId, instanceId, complianceType, itemContentHash, items
-func (_m *Service) PutComplianceItems(_a0 log.T, executionTime *time.Time, executionType string, executionId string, instanceId string, complianceType string, itemContentHash string, items []*ssm.ComplianceItemEntry) (*ssm.PutComplianceItemsOutput, error) {
-	ret := _m.Called(_a0, executionTime, executionType, executionId, instanceId, complianceType, itemContentHash, items)
+// PutComplianceItems provides a mock function with given fields: _a0, executionTime, executionType, executionId, instanceId, complianceType, itemContentHash, uploadType, items
+func (_m *Service) PutComplianceItems(_a0 log.T, executionTime *time.Time, executionType string, executionId string, instanceId string, complianceType string, itemContentHash string, uploadType string, items []*ssm.ComplianceItemEntry) (*ssm.PutComplianceItemsOutput, error) {
+	ret := _m.Called(_a0, executionTime, executionType, executionId, instanceId, complianceType, itemContentHash, uploadType, items)
 
 	var r0 *ssm.PutComplianceItemsOutput
-	if rf, ok := ret.Get(0).(func(log.T, *time.Time, string, string, string, string, string, []*ssm.ComplianceItemEntry) *ssm.PutComplianceItemsOutput); ok {
-		r0 = rf(_a0, executionTime, executionType, executionId, instanceId, complianceType, itemContentHash, items)
+	if rf, ok := ret.Get(0).(func(log.T, *time.Time, string, string, string, string, string, string, []*ssm.ComplianceItemEntry) *ssm.PutComplianceItemsOutput); ok {
+		r0 = rf(_a0, executionTime, executionType, executionId, instanceId, complianceType, itemContentHash, uploadType, items)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*ssm.PutComplianceItemsOutput)
@@ -293,8 +293,8 @@ func (_m *Service) PutComplianceItems(_a0 log.T, executionTime *time.Time, execu
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(log.T, *time.Time, string, string, string, string, string, []*ssm.ComplianceItemEntry) error); ok {
-		r1 = rf(_a0, executionTime, executionType, executionId, instanceId, complianceType, itemContentHash, items)
+	if rf, ok := ret.Get(1).(func(log.T, *time.Time, string, string, string, string, string, string, []*ssm.ComplianceItemEntry) error); ok {
+		r1 = rf(_a0, executionTime, executionType, executionId, instanceId, complianceType, itemContentHash, uploadType, items)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -154,9 +154,10 @@ func (m *Mock) PutComplianceItems(
 	instanceId string,
 	complianceType string,
 	itemContentHash string,
+	uploadType string,
 	items []*ssm.ComplianceItemEntry) (response *ssm.PutComplianceItemsOutput, err error) {
 
-	args := m.Called(log, executionTime, executionType, executionId, instanceId, complianceType, itemContentHash, items)
+	args := m.Called(log, executionTime, executionType, executionId, instanceId, complianceType, itemContentHash, uploadType, items)
 	return args.Get(0).(*ssm.PutComplianceItemsOutput), args.Error(1)
 
 }
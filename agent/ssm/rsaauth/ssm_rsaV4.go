@@ -175,14 +175,14 @@ func MakeSignRsaHandler(encodedPrivateKey string) func(req *request.Request) {
 	}
 }
 
-// BuildRSASignature signs a string using a private RSA signing key
+// BuildRSASignature signs a string using the private signing key, the key is either an RSA or an ECDSA key
 func BuildRSASignature(encodedPrivateKey string, stringToSign string) (signature string, err error) {
-	var rsaKey auth.RsaKey
-	rsaKey, err = auth.DecodePrivateKey(encodedPrivateKey)
+	var key auth.Key
+	key, err = auth.DecodeKey(encodedPrivateKey)
 	if err != nil {
 		return
 	}
 
-	signature, err = rsaKey.Sign(stringToSign)
+	signature, err = key.Sign(stringToSign)
 	return
 }
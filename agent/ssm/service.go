@@ -51,6 +51,7 @@ type Service interface {
 		instanceId string,
 		complianceType string,
 		itemContentHash string,
+		uploadType string,
 		items []*ssm.ComplianceItemEntry) (response *ssm.PutComplianceItemsOutput, err error)
 	SendCommand(log log.T,
 		documentName string,
@@ -158,6 +159,7 @@ func (svc *sdkService) ListInstanceAssociations(log log.T, instanceID string, ne
 }
 
 // PutComplianceItem calls to PutComplianceItem SSM API.
+// The service default upload type is used when uploadType is empty.
 func (svc *sdkService) PutComplianceItems(
 	log log.T,
 	executionTime *time.Time,
@@ -166,6 +168,7 @@ func (svc *sdkService) PutComplianceItems(
 	instanceId string,
 	complianceType string,
 	itemContentHash string,
+	uploadType string,
 	items []*ssm.ComplianceItemEntry) (response *ssm.PutComplianceItemsOutput, err error) {
 
 	executionSummary := &ssm.ComplianceExecutionSummary{
@@ -180,6 +183,9 @@ func (svc *sdkService) PutComplianceItems(
 		ItemContentHash:  aws.String(itemContentHash),
 		Items:            items,
 	}
+	if uploadType != "" {
+		params.UploadType = aws.String(uploadType)
+	}
 
 	response, err = svc.sdk.PutComplianceItems(params)
 	if err != nil {
@@ -21,6 +21,8 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/circuitbreaker"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil/ratelimit"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil/retryer"
 	"github.com/aws/amazon-ssm-agent/common/identity/endpoint"
 	"github.com/aws/aws-sdk-go/aws"
@@ -30,12 +32,13 @@ func AwsConfig(logger log.T, appConfig appconfig.SsmagentConfig, service, region
 	endpointHelper := endpoint.NewEndpointHelper(logger, appConfig)
 
 	return &aws.Config{
-		Retryer:    newRetryer(),
-		SleepDelay: sleepDelay,
-		HTTPClient: &http.Client{Transport: network.GetDefaultTransport(logger, appConfig)},
-		Region:     aws.String(region),
-		Endpoint:   aws.String(endpointHelper.GetServiceEndpoint(service, region)),
-		Logger:     logger,
+		Retryer:                 newRetryer(),
+		SleepDelay:              sleepDelay,
+		EnforceShouldRetryCheck: aws.Bool(appConfig.Network.CircuitBreaker.Enabled),
+		HTTPClient:              &http.Client{Transport: circuitbreaker.NewTransport(logger, appConfig, ratelimit.NewTransport(logger, appConfig, network.GetDefaultTransport(logger, appConfig)))},
+		Region:                  aws.String(region),
+		Endpoint:                aws.String(endpointHelper.GetServiceEndpoint(service, region)),
+		Logger:                  logger,
 	}
 
 }
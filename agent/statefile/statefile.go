@@ -0,0 +1,158 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package statefile versions the formats of the state files of the agent. The version of a state file is stored in
+// a field of its json object, a file without version has the first version of its format. The files written by a
+// previous agent are upgraded to the current format before they are parsed.
+package statefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// DefaultVersionField is the json field holding the version of a state file
+const DefaultVersionField = "StateVersion"
+
+// Migration upgrades the decoded json object of a state file from a version of its format to the next one
+type Migration func(state map[string]interface{}) (map[string]interface{}, error)
+
+// Schema describes the versions of the format of a state file
+type Schema struct {
+	// Name identifies the state file in the logs
+	Name string
+	// VersionField is the json field holding the version, DefaultVersionField when empty
+	VersionField string
+	// Migrations upgrade the format from a version to the next one, Migrations[0] upgrades the version 1 to the
+	// version 2. Migrations are only appended, the current version is len(Migrations)+1.
+	Migrations []Migration
+}
+
+// Version returns the current version of the format
+func (s Schema) Version() int {
+	return len(s.Migrations) + 1
+}
+
+func (s Schema) versionField() string {
+	if s.VersionField == "" {
+		return DefaultVersionField
+	}
+	return s.VersionField
+}
+
+// Stamp adds the current version to a json object serialized in the current format
+func (s Schema) Stamp(content []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) < 2 || trimmed[0] != '{' {
+		return nil, fmt.Errorf("%v state is not a json object", s.Name)
+	}
+	versionField, _ := json.Marshal(s.versionField())
+	stamped := append([]byte{'{'}, versionField...)
+	stamped = append(stamped, ':')
+	stamped = strconv.AppendInt(stamped, int64(s.Version()), 10)
+	if rest := bytes.TrimSpace(trimmed[1:]); len(rest) > 0 && rest[0] != '}' {
+		stamped = append(stamped, ',')
+	}
+	return append(stamped, trimmed[1:]...), nil
+}
+
+// Upgrade returns the state upgraded to the current format and the version it was read with.
+// A state written by a newer agent is returned unchanged, its unknown fields are ignored by the parsers.
+func (s Schema) Upgrade(content []byte) (upgraded []byte, version int, err error) {
+	var state map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err = decoder.Decode(&state); err != nil {
+		return nil, 0, fmt.Errorf("%v state cannot be parsed: %v", s.Name, err)
+	}
+	if version, err = s.versionOf(state); err != nil || version >= s.Version() {
+		return content, version, err
+	}
+
+	for v := version; v < s.Version(); v++ {
+		if state, err = s.Migrations[v-1](state); err != nil {
+			return nil, version, fmt.Errorf("%v state cannot be upgraded from version %d to %d: %v", s.Name, v, v+1, err)
+		}
+	}
+	state[s.versionField()] = s.Version()
+	if upgraded, err = json.Marshal(state); err != nil {
+		return nil, version, fmt.Errorf("%v state cannot be serialized: %v", s.Name, err)
+	}
+	return upgraded, version, nil
+}
+
+// versionOf returns the version of a decoded state, 1 when it has no version
+func (s Schema) versionOf(state map[string]interface{}) (int, error) {
+	value, found := state[s.versionField()]
+	if !found {
+		return 1, nil
+	}
+	if number, ok := value.(json.Number); ok {
+		if version, err := strconv.Atoi(number.String()); err == nil && version > 0 {
+			return version, nil
+		}
+	}
+	return 0, fmt.Errorf("%v state has an invalid version %v", s.Name, value)
+}
+
+// UpgradeFile rewrites a state file in the current format when it was written by a previous agent
+func UpgradeFile(log log.T, schema Schema, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	upgraded, version, err := schema.Upgrade(content)
+	if err != nil {
+		return err
+	}
+	if version > schema.Version() {
+		log.Warnf("%v state %v has version %d written by a newer agent, this agent supports version %d", schema.Name, path, version, schema.Version())
+		return nil
+	}
+	if version == schema.Version() {
+		return nil
+	}
+
+	// write the upgraded state next to the file then replace it, the file is never left half written
+	temp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(upgraded)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), info.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	log.Infof("Upgraded %v state %v from version %d to %d", schema.Name, path, version, schema.Version())
+	return nil
+}
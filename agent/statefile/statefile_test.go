@@ -0,0 +1,116 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package statefile
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSchema renames the field Name to FullName in version 2 and adds the field Enabled in version 3
+var testSchema = Schema{
+	Name: "test",
+	Migrations: []Migration{
+		func(state map[string]interface{}) (map[string]interface{}, error) {
+			state["FullName"] = state["Name"]
+			delete(state, "Name")
+			return state, nil
+		},
+		func(state map[string]interface{}) (map[string]interface{}, error) {
+			state["Enabled"] = true
+			return state, nil
+		},
+	},
+}
+
+func TestStamp(t *testing.T) {
+	stamped, err := testSchema.Stamp([]byte(`{"FullName":"test"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"StateVersion":3,"FullName":"test"}`, string(stamped))
+
+	stamped, err = Schema{Name: "empty", VersionField: "stateVersion"}.Stamp([]byte(`{}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"stateVersion":1}`, string(stamped))
+
+	_, err = testSchema.Stamp([]byte(`["test"]`))
+	assert.Error(t, err)
+}
+
+func TestUpgrade_AppliesMigrationsFromFileVersion(t *testing.T) {
+	upgraded, version, err := testSchema.Upgrade([]byte(`{"Name":"test","Size":12345678901234}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version)
+	assert.JSONEq(t, `{"StateVersion":3,"FullName":"test","Size":12345678901234,"Enabled":true}`, string(upgraded))
+
+	upgraded, version, err = testSchema.Upgrade([]byte(`{"StateVersion":2,"FullName":"test"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+	assert.JSONEq(t, `{"StateVersion":3,"FullName":"test","Enabled":true}`, string(upgraded))
+}
+
+func TestUpgrade_CurrentAndNewerVersionsUnchanged(t *testing.T) {
+	for _, content := range []string{
+		`{"StateVersion":3,"FullName":"test","Enabled":false}`,
+		`{"StateVersion":4,"Names":["test"]}`,
+	} {
+		upgraded, _, err := testSchema.Upgrade([]byte(content))
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(upgraded))
+	}
+}
+
+func TestUpgrade_Errors(t *testing.T) {
+	_, _, err := testSchema.Upgrade([]byte(`{"Name":`))
+	assert.Error(t, err)
+
+	_, _, err = testSchema.Upgrade([]byte(`{"StateVersion":"two"}`))
+	assert.Error(t, err)
+
+	failing := Schema{Name: "failing", Migrations: []Migration{
+		func(map[string]interface{}) (map[string]interface{}, error) { return nil, errors.New("missing field") },
+	}}
+	_, _, err = failing.Upgrade([]byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestUpgradeFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statefile")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"Name":"test"}`), 0600))
+
+	assert.NoError(t, UpgradeFile(logmocks.NewMockLog(), testSchema, path))
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	var state map[string]interface{}
+	assert.NoError(t, json.Unmarshal(content, &state))
+	assert.Equal(t, map[string]interface{}{"StateVersion": 3.0, "FullName": "test", "Enabled": true}, state)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1)
+
+	// a file in the current format is not rewritten
+	info, _ := os.Stat(path)
+	assert.NoError(t, UpgradeFile(logmocks.NewMockLog(), testSchema, path))
+	infoAfter, _ := os.Stat(path)
+	assert.Equal(t, info.ModTime(), infoAfter.ModTime())
+}
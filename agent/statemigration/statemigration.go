@@ -0,0 +1,102 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package statemigration upgrades the state files written by a previous agent to their current format when the
+// agent starts, before the core modules read them.
+package statemigration
+
+import (
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/longrunning/datastore"
+	"github.com/aws/amazon-ssm-agent/agent/managedInstances/registration"
+	"github.com/aws/amazon-ssm-agent/agent/statefile"
+	"github.com/aws/amazon-ssm-agent/agent/statestore"
+	"github.com/aws/amazon-ssm-agent/common/identity/availableidentities/ec2"
+)
+
+var (
+	upgradeFile         = statefile.UpgradeFile
+	upgradeInstanceInfo = registration.UpgradeInstanceInfo
+	transferStates      = statestore.Transfer
+)
+
+// stateBackendLocations are the locations under the instance folder of the states kept in the configured state backend
+var stateBackendLocations = [][]string{
+	{appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState, appconfig.DefaultLocationOfPending},
+	{appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState, appconfig.DefaultLocationOfCurrent},
+	{appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState, appconfig.DefaultLocationOfCorrupt},
+	{appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfAssociation},
+	{appconfig.RepliesRootDirName},
+	{appconfig.RepliesMGSRootDirName},
+}
+
+// Run moves the states to the configured state backend, then upgrades the document states that are resumed, the
+// long running plugins data store and the instance info of the registrations. A file that cannot be upgraded is
+// left as is, its reader reports the error.
+func Run(context context.T) {
+	log := context.Log()
+	shortInstanceID, err := context.Identity().ShortInstanceID()
+	if err != nil {
+		log.Warnf("State files not upgraded, the instance id is not available: %v", err)
+		return
+	}
+	instanceDir := filepath.Join(appconfig.DefaultDataStorePath, shortInstanceID)
+
+	// the states persisted before the state backend was changed are moved to the configured one
+	for _, location := range stateBackendLocations {
+		transferStates(log, context.AppConfig(), shortInstanceID, location...)
+	}
+
+	for _, location := range []string{appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent} {
+		stateDir := filepath.Join(instanceDir, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState, location)
+		fileNames, err := fileutil.GetFileNames(stateDir)
+		if err != nil {
+			continue
+		}
+		for _, fileName := range fileNames {
+			if !docmanager.IsDocumentStateFileName(fileName) {
+				continue
+			}
+			upgrade(log, docmanager.DocumentStateSchema, filepath.Join(stateDir, fileName))
+		}
+	}
+
+	upgrade(log, datastore.Schema, filepath.Join(instanceDir,
+		appconfig.LongRunningPluginsLocation,
+		appconfig.LongRunningPluginDataStoreLocation,
+		appconfig.LongRunningPluginDataStoreFileName))
+
+	for manifestFileNamePrefix, vaultKey := range map[string]string{
+		"":               registration.RegVaultKey,
+		ec2.IdentityType: registration.EC2RegistrationVaultKey,
+	} {
+		if err := upgradeInstanceInfo(log, manifestFileNamePrefix, vaultKey); err != nil {
+			log.Warnf("Failed to upgrade the %v instance info: %v", vaultKey, err)
+		}
+	}
+}
+
+func upgrade(log log.T, schema statefile.Schema, path string) {
+	if !fileutil.Exists(path) {
+		return
+	}
+	if err := upgradeFile(log, schema, path); err != nil {
+		log.Warnf("Failed to upgrade %v: %v", path, err)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package statemigration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/managedInstances/registration"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	identityMocks "github.com/aws/amazon-ssm-agent/common/identity/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun_UpgradesStateFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statemigration")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	originalDataStorePath, originalUpgradeInstanceInfo := appconfig.DefaultDataStorePath, upgradeInstanceInfo
+	defer func() {
+		appconfig.DefaultDataStorePath, upgradeInstanceInfo = originalDataStorePath, originalUpgradeInstanceInfo
+	}()
+	appconfig.DefaultDataStorePath = dir
+	var upgradedVaultKeys []string
+	upgradeInstanceInfo = func(log log.T, manifestFileNamePrefix, vaultKey string) error {
+		upgradedVaultKeys = append(upgradedVaultKeys, vaultKey)
+		return nil
+	}
+
+	instanceDir := filepath.Join(dir, identityMocks.MockShortInstanceID)
+	dataStoreDir := filepath.Join(instanceDir, appconfig.LongRunningPluginsLocation, appconfig.LongRunningPluginDataStoreLocation)
+	assert.NoError(t, os.MkdirAll(dataStoreDir, appconfig.ReadWriteExecuteAccess))
+	dataStoreFile := filepath.Join(dataStoreDir, appconfig.LongRunningPluginDataStoreFileName)
+	assert.NoError(t, ioutil.WriteFile(dataStoreFile, []byte(`{"awsCloudWatch":{"Name":"awsCloudWatch"}}`), appconfig.ReadWriteAccess))
+
+	stateDir := filepath.Join(instanceDir, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState, appconfig.DefaultLocationOfPending)
+	assert.NoError(t, os.MkdirAll(stateDir, appconfig.ReadWriteExecuteAccess))
+	stateFile := filepath.Join(stateDir, "command")
+	assert.NoError(t, ioutil.WriteFile(stateFile, []byte(`{"DocumentType":"SendCommand"}`), appconfig.ReadWriteAccess))
+	corruptFile := filepath.Join(stateDir, "corrupt")
+	assert.NoError(t, ioutil.WriteFile(corruptFile, []byte(`{"DocumentType":`), appconfig.ReadWriteAccess))
+
+	Run(contextmocks.NewMockDefault())
+
+	content, err := ioutil.ReadFile(dataStoreFile)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"StateVersion":3,"Plugins":{"awsCloudWatch":{"Name":"awsCloudWatch","State":{"DesiredState":"Running"}}}}`, string(content))
+	// the document state format has a single version
+	content, err = ioutil.ReadFile(stateFile)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"DocumentType":"SendCommand"}`, string(content))
+	content, err = ioutil.ReadFile(corruptFile)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"DocumentType":`, string(content))
+	assert.ElementsMatch(t, []string{registration.RegVaultKey, registration.EC2RegistrationVaultKey}, upgradedVaultKeys)
+}
+
+func TestRun_TransfersStatesToConfiguredBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statemigration")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	originalDataStorePath, originalTransferStates, originalUpgradeInstanceInfo := appconfig.DefaultDataStorePath, transferStates, upgradeInstanceInfo
+	defer func() {
+		appconfig.DefaultDataStorePath, transferStates, upgradeInstanceInfo = originalDataStorePath, originalTransferStates, originalUpgradeInstanceInfo
+	}()
+	appconfig.DefaultDataStorePath = dir
+	upgradeInstanceInfo = func(log.T, string, string) error { return nil }
+	var transferred [][]string
+	transferStates = func(log log.T, appConfig appconfig.SsmagentConfig, instanceID string, location ...string) {
+		assert.Equal(t, appconfig.StateBackendBoltDB, appConfig.Storage.StateBackend)
+		assert.Equal(t, identityMocks.MockShortInstanceID, instanceID)
+		transferred = append(transferred, location)
+	}
+
+	config := appconfig.DefaultConfig()
+	config.Storage.StateBackend = appconfig.StateBackendBoltDB
+	Run(contextmocks.NewMockDefaultWithConfig(config))
+	assert.Equal(t, stateBackendLocations, transferred)
+}
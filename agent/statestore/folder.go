@@ -0,0 +1,180 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package statestore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// dataStorePath returns the folder holding the data store folders of the instances
+var dataStorePath = func() string {
+	return appconfig.DefaultDataStorePath
+}
+
+// Folder is a set of named states, such as the queue of the replies not delivered to the service
+type Folder interface {
+	// Names returns the names of the states sorted in ascending order
+	Names() ([]string, error)
+	// Read returns the state, the error satisfies os.IsNotExist if there is none
+	Read(name string) ([]byte, error)
+	// Write replaces the state
+	Write(name string, content []byte) error
+	// Delete removes the state, it is not an error if there is none
+	Delete(name string) error
+}
+
+// NewFolder returns the folder of the configured backend at the location under the data store folder of the instance
+func NewFolder(appConfig appconfig.SsmagentConfig, instanceID string, location ...string) Folder {
+	if Enabled(appConfig) {
+		return newDBFolder(instanceID, location)
+	}
+	return newFileFolder(instanceID, location)
+}
+
+// Transfer moves the states of the folder persisted by the backend that is not configured to the configured one, so
+// that the states written before the backend was changed are not lost. A state found in both backends is left as is.
+func Transfer(log log.T, appConfig appconfig.SsmagentConfig, instanceID string, location ...string) {
+	var src, dst Folder = newFileFolder(instanceID, location), newDBFolder(instanceID, location)
+	if !Enabled(appConfig) {
+		// the database is not created for the file backend, there is nothing to transfer if it does not exist
+		if !fileutil.Exists(DatabasePath(dataStorePath(), instanceID)) {
+			return
+		}
+		src, dst = dst, src
+	}
+	names, err := src.Names()
+	if err != nil {
+		log.Warnf("Failed to list the states of %v to transfer: %v", filepath.Join(location...), err)
+		return
+	}
+	if len(names) == 0 {
+		return
+	}
+	existing, err := dst.Names()
+	if err != nil {
+		log.Warnf("Failed to list the states of %v: %v", filepath.Join(location...), err)
+		return
+	}
+	for _, name := range names {
+		if i := sort.SearchStrings(existing, name); i < len(existing) && existing[i] == name {
+			log.Warnf("State %v of %v found in both state backends, keeping the %v one", name, filepath.Join(location...), appConfig.Storage.StateBackend)
+			continue
+		}
+		content, err := src.Read(name)
+		if err == nil {
+			err = dst.Write(name, content)
+		}
+		if err == nil {
+			err = src.Delete(name)
+		}
+		if err != nil {
+			log.Warnf("Failed to transfer the state %v of %v to the %v backend: %v", name, filepath.Join(location...), appConfig.Storage.StateBackend, err)
+			continue
+		}
+		log.Infof("Transferred the state %v of %v to the %v backend", name, filepath.Join(location...), appConfig.Storage.StateBackend)
+	}
+}
+
+// fileFolder keeps each state in a file of a folder
+type fileFolder struct {
+	dir string
+}
+
+func newFileFolder(instanceID string, location []string) *fileFolder {
+	return &fileFolder{dir: filepath.Join(append([]string{dataStorePath(), instanceID}, location...)...)}
+}
+
+// Names returns the names of the files, the hidden files such as the copies being written are not states
+func (f *fileFolder) Names() ([]string, error) {
+	fileNames, err := fileutil.GetFileNames(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, fileName := range fileNames {
+		if !strings.HasPrefix(fileName, ".") {
+			names = append(names, fileName)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f *fileFolder) Read(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(f.dir, name))
+}
+
+func (f *fileFolder) Write(name string, content []byte) error {
+	if err := fileutil.MakeDirs(f.dir); err != nil {
+		return err
+	}
+	_, err := fileutil.WriteIntoFileWithPermissions(filepath.Join(f.dir, name), string(content), os.FileMode(appconfig.ReadWriteAccess))
+	return err
+}
+
+func (f *fileFolder) Delete(name string) error {
+	if err := fileutil.DeleteFile(filepath.Join(f.dir, name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// dbFolder keeps the states in a bucket of the database of the instance
+type dbFolder struct {
+	db       *DB
+	location []string
+}
+
+func newDBFolder(instanceID string, location []string) *dbFolder {
+	return &dbFolder{db: NewDB(DatabasePath(dataStorePath(), instanceID)), location: location}
+}
+
+func (f *dbFolder) Names() (names []string, err error) {
+	err = f.db.View(func(tx *Tx) error {
+		names = tx.Names(f.location)
+		return nil
+	})
+	return
+}
+
+func (f *dbFolder) Read(name string) (content []byte, err error) {
+	err = f.db.View(func(tx *Tx) error {
+		var found bool
+		if content, found = tx.Get(f.location, name); !found {
+			return os.ErrNotExist
+		}
+		return nil
+	})
+	return
+}
+
+func (f *dbFolder) Write(name string, content []byte) error {
+	return f.db.Update(func(tx *Tx) error {
+		return tx.Put(f.location, name, content)
+	})
+}
+
+func (f *dbFolder) Delete(name string) error {
+	return f.db.Update(func(tx *Tx) error {
+		return tx.Delete(f.location, name)
+	})
+}
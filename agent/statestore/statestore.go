@@ -0,0 +1,200 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package statestore persists the states of an instance, the document states, the association state and the reply
+// queues, in the backend selected by the Storage.StateBackend config: one file per state or a BoltDB database
+// updated in transactions.
+package statestore
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// DatabaseFileName is the name of the database holding the states of an instance under its data store folder
+	DatabaseFileName = "state.db"
+
+	// openTimeout bounds the wait for the database lock held by another process
+	openTimeout = 10 * time.Second
+)
+
+// databaseLocks holds the in process locks of the databases, the file lock of the database only serializes the
+// processes
+var databaseLocks = struct {
+	sync.Mutex
+	paths map[string]*sync.Mutex
+}{paths: make(map[string]*sync.Mutex)}
+
+// Enabled returns true if the states are persisted in the database rather than in files
+func Enabled(appConfig appconfig.SsmagentConfig) bool {
+	return appConfig.Storage.StateBackend == appconfig.StateBackendBoltDB
+}
+
+// DatabasePath returns the path of the database of the instance under the data store
+func DatabasePath(dataStorePath, instanceID string) string {
+	return filepath.Join(dataStorePath, instanceID, DatabaseFileName)
+}
+
+// DB is the database of the states of an instance. The agent worker and the document workers access it concurrently,
+// it is opened for each transaction so that no process holds its lock between two accesses.
+type DB struct {
+	path    string
+	timeout time.Duration
+}
+
+// NewDB returns the database at the given path, it is created by the first update
+func NewDB(path string) *DB {
+	return &DB{path: path, timeout: openTimeout}
+}
+
+// Path returns the path of the database file
+func (db *DB) Path() string {
+	return db.path
+}
+
+// View runs the read only access in a transaction
+func (db *DB) View(access func(tx *Tx) error) error {
+	return db.run(func(boltDB *bolt.DB) error {
+		return boltDB.View(func(tx *bolt.Tx) error {
+			return access(&Tx{tx: tx})
+		})
+	})
+}
+
+// Update runs the access in a transaction committed if the access returns no error
+func (db *DB) Update(access func(tx *Tx) error) error {
+	return db.run(func(boltDB *bolt.DB) error {
+		return boltDB.Update(func(tx *bolt.Tx) error {
+			return access(&Tx{tx: tx})
+		})
+	})
+}
+
+// run opens the database for the duration of the access
+func (db *DB) run(access func(boltDB *bolt.DB) error) error {
+	dbLock := databaseLock(db.path)
+	dbLock.Lock()
+	defer dbLock.Unlock()
+
+	if err := fileutil.MakeDirs(filepath.Dir(db.path)); err != nil {
+		return err
+	}
+	boltDB, err := bolt.Open(db.path, appconfig.ReadWriteAccess, &bolt.Options{Timeout: db.timeout})
+	if err != nil {
+		return err
+	}
+	defer boltDB.Close()
+	return access(boltDB)
+}
+
+// databaseLock returns the in process lock of the database
+func databaseLock(path string) *sync.Mutex {
+	databaseLocks.Lock()
+	defer databaseLocks.Unlock()
+	dbLock, found := databaseLocks.paths[path]
+	if !found {
+		dbLock = &sync.Mutex{}
+		databaseLocks.paths[path] = dbLock
+	}
+	return dbLock
+}
+
+// Tx is a transaction of the database. A location is the path of nested buckets holding the states, the same path
+// as the folder of the state files under the data store folder of the instance.
+type Tx struct {
+	tx *bolt.Tx
+}
+
+// Get returns a copy of the state in the location, found is false if there is none
+func (tx *Tx) Get(location []string, name string) (content []byte, found bool) {
+	bucket := tx.bucket(location)
+	if bucket == nil {
+		return nil, false
+	}
+	value := bucket.Get([]byte(name))
+	if value == nil {
+		return nil, false
+	}
+	// the value is only valid during the transaction
+	return append([]byte{}, value...), true
+}
+
+// Put replaces the state in the location, the buckets of the location are created if needed
+func (tx *Tx) Put(location []string, name string, content []byte) error {
+	bucket, err := tx.createBucket(location)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(name), content)
+}
+
+// Delete removes the state from the location, it is not an error if there is none
+func (tx *Tx) Delete(location []string, name string) error {
+	bucket := tx.bucket(location)
+	if bucket == nil {
+		return nil
+	}
+	return bucket.Delete([]byte(name))
+}
+
+// Names returns the names of the states in the location sorted in ascending order
+func (tx *Tx) Names(location []string) (names []string) {
+	bucket := tx.bucket(location)
+	if bucket == nil {
+		return
+	}
+	bucket.ForEach(func(key, value []byte) error {
+		// the nested buckets have no value
+		if value != nil {
+			names = append(names, string(key))
+		}
+		return nil
+	})
+	return
+}
+
+// bucket returns the bucket of the location, nil if it does not exist
+func (tx *Tx) bucket(location []string) *bolt.Bucket {
+	if len(location) == 0 {
+		return nil
+	}
+	bucket := tx.tx.Bucket([]byte(location[0]))
+	for _, name := range location[1:] {
+		if bucket == nil {
+			return nil
+		}
+		bucket = bucket.Bucket([]byte(name))
+	}
+	return bucket
+}
+
+// createBucket returns the bucket of the location, creating the missing buckets
+func (tx *Tx) createBucket(location []string) (*bolt.Bucket, error) {
+	if len(location) == 0 {
+		return nil, bolt.ErrBucketNameRequired
+	}
+	bucket, err := tx.tx.CreateBucketIfNotExists([]byte(location[0]))
+	for _, name := range location[1:] {
+		if err != nil {
+			return nil, err
+		}
+		bucket, err = bucket.CreateBucketIfNotExists([]byte(name))
+	}
+	return bucket, err
+}
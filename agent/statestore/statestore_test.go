@@ -0,0 +1,172 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package statestore
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const testInstanceID = "i-1234567890"
+
+func useTempDataStore(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "statestore")
+	assert.NoError(t, err)
+	previous := dataStorePath
+	dataStorePath = func() string { return dir }
+	t.Cleanup(func() {
+		dataStorePath = previous
+		os.RemoveAll(dir)
+	})
+	return dir
+}
+
+func boltConfig() appconfig.SsmagentConfig {
+	config := appconfig.DefaultConfig()
+	config.Storage.StateBackend = appconfig.StateBackendBoltDB
+	return config
+}
+
+func TestFolder_Backends(t *testing.T) {
+	for _, config := range []appconfig.SsmagentConfig{appconfig.DefaultConfig(), boltConfig()} {
+		useTempDataStore(t)
+		folder := NewFolder(config, testInstanceID, "replies")
+
+		names, err := folder.Names()
+		assert.NoError(t, err)
+		assert.Empty(t, names)
+		_, err = folder.Read("missing")
+		assert.True(t, os.IsNotExist(err), config.Storage.StateBackend)
+		assert.NoError(t, folder.Delete("missing"))
+
+		assert.NoError(t, folder.Write("b", []byte("second")))
+		assert.NoError(t, folder.Write("a", []byte("first")))
+		assert.NoError(t, folder.Write("a", []byte("first updated")))
+		names, err = folder.Names()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, names)
+		content, err := folder.Read("a")
+		assert.NoError(t, err)
+		assert.Equal(t, "first updated", string(content))
+
+		assert.NoError(t, folder.Delete("a"))
+		names, err = folder.Names()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b"}, names)
+	}
+}
+
+func TestFolder_FileBackendDoesNotCreateTheDatabase(t *testing.T) {
+	dir := useTempDataStore(t)
+	folder := NewFolder(appconfig.DefaultConfig(), testInstanceID, "replies")
+	assert.NoError(t, folder.Write("a", []byte("first")))
+	Transfer(log.NewMockLog(), appconfig.DefaultConfig(), testInstanceID, "replies")
+
+	assert.FileExists(t, filepath.Join(dir, testInstanceID, "replies", "a"))
+	assert.NoFileExists(t, DatabasePath(dir, testInstanceID))
+}
+
+func TestTransfer_MovesTheStatesToTheConfiguredBackend(t *testing.T) {
+	dir := useTempDataStore(t)
+	files := NewFolder(appconfig.DefaultConfig(), testInstanceID, "document", "state", "pending")
+	assert.NoError(t, files.Write("commandID", []byte("pending")))
+	assert.NoError(t, files.Write(".commandID123", []byte("copy being written")))
+
+	Transfer(log.NewMockLog(), boltConfig(), testInstanceID, "document", "state", "pending")
+	db := NewFolder(boltConfig(), testInstanceID, "document", "state", "pending")
+	content, err := db.Read("commandID")
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", string(content))
+	assert.NoFileExists(t, filepath.Join(dir, testInstanceID, "document", "state", "pending", "commandID"))
+
+	// back to the files
+	Transfer(log.NewMockLog(), appconfig.DefaultConfig(), testInstanceID, "document", "state", "pending")
+	content, err = files.Read("commandID")
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", string(content))
+	names, err := db.Names()
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestTransfer_KeepsTheStatesOfTheConfiguredBackend(t *testing.T) {
+	useTempDataStore(t)
+	files := NewFolder(appconfig.DefaultConfig(), testInstanceID, "replies")
+	db := NewFolder(boltConfig(), testInstanceID, "replies")
+	assert.NoError(t, files.Write("reply", []byte("file")))
+	assert.NoError(t, db.Write("reply", []byte("database")))
+
+	Transfer(log.NewMockLog(), boltConfig(), testInstanceID, "replies")
+	content, err := db.Read("reply")
+	assert.NoError(t, err)
+	assert.Equal(t, "database", string(content))
+	content, err = files.Read("reply")
+	assert.NoError(t, err)
+	assert.Equal(t, "file", string(content))
+}
+
+func TestDB_UpdateIsRolledBackOnError(t *testing.T) {
+	dir := useTempDataStore(t)
+	db := NewDB(DatabasePath(dir, testInstanceID))
+	location := []string{"document", "state", "current"}
+	assert.NoError(t, db.Update(func(tx *Tx) error {
+		return tx.Put(location, "commandID", []byte("current"))
+	}))
+
+	failure := errors.New("failure")
+	assert.Equal(t, failure, db.Update(func(tx *Tx) error {
+		assert.NoError(t, tx.Delete(location, "commandID"))
+		assert.NoError(t, tx.Put([]string{"document", "state", "completed"}, "commandID", []byte("completed")))
+		return failure
+	}))
+
+	assert.NoError(t, db.View(func(tx *Tx) error {
+		content, found := tx.Get(location, "commandID")
+		assert.True(t, found)
+		assert.Equal(t, "current", string(content))
+		assert.Empty(t, tx.Names([]string{"document", "state", "completed"}))
+		// the nested buckets are not states
+		assert.Empty(t, tx.Names([]string{"document", "state"}))
+		return nil
+	}))
+}
+
+func TestDB_ConcurrentUpdates(t *testing.T) {
+	dir := useTempDataStore(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			db := NewDB(DatabasePath(dir, testInstanceID))
+			assert.NoError(t, db.Update(func(tx *Tx) error {
+				return tx.Put([]string{"replies"}, fmt.Sprintf("reply%v", i), []byte("content"))
+			}))
+		}(i)
+	}
+	wg.Wait()
+
+	names, err := NewFolder(boltConfig(), testInstanceID, "replies").Names()
+	assert.NoError(t, err)
+	assert.Len(t, names, 10)
+}
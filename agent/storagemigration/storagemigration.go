@@ -0,0 +1,176 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package storagemigration moves the data of an existing install to the storage locations of the app config.
+package storagemigration
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/common/identity"
+)
+
+const (
+	vaultDirName         = "Vault"
+	registrationFileName = "registration"
+)
+
+var (
+	builtInLocations = appconfig.BuiltInStorageLocations
+	currentLocations = appconfig.CurrentStorageLocations
+	rename           = os.Rename
+)
+
+// MigrateHostData moves the data that is not bound to the instance from its built-in location to the configured one:
+// the registration vault and file, the local commands, the session files, the custom inventory, the runtime configs
+// and the downloads. It runs before the identity of the agent is loaded from them. A location is not moved when
+// its destination already holds data, the agent then keeps the data of the destination. The logs are not moved.
+func MigrateHostData(log log.T) {
+	builtIn, current := builtInLocations(), currentLocations()
+
+	if !samePath(builtIn.DataStorePath, current.DataStorePath) {
+		for _, name := range []string{vaultDirName, registrationFileName} {
+			moveDir(log, filepath.Join(builtIn.DataStorePath, name), filepath.Join(current.DataStorePath, name))
+		}
+	}
+	for _, location := range [][2]string{
+		{builtIn.LocalCommandRoot, current.LocalCommandRoot},
+		{builtIn.SessionFilesPath, current.SessionFilesPath},
+		{builtIn.CustomInventoryFolder, current.CustomInventoryFolder},
+		{builtIn.RuntimeConfigFolderPath, current.RuntimeConfigFolderPath},
+		{builtIn.DownloadRoot, current.DownloadRoot},
+	} {
+		if !samePath(location[0], location[1]) {
+			moveDir(log, location[0], location[1])
+		}
+	}
+}
+
+// Migrate moves the instance data and the orchestration outputs from their previous location to the configured
+// one, it runs in the core agent before the workers start.
+func Migrate(log log.T, appConfig appconfig.SsmagentConfig, agentIdentity identity.IAgentIdentity) {
+	shortInstanceID, err := agentIdentity.ShortInstanceID()
+	if err != nil {
+		log.Warnf("Storage migration skipped, the instance id is not available: %v", err)
+		return
+	}
+	builtIn, current := builtInLocations(), currentLocations()
+
+	if !samePath(builtIn.DataStorePath, current.DataStorePath) {
+		moveDir(log,
+			filepath.Join(builtIn.DataStorePath, shortInstanceID),
+			filepath.Join(current.DataStorePath, shortInstanceID))
+	}
+	if !samePath(current.DataStorePath, current.OrchestrationStorePath) {
+		orchestrationRootDir := appConfig.Agent.OrchestrationRootDir
+		for _, rootDirName := range []string{appconfig.DefaultDocumentRootDirName, appconfig.DefaultSessionRootDirName} {
+			moveDir(log,
+				filepath.Join(current.DataStorePath, shortInstanceID, rootDirName, orchestrationRootDir),
+				filepath.Join(current.OrchestrationStorePath, shortInstanceID, rootDirName, orchestrationRootDir))
+		}
+	}
+}
+
+// moveDir moves the src directory or file to dst when dst does not exist or is an empty directory, the content is
+// copied when src cannot be renamed, e.g. dst is on another volume
+func moveDir(log log.T, src, dst string) {
+	src, dst = filepath.Clean(src), filepath.Clean(dst)
+	if _, err := os.Stat(src); err != nil {
+		return
+	}
+	if isWithin(dst, src) || isWithin(src, dst) {
+		log.Warnf("Not moving %v to %v, one location contains the other", src, dst)
+		return
+	}
+	if info, err := os.Stat(dst); err == nil {
+		if entries, err := ioutil.ReadDir(dst); !info.IsDir() || err != nil || len(entries) > 0 {
+			log.Warnf("Not moving %v to %v, the destination is not empty", src, dst)
+			return
+		}
+	}
+
+	log.Infof("Moving %v to %v", src, dst)
+	if err := os.MkdirAll(filepath.Dir(dst), appconfig.ReadWriteExecuteAccess); err != nil {
+		log.Errorf("Failed to move %v to %v: %v", src, dst, err)
+		return
+	}
+	os.Remove(dst)
+	if err := rename(src, dst); err == nil {
+		return
+	}
+	if err := copyDir(src, dst); err != nil {
+		log.Errorf("Failed to copy %v to %v, the agent keeps using an empty %v: %v", src, dst, dst, err)
+		os.RemoveAll(dst)
+		return
+	}
+	if err := os.RemoveAll(src); err != nil {
+		log.Warnf("Failed to delete %v after it was copied to %v: %v", src, dst, err)
+	}
+}
+
+// copyDir copies the src directory tree or file to dst, keeping the permissions of the files and directories
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relativePath)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return fmt.Errorf("%v is not a regular file", path)
+		}
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// samePath returns true if both paths name the same directory
+func samePath(a, b string) bool {
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// isWithin returns true if path is a sub directory of dir
+func isWithin(path, dir string) bool {
+	relativePath, err := filepath.Rel(dir, path)
+	return err == nil && relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(os.PathSeparator)) && relativePath != "."
+}
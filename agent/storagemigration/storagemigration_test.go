@@ -0,0 +1,210 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storagemigration
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	identityMocks "github.com/aws/amazon-ssm-agent/common/identity/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), appconfig.ReadWriteExecuteAccess))
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), appconfig.ReadWriteAccess))
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+// setupLocations mocks the built-in and configured locations under a temporary directory
+func setupLocations(t *testing.T, builtIn, current appconfig.StorageLocations) {
+	originalBuiltIn, originalCurrent, originalRename := builtInLocations, currentLocations, rename
+	t.Cleanup(func() {
+		builtInLocations, currentLocations, rename = originalBuiltIn, originalCurrent, originalRename
+	})
+	builtInLocations = func() appconfig.StorageLocations { return builtIn }
+	currentLocations = func() appconfig.StorageLocations { return current }
+}
+
+func TestMigrate_MovesInstanceDataAndOrchestration(t *testing.T) {
+	root, err := ioutil.TempDir("", "storagemigration")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	instanceID := identityMocks.MockShortInstanceID
+
+	builtIn := appconfig.StorageLocations{
+		DataStorePath:          filepath.Join(root, "data"),
+		DownloadRoot:           filepath.Join(root, "download"),
+		OrchestrationStorePath: filepath.Join(root, "data"),
+	}
+	current := appconfig.StorageLocations{
+		DataStorePath:          filepath.Join(root, "state"),
+		DownloadRoot:           filepath.Join(root, "ephemeral", "download"),
+		OrchestrationStorePath: filepath.Join(root, "ephemeral", "outputs"),
+	}
+	setupLocations(t, builtIn, current)
+	writeFile(t, filepath.Join(builtIn.DataStorePath, instanceID, "document", "state", "current", "command"), "state")
+	writeFile(t, filepath.Join(builtIn.DataStorePath, instanceID, "document", "orchestration", "command", "stdout"), "output")
+
+	config := appconfig.SsmagentConfig{}
+	config.Agent.OrchestrationRootDir = "orchestration"
+	context := contextmocks.NewMockDefault()
+	Migrate(context.Log(), config, context.Identity())
+
+	assert.Equal(t, "state", readFile(t, filepath.Join(current.DataStorePath, instanceID, "document", "state", "current", "command")))
+	assert.Equal(t, "output", readFile(t, filepath.Join(current.OrchestrationStorePath, instanceID, "document", "orchestration", "command", "stdout")))
+	assert.NoDirExists(t, filepath.Join(builtIn.DataStorePath, instanceID))
+	assert.NoDirExists(t, filepath.Join(current.DataStorePath, instanceID, "document", "orchestration"))
+}
+
+func TestMigrateHostData_MovesRegistrationHostDirectoriesAndDownloads(t *testing.T) {
+	root, err := ioutil.TempDir("", "storagemigration")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	builtIn := appconfig.StorageLocations{
+		DataStorePath:           filepath.Join(root, "data"),
+		DownloadRoot:            filepath.Join(root, "download"),
+		LocalCommandRoot:        filepath.Join(root, "data", "localcommands"),
+		SessionFilesPath:        filepath.Join(root, "data", "session"),
+		CustomInventoryFolder:   filepath.Join(root, "data", "inventory", "custom"),
+		RuntimeConfigFolderPath: filepath.Join(root, "data", "runtimeconfig"),
+	}
+	current := appconfig.StorageLocations{
+		DataStorePath:           filepath.Join(root, "state"),
+		DownloadRoot:            filepath.Join(root, "ephemeral", "download"),
+		LocalCommandRoot:        filepath.Join(root, "state", "localcommands"),
+		SessionFilesPath:        filepath.Join(root, "state", "session"),
+		CustomInventoryFolder:   filepath.Join(root, "state", "inventory", "custom"),
+		RuntimeConfigFolderPath: filepath.Join(root, "state", "runtimeconfig"),
+	}
+	setupLocations(t, builtIn, current)
+	writeFile(t, filepath.Join(builtIn.DataStorePath, "Vault", "Manifest"), "manifest")
+	writeFile(t, filepath.Join(builtIn.DataStorePath, "registration"), "registration")
+	writeFile(t, filepath.Join(builtIn.LocalCommandRoot, "submitted", "command"), "command")
+	writeFile(t, filepath.Join(builtIn.SessionFilesPath, "sessionuser", "state"), "session")
+	writeFile(t, filepath.Join(builtIn.CustomInventoryFolder, "inventory.json"), "inventory")
+	writeFile(t, filepath.Join(builtIn.RuntimeConfigFolderPath, "identity_config.json"), "identity")
+	writeFile(t, filepath.Join(builtIn.DownloadRoot, "artifact"), "artifact")
+
+	MigrateHostData(contextmocks.NewMockDefault().Log())
+
+	assert.Equal(t, "manifest", readFile(t, filepath.Join(current.DataStorePath, "Vault", "Manifest")))
+	assert.Equal(t, "registration", readFile(t, filepath.Join(current.DataStorePath, "registration")))
+	assert.Equal(t, "command", readFile(t, filepath.Join(current.LocalCommandRoot, "submitted", "command")))
+	assert.Equal(t, "session", readFile(t, filepath.Join(current.SessionFilesPath, "sessionuser", "state")))
+	assert.Equal(t, "inventory", readFile(t, filepath.Join(current.CustomInventoryFolder, "inventory.json")))
+	assert.Equal(t, "identity", readFile(t, filepath.Join(current.RuntimeConfigFolderPath, "identity_config.json")))
+	assert.Equal(t, "artifact", readFile(t, filepath.Join(current.DownloadRoot, "artifact")))
+	assert.NoDirExists(t, filepath.Join(builtIn.DataStorePath, "Vault"))
+	assert.NoFileExists(t, filepath.Join(builtIn.DataStorePath, "registration"))
+	assert.NoDirExists(t, builtIn.LocalCommandRoot)
+	assert.NoDirExists(t, builtIn.DownloadRoot)
+}
+
+func TestMigrate_BuiltInLocationsNotMoved(t *testing.T) {
+	root, err := ioutil.TempDir("", "storagemigration")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	locations := appconfig.StorageLocations{
+		DataStorePath:          filepath.Join(root, "data"),
+		DownloadRoot:           filepath.Join(root, "download"),
+		OrchestrationStorePath: filepath.Join(root, "data"),
+	}
+	setupLocations(t, locations, locations)
+	rename = func(string, string) error {
+		assert.Fail(t, "nothing should be moved")
+		return nil
+	}
+	writeFile(t, filepath.Join(locations.DataStorePath, identityMocks.MockShortInstanceID, "state"), "state")
+
+	context := contextmocks.NewMockDefault()
+	Migrate(context.Log(), context.AppConfig(), context.Identity())
+	MigrateHostData(contextmocks.NewMockDefault().Log())
+
+	assert.Equal(t, "state", readFile(t, filepath.Join(locations.DataStorePath, identityMocks.MockShortInstanceID, "state")))
+}
+
+func TestMoveDir_DestinationNotEmptyKeepsBothLocations(t *testing.T) {
+	root, err := ioutil.TempDir("", "storagemigration")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	writeFile(t, filepath.Join(src, "file"), "old")
+	writeFile(t, filepath.Join(dst, "file"), "new")
+
+	moveDir(contextmocks.NewMockDefault().Log(), src, dst)
+
+	assert.Equal(t, "old", readFile(t, filepath.Join(src, "file")))
+	assert.Equal(t, "new", readFile(t, filepath.Join(dst, "file")))
+}
+
+func TestMoveDir_ExistingFileKept(t *testing.T) {
+	root, err := ioutil.TempDir("", "storagemigration")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	src, dst := filepath.Join(root, "src", "registration"), filepath.Join(root, "dst", "registration")
+	writeFile(t, src, "old")
+	writeFile(t, dst, "new")
+
+	moveDir(contextmocks.NewMockDefault().Log(), src, dst)
+
+	assert.Equal(t, "old", readFile(t, src))
+	assert.Equal(t, "new", readFile(t, dst))
+}
+
+func TestMoveDir_CopiesWhenRenameFails(t *testing.T) {
+	root, err := ioutil.TempDir("", "storagemigration")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	originalRename := rename
+	defer func() { rename = originalRename }()
+	// a rename across volumes fails
+	rename = func(string, string) error { return errors.New("invalid cross-device link") }
+
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "other", "dst")
+	writeFile(t, filepath.Join(src, "file"), "content")
+	writeFile(t, filepath.Join(src, "sub", "nested"), "nested")
+	assert.NoError(t, os.MkdirAll(dst, appconfig.ReadWriteExecuteAccess))
+
+	moveDir(contextmocks.NewMockDefault().Log(), src, dst)
+
+	assert.Equal(t, "content", readFile(t, filepath.Join(dst, "file")))
+	assert.Equal(t, "nested", readFile(t, filepath.Join(dst, "sub", "nested")))
+	assert.NoDirExists(t, src)
+}
+
+func TestMoveDir_NestedLocationsNotMoved(t *testing.T) {
+	root, err := ioutil.TempDir("", "storagemigration")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	src := filepath.Join(root, "download")
+	writeFile(t, filepath.Join(src, "file"), "content")
+
+	moveDir(contextmocks.NewMockDefault().Log(), src, filepath.Join(src, "moved"))
+
+	assert.Equal(t, "content", readFile(t, filepath.Join(src, "file")))
+	assert.NoDirExists(t, filepath.Join(src, "moved"))
+}
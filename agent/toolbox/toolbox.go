@@ -0,0 +1,137 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package toolbox implements the helpers of ssm-cli toolbox, the documents can download, unzip and hash files and
+// restart services with them on the hosts missing curl, unzip or the service wrappers.
+package toolbox
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const cliName = "ssm-cli"
+
+// Hash algorithms supported by Hash
+const (
+	HashSha256 = "sha256"
+	HashSha1   = "sha1"
+	HashMd5    = "md5"
+)
+
+var newHashes = map[string]func() hash.Hash{
+	HashSha256: sha256.New,
+	HashSha1:   sha1.New,
+	HashMd5:    md5.New,
+}
+
+// CliPath returns the path of the ssm-cli installed next to the running executable, false if there is none
+func CliPath() (string, bool) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", false
+	}
+	name := cliName
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	path := filepath.Join(filepath.Dir(executable), name)
+	return path, fileutil.Exists(path)
+}
+
+// Download downloads the file at sourceURL to destination, the file is only written when its content matches the
+// sha256 checksum if one is given
+func Download(context context.T, sourceURL string, destination string, checksum string) error {
+	if err := fileutil.MakeDirs(filepath.Dir(destination)); err != nil {
+		return fmt.Errorf("failed to create directory %v: %v", filepath.Dir(destination), err)
+	}
+	downloadDir, err := ioutil.TempDir(filepath.Dir(destination), ".download")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(downloadDir)
+
+	input := artifact.DownloadInput{SourceURL: sourceURL, DestinationDirectory: downloadDir}
+	if checksum != "" {
+		input.SourceChecksums = map[string]string{HashSha256: checksum}
+	}
+	output, err := artifact.Download(context, input)
+	if err != nil {
+		return fmt.Errorf("failed to download %v: %v", sourceURL, err)
+	}
+	if !output.IsHashMatched {
+		return fmt.Errorf("the checksum of %v does not match", sourceURL)
+	}
+	return copyFile(context.Log(), output.LocalFilePath, destination)
+}
+
+// Unzip extracts the zip archive to the destination directory
+func Unzip(source string, destination string) error {
+	if err := fileutil.Unzip(source, destination); err != nil {
+		return fmt.Errorf("failed to unzip %v: %v", source, err)
+	}
+	return nil
+}
+
+// Hash returns the hex encoded hash of the file computed with the algorithm sha256, sha1 or md5
+func Hash(path string, algorithm string) (string, error) {
+	newHash, supported := newHashes[strings.ToLower(algorithm)]
+	if !supported {
+		return "", fmt.Errorf("unsupported hash algorithm %v", algorithm)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hasher := newHash()
+	if _, err = io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// RestartService restarts the service with the service manager of the host, a name starting with a dash
+// would be read as an option of the service manager and is rejected
+func RestartService(log log.T, name string) error {
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid service name %q", name)
+	}
+	return restartService(log, name)
+}
+
+// copyFile copies the content of the source file to the destination file
+func copyFile(log log.T, source string, destination string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = artifact.FileCopy(log, destination, file)
+	return err
+}
@@ -0,0 +1,34 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package toolbox
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// restartService restarts the launchd system service, killing its running instance first
+func restartService(log log.T, name string) error {
+	log.Infof("Restarting service %v with launchctl", name)
+	if output, err := exec.Command("launchctl", "kickstart", "-k", "system/"+name).CombinedOutput(); err != nil {
+		return fmt.Errorf("launchctl kickstart failed: %v %v", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
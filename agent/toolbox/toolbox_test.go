@@ -0,0 +1,119 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package toolbox
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var testContent = []byte("toolbox test content")
+
+func testChecksum() string {
+	sum := sha256.Sum256(testContent)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, ioutil.WriteFile(path, testContent, 0600))
+
+	hash, err := Hash(path, "SHA256")
+	assert.NoError(t, err)
+	assert.Equal(t, testChecksum(), hash)
+
+	hash, err = Hash(path, HashMd5)
+	assert.NoError(t, err)
+	assert.Len(t, hash, 32)
+
+	_, err = Hash(path, "crc32")
+	assert.Error(t, err)
+	_, err = Hash(filepath.Join(t.TempDir(), "missing"), HashSha256)
+	assert.Error(t, err)
+}
+
+func TestDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testContent)
+	}))
+	defer server.Close()
+	destinationDir := t.TempDir()
+	destination := filepath.Join(destinationDir, "downloaded")
+
+	assert.NoError(t, Download(context.NewMockDefault(), server.URL+"/file", destination, testChecksum()))
+	content, err := ioutil.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, testContent, content)
+
+	// the temporary download directory is removed
+	files, _ := ioutil.ReadDir(destinationDir)
+	assert.Len(t, files, 1)
+}
+
+func TestDownloadWithMismatchedChecksum(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testContent)
+	}))
+	defer server.Close()
+	destination := filepath.Join(t.TempDir(), "downloaded")
+
+	assert.Error(t, Download(context.NewMockDefault(), server.URL+"/file", destination, hex.EncodeToString(make([]byte, sha256.Size))))
+	assert.NoFileExists(t, destination)
+}
+
+func TestUnzip(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "archive.zip")
+	file, err := os.Create(archive)
+	assert.NoError(t, err)
+	writer := zip.NewWriter(file)
+	entry, _ := writer.Create("file.txt")
+	entry.Write(testContent)
+	assert.NoError(t, writer.Close())
+	file.Close()
+	destination := t.TempDir()
+
+	assert.NoError(t, Unzip(archive, destination))
+	content, err := ioutil.ReadFile(filepath.Join(destination, "file.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, testContent, content)
+
+	assert.Error(t, Unzip(filepath.Join(t.TempDir(), "missing.zip"), destination))
+}
+
+func TestRestartServiceWithInvalidName(t *testing.T) {
+	for _, name := range []string{"", "../sshd", `..\sshd`, "--help", "-a"} {
+		assert.Error(t, RestartService(log.NewMockLog(), name), name)
+	}
+}
+
+func TestCliPath(t *testing.T) {
+	path, _ := CliPath()
+	name := "ssm-cli"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	assert.Equal(t, name, filepath.Base(path))
+}
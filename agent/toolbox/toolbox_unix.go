@@ -0,0 +1,68 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package toolbox
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const initScriptsDir = "/etc/init.d"
+
+// serviceManagers are tried in order, the first one installed on the host restarts the service
+var serviceManagers = []func(name string) []string{
+	func(name string) []string { return []string{"systemctl", "restart", name} },
+	func(name string) []string { return []string{"service", name, "restart"} },
+	func(name string) []string { return []string{"initctl", "restart", name} },
+}
+
+var execCommand = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+var lookPath = exec.LookPath
+
+// restartService restarts the service with systemd, the service or initctl wrappers or its init script
+func restartService(log log.T, name string) error {
+	for _, serviceManager := range serviceManagers {
+		command := serviceManager(name)
+		if _, err := lookPath(command[0]); err != nil {
+			continue
+		}
+		log.Infof("Restarting service %v with %v", name, command[0])
+		return runRestart(command)
+	}
+
+	initScript := filepath.Join(initScriptsDir, name)
+	if !fileutil.Exists(initScript) {
+		return fmt.Errorf("no service manager found to restart service %v", name)
+	}
+	log.Infof("Restarting service %v with %v", name, initScript)
+	return runRestart([]string{initScript, "restart"})
+}
+
+func runRestart(command []string) error {
+	if output, err := execCommand(command[0], command[1:]...); err != nil {
+		return fmt.Errorf("%v failed: %v %v", strings.Join(command, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package toolbox
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubServiceManagers(t *testing.T, installed map[string]bool, failing bool) *[][]string {
+	var commands [][]string
+	lookPath = func(file string) (string, error) {
+		if installed[file] {
+			return "/usr/bin/" + file, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	execCommand = func(name string, args ...string) ([]byte, error) {
+		commands = append(commands, append([]string{name}, args...))
+		if failing {
+			return []byte("unit not found"), fmt.Errorf("exit status 5")
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() {
+		lookPath = exec.LookPath
+		execCommand = func(name string, args ...string) ([]byte, error) {
+			return exec.Command(name, args...).CombinedOutput()
+		}
+	})
+	return &commands
+}
+
+func TestRestartServiceWithSystemd(t *testing.T) {
+	commands := stubServiceManagers(t, map[string]bool{"systemctl": true, "service": true}, false)
+
+	assert.NoError(t, RestartService(log.NewMockLog(), "nginx"))
+	assert.Equal(t, [][]string{{"systemctl", "restart", "nginx"}}, *commands)
+}
+
+func TestRestartServiceFallsBackToServiceWrapper(t *testing.T) {
+	commands := stubServiceManagers(t, map[string]bool{"service": true}, false)
+
+	assert.NoError(t, RestartService(log.NewMockLog(), "nginx"))
+	assert.Equal(t, [][]string{{"service", "nginx", "restart"}}, *commands)
+}
+
+func TestRestartServiceReportsFailure(t *testing.T) {
+	stubServiceManagers(t, map[string]bool{"systemctl": true}, true)
+
+	err := RestartService(log.NewMockLog(), "nginx")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unit not found")
+}
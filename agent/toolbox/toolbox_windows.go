@@ -0,0 +1,71 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package toolbox
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const (
+	serviceStopTimeout  = 60 * time.Second
+	servicePollInterval = 500 * time.Millisecond
+)
+
+// restartService stops the service with the service control manager, waits for it to stop and starts it again
+func restartService(log log.T, name string) error {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service control manager: %v", err)
+	}
+	defer manager.Disconnect()
+
+	service, err := manager.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("failed to open service %v: %v", name, err)
+	}
+	defer service.Close()
+
+	log.Infof("Restarting service %v with the service control manager", name)
+	status, err := service.Query()
+	if err != nil {
+		return fmt.Errorf("failed to query service %v: %v", name, err)
+	}
+	if status.State != svc.Stopped {
+		if status, err = service.Control(svc.Stop); err != nil {
+			return fmt.Errorf("failed to stop service %v: %v", name, err)
+		}
+		deadline := time.Now().Add(serviceStopTimeout)
+		for status.State != svc.Stopped {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("service %v did not stop within %v", name, serviceStopTimeout)
+			}
+			time.Sleep(servicePollInterval)
+			if status, err = service.Query(); err != nil {
+				return fmt.Errorf("failed to query service %v: %v", name, err)
+			}
+		}
+	}
+	if err = service.Start(); err != nil {
+		return fmt.Errorf("failed to start service %v: %v", name, err)
+	}
+	return nil
+}
@@ -170,7 +170,7 @@ func getV12DocOrchDir(identity identity.IAgentIdentity, log log.T, update *Updat
 	}
 
 	return fileutil.BuildPath(
-		appconfig.DefaultDataStorePath,
+		appconfig.OrchestrationStorePath,
 		shortInstanceId,
 		appconfig.DefaultDocumentRootDirName,
 		"orchestration",
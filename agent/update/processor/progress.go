@@ -22,6 +22,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	logPkg "github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/aws/amazon-ssm-agent/agent/log/winevent"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil/updateconstants"
 )
 
@@ -90,6 +91,11 @@ func (u *updateManager) succeeded(updateDetail *UpdateDetail, log logPkg.T) (err
 		logger.AgentUpdateResultMessage,
 		updateDetail.SourceVersion,
 		PrepareHealthStatus(updateDetail, "", updateDetail.TargetVersion))
+	winevent.Write(log, winevent.UpdateApplied, winevent.Fields{
+		"sourceVersion": updateDetail.SourceVersion,
+		"targetVersion": updateDetail.TargetVersion,
+		"status":        string(updateDetail.Result),
+	})
 	return u.finalize(u, updateDetail, "")
 }
 
@@ -114,6 +120,12 @@ func (u *updateManager) failed(updateDetail *UpdateDetail, log logPkg.T, code up
 		logger.AgentUpdateResultMessage,
 		updateDetail.SourceVersion,
 		PrepareHealthStatus(updateDetail, errorCode, updateDetail.TargetVersion))
+	winevent.WriteWarning(log, winevent.UpdateApplied, winevent.Fields{
+		"sourceVersion": updateDetail.SourceVersion,
+		"targetVersion": updateDetail.TargetVersion,
+		"status":        string(updateDetail.Result),
+		"errorCode":     errorCode,
+	})
 	return u.finalize(u, updateDetail, errorCode)
 }
 
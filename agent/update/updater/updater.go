@@ -76,7 +76,7 @@ var (
 )
 
 func init() {
-	log = ssmlog.GetUpdaterLogger(logger.DefaultLogDir, defaultLogFileName)
+	log = ssmlog.GetUpdaterLogger(logger.LogDir(), defaultLogFileName)
 
 	// Load update detail from command line
 	update = flag.Bool(updateconstants.UpdateCmd, false, "current Agent Version")
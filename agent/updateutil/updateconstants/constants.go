@@ -137,12 +137,6 @@ const (
 	// ManifestFile is the manifest file name
 	ManifestFile = "ssm-agent-manifest.json"
 
-	// CommonS3URL is the s3 URL for regular regions
-	CommonS3URL = "https://s3.{Region}.amazonaws.com"
-
-	// ChinaS3URL is the s3 URL for regions in China
-	ChinaS3URL = "https://s3.{Region}.amazonaws.com.cn"
-
 	// DarwinBinaryPath is the default path of the amazon-ssm-agent binary on darwin
 	DarwinBinaryPath = "/opt/aws/ssm/bin/amazon-ssm-agent"
 )
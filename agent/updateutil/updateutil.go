@@ -34,11 +34,11 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
-	"github.com/aws/amazon-ssm-agent/agent/s3util"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil/updateconstants"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil/updateinfo"
 	"github.com/aws/amazon-ssm-agent/agent/versionutil"
 	"github.com/aws/amazon-ssm-agent/common/identity"
+	"github.com/aws/amazon-ssm-agent/common/identity/endpoint"
 	identity2 "github.com/aws/amazon-ssm-agent/common/identity/identity"
 	"github.com/aws/amazon-ssm-agent/core/executor"
 	"github.com/aws/amazon-ssm-agent/core/workerprovider/longrunningprovider/model"
@@ -332,7 +332,7 @@ func (util *Utility) IsServiceRunning(log log.T, i updateinfo.T) (result bool, e
 
 	if isSystemD {
 		expectedOutput = "Active: active (running)"
-		if commandOutput, err = execCommand("systemctl", "status", "amazon-ssm-agent.service").Output(); err != nil {
+		if commandOutput, err = execCommand("systemctl", "status", appconfig.InstanceServiceName("amazon-ssm-agent")+".service").Output(); err != nil {
 			//test snap service enabled
 			if commandOutput, err = execCommand("systemctl", "status", "snap.amazon-ssm-agent.amazon-ssm-agent.service").Output(); err != nil {
 				return false, err
@@ -723,14 +723,11 @@ func GetManifestURLFromSourceUrl(sourceURL string) (string, error) {
 
 // ResolveAgentReleaseBucketURL makes best effort to generate an url for the ssm agent bucket
 func ResolveAgentReleaseBucketURL(region string, identity identity.IAgentIdentity) string {
-	s3Url := ""
-	if dynamicS3Endpoint := identity.GetServiceEndpoint("s3"); dynamicS3Endpoint != "" {
-		s3Url = "https://" + dynamicS3Endpoint
-	} else if strings.HasPrefix(region, s3util.ChinaRegionPrefix) {
-		s3Url = updateconstants.ChinaS3URL
-	} else {
-		s3Url = updateconstants.CommonS3URL
+	s3Endpoint := identity.GetServiceEndpoint("s3")
+	if s3Endpoint == "" {
+		s3Endpoint = endpoint.GetDefaultServiceEndpoint("s3", region)
 	}
+	s3Url := "https://" + s3Endpoint
 
 	return strings.Replace(s3Url+updateconstants.BucketPath, updateconstants.RegionHolder, region, -1)
 }
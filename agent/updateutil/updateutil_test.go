@@ -588,3 +588,26 @@ func (p *testProcess) Processes() ([]executor.OsProcess, error) {
 	allProcess = append(allProcess, process)
 	return allProcess, nil
 }
+
+func TestResolveAgentReleaseBucketURL_DefaultEndpointPerPartition(t *testing.T) {
+	partitionBucketURLs := map[string]string{
+		"us-east-1":      "https://s3.us-east-1.amazonaws.com/amazon-ssm-us-east-1/",
+		"cn-north-1":     "https://s3.cn-north-1.amazonaws.com.cn/amazon-ssm-cn-north-1/",
+		"us-gov-west-1":  "https://s3.us-gov-west-1.amazonaws.com/amazon-ssm-us-gov-west-1/",
+		"us-iso-east-1":  "https://s3.us-iso-east-1.c2s.ic.gov/amazon-ssm-us-iso-east-1/",
+		"us-isob-east-1": "https://s3.us-isob-east-1.sc2s.sgov.gov/amazon-ssm-us-isob-east-1/",
+	}
+	for region, bucketURL := range partitionBucketURLs {
+		agentIdentity := &identityMocks.IAgentIdentity{}
+		agentIdentity.On("GetServiceEndpoint", "s3").Return("")
+
+		assert.Equal(t, bucketURL, ResolveAgentReleaseBucketURL(region, agentIdentity))
+	}
+}
+
+func TestResolveAgentReleaseBucketURL_IdentityEndpoint(t *testing.T) {
+	agentIdentity := &identityMocks.IAgentIdentity{}
+	agentIdentity.On("GetServiceEndpoint", "s3").Return("s3.vpce.example.com")
+
+	assert.Equal(t, "https://s3.vpce.example.com/amazon-ssm-us-east-1/", ResolveAgentReleaseBucketURL("us-east-1", agentIdentity))
+}
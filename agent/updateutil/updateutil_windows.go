@@ -34,7 +34,7 @@ func prepareProcess(command *exec.Cmd) {
 }
 
 func isAgentServiceRunning(log log.T) (bool, error) {
-	serviceName := "AmazonSSMAgent"
+	serviceName := appconfig.InstanceServiceName(appconfig.ServiceName)
 	expectedState := svc.Running
 
 	manager, err := mgr.Connect()
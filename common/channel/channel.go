@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/featureflag"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/common/channel/utils"
 	"github.com/aws/amazon-ssm-agent/common/identity"
@@ -55,7 +56,8 @@ func GetChannelCreator(log log.T, appConfig appconfig.SsmagentConfig, identity i
 // canUseNamedPipe checks whether named pipe can be used for IPC or not
 func canUseNamedPipe(log log.T, appConfig appconfig.SsmagentConfig, identity identity.IAgentIdentity) (useNamedPipe bool) {
 	// named pipes '.Listen' halts randomly on windows 2012, disabling named pipes on windows and using file channel instead
-	if runtime.GOOS == "windows" {
+	// unless the NewIPC feature flag opts in to them
+	if runtime.GOOS == "windows" && !featureflag.IsEnabled(appConfig, featureflag.NewIPC) {
 		log.Info("Not using named pipe on windows")
 		return false
 	}
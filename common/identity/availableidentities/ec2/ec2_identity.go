@@ -298,6 +298,7 @@ func (i *Identity) initEc2RoleProvider(endpointHelper endpoint.IEndpointHelper,
 		Log:                    i.Log.WithContext(ec2rolecreds.ProviderName),
 		Config:                 i.Config,
 		InstanceInfo:           instanceInfo,
+		IMDSClient:             i.Client,
 		SsmEndpoint:            endpointHelper.GetServiceEndpoint("ssm", instanceInfo.Region),
 		ShareFileLocation:      appconfig.DefaultEC2SharedCredentialsFilePath,
 		CredentialProfile:      "default",
@@ -23,3 +23,14 @@ type IRemoteProvider interface {
 	ShareFile() string
 	SharesCredentials() bool
 }
+
+// IRoleChangeDetector is implemented by the credential providers able to detect that the role they assume changed
+type IRoleChangeDetector interface {
+	RoleChanged() (bool, error)
+}
+
+// ICredentialSourceReporter is implemented by the credential providers choosing between several credential sources
+type ICredentialSourceReporter interface {
+	CredentialSource() string
+	CredentialSourceReason() string
+}
@@ -15,6 +15,7 @@
 package ec2roleprovider
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"runtime"
@@ -27,6 +28,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/version"
+	"github.com/aws/amazon-ssm-agent/common/identity/credentialproviders/iirprovider"
 	"github.com/aws/amazon-ssm-agent/common/identity/credentialproviders/ssmec2roleprovider"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -41,13 +43,18 @@ type EC2RoleProvider struct {
 	Log                         log.T
 	Config                      *appconfig.SsmagentConfig
 	InstanceInfo                *ssmec2roleprovider.InstanceInfo
+	IMDSClient                  iirprovider.IEC2MdsSdkClient
 	credentialSource            string
+	credentialSourceReason      string
 	SsmEndpoint                 string
 	ShareFileLocation           string
 	CredentialProfile           string
 	ShouldShareCredentials      bool
 	expirationUpdateLock        sync.Mutex
 	currentCredentialExpiration time.Time
+	roleFingerprintLock         sync.Mutex
+	lastRoleFingerprint         string
+	roleFingerprintKnown        bool
 }
 
 // GetInnerProvider gets the role provider that is currently being used for credentials
@@ -63,27 +70,41 @@ func (p *EC2RoleProvider) GetInnerProvider() IInnerProvider {
 // returns ssm provided credentials otherwise. If neither can be retrieved then empty credentials are returned
 func (p *EC2RoleProvider) Retrieve() (credentials.Value, error) {
 	p.Log.Debug("Attempting to retrieve instance profile role")
-	if iprCredentials, err := p.iprCredentials(p.SsmEndpoint); err != nil {
-		p.Log.Debugf("Failed to connect to Systems Manager with instance profile role credentials. Err: %v", err)
-	} else {
+	iprCredentials, iprErr := p.iprCredentials(p.SsmEndpoint)
+	if iprErr == nil {
 		p.Log.Info("Successfully connected with instance profile role credentials")
 		p.credentialSource = CredentialSourceEC2
+		p.credentialSourceReason = "instance profile role credentials can connect to Systems Manager"
 		return iprCredentials.Get()
 	}
+	p.Log.Debugf("Failed to connect to Systems Manager with instance profile role credentials. Err: %v", iprErr)
+	iprReason := iprUnavailableReason(iprErr)
 
 	p.Log.Debug("Attempting to retrieve role from Systems Manager")
-	if ssmCredentials, err := p.ssmEc2Credentials(p.SsmEndpoint); err != nil {
-		p.Log.Debugf("Failed to connect to Systems Manager with SSM role credentials. %v", err)
-		p.credentialSource = CredentialSourceEC2
-	} else {
-		p.Log.Info("Successfully connected with Systems Manager role credentials")
+	ssmCredentials, ssmErr := p.ssmEc2Credentials(p.SsmEndpoint)
+	if ssmErr == nil {
+		p.Log.Infof("Successfully connected with Systems Manager role credentials, %s", iprReason)
 		p.credentialSource = CredentialSourceSSM
+		p.credentialSourceReason = fmt.Sprintf("using Default Host Management Configuration credentials, %s", iprReason)
 		return ssmCredentials.Get()
 	}
+	p.Log.Debugf("Failed to connect to Systems Manager with SSM role credentials. %v", ssmErr)
 
+	p.credentialSource = CredentialSourceEC2
+	p.credentialSourceReason = fmt.Sprintf("no valid credentials, %s and Default Host Management Configuration credentials are unavailable: %v", iprReason, ssmErr)
+	p.Log.Warnf("No valid credentials could be retrieved: %s", p.credentialSourceReason)
 	return iprEmptyCredential, fmt.Errorf("no valid credentials could be retrieved for ec2 identity")
 }
 
+// iprUnavailableReason describes why the instance profile role credentials cannot be used
+func iprUnavailableReason(err error) string {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == iprNoRoleErrCode {
+		return "no instance profile role is attached to the instance"
+	}
+	return fmt.Sprintf("instance profile role credentials cannot connect to Systems Manager: %v", err)
+}
+
 // iprCredentials retrieves instance profile role credentials and returns an error if the returned credentials cannot
 // connect to Systems Manager
 func (p *EC2RoleProvider) iprCredentials(ssmEndpoint string) (*credentials.Credentials, error) {
@@ -197,3 +218,13 @@ func (p *EC2RoleProvider) ExpiresAt() time.Time {
 	}
 	return p.currentCredentialExpiration
 }
+
+// CredentialSource returns the source of the last retrieved credentials
+func (p *EC2RoleProvider) CredentialSource() string {
+	return p.credentialSource
+}
+
+// CredentialSourceReason returns why the source of the last retrieved credentials was selected
+func (p *EC2RoleProvider) CredentialSourceReason() string {
+	return p.credentialSourceReason
+}
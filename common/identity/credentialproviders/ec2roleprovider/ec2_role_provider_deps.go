@@ -27,6 +27,9 @@ const (
 	CredentialSourceSSM              = "Systems Manager"
 	CredentialSourceEC2              = "EC2"
 	maxCredentialExpiryJitterSeconds = 300
+
+	// iprNoRoleErrCode is the error code returned by the instance profile role provider when no role is attached
+	iprNoRoleErrCode = "EC2RoleRequestError"
 )
 
 var (
@@ -23,6 +23,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	"github.com/aws/amazon-ssm-agent/common/identity/credentialproviders/ec2roleprovider/stubs"
+	imdsmocks "github.com/aws/amazon-ssm-agent/common/identity/credentialproviders/iirprovider/mocks"
 	"github.com/aws/amazon-ssm-agent/common/identity/credentialproviders/ssmclient"
 	"github.com/aws/amazon-ssm-agent/common/identity/credentialproviders/ssmclient/mocks"
 	"github.com/aws/amazon-ssm-agent/common/identity/credentialproviders/ssmec2roleprovider"
@@ -197,7 +198,28 @@ func TestEC2RoleProvider_Retrieve_ReturnsSSMCredentials(t *testing.T) {
 	//Assert
 	assert.NoError(t, err)
 	assert.Equal(t, ssmProvider.ProviderName, creds.ProviderName)
-	assert.Equal(t, CredentialSourceSSM, ec2RoleProvider.credentialSource)
+	assert.Equal(t, CredentialSourceSSM, ec2RoleProvider.CredentialSource())
+	assert.Contains(t, ec2RoleProvider.CredentialSourceReason(), "instance profile role credentials cannot connect to Systems Manager")
+}
+
+func TestEC2RoleProvider_Retrieve_NoInstanceProfile_ReturnsSSMCredentials(t *testing.T) {
+	// Arrange
+	noRoleErr := awserr.New(iprNoRoleErrCode, "no EC2 instance role found", nil)
+	ssmClient, ec2RoleProvider := arrangeUpdateInstanceInformation(noRoleErr)
+	ssmClient.On("UpdateInstanceInformation", mock.Anything).Return(&ssm.UpdateInstanceInformationOutput{}, nil)
+	ec2RoleProvider.InnerProviders = &EC2InnerProviders{
+		IPRProvider:    &stubs.InnerProvider{ProviderName: IPRProviderName},
+		SsmEc2Provider: &stubs.InnerProvider{ProviderName: SsmEc2ProviderName},
+	}
+
+	// Act
+	creds, err := ec2RoleProvider.Retrieve()
+
+	//Assert
+	assert.NoError(t, err)
+	assert.Equal(t, SsmEc2ProviderName, creds.ProviderName)
+	assert.Equal(t, CredentialSourceSSM, ec2RoleProvider.CredentialSource())
+	assert.Equal(t, "using Default Host Management Configuration credentials, no instance profile role is attached to the instance", ec2RoleProvider.CredentialSourceReason())
 }
 
 func TestEC2RoleProvider_Retrieve_ReturnsEmptyCredentials(t *testing.T) {
@@ -222,6 +244,7 @@ func TestEC2RoleProvider_Retrieve_ReturnsEmptyCredentials(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, iprEmptyCredential, creds)
 	assert.Equal(t, CredentialSourceEC2, ec2RoleProvider.credentialSource)
+	assert.Contains(t, ec2RoleProvider.CredentialSourceReason(), "Default Host Management Configuration credentials are unavailable")
 }
 
 func TestEC2RoleProvider_GetInnerProvider_ReturnsIPRProvider_WhenCredentialSourceEmpty(t *testing.T) {
@@ -270,3 +293,76 @@ func TestEC2RoleProvider_GetInnerProvider_ReturnsSsmEc2Provider_WhenCredentialSo
 
 	assert.Equal(t, ssmProvider, ec2RoleProvider.GetInnerProvider())
 }
+
+func arrangeRoleChange(t *testing.T, roleNames ...string) *EC2RoleProvider {
+	imdsClient := &imdsmocks.IEC2MdsSdkClient{}
+	for _, roleName := range roleNames {
+		info := fmt.Sprintf(`{"Code":"Success","InstanceProfileArn":"arn:aws:iam::123456789012:instance-profile/%s","InstanceProfileId":"AIPA%s"}`, roleName, roleName)
+		imdsClient.On("GetMetadata", iamInfoPath).Return(info, nil).Once()
+		imdsClient.On("GetMetadata", iamSecurityCredsPath).Return(roleName, nil).Once()
+	}
+	t.Cleanup(func() { imdsClient.AssertExpectations(t) })
+	return &EC2RoleProvider{
+		Log:        logmocks.NewMockLog(),
+		IMDSClient: imdsClient,
+	}
+}
+
+func TestEC2RoleProvider_RoleChanged_SameRole_False(t *testing.T) {
+	ec2RoleProvider := arrangeRoleChange(t, "SomeRole", "SomeRole")
+
+	for i := 0; i < 2; i++ {
+		changed, err := ec2RoleProvider.RoleChanged()
+		assert.NoError(t, err)
+		assert.False(t, changed)
+	}
+}
+
+func TestEC2RoleProvider_RoleChanged_NewRole_TrueAndCredentialsExpired(t *testing.T) {
+	ec2RoleProvider := arrangeRoleChange(t, "SomeRole", "OtherRole")
+	ec2RoleProvider.credentialSource = CredentialSourceEC2
+	ec2RoleProvider.currentCredentialExpiration = time.Now().Add(time.Hour)
+
+	changed, err := ec2RoleProvider.RoleChanged()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.False(t, ec2RoleProvider.IsExpired())
+
+	changed, err = ec2RoleProvider.RoleChanged()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, ec2RoleProvider.IsExpired())
+}
+
+func TestEC2RoleProvider_RoleChanged_RoleDetached_True(t *testing.T) {
+	ec2RoleProvider := arrangeRoleChange(t, "SomeRole")
+	imdsClient := ec2RoleProvider.IMDSClient.(*imdsmocks.IEC2MdsSdkClient)
+	notFound := awserr.NewRequestFailure(awserr.New("EC2MetadataError", "not found", nil), 404, "")
+	imdsClient.On("GetMetadata", iamInfoPath).Return("", notFound).Once()
+
+	changed, _ := ec2RoleProvider.RoleChanged()
+	assert.False(t, changed)
+
+	changed, err := ec2RoleProvider.RoleChanged()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+}
+
+func TestEC2RoleProvider_RoleChanged_MetadataError_KeepsLastRole(t *testing.T) {
+	ec2RoleProvider := arrangeRoleChange(t, "SomeRole")
+	imdsClient := ec2RoleProvider.IMDSClient.(*imdsmocks.IEC2MdsSdkClient)
+	imdsClient.On("GetMetadata", iamInfoPath).Return("", fmt.Errorf("SomeError")).Once()
+	imdsClient.On("GetMetadata", iamInfoPath).Return(`{"Code":"Success","InstanceProfileArn":"arn:aws:iam::123456789012:instance-profile/SomeRole","InstanceProfileId":"AIPASomeRole"}`, nil).Once()
+	imdsClient.On("GetMetadata", iamSecurityCredsPath).Return("SomeRole", nil).Once()
+
+	changed, _ := ec2RoleProvider.RoleChanged()
+	assert.False(t, changed)
+
+	changed, err := ec2RoleProvider.RoleChanged()
+	assert.Error(t, err)
+	assert.False(t, changed)
+
+	changed, err = ec2RoleProvider.RoleChanged()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+}
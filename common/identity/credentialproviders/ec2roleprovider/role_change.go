@@ -0,0 +1,95 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License").
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ec2roleprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+const (
+	iamInfoPath          = "iam/info"
+	iamSecurityCredsPath = "iam/security-credentials/"
+)
+
+// iamInfo is the part of the instance metadata iam info document identifying the attached instance profile
+type iamInfo struct {
+	InstanceProfileArn string
+	InstanceProfileId  string
+}
+
+// RoleChanged queries the instance metadata service for the attached instance profile and role and returns true
+// when they differ from the ones seen by the previous call. The first call records the attached role and returns false.
+// When the role changed, the EC2 credentials are expired so the next Retrieve resolves the credential source again.
+func (p *EC2RoleProvider) RoleChanged() (bool, error) {
+	if p.IMDSClient == nil {
+		return false, nil
+	}
+
+	fingerprint, err := p.roleFingerprint()
+	if err != nil {
+		return false, err
+	}
+
+	p.roleFingerprintLock.Lock()
+	defer p.roleFingerprintLock.Unlock()
+	previous, known := p.lastRoleFingerprint, p.roleFingerprintKnown
+	p.lastRoleFingerprint, p.roleFingerprintKnown = fingerprint, true
+	if !known || previous == fingerprint {
+		return false, nil
+	}
+
+	p.Log.Infof("Instance profile role changed from '%s' to '%s'", previous, fingerprint)
+	p.expirationUpdateLock.Lock()
+	p.currentCredentialExpiration = time.Time{}
+	p.expirationUpdateLock.Unlock()
+	return true, nil
+}
+
+// roleFingerprint returns the instance profile arn and id and the role name attached to the instance,
+// an empty fingerprint is returned when no instance profile is attached
+func (p *EC2RoleProvider) roleFingerprint() (string, error) {
+	infoResponse, err := p.IMDSClient.GetMetadata(iamInfoPath)
+	if isNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to query instance profile: %w", err)
+	}
+
+	var info iamInfo
+	if err = json.Unmarshal([]byte(infoResponse), &info); err != nil {
+		return "", fmt.Errorf("failed to parse instance profile: %w", err)
+	}
+
+	roleName, err := p.IMDSClient.GetMetadata(iamSecurityCredsPath)
+	if isNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to query instance profile role: %w", err)
+	}
+
+	return strings.Join([]string{info.InstanceProfileArn, info.InstanceProfileId, strings.TrimSpace(roleName)}, "/"), nil
+}
+
+// isNotFound returns true if the instance metadata service returned not found, which it does when no role is attached
+func isNotFound(err error) bool {
+	requestFailure, ok := err.(awserr.RequestFailure)
+	return ok && requestFailure.StatusCode() == http.StatusNotFound
+}
@@ -0,0 +1,31 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// IRoleChangeDetector is an autogenerated mock type for the IRoleChangeDetector type
+type IRoleChangeDetector struct {
+	mock.Mock
+}
+
+// RoleChanged provides a mock function with given fields:
+func (_m *IRoleChangeDetector) RoleChanged() (bool, error) {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -124,11 +124,18 @@ func (m *onpremCredentialsProvider) Retrieve() (credentials.Value, error) {
 		return emptyCredential, err
 	}
 
-	shouldRotate, err := m.registrationInfo.ShouldRotatePrivateKey(m.log, m.executableToRotateKey, m.config.Profile.KeyAutoRotateDays, *roleCreds.UpdateKeyPair, "", registration.RegVaultKey)
+	serviceSaysRotate := *roleCreds.UpdateKeyPair
+	shouldRotate, err := m.registrationInfo.ShouldRotatePrivateKey(m.log, m.executableToRotateKey, m.config.Profile.KeyAutoRotateDays, serviceSaysRotate, "", registration.RegVaultKey)
 	if err != nil {
 		m.log.Warnf("Failed to check if private key should be rotated: %v", err)
+	} else if shouldRotate && !serviceSaysRotate && m.isRotationRetryPending() {
+		m.log.Debugf("Private key rotation failed less than %v minutes ago, not retrying yet", m.config.Profile.KeyRotationRetryMinutes)
 	} else if shouldRotate {
-		rotateKeyErr := m.rotatePrivateKey(fingerprint, exponentialBackoff)
+		reason := registration.KeyRotationReasonKeyAge
+		if serviceSaysRotate {
+			reason = registration.KeyRotationReasonServiceRequest
+		}
+		rotateKeyErr := m.rotatePrivateKey(fingerprint, reason, exponentialBackoff)
 		if rotateKeyErr != nil {
 			m.log.Error("Failed to rotate private key with error: ", rotateKeyErr)
 		}
@@ -156,9 +163,43 @@ func (m *onpremCredentialsProvider) Retrieve() (credentials.Value, error) {
 	}, nil
 }
 
-// rotatePrivateKey attempts to rotate the instance private key
-func (m *onpremCredentialsProvider) rotatePrivateKey(fingerprint string, exponentialBackoff *backoff.ExponentialBackOff) error {
+// isRotationRetryPending returns true if the last private key rotation failed less than KeyRotationRetryMinutes ago
+func (m *onpremCredentialsProvider) isRotationRetryPending() bool {
+	history := m.registrationInfo.KeyRotationHistory(m.log, "", registration.RegVaultKey)
+	if len(history) == 0 {
+		return false
+	}
+	lastRotation := history[len(history)-1]
+	retryDelay := time.Duration(m.config.Profile.KeyRotationRetryMinutes) * time.Minute
+	return lastRotation.Status == registration.KeyRotationFailed && time.Since(lastRotation.Time) < retryDelay
+}
+
+// recordRotation adds the outcome of a private key rotation to the rotation history
+func (m *onpremCredentialsProvider) recordRotation(reason, publicKey, keyType string, rotationErr error) {
+	record := registration.KeyRotationRecord{
+		Time:                 time.Now().UTC(),
+		Reason:               reason,
+		Status:               registration.KeyRotationSucceeded,
+		KeyType:              keyType,
+		PublicKeyFingerprint: registration.PublicKeyFingerprint(publicKey),
+	}
+	if rotationErr != nil {
+		record.Status = registration.KeyRotationFailed
+		record.Error = rotationErr.Error()
+	}
+	if err := m.registrationInfo.AppendKeyRotationRecord(m.log, record, "", registration.RegVaultKey); err != nil {
+		m.log.Warnf("Failed to save private key rotation history: %v", err)
+	}
+}
+
+// rotatePrivateKey attempts to rotate the instance private key to a key of the configured type,
+// the new key is registered and validated with the service before the old key is discarded
+func (m *onpremCredentialsProvider) rotatePrivateKey(fingerprint, reason string, exponentialBackoff *backoff.ExponentialBackOff) (err error) {
 	m.log.Infof("Attempting to rotate private key")
+	var newPublicKey, newKeyType string
+	defer func() {
+		m.recordRotation(reason, newPublicKey, newKeyType, err)
+	}()
 
 	oldPrivateKey := m.registrationInfo.PrivateKey(m.log, "", registration.RegVaultKey)
 	oldKeyType := m.registrationInfo.PrivateKeyType(m.log, "", registration.RegVaultKey)
@@ -168,7 +209,11 @@ func (m *onpremCredentialsProvider) rotatePrivateKey(fingerprint string, exponen
 		return err
 	}
 
-	newPublicKey, newPrivateKey, newKeyType, err := m.registrationInfo.GenerateKeyPair()
+	keyType := m.config.Profile.KeyType
+	if keyType == "" {
+		keyType = appconfig.ProfileKeyTypeRsa
+	}
+	newPublicKey, newPrivateKey, newKeyType, err := m.registrationInfo.GenerateKeyPairOfType(keyType, m.config.Profile.KeyStorage)
 
 	if err != nil {
 		m.log.Warnf("Failed to generate new key pair: %v", err)
@@ -197,6 +242,7 @@ func (m *onpremCredentialsProvider) rotatePrivateKey(fingerprint string, exponen
 		}, exponentialBackoff)
 
 		if err == nil {
+			m.registrationInfo.ReleasePrivateKey(m.log, newPrivateKey)
 			return fmt.Errorf("Failed to update remote public key, old key still works")
 		}
 
@@ -218,6 +264,25 @@ func (m *onpremCredentialsProvider) rotatePrivateKey(fingerprint string, exponen
 		}
 
 		m.log.Infof("Successfully verified new key is upstream, updating local key")
+	} else if err = m.validateNewPrivateKey(fingerprint, newPrivateKey, exponentialBackoff); err != nil {
+		m.log.Warnf("Failed to validate new key with the service, restoring old public key: %v", err)
+
+		validationErr := err
+		err = backoffRetry(func() error {
+			_, err = m.client.UpdateManagedInstancePublicKey(oldPublicKey, oldKeyType)
+			if shouldRetryAwsRequest(err) {
+				return err
+			}
+			return nil
+		}, exponentialBackoff)
+		m.initializeClient(oldPrivateKey)
+
+		if err != nil {
+			m.log.Errorf("Failed to restore old public key, instance most likely needs to be re-registered: %v", err)
+			return fmt.Errorf("failed to restore old public key after new key validation failed: %v", err)
+		}
+		m.registrationInfo.ReleasePrivateKey(m.log, newPrivateKey)
+		return fmt.Errorf("new key could not be validated, old key restored: %v", validationErr)
 	}
 
 	// New key has been updated remotely, update client to use new private key
@@ -248,6 +313,7 @@ func (m *onpremCredentialsProvider) rotatePrivateKey(fingerprint string, exponen
 
 		m.log.Warn("Successfully rolled back remote key, and recovered registration")
 		m.initializeClient(oldPrivateKey)
+		m.registrationInfo.ReleasePrivateKey(m.log, newPrivateKey)
 		return fmt.Errorf("failed to save new private key to disk")
 	}
 
@@ -255,6 +321,21 @@ func (m *onpremCredentialsProvider) rotatePrivateKey(fingerprint string, exponen
 	return nil
 }
 
+// validateNewPrivateKey requests a role token signed with the new private key to make sure the service accepts it,
+// the client is left using the new private key
+func (m *onpremCredentialsProvider) validateNewPrivateKey(fingerprint, newPrivateKey string, exponentialBackoff *backoff.ExponentialBackOff) (err error) {
+	m.initializeClient(newPrivateKey)
+
+	_ = backoffRetry(func() error {
+		_, err = m.client.RequestManagedInstanceRoleToken(fingerprint)
+		if shouldRetryAwsRequest(err) {
+			return err
+		}
+		return nil
+	}, exponentialBackoff)
+	return err
+}
+
 func (m *onpremCredentialsProvider) initializeClient(newPrivateKey string) {
 	m.client = createNewClient(m, newPrivateKey)
 }
@@ -23,6 +23,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/managedInstances/registration"
 	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	"github.com/aws/amazon-ssm-agent/agent/ssm/authtokenrequest"
 
@@ -141,7 +142,7 @@ func TestRotatePrivateKey_FailGenerateOldPublicKey(t *testing.T) {
 		},
 	}
 
-	err := testProvider.rotatePrivateKey("test123", nil)
+	err := testProvider.rotatePrivateKey("test123", registration.KeyRotationReasonKeyAge, nil)
 	assert.NotNil(t, err)
 }
 
@@ -155,7 +156,7 @@ func TestRotatePrivateKey_FailGenerateKeyPair(t *testing.T) {
 		},
 	}
 
-	err := testProvider.rotatePrivateKey("test123", nil)
+	err := testProvider.rotatePrivateKey("test123", registration.KeyRotationReasonKeyAge, nil)
 	assert.NotNil(t, err)
 }
 
@@ -173,12 +174,32 @@ func TestRotatePrivateKey_FailUpdateKey_SuccessVerifyOldKey(t *testing.T) {
 		registrationInfo: &registrationStub{},
 	}
 
-	err := testProvider.rotatePrivateKey("test123", nil)
+	err := testProvider.rotatePrivateKey("test123", registration.KeyRotationReasonKeyAge, nil)
 	assert.NotNil(t, err)
 	assert.Equal(t, 1, rsaClient.updateCalled)
 	assert.Equal(t, 1, rsaClient.roleCalled)
 }
 
+func TestRotatePrivateKey_FailUpdateKey_SuccessVerifyOldKey_ReleasesNewKey(t *testing.T) {
+	rsaClient := &RsaSignedServiceStub{
+		keyResponse:  ssm.UpdateManagedInstancePublicKeyOutput{},
+		roleResponse: ssm.RequestManagedInstanceRoleTokenOutput{},
+		errList:      []error{fmt.Errorf("SomeError")},
+	}
+	registrationInfo := &registrationStub{privateKey: "hardware:tpm:0x81010101"}
+
+	testProvider := onpremCredentialsProvider{
+		client:           rsaClient,
+		config:           &appconfig.SsmagentConfig{Profile: appconfig.CredentialProfile{KeyStorage: appconfig.ProfileKeyStorageHardware}},
+		log:              logmocks.NewMockLog(),
+		registrationInfo: registrationInfo,
+	}
+
+	err := testProvider.rotatePrivateKey("test123", registration.KeyRotationReasonKeyAge, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"hardware:tpm:0x81010101"}, registrationInfo.released)
+}
+
 func TestRotatePrivateKey_FailUpdateKey_NewKeyWorks_SuccessSaveNewKey(t *testing.T) {
 	rsaClient := &RsaSignedServiceStub{
 		keyResponse:  ssm.UpdateManagedInstancePublicKeyOutput{},
@@ -193,7 +214,7 @@ func TestRotatePrivateKey_FailUpdateKey_NewKeyWorks_SuccessSaveNewKey(t *testing
 		registrationInfo: &registrationStub{},
 	}
 
-	err := testProvider.rotatePrivateKey("test123", nil)
+	err := testProvider.rotatePrivateKey("test123", registration.KeyRotationReasonKeyAge, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, rsaClient.updateCalled)
 	assert.Equal(t, 2, rsaClient.roleCalled)
@@ -203,7 +224,7 @@ func TestRotatePrivateKey_SuccessUpdateKey_FailSaveNewKey_FailUpdateToOldKey(t *
 	rsaClient := &RsaSignedServiceStub{
 		keyResponse:  ssm.UpdateManagedInstancePublicKeyOutput{},
 		roleResponse: ssm.RequestManagedInstanceRoleTokenOutput{},
-		errList:      []error{nil, fmt.Errorf("FailUpdateToOldKey")},
+		errList:      []error{nil, nil, fmt.Errorf("FailUpdateToOldKey")},
 	}
 
 	testProvider := onpremCredentialsProvider{
@@ -215,10 +236,10 @@ func TestRotatePrivateKey_SuccessUpdateKey_FailSaveNewKey_FailUpdateToOldKey(t *
 		},
 	}
 
-	err := testProvider.rotatePrivateKey("test123", nil)
+	err := testProvider.rotatePrivateKey("test123", registration.KeyRotationReasonKeyAge, nil)
 	assert.NotNil(t, err)
 	assert.Equal(t, 2, rsaClient.updateCalled)
-	assert.Equal(t, 0, rsaClient.roleCalled)
+	assert.Equal(t, 1, rsaClient.roleCalled)
 }
 
 func TestRotatePrivateKey_SuccessUpdateKey_FailSaveNewKey_SuccessUpdateToOldKey(t *testing.T) {
@@ -226,7 +247,7 @@ func TestRotatePrivateKey_SuccessUpdateKey_FailSaveNewKey_SuccessUpdateToOldKey(
 	rsaClient := &RsaSignedServiceStub{
 		keyResponse:  ssm.UpdateManagedInstancePublicKeyOutput{},
 		roleResponse: ssm.RequestManagedInstanceRoleTokenOutput{},
-		errList:      []error{nil, nil},
+		errList:      []error{nil, nil, nil},
 	}
 
 	testProvider := onpremCredentialsProvider{
@@ -238,10 +259,89 @@ func TestRotatePrivateKey_SuccessUpdateKey_FailSaveNewKey_SuccessUpdateToOldKey(
 		},
 	}
 
-	err := testProvider.rotatePrivateKey("test123", nil)
+	err := testProvider.rotatePrivateKey("test123", registration.KeyRotationReasonKeyAge, nil)
 	assert.NotNil(t, err)
 	assert.Equal(t, 2, rsaClient.updateCalled)
-	assert.Equal(t, 0, rsaClient.roleCalled)
+	assert.Equal(t, 1, rsaClient.roleCalled)
+}
+
+func TestRotatePrivateKey_SuccessUpdateKey_FailValidateNewKey_RestoresOldKey(t *testing.T) {
+	rsaClient := &RsaSignedServiceStub{
+		keyResponse:  ssm.UpdateManagedInstancePublicKeyOutput{},
+		roleResponse: ssm.RequestManagedInstanceRoleTokenOutput{},
+		errList:      []error{nil, fmt.Errorf("ValidateError"), nil},
+	}
+	registrationInfo := &registrationStub{keyType: "Ecdsa"}
+
+	testProvider := onpremCredentialsProvider{
+		client:           rsaClient,
+		config:           &appconfig.SsmagentConfig{Profile: appconfig.CredentialProfile{KeyType: appconfig.ProfileKeyTypeEcdsa}},
+		log:              logmocks.NewMockLog(),
+		registrationInfo: registrationInfo,
+	}
+
+	err := testProvider.rotatePrivateKey("test123", registration.KeyRotationReasonServiceRequest, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 2, rsaClient.updateCalled)
+	assert.Equal(t, 1, rsaClient.roleCalled)
+	assert.Len(t, registrationInfo.history, 1)
+	assert.Equal(t, registration.KeyRotationFailed, registrationInfo.history[0].Status)
+	assert.Equal(t, registration.KeyRotationReasonServiceRequest, registrationInfo.history[0].Reason)
+	assert.Equal(t, "Ecdsa", registrationInfo.history[0].KeyType)
+}
+
+func TestRotatePrivateKey_Success_RecordsRotation(t *testing.T) {
+	rsaClient := &RsaSignedServiceStub{}
+	registrationInfo := &registrationStub{publicKey: "cHVibGljS2V5", keyType: "Rsa"}
+
+	testProvider := onpremCredentialsProvider{
+		client:           rsaClient,
+		config:           &appconfig.SsmagentConfig{},
+		log:              logmocks.NewMockLog(),
+		registrationInfo: registrationInfo,
+	}
+
+	err := testProvider.rotatePrivateKey("test123", registration.KeyRotationReasonKeyAge, nil)
+	assert.NoError(t, err)
+	assert.Len(t, registrationInfo.history, 1)
+	assert.Equal(t, registration.KeyRotationSucceeded, registrationInfo.history[0].Status)
+	assert.Equal(t, registration.PublicKeyFingerprint("cHVibGljS2V5"), registrationInfo.history[0].PublicKeyFingerprint)
+}
+
+func TestRetrieve_DelaysAgeRotationRetryAfterFailure(t *testing.T) {
+	updateKeyPair := false
+	tokenExpirationDate := time.Now().Add(1 * time.Hour)
+	client := &RsaSignedServiceStub{
+		roleResponse: ssm.RequestManagedInstanceRoleTokenOutput{
+			AccessKeyId:         &accessKeyID,
+			SecretAccessKey:     &secretAccessKey,
+			SessionToken:        &sessionToken,
+			UpdateKeyPair:       &updateKeyPair,
+			TokenExpirationDate: &tokenExpirationDate,
+		},
+	}
+	registrationInfo := &registrationStub{
+		shouldRotate: true,
+		history: []registration.KeyRotationRecord{
+			{Time: time.Now().Add(-10 * time.Minute), Status: registration.KeyRotationFailed},
+		},
+	}
+	testProvider := onpremCredentialsProvider{
+		client:           client,
+		config:           &appconfig.SsmagentConfig{Profile: appconfig.CredentialProfile{KeyRotationRetryMinutes: 60}},
+		log:              logmocks.NewMockLog(),
+		registrationInfo: registrationInfo,
+	}
+
+	_, err := testProvider.Retrieve()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, client.updateCalled)
+
+	// the retry delay is over
+	registrationInfo.history[0].Time = time.Now().Add(-2 * time.Hour)
+	_, err = testProvider.Retrieve()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.updateCalled)
 }
 
 // RsaSignedService client stub
@@ -285,6 +385,8 @@ type registrationStub struct {
 	hasCreds         bool
 	shouldRotate     bool
 	errList          []error
+	history          []registration.KeyRotationRecord
+	released         []string
 }
 
 func (r *registrationStub) getErr() error {
@@ -324,10 +426,18 @@ func (r *registrationStub) GenerateKeyPair() (publicKey, privateKey, keyType str
 	return r.publicKey, r.privateKey, r.keyType, r.getErr()
 }
 
+func (r *registrationStub) GenerateKeyPairOfType(keyType, keyStorage string) (publicKey, privateKey, newKeyType string, err error) {
+	return r.publicKey, r.privateKey, r.keyType, r.getErr()
+}
+
 func (r *registrationStub) UpdatePrivateKey(log log.T, privateKey, privateKeyType, manifestFileNamePrefix, vaultKey string) (err error) {
 	return r.getErr()
 }
 
+func (r *registrationStub) ReleasePrivateKey(log log.T, privateKey string) {
+	r.released = append(r.released, privateKey)
+}
+
 func (r *registrationStub) ShouldRotatePrivateKey(log.T, string, int, bool, string, string) (bool, error) {
 	return r.shouldRotate, r.getErr()
 }
@@ -341,3 +451,12 @@ func (r *registrationStub) HasManagedInstancesCredentials(log log.T, manifestFil
 }
 
 func (r *registrationStub) ReloadInstanceInfo(log log.T, manifestFileNamePrefix, vaultKey string) {}
+
+func (r *registrationStub) KeyRotationHistory(log log.T, manifestFileNamePrefix, vaultKey string) []registration.KeyRotationRecord {
+	return r.history
+}
+
+func (r *registrationStub) AppendKeyRotationRecord(log log.T, record registration.KeyRotationRecord, manifestFileNamePrefix, vaultKey string) error {
+	r.history = append(r.history, record)
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package credentialrotation notifies the modules of the agent when the agent credentials rotate so that their
+// long-lived clients do not keep using the previous credentials until their next retry.
+package credentialrotation
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// ReasonCredentialsRefreshed is the reason of a rotation after which the agent identity is unchanged
+	ReasonCredentialsRefreshed = "CredentialsRefreshed"
+	// ReasonIdentityChanged is the reason of a rotation which changed the identity, the shared credentials file or
+	// profile of the agent, e.g. after the instance role changed
+	ReasonIdentityChanged = "IdentityChanged"
+)
+
+// Rotation describes a rotation of the agent credentials
+type Rotation struct {
+	Reason      string
+	RetrievedAt time.Time
+}
+
+// Listener is called when the agent credentials rotate, it must not block
+type Listener func(rotation Rotation)
+
+var subscriptions = struct {
+	sync.Mutex
+	nextID    int
+	listeners map[int]Listener
+}{listeners: make(map[int]Listener)}
+
+// Subscribe registers a listener notified of the rotations of the agent credentials in this process,
+// the returned function unsubscribes the listener
+func Subscribe(listener Listener) (unsubscribe func()) {
+	subscriptions.Lock()
+	defer subscriptions.Unlock()
+	id := subscriptions.nextID
+	subscriptions.nextID++
+	subscriptions.listeners[id] = listener
+	return func() {
+		subscriptions.Lock()
+		defer subscriptions.Unlock()
+		delete(subscriptions.listeners, id)
+	}
+}
+
+// Notify notifies the listeners that the agent credentials rotated
+func Notify(rotation Rotation) {
+	subscriptions.Lock()
+	listeners := make([]Listener, 0, len(subscriptions.listeners))
+	for _, listener := range subscriptions.listeners {
+		listeners = append(listeners, listener)
+	}
+	subscriptions.Unlock()
+
+	for _, listener := range listeners {
+		listener(rotation)
+	}
+}
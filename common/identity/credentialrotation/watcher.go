@@ -0,0 +1,137 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package credentialrotation
+
+import (
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// pollInterval is the interval at which the identity runtime config and the shared credentials file are checked
+const pollInterval = 30 * time.Second
+
+// IWatcher detects the rotations of the credentials the core agent shares with the workers
+type IWatcher interface {
+	Start()
+	Stop()
+}
+
+// watcher detects the rotations from the identity runtime config the core agent saves after each credential refresh
+// and from the modification time of the shared credentials file
+type watcher struct {
+	log                 log.T
+	credentials         *credentials.Credentials
+	runtimeConfigClient runtimeconfig.IIdentityRuntimeConfigClient
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+
+	initialized      bool
+	config           runtimeconfig.IdentityRuntimeConfig
+	shareFileModTime time.Time
+}
+
+// NewWatcher returns a watcher which expires the given identity credentials, if any, and notifies the listeners
+// when the shared credentials rotate
+func NewWatcher(log log.T, credentials *credentials.Credentials) IWatcher {
+	return &watcher{
+		log:                 log.WithContext("[CredentialRotationWatcher]"),
+		credentials:         credentials,
+		runtimeConfigClient: runtimeconfig.NewIdentityRuntimeConfigClient(),
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// Start starts watching the shared credentials
+func (w *watcher) Start() {
+	w.check()
+	go w.run()
+}
+
+// Stop stops watching the shared credentials
+func (w *watcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stopChan) })
+}
+
+func (w *watcher) run() {
+	defer func() {
+		if r := recover(); r != nil {
+			w.log.Errorf("Credential rotation watcher panic: %v", r)
+			w.log.Errorf("Stacktrace:\n%s", debug.Stack())
+		}
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stopChan:
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+// poll expires the identity credentials and notifies the listeners when the shared credentials rotated
+func (w *watcher) poll() {
+	rotation, rotated := w.check()
+	if !rotated {
+		return
+	}
+	w.log.Infof("Agent credentials rotated, reason: %v", rotation.Reason)
+	if w.credentials != nil {
+		w.credentials.Expire()
+	}
+	Notify(rotation)
+}
+
+// check compares the identity runtime config and the shared credentials file with their last known state
+func (w *watcher) check() (rotation Rotation, rotated bool) {
+	config, err := w.runtimeConfigClient.GetConfig()
+	if err != nil {
+		w.log.Debugf("Failed to read the identity runtime config: %v", err)
+		return
+	}
+	shareFileModTime := modTime(config.ShareFile)
+
+	if !w.initialized {
+		w.initialized = true
+	} else if !config.Equal(w.config) {
+		rotation, rotated = Rotation{Reason: ReasonIdentityChanged, RetrievedAt: config.CredentialsRetrievedAt}, true
+	} else if !config.CredentialsRetrievedAt.Equal(w.config.CredentialsRetrievedAt) || !shareFileModTime.Equal(w.shareFileModTime) {
+		rotation, rotated = Rotation{Reason: ReasonCredentialsRefreshed, RetrievedAt: config.CredentialsRetrievedAt}, true
+	}
+	w.config = config
+	w.shareFileModTime = shareFileModTime
+	return
+}
+
+// modTime returns the modification time of the file, the zero time when it cannot be read
+func modTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fileInfo.ModTime()
+}
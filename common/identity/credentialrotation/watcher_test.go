@@ -0,0 +1,120 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package credentialrotation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
+	runtimeMock "github.com/aws/amazon-ssm-agent/common/runtimeconfig/mocks"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestWatcher(runtimeConfigClient runtimeconfig.IIdentityRuntimeConfigClient, creds *credentials.Credentials) *watcher {
+	return &watcher{
+		log:                 log.NewMockLog(),
+		credentials:         creds,
+		runtimeConfigClient: runtimeConfigClient,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	var received []Rotation
+	unsubscribe := Subscribe(func(rotation Rotation) { received = append(received, rotation) })
+
+	Notify(Rotation{Reason: ReasonCredentialsRefreshed})
+	unsubscribe()
+	Notify(Rotation{Reason: ReasonIdentityChanged})
+
+	assert.Equal(t, []Rotation{{Reason: ReasonCredentialsRefreshed}}, received)
+}
+
+func TestCheck(t *testing.T) {
+	shareFile := filepath.Join(t.TempDir(), "credentials")
+	assert.NoError(t, ioutil.WriteFile(shareFile, []byte("[default]"), 0600))
+	retrievedAt := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	config := runtimeconfig.IdentityRuntimeConfig{
+		InstanceId:             "i-1234567890",
+		IdentityType:           "EC2",
+		ShareFile:              shareFile,
+		ShareProfile:           "default",
+		CredentialsRetrievedAt: retrievedAt,
+	}
+	refreshed := config
+	refreshed.CredentialsRetrievedAt = retrievedAt.Add(time.Hour)
+	changed := refreshed
+	changed.ShareProfile = "SSM"
+
+	runtimeConfigClient := &runtimeMock.IIdentityRuntimeConfigClient{}
+	runtimeConfigClient.On("GetConfig").Return(config, nil).Twice()
+	runtimeConfigClient.On("GetConfig").Return(refreshed, nil).Once()
+	runtimeConfigClient.On("GetConfig").Return(runtimeconfig.IdentityRuntimeConfig{}, fmt.Errorf("SomeGetConfigError")).Once()
+	runtimeConfigClient.On("GetConfig").Return(changed, nil).Twice()
+	w := newTestWatcher(runtimeConfigClient, nil)
+
+	// the first check records the initial state
+	_, rotated := w.check()
+	assert.False(t, rotated)
+	_, rotated = w.check()
+	assert.False(t, rotated)
+
+	rotation, rotated := w.check()
+	assert.True(t, rotated)
+	assert.Equal(t, Rotation{Reason: ReasonCredentialsRefreshed, RetrievedAt: refreshed.CredentialsRetrievedAt}, rotation)
+
+	_, rotated = w.check()
+	assert.False(t, rotated)
+
+	rotation, rotated = w.check()
+	assert.True(t, rotated)
+	assert.Equal(t, ReasonIdentityChanged, rotation.Reason)
+
+	// the shared credentials file is rewritten
+	modTime := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(shareFile, modTime, modTime))
+	rotation, rotated = w.check()
+	assert.True(t, rotated)
+	assert.Equal(t, ReasonCredentialsRefreshed, rotation.Reason)
+}
+
+func TestPollExpiresCredentials(t *testing.T) {
+	config := runtimeconfig.IdentityRuntimeConfig{InstanceId: "i-1234567890", CredentialsRetrievedAt: time.Now()}
+	refreshed := config
+	refreshed.CredentialsRetrievedAt = config.CredentialsRetrievedAt.Add(time.Hour)
+	runtimeConfigClient := &runtimeMock.IIdentityRuntimeConfigClient{}
+	runtimeConfigClient.On("GetConfig").Return(config, nil).Once()
+	runtimeConfigClient.On("GetConfig").Return(refreshed, nil).Once()
+
+	creds := credentials.NewStaticCredentials("id", "secret", "")
+	_, err := creds.Get()
+	assert.NoError(t, err)
+	w := newTestWatcher(runtimeConfigClient, creds)
+
+	var received []Rotation
+	unsubscribe := Subscribe(func(rotation Rotation) { received = append(received, rotation) })
+	defer unsubscribe()
+	w.check()
+	w.poll()
+
+	assert.True(t, creds.IsExpired())
+	assert.Equal(t, []Rotation{{Reason: ReasonCredentialsRefreshed, RetrievedAt: refreshed.CredentialsRetrievedAt}}, received)
+}
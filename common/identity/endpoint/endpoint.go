@@ -22,17 +22,25 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
-// Map defining region prefixes and the default service domain for prefix, keys are processed in random order
+// Map defining region prefixes and the default service domain for prefix, keys are processed in random order.
+// The GovCloud (us-gov-) regions use the default service domain.
 var regionPrefixServiceDomain = map[string]string{
 	"cn-":      "amazonaws.com.cn",
 	"us-iso-":  "c2s.ic.gov",
 	"us-isob-": "sc2s.sgov.gov",
+	"us-isof-": "csp.hci.ic.gov",
+	"eu-isoe-": "cloud.adc-e.uk",
 }
 
 // default service domain if prefix does not exist in awsFallbackServiceDomain map
 const (
 	defaultServiceDomain = "amazonaws.com"
 
+	// service domain of the China partition and the dualstack service domains of the partitions supporting them
+	chinaServiceDomain            = "amazonaws.com.cn"
+	defaultDualStackServiceDomain = "api.aws"
+	chinaDualStackServiceDomain   = "api.amazonwebservices.com.cn"
+
 	regionMaxLength = 100
 )
 
@@ -54,6 +62,38 @@ func GetServiceDomainByPrefix(region string) string {
 	return defaultServiceDomain
 }
 
+// GetDefaultServiceEndpoint returns the endpoint of a service in a region using the service domain of the partition
+// of the region, it is used when the endpoint cannot be resolved from the agent configuration or identity
+func GetDefaultServiceEndpoint(service, region string) string {
+	return service + "." + region + "." + GetServiceDomainByPrefix(region)
+}
+
+// GetDualStackServiceEndpoint returns the dualstack endpoint of a service in a region, reachable over IPv4 and IPv6.
+// S3 uses its s3.dualstack endpoints, an empty endpoint is returned when the partition of the region has no
+// dualstack endpoints.
+func GetDualStackServiceEndpoint(service, region string) string {
+	serviceDomain := GetServiceDomainByPrefix(region)
+	var dualStackServiceDomain string
+	switch serviceDomain {
+	case defaultServiceDomain:
+		dualStackServiceDomain = defaultDualStackServiceDomain
+	case chinaServiceDomain:
+		dualStackServiceDomain = chinaDualStackServiceDomain
+	default:
+		return ""
+	}
+
+	if service == "s3" {
+		return "s3.dualstack." + region + "." + serviceDomain
+	}
+	return service + "." + region + "." + dualStackServiceDomain
+}
+
+// useDualStackEndpoints returns true if the IP mode of the agent prefers or forces IPv6
+func useDualStackEndpoints(config appconfig.SsmagentConfig) bool {
+	return config.Network.IPMode == appconfig.NetworkIPModePreferIPv6 || config.Network.IPMode == appconfig.NetworkIPModeIPv6Only
+}
+
 type endpointImpl struct {
 	log    log.T
 	config appconfig.SsmagentConfig
@@ -89,7 +129,6 @@ func (e *endpointImpl) setEndpointCache(service, region, endpoint string) {
 
 func (e *endpointImpl) GetServiceEndpoint(service, region string) string {
 	e.log.Debugf("Determining endpoint for service %s in region %s", service, region)
-	var serviceDomain string
 	if region == "" {
 		// If region is not defined, we are unable to determine endpoint for the service
 		e.log.Errorf("Cannot get endpoint for service %s due to unspecified region.", service)
@@ -105,14 +144,15 @@ func (e *endpointImpl) GetServiceEndpoint(service, region string) string {
 		return endpoint
 	}
 
+	// Build the full endpoint for the service in the region
+	var endpoint string
 	if e.config.Agent.ServiceDomain != "" {
-		serviceDomain = e.config.Agent.ServiceDomain
+		endpoint = service + "." + region + "." + e.config.Agent.ServiceDomain
+	} else if dualStackEndpoint := GetDualStackServiceEndpoint(service, region); dualStackEndpoint != "" && useDualStackEndpoints(e.config) {
+		endpoint = dualStackEndpoint
 	} else {
-		serviceDomain = GetServiceDomainByPrefix(region)
+		endpoint = GetDefaultServiceEndpoint(service, region)
 	}
-
-	// Build the full endpoint for the service in the region
-	endpoint := service + "." + region + "." + serviceDomain
 	e.setEndpointCache(service, region, endpoint)
 	return endpoint
 }
@@ -60,6 +60,8 @@ var regionServiceDomainMap = map[string]string{
 	"cn-future-1":      "amazonaws.com.cn",
 	"us-isob-future-1": "sc2s.sgov.gov",
 	"us-iso-future-1":  "c2s.ic.gov",
+	"us-isof-south-1":  "csp.hci.ic.gov",
+	"eu-isoe-west-1":   "cloud.adc-e.uk",
 }
 
 var testServices = []string{
@@ -243,3 +245,40 @@ func TestIsRegionValid(t *testing.T) {
 	maxLengthRegion := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa-aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa-1"
 	assert.True(t, e.isRegionValid(maxLengthRegion))
 }
+
+func TestGetDefaultServiceEndpoint(t *testing.T) {
+	for region, serviceDomain := range regionServiceDomainMap {
+		for _, service := range testServices {
+			assert.Equal(t, fmt.Sprintf("%s.%s.%s", service, region, serviceDomain), GetDefaultServiceEndpoint(service, region))
+		}
+	}
+}
+
+func TestGetDualStackServiceEndpoint(t *testing.T) {
+	assert.Equal(t, "ssm.us-east-1.api.aws", GetDualStackServiceEndpoint("ssm", "us-east-1"))
+	assert.Equal(t, "ssmmessages.us-gov-west-1.api.aws", GetDualStackServiceEndpoint("ssmmessages", "us-gov-west-1"))
+	assert.Equal(t, "ssm.cn-north-1.api.amazonwebservices.com.cn", GetDualStackServiceEndpoint("ssm", "cn-north-1"))
+	assert.Equal(t, "s3.dualstack.us-east-1.amazonaws.com", GetDualStackServiceEndpoint("s3", "us-east-1"))
+	assert.Equal(t, "s3.dualstack.cn-north-1.amazonaws.com.cn", GetDualStackServiceEndpoint("s3", "cn-north-1"))
+	assert.Equal(t, "", GetDualStackServiceEndpoint("ssm", "us-iso-east-1"))
+}
+
+func TestGetServiceEndpoint_IPv6Modes(t *testing.T) {
+	for _, ipMode := range []string{appconfig.NetworkIPModePreferIPv6, appconfig.NetworkIPModeIPv6Only} {
+		config := appconfig.DefaultConfig()
+		config.Network.IPMode = ipMode
+		e := NewEndpointHelper(logMock, config)
+
+		assert.Equal(t, "ssm.us-east-1.api.aws", e.GetServiceEndpoint("ssm", "us-east-1"))
+		assert.Equal(t, "s3.dualstack.us-east-1.amazonaws.com", e.GetServiceEndpoint("s3", "us-east-1"))
+		// partitions without dualstack endpoints keep the IPv4 endpoints
+		assert.Equal(t, "ssm.us-iso-east-1.c2s.ic.gov", e.GetServiceEndpoint("ssm", "us-iso-east-1"))
+	}
+
+	// the configured service domain takes precedence
+	config := appconfig.DefaultConfig()
+	config.Network.IPMode = appconfig.NetworkIPModeIPv6Only
+	config.Agent.ServiceDomain = "example.com"
+	e := NewEndpointHelper(logMock, config)
+	assert.Equal(t, "ssm.us-east-1.example.com", e.GetServiceEndpoint("ssm", "us-east-1"))
+}
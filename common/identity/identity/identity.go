@@ -77,9 +77,23 @@ func (c *agentIdentityCacher) regionInner() (string, error) {
 		return c.region, nil
 	}
 
-	var err error
-	c.region, err = c.client.Region()
-	return c.region, err
+	region, source, err := c.resolveRegion()
+	if err != nil {
+		return "", err
+	}
+	c.log.Infof("Agent region %s resolved from %s", region, source)
+	c.region, c.regionSource = region, source
+	return c.region, nil
+}
+
+// RegionSource returns the source the region of the agent was resolved from
+func (c *agentIdentityCacher) RegionSource() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, err := c.regionInner(); err != nil {
+		return ""
+	}
+	return c.regionSource
 }
 
 func (c *agentIdentityCacher) AvailabilityZone() (string, error) {
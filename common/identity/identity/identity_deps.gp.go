@@ -14,6 +14,7 @@ type agentIdentityCacher struct {
 	instanceID         string
 	shortInstanceID    string
 	region             string
+	regionSource       string
 	availabilityZone   string
 	availabilityZoneId string
 	instanceType       string
@@ -23,6 +24,9 @@ type agentIdentityCacher struct {
 	log                log.T
 	client             identityinterface.IAgentIdentityInner
 	endpointHelper     endpoint.IEndpointHelper
+
+	regionResolutionOrder []string
+	configuredRegion      string
 }
 
 type createIdentityFunc func(log.T, *appconfig.SsmagentConfig) []identityinterface.IAgentIdentityInner
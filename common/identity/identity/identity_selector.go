@@ -71,6 +71,9 @@ func newAgentIdentityInner(log log.T, config *appconfig.SsmagentConfig, selector
 			log:            log,
 			client:         agentIdentity,
 			endpointHelper: endpoint.NewEndpointHelper(log, *config),
+
+			regionResolutionOrder: config.Identity.RegionResolutionOrder,
+			configuredRegion:      config.Agent.Region,
 		}, nil
 	}
 
@@ -91,6 +94,9 @@ func newAgentIdentityInner(log log.T, config *appconfig.SsmagentConfig, selector
 				log:            log,
 				client:         agentIdentity,
 				endpointHelper: endpoint.NewEndpointHelper(log, *config),
+
+				regionResolutionOrder: config.Identity.RegionResolutionOrder,
+				configuredRegion:      config.Agent.Region,
 			}, nil
 		}
 	}
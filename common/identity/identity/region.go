@@ -0,0 +1,108 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package identity
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"gopkg.in/ini.v1"
+)
+
+const defaultProfile = "default"
+
+var getEnv = os.Getenv
+var userHomeDir = os.UserHomeDir
+
+// resolveRegion queries the region sources in the configured order and returns the first region found with its source,
+// the region reported by the identity is used when no order is configured
+func (c *agentIdentityCacher) resolveRegion() (region string, source string, err error) {
+	order := c.regionResolutionOrder
+	if len(order) == 0 {
+		order = appconfig.DefaultRegionResolutionOrder
+	}
+
+	var errs []string
+	for _, source = range order {
+		if region, err = c.regionFromSource(source); err != nil {
+			c.log.Debugf("Failed to get region from %s: %v", source, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", source, err))
+			continue
+		}
+		if region != "" {
+			return region, source, nil
+		}
+	}
+
+	if len(errs) == 1 && len(order) == 1 {
+		// keep the error of the identity as is when it is the only region source
+		return "", "", err
+	}
+	return "", "", fmt.Errorf("no region found in sources %v: %s", order, strings.Join(errs, "; "))
+}
+
+// regionFromSource returns the region of a region source, the region is empty when the source does not define one
+func (c *agentIdentityCacher) regionFromSource(source string) (string, error) {
+	switch source {
+	case appconfig.RegionSourceAppConfig:
+		return c.configuredRegion, nil
+	case appconfig.RegionSourceEnvironment:
+		if region := getEnv("AWS_REGION"); region != "" {
+			return region, nil
+		}
+		return getEnv("AWS_DEFAULT_REGION"), nil
+	case appconfig.RegionSourceIdentity:
+		return c.client.Region()
+	case appconfig.RegionSourceConfigFile:
+		return sharedConfigFileRegion()
+	}
+	return "", fmt.Errorf("unknown region source")
+}
+
+// sharedConfigFileRegion returns the region of the profile selected by AWS_PROFILE in the AWS shared config file
+func sharedConfigFileRegion() (string, error) {
+	path := getEnv("AWS_CONFIG_FILE")
+	if path == "" {
+		home, err := userHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, ".aws", "config")
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	config, err := ini.Load(path)
+	if err != nil {
+		return "", err
+	}
+
+	profile := getEnv("AWS_PROFILE")
+	if profile == "" {
+		profile = defaultProfile
+	}
+	sectionName := profile
+	if profile != defaultProfile {
+		sectionName = "profile " + profile
+	}
+	section, err := config.GetSection(sectionName)
+	if err != nil {
+		return "", nil
+	}
+	return section.Key("region").String(), nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package identity
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/common/identity/availableidentities/ec2/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func setEnv(t *testing.T, env map[string]string) {
+	getEnv = func(key string) string { return env[key] }
+	t.Cleanup(func() { getEnv = os.Getenv })
+}
+
+func TestAgentIdentityCacher_Region_DefaultOrderUsesIdentity(t *testing.T) {
+	setEnv(t, map[string]string{"AWS_REGION": "eu-west-1"})
+	agentIdentityInner := &mocks.IEC2Identity{}
+	agentIdentityInner.On("Region").Return("us-east-1", nil).Once()
+
+	cacher := &agentIdentityCacher{log: log.NewMockLog(), client: agentIdentityInner, configuredRegion: "us-west-2"}
+
+	region, err := cacher.Region()
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-1", region)
+	assert.Equal(t, appconfig.RegionSourceIdentity, cacher.RegionSource())
+	agentIdentityInner.AssertExpectations(t)
+}
+
+func TestAgentIdentityCacher_Region_FirstSourceWithRegionWins(t *testing.T) {
+	setEnv(t, map[string]string{"AWS_DEFAULT_REGION": "eu-west-1"})
+	agentIdentityInner := &mocks.IEC2Identity{}
+
+	cacher := &agentIdentityCacher{
+		log:    log.NewMockLog(),
+		client: agentIdentityInner,
+		regionResolutionOrder: []string{
+			appconfig.RegionSourceAppConfig,
+			appconfig.RegionSourceEnvironment,
+			appconfig.RegionSourceIdentity,
+		},
+	}
+
+	region, err := cacher.Region()
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", region)
+	assert.Equal(t, appconfig.RegionSourceEnvironment, cacher.RegionSource())
+	agentIdentityInner.AssertNotCalled(t, "Region")
+}
+
+func TestAgentIdentityCacher_Region_FallsBackOnIdentityError(t *testing.T) {
+	setEnv(t, map[string]string{})
+	agentIdentityInner := &mocks.IEC2Identity{}
+	agentIdentityInner.On("Region").Return("", fmt.Errorf("SomeError")).Once()
+
+	cacher := &agentIdentityCacher{
+		log:                   log.NewMockLog(),
+		client:                agentIdentityInner,
+		configuredRegion:      "us-west-2",
+		regionResolutionOrder: []string{appconfig.RegionSourceIdentity, appconfig.RegionSourceAppConfig},
+	}
+
+	region, err := cacher.Region()
+	assert.NoError(t, err)
+	assert.Equal(t, "us-west-2", region)
+	assert.Equal(t, appconfig.RegionSourceAppConfig, cacher.RegionSource())
+}
+
+func TestAgentIdentityCacher_Region_NoRegionFound(t *testing.T) {
+	setEnv(t, map[string]string{})
+	agentIdentityInner := &mocks.IEC2Identity{}
+	agentIdentityInner.On("Region").Return("", fmt.Errorf("SomeError"))
+
+	cacher := &agentIdentityCacher{
+		log:                   log.NewMockLog(),
+		client:                agentIdentityInner,
+		regionResolutionOrder: []string{appconfig.RegionSourceEnvironment, appconfig.RegionSourceIdentity},
+	}
+
+	region, err := cacher.Region()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "SomeError")
+	assert.Empty(t, region)
+	assert.Empty(t, cacher.RegionSource())
+}
+
+func TestSharedConfigFileRegion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "region")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configPath := filepath.Join(dir, "config")
+	content := "[default]\nregion = us-east-2\n\n[profile other]\nregion = ap-south-1\n"
+	assert.NoError(t, ioutil.WriteFile(configPath, []byte(content), 0600))
+
+	setEnv(t, map[string]string{"AWS_CONFIG_FILE": configPath})
+	region, err := sharedConfigFileRegion()
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-2", region)
+
+	setEnv(t, map[string]string{"AWS_CONFIG_FILE": configPath, "AWS_PROFILE": "other"})
+	region, err = sharedConfigFileRegion()
+	assert.NoError(t, err)
+	assert.Equal(t, "ap-south-1", region)
+
+	setEnv(t, map[string]string{"AWS_CONFIG_FILE": configPath, "AWS_PROFILE": "missing"})
+	region, err = sharedConfigFileRegion()
+	assert.NoError(t, err)
+	assert.Empty(t, region)
+
+	setEnv(t, map[string]string{"AWS_CONFIG_FILE": filepath.Join(dir, "missing")})
+	region, err = sharedConfigFileRegion()
+	assert.NoError(t, err)
+	assert.Empty(t, region)
+}
@@ -67,3 +67,8 @@ type ICredentialRefresherProvider interface {
 type IMetadataIdentity interface {
 	VpcPrimaryCIDRBlock() (map[string][]string, error)
 }
+
+// IRegionSourceReporter defines the interface for agent identities reporting the source their region was resolved from
+type IRegionSourceReporter interface {
+	RegionSource() string
+}
@@ -16,6 +16,7 @@ package message
 
 import (
 	"encoding/json"
+	"time"
 )
 
 // HealthResultPayload contains information required by Core Agent to decide if a worker is healthy
@@ -35,6 +36,32 @@ type TerminateWorkerResultPayload struct {
 	IsTerminating bool
 }
 
+// LongRunningPluginStatusPayload contains the status of the long running plugins running in the worker
+type LongRunningPluginStatusPayload struct {
+	SchemaVersion int
+	Plugins       []LongRunningPluginStatus
+}
+
+// LongRunningPluginStatus contains the status of a long running plugin
+type LongRunningPluginStatus struct {
+	Name string
+	// Pid is the id of the plugin process, it is 0 when the plugin does not run a process the agent tracks
+	Pid int
+	// UptimeSeconds is the time elapsed since the plugin was started
+	UptimeSeconds int64
+	// Restarts is the number of times the supervisor of the plugin restarted its process
+	Restarts int
+	// LastHealthCheck is the result of the last health check, it is nil until the plugin is checked
+	LastHealthCheck *LongRunningPluginHealthCheck `json:",omitempty"`
+}
+
+// LongRunningPluginHealthCheck contains the result of a health check of a long running plugin
+type LongRunningPluginHealthCheck struct {
+	Time    time.Time
+	Healthy bool
+	Error   string `json:",omitempty"`
+}
+
 type Message struct {
 	SchemaVersion int
 	Topic         TopicType
@@ -57,6 +84,9 @@ const (
 	GetWorkerHealthResult  TopicType = "GetWorkerHealthResult"
 	TerminateWorkerRequest TopicType = "TerminateWorkerRequest"
 	TerminateWorkerResult  TopicType = "TerminateWorkerResult"
+
+	GetLongRunningPluginStatusRequest TopicType = "GetLongRunningPluginStatusRequest"
+	GetLongRunningPluginStatusResult  TopicType = "GetLongRunningPluginStatusResult"
 )
 
 // CreateHealthRequest creates an instance of health request message
@@ -125,3 +155,29 @@ func CreateTerminateWorkerResult(
 		Payload:       payloadBytes,
 	}, err
 }
+
+// CreateLongRunningPluginStatusRequest creates an instance of long running plugin status request message
+func CreateLongRunningPluginStatusRequest() *Message {
+	return &Message{
+		SchemaVersion: SchemaVersion,
+		Topic:         GetLongRunningPluginStatusRequest,
+	}
+}
+
+// CreateLongRunningPluginStatusResult creates an instance of long running plugin status result message
+func CreateLongRunningPluginStatusResult(plugins []LongRunningPluginStatus) (*Message, error) {
+	payload := LongRunningPluginStatusPayload{
+		SchemaVersion: SchemaVersion,
+		Plugins:       plugins,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		SchemaVersion: payload.SchemaVersion,
+		Topic:         GetLongRunningPluginStatusResult,
+		Payload:       payloadBytes,
+	}, nil
+}
@@ -26,4 +26,5 @@ var (
 	DefaultCoreAgentChannel  = appconfig.AgentData + "ipc/"
 	GetWorkerHealthChannel   = DefaultIPCPrefix + DefaultCoreAgentChannel + "health"
 	TerminationWorkerChannel = DefaultIPCPrefix + DefaultCoreAgentChannel + "termination"
+	LongRunningStatusChannel = DefaultIPCPrefix + DefaultCoreAgentChannel + "longrunningstatus"
 )
@@ -17,9 +17,14 @@
 // Package message contains information for the IPC messages
 package message
 
-const (
+import (
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+var (
 	DefaultIPCPrefix         = "ipc://"
-	DefaultCoreAgentChannel  = "Amazon\\SSM\\InstanceData\\"
+	DefaultCoreAgentChannel  = appconfig.InstanceFolder(appconfig.SSMFolder) + "\\InstanceData\\"
 	GetWorkerHealthChannel   = DefaultIPCPrefix + DefaultCoreAgentChannel + "health"
 	TerminationWorkerChannel = DefaultIPCPrefix + DefaultCoreAgentChannel + "termination"
+	LongRunningStatusChannel = DefaultIPCPrefix + DefaultCoreAgentChannel + "longrunningstatus"
 )
@@ -0,0 +1,88 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtimeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	rch "github.com/aws/amazon-ssm-agent/common/runtimeconfig/runtimeconfighandler"
+)
+
+const (
+	circuitBreakerConfig = "circuit_breaker.json"
+)
+
+// CircuitBreakerRuntimeConfig holds the circuit breakers of the AWS endpoints called by ssm-agent-worker
+type CircuitBreakerRuntimeConfig struct {
+	Breakers  []CircuitBreakerStatus
+	UpdatedAt time.Time
+}
+
+// CircuitBreakerStatus holds the state of the circuit breaker of an endpoint, OpenUntil is set when it is not closed
+type CircuitBreakerStatus struct {
+	Endpoint            string
+	State               string
+	ConsecutiveFailures int
+	LastFailure         string    `json:",omitempty"`
+	OpenUntil           time.Time `json:",omitempty"`
+	RejectedCalls       int
+	RetryBudget         int
+}
+
+func NewCircuitBreakerRuntimeConfigClient() ICircuitBreakerRuntimeConfigClient {
+	return &circuitBreakerRuntimeConfigClient{
+		configHandler: rch.NewRuntimeConfigHandler(circuitBreakerConfig),
+	}
+}
+
+type ICircuitBreakerRuntimeConfigClient interface {
+	ConfigExists() (bool, error)
+	GetConfig() (CircuitBreakerRuntimeConfig, error)
+	SaveConfig(CircuitBreakerRuntimeConfig) error
+}
+
+type circuitBreakerRuntimeConfigClient struct {
+	configHandler rch.IRuntimeConfigHandler
+}
+
+func (c *circuitBreakerRuntimeConfigClient) ConfigExists() (bool, error) {
+	return c.configHandler.ConfigExists()
+}
+
+func (c *circuitBreakerRuntimeConfigClient) GetConfig() (CircuitBreakerRuntimeConfig, error) {
+	var config CircuitBreakerRuntimeConfig
+
+	bytesContent, err := c.configHandler.GetConfig()
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(bytesContent, &config)
+	if err != nil {
+		return config, fmt.Errorf("error decoding circuit breaker runtime config: %v", err)
+	}
+
+	return config, nil
+}
+
+func (c *circuitBreakerRuntimeConfigClient) SaveConfig(config CircuitBreakerRuntimeConfig) error {
+	bytesContent, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("error encoding circuit breaker runtime config: %v", err)
+	}
+
+	return c.configHandler.SaveConfig(bytesContent)
+}
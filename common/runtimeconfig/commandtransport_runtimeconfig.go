@@ -0,0 +1,87 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtimeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	rch "github.com/aws/amazon-ssm-agent/common/runtimeconfig/runtimeconfighandler"
+)
+
+const (
+	commandTransportConfig = "command_transport.json"
+)
+
+// CommandTransportRuntimeConfig holds the transport the agent currently receives the Run Command documents from
+type CommandTransportRuntimeConfig struct {
+	Order           []string
+	ActiveTransport string
+	Reason          string
+	Transports      []CommandTransportStatus
+	UpdatedAt       time.Time
+}
+
+// CommandTransportStatus holds the health of a transport, Reason explains why an unhealthy transport is not used
+type CommandTransportStatus struct {
+	Name    string
+	Healthy bool
+	Reason  string `json:",omitempty"`
+}
+
+func NewCommandTransportRuntimeConfigClient() ICommandTransportRuntimeConfigClient {
+	return &commandTransportRuntimeConfigClient{
+		configHandler: rch.NewRuntimeConfigHandler(commandTransportConfig),
+	}
+}
+
+type ICommandTransportRuntimeConfigClient interface {
+	ConfigExists() (bool, error)
+	GetConfig() (CommandTransportRuntimeConfig, error)
+	SaveConfig(CommandTransportRuntimeConfig) error
+}
+
+type commandTransportRuntimeConfigClient struct {
+	configHandler rch.IRuntimeConfigHandler
+}
+
+func (c *commandTransportRuntimeConfigClient) ConfigExists() (bool, error) {
+	return c.configHandler.ConfigExists()
+}
+
+func (c *commandTransportRuntimeConfigClient) GetConfig() (CommandTransportRuntimeConfig, error) {
+	var config CommandTransportRuntimeConfig
+
+	bytesContent, err := c.configHandler.GetConfig()
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(bytesContent, &config)
+	if err != nil {
+		return config, fmt.Errorf("error decoding command transport runtime config: %v", err)
+	}
+
+	return config, nil
+}
+
+func (c *commandTransportRuntimeConfigClient) SaveConfig(config CommandTransportRuntimeConfig) error {
+	bytesContent, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("error encoding command transport runtime config: %v", err)
+	}
+
+	return c.configHandler.SaveConfig(bytesContent)
+}
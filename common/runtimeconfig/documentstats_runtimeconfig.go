@@ -0,0 +1,94 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtimeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	rch "github.com/aws/amazon-ssm-agent/common/runtimeconfig/runtimeconfighandler"
+)
+
+const (
+	documentStatsConfig = "document_stats.json"
+)
+
+// DocumentStatsRuntimeConfig holds the daily execution statistics of the documents run by ssm-agent-worker
+type DocumentStatsRuntimeConfig struct {
+	Documents []DocumentRunStats
+	UpdatedAt time.Time
+}
+
+// DocumentRunStats holds the executions of a document aggregated by day, the oldest day first
+type DocumentRunStats struct {
+	DocumentName  string
+	LastStatus    string
+	LastRun       time.Time
+	LastCommandID string `json:",omitempty"`
+	Days          []DocumentRunDay
+}
+
+// DocumentRunDay holds the executions of a document completed during a day (UTC)
+type DocumentRunDay struct {
+	Day                 string
+	Runs                int
+	Failures            int
+	TotalDurationMillis int64
+}
+
+func NewDocumentStatsRuntimeConfigClient() IDocumentStatsRuntimeConfigClient {
+	return &documentStatsRuntimeConfigClient{
+		configHandler: rch.NewRuntimeConfigHandler(documentStatsConfig),
+	}
+}
+
+type IDocumentStatsRuntimeConfigClient interface {
+	ConfigExists() (bool, error)
+	GetConfig() (DocumentStatsRuntimeConfig, error)
+	SaveConfig(DocumentStatsRuntimeConfig) error
+}
+
+type documentStatsRuntimeConfigClient struct {
+	configHandler rch.IRuntimeConfigHandler
+}
+
+func (c *documentStatsRuntimeConfigClient) ConfigExists() (bool, error) {
+	return c.configHandler.ConfigExists()
+}
+
+func (c *documentStatsRuntimeConfigClient) GetConfig() (DocumentStatsRuntimeConfig, error) {
+	var config DocumentStatsRuntimeConfig
+
+	bytesContent, err := c.configHandler.GetConfig()
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(bytesContent, &config)
+	if err != nil {
+		return config, fmt.Errorf("error decoding document stats runtime config: %v", err)
+	}
+
+	return config, nil
+}
+
+func (c *documentStatsRuntimeConfigClient) SaveConfig(config DocumentStatsRuntimeConfig) error {
+	bytesContent, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("error encoding document stats runtime config: %v", err)
+	}
+
+	return c.configHandler.SaveConfig(bytesContent)
+}
@@ -32,6 +32,8 @@ type IdentityRuntimeConfig struct {
 	ShareProfile           string
 	CredentialsExpiresAt   time.Time
 	CredentialsRetrievedAt time.Time
+	CredentialSource       string `json:",omitempty"`
+	CredentialSourceReason string `json:",omitempty"`
 }
 
 func (i IdentityRuntimeConfig) Equal(config IdentityRuntimeConfig) bool {
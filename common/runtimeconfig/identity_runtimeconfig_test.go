@@ -35,6 +35,8 @@ func Test_identityRuntimeConfigClient_GetConfig(t *testing.T) {
 		"ShareProfile",
 		time.Time{},
 		time.Time{},
+		"",
+		"",
 	}
 	handlerErrorMock := &mocks.IRuntimeConfigHandler{}
 	handlerErrorMock.On("GetConfig").Return(nil, fmt.Errorf("SomeError"))
@@ -105,6 +107,8 @@ func Test_identityRuntimeConfigClient_SaveConfig(t *testing.T) {
 		"ShareProfile",
 		time.Now(),
 		time.Now(),
+		"",
+		"",
 	}
 	successContent, _ := json.Marshal(successConfig)
 	failContent, _ := json.Marshal(IdentityRuntimeConfig{})
@@ -167,6 +171,8 @@ func Test_identityRuntimeConfigClient_SaveConfig_VerifyFailGetConfig(t *testing.
 		"ShareProfile",
 		time.Now(),
 		time.Now(),
+		"",
+		"",
 	}
 	byteConfig, _ := json.Marshal(config)
 
@@ -192,6 +198,8 @@ func Test_identityRuntimeConfigClient_SaveConfig_VerifyFailConfigEquals(t *testi
 		"ShareProfile",
 		time.Now(),
 		time.Now(),
+		"",
+		"",
 	}
 
 	wrongConfig := IdentityRuntimeConfig{
@@ -201,6 +209,8 @@ func Test_identityRuntimeConfigClient_SaveConfig_VerifyFailConfigEquals(t *testi
 		"ShareProfile",
 		time.Now(),
 		time.Now(),
+		"",
+		"",
 	}
 	wrongByteConfig, _ := json.Marshal(wrongConfig)
 
@@ -240,6 +250,8 @@ func TestIdentityRuntimeConfig_Equal(t *testing.T) {
 			"ShareProfile",
 			time.Now(),
 			time.Now(),
+			"",
+			"",
 		},
 	}
 	tests := []struct {
@@ -0,0 +1,85 @@
+// Copyright 2022 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtimeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	rch "github.com/aws/amazon-ssm-agent/common/runtimeconfig/runtimeconfighandler"
+)
+
+const (
+	preflightConfig = "preflight.json"
+)
+
+// PreflightRuntimeConfig holds the results of the preflight checks run when the agent started
+type PreflightRuntimeConfig struct {
+	Checks    []PreflightCheckResult
+	CheckedAt time.Time
+}
+
+// PreflightCheckResult holds the result of a preflight check, Remediation explains how to fix a failed check
+type PreflightCheckResult struct {
+	Name        string
+	Status      string
+	Message     string
+	Remediation string `json:",omitempty"`
+}
+
+func NewPreflightRuntimeConfigClient() IPreflightRuntimeConfigClient {
+	return &preflightRuntimeConfigClient{
+		configHandler: rch.NewRuntimeConfigHandler(preflightConfig),
+	}
+}
+
+type IPreflightRuntimeConfigClient interface {
+	ConfigExists() (bool, error)
+	GetConfig() (PreflightRuntimeConfig, error)
+	SaveConfig(PreflightRuntimeConfig) error
+}
+
+type preflightRuntimeConfigClient struct {
+	configHandler rch.IRuntimeConfigHandler
+}
+
+func (c *preflightRuntimeConfigClient) ConfigExists() (bool, error) {
+	return c.configHandler.ConfigExists()
+}
+
+func (c *preflightRuntimeConfigClient) GetConfig() (PreflightRuntimeConfig, error) {
+	var config PreflightRuntimeConfig
+
+	bytesContent, err := c.configHandler.GetConfig()
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(bytesContent, &config)
+	if err != nil {
+		return config, fmt.Errorf("error decoding preflight runtime config: %v", err)
+	}
+
+	return config, nil
+}
+
+func (c *preflightRuntimeConfigClient) SaveConfig(config PreflightRuntimeConfig) error {
+	bytesContent, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("error encoding preflight runtime config: %v", err)
+	}
+
+	return c.configHandler.SaveConfig(bytesContent)
+}
@@ -27,7 +27,10 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/profiling"
 	"github.com/aws/amazon-ssm-agent/agent/proxyconfig"
+	"github.com/aws/amazon-ssm-agent/agent/storagemigration"
 	"github.com/aws/amazon-ssm-agent/core/app"
 	"github.com/aws/amazon-ssm-agent/core/app/bootstrap"
 	"github.com/aws/amazon-ssm-agent/core/app/runtimeconfiginit"
@@ -66,17 +69,37 @@ var (
 func initializeBasicModules(log log.T) (app.CoreAgent, log.T, error) {
 	log.WriteEvent(logger.AgentTelemetryMessage, "", logger.AmazonAgentStartEvent)
 
+	// a process with an invalid instance name would share the folders of the primary instance
+	if err := appconfig.InstanceNameError(); err != nil {
+		return nil, log, err
+	}
+	if appconfig.IsSecondaryInstance() {
+		log.Infof("Starting agent instance %v", appconfig.InstanceName())
+	}
+
 	proxyConfig := proxyconfig.SetProxyConfig(log)
 	log.Infof("Proxy environment variables:")
 	for key, value := range proxyConfig {
 		log.Infof(key + ": " + value)
 	}
 
+	// the instance metadata service endpoint has to be selected before the identity is created, the workers and
+	// the processes started by the agent inherit it
+	appConfig, _ := appconfig.Config(false)
+	network.SetIMDSEndpointMode(log, appConfig)
+	profiling.Start(log, appConfig.Agent.Profiling, profiling.CoreProcess)
+
+	// move the data of an existing install to the configured storage locations before the identity is loaded from
+	// the registration and the runtime configs
+	storagemigration.MigrateHostData(log)
+
 	bs := bootstrap.NewBootstrap(log, filesystem.NewFileSystem())
 	context, err := bs.Init()
 	if err != nil {
 		return nil, log, err
 	}
+	// the instance data is moved before the message bus and the workers use it
+	storagemigration.Migrate(context.Log(), *context.AppConfig(), context.Identity())
 
 	// Initialize runtime configs
 	rci := runtimeconfiginit.New(context.Log(), context.Identity())
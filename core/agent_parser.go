@@ -25,6 +25,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	logger "github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/winevent"
 	"github.com/aws/amazon-ssm-agent/agent/managedInstances/fingerprint"
 	"github.com/aws/amazon-ssm-agent/agent/managedInstances/registration"
 	"github.com/aws/amazon-ssm-agent/agent/ssm/anonauth"
@@ -177,6 +178,11 @@ func processRegistration(log logger.T) (exitCode int) {
 		return 1
 	}
 	log.Infof("Successfully registered the instance with AWS SSM using Managed instance-id: %s", managedInstanceID)
+	winevent.Write(log, winevent.RegistrationChanged, winevent.Fields{
+		"action":            "registered",
+		"managedInstanceId": managedInstanceID,
+		"region":            region,
+	})
 	return 0
 }
 
@@ -192,8 +198,13 @@ func processFingerprint(log logger.T) (exitCode int) {
 
 // registerManagedInstance checks for activation credentials and performs managed instance registration when present
 func registerManagedInstance(log logger.T) (managedInstanceID string, err error) {
+	if err = appconfig.InstanceNameError(); err != nil {
+		return "", err
+	}
+
 	// try to activate the instance with the activation credentials
-	publicKey, privateKey, keyType, err := registration.GenerateKeyPair()
+	appConfig, _ := appconfig.Config(false)
+	publicKey, privateKey, keyType, err := registration.GenerateKeyPairOfType(appconfig.ProfileKeyTypeRsa, appConfig.Profile.KeyStorage)
 	if err != nil {
 		return "", fmt.Errorf("error generating signing keys. %v", err)
 	}
@@ -242,6 +253,7 @@ func registerManagedInstance(log logger.T) (managedInstanceID string, err error)
 	}
 
 	if err != nil {
+		registration.ReleasePrivateKey(log, privateKey)
 		return managedInstanceID, fmt.Errorf("error registering the instance with AWS SSM. %v", err)
 	}
 
@@ -273,6 +285,7 @@ func clearRegistration(log logger.T) (exitCode int) {
 	err := registration.UpdateServerInfo("", "", "", "", "", registration.RegVaultKey)
 	if err == nil {
 		log.Info("Registration information has been removed from the instance.")
+		winevent.Write(log, winevent.RegistrationChanged, winevent.Fields{"action": "cleared"})
 		return 0
 	}
 	log.Errorf("error clearing the instance registration information. %v\nTry running as sudo/administrator.", err)
@@ -17,7 +17,8 @@ import (
 	"golang.org/x/sys/windows/svc/mgr"
 )
 
-const serviceName = "AmazonSSMAgent"
+var serviceName = appconfig.InstanceServiceName(appconfig.ServiceName)
+
 const imageStateComplete = "IMAGE_STATE_COMPLETE"
 const runningService = 4
 
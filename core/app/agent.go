@@ -19,6 +19,9 @@ import (
 	"time"
 
 	agentcontracts "github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/featureflag"
+	"github.com/aws/amazon-ssm-agent/agent/log/winevent"
+	"github.com/aws/amazon-ssm-agent/agent/network"
 	"github.com/aws/amazon-ssm-agent/agent/version"
 	"github.com/aws/amazon-ssm-agent/core/app/context"
 	"github.com/aws/amazon-ssm-agent/core/app/credentialrefresher"
@@ -65,6 +68,9 @@ func (agent *SSMCoreAgent) Start(statusChan *agentcontracts.StatusComm) error {
 
 	log.Infof("amazon-ssm-agent - %v", version.String())
 	log.Infof("OS: %s, Arch: %s", runtime.GOOS, runtime.GOARCH)
+	featureflag.LogFlags(log, *agent.context.AppConfig())
+	network.LogTLSPolicy(log, *agent.context.AppConfig())
+	network.LogNetworkConfig(log, *agent.context.AppConfig())
 	log.Info("Starting Core Agent")
 
 	if agent.registrar != nil {
@@ -86,6 +92,7 @@ func (agent *SSMCoreAgent) Start(statusChan *agentcontracts.StatusComm) error {
 		agent.container.Start()
 		go agent.container.Monitor()
 		agent.selfupdate.Start()
+		winevent.Write(log, winevent.ServiceStarted, winevent.Fields{"version": version.Version})
 		// removing the below wait time will cause the agent worker to run orphaned when
 		// agent is stopped immediately after start
 		time.Sleep(3 * time.Second)
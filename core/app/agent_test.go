@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	contextmocks "github.com/aws/amazon-ssm-agent/core/app/context/mocks"
@@ -60,6 +61,8 @@ func (suite *AgentTestSuite) SetupTest() {
 
 	mockLog := log.NewMockLog()
 	suite.context.On("Log").Return(mockLog)
+	appConfig := appconfig.DefaultConfig()
+	suite.context.On("AppConfig").Return(&appConfig)
 }
 
 // Execute the test suite
@@ -25,9 +25,12 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/backoffconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/aws/amazon-ssm-agent/agent/log/winevent"
 	"github.com/aws/amazon-ssm-agent/agent/managedInstances/sharedCredentials"
 	"github.com/aws/amazon-ssm-agent/common/identity"
 	"github.com/aws/amazon-ssm-agent/common/identity/credentialproviders"
+	"github.com/aws/amazon-ssm-agent/common/identity/credentialrotation"
 	"github.com/aws/amazon-ssm-agent/common/identity/endpoint"
 	identity2 "github.com/aws/amazon-ssm-agent/common/identity/identity"
 	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
@@ -44,6 +47,9 @@ var storeSharedCredentials = sharedCredentials.Store
 var backoffRetry = backoff.Retry
 var newSharedCredentials = credentials.NewSharedCredentials
 
+// roleChangeCheckInterval is the interval at which the attached role is compared to the one used for the credentials
+const roleChangeCheckInterval = time.Minute
+
 // Indicates the retrier should retry call for systems manager provided credentials
 var shouldRetry = true
 
@@ -70,6 +76,9 @@ type credentialsRefresher struct {
 	provider      credentialproviders.IRemoteProvider
 	expirer       credentials.Expirer
 
+	roleChangeDetector      credentialproviders.IRoleChangeDetector
+	roleChangeCheckInterval time.Duration
+
 	runtimeConfigClient   runtimeconfig.IIdentityRuntimeConfigClient
 	identityRuntimeConfig runtimeconfig.IdentityRuntimeConfig
 	endpointHelper        endpoint.IEndpointHelper
@@ -92,6 +101,7 @@ func NewCredentialRefresher(context context.ICoreAgentContext) ICredentialRefres
 		agentIdentity:                context.Identity(),
 		provider:                     nil,
 		expirer:                      nil,
+		roleChangeCheckInterval:      roleChangeCheckInterval,
 		runtimeConfigClient:          runtimeconfig.NewIdentityRuntimeConfigClient(),
 		identityRuntimeConfig:        runtimeconfig.IdentityRuntimeConfig{},
 		credsReadyOnce:               sync.Once{},
@@ -151,6 +161,14 @@ func (c *credentialsRefresher) Start() error {
 		return fmt.Errorf("credentials provider for identity %v does not implement Expirer interface", c.agentIdentity.IdentityType())
 	}
 
+	// role change detection is optional, the credentials are refreshed on expiration only when not supported
+	if c.roleChangeDetector, ok = c.provider.(credentialproviders.IRoleChangeDetector); ok {
+		// record the role the current credentials were retrieved with
+		if _, err = c.roleChangeDetector.RoleChanged(); err != nil {
+			c.log.Warnf("Failed to query the attached role: %v", err)
+		}
+	}
+
 	// Initialize the identity runtime config from disk
 	if c.identityRuntimeConfig, err = c.runtimeConfigClient.GetConfig(); err != nil {
 		return err
@@ -255,7 +273,6 @@ func getLongSleepDuration(sleepDuration time.Duration) time.Duration {
 }
 
 func (c *credentialsRefresher) credentialRefresherRoutine() {
-	var err error
 	defer func() {
 		if err := recover(); err != nil {
 			c.log.Errorf("credentials refresher panic: %v", err)
@@ -284,53 +301,101 @@ func (c *credentialsRefresher) credentialRefresherRoutine() {
 	}
 
 	c.log.Info("Starting credentials refresher loop")
+	refreshChan := c.timeAfterFunc(c.durationUntilRefresh())
 	for {
 		select {
 		case <-c.stopCredentialRefresherChan:
 			c.log.Info("Stopping credentials refresher")
 			c.log.Flush()
 			return
-		case <-c.timeAfterFunc(c.durationUntilRefresh()):
-			c.log.Debug("Calling Retrieve on credentials provider")
-			creds, stopped := c.retrieveCredsWithRetry()
-			credentialsRetrievedAt := c.getCurrentTimeFunc()
-			if stopped {
-				c.log.Info("Stopping credentials refresher")
-				c.log.Flush()
-				return
+		case <-c.roleChangeCheckChan():
+			if !c.roleChanged() {
+				continue
 			}
+			c.log.Info("Instance profile role changed, refreshing credentials now")
+		case <-refreshChan:
+		}
 
-			err = backoffRetry(func() error {
-				return storeSharedCredentials(c.log, creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, c.identityRuntimeConfig.ShareFile, c.identityRuntimeConfig.ShareProfile, false)
-			}, c.backoffConfig)
+		if stopped := c.refreshCredentials(); stopped {
+			c.log.Info("Stopping credentials refresher")
+			c.log.Flush()
+			return
+		}
+		refreshChan = c.timeAfterFunc(c.durationUntilRefresh())
+	}
+}
 
-			// If failed, try once more with force
-			if err != nil {
-				c.log.Warn("Failed to write credentials to disk, attempting force write")
-				err = storeSharedCredentials(c.log, creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, c.identityRuntimeConfig.ShareFile, c.identityRuntimeConfig.ShareProfile, true)
-			}
+// roleChangeCheckChan returns the channel signaling the next role change check, it never signals when the provider
+// cannot detect role changes
+func (c *credentialsRefresher) roleChangeCheckChan() <-chan time.Time {
+	if c.roleChangeDetector == nil {
+		return nil
+	}
+	return c.timeAfterFunc(c.roleChangeCheckInterval)
+}
 
-			if err != nil {
-				// Saving credentials has been retried 6 times at this point.
-				c.log.Errorf("Failed to write credentials to disk even with force, retrying: %v", err)
-				continue
-			}
+// roleChanged returns true if the role attached to the instance changed and writes a health event when it did
+func (c *credentialsRefresher) roleChanged() bool {
+	changed, err := c.roleChangeDetector.RoleChanged()
+	if err != nil {
+		c.log.Debugf("Failed to check the attached role: %v", err)
+		return false
+	}
+	if changed {
+		c.log.WriteEvent(logger.AgentTelemetryMessage, "", logger.AmazonAgentRoleChangeEvent)
+		winevent.WriteWarning(c.log, winevent.RoleChanged, winevent.Fields{"identity": c.agentIdentity.IdentityType()})
+	}
+	return changed
+}
 
-			c.log.Debug("Successfully stored credentials, writing runtime configuration with updated expiration time")
-			configCopy := c.identityRuntimeConfig
-			configCopy.CredentialsRetrievedAt = credentialsRetrievedAt
-			configCopy.CredentialsExpiresAt = c.expirer.ExpiresAt()
+// refreshCredentials retrieves new credentials and saves them to disk, it returns true if the refresher was stopped
+// while retrieving the credentials
+func (c *credentialsRefresher) refreshCredentials() bool {
+	c.log.Debug("Calling Retrieve on credentials provider")
+	creds, stopped := c.retrieveCredsWithRetry()
+	credentialsRetrievedAt := c.getCurrentTimeFunc()
+	if stopped {
+		return true
+	}
 
-			err = backoffRetry(func() error {
-				return c.runtimeConfigClient.SaveConfig(configCopy)
-			}, c.backoffConfig)
-			if err != nil {
-				c.log.Warnf("Failed to save new expiration: %v", err)
-				continue
-			}
+	err := backoffRetry(func() error {
+		return storeSharedCredentials(c.log, creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, c.identityRuntimeConfig.ShareFile, c.identityRuntimeConfig.ShareProfile, false)
+	}, c.backoffConfig)
 
-			c.identityRuntimeConfig = configCopy
-			c.sendCredentialsReadyMessage()
-		}
+	// If failed, try once more with force
+	if err != nil {
+		c.log.Warn("Failed to write credentials to disk, attempting force write")
+		err = storeSharedCredentials(c.log, creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, c.identityRuntimeConfig.ShareFile, c.identityRuntimeConfig.ShareProfile, true)
 	}
+
+	if err != nil {
+		// Saving credentials has been retried 6 times at this point.
+		c.log.Errorf("Failed to write credentials to disk even with force, retrying: %v", err)
+		return false
+	}
+
+	c.log.Debug("Successfully stored credentials, writing runtime configuration with updated expiration time")
+	configCopy := c.identityRuntimeConfig
+	configCopy.CredentialsRetrievedAt = credentialsRetrievedAt
+	configCopy.CredentialsExpiresAt = c.expirer.ExpiresAt()
+	if reporter, ok := c.provider.(credentialproviders.ICredentialSourceReporter); ok {
+		configCopy.CredentialSource = reporter.CredentialSource()
+		configCopy.CredentialSourceReason = reporter.CredentialSourceReason()
+	}
+
+	err = backoffRetry(func() error {
+		return c.runtimeConfigClient.SaveConfig(configCopy)
+	}, c.backoffConfig)
+	if err != nil {
+		c.log.Warnf("Failed to save new expiration: %v", err)
+		return false
+	}
+
+	c.identityRuntimeConfig = configCopy
+	c.sendCredentialsReadyMessage()
+	credentialrotation.Notify(credentialrotation.Rotation{
+		Reason:      credentialrotation.ReasonCredentialsRefreshed,
+		RetrievedAt: credentialsRetrievedAt,
+	})
+	return false
 }
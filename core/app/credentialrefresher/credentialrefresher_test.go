@@ -23,6 +23,7 @@ import (
 	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	"github.com/aws/amazon-ssm-agent/common/identity"
 	credentialmocks "github.com/aws/amazon-ssm-agent/common/identity/credentialproviders/mocks"
+	"github.com/aws/amazon-ssm-agent/common/identity/credentialrotation"
 	identityMock "github.com/aws/amazon-ssm-agent/common/identity/mocks"
 	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
 	runtimeconfigmocks "github.com/aws/amazon-ssm-agent/common/runtimeconfig/mocks"
@@ -340,6 +341,10 @@ func Test_credentialsRefresher_credentialRefresherRoutine_CredentialsDontExist(t
 		timeAfterFunc:                time.After,
 	}
 
+	rotations := make(chan credentialrotation.Rotation, 1)
+	unsubscribe := credentialrotation.Subscribe(func(rotation credentialrotation.Rotation) { rotations <- rotation })
+	defer unsubscribe()
+
 	go c.credentialRefresherRoutine()
 
 	// verify credentials ready message is sent because there are still 5 minutes left of credential
@@ -352,6 +357,14 @@ func Test_credentialsRefresher_credentialRefresherRoutine_CredentialsDontExist(t
 	// Give goroutine 1 second to go through retrieval
 	time.Sleep(time.Second)
 
+	// verify the modules are notified of the new credentials
+	select {
+	case rotation := <-rotations:
+		assert.Equal(t, credentialrotation.ReasonCredentialsRefreshed, rotation.Reason)
+	default:
+		assert.Fail(t, "credential rotation was never notified")
+	}
+
 	// Stop goroutine
 	c.Stop()
 	assert.False(t, c.isCredentialRefresherRunning)
@@ -365,6 +378,75 @@ func Test_credentialsRefresher_credentialRefresherRoutine_CredentialsDontExist(t
 
 }
 
+func Test_credentialsRefresher_credentialRefresherRoutine_RoleChanged_RefreshesImmediately(t *testing.T) {
+	storeSharedCredentials = func(log.T, string, string, string, string, string, bool) error {
+		return nil
+	}
+	backoffRetry = func(o backoff.Operation, _ backoff.BackOff) error {
+		return o()
+	}
+
+	// credentials are valid for ten more minutes, refresh is only triggered by the role change
+	runtimeConfig := runtimeconfig.IdentityRuntimeConfig{
+		CredentialsExpiresAt:   tenMinAfterTime,
+		CredentialsRetrievedAt: currentTime,
+	}
+
+	runtimeConfigClient := &runtimeconfigmocks.IIdentityRuntimeConfigClient{}
+	runtimeConfigClient.On("SaveConfig", mock.Anything).Return(nil).Once()
+
+	provider := &credentialmocks.IRemoteProvider{}
+	provider.On("Retrieve").Return(credentials.Value{}, nil).Once()
+
+	expirer := &credentialmocks.Expirer{}
+	expirer.On("ExpiresAt").Return(tenMinAfterTime).Once()
+
+	roleChangeDetector := &credentialmocks.IRoleChangeDetector{}
+	roleChangeDetector.On("RoleChanged").Return(true, nil).Once()
+	roleChangeDetector.On("RoleChanged").Return(false, nil)
+
+	agentIdentity := &identityMock.IAgentIdentity{}
+	agentIdentity.On("IdentityType").Return("EC2")
+
+	c := &credentialsRefresher{
+		log:                          logmocks.NewMockLog(),
+		agentIdentity:                agentIdentity,
+		provider:                     provider,
+		expirer:                      expirer,
+		roleChangeDetector:           roleChangeDetector,
+		roleChangeCheckInterval:      10 * time.Millisecond,
+		runtimeConfigClient:          runtimeConfigClient,
+		identityRuntimeConfig:        runtimeConfig,
+		credsReadyOnce:               sync.Once{},
+		credentialsReadyChan:         make(chan struct{}, 1),
+		stopCredentialRefresherChan:  make(chan struct{}),
+		isCredentialRefresherRunning: true,
+		getCurrentTimeFunc:           func() time.Time { return currentTime },
+		timeAfterFunc:                time.After,
+	}
+
+	rotations := make(chan credentialrotation.Rotation, 1)
+	unsubscribe := credentialrotation.Subscribe(func(rotation credentialrotation.Rotation) { rotations <- rotation })
+	defer unsubscribe()
+
+	go c.credentialRefresherRoutine()
+
+	// verify the credentials are refreshed well before their refresh time
+	select {
+	case rotation := <-rotations:
+		assert.Equal(t, credentialrotation.ReasonCredentialsRefreshed, rotation.Reason)
+	case <-time.After(time.Second):
+		assert.Fail(t, "credentials were not refreshed after the role change")
+	}
+
+	c.Stop()
+	assert.False(t, c.isCredentialRefresherRunning)
+
+	runtimeConfigClient.AssertExpectations(t)
+	provider.AssertExpectations(t)
+	expirer.AssertExpectations(t)
+}
+
 // Mock aws error struct
 type awsTestError struct {
 	errCode string
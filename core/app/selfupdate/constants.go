@@ -113,15 +113,6 @@ const (
 	// url format to download updater for ssm agent
 	UrlPath = "/amazon-ssm-{Region}/amazon-ssm-agent-updater/latest/{FileName}"
 
-	// CommonUrlPath is the url format for download package in common region
-	CommonUrlPath = "https://s3.{Region}.amazonaws.com" + UrlPath
-
 	//ManifestPath is path of manifest in the s3 bucket
 	ManifestPath = "/amazon-ssm-{Region}/ssm-agent-manifest.json"
-
-	// CommonManifestURL is the Manifest URL for regular regions
-	CommonManifestURL = "https://s3.{Region}.amazonaws.com" + ManifestPath
-
-	// cn- is a prefix for China region
-	ChinaRegionPrefix = "cn-"
 )
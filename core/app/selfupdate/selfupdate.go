@@ -34,6 +34,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/updateutil"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil/updateconstants"
 	"github.com/aws/amazon-ssm-agent/agent/version"
+	"github.com/aws/amazon-ssm-agent/common/identity/endpoint"
 	"github.com/aws/amazon-ssm-agent/core/app/context"
 	"github.com/aws/amazon-ssm-agent/core/app/selfupdate/fileutil"
 	"github.com/aws/amazon-ssm-agent/core/app/selfupdate/fileutil/artifact"
@@ -250,7 +251,7 @@ func (u *SelfUpdate) init(instanceId string) (err error) {
 	var orchestrationDir string
 
 	orchestrationDir = filepath.Join(
-		appconfig.DefaultDataStorePath,
+		appconfig.OrchestrationStorePath,
 		instanceId,
 		appconfig.DefaultDocumentRootDirName,
 		"orchestration", DefaultSelfUpdateFolder, DefaultOutputFolder)
@@ -389,15 +390,17 @@ func (u *SelfUpdate) unCompress(log log.T,
 	return
 }
 
-func (u *SelfUpdate) generateDownloadUpdaterURL(log log.T, region string, fileName string) (url string) {
-	var urlFormat string
-
+// s3Endpoint returns the s3 endpoint of the identity, the endpoint is generated from the partition of the region when
+// the identity cannot provide it
+func (u *SelfUpdate) s3Endpoint(region string) string {
 	if dynamicS3Endpoint := u.context.Identity().GetServiceEndpoint("s3"); dynamicS3Endpoint != "" {
-		urlFormat = "https://" + dynamicS3Endpoint + UrlPath
-	} else {
-		// could not retrieve the default s3 endpoint, generate endpoint from region information
-		urlFormat = CommonUrlPath
+		return dynamicS3Endpoint
 	}
+	return endpoint.GetDefaultServiceEndpoint("s3", region)
+}
+
+func (u *SelfUpdate) generateDownloadUpdaterURL(log log.T, region string, fileName string) (url string) {
+	urlFormat := "https://" + u.s3Endpoint(region) + UrlPath
 
 	urlFormat = strings.Replace(urlFormat, RegionHolder, region, -1)
 	urlFormat = strings.Replace(urlFormat, FileNameHolder, fileName, -1)
@@ -408,14 +411,7 @@ func (u *SelfUpdate) generateDownloadUpdaterURL(log log.T, region string, fileNa
 }
 
 func (u *SelfUpdate) generateDownloadManifestURL(log log.T, region string) (manifestUrl string) {
-
-	if dynamicS3Endpoint := u.context.Identity().GetServiceEndpoint("s3"); dynamicS3Endpoint != "" {
-		manifestUrl = "https://" + dynamicS3Endpoint + ManifestPath
-	} else {
-		// could not retrieve the default s3 endpoint, generate endpoint from region information
-		manifestUrl = CommonManifestURL
-	}
-
+	manifestUrl = "https://" + u.s3Endpoint(region) + ManifestPath
 	manifestUrl = strings.Replace(manifestUrl, RegionHolder, region, -1)
 
 	log.Debugf("manifest download url is %s", manifestUrl)
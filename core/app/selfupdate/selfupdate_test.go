@@ -161,6 +161,26 @@ func (suite *SelfUpdateTestSuite) TestGetDownloadUpdaterChina() {
 	assert.Equal(suite.T(), commonUpdaterUrl, updaterUrl)
 }
 
+func (suite *SelfUpdateTestSuite) TestGetDownloadURLs_DefaultEndpointPerPartition() {
+	fileName := "amazon-ssm-agent-updater-linux-amd64.tar.gz"
+	partitionS3Endpoints := map[string]string{
+		"us-east-1":      "s3.us-east-1.amazonaws.com",
+		"cn-northwest-1": "s3.cn-northwest-1.amazonaws.com.cn",
+		"us-gov-east-1":  "s3.us-gov-east-1.amazonaws.com",
+		"us-iso-west-1":  "s3.us-iso-west-1.c2s.ic.gov",
+		"us-isob-east-1": "s3.us-isob-east-1.sc2s.sgov.gov",
+	}
+	suite.identityMock.On("GetServiceEndpoint", "s3").Return("")
+
+	for region, s3Endpoint := range partitionS3Endpoints {
+		manifestUrl := suite.selfUpdater.generateDownloadManifestURL(suite.logMock, region)
+		assert.Equal(suite.T(), "https://"+s3Endpoint+"/amazon-ssm-"+region+"/ssm-agent-manifest.json", manifestUrl)
+
+		updaterUrl := suite.selfUpdater.generateDownloadUpdaterURL(suite.logMock, region, fileName)
+		assert.Equal(suite.T(), "https://"+s3Endpoint+"/amazon-ssm-"+region+"/amazon-ssm-agent-updater/latest/"+fileName, updaterUrl)
+	}
+}
+
 func (suite *SelfUpdateTestSuite) TestFileName() {
 	platformNameGetter = func(log log.T) (name string, err error) {
 		return PlatformRedHat, nil
@@ -27,7 +27,8 @@ import (
 )
 
 const SSMDataHardened = "SSMDataHardened"
-const SSMDataHardenedRegistryValuePath = appconfig.ItemPropertyPath + SSMDataHardened
+
+var SSMDataHardenedRegistryValuePath = appconfig.ItemPropertyPath + SSMDataHardened
 
 // AddHardenedFlagToRegistry adds a flag to the windows registry
 // which signals %PROGRAMDATA%\Amazon\SSM has been hardened
@@ -50,7 +51,7 @@ func AddHardenedFlagToRegistry() error {
 	}
 	defer servicesKey.Close()
 
-	agentKey, alreadyExists, err := registry.CreateKey(servicesKey, "AmazonSSMAgent", registry.SET_VALUE)
+	agentKey, alreadyExists, err := registry.CreateKey(servicesKey, appconfig.InstanceServiceName(appconfig.ServiceName), registry.SET_VALUE)
 	if err != nil && !alreadyExists {
 		return fmt.Errorf("Error creating %v registry key: %v", appconfig.ItemPropertyName, err)
 	}
@@ -8,6 +8,7 @@ replace github.com/nightlyone/lockfile => ./extra/lockfile
 
 require (
 	github.com/Jeffail/gabs v1.0.0
+	github.com/Microsoft/go-winio v0.5.0
 	github.com/Workiva/go-datastructures v1.0.53
 	github.com/aws/aws-sdk-go v1.44.78
 	github.com/carlescere/scheduler v0.0.0-20150615230211-9b78eac89dfb
@@ -31,6 +32,7 @@ require (
 	github.com/stretchr/testify v1.7.0
 	github.com/twinj/uuid v0.0.0-20151029044442-89173bcdda19 // Don't update -- breaks
 	github.com/xtaci/smux v1.5.15
+	go.etcd.io/bbolt v1.3.6
 	go.nanomsg.org/mangos/v3 v3.3.0
 	golang.org/x/crypto v0.0.0-20210506145944-38f3c27a63bf
 	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd
@@ -18,7 +18,7 @@ func NewMessageService(context context.T, mdsService mds.Service) contracts.ICor
 	messageServiceRef := messageServiceCoreModule.(*messageservice.MessageService)
 
 	// create new mds interactor
-	interactorRef, _ := mdsinteractor.New(context, messageServiceRef.GetMessageHandler(), mdsService)
+	interactorRef, _ := mdsinteractor.New(context, messageServiceRef.GetMessageHandler(), mdsService, messageServiceRef.GetTransportSelector())
 	interactors = append(interactors, interactorRef)
 	// add mds interactor with mock mds service to the message service
 	messageServiceRef.SetInteractor(interactors)